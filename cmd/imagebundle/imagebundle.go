@@ -0,0 +1,211 @@
+// Package imagebundle provides commands for building offline species image bundles
+package imagebundle
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"github.com/tphakala/birdnet-go/internal/birdnet"
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/httpcontroller"
+	"github.com/tphakala/birdnet-go/internal/imageprovider"
+)
+
+// Command returns the imagebundle command
+func Command(settings *conf.Settings) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "imagebundle",
+		Short: "Manage offline species image bundles",
+	}
+	cmd.AddCommand(buildCommand(settings), inspectCommand())
+	return cmd
+}
+
+// buildCommand returns the imagebundle build subcommand
+func buildCommand(settings *conf.Settings) *cobra.Command {
+	var (
+		output      string
+		provider    string
+		speciesFile string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "build",
+		Short: "Download species images into an offline bundle",
+		Long: `Download species images and their attribution from an online image provider
+into a bundle that can be copied to instances without internet access.
+
+By default the bundle contains every species detected in the configured database.
+Use --species-file to supply a list of scientific names instead, one per line.
+If the output path ends in .tar, .tar.gz or .tgz an archive is written, otherwise
+a directory. Point realtime.dashboard.thumbnails.imagebundle at the result.
+
+Examples:
+  # Bundle all detected species into a compressed archive
+  birdnet-go imagebundle build --output images.tar.gz
+
+  # Bundle a custom species list from Avicommons into a directory
+  birdnet-go imagebundle build --provider avicommons --species-file species.txt --output /data/images`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runBuild(cmd.Context(), settings, output, provider, speciesFile)
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Bundle directory or .tar/.tar.gz/.tgz archive path")
+	cmd.Flags().StringVar(&provider, "provider", "wikimedia", "Image provider to download from: wikimedia, avicommons")
+	cmd.Flags().StringVar(&speciesFile, "species-file", "", "File with one scientific name per line (default: species detected in the database)")
+	_ = cmd.MarkFlagRequired("output")
+
+	return cmd
+}
+
+// inspectCommand returns the imagebundle inspect subcommand
+func inspectCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "inspect <bundle>",
+		Short: "Validate an offline image bundle and print its size",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			bundle, err := imageprovider.OpenImageBundle(args[0])
+			if err != nil {
+				return err
+			}
+			defer func() { _ = bundle.Close() }()
+			fmt.Printf("✅ %s contains %d species images\n", args[0], bundle.Len())
+			return nil
+		},
+	}
+}
+
+// runBuild resolves the species list and provider, then builds the bundle
+func runBuild(ctx context.Context, settings *conf.Settings, output, providerName, speciesFile string) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	provider, err := newProvider(providerName, settings)
+	if err != nil {
+		return err
+	}
+
+	species, err := loadSpecies(settings, speciesFile)
+	if err != nil {
+		return err
+	}
+	if len(species) == 0 {
+		return fmt.Errorf("no species to bundle")
+	}
+
+	fmt.Printf("📦 Building image bundle with %d species from %s...\n", len(species), providerName)
+	report, err := imageprovider.BuildImageBundle(ctx, &imageprovider.BundleBuildOptions{
+		Output:     output,
+		Provider:   provider,
+		Species:    species,
+		AppVersion: settings.Version,
+		Progress: func(done, total int, scientificName string, err error) {
+			if err != nil {
+				fmt.Printf("  [%d/%d] %s: %v\n", done, total, scientificName, err)
+				return
+			}
+			fmt.Printf("  [%d/%d] %s\n", done, total, scientificName)
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to build image bundle: %w", err)
+	}
+
+	fmt.Println("\nResults:")
+	fmt.Printf("  Added:     %d\n", report.Added)
+	fmt.Printf("  Not found: %d\n", report.NotFound)
+	fmt.Printf("  Failed:    %d\n", report.Failed)
+	fmt.Printf("  Size:      %.1f MB\n", float64(report.Bytes)/(1024*1024))
+	fmt.Printf("\n✅ Bundle written to %s\n", output)
+
+	return nil
+}
+
+// newProvider creates the online image provider to download from
+func newProvider(name string, settings *conf.Settings) (imageprovider.ImageProvider, error) {
+	switch name {
+	case "wikimedia":
+		return imageprovider.NewWikiMediaProvider()
+	case "avicommons":
+		return imageprovider.NewAviCommonsProvider(httpcontroller.ImageDataFs, settings.Realtime.Dashboard.Thumbnails.Debug)
+	default:
+		return nil, fmt.Errorf("unsupported image provider %q, use wikimedia or avicommons", name)
+	}
+}
+
+// loadSpecies returns the species to bundle from a file or the configured database
+func loadSpecies(settings *conf.Settings, speciesFile string) ([]imageprovider.BundleSpecies, error) {
+	_, scientificIndex, err := birdnet.LoadTaxonomyData("")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load taxonomy data: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	var species []imageprovider.BundleSpecies
+	add := func(scientificName, code string) {
+		scientificName = strings.TrimSpace(scientificName)
+		if scientificName == "" || seen[strings.ToLower(scientificName)] {
+			return
+		}
+		seen[strings.ToLower(scientificName)] = true
+		if code == "" {
+			code = scientificIndex[scientificName]
+		}
+		species = append(species, imageprovider.BundleSpecies{ScientificName: scientificName, SpeciesCode: code})
+	}
+
+	if speciesFile != "" {
+		f, err := os.Open(speciesFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open species file: %w", err)
+		}
+		defer func() { _ = f.Close() }()
+
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			add(line, "")
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read species file: %w", err)
+		}
+		return species, nil
+	}
+
+	ds := datastore.New(settings)
+	if ds == nil {
+		return nil, fmt.Errorf("no database output is enabled in configuration, use --species-file")
+	}
+	if err := ds.Open(); err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	defer func() {
+		if err := ds.Close(); err != nil {
+			fmt.Printf("⚠️ Failed to close database: %v\n", err)
+		}
+	}()
+
+	notes, err := ds.GetAllDetectedSpecies()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list detected species: %w", err)
+	}
+	for i := range notes {
+		add(notes[i].ScientificName, notes[i].SpeciesCode)
+	}
+	return species, nil
+}
@@ -0,0 +1,201 @@
+// Package loadtest provides the load-test command which injects synthetic detections
+package loadtest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/loadtest"
+	"github.com/tphakala/birdnet-go/internal/notification"
+)
+
+// Command returns the load-test command
+func Command(settings *conf.Settings) *cobra.Command {
+	var (
+		rate          float64
+		duration      time.Duration
+		concurrency   int
+		notifications bool
+		useConfigDB   bool
+		keep          bool
+		purge         bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "loadtest",
+		Short: "Inject synthetic detections to measure pipeline throughput",
+		Long: `Inject synthetic detections at a fixed rate through the detection storage
+pipeline and report throughput and latency. Useful for sizing hardware before
+deployment.
+
+By default detections are written to a temporary SQLite database which is removed
+afterwards. MQTT, BirdWeather and push notifications are always disabled.
+
+Synthetic detections have "loadtest" as their source node. When they are written
+to the configured database they are deleted after the run unless --keep is given;
+--purge deletes the ones left behind by earlier runs.
+
+Examples:
+  # 50 detections per second for one minute
+  birdnet-go loadtest --rate=50 --duration=1m
+
+  # Measure against the configured database, including in-app notifications
+  birdnet-go loadtest --rate=20 --use-configured-db --notifications
+
+  # Delete synthetic detections kept in the configured database
+  birdnet-go loadtest --purge`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if purge {
+				return runPurge(cmd.Context(), settings)
+			}
+			if keep && !useConfigDB {
+				return fmt.Errorf("--keep requires --use-configured-db")
+			}
+			return runLoadTest(cmd.Context(), settings, loadtest.Config{
+				Rate:        rate,
+				Duration:    duration,
+				Concurrency: concurrency,
+				Latitude:    settings.BirdNET.Latitude,
+				Longitude:   settings.BirdNET.Longitude,
+			}, notifications, useConfigDB, keep)
+		},
+	}
+
+	cmd.Flags().Float64Var(&rate, "rate", loadtest.DefaultRate, "Synthetic detections per second")
+	cmd.Flags().DurationVar(&duration, "duration", loadtest.DefaultDuration, "How long to generate load")
+	cmd.Flags().IntVar(&concurrency, "concurrency", loadtest.DefaultConcurrency, "Number of concurrent writers")
+	cmd.Flags().BoolVar(&notifications, "notifications", false, "Also create in-app detection notifications (sandboxed, never pushed)")
+	cmd.Flags().BoolVar(&useConfigDB, "use-configured-db", false, "Write to the configured database instead of a temporary one")
+	cmd.Flags().BoolVar(&keep, "keep", false, "Keep the synthetic detections in the configured database after the run")
+	cmd.Flags().BoolVar(&purge, "purge", false, "Delete synthetic detections from the configured database and exit")
+
+	return cmd
+}
+
+// runLoadTest prepares a sandboxed environment, runs the generator and prints the report
+func runLoadTest(ctx context.Context, settings *conf.Settings, cfg loadtest.Config, notifications, useConfigDB, keep bool) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Work on a copy so outbound integrations can be disabled without touching global settings
+	sandbox := *settings
+	sandbox.Realtime.MQTT.Enabled = false
+	sandbox.Realtime.Birdweather.Enabled = false
+	sandbox.Notification.Push.Enabled = false
+
+	if !useConfigDB {
+		tmpDir, err := os.MkdirTemp("", "birdnet-go-loadtest-*")
+		if err != nil {
+			return fmt.Errorf("failed to create temporary directory: %w", err)
+		}
+		defer func() {
+			if err := os.RemoveAll(tmpDir); err != nil {
+				fmt.Printf("⚠️ Failed to remove temporary directory %s: %v\n", tmpDir, err)
+			}
+		}()
+		sandbox.Output.MySQL.Enabled = false
+		sandbox.Output.SQLite.Enabled = true
+		sandbox.Output.SQLite.Path = filepath.Join(tmpDir, "loadtest.db")
+	}
+
+	ds := datastore.New(&sandbox)
+	if ds == nil {
+		return fmt.Errorf("no database output is enabled in configuration")
+	}
+	if err := ds.Open(); err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer func() {
+		if err := ds.Close(); err != nil {
+			fmt.Printf("⚠️ Failed to close database: %v\n", err)
+		}
+	}()
+
+	var notifier *notification.Service
+	if notifications {
+		// Private service instance with rate limiting sized to the requested load
+		svcConfig := notification.DefaultServiceConfig()
+		svcConfig.RateLimitWindow = time.Second
+		svcConfig.RateLimitMaxEvents = int(cfg.Rate*2) + 1
+		notifier = notification.NewService(svcConfig)
+		defer notifier.Stop()
+	}
+
+	gen, err := loadtest.NewGenerator(cfg, loadtest.NewPipelineSink(ds, notifier))
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("🚀 Generating %.1f detections/sec for %s with %d writers...\n", cfg.Rate, cfg.Duration, cfg.Concurrency)
+	report := gen.Run(ctx)
+
+	fmt.Println("\nResults:")
+	fmt.Printf("  Submitted:   %d\n", report.Submitted)
+	fmt.Printf("  Succeeded:   %d\n", report.Succeeded)
+	fmt.Printf("  Failed:      %d\n", report.Failed)
+	fmt.Printf("  Dropped:     %d\n", report.Dropped)
+	fmt.Printf("  Throughput:  %.2f detections/sec\n", report.Throughput)
+	fmt.Printf("  Latency avg: %s\n", report.LatencyAvg)
+	fmt.Printf("  Latency p50: %s\n", report.LatencyP50)
+	fmt.Printf("  Latency p95: %s\n", report.LatencyP95)
+	fmt.Printf("  Latency p99: %s\n", report.LatencyP99)
+	fmt.Printf("  Latency max: %s\n", report.LatencyMax)
+	if report.FirstError != "" {
+		fmt.Printf("  First error: %s\n", report.FirstError)
+	}
+
+	if report.Dropped > 0 {
+		fmt.Println("\n⚠️ Some detections were dropped because writers could not keep up with the requested rate.")
+	}
+
+	if useConfigDB && !keep {
+		// The run may have been interrupted, the synthetic detections are still deleted
+		return purgeSynthetic(context.WithoutCancel(ctx), ds)
+	}
+	return nil
+}
+
+// runPurge deletes the synthetic detections from the configured database
+func runPurge(ctx context.Context, settings *conf.Settings) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ds := datastore.New(settings)
+	if ds == nil {
+		return fmt.Errorf("no database output is enabled in configuration")
+	}
+	if err := ds.Open(); err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer func() {
+		if err := ds.Close(); err != nil {
+			fmt.Printf("⚠️ Failed to close database: %v\n", err)
+		}
+	}()
+	return purgeSynthetic(ctx, ds)
+}
+
+// purgeSynthetic deletes the synthetic detections and prints how many were deleted
+func purgeSynthetic(ctx context.Context, ds datastore.Interface) error {
+	store, ok := ds.(loadtest.PurgeStore)
+	if !ok {
+		return fmt.Errorf("database does not support deleting synthetic detections")
+	}
+	deleted, err := loadtest.Purge(ctx, store)
+	fmt.Printf("🧹 Deleted %d synthetic detections\n", deleted)
+	if err != nil {
+		return fmt.Errorf("failed to delete synthetic detections: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+// Package manifest provides commands for listing and verifying the manifest
+// of uploads and exports
+package manifest
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/manifest"
+)
+
+// Command returns the manifest command
+func Command(settings *conf.Settings) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "manifest",
+		Short: "List and verify the checksums of uploads and exports",
+		Long: `The manifest records the SHA-256 checksum, size and destination of every
+BirdWeather upload, backup and export when manifest.enabled is set.`,
+	}
+	cmd.AddCommand(listCommand(settings), verifyCommand(settings))
+	return cmd
+}
+
+// listCommand returns the manifest list subcommand
+func listCommand(settings *conf.Settings) *cobra.Command {
+	var (
+		kind  string
+		limit int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List recorded uploads and exports, newest first",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withStore(settings, func(store manifest.Store) error {
+				entries, err := store.ListManifestEntries(&datastore.ManifestFilter{Kind: kind, Limit: limit})
+				if err != nil {
+					return err
+				}
+				for i := range entries {
+					e := &entries[i]
+					verified := "never verified"
+					if e.VerifiedAt != nil {
+						verified = fmt.Sprintf("%s %s", e.VerifyStatus, e.VerifiedAt.Format(time.DateTime))
+					}
+					fmt.Printf("%d\t%s\t%s\t%s\t%s\t%d bytes\t%s -> %s\t(%s)\n",
+						e.ID, e.CreatedAt.Format(time.DateTime), e.Kind, e.Name, e.SHA256, e.Size, e.Path, e.Destination, verified)
+				}
+				return nil
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&kind, "kind", "", "Only list entries of a kind: birdweather, backup, export")
+	cmd.Flags().IntVar(&limit, "limit", 50, "Number of entries to list, 0 for all")
+
+	return cmd
+}
+
+// verifyCommand returns the manifest verify subcommand
+func verifyCommand(settings *conf.Settings) *cobra.Command {
+	var (
+		kind string
+		file string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "verify [id...]",
+		Short: "Re-check the integrity of uploads and exports",
+		Long: `Re-check the local copies of recorded uploads and exports, such as backups in a
+local target, against their checksums and record the outcome. Entries stored only
+remotely are reported as remote; download them and check the copy with --file.
+
+Examples:
+  # Verify all entries
+  birdnet-go manifest verify
+
+  # Verify the backups
+  birdnet-go manifest verify --kind backup
+
+  # Check a downloaded export or backup against the manifest
+  birdnet-go manifest verify --file birdnet-go-training-1234.zip`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ids := make([]uint, 0, len(args))
+			for _, arg := range args {
+				id, err := strconv.ParseUint(arg, 10, 0)
+				if err != nil {
+					return fmt.Errorf("invalid manifest entry ID %q", arg)
+				}
+				ids = append(ids, uint(id))
+			}
+
+			return withStore(settings, func(store manifest.Store) error {
+				if file != "" {
+					return verifyFile(store, file)
+				}
+				return verifyEntries(store, &datastore.ManifestFilter{Kind: kind, IDs: ids})
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&kind, "kind", "", "Only verify entries of a kind: birdweather, backup, export")
+	cmd.Flags().StringVar(&file, "file", "", "Check a copy of an upload or export against the recorded checksums")
+
+	return cmd
+}
+
+// verifyEntries verifies the entries matching filter and fails when any local
+// copy is missing or altered
+func verifyEntries(store manifest.Store, filter *datastore.ManifestFilter) error {
+	results, err := manifest.Verify(store, filter, time.Now())
+	if err != nil {
+		return err
+	}
+
+	counts := make(map[string]int)
+	for i := range results {
+		r := &results[i]
+		counts[r.Status]++
+		switch r.Status {
+		case manifest.StatusOK:
+			fmt.Printf("✅ %d %s: ok\n", r.Entry.ID, r.Entry.Name)
+		case manifest.StatusRemote:
+			fmt.Printf("☁️  %d %s: stored only at %s, not checked\n", r.Entry.ID, r.Entry.Name, r.Entry.Destination)
+		case manifest.StatusMissing:
+			fmt.Printf("⚠️  %d %s: %s no longer exists\n", r.Entry.ID, r.Entry.Name, r.Entry.Path)
+		default:
+			if r.Err != nil {
+				fmt.Printf("❌ %d %s: %v\n", r.Entry.ID, r.Entry.Name, r.Err)
+			} else {
+				fmt.Printf("❌ %d %s: checksum %s, recorded %s\n", r.Entry.ID, r.Entry.Name, r.Actual, r.Entry.SHA256)
+			}
+		}
+	}
+	fmt.Printf("%d verified: %d ok, %d mismatched, %d missing, %d remote\n", len(results),
+		counts[manifest.StatusOK], counts[manifest.StatusMismatch], counts[manifest.StatusMissing], counts[manifest.StatusRemote])
+
+	if counts[manifest.StatusMismatch] > 0 {
+		return fmt.Errorf("%d entries do not match their recorded checksums", counts[manifest.StatusMismatch])
+	}
+	return nil
+}
+
+// verifyFile looks up a copy of an upload or export by its checksum
+func verifyFile(store manifest.Store, path string) error {
+	sum, entries, err := manifest.FindFile(store, path)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("%s (sha256 %s) matches no recorded upload or export", path, sum)
+	}
+	for i := range entries {
+		e := &entries[i]
+		fmt.Printf("✅ %s matches %d %s %s sent to %s at %s\n", path, e.ID, e.Kind, e.Name, e.Destination, e.CreatedAt.Format(time.DateTime))
+	}
+	return nil
+}
+
+// withStore opens the configured database and runs fn with its manifest
+func withStore(settings *conf.Settings, fn func(store manifest.Store) error) error {
+	ds := datastore.New(settings)
+	if ds == nil {
+		return fmt.Errorf("no database output is enabled in configuration")
+	}
+	if err := ds.Open(); err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer func() {
+		if err := ds.Close(); err != nil {
+			fmt.Printf("⚠️ Failed to close database: %v\n", err)
+		}
+	}()
+
+	store, ok := ds.(manifest.Store)
+	if !ok {
+		return fmt.Errorf("database does not support the manifest")
+	}
+	return fn(store)
+}
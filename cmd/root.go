@@ -11,12 +11,16 @@ import (
 	"github.com/tphakala/birdnet-go/cmd/benchmark"
 	"github.com/tphakala/birdnet-go/cmd/directory"
 	"github.com/tphakala/birdnet-go/cmd/file"
+	"github.com/tphakala/birdnet-go/cmd/imagebundle"
 	"github.com/tphakala/birdnet-go/cmd/license"
+	"github.com/tphakala/birdnet-go/cmd/loadtest"
+	"github.com/tphakala/birdnet-go/cmd/manifest"
 	"github.com/tphakala/birdnet-go/cmd/notify"
 	"github.com/tphakala/birdnet-go/cmd/rangefilter"
 	"github.com/tphakala/birdnet-go/cmd/realtime"
 	"github.com/tphakala/birdnet-go/cmd/support"
 	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/cpulimit"
 )
 
 // RootCommand creates and returns the root command
@@ -43,6 +47,9 @@ func RootCommand(settings *conf.Settings) *cobra.Command {
 	supportCmd := support.Command(settings)
 	benchmarkCmd := benchmark.Command(settings)
 	notifyCmd := notify.Command(settings)
+	loadtestCmd := loadtest.Command(settings)
+	imagebundleCmd := imagebundle.Command(settings)
+	manifestCmd := manifest.Command(settings)
 
 	subcommands := []*cobra.Command{
 		fileCmd,
@@ -54,14 +61,22 @@ func RootCommand(settings *conf.Settings) *cobra.Command {
 		supportCmd,
 		benchmarkCmd,
 		notifyCmd,
+		loadtestCmd,
+		imagebundleCmd,
+		manifestCmd,
 	}
 
 	rootCmd.AddCommand(subcommands...)
 
 	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		// Seed runtime read-only state from config file or --read-only flag
+		conf.SetReadOnly(settings.Main.ReadOnly)
+		// Seed runtime offline state from config file or --offline flag
+		conf.SetOffline(settings.Main.Offline)
+
 		// Skip setup for authors and license commands
 		if cmd.Name() != authorsCmd.Name() && cmd.Name() != licenseCmd.Name() {
-			if err := initialize(); err != nil {
+			if err := initialize(settings); err != nil {
 				return fmt.Errorf("error initializing: %w", err)
 			}
 		}
@@ -74,13 +89,25 @@ func RootCommand(settings *conf.Settings) *cobra.Command {
 
 // initialize is called before any subcommands are run, but after the context is ready
 // This function is responsible for setting up configurations, ensuring the environment is ready, etc.
-func initialize() error {
+func initialize(settings *conf.Settings) error {
+	// Apply CPU limits before the model interpreter starts its threads. A limit
+	// that cannot be applied is not fatal, analysis just runs without it.
+	limits, err := cpulimit.Apply(&settings.Main.Resources)
+	if err != nil {
+		log.Printf("warning: could not apply all CPU limits: %v", err)
+	}
+	if limits.Nice > 0 || limits.Cgroup != "" || settings.Main.Resources.MaxProcs > 0 {
+		log.Printf("CPU limits: maxprocs %d, nice %d, cgroup %q quota %g CPUs weight %d",
+			limits.MaxProcs, limits.Nice, limits.Cgroup, limits.CPUQuota, limits.CPUWeight)
+	}
 	return nil
 }
 
 // defineGlobalFlags defines flags that are global to the command line interface
 func setupFlags(rootCmd *cobra.Command, settings *conf.Settings) error {
 	rootCmd.PersistentFlags().BoolVarP(&settings.Debug, "debug", "d", viper.GetBool("debug"), "Enable debug output")
+	rootCmd.PersistentFlags().BoolVar(&settings.Main.ReadOnly, "read-only", viper.GetBool("main.readonly"), "Run in read-only mode, detections and settings are not written")
+	rootCmd.PersistentFlags().BoolVar(&settings.Main.Offline, "offline", viper.GetBool("main.offline"), "Run in air-gapped mode, no outbound network calls are made")
 	rootCmd.PersistentFlags().StringVar(&settings.BirdNET.Locale, "locale", viper.GetString("birdnet.locale"), "Set the locale for labels. Accepts full name or 2-letter code.")
 	rootCmd.PersistentFlags().IntVarP(&settings.BirdNET.Threads, "threads", "j", viper.GetInt("birdnet.threads"), "Number of CPU threads to use for analysis (default 0 which is all CPUs)")
 	rootCmd.PersistentFlags().Float64VarP(&settings.BirdNET.Sensitivity, "sensitivity", "s", viper.GetFloat64("birdnet.sensitivity"), "Sigmoid sensitivity value between 0.0 and 1.5")
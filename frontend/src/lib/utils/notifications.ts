@@ -10,7 +10,7 @@ export const TOAST_NOTIFICATION_TITLE = 'Toast Message';
 
 export interface Notification {
   id: string;
-  type: 'error' | 'warning' | 'info' | 'detection' | 'system';
+  type: 'error' | 'warning' | 'info' | 'detection' | 'system' | 'rare_species';
   title: string;
   message: string;
   timestamp: string;
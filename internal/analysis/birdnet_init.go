@@ -1,7 +1,10 @@
 package analysis
 
 import (
+	"context"
 	"fmt"
+	"log"
+	"sync"
 
 	"github.com/tphakala/birdnet-go/internal/birdnet"
 	"github.com/tphakala/birdnet-go/internal/conf"
@@ -52,3 +55,51 @@ func UpdateBirdNETModelLoadedMetric(birdnetMetrics *metrics.BirdNETMetrics) {
 		birdnetMetrics.RecordModelLoad(modelNameBirdNET, nil)
 	}
 }
+
+// startModelWarmup runs dummy inferences on the shared BirdNET interpreter in
+// a new goroutine, and then keeps it warm while it is idle until quitChan is
+// closed. Realtime, file and directory analysis share the interpreter, so it
+// stays warm for all of them.
+func startModelWarmup(wg *sync.WaitGroup, settings *conf.Settings, quitChan chan struct{}) {
+	warmup := settings.BirdNET.Warmup
+	if !warmup.Enabled || bn == nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer cancel()
+		go func() {
+			select {
+			case <-quitChan:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		warmUpBirdNET(ctx, bn, warmup.Runs)
+		bn.KeepWarm(ctx, warmup.KeepWarm)
+	}()
+}
+
+// warmUpBirdNET warms up a BirdNET interpreter, logging the result
+func warmUpBirdNET(ctx context.Context, model *birdnet.BirdNET, runs int) {
+	if err := model.Warmup(ctx, runs); err != nil {
+		if ctx.Err() == nil {
+			GetLogger().Warn("BirdNET model warm-up failed",
+				"error", err,
+				"operation", "model_warmup")
+		}
+		return
+	}
+	status := model.WarmupStatus()
+	GetLogger().Info("BirdNET model warmed up",
+		"runs", status.Runs,
+		"first_inference_ms", status.FirstInferenceMs,
+		"last_inference_ms", status.LastInferenceMs,
+		"operation", "model_warmup")
+	log.Printf("🔥 BirdNET model warmed up, first inference %.0f ms, warm inference %.0f ms",
+		status.FirstInferenceMs, status.LastInferenceMs)
+}
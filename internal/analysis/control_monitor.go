@@ -225,6 +225,11 @@ func (cm *ControlMonitor) handleReloadBirdnet() {
 	log.Printf("\033[32m✅ BirdNET model reloaded successfully\033[0m")
 	cm.notifySuccess("BirdNET model reloaded successfully")
 
+	// Warm up the new interpreter before the next detection
+	if settings := conf.Setting(); settings.BirdNET.Warmup.Enabled {
+		warmUpBirdNET(context.Background(), cm.bn, settings.BirdNET.Warmup.Runs)
+	}
+
 	// Rebuild range filter after model reload
 	if err := birdnet.BuildRangeFilter(cm.bn); err != nil {
 		log.Printf("\033[31m❌ Error rebuilding range filter after model reload: %v\033[0m", err)
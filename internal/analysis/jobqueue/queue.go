@@ -613,6 +613,14 @@ func (q *JobQueue) executeJob(ctx context.Context, job *Job) {
 	executionEndTime := q.clock.Now()
 	executionDuration := executionEndTime.Sub(executionStartTime)
 
+	// Hand a final failure to the action once the queue is unlocked
+	failed := false
+	defer func() {
+		if handler, ok := job.Action.(FailureHandler); ok && failed {
+			handler.OnFailure(job.Data, err)
+		}
+	}()
+
 	// Handle the result
 	q.mu.Lock()
 	defer q.mu.Unlock()
@@ -652,6 +660,7 @@ func (q *JobQueue) executeJob(ctx context.Context, job *Job) {
 		if job.Attempts >= job.MaxAttempts {
 			// No more retries
 			job.Status = JobStatusFailed
+			failed = true
 
 			q.stats.FailedJobs++
 			stats.Failed++
@@ -516,6 +516,48 @@ func TestRetryExhaustion(t *testing.T) {
 	assert.True(t, jobFailed, "Job should have failed permanently after exhausting retries")
 }
 
+// failureHandlingAction is a mock action recording its final failure
+type failureHandlingAction struct {
+	MockAction
+	failures atomic.Int32
+	lastData atomic.Value
+}
+
+func (a *failureHandlingAction) OnFailure(data any, err error) {
+	a.failures.Add(1)
+	a.lastData.Store(data)
+}
+
+// TestFailureHandler tests that actions are told of a job failing its last
+// attempt only
+func TestFailureHandler(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	queue := setupTestQueue(t, 100, 10, false)
+	defer teardownTestQueue(t, queue)
+
+	action := &failureHandlingAction{MockAction: MockAction{
+		ExecuteFunc: func(data interface{}) error {
+			return errors.New("simulated failure")
+		},
+	}}
+	data := &TestData{ID: "failure-handler-test"}
+	config := RetryConfig{Enabled: true, MaxRetries: 1, InitialDelay: time.Millisecond, MaxDelay: time.Millisecond, Multiplier: 1}
+	_, err := queue.Enqueue(context.Background(), action, data, config)
+	require.NoError(t, err)
+
+	queue.ProcessImmediately(ctx)
+	require.Eventually(t, func() bool { return action.GetExecuteCount() == 1 }, time.Second, time.Millisecond)
+	assert.Zero(t, action.failures.Load(), "retried jobs have not failed yet")
+
+	time.Sleep(5 * time.Millisecond)
+	queue.ProcessImmediately(ctx)
+	require.Eventually(t, func() bool { return action.failures.Load() == 1 }, time.Second, time.Millisecond)
+	assert.Same(t, data, action.lastData.Load())
+}
+
 // TestRetryBackoff tests that the retry backoff mechanism works correctly
 func TestRetryBackoff(t *testing.T) {
 	// TODO: This test could be improved by using a mock clock implementation
@@ -59,6 +59,13 @@ type Action interface {
 	GetDescription() string // Returns a human-readable description of the action
 }
 
+// FailureHandler is implemented by actions that handle a job failing its
+// last attempt, for example to keep the work for a later delivery. OnFailure
+// is called with the data of the job and its last error.
+type FailureHandler interface {
+	OnFailure(data any, err error)
+}
+
 // Clock is an interface for time-related operations that can be mocked for testing
 type Clock interface {
 	Now() time.Time
@@ -10,16 +10,19 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/tphakala/birdnet-go/internal/analysis/jobqueue"
 	"github.com/tphakala/birdnet-go/internal/analysis/species"
+	"github.com/tphakala/birdnet-go/internal/atrest"
 	"github.com/tphakala/birdnet-go/internal/birdnet"
 	"github.com/tphakala/birdnet-go/internal/birdweather"
 	"github.com/tphakala/birdnet-go/internal/conf"
 	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/diskmanager"
 	"github.com/tphakala/birdnet-go/internal/errors"
 	"github.com/tphakala/birdnet-go/internal/events"
 	"github.com/tphakala/birdnet-go/internal/imageprovider"
@@ -59,6 +62,12 @@ const (
 
 	// ExecuteCommandTimeout is the timeout for external command execution
 	ExecuteCommandTimeout = 5 * time.Minute
+
+	// snippetEncodeTimeout is the maximum time to encode a detection snippet
+	snippetEncodeTimeout = 30 * time.Second
+
+	// snippetChunkSeconds is the length of the analyzed chunk a snippet is centered on
+	snippetChunkSeconds = 3
 )
 
 // Action is the base interface for all actions that can be executed
@@ -91,6 +100,8 @@ type DatabaseAction struct {
 	EventTracker      *EventTracker
 	NewSpeciesTracker *species.SpeciesTracker // Add reference to new species tracker
 	processor         *Processor              // Add reference to processor for source name resolution
+	contextCheck      *contextCheck           // Cross-checks new species before notifying, nil when disabled
+	rareSpecies       *rareSpecies            // Alerts of locally unusual species, nil when disabled
 	Description       string
 	CorrelationID     string     // Detection correlation ID for log tracking
 	mu                sync.Mutex // Protect concurrent access to Note and Results
@@ -100,6 +111,7 @@ type SaveAudioAction struct {
 	Settings      *conf.Settings
 	ClipName      string
 	pcmData       []byte
+	sampleRate    int // sample rate of pcmData, 0 for the analyzed sample rate
 	EventTracker  *EventTracker
 	Description   string
 	CorrelationID string     // Detection correlation ID for log tracking
@@ -526,6 +538,16 @@ func (a *DatabaseAction) Execute(data interface{}) error {
 		return nil
 	}
 
+	// Skip storage entirely in read-only mode, neither the note nor the audio clip is written
+	if conf.IsReadOnly() {
+		GetLogger().Debug("Read-only mode enabled, skipping database save",
+			"component", "analysis.processor.actions",
+			"detection_id", a.CorrelationID,
+			"species", a.Note.CommonName,
+			"operation", "database_save_skipped")
+		return nil
+	}
+
 	// Check if this is a new species and update atomically to prevent race conditions
 	var isNewSpecies bool
 	var daysSinceFirstSeen int
@@ -535,6 +557,31 @@ func (a *DatabaseAction) Execute(data interface{}) error {
 		isNewSpecies, daysSinceFirstSeen = a.NewSpeciesTracker.CheckAndUpdateSpecies(a.Note.ScientificName, time.Now())
 	}
 
+	// In disk protective mode keep the detection metadata but do not write the clip,
+	// and clear the clip name so the note does not reference a file that never existed
+	saveClip := a.Settings.Realtime.Audio.Export.Enabled
+	saveSnippet := !saveClip && a.Settings.Realtime.Audio.Export.Snippet.Enabled
+	if (saveClip || saveSnippet) && !diskmanager.ClipWritesAllowed() {
+		saveClip, saveSnippet = false, false
+		a.Note.ClipName = ""
+		if guard := diskmanager.GetProtectiveGuard(); guard != nil {
+			guard.RecordSkippedClip()
+		}
+		GetLogger().Warn("Disk protective mode active, skipping audio clip",
+			"component", "analysis.processor.actions",
+			"detection_id", a.CorrelationID,
+			"species", a.Note.CommonName,
+			"operation", "save_audio_clip_skipped")
+	}
+
+	// Without clip export a compressed snippet is kept in place of the clip
+	if saveSnippet && a.Note.ClipName != "" {
+		ext := myaudio.DestinationExtension(&a.Settings.Realtime.Audio, myaudio.DestinationSnippet)
+		a.Note.ClipName = strings.TrimSuffix(a.Note.ClipName, filepath.Ext(a.Note.ClipName)) + "." + ext
+	} else {
+		saveSnippet = false
+	}
+
 	// Save note to database
 	if err := a.Ds.Save(&a.Note, a.Results); err != nil {
 		// Add structured logging
@@ -551,11 +598,30 @@ func (a *DatabaseAction) Execute(data interface{}) error {
 		return err
 	}
 
-	// After successful save, publish detection event for new species
-	a.publishNewSpeciesDetectionEvent(isNewSpecies, daysSinceFirstSeen)
+	// Push the committed detection to the live detection stream
+	if a.processor != nil {
+		if stream := a.processor.GetDetectionStreamer(); stream != nil {
+			note := a.Note
+			stream(&note)
+		}
+	}
+
+	// After successful save, publish the detection event for notification rules
+	a.publishDetectionEvent(isNewSpecies, daysSinceFirstSeen)
+
+	// Check the rarity of the species in the background, as the frequency
+	// data may have to be fetched from eBird first
+	if a.rareSpecies != nil {
+		note := a.Note
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), rarityTimeout)
+			defer cancel()
+			a.rareSpecies.check(ctx, &note)
+		}()
+	}
 
 	// Save audio clip to file if enabled
-	if a.Settings.Realtime.Audio.Export.Enabled {
+	if saveClip {
 		captureLength := a.Settings.Realtime.Audio.Export.Length
 
 		// debug log note begin, end and capture length
@@ -567,8 +633,8 @@ func (a *DatabaseAction) Execute(data interface{}) error {
 			"capture_length", captureLength,
 			"operation", "note_begin_end_capture_length")
 
-		// export audio clip from capture buffer
-		pcmData, err := myaudio.ReadSegmentFromCaptureBuffer(a.Note.Source.ID, a.Note.BeginTime, captureLength)
+		// export audio clip from capture buffer, at the capture sample rate when the source keeps it
+		pcmData, sampleRate, err := myaudio.ReadClipSegment(&a.Settings.Realtime.Audio, a.Note.Source.ID, a.Note.BeginTime, captureLength)
 		if err != nil {
 			// Add structured logging
 			GetLogger().Error("Failed to read audio segment from buffer",
@@ -584,14 +650,35 @@ func (a *DatabaseAction) Execute(data interface{}) error {
 			return err
 		}
 
-		// Create a SaveAudioAction and execute it
+		// Encode and write the clip in the background
 		saveAudioAction := &SaveAudioAction{
-			Settings: a.Settings,
-			ClipName: a.Note.ClipName,
-			pcmData:  pcmData,
+			Settings:      a.Settings,
+			ClipName:      a.Note.ClipName,
+			pcmData:       pcmData,
+			sampleRate:    sampleRate,
+			CorrelationID: a.CorrelationID,
 		}
-
-		if err := saveAudioAction.Execute(nil); err != nil {
+		note := a.Note
+		if err := a.writeClip(func() error {
+			if err := saveAudioAction.Execute(nil); err != nil {
+				return err
+			}
+			if a.Settings.Debug {
+				// Add structured logging
+				GetLogger().Debug("Saved audio clip successfully",
+					"component", "analysis.processor.actions",
+					"detection_id", saveAudioAction.CorrelationID,
+					"species", note.CommonName,
+					"clip_name", note.ClipName,
+					"detection_time", note.Time,
+					"begin_time", note.BeginTime,
+					"end_time", time.Now(),
+					"operation", "save_audio_clip_debug")
+				log.Printf("✅ Saved audio clip to %s\n", note.ClipName)
+				log.Printf("detection time %v, begin time %v, end time %v\n", note.Time, note.BeginTime, time.Now())
+			}
+			return nil
+		}); err != nil {
 			// Add structured logging
 			GetLogger().Error("Failed to save audio clip",
 				"component", "analysis.processor.actions",
@@ -603,26 +690,85 @@ func (a *DatabaseAction) Execute(data interface{}) error {
 			log.Printf("❌ Failed to save audio clip")
 			return err
 		}
+	}
 
-		if a.Settings.Debug {
-			// Add structured logging
-			GetLogger().Debug("Saved audio clip successfully",
+	if saveSnippet {
+		if err := a.saveSnippet(); err != nil {
+			GetLogger().Error("Failed to save audio snippet",
 				"component", "analysis.processor.actions",
 				"detection_id", a.CorrelationID,
+				"error", err,
 				"species", a.Note.CommonName,
 				"clip_name", a.Note.ClipName,
-				"detection_time", a.Note.Time,
-				"begin_time", a.Note.BeginTime,
-				"end_time", time.Now(),
-				"operation", "save_audio_clip_debug")
-			log.Printf("✅ Saved audio clip to %s\n", a.Note.ClipName)
-			log.Printf("detection time %v, begin time %v, end time %v\n", a.Note.Time, a.Note.BeginTime, time.Now())
+				"operation", "save_detection_snippet")
+			log.Printf("❌ Failed to save audio snippet")
+			return err
 		}
 	}
 
 	return nil
 }
 
+// saveSnippet saves a short low-bitrate snippet of the detection, centered on
+// the analyzed chunk, when full clips are not exported
+func (a *DatabaseAction) saveSnippet() error {
+	snippet := a.Settings.Realtime.Audio.Export.Snippet
+	offset := time.Duration(max(snippet.Length-snippetChunkSeconds, 0)) * time.Second / 2
+
+	pcmData, err := myaudio.ReadSegmentFromCaptureBuffer(a.Note.Source.ID, a.Note.BeginTime.Add(-offset), snippet.Length)
+	if err != nil {
+		return err
+	}
+
+	settings := a.Settings
+	outputPath := filepath.Join(settings.Realtime.Audio.Export.Path, a.Note.ClipName)
+	return a.writeClip(func() error {
+		if err := os.MkdirAll(filepath.Dir(outputPath), 0o755); err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), snippetEncodeTimeout)
+		defer cancel()
+		if err := myaudio.SaveSnippet(ctx, pcmData, outputPath, &settings.Realtime.Audio); err != nil {
+			return err
+		}
+
+		if settings.Encryption.Clips {
+			return encryptClip(outputPath)
+		}
+		return nil
+	})
+}
+
+// writeClip encodes and writes a clip or snippet through the clip writer of
+// the processor, so slow storage does not hold up detection processing.
+// Without a clip writer the clip is written right away. Queued clips report
+// their errors through the clip writer. When the queue is full and the clip
+// is dropped, the clip name of the saved note is cleared so it does not
+// reference a file that will never be written.
+func (a *DatabaseAction) writeClip(write func() error) error {
+	if a.processor == nil || a.processor.clipWriter == nil {
+		return write()
+	}
+	ds, noteID, correlationID := a.Ds, strconv.FormatUint(uint64(a.Note.ID), 10), a.CorrelationID
+	a.processor.clipWriter.enqueue(&clipWriteJob{
+		clipName:      a.Note.ClipName,
+		correlationID: correlationID,
+		write:         write,
+		dropped: func() {
+			if err := ds.DeleteNoteClipPath(noteID); err != nil {
+				GetLogger().Error("Failed to clear clip name of dropped audio clip",
+					"component", "analysis.processor.actions",
+					"detection_id", correlationID,
+					"note_id", noteID,
+					"error", err,
+					"operation", "clear_dropped_clip_name")
+			}
+		},
+	})
+	return nil
+}
+
 // isEOFError checks if an error is an EOF error using both precise matching and string fallback
 func isEOFError(err error) bool {
 	if err == nil {
@@ -636,18 +782,23 @@ func isEOFError(err error) bool {
 	return strings.Contains(strings.ToLower(err.Error()), "eof")
 }
 
-// publishNewSpeciesDetectionEvent publishes a detection event for new species
+// publishDetectionEvent publishes a detection event for the notification rules.
+// Only new species are published unless a rule or a saved search subscription
+// may match known species too.
 // This helper method handles event bus retrieval, event creation, publishing, and debug logging
-func (a *DatabaseAction) publishNewSpeciesDetectionEvent(isNewSpecies bool, daysSinceFirstSeen int) {
-	if !isNewSpecies || !events.IsInitialized() {
+func (a *DatabaseAction) publishDetectionEvent(isNewSpecies bool, daysSinceFirstSeen int) {
+	if !events.IsInitialized() {
+		return
+	}
+	if !isNewSpecies && !a.Settings.Notification.NeedsAllDetections() && !notification.HasSubscriptions() {
 		return
 	}
 
 	// Store current time for consistent use throughout
 	var notificationTime time.Time
 
-	// Check notification suppression if tracker is available
-	if a.NewSpeciesTracker != nil {
+	// Check notification suppression of new species if tracker is available
+	if isNewSpecies && a.NewSpeciesTracker != nil {
 		notificationTime = time.Now()
 
 		// Check if notification should be suppressed for this species
@@ -669,6 +820,23 @@ func (a *DatabaseAction) publishNewSpeciesDetectionEvent(isNewSpecies bool, days
 		return
 	}
 
+	// Hold back new species the audio around the detection does not corroborate
+	var check contextCheckResult
+	if isNewSpecies && a.contextCheck != nil {
+		check = a.contextCheck.check(context.Background(), &a.Note)
+		if !check.corroborated {
+			GetLogger().Info("New species notification held back, not corroborated by surrounding audio",
+				"component", "analysis.processor.actions",
+				"detection_id", a.CorrelationID,
+				"species", a.Note.CommonName,
+				"scientific_name", a.Note.ScientificName,
+				"confidence", a.Note.Confidence,
+				"context_confidences", check.confidences,
+				"operation", "context_check")
+			return
+		}
+	}
+
 	// Use display name directly from the AudioSource struct for user-facing notifications
 	displayLocation := a.Note.Source.DisplayName
 
@@ -705,6 +873,9 @@ func (a *DatabaseAction) publishNewSpeciesDetectionEvent(isNewSpecies bool, days
 		metadata["latitude"] = a.Note.Latitude
 		metadata["longitude"] = a.Note.Longitude
 		metadata["begin_time"] = a.Note.BeginTime
+		if check.corroborated && !check.skipped {
+			metadata["context_confidences"] = check.confidences
+		}
 
 		// Get bird image URL from cache and add to metadata
 		if a.processor != nil && a.processor.BirdImageCache != nil {
@@ -732,7 +903,7 @@ func (a *DatabaseAction) publishNewSpeciesDetectionEvent(isNewSpecies bool, days
 
 		if a.Settings.Debug {
 			// Add structured logging
-			GetLogger().Debug("Published new species detection event",
+			GetLogger().Debug("Published detection event",
 				"component", "analysis.processor.actions",
 				"detection_id", a.CorrelationID,
 				"species", a.Note.CommonName,
@@ -741,7 +912,9 @@ func (a *DatabaseAction) publishNewSpeciesDetectionEvent(isNewSpecies bool, days
 				"is_new_species", isNewSpecies,
 				"days_since_first_seen", daysSinceFirstSeen,
 				"operation", "publish_detection_event")
-			log.Printf("🌟 Published new species detection event: %s", a.Note.CommonName)
+			if isNewSpecies {
+				log.Printf("🌟 Published new species detection event: %s", a.Note.CommonName)
+			}
 		}
 	}
 }
@@ -768,32 +941,34 @@ func (a *SaveAudioAction) Execute(data interface{}) error {
 		return err
 	}
 
-	if a.Settings.Realtime.Audio.Export.Type == "wav" {
-		if err := myaudio.SavePCMDataToWAV(outputPath, a.pcmData); err != nil {
-			// Add structured logging
-			GetLogger().Error("Failed to save audio clip to WAV",
-				"component", "analysis.processor.actions",
-				"detection_id", a.CorrelationID,
-				"error", err,
-				"output_path", outputPath,
-				"clip_name", a.ClipName,
-				"format", "wav",
-				"operation", "save_wav")
-			log.Printf("❌ Error saving audio clip to WAV")
-			return err
-		}
-	} else {
-		if err := myaudio.ExportAudioWithFFmpeg(a.pcmData, outputPath, &a.Settings.Realtime.Audio); err != nil {
-			// Add structured logging
-			GetLogger().Error("Failed to export audio clip with FFmpeg",
+	sampleRate := a.sampleRate
+	if sampleRate == 0 {
+		sampleRate = conf.SampleRate
+	}
+	if err := myaudio.SaveClipAtRate(a.pcmData, sampleRate, outputPath, &a.Settings.Realtime.Audio); err != nil {
+		// Add structured logging
+		GetLogger().Error("Failed to save audio clip",
+			"component", "analysis.processor.actions",
+			"detection_id", a.CorrelationID,
+			"error", err,
+			"output_path", outputPath,
+			"clip_name", a.ClipName,
+			"format", a.Settings.Realtime.Audio.Export.Type,
+			"operation", "save_clip")
+		log.Printf("❌ Error saving audio clip as %s", a.Settings.Realtime.Audio.Export.Type)
+		return err
+	}
+
+	if a.Settings.Encryption.Clips {
+		if err := encryptClip(outputPath); err != nil {
+			GetLogger().Error("Failed to encrypt audio clip",
 				"component", "analysis.processor.actions",
 				"detection_id", a.CorrelationID,
 				"error", err,
 				"output_path", outputPath,
 				"clip_name", a.ClipName,
-				"format", a.Settings.Realtime.Audio.Export.Type,
-				"operation", "ffmpeg_export")
-			log.Printf("❌ Error exporting audio clip with FFmpeg")
+				"operation", "encrypt_clip")
+			log.Printf("❌ Error encrypting audio clip")
 			return err
 		}
 	}
@@ -801,6 +976,19 @@ func (a *SaveAudioAction) Execute(data interface{}) error {
 	return nil
 }
 
+// encryptClip encrypts a saved clip in place with the active cipher. A clip
+// that cannot be encrypted is removed rather than kept in plaintext.
+func encryptClip(path string) error {
+	var err error = atrest.ErrNoKey
+	if c := atrest.Active(); c != nil {
+		err = c.EncryptFile(path)
+	}
+	if err != nil {
+		_ = os.Remove(path)
+	}
+	return err
+}
+
 // Execute sends the note to the BirdWeather API
 func (a *BirdWeatherAction) Execute(data interface{}) error {
 	a.mu.Lock()
@@ -916,6 +1104,62 @@ type NoteWithBirdImage struct {
 	BirdImage imageprovider.BirdImage
 }
 
+// message returns the MQTT message of the note: the note with its bird image
+// as JSON
+func (a *MqttAction) message() ([]byte, error) {
+	// Get bird image of detected bird
+	birdImage := imageprovider.BirdImage{} // Default to empty image
+	// Add nil check for BirdImageCache before calling Get
+	if a.BirdImageCache != nil {
+		var err error
+		birdImage, err = a.BirdImageCache.Get(a.Note.ScientificName)
+		if err != nil {
+			// Add structured logging
+			GetLogger().Warn("Error getting bird image from cache",
+				"component", "analysis.processor.actions",
+				"detection_id", a.CorrelationID,
+				"error", err,
+				"species", a.Note.CommonName,
+				"scientific_name", a.Note.ScientificName,
+				"operation", "get_bird_image")
+			log.Printf("⚠️ Error getting bird image from cache for %s: %v", a.Note.ScientificName, err)
+			// Continue with the default empty image
+		}
+	} else {
+		// Log if the cache is nil, maybe helpful for debugging setup issues
+		// Add structured logging
+		GetLogger().Warn("BirdImageCache is nil, cannot fetch image",
+			"component", "analysis.processor.actions",
+			"detection_id", a.CorrelationID,
+			"species", a.Note.CommonName,
+			"scientific_name", a.Note.ScientificName,
+			"operation", "check_bird_image_cache")
+		log.Printf("🟡 BirdImageCache is nil, cannot fetch image for %s", a.Note.ScientificName)
+	}
+
+	// Create a copy of the Note (source is already sanitized in SafeString field)
+	noteCopy := a.Note
+
+	// Wrap note with bird image (using copy)
+	noteWithBirdImage := NoteWithBirdImage{Note: noteCopy, BirdImage: birdImage}
+
+	// Create a JSON representation of the note
+	noteJson, err := json.Marshal(noteWithBirdImage)
+	if err != nil {
+		// Add structured logging
+		GetLogger().Error("Failed to marshal note to JSON",
+			"component", "analysis.processor.actions",
+			"detection_id", a.CorrelationID,
+			"error", err,
+			"species", a.Note.CommonName,
+			"scientific_name", a.Note.ScientificName,
+			"operation", "json_marshal")
+		log.Printf("❌ Error marshalling note to JSON")
+		return nil, err
+	}
+	return noteJson, nil
+}
+
 // Execute sends the note to the MQTT broker
 func (a *MqttAction) Execute(data interface{}) error {
 	a.mu.Lock()
@@ -967,54 +1211,8 @@ func (a *MqttAction) Execute(data interface{}) error {
 			Build()
 	}
 
-	// Get bird image of detected bird
-	birdImage := imageprovider.BirdImage{} // Default to empty image
-	// Add nil check for BirdImageCache before calling Get
-	if a.BirdImageCache != nil {
-		var err error
-		birdImage, err = a.BirdImageCache.Get(a.Note.ScientificName)
-		if err != nil {
-			// Add structured logging
-			GetLogger().Warn("Error getting bird image from cache",
-				"component", "analysis.processor.actions",
-				"detection_id", a.CorrelationID,
-				"error", err,
-				"species", a.Note.CommonName,
-				"scientific_name", a.Note.ScientificName,
-				"operation", "get_bird_image")
-			log.Printf("⚠️ Error getting bird image from cache for %s: %v", a.Note.ScientificName, err)
-			// Continue with the default empty image
-		}
-	} else {
-		// Log if the cache is nil, maybe helpful for debugging setup issues
-		// Add structured logging
-		GetLogger().Warn("BirdImageCache is nil, cannot fetch image",
-			"component", "analysis.processor.actions",
-			"detection_id", a.CorrelationID,
-			"species", a.Note.CommonName,
-			"scientific_name", a.Note.ScientificName,
-			"operation", "check_bird_image_cache")
-		log.Printf("🟡 BirdImageCache is nil, cannot fetch image for %s", a.Note.ScientificName)
-	}
-
-	// Create a copy of the Note (source is already sanitized in SafeString field)
-	noteCopy := a.Note
-
-	// Wrap note with bird image (using copy)
-	noteWithBirdImage := NoteWithBirdImage{Note: noteCopy, BirdImage: birdImage}
-
-	// Create a JSON representation of the note
-	noteJson, err := json.Marshal(noteWithBirdImage)
+	noteJson, err := a.message()
 	if err != nil {
-		// Add structured logging
-		GetLogger().Error("Failed to marshal note to JSON",
-			"component", "analysis.processor.actions",
-			"detection_id", a.CorrelationID,
-			"error", err,
-			"species", a.Note.CommonName,
-			"scientific_name", a.Note.ScientificName,
-			"operation", "json_marshal")
-		log.Printf("❌ Error marshalling note to JSON")
 		return err
 	}
 
@@ -0,0 +1,273 @@
+// clipwriter.go: encodes and writes detection clips off the detection processing path
+package processor
+
+import (
+	"sync"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+const (
+	// defaultClipQueueSize is the number of clips waiting to be written when
+	// the queue size is not configured
+	defaultClipQueueSize = 32
+
+	// Clip writer drop policies, applied when the queue is full
+	clipDropOldest = "oldest"
+	clipDropNewest = "newest"
+
+	// defaultShutdownTimeout is the graceful shutdown deadline when it is not
+	// configured, 9s for Docker's 10s default
+	defaultShutdownTimeout = 9 * time.Second
+
+	// clipWriterStopShare is the fraction of the shutdown deadline spent writing
+	// queued clips, leaving the remainder for the other shutdown steps
+	clipWriterStopShare = 0.4
+)
+
+// clipWriterStopTimeout returns the time spent writing queued clips on
+// shutdown, a share of the graceful shutdown deadline
+func clipWriterStopTimeout(settings *conf.Settings) time.Duration {
+	deadline := defaultShutdownTimeout
+	if settings != nil && settings.Realtime.Shutdown.Timeout > 0 {
+		deadline = time.Duration(settings.Realtime.Shutdown.Timeout) * time.Second
+	}
+	return time.Duration(float64(deadline) * clipWriterStopShare)
+}
+
+// clipWriterMetrics records the clip writer queue; implemented by the myaudio metrics
+type clipWriterMetrics interface {
+	UpdateClipWriterQueue(depth, capacity int)
+	RecordClipWrite(status string, wait, duration float64)
+	RecordClipDropped()
+}
+
+// clipWriteJob is a clip or snippet waiting to be encoded and written
+type clipWriteJob struct {
+	clipName      string
+	correlationID string
+	write         func() error
+	dropped       func() // called when the clip is dropped from a full queue, optional
+	queued        time.Time
+}
+
+// clipWriter encodes and writes clips one at a time in a background goroutine.
+// The queue is bounded, as each queued clip holds its PCM data: when slow
+// storage lets it fill up, the oldest or the newest clip is dropped and the
+// dropped callback of the job clears the clip name of its detection, which is
+// kept without audio.
+type clipWriter struct {
+	mu         sync.Mutex
+	jobs       []*clipWriteJob
+	maxSize    int
+	dropPolicy string
+	written    int
+	failed     int
+	dropped    int
+	writing    int // queued clips being written
+	stopped    bool
+	wake       chan struct{}
+	done       chan struct{}
+	metrics    clipWriterMetrics
+}
+
+// ClipWriterStats describes the clips handled by the clip writer
+type ClipWriterStats struct {
+	Queued  int `json:"queued"`
+	Written int `json:"written"`
+	Failed  int `json:"failed"`
+	Dropped int `json:"dropped"`
+	MaxSize int `json:"max_size"`
+}
+
+// newClipWriter starts a clip writer. Metrics are optional.
+func newClipWriter(settings *conf.ClipWriterSettings, metrics clipWriterMetrics) *clipWriter {
+	w := &clipWriter{
+		maxSize:    settings.QueueSize,
+		dropPolicy: clipDropOldest,
+		wake:       make(chan struct{}, 1),
+		done:       make(chan struct{}),
+		metrics:    metrics,
+	}
+	if w.maxSize <= 0 {
+		w.maxSize = defaultClipQueueSize
+	}
+	if settings.DropPolicy == clipDropNewest {
+		w.dropPolicy = clipDropNewest
+	}
+	w.updateMetrics(0)
+	go w.run()
+	return w
+}
+
+// enqueue queues a clip to be written. When the queue is full a clip is
+// dropped according to the drop policy and its dropped callback is called.
+// After the writer has stopped the clip is written right away.
+func (w *clipWriter) enqueue(job *clipWriteJob) {
+	w.mu.Lock()
+	if w.stopped {
+		w.mu.Unlock()
+		w.execute(job)
+		return
+	}
+
+	var dropped *clipWriteJob
+	switch {
+	case len(w.jobs) < w.maxSize:
+	case w.dropPolicy == clipDropOldest:
+		dropped = w.jobs[0]
+		w.jobs[0] = nil
+		w.jobs = w.jobs[1:]
+	default:
+		dropped = job
+	}
+	if dropped != nil {
+		w.dropped++
+	}
+	if dropped != job {
+		job.queued = time.Now()
+		w.jobs = append(w.jobs, job)
+	}
+	depth := len(w.jobs)
+	w.mu.Unlock()
+
+	if dropped != nil {
+		if w.metrics != nil {
+			w.metrics.RecordClipDropped()
+		}
+		GetLogger().Warn("Clip writer queue full, dropping audio clip",
+			"component", "analysis.processor.clipwriter",
+			"detection_id", dropped.correlationID,
+			"clip_name", dropped.clipName,
+			"queue_size", w.maxSize,
+			"drop_policy", w.dropPolicy,
+			"operation", "clip_writer_drop")
+		if dropped.dropped != nil {
+			dropped.dropped()
+		}
+	}
+	w.updateMetrics(depth)
+
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run writes queued clips until the writer is stopped and the queue is empty
+func (w *clipWriter) run() {
+	defer close(w.done)
+	for {
+		w.mu.Lock()
+		if len(w.jobs) == 0 {
+			stopped := w.stopped
+			w.mu.Unlock()
+			if stopped {
+				return
+			}
+			<-w.wake
+			continue
+		}
+		job := w.jobs[0]
+		w.jobs[0] = nil
+		w.jobs = w.jobs[1:]
+		depth := len(w.jobs)
+		w.writing++
+		w.mu.Unlock()
+
+		w.updateMetrics(depth)
+		w.execute(job)
+
+		w.mu.Lock()
+		w.writing--
+		w.mu.Unlock()
+	}
+}
+
+// execute writes one clip and records the outcome
+func (w *clipWriter) execute(job *clipWriteJob) {
+	start := time.Now()
+	wait := 0.0
+	if !job.queued.IsZero() {
+		wait = start.Sub(job.queued).Seconds()
+	}
+
+	err := job.write()
+	status := "written"
+	w.mu.Lock()
+	if err != nil {
+		status = "failed"
+		w.failed++
+	} else {
+		w.written++
+	}
+	w.mu.Unlock()
+
+	if w.metrics != nil {
+		w.metrics.RecordClipWrite(status, wait, time.Since(start).Seconds())
+	}
+	if err != nil {
+		GetLogger().Error("Failed to write audio clip",
+			"component", "analysis.processor.clipwriter",
+			"detection_id", job.correlationID,
+			"clip_name", job.clipName,
+			"error", err,
+			"queue_wait_ms", int64(wait*1000),
+			"operation", "clip_writer_write")
+	}
+}
+
+// updateMetrics publishes the queue depth
+func (w *clipWriter) updateMetrics(depth int) {
+	if w.metrics != nil {
+		w.metrics.UpdateClipWriterQueue(depth, w.maxSize)
+	}
+}
+
+// stats returns the clip writer counters
+func (w *clipWriter) stats() ClipWriterStats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return ClipWriterStats{Queued: len(w.jobs), Written: w.written, Failed: w.failed, Dropped: w.dropped, MaxSize: w.maxSize}
+}
+
+// backlog returns the number of clips queued or being written
+func (w *clipWriter) backlog() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.jobs) + w.writing
+}
+
+// ClipWriterStats returns the clip writer counters
+func (p *Processor) ClipWriterStats() ClipWriterStats {
+	if p.clipWriter == nil {
+		return ClipWriterStats{}
+	}
+	return p.clipWriter.stats()
+}
+
+// stop writes the queued clips and stops the writer, giving up after the timeout
+func (w *clipWriter) stop(timeout time.Duration) error {
+	w.mu.Lock()
+	w.stopped = true
+	pending := len(w.jobs)
+	w.mu.Unlock()
+
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+
+	select {
+	case <-w.done:
+		return nil
+	case <-time.After(timeout):
+		return errors.Newf("clip writer did not finish %d queued clips within %v", pending, timeout).
+			Component("analysis.processor").
+			Category(errors.CategoryFileIO).
+			Context("operation", "clip_writer_stop").
+			Build()
+	}
+}
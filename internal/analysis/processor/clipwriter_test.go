@@ -0,0 +1,177 @@
+package processor
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+// recordingClipMetrics records clip writer metrics for assertions
+type recordingClipMetrics struct {
+	mu       sync.Mutex
+	maxDepth int
+	statuses []string
+	dropped  int
+}
+
+func (m *recordingClipMetrics) UpdateClipWriterQueue(depth, _ int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.maxDepth = max(m.maxDepth, depth)
+}
+
+func (m *recordingClipMetrics) RecordClipWrite(status string, _, _ float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.statuses = append(m.statuses, status)
+}
+
+func (m *recordingClipMetrics) RecordClipDropped() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.dropped++
+}
+
+// blockedClipWriter returns a clip writer whose first write blocks until
+// release is closed, so that later clips stay queued
+func blockedClipWriter(t *testing.T, settings conf.ClipWriterSettings, metrics clipWriterMetrics) (w *clipWriter, release chan struct{}) {
+	t.Helper()
+	w = newClipWriter(&settings, metrics)
+	release = make(chan struct{})
+	started := make(chan struct{})
+	w.enqueue(&clipWriteJob{clipName: "blocking.wav", write: func() error {
+		close(started)
+		<-release
+		return nil
+	}})
+	<-started
+	return w, release
+}
+
+func TestClipWriterDropOldest(t *testing.T) {
+	t.Parallel()
+
+	metrics := &recordingClipMetrics{}
+	w, release := blockedClipWriter(t, conf.ClipWriterSettings{QueueSize: 2, DropPolicy: "oldest"}, metrics)
+
+	var mu sync.Mutex
+	var written []string
+	for _, name := range []string{"a.wav", "b.wav", "c.wav"} {
+		w.enqueue(&clipWriteJob{clipName: name, write: func() error {
+			mu.Lock()
+			defer mu.Unlock()
+			written = append(written, name)
+			return nil
+		}})
+	}
+	assert.Equal(t, ClipWriterStats{Queued: 2, Written: 0, Dropped: 1, MaxSize: 2}, w.stats())
+
+	close(release)
+	require.NoError(t, w.stop(5*time.Second))
+	assert.Equal(t, []string{"b.wav", "c.wav"}, written, "the oldest queued clip is dropped")
+	assert.Equal(t, ClipWriterStats{Written: 3, Dropped: 1, MaxSize: 2}, w.stats())
+	assert.Equal(t, 2, metrics.maxDepth)
+	assert.Equal(t, 1, metrics.dropped)
+	assert.Equal(t, []string{"written", "written", "written"}, metrics.statuses)
+}
+
+func TestClipWriterDropNewest(t *testing.T) {
+	t.Parallel()
+
+	w, release := blockedClipWriter(t, conf.ClipWriterSettings{QueueSize: 1, DropPolicy: "newest"}, nil)
+
+	var written []string
+	for _, name := range []string{"a.wav", "b.wav"} {
+		w.enqueue(&clipWriteJob{clipName: name, write: func() error {
+			written = append(written, name)
+			return nil
+		}})
+	}
+
+	close(release)
+	require.NoError(t, w.stop(5*time.Second))
+	assert.Equal(t, []string{"a.wav"}, written, "the new clip is dropped")
+	assert.Equal(t, 1, w.stats().Dropped)
+}
+
+func TestClipWriterFailuresAndStop(t *testing.T) {
+	t.Parallel()
+
+	metrics := &recordingClipMetrics{}
+	w := newClipWriter(&conf.ClipWriterSettings{}, metrics)
+	assert.Equal(t, defaultClipQueueSize, w.stats().MaxSize)
+
+	w.enqueue(&clipWriteJob{clipName: "broken.wav", write: func() error { return errors.New("disk full") }})
+	require.NoError(t, w.stop(5*time.Second))
+
+	// Clips arriving after shutdown are written right away
+	wrote := false
+	w.enqueue(&clipWriteJob{clipName: "late.wav", write: func() error {
+		wrote = true
+		return nil
+	}})
+	assert.True(t, wrote)
+	assert.Equal(t, ClipWriterStats{Written: 1, Failed: 1, MaxSize: defaultClipQueueSize}, w.stats())
+	assert.Equal(t, []string{"failed", "written"}, metrics.statuses)
+}
+
+func TestClipWriterStopTimeout(t *testing.T) {
+	t.Parallel()
+
+	w, release := blockedClipWriter(t, conf.ClipWriterSettings{}, nil)
+	defer close(release)
+	assert.Error(t, w.stop(10*time.Millisecond))
+}
+
+func TestClipWriterStopTimeoutFitsShutdownDeadline(t *testing.T) {
+	t.Parallel()
+
+	settings := &conf.Settings{}
+	assert.Less(t, clipWriterStopTimeout(settings), defaultShutdownTimeout)
+	assert.Less(t, clipWriterStopTimeout(nil), defaultShutdownTimeout)
+
+	settings.Realtime.Shutdown.Timeout = 30
+	assert.Equal(t, 12*time.Second, clipWriterStopTimeout(settings))
+}
+
+// clipPathStore records the notes whose clip name was cleared
+type clipPathStore struct {
+	*MockDatastore
+	mu      sync.Mutex
+	cleared []string
+}
+
+func (s *clipPathStore) DeleteNoteClipPath(noteID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cleared = append(s.cleared, noteID)
+	return nil
+}
+
+func TestClipWriterFullQueueClearsClipName(t *testing.T) {
+	t.Parallel()
+
+	w, release := blockedClipWriter(t, conf.ClipWriterSettings{QueueSize: 1, DropPolicy: "oldest"}, nil)
+	store := &clipPathStore{MockDatastore: &MockDatastore{}}
+	p := &Processor{clipWriter: w}
+
+	var written []uint
+	for _, id := range []uint{42, 43} {
+		action := &DatabaseAction{Ds: store, Note: datastore.Note{ID: id, ClipName: "clip.wav"}, processor: p}
+		require.NoError(t, action.writeClip(func() error {
+			written = append(written, id)
+			return nil
+		}))
+	}
+
+	close(release)
+	require.NoError(t, w.stop(5*time.Second))
+	assert.Equal(t, []uint{43}, written)
+	assert.Equal(t, []string{"42"}, store.cleared, "the note of the dropped clip no longer references it")
+}
@@ -0,0 +1,109 @@
+// context_check.go: cross-checks new species detections against the audio windows around them
+package processor
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/myaudio"
+)
+
+// contextWindowSeconds is the length of the analyzed windows before and after
+// a detection, the length of a BirdNET analysis window
+const contextWindowSeconds = 3
+
+// contextPredictor is the part of a BirdNET instance used to re-analyze the
+// windows around a detection
+type contextPredictor interface {
+	PredictWithInput(ctx context.Context, sampleLength int, fill func(input []float32) error) ([]datastore.Results, error)
+	EnrichResultWithTaxonomy(speciesLabel string) (scientific, common, code string)
+}
+
+// contextCheck re-analyzes the windows before and after a detection to
+// corroborate it, cutting one-off misfires of new species
+type contextCheck struct {
+	settings   *conf.ContextCheckSettings
+	predictor  contextPredictor
+	readWindow func(sourceID string, start time.Time, duration int) ([]byte, error)
+}
+
+// contextCheckResult is the outcome of cross-checking a detection
+type contextCheckResult struct {
+	corroborated bool
+	skipped      bool      // true when the detection was confident enough not to be cross-checked
+	confidences  []float64 // confidence of the species in the windows before and after
+}
+
+// newContextCheck returns the context check of new species detections, nil
+// when disabled or without a model to re-analyze with
+func (p *Processor) newContextCheck() *contextCheck {
+	settings := &p.Settings.Realtime.SpeciesTracking.ContextCheck
+	bn := p.GetBirdNET()
+	if !settings.Enabled || bn == nil {
+		return nil
+	}
+	return &contextCheck{
+		settings:   settings,
+		predictor:  bn,
+		readWindow: myaudio.ReadSegmentFromCaptureBuffer,
+	}
+}
+
+// check cross-checks a detection against the windows right before and after
+// it. A detection at or above the skip confidence is corroborated without a
+// check. Windows that cannot be read or analyzed, such as past the ends of the
+// capture buffer, count as corroborating so a failed check does not hold back
+// notifications.
+func (c *contextCheck) check(ctx context.Context, note *datastore.Note) contextCheckResult {
+	if note.Confidence >= c.settings.SkipConfidence {
+		return contextCheckResult{corroborated: true, skipped: true}
+	}
+
+	window := contextWindowSeconds * time.Second
+	starts := []time.Time{note.BeginTime.Add(-window), note.BeginTime.Add(window)}
+	result := contextCheckResult{confidences: make([]float64, 0, len(starts))}
+	corroborating := 0
+	for _, start := range starts {
+		confidence, err := c.windowConfidence(ctx, note, start)
+		if err != nil {
+			GetLogger().Debug("Failed to analyze context window, counting it as corroborating",
+				"species", note.CommonName,
+				"source", note.Source.ID,
+				"window_start", start,
+				"error", err,
+				"operation", "context_check")
+			corroborating++
+			continue
+		}
+		result.confidences = append(result.confidences, confidence)
+		if confidence >= c.settings.MinConfidence {
+			corroborating++
+		}
+	}
+	result.corroborated = corroborating >= c.settings.MinWindows
+	return result
+}
+
+// windowConfidence returns the confidence of the species of a note in the
+// window starting at start, 0 when it is not among the results
+func (c *contextCheck) windowConfidence(ctx context.Context, note *datastore.Note, start time.Time) (float64, error) {
+	pcm, err := c.readWindow(note.Source.ID, start, contextWindowSeconds)
+	if err != nil {
+		return 0, err
+	}
+	results, err := c.predictor.PredictWithInput(ctx, myaudio.PCMSampleCount(pcm, conf.BitDepth), myaudio.PCMInput(pcm, conf.BitDepth))
+	if err != nil {
+		return 0, err
+	}
+
+	var confidence float64
+	for _, result := range results {
+		if scientific, _, _ := c.predictor.EnrichResultWithTaxonomy(result.Species); scientific == note.ScientificName {
+			confidence = math.Max(confidence, float64(result.Confidence))
+		}
+	}
+	return math.Round(confidence*100) / 100, nil
+}
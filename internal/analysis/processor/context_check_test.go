@@ -0,0 +1,106 @@
+// context_check_test.go: Unit tests for the new species context check
+package processor
+
+import (
+	"context"
+	stderrors "errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+// windowPredictor returns the results of a window by its first sample, which
+// the test window reader sets to the window index
+type windowPredictor struct {
+	results map[int][]datastore.Results
+}
+
+func (p *windowPredictor) PredictWithInput(_ context.Context, sampleLength int, fill func(input []float32) error) ([]datastore.Results, error) {
+	input := make([]float32, sampleLength)
+	if err := fill(input); err != nil {
+		return nil, err
+	}
+	return p.results[int(input[0]*32768+0.5)], nil
+}
+
+func (p *windowPredictor) EnrichResultWithTaxonomy(label string) (scientific, common, code string) {
+	scientific, common, _ = strings.Cut(label, "_")
+	return scientific, common, "code"
+}
+
+// testContextCheck returns a context check reading windows before and after
+// begin as windows 1 and 2, failing to read the windows in missing
+func testContextCheck(settings *conf.ContextCheckSettings, begin time.Time, results map[int][]datastore.Results, missing ...int) *contextCheck {
+	return &contextCheck{
+		settings:  settings,
+		predictor: &windowPredictor{results: results},
+		readWindow: func(sourceID string, start time.Time, duration int) ([]byte, error) {
+			index := 1
+			if start.After(begin) {
+				index = 2
+			}
+			for _, m := range missing {
+				if m == index {
+					return nil, stderrors.New("outside the buffer")
+				}
+			}
+			pcm := make([]byte, duration*conf.SampleRate*conf.BitDepth/8)
+			pcm[0] = byte(index)
+			return pcm, nil
+		},
+	}
+}
+
+func TestContextCheck(t *testing.T) {
+	t.Parallel()
+
+	begin := time.Date(2024, 5, 1, 6, 0, 0, 0, time.UTC)
+	note := &datastore.Note{ScientificName: "Jynx torquilla", CommonName: "Eurasian Wryneck", Confidence: 0.75, BeginTime: begin}
+	wryneck := func(confidence float32) []datastore.Results {
+		return []datastore.Results{{Species: "Parus major_Great Tit", Confidence: 0.9}, {Species: "Jynx torquilla_Eurasian Wryneck", Confidence: confidence}}
+	}
+	settings := conf.ContextCheckSettings{Enabled: true, MinConfidence: 0.3, MinWindows: 1, SkipConfidence: 0.9}
+
+	tests := []struct {
+		name         string
+		minWindows   int
+		results      map[int][]datastore.Results
+		missing      []int
+		corroborated bool
+		confidences  []float64
+	}{
+		{"corroborated after", 1, map[int][]datastore.Results{2: wryneck(0.42)}, nil, true, []float64{0, 0.42}},
+		{"one-off misfire", 1, map[int][]datastore.Results{1: wryneck(0.1), 2: wryneck(0.2)}, nil, false, []float64{0.1, 0.2}},
+		{"both windows required", 2, map[int][]datastore.Results{1: wryneck(0.5)}, nil, false, []float64{0.5, 0}},
+		{"both windows corroborate", 2, map[int][]datastore.Results{1: wryneck(0.5), 2: wryneck(0.3)}, nil, true, []float64{0.5, 0.3}},
+		{"unreadable window counts as corroborating", 1, nil, []int{2}, true, []float64{0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			s := settings
+			s.MinWindows = tt.minWindows
+			result := testContextCheck(&s, begin, tt.results, tt.missing...).check(context.Background(), note)
+			assert.Equal(t, tt.corroborated, result.corroborated)
+			assert.False(t, result.skipped)
+			assert.Equal(t, tt.confidences, result.confidences)
+		})
+	}
+}
+
+func TestContextCheckSkipsConfidentDetections(t *testing.T) {
+	t.Parallel()
+
+	begin := time.Date(2024, 5, 1, 6, 0, 0, 0, time.UTC)
+	settings := conf.ContextCheckSettings{Enabled: true, MinConfidence: 0.3, MinWindows: 2, SkipConfidence: 0.9}
+	note := &datastore.Note{ScientificName: "Jynx torquilla", Confidence: 0.93, BeginTime: begin}
+
+	result := testContextCheck(&settings, begin, nil).check(context.Background(), note)
+	assert.True(t, result.corroborated)
+	assert.True(t, result.skipped)
+	assert.Empty(t, result.confidences)
+}
@@ -0,0 +1,156 @@
+// delivery_queue.go: keeps BirdWeather uploads and MQTT messages failing all job queue attempts in the delivery queue
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/errors"
+	"github.com/tphakala/birdnet-go/internal/notification"
+)
+
+// birdWeatherDelivery is the payload of a queued BirdWeather upload
+type birdWeatherDelivery struct {
+	Note datastore.Note `json:"note"`
+	PCM  []byte         `json:"pcm"`
+}
+
+// mqttDelivery is the payload of a queued MQTT message
+type mqttDelivery struct {
+	Topic   string `json:"topic"`
+	Message string `json:"message"`
+}
+
+// OnFailure keeps an upload that failed all its attempts in the delivery
+// queue, unless the failure is permanent
+func (a *BirdWeatherAction) OnFailure(data any, err error) {
+	queue := notification.GetDeliveryQueue()
+	if queue == nil || !isRetryableError(err) {
+		return
+	}
+
+	a.mu.Lock()
+	payload, encodeErr := json.Marshal(birdWeatherDelivery{Note: a.Note, PCM: a.pcmData})
+	description := deliveryDescription(&a.Note)
+	a.mu.Unlock()
+
+	queueDelivery(queue, notification.DeliveryTargetBirdWeather, description, payload, encodeErr, err)
+}
+
+// OnFailure keeps a message that failed all its attempts in the delivery
+// queue, unless the failure is permanent
+func (a *MqttAction) OnFailure(data any, err error) {
+	queue := notification.GetDeliveryQueue()
+	if queue == nil || !isRetryableError(err) {
+		return
+	}
+
+	a.mu.Lock()
+	message, encodeErr := a.message()
+	description := deliveryDescription(&a.Note)
+	a.mu.Unlock()
+
+	var payload []byte
+	if encodeErr == nil {
+		payload, encodeErr = json.Marshal(mqttDelivery{Topic: a.Settings.Realtime.MQTT.Topic, Message: string(message)})
+	}
+	queueDelivery(queue, notification.DeliveryTargetMQTT, description, payload, encodeErr, err)
+}
+
+// queueDelivery adds a failed delivery to the delivery queue, logging why
+// it could not be queued
+func queueDelivery(queue *notification.DeliveryQueue, target, description string, payload []byte, encodeErr, cause error) {
+	if encodeErr == nil {
+		_, encodeErr = queue.Enqueue(target, description, payload, cause)
+	}
+	if encodeErr != nil {
+		GetLogger().Warn("Failed to queue failed delivery for retry",
+			"target", target,
+			"description", description,
+			"error", encodeErr,
+			"operation", "queue_failed_delivery")
+	}
+}
+
+// deliveryDescription describes the delivery of a note in the delivery queue
+func deliveryDescription(note *datastore.Note) string {
+	return fmt.Sprintf("%s at %s %s", note.CommonName, note.Date, note.Time)
+}
+
+// isRetryableError reports whether an action error may succeed when retried;
+// errors not marked as permanent are
+func isRetryableError(err error) bool {
+	var enhancedErr *errors.EnhancedError
+	if errors.As(err, &enhancedErr) {
+		if retryable, ok := enhancedErr.GetContext()["retryable"].(bool); ok {
+			return retryable
+		}
+	}
+	return true
+}
+
+// registerDeliveryHandlers lets the delivery queue send queued BirdWeather
+// uploads and MQTT messages with the current clients
+func (p *Processor) registerDeliveryHandlers() {
+	queue := notification.GetDeliveryQueue()
+	if queue == nil {
+		return
+	}
+	queue.Register(notification.DeliveryTargetBirdWeather, p.redeliverBirdWeather)
+	queue.Register(notification.DeliveryTargetMQTT, p.redeliverMQTT)
+}
+
+// redeliverBirdWeather uploads a queued BirdWeather upload
+func (p *Processor) redeliverBirdWeather(ctx context.Context, payload []byte) error {
+	if !outboundAllowed() {
+		return notification.ErrDeliveryDeferred
+	}
+	client := p.GetBwClient()
+	if client == nil {
+		return errors.Newf("BirdWeather client is not initialized").
+			Component("analysis.processor").
+			Category(errors.CategoryIntegration).
+			Context("operation", "birdweather_redelivery").
+			Context("integration", "birdweather").
+			Build()
+	}
+
+	var delivery birdWeatherDelivery
+	if err := json.Unmarshal(payload, &delivery); err != nil {
+		return decodeDeliveryError(err, "birdweather")
+	}
+	return client.Publish(&delivery.Note, delivery.PCM)
+}
+
+// redeliverMQTT publishes a queued MQTT message
+func (p *Processor) redeliverMQTT(ctx context.Context, payload []byte) error {
+	client := p.GetMQTTClient()
+	if client == nil || !client.IsConnected() {
+		return errors.Newf("MQTT client not connected").
+			Component("analysis.processor").
+			Category(errors.CategoryMQTTConnection).
+			Context("operation", "mqtt_redelivery").
+			Context("integration", "mqtt").
+			Build()
+	}
+
+	var delivery mqttDelivery
+	if err := json.Unmarshal(payload, &delivery); err != nil {
+		return decodeDeliveryError(err, "mqtt")
+	}
+	ctx, cancel := context.WithTimeout(ctx, MQTTPublishTimeout)
+	defer cancel()
+	return client.Publish(ctx, delivery.Topic, delivery.Message)
+}
+
+// decodeDeliveryError wraps an error decoding the payload of a queued delivery
+func decodeDeliveryError(err error, integration string) error {
+	return errors.New(err).
+		Component("analysis.processor").
+		Category(errors.CategorySystem).
+		Context("operation", "decode_queued_delivery").
+		Context("integration", integration).
+		Build()
+}
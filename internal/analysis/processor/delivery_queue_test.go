@@ -0,0 +1,70 @@
+package processor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/errors"
+	"github.com/tphakala/birdnet-go/internal/notification"
+	"github.com/tphakala/birdnet-go/internal/notification/history"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// TestMqttAction_FailedMessageRedelivered verifies an MQTT message failing
+// all job queue attempts is queued and published again by the processor.
+// Not parallel: the delivery queue is process-wide state.
+func TestMqttAction_FailedMessageRedelivered(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&datastore.DeliveryRecord{}))
+	settings := conf.DeliveryQueueSettings{Enabled: true, MaxAttempts: 3, InitialDelay: time.Minute, MaxDelay: time.Hour, MaxPending: 10}
+	queue := notification.NewDeliveryQueue(history.NewDeliveryStore(&datastore.DataStore{DB: db}), &settings, false)
+	notification.SetDeliveryQueue(queue)
+	t.Cleanup(func() { notification.SetDeliveryQueue(nil) })
+
+	client := &MockMqttClientWithCapture{}
+	action := &MqttAction{
+		Settings:   &conf.Settings{Realtime: conf.RealtimeSettings{MQTT: conf.MQTTSettings{Enabled: true, Topic: "birdnet/detections"}}},
+		MqttClient: client,
+		Note: datastore.Note{
+			CommonName:     "Eurasian Wryneck",
+			ScientificName: "Jynx torquilla",
+			Date:           "2024-05-01",
+			Time:           "06:00:00",
+			Source:         testAudioSource(),
+		},
+	}
+
+	// Permanent failures are not queued
+	action.OnFailure(nil, errors.Newf("MQTT topic is not specified").Context("retryable", false).Build())
+	_, total, err := queue.List(nil)
+	require.NoError(t, err)
+	assert.Zero(t, total)
+
+	action.OnFailure(nil, errors.Newf("MQTT client not connected").Context("retryable", true).Build())
+	deliveries, total, err := queue.List(nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, total)
+	assert.Equal(t, notification.DeliveryTargetMQTT, deliveries[0].Target)
+	assert.Equal(t, "Eurasian Wryneck at 2024-05-01 06:00:00", deliveries[0].Description)
+
+	p := &Processor{Settings: &conf.Settings{}}
+	p.SetMQTTClient(client)
+	p.registerDeliveryHandlers()
+
+	// Not sent while disconnected, then published once the broker is back
+	now := deliveries[0].NextAttemptAt
+	assert.Zero(t, queue.ProcessDue(context.Background(), now))
+	client.Connected = true
+	d, err := queue.Get(deliveries[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, queue.ProcessDue(context.Background(), d.NextAttemptAt))
+	assert.Equal(t, "birdnet/detections", client.PublishedTopic)
+	assert.Contains(t, client.PublishedData, `"Jynx torquilla"`)
+}
@@ -0,0 +1,151 @@
+// drain.go: flushes held detections, queued jobs and clips during graceful shutdown
+package processor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/birdnet"
+)
+
+// drainPollInterval is how often the job queue and clip writer are checked while draining
+const drainPollInterval = 100 * time.Millisecond
+
+// DrainReport describes the outcome of draining the processor during shutdown
+type DrainReport struct {
+	QueuedResults       int           // analysis results consumed from the results queue during drain
+	FlushedDetections   int           // held detections approved and handed to the job queue
+	DiscardedDetections int           // held detections discarded because they lacked confirmations
+	CompletedJobs       int           // jobs that finished while draining
+	AbandonedJobs       int           // jobs still queued or running when the deadline was reached
+	AbandonedActions    []string      // descriptions of abandoned jobs that never started, for reporting
+	QueuedClips         int           // clips queued or being written when the job queue was drained
+	AbandonedClips      int           // clips still queued or being written when the deadline was reached
+	Duration            time.Duration // time spent draining
+	DeadlineExceeded    bool          // true if ctx expired before the queue emptied
+}
+
+// String returns a short human readable summary
+func (r *DrainReport) String() string {
+	return fmt.Sprintf("flushed %d detections (%d discarded, %d queued results), completed %d jobs, abandoned %d jobs and %d of %d clips in %v",
+		r.FlushedDetections, r.DiscardedDetections, r.QueuedResults, r.CompletedJobs, r.AbandonedJobs,
+		r.AbandonedClips, r.QueuedClips, r.Duration.Round(time.Millisecond))
+}
+
+// Drain flushes all pending work before shutdown. Results still waiting in the
+// results queue are consumed, held detections are flushed immediately instead of
+// waiting for their deadline, and the job queue is given until ctx expires to
+// finish database writes and uploads, then the clip writer to write the clips of
+// the saved detections. Anything left over is reported as abandoned.
+//
+// Drain must be called after audio capture and analysis have stopped so that no
+// new results arrive while draining.
+func (p *Processor) Drain(ctx context.Context) *DrainReport {
+	start := time.Now()
+	report := &DrainReport{}
+
+	report.QueuedResults = p.drainResultsQueue()
+	report.FlushedDetections, report.DiscardedDetections = p.flushAllPendingDetections()
+
+	initial := p.JobQueue.GetStats()
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+
+wait:
+	for p.JobQueue.GetStats().PendingJobs > 0 {
+		select {
+		case <-ctx.Done():
+			report.DeadlineExceeded = true
+			break wait
+		case <-ticker.C:
+		}
+	}
+
+	// Clips are queued by the database writes, wait for them once the jobs are done
+	if p.clipWriter != nil {
+		report.QueuedClips = p.clipWriter.backlog()
+	clips:
+		for !report.DeadlineExceeded && p.clipWriter.backlog() > 0 {
+			select {
+			case <-ctx.Done():
+				report.DeadlineExceeded = true
+				break clips
+			case <-ticker.C:
+			}
+		}
+		report.AbandonedClips = p.clipWriter.backlog()
+	}
+
+	final := p.JobQueue.GetStats()
+	report.CompletedJobs = (final.SuccessfulJobs + final.FailedJobs) - (initial.SuccessfulJobs + initial.FailedJobs)
+	report.AbandonedJobs = final.PendingJobs
+	for _, job := range p.JobQueue.GetPendingJobs() {
+		if job.Action != nil {
+			report.AbandonedActions = append(report.AbandonedActions, sanitizeString(job.Action.GetDescription()))
+		}
+	}
+	report.Duration = time.Since(start)
+
+	GetLogger().Info("Processor drain completed",
+		"queued_results", report.QueuedResults,
+		"flushed_detections", report.FlushedDetections,
+		"discarded_detections", report.DiscardedDetections,
+		"completed_jobs", report.CompletedJobs,
+		"abandoned_jobs", report.AbandonedJobs,
+		"queued_clips", report.QueuedClips,
+		"abandoned_clips", report.AbandonedClips,
+		"deadline_exceeded", report.DeadlineExceeded,
+		"duration_ms", report.Duration.Milliseconds(),
+		"operation", "processor_drain")
+
+	return report
+}
+
+// drainResultsQueue processes any analysis results still buffered in the results
+// queue without blocking, returning the number of results consumed.
+func (p *Processor) drainResultsQueue() int {
+	count := 0
+	for {
+		select {
+		case item, ok := <-birdnet.ResultsQueue:
+			if !ok {
+				return count
+			}
+			p.processDetections(item)
+			count++
+		default:
+			return count
+		}
+	}
+}
+
+// flushAllPendingDetections processes every held detection regardless of its
+// flush deadline. Detections that do not meet the confirmation requirement are
+// discarded as they would have been by the regular flusher.
+func (p *Processor) flushAllPendingDetections() (flushed, discarded int) {
+	minDetections := p.calculateMinDetections()
+
+	p.pendingMutex.Lock()
+	defer p.pendingMutex.Unlock()
+
+	for species := range p.pendingDetections {
+		item := p.pendingDetections[species]
+		delete(p.pendingDetections, species)
+
+		if shouldDiscard, reason := p.shouldDiscardDetection(&item, minDetections); shouldDiscard {
+			GetLogger().Info("Discarding held detection during drain",
+				"species", species,
+				"reason", reason,
+				"count", item.Count,
+				"operation", "drain_discard_detection")
+			discarded++
+			continue
+		}
+
+		p.processApprovedDetection(&item, species)
+		flushed++
+	}
+
+	return flushed, discarded
+}
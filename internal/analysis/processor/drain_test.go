@@ -0,0 +1,122 @@
+package processor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/analysis/jobqueue"
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+// newDrainTestProcessor creates a processor with a running job queue for drain tests
+func newDrainTestProcessor(t *testing.T) *Processor {
+	t.Helper()
+
+	queue := jobqueue.NewJobQueue()
+	queue.SetProcessingInterval(10 * time.Millisecond)
+	queue.Start()
+	t.Cleanup(func() {
+		if err := queue.Stop(); err != nil {
+			t.Errorf("Failed to stop queue: %v", err)
+		}
+	})
+
+	return &Processor{
+		JobQueue:          queue,
+		Settings:          &conf.Settings{},
+		pendingDetections: make(map[string]PendingDetection),
+	}
+}
+
+// TestDrain_CompletesQueuedJobs verifies that drain waits for queued jobs to finish
+func TestDrain_CompletesQueuedJobs(t *testing.T) {
+	p := newDrainTestProcessor(t)
+
+	action := &SimpleAction{name: "Drain Test Action", executeDelay: 50 * time.Millisecond}
+	require.NoError(t, p.EnqueueTask(&Task{Type: TaskTypeAction, Detection: createSimpleDetection(), Action: action}))
+
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+
+	report := p.Drain(ctx)
+	assert.False(t, report.DeadlineExceeded)
+	assert.Zero(t, report.AbandonedJobs)
+	assert.Equal(t, 1, report.CompletedJobs)
+
+	action.executeMutex.Lock()
+	defer action.executeMutex.Unlock()
+	assert.True(t, action.executed, "queued action should run before drain returns")
+}
+
+// TestDrain_ReportsAbandonedJobs verifies that jobs still running at the deadline are reported
+func TestDrain_ReportsAbandonedJobs(t *testing.T) {
+	p := newDrainTestProcessor(t)
+
+	action := &SimpleAction{name: "Slow Drain Action", executeDelay: 2 * time.Second}
+	require.NoError(t, p.EnqueueTask(&Task{Type: TaskTypeAction, Detection: createSimpleDetection(), Action: action}))
+
+	ctx, cancel := context.WithTimeout(t.Context(), 100*time.Millisecond)
+	defer cancel()
+
+	report := p.Drain(ctx)
+	assert.True(t, report.DeadlineExceeded)
+	assert.Equal(t, 1, report.AbandonedJobs)
+	assert.Contains(t, report.String(), "abandoned 1 jobs")
+}
+
+// TestDrain_NoPendingWork verifies that drain returns immediately with nothing to do
+func TestDrain_NoPendingWork(t *testing.T) {
+	p := newDrainTestProcessor(t)
+
+	report := p.Drain(t.Context())
+	assert.False(t, report.DeadlineExceeded)
+	assert.Zero(t, report.FlushedDetections)
+	assert.Zero(t, report.DiscardedDetections)
+	assert.Zero(t, report.AbandonedJobs)
+}
+
+// TestDrain_WaitsForQueuedClips verifies that drain waits for the clip writer backlog
+func TestDrain_WaitsForQueuedClips(t *testing.T) {
+	p := newDrainTestProcessor(t)
+	w, release := blockedClipWriter(t, conf.ClipWriterSettings{}, nil)
+	t.Cleanup(func() { assert.NoError(t, w.stop(5*time.Second)) })
+	p.clipWriter = w
+	w.enqueue(&clipWriteJob{clipName: "queued.wav", write: func() error { return nil }, queued: time.Now()})
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+	}()
+
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+
+	report := p.Drain(ctx)
+	assert.False(t, report.DeadlineExceeded)
+	assert.Equal(t, 2, report.QueuedClips)
+	assert.Zero(t, report.AbandonedClips)
+	assert.Equal(t, 2, w.stats().Written, "queued clips should be written before drain returns")
+}
+
+// TestDrain_ReportsAbandonedClips verifies that clips still queued at the deadline are reported
+func TestDrain_ReportsAbandonedClips(t *testing.T) {
+	p := newDrainTestProcessor(t)
+	w, release := blockedClipWriter(t, conf.ClipWriterSettings{}, nil)
+	t.Cleanup(func() {
+		close(release)
+		assert.NoError(t, w.stop(5*time.Second))
+	})
+	p.clipWriter = w
+	w.enqueue(&clipWriteJob{clipName: "queued.wav", write: func() error { return nil }, queued: time.Now()})
+
+	ctx, cancel := context.WithTimeout(t.Context(), 100*time.Millisecond)
+	defer cancel()
+
+	report := p.Drain(ctx)
+	assert.True(t, report.DeadlineExceeded)
+	assert.Equal(t, 2, report.AbandonedClips)
+	assert.Contains(t, report.String(), "abandoned 0 jobs and 2 of 2 clips")
+}
@@ -0,0 +1,101 @@
+// offline.go: holds outbound tasks while offline mode or the data budget blocks uploads
+package processor
+
+import (
+	"sync"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/databudget"
+)
+
+// maxOfflineQueueSize bounds the number of outbound tasks held while offline.
+// BirdWeather uploads carry their audio clip, so the oldest tasks are dropped
+// once the limit is reached to keep memory use predictable.
+const maxOfflineQueueSize = 200
+
+// offlineQueue holds outbound tasks deferred during offline mode or while the
+// data budget defers uploads
+type offlineQueue struct {
+	mu      sync.Mutex
+	tasks   []*Task
+	dropped int
+}
+
+// OfflineQueueStats describes the outbound tasks held back by offline mode
+type OfflineQueueStats struct {
+	Queued  int `json:"queued"`
+	Dropped int `json:"dropped"`
+	MaxSize int `json:"max_size"`
+}
+
+// isOutboundAction reports whether an action needs internet access and can be
+// deferred until offline mode is turned off
+func isOutboundAction(action Action) bool {
+	_, ok := action.(*BirdWeatherAction)
+	return ok
+}
+
+// outboundAllowed reports whether outbound tasks may be sent now
+func outboundAllowed() bool {
+	return !conf.IsOffline() && databudget.Allow(databudget.Uploads)
+}
+
+// deferOfflineTask queues an outbound task, dropping the oldest one when full
+func (p *Processor) deferOfflineTask(task *Task) {
+	q := &p.offlineTasks
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.tasks) >= maxOfflineQueueSize {
+		q.tasks[0] = nil
+		q.tasks = q.tasks[1:]
+		q.dropped++
+	}
+	q.tasks = append(q.tasks, task)
+
+	GetLogger().Debug("Deferred outbound task in offline mode",
+		"task_description", sanitizeString(task.Action.GetDescription()),
+		"queued", len(q.tasks),
+		"component", "analysis.processor",
+		"operation", "offline_defer_task")
+}
+
+// OfflineQueueStats returns the current offline queue counters
+func (p *Processor) OfflineQueueStats() OfflineQueueStats {
+	q := &p.offlineTasks
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return OfflineQueueStats{Queued: len(q.tasks), Dropped: q.dropped, MaxSize: maxOfflineQueueSize}
+}
+
+// FlushOfflineQueue submits deferred outbound tasks to the job queue. It does
+// nothing while offline mode is active or the data budget still defers uploads.
+// Returns the number of tasks submitted and the number that could not be enqueued.
+func (p *Processor) FlushOfflineQueue() (flushed, failed int) {
+	if !outboundAllowed() {
+		return 0, 0
+	}
+
+	q := &p.offlineTasks
+	q.mu.Lock()
+	tasks := q.tasks
+	q.tasks = nil
+	q.mu.Unlock()
+
+	for _, task := range tasks {
+		if err := p.EnqueueTask(task); err != nil {
+			failed++
+			continue
+		}
+		flushed++
+	}
+
+	if len(tasks) > 0 {
+		GetLogger().Info("Flushed outbound tasks deferred during offline mode",
+			"flushed", flushed,
+			"failed", failed,
+			"component", "analysis.processor",
+			"operation", "offline_flush")
+	}
+	return flushed, failed
+}
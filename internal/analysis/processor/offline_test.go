@@ -0,0 +1,69 @@
+package processor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+// newOfflineBirdWeatherTask returns a BirdWeather task that is a no-op when executed
+func newOfflineBirdWeatherTask() *Task {
+	return &Task{
+		Type:      TaskTypeAction,
+		Detection: createSimpleDetection(),
+		Action: &BirdWeatherAction{
+			Settings:     &conf.Settings{},
+			EventTracker: NewEventTracker(time.Minute),
+			Description:  "Offline BirdWeather Test",
+		},
+	}
+}
+
+// TestOfflineMode_DefersOutboundTasks verifies outbound tasks are held while offline
+// and submitted once offline mode is turned off.
+// Not parallel: offline mode is process-wide state.
+func TestOfflineMode_DefersOutboundTasks(t *testing.T) {
+	conf.SetOffline(true)
+	t.Cleanup(func() { conf.SetOffline(false) })
+
+	p := newDrainTestProcessor(t)
+
+	require.NoError(t, p.EnqueueTask(newOfflineBirdWeatherTask()))
+	assert.Equal(t, 1, p.OfflineQueueStats().Queued)
+	assert.Empty(t, p.JobQueue.GetPendingJobs(), "outbound task should not reach the job queue")
+
+	// Local actions still run while offline
+	local := &SimpleAction{name: "Local Action"}
+	require.NoError(t, p.EnqueueTask(&Task{Type: TaskTypeAction, Detection: createSimpleDetection(), Action: local}))
+	assert.Equal(t, 1, p.OfflineQueueStats().Queued)
+
+	flushed, failed := p.FlushOfflineQueue()
+	assert.Zero(t, flushed, "flush must not run while offline")
+	assert.Zero(t, failed)
+
+	conf.SetOffline(false)
+	flushed, failed = p.FlushOfflineQueue()
+	assert.Equal(t, 1, flushed)
+	assert.Zero(t, failed)
+	assert.Zero(t, p.OfflineQueueStats().Queued)
+}
+
+// TestOfflineMode_QueueDropsOldest verifies the offline queue stays bounded
+func TestOfflineMode_QueueDropsOldest(t *testing.T) {
+	t.Parallel()
+
+	p := &Processor{Settings: &conf.Settings{}}
+	first := newOfflineBirdWeatherTask()
+	p.deferOfflineTask(first)
+	for range maxOfflineQueueSize {
+		p.deferOfflineTask(newOfflineBirdWeatherTask())
+	}
+
+	stats := p.OfflineQueueStats()
+	assert.Equal(t, maxOfflineQueueSize, stats.Queued)
+	assert.Equal(t, 1, stats.Dropped)
+	assert.NotSame(t, first, p.offlineTasks.tasks[0], "oldest task should be dropped first")
+}
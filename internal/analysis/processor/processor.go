@@ -24,6 +24,7 @@ import (
 	"github.com/tphakala/birdnet-go/internal/myaudio"
 	"github.com/tphakala/birdnet-go/internal/observability"
 	"github.com/tphakala/birdnet-go/internal/privacy"
+	"github.com/tphakala/birdnet-go/internal/shadowmodel"
 )
 
 // Species identification constants for filtering
@@ -67,14 +68,36 @@ type Processor struct {
 	// SSE related fields
 	SSEBroadcaster      func(note *datastore.Note, birdImage *imageprovider.BirdImage) error // Function to broadcast detection via SSE
 	sseBroadcasterMutex sync.RWMutex                                                         // Mutex to protect SSE broadcaster access
+	// Live detection stream, called as each detection is committed to the database
+	detectionStreamer      func(note *datastore.Note)
+	detectionStreamerMutex sync.RWMutex
 
 	// Backup system fields (optional)
 	backupManager   interface{} // Use interface{} to avoid import cycle
 	backupScheduler interface{} // Use interface{} to avoid import cycle
 	backupMutex     sync.RWMutex
 
+	// Candidate model running in shadow mode (optional)
+	shadowModel      *shadowmodel.Runner
+	shadowModelMutex sync.RWMutex
+
 	// Log deduplication (extracted to separate type for SRP)
 	logDedup *LogDeduplicator // Handles log deduplication logic
+
+	// Outbound tasks held back while offline mode is active
+	offlineTasks offlineQueue
+
+	// Writes detection clips in the background so slow storage does not hold up detections
+	clipWriter *clipWriter
+
+	// Raises the confidence threshold in wind and rain
+	weatherAdjuster weatherAdjuster
+
+	// Raises the confidence threshold of species at hours they are rarely active
+	timePriors timePriors
+
+	// Alerts of locally unusual species, nil when disabled
+	rareSpecies *rareSpecies
 }
 
 // DynamicThreshold represents the dynamic threshold configuration for a species.
@@ -204,6 +227,16 @@ func New(settings *conf.Settings, ds datastore.Interface, bn *birdnet.BirdNET, m
 		}
 	}
 
+	// Start the clip writer before detections can queue clips
+	var clipMetrics clipWriterMetrics
+	if metrics != nil && metrics.MyAudio != nil {
+		clipMetrics = metrics.MyAudio
+	}
+	p.clipWriter = newClipWriter(&settings.Realtime.Audio.Export.Writer, clipMetrics)
+
+	// Load the frequency data of rare species alerts before detections are processed
+	p.rareSpecies = newRareSpecies(settings)
+
 	// Start the detection processor
 	p.startDetectionProcessor()
 
@@ -232,6 +265,9 @@ func New(settings *conf.Settings, ds datastore.Interface, bn *birdnet.BirdNET, m
 	// Initialize MQTT client if enabled in settings
 	p.initializeMQTT(settings)
 
+	// Send the BirdWeather uploads and MQTT messages of the delivery queue
+	p.registerDeliveryHandlers()
+
 	// Start the job queue
 	p.JobQueue.Start()
 
@@ -367,6 +403,9 @@ func (p *Processor) processResults(item birdnet.Results) []Detections {
 	// Sync species tracker if needed
 	p.syncSpeciesTrackerIfNeeded()
 
+	// Threshold increase for wind and rain, the same for all results of the chunk
+	weatherAdjustment := p.weatherThresholdAdjustment(time.Now())
+
 	// Process each result in item.Results
 	for _, result := range item.Results {
 		// Parse and validate species information
@@ -394,8 +433,14 @@ func (p *Processor) processResults(item birdnet.Results) []Detections {
 		// Determine confidence threshold and check filters
 		baseThreshold := p.getBaseConfidenceThreshold(speciesLowercase)
 
+		// Time-of-day prior of the species, raising the threshold at hours it is rarely active
+		prior := p.timePriorFor(scientificName, speciesLowercase, item.StartTime)
+
 		// Check if detection should be filtered
-		shouldSkip, _ := p.shouldFilterDetection(result, commonName, speciesLowercase, baseThreshold, item.Source.ID)
+		shouldSkip, threshold, priorRejected := p.shouldFilterDetection(result, commonName, speciesLowercase, baseThreshold, weatherAdjustment, prior.adjustment, item.Source.ID)
+		if priorRejected {
+			p.auditTimePriorRejection(result, scientificName, commonName, item.Source.ID, item.StartTime, prior, threshold)
+		}
 		if shouldSkip {
 			continue
 		}
@@ -407,6 +452,7 @@ func (p *Processor) processResults(item birdnet.Results) []Detections {
 
 		// Create the detection
 		detection := p.createDetection(item, result, scientificName, commonName, speciesCode)
+		detection.Note.WeatherAdjustment = float64(weatherAdjustment)
 		detections = append(detections, detection)
 	}
 
@@ -453,11 +499,13 @@ func (p *Processor) parseAndValidateSpecies(result datastore.Results, item birdn
 	return
 }
 
-// shouldFilterDetection checks if a detection should be filtered out
-func (p *Processor) shouldFilterDetection(result datastore.Results, commonName, speciesLowercase string, baseThreshold float32, source string) (shouldFilter bool, confidenceThreshold float32) {
+// shouldFilterDetection checks if a detection should be filtered out. It
+// reports a detection filtered only because of the time-of-day prior
+// adjustment as prior rejected, along with the threshold without the prior.
+func (p *Processor) shouldFilterDetection(result datastore.Results, commonName, speciesLowercase string, baseThreshold, weatherAdjustment, priorAdjustment float32, source string) (shouldFilter bool, confidenceThreshold float32, priorRejected bool) {
 	// Check human detection privacy filter
 	if strings.Contains(strings.ToLower(commonName), speciesHuman) && result.Confidence > baseThreshold {
-		return true, 0 // Filter out human detections for privacy
+		return true, 0, false // Filter out human detections for privacy
 	}
 
 	// Determine confidence threshold
@@ -466,18 +514,22 @@ func (p *Processor) shouldFilterDetection(result datastore.Results, commonName,
 	} else {
 		confidenceThreshold = baseThreshold
 	}
+	confidenceThreshold = applyWeatherAdjustment(confidenceThreshold, weatherAdjustment, float32(p.Settings.Realtime.Weather.Adaptive.MaxThreshold))
+	priorThreshold := applyWeatherAdjustment(confidenceThreshold, priorAdjustment, float32(p.Settings.Realtime.TimePriors.MaxThreshold))
 
 	// Check confidence threshold
-	if result.Confidence <= confidenceThreshold {
+	if result.Confidence <= priorThreshold {
 		if p.Settings.Debug {
 			GetLogger().Debug("Detection filtered out due to low confidence",
 				"species", result.Species,
 				"confidence", result.Confidence,
-				"threshold", confidenceThreshold,
+				"threshold", priorThreshold,
+				"weather_adjustment", weatherAdjustment,
+				"prior_adjustment", priorAdjustment,
 				"source", p.getDisplayNameForSource(source),
 				"operation", "confidence_filter")
 		}
-		return true, confidenceThreshold
+		return true, confidenceThreshold, result.Confidence > confidenceThreshold
 	}
 
 	// Check species inclusion filter
@@ -489,10 +541,10 @@ func (p *Processor) shouldFilterDetection(result datastore.Results, commonName,
 				"operation", "species_inclusion_filter")
 			log.Printf("Species not on included list: %s\n", result.Species)
 		}
-		return true, confidenceThreshold
+		return true, confidenceThreshold, false
 	}
 
-	return false, confidenceThreshold
+	return false, confidenceThreshold, false
 }
 
 // createDetection creates a detection object with all necessary information
@@ -521,6 +573,15 @@ func (p *Processor) createDetection(item birdnet.Results, result datastore.Resul
 		item.Source.ID, clipName,
 		item.ElapsedTime, occurrence)
 
+	// Estimate the bearing of the caller from the stereo audio of the analyzed chunk
+	if p.Settings.Realtime.Audio.DOA.Enabled {
+		chunkDuration := time.Duration(len(item.PCMdata)/(conf.BitDepth/8)) * time.Second / conf.SampleRate
+		if bearing, ok := myaudio.EstimateBearing(item.Source.ID, item.StartTime, item.StartTime.Add(chunkDuration), &p.Settings.Realtime.Audio.DOA); ok {
+			bearing = math.Round(bearing)
+			note.Bearing = &bearing
+		}
+	}
+
 	// Update species tracker if enabled
 	p.speciesTrackerMu.RLock()
 	tracker := p.NewSpeciesTracker
@@ -1000,6 +1061,8 @@ func (p *Processor) getDefaultActions(detection *Detections) []Action {
 			EventTracker:      p.GetEventTracker(),
 			NewSpeciesTracker: tracker,
 			processor:         p, // Add processor reference for source name resolution
+			contextCheck:      p.newContextCheck(),
+			rareSpecies:       p.rareSpecies,
 			Note:              detection.Note,
 			Results:           detection.Results,
 			Ds:                p.Ds,
@@ -1062,8 +1125,10 @@ func (p *Processor) getDefaultActions(detection *Detections) []Action {
 		}
 	}
 
-	// Add BirdWeatherAction if enabled and client is initialized
-	if p.Settings.Realtime.Birdweather.Enabled {
+	// Add BirdWeatherAction if enabled and client is initialized, keeping
+	// sensitive species private
+	if p.Settings.Realtime.Birdweather.Enabled &&
+		!p.Settings.Realtime.Species.IsSensitive(detection.Note.ScientificName, detection.Note.CommonName) {
 		bwClient := p.GetBwClient() // Use getter for thread safety
 		if bwClient != nil {
 			// Create BirdWeather retry config from settings
@@ -1202,6 +1267,21 @@ func (p *Processor) GetSSEBroadcaster() func(note *datastore.Note, birdImage *im
 	return p.SSEBroadcaster
 }
 
+// SetDetectionStreamer safely sets the function called with each detection
+// as soon as it is committed to the database. It must not block.
+func (p *Processor) SetDetectionStreamer(streamer func(note *datastore.Note)) {
+	p.detectionStreamerMutex.Lock()
+	defer p.detectionStreamerMutex.Unlock()
+	p.detectionStreamer = streamer
+}
+
+// GetDetectionStreamer safely returns the current detection streamer function
+func (p *Processor) GetDetectionStreamer() func(note *datastore.Note) {
+	p.detectionStreamerMutex.RLock()
+	defer p.detectionStreamerMutex.RUnlock()
+	return p.detectionStreamer
+}
+
 // SetBackupManager safely sets the backup manager
 func (p *Processor) SetBackupManager(manager interface{}) {
 	p.backupMutex.Lock()
@@ -1230,6 +1310,20 @@ func (p *Processor) GetBackupScheduler() interface{} {
 	return p.backupScheduler
 }
 
+// SetShadowModel safely sets the candidate model running in shadow mode
+func (p *Processor) SetShadowModel(runner *shadowmodel.Runner) {
+	p.shadowModelMutex.Lock()
+	defer p.shadowModelMutex.Unlock()
+	p.shadowModel = runner
+}
+
+// GetShadowModel safely returns the candidate model running in shadow mode, or nil
+func (p *Processor) GetShadowModel() *shadowmodel.Runner {
+	p.shadowModelMutex.RLock()
+	defer p.shadowModelMutex.RUnlock()
+	return p.shadowModel
+}
+
 // CleanupLogDeduplicator removes stale log deduplication entries to prevent memory growth.
 // Returns the number of entries removed.
 func (p *Processor) CleanupLogDeduplicator(staleAfter time.Duration) int {
@@ -1315,6 +1409,17 @@ func (p *Processor) Shutdown() error {
 		log.Printf("Warning: job queue shutdown timed out: %v", err)
 	}
 
+	// Write the clips of detections processed before shutdown
+	if p.clipWriter != nil {
+		stopTimeout := clipWriterStopTimeout(p.Settings)
+		if err := p.clipWriter.stop(stopTimeout); err != nil {
+			GetLogger().Warn("Clip writer shutdown timed out",
+				"error", err,
+				"timeout_seconds", stopTimeout.Seconds(),
+				"operation", "clip_writer_shutdown")
+		}
+	}
+
 	// Disconnect BirdWeather client
 	p.DisconnectBwClient()
 
@@ -1363,6 +1468,7 @@ func (p *Processor) NewWithSpeciesInfo(
 	timeStr := detectionTime.Format("15:04:05")
 
 	var sourceStruct datastore.AudioSource
+	var channel string // analyzed channel of a stereo source
 	if p.Settings.Input.Path != "" {
 		// For file input, create simple source struct
 		sourceStruct = datastore.AudioSource{
@@ -1381,6 +1487,7 @@ func (p *Processor) NewWithSpeciesInfo(
 					SafeString:  existingSource.SafeString,  // Use sanitized string for logging
 					DisplayName: existingSource.DisplayName, // Use display name for UI
 				}
+				channel = existingSource.Channel
 			} else {
 				// Try to get by ID directly
 				if registrySource, exists := registry.GetSourceByID(source); exists {
@@ -1389,6 +1496,7 @@ func (p *Processor) NewWithSpeciesInfo(
 						SafeString:  registrySource.SafeString,
 						DisplayName: registrySource.DisplayName,
 					}
+					channel = registrySource.Channel
 				} else {
 					// Last resort: create struct with manual sanitization for safety
 					sourceStruct = datastore.AudioSource{
@@ -1417,6 +1525,7 @@ func (p *Processor) NewWithSpeciesInfo(
 		Date:           date,                           // Use ISO 8601 date format
 		Time:           timeStr,                        // Use 24-hour time format
 		Source:         sourceStruct,                   // Proper AudioSource struct with ID, SafeString, DisplayName
+		Channel:        channel,                        // Analyzed channel of a stereo source
 		BeginTime:      beginTime,                      // Start time of the observation
 		EndTime:        endTime,                        // End time of the observation
 		SpeciesCode:    speciesCode,                    // Species code from taxonomy lookup
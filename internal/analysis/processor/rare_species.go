@@ -0,0 +1,157 @@
+// rare_species.go: alerts of detections of species that are locally unusual according to eBird frequency data
+package processor
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/ebird"
+	"github.com/tphakala/birdnet-go/internal/events"
+	"github.com/tphakala/birdnet-go/internal/notification"
+)
+
+// rarityTimeout limits how long looking up the frequency of a species may
+// take, which may take several eBird API requests on the first detection of
+// a week
+const rarityTimeout = 2 * time.Minute
+
+// rareSpecies scores detections by the expected frequency of their species
+// at the station and week, and alerts of the rare ones
+type rareSpecies struct {
+	settings *conf.RareSpeciesSettings
+	source   ebird.FrequencySource
+	notify   func(event events.DetectionEvent, frequency float64, source string) error
+
+	mu      sync.Mutex
+	alerted map[string]time.Time // last alert by lowercase scientific name
+}
+
+// newRareSpecies returns the rare species alerts, nil when disabled or
+// without the frequency data to score detections with
+func newRareSpecies(settings *conf.Settings) *rareSpecies {
+	rarity := &settings.Realtime.RareSpecies
+	if !rarity.Enabled {
+		return nil
+	}
+
+	var source ebird.FrequencySource
+	switch rarity.Source {
+	case "barchart":
+		chart, err := ebird.LoadBarChart(rarity.BarChartFile)
+		if err != nil {
+			GetLogger().Warn("Rare species alerts disabled, failed to load eBird bar chart",
+				"path", rarity.BarChartFile,
+				"error", err,
+				"operation", "rare_species_init")
+			return nil
+		}
+		source = chart
+	default:
+		if !settings.Realtime.EBird.Enabled || settings.Realtime.EBird.APIKey == "" {
+			GetLogger().Warn("Rare species alerts disabled, the ebird source needs the eBird integration enabled with an API key",
+				"operation", "rare_species_init")
+			return nil
+		}
+		client, err := ebird.NewClient(ebird.Config{
+			APIKey:   settings.Realtime.EBird.APIKey,
+			CacheTTL: time.Duration(settings.Realtime.EBird.CacheTTL) * time.Hour,
+		})
+		if err != nil {
+			GetLogger().Warn("Rare species alerts disabled, failed to create eBird client",
+				"error", err,
+				"operation", "rare_species_init")
+			return nil
+		}
+		source = ebird.NewLocalFrequencies(client, settings.BirdNET.Latitude, settings.BirdNET.Longitude, rarity.RegionCode)
+	}
+
+	GetLogger().Info("Rare species alerts enabled",
+		"source", rarity.Source,
+		"max_frequency", rarity.MaxFrequency,
+		"min_confidence", rarity.MinConfidence,
+		"operation", "rare_species_init")
+	return &rareSpecies{
+		settings: rarity,
+		source:   source,
+		notify:   notification.NotifyRareSpecies,
+		alerted:  make(map[string]time.Time),
+	}
+}
+
+// check scores a stored detection and alerts of it when its species is
+// rare, unless the species was alerted of within the cooldown. It returns
+// whether an alert was raised.
+func (r *rareSpecies) check(ctx context.Context, note *datastore.Note) bool {
+	if note.Confidence < r.settings.MinConfidence || !r.due(note.ScientificName, note.BeginTime) {
+		return false
+	}
+
+	frequency, err := r.source.Frequency(ctx, note.ScientificName, note.BeginTime)
+	if err != nil {
+		GetLogger().Warn("Failed to look up the frequency of a species, skipping rare species check",
+			"species", note.CommonName,
+			"scientific_name", note.ScientificName,
+			"error", err,
+			"operation", "rare_species_check")
+		return false
+	}
+	if frequency > r.settings.MaxFrequency || !r.markAlerted(note.ScientificName, note.BeginTime) {
+		return false
+	}
+
+	event, err := events.NewDetectionEvent(note.CommonName, note.ScientificName, note.Confidence,
+		note.Source.DisplayName, false, 0)
+	if err != nil {
+		GetLogger().Warn("Failed to create rare species detection event",
+			"species", note.CommonName,
+			"error", err,
+			"operation", "rare_species_notify")
+		return false
+	}
+	if metadata := event.GetMetadata(); metadata != nil {
+		metadata["note_id"] = note.ID
+		metadata["latitude"] = note.Latitude
+		metadata["longitude"] = note.Longitude
+		metadata["begin_time"] = note.BeginTime
+	}
+
+	GetLogger().Info("Rare species detected",
+		"species", note.CommonName,
+		"scientific_name", note.ScientificName,
+		"confidence", note.Confidence,
+		"frequency", frequency,
+		"source", r.settings.Source,
+		"operation", "rare_species_check")
+	if err := r.notify(event, frequency, r.settings.Source); err != nil {
+		GetLogger().Warn("Failed to notify of rare species",
+			"species", note.CommonName,
+			"error", err,
+			"operation", "rare_species_notify")
+	}
+	return true
+}
+
+// due reports whether the cooldown of a species has passed
+func (r *rareSpecies) due(scientificName string, at time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	last, ok := r.alerted[strings.ToLower(scientificName)]
+	return !ok || at.Sub(last) >= time.Duration(r.settings.Cooldown)*time.Hour
+}
+
+// markAlerted records an alert of a species, reporting false when another
+// detection of it was alerted of within the cooldown meanwhile
+func (r *rareSpecies) markAlerted(scientificName string, at time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	name := strings.ToLower(scientificName)
+	if last, ok := r.alerted[name]; ok && at.Sub(last) < time.Duration(r.settings.Cooldown)*time.Hour {
+		return false
+	}
+	r.alerted[name] = at
+	return true
+}
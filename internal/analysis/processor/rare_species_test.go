@@ -0,0 +1,79 @@
+// rare_species_test.go: Unit tests for the rare species alerts
+package processor
+
+import (
+	"context"
+	stderrors "errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/events"
+)
+
+// staticFrequencies returns fixed frequencies by lowercase scientific name,
+// failing for the species in failing
+type staticFrequencies struct {
+	frequencies map[string]float64
+	failing     string
+}
+
+func (s *staticFrequencies) Frequency(_ context.Context, scientificName string, _ time.Time) (float64, error) {
+	if strings.EqualFold(scientificName, s.failing) {
+		return 0, stderrors.New("eBird unavailable")
+	}
+	return s.frequencies[strings.ToLower(scientificName)], nil
+}
+
+func TestRareSpeciesCheck(t *testing.T) {
+	t.Parallel()
+
+	var notified []string
+	rarity := &rareSpecies{
+		settings: &conf.RareSpeciesSettings{Source: "barchart", MaxFrequency: 0.02, MinConfidence: 0.8, Cooldown: 24},
+		source: &staticFrequencies{
+			frequencies: map[string]float64{"parus major": 0.8, "coccothraustes coccothraustes": 0.01},
+			failing:     "Bombycilla garrulus",
+		},
+		notify: func(event events.DetectionEvent, frequency float64, source string) error {
+			notified = append(notified, event.GetScientificName())
+			assert.Equal(t, "barchart", source)
+			return nil
+		},
+		alerted: make(map[string]time.Time),
+	}
+
+	begin := time.Date(2026, time.January, 10, 8, 0, 0, 0, time.UTC)
+	note := func(scientificName string, confidence float64, at time.Time) *datastore.Note {
+		return &datastore.Note{CommonName: "Test bird", ScientificName: scientificName, Confidence: confidence, BeginTime: at}
+	}
+	ctx := context.Background()
+
+	assert.False(t, rarity.check(ctx, note("Parus major", 0.9, begin)), "common species")
+	assert.True(t, rarity.check(ctx, note("Coccothraustes coccothraustes", 0.9, begin)), "rare species")
+	assert.True(t, rarity.check(ctx, note("Loxia pytyopsittacus", 0.9, begin)), "species never reported are rare")
+	assert.False(t, rarity.check(ctx, note("Bucephala albeola", 0.7, begin)), "below the minimum confidence")
+	assert.False(t, rarity.check(ctx, note("Bombycilla garrulus", 0.9, begin)), "frequency lookup failed")
+
+	// The same species is alerted of again only after the cooldown
+	assert.False(t, rarity.check(ctx, note("COCCOTHRAUSTES coccothraustes", 0.95, begin.Add(time.Hour))))
+	assert.True(t, rarity.check(ctx, note("Coccothraustes coccothraustes", 0.9, begin.Add(24*time.Hour))))
+
+	assert.Equal(t, []string{"Coccothraustes coccothraustes", "Loxia pytyopsittacus", "Coccothraustes coccothraustes"}, notified)
+}
+
+func TestNewRareSpecies(t *testing.T) {
+	t.Parallel()
+
+	settings := &conf.Settings{}
+	assert.Nil(t, newRareSpecies(settings), "disabled")
+
+	settings.Realtime.RareSpecies = conf.RareSpeciesSettings{Enabled: true, Source: "ebird"}
+	assert.Nil(t, newRareSpecies(settings), "ebird source without the eBird integration")
+
+	settings.Realtime.RareSpecies = conf.RareSpeciesSettings{Enabled: true, Source: "barchart", BarChartFile: t.TempDir() + "/missing.txt"}
+	assert.Nil(t, newRareSpecies(settings), "bar chart that cannot be loaded")
+}
@@ -0,0 +1,280 @@
+// time_priors.go: raises the confidence threshold of species at hours of the day they are rarely active
+package processor
+
+import (
+	"context"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+const (
+	// timePriorRefresh is how often priors are learned again from the stored
+	// detections
+	timePriorRefresh = 24 * time.Hour
+
+	// timePriorLearnTimeout limits how long learning the priors may take
+	timePriorLearnTimeout = 2 * time.Minute
+
+	// maxTimePriorRejections is the number of recent rejections kept for auditing
+	maxTimePriorRejections = 100
+)
+
+// hourlySpeciesCounter is implemented by datastores that count detections by
+// species and hour of the day
+type hourlySpeciesCounter interface {
+	GetHourlySpeciesCounts(ctx context.Context, startDate, endDate string) ([]datastore.HourlySpeciesCount, error)
+}
+
+// hourlyPriors holds the prior of a species for each hour of the day, from 0
+// for hours it is never active to 1 for its most active hours
+type hourlyPriors [24]float64
+
+// TimePriorRejection is a detection that passed its confidence threshold but
+// was rejected by the time-of-day prior of its species
+type TimePriorRejection struct {
+	Time           time.Time `json:"time"`
+	Source         string    `json:"source"`
+	ScientificName string    `json:"scientificName"`
+	CommonName     string    `json:"commonName"`
+	Confidence     float64   `json:"confidence"`
+	Threshold      float64   `json:"threshold"`  // threshold without the prior
+	Prior          float64   `json:"prior"`      // prior of the species at the hour of the detection
+	Adjustment     float64   `json:"adjustment"` // threshold increase of the prior
+}
+
+// TimePriorStatus describes the time-of-day priors in use
+type TimePriorStatus struct {
+	LearnedAt      time.Time            `json:"learnedAt"`      // zero when not learned yet
+	LearnedSpecies int                  `json:"learnedSpecies"` // species with a learned prior
+	Rejections     []TimePriorRejection `json:"rejections"`     // recent rejections, newest first
+}
+
+// timePrior is the prior of a species at the hour of a detection
+type timePrior struct {
+	value      float64
+	adjustment float32
+}
+
+// timePriors keeps the learned priors and the recent rejections
+type timePriors struct {
+	mu         sync.Mutex
+	learned    map[string]hourlyPriors // by lowercase scientific name
+	learnedAt  time.Time
+	learning   bool
+	rejections []TimePriorRejection // oldest first
+}
+
+// timePriorFor returns the prior of a species at the given time and the
+// threshold increase it causes, a zero prior when time priors are disabled
+// or the species has no prior
+func (p *Processor) timePriorFor(scientificName, speciesLowercase string, at time.Time) timePrior {
+	settings := &p.Settings.Realtime.TimePriors
+	if !settings.Enabled {
+		return timePrior{value: 1}
+	}
+
+	priors, ok := configuredTimePrior(settings.Species, scientificName, speciesLowercase)
+	if !ok && settings.Learn {
+		p.learnTimePriorsIfDue(settings, at)
+		priors, ok = p.timePriors.lookup(strings.ToLower(scientificName))
+	}
+	if !ok {
+		return timePrior{value: 1}
+	}
+
+	value := priors[at.Hour()]
+	return timePrior{value: value, adjustment: float32(timePriorIncrease(settings.Adjustment, value))}
+}
+
+// learnTimePriorsIfDue learns the priors again in the background once the
+// previous ones are older than the refresh interval
+func (p *Processor) learnTimePriorsIfDue(settings *conf.TimePriorSettings, now time.Time) {
+	counter, ok := p.Ds.(hourlySpeciesCounter)
+	if !ok || !p.timePriors.startLearning(now) {
+		return
+	}
+	learnDays, minDetections := settings.LearnDays, settings.MinDetections
+	go p.timePriors.learn(counter, learnDays, minDetections, now)
+}
+
+// startLearning reports whether learning is due and marks it started
+func (t *timePriors) startLearning(now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.learning || (!t.learnedAt.IsZero() && now.Sub(t.learnedAt) < timePriorRefresh) {
+		return false
+	}
+	t.learning = true
+	return true
+}
+
+// learn learns the priors from the detections of the last learnDays days
+func (t *timePriors) learn(counter hourlySpeciesCounter, learnDays, minDetections int, now time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), timePriorLearnTimeout)
+	defer cancel()
+
+	counts, err := counter.GetHourlySpeciesCounts(ctx,
+		now.AddDate(0, 0, -learnDays).Format(time.DateOnly), now.Format(time.DateOnly))
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.learning = false
+	// Retry only after the refresh interval, also when learning failed
+	t.learnedAt = now
+	if err != nil {
+		// Keep the previous priors
+		GetLogger().Warn("Failed to learn time-of-day priors",
+			"error", err,
+			"operation", "learn_time_priors")
+		return
+	}
+	t.learned = learnedTimePriors(counts, minDetections)
+	GetLogger().Info("Learned time-of-day priors",
+		"species", len(t.learned),
+		"learn_days", learnDays,
+		"operation", "learn_time_priors")
+}
+
+// lookup returns the learned priors of a species
+func (t *timePriors) lookup(scientificName string) (hourlyPriors, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	priors, ok := t.learned[scientificName]
+	return priors, ok
+}
+
+// recordRejection keeps a rejection for auditing, dropping the oldest ones
+// beyond the limit
+func (t *timePriors) recordRejection(rejection TimePriorRejection) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.rejections) >= maxTimePriorRejections {
+		t.rejections = append(t.rejections[:0], t.rejections[1:]...)
+	}
+	t.rejections = append(t.rejections, rejection)
+}
+
+// TimePriorStatus returns the learned time-of-day priors and the recent
+// detections they rejected
+func (p *Processor) TimePriorStatus() TimePriorStatus {
+	p.timePriors.mu.Lock()
+	defer p.timePriors.mu.Unlock()
+
+	status := TimePriorStatus{
+		LearnedSpecies: len(p.timePriors.learned),
+		Rejections:     make([]TimePriorRejection, 0, len(p.timePriors.rejections)),
+	}
+	if p.timePriors.learned != nil {
+		status.LearnedAt = p.timePriors.learnedAt
+	}
+	for i := len(p.timePriors.rejections) - 1; i >= 0; i-- {
+		status.Rejections = append(status.Rejections, p.timePriors.rejections[i])
+	}
+	return status
+}
+
+// auditTimePriorRejection logs and keeps a detection rejected by its prior
+//
+//nolint:gocritic // hugeParam: result is passed by value like in processResults
+func (p *Processor) auditTimePriorRejection(result datastore.Results, scientificName, commonName, source string, at time.Time, prior timePrior, threshold float32) {
+	rejection := TimePriorRejection{
+		Time:           at,
+		Source:         p.getDisplayNameForSource(source),
+		ScientificName: scientificName,
+		CommonName:     commonName,
+		Confidence:     math.Round(float64(result.Confidence)*100) / 100,
+		Threshold:      math.Round(float64(threshold)*100) / 100,
+		Prior:          prior.value,
+		Adjustment:     float64(prior.adjustment),
+	}
+	p.timePriors.recordRejection(rejection)
+	GetLogger().Info("Detection rejected by time-of-day prior",
+		"species", commonName,
+		"scientific_name", scientificName,
+		"confidence", rejection.Confidence,
+		"threshold", rejection.Threshold,
+		"prior", rejection.Prior,
+		"adjustment", rejection.Adjustment,
+		"source", rejection.Source,
+		"audit", "time_prior_rejected",
+		"operation", "time_prior_filter")
+}
+
+// configuredTimePrior returns the configured priors of a species, matching
+// its common or scientific name regardless of case
+func configuredTimePrior(species map[string]conf.TimePrior, scientificName, speciesLowercase string) (hourlyPriors, bool) {
+	for name, prior := range species {
+		if strings.EqualFold(name, speciesLowercase) || strings.EqualFold(name, scientificName) {
+			return configuredHourlyPriors(prior), true
+		}
+	}
+	return hourlyPriors{}, false
+}
+
+// configuredHourlyPriors returns the priors of a configured prior: 1 at the
+// active hours and the inactive prior at the others
+func configuredHourlyPriors(prior conf.TimePrior) hourlyPriors {
+	var priors hourlyPriors
+	for hour := range priors {
+		priors[hour] = prior.Inactive
+	}
+	for _, hour := range prior.ActiveHours {
+		if hour >= 0 && hour < len(priors) {
+			priors[hour] = 1
+		}
+	}
+	return priors
+}
+
+// learnedTimePriors learns the priors of the species with at least
+// minDetections detections from their counts by hour. The counts are
+// smoothed with the neighbouring hours so that the edges of the active hours
+// are not penalized, and scaled so the most active hour has a prior of 1.
+func learnedTimePriors(counts []datastore.HourlySpeciesCount, minDetections int) map[string]hourlyPriors {
+	hourly := make(map[string]*[24]int)
+	for i := range counts {
+		name := strings.ToLower(counts[i].ScientificName)
+		if hourly[name] == nil {
+			hourly[name] = new([24]int)
+		}
+		hourly[name][counts[i].Hour] += counts[i].Count
+	}
+
+	learned := make(map[string]hourlyPriors, len(hourly))
+	for name, hours := range hourly {
+		total := 0
+		for _, count := range hours {
+			total += count
+		}
+		if total < minDetections {
+			continue
+		}
+
+		var smoothed [24]float64
+		var peak float64
+		for hour := range hours {
+			smoothed[hour] = float64(hours[(hour+23)%24]+2*hours[hour]+hours[(hour+1)%24]) / 4
+			peak = math.Max(peak, smoothed[hour])
+		}
+		var priors hourlyPriors
+		for hour := range smoothed {
+			priors[hour] = math.Round(smoothed[hour]/peak*100) / 100
+		}
+		learned[name] = priors
+	}
+	return learned
+}
+
+// timePriorIncrease returns the threshold increase at a prior, the full
+// adjustment at a prior of 0 and none at a prior of 1
+func timePriorIncrease(adjustment, prior float64) float64 {
+	// Round to two decimals like the thresholds themselves
+	return math.Round(adjustment*(1-prior)*100) / 100
+}
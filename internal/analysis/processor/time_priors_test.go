@@ -0,0 +1,125 @@
+// time_priors_test.go: Unit tests for the time-of-day detection priors
+package processor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+// hourlyCountsDatastore returns fixed detection counts by species and hour
+type hourlyCountsDatastore struct {
+	MockDatastore
+	counts []datastore.HourlySpeciesCount
+}
+
+func (m *hourlyCountsDatastore) GetHourlySpeciesCounts(ctx context.Context, startDate, endDate string) ([]datastore.HourlySpeciesCount, error) {
+	return m.counts, nil
+}
+
+func testTimePriorSettings() conf.TimePriorSettings {
+	return conf.TimePriorSettings{Enabled: true, Learn: true, LearnDays: 60, MinDetections: 10, Adjustment: 0.2, MaxThreshold: 0.95}
+}
+
+func TestLearnedTimePriors(t *testing.T) {
+	t.Parallel()
+
+	counts := []datastore.HourlySpeciesCount{
+		{Date: "2024-05-01", Hour: 23, ScientificName: "Strix aluco", Count: 8},
+		{Date: "2024-05-02", Hour: 23, ScientificName: "Strix aluco", Count: 8},
+		{Date: "2024-05-01", Hour: 0, ScientificName: "Strix aluco", Count: 8},
+		{Date: "2024-05-01", Hour: 12, ScientificName: "Turdus merula", Count: 5},
+	}
+	learned := learnedTimePriors(counts, 10)
+
+	require.Contains(t, learned, "strix aluco")
+	assert.NotContains(t, learned, "turdus merula", "species below the minimum detections are not learned")
+
+	priors := learned["strix aluco"]
+	assert.InDelta(t, 1, priors[23], 1e-9, "the most active hour has a prior of 1")
+	assert.InDelta(t, 0.8, priors[0], 1e-9, "priors are scaled to the most active hour")
+	assert.InDelta(t, 0.4, priors[22], 1e-9, "neighbouring hours are smoothed")
+	assert.Zero(t, priors[12])
+}
+
+func TestTimePriorFor(t *testing.T) {
+	t.Parallel()
+
+	settings := &conf.Settings{}
+	settings.Realtime.TimePriors = testTimePriorSettings()
+	settings.Realtime.TimePriors.Species = map[string]conf.TimePrior{
+		"Tawny Owl": {ActiveHours: []int{22, 23}, Inactive: 0.25},
+	}
+	ds := &hourlyCountsDatastore{counts: []datastore.HourlySpeciesCount{
+		{Hour: 3, ScientificName: "Bubo bubo", Count: 20},
+	}}
+	p := &Processor{Settings: settings, Ds: ds}
+
+	noon := time.Date(2024, 5, 1, 12, 0, 0, 0, time.Local)
+	night := time.Date(2024, 5, 1, 23, 0, 0, 0, time.Local)
+
+	// Configured priors match common names regardless of case
+	prior := p.timePriorFor("Strix aluco", "tawny owl", noon)
+	assert.InDelta(t, 0.25, prior.value, 1e-9)
+	assert.InDelta(t, 0.15, prior.adjustment, 1e-6)
+	assert.Zero(t, p.timePriorFor("Strix aluco", "tawny owl", night).adjustment)
+
+	// Learned priors apply once learned
+	p.timePriors.learn(ds, 60, 10, noon)
+	prior = p.timePriorFor("Bubo bubo", "eurasian eagle-owl", noon)
+	assert.Zero(t, prior.value)
+	assert.InDelta(t, 0.2, prior.adjustment, 1e-6)
+	assert.Zero(t, p.timePriorFor("Turdus merula", "eurasian blackbird", noon).adjustment, "species without a prior are not adjusted")
+
+	settings.Realtime.TimePriors.Enabled = false
+	assert.Zero(t, p.timePriorFor("Bubo bubo", "eurasian eagle-owl", noon).adjustment)
+}
+
+func TestShouldFilterDetectionTimePrior(t *testing.T) {
+	t.Parallel()
+
+	settings := &conf.Settings{}
+	settings.Realtime.TimePriors = testTimePriorSettings()
+	settings.BirdNET.RangeFilter.Species = []string{"Strix aluco_Tawny Owl"}
+	p := &Processor{Settings: settings}
+
+	result := datastore.Results{Species: "Strix aluco_Tawny Owl", Confidence: 0.8}
+	filtered, threshold, priorRejected := p.shouldFilterDetection(result, "Tawny Owl", "tawny owl", 0.7, 0, 0.2, "mic")
+	assert.True(t, filtered)
+	assert.True(t, priorRejected, "the detection passes the threshold without the prior")
+	assert.InDelta(t, 0.7, threshold, 1e-6)
+
+	result.Confidence = 0.6
+	filtered, _, priorRejected = p.shouldFilterDetection(result, "Tawny Owl", "tawny owl", 0.7, 0, 0.2, "mic")
+	assert.True(t, filtered)
+	assert.False(t, priorRejected, "the detection fails the threshold without the prior")
+
+	result.Confidence = 0.96
+	filtered, _, _ = p.shouldFilterDetection(result, "Tawny Owl", "tawny owl", 0.85, 0, 0.2, "mic")
+	assert.False(t, filtered, "the prior raises the threshold up to the maximum threshold only")
+}
+
+func TestTimePriorRejectionAudit(t *testing.T) {
+	t.Parallel()
+
+	p := &Processor{Settings: &conf.Settings{}}
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.Local)
+	for i := range maxTimePriorRejections + 5 {
+		result := datastore.Results{Species: "Strix aluco_Tawny Owl", Confidence: 0.8}
+		p.auditTimePriorRejection(result, "Strix aluco", "Tawny Owl", "mic", at.Add(time.Duration(i)*time.Minute), timePrior{value: 0, adjustment: 0.2}, 0.7)
+	}
+
+	status := p.TimePriorStatus()
+	require.Len(t, status.Rejections, maxTimePriorRejections, "only the most recent rejections are kept")
+	newest := status.Rejections[0]
+	assert.Equal(t, at.Add(time.Duration(maxTimePriorRejections+4)*time.Minute), newest.Time, "newest first")
+	assert.Equal(t, "Strix aluco", newest.ScientificName)
+	assert.InDelta(t, 0.7, newest.Threshold, 1e-9)
+	assert.InDelta(t, 0.2, newest.Adjustment, 1e-6)
+	assert.True(t, status.LearnedAt.IsZero(), "no priors learned")
+}
@@ -0,0 +1,123 @@
+// weather_adjustment.go: raises the confidence threshold in wind and rain
+package processor
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/weather"
+)
+
+// weatherReadingRefresh is how often the latest weather reading is read from
+// the database; readings are stored once per weather poll interval
+const weatherReadingRefresh = time.Minute
+
+// weatherAdjuster raises the confidence threshold from the latest weather
+// reading while wind noise and rain make false positives likely
+type weatherAdjuster struct {
+	mu         sync.Mutex
+	reading    *datastore.HourlyWeather
+	readAt     time.Time
+	lastLogged float64 // last adjustment logged, to log changes only
+}
+
+// weatherThresholdAdjustment returns the confidence threshold increase for
+// the current weather, 0 when disabled, calm and dry or without a recent
+// weather reading
+func (p *Processor) weatherThresholdAdjustment(now time.Time) float32 {
+	settings := &p.Settings.Realtime.Weather
+	if !settings.Adaptive.Enabled || p.Ds == nil {
+		return 0
+	}
+
+	reading := p.weatherAdjuster.latest(p.Ds, now)
+	if reading == nil || now.Sub(reading.Time) > time.Duration(settings.Adaptive.MaxAge)*time.Minute {
+		return 0
+	}
+
+	windSpeed := reading.WindSpeed
+	if settings.Provider == "openweather" && settings.OpenWeather.Units == "imperial" {
+		windSpeed *= weather.MphToMs
+	}
+	raining := isRainIcon(reading.WeatherIcon)
+	adjustment := weatherThresholdIncrease(&settings.Adaptive, windSpeed, raining)
+	p.weatherAdjuster.logChange(adjustment, windSpeed, raining)
+	return float32(adjustment)
+}
+
+// latest returns the latest weather reading, read from the database at most
+// once per refresh interval
+func (w *weatherAdjuster) latest(ds datastore.Interface, now time.Time) *datastore.HourlyWeather {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if now.Sub(w.readAt) < weatherReadingRefresh {
+		return w.reading
+	}
+	w.readAt = now
+
+	reading, err := ds.LatestHourlyWeather()
+	if err != nil {
+		// Keep the previous reading, its age limits how long it is used
+		GetLogger().Debug("Failed to read latest weather for threshold adjustment",
+			"error", err,
+			"operation", "weather_threshold_adjustment")
+		return w.reading
+	}
+	w.reading = reading
+	return reading
+}
+
+// logChange logs the adjustment when it changes
+func (w *weatherAdjuster) logChange(adjustment, windSpeed float64, raining bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if adjustment == w.lastLogged {
+		return
+	}
+	w.lastLogged = adjustment
+	GetLogger().Info("Weather threshold adjustment changed",
+		"adjustment", adjustment,
+		"wind_speed_ms", math.Round(windSpeed*10)/10,
+		"raining", raining,
+		"operation", "weather_threshold_adjustment")
+}
+
+// weatherThresholdIncrease returns the threshold increase for a wind speed in
+// m/s and rain. The wind adjustment grows linearly from windMin to windMax;
+// the rain adjustment is added while it rains.
+func weatherThresholdIncrease(settings *conf.WeatherAdaptiveSettings, windSpeed float64, raining bool) float64 {
+	var increase float64
+	if windSpeed > settings.WindMin && settings.WindMax > settings.WindMin {
+		fraction := math.Min((windSpeed-settings.WindMin)/(settings.WindMax-settings.WindMin), 1)
+		increase = fraction * settings.WindAdjustment
+	}
+	if raining {
+		increase += settings.RainAdjustment
+	}
+	// Round to two decimals like the thresholds themselves
+	return math.Round(increase*100) / 100
+}
+
+// applyWeatherAdjustment raises a confidence threshold by the weather
+// adjustment, up to the maximum threshold. A threshold already above the
+// maximum is kept.
+func applyWeatherAdjustment(threshold, adjustment, maxThreshold float32) float32 {
+	if adjustment <= 0 {
+		return threshold
+	}
+	return max(threshold, min(threshold+adjustment, maxThreshold))
+}
+
+// isRainIcon reports whether a standardized weather icon code shows rain
+func isRainIcon(icon string) bool {
+	switch weather.IconCode(icon) {
+	case weather.IconRainShowers, weather.IconRain, weather.IconThunderstorm, weather.IconSleet:
+		return true
+	}
+	return false
+}
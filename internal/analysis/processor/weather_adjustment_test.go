@@ -0,0 +1,97 @@
+// weather_adjustment_test.go: Unit tests for the weather-adaptive confidence threshold
+package processor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+// weatherDatastore returns a fixed latest weather reading
+type weatherDatastore struct {
+	MockDatastore
+	reading *datastore.HourlyWeather
+	reads   int
+}
+
+func (m *weatherDatastore) LatestHourlyWeather() (*datastore.HourlyWeather, error) {
+	m.reads++
+	return m.reading, nil
+}
+
+func testWeatherAdaptiveSettings() conf.WeatherAdaptiveSettings {
+	return conf.WeatherAdaptiveSettings{Enabled: true, WindMin: 4, WindMax: 10, WindAdjustment: 0.15, RainAdjustment: 0.1, MaxThreshold: 0.95, MaxAge: 120}
+}
+
+func TestWeatherThresholdIncrease(t *testing.T) {
+	t.Parallel()
+
+	settings := testWeatherAdaptiveSettings()
+	tests := []struct {
+		name      string
+		windSpeed float64
+		raining   bool
+		want      float64
+	}{
+		{"calm and dry", 2, false, 0},
+		{"at wind minimum", 4, false, 0},
+		{"half way", 7, false, 0.08},
+		{"strong wind", 15, false, 0.15},
+		{"calm rain", 1, true, 0.1},
+		{"storm", 12, true, 0.25},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.InDelta(t, tt.want, weatherThresholdIncrease(&settings, tt.windSpeed, tt.raining), 1e-9)
+		})
+	}
+}
+
+func TestApplyWeatherAdjustment(t *testing.T) {
+	t.Parallel()
+
+	assert.InDelta(t, 0.8, applyWeatherAdjustment(0.8, 0, 0.95), 1e-6)
+	assert.InDelta(t, 0.9, applyWeatherAdjustment(0.8, 0.1, 0.95), 1e-6)
+	assert.InDelta(t, 0.95, applyWeatherAdjustment(0.9, 0.25, 0.95), 1e-6, "capped at the maximum threshold")
+	assert.InDelta(t, 0.97, applyWeatherAdjustment(0.97, 0.1, 0.95), 1e-6, "a higher threshold is not lowered")
+}
+
+func TestWeatherThresholdAdjustment(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	ds := &weatherDatastore{reading: &datastore.HourlyWeather{Time: now.Add(-30 * time.Minute), WindSpeed: 10, WeatherIcon: "10"}}
+	settings := &conf.Settings{}
+	settings.Realtime.Weather.Provider = "yrno"
+	settings.Realtime.Weather.Adaptive = testWeatherAdaptiveSettings()
+	p := &Processor{Settings: settings, Ds: ds}
+
+	assert.InDelta(t, 0.25, p.weatherThresholdAdjustment(now), 1e-6, "full wind and rain adjustment")
+	assert.InDelta(t, 0.25, p.weatherThresholdAdjustment(now.Add(10*time.Second)), 1e-6)
+	assert.Equal(t, 1, ds.reads, "the reading is cached between refreshes")
+
+	// Readings older than the maximum age are not used
+	assert.Zero(t, p.weatherThresholdAdjustment(now.Add(3*time.Hour)))
+
+	settings.Realtime.Weather.Adaptive.Enabled = false
+	assert.Zero(t, p.weatherThresholdAdjustment(now))
+}
+
+func TestWeatherThresholdAdjustmentImperialWind(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	// 15 mph is 6.7 m/s
+	ds := &weatherDatastore{reading: &datastore.HourlyWeather{Time: now, WindSpeed: 15, WeatherIcon: "04"}}
+	settings := &conf.Settings{}
+	settings.Realtime.Weather.Provider = "openweather"
+	settings.Realtime.Weather.OpenWeather.Units = "imperial"
+	settings.Realtime.Weather.Adaptive = testWeatherAdaptiveSettings()
+	p := &Processor{Settings: settings, Ds: ds}
+
+	assert.InDelta(t, 0.07, p.weatherThresholdAdjustment(now), 1e-6)
+}
@@ -7,6 +7,8 @@ import (
 	"time"
 
 	"github.com/tphakala/birdnet-go/internal/analysis/jobqueue"
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/databudget"
 	"github.com/tphakala/birdnet-go/internal/errors"
 )
 
@@ -240,6 +242,15 @@ func (p *Processor) EnqueueTaskCtx(ctx context.Context, task *Task) error {
 			Build()
 	}
 
+	// Hold outbound tasks until offline mode is turned off or the data budget allows uploads again
+	if isOutboundAction(task.Action) && !outboundAllowed() {
+		if !conf.IsOffline() {
+			databudget.RecordDeferred(databudget.Uploads)
+		}
+		p.deferOfflineTask(task)
+		return nil
+	}
+
 	// Get action description for logging and error context
 	actionDesc := task.Action.GetDescription()
 	sanitizedDesc := sanitizeString(actionDesc)
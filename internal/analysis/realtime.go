@@ -16,23 +16,35 @@ import (
 
 	"github.com/shirou/gopsutil/v3/host"
 	"github.com/tphakala/birdnet-go/internal/analysis/processor"
+	"github.com/tphakala/birdnet-go/internal/atrest"
 	"github.com/tphakala/birdnet-go/internal/audiocore/adapter"
 	"github.com/tphakala/birdnet-go/internal/backup"
 	"github.com/tphakala/birdnet-go/internal/birdnet"
 	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/databudget"
+	"github.com/tphakala/birdnet-go/internal/dataquality"
 	"github.com/tphakala/birdnet-go/internal/datastore"
 	"github.com/tphakala/birdnet-go/internal/diskmanager"
 	"github.com/tphakala/birdnet-go/internal/errors"
+	"github.com/tphakala/birdnet-go/internal/fieldsync"
+	"github.com/tphakala/birdnet-go/internal/forecast"
 	"github.com/tphakala/birdnet-go/internal/httpcontroller"
 	"github.com/tphakala/birdnet-go/internal/httpcontroller/handlers"
 	"github.com/tphakala/birdnet-go/internal/imageprovider"
 	"github.com/tphakala/birdnet-go/internal/logging"
+	"github.com/tphakala/birdnet-go/internal/manifest"
 	"github.com/tphakala/birdnet-go/internal/monitor"
 	"github.com/tphakala/birdnet-go/internal/myaudio"
+	"github.com/tphakala/birdnet-go/internal/newsletter"
 	"github.com/tphakala/birdnet-go/internal/notification"
+	"github.com/tphakala/birdnet-go/internal/notification/digest"
+	"github.com/tphakala/birdnet-go/internal/notification/history"
 	"github.com/tphakala/birdnet-go/internal/observability"
+	"github.com/tphakala/birdnet-go/internal/onthisday"
 	"github.com/tphakala/birdnet-go/internal/privacy"
+	"github.com/tphakala/birdnet-go/internal/shadowmodel"
 	"github.com/tphakala/birdnet-go/internal/telemetry"
+	"github.com/tphakala/birdnet-go/internal/timelapse"
 	"github.com/tphakala/birdnet-go/internal/weather"
 )
 
@@ -40,8 +52,20 @@ import (
 const (
 	// shutdownTimeout is the maximum time allowed for graceful shutdown (9s for Docker's 10s default)
 	shutdownTimeout = 9 * time.Second
+
+	// drainShare is the fraction of the shutdown deadline given to draining pending work,
+	// leaving the remainder for the HTTP server, goroutines and interpreter cleanup
+	drainShare = 0.6
 )
 
+// getShutdownTimeout returns the configured graceful shutdown deadline, falling back to the default
+func getShutdownTimeout(settings *conf.Settings) time.Duration {
+	if settings == nil || settings.Realtime.Shutdown.Timeout <= 0 {
+		return shutdownTimeout
+	}
+	return time.Duration(settings.Realtime.Shutdown.Timeout) * time.Second
+}
+
 // audioLevelChan is a channel to send audio level updates
 var audioLevelChan = make(chan myaudio.AudioLevelData, 100)
 
@@ -126,6 +150,11 @@ func RealtimeAnalysis(settings *conf.Settings, notificationChan chan handlers.No
 	// Print system details and configuration
 	printSystemDetails(settings)
 
+	// Load the at-rest encryption key before any clip or database access
+	if err := initializeEncryption(settings); err != nil {
+		return err
+	}
+
 	// Initialize database access.
 	dataStore := datastore.New(settings)
 
@@ -181,6 +210,27 @@ func RealtimeAnalysis(settings *conf.Settings, notificationChan chan handlers.No
 
 	// Note: datastore monitoring is automatically started when the database is opened
 
+	// Keep the notification history in the database from now on
+	initializeNotificationHistory(dataStore)
+
+	// Notify of new detections matching subscribed saved searches
+	if initializeSubscriptions(dataStore) {
+		defer notification.SetSubscriptions(nil)
+	}
+
+	// Retry failed outbound deliveries from the database
+	if deliveryQueue := initializeDeliveryQueue(settings, dataStore); deliveryQueue != nil {
+		defer deliveryQueue.Stop()
+	}
+
+	// Record the checksums of uploads and exports
+	if initializeManifest(settings, dataStore) {
+		defer manifest.SetStore(nil)
+	}
+
+	// Install the data budget before any integration starts sending traffic
+	budget := initializeDataBudget(settings)
+
 	// Initialize bird image cache if needed
 	birdImageCache := initializeBirdImageCacheIfNeeded(settings, dataStore, metrics)
 
@@ -268,6 +318,9 @@ func RealtimeAnalysis(settings *conf.Settings, notificationChan chan handlers.No
 	}
 
 	// start audio capture
+	// warm up the analysis model so the first detection is not delayed
+	startModelWarmup(&wg, settings, quitChan)
+
 	startAudioCapture(&wg, settings, quitChan, restartChan, audioLevelChan, soundLevelChan)
 
 	// Sound level monitoring is now managed by the control monitor for hot reload support.
@@ -287,6 +340,89 @@ func RealtimeAnalysis(settings *conf.Settings, notificationChan chan handlers.No
 		startClipCleanupMonitor(&wg, quitChan, dataStore)
 	}
 
+	// start disk-full protective mode monitor
+	if settings.Realtime.Audio.Export.Enabled {
+		startDiskProtectionMonitor(&wg, settings, quitChan, dataStore)
+	}
+
+	// persist data budget usage and send deferred uploads when a new billing period starts
+	if budget != nil {
+		startDataBudgetMonitor(&wg, budget, proc, quitChan)
+	}
+
+	// start delta sync of detections and clips to the aggregator
+	if settings.FieldSync.Enabled {
+		startFieldSync(&wg, settings, dataStore, quitChan)
+	}
+
+	// start the candidate model running in parallel with the production model
+	if settings.BirdNET.Shadow.Enabled {
+		startShadowModel(&wg, settings, dataStore, proc, quitChan)
+	}
+
+	// start the daily digest notification
+	if settings.Notification.DailyDigest.Enabled {
+		startDailyDigest(&wg, settings, dataStore, quitChan)
+	}
+
+	// start daily data quality reports
+	if settings.DataQuality.Enabled {
+		startDataQualityJob(&wg, settings, dataStore, quitChan)
+	}
+
+	// start watching for failed microphones
+	if settings.DataQuality.MicFailure.Enabled {
+		startMicFailureMonitor(&wg, settings, quitChan)
+	}
+
+	// start tracking calibration tone levels
+	if settings.DataQuality.Calibration.Enabled {
+		startCalibrationMonitor(&wg, settings, dataStore, quitChan)
+	}
+
+	// start watching for unusual detection rates
+	if settings.DataQuality.Anomaly.Enabled {
+		startAnomalyMonitor(&wg, settings, dataStore, quitChan)
+	}
+
+	// start watching for missing species that are usually detected daily
+	if settings.DataQuality.Absence.Enabled {
+		startAbsenceMonitor(&wg, settings, dataStore, quitChan)
+	}
+
+	// start the weekly forecast of the birds to expect
+	if settings.Forecast.Notify {
+		startForecastNotifier(&wg, settings, dataStore, proc.GetBirdNET(), quitChan)
+	}
+
+	// start writing the weekly newsletter
+	if settings.Newsletter.Enabled {
+		startNewsletterJob(&wg, settings, dataStore, quitChan)
+	}
+
+	// start writing the soundscape time-lapse of each past day
+	if settings.TimeLapse.Enabled {
+		startTimeLapseJob(&wg, settings, dataStore, quitChan)
+	}
+
+	// start the morning highlights of past years on today's date
+	if settings.OnThisDay.Notify {
+		startOnThisDayNotifier(&wg, settings, dataStore, quitChan)
+	}
+
+	// start maintaining the materialized daily aggregates used by analytics
+	startDailyAggregates(&wg, dataStore, quitChan)
+
+	// start thinning old detections into hourly aggregates
+	if settings.DetectionRetention.Enabled && len(settings.DetectionRetention.Policies) > 0 {
+		startDetectionRetention(&wg, settings, dataStore, quitChan)
+	}
+
+	// start purging detections kept in the trash past the retention period
+	if settings.Trash.Enabled {
+		startTrashPurge(&wg, settings, dataStore, quitChan)
+	}
+
 	// start weather polling
 	if settings.Realtime.Weather.Provider != "none" {
 		startWeatherPolling(&wg, settings, dataStore, metrics, quitChan)
@@ -313,15 +449,17 @@ func RealtimeAnalysis(settings *conf.Settings, notificationChan chan handlers.No
 	for {
 		select {
 		case <-quitChan:
+			shutdownDeadline := getShutdownTimeout(settings)
 			// Add structured logging
 			GetLogger().Info("Initiating graceful shutdown sequence",
-				"shutdown_timeout_seconds", shutdownTimeout.Seconds(),
+				"shutdown_timeout_seconds", shutdownDeadline.Seconds(),
+				"drain_pending", settings.Realtime.Shutdown.DrainPending,
 				"operation", "graceful_shutdown")
 			log.Println("🛑 Initiating graceful shutdown sequence...")
 			shutdownStart := time.Now()
 
 			// Create context with timeout for the entire shutdown process
-			ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+			ctx, cancel := context.WithTimeout(context.Background(), shutdownDeadline)
 
 			// Execute shutdown with context
 			shutdownComplete := make(chan struct{})
@@ -374,6 +512,12 @@ func RealtimeAnalysis(settings *conf.Settings, notificationChan chan handlers.No
 				log.Println("  3️⃣ Stopping analysis buffer monitors...")
 				bufferManager.RemoveAllMonitors()
 
+				// With capture and analysis stopped, flush held detections and let queued
+				// database writes and uploads finish within part of the shutdown deadline
+				if settings.Realtime.Shutdown.DrainPending {
+					drainPendingWork(ctx, proc, shutdownDeadline)
+				}
+
 				if ctx.Err() != nil {
 					// Add structured logging
 					GetLogger().Warn("Shutdown context cancelled after step 3",
@@ -453,168 +597,1195 @@ func RealtimeAnalysis(settings *conf.Settings, notificationChan chan handlers.No
 					return
 				}
 
-				// Step 7: Stop system monitor
-				if systemMonitorRef != nil {
-					// Add structured logging
-					GetLogger().Info("Shutdown step 7: Stopping system monitor",
-						"step", 7,
-						"operation", "shutdown_system_monitor")
-					log.Println("  7️⃣ Stopping system monitor...")
-					systemMonitorRef.Stop()
-				}
+				// Step 7: Stop system monitor
+				if systemMonitorRef != nil {
+					// Add structured logging
+					GetLogger().Info("Shutdown step 7: Stopping system monitor",
+						"step", 7,
+						"operation", "shutdown_system_monitor")
+					log.Println("  7️⃣ Stopping system monitor...")
+					systemMonitorRef.Stop()
+				}
+
+				if ctx.Err() != nil {
+					// Add structured logging
+					GetLogger().Warn("Shutdown context cancelled after step 7",
+						"step", 7,
+						"error", ctx.Err(),
+						"operation", "shutdown_timeout")
+					log.Printf("  ⚠️ Shutdown context cancelled after step 7")
+					return
+				}
+
+				// Step 8: Stop notification service
+				if notification.IsInitialized() {
+					// Add structured logging
+					GetLogger().Info("Shutdown step 8: Stopping notification service",
+						"step", 8,
+						"operation", "shutdown_notification_service")
+					log.Println("  8️⃣ Stopping notification service...")
+					if service := notification.GetService(); service != nil {
+						service.Stop()
+					}
+				}
+
+				if ctx.Err() != nil {
+					// Add structured logging
+					GetLogger().Warn("Shutdown context cancelled after step 8",
+						"step", 8,
+						"error", ctx.Err(),
+						"operation", "shutdown_timeout")
+					log.Printf("  ⚠️ Shutdown context cancelled after step 8")
+					return
+				}
+
+				// Step 9: Delete BirdNET interpreter
+				// Add structured logging
+				GetLogger().Info("Shutdown step 9: Cleaning up BirdNET interpreter",
+					"step", 9,
+					"operation", "shutdown_birdnet_cleanup")
+				log.Println("  9️⃣ Cleaning up BirdNET interpreter...")
+				bn.Delete()
+
+				// Add structured logging
+				GetLogger().Info("Graceful shutdown completed",
+					"duration_ms", time.Since(shutdownStart).Milliseconds(),
+					"operation", "shutdown_complete")
+				log.Printf("✅ Graceful shutdown completed in %v", time.Since(shutdownStart))
+			}()
+
+			// Wait for shutdown to complete or context timeout
+			select {
+			case <-shutdownComplete:
+				// Shutdown completed successfully
+				cancel()
+				return nil
+			case <-ctx.Done():
+				// Add structured logging
+				GetLogger().Warn("Shutdown timeout exceeded, forcing exit",
+					"timeout_seconds", shutdownDeadline.Seconds(),
+					"operation", "shutdown_forced_exit")
+				log.Printf("⚠️ Shutdown timeout exceeded (%v), forcing exit", shutdownDeadline)
+				cancel()
+				return nil
+			}
+
+		case <-restartChan:
+			// Handle the restart signal.
+			// Add structured logging
+			GetLogger().Info("Restarting audio capture",
+				"operation", "restart_audio_capture")
+			fmt.Println("🔄 Restarting audio capture")
+			startAudioCapture(&wg, settings, quitChan, restartChan, audioLevelChan, soundLevelChan)
+		}
+	}
+}
+
+// drainPendingWork drains the processor within a share of the shutdown deadline and
+// reports any work that had to be abandoned.
+func drainPendingWork(ctx context.Context, proc *processor.Processor, deadline time.Duration) {
+	if proc == nil {
+		return
+	}
+
+	drainTimeout := time.Duration(float64(deadline) * drainShare)
+	GetLogger().Info("Draining pending detections and jobs",
+		"drain_timeout_seconds", drainTimeout.Seconds(),
+		"operation", "shutdown_drain")
+	log.Println("  ⏳ Draining pending detections and queued jobs...")
+
+	drainCtx, cancel := context.WithTimeout(ctx, drainTimeout)
+	defer cancel()
+
+	report := proc.Drain(drainCtx)
+	if report.AbandonedJobs > 0 || report.AbandonedClips > 0 {
+		GetLogger().Warn("Shutdown abandoned pending jobs",
+			"abandoned_jobs", report.AbandonedJobs,
+			"abandoned_actions", report.AbandonedActions,
+			"abandoned_clips", report.AbandonedClips,
+			"operation", "shutdown_drain")
+		log.Printf("  ⚠️ Drain incomplete: %s", report.String())
+	} else {
+		log.Printf("  ✅ Drain complete: %s", report.String())
+	}
+}
+
+// startAudioCapture initializes and starts the audio capture routine in a new goroutine.
+func startAudioCapture(wg *sync.WaitGroup, settings *conf.Settings, quitChan, restartChan chan struct{}, audioLevelChan chan myaudio.AudioLevelData, soundLevelChan chan myaudio.SoundLevelData) {
+	// Stop previous demultiplexing goroutine if it exists
+	audioDemuxManager.Stop()
+
+	// Start new demux goroutine
+	doneChan := audioDemuxManager.Start()
+
+	// Create a unified audio channel
+	unifiedAudioChan := make(chan myaudio.UnifiedAudioData, 100)
+	go func() {
+		defer audioDemuxManager.Done()
+
+		// Convert unified audio data back to separate channels for existing handlers
+		for {
+			select {
+			case <-doneChan:
+				// Exit when signaled
+				return
+			case <-quitChan:
+				// Exit when quit signal received
+				return
+			case unifiedData, ok := <-unifiedAudioChan:
+				if !ok {
+					// Channel closed, exit
+					return
+				}
+
+				// Send audio level data to existing audio level channel with safety check
+				select {
+				case <-doneChan:
+					return
+				case <-quitChan:
+					return
+				case audioLevelChan <- unifiedData.AudioLevel:
+				default:
+					// Channel full, drop data
+				}
+
+				// Send sound level data to existing sound level channel if present
+				if unifiedData.SoundLevel != nil {
+					select {
+					case <-doneChan:
+						return
+					case <-quitChan:
+						return
+					case soundLevelChan <- *unifiedData.SoundLevel:
+					default:
+						// Channel full, drop data
+					}
+				}
+			}
+		}
+	}()
+
+	// waitgroup is managed within CaptureAudio
+	if settings.Realtime.Audio.UseAudioCore {
+		// Use new audiocore implementation
+		go func() {
+			// Add structured logging
+			GetLogger().Info("Using new audiocore audio capture system",
+				"audio_system", "audiocore",
+				"operation", "start_audio_capture")
+			log.Println("🎵 Using new audiocore audio capture system")
+			adapter.StartAudioCoreCapture(settings, wg, quitChan, restartChan, unifiedAudioChan)
+		}()
+	} else {
+		// Use existing myaudio implementation
+		go myaudio.CaptureAudio(settings, wg, quitChan, restartChan, unifiedAudioChan)
+	}
+}
+
+// startClipCleanupMonitor initializes and starts the clip cleanup monitoring routine in a new goroutine.
+func startClipCleanupMonitor(wg *sync.WaitGroup, quitChan chan struct{}, dataStore datastore.Interface) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		clipCleanupMonitor(quitChan, dataStore)
+	}()
+}
+
+// initializeEncryption loads the key used for at-rest encryption. A key is also
+// loaded when only previously encrypted clips need to be read.
+func initializeEncryption(settings *conf.Settings) error {
+	cfg := settings.Encryption
+	if cfg.Key == "" && cfg.KeyFile == "" {
+		return nil
+	}
+
+	cipher, err := atrest.LoadKey(cfg.KeyFile, cfg.Key)
+	if err != nil {
+		GetLogger().Error("Failed to load encryption key",
+			"error", err,
+			"key_file", cfg.KeyFile,
+			"operation", "encryption_init")
+		log.Printf("❌ Failed to load encryption key: %v", err)
+		return err
+	}
+
+	atrest.SetActive(cipher)
+	GetLogger().Info("At-rest encryption key loaded",
+		"clips", cfg.Clips,
+		"database", cfg.Database,
+		"operation", "encryption_init")
+	log.Printf("🔐 At-rest encryption: clips %t, database %t", cfg.Clips, cfg.Database)
+	return nil
+}
+
+// initializeNotificationHistory moves the notification history to the
+// database, so notifications survive restarts
+func initializeNotificationHistory(dataStore datastore.Interface) {
+	persister, ok := dataStore.(history.Persister)
+	if !ok || !notification.IsInitialized() {
+		return
+	}
+	service := notification.GetService()
+	if err := service.UseStore(history.NewStore(persister, telemetry.DefaultMaxNotifications)); err != nil {
+		GetLogger().Warn("Failed to persist notification history, keeping it in memory",
+			"error", err,
+			"operation", "notification_history_init")
+		log.Printf("⚠️ Failed to persist notification history: %v", err)
+	}
+}
+
+// initializeSubscriptions evaluates the saved search subscriptions of the
+// datastore with the notification rules
+func initializeSubscriptions(dataStore datastore.Interface) bool {
+	persister, ok := dataStore.(history.SearchPersister)
+	if !ok {
+		return false
+	}
+	notification.SetSubscriptions(history.NewSubscriptionStore(persister))
+	return true
+}
+
+// initializeDeliveryQueue starts the persistent queue of failed outbound
+// deliveries if enabled
+func initializeDeliveryQueue(settings *conf.Settings, dataStore datastore.Interface) *notification.DeliveryQueue {
+	if !settings.Notification.Deliveries.Enabled {
+		return nil
+	}
+	persister, ok := dataStore.(history.DeliveryPersister)
+	if !ok {
+		GetLogger().Error("Datastore does not support the delivery queue",
+			"operation", "delivery_queue_init")
+		return nil
+	}
+
+	queue := notification.NewDeliveryQueue(history.NewDeliveryStore(persister), &settings.Notification.Deliveries, settings.Debug)
+	notification.SetDeliveryQueue(queue)
+	queue.Start()
+	return queue
+}
+
+// initializeManifest records uploads and exports in the manifest if enabled
+func initializeManifest(settings *conf.Settings, dataStore datastore.Interface) bool {
+	if !settings.Manifest.Enabled {
+		return false
+	}
+	store, ok := dataStore.(manifest.Store)
+	if !ok {
+		GetLogger().Error("Datastore does not support the upload and export manifest",
+			"operation", "manifest_init")
+		return false
+	}
+	manifest.SetStore(store)
+	return true
+}
+
+// initializeDataBudget installs the metered connection data budget if enabled
+func initializeDataBudget(settings *conf.Settings) *databudget.Manager {
+	cfg := settings.DataBudget
+	if !cfg.Enabled {
+		return nil
+	}
+
+	var statePath string
+	if configPaths, err := conf.GetDefaultConfigPaths(); err == nil && len(configPaths) > 0 {
+		statePath = filepath.Join(configPaths[0], "databudget.json")
+	}
+
+	essential := make([]databudget.Integration, 0, len(cfg.Essential))
+	for _, name := range cfg.Essential {
+		essential = append(essential, databudget.Integration(name))
+	}
+
+	budget, err := databudget.NewManager(databudget.Config{
+		CapBytes:        int64(cfg.MonthlyCapMB) * 1024 * 1024,
+		ThrottlePercent: cfg.ThrottleThreshold,
+		ResetDay:        cfg.ResetDay,
+		Essential:       essential,
+	}, statePath)
+	if budget == nil {
+		GetLogger().Error("Failed to initialize data budget",
+			"error", err,
+			"operation", "databudget_init")
+		log.Printf("⚠️ Failed to initialize data budget: %v", err)
+		return nil
+	}
+	if err != nil {
+		// Usage could not be restored, the budget starts counting from zero
+		GetLogger().Warn("Failed to restore data budget usage",
+			"error", err,
+			"path", statePath,
+			"operation", "databudget_init")
+		log.Printf("⚠️ Failed to restore data budget usage, starting from zero: %v", err)
+	}
+
+	databudget.SetActive(budget)
+	status := budget.Status()
+	GetLogger().Info("Data budget enabled",
+		"cap_mb", cfg.MonthlyCapMB,
+		"used_bytes", status.UsedBytes,
+		"throttle_percent", status.ThrottlePercent,
+		"period_start", status.PeriodStart,
+		"operation", "databudget_init")
+	log.Printf("📶 Data budget enabled: %.1f of %d MB used this period", float64(status.UsedBytes)/(1024*1024), cfg.MonthlyCapMB)
+
+	return budget
+}
+
+// startDataBudgetMonitor flushes deferred uploads when a new billing period starts
+// and saves usage on shutdown.
+func startDataBudgetMonitor(wg *sync.WaitGroup, budget *databudget.Manager, proc *processor.Processor, quitChan chan struct{}) {
+	budget.SetResetHook(func() {
+		flushed, failed := proc.FlushOfflineQueue()
+		GetLogger().Info("Data budget period reset",
+			"flushed_uploads", flushed,
+			"failed_uploads", failed,
+			"operation", "databudget_reset")
+	})
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		<-quitChan
+		if err := budget.Save(); err != nil {
+			GetLogger().Warn("Failed to save data budget usage",
+				"error", err,
+				"operation", "databudget_save")
+		}
+	}()
+}
+
+// startFieldSync starts pushing new detections and selected clips to the
+// configured aggregator in a new goroutine.
+func startFieldSync(wg *sync.WaitGroup, settings *conf.Settings, dataStore datastore.Interface, quitChan chan struct{}) {
+	store, ok := dataStore.(fieldsync.SourceStore)
+	if !ok {
+		GetLogger().Error("Datastore does not support field sync",
+			"operation", "fieldsync_init")
+		return
+	}
+
+	cfg := settings.FieldSync
+	var statePath string
+	if configPaths, err := conf.GetDefaultConfigPaths(); err == nil && len(configPaths) > 0 {
+		statePath = filepath.Join(configPaths[0], "fieldsync.json")
+	}
+
+	client, err := fieldsync.NewClient(store, fieldsync.ClientOptions{
+		AggregatorURL: cfg.AggregatorURL,
+		Token:         cfg.Token,
+		BatchSize:     cfg.BatchSize,
+		ChunkSize:     cfg.ChunkSizeKB * 1024,
+		ClipRoot:      settings.Realtime.Audio.Export.Path,
+		Clips: fieldsync.ClipSelection{
+			Enabled:       cfg.Clips.Enabled,
+			MinConfidence: cfg.Clips.MinConfidence,
+			Species:       cfg.Clips.Species,
+		},
+		StatePath: statePath,
+	})
+	if client == nil {
+		GetLogger().Error("Failed to initialize field sync",
+			"error", err,
+			"operation", "fieldsync_init")
+		log.Printf("⚠️ Failed to initialize field sync: %v", err)
+		return
+	}
+	if err != nil {
+		GetLogger().Warn("Failed to restore pending field sync clips",
+			"error", err,
+			"path", statePath,
+			"operation", "fieldsync_init")
+	}
+
+	GetLogger().Info("Starting field sync",
+		"aggregator_url", cfg.AggregatorURL,
+		"interval_minutes", cfg.Interval,
+		"clips_enabled", cfg.Clips.Enabled,
+		"operation", "fieldsync_start")
+	log.Printf("🔄 Field sync to %s every %d minutes", cfg.AggregatorURL, cfg.Interval)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		<-quitChan
+		cancel()
+	}()
+	go func() {
+		defer wg.Done()
+		client.Run(ctx, time.Duration(cfg.Interval)*time.Minute, func(report *fieldsync.Report, err error) {
+			switch {
+			case err == nil:
+				if report.Detections > 0 || report.Clips > 0 {
+					GetLogger().Info("Field sync completed",
+						"detections", report.Detections,
+						"clips", report.Clips,
+						"pending_clips", report.PendingClips,
+						"operation", "fieldsync_run")
+				}
+			case errors.Is(err, conf.ErrOfflineMode), ctx.Err() != nil:
+				// Nothing to report, the next run resumes from the aggregator's token
+			default:
+				GetLogger().Warn("Field sync interrupted, will resume on next run",
+					"error", err,
+					"operation", "fieldsync_run")
+			}
+		})
+	}()
+}
+
+// startShadowModel loads the candidate model and starts analyzing the live
+// audio with it in a new goroutine.
+func startShadowModel(wg *sync.WaitGroup, settings *conf.Settings, dataStore datastore.Interface, proc *processor.Processor, quitChan chan struct{}) {
+	store, ok := dataStore.(shadowmodel.Store)
+	if !ok {
+		GetLogger().Error("Datastore does not support the shadow model",
+			"operation", "shadowmodel_init")
+		return
+	}
+
+	runner, err := shadowmodel.New(settings, store)
+	if err != nil {
+		GetLogger().Error("Failed to initialize shadow model",
+			"error", err,
+			"model_path", settings.BirdNET.Shadow.ModelPath,
+			"operation", "shadowmodel_init")
+		log.Printf("⚠️ Failed to initialize shadow model: %v", err)
+		return
+	}
+
+	GetLogger().Info("Starting shadow model",
+		"model_path", runner.Model(),
+		"trial_days", settings.BirdNET.Shadow.TrialDays,
+		"operation", "shadowmodel_start")
+	log.Printf("🧪 Shadow model %s analyzing live audio in parallel", runner.Model())
+
+	proc.SetShadowModel(runner)
+	myaudio.SetShadowAnalyzer(runner)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		<-quitChan
+		cancel()
+	}()
+	go func() {
+		defer wg.Done()
+		runner.Run(ctx)
+		myaudio.SetShadowAnalyzer(nil)
+		runner.Close()
+	}()
+}
+
+// startDataQualityJob starts generating the data quality report of each day in a new goroutine.
+func startDataQualityJob(wg *sync.WaitGroup, settings *conf.Settings, dataStore datastore.Interface, quitChan chan struct{}) {
+	store, ok := dataStore.(dataquality.Store)
+	if !ok {
+		GetLogger().Error("Datastore does not support data quality reports",
+			"operation", "dataquality_init")
+		return
+	}
+
+	builder := dataquality.NewBuilder(dataquality.ConfigFromSettings(&settings.DataQuality), dataStore)
+	job := dataquality.NewJob(builder, store)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		<-quitChan
+		cancel()
+	}()
+	go func() {
+		defer wg.Done()
+		job.Run(ctx, func(report *dataquality.Report, err error) {
+			if err != nil {
+				GetLogger().Warn("Failed to generate data quality report",
+					"error", err,
+					"operation", "dataquality_report")
+				return
+			}
+			GetLogger().Info("Data quality report generated",
+				"date", report.Date,
+				"flags", report.Flags,
+				"operation", "dataquality_report")
+			if len(report.Flags) > 0 {
+				log.Printf("⚠️ Data quality issues on %s: %s", report.Date, strings.Join(report.Flags, ", "))
+			}
+		})
+	}()
+}
+
+// startDailyDigest sends a summary notification of the day's detections at
+// the configured time each day, in a new goroutine.
+func startDailyDigest(wg *sync.WaitGroup, settings *conf.Settings, dataStore datastore.Interface, quitChan chan struct{}) {
+	store, ok := dataStore.(digest.Store)
+	if !ok {
+		GetLogger().Error("Datastore does not support the daily digest",
+			"operation", "daily_digest_init")
+		return
+	}
+
+	job := digest.New(settings, store)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		<-quitChan
+		cancel()
+	}()
+	go func() {
+		defer wg.Done()
+		job.Run(ctx, func(n *notification.Notification, err error) {
+			if err != nil {
+				GetLogger().Warn("Failed to send daily digest",
+					"error", err,
+					"operation", "daily_digest")
+				return
+			}
+			GetLogger().Info("Daily digest sent",
+				"date", n.Metadata[digest.MetadataKeyDailyDigest],
+				"operation", "daily_digest")
+		})
+	}()
+}
+
+// dailyAggregator is the datastore capability of maintaining daily aggregates
+type dailyAggregator interface {
+	BuildDailyAggregates(ctx context.Context) error
+	RefreshDailyAggregates(ctx context.Context) error
+}
+
+// dailyAggregatesRefreshInterval is how often changed days are re-aggregated
+// in the background, analytics queries refresh outdated days themselves
+const dailyAggregatesRefreshInterval = time.Minute
+
+// startDailyAggregates builds the materialized daily aggregates in a new
+// goroutine and then keeps them up to date with new detections.
+func startDailyAggregates(wg *sync.WaitGroup, dataStore datastore.Interface, quitChan chan struct{}) {
+	aggregator, ok := dataStore.(dailyAggregator)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		<-quitChan
+		cancel()
+	}()
+	go func() {
+		defer wg.Done()
+		start := time.Now()
+		if err := aggregator.BuildDailyAggregates(ctx); err != nil {
+			if ctx.Err() == nil {
+				GetLogger().Warn("Failed to build daily aggregates, analytics will scan detections",
+					"error", err,
+					"operation", "daily_aggregates_build")
+			}
+			return
+		}
+		GetLogger().Info("Daily aggregates built",
+			"duration", time.Since(start),
+			"operation", "daily_aggregates_build")
+
+		ticker := time.NewTicker(dailyAggregatesRefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if err := aggregator.RefreshDailyAggregates(ctx); err != nil && ctx.Err() == nil {
+				GetLogger().Warn("Failed to refresh daily aggregates",
+					"error", err,
+					"operation", "daily_aggregates_refresh")
+			}
+		}
+	}()
+}
+
+// detectionThinner is the datastore capability needed by detection retention
+type detectionThinner interface {
+	ThinDetections(ctx context.Context, policy *datastore.ThinningPolicy) (*datastore.ThinningResult, error)
+}
+
+// detectionRetentionStartDelay delays the first detection retention run after
+// startup, so restarts do not thin detections right away
+const detectionRetentionStartDelay = 15 * time.Minute
+
+// startDetectionRetention starts applying the detection retention policies
+// once a day in a new goroutine, replacing old detections with hourly aggregates.
+func startDetectionRetention(wg *sync.WaitGroup, settings *conf.Settings, dataStore datastore.Interface, quitChan chan struct{}) {
+	thinner, ok := dataStore.(detectionThinner)
+	if !ok {
+		GetLogger().Error("Datastore does not support detection retention",
+			"operation", "detection_retention_init")
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		<-quitChan
+		cancel()
+	}()
+	go func() {
+		defer wg.Done()
+		timer := time.NewTimer(detectionRetentionStartDelay)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		ticker := time.NewTicker(24 * time.Hour)
+		defer ticker.Stop()
+		for {
+			applyDetectionRetention(ctx, settings, thinner)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// applyDetectionRetention applies the detection retention policies once.
+// Nothing is deleted while read-only mode is active.
+func applyDetectionRetention(ctx context.Context, settings *conf.Settings, thinner detectionThinner) {
+	if conf.IsReadOnly() {
+		GetLogger().Debug("Read-only mode enabled, skipping detection retention",
+			"operation", "detection_retention_skipped")
+		return
+	}
+
+	for i := range settings.DetectionRetention.Policies {
+		policy := &settings.DetectionRetention.Policies[i]
+		result, err := thinner.ThinDetections(ctx, &datastore.ThinningPolicy{
+			Before:        time.Now().AddDate(0, 0, -policy.MaxAgeDays).Format(time.DateOnly),
+			Species:       policy.Species,
+			MinDetections: policy.MinDetections,
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			GetLogger().Warn("Failed to apply detection retention policy",
+				"policy", policy.Name,
+				"error", err,
+				"operation", "detection_retention")
+			continue
+		}
+		if result.Detections > 0 {
+			GetLogger().Info("Detection retention policy applied",
+				"policy", policy.Name,
+				"detections", result.Detections,
+				"days", result.Days,
+				"operation", "detection_retention")
+			log.Printf("🗜️ Detection retention %q: %d detections on %d days replaced by hourly aggregates", policy.Name, result.Detections, result.Days)
+		}
+	}
+}
+
+// trashPurger is the datastore capability needed by the trash purge
+type trashPurger interface {
+	PurgeTrash(before time.Time) (*datastore.PurgeResult, error)
+}
+
+// startTrashPurge starts permanently removing detections that have been in the
+// trash longer than the retention period once a day in a new goroutine,
+// together with their clips.
+func startTrashPurge(wg *sync.WaitGroup, settings *conf.Settings, dataStore datastore.Interface, quitChan chan struct{}) {
+	purger, ok := dataStore.(trashPurger)
+	if !ok {
+		GetLogger().Error("Datastore does not support the detection trash",
+			"operation", "trash_purge_init")
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		<-quitChan
+		cancel()
+	}()
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(24 * time.Hour)
+		defer ticker.Stop()
+
+		for {
+			purgeTrash(settings, purger)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// purgeTrash permanently deletes the detections trashed before the retention
+// period and their clips. Nothing is deleted while read-only mode is active.
+func purgeTrash(settings *conf.Settings, purger trashPurger) {
+	if conf.IsReadOnly() {
+		GetLogger().Debug("Read-only mode enabled, skipping detection trash purge",
+			"operation", "trash_purge_skipped")
+		return
+	}
+
+	result, err := purger.PurgeTrash(time.Now().AddDate(0, 0, -settings.Trash.RetentionDays))
+	switch {
+	case err != nil:
+		GetLogger().Warn("Failed to purge detection trash",
+			"error", err,
+			"operation", "trash_purge")
+	case result.Counts.Detections > 0:
+		removed := removeTrashedClips(settings.Realtime.Audio.Export.Path, result.ClipNames)
+		GetLogger().Info("Detection trash purged",
+			"detections", result.Counts.Detections,
+			"files_removed", removed,
+			"retention_days", settings.Trash.RetentionDays,
+			"operation", "trash_purge")
+	}
+}
+
+// removeTrashedClips removes the clips of purged detections with their
+// spectrograms and returns the number of removed files
+func removeTrashedClips(exportPath string, clipNames []string) int {
+	removed := 0
+	for _, clip := range clipNames {
+		if exportPath == "" || !filepath.IsLocal(clip) {
+			continue
+		}
+		path := filepath.Join(exportPath, clip)
+		for _, file := range []string{path, strings.TrimSuffix(path, filepath.Ext(path)) + ".png"} {
+			if os.Remove(file) == nil {
+				removed++
+			}
+		}
+	}
+	return removed
+}
+
+// startMicFailureMonitor starts watching the audio of every source for signs of
+// a failed microphone in a new goroutine, raising a high priority notification
+// when one is found.
+func startMicFailureMonitor(wg *sync.WaitGroup, settings *conf.Settings, quitChan chan struct{}) {
+	monitor := dataquality.NewMicMonitor(dataquality.MicConfigFromSettings(&settings.DataQuality.MicFailure))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		<-quitChan
+		cancel()
+	}()
+	go func() {
+		defer wg.Done()
+		monitor.Run(ctx, func(status dataquality.MicStatus) {
+			name := status.Source
+			if registry := myaudio.GetRegistry(); registry != nil {
+				if source, exists := registry.GetSourceByID(status.Source); exists {
+					name = source.DisplayName
+				}
+			}
+
+			if status.Failure == "" {
+				GetLogger().Info("Microphone recovered",
+					"source", status.Source,
+					"operation", "mic_failure_check")
+				log.Printf("🎤 Microphone on %s is working again", name)
+				notification.NotifyInfo("Microphone Recovered", fmt.Sprintf("Audio from %s looks normal again", name))
+				return
+			}
+
+			GetLogger().Warn("Microphone has likely failed",
+				"source", status.Source,
+				"failure", status.Failure,
+				"detail", status.Detail,
+				"since", status.Since,
+				"operation", "mic_failure_check")
+			log.Printf("⚠️ Microphone on %s has likely failed (%s): %s", name, status.Failure, status.Detail)
+			notification.NotifySystemAlert(notification.PriorityHigh, "Microphone Failure Suspected",
+				fmt.Sprintf("The microphone on %s has likely failed since %s: %s", name, status.Since.Format(time.DateTime), status.Detail))
+		})
+	}()
+}
+
+// startAnomalyMonitor starts checking each completed hour for unusual detection
+// rates in a new goroutine. Silences and spikes of all species together raise
+// a warning as they may point to equipment issues, spikes of a single species
+// an informational notification.
+func startAnomalyMonitor(wg *sync.WaitGroup, settings *conf.Settings, dataStore datastore.Interface, quitChan chan struct{}) {
+	store, ok := dataStore.(dataquality.AnomalyStore)
+	if !ok {
+		GetLogger().Error("Datastore does not support hourly detection counts",
+			"operation", "anomaly_init")
+		return
+	}
+
+	detector := dataquality.NewAnomalyDetector(dataquality.AnomalyConfigFromSettings(&settings.DataQuality.Anomaly), store)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		<-quitChan
+		cancel()
+	}()
+	go func() {
+		defer wg.Done()
+		detector.Run(ctx, func(anomaly *dataquality.Anomaly, err error) {
+			if err != nil {
+				GetLogger().Warn("Failed to check detection rates for anomalies",
+					"error", err,
+					"operation", "anomaly_check")
+				return
+			}
+
+			hour := anomaly.Hour.Format("15:04")
+			GetLogger().Info("Detection rate anomaly",
+				"kind", anomaly.Kind,
+				"hour", anomaly.Hour,
+				"species", anomaly.ScientificName,
+				"count", anomaly.Count,
+				"expected", anomaly.Expected,
+				"score", anomaly.Score,
+				"operation", "anomaly_check")
+
+			switch {
+			case anomaly.ScientificName != "":
+				notification.NotifyInfo("Unusual Activity: "+anomaly.CommonName,
+					fmt.Sprintf("%d detections of %s at %s, typically %.1f", anomaly.Count, anomaly.CommonName, hour, anomaly.Expected))
+			case anomaly.Kind == dataquality.AnomalySilence:
+				log.Printf("⚠️ Unusually few detections at %s: %d, typically %.1f", hour, anomaly.Count, anomaly.Expected)
+				notification.NotifyWarning("dataquality", "Unusually Few Detections",
+					fmt.Sprintf("Only %d detections at %s while %.1f are typical, check the microphone and recorder", anomaly.Count, hour, anomaly.Expected))
+			default:
+				log.Printf("⚠️ Unusually many detections at %s: %d, typically %.1f", hour, anomaly.Count, anomaly.Expected)
+				notification.NotifyWarning("dataquality", "Unusually Many Detections",
+					fmt.Sprintf("%d detections at %s while %.1f are typical, possibly noise or a notable bird event", anomaly.Count, hour, anomaly.Expected))
+			}
+		})
+	}()
+}
+
+// startAbsenceMonitor starts checking each completed day for usually daily
+// species that have not been detected for the configured days in a new
+// goroutine. Several species missing at once raise a single warning, as they
+// more likely point to equipment issues than to local changes.
+func startAbsenceMonitor(wg *sync.WaitGroup, settings *conf.Settings, dataStore datastore.Interface, quitChan chan struct{}) {
+	store, ok := dataStore.(dataquality.AnomalyStore)
+	if !ok {
+		GetLogger().Error("Datastore does not support hourly detection counts",
+			"operation", "absence_init")
+		return
+	}
+
+	monitor := dataquality.NewAbsenceMonitor(dataquality.AbsenceConfigFromSettings(&settings.DataQuality.Absence), store)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		<-quitChan
+		cancel()
+	}()
+	go func() {
+		defer wg.Done()
+		monitor.Run(ctx, func(absences []dataquality.Absence, err error) {
+			if err != nil {
+				GetLogger().Warn("Failed to check for absent species",
+					"error", err,
+					"operation", "absence_check")
+				return
+			}
+
+			names := make([]string, len(absences))
+			for i := range absences {
+				names[i] = absences[i].CommonName
+				GetLogger().Info("Usually daily species absent",
+					"species", absences[i].ScientificName,
+					"last_seen", absences[i].LastSeen,
+					"days_absent", absences[i].DaysAbsent,
+					"presence", absences[i].Presence,
+					"operation", "absence_check")
+			}
+
+			if len(absences) == 1 {
+				absence := &absences[0]
+				notification.NotifyWarning("dataquality", "Species Missing: "+absence.CommonName,
+					fmt.Sprintf("%s was detected on %.0f%% of days but not since %s (%d days), which may point to local changes or equipment issues",
+						absence.CommonName, absence.Presence*100, absence.LastSeen, absence.DaysAbsent))
+				return
+			}
+			log.Printf("⚠️ %d usually daily species not detected for %d days: %s", len(absences), settings.DataQuality.Absence.Days, strings.Join(names, ", "))
+			notification.NotifyWarning("dataquality", "Several Species Missing",
+				fmt.Sprintf("%d usually daily species have not been detected for %d days: %s. Check the microphone and recorder",
+					len(absences), settings.DataQuality.Absence.Days, strings.Join(names, ", ")))
+		})
+	}()
+}
+
+// startForecastNotifier starts sending the species expected in the coming
+// days as an informational notification once a week in a new goroutine.
+func startForecastNotifier(wg *sync.WaitGroup, settings *conf.Settings, dataStore datastore.Interface, bn *birdnet.BirdNET, quitChan chan struct{}) {
+	weekday, err := conf.ParseWeekday(settings.Forecast.NotifyWeekday)
+	if err != nil {
+		GetLogger().Error("Invalid forecast notification weekday",
+			"weekday", settings.Forecast.NotifyWeekday,
+			"error", err,
+			"operation", "forecast_init")
+		return
+	}
+
+	var rangeFilter forecast.RangeFilter
+	if bn != nil {
+		rangeFilter = bn
+	}
+	forecaster := forecast.New(forecast.ConfigFromSettings(&settings.Forecast), dataStore, rangeFilter)
+	notifier := forecast.NewNotifier(forecaster, weekday, settings.Forecast.NotifyHour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		<-quitChan
+		cancel()
+	}()
+	go func() {
+		defer wg.Done()
+		notifier.Run(ctx, func(result *forecast.Forecast, err error) {
+			if err != nil {
+				GetLogger().Warn("Failed to forecast species",
+					"error", err,
+					"operation", "forecast_notify")
+				return
+			}
+
+			GetLogger().Info("Species forecast",
+				"start", result.Start,
+				"end", result.End,
+				"species", len(result.Species),
+				"years_with_data", result.YearsWithData,
+				"operation", "forecast_notify")
+			notification.NotifyInfo("Birds to Expect This Week", forecast.Message(result, settings.Forecast.NotifyLimit))
+		})
+	}()
+}
 
-				if ctx.Err() != nil {
-					// Add structured logging
-					GetLogger().Warn("Shutdown context cancelled after step 7",
-						"step", 7,
-						"error", ctx.Err(),
-						"operation", "shutdown_timeout")
-					log.Printf("  ⚠️ Shutdown context cancelled after step 7")
-					return
-				}
+// startNewsletterJob starts writing the newsletter of each past week to the
+// newsletter directory in a new goroutine.
+func startNewsletterJob(wg *sync.WaitGroup, settings *conf.Settings, dataStore datastore.Interface, quitChan chan struct{}) {
+	builder := newsletter.NewBuilder(newsletter.ConfigFromSettings(settings), dataStore)
+	job := newsletter.NewJob(builder, settings.Newsletter.Path, settings.Newsletter.Hour)
 
-				// Step 8: Stop notification service
-				if notification.IsInitialized() {
-					// Add structured logging
-					GetLogger().Info("Shutdown step 8: Stopping notification service",
-						"step", 8,
-						"operation", "shutdown_notification_service")
-					log.Println("  8️⃣ Stopping notification service...")
-					if service := notification.GetService(); service != nil {
-						service.Stop()
-					}
-				}
+	ctx, cancel := context.WithCancel(context.Background())
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		<-quitChan
+		cancel()
+	}()
+	go func() {
+		defer wg.Done()
+		job.Run(ctx, func(path string, n *newsletter.Newsletter, err error) {
+			if err != nil {
+				GetLogger().Warn("Failed to generate weekly newsletter",
+					"error", err,
+					"operation", "newsletter_generate")
+				return
+			}
+			GetLogger().Info("Weekly newsletter generated",
+				"path", path,
+				"week", n.Start,
+				"detections", n.Detections,
+				"species", n.Species,
+				"operation", "newsletter_generate")
+		})
+	}()
+}
 
-				if ctx.Err() != nil {
-					// Add structured logging
-					GetLogger().Warn("Shutdown context cancelled after step 8",
-						"step", 8,
-						"error", ctx.Err(),
-						"operation", "shutdown_timeout")
-					log.Printf("  ⚠️ Shutdown context cancelled after step 8")
-					return
-				}
+// startTimeLapseJob starts writing the soundscape time-lapse of each past day
+// to the time-lapse directory in a new goroutine. With notify set the files of
+// a new time-lapse are sent in the metadata of an informational notification.
+func startTimeLapseJob(wg *sync.WaitGroup, settings *conf.Settings, dataStore datastore.Interface, quitChan chan struct{}) {
+	generator := timelapse.NewGenerator(timelapse.ConfigFromSettings(settings), dataStore)
+	job := timelapse.NewJob(generator, settings.TimeLapse.Path, settings.TimeLapse.Hour)
 
-				// Step 9: Delete BirdNET interpreter
-				// Add structured logging
-				GetLogger().Info("Shutdown step 9: Cleaning up BirdNET interpreter",
-					"step", 9,
-					"operation", "shutdown_birdnet_cleanup")
-				log.Println("  9️⃣ Cleaning up BirdNET interpreter...")
-				bn.Delete()
+	ctx, cancel := context.WithCancel(context.Background())
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		<-quitChan
+		cancel()
+	}()
+	go func() {
+		defer wg.Done()
+		job.Run(ctx, func(tl *timelapse.TimeLapse, err error) {
+			if err != nil {
+				GetLogger().Warn("Failed to generate soundscape time-lapse",
+					"error", err,
+					"operation", "timelapse_generate")
+				return
+			}
+			GetLogger().Info("Soundscape time-lapse generated",
+				"date", tl.Date,
+				"path", tl.AudioPath,
+				"hours", len(tl.Segments),
+				"operation", "timelapse_generate")
 
-				// Add structured logging
-				GetLogger().Info("Graceful shutdown completed",
-					"duration_ms", time.Since(shutdownStart).Milliseconds(),
-					"operation", "shutdown_complete")
-				log.Printf("✅ Graceful shutdown completed in %v", time.Since(shutdownStart))
-			}()
+			if !settings.TimeLapse.Notify || !notification.IsInitialized() {
+				return
+			}
+			if service := notification.GetService(); service != nil {
+				n := notification.NewNotification(notification.TypeInfo, notification.PriorityLow,
+					"Soundscape Time-Lapse", timelapse.Message(tl)).
+					WithComponent("timelapse").
+					WithMetadata("date", tl.Date).
+					WithMetadata("audio_path", tl.AudioPath).
+					WithMetadata("image_path", tl.ImagePath)
+				_ = service.CreateWithMetadata(n)
+			}
+		})
+	}()
+}
 
-			// Wait for shutdown to complete or context timeout
-			select {
-			case <-shutdownComplete:
-				// Shutdown completed successfully
-				cancel()
-				return nil
-			case <-ctx.Done():
-				// Add structured logging
-				GetLogger().Warn("Shutdown timeout exceeded, forcing exit",
-					"timeout_seconds", shutdownTimeout.Seconds(),
-					"operation", "shutdown_forced_exit")
-				log.Printf("⚠️ Shutdown timeout exceeded (%v), forcing exit", shutdownTimeout)
-				cancel()
-				return nil
+// startOnThisDayNotifier starts sending the first-ever detections and rarities
+// of past years on today's date as an informational notification every
+// morning in a new goroutine. Nothing is sent on days without highlights.
+func startOnThisDayNotifier(wg *sync.WaitGroup, settings *conf.Settings, dataStore datastore.Interface, quitChan chan struct{}) {
+	notifier := onthisday.NewNotifier(onthisday.ConfigFromSettings(&settings.OnThisDay), dataStore, settings.OnThisDay.NotifyHour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		<-quitChan
+		cancel()
+	}()
+	go func() {
+		defer wg.Done()
+		notifier.Run(ctx, func(result *onthisday.OnThisDay, err error) {
+			if err != nil {
+				GetLogger().Warn("Failed to find the highlights of past years",
+					"error", err,
+					"operation", "onthisday_notify")
+				return
 			}
 
-		case <-restartChan:
-			// Handle the restart signal.
-			// Add structured logging
-			GetLogger().Info("Restarting audio capture",
-				"operation", "restart_audio_capture")
-			fmt.Println("🔄 Restarting audio capture")
-			startAudioCapture(&wg, settings, quitChan, restartChan, audioLevelChan, soundLevelChan)
-		}
-	}
+			GetLogger().Info("On this day highlights",
+				"date", result.Date,
+				"years", len(result.Years),
+				"highlights", result.Highlights(),
+				"operation", "onthisday_notify")
+			if message := onthisday.Message(result); message != "" {
+				notification.NotifyInfo("On This Day", message)
+			}
+		})
+	}()
 }
 
-// startAudioCapture initializes and starts the audio capture routine in a new goroutine.
-func startAudioCapture(wg *sync.WaitGroup, settings *conf.Settings, quitChan, restartChan chan struct{}, audioLevelChan chan myaudio.AudioLevelData, soundLevelChan chan myaudio.SoundLevelData) {
-	// Stop previous demultiplexing goroutine if it exists
-	audioDemuxManager.Stop()
+// startCalibrationMonitor starts measuring calibration tones and storing their
+// level in a new goroutine, raising a high priority notification when the input
+// gain of a source drifts beyond the tolerance.
+func startCalibrationMonitor(wg *sync.WaitGroup, settings *conf.Settings, dataStore datastore.Interface, quitChan chan struct{}) {
+	store, ok := dataStore.(dataquality.CalibrationStore)
+	if !ok {
+		GetLogger().Error("Datastore does not support calibration measurements",
+			"operation", "calibration_init")
+		return
+	}
 
-	// Start new demux goroutine
-	doneChan := audioDemuxManager.Start()
+	myaudio.ConfigureCalibrationTone(&settings.DataQuality.Calibration)
+	monitor := dataquality.NewCalibrationMonitor(dataquality.CalibrationConfigFromSettings(&settings.DataQuality.Calibration), store)
 
-	// Create a unified audio channel
-	unifiedAudioChan := make(chan myaudio.UnifiedAudioData, 100)
+	ctx, cancel := context.WithCancel(context.Background())
+	wg.Add(2)
 	go func() {
-		defer audioDemuxManager.Done()
-
-		// Convert unified audio data back to separate channels for existing handlers
-		for {
-			select {
-			case <-doneChan:
-				// Exit when signaled
-				return
-			case <-quitChan:
-				// Exit when quit signal received
+		defer wg.Done()
+		<-quitChan
+		cancel()
+	}()
+	go func() {
+		defer wg.Done()
+		defer myaudio.ConfigureCalibrationTone(&conf.CalibrationSettings{})
+		monitor.Run(ctx, func(status *dataquality.CalibrationStatus, err error) {
+			if err != nil {
+				GetLogger().Warn("Failed to store calibration measurement",
+					"error", err,
+					"operation", "calibration_check")
 				return
-			case unifiedData, ok := <-unifiedAudioChan:
-				if !ok {
-					// Channel closed, exit
-					return
-				}
+			}
 
-				// Send audio level data to existing audio level channel with safety check
-				select {
-				case <-doneChan:
-					return
-				case <-quitChan:
-					return
-				case audioLevelChan <- unifiedData.AudioLevel:
-				default:
-					// Channel full, drop data
+			name := status.Source
+			if registry := myaudio.GetRegistry(); registry != nil {
+				if source, exists := registry.GetSourceByID(status.Source); exists {
+					name = source.DisplayName
 				}
+			}
 
-				// Send sound level data to existing sound level channel if present
-				if unifiedData.SoundLevel != nil {
-					select {
-					case <-doneChan:
-						return
-					case <-quitChan:
-						return
-					case soundLevelChan <- *unifiedData.SoundLevel:
-					default:
-						// Channel full, drop data
-					}
-				}
+			if !status.Drifted {
+				GetLogger().Info("Input gain back within calibration tolerance",
+					"source", status.Source,
+					"drift_db", status.Drift,
+					"operation", "calibration_check")
+				log.Printf("🎚️ Input gain on %s is back within tolerance (%+.1f dB)", name, status.Drift)
+				notification.NotifyInfo("Input Gain Recovered", fmt.Sprintf("The calibration tone on %s is back within tolerance (%+.1f dB)", name, status.Drift))
+				return
 			}
-		}
+
+			GetLogger().Warn("Input gain drifted beyond calibration tolerance",
+				"source", status.Source,
+				"level_dbfs", status.Level,
+				"reference_dbfs", status.Reference,
+				"drift_db", status.Drift,
+				"operation", "calibration_check")
+			log.Printf("⚠️ Input gain on %s drifted %+.1f dB from the calibration reference", name, status.Drift)
+			notification.NotifySystemAlert(notification.PriorityHigh, "Input Gain Drift",
+				fmt.Sprintf("The calibration tone on %s measured %.1f dBFS at %s, %+.1f dB from the reference of %.1f dBFS. Recordings may no longer be comparable.",
+					name, status.Level, status.MeasuredAt.Format(time.DateTime), status.Drift, status.Reference))
+		})
 	}()
+}
 
-	// waitgroup is managed within CaptureAudio
-	if settings.Realtime.Audio.UseAudioCore {
-		// Use new audiocore implementation
-		go func() {
-			// Add structured logging
-			GetLogger().Info("Using new audiocore audio capture system",
-				"audio_system", "audiocore",
-				"operation", "start_audio_capture")
-			log.Println("🎵 Using new audiocore audio capture system")
-			adapter.StartAudioCoreCapture(settings, wg, quitChan, restartChan, unifiedAudioChan)
-		}()
-	} else {
-		// Use existing myaudio implementation
-		go myaudio.CaptureAudio(settings, wg, quitChan, restartChan, unifiedAudioChan)
+// startDiskProtectionMonitor installs the disk-full protective guard and starts
+// monitoring free space on the clip export path in a new goroutine.
+func startDiskProtectionMonitor(wg *sync.WaitGroup, settings *conf.Settings, quitChan chan struct{}, dataStore datastore.Interface) {
+	guard, err := diskmanager.NewProtectiveGuardFromSettings(settings)
+	if err != nil {
+		GetLogger().Error("Failed to initialize disk protective mode",
+			"error", err,
+			"operation", "disk_protection_init")
+		log.Printf("⚠️ Failed to initialize disk protective mode: %v", err)
+		return
+	}
+	if guard == nil {
+		return
+	}
+	diskmanager.SetProtectiveGuard(guard)
+
+	checkInterval := settings.Realtime.Audio.Export.DiskProtection.CheckInterval
+	if checkInterval <= 0 {
+		checkInterval = conf.DefaultDiskProtectionCheckInterval
 	}
-}
 
-// startClipCleanupMonitor initializes and starts the clip cleanup monitoring routine in a new goroutine.
-func startClipCleanupMonitor(wg *sync.WaitGroup, quitChan chan struct{}, dataStore datastore.Interface) {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		clipCleanupMonitor(quitChan, dataStore)
+		diskProtectionMonitor(guard, time.Duration(checkInterval)*time.Second, quitChan, dataStore)
 	}()
 }
 
@@ -849,6 +2020,102 @@ func clipCleanupMonitor(quitChan chan struct{}, dataStore datastore.Interface) {
 	}
 }
 
+// diskProtectionMonitor periodically checks free space on the clip export path. When free
+// space drops below the configured floor clip writing is suspended, a critical notification
+// is sent and emergency cleanup runs until the resume threshold is reached.
+func diskProtectionMonitor(guard *diskmanager.ProtectiveGuard, interval time.Duration, quitChan chan struct{}, dataStore datastore.Interface) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	state := guard.State()
+	GetLogger().Info("Disk protection monitor initialized",
+		"path", state.Path,
+		"min_free_bytes", state.MinFreeBytes,
+		"resume_free_bytes", state.ResumeFreeBytes,
+		"check_interval", interval.String(),
+		"operation", "disk_protection_init")
+
+	check := func() {
+		changed, active, err := guard.Check()
+		if err != nil {
+			GetLogger().Warn("Failed to check free disk space",
+				"error", err,
+				"operation", "disk_protection_check")
+			return
+		}
+		if !changed && !active {
+			return
+		}
+		if changed && !active {
+			GetLogger().Info("Disk protective mode deactivated, resuming clip writes",
+				"operation", "disk_protection_deactivated")
+			log.Println("✅ Free disk space recovered, resuming audio clip writes")
+			notifyDiskProtection(notification.TypeInfo, notification.PriorityMedium,
+				"Disk space recovered",
+				"Free disk space has recovered above the resume threshold. Audio clip saving has resumed.")
+			return
+		}
+
+		if changed {
+			state := guard.State()
+			GetLogger().Error("Disk protective mode activated, suspending clip writes",
+				"path", state.Path,
+				"free_bytes", state.FreeBytes,
+				"min_free_bytes", state.MinFreeBytes,
+				"operation", "disk_protection_activated")
+			log.Printf("🚨 Free disk space below %d bytes, suspending audio clip writes", state.MinFreeBytes)
+			notifyDiskProtection(notification.TypeError, notification.PriorityCritical,
+				"Disk almost full",
+				fmt.Sprintf("Free space on %s is %d MB. Audio clip saving is suspended and emergency cleanup has started. Detections are still recorded.",
+					state.Path, state.FreeBytes/(1024*1024)))
+		}
+
+		// Keep freeing space while protective mode is active
+		result := diskmanager.EmergencyCleanup(quitChan, dataStore, guard.State().Path, guard.ResumeFreeBytes())
+		if result.Err != nil {
+			GetLogger().Error("Emergency cleanup failed",
+				"error", result.Err,
+				"operation", "emergency_cleanup")
+			return
+		}
+		if result.ClipsRemoved > 0 {
+			log.Printf("🧹 Emergency cleanup removed %d clips, current disk utilization: %d%%", result.ClipsRemoved, result.DiskUtilization)
+			// Re-check right away so clip writes resume without waiting for the next tick
+			if changed, active, err := guard.Check(); err == nil && changed && !active {
+				log.Println("✅ Free disk space recovered, resuming audio clip writes")
+				notifyDiskProtection(notification.TypeInfo, notification.PriorityMedium,
+					"Disk space recovered",
+					"Emergency cleanup freed enough disk space. Audio clip saving has resumed.")
+			}
+		}
+	}
+
+	// Check once at startup so a full disk is caught before the first clip is written
+	check()
+
+	for {
+		select {
+		case <-quitChan:
+			diskmanager.SetProtectiveGuard(nil)
+			return
+		case <-ticker.C:
+			check()
+		}
+	}
+}
+
+// notifyDiskProtection sends a disk protective mode notification if the notification service is available
+func notifyDiskProtection(notifType notification.Type, priority notification.Priority, title, message string) {
+	if !notification.IsInitialized() {
+		return
+	}
+	if _, err := notification.GetService().CreateWithComponent(notifType, priority, title, message, "diskmanager"); err != nil {
+		GetLogger().Warn("Failed to send disk protection notification",
+			"error", err,
+			"operation", "disk_protection_notify")
+	}
+}
+
 // NOTE: Potential Race Condition: If multiple goroutines call this function concurrently,
 // especially during initial startup, there's a risk of race conditions during provider
 // registration (checking Get then Register is not atomic). Consider using sync.Once
@@ -1006,6 +2273,32 @@ func setupImageProviderRegistry(ds datastore.Interface, metrics *observability.M
 		log.Println("Using existing AviCommons image provider")
 	}
 
+	// Attempt to register the offline image bundle if one is configured
+	if bundlePath := conf.Setting().Realtime.Dashboard.Thumbnails.ImageBundle; bundlePath != "" {
+		if _, ok := registry.GetCache(imageprovider.LocalBundleProviderName); !ok {
+			if err := imageprovider.RegisterLocalBundleProvider(registry, bundlePath, metrics, ds); err != nil {
+				GetLogger().Error("Failed to register offline image bundle",
+					"error", err,
+					"provider", imageprovider.LocalBundleProviderName,
+					"bundle_path", bundlePath,
+					"operation", "register_image_provider")
+				log.Printf("Failed to register offline image bundle %s: %v", bundlePath, err)
+				errs = append(errs, errors.New(err).
+					Component("realtime-analysis").
+					Category(errors.CategoryImageProvider).
+					Context("operation", "register_localbundle_provider").
+					Context("provider", imageprovider.LocalBundleProviderName).
+					Build())
+			} else {
+				GetLogger().Info("Successfully registered image provider",
+					"provider", imageprovider.LocalBundleProviderName,
+					"bundle_path", bundlePath,
+					"operation", "register_image_provider")
+				log.Printf("Registered offline image bundle from %s", bundlePath)
+			}
+		}
+	}
+
 	// Set the registry in each provider for fallback support
 	registry.RangeProviders(func(name string, cache *imageprovider.BirdImageCache) bool {
 		cache.SetRegistry(registry)
@@ -1026,6 +2319,17 @@ func selectDefaultImageProvider(registry *imageprovider.ImageProviderRegistry) *
 	var defaultCache *imageprovider.BirdImageCache
 
 	if preferredProvider == "auto" {
+		// An offline image bundle is only configured for instances without reliable
+		// internet access, so prefer it in auto mode when one is registered
+		if cache, ok := registry.GetCache(imageprovider.LocalBundleProviderName); ok {
+			GetLogger().Info("Selected default image provider",
+				"provider", imageprovider.LocalBundleProviderName,
+				"mode", "auto",
+				"operation", "select_default_provider")
+			log.Println("Using offline image bundle as the default image provider (auto mode)")
+			return cache
+		}
+
 		// Use wikimedia as the default provider in auto mode, if available
 		defaultCache, _ = registry.GetCache("wikimedia")
 		// Add structured logging
@@ -1279,6 +2583,11 @@ func initBirdImageCache(ds datastore.Interface, metrics *observability.Metrics)
 	// 4. Start the background cache warm-up process with validated species list
 	warmUpImageCacheInBackground(ds, registry, defaultCache, validSpeciesList)
 
+	// 5. Pre-fetch the images of all species in the range filter, not only the detected ones
+	if conf.Setting().Realtime.Dashboard.Thumbnails.Prefetch {
+		defaultCache.StartPrefetch(func() []string { return conf.Setting().GetIncludedSpecies() })
+	}
+
 	return defaultCache
 }
 
@@ -1598,15 +2907,13 @@ func initializeAudioSources(settings *conf.Settings) ([]string, error) {
 		}
 		if settings.Realtime.Audio.Source != "" {
 			// Register the audio device in the source registry and use its ID
-			// This ensures consistent UUID-based IDs like RTSP sources
-			registry := myaudio.GetRegistry()
-			source, err := registry.RegisterSource(settings.Realtime.Audio.Source, myaudio.SourceConfig{
-				Type:        myaudio.SourceTypeAudioCard,
-				DisplayName: settings.Realtime.Audio.Source,
-			})
+			// This ensures consistent UUID-based IDs like RTSP sources.
+			// Channels of a stereo card analyzed separately get a source each.
+			cardSources, err := myaudio.RegisterAudioCardSources(myaudio.GetRegistry(), &settings.Realtime.Audio)
 			if err != nil {
 				log.Printf("⚠️  Failed to register audio device source: %v", err)
-			} else {
+			}
+			for _, source := range cardSources {
 				sources = append(sources, source.ID)
 			}
 		}
@@ -0,0 +1,67 @@
+package analysis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+// fakeThinner records the thinning policies it is asked to apply
+type fakeThinner struct {
+	policies []datastore.ThinningPolicy
+}
+
+func (f *fakeThinner) ThinDetections(_ context.Context, policy *datastore.ThinningPolicy) (*datastore.ThinningResult, error) {
+	f.policies = append(f.policies, *policy)
+	return &datastore.ThinningResult{}, nil
+}
+
+func TestApplyDetectionRetention(t *testing.T) {
+	t.Cleanup(func() { conf.SetReadOnly(false) })
+
+	settings := &conf.Settings{}
+	settings.DetectionRetention.Policies = []conf.DetectionRetentionPolicy{
+		{Name: "common", MaxAgeDays: 30, MinDetections: 100},
+	}
+
+	thinner := &fakeThinner{}
+	conf.SetReadOnly(true)
+	applyDetectionRetention(context.Background(), settings, thinner)
+	assert.Empty(t, thinner.policies, "no detections may be thinned in read-only mode")
+
+	conf.SetReadOnly(false)
+	applyDetectionRetention(context.Background(), settings, thinner)
+	if assert.Len(t, thinner.policies, 1) {
+		assert.Equal(t, 100, thinner.policies[0].MinDetections)
+	}
+}
+
+// fakePurger counts the trash purges it is asked to run
+type fakePurger struct {
+	calls int
+}
+
+func (f *fakePurger) PurgeTrash(_ time.Time) (*datastore.PurgeResult, error) {
+	f.calls++
+	return &datastore.PurgeResult{}, nil
+}
+
+func TestPurgeTrashReadOnly(t *testing.T) {
+	t.Cleanup(func() { conf.SetReadOnly(false) })
+
+	settings := &conf.Settings{}
+	settings.Trash.RetentionDays = 30
+
+	purger := &fakePurger{}
+	conf.SetReadOnly(true)
+	purgeTrash(settings, purger)
+	assert.Zero(t, purger.calls, "the trash may not be purged in read-only mode")
+
+	conf.SetReadOnly(false)
+	purgeTrash(settings, purger)
+	assert.Equal(t, 1, purger.calls)
+}
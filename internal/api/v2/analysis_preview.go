@@ -0,0 +1,371 @@
+// internal/api/v2/analysis_preview.go
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/birdnet"
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/errors"
+	"github.com/tphakala/birdnet-go/internal/myaudio"
+)
+
+// Analysis preview limits
+const (
+	maxPreviewUploadSize      = 256 << 20 // bytes of the segments of one confirmation
+	maxPreviewSegments        = 100       // segments confirmed per request
+	maxPreviewSegmentDuration = 60        // seconds of audio per segment
+	previewSegmentLength      = 3         // seconds of audio the model analyses at once
+)
+
+// AnalysisPreviewManifest is everything a client-side (WASM) build of the
+// model needs to pre-analyse a recording like the server would: the labels
+// in the order of the model outputs, the thresholds and the species the range
+// filter includes. Clients send only the segments they find interesting to
+// be confirmed, instead of uploading the whole recording.
+type AnalysisPreviewManifest struct {
+	Version           string                     `json:"version"` // changes whenever the manifest does, also the ETag
+	Model             AnalysisPreviewModel       `json:"model"`
+	Labels            []string                   `json:"labels"`
+	Threshold         float64                    `json:"threshold"`                   // confidence threshold of detections
+	SpeciesThresholds map[string]float64         `json:"speciesThresholds,omitempty"` // custom thresholds by lowercase species name
+	RangeFilter       AnalysisPreviewRangeFilter `json:"rangeFilter"`
+	Exclude           []string                   `json:"exclude,omitempty"` // species never reported
+	ConfirmURL        string                     `json:"confirmUrl"`
+	MaxSegments       int                        `json:"maxSegments"`       // segments confirmed per request
+	MaxSegmentSeconds int                        `json:"maxSegmentSeconds"` // seconds of audio per segment
+}
+
+// AnalysisPreviewModel describes the model and the audio it analyses
+type AnalysisPreviewModel struct {
+	ID            string  `json:"id"`
+	Name          string  `json:"name"`
+	SampleRate    int     `json:"sampleRate"`    // Hz, mono
+	SegmentLength float64 `json:"segmentLength"` // seconds analysed at once
+	Overlap       float64 `json:"overlap"`       // seconds consecutive segments overlap
+	Sensitivity   float64 `json:"sensitivity"`   // sigmoid sensitivity of the model outputs
+}
+
+// AnalysisPreviewRangeFilter is the species the range filter includes at the
+// station at the time of the manifest
+type AnalysisPreviewRangeFilter struct {
+	Threshold   float32   `json:"threshold"`
+	Species     []string  `json:"species"` // labels of the included species
+	LastUpdated time.Time `json:"lastUpdated"`
+}
+
+// AnalysisPreviewSegment is a segment of a recording the client found
+// interesting, with its provisional detections
+type AnalysisPreviewSegment struct {
+	Start      float64                      `json:"start"` // seconds from the start of the recording
+	Detections []AnalysisPreviewProvisional `json:"detections"`
+}
+
+// AnalysisPreviewProvisional is a detection of the client-side preview
+type AnalysisPreviewProvisional struct {
+	ScientificName string  `json:"scientificName"`
+	Confidence     float64 `json:"confidence"`
+	Confirmed      bool    `json:"confirmed"` // set in responses: the server detected the species in the segment
+}
+
+// AnalysisPreviewSegmentResult is the server-side analysis of a segment
+type AnalysisPreviewSegmentResult struct {
+	Start       float64                      `json:"start"`
+	End         float64                      `json:"end"`
+	Detections  []FileAnalysisDetection      `json:"detections"`  // detections of the server, positioned in the recording
+	Provisional []AnalysisPreviewProvisional `json:"provisional"` // the client's detections, marked confirmed or not
+}
+
+// AnalysisPreviewConfirmation is the response to a confirmation request
+type AnalysisPreviewConfirmation struct {
+	Segments    []AnalysisPreviewSegmentResult `json:"segments"`
+	Confirmed   int                            `json:"confirmed"`   // provisional detections the server confirmed
+	Unconfirmed int                            `json:"unconfirmed"` // provisional detections the server did not
+}
+
+// analysisPreviewRequest is the "results" field of a confirmation request
+type analysisPreviewRequest struct {
+	Segments []AnalysisPreviewSegment `json:"segments"`
+}
+
+// initAnalysisPreviewRoutes registers the client-side analysis preview endpoints
+func (c *Controller) initAnalysisPreviewRoutes() {
+	authMiddleware := c.getEffectiveAuthMiddleware()
+	c.Group.GET("/analysis/preview/manifest", c.GetAnalysisPreviewManifest, authMiddleware)
+	c.Group.POST("/analysis/preview/confirm", c.ConfirmAnalysisPreview, authMiddleware)
+}
+
+// GetAnalysisPreviewManifest handles GET /api/v2/analysis/preview/manifest
+// It returns the labels, thresholds and range filter of the realtime analysis
+// for a client-side preview. Requests with the version as If-None-Match get
+// 304 Not Modified while it is current.
+func (c *Controller) GetAnalysisPreviewManifest(ctx echo.Context) error {
+	if c.Processor == nil || c.Processor.GetBirdNET() == nil {
+		return c.HandleError(ctx, errors.Newf("BirdNET model not loaded").
+			Component("api").
+			Category(errors.CategoryModelInit).
+			Build(), "The BirdNET model is not loaded", http.StatusServiceUnavailable)
+	}
+	return c.getAnalysisPreviewManifest(ctx, c.Processor.GetBirdNET().ModelInfo)
+}
+
+// getAnalysisPreviewManifest responds with the manifest of a model
+func (c *Controller) getAnalysisPreviewManifest(ctx echo.Context, model birdnet.ModelInfo) error {
+	manifest := c.analysisPreviewManifest(model)
+	etag := `"` + manifest.Version + `"`
+	ctx.Response().Header().Set("ETag", etag)
+	ctx.Response().Header().Set("Cache-Control", "no-cache")
+	if ctx.Request().Header.Get("If-None-Match") == etag {
+		return ctx.NoContent(http.StatusNotModified)
+	}
+	return ctx.JSON(http.StatusOK, manifest)
+}
+
+// analysisPreviewManifest builds the manifest of the current settings
+func (c *Controller) analysisPreviewManifest(model birdnet.ModelInfo) *AnalysisPreviewManifest {
+	settings := c.Settings
+	manifest := &AnalysisPreviewManifest{
+		Model: AnalysisPreviewModel{
+			ID:            model.ID,
+			Name:          model.Name,
+			SampleRate:    conf.SampleRate,
+			SegmentLength: previewSegmentLength,
+			Overlap:       settings.BirdNET.Overlap,
+			Sensitivity:   settings.BirdNET.Sensitivity,
+		},
+		Labels:    slices.Clone(settings.BirdNET.Labels),
+		Threshold: settings.BirdNET.Threshold,
+		RangeFilter: AnalysisPreviewRangeFilter{
+			Threshold:   settings.BirdNET.RangeFilter.Threshold,
+			Species:     settings.GetIncludedSpecies(),
+			LastUpdated: settings.BirdNET.RangeFilter.LastUpdated,
+		},
+		Exclude:           slices.Clone(settings.Realtime.Species.Exclude),
+		ConfirmURL:        "/api/v2/analysis/preview/confirm",
+		MaxSegments:       maxPreviewSegments,
+		MaxSegmentSeconds: maxPreviewSegmentDuration,
+	}
+	for name, species := range settings.Realtime.Species.Config {
+		if species.Threshold <= 0 {
+			continue
+		}
+		if manifest.SpeciesThresholds == nil {
+			manifest.SpeciesThresholds = make(map[string]float64)
+		}
+		manifest.SpeciesThresholds[strings.ToLower(name)] = species.Threshold
+	}
+
+	// The version hashes the manifest without it; maps marshal sorted
+	content, _ := json.Marshal(manifest)
+	sum := sha256.Sum256(content)
+	manifest.Version = hex.EncodeToString(sum[:8])
+	return manifest
+}
+
+// ConfirmAnalysisPreview handles POST /api/v2/analysis/preview/confirm
+// The multipart request has a "results" field with the provisional results
+// of the client as {"segments": [...]} and a WAV or FLAC "segment" file for
+// each of them, in the same order. Each segment is analysed with the model of
+// the realtime analysis and the provisional detections are marked confirmed
+// when the server detects the species in the segment too.
+func (c *Controller) ConfirmAnalysisPreview(ctx echo.Context) error {
+	if c.Processor == nil || c.Processor.GetBirdNET() == nil {
+		return c.HandleError(ctx, errors.Newf("BirdNET model not loaded").
+			Component("api").
+			Category(errors.CategoryModelInit).
+			Build(), "The BirdNET model is not loaded", http.StatusServiceUnavailable)
+	}
+	return c.confirmAnalysisPreview(ctx, c.Processor.GetBirdNET().ProcessChunkWithContext)
+}
+
+// confirmAnalysisPreview confirms the provisional results with analyze
+func (c *Controller) confirmAnalysisPreview(ctx echo.Context, analyze chunkAnalyzer) error {
+	segments, paths, err := readAnalysisPreview(ctx)
+	defer func() {
+		for _, path := range paths {
+			_ = os.Remove(path)
+		}
+	}()
+	if err != nil {
+		if isValidationError(err) {
+			return c.HandleError(ctx, err, err.Error(), http.StatusBadRequest)
+		}
+		return c.HandleError(ctx, err, "Failed to store the segments", http.StatusInternalServerError)
+	}
+
+	confirmation := AnalysisPreviewConfirmation{Segments: make([]AnalysisPreviewSegmentResult, 0, len(segments))}
+	for i := range segments {
+		result, err := c.confirmPreviewSegment(ctx.Request().Context(), &segments[i], paths[i], analyze)
+		if err != nil {
+			if isValidationError(err) {
+				return c.HandleError(ctx, err, err.Error(), http.StatusBadRequest)
+			}
+			return c.HandleError(ctx, err, "Failed to analyse a segment", http.StatusInternalServerError)
+		}
+		for _, provisional := range result.Provisional {
+			if provisional.Confirmed {
+				confirmation.Confirmed++
+			} else {
+				confirmation.Unconfirmed++
+			}
+		}
+		confirmation.Segments = append(confirmation.Segments, result)
+	}
+
+	if c.apiLogger != nil {
+		c.apiLogger.Info("Analysis preview confirmed",
+			"segments", len(segments),
+			"confirmed", confirmation.Confirmed,
+			"unconfirmed", confirmation.Unconfirmed)
+	}
+	return ctx.JSON(http.StatusOK, confirmation)
+}
+
+// readAnalysisPreview reads the provisional results and stores the segment
+// files of a confirmation request. The paths stored are returned also on
+// errors, for the caller to remove.
+func readAnalysisPreview(ctx echo.Context) (segments []AnalysisPreviewSegment, paths []string, err error) {
+	req := ctx.Request()
+	req.Body = http.MaxBytesReader(ctx.Response(), req.Body, maxPreviewUploadSize)
+	reader, err := req.MultipartReader()
+	if err != nil {
+		return nil, nil, previewValidationError("%s", err.Error())
+	}
+
+	var results *analysisPreviewRequest
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, paths, previewValidationError("%s", err.Error())
+		}
+		switch part.FormName() {
+		case "results":
+			results = &analysisPreviewRequest{}
+			err = json.NewDecoder(part).Decode(results)
+			_ = part.Close()
+			if err != nil {
+				return nil, paths, previewValidationError("the results field is not valid JSON: %v", err)
+			}
+		case "segment":
+			if len(paths) == maxPreviewSegments {
+				_ = part.Close()
+				return nil, paths, previewValidationError("more than %d segments", maxPreviewSegments)
+			}
+			path, _, err := storeAnalysisPart(part)
+			if err != nil {
+				return nil, paths, err
+			}
+			paths = append(paths, path)
+		default:
+			_ = part.Close()
+		}
+	}
+
+	switch {
+	case results == nil:
+		return nil, paths, previewValidationError("the request has no results field")
+	case len(results.Segments) == 0:
+		return nil, paths, previewValidationError("the results list no segments")
+	case len(results.Segments) != len(paths):
+		return nil, paths, previewValidationError("the results list a different number of segments than uploaded")
+	}
+	for i := range results.Segments {
+		if start := results.Segments[i].Start; start < 0 || math.IsNaN(start) || math.IsInf(start, 0) {
+			return nil, paths, previewValidationError("segment starts must be non-negative seconds")
+		}
+	}
+	return results.Segments, paths, nil
+}
+
+// confirmPreviewSegment analyses a segment file and marks the provisional
+// detections of the segment the server detects too
+func (c *Controller) confirmPreviewSegment(ctx context.Context, segment *AnalysisPreviewSegment, path string, analyze chunkAnalyzer) (AnalysisPreviewSegmentResult, error) {
+	result := AnalysisPreviewSegmentResult{
+		Start:       segment.Start,
+		Detections:  []FileAnalysisDetection{},
+		Provisional: slices.Clone(segment.Detections),
+	}
+
+	info, err := myaudio.GetAudioInfo(path)
+	if err != nil {
+		return result, previewValidationError("a segment is not a readable WAV or FLAC file")
+	}
+	if info.SampleRate <= 0 || info.TotalSamples > info.SampleRate*maxPreviewSegmentDuration {
+		return result, previewValidationError("segments must be at most %d seconds long", maxPreviewSegmentDuration)
+	}
+	result.End = segment.Start + float64(info.TotalSamples)/float64(info.SampleRate)
+
+	err = c.analyzeFileSegments(ctx, path, analyze, func(start float64, notes []datastore.Note) {
+		for i := range notes {
+			if !c.previewDetected(&notes[i]) {
+				continue
+			}
+			result.Detections = append(result.Detections, FileAnalysisDetection{
+				Start:          segment.Start + start,
+				End:            segment.Start + start + previewSegmentLength,
+				ScientificName: notes[i].ScientificName,
+				CommonName:     notes[i].CommonName,
+				SpeciesCode:    notes[i].SpeciesCode,
+				Confidence:     notes[i].Confidence,
+			})
+		}
+	})
+	if err != nil {
+		return result, err
+	}
+
+	for i := range result.Provisional {
+		result.Provisional[i].Confirmed = slices.ContainsFunc(result.Detections, func(d FileAnalysisDetection) bool {
+			return strings.EqualFold(d.ScientificName, result.Provisional[i].ScientificName)
+		})
+	}
+	return result, nil
+}
+
+// previewDetected reports whether a prediction is a detection by the
+// manifest: above the threshold of its species, included by the range
+// filter and not excluded
+func (c *Controller) previewDetected(note *datastore.Note) bool {
+	threshold := c.Settings.BirdNET.Threshold
+	for _, name := range []string{note.CommonName, note.ScientificName} {
+		if species, ok := c.Settings.Realtime.Species.Config[strings.ToLower(name)]; ok && species.Threshold > 0 {
+			threshold = species.Threshold
+			break
+		}
+	}
+	if note.Confidence <= threshold || !c.Settings.IsSpeciesIncluded(note.ScientificName) {
+		return false
+	}
+	return !slices.ContainsFunc(c.Settings.Realtime.Species.Exclude, func(name string) bool {
+		return strings.EqualFold(name, note.CommonName) || strings.EqualFold(name, note.ScientificName)
+	})
+}
+
+// previewValidationError returns the error of an invalid confirmation request
+func previewValidationError(format string, args ...any) error {
+	return errors.Newf(format, args...).
+		Component("api").
+		Category(errors.CategoryValidation).
+		Build()
+}
+
+// isValidationError reports whether an error is of an invalid request
+func isValidationError(err error) bool {
+	var enhancedErr *errors.EnhancedError
+	return errors.As(err, &enhancedErr) && enhancedErr.Category == errors.CategoryValidation
+}
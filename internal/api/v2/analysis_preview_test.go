@@ -0,0 +1,168 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/birdnet"
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+// previewConfirmation builds a multipart confirmation request with the
+// results and a segment file for each of contents
+func previewConfirmation(t *testing.T, results string, contents ...[]byte) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	require.NoError(t, writer.WriteField("results", results))
+	for _, content := range contents {
+		part, err := writer.CreateFormFile("segment", "segment.wav")
+		require.NoError(t, err)
+		_, err = part.Write(content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/analysis/preview/confirm", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func TestAnalysisPreviewManifest(t *testing.T) {
+	e, _, controller := setupTestEnvironment(t)
+	controller.Settings.BirdNET.Labels = []string{"Turdus merula_Eurasian Blackbird", "Pica pica_Eurasian Magpie"}
+	controller.Settings.BirdNET.Threshold = 0.7
+	controller.Settings.BirdNET.RangeFilter.Species = []string{"Turdus merula_Eurasian Blackbird"}
+	controller.Settings.Realtime.Species.Config = map[string]conf.SpeciesConfig{"Eurasian Blackbird": {Threshold: 0.5}}
+	model := birdnet.ModelInfo{ID: "BirdNET_GLOBAL_6K_V2.4", Name: "BirdNET GLOBAL 6K V2.4"}
+
+	rec := httptest.NewRecorder()
+	require.NoError(t, controller.getAnalysisPreviewManifest(e.NewContext(httptest.NewRequest(http.MethodGet, "/api/v2/analysis/preview/manifest", http.NoBody), rec), model))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var manifest AnalysisPreviewManifest
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &manifest))
+	assert.Equal(t, "BirdNET_GLOBAL_6K_V2.4", manifest.Model.ID)
+	assert.Equal(t, conf.SampleRate, manifest.Model.SampleRate)
+	assert.Equal(t, controller.Settings.BirdNET.Labels, manifest.Labels)
+	assert.InDelta(t, 0.7, manifest.Threshold, 0.001)
+	assert.Equal(t, map[string]float64{"eurasian blackbird": 0.5}, manifest.SpeciesThresholds)
+	assert.Equal(t, []string{"Turdus merula_Eurasian Blackbird"}, manifest.RangeFilter.Species)
+	assert.Equal(t, `"`+manifest.Version+`"`, rec.Header().Get("ETag"))
+
+	// An unchanged manifest is not sent again
+	req := httptest.NewRequest(http.MethodGet, "/api/v2/analysis/preview/manifest", http.NoBody)
+	req.Header.Set("If-None-Match", rec.Header().Get("ETag"))
+	rec = httptest.NewRecorder()
+	require.NoError(t, controller.getAnalysisPreviewManifest(e.NewContext(req, rec), model))
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+
+	// Any change of the settings changes the version
+	controller.Settings.BirdNET.Threshold = 0.8
+	assert.NotEqual(t, manifest.Version, controller.analysisPreviewManifest(model).Version)
+}
+
+func TestConfirmAnalysisPreview(t *testing.T) {
+	e, _, controller := setupTestEnvironment(t)
+	controller.Settings.BirdNET.Threshold = 0.7
+	controller.Settings.BirdNET.RangeFilter.Species = []string{"Turdus merula_Eurasian Blackbird", "Pica pica_Eurasian Magpie"}
+	controller.Settings.Realtime.Species.Config = map[string]conf.SpeciesConfig{"eurasian magpie": {Threshold: 0.4}}
+
+	wavPath := filepath.Join(t.TempDir(), "segment.wav")
+	writeSilentWAV(t, wavPath, 6)
+	content, err := os.ReadFile(wavPath)
+	require.NoError(t, err)
+
+	// The model hears a blackbird in the second 3 seconds of a segment and a
+	// magpie, below the global but above its own threshold, in the first
+	analyze := func(_ context.Context, _ []float32, start time.Time) ([]datastore.Note, error) {
+		if start.Sub(time.Time{}) == 3*time.Second {
+			return []datastore.Note{{ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", Confidence: 0.9}}, nil
+		}
+		return []datastore.Note{{ScientificName: "Pica pica", CommonName: "Eurasian Magpie", Confidence: 0.5}}, nil
+	}
+
+	results := `{"segments": [
+		{"start": 60, "detections": [{"scientificName": "Turdus merula", "confidence": 0.6}, {"scientificName": "Corvus corax", "confidence": 0.4}]},
+		{"start": 120, "detections": []}
+	]}`
+	rec := httptest.NewRecorder()
+	require.NoError(t, controller.confirmAnalysisPreview(e.NewContext(previewConfirmation(t, results, content, content), rec), analyze))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var confirmation AnalysisPreviewConfirmation
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &confirmation))
+	assert.Equal(t, 1, confirmation.Confirmed)
+	assert.Equal(t, 1, confirmation.Unconfirmed)
+	require.Len(t, confirmation.Segments, 2)
+
+	first := confirmation.Segments[0]
+	assert.InDelta(t, 60, first.Start, 0.001)
+	assert.InDelta(t, 66, first.End, 0.001)
+	assert.Equal(t, []FileAnalysisDetection{
+		{Start: 60, End: 63, ScientificName: "Pica pica", CommonName: "Eurasian Magpie", Confidence: 0.5},
+		{Start: 63, End: 66, ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", Confidence: 0.9},
+	}, first.Detections, "detections are positioned in the recording")
+	assert.Equal(t, []AnalysisPreviewProvisional{
+		{ScientificName: "Turdus merula", Confidence: 0.6, Confirmed: true},
+		{ScientificName: "Corvus corax", Confidence: 0.4},
+	}, first.Provisional)
+	assert.Len(t, confirmation.Segments[1].Detections, 2)
+}
+
+func TestConfirmAnalysisPreviewValidation(t *testing.T) {
+	e, _, controller := setupTestEnvironment(t)
+
+	wavPath := filepath.Join(t.TempDir(), "segment.wav")
+	writeSilentWAV(t, wavPath, 3)
+	content, err := os.ReadFile(wavPath)
+	require.NoError(t, err)
+	longPath := filepath.Join(t.TempDir(), "long.wav")
+	writeSilentWAV(t, longPath, maxPreviewSegmentDuration+1)
+	long, err := os.ReadFile(longPath)
+	require.NoError(t, err)
+
+	analyze := func(context.Context, []float32, time.Time) ([]datastore.Note, error) {
+		return nil, nil
+	}
+	tests := []struct {
+		name     string
+		results  string
+		segments [][]byte
+	}{
+		{"invalid results", `{"segments":`, [][]byte{content}},
+		{"no segments", `{"segments": []}`, nil},
+		{"segment count mismatch", `{"segments": [{"start": 0}, {"start": 3}]}`, [][]byte{content}},
+		{"negative start", `{"segments": [{"start": -1}]}`, [][]byte{content}},
+		{"not audio", `{"segments": [{"start": 0}]}`, [][]byte{[]byte("not a wav file")}},
+		{"segment too long", `{"segments": [{"start": 0}]}`, [][]byte{long}},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		require.NoError(t, controller.confirmAnalysisPreview(e.NewContext(previewConfirmation(t, tt.results, tt.segments...), rec), analyze))
+		assert.Equal(t, http.StatusBadRequest, rec.Code, tt.name)
+	}
+}
+
+func TestConfirmAnalysisPreviewBodyLimit(t *testing.T) {
+	t.Parallel()
+
+	e, _, controller := setupTestEnvironment(t)
+	controller.initAnalysisPreviewRoutes()
+
+	// The segments of a confirmation are larger than the request body limit
+	// of the group. Past the limit the handler answers that no model is loaded.
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, previewConfirmation(t, `{"segments": []}`, make([]byte, 2<<20)))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
@@ -40,6 +40,9 @@ type SpeciesDailySummary struct {
 	DaysThisYear    int    `json:"days_this_year,omitempty"`     // Days since first this year
 	DaysThisSeason  int    `json:"days_this_season,omitempty"`   // Days since first this season
 	CurrentSeason   string `json:"current_season,omitempty"`     // Current season name
+
+	// License attribution that must be displayed alongside the thumbnail
+	ThumbnailAttribution *ImageAttribution `json:"thumbnail_attribution,omitempty"`
 }
 
 // SpeciesSummary represents a bird in the overall species summary API response
@@ -53,6 +56,9 @@ type SpeciesSummary struct {
 	AvgConfidence  float64 `json:"avg_confidence,omitempty"`
 	MaxConfidence  float64 `json:"max_confidence,omitempty"`
 	ThumbnailURL   string  `json:"thumbnail_url,omitempty"`
+
+	// License attribution that must be displayed alongside the thumbnail
+	ThumbnailAttribution *ImageAttribution `json:"thumbnail_attribution,omitempty"`
 }
 
 // HourlyDistribution represents detections aggregated by hour
@@ -113,6 +119,33 @@ func (c *Controller) initAnalyticsRoutes() {
 	timeGroup.GET("/daily", c.GetDailyAnalytics)
 	timeGroup.GET("/daily/batch", c.GetBatchDailySpeciesData)         // Batch daily trends for multiple species
 	timeGroup.GET("/distribution/hourly", c.GetTimeOfDayDistribution) // Renamed endpoint for time-of-day distribution
+
+	// Data quality report
+	analyticsGroup.GET("/quality", c.GetDataQualityReport)
+
+	// Hours with unusual detection rates
+	analyticsGroup.GET("/anomalies", c.GetDetectionAnomalies)
+
+	// Comparison of species and counts between two periods
+	analyticsGroup.GET("/compare", c.GetPeriodComparison)
+
+	// Detection counts per time bucket for charting
+	analyticsGroup.GET("/timeseries", c.GetDetectionTimeSeries)
+
+	// Calibration tone levels for tracking input gain drift
+	analyticsGroup.GET("/calibration", c.GetCalibrationHistory)
+
+	// First-ever detections and rarities of past years on today's date
+	analyticsGroup.GET("/on-this-day", c.GetOnThisDay)
+
+	// Time-of-day priors and the detections they rejected
+	analyticsGroup.GET("/time-priors", c.GetTimePriors)
+
+	// Long-term trend and seasonal cycle of weekly detection counts
+	analyticsGroup.GET("/trends", c.GetSpeciesTrend)
+
+	// Best times of day to listen for a species through the year
+	analyticsGroup.GET("/heat-hours", c.GetHeatHours)
 }
 
 // GetDailySpeciesSummary handles GET /api/v2/analytics/species/daily
@@ -540,6 +573,7 @@ func (c *Controller) buildDailySpeciesSummaryResponse(aggregatedData map[string]
 
 	// Batch fetch thumbnail URLs (cached only for fast response)
 	thumbnailURLs := make(map[string]string)
+	thumbnailAttributions := make(map[string]*ImageAttribution)
 	if c.BirdImageCache != nil && len(scientificNames) > 0 {
 		batchResults := c.BirdImageCache.GetBatchCachedOnly(scientificNames)
 		if len(batchResults) > 0 {
@@ -547,6 +581,7 @@ func (c *Controller) buildDailySpeciesSummaryResponse(aggregatedData map[string]
 				imgData := batchResults[name] // Access value using key
 				if imgData.URL != "" {
 					thumbnailURLs[name] = imgData.URL
+					thumbnailAttributions[name] = NewImageAttribution(&imgData)
 				}
 			}
 		}
@@ -594,6 +629,7 @@ func (c *Controller) buildDailySpeciesSummaryResponse(aggregatedData map[string]
 			LatestHeard:    data.Latest,
 			ThumbnailURL:   thumbnailURL,
 		}
+		speciesSummary.ThumbnailAttribution = thumbnailAttributions[scientificName]
 
 		// Add species tracking metadata from batch results
 		if status, exists := batchSpeciesStatus[scientificName]; exists {
@@ -752,9 +788,11 @@ func (c *Controller) GetSpeciesSummary(ctx echo.Context) error {
 
 		// Get bird thumbnail URL from batch results
 		var thumbnailURL string
+		var thumbnailAttribution *ImageAttribution
 		if thumbnailURLs != nil {
 			if birdImage, ok := thumbnailURLs[data.ScientificName]; ok {
 				thumbnailURL = birdImage.URL
+				thumbnailAttribution = NewImageAttribution(&birdImage)
 			}
 		}
 
@@ -770,6 +808,7 @@ func (c *Controller) GetSpeciesSummary(ctx echo.Context) error {
 			MaxConfidence:  data.MaxConfidence,
 			ThumbnailURL:   thumbnailURL,
 		}
+		summary.ThumbnailAttribution = thumbnailAttribution
 
 		response = append(response, summary)
 	}
@@ -1029,11 +1068,12 @@ func (c *Controller) GetDailyAnalytics(ctx echo.Context) error {
 	}
 
 	response := struct {
-		StartDate string          `json:"start_date"`
-		EndDate   string          `json:"end_date"`
-		Species   string          `json:"species,omitempty"`
-		Data      []DailyResponse `json:"data"`
-		Total     int             `json:"total"`
+		StartDate string             `json:"start_date"`
+		EndDate   string             `json:"end_date"`
+		Species   string             `json:"species,omitempty"`
+		Data      []DailyResponse    `json:"data"`
+		Total     int                `json:"total"`
+		Markers   []DeploymentMarker `json:"markers,omitempty"` // deployment changes during the period
 	}{
 		StartDate: startDate,
 		EndDate:   endDate,
@@ -1053,6 +1093,11 @@ func (c *Controller) GetDailyAnalytics(ctx echo.Context) error {
 	}
 	response.Total = totalCount
 
+	periodStart, _ := time.ParseInLocation(time.DateOnly, startDate, time.Local)
+	if periodEnd, err := time.ParseInLocation(time.DateOnly, endDate, time.Local); err == nil {
+		response.Markers = c.deploymentMarkersBetween(periodStart, periodEnd.AddDate(0, 0, 1))
+	}
+
 	if c.apiLogger != nil {
 		c.apiLogger.Info("Daily analytics retrieved",
 			"start_date", startDate,
@@ -0,0 +1,70 @@
+// internal/api/v2/analytics_anomalies.go
+package api
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/dataquality"
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// maxAnomalyHours is the longest period checked by the anomaly endpoint
+const maxAnomalyHours = 7 * 24
+
+// AnomaliesResponse lists the detection rate anomalies of the requested period
+type AnomaliesResponse struct {
+	From      time.Time             `json:"from"`
+	To        time.Time             `json:"to"`
+	Anomalies []dataquality.Anomaly `json:"anomalies"` // newest first
+	Markers   []DeploymentMarker    `json:"markers,omitempty"`
+}
+
+// GetDetectionAnomalies handles GET /api/v2/analytics/anomalies
+// It returns the hours of the last completed hours (default 24) with unusually
+// many or few detections compared to the same hour on the baseline days.
+func (c *Controller) GetDetectionAnomalies(ctx echo.Context) error {
+	hours := 24
+	if param := ctx.QueryParam("hours"); param != "" {
+		parsed, err := strconv.Atoi(param)
+		if err != nil || parsed < 1 || parsed > maxAnomalyHours {
+			return c.HandleError(ctx, errors.Newf("invalid hours parameter: %s", param).
+				Component("api").
+				Category(errors.CategoryValidation).
+				Build(), "Hours must be a number between 1 and 168", http.StatusBadRequest)
+		}
+		hours = parsed
+	}
+
+	store, ok := c.DS.(dataquality.AnomalyStore)
+	if !ok {
+		return c.HandleError(ctx, errors.Newf("datastore does not support hourly detection counts").
+			Component("api").
+			Category(errors.CategoryConfiguration).
+			Build(), "Anomaly detection is not supported by this datastore", http.StatusNotImplemented)
+	}
+
+	now := time.Now()
+	to := time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), 0, 0, 0, now.Location())
+	from := to.Add(-time.Duration(hours) * time.Hour)
+
+	detector := dataquality.NewAnomalyDetector(dataquality.AnomalyConfigFromSettings(&c.Settings.DataQuality.Anomaly), store)
+	anomalies, err := detector.Detect(ctx.Request().Context(), from, to)
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to detect anomalies", http.StatusInternalServerError)
+	}
+	slices.Reverse(anomalies)
+	if anomalies == nil {
+		anomalies = []dataquality.Anomaly{}
+	}
+
+	return ctx.JSON(http.StatusOK, AnomaliesResponse{
+		From:      from,
+		To:        to,
+		Anomalies: anomalies,
+		Markers:   c.deploymentMarkersBetween(from, to),
+	})
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+// hourlyCountsMockDataStore adds hourly species counts to MockDataStore
+type hourlyCountsMockDataStore struct {
+	*MockDataStore
+	counts []datastore.HourlySpeciesCount
+}
+
+func (m *hourlyCountsMockDataStore) GetHourlySpeciesCounts(_ context.Context, startDate, endDate string) ([]datastore.HourlySpeciesCount, error) {
+	var result []datastore.HourlySpeciesCount
+	for _, c := range m.counts {
+		if c.Date >= startDate && c.Date <= endDate {
+			result = append(result, c)
+		}
+	}
+	return result, nil
+}
+
+func TestGetDetectionAnomalies(t *testing.T) {
+	t.Parallel()
+
+	e, mockDS, controller := setupTestEnvironment(t)
+	controller.Settings.DataQuality.Anomaly.BaselineDays = 7
+	controller.Settings.DataQuality.Anomaly.Threshold = 3
+	controller.Settings.DataQuality.Anomaly.MinSpike = 10
+	controller.Settings.DataQuality.Anomaly.MinExpected = 5
+
+	// A quiet hour every day and a spike in the last completed hour
+	now := time.Now()
+	lastHour := time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), 0, 0, 0, now.Location()).Add(-time.Hour)
+	store := &hourlyCountsMockDataStore{MockDataStore: mockDS}
+	for day := 1; day <= 7; day++ {
+		store.counts = append(store.counts, datastore.HourlySpeciesCount{
+			Date: lastHour.AddDate(0, 0, -day).Format(time.DateOnly), Hour: lastHour.Hour(), ScientificName: "Turdus merula", Count: 2,
+		})
+	}
+	store.counts = append(store.counts, datastore.HourlySpeciesCount{
+		Date: lastHour.Format(time.DateOnly), Hour: lastHour.Hour(), ScientificName: "Turdus merula", Count: 50,
+	})
+	controller.DS = store
+
+	get := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v2/analytics/anomalies"+query, http.NoBody)
+		rec := httptest.NewRecorder()
+		require.NoError(t, controller.GetDetectionAnomalies(e.NewContext(req, rec)))
+		return rec
+	}
+
+	rec := get("?hours=3")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp AnomaliesResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.NotEmpty(t, resp.Anomalies)
+	assert.True(t, resp.Anomalies[0].Hour.Equal(lastHour))
+	assert.Equal(t, 50, resp.Anomalies[0].Count)
+
+	assert.Equal(t, http.StatusBadRequest, get("?hours=0").Code)
+	assert.Equal(t, http.StatusBadRequest, get("?hours=1000").Code)
+
+	controller.DS = mockDS
+	assert.Equal(t, http.StatusNotImplemented, get("").Code)
+}
@@ -0,0 +1,86 @@
+// internal/api/v2/analytics_calibration.go
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// maxCalibrationDays is the longest history returned by the calibration endpoint
+const maxCalibrationDays = 3650
+
+// CalibrationHistoryReader is implemented by datastores that store calibration tone measurements
+type CalibrationHistoryReader interface {
+	GetCalibrationMeasurements(source string, since time.Time) ([]datastore.CalibrationMeasurement, error)
+}
+
+// CalibrationMeasurementResponse is one measured calibration tone
+type CalibrationMeasurementResponse struct {
+	Source     string    `json:"source"`
+	MeasuredAt time.Time `json:"measuredAt"`
+	Frequency  float64   `json:"frequency"`
+	Level      float64   `json:"level"`
+	Drift      float64   `json:"drift"`
+	Drifted    bool      `json:"drifted"`
+}
+
+// CalibrationHistoryResponse is the calibration tone history of the requested period
+type CalibrationHistoryResponse struct {
+	Enabled      bool                             `json:"enabled"`
+	Tolerance    float64                          `json:"tolerance"`
+	Measurements []CalibrationMeasurementResponse `json:"measurements"`
+}
+
+// GetCalibrationHistory handles GET /api/v2/analytics/calibration
+// It returns the calibration tone levels measured over the last days (default
+// 30), optionally of one source, with the drift from the reference level.
+func (c *Controller) GetCalibrationHistory(ctx echo.Context) error {
+	days := 30
+	if param := ctx.QueryParam("days"); param != "" {
+		parsed, err := strconv.Atoi(param)
+		if err != nil || parsed < 1 || parsed > maxCalibrationDays {
+			return c.HandleError(ctx, errors.Newf("invalid days parameter: %s", param).
+				Component("api").
+				Category(errors.CategoryValidation).
+				Build(), "Days must be a number between 1 and 3650", http.StatusBadRequest)
+		}
+		days = parsed
+	}
+
+	reader, ok := c.DS.(CalibrationHistoryReader)
+	if !ok {
+		return c.HandleError(ctx, errors.Newf("datastore does not support calibration measurements").
+			Component("api").
+			Category(errors.CategoryConfiguration).
+			Build(), "Calibration tracking is not supported by this datastore", http.StatusNotImplemented)
+	}
+
+	measurements, err := reader.GetCalibrationMeasurements(ctx.QueryParam("source"), time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to load calibration measurements", http.StatusInternalServerError)
+	}
+
+	settings := &c.Settings.DataQuality.Calibration
+	resp := CalibrationHistoryResponse{
+		Enabled:      settings.Enabled,
+		Tolerance:    settings.Tolerance,
+		Measurements: make([]CalibrationMeasurementResponse, 0, len(measurements)),
+	}
+	for i := range measurements {
+		m := &measurements[i]
+		resp.Measurements = append(resp.Measurements, CalibrationMeasurementResponse{
+			Source:     m.Source,
+			MeasuredAt: m.MeasuredAt,
+			Frequency:  m.Frequency,
+			Level:      m.Level,
+			Drift:      m.Drift,
+			Drifted:    m.Drift > settings.Tolerance || m.Drift < -settings.Tolerance,
+		})
+	}
+	return ctx.JSON(http.StatusOK, resp)
+}
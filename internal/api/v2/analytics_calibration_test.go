@@ -0,0 +1,74 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+// calibrationMockDataStore adds stored calibration measurements to MockDataStore
+type calibrationMockDataStore struct {
+	*MockDataStore
+	measurements []datastore.CalibrationMeasurement
+}
+
+func (m *calibrationMockDataStore) GetCalibrationMeasurements(source string, since time.Time) ([]datastore.CalibrationMeasurement, error) {
+	var result []datastore.CalibrationMeasurement
+	for _, measurement := range m.measurements {
+		if (source == "" || measurement.Source == source) && !measurement.MeasuredAt.Before(since) {
+			result = append(result, measurement)
+		}
+	}
+	return result, nil
+}
+
+func TestGetCalibrationHistory(t *testing.T) {
+	t.Parallel()
+
+	e, mockDS, controller := setupTestEnvironment(t)
+	controller.Settings.DataQuality.Calibration.Enabled = true
+	controller.Settings.DataQuality.Calibration.Tolerance = 3
+
+	now := time.Now()
+	controller.DS = &calibrationMockDataStore{MockDataStore: mockDS, measurements: []datastore.CalibrationMeasurement{
+		{Source: "mic", MeasuredAt: now.AddDate(0, 0, -40), Frequency: 1000, Level: -20},
+		{Source: "mic", MeasuredAt: now.AddDate(0, 0, -2), Frequency: 1000, Level: -21, Drift: -1},
+		{Source: "mic", MeasuredAt: now.AddDate(0, 0, -1), Frequency: 1000, Level: -24, Drift: -4},
+		{Source: "line", MeasuredAt: now.AddDate(0, 0, -1), Frequency: 1000, Level: -30},
+	}}
+
+	get := func(query string) (*httptest.ResponseRecorder, CalibrationHistoryResponse) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v2/analytics/calibration"+query, http.NoBody)
+		rec := httptest.NewRecorder()
+		require.NoError(t, controller.GetCalibrationHistory(e.NewContext(req, rec)))
+		var resp CalibrationHistoryResponse
+		if rec.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		}
+		return rec, resp
+	}
+
+	rec, resp := get("?source=mic")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, resp.Enabled)
+	require.Len(t, resp.Measurements, 2, "measurements older than 30 days should be left out")
+	assert.False(t, resp.Measurements[0].Drifted)
+	assert.True(t, resp.Measurements[1].Drifted)
+
+	_, resp = get("?days=60")
+	assert.Len(t, resp.Measurements, 4)
+
+	rec, _ = get("?days=0")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// Datastores without calibration support
+	controller.DS = mockDS
+	rec, _ = get("")
+	assert.Equal(t, http.StatusNotImplemented, rec.Code)
+}
@@ -0,0 +1,236 @@
+// internal/api/v2/analytics_compare.go
+package api
+
+import (
+	"cmp"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// Default periods of the comparison endpoint
+const (
+	defaultComparePeriodA = "this_week"
+	defaultComparePeriodB = "last_week"
+)
+
+// AnalyticsPeriod is a date range of a comparison, both dates inclusive
+type AnalyticsPeriod struct {
+	Name      string `json:"name"`
+	StartDate string `json:"startDate"`
+	EndDate   string `json:"endDate"`
+	Total     int    `json:"total"`
+	Species   int    `json:"species"`
+}
+
+// SpeciesComparison holds the counts of a species in both compared periods
+type SpeciesComparison struct {
+	ScientificName string   `json:"scientificName"`
+	CommonName     string   `json:"commonName"`
+	SpeciesCode    string   `json:"speciesCode,omitempty"`
+	CountA         int      `json:"countA"`
+	CountB         int      `json:"countB"`
+	Delta          int      `json:"delta"`                   // CountA - CountB
+	ChangePercent  *float64 `json:"changePercent,omitempty"` // relative to CountB, nil when the species was absent in period B
+}
+
+// PeriodComparisonResponse compares the detections of two periods
+type PeriodComparisonResponse struct {
+	PeriodA     AnalyticsPeriod     `json:"periodA"`
+	PeriodB     AnalyticsPeriod     `json:"periodB"`
+	Overlap     int                 `json:"overlap"`     // species detected in both periods
+	Appeared    []string            `json:"appeared"`    // scientific names detected in period A only
+	Disappeared []string            `json:"disappeared"` // scientific names detected in period B only
+	Species     []SpeciesComparison `json:"species"`     // ordered by absolute delta, largest first
+}
+
+// GetPeriodComparison handles GET /api/v2/analytics/compare
+// It compares species and counts between periodA (default this week) and
+// periodB (default last week). A period is a preset name such as last_week or
+// this_month_last_year, or an explicit range of YYYY-MM-DD..YYYY-MM-DD.
+func (c *Controller) GetPeriodComparison(ctx echo.Context) error {
+	now := time.Now()
+
+	periods := make([]AnalyticsPeriod, 2)
+	for i, param := range []struct{ name, fallback string }{
+		{"periodA", defaultComparePeriodA},
+		{"periodB", defaultComparePeriodB},
+	} {
+		value := ctx.QueryParam(param.name)
+		if value == "" {
+			value = param.fallback
+		}
+		start, end, err := parseAnalyticsPeriod(value, now)
+		if err != nil {
+			return c.HandleError(ctx, err, "Invalid "+param.name+": use a preset such as last_week or YYYY-MM-DD..YYYY-MM-DD", http.StatusBadRequest)
+		}
+		periods[i] = AnalyticsPeriod{
+			Name:      value,
+			StartDate: start.Format(time.DateOnly),
+			EndDate:   end.Format(time.DateOnly),
+		}
+	}
+
+	summaries := make([][]datastore.SpeciesSummaryData, 2)
+	for i := range periods {
+		data, err := c.DS.GetSpeciesSummaryData(ctx.Request().Context(), periods[i].StartDate, periods[i].EndDate)
+		if err != nil {
+			return c.HandleError(ctx, err, "Failed to get species data for "+periods[i].Name, http.StatusInternalServerError)
+		}
+		summaries[i] = data
+	}
+
+	return ctx.JSON(http.StatusOK, comparePeriods(periods[0], periods[1], summaries[0], summaries[1]))
+}
+
+// comparePeriods builds the comparison of the species summaries of two periods
+func comparePeriods(periodA, periodB AnalyticsPeriod, dataA, dataB []datastore.SpeciesSummaryData) PeriodComparisonResponse {
+	resp := PeriodComparisonResponse{
+		Appeared:    []string{},
+		Disappeared: []string{},
+		Species:     []SpeciesComparison{},
+	}
+
+	bySpecies := make(map[string]*SpeciesComparison)
+	species := func(data *datastore.SpeciesSummaryData) *SpeciesComparison {
+		s, ok := bySpecies[data.ScientificName]
+		if !ok {
+			s = &SpeciesComparison{
+				ScientificName: data.ScientificName,
+				CommonName:     data.CommonName,
+				SpeciesCode:    data.SpeciesCode,
+			}
+			bySpecies[data.ScientificName] = s
+		}
+		return s
+	}
+	for i := range dataA {
+		species(&dataA[i]).CountA += dataA[i].Count
+		periodA.Total += dataA[i].Count
+	}
+	for i := range dataB {
+		species(&dataB[i]).CountB += dataB[i].Count
+		periodB.Total += dataB[i].Count
+	}
+
+	for _, s := range bySpecies {
+		s.Delta = s.CountA - s.CountB
+		switch {
+		case s.CountA > 0 && s.CountB > 0:
+			resp.Overlap++
+		case s.CountA > 0:
+			resp.Appeared = append(resp.Appeared, s.ScientificName)
+		default:
+			resp.Disappeared = append(resp.Disappeared, s.ScientificName)
+		}
+		if s.CountA > 0 {
+			periodA.Species++
+		}
+		if s.CountB > 0 {
+			periodB.Species++
+			change := float64(s.Delta) / float64(s.CountB) * 100
+			s.ChangePercent = &change
+		}
+		resp.Species = append(resp.Species, *s)
+	}
+
+	slices.SortFunc(resp.Species, func(a, b SpeciesComparison) int {
+		if c := cmp.Compare(abs(b.Delta), abs(a.Delta)); c != 0 {
+			return c
+		}
+		return strings.Compare(a.CommonName, b.CommonName)
+	})
+	slices.Sort(resp.Appeared)
+	slices.Sort(resp.Disappeared)
+
+	resp.PeriodA = periodA
+	resp.PeriodB = periodB
+	return resp
+}
+
+// parseAnalyticsPeriod resolves a period preset or an explicit date range
+// relative to now. Weeks start on Monday.
+func parseAnalyticsPeriod(value string, now time.Time) (start, end time.Time, err error) {
+	if from, to, ok := strings.Cut(value, ".."); ok {
+		start, err = time.ParseInLocation(time.DateOnly, from, now.Location())
+		if err == nil {
+			end, err = time.ParseInLocation(time.DateOnly, to, now.Location())
+		}
+		if err != nil {
+			return start, end, errors.New(err).
+				Component("api").
+				Category(errors.CategoryValidation).
+				Context("period", value).
+				Build()
+		}
+		if end.Before(start) {
+			return start, end, errors.Newf("period %s ends before it starts", value).
+				Component("api").
+				Category(errors.CategoryValidation).
+				Build()
+		}
+		return start, end, nil
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if value == "last_year" {
+		start = time.Date(today.Year()-1, time.January, 1, 0, 0, 0, 0, today.Location())
+		return start, start.AddDate(1, 0, -1), nil
+	}
+
+	// Presets with a _last_year suffix are the same period one year earlier
+	preset, lastYear := strings.CutSuffix(value, "_last_year")
+	if lastYear {
+		// Weeks are shifted by 52 weeks so that they keep their weekdays
+		switch {
+		case strings.HasSuffix(preset, "_week"):
+			today = today.AddDate(0, 0, -364)
+		case strings.HasSuffix(preset, "_month"), strings.HasSuffix(preset, "_year"):
+			today = time.Date(today.Year()-1, today.Month(), 1, 0, 0, 0, 0, today.Location())
+		default:
+			today = today.AddDate(-1, 0, 0)
+		}
+	}
+
+	switch preset {
+	case "today":
+		start, end = today, today
+	case "yesterday":
+		start = today.AddDate(0, 0, -1)
+		end = start
+	case "this_week":
+		start = today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+		end = start.AddDate(0, 0, 6)
+	case "last_week":
+		start = today.AddDate(0, 0, -(int(today.Weekday())+6)%7-7)
+		end = start.AddDate(0, 0, 6)
+	case "this_month":
+		start = time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, today.Location())
+		end = start.AddDate(0, 1, -1)
+	case "last_month":
+		start = time.Date(today.Year(), today.Month()-1, 1, 0, 0, 0, 0, today.Location())
+		end = start.AddDate(0, 1, -1)
+	case "this_year":
+		start = time.Date(today.Year(), time.January, 1, 0, 0, 0, 0, today.Location())
+		end = start.AddDate(1, 0, -1)
+	default:
+		return start, end, errors.Newf("unknown period %q", value).
+			Component("api").
+			Category(errors.CategoryValidation).
+			Build()
+	}
+	return start, end, nil
+}
+
+// abs returns the absolute value of an integer
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+func TestParseAnalyticsPeriod(t *testing.T) {
+	t.Parallel()
+
+	// Wednesday
+	now := time.Date(2025, time.March, 12, 15, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		period     string
+		start, end string
+		wantErr    bool
+	}{
+		{period: "today", start: "2025-03-12", end: "2025-03-12"},
+		{period: "yesterday", start: "2025-03-11", end: "2025-03-11"},
+		{period: "this_week", start: "2025-03-10", end: "2025-03-16"},
+		{period: "last_week", start: "2025-03-03", end: "2025-03-09"},
+		{period: "this_week_last_year", start: "2024-03-11", end: "2024-03-17"},
+		{period: "this_month", start: "2025-03-01", end: "2025-03-31"},
+		{period: "last_month", start: "2025-02-01", end: "2025-02-28"},
+		{period: "this_month_last_year", start: "2024-03-01", end: "2024-03-31"},
+		{period: "last_month_last_year", start: "2024-02-01", end: "2024-02-29"},
+		{period: "this_year", start: "2025-01-01", end: "2025-12-31"},
+		{period: "last_year", start: "2024-01-01", end: "2024-12-31"},
+		{period: "2024-05-01..2024-05-31", start: "2024-05-01", end: "2024-05-31"},
+		{period: "2024-05-31..2024-05-01", wantErr: true},
+		{period: "2024-05-01..", wantErr: true},
+		{period: "fortnight", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.period, func(t *testing.T) {
+			t.Parallel()
+			start, end, err := parseAnalyticsPeriod(tt.period, now)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.start, start.Format(time.DateOnly))
+			assert.Equal(t, tt.end, end.Format(time.DateOnly))
+		})
+	}
+}
+
+func TestGetPeriodComparison(t *testing.T) {
+	t.Parallel()
+
+	e, mockDS, controller := setupTestEnvironment(t)
+
+	mockDS.On("GetSpeciesSummaryData", mock.Anything, "2025-03-01", "2025-03-31").Return([]datastore.SpeciesSummaryData{
+		{ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", Count: 30},
+		{ScientificName: "Erithacus rubecula", CommonName: "European Robin", Count: 5},
+	}, nil)
+	mockDS.On("GetSpeciesSummaryData", mock.Anything, "2024-03-01", "2024-03-31").Return([]datastore.SpeciesSummaryData{
+		{ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", Count: 20},
+		{ScientificName: "Bombycilla garrulus", CommonName: "Bohemian Waxwing", Count: 12},
+	}, nil)
+
+	get := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v2/analytics/compare"+query, http.NoBody)
+		rec := httptest.NewRecorder()
+		require.NoError(t, controller.GetPeriodComparison(e.NewContext(req, rec)))
+		return rec
+	}
+
+	rec := get("?periodA=2025-03-01..2025-03-31&periodB=2024-03-01..2024-03-31")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp PeriodComparisonResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+
+	assert.Equal(t, 35, resp.PeriodA.Total)
+	assert.Equal(t, 2, resp.PeriodA.Species)
+	assert.Equal(t, 32, resp.PeriodB.Total)
+	assert.Equal(t, 1, resp.Overlap)
+	assert.Equal(t, []string{"Erithacus rubecula"}, resp.Appeared)
+	assert.Equal(t, []string{"Bombycilla garrulus"}, resp.Disappeared)
+
+	require.Len(t, resp.Species, 3)
+	assert.Equal(t, "Bohemian Waxwing", resp.Species[0].CommonName, "species should be ordered by the size of the change")
+	assert.Equal(t, -12, resp.Species[0].Delta)
+	assert.Equal(t, 10, resp.Species[1].Delta)
+	require.NotNil(t, resp.Species[1].ChangePercent)
+	assert.InDelta(t, 50.0, *resp.Species[1].ChangePercent, 1e-9)
+	assert.Nil(t, resp.Species[2].ChangePercent, "species absent in period B should have no relative change")
+
+	assert.Equal(t, http.StatusBadRequest, get("?periodA=fortnight").Code)
+}
@@ -0,0 +1,202 @@
+// internal/api/v2/analytics_heat_hours.go
+package api
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// Heat hours limits
+const (
+	heatHourSlotMinutes     = 15 // windows start and end on quarter hours
+	heatHourSlotsPerDay     = minutesPerDay / heatHourSlotMinutes
+	defaultHeatHourWindow   = 45
+	maxHeatHourWindow       = 240
+	defaultHeatHourYears    = 3
+	maxHeatHourYears        = 10
+	heatHourPeriodsPerMonth = 3 // early (1-10), mid (11-20) and late (21-) month
+)
+
+// heatHourParts names the periods of a month
+var heatHourParts = [heatHourPeriodsPerMonth]string{"early", "mid", "late"}
+
+// HeatHourWindow is the time of day a species was detected the most in a
+// period of the year
+type HeatHourWindow struct {
+	Period        string  `json:"period"` // such as "late May"
+	Month         int     `json:"month"`
+	Part          string  `json:"part"`  // early, mid or late month
+	Start         string  `json:"start"` // HH:MM
+	End           string  `json:"end"`   // HH:MM, may be past midnight from the start
+	Detections    int     `json:"detections"`
+	PerDay        float64 `json:"perDay"`        // detections in the window per day of the period in the years
+	Share         float64 `json:"share"`         // percent of the detections of the period falling in the window
+	DetectionDays int     `json:"detectionDays"` // days of the period with detections of the species
+}
+
+// HeatHoursResponse holds the best listening windows of a species
+type HeatHoursResponse struct {
+	Species       string           `json:"species"`
+	StartDate     string           `json:"startDate"`
+	EndDate       string           `json:"endDate"`
+	WindowMinutes int              `json:"windowMinutes"`
+	Total         int              `json:"total"`
+	Best          *HeatHourWindow  `json:"best"`    // null without detections
+	Periods       []HeatHourWindow `json:"periods"` // periods with detections, from early January
+}
+
+// GetHeatHours handles GET /api/v2/analytics/heat-hours
+// It recommends when to listen for or record a species: for each third of a
+// month, the window of window minutes (15-240 in steps of 15, default 45)
+// with the most detections over the last years (1-10, default 3), and the
+// best of these by detections per day.
+func (c *Controller) GetHeatHours(ctx echo.Context) error {
+	species := ctx.QueryParam("species")
+	if species == "" {
+		return c.HandleError(ctx, errors.Newf("species is required").
+			Component("api").
+			Category(errors.CategoryValidation).
+			Build(), "Species is required", http.StatusBadRequest)
+	}
+	window, err := heatHoursParam(ctx.QueryParam("window"), defaultHeatHourWindow, heatHourSlotMinutes, maxHeatHourWindow)
+	if err != nil || window%heatHourSlotMinutes != 0 {
+		return c.HandleError(ctx, errors.Newf("invalid window: %s", ctx.QueryParam("window")).
+			Component("api").
+			Category(errors.CategoryValidation).
+			Build(), "Window must be a multiple of 15 minutes up to 240", http.StatusBadRequest)
+	}
+	years, err := heatHoursParam(ctx.QueryParam("years"), defaultHeatHourYears, 1, maxHeatHourYears)
+	if err != nil {
+		return c.HandleError(ctx, err, "Years must be a whole number between 1 and 10", http.StatusBadRequest)
+	}
+
+	reader, ok := c.DS.(TimeSeriesReader)
+	if !ok {
+		return c.HandleError(ctx, errors.Newf("datastore does not support time bucketed counts").
+			Component("api").
+			Category(errors.CategoryConfiguration).
+			Build(), "Heat hours are not supported by this datastore", http.StatusNotImplemented)
+	}
+
+	now := time.Now()
+	endDate := now.Format(time.DateOnly)
+	startDate := now.AddDate(-years, 0, 1).Format(time.DateOnly)
+	counts, err := reader.GetDetectionCountsByMinute(ctx.Request().Context(), startDate, endDate, species)
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to get detection counts", http.StatusInternalServerError)
+	}
+
+	resp := HeatHoursResponse{
+		Species:       species,
+		StartDate:     startDate,
+		EndDate:       endDate,
+		WindowMinutes: window,
+	}
+	resp.Periods, resp.Total = heatHours(counts, window/heatHourSlotMinutes, years)
+	for i := range resp.Periods {
+		if resp.Best == nil || resp.Periods[i].PerDay > resp.Best.PerDay {
+			resp.Best = &resp.Periods[i]
+		}
+	}
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+// heatHoursParam parses a whole number query parameter between lo and hi
+func heatHoursParam(value string, fallback, lo, hi int) (int, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < lo || n > hi {
+		return 0, errors.Newf("invalid value %q, expected %d-%d", value, lo, hi).
+			Component("api").
+			Category(errors.CategoryValidation).
+			Build()
+	}
+	return n, nil
+}
+
+// heatHours returns the window of slots quarter hours with the most detections
+// in each period of the year with detections over years, and the total of the
+// counts. Windows may wrap past midnight, for nocturnal species.
+func heatHours(counts []datastore.TimeCount, slots, years int) (periods []HeatHourWindow, total int) {
+	const periodsPerYear = 12 * heatHourPeriodsPerMonth
+	var grid [periodsPerYear][heatHourSlotsPerDay]int
+	var periodTotals [periodsPerYear]int
+	days := make([]map[string]bool, periodsPerYear)
+
+	for _, count := range counts {
+		date, err := time.Parse(time.DateOnly, count.Date)
+		if err != nil || count.Minute < 0 || count.Minute >= minutesPerDay {
+			continue
+		}
+		period := heatHourPeriod(date.Month(), date.Day())
+		grid[period][count.Minute/heatHourSlotMinutes] += count.Count
+		periodTotals[period] += count.Count
+		if days[period] == nil {
+			days[period] = map[string]bool{}
+		}
+		days[period][count.Date] = true
+		total += count.Count
+	}
+
+	periods = []HeatHourWindow{}
+	for period := range periodsPerYear {
+		if periodTotals[period] == 0 {
+			continue
+		}
+		bestStart, best := 0, -1
+		for start := range heatHourSlotsPerDay {
+			sum := 0
+			for i := range slots {
+				sum += grid[period][(start+i)%heatHourSlotsPerDay]
+			}
+			if sum > best {
+				bestStart, best = start, sum
+			}
+		}
+
+		month := time.Month(period/heatHourPeriodsPerMonth + 1)
+		part := heatHourParts[period%heatHourPeriodsPerMonth]
+		periods = append(periods, HeatHourWindow{
+			Period:        fmt.Sprintf("%s %s", part, month),
+			Month:         int(month),
+			Part:          part,
+			Start:         slotClock(bestStart),
+			End:           slotClock(bestStart + slots),
+			Detections:    best,
+			PerDay:        math.Round(float64(best)/float64(heatHourPeriodDays(month, period%heatHourPeriodsPerMonth)*years)*100) / 100,
+			Share:         math.Round(float64(best)/float64(periodTotals[period])*1000) / 10,
+			DetectionDays: len(days[period]),
+		})
+	}
+	return periods, total
+}
+
+// heatHourPeriod returns the period of the year of a date, from 0 for early
+// January to 35 for late December
+func heatHourPeriod(month time.Month, day int) int {
+	part := min((day-1)/10, heatHourPeriodsPerMonth-1)
+	return (int(month)-1)*heatHourPeriodsPerMonth + part
+}
+
+// heatHourPeriodDays returns the number of days of a period in a common year
+func heatHourPeriodDays(month time.Month, part int) int {
+	if part < heatHourPeriodsPerMonth-1 {
+		return 10
+	}
+	return time.Date(2001, month+1, 0, 0, 0, 0, 0, time.UTC).Day() - 20
+}
+
+// slotClock returns the time of day (HH:MM) a quarter hour slot starts at
+func slotClock(slot int) string {
+	minute := slot * heatHourSlotMinutes % minutesPerDay
+	return fmt.Sprintf("%02d:%02d", minute/60, minute%60)
+}
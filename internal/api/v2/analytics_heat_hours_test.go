@@ -0,0 +1,76 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+func TestHeatHours(t *testing.T) {
+	t.Parallel()
+
+	counts := []datastore.TimeCount{
+		// Late May, singing at dawn
+		{Date: "2024-05-25", Minute: 4*60 + 35, Count: 6},
+		{Date: "2024-05-26", Minute: 4*60 + 50, Count: 5},
+		{Date: "2025-05-28", Minute: 5*60 + 10, Count: 4},
+		{Date: "2025-05-28", Minute: 12 * 60, Count: 1},
+		// Early June, calling around midnight
+		{Date: "2024-06-03", Minute: 23*60 + 50, Count: 2},
+		{Date: "2024-06-04", Minute: 10, Count: 2},
+	}
+
+	periods, total := heatHours(counts, 3, 2)
+	assert.Equal(t, 20, total)
+	require.Len(t, periods, 2)
+
+	may := periods[0]
+	assert.Equal(t, "late May", may.Period)
+	assert.Equal(t, "04:30", may.Start)
+	assert.Equal(t, "05:15", may.End)
+	assert.Equal(t, 15, may.Detections)
+	assert.Equal(t, 3, may.DetectionDays)
+	assert.InDelta(t, 93.8, may.Share, 0.01)
+	assert.InDelta(t, 0.68, may.PerDay, 0.001, "15 detections over 11 days of 2 years")
+
+	june := periods[1]
+	assert.Equal(t, "early June", june.Period)
+	assert.Equal(t, 4, june.Detections)
+	assert.Equal(t, "23:30", june.Start, "windows wrap past midnight")
+	assert.Equal(t, "00:15", june.End)
+}
+
+func TestGetHeatHours(t *testing.T) {
+	t.Parallel()
+
+	e, mockDS, controller := setupTestEnvironment(t)
+	controller.DS = &timeSeriesMockDataStore{
+		MockDataStore: mockDS,
+		minutes:       []datastore.TimeCount{{Date: "2024-05-25", Minute: 4*60 + 35, Count: 6}},
+	}
+
+	get := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v2/analytics/heat-hours"+query, http.NoBody)
+		rec := httptest.NewRecorder()
+		require.NoError(t, controller.GetHeatHours(e.NewContext(req, rec)))
+		return rec
+	}
+
+	assert.Equal(t, http.StatusBadRequest, get("").Code, "species is required")
+	assert.Equal(t, http.StatusBadRequest, get("?species=Locustella+naevia&window=20").Code)
+	assert.Equal(t, http.StatusBadRequest, get("?species=Locustella+naevia&years=11").Code)
+
+	rec := get("?species=Locustella+naevia&window=60")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp HeatHoursResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.NotNil(t, resp.Best)
+	assert.Equal(t, "late May", resp.Best.Period)
+	assert.Equal(t, 60, resp.WindowMinutes)
+	assert.Equal(t, 6, resp.Total)
+}
@@ -0,0 +1,53 @@
+// internal/api/v2/analytics_onthisday.go
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/errors"
+	"github.com/tphakala/birdnet-go/internal/onthisday"
+)
+
+// maxOnThisDayYears is the most past years the on this day endpoint looks at
+const maxOnThisDayYears = 50
+
+// GetOnThisDay handles GET /api/v2/analytics/on-this-day
+// It returns the detections of past years on today's date, or on the date
+// parameter (YYYY-MM-DD), with the species detected for the first time ever
+// and the rarities of each year. Optional years overrides how many past years
+// are looked at.
+func (c *Controller) GetOnThisDay(ctx echo.Context) error {
+	config := onthisday.ConfigFromSettings(&c.Settings.OnThisDay)
+	if param := ctx.QueryParam("years"); param != "" {
+		years, err := strconv.Atoi(param)
+		if err != nil || years < 1 || years > maxOnThisDayYears {
+			return c.HandleError(ctx, errors.Newf("invalid years parameter: %s", param).
+				Component("api").
+				Category(errors.CategoryValidation).
+				Build(), "Years must be a number between 1 and 50", http.StatusBadRequest)
+		}
+		config.Years = years
+	}
+
+	now := time.Now()
+	if param := ctx.QueryParam("date"); param != "" {
+		date, err := time.ParseInLocation(time.DateOnly, param, time.Local)
+		if err != nil {
+			return c.HandleError(ctx, errors.New(err).
+				Component("api").
+				Category(errors.CategoryValidation).
+				Context("date", param).
+				Build(), "Invalid date format, use YYYY-MM-DD", http.StatusBadRequest)
+		}
+		now = date
+	}
+
+	result, err := onthisday.Find(ctx.Request().Context(), config, c.DS, now)
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to get the detections of past years", http.StatusInternalServerError)
+	}
+	return ctx.JSON(http.StatusOK, result)
+}
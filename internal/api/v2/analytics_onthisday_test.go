@@ -0,0 +1,53 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/onthisday"
+)
+
+func TestGetOnThisDay(t *testing.T) {
+	t.Parallel()
+
+	e, mockDS, controller := setupTestEnvironment(t)
+
+	mockDS.On("GetSpeciesSummaryData", mock.Anything, "", "").Return([]datastore.SpeciesSummaryData{
+		{ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", Count: 800, FirstSeen: time.Date(2022, 4, 2, 5, 0, 0, 0, time.Local)},
+		{ScientificName: "Bombycilla garrulus", CommonName: "Bohemian Waxwing", Count: 4, FirstSeen: time.Date(2024, 3, 12, 9, 0, 0, 0, time.Local)},
+	}, nil)
+	mockDS.On("GetSpeciesSummaryData", mock.Anything, "2024-03-12", "2024-03-12").Return([]datastore.SpeciesSummaryData{
+		{ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", Count: 9},
+		{ScientificName: "Bombycilla garrulus", CommonName: "Bohemian Waxwing", Count: 4},
+	}, nil)
+	mockDS.On("GetSpeciesSummaryData", mock.Anything, "2023-03-12", "2023-03-12").Return([]datastore.SpeciesSummaryData{}, nil)
+
+	get := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v2/analytics/on-this-day"+query, http.NoBody)
+		rec := httptest.NewRecorder()
+		require.NoError(t, controller.GetOnThisDay(e.NewContext(req, rec)))
+		return rec
+	}
+
+	rec := get("?date=2025-03-12&years=2")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp onthisday.OnThisDay
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+
+	assert.Equal(t, "2025-03-12", resp.Date)
+	require.Len(t, resp.Years, 1)
+	assert.Equal(t, 13, resp.Years[0].Detections)
+	require.Len(t, resp.Years[0].Highlights, 1)
+	assert.Equal(t, onthisday.KindFirst, resp.Years[0].Highlights[0].Kind)
+	assert.Equal(t, "Bohemian Waxwing", resp.Years[0].Highlights[0].CommonName)
+
+	assert.Equal(t, http.StatusBadRequest, get("?date=12.03.2025").Code)
+	assert.Equal(t, http.StatusBadRequest, get("?years=0").Code)
+}
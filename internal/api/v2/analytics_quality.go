@@ -0,0 +1,55 @@
+// internal/api/v2/analytics_quality.go
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/dataquality"
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// GetDataQualityReport handles GET /api/v2/analytics/quality
+// It returns the data quality report of the date given as YYYY-MM-DD, by
+// default the previous day. Reports of past days are stored by the daily job;
+// the report of the current day covers the day so far.
+func (c *Controller) GetDataQualityReport(ctx echo.Context) error {
+	now := time.Now()
+	date := ctx.QueryParam("date")
+	if date == "" {
+		date = now.AddDate(0, 0, -1).Format(time.DateOnly)
+	}
+	day, err := time.ParseInLocation(time.DateOnly, date, now.Location())
+	if err != nil {
+		return c.HandleError(ctx, err, "Invalid date format, use YYYY-MM-DD", http.StatusBadRequest)
+	}
+	if day.After(now) {
+		return c.HandleError(ctx, errors.Newf("date is in the future").
+			Component("api").
+			Category(errors.CategoryValidation).
+			Build(), "Date cannot be in the future", http.StatusBadRequest)
+	}
+
+	if store, ok := c.DS.(dataquality.Store); ok && date != now.Format(time.DateOnly) {
+		report, err := dataquality.Load(store, date)
+		if err == nil {
+			return ctx.JSON(http.StatusOK, report)
+		}
+		var enhancedErr *errors.EnhancedError
+		if !errors.As(err, &enhancedErr) || enhancedErr.Category != errors.CategoryNotFound {
+			return c.HandleError(ctx, err, "Failed to load data quality report", http.StatusInternalServerError)
+		}
+	}
+
+	// Not stored yet, build the report from the audio statistics kept in memory
+	builder := dataquality.NewBuilder(dataquality.ConfigFromSettings(&c.Settings.DataQuality), c.DS)
+	report, err := builder.Build(ctx.Request().Context(), day)
+	if errors.Is(err, dataquality.ErrNoData) {
+		return c.HandleError(ctx, err, "No data quality report is available for this date", http.StatusNotFound)
+	}
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to build data quality report", http.StatusInternalServerError)
+	}
+	return ctx.JSON(http.StatusOK, report)
+}
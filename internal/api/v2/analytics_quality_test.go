@@ -0,0 +1,72 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/dataquality"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// qualityMockDataStore adds stored data quality reports to MockDataStore
+type qualityMockDataStore struct {
+	*MockDataStore
+	reports map[string]*datastore.DataQualityReport
+}
+
+func (m *qualityMockDataStore) SaveDataQualityReport(report *datastore.DataQualityReport) error {
+	m.reports[report.Date] = report
+	return nil
+}
+
+func (m *qualityMockDataStore) GetDataQualityReport(date string) (*datastore.DataQualityReport, error) {
+	if report, ok := m.reports[date]; ok {
+		return report, nil
+	}
+	return nil, errors.Newf("data quality report for %s not found", date).
+		Component("datastore").
+		Category(errors.CategoryNotFound).
+		Build()
+}
+
+func TestGetDataQualityReport(t *testing.T) {
+	t.Parallel()
+
+	e, mockDS, controller := setupTestEnvironment(t)
+	store := &qualityMockDataStore{MockDataStore: mockDS, reports: map[string]*datastore.DataQualityReport{}}
+	controller.DS = store
+
+	stored := &dataquality.Report{
+		Date:    "2024-05-01",
+		Flags:   []string{dataquality.FlagSilentMicrophone},
+		Sources: []dataquality.SourceReport{{Source: "mic", CoverageMinutes: 1440, CoveragePercent: 100}},
+	}
+	require.NoError(t, dataquality.Save(store, stored))
+
+	get := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v2/analytics/quality"+query, http.NoBody)
+		rec := httptest.NewRecorder()
+		require.NoError(t, controller.GetDataQualityReport(e.NewContext(req, rec)))
+		return rec
+	}
+
+	rec := get("?date=2024-05-01")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var report dataquality.Report
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	assert.Equal(t, stored.Flags, report.Flags)
+	require.Len(t, report.Sources, 1)
+	assert.Equal(t, "mic", report.Sources[0].Source)
+
+	// Audio statistics are only kept in memory since the process started
+	assert.Equal(t, http.StatusNotFound, get("?date=2020-01-01").Code)
+
+	assert.Equal(t, http.StatusBadRequest, get("?date=01-05-2024").Code)
+	assert.Equal(t, http.StatusBadRequest, get("?date="+time.Now().AddDate(0, 0, 2).Format(time.DateOnly)).Code)
+}
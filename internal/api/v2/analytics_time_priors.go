@@ -0,0 +1,38 @@
+// internal/api/v2/analytics_time_priors.go
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/analysis/processor"
+)
+
+// TimePriorsResponse describes the time-of-day priors and the detections
+// they rejected
+type TimePriorsResponse struct {
+	Enabled      bool    `json:"enabled"`
+	Learn        bool    `json:"learn"`
+	Adjustment   float64 `json:"adjustment"`
+	MaxThreshold float64 `json:"maxThreshold"`
+	processor.TimePriorStatus
+}
+
+// GetTimePriors handles GET /api/v2/analytics/time-priors
+// It returns the time-of-day prior settings, the number of species with a
+// learned prior and the recent detections rejected by their prior, so
+// rejections can be audited.
+func (c *Controller) GetTimePriors(ctx echo.Context) error {
+	settings := &c.Settings.Realtime.TimePriors
+	resp := TimePriorsResponse{
+		Enabled:         settings.Enabled,
+		Learn:           settings.Learn,
+		Adjustment:      settings.Adjustment,
+		MaxThreshold:    settings.MaxThreshold,
+		TimePriorStatus: processor.TimePriorStatus{Rejections: []processor.TimePriorRejection{}},
+	}
+	if c.Processor != nil {
+		resp.TimePriorStatus = c.Processor.TimePriorStatus()
+	}
+	return ctx.JSON(http.StatusOK, resp)
+}
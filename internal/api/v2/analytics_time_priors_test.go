@@ -0,0 +1,32 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetTimePriors(t *testing.T) {
+	t.Parallel()
+
+	e, _, controller := setupTestEnvironment(t)
+	controller.Settings.Realtime.TimePriors.Enabled = true
+	controller.Settings.Realtime.TimePriors.Adjustment = 0.2
+	controller.Processor = nil
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v2/analytics/time-priors", http.NoBody)
+	rec := httptest.NewRecorder()
+	require.NoError(t, controller.GetTimePriors(e.NewContext(req, rec)))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp TimePriorsResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.True(t, resp.Enabled)
+	assert.InDelta(t, 0.2, resp.Adjustment, 1e-9)
+	assert.NotNil(t, resp.Rejections, "rejections are an empty list without a processor")
+	assert.Zero(t, resp.LearnedSpecies)
+}
@@ -0,0 +1,186 @@
+// internal/api/v2/analytics_timeseries.go
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// Time series limits
+const (
+	maxTimeSeriesBuckets    = 10000 // most buckets returned by one request
+	minutesPerDay           = 24 * 60
+	defaultTimeSeriesBucket = "1h"
+)
+
+// TimeSeriesReader is the datastore capability needed for time series with
+// buckets shorter than a day
+type TimeSeriesReader interface {
+	GetDetectionCountsByMinute(ctx context.Context, startDate, endDate, species string) ([]datastore.TimeCount, error)
+	GetDetectionCountsByHour(ctx context.Context, startDate, endDate, species string) ([]datastore.TimeCount, error)
+}
+
+// TimeSeriesBucket is the number of detections in one bucket
+type TimeSeriesBucket struct {
+	Start time.Time `json:"start"`
+	Count int       `json:"count"`
+}
+
+// TimeSeriesResponse holds the detection counts of consecutive buckets
+type TimeSeriesResponse struct {
+	Bucket    string             `json:"bucket"`
+	StartDate string             `json:"startDate"`
+	EndDate   string             `json:"endDate"`
+	Species   string             `json:"species,omitempty"`
+	Total     int                `json:"total"`
+	Buckets   []TimeSeriesBucket `json:"buckets"` // every bucket of the range, empty ones included
+	Markers   []DeploymentMarker `json:"markers,omitempty"`
+}
+
+// GetDetectionTimeSeries handles GET /api/v2/analytics/timeseries
+// It returns detection counts per bucket (e.g. 5m, 1h or 1d, default 1h)
+// between start_date and end_date, optionally of one species. Daily buckets
+// come from the daily aggregates, shorter ones from the detections. Requests
+// resulting in more than 10000 buckets are rejected.
+func (c *Controller) GetDetectionTimeSeries(ctx echo.Context) error {
+	bucket := ctx.QueryParam("bucket")
+	if bucket == "" {
+		bucket = defaultTimeSeriesBucket
+	}
+	bucketMinutes, err := parseTimeSeriesBucket(bucket)
+	if err != nil {
+		return c.HandleError(ctx, err, "Bucket must be a whole number of minutes dividing a day, such as 5m, 1h or 1d", http.StatusBadRequest)
+	}
+
+	start, end, err := timeSeriesRange(ctx.QueryParam("start_date"), ctx.QueryParam("end_date"), bucketMinutes, time.Now())
+	if err != nil {
+		return c.HandleError(ctx, err, "Invalid date range, use start_date and end_date in YYYY-MM-DD format", http.StatusBadRequest)
+	}
+	days := int(end.Sub(start).Hours()/24+0.5) + 1
+	bucketsPerDay := minutesPerDay / bucketMinutes
+	if days*bucketsPerDay > maxTimeSeriesBuckets {
+		return c.HandleError(ctx, errors.Newf("time series of %d days with %s buckets exceeds %d buckets", days, bucket, maxTimeSeriesBuckets).
+			Component("api").
+			Category(errors.CategoryValidation).
+			Build(), "Too many buckets, use a larger bucket or a shorter date range", http.StatusBadRequest)
+	}
+
+	species := ctx.QueryParam("species")
+	startDate, endDate := start.Format(time.DateOnly), end.Format(time.DateOnly)
+	reqCtx := ctx.Request().Context()
+
+	var counts []datastore.TimeCount
+	if bucketMinutes == minutesPerDay {
+		daily, err := c.DS.GetDailyAnalyticsData(reqCtx, startDate, endDate, species)
+		if err != nil {
+			return c.HandleError(ctx, err, "Failed to get daily detection counts", http.StatusInternalServerError)
+		}
+		for _, d := range daily {
+			counts = append(counts, datastore.TimeCount{Date: d.Date, Count: d.Count})
+		}
+	} else {
+		reader, ok := c.DS.(TimeSeriesReader)
+		if !ok {
+			return c.HandleError(ctx, errors.Newf("datastore does not support time bucketed counts").
+				Component("api").
+				Category(errors.CategoryConfiguration).
+				Build(), "Time series shorter than a day are not supported by this datastore", http.StatusNotImplemented)
+		}
+		if bucketMinutes%60 == 0 {
+			counts, err = reader.GetDetectionCountsByHour(reqCtx, startDate, endDate, species)
+		} else {
+			counts, err = reader.GetDetectionCountsByMinute(reqCtx, startDate, endDate, species)
+		}
+		if err != nil {
+			return c.HandleError(ctx, err, "Failed to get detection counts", http.StatusInternalServerError)
+		}
+	}
+
+	resp := TimeSeriesResponse{
+		Bucket:    bucket,
+		StartDate: startDate,
+		EndDate:   endDate,
+		Species:   species,
+		Buckets:   make([]TimeSeriesBucket, days*bucketsPerDay),
+	}
+	for day := range days {
+		date := start.AddDate(0, 0, day)
+		for i := range bucketsPerDay {
+			resp.Buckets[day*bucketsPerDay+i].Start = date.Add(time.Duration(i*bucketMinutes) * time.Minute)
+		}
+	}
+	for _, count := range counts {
+		date, err := time.ParseInLocation(time.DateOnly, count.Date, start.Location())
+		if err != nil || date.Before(start) || date.After(end) {
+			continue
+		}
+		day := int(date.Sub(start).Hours()/24 + 0.5)
+		resp.Buckets[day*bucketsPerDay+count.Minute/bucketMinutes].Count += count.Count
+		resp.Total += count.Count
+	}
+	resp.Markers = c.deploymentMarkersBetween(start, end.AddDate(0, 0, 1))
+
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+// parseTimeSeriesBucket returns the length in minutes of a bucket such as 5m,
+// 1h or 1d. Buckets divide a day evenly so that every day starts a bucket.
+func parseTimeSeriesBucket(value string) (int, error) {
+	if value == "1d" {
+		return minutesPerDay, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < time.Minute || d%time.Minute != 0 || minutesPerDay%int(d/time.Minute) != 0 {
+		return 0, errors.Newf("invalid bucket %q", value).
+			Component("api").
+			Category(errors.CategoryValidation).
+			Build()
+	}
+	return int(d / time.Minute), nil
+}
+
+// timeSeriesRange resolves the dates of a time series. The end date defaults
+// to today and the start date to one day of minute buckets, a week of hourly
+// buckets or a month of daily buckets before it.
+func timeSeriesRange(startParam, endParam string, bucketMinutes int, now time.Time) (start, end time.Time, err error) {
+	end = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if endParam != "" {
+		if end, err = time.ParseInLocation(time.DateOnly, endParam, now.Location()); err != nil {
+			return start, end, errors.New(err).
+				Component("api").
+				Category(errors.CategoryValidation).
+				Context("end_date", endParam).
+				Build()
+		}
+	}
+
+	switch {
+	case startParam != "":
+		if start, err = time.ParseInLocation(time.DateOnly, startParam, now.Location()); err != nil {
+			return start, end, errors.New(err).
+				Component("api").
+				Category(errors.CategoryValidation).
+				Context("start_date", startParam).
+				Build()
+		}
+	case bucketMinutes == minutesPerDay:
+		start = end.AddDate(0, 0, -29)
+	case bucketMinutes%60 == 0:
+		start = end.AddDate(0, 0, -6)
+	default:
+		start = end
+	}
+
+	if end.Before(start) {
+		return start, end, errors.Newf("end date %s is before start date %s", end.Format(time.DateOnly), start.Format(time.DateOnly)).
+			Component("api").
+			Category(errors.CategoryValidation).
+			Build()
+	}
+	return start, end, nil
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+// timeSeriesMockDataStore adds time bucketed counts to MockDataStore
+type timeSeriesMockDataStore struct {
+	*MockDataStore
+	minutes []datastore.TimeCount
+	hours   []datastore.TimeCount
+}
+
+func (m *timeSeriesMockDataStore) GetDetectionCountsByMinute(_ context.Context, _, _, _ string) ([]datastore.TimeCount, error) {
+	return m.minutes, nil
+}
+
+func (m *timeSeriesMockDataStore) GetDetectionCountsByHour(_ context.Context, _, _, _ string) ([]datastore.TimeCount, error) {
+	return m.hours, nil
+}
+
+func TestGetDetectionTimeSeries(t *testing.T) {
+	t.Parallel()
+
+	e, mockDS, controller := setupTestEnvironment(t)
+	store := &timeSeriesMockDataStore{
+		MockDataStore: mockDS,
+		minutes: []datastore.TimeCount{
+			{Date: "2024-05-01", Minute: 6*60 + 2, Count: 2},
+			{Date: "2024-05-01", Minute: 6*60 + 4, Count: 1},
+			{Date: "2024-05-01", Minute: 6*60 + 5, Count: 4},
+		},
+		hours: []datastore.TimeCount{
+			{Date: "2024-05-02", Minute: 7 * 60, Count: 3},
+		},
+	}
+	controller.DS = store
+
+	get := func(query string) (*httptest.ResponseRecorder, TimeSeriesResponse) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v2/analytics/timeseries"+query, http.NoBody)
+		rec := httptest.NewRecorder()
+		require.NoError(t, controller.GetDetectionTimeSeries(e.NewContext(req, rec)))
+		var resp TimeSeriesResponse
+		if rec.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		}
+		return rec, resp
+	}
+
+	rec, resp := get("?bucket=5m&start_date=2024-05-01&end_date=2024-05-01")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Len(t, resp.Buckets, 288)
+	assert.Equal(t, 3, resp.Buckets[6*12].Count)
+	assert.Equal(t, 4, resp.Buckets[6*12+1].Count)
+	assert.Equal(t, 7, resp.Total)
+	assert.Equal(t, 6, resp.Buckets[6*12+1].Start.Hour())
+	assert.Equal(t, 5, resp.Buckets[6*12+1].Start.Minute())
+
+	rec, resp = get("?bucket=2h&start_date=2024-05-01&end_date=2024-05-02")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Len(t, resp.Buckets, 24)
+	assert.Equal(t, 3, resp.Buckets[12+3].Count, "07:00 falls into the 06:00 bucket")
+
+	mockDS.On("GetDailyAnalyticsData", mock.Anything, "2024-05-01", "2024-05-03", "Turdus merula").
+		Return([]datastore.DailyAnalyticsData{{Date: "2024-05-02", Count: 9}}, nil)
+	rec, resp = get("?bucket=1d&start_date=2024-05-01&end_date=2024-05-03&species=Turdus+merula")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Len(t, resp.Buckets, 3)
+	assert.Equal(t, []int{0, 9, 0}, []int{resp.Buckets[0].Count, resp.Buckets[1].Count, resp.Buckets[2].Count})
+
+	for _, query := range []string{"?bucket=7m", "?bucket=30s", "?bucket=soon", "?bucket=1m&start_date=2024-01-01&end_date=2024-01-31", "?start_date=2024-05-03&end_date=2024-05-01"} {
+		rec, _ := get(query)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+
+	controller.DS = mockDS
+	rec, _ = get("?bucket=15m")
+	assert.Equal(t, http.StatusNotImplemented, rec.Code)
+}
+
+func TestParseTimeSeriesBucket(t *testing.T) {
+	t.Parallel()
+
+	for value, want := range map[string]int{"1m": 1, "5m": 5, "15m": 15, "1h": 60, "3h": 180, "24h": 1440, "1d": 1440} {
+		got, err := parseTimeSeriesBucket(value)
+		require.NoError(t, err, value)
+		assert.Equal(t, want, got, value)
+	}
+	for _, value := range []string{"", "0m", "7m", "90s", "48h", "2d"} {
+		_, err := parseTimeSeriesBucket(value)
+		assert.Error(t, err, value)
+	}
+
+	start, end, err := timeSeriesRange("", "", 60, time.Date(2024, 5, 10, 15, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, "2024-05-04", start.Format(time.DateOnly))
+	assert.Equal(t, "2024-05-10", end.Format(time.DateOnly))
+}
@@ -0,0 +1,43 @@
+// internal/api/v2/analytics_trends.go
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/errors"
+	"github.com/tphakala/birdnet-go/internal/trend"
+)
+
+// defaultTrendYears is the number of years decomposed when none are requested
+const defaultTrendYears = 3
+
+// GetSpeciesTrend handles GET /api/v2/analytics/trends
+// It decomposes the weekly detection counts of a species, or of all species
+// without species, over the last years (2-10, default 3) into a long-term
+// trend, a yearly seasonal cycle and a remainder. Stations with less than two
+// years of detections get 422.
+func (c *Controller) GetSpeciesTrend(ctx echo.Context) error {
+	years := defaultTrendYears
+	if value := ctx.QueryParam("years"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < trend.MinYears || parsed > trend.MaxYears {
+			return c.HandleError(ctx, errors.Newf("invalid years: %s", value).
+				Component("api").
+				Category(errors.CategoryValidation).
+				Build(), "Years must be a whole number between 2 and 10", http.StatusBadRequest)
+		}
+		years = parsed
+	}
+
+	decomposition, err := trend.Analyze(ctx.Request().Context(), c.DS, ctx.QueryParam("species"), time.Now(), years)
+	if errors.Is(err, trend.ErrInsufficientData) {
+		return c.HandleError(ctx, err, "At least two years of detections are needed for a trend", http.StatusUnprocessableEntity)
+	}
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to decompose detection trend", http.StatusInternalServerError)
+	}
+	return ctx.JSON(http.StatusOK, decomposition)
+}
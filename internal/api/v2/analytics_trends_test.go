@@ -0,0 +1,60 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/trend"
+)
+
+func TestGetSpeciesTrend(t *testing.T) {
+	t.Parallel()
+
+	e, mockDS, controller := setupTestEnvironment(t)
+
+	get := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v2/analytics/trends"+query, http.NoBody)
+		rec := httptest.NewRecorder()
+		require.NoError(t, controller.GetSpeciesTrend(e.NewContext(req, rec)))
+		return rec
+	}
+
+	assert.Equal(t, http.StatusBadRequest, get("?years=1").Code)
+	assert.Equal(t, http.StatusBadRequest, get("?years=abc").Code)
+
+	// Three years of daily detections ending yesterday
+	var daily []datastore.DailyAnalyticsData
+	today := time.Now()
+	for day := today.AddDate(-3, 0, 0); day.Before(today.AddDate(0, 0, -1)); day = day.AddDate(0, 0, 1) {
+		daily = append(daily, datastore.DailyAnalyticsData{Date: day.Format(time.DateOnly), Count: 2})
+	}
+	mockDS.On("GetDailyAnalyticsData", mock.Anything, mock.Anything, mock.Anything, "").Return(daily, nil)
+
+	rec := get("?years=2")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp trend.Decomposition
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Len(t, resp.Weeks, 2*trend.WeeksPerYear)
+	assert.Len(t, resp.SeasonalProfile, trend.WeeksPerYear)
+	assert.InDelta(t, 14, resp.Weeks[60].Trend, 0.5)
+}
+
+func TestGetSpeciesTrendInsufficientData(t *testing.T) {
+	t.Parallel()
+
+	e, mockDS, controller := setupTestEnvironment(t)
+	mockDS.On("GetDailyAnalyticsData", mock.Anything, mock.Anything, mock.Anything, "").
+		Return([]datastore.DailyAnalyticsData{{Date: time.Now().AddDate(0, -2, 0).Format(time.DateOnly), Count: 5}}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v2/analytics/trends", http.NoBody)
+	rec := httptest.NewRecorder()
+	require.NoError(t, controller.GetSpeciesTrend(e.NewContext(req, rec)))
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+}
@@ -16,6 +16,7 @@ import (
 	"log/slog"
 	"net"
 	"net/url"
+	"slices"
 	"strings"
 
 	"github.com/labstack/echo/v4"
@@ -24,7 +25,9 @@ import (
 	"github.com/tphakala/birdnet-go/internal/analysis/processor"
 	"github.com/tphakala/birdnet-go/internal/api/v2/auth"
 	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/databudget"
 	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/diskmanager"
 	"github.com/tphakala/birdnet-go/internal/ebird"
 	"github.com/tphakala/birdnet-go/internal/errors"
 	"github.com/tphakala/birdnet-go/internal/imageprovider"
@@ -32,6 +35,7 @@ import (
 	"github.com/tphakala/birdnet-go/internal/observability"
 	"github.com/tphakala/birdnet-go/internal/securefs"
 	"github.com/tphakala/birdnet-go/internal/security"
+	"github.com/tphakala/birdnet-go/internal/speciesalias"
 	"github.com/tphakala/birdnet-go/internal/suncalc"
 )
 
@@ -55,12 +59,19 @@ type Controller struct {
 	DisableSaveSettings bool         // disables disk persistence of settings
 	settingsMutex       sync.RWMutex // Mutex for settings operations
 	detectionCache      *cache.Cache // Cache for detection queries
+	idempotencyCache    *cache.Cache // Responses to requests with an Idempotency-Key, see IdempotencyMiddleware
 	startTime           *time.Time
 	SFS                 *securefs.SecureFS     // Add SecureFS instance
 	apiLogger           *slog.Logger           // Structured logger for API operations
 	apiLevelVar         *slog.LevelVar         // Dynamic level control (type declaration)
 	apiLoggerClose      func() error           // Function to close the log file
 	metrics             *observability.Metrics // Shared metrics instance
+	aliasesOnce         sync.Once              // Loads the species alias table on first use
+	aliases             *speciesalias.Index    // Former species names, see speciesAliases
+
+	// API versioning related fields
+	deprecations       map[string]EndpointDeprecation // Deprecated endpoints by method and route, see VersioningMiddleware
+	deprecationsLogged sync.Map                       // Last time the use of each deprecated endpoint was logged
 
 	// Auth related fields
 	// AuthService stores the shared authentication service instance.
@@ -71,7 +82,11 @@ type Controller struct {
 	authMiddlewareFn echo.MiddlewareFunc // Authentication middleware function (set if auth configured)
 
 	// SSE related fields
-	sseManager *SSEManager // Manager for Server-Sent Events connections
+	sseManager      *SSEManager         // Manager for Server-Sent Events connections
+	detectionStream *detectionStreamHub // WebSocket clients of the detection stream
+
+	usage   *apiUsageMeter        // API usage per consumer, see UsageMiddleware
+	apiKeys *security.APIKeyStore // API keys with scopes, nil when unavailable
 
 	// Cleanup related fields
 	ctx    context.Context    // Context for managing goroutines
@@ -167,6 +182,20 @@ func (c *Controller) TunnelDetectionMiddleware() echo.MiddlewareFunc {
 	}
 }
 
+// uploadRoutes take files larger than the request body limit of the group.
+// Their handlers limit the size of the body themselves.
+var uploadRoutes = []string{
+	"POST /analysis/files",
+	"PATCH /analysis/uploads/:id", // chunks are limited to the rest of the upload, at most Tus-Max-Size
+	"POST /analysis/preview/confirm",
+}
+
+// isUploadRoute reports whether the request is to one of uploadRoutes
+func isUploadRoute(ctx echo.Context) bool {
+	route := strings.TrimPrefix(ctx.Path(), "/api/v2")
+	return slices.Contains(uploadRoutes, ctx.Request().Method+" "+route)
+}
+
 // New creates a new API controller, returning an error if initialization fails.
 func New(e *echo.Echo, ds datastore.Interface, settings *conf.Settings,
 	birdImageCache *imageprovider.BirdImageCache, sunCalc *suncalc.SunCalc,
@@ -261,6 +290,7 @@ func NewWithOptions(e *echo.Echo, ds datastore.Interface, settings *conf.Setting
 		ctx:            ctx,
 		cancel:         cancel,
 	}
+	c.idempotencyCache = cache.New(idempotencyTTL, time.Hour)
 
 	// Update spectrogram logger level based on debug setting
 	UpdateSpectrogramLogLevel(settings.Debug)
@@ -291,6 +321,7 @@ func NewWithOptions(e *echo.Echo, ds datastore.Interface, settings *conf.Setting
 		// This single instance is shared across requests handled by this controller.
 		// Concurrency safety is handled within the auth.Service implementation.
 		c.AuthService = auth.NewSecurityAdapter(oauth2Server, c.apiLogger)
+		c.apiKeys = oauth2Server.APIKeys
 
 		// Create the middleware provider using the stored service
 		authMiddlewareProvider := auth.NewMiddleware(c.AuthService, c.apiLogger)
@@ -305,14 +336,24 @@ func NewWithOptions(e *echo.Echo, ds datastore.Interface, settings *conf.Setting
 
 	// Create v2 API group
 	c.Group = e.Group("/api/v2")
+	c.usage = newAPIUsageMeter()
 
 	// Configure middlewares
 	c.Group.Use(middleware.Recover())          // Recover should be early
 	c.Group.Use(c.TunnelDetectionMiddleware()) // Add tunnel detection **before** logging
 	// c.Group.Use(middleware.Logger())        // Removed: Use custom LoggingMiddleware below for structured logging
-	c.Group.Use(middleware.CORS())          // CORS handling
-	c.Group.Use(middleware.BodyLimit("1M")) // Limit request body to 1MB to prevent DoS attacks
-	c.Group.Use(c.LoggingMiddleware())      // Use custom structured logging middleware
+	c.Group.Use(middleware.CORS()) // CORS handling
+	c.Group.Use(middleware.BodyLimitWithConfig(middleware.BodyLimitConfig{
+		Limit:   "1M",          // Limit request body to 1MB to prevent DoS attacks
+		Skipper: isUploadRoute, // uploads limit their size themselves
+	}))
+	c.Group.Use(c.LoggingMiddleware())     // Use custom structured logging middleware
+	c.Group.Use(c.UsageMiddleware())       // API usage per token or client, see /auth/tokens
+	c.Group.Use(c.APIKeyMiddleware())      // API key authentication and scopes
+	c.Group.Use(c.SessionRoleMiddleware()) // limits editor and viewer sessions
+	c.Group.Use(c.VersioningMiddleware())  // API version negotiation and deprecation headers
+	c.Group.Use(c.ReadOnlyMiddleware)      // rejects changes while read-only mode is active
+	c.setDeprecations(deprecatedEndpoints)
 
 	// NOTE: CSRF Protection Consideration
 	// The V2 API uses Bearer token authentication (Authorization: Bearer <token>)
@@ -327,6 +368,7 @@ func NewWithOptions(e *echo.Echo, ds datastore.Interface, settings *conf.Setting
 
 	// Initialize SSE manager
 	c.sseManager = NewSSEManager(logger)
+	c.detectionStream = newDetectionStreamHub()
 
 	// Initialize eBird client if enabled
 	if settings.Realtime.EBird.Enabled {
@@ -428,10 +470,15 @@ func (c *Controller) initRoutes() {
 	}{
 		{"search routes", c.initSearchRoutes},
 		{"detection routes", c.initDetectionRoutes},
+		{"review routes", c.initReviewRoutes},
+		{"tag routes", c.initTagRoutes},
+		{"comment routes", c.initCommentRoutes},
 		{"analytics routes", c.initAnalyticsRoutes},
 		{"weather routes", c.initWeatherRoutes},
 		{"system routes", c.initSystemRoutes},
 		{"settings routes", c.initSettingsRoutes},
+		{"API key routes", c.initAPIKeyRoutes},
+		{"preset routes", c.initPresetRoutes},
 		{"filesystem routes", c.initFileSystemRoutes},
 		{"stream routes", c.initStreamRoutes},
 		{"stream health routes", c.initStreamHealthRoutes},
@@ -445,6 +492,24 @@ func (c *Controller) initRoutes() {
 		{"support routes", c.initSupportRoutes},
 		{"debug routes", c.initDebugRoutes},
 		{"species routes", c.initSpeciesRoutes},
+		{"sync routes", c.initSyncRoutes},
+		{"summary routes", c.initSummaryRoutes},
+		{"label studio routes", c.initLabelStudioRoutes},
+		{"station routes", c.initStationRoutes},
+		{"deployment journal routes", c.initDeploymentJournalRoutes},
+		{"dashboard routes", c.initDashboardRoutes},
+		{"saved search routes", c.initSavedSearchRoutes},
+		{"forecast routes", c.initForecastRoutes},
+		{"newsletter routes", c.initNewsletterRoutes},
+		{"version routes", c.initVersionRoutes},
+		{"OpenAPI routes", c.initOpenAPIRoutes},
+		{"bulk routes", c.initBulkRoutes},
+		{"trash routes", c.initTrashRoutes},
+		{"highlights routes", c.initHighlightsRoutes},
+		{"chat-ops routes", c.initChatOpsRoutes},
+		{"file analysis routes", c.initFileAnalysisRoutes},
+		{"resumable upload routes", c.initResumableUploadRoutes},
+		{"analysis preview routes", c.initAnalysisPreviewRoutes},
 	}
 
 	for _, initializer := range routeInitializers {
@@ -501,6 +566,38 @@ func (c *Controller) HealthCheck(ctx echo.Context) error {
 		response["database_error"] = dbError
 	}
 
+	// Report read-only mode so operators can see why detections are not being stored
+	response["read_only"] = conf.IsReadOnly()
+
+	// Report offline mode; weather, image, BirdWeather and other outbound calls are disabled
+	response["offline"] = conf.IsOffline()
+	if conf.IsOffline() {
+		if stats := c.offlineQueueStats(); stats != nil {
+			response["offline_queue"] = stats
+		}
+	}
+
+	// Report data budget usage on metered connections
+	if budget := databudget.Active(); budget != nil {
+		response["data_budget"] = budget.Status()
+	}
+
+	// Report disk-full protective mode; clips are not being saved while it is active
+	if guard := diskmanager.GetProtectiveGuard(); guard != nil {
+		state := guard.State()
+		response["disk_protection"] = state
+		if state.Active {
+			response["status"] = "degraded"
+		}
+	}
+
+	// Report the analysis model warm-up; detections are delayed until it is ready
+	if c.Processor != nil {
+		if bn := c.Processor.GetBirdNET(); bn != nil {
+			response["model_warmup"] = bn.WarmupStatus()
+		}
+	}
+
 	// Add uptime if available
 	if c.startTime != nil {
 		uptime := time.Since(*c.startTime)
@@ -824,7 +921,7 @@ func (c *Controller) handleUnauthorized(ctx echo.Context) error {
 // check in AuthMiddleware.
 func (c *Controller) isAuthRequiredWithoutService(ctx echo.Context) bool {
 	// Assume auth is required if any provider is enabled
-	authWouldBeRequired := c.Settings.Security.BasicAuth.Enabled || c.Settings.Security.GoogleAuth.Enabled || c.Settings.Security.GithubAuth.Enabled
+	authWouldBeRequired := c.Settings.Security.BasicAuth.Enabled || c.Settings.Security.GoogleAuth.Enabled || c.Settings.Security.GithubAuth.Enabled || c.Settings.Security.OIDCAuth.Enabled
 
 	// Check for subnet bypass only if auth would otherwise be required
 	if authWouldBeRequired && c.Settings.Security.AllowSubnetBypass.Enabled {
@@ -876,6 +973,11 @@ func (c *Controller) isAuthRequiredWithoutService(ctx echo.Context) bool {
 // It uses the Controller's stored AuthService instance.
 func (c *Controller) AuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(ctx echo.Context) error {
+		// Requests with an API key were authenticated by APIKeyMiddleware
+		if auth.IsAPIKeyAuthenticated(ctx) {
+			return next(ctx)
+		}
+
 		// Use the stored AuthService instance
 		authService := c.AuthService
 
@@ -0,0 +1,232 @@
+// internal/api/v2/apikeys.go
+package api
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/api/v2/auth"
+	"github.com/tphakala/birdnet-go/internal/errors"
+	"github.com/tphakala/birdnet-go/internal/security"
+)
+
+// maxAPIKeyNameLength limits the name given to an API key
+const maxAPIKeyNameLength = 100
+
+// apiKeyAdminPrefixes are the routes an API key needs settings:admin for, even to read
+var apiKeyAdminPrefixes = []string{"/settings", "/filesystem", "/debug", "/support", "/control"}
+
+// apiKeyDetectionPrefixes are the routes detections:write allows changing
+var apiKeyDetectionPrefixes = []string{"/detections", "/bulk"}
+
+// apiKeyOwnDataPrefixes are the routes of data kept per user, which every
+// scope allows changing
+var apiKeyOwnDataPrefixes = []string{"/dashboards", "/saved-searches"}
+
+// APIKeyInfo is an API key as listed, without its hash
+type APIKeyInfo struct {
+	ID         string                 `json:"id"`
+	Name       string                 `json:"name"`
+	Prefix     string                 `json:"prefix"` // start of the key, to recognise it
+	Scopes     []security.APIKeyScope `json:"scopes"`
+	CreatedAt  time.Time              `json:"createdAt"`
+	LastUsedAt *time.Time             `json:"lastUsedAt,omitempty"` // since the server started
+}
+
+// CreatedAPIKey is a new API key with the key itself, shown only once
+type CreatedAPIKey struct {
+	APIKeyInfo
+	Key string `json:"key"`
+}
+
+// CreateAPIKeyRequest is the body of POST /api/v2/settings/apikeys
+type CreateAPIKeyRequest struct {
+	Name   string                 `json:"name"`
+	Scopes []security.APIKeyScope `json:"scopes"`
+}
+
+// initAPIKeyRoutes registers the API key management endpoints
+func (c *Controller) initAPIKeyRoutes() {
+	apiKeyGroup := c.Group.Group("/settings/apikeys", c.AuthMiddleware)
+	apiKeyGroup.GET("", c.ListAPIKeys)
+	apiKeyGroup.POST("", c.CreateAPIKey)
+	apiKeyGroup.DELETE("/:id", c.RevokeAPIKey)
+}
+
+// newAPIKeyInfo returns the listed form of a stored key
+func newAPIKeyInfo(key *security.APIKey) APIKeyInfo {
+	return APIKeyInfo{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		CreatedAt:  key.CreatedAt,
+		LastUsedAt: key.LastUsedAt,
+	}
+}
+
+// requiredAPIKeyScope returns the scope an API key needs for a request:
+// settings:admin for the admin routes and any other change, detections:write
+// to change detections, own-data to change data of the caller and read-only
+// to read
+func requiredAPIKeyScope(method, path string) security.APIKeyScope {
+	route := strings.TrimPrefix(path, "/api/v2")
+
+	switch {
+	case routeHasPrefix(route, apiKeyAdminPrefixes):
+		return security.ScopeSettingsAdmin
+	case method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions:
+		return security.ScopeReadOnly
+	case routeHasPrefix(route, apiKeyDetectionPrefixes):
+		return security.ScopeDetectionsWrite
+	case routeHasPrefix(route, apiKeyOwnDataPrefixes), isNotificationStateRoute(method, route):
+		return security.ScopeOwnData
+	default:
+		return security.ScopeSettingsAdmin
+	}
+}
+
+// routeHasPrefix reports whether a route is one of the prefixes or below one
+func routeHasPrefix(route string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if route == prefix || strings.HasPrefix(route, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// isNotificationStateRoute reports whether a request only marks notifications
+// read or acknowledged, or changes their status
+func isNotificationStateRoute(method, route string) bool {
+	id, ok := strings.CutPrefix(route, "/notifications/")
+	if !ok {
+		return false
+	}
+	switch {
+	case id == "read-all":
+		return method == http.MethodPost
+	case strings.HasSuffix(id, "/read"), strings.HasSuffix(id, "/acknowledge"):
+		return method == http.MethodPut && strings.Count(id, "/") == 1
+	default:
+		return method == http.MethodPatch && !strings.Contains(id, "/")
+	}
+}
+
+// APIKeyMiddleware authenticates requests carrying an API key and enforces
+// its scopes. Requests without one are left to the other authentication.
+func (c *Controller) APIKeyMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			secret := security.APIKeyFromRequest(ctx.Request())
+			if secret == "" {
+				return next(ctx)
+			}
+
+			if c.apiKeys == nil {
+				return ctx.JSON(http.StatusUnauthorized, map[string]string{"error": "API keys are not available"})
+			}
+			key, err := c.apiKeys.Authenticate(secret)
+			if err != nil {
+				if c.apiLogger != nil {
+					c.apiLogger.Warn("API key authentication failed", "path", ctx.Request().URL.Path, "ip", ctx.RealIP())
+				}
+				ctx.Response().Header().Set("WWW-Authenticate",
+					`Bearer realm="api", error="invalid_token", error_description="Invalid or revoked API key"`)
+				return ctx.JSON(http.StatusUnauthorized, map[string]string{"error": "Invalid or revoked API key"})
+			}
+
+			required := requiredAPIKeyScope(ctx.Request().Method, ctx.Request().URL.Path)
+			if !key.Allows(required) {
+				if c.apiLogger != nil {
+					c.apiLogger.Warn("API key lacks the required scope",
+						"key_id", key.ID,
+						"required_scope", required,
+						"path", ctx.Request().URL.Path,
+						"ip", ctx.RealIP(),
+					)
+				}
+				ctx.Response().Header().Set("WWW-Authenticate",
+					`Bearer realm="api", error="insufficient_scope", scope="`+string(required)+`"`)
+				return ctx.JSON(http.StatusForbidden, map[string]string{
+					"error": "API key lacks the " + string(required) + " scope",
+				})
+			}
+
+			ctx.Set("isAuthenticated", true)
+			ctx.Set("authMethod", auth.AuthMethodAPIKey)
+			ctx.Set("username", "apikey:"+key.Name)
+			ctx.Set(auth.APIKeyContextKey, key)
+			return next(ctx)
+		}
+	}
+}
+
+// ListAPIKeys handles GET /api/v2/settings/apikeys
+func (c *Controller) ListAPIKeys(ctx echo.Context) error {
+	if c.apiKeys == nil {
+		return c.apiKeysUnavailable(ctx)
+	}
+	keys := c.apiKeys.List()
+	infos := make([]APIKeyInfo, 0, len(keys))
+	for i := range keys {
+		infos = append(infos, newAPIKeyInfo(&keys[i]))
+	}
+	return ctx.JSON(http.StatusOK, map[string]any{
+		"keys":   infos,
+		"scopes": security.ValidAPIKeyScopes(),
+	})
+}
+
+// CreateAPIKey handles POST /api/v2/settings/apikeys
+// It returns the new key with 201 Created, the only time the key is shown.
+func (c *Controller) CreateAPIKey(ctx echo.Context) error {
+	if c.apiKeys == nil {
+		return c.apiKeysUnavailable(ctx)
+	}
+
+	var req CreateAPIKeyRequest
+	if err := ctx.Bind(&req); err != nil {
+		return c.HandleError(ctx, err, "Invalid request body", http.StatusBadRequest)
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > maxAPIKeyNameLength {
+		return c.HandleError(ctx, errors.Newf("API key name must be 1 to %d characters", maxAPIKeyNameLength).
+			Component("api").
+			Category(errors.CategoryValidation).
+			Build(), "Invalid API key name", http.StatusBadRequest)
+	}
+
+	key, secret, err := c.apiKeys.Create(req.Name, req.Scopes)
+	if err != nil {
+		if errors.Is(err, security.ErrAPIKeyInvalidScope) {
+			return c.HandleError(ctx, err, "Invalid API key scopes", http.StatusBadRequest)
+		}
+		return c.HandleError(ctx, err, "Failed to create API key", http.StatusInternalServerError)
+	}
+	return ctx.JSON(http.StatusCreated, CreatedAPIKey{APIKeyInfo: newAPIKeyInfo(&key), Key: secret})
+}
+
+// RevokeAPIKey handles DELETE /api/v2/settings/apikeys/:id
+func (c *Controller) RevokeAPIKey(ctx echo.Context) error {
+	if c.apiKeys == nil {
+		return c.apiKeysUnavailable(ctx)
+	}
+	if err := c.apiKeys.Revoke(ctx.Param("id")); err != nil {
+		if errors.Is(err, security.ErrAPIKeyNotFound) {
+			return c.HandleError(ctx, err, "API key not found", http.StatusNotFound)
+		}
+		return c.HandleError(ctx, err, "Failed to revoke API key", http.StatusInternalServerError)
+	}
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// apiKeysUnavailable responds when the API key store could not be loaded
+func (c *Controller) apiKeysUnavailable(ctx echo.Context) error {
+	return c.HandleError(ctx, errors.Newf("API key store not available").
+		Component("api").
+		Category(errors.CategoryConfiguration).
+		Build(), "API keys are not available", http.StatusServiceUnavailable)
+}
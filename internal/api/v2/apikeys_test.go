@@ -0,0 +1,123 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/security"
+)
+
+func TestAPIKeyMiddleware(t *testing.T) {
+	t.Parallel()
+
+	store, err := security.NewAPIKeyStore("")
+	require.NoError(t, err)
+	_, readOnly, err := store.Create("dashboard", []security.APIKeyScope{security.ScopeReadOnly})
+	require.NoError(t, err)
+	_, writer, err := store.Create("review bot", []security.APIKeyScope{security.ScopeDetectionsWrite})
+	require.NoError(t, err)
+	_, admin, err := store.Create("provisioning", []security.APIKeyScope{security.ScopeSettingsAdmin})
+	require.NoError(t, err)
+
+	e := echo.New()
+	controller := &Controller{
+		AuthService: &tokenAuthService{tokens: map[string]bool{"oauth-token": true}},
+		apiKeys:     store,
+		logger:      log.New(io.Discard, "", 0),
+	}
+	ok := func(ctx echo.Context) error { return ctx.NoContent(http.StatusOK) }
+	group := e.Group("/api/v2", controller.APIKeyMiddleware())
+	group.GET("/analytics/species/daily", ok, controller.AuthMiddleware)
+	group.POST("/detections/:id/review", ok, controller.AuthMiddleware)
+	group.GET("/settings", ok, controller.AuthMiddleware)
+	group.POST("/control/restart", ok, controller.AuthMiddleware)
+
+	request := func(method, path, header, value string) int {
+		req := httptest.NewRequest(method, path, http.NoBody)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	bearer := func(method, path, key string) int {
+		return request(method, path, "Authorization", "Bearer "+key)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		key    string
+		want   int
+	}{
+		{"read-only reads", http.MethodGet, "/api/v2/analytics/species/daily", readOnly, http.StatusOK},
+		{"read-only cannot review", http.MethodPost, "/api/v2/detections/1/review", readOnly, http.StatusForbidden},
+		{"read-only cannot read settings", http.MethodGet, "/api/v2/settings", readOnly, http.StatusForbidden},
+		{"detections:write reviews", http.MethodPost, "/api/v2/detections/1/review", writer, http.StatusOK},
+		{"detections:write reads", http.MethodGet, "/api/v2/analytics/species/daily", writer, http.StatusOK},
+		{"detections:write cannot restart", http.MethodPost, "/api/v2/control/restart", writer, http.StatusForbidden},
+		{"settings:admin reads settings", http.MethodGet, "/api/v2/settings", admin, http.StatusOK},
+		{"settings:admin restarts", http.MethodPost, "/api/v2/control/restart", admin, http.StatusOK},
+		{"unknown key", http.MethodGet, "/api/v2/analytics/species/daily", security.APIKeyPrefix + "unknown", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, bearer(tt.method, tt.path, tt.key), tt.name)
+	}
+
+	assert.Equal(t, http.StatusOK, request(http.MethodGet, "/api/v2/settings", security.APIKeyHeader, admin), "keys are also accepted in the X-API-Key header")
+	assert.Equal(t, http.StatusForbidden, request(http.MethodGet, "/api/v2/settings", security.APIKeyHeader, readOnly))
+	assert.Equal(t, http.StatusOK, bearer(http.MethodGet, "/api/v2/settings", "oauth-token"), "OAuth tokens are left to the auth middleware")
+}
+
+func TestAPIKeyManagement(t *testing.T) {
+	t.Parallel()
+
+	store, err := security.NewAPIKeyStore("")
+	require.NoError(t, err)
+	e := echo.New()
+	controller := &Controller{apiKeys: store, logger: log.New(io.Discard, "", 0)}
+
+	call := func(method, body string, handler echo.HandlerFunc, id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/v2/settings/apikeys", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		if id != "" {
+			ctx.SetParamNames("id")
+			ctx.SetParamValues(id)
+		}
+		require.NoError(t, handler(ctx))
+		return rec
+	}
+
+	rec := call(http.MethodPost, `{"name": "Home Assistant", "scopes": ["read-only"]}`, controller.CreateAPIKey, "")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var created CreatedAPIKey
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	assert.True(t, strings.HasPrefix(created.Key, security.APIKeyPrefix))
+	assert.Equal(t, []security.APIKeyScope{security.ScopeReadOnly}, created.Scopes)
+
+	rec = call(http.MethodGet, "", controller.ListAPIKeys, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), created.Key, "keys are shown only when created")
+	assert.NotContains(t, rec.Body.String(), `"hash"`)
+	assert.Contains(t, rec.Body.String(), created.ID)
+
+	assert.Equal(t, http.StatusBadRequest, call(http.MethodPost, `{"name": "", "scopes": ["read-only"]}`, controller.CreateAPIKey, "").Code)
+	assert.Equal(t, http.StatusBadRequest, call(http.MethodPost, `{"name": "x", "scopes": ["root"]}`, controller.CreateAPIKey, "").Code)
+
+	assert.Equal(t, http.StatusNoContent, call(http.MethodDelete, "", controller.RevokeAPIKey, created.ID).Code)
+	assert.Equal(t, http.StatusNotFound, call(http.MethodDelete, "", controller.RevokeAPIKey, created.ID).Code)
+	_, err = store.Authenticate(created.Key)
+	require.ErrorIs(t, err, security.ErrAPIKeyNotFound)
+}
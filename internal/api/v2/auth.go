@@ -104,6 +104,8 @@ func (c *Controller) initAuthRoutes() {
 	protectedGroup := authGroup.Group("", c.AuthMiddleware)
 	protectedGroup.POST("/logout", c.Logout)
 	protectedGroup.GET("/status", c.GetAuthStatus)
+	protectedGroup.GET("/tokens", c.ListTokenUsage)
+	protectedGroup.GET("/tokens/:id/usage", c.GetTokenUsage)
 }
 
 // Login handles POST /api/v2/auth/login
@@ -357,6 +359,12 @@ func boolFromCtx(ctx echo.Context, key string, defaultValue bool) bool {
 	return defaultValue
 }
 
+// currentUsername returns the user the request was authenticated as, empty
+// when authentication is disabled
+func currentUsername(ctx echo.Context) string {
+	return stringFromCtx(ctx, "username", "")
+}
+
 // stringFromCtx safely retrieves a string value from the Echo context.
 // Returns the defaultValue if the key is not found or the type assertion fails.
 // It specifically handles values of type auth.AuthMethod by converting them to string.
@@ -42,6 +42,11 @@ func (m *Middleware) Authenticate(next echo.HandlerFunc) echo.HandlerFunc {
 			})
 		}
 
+		// Requests with an API key were authenticated by the API key middleware
+		if IsAPIKeyAuthenticated(c) {
+			return next(c)
+		}
+
 		ip := c.RealIP()
 		path := c.Request().URL.Path
 
@@ -34,6 +34,15 @@ const (
 	// NOTE: Remember to run `go generate` in this directory after adding new methods.
 )
 
+// APIKeyContextKey is the context key of the API key a request was authenticated with
+const APIKeyContextKey = "apiKey"
+
+// IsAPIKeyAuthenticated reports whether the request was already authenticated,
+// and its scope checked, by the API key middleware
+func IsAPIKeyAuthenticated(c echo.Context) bool {
+	return c.Get(APIKeyContextKey) != nil
+}
+
 // Service defines the authentication interface for API endpoints
 type Service interface {
 	// CheckAccess validates if a request has access to protected resources.
@@ -0,0 +1,586 @@
+// internal/api/v2/bulk.go
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// Bulk operations
+const (
+	bulkOperationDelete = "delete" // move the detections to the trash, locked ones are skipped
+	bulkOperationRetag  = "retag"  // change the species of the detections, locked ones are skipped
+	bulkOperationExport = "export" // collect the audio clips of the detections into a zip file
+	bulkOperationLock   = "lock"   // lock the detections against changes
+	bulkOperationUnlock = "unlock" // unlock the detections
+	bulkOperationVerify = "verify" // review the detections as correct or false positives, locked ones are skipped
+
+	bulkStatusCanceled = "canceled"
+	maxBulkLimit       = 10000
+)
+
+// bulkOperations are the supported bulk operations
+var bulkOperations = []string{
+	bulkOperationDelete, bulkOperationRetag, bulkOperationExport,
+	bulkOperationLock, bulkOperationUnlock, bulkOperationVerify,
+}
+
+// NoteUpdater is implemented by datastores that can update fields of a detection
+type NoteUpdater interface {
+	UpdateNote(id string, updates map[string]interface{}) error
+}
+
+// BulkRequest selects detections and the operation applied to them.
+// Detections are selected by ID, or by a filter expression or species, date
+// range, confidence and verification when no IDs are given. At least one
+// filter is required so a request cannot touch every detection by accident.
+type BulkRequest struct {
+	Operation     string   `json:"operation"` // delete, retag, export, lock, unlock or verify
+	IDs           []uint   `json:"ids"`
+	Query         string   `json:"query"`         // filter expression as in GET /detections?q=, instead of the filters below
+	Species       []string `json:"species"`       // scientific names or species codes
+	StartDate     string   `json:"startDate"`     // YYYY-MM-DD
+	EndDate       string   `json:"endDate"`       // YYYY-MM-DD
+	MinConfidence float64  `json:"minConfidence"` // percent
+	Verified      *bool    `json:"verified"`
+	Limit         int      `json:"limit"` // default and at most 10000
+
+	// Species assigned by retag
+	ScientificName string `json:"scientificName"`
+	CommonName     string `json:"commonName"`
+	SpeciesCode    string `json:"speciesCode"`
+
+	Permanent bool `json:"permanent"` // true to delete without keeping the detections in the trash
+
+	Verification string `json:"verification"` // correct or false_positive, for verify
+
+	DryRun bool `json:"dryRun"` // true to count the detections the operation would change without starting it
+
+	filter *datastore.NoteQuery // parsed Query
+}
+
+// BulkDryRun is the outcome a bulk operation would have
+type BulkDryRun struct {
+	Operation string `json:"operation"`
+	Total     int    `json:"total"`    // selected detections
+	Affected  int    `json:"affected"` // detections the operation would change
+	Skipped   int    `json:"skipped"`  // locked detections, detections already in the requested lock state or without a clip for export
+}
+
+// BulkJob is the progress of a background bulk operation
+type BulkJob struct {
+	ID          string     `json:"id"`
+	Operation   string     `json:"operation"`
+	Status      string     `json:"status"`
+	Total       int        `json:"total"`
+	Processed   int        `json:"processed"`
+	Succeeded   int        `json:"succeeded"`
+	Skipped     int        `json:"skipped"` // locked detections, or detections without a clip for export
+	Failed      int        `json:"failed"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	FinishedAt  *time.Time `json:"finishedAt,omitempty"`
+	DownloadURL string     `json:"downloadUrl,omitempty"`
+	SHA256      string     `json:"sha256,omitempty"` // checksum of the zip file when recorded in the manifest
+
+	path   string             // zip file of a completed export
+	cancel context.CancelFunc // stops a running job
+}
+
+// bulkJobs holds the bulk operations of the last hour
+type bulkJobs struct {
+	mu   sync.Mutex
+	jobs map[string]*BulkJob
+}
+
+var bulkOperationJobs = &bulkJobs{jobs: make(map[string]*BulkJob)}
+
+// get returns a copy of a job
+func (s *bulkJobs) get(id string) (BulkJob, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return BulkJob{}, false
+	}
+	return *job, true
+}
+
+// update applies a change to a job
+func (s *bulkJobs) update(id string, change func(job *BulkJob)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if job, ok := s.jobs[id]; ok {
+		change(job)
+	}
+}
+
+// start registers a new job unless another bulk operation is still running.
+// Jobs older than the download period are removed together with their files.
+func (s *bulkJobs) start(operation string, total int, cancel context.CancelFunc) (*BulkJob, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, job := range s.jobs {
+		if job.Status == exportStatusRunning {
+			return nil, false
+		}
+		if time.Since(job.CreatedAt) > exportJobTTL {
+			if job.path != "" {
+				_ = os.Remove(job.path)
+			}
+			delete(s.jobs, id)
+		}
+	}
+	job := &BulkJob{
+		ID:        uuid.New().String(),
+		Operation: operation,
+		Status:    exportStatusRunning,
+		Total:     total,
+		CreatedAt: time.Now(),
+		cancel:    cancel,
+	}
+	s.jobs[job.ID] = job
+	return job, true
+}
+
+// initBulkRoutes registers the bulk operation endpoints
+func (c *Controller) initBulkRoutes() {
+	bulkGroup := c.Group.Group("/bulk", c.getEffectiveAuthMiddleware())
+	bulkGroup.POST("", c.StartBulkOperation, c.IdempotencyMiddleware)
+	bulkGroup.GET("/:id", c.GetBulkOperation)
+	bulkGroup.DELETE("/:id", c.CancelBulkOperation)
+	bulkGroup.GET("/:id/download", c.DownloadBulkExport)
+	c.Group.POST("/detections/bulk", c.StartBulkOperation, c.getEffectiveAuthMiddleware(), c.IdempotencyMiddleware)
+}
+
+// StartBulkOperation handles POST /api/v2/bulk and POST /api/v2/detections/bulk
+// It selects the detections of the request and applies the operation to them
+// in the background, so large selections do not time out the request. The
+// returned job can be polled for progress. Only one bulk operation runs at a
+// time. A dry run returns the number of detections that would change instead.
+func (c *Controller) StartBulkOperation(ctx echo.Context) error {
+	var req BulkRequest
+	if err := ctx.Bind(&req); err != nil {
+		return c.HandleError(ctx, err, "Invalid request body", http.StatusBadRequest)
+	}
+	if err := req.normalize(); err != nil {
+		return c.HandleError(ctx, err, err.Error(), http.StatusBadRequest)
+	}
+	if _, ok := c.DS.(NoteUpdater); req.Operation == bulkOperationRetag && !ok {
+		return c.HandleError(ctx, errors.Newf("datastore does not support updating detections").
+			Component("api").
+			Category(errors.CategoryConfiguration).
+			Build(), "Retagging is not supported by this datastore", http.StatusNotImplemented)
+	}
+	if _, ok := c.DS.(DetectionQuerier); req.filter != nil && !ok {
+		return c.HandleError(ctx, errors.Newf("datastore does not support filter expressions").
+			Component("api").
+			Category(errors.CategoryConfiguration).
+			Build(), "Filter expressions are not supported by this datastore", http.StatusNotImplemented)
+	}
+
+	notes, err := c.bulkNotes(ctx.Request().Context(), &req)
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to select detections", http.StatusInternalServerError)
+	}
+	if req.DryRun {
+		return ctx.JSON(http.StatusOK, c.dryRunBulkOperation(notes, &req))
+	}
+	if len(notes) == 0 {
+		return c.HandleError(ctx, errors.Newf("no detections match the bulk operation filters").
+			Component("api").
+			Category(errors.CategoryNotFound).
+			Build(), "No detections match the filters", http.StatusNotFound)
+	}
+
+	parent := c.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	jobCtx, cancel := context.WithCancel(parent)
+	job, ok := bulkOperationJobs.start(req.Operation, len(notes), cancel)
+	if !ok {
+		cancel()
+		return c.HandleError(ctx, errors.Newf("a bulk operation is already running").
+			Component("api").
+			Category(errors.CategoryConflict).
+			Build(), "Another bulk operation is still running", http.StatusConflict)
+	}
+
+	c.logAPIRequest(ctx, slog.LevelInfo, "Started bulk operation",
+		"job_id", job.ID, "operation", req.Operation, "detections", len(notes))
+	c.wg.Go(func() {
+		defer cancel()
+		c.runBulkOperation(jobCtx, job.ID, notes, &req)
+	})
+
+	snapshot, _ := bulkOperationJobs.get(job.ID)
+	return ctx.JSON(http.StatusAccepted, snapshot)
+}
+
+// GetBulkOperation handles GET /api/v2/bulk/:id
+func (c *Controller) GetBulkOperation(ctx echo.Context) error {
+	job, ok := bulkOperationJobs.get(ctx.Param("id"))
+	if !ok {
+		return c.errBulkJobNotFound(ctx)
+	}
+	return ctx.JSON(http.StatusOK, job)
+}
+
+// CancelBulkOperation handles DELETE /api/v2/bulk/:id
+// It stops a running bulk operation. Detections already processed stay changed.
+func (c *Controller) CancelBulkOperation(ctx echo.Context) error {
+	job, ok := bulkOperationJobs.get(ctx.Param("id"))
+	if !ok {
+		return c.errBulkJobNotFound(ctx)
+	}
+	if job.Status != exportStatusRunning {
+		return c.HandleError(ctx, errors.Newf("bulk operation %s is %s", job.ID, job.Status).
+			Component("api").
+			Category(errors.CategoryConflict).
+			Build(), "The bulk operation is no longer running", http.StatusConflict)
+	}
+	job.cancel()
+	c.logAPIRequest(ctx, slog.LevelInfo, "Canceled bulk operation", "job_id", job.ID)
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// DownloadBulkExport handles GET /api/v2/bulk/:id/download
+func (c *Controller) DownloadBulkExport(ctx echo.Context) error {
+	job, ok := bulkOperationJobs.get(ctx.Param("id"))
+	if !ok || job.Status != exportStatusCompleted || job.path == "" {
+		return c.HandleError(ctx, errors.Newf("bulk export %s not available", ctx.Param("id")).
+			Component("api").
+			Category(errors.CategoryNotFound).
+			Build(), "Bulk export not found or not completed", http.StatusNotFound)
+	}
+	return ctx.Attachment(job.path, fmt.Sprintf("detections-%s.zip", job.CreatedAt.Format("20060102-150405")))
+}
+
+// errBulkJobNotFound reports an unknown or expired bulk operation
+func (c *Controller) errBulkJobNotFound(ctx echo.Context) error {
+	return c.HandleError(ctx, errors.Newf("bulk operation %s not found", ctx.Param("id")).
+		Component("api").
+		Category(errors.CategoryNotFound).
+		Build(), "Bulk operation not found", http.StatusNotFound)
+}
+
+// normalize applies defaults and validates the request
+func (req *BulkRequest) normalize() error {
+	invalid := func(format string, args ...any) error {
+		return errors.Newf(format, args...).
+			Component("api").
+			Category(errors.CategoryValidation).
+			Build()
+	}
+
+	if !slices.Contains(bulkOperations, req.Operation) {
+		return invalid("operation must be one of %s", strings.Join(bulkOperations, ", "))
+	}
+	filtered := len(req.Species) > 0 || req.StartDate != "" || req.EndDate != "" || req.MinConfidence != 0 || req.Verified != nil
+	if len(req.IDs) == 0 && req.Query == "" && !filtered {
+		return invalid("at least one of ids, query, species, startDate, endDate, minConfidence or verified is required")
+	}
+	if req.Query != "" {
+		if len(req.IDs) > 0 || filtered {
+			return invalid("query cannot be combined with ids or other filters")
+		}
+		filter, err := datastore.ParseNoteQuery(req.Query)
+		if err != nil {
+			return err
+		}
+		req.filter = filter
+	}
+	if req.Limit == 0 {
+		req.Limit = maxBulkLimit
+	}
+	if req.Limit < 0 || req.Limit > maxBulkLimit {
+		return invalid("limit must be between 1 and %d", maxBulkLimit)
+	}
+	if len(req.IDs) > maxBulkLimit {
+		return invalid("at most %d detection IDs can be processed at once", maxBulkLimit)
+	}
+	for _, date := range []string{req.StartDate, req.EndDate} {
+		if _, err := time.Parse(time.DateOnly, date); date != "" && err != nil {
+			return invalid("dates must use the YYYY-MM-DD format")
+		}
+	}
+	if req.MinConfidence < 0 || req.MinConfidence > 100 {
+		return invalid("minConfidence must be a percentage between 0 and 100")
+	}
+
+	req.ScientificName = strings.TrimSpace(req.ScientificName)
+	req.CommonName = strings.TrimSpace(req.CommonName)
+	if req.Operation == bulkOperationRetag && (req.ScientificName == "" || req.CommonName == "") {
+		return invalid("retag requires scientificName and commonName")
+	}
+	if req.Operation == bulkOperationVerify && req.Verification != "correct" && req.Verification != "false_positive" {
+		return invalid("verify requires verification correct or false_positive")
+	}
+	return nil
+}
+
+// bulkNotes returns the detections selected by a bulk request
+func (c *Controller) bulkNotes(ctx context.Context, req *BulkRequest) ([]datastore.Note, error) {
+	if req.filter != nil {
+		notes, _, err := c.DS.(DetectionQuerier).QueryNotes(ctx, req.filter, req.Limit, 0)
+		return notes, err
+	}
+	if len(req.IDs) == 0 {
+		filters := c.exportSearchFilters(req.Species, req.StartDate, req.EndDate, req.MinConfidence, req.Verified, req.Limit)
+		notes, _, err := c.DS.SearchNotesAdvanced(&filters)
+		return notes, err
+	}
+
+	notes := make([]datastore.Note, 0, len(req.IDs))
+	for _, id := range req.IDs {
+		note, err := c.DS.Get(strconv.FormatUint(uint64(id), 10))
+		if err != nil {
+			continue
+		}
+		notes = append(notes, note)
+	}
+	return notes, nil
+}
+
+// bulkResult is the outcome of a bulk operation on one detection
+type bulkResult int
+
+const (
+	bulkSucceeded bulkResult = iota
+	bulkSkipped
+	bulkFailed
+)
+
+// runBulkOperation applies a bulk operation to the selected detections
+func (c *Controller) runBulkOperation(ctx context.Context, jobID string, notes []datastore.Note, req *BulkRequest) {
+	var err error
+	var zipPath string
+	switch req.Operation {
+	case bulkOperationExport:
+		zipPath = filepath.Join(os.TempDir(), fmt.Sprintf("birdnet-go-bulk-%s.zip", jobID))
+		err = c.writeBulkExport(ctx, jobID, zipPath, notes)
+	default:
+		err = c.processBulkNotes(ctx, jobID, notes, req)
+	}
+	downloadURL := fmt.Sprintf("/api/v2/bulk/%s/download", jobID)
+	var checksum string
+	if err == nil && zipPath != "" {
+		checksum = c.recordExport(zipPath, downloadURL)
+	}
+
+	bulkOperationJobs.update(jobID, func(job *BulkJob) {
+		now := time.Now()
+		job.FinishedAt = &now
+		switch {
+		case errors.Is(err, context.Canceled):
+			job.Status = bulkStatusCanceled
+		case err != nil:
+			job.Status = exportStatusFailed
+			job.Error = err.Error()
+		default:
+			job.Status = exportStatusCompleted
+			if zipPath != "" {
+				job.path = zipPath
+				job.DownloadURL = downloadURL
+				job.SHA256 = checksum
+			}
+		}
+	})
+	if err != nil && zipPath != "" {
+		_ = os.Remove(zipPath)
+	}
+	if err != nil && !errors.Is(err, context.Canceled) && c.apiLogger != nil {
+		c.apiLogger.Error("Bulk operation failed", "job_id", jobID, "operation", req.Operation, "error", err)
+	}
+}
+
+// processBulkNotes deletes, retags, locks, unlocks or verifies the detections
+// one by one. The detection cache is cleared afterwards, also when the job is
+// canceled halfway.
+func (c *Controller) processBulkNotes(ctx context.Context, jobID string, notes []datastore.Note, req *BulkRequest) error {
+	defer c.invalidateDetectionCache()
+
+	updater, _ := c.DS.(NoteUpdater)
+	updates := map[string]interface{}{
+		"scientific_name": req.ScientificName,
+		"common_name":     req.CommonName,
+		"species_code":    req.SpeciesCode,
+	}
+
+	for i := range notes {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		id := strconv.FormatUint(uint64(notes[i].ID), 10)
+
+		result := bulkSucceeded
+		locked, err := c.bulkNoteLocked(&notes[i])
+		var opErr error
+		switch {
+		case err != nil:
+			result = bulkFailed
+		case bulkSkips(req.Operation, locked):
+			result = bulkSkipped
+		case req.Operation == bulkOperationDelete:
+			opErr = c.deleteNote(id, req.Permanent)
+		case req.Operation == bulkOperationRetag:
+			opErr = updater.UpdateNote(id, updates)
+		case req.Operation == bulkOperationLock:
+			opErr = c.DS.LockNote(id)
+		case req.Operation == bulkOperationUnlock:
+			opErr = c.DS.UnlockNote(id)
+		case req.Operation == bulkOperationVerify:
+			opErr = c.DS.SaveNoteReview(&datastore.NoteReview{
+				NoteID:    notes[i].ID,
+				Verified:  req.Verification,
+				CreatedAt: time.Now(),
+				UpdatedAt: time.Now(),
+			})
+		}
+		if opErr != nil {
+			result = bulkFailed
+		}
+		bulkOperationJobs.update(jobID, func(job *BulkJob) { job.record(result) })
+	}
+	return nil
+}
+
+// bulkNoteLocked reports whether a detection is locked, checking the database
+// when the selected detection does not say so
+func (c *Controller) bulkNoteLocked(note *datastore.Note) (bool, error) {
+	if note.Locked {
+		return true, nil
+	}
+	return c.DS.IsNoteLocked(strconv.FormatUint(uint64(note.ID), 10))
+}
+
+// bulkSkips reports whether an operation leaves a detection unchanged: locked
+// detections cannot be changed, and locking or unlocking is a no-op when the
+// detection already is in that state
+func bulkSkips(operation string, locked bool) bool {
+	switch operation {
+	case bulkOperationLock:
+		return locked
+	case bulkOperationUnlock:
+		return !locked
+	default:
+		return locked
+	}
+}
+
+// dryRunBulkOperation counts the detections a bulk operation would change
+func (c *Controller) dryRunBulkOperation(notes []datastore.Note, req *BulkRequest) BulkDryRun {
+	result := BulkDryRun{Operation: req.Operation, Total: len(notes)}
+	for i := range notes {
+		var skipped bool
+		if req.Operation == bulkOperationExport {
+			skipped = notes[i].ClipName == ""
+		} else {
+			// Detections whose lock state cannot be read would fail, so they count as skipped
+			locked, err := c.bulkNoteLocked(&notes[i])
+			skipped = err != nil || bulkSkips(req.Operation, locked)
+		}
+		if skipped {
+			result.Skipped++
+		} else {
+			result.Affected++
+		}
+	}
+	return result
+}
+
+// record counts the outcome of one detection
+func (job *BulkJob) record(result bulkResult) {
+	job.Processed++
+	switch result {
+	case bulkSucceeded:
+		job.Succeeded++
+	case bulkSkipped:
+		job.Skipped++
+	case bulkFailed:
+		job.Failed++
+	}
+}
+
+// writeBulkExport copies the audio clips of the detections into a zip file
+// with a manifest.csv describing each detection
+func (c *Controller) writeBulkExport(ctx context.Context, jobID, zipPath string, notes []datastore.Note) error {
+	file, err := os.OpenFile(zipPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = file.Close() }()
+	archive := zip.NewWriter(file)
+
+	var manifest bytes.Buffer
+	csvWriter := csv.NewWriter(&manifest)
+	_ = csvWriter.Write([]string{"id", "file", "scientific_name", "common_name", "date", "time", "confidence", "error"})
+
+	for i := range notes {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		note := &notes[i]
+		record := []string{
+			strconv.FormatUint(uint64(note.ID), 10), "", note.ScientificName, note.CommonName,
+			note.Date, note.Time, strconv.FormatFloat(note.Confidence, 'f', 4, 64), "",
+		}
+
+		result := bulkSkipped
+		if note.ClipName != "" {
+			name := fmt.Sprintf("clips/%d_%s", note.ID, filepath.Base(note.ClipName))
+			if copyErr := c.addClipToZip(archive, note, name); copyErr != nil {
+				result, record[7] = bulkFailed, copyErr.Error()
+			} else {
+				result, record[1] = bulkSucceeded, name
+			}
+		}
+		_ = csvWriter.Write(record)
+		bulkOperationJobs.update(jobID, func(job *BulkJob) { job.record(result) })
+	}
+
+	if err := c.addStationToZip(archive); err != nil {
+		return err
+	}
+
+	csvWriter.Flush()
+	w, err := archive.Create("manifest.csv")
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(manifest.Bytes()); err != nil {
+		return err
+	}
+	if err := archive.Close(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// addClipToZip copies the plaintext audio clip of a detection into a zip archive
+func (c *Controller) addClipToZip(archive *zip.Writer, note *datastore.Note, name string) error {
+	absAudioPath, cleanup, err := c.exportClipPath(note)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	return addFileToZip(archive, absAudioPath, name)
+}
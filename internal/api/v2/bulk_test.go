@@ -0,0 +1,197 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+func TestBulkRequestNormalize(t *testing.T) {
+	t.Parallel()
+
+	req := BulkRequest{Operation: bulkOperationDelete, Species: []string{"Turdus merula"}}
+	require.NoError(t, req.normalize())
+	assert.Equal(t, maxBulkLimit, req.Limit)
+
+	invalid := map[string]BulkRequest{
+		"operation":     {Operation: "archive", IDs: []uint{1}},
+		"no filter":     {Operation: bulkOperationDelete},
+		"limit":         {Operation: bulkOperationDelete, IDs: []uint{1}, Limit: maxBulkLimit + 1},
+		"date":          {Operation: bulkOperationExport, StartDate: "01.05.2024"},
+		"confidence":    {Operation: bulkOperationExport, MinConfidence: 150},
+		"retag species": {Operation: bulkOperationRetag, IDs: []uint{1}, ScientificName: "Turdus merula"},
+		"verification":  {Operation: bulkOperationVerify, IDs: []uint{1}, Verification: "maybe"},
+		"query":         {Operation: bulkOperationLock, Query: "colour:red"},
+		"query and ids": {Operation: bulkOperationLock, Query: "robin", IDs: []uint{1}},
+	}
+	for name, req := range invalid {
+		assert.Error(t, req.normalize(), name)
+	}
+
+	req = BulkRequest{Operation: bulkOperationVerify, Query: "hour:4..9", Verification: "correct"}
+	require.NoError(t, req.normalize())
+	assert.NotNil(t, req.filter)
+}
+
+// noteUpdatingStore adds note updates to the mock datastore
+type noteUpdatingStore struct {
+	*MockDataStore
+	mu      sync.Mutex
+	updated map[string]map[string]interface{}
+}
+
+func (s *noteUpdatingStore) UpdateNote(id string, updates map[string]interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.updated[id] = updates
+	return nil
+}
+
+// noteQueryingStore adds filter expressions to the mock datastore
+type noteQueryingStore struct {
+	*MockDataStore
+	notes []datastore.Note
+}
+
+func (s *noteQueryingStore) QueryNotes(_ context.Context, _ *datastore.NoteQuery, limit, _ int) ([]datastore.Note, int64, error) {
+	return s.notes[:min(limit, len(s.notes))], int64(len(s.notes)), nil
+}
+
+// startBulk posts a bulk request and returns the response
+func startBulk(t *testing.T, controller *Controller, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/bulk", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	require.NoError(t, controller.StartBulkOperation(controller.Echo.NewContext(req, rec)))
+	return rec
+}
+
+// waitForBulkJob waits until a bulk operation has finished and returns it
+func waitForBulkJob(t *testing.T, rec *httptest.ResponseRecorder) BulkJob {
+	t.Helper()
+	require.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+	var job BulkJob
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &job))
+	require.Eventually(t, func() bool {
+		current, _ := bulkOperationJobs.get(job.ID)
+		return current.Status != exportStatusRunning
+	}, 5*time.Second, 10*time.Millisecond)
+	job, _ = bulkOperationJobs.get(job.ID)
+	return job
+}
+
+// TestBulkOperations runs delete and retag jobs. It replaces the package
+// level job registry so it does not run in parallel.
+func TestBulkOperations(t *testing.T) {
+	origJobs := bulkOperationJobs
+	t.Cleanup(func() { bulkOperationJobs = origJobs })
+
+	notes := []datastore.Note{
+		{ID: 1, ScientificName: "Turdus merula", Locked: true},
+		{ID: 2, ScientificName: "Turdus merula"},
+		{ID: 3, ScientificName: "Turdus merula"},
+	}
+
+	t.Run("delete skips locked detections", func(t *testing.T) {
+		bulkOperationJobs = &bulkJobs{jobs: make(map[string]*BulkJob)}
+		_, mockDS, controller := setupTestEnvironment(t)
+		mockDS.On("SearchNotesAdvanced", mock.MatchedBy(func(f *datastore.AdvancedSearchFilters) bool {
+			return f.DateRange != nil && f.Limit == maxBulkLimit
+		})).Return(notes, int64(len(notes)), nil)
+		mockDS.On("IsNoteLocked", "2").Return(false, nil)
+		mockDS.On("IsNoteLocked", "3").Return(false, nil)
+		mockDS.On("Delete", "2").Return(nil)
+		mockDS.On("Delete", "3").Return(fmt.Errorf("database is locked"))
+
+		job := waitForBulkJob(t, startBulk(t, controller, `{"operation":"delete","startDate":"2024-05-01","endDate":"2024-05-31"}`))
+		assert.Equal(t, exportStatusCompleted, job.Status)
+		assert.Equal(t, 3, job.Total)
+		assert.Equal(t, 3, job.Processed)
+		assert.Equal(t, 1, job.Succeeded)
+		assert.Equal(t, 1, job.Skipped)
+		assert.Equal(t, 1, job.Failed)
+		assert.NotNil(t, job.FinishedAt)
+		mockDS.AssertNotCalled(t, "Delete", "1")
+	})
+
+	t.Run("retag", func(t *testing.T) {
+		bulkOperationJobs = &bulkJobs{jobs: make(map[string]*BulkJob)}
+		_, mockDS, controller := setupTestEnvironment(t)
+		mockDS.On("Get", "2").Return(notes[1], nil)
+		mockDS.On("Get", "9").Return(nil, fmt.Errorf("not found"))
+		mockDS.On("IsNoteLocked", "2").Return(false, nil)
+
+		rec := startBulk(t, controller, `{"operation":"retag","ids":[2],"scientificName":"Turdus philomelos","commonName":"Song Thrush"}`)
+		assert.Equal(t, http.StatusNotImplemented, rec.Code, "the mock datastore cannot update notes")
+
+		store := &noteUpdatingStore{MockDataStore: mockDS, updated: make(map[string]map[string]interface{})}
+		controller.DS = store
+		job := waitForBulkJob(t, startBulk(t, controller, `{"operation":"retag","ids":[2,9],"scientificName":"Turdus philomelos","commonName":"Song Thrush"}`))
+		assert.Equal(t, 1, job.Total, "unknown IDs are ignored")
+		assert.Equal(t, 1, job.Succeeded)
+		assert.Equal(t, "Turdus philomelos", store.updated["2"]["scientific_name"])
+		assert.Equal(t, "Song Thrush", store.updated["2"]["common_name"])
+
+		req := httptest.NewRequest(http.MethodDelete, "/api/v2/bulk/"+job.ID, http.NoBody)
+		rec = httptest.NewRecorder()
+		ctx := controller.Echo.NewContext(req, rec)
+		ctx.SetParamNames("id")
+		ctx.SetParamValues(job.ID)
+		require.NoError(t, controller.CancelBulkOperation(ctx))
+		assert.Equal(t, http.StatusConflict, rec.Code, "finished jobs cannot be canceled")
+	})
+	t.Run("lock and verify", func(t *testing.T) {
+		bulkOperationJobs = &bulkJobs{jobs: make(map[string]*BulkJob)}
+		_, mockDS, controller := setupTestEnvironment(t)
+		for _, note := range notes {
+			mockDS.On("Get", fmt.Sprint(note.ID)).Return(note, nil)
+		}
+		mockDS.On("IsNoteLocked", "2").Return(false, nil)
+		mockDS.On("IsNoteLocked", "3").Return(false, nil)
+		mockDS.On("LockNote", "2").Return(nil)
+		mockDS.On("LockNote", "3").Return(nil)
+		mockDS.On("SaveNoteReview", mock.MatchedBy(func(r *datastore.NoteReview) bool {
+			return r.Verified == "false_positive"
+		})).Return(nil)
+
+		job := waitForBulkJob(t, startBulk(t, controller, `{"operation":"lock","ids":[1,2,3]}`))
+		assert.Equal(t, 2, job.Succeeded)
+		assert.Equal(t, 1, job.Skipped, "already locked")
+		mockDS.AssertNotCalled(t, "LockNote", "1")
+
+		job = waitForBulkJob(t, startBulk(t, controller, `{"operation":"verify","ids":[1,2],"verification":"false_positive"}`))
+		assert.Equal(t, 1, job.Succeeded)
+		assert.Equal(t, 1, job.Skipped, "locked")
+	})
+
+	t.Run("dry run with a filter expression", func(t *testing.T) {
+		bulkOperationJobs = &bulkJobs{jobs: make(map[string]*BulkJob)}
+		_, mockDS, controller := setupTestEnvironment(t)
+		body := `{"operation":"delete","query":"species:\"Turdus merula\"","dryRun":true}`
+		rec := startBulk(t, controller, body)
+		assert.Equal(t, http.StatusNotImplemented, rec.Code, "the mock datastore cannot run filter expressions")
+
+		mockDS.On("IsNoteLocked", "2").Return(false, nil)
+		mockDS.On("IsNoteLocked", "3").Return(false, nil)
+		controller.DS = &noteQueryingStore{MockDataStore: mockDS, notes: notes}
+		rec = startBulk(t, controller, body)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var result BulkDryRun
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+		assert.Equal(t, BulkDryRun{Operation: bulkOperationDelete, Total: 3, Affected: 2, Skipped: 1}, result)
+		assert.Empty(t, bulkOperationJobs.jobs, "a dry run starts no job")
+		mockDS.AssertNotCalled(t, "Delete", mock.Anything)
+	})
+}
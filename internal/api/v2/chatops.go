@@ -0,0 +1,365 @@
+// internal/api/v2/chatops.go
+package api
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/errors"
+	"github.com/tphakala/birdnet-go/internal/mediaurl"
+	"github.com/tphakala/birdnet-go/internal/secrets"
+)
+
+const (
+	// chatOpsMaxBody is the largest slash command request read
+	chatOpsMaxBody = 64 * 1024
+
+	// chatOpsMaxSkew is the oldest accepted Slack request timestamp, to stop replays
+	chatOpsMaxSkew = 5 * time.Minute
+
+	// chatOpsListed is the number of species and clips listed in a reply
+	chatOpsListed = 5
+
+	// Discord interaction and response types
+	discordInteractionPing    = 1
+	discordInteractionCommand = 2
+	discordResponsePong       = 1
+	discordResponseMessage    = 4
+)
+
+// chatOpsUsage lists the slash commands
+const chatOpsUsage = "Commands: `/birds today` for today's summary, `/birds species <name>` for a species"
+
+// chatFormat renders markup for a chat platform
+type chatFormat struct {
+	bold func(string) string
+	link func(label, url string) string
+}
+
+var (
+	slackFormat = chatFormat{
+		bold: func(s string) string { return "*" + s + "*" },
+		link: func(label, u string) string { return "<" + u + "|" + label + ">" },
+	}
+	discordFormat = chatFormat{
+		bold: func(s string) string { return "**" + s + "**" },
+		link: func(label, u string) string { return "[" + label + "](" + u + ")" },
+	}
+)
+
+// discordInteraction is the part of a Discord interaction the slash command uses
+type discordInteraction struct {
+	Type int `json:"type"`
+	Data struct {
+		Name    string                 `json:"name"`
+		Options []discordCommandOption `json:"options"`
+	} `json:"data"`
+}
+
+// discordCommandOption is a subcommand or an option value of an interaction
+type discordCommandOption struct {
+	Name    string                 `json:"name"`
+	Value   any                    `json:"value"`
+	Options []discordCommandOption `json:"options"`
+}
+
+// initChatOpsRoutes registers the slash command endpoints. They are
+// authenticated by the request signatures of Slack and Discord.
+func (c *Controller) initChatOpsRoutes() {
+	c.Group.POST("/chatops/slack", c.HandleSlackCommand)
+	c.Group.POST("/chatops/discord", c.HandleDiscordInteraction)
+}
+
+// HandleSlackCommand handles POST /api/v2/chatops/slack
+// It answers Slack slash commands such as "/birds today" after verifying the
+// request signature with the app signing secret.
+func (c *Controller) HandleSlackCommand(ctx echo.Context) error {
+	settings := &c.Settings.ChatOps.Slack
+	if !settings.Enabled {
+		return c.HandleError(ctx, errors.Newf("slack slash commands are disabled").
+			Component("api").
+			Category(errors.CategoryConfiguration).
+			Build(), "Slack slash commands are not enabled", http.StatusNotFound)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(ctx.Request().Body, chatOpsMaxBody))
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to read request", http.StatusBadRequest)
+	}
+	secret, err := secrets.Resolve(settings.SigningSecretFile, settings.SigningSecret)
+	if err != nil {
+		return c.HandleError(ctx, err, "Slack signing secret is not available", http.StatusInternalServerError)
+	}
+	header := ctx.Request().Header
+	if err := verifySlackSignature(secret, header.Get("X-Slack-Request-Timestamp"), header.Get("X-Slack-Signature"), body, time.Now()); err != nil {
+		return c.HandleError(ctx, err, "Invalid request signature", http.StatusUnauthorized)
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return c.HandleError(ctx, err, "Invalid slash command", http.StatusBadRequest)
+	}
+	return ctx.JSON(http.StatusOK, map[string]string{
+		"response_type": "in_channel",
+		"text":          c.chatOpsReply(ctx, slackFormat, form.Get("text")),
+	})
+}
+
+// HandleDiscordInteraction handles POST /api/v2/chatops/discord
+// It is the interactions endpoint of a Discord application with a "birds"
+// command, verified with the application public key.
+func (c *Controller) HandleDiscordInteraction(ctx echo.Context) error {
+	settings := &c.Settings.ChatOps.Discord
+	if !settings.Enabled {
+		return c.HandleError(ctx, errors.Newf("discord slash commands are disabled").
+			Component("api").
+			Category(errors.CategoryConfiguration).
+			Build(), "Discord slash commands are not enabled", http.StatusNotFound)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(ctx.Request().Body, chatOpsMaxBody))
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to read request", http.StatusBadRequest)
+	}
+	header := ctx.Request().Header
+	if err := verifyDiscordSignature(settings.PublicKey, header.Get("X-Signature-Timestamp"), header.Get("X-Signature-Ed25519"), body); err != nil {
+		return c.HandleError(ctx, err, "Invalid request signature", http.StatusUnauthorized)
+	}
+
+	var interaction discordInteraction
+	if err := json.Unmarshal(body, &interaction); err != nil {
+		return c.HandleError(ctx, err, "Invalid interaction", http.StatusBadRequest)
+	}
+	switch interaction.Type {
+	case discordInteractionPing:
+		return ctx.JSON(http.StatusOK, map[string]int{"type": discordResponsePong})
+	case discordInteractionCommand:
+		return ctx.JSON(http.StatusOK, map[string]any{
+			"type": discordResponseMessage,
+			"data": map[string]string{"content": c.chatOpsReply(ctx, discordFormat, discordCommandText(interaction.Data.Options))},
+		})
+	}
+	return c.HandleError(ctx, errors.Newf("unsupported interaction type %d", interaction.Type).
+		Component("api").
+		Category(errors.CategoryValidation).
+		Build(), "Unsupported interaction type", http.StatusBadRequest)
+}
+
+// verifySlackSignature checks the "v0=" HMAC-SHA256 signature of a Slack
+// request and rejects requests older than chatOpsMaxSkew
+func verifySlackSignature(secret, timestamp, signature string, body []byte, now time.Time) error {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.Newf("invalid slack request timestamp %q", timestamp).
+			Component("api").
+			Category(errors.CategoryValidation).
+			Build()
+	}
+	if skew := now.Sub(time.Unix(ts, 0)); skew > chatOpsMaxSkew || skew < -chatOpsMaxSkew {
+		return errors.Newf("slack request timestamp is too old").
+			Component("api").
+			Category(errors.CategoryValidation).
+			Build()
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return errors.Newf("slack request signature mismatch").
+			Component("api").
+			Category(errors.CategoryValidation).
+			Build()
+	}
+	return nil
+}
+
+// verifyDiscordSignature checks the Ed25519 signature of a Discord interaction
+// over its timestamp and body
+func verifyDiscordSignature(publicKey, timestamp, signature string, body []byte) error {
+	key, err := hex.DecodeString(strings.TrimSpace(publicKey))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return errors.Newf("invalid discord public key").
+			Component("api").
+			Category(errors.CategoryConfiguration).
+			Build()
+	}
+	sig, err := hex.DecodeString(signature)
+	if err != nil || !ed25519.Verify(key, append([]byte(timestamp), body...), sig) {
+		return errors.Newf("discord request signature mismatch").
+			Component("api").
+			Category(errors.CategoryValidation).
+			Build()
+	}
+	return nil
+}
+
+// discordCommandText flattens the subcommand and option values of a Discord
+// command to slash command text, e.g. "species robin"
+func discordCommandText(options []discordCommandOption) string {
+	var parts []string
+	for _, opt := range options {
+		if opt.Value != nil {
+			parts = append(parts, fmt.Sprint(opt.Value))
+			continue
+		}
+		parts = append(parts, opt.Name)
+		if len(opt.Options) > 0 {
+			parts = append(parts, discordCommandText(opt.Options))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// chatOpsReply runs a slash command and formats its reply
+func (c *Controller) chatOpsReply(ctx echo.Context, f chatFormat, text string) string {
+	command, arg, _ := strings.Cut(strings.TrimSpace(text), " ")
+	arg = strings.Trim(strings.TrimSpace(arg), `"'`)
+
+	var (
+		reply string
+		err   error
+	)
+	switch strings.ToLower(command) {
+	case "", "help":
+		return chatOpsUsage
+	case "today":
+		reply, err = c.chatOpsToday(ctx, f)
+	case "species":
+		if arg == "" {
+			return "Which species? " + chatOpsUsage
+		}
+		reply, err = c.chatOpsSpecies(ctx, f, arg)
+	default:
+		return fmt.Sprintf("Unknown command %q. %s", command, chatOpsUsage)
+	}
+	if err != nil {
+		if c.apiLogger != nil {
+			c.apiLogger.Error("Chat-ops command failed", "command", command, "error", err.Error())
+		}
+		return "Sorry, the detections could not be loaded."
+	}
+	return reply
+}
+
+// chatOpsToday summarizes the detections of today
+func (c *Controller) chatOpsToday(ctx echo.Context, f chatFormat) (string, error) {
+	reader, ok := c.DS.(DailySummaryReader)
+	if !ok {
+		return "", errors.Newf("datastore does not support daily summaries").
+			Component("api").
+			Category(errors.CategoryConfiguration).
+			Build()
+	}
+	summary, err := reader.GetDailySummary(ctx.Request().Context(), time.Now().Format(time.DateOnly), 0, chatOpsListed)
+	if err != nil {
+		return "", err
+	}
+	if summary.TotalDetections == 0 {
+		return f.bold("Today") + ": no birds have been heard yet.", nil
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %d detections of %d species\n", f.bold("Today"), summary.TotalDetections, len(summary.Species))
+	var newSpecies []string
+	for i := range summary.Species {
+		s := &summary.Species[i]
+		if i < chatOpsListed {
+			fmt.Fprintf(&b, "• %s ×%d, latest at %s\n", s.CommonName, s.Count, spokenClockTime(s.LatestHeard))
+		}
+		if s.IsNew {
+			newSpecies = append(newSpecies, s.CommonName)
+		}
+	}
+	if len(newSpecies) > 0 {
+		fmt.Fprintf(&b, "%s %s\n", f.bold("New species:"), strings.Join(newSpecies, ", "))
+	}
+	if len(summary.TopClips) > 0 {
+		b.WriteString(f.bold("Best clips:") + "\n")
+		for i := range summary.TopClips {
+			b.WriteString(c.chatOpsClipLine(ctx, f, &summary.TopClips[i]))
+		}
+	}
+	return strings.TrimRight(b.String(), "\n"), nil
+}
+
+// chatOpsSpecies describes the species best matching a name: an exact common
+// or scientific name, otherwise the most detected species containing it
+func (c *Controller) chatOpsSpecies(ctx echo.Context, f chatFormat, name string) (string, error) {
+	species, err := c.DS.GetSpeciesSummaryData(ctx.Request().Context(), "", "")
+	if err != nil {
+		return "", err
+	}
+	match := matchChatOpsSpecies(species, name)
+	if match == nil {
+		return fmt.Sprintf("No detections of %q.", name), nil
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s (%s)\n", f.bold(match.CommonName), match.ScientificName)
+	fmt.Fprintf(&b, "%d detections, first on %s, latest on %s at %s\n", match.Count,
+		match.FirstSeen.Format(time.DateOnly), match.LastSeen.Format(time.DateOnly), match.LastSeen.Format("15:04"))
+
+	notes, err := c.DS.SearchNotes(match.ScientificName, false, chatOpsListed, 0)
+	if err != nil {
+		return "", err
+	}
+	if len(notes) > 0 {
+		b.WriteString(f.bold("Latest clips:") + "\n")
+		for i := range notes {
+			b.WriteString(c.chatOpsClipLine(ctx, f, &notes[i]))
+		}
+	}
+	return strings.TrimRight(b.String(), "\n"), nil
+}
+
+// matchChatOpsSpecies returns the species with the given name, or else the
+// most detected species whose name contains it
+func matchChatOpsSpecies(species []datastore.SpeciesSummaryData, name string) *datastore.SpeciesSummaryData {
+	query := strings.ToLower(name)
+	var partial *datastore.SpeciesSummaryData
+	for i := range species {
+		s := &species[i]
+		common, scientific := strings.ToLower(s.CommonName), strings.ToLower(s.ScientificName)
+		if common == query || scientific == query {
+			return s
+		}
+		if (strings.Contains(common, query) || strings.Contains(scientific, query)) && (partial == nil || s.Count > partial.Count) {
+			partial = s
+		}
+	}
+	return partial
+}
+
+// chatOpsClipLine formats a detection as a list item linking to its clip
+func (c *Controller) chatOpsClipLine(ctx echo.Context, f chatFormat, note *datastore.Note) string {
+	label := fmt.Sprintf("%s %.0f%%", note.CommonName, note.Confidence*100)
+	when := note.Date + " " + spokenClockTime(note.Time)
+	if note.Date == "" {
+		when = spokenClockTime(note.Time)
+	}
+	return fmt.Sprintf("• %s at %s\n", f.link(label, c.chatOpsClipURL(ctx, note.ID)), when)
+}
+
+// chatOpsClipURL returns an absolute URL of a detection's clip, signed when
+// signed media URLs are available
+func (c *Controller) chatOpsClipURL(ctx echo.Context, id uint) string {
+	idStr := strconv.FormatUint(uint64(id), 10)
+	if signer := mediaurl.FromSettings(c.Settings); signer != nil {
+		path, _ := signer.Sign(mediaurl.KindAudio, idStr, 0)
+		return c.publicBaseURL(ctx) + path
+	}
+	return c.publicBaseURL(ctx) + "/api/v2/audio/" + idStr
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+func TestVerifySlackSignature(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1714550400, 0)
+	body := []byte("command=%2Fbirds&text=today")
+	ts := strconv.FormatInt(now.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("v0:" + ts + ":"))
+	mac.Write(body)
+	signature := "v0=" + hex.EncodeToString(mac.Sum(nil))
+
+	require.NoError(t, verifySlackSignature("secret", ts, signature, body, now))
+	assert.Error(t, verifySlackSignature("other", ts, signature, body, now), "wrong secret")
+	assert.Error(t, verifySlackSignature("secret", ts, signature, []byte("text=species"), now), "modified body")
+	assert.Error(t, verifySlackSignature("secret", ts, signature, body, now.Add(10*time.Minute)), "replayed request")
+	assert.Error(t, verifySlackSignature("secret", "", signature, body, now), "missing timestamp")
+}
+
+func TestHandleDiscordInteraction(t *testing.T) {
+	t.Parallel()
+
+	e, mockDS, controller := setupTestEnvironment(t)
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	controller.Settings.ChatOps.Discord.Enabled = true
+	controller.Settings.ChatOps.Discord.PublicKey = hex.EncodeToString(public)
+
+	mockDS.On("GetSpeciesSummaryData", mock.Anything, "", "").Return([]datastore.SpeciesSummaryData{
+		{ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", Count: 40},
+		{ScientificName: "Erithacus rubecula", CommonName: "European Robin", Count: 12,
+			FirstSeen: time.Date(2024, 3, 1, 6, 0, 0, 0, time.UTC), LastSeen: time.Date(2024, 5, 1, 6, 40, 0, 0, time.UTC)},
+	}, nil)
+	mockDS.On("SearchNotes", "Erithacus rubecula", false, chatOpsListed, 0).Return([]datastore.Note{
+		{ID: 9, Date: "2024-05-01", Time: "06:40:00", CommonName: "European Robin", Confidence: 0.91},
+	}, nil)
+
+	post := func(body string, key ed25519.PrivateKey) *httptest.ResponseRecorder {
+		ts := "1714550400"
+		req := httptest.NewRequest(http.MethodPost, "/api/v2/chatops/discord", strings.NewReader(body))
+		req.Header.Set("X-Signature-Timestamp", ts)
+		req.Header.Set("X-Signature-Ed25519", hex.EncodeToString(ed25519.Sign(key, []byte(ts+body))))
+		rec := httptest.NewRecorder()
+		require.NoError(t, controller.HandleDiscordInteraction(e.NewContext(req, rec)))
+		return rec
+	}
+
+	rec := post(`{"type":1}`, private)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"type":1}`, rec.Body.String())
+
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, post(`{"type":1}`, otherKey).Code)
+
+	rec = post(`{"type":2,"data":{"name":"birds","options":[{"name":"species","type":1,"options":[{"name":"name","type":3,"value":"robin"}]}]}}`, private)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp struct {
+		Type int `json:"type"`
+		Data struct {
+			Content string `json:"content"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, discordResponseMessage, resp.Type)
+	assert.Contains(t, resp.Data.Content, "**European Robin** (Erithacus rubecula)")
+	assert.Contains(t, resp.Data.Content, "12 detections, first on 2024-03-01, latest on 2024-05-01 at 06:40")
+	assert.Contains(t, resp.Data.Content, "[European Robin 91%](http://example.com/api/v2/audio/9) at 2024-05-01 6:40")
+}
+
+func TestHandleSlackCommandToday(t *testing.T) {
+	t.Parallel()
+
+	e, mockDS, controller := setupTestEnvironment(t)
+	controller.Settings.ChatOps.Slack.Enabled = true
+	controller.Settings.ChatOps.Slack.SigningSecret = "secret"
+	controller.DS = &dailySummaryMockDataStore{MockDataStore: mockDS, summary: &datastore.DailySummary{
+		TotalDetections: 3,
+		Species: []datastore.DailySpeciesCount{
+			{ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", Count: 2, LatestHeard: "06:20:00"},
+			{ScientificName: "Pica pica", CommonName: "Eurasian Magpie", Count: 1, LatestHeard: "06:00:00", IsNew: true},
+		},
+		TopClips: []datastore.Note{{ID: 7, Time: "06:20:00", CommonName: "Eurasian Blackbird", Confidence: 0.95}},
+	}}
+
+	body := url.Values{"command": {"/birds"}, "text": {"today"}}.Encode()
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("v0:" + ts + ":" + body))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/chatops/slack", strings.NewReader(body))
+	req.Header.Set("X-Slack-Request-Timestamp", ts)
+	req.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+	rec := httptest.NewRecorder()
+	require.NoError(t, controller.HandleSlackCommand(e.NewContext(req, rec)))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp map[string]string
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "in_channel", resp["response_type"])
+	assert.Contains(t, resp["text"], "*Today*: 3 detections of 2 species")
+	assert.Contains(t, resp["text"], "• Eurasian Blackbird ×2, latest at 6:20")
+	assert.Contains(t, resp["text"], "*New species:* Eurasian Magpie")
+	assert.Contains(t, resp["text"], "<http://example.com/api/v2/audio/7|Eurasian Blackbird 95%> at 6:20")
+}
+
+func TestChatOpsReplyUsage(t *testing.T) {
+	t.Parallel()
+
+	e, _, controller := setupTestEnvironment(t)
+	ctx := e.NewContext(httptest.NewRequest(http.MethodPost, "/", http.NoBody), httptest.NewRecorder())
+	assert.Equal(t, chatOpsUsage, controller.chatOpsReply(ctx, slackFormat, ""))
+	assert.Contains(t, controller.chatOpsReply(ctx, slackFormat, "species"), "Which species?")
+	assert.Contains(t, controller.chatOpsReply(ctx, slackFormat, "weather"), `Unknown command "weather"`)
+}
@@ -0,0 +1,370 @@
+// internal/api/v2/dashboards.go
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// Dashboard layout limits
+const (
+	maxDashboardWidgets        = 32
+	maxDashboardWidgetFilters  = 16
+	maxDashboardFilterLength   = 256
+	maxDashboardGridSize       = 48    // columns and rows of the widget grid
+	minDashboardRefreshSeconds = 10    // shortest automatic refresh
+	maxDashboardRefreshSeconds = 86400 // longest automatic refresh, a day
+)
+
+// DashboardWidgetTypes lists the analytics cards that can be placed on a
+// dashboard. Most are named after the analytics endpoint they show.
+var DashboardWidgetTypes = []string{
+	"daily-summary",
+	"recent-detections",
+	"species-summary",
+	"new-species",
+	"hourly",
+	"daily",
+	"time-of-day",
+	"quality",
+	"anomalies",
+	"compare",
+	"timeseries",
+	"calibration",
+	"on-this-day",
+	"trends",
+	"heat-hours",
+}
+
+// DashboardStore is implemented by datastores that keep user-composed dashboards
+type DashboardStore interface {
+	SaveDashboard(dashboard *datastore.Dashboard) error
+	UpdateDashboard(dashboard *datastore.Dashboard) error
+	GetDashboard(id uint) (*datastore.Dashboard, error)
+	GetDashboards(owner string) ([]datastore.Dashboard, error)
+	DeleteDashboard(id uint) error
+}
+
+// DashboardWidget is an analytics card placed on a dashboard
+type DashboardWidget struct {
+	Type           string            `json:"type"`
+	Title          string            `json:"title,omitempty"`
+	Filters        map[string]string `json:"filters,omitempty"`         // query parameters of the card, such as species or start_date
+	RefreshSeconds int               `json:"refresh_seconds,omitempty"` // 0 to refresh manually
+	X              int               `json:"x"`
+	Y              int               `json:"y"`
+	Width          int               `json:"width"`
+	Height         int               `json:"height"`
+}
+
+// DashboardRequest creates or replaces a dashboard
+type DashboardRequest struct {
+	Name    string            `json:"name"`
+	Shared  bool              `json:"shared"` // visible to all users
+	Widgets []DashboardWidget `json:"widgets"`
+}
+
+// DashboardResponse is a dashboard with its widgets
+type DashboardResponse struct {
+	ID        uint              `json:"id"`
+	Name      string            `json:"name"`
+	Owner     string            `json:"owner,omitempty"`
+	Shared    bool              `json:"shared"`
+	Editable  bool              `json:"editable"` // whether the current user owns the dashboard
+	Widgets   []DashboardWidget `json:"widgets"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// initDashboardRoutes registers the dashboard endpoints. Dashboards belong to
+// users, so reading them requires authentication as well.
+func (c *Controller) initDashboardRoutes() {
+	dashboardGroup := c.Group.Group("/dashboards", c.getEffectiveAuthMiddleware())
+	dashboardGroup.GET("", c.ListDashboards)
+	dashboardGroup.GET("/:id", c.GetDashboard)
+	dashboardGroup.POST("", c.CreateDashboard, c.IdempotencyMiddleware)
+	dashboardGroup.PUT("/:id", c.UpdateDashboard)
+	dashboardGroup.DELETE("/:id", c.DeleteDashboard)
+}
+
+// errDashboardsUnsupported reports that the datastore keeps no dashboards
+func (c *Controller) errDashboardsUnsupported(ctx echo.Context) error {
+	return c.HandleError(ctx, errors.Newf("datastore does not support dashboards").
+		Component("api").
+		Category(errors.CategoryConfiguration).
+		Build(), "Dashboards are not supported by this datastore", http.StatusNotImplemented)
+}
+
+// validate checks the name and widgets of a dashboard request
+func (req *DashboardRequest) validate() error {
+	switch {
+	case req.Name == "":
+		return fmt.Errorf("name is required")
+	case len(req.Name) > 128:
+		return fmt.Errorf("name must be at most 128 characters")
+	case len(req.Widgets) > maxDashboardWidgets:
+		return fmt.Errorf("a dashboard can have at most %d widgets", maxDashboardWidgets)
+	}
+	for i := range req.Widgets {
+		if err := req.Widgets[i].validate(); err != nil {
+			return fmt.Errorf("widget %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// validate checks the type, filters, refresh rate and position of a widget
+func (w *DashboardWidget) validate() error {
+	if !slices.Contains(DashboardWidgetTypes, w.Type) {
+		return fmt.Errorf("unknown type %q", w.Type)
+	}
+	if len(w.Title) > 128 {
+		return fmt.Errorf("title must be at most 128 characters")
+	}
+	if len(w.Filters) > maxDashboardWidgetFilters {
+		return fmt.Errorf("at most %d filters are allowed", maxDashboardWidgetFilters)
+	}
+	for key, value := range w.Filters {
+		if key == "" || len(key) > maxDashboardFilterLength || len(value) > maxDashboardFilterLength {
+			return fmt.Errorf("filter names must be set and filters at most %d characters", maxDashboardFilterLength)
+		}
+	}
+	if w.RefreshSeconds != 0 && (w.RefreshSeconds < minDashboardRefreshSeconds || w.RefreshSeconds > maxDashboardRefreshSeconds) {
+		return fmt.Errorf("refresh_seconds must be 0 or between %d and %d", minDashboardRefreshSeconds, maxDashboardRefreshSeconds)
+	}
+	if w.X < 0 || w.Y < 0 || w.Width < 0 || w.Height < 0 ||
+		w.X+w.Width > maxDashboardGridSize || w.Y+w.Height > maxDashboardGridSize {
+		return fmt.Errorf("position must be within a %d by %d grid", maxDashboardGridSize, maxDashboardGridSize)
+	}
+	return nil
+}
+
+// bindDashboardRequest decodes and validates a dashboard request, or returns
+// nil with the error response sent
+func (c *Controller) bindDashboardRequest(ctx echo.Context) (*DashboardRequest, error) {
+	var req DashboardRequest
+	if err := ctx.Bind(&req); err != nil {
+		return nil, c.HandleError(ctx, err, "Invalid request body", http.StatusBadRequest)
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Widgets == nil {
+		req.Widgets = []DashboardWidget{}
+	}
+	if err := req.validate(); err != nil {
+		return nil, c.HandleError(ctx, errors.New(err).
+			Component("api").
+			Category(errors.CategoryValidation).
+			Build(), "Invalid dashboard", http.StatusBadRequest)
+	}
+	return &req, nil
+}
+
+// dashboardResponse converts a stored dashboard for the current user
+func dashboardResponse(dashboard *datastore.Dashboard, user string) (DashboardResponse, error) {
+	resp := DashboardResponse{
+		ID:        dashboard.ID,
+		Name:      dashboard.Name,
+		Owner:     dashboard.Owner,
+		Shared:    dashboard.Shared,
+		Editable:  dashboard.Owner == user,
+		Widgets:   []DashboardWidget{},
+		CreatedAt: dashboard.CreatedAt,
+		UpdatedAt: dashboard.UpdatedAt,
+	}
+	if err := json.Unmarshal([]byte(dashboard.Layout), &resp.Widgets); err != nil {
+		return resp, errors.New(err).
+			Component("api").
+			Category(errors.CategoryFileParsing).
+			Context("dashboard_id", dashboard.ID).
+			Build()
+	}
+	return resp, nil
+}
+
+// visibleDashboard returns a dashboard the current user owns or that is
+// shared, or nil with the error response sent. Other users' private
+// dashboards are reported as not found.
+func (c *Controller) visibleDashboard(ctx echo.Context, store DashboardStore) (*datastore.Dashboard, error) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return nil, c.HandleError(ctx, err, "Invalid dashboard ID", http.StatusBadRequest)
+	}
+	dashboard, err := store.GetDashboard(uint(id))
+	if err == nil && !dashboard.Shared && dashboard.Owner != currentUsername(ctx) {
+		err = datastore.ErrDashboardNotFound
+	}
+	if err != nil {
+		if errors.Is(err, datastore.ErrDashboardNotFound) {
+			return nil, c.HandleError(ctx, err, "Dashboard not found", http.StatusNotFound)
+		}
+		return nil, c.HandleError(ctx, err, "Failed to get dashboard", http.StatusInternalServerError)
+	}
+	return dashboard, nil
+}
+
+// ownedDashboard returns a dashboard the current user may change, or nil with
+// the error response sent
+func (c *Controller) ownedDashboard(ctx echo.Context, store DashboardStore) (*datastore.Dashboard, error) {
+	dashboard, err := c.visibleDashboard(ctx, store)
+	if dashboard == nil {
+		return nil, err
+	}
+	if dashboard.Owner != currentUsername(ctx) {
+		return nil, c.HandleError(ctx, errors.Newf("dashboard %d is owned by another user", dashboard.ID).
+			Component("api").
+			Category(errors.CategoryValidation).
+			Build(), "Only the owner can change a dashboard", http.StatusForbidden)
+	}
+	return dashboard, nil
+}
+
+// ListDashboards handles GET /api/v2/dashboards
+// It returns the dashboards of the current user and those shared by others,
+// ordered by name.
+func (c *Controller) ListDashboards(ctx echo.Context) error {
+	store, ok := c.DS.(DashboardStore)
+	if !ok {
+		return c.errDashboardsUnsupported(ctx)
+	}
+	user := currentUsername(ctx)
+	dashboards, err := store.GetDashboards(user)
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to get dashboards", http.StatusInternalServerError)
+	}
+
+	resp := make([]DashboardResponse, 0, len(dashboards))
+	for i := range dashboards {
+		dashboard, err := dashboardResponse(&dashboards[i], user)
+		if err != nil {
+			return c.HandleError(ctx, err, "Failed to read dashboard layout", http.StatusInternalServerError)
+		}
+		resp = append(resp, dashboard)
+	}
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+// GetDashboard handles GET /api/v2/dashboards/:id
+func (c *Controller) GetDashboard(ctx echo.Context) error {
+	store, ok := c.DS.(DashboardStore)
+	if !ok {
+		return c.errDashboardsUnsupported(ctx)
+	}
+	dashboard, err := c.visibleDashboard(ctx, store)
+	if dashboard == nil {
+		return err
+	}
+	resp, err := dashboardResponse(dashboard, currentUsername(ctx))
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to read dashboard layout", http.StatusInternalServerError)
+	}
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+// CreateDashboard handles POST /api/v2/dashboards
+// The dashboard is owned by the current user.
+func (c *Controller) CreateDashboard(ctx echo.Context) error {
+	store, ok := c.DS.(DashboardStore)
+	if !ok {
+		return c.errDashboardsUnsupported(ctx)
+	}
+	req, err := c.bindDashboardRequest(ctx)
+	if req == nil {
+		return err
+	}
+
+	layout, err := json.Marshal(req.Widgets)
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to encode dashboard layout", http.StatusInternalServerError)
+	}
+	user := currentUsername(ctx)
+	dashboard := &datastore.Dashboard{Name: req.Name, Owner: user, Shared: req.Shared, Layout: string(layout)}
+	if err := store.SaveDashboard(dashboard); err != nil {
+		return c.HandleError(ctx, err, "Failed to save dashboard", http.StatusInternalServerError)
+	}
+	c.logAPIRequest(ctx, slog.LevelInfo, "Dashboard created",
+		"id", dashboard.ID,
+		"widgets", len(req.Widgets),
+		"shared", req.Shared)
+
+	resp, err := dashboardResponse(dashboard, user)
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to read dashboard layout", http.StatusInternalServerError)
+	}
+	return ctx.JSON(http.StatusCreated, resp)
+}
+
+// UpdateDashboard handles PUT /api/v2/dashboards/:id
+// It replaces the name, sharing and widgets of a dashboard of the current user.
+func (c *Controller) UpdateDashboard(ctx echo.Context) error {
+	store, ok := c.DS.(DashboardStore)
+	if !ok {
+		return c.errDashboardsUnsupported(ctx)
+	}
+	dashboard, err := c.ownedDashboard(ctx, store)
+	if dashboard == nil {
+		return err
+	}
+	req, err := c.bindDashboardRequest(ctx)
+	if req == nil {
+		return err
+	}
+
+	layout, err := json.Marshal(req.Widgets)
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to encode dashboard layout", http.StatusInternalServerError)
+	}
+	dashboard.Name = req.Name
+	dashboard.Shared = req.Shared
+	dashboard.Layout = string(layout)
+	if err := store.UpdateDashboard(dashboard); err != nil {
+		if errors.Is(err, datastore.ErrDashboardNotFound) {
+			return c.HandleError(ctx, err, "Dashboard not found", http.StatusNotFound)
+		}
+		return c.HandleError(ctx, err, "Failed to update dashboard", http.StatusInternalServerError)
+	}
+	c.logAPIRequest(ctx, slog.LevelInfo, "Dashboard updated",
+		"id", dashboard.ID,
+		"widgets", len(req.Widgets),
+		"shared", req.Shared)
+
+	// The datastore sets the update time
+	if updated, err := store.GetDashboard(dashboard.ID); err == nil {
+		dashboard = updated
+	}
+	resp, err := dashboardResponse(dashboard, currentUsername(ctx))
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to read dashboard layout", http.StatusInternalServerError)
+	}
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+// DeleteDashboard handles DELETE /api/v2/dashboards/:id
+func (c *Controller) DeleteDashboard(ctx echo.Context) error {
+	store, ok := c.DS.(DashboardStore)
+	if !ok {
+		return c.errDashboardsUnsupported(ctx)
+	}
+	dashboard, err := c.ownedDashboard(ctx, store)
+	if dashboard == nil {
+		return err
+	}
+
+	if err := store.DeleteDashboard(dashboard.ID); err != nil {
+		if errors.Is(err, datastore.ErrDashboardNotFound) {
+			return c.HandleError(ctx, err, "Dashboard not found", http.StatusNotFound)
+		}
+		return c.HandleError(ctx, err, "Failed to delete dashboard", http.StatusInternalServerError)
+	}
+	c.logAPIRequest(ctx, slog.LevelInfo, "Dashboard deleted", "id", dashboard.ID)
+	return ctx.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+// dashboardMockDataStore adds dashboards to MockDataStore
+type dashboardMockDataStore struct {
+	*MockDataStore
+	dashboards []datastore.Dashboard
+}
+
+func (m *dashboardMockDataStore) SaveDashboard(dashboard *datastore.Dashboard) error {
+	dashboard.ID = uint(len(m.dashboards) + 1)
+	m.dashboards = append(m.dashboards, *dashboard)
+	return nil
+}
+
+func (m *dashboardMockDataStore) UpdateDashboard(dashboard *datastore.Dashboard) error {
+	for i := range m.dashboards {
+		if m.dashboards[i].ID == dashboard.ID {
+			m.dashboards[i].Name = dashboard.Name
+			m.dashboards[i].Shared = dashboard.Shared
+			m.dashboards[i].Layout = dashboard.Layout
+			return nil
+		}
+	}
+	return datastore.ErrDashboardNotFound
+}
+
+func (m *dashboardMockDataStore) GetDashboard(id uint) (*datastore.Dashboard, error) {
+	for i := range m.dashboards {
+		if m.dashboards[i].ID == id {
+			dashboard := m.dashboards[i]
+			return &dashboard, nil
+		}
+	}
+	return nil, datastore.ErrDashboardNotFound
+}
+
+func (m *dashboardMockDataStore) GetDashboards(owner string) ([]datastore.Dashboard, error) {
+	var dashboards []datastore.Dashboard
+	for _, dashboard := range m.dashboards {
+		if dashboard.Owner == owner || dashboard.Shared {
+			dashboards = append(dashboards, dashboard)
+		}
+	}
+	return dashboards, nil
+}
+
+func (m *dashboardMockDataStore) DeleteDashboard(id uint) error {
+	for i := range m.dashboards {
+		if m.dashboards[i].ID == id {
+			m.dashboards = append(m.dashboards[:i], m.dashboards[i+1:]...)
+			return nil
+		}
+	}
+	return datastore.ErrDashboardNotFound
+}
+
+func TestDashboards(t *testing.T) {
+	t.Parallel()
+
+	_, mockDS, controller := setupTestEnvironment(t)
+	store := &dashboardMockDataStore{MockDataStore: mockDS}
+	controller.DS = store
+
+	call := func(handler echo.HandlerFunc, method, id, user, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/v2/dashboards", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		ctx := controller.Echo.NewContext(req, rec)
+		ctx.Set("username", user)
+		if id != "" {
+			ctx.SetParamNames("id")
+			ctx.SetParamValues(id)
+		}
+		require.NoError(t, handler(ctx))
+		return rec
+	}
+
+	rec := call(controller.CreateDashboard, http.MethodPost, "", "alice", `{"name":" Mornings ","widgets":[
+		{"type":"hourly","filters":{"species":"Turdus merula"},"refresh_seconds":60,"x":0,"y":0,"width":6,"height":4},
+		{"type":"trends","x":6,"y":0,"width":6,"height":4}]}`)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	var created DashboardResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	assert.Equal(t, "Mornings", created.Name)
+	assert.Equal(t, "alice", created.Owner)
+	assert.True(t, created.Editable)
+	require.Len(t, created.Widgets, 2)
+	assert.Equal(t, "Turdus merula", created.Widgets[0].Filters["species"])
+	assert.Equal(t, 60, created.Widgets[0].RefreshSeconds)
+
+	for _, body := range []string{
+		`{"name":"","widgets":[]}`,
+		`{"name":"x","widgets":[{"type":"unknown"}]}`,
+		`{"name":"x","widgets":[{"type":"hourly","refresh_seconds":1}]}`,
+		`{"name":"x","widgets":[{"type":"hourly","x":40,"width":12}]}`,
+	} {
+		assert.Equal(t, http.StatusBadRequest, call(controller.CreateDashboard, http.MethodPost, "", "alice", body).Code, body)
+	}
+
+	// Private dashboards are hidden from other users until shared
+	assert.Equal(t, http.StatusNotFound, call(controller.GetDashboard, http.MethodGet, "1", "bob", "").Code)
+	rec = call(controller.UpdateDashboard, http.MethodPut, "1", "alice", `{"name":"Mornings","shared":true,"widgets":[{"type":"daily-summary"}]}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec = call(controller.ListDashboards, http.MethodGet, "", "bob", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var listed []DashboardResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &listed))
+	require.Len(t, listed, 1)
+	assert.True(t, listed[0].Shared)
+	assert.False(t, listed[0].Editable)
+	require.Len(t, listed[0].Widgets, 1)
+	assert.Equal(t, "daily-summary", listed[0].Widgets[0].Type)
+
+	// Only the owner changes a shared dashboard
+	assert.Equal(t, http.StatusForbidden, call(controller.UpdateDashboard, http.MethodPut, "1", "bob", `{"name":"Mine now"}`).Code)
+	assert.Equal(t, http.StatusForbidden, call(controller.DeleteDashboard, http.MethodDelete, "1", "bob", "").Code)
+	assert.Equal(t, http.StatusNoContent, call(controller.DeleteDashboard, http.MethodDelete, "1", "alice", "").Code)
+	assert.Equal(t, http.StatusNotFound, call(controller.GetDashboard, http.MethodGet, "1", "alice", "").Code)
+	assert.Equal(t, http.StatusBadRequest, call(controller.GetDashboard, http.MethodGet, "first", "alice", "").Code)
+
+	controller.DS = mockDS
+	assert.Equal(t, http.StatusNotImplemented, call(controller.ListDashboards, http.MethodGet, "", "alice", "").Code)
+}
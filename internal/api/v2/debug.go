@@ -300,6 +300,8 @@ func mapNotificationType(typeStr string) notification.Type {
 		return notification.TypeDetection
 	case "system":
 		return notification.TypeSystem
+	case "rare_species":
+		return notification.TypeRareSpecies
 	default:
 		return notification.TypeInfo
 	}
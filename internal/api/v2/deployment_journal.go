@@ -0,0 +1,197 @@
+// internal/api/v2/deployment_journal.go
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// DeploymentJournal is implemented by datastores that keep a journal of
+// equipment and configuration changes
+type DeploymentJournal interface {
+	SaveDeploymentChange(change *datastore.DeploymentChange) error
+	GetDeploymentChanges(start, end time.Time) ([]datastore.DeploymentChange, error)
+	DeleteDeploymentChange(id uint) error
+}
+
+// DeploymentChangeRequest adds an entry to the deployment journal
+type DeploymentChangeRequest struct {
+	OccurredAt string `json:"occurred_at"` // RFC 3339, default now
+	Category   string `json:"category"`    // equipment, gain, location, configuration or other
+	Summary    string `json:"summary"`
+	Details    string `json:"details,omitempty"`
+}
+
+// DeploymentMarker is a deployment journal entry shown on analytics, so
+// sudden changes in detection rates can be attributed to the deployment
+type DeploymentMarker struct {
+	ID         uint      `json:"id"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Category   string    `json:"category"`
+	Summary    string    `json:"summary"`
+	Details    string    `json:"details,omitempty"`
+}
+
+// initDeploymentJournalRoutes registers the deployment journal endpoints
+func (c *Controller) initDeploymentJournalRoutes() {
+	journalGroup := c.Group.Group("/station/journal")
+	journalGroup.GET("", c.GetDeploymentChanges)
+
+	protectedGroup := journalGroup.Group("", c.getEffectiveAuthMiddleware())
+	protectedGroup.POST("", c.CreateDeploymentChange, c.IdempotencyMiddleware)
+	protectedGroup.DELETE("/:id", c.DeleteDeploymentChange)
+}
+
+// errDeploymentJournalUnsupported reports that the datastore keeps no deployment journal
+func (c *Controller) errDeploymentJournalUnsupported(ctx echo.Context) error {
+	return c.HandleError(ctx, errors.Newf("datastore does not support a deployment journal").
+		Component("api").
+		Category(errors.CategoryConfiguration).
+		Build(), "The deployment journal is not supported by this datastore", http.StatusNotImplemented)
+}
+
+// deploymentMarkers converts journal entries to markers
+func deploymentMarkers(changes []datastore.DeploymentChange) []DeploymentMarker {
+	markers := make([]DeploymentMarker, 0, len(changes))
+	for i := range changes {
+		markers = append(markers, DeploymentMarker{
+			ID:         changes[i].ID,
+			OccurredAt: changes[i].OccurredAt,
+			Category:   changes[i].Category,
+			Summary:    changes[i].Summary,
+			Details:    changes[i].Details,
+		})
+	}
+	return markers
+}
+
+// deploymentMarkersBetween returns the markers of the journal entries between
+// start and end for an analytics response. Markers are optional, so nothing
+// is returned when the datastore keeps no journal or it cannot be read.
+func (c *Controller) deploymentMarkersBetween(start, end time.Time) []DeploymentMarker {
+	journal, ok := c.DS.(DeploymentJournal)
+	if !ok {
+		return nil
+	}
+	changes, err := journal.GetDeploymentChanges(start, end)
+	if err != nil {
+		if c.apiLogger != nil {
+			c.apiLogger.Warn("Failed to get deployment markers", "error", err.Error())
+		}
+		return nil
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	return deploymentMarkers(changes)
+}
+
+// parseJournalTime parses a journal period bound given as RFC 3339 or as a
+// date, which is taken as local midnight
+func parseJournalTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation(time.DateOnly, value, time.Local)
+}
+
+// GetDeploymentChanges handles GET /api/v2/station/journal
+// It returns the journal entries in ascending order, optionally between start
+// and end given as RFC 3339 times or YYYY-MM-DD dates.
+func (c *Controller) GetDeploymentChanges(ctx echo.Context) error {
+	journal, ok := c.DS.(DeploymentJournal)
+	if !ok {
+		return c.errDeploymentJournalUnsupported(ctx)
+	}
+	start, err := parseJournalTime(ctx.QueryParam("start"))
+	if err != nil {
+		return c.HandleError(ctx, err, "Invalid start, use RFC 3339 or YYYY-MM-DD format", http.StatusBadRequest)
+	}
+	end, err := parseJournalTime(ctx.QueryParam("end"))
+	if err != nil {
+		return c.HandleError(ctx, err, "Invalid end, use RFC 3339 or YYYY-MM-DD format", http.StatusBadRequest)
+	}
+
+	changes, err := journal.GetDeploymentChanges(start, end)
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to get deployment journal", http.StatusInternalServerError)
+	}
+	return ctx.JSON(http.StatusOK, deploymentMarkers(changes))
+}
+
+// CreateDeploymentChange handles POST /api/v2/station/journal
+func (c *Controller) CreateDeploymentChange(ctx echo.Context) error {
+	journal, ok := c.DS.(DeploymentJournal)
+	if !ok {
+		return c.errDeploymentJournalUnsupported(ctx)
+	}
+
+	var req DeploymentChangeRequest
+	if err := ctx.Bind(&req); err != nil {
+		return c.HandleError(ctx, err, "Invalid request body", http.StatusBadRequest)
+	}
+	change := &datastore.DeploymentChange{
+		OccurredAt: time.Now(),
+		Category:   strings.ToLower(strings.TrimSpace(req.Category)),
+		Summary:    strings.TrimSpace(req.Summary),
+		Details:    strings.TrimSpace(req.Details),
+	}
+	if req.OccurredAt != "" {
+		occurredAt, err := time.Parse(time.RFC3339, req.OccurredAt)
+		if err != nil {
+			return c.HandleError(ctx, err, "Invalid occurred_at time, use RFC 3339 format", http.StatusBadRequest)
+		}
+		change.OccurredAt = occurredAt
+	}
+	if len(change.Summary) > 255 || len(change.Details) > 2048 {
+		return c.HandleError(ctx, errors.Newf("deployment change text too long").
+			Component("api").
+			Category(errors.CategoryValidation).
+			Build(), "Summary must be at most 255 and details at most 2048 characters", http.StatusBadRequest)
+	}
+
+	if err := journal.SaveDeploymentChange(change); err != nil {
+		var enhancedErr *errors.EnhancedError
+		if errors.As(err, &enhancedErr) && enhancedErr.Category == errors.CategoryValidation {
+			return c.HandleError(ctx, err, "Summary is required and category must be one of "+
+				strings.Join(datastore.DeploymentChangeCategories, ", "), http.StatusBadRequest)
+		}
+		return c.HandleError(ctx, err, "Failed to save deployment change", http.StatusInternalServerError)
+	}
+	c.logAPIRequest(ctx, slog.LevelInfo, "Deployment change recorded",
+		"category", change.Category,
+		"occurred_at", change.OccurredAt)
+
+	return ctx.JSON(http.StatusCreated, deploymentMarkers([]datastore.DeploymentChange{*change})[0])
+}
+
+// DeleteDeploymentChange handles DELETE /api/v2/station/journal/:id
+func (c *Controller) DeleteDeploymentChange(ctx echo.Context) error {
+	journal, ok := c.DS.(DeploymentJournal)
+	if !ok {
+		return c.errDeploymentJournalUnsupported(ctx)
+	}
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return c.HandleError(ctx, err, "Invalid journal entry ID", http.StatusBadRequest)
+	}
+
+	if err := journal.DeleteDeploymentChange(uint(id)); err != nil {
+		if errors.Is(err, datastore.ErrDeploymentChangeNotFound) {
+			return c.HandleError(ctx, err, "Journal entry not found", http.StatusNotFound)
+		}
+		return c.HandleError(ctx, err, "Failed to delete deployment change", http.StatusInternalServerError)
+	}
+	c.logAPIRequest(ctx, slog.LevelInfo, "Deployment change deleted", "id", id)
+	return ctx.NoContent(http.StatusNoContent)
+}
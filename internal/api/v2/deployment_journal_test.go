@@ -0,0 +1,114 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// journalMockDataStore adds a deployment journal to MockDataStore
+type journalMockDataStore struct {
+	*MockDataStore
+	changes []datastore.DeploymentChange
+}
+
+func (m *journalMockDataStore) SaveDeploymentChange(change *datastore.DeploymentChange) error {
+	if change.Summary == "" {
+		return errors.Newf("summary cannot be empty").Category(errors.CategoryValidation).Build()
+	}
+	change.ID = uint(len(m.changes) + 1)
+	m.changes = append(m.changes, *change)
+	return nil
+}
+
+func (m *journalMockDataStore) GetDeploymentChanges(start, end time.Time) ([]datastore.DeploymentChange, error) {
+	var changes []datastore.DeploymentChange
+	for _, change := range m.changes {
+		if (start.IsZero() || !change.OccurredAt.Before(start)) && (end.IsZero() || change.OccurredAt.Before(end)) {
+			changes = append(changes, change)
+		}
+	}
+	return changes, nil
+}
+
+func (m *journalMockDataStore) DeleteDeploymentChange(id uint) error {
+	for i := range m.changes {
+		if m.changes[i].ID == id {
+			m.changes = append(m.changes[:i], m.changes[i+1:]...)
+			return nil
+		}
+	}
+	return datastore.ErrDeploymentChangeNotFound
+}
+
+func TestDeploymentJournal(t *testing.T) {
+	t.Parallel()
+
+	_, mockDS, controller := setupTestEnvironment(t)
+	store := &journalMockDataStore{MockDataStore: mockDS}
+	controller.DS = store
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v2/station/journal", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		require.NoError(t, controller.CreateDeploymentChange(controller.Echo.NewContext(req, rec)))
+		return rec
+	}
+
+	rec := post(`{"occurred_at":"2024-06-01T09:00:00Z","category":"Gain","summary":"Gain +6 dB"}`)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	var marker DeploymentMarker
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &marker))
+	assert.Equal(t, "gain", marker.Category)
+	assert.Equal(t, uint(1), marker.ID)
+
+	assert.Equal(t, http.StatusBadRequest, post(`{"occurred_at":"June","category":"gain","summary":"x"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, post(`{"category":"gain"}`).Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v2/station/journal?start=2024-06-01&end=2024-06-02T00:00:00Z", http.NoBody)
+	rec = httptest.NewRecorder()
+	require.NoError(t, controller.GetDeploymentChanges(controller.Echo.NewContext(req, rec)))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var markers []DeploymentMarker
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &markers))
+	require.Len(t, markers, 1)
+	assert.Equal(t, "Gain +6 dB", markers[0].Summary)
+
+	day := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	assert.Len(t, controller.deploymentMarkersBetween(day, day.AddDate(0, 0, 1)), 1)
+	assert.Nil(t, controller.deploymentMarkersBetween(day.AddDate(0, 0, 1), day.AddDate(0, 0, 2)))
+
+	del := func(id string) int {
+		req := httptest.NewRequest(http.MethodDelete, "/api/v2/station/journal/"+id, http.NoBody)
+		rec := httptest.NewRecorder()
+		ctx := controller.Echo.NewContext(req, rec)
+		ctx.SetParamNames("id")
+		ctx.SetParamValues(id)
+		require.NoError(t, controller.DeleteDeploymentChange(ctx))
+		return rec.Code
+	}
+	assert.Equal(t, http.StatusNoContent, del("1"))
+	assert.Equal(t, http.StatusNotFound, del("1"))
+	assert.Equal(t, http.StatusBadRequest, del("first"))
+}
+
+func TestDeploymentMarkers_Unsupported(t *testing.T) {
+	t.Parallel()
+
+	_, _, controller := setupTestEnvironment(t)
+	assert.Nil(t, controller.deploymentMarkersBetween(time.Time{}, time.Now()))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v2/station/journal", http.NoBody)
+	rec := httptest.NewRecorder()
+	require.NoError(t, controller.GetDeploymentChanges(controller.Echo.NewContext(req, rec)))
+	assert.Equal(t, http.StatusNotImplemented, rec.Code)
+}
@@ -0,0 +1,194 @@
+// internal/api/v2/detection_comments.go
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// maxCommentLength is the longest comment the datastore keeps
+const maxCommentLength = 1000
+
+// DetectionCommentRequest adds or replaces the text of a comment
+type DetectionCommentRequest struct {
+	Entry string `json:"entry"`
+}
+
+// DetectionCommentResponse is a comment on a detection
+type DetectionCommentResponse struct {
+	ID        uint      `json:"id"`
+	Entry     string    `json:"entry"`
+	Author    string    `json:"author,omitempty"` // empty when written with authentication disabled
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// DetectionDetailResponse is a single detection with its comments in full
+type DetectionDetailResponse struct {
+	DetectionResponse
+	Annotations []DetectionCommentResponse `json:"annotations,omitempty"` // comments with their author and times, newest first
+}
+
+// initCommentRoutes registers the detection comment endpoints. Comments are
+// read publicly like detections; writing them requires authentication.
+func (c *Controller) initCommentRoutes() {
+	c.Group.GET("/detections/:id/comments", c.GetDetectionComments)
+
+	protected := []echo.MiddlewareFunc{c.getEffectiveAuthMiddleware()}
+	c.Group.POST("/detections/:id/comments", c.AddDetectionComment, append(protected, c.IdempotencyMiddleware)...)
+	c.Group.PUT("/detections/:id/comments/:commentId", c.UpdateDetectionComment, protected...)
+	c.Group.DELETE("/detections/:id/comments/:commentId", c.DeleteDetectionComment, protected...)
+}
+
+// commentResponses converts the comments of a detection
+func commentResponses(comments []datastore.NoteComment) []DetectionCommentResponse {
+	resp := make([]DetectionCommentResponse, 0, len(comments))
+	for i := range comments {
+		resp = append(resp, DetectionCommentResponse{
+			ID:        comments[i].ID,
+			Entry:     comments[i].Entry,
+			Author:    comments[i].Author,
+			CreatedAt: comments[i].CreatedAt,
+			UpdatedAt: comments[i].UpdatedAt,
+		})
+	}
+	return resp
+}
+
+// bindCommentEntry decodes and validates the text of a comment request,
+// returning false with the error response sent
+func (c *Controller) bindCommentEntry(ctx echo.Context) (string, bool, error) {
+	var req DetectionCommentRequest
+	if err := ctx.Bind(&req); err != nil {
+		return "", false, c.HandleError(ctx, err, "Invalid request body", http.StatusBadRequest)
+	}
+	entry := strings.TrimSpace(req.Entry)
+	if entry == "" || len(entry) > maxCommentLength {
+		return "", false, c.HandleError(ctx, errors.Newf("comment of %d characters", len(entry)).
+			Component("api").
+			Category(errors.CategoryValidation).
+			Build(), "Entry is required and must be at most "+strconv.Itoa(maxCommentLength)+" characters", http.StatusBadRequest)
+	}
+	return entry, true, nil
+}
+
+// authoredComment returns a comment of a detection written by the current
+// user, or nil with the error response sent. Comments of other users cannot
+// be changed.
+func (c *Controller) authoredComment(ctx echo.Context) (*datastore.NoteComment, error) {
+	commentID, ok, err := c.parseIDParam(ctx, "commentId", "comment ID")
+	if !ok {
+		return nil, err
+	}
+	note, err := c.DS.Get(ctx.Param("id"))
+	if err != nil {
+		return nil, c.HandleError(ctx, err, "Detection not found", http.StatusNotFound)
+	}
+	for i := range note.Comments {
+		comment := note.Comments[i]
+		if comment.ID != commentID {
+			continue
+		}
+		if comment.Author != currentUsername(ctx) {
+			return nil, c.HandleError(ctx, errors.Newf("comment %d was written by another user", commentID).
+				Component("api").
+				Category(errors.CategoryValidation).
+				Build(), "Only the author can change a comment", http.StatusForbidden)
+		}
+		return &comment, nil
+	}
+	return nil, c.HandleError(ctx, errors.Newf("comment %d not found on detection %d", commentID, note.ID).
+		Component("api").
+		Category(errors.CategoryNotFound).
+		Build(), "Comment not found", http.StatusNotFound)
+}
+
+// GetDetectionComments handles GET /api/v2/detections/:id/comments
+// It returns the comments of a detection, newest first.
+func (c *Controller) GetDetectionComments(ctx echo.Context) error {
+	note, err := c.DS.Get(ctx.Param("id"))
+	if err != nil {
+		return c.HandleError(ctx, err, "Detection not found", http.StatusNotFound)
+	}
+	return ctx.JSON(http.StatusOK, commentResponses(note.Comments))
+}
+
+// AddDetectionComment handles POST /api/v2/detections/:id/comments
+// The comment is written by the current user.
+func (c *Controller) AddDetectionComment(ctx echo.Context) error {
+	entry, ok, err := c.bindCommentEntry(ctx)
+	if !ok {
+		return err
+	}
+	note, err := c.DS.Get(ctx.Param("id"))
+	if err != nil {
+		return c.HandleError(ctx, err, "Detection not found", http.StatusNotFound)
+	}
+
+	now := time.Now()
+	comment := &datastore.NoteComment{
+		NoteID:    note.ID,
+		Entry:     entry,
+		Author:    currentUsername(ctx),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := c.DS.SaveNoteComment(comment); err != nil {
+		return c.HandleError(ctx, err, "Failed to add comment", http.StatusInternalServerError)
+	}
+	c.invalidateDetectionCache()
+	c.logAPIRequest(ctx, slog.LevelInfo, "Detection comment added",
+		"detection_id", note.ID,
+		"comment_id", comment.ID)
+	return ctx.JSON(http.StatusCreated, commentResponses([]datastore.NoteComment{*comment})[0])
+}
+
+// UpdateDetectionComment handles PUT /api/v2/detections/:id/comments/:commentId
+// It replaces the text of a comment of the current user.
+func (c *Controller) UpdateDetectionComment(ctx echo.Context) error {
+	entry, ok, err := c.bindCommentEntry(ctx)
+	if !ok {
+		return err
+	}
+	comment, err := c.authoredComment(ctx)
+	if comment == nil {
+		return err
+	}
+
+	if err := c.DS.UpdateNoteComment(strconv.FormatUint(uint64(comment.ID), 10), entry); err != nil {
+		return c.HandleError(ctx, err, "Failed to update comment", http.StatusInternalServerError)
+	}
+	c.invalidateDetectionCache()
+	c.logAPIRequest(ctx, slog.LevelInfo, "Detection comment updated",
+		"detection_id", comment.NoteID,
+		"comment_id", comment.ID)
+
+	comment.Entry = entry
+	comment.UpdatedAt = time.Now()
+	return ctx.JSON(http.StatusOK, commentResponses([]datastore.NoteComment{*comment})[0])
+}
+
+// DeleteDetectionComment handles DELETE /api/v2/detections/:id/comments/:commentId
+// It deletes a comment of the current user.
+func (c *Controller) DeleteDetectionComment(ctx echo.Context) error {
+	comment, err := c.authoredComment(ctx)
+	if comment == nil {
+		return err
+	}
+
+	if err := c.DS.DeleteNoteComment(strconv.FormatUint(uint64(comment.ID), 10)); err != nil {
+		return c.HandleError(ctx, err, "Failed to delete comment", http.StatusInternalServerError)
+	}
+	c.invalidateDetectionCache()
+	c.logAPIRequest(ctx, slog.LevelInfo, "Detection comment deleted",
+		"detection_id", comment.NoteID,
+		"comment_id", comment.ID)
+	return ctx.NoContent(http.StatusNoContent)
+}
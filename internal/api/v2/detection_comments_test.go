@@ -0,0 +1,82 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+func TestDetectionComments(t *testing.T) {
+	t.Parallel()
+
+	_, mockDS, controller := setupTestEnvironment(t)
+	mockDS.On("Get", "1").Return(datastore.Note{ID: 1, Comments: []datastore.NoteComment{
+		{ID: 8, NoteID: 1, Entry: "ringed, left leg", Author: "alice"},
+		{ID: 5, NoteID: 1, Entry: "in the nestbox"},
+	}}, nil)
+	mockDS.On("SaveNoteComment", mock.MatchedBy(func(c *datastore.NoteComment) bool {
+		return c.NoteID == 1 && c.Entry == "second brood" && c.Author == "alice"
+	})).Run(func(args mock.Arguments) {
+		args.Get(0).(*datastore.NoteComment).ID = 9
+	}).Return(nil).Once()
+	mockDS.On("UpdateNoteComment", "8", "ringed, right leg").Return(nil).Once()
+	mockDS.On("DeleteNoteComment", "8").Return(nil).Once()
+
+	call := func(handler echo.HandlerFunc, method, user, body string, params ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/v2/detections/1/comments", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		ctx := controller.Echo.NewContext(req, rec)
+		ctx.Set("username", user)
+		ctx.SetParamNames(append([]string{"id"}, "commentId")[:1+len(params)]...)
+		ctx.SetParamValues(append([]string{"1"}, params...)...)
+		require.NoError(t, handler(ctx))
+		return rec
+	}
+
+	rec := call(controller.AddDetectionComment, http.MethodPost, "alice", `{"entry":" second brood "}`)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	var created DetectionCommentResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	assert.Equal(t, uint(9), created.ID)
+	assert.Equal(t, "alice", created.Author)
+	assert.Equal(t, http.StatusBadRequest, call(controller.AddDetectionComment, http.MethodPost, "alice", `{"entry":"  "}`).Code)
+	assert.Equal(t, http.StatusBadRequest, call(controller.AddDetectionComment, http.MethodPost, "alice",
+		`{"entry":"`+strings.Repeat("a", maxCommentLength+1)+`"}`).Code)
+
+	rec = call(controller.GetDetectionComments, http.MethodGet, "", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var comments []DetectionCommentResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &comments))
+	require.Len(t, comments, 2)
+	assert.Equal(t, "alice", comments[0].Author)
+
+	// Only the author changes a comment
+	assert.Equal(t, http.StatusForbidden, call(controller.UpdateDetectionComment, http.MethodPut, "bob", `{"entry":"mine"}`, "8").Code)
+	assert.Equal(t, http.StatusForbidden, call(controller.DeleteDetectionComment, http.MethodDelete, "alice", "", "5").Code)
+	assert.Equal(t, http.StatusNotFound, call(controller.DeleteDetectionComment, http.MethodDelete, "alice", "", "6").Code)
+	assert.Equal(t, http.StatusBadRequest, call(controller.DeleteDetectionComment, http.MethodDelete, "alice", "", "x").Code)
+	rec = call(controller.UpdateDetectionComment, http.MethodPut, "alice", `{"entry":"ringed, right leg"}`, "8")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "right leg")
+	assert.Equal(t, http.StatusNoContent, call(controller.DeleteDetectionComment, http.MethodDelete, "alice", "", "8").Code)
+
+	// Detection details include the comments in full
+	rec = call(controller.GetDetection, http.MethodGet, "", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var detail DetectionDetailResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &detail))
+	assert.Equal(t, []string{"ringed, left leg", "in the nestbox"}, detail.Comments)
+	require.Len(t, detail.Annotations, 2)
+	assert.Equal(t, uint(8), detail.Annotations[0].ID)
+
+	mockDS.AssertExpectations(t)
+}
@@ -0,0 +1,251 @@
+// internal/api/v2/detection_review.go
+package api
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// Verification states of a detection
+const (
+	VerificationUnverified    = "unverified"
+	VerificationConfirmed     = "confirmed"
+	VerificationFalsePositive = "false_positive"
+)
+
+// Review queue sample sizes
+const (
+	defaultReviewQueueLimit = 20
+	maxReviewQueueLimit     = 100
+)
+
+// ReviewStore is implemented by datastores that sample detections for review
+// and aggregate review verdicts
+type ReviewStore interface {
+	DeleteNoteReview(noteID uint) error
+	GetReviewQueue(ctx context.Context, filter datastore.ReviewQueueFilter, limit int) ([]datastore.Note, int64, error)
+	GetSpeciesPrecision(ctx context.Context, startDate, endDate string) ([]datastore.SpeciesPrecision, error)
+}
+
+// VerificationRequest sets the verification state of a detection
+type VerificationRequest struct {
+	State string `json:"state"` // unverified, confirmed or false_positive
+}
+
+// VerificationResponse is the verification state of a detection
+type VerificationResponse struct {
+	ID    uint   `json:"id"`
+	State string `json:"state"`
+}
+
+// ReviewQueueResponse is a random sample of detections needing review
+type ReviewQueueResponse struct {
+	Data       []DetectionResponse `json:"data"`
+	Unreviewed int64               `json:"unreviewed"` // detections matching the filter that need review
+}
+
+// SpeciesPrecisionResponse is the precision of a species derived from review
+// verdicts. The overall row has no names.
+type SpeciesPrecisionResponse struct {
+	ScientificName string   `json:"scientificName,omitempty"`
+	CommonName     string   `json:"commonName,omitempty"`
+	Detections     int64    `json:"detections"`
+	Confirmed      int64    `json:"confirmed"`
+	FalsePositives int64    `json:"falsePositives"`
+	Unverified     int64    `json:"unverified"`
+	Precision      *float64 `json:"precision"` // confirmed share of reviewed detections, null before any review
+	Coverage       float64  `json:"coverage"`  // reviewed share of detections
+}
+
+// ReviewStatsResponse is the precision of each species and of all detections
+type ReviewStatsResponse struct {
+	Species []SpeciesPrecisionResponse `json:"species"`
+	Overall SpeciesPrecisionResponse   `json:"overall"`
+}
+
+// initReviewRoutes registers the review workflow endpoints
+func (c *Controller) initReviewRoutes() {
+	c.Group.GET("/detections/review/queue", c.GetReviewQueue)
+	c.Group.GET("/detections/review/stats", c.GetReviewStats)
+	c.Group.PUT("/detections/:id/verification", c.SetDetectionVerification,
+		c.getEffectiveAuthMiddleware(), c.IdempotencyMiddleware)
+}
+
+// errReviewUnsupported reports that the datastore cannot sample or aggregate reviews
+func (c *Controller) errReviewUnsupported(ctx echo.Context) error {
+	return c.HandleError(ctx, errors.Newf("datastore does not support the review workflow").
+		Component("api").
+		Category(errors.CategoryConfiguration).
+		Build(), "The review workflow is not supported by this datastore", http.StatusNotImplemented)
+}
+
+// SetDetectionVerification handles PUT /api/v2/detections/:id/verification
+// It confirms a detection, marks it a false positive or clears its review.
+// Locked detections cannot change state.
+func (c *Controller) SetDetectionVerification(ctx echo.Context) error {
+	idStr := ctx.Param("id")
+	var req VerificationRequest
+	if err := ctx.Bind(&req); err != nil {
+		return c.HandleError(ctx, err, "Invalid request body", http.StatusBadRequest)
+	}
+	if req.State != VerificationUnverified && req.State != VerificationConfirmed && req.State != VerificationFalsePositive {
+		return c.HandleError(ctx, errors.Newf("unknown verification state %q", req.State).
+			Component("api").
+			Category(errors.CategoryValidation).
+			Build(), "State must be unverified, confirmed or false_positive", http.StatusBadRequest)
+	}
+
+	note, err := c.DS.Get(idStr)
+	if err != nil {
+		return c.HandleError(ctx, err, "Detection not found", http.StatusNotFound)
+	}
+	locked, err := c.DS.IsNoteLocked(idStr)
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to check lock status", http.StatusInternalServerError)
+	}
+	if locked {
+		return c.HandleError(ctx, errors.Newf("detection %s is locked", idStr).
+			Component("api").
+			Category(errors.CategoryConflict).
+			Build(), "Detection is locked and status cannot be changed", http.StatusConflict)
+	}
+
+	if req.State == VerificationUnverified {
+		store, ok := c.DS.(ReviewStore)
+		if !ok {
+			return c.errReviewUnsupported(ctx)
+		}
+		err = store.DeleteNoteReview(note.ID)
+	} else {
+		err = c.AddReview(note.ID, req.State == VerificationConfirmed)
+	}
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to update verification", http.StatusInternalServerError)
+	}
+	c.invalidateDetectionCache()
+	c.logAPIRequest(ctx, slog.LevelInfo, "Detection verification set",
+		"detection_id", note.ID,
+		"state", req.State)
+
+	return ctx.JSON(http.StatusOK, VerificationResponse{ID: note.ID, State: req.State})
+}
+
+// GetReviewQueue handles GET /api/v2/detections/review/queue
+// It returns a random sample of unreviewed, unlocked detections, at most limit
+// (default 20, max 100), optionally of a species, between start_date and
+// end_date and with a confidence between minConfidence and maxConfidence
+// percent.
+func (c *Controller) GetReviewQueue(ctx echo.Context) error {
+	store, ok := c.DS.(ReviewStore)
+	if !ok {
+		return c.errReviewUnsupported(ctx)
+	}
+
+	filter := datastore.ReviewQueueFilter{
+		Species:   ctx.QueryParam("species"),
+		StartDate: ctx.QueryParam("start_date"),
+		EndDate:   ctx.QueryParam("end_date"),
+	}
+	for _, date := range []struct{ value, name string }{{filter.StartDate, "start_date"}, {filter.EndDate, "end_date"}} {
+		if err := validateDateParam(date.value, date.name); err != nil {
+			return c.HandleError(ctx, err, err.Error(), http.StatusBadRequest)
+		}
+	}
+	for _, bound := range []struct {
+		name   string
+		target *float64
+	}{{"minConfidence", &filter.MinConfidence}, {"maxConfidence", &filter.MaxConfidence}} {
+		value := ctx.QueryParam(bound.name)
+		if value == "" {
+			continue
+		}
+		percent, err := strconv.ParseFloat(value, 64)
+		if err != nil || percent < 0 || percent > 100 {
+			return c.HandleError(ctx, errors.Newf("invalid %s %q", bound.name, value).
+				Component("api").
+				Category(errors.CategoryValidation).
+				Build(), bound.name+" must be a percentage between 0 and 100", http.StatusBadRequest)
+		}
+		*bound.target = percent / 100
+	}
+	limit := defaultReviewQueueLimit
+	if value := ctx.QueryParam("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil || limit <= 0 || limit > maxReviewQueueLimit {
+			return c.HandleError(ctx, errors.Newf("invalid limit %q", value).
+				Component("api").
+				Category(errors.CategoryValidation).
+				Build(), "Limit must be between 1 and "+strconv.Itoa(maxReviewQueueLimit), http.StatusBadRequest)
+		}
+	}
+
+	notes, unreviewed, err := store.GetReviewQueue(ctx.Request().Context(), filter, limit)
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to get review queue", http.StatusInternalServerError)
+	}
+	return ctx.JSON(http.StatusOK, ReviewQueueResponse{
+		Data:       c.convertNotesToDetectionResponses(notes, false),
+		Unreviewed: unreviewed,
+	})
+}
+
+// GetReviewStats handles GET /api/v2/detections/review/stats
+// It returns the precision of each species derived from the review verdicts
+// of its detections between start_date and end_date, most detected first.
+func (c *Controller) GetReviewStats(ctx echo.Context) error {
+	store, ok := c.DS.(ReviewStore)
+	if !ok {
+		return c.errReviewUnsupported(ctx)
+	}
+	startDate, endDate := ctx.QueryParam("start_date"), ctx.QueryParam("end_date")
+	if err := validateDateParam(startDate, "start_date"); err != nil {
+		return c.HandleError(ctx, err, err.Error(), http.StatusBadRequest)
+	}
+	if err := validateDateParam(endDate, "end_date"); err != nil {
+		return c.HandleError(ctx, err, err.Error(), http.StatusBadRequest)
+	}
+
+	precision, err := store.GetSpeciesPrecision(ctx.Request().Context(), startDate, endDate)
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to get review statistics", http.StatusInternalServerError)
+	}
+
+	resp := ReviewStatsResponse{Species: make([]SpeciesPrecisionResponse, 0, len(precision))}
+	var overall datastore.SpeciesPrecision
+	for i := range precision {
+		resp.Species = append(resp.Species, speciesPrecisionResponse(&precision[i]))
+		overall.Detections += precision[i].Detections
+		overall.Confirmed += precision[i].Confirmed
+		overall.FalsePositives += precision[i].FalsePositives
+	}
+	resp.Overall = speciesPrecisionResponse(&overall)
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+// speciesPrecisionResponse derives the precision and review coverage of verdict counts
+func speciesPrecisionResponse(p *datastore.SpeciesPrecision) SpeciesPrecisionResponse {
+	reviewed := p.Confirmed + p.FalsePositives
+	resp := SpeciesPrecisionResponse{
+		ScientificName: p.ScientificName,
+		CommonName:     p.CommonName,
+		Detections:     p.Detections,
+		Confirmed:      p.Confirmed,
+		FalsePositives: p.FalsePositives,
+		Unverified:     p.Detections - reviewed,
+	}
+	if reviewed > 0 {
+		precision := math.Round(float64(p.Confirmed)/float64(reviewed)*100) / 100
+		resp.Precision = &precision
+	}
+	if p.Detections > 0 {
+		resp.Coverage = math.Round(float64(reviewed)/float64(p.Detections)*100) / 100
+	}
+	return resp
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+// reviewMockDataStore adds the review queue and species precision to MockDataStore
+type reviewMockDataStore struct {
+	*MockDataStore
+	deleted   []uint
+	filter    datastore.ReviewQueueFilter
+	limit     int
+	precision []datastore.SpeciesPrecision
+}
+
+func (m *reviewMockDataStore) DeleteNoteReview(noteID uint) error {
+	m.deleted = append(m.deleted, noteID)
+	return nil
+}
+
+func (m *reviewMockDataStore) GetReviewQueue(_ context.Context, filter datastore.ReviewQueueFilter, limit int) ([]datastore.Note, int64, error) {
+	m.filter, m.limit = filter, limit
+	return []datastore.Note{{ID: 7, ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird"}}, 42, nil
+}
+
+func (m *reviewMockDataStore) GetSpeciesPrecision(_ context.Context, _, _ string) ([]datastore.SpeciesPrecision, error) {
+	return m.precision, nil
+}
+
+func TestSetDetectionVerification(t *testing.T) {
+	t.Parallel()
+
+	_, mockDS, controller := setupTestEnvironment(t)
+	store := &reviewMockDataStore{MockDataStore: mockDS}
+	controller.DS = store
+
+	mockDS.On("Get", "1").Return(datastore.Note{ID: 1}, nil)
+	mockDS.On("IsNoteLocked", "1").Return(false, nil)
+	mockDS.On("Get", "2").Return(datastore.Note{ID: 2}, nil)
+	mockDS.On("IsNoteLocked", "2").Return(true, nil)
+	mockDS.On("SaveNoteReview", mock.MatchedBy(func(review *datastore.NoteReview) bool {
+		return review.NoteID == 1 && review.Verified == "false_positive"
+	})).Return(nil).Once()
+
+	put := func(id, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/api/v2/detections/"+id+"/verification", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		ctx := controller.Echo.NewContext(req, rec)
+		ctx.SetParamNames("id")
+		ctx.SetParamValues(id)
+		require.NoError(t, controller.SetDetectionVerification(ctx))
+		return rec
+	}
+
+	rec := put("1", `{"state":"false_positive"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp VerificationResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, VerificationResponse{ID: 1, State: VerificationFalsePositive}, resp)
+
+	require.Equal(t, http.StatusOK, put("1", `{"state":"unverified"}`).Code)
+	assert.Equal(t, []uint{1}, store.deleted)
+
+	assert.Equal(t, http.StatusConflict, put("2", `{"state":"confirmed"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, put("1", `{"state":"correct"}`).Code)
+	mockDS.AssertExpectations(t)
+}
+
+func TestReviewQueueAndStats(t *testing.T) {
+	t.Parallel()
+
+	_, mockDS, controller := setupTestEnvironment(t)
+	store := &reviewMockDataStore{MockDataStore: mockDS, precision: []datastore.SpeciesPrecision{
+		{ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", Detections: 10, Confirmed: 3, FalsePositives: 1},
+		{ScientificName: "Parus major", CommonName: "Great Tit", Detections: 5},
+	}}
+	controller.DS = store
+
+	get := func(handler echo.HandlerFunc, target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		require.NoError(t, handler(controller.Echo.NewContext(httptest.NewRequest(http.MethodGet, target, http.NoBody), rec)))
+		return rec
+	}
+
+	rec := get(controller.GetReviewQueue, "/api/v2/detections/review/queue?species=Great+Tit&minConfidence=50&start_date=2024-05-01")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var queue ReviewQueueResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &queue))
+	assert.Equal(t, int64(42), queue.Unreviewed)
+	require.Len(t, queue.Data, 1)
+	assert.Equal(t, uint(7), queue.Data[0].ID)
+	assert.Equal(t, datastore.ReviewQueueFilter{Species: "Great Tit", StartDate: "2024-05-01", MinConfidence: 0.5}, store.filter)
+	assert.Equal(t, defaultReviewQueueLimit, store.limit)
+
+	assert.Equal(t, http.StatusBadRequest, get(controller.GetReviewQueue, "/api/v2/detections/review/queue?limit=500").Code)
+	assert.Equal(t, http.StatusBadRequest, get(controller.GetReviewQueue, "/api/v2/detections/review/queue?maxConfidence=high").Code)
+	assert.Equal(t, http.StatusBadRequest, get(controller.GetReviewQueue, "/api/v2/detections/review/queue?end_date=May").Code)
+
+	rec = get(controller.GetReviewStats, "/api/v2/detections/review/stats")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var stats ReviewStatsResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &stats))
+	require.Len(t, stats.Species, 2)
+	require.NotNil(t, stats.Species[0].Precision)
+	assert.InDelta(t, 0.75, *stats.Species[0].Precision, 1e-9)
+	assert.InDelta(t, 0.4, stats.Species[0].Coverage, 1e-9)
+	assert.Equal(t, int64(6), stats.Species[0].Unverified)
+	assert.Nil(t, stats.Species[1].Precision, "no precision before any review")
+	assert.Equal(t, int64(15), stats.Overall.Detections)
+	assert.InDelta(t, 0.27, stats.Overall.Coverage, 1e-9)
+
+	controller.DS = mockDS
+	assert.Equal(t, http.StatusNotImplemented, get(controller.GetReviewStats, "/api/v2/detections/review/stats").Code)
+}
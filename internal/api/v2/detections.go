@@ -66,9 +66,10 @@ func (c *Controller) initDetectionRoutes() {
 	// Protected detection management endpoints
 	detectionGroup := c.Group.Group("/detections", c.AuthMiddleware)
 	detectionGroup.DELETE("/:id", c.DeleteDetection)
-	detectionGroup.POST("/:id/review", c.ReviewDetection)
-	detectionGroup.POST("/:id/lock", c.LockDetection)
-	detectionGroup.POST("/ignore", c.IgnoreSpecies)
+	detectionGroup.POST("/:id/review", c.ReviewDetection, c.IdempotencyMiddleware)
+	detectionGroup.POST("/:id/lock", c.LockDetection, c.IdempotencyMiddleware)
+	detectionGroup.POST("/ignore", c.IgnoreSpecies, c.IdempotencyMiddleware)
+	detectionGroup.POST("/reanalysis/diff", c.DiffReanalysis)
 }
 
 // DetectionResponse represents a detection in the API response
@@ -77,12 +78,15 @@ type DetectionResponse struct {
 	Date               string       `json:"date"`
 	Time               string       `json:"time"`
 	Source             string       `json:"source"`
+	Channel            string       `json:"channel,omitempty"` // Analyzed channel of a stereo source
+	Bearing            *float64     `json:"bearing,omitempty"` // Estimated compass bearing of the caller in degrees
 	BeginTime          string       `json:"beginTime"`
 	EndTime            string       `json:"endTime"`
 	SpeciesCode        string       `json:"speciesCode"`
 	ScientificName     string       `json:"scientificName"`
 	CommonName         string       `json:"commonName"`
 	Confidence         float64      `json:"confidence"`
+	WeatherAdjustment  float64      `json:"weatherAdjustment,omitempty"` // Confidence threshold increase applied for wind and rain
 	Verified           string       `json:"verified"`
 	Locked             bool         `json:"locked"`
 	Comments           []string     `json:"comments,omitempty"`
@@ -156,6 +160,19 @@ type detectionQueryParams struct {
 	Locked     string
 	// Include additional data
 	IncludeWeather bool
+	// Cursor pagination, see detections_cursor.go
+	Cursor           string
+	CursorPagination bool
+	// Filter expression, see detections_query.go
+	Query  string
+	Filter *datastore.NoteQuery
+}
+
+// hasAdvancedFilters reports whether any advanced filter is set
+func (p *detectionQueryParams) hasAdvancedFilters() bool {
+	return p.Confidence != "" || p.TimeOfDay != "" ||
+		p.HourRange != "" || p.Verified != "" ||
+		p.Location != "" || p.Locked != ""
 }
 
 // parseDetectionQueryParams extracts and validates query parameters from the request
@@ -177,6 +194,22 @@ func (c *Controller) parseDetectionQueryParams(ctx echo.Context) (*detectionQuer
 		Locked:     ctx.QueryParam("locked"),
 		// Include weather data
 		IncludeWeather: ctx.QueryParam("includeWeather") == "true",
+		// Cursor pagination instead of offsets
+		Cursor: ctx.QueryParam("cursor"),
+	}
+	params.CursorPagination = params.Cursor != "" || ctx.QueryParam("pagination") == "cursor"
+
+	// Parse the filter expression, with the tag filters as further terms
+	query, err := withTagFilters(ctx.QueryParam("q"), ctx.QueryParams()["tag"])
+	if err != nil {
+		return nil, err
+	}
+	if params.Query = query; params.Query != "" {
+		filter, err := parseDetectionFilter(params)
+		if err != nil {
+			return nil, err
+		}
+		params.Filter = filter
 	}
 
 	// Parse duration
@@ -359,6 +392,9 @@ func (c *Controller) parseOffset(offsetStr string) (int, error) {
 }
 
 // GetDetections handles GET requests for detections
+// Pages are selected by offset, or with pagination=cursor and the returned
+// next_cursor for large databases, where offsets get slow. The q parameter
+// filters by an expression instead of the other filters.
 func (c *Controller) GetDetections(ctx echo.Context) error {
 	// Parse and validate query parameters
 	params, err := c.parseDetectionQueryParams(ctx)
@@ -391,6 +427,13 @@ func (c *Controller) GetDetections(ctx echo.Context) error {
 		)
 	}
 
+	if params.CursorPagination {
+		return c.getDetectionsByCursor(ctx, params)
+	}
+	if params.Filter != nil {
+		return c.getDetectionsByFilter(ctx, params)
+	}
+
 	// Get notes based on query type
 	notes, totalResults, err := c.getDetectionsByQueryType(params)
 	if err != nil {
@@ -430,9 +473,7 @@ func (c *Controller) GetDetections(ctx echo.Context) error {
 // getDetectionsByQueryType retrieves detections based on the query type
 func (c *Controller) getDetectionsByQueryType(params *detectionQueryParams) ([]datastore.Note, int64, error) {
 	// Check if advanced filters are present
-	hasAdvancedFilters := params.Confidence != "" || params.TimeOfDay != "" ||
-		params.HourRange != "" || params.Verified != "" ||
-		params.Location != "" || params.Locked != ""
+	hasAdvancedFilters := params.hasAdvancedFilters()
 
 	switch params.QueryType {
 	case "hourly":
@@ -472,17 +513,20 @@ func (c *Controller) convertNotesToDetectionResponses(notes []datastore.Note, in
 // noteToDetectionResponse converts a single note to a detection response
 func (c *Controller) noteToDetectionResponse(note *datastore.Note, includeWeather bool, weatherCache map[string][]datastore.HourlyWeather) DetectionResponse {
 	detection := DetectionResponse{
-		ID:             note.ID,
-		Date:           note.Date,
-		Time:           note.Time,
-		Source:         note.Source.SafeString,
-		BeginTime:      note.BeginTime.Format(time.RFC3339),
-		EndTime:        note.EndTime.Format(time.RFC3339),
-		SpeciesCode:    note.SpeciesCode,
-		ScientificName: note.ScientificName,
-		CommonName:     note.CommonName,
-		Confidence:     note.Confidence,
-		Locked:         note.Locked,
+		ID:                note.ID,
+		Date:              note.Date,
+		Time:              note.Time,
+		Source:            note.Source.SafeString,
+		Channel:           note.Channel,
+		Bearing:           note.Bearing,
+		BeginTime:         note.BeginTime.Format(time.RFC3339),
+		EndTime:           note.EndTime.Format(time.RFC3339),
+		SpeciesCode:       note.SpeciesCode,
+		ScientificName:    note.ScientificName,
+		CommonName:        note.CommonName,
+		Confidence:        note.Confidence,
+		Locked:            note.Locked,
+		WeatherAdjustment: note.WeatherAdjustment,
 	}
 
 	// Add species tracking metadata if processor has tracker
@@ -813,9 +857,9 @@ func (c *Controller) getSearchDetectionsAdvanced(params *detectionQueryParams) (
 		}
 	}
 
-	// Parse species filter
+	// Parse species filter, matching detections stored under former names
 	if params.Species != "" {
-		filters.Species = []string{params.Species}
+		filters.Species = c.speciesAliases().Expand([]string{params.Species})
 	}
 
 	// Parse verified filter
@@ -973,7 +1017,10 @@ func (c *Controller) GetDetection(ctx echo.Context) error {
 
 	// For single detection, include weather data by default
 	weatherCache := make(map[string][]datastore.HourlyWeather)
-	detection := c.noteToDetectionResponse(&note, true, weatherCache)
+	detection := DetectionDetailResponse{DetectionResponse: c.noteToDetectionResponse(&note, true, weatherCache)}
+	if len(note.Comments) > 0 {
+		detection.Annotations = commentResponses(note.Comments)
+	}
 	return ctx.JSON(http.StatusOK, detection)
 }
 
@@ -999,7 +1046,8 @@ func (c *Controller) GetRecentDetections(ctx echo.Context) error {
 	return ctx.JSON(http.StatusOK, detections)
 }
 
-// DeleteDetection deletes a detection by ID
+// DeleteDetection deletes a detection by ID. The detection is moved to the
+// trash when it is enabled, unless the permanent query parameter is true.
 func (c *Controller) DeleteDetection(ctx echo.Context) error {
 	idStr := ctx.Param("id")
 	note, err := c.DS.Get(idStr)
@@ -1012,7 +1060,7 @@ func (c *Controller) DeleteDetection(ctx echo.Context) error {
 		return c.HandleError(ctx, fmt.Errorf("detection is locked"), "Detection is locked", http.StatusForbidden)
 	}
 
-	err = c.DS.Delete(idStr)
+	err = c.deleteNote(idStr, ctx.QueryParam("permanent") == "true")
 	if err != nil {
 		return c.HandleError(ctx, err, "Failed to delete detection", http.StatusInternalServerError)
 	}
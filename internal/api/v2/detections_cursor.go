@@ -0,0 +1,104 @@
+// internal/api/v2/detections_cursor.go
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// DetectionPager is the datastore capability needed for cursor pagination
+type DetectionPager interface {
+	GetNotesAfter(ctx context.Context, filter datastore.NotePageFilter, after *datastore.NoteCursor, limit int) ([]datastore.Note, error)
+}
+
+// CursorPaginatedResponse is a page of detections with the cursor of the next page
+type CursorPaginatedResponse struct {
+	Data       []DetectionResponse `json:"data"`
+	Limit      int                 `json:"limit"`
+	NextCursor string              `json:"next_cursor,omitempty"` // pass as cursor for the next page, empty on the last page
+	HasMore    bool                `json:"has_more"`
+}
+
+// getDetectionsByCursor handles GET /api/v2/detections with pagination=cursor
+// or a cursor. Detections are returned newest first by date, time and ID,
+// filtered by search, species, start_date and end_date or q. Offsets and the other
+// query types and filters are not supported with cursors.
+func (c *Controller) getDetectionsByCursor(ctx echo.Context, params *detectionQueryParams) error {
+	if params.Offset > 0 || (params.QueryType != "" && params.QueryType != "all" && params.QueryType != "search") || params.hasAdvancedFilters() {
+		return c.HandleError(ctx, errors.Newf("cursor pagination does not support offset, queryType %q or advanced filters", params.QueryType).
+			Component("api").
+			Category(errors.CategoryValidation).
+			Build(), "Cursor pagination supports the search, species, start_date, end_date and q filters only", http.StatusBadRequest)
+	}
+
+	var after *datastore.NoteCursor
+	if params.Cursor != "" {
+		cursor, err := decodeDetectionCursor(params.Cursor)
+		if err != nil {
+			return c.HandleError(ctx, err, "Invalid cursor", http.StatusBadRequest)
+		}
+		after = &cursor
+	}
+
+	pager, ok := c.DS.(DetectionPager)
+	if !ok {
+		return c.HandleError(ctx, errors.Newf("datastore does not support cursor pagination").
+			Component("api").
+			Category(errors.CategoryConfiguration).
+			Build(), "Cursor pagination is not supported by this datastore", http.StatusNotImplemented)
+	}
+
+	filter := datastore.NotePageFilter{
+		Search:    params.Search,
+		Species:   params.Species,
+		StartDate: params.StartDate,
+		EndDate:   params.EndDate,
+		Query:     params.Filter,
+	}
+	// One detection more than a page tells whether there is a next page
+	notes, err := pager.GetNotesAfter(ctx.Request().Context(), filter, after, params.NumResults+1)
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to get detections", http.StatusInternalServerError)
+	}
+
+	resp := CursorPaginatedResponse{Limit: params.NumResults}
+	if len(notes) > params.NumResults {
+		notes = notes[:params.NumResults]
+		resp.HasMore = true
+		resp.NextCursor = encodeDetectionCursor(notes[len(notes)-1].Cursor())
+	}
+	resp.Data = c.convertNotesToDetectionResponses(notes, params.IncludeWeather)
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+// encodeDetectionCursor returns the opaque form of a cursor
+func encodeDetectionCursor(cursor datastore.NoteCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeDetectionCursor parses a cursor returned as next_cursor
+func decodeDetectionCursor(value string) (datastore.NoteCursor, error) {
+	var cursor datastore.NoteCursor
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err == nil {
+		err = json.Unmarshal(data, &cursor)
+	}
+	if err == nil && (validateDateParam(cursor.Date, "cursor") != nil || cursor.Date == "" || cursor.ID == 0) {
+		err = errors.NewStd("incomplete cursor")
+	}
+	if err != nil {
+		return cursor, errors.New(err).
+			Component("api").
+			Category(errors.CategoryValidation).
+			Context("cursor", value).
+			Build()
+	}
+	return cursor, nil
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+// cursorMockDataStore pages through notes ordered newest first
+type cursorMockDataStore struct {
+	*MockDataStore
+	notes  []datastore.Note
+	filter datastore.NotePageFilter
+}
+
+func (m *cursorMockDataStore) GetNotesAfter(_ context.Context, filter datastore.NotePageFilter, after *datastore.NoteCursor, limit int) ([]datastore.Note, error) {
+	m.filter = filter
+	start := 0
+	if after != nil {
+		for i := range m.notes {
+			if m.notes[i].ID == after.ID {
+				start = i + 1
+			}
+		}
+	}
+	return m.notes[start:min(start+limit, len(m.notes))], nil
+}
+
+func TestGetDetectionsByCursor(t *testing.T) {
+	t.Parallel()
+
+	e, mockDS, controller := setupTestEnvironment(t)
+	store := &cursorMockDataStore{MockDataStore: mockDS}
+	for id := uint(5); id >= 1; id-- {
+		store.notes = append(store.notes, datastore.Note{ID: id, Date: "2024-05-01", Time: "06:00:00", CommonName: "Eurasian Blackbird"})
+	}
+	controller.DS = store
+
+	get := func(query string) (*httptest.ResponseRecorder, CursorPaginatedResponse) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v2/detections"+query, http.NoBody)
+		rec := httptest.NewRecorder()
+		require.NoError(t, controller.GetDetections(e.NewContext(req, rec)))
+		var resp CursorPaginatedResponse
+		if rec.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		}
+		return rec, resp
+	}
+
+	rec, resp := get("?pagination=cursor&numResults=2&species=Turdus+merula")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Len(t, resp.Data, 2)
+	assert.True(t, resp.HasMore)
+	assert.Equal(t, "Turdus merula", store.filter.Species)
+
+	var ids []uint
+	for range 5 {
+		for _, d := range resp.Data {
+			ids = append(ids, d.ID)
+		}
+		if !resp.HasMore {
+			break
+		}
+		rec, resp = get("?numResults=2&cursor=" + resp.NextCursor)
+		require.Equal(t, http.StatusOK, rec.Code)
+	}
+	assert.Equal(t, []uint{5, 4, 3, 2, 1}, ids)
+	assert.Empty(t, resp.NextCursor)
+
+	rec, _ = get("?cursor=not-a-cursor")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	rec, _ = get("?pagination=cursor&offset=100")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	rec, _ = get("?pagination=cursor&confidence=0.8")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestDetectionCursorRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	cursor := datastore.NoteCursor{Date: "2024-05-01", Time: "06:00:00", ID: 42}
+	decoded, err := decodeDetectionCursor(encodeDetectionCursor(cursor))
+	require.NoError(t, err)
+	assert.Equal(t, cursor, decoded)
+
+	_, err = decodeDetectionCursor(encodeDetectionCursor(datastore.NoteCursor{Date: "yesterday", ID: 1}))
+	assert.Error(t, err)
+}
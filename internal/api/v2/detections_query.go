@@ -0,0 +1,74 @@
+// internal/api/v2/detections_query.go
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// DetectionQuerier is the datastore capability needed for filter expressions
+type DetectionQuerier interface {
+	QueryNotes(ctx context.Context, query *datastore.NoteQuery, limit, offset int) ([]datastore.Note, int64, error)
+}
+
+// withTagFilters adds a tag:"name" term to a filter expression for each tag
+// a detection must have. Tag names cannot contain quotes.
+func withTagFilters(expr string, tags []string) (string, error) {
+	if len(tags) == 0 {
+		return expr, nil
+	}
+	terms := make([]string, 0, len(tags)+1)
+	if expr != "" {
+		terms = append(terms, "("+expr+")")
+	}
+	for _, tag := range tags {
+		if strings.TrimSpace(tag) == "" || strings.Contains(tag, `"`) {
+			return "", errors.Newf("invalid tag %q", tag).
+				Component("api").
+				Category(errors.CategoryValidation).
+				Build()
+		}
+		terms = append(terms, `tag:"`+tag+`"`)
+	}
+	return strings.Join(terms, " "), nil
+}
+
+// parseDetectionFilter parses the q parameter, a filter expression such as
+// species:"Great Tit" confidence>=0.8 hour:4..9 source:rtsp1. The expression
+// replaces the other filters, so combining them is an error.
+func parseDetectionFilter(params *detectionQueryParams) (*datastore.NoteQuery, error) {
+	if (params.QueryType != "" && params.QueryType != "all" && params.QueryType != "search") ||
+		params.Search != "" || params.Species != "" || params.Date != "" || params.Hour != "" ||
+		params.StartDate != "" || params.EndDate != "" || params.hasAdvancedFilters() {
+		return nil, errors.Newf("q and tag cannot be combined with queryType %q or other filters", params.QueryType).
+			Component("api").
+			Category(errors.CategoryValidation).
+			Build()
+	}
+	return datastore.ParseNoteQuery(params.Query)
+}
+
+// getDetectionsByFilter handles GET /api/v2/detections with q, returning a
+// page of the detections matching the filter expression, newest first
+func (c *Controller) getDetectionsByFilter(ctx echo.Context, params *detectionQueryParams) error {
+	querier, ok := c.DS.(DetectionQuerier)
+	if !ok {
+		return c.HandleError(ctx, errors.Newf("datastore does not support filter expressions").
+			Component("api").
+			Category(errors.CategoryConfiguration).
+			Build(), "Filter expressions are not supported by this datastore", http.StatusNotImplemented)
+	}
+
+	notes, total, err := querier.QueryNotes(ctx.Request().Context(), params.Filter, params.NumResults, params.Offset)
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to get detections", http.StatusInternalServerError)
+	}
+
+	detections := c.convertNotesToDetectionResponses(notes, params.IncludeWeather)
+	return ctx.JSON(http.StatusOK, c.createPaginatedResponse(detections, total, params.NumResults, params.Offset))
+}
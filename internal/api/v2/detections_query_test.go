@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+// queryMockDataStore records the filter expression of a query
+type queryMockDataStore struct {
+	*MockDataStore
+	query *datastore.NoteQuery
+}
+
+func (m *queryMockDataStore) QueryNotes(_ context.Context, query *datastore.NoteQuery, limit, offset int) ([]datastore.Note, int64, error) {
+	m.query = query
+	return []datastore.Note{{ID: 7, Date: "2024-05-01", Time: "05:00:00", CommonName: "Great Tit"}}, 12, nil
+}
+
+func TestGetDetectionsByFilter(t *testing.T) {
+	t.Parallel()
+
+	e, mockDS, controller := setupTestEnvironment(t)
+	store := &queryMockDataStore{MockDataStore: mockDS}
+	controller.DS = store
+
+	// get returns the status and body of a request, or of the HTTP error for
+	// invalid query parameters
+	get := func(query url.Values) (int, string) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v2/detections?"+query.Encode(), http.NoBody)
+		rec := httptest.NewRecorder()
+		var he *echo.HTTPError
+		if err := controller.GetDetections(e.NewContext(req, rec)); errors.As(err, &he) {
+			return he.Code, fmt.Sprint(he.Message)
+		}
+		return rec.Code, rec.Body.String()
+	}
+
+	expr := `species:"Great Tit" confidence>=0.8 hour:4..9 source:rtsp1`
+	code, body := get(url.Values{"q": {expr}, "numResults": {"5"}})
+	require.Equal(t, http.StatusOK, code)
+	var resp PaginatedResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &resp))
+	assert.Equal(t, int64(12), resp.Total)
+	require.NotNil(t, store.query)
+	assert.Equal(t, expr, store.query.String())
+
+	code, body = get(url.Values{"q": {"colour:red"}})
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Contains(t, body, `unknown field "colour"`)
+
+	code, _ = get(url.Values{"q": {"robin"}, "species": {"Great Tit"}})
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// Tag filters are added as terms the detections must all match
+	code, _ = get(url.Values{"q": {"robin OR wren"}, "tag": {"nestbox", "banded bird"}})
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, `(robin OR wren) tag:"nestbox" tag:"banded bird"`, store.query.String())
+	code, _ = get(url.Values{"tag": {`a" OR "b`}})
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// Filter expressions need a datastore that supports them
+	controller.DS = mockDS
+	code, _ = get(url.Values{"q": {"robin"}})
+	assert.Equal(t, http.StatusNotImplemented, code)
+}
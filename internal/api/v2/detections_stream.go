@@ -0,0 +1,212 @@
+// internal/api/v2/detections_stream.go
+package api
+
+import (
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+// detectionStreamBufferSize is the number of detections queued for a WebSocket
+// client, a client that falls further behind is disconnected
+const detectionStreamBufferSize = 64
+
+// detectionStreamUpgrader upgrades connections to the detection stream. Its
+// CheckOrigin is left nil, so browsers on other origins are rejected while
+// clients that send no Origin header, such as scripts, can connect.
+var detectionStreamUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+// DetectionStreamMessage is a detection pushed to the WebSocket detection
+// stream as it is committed to the database
+type DetectionStreamMessage struct {
+	ID             uint      `json:"id"`
+	Date           string    `json:"date"`
+	Time           string    `json:"time"`
+	BeginTime      time.Time `json:"beginTime"`
+	EndTime        time.Time `json:"endTime"`
+	ScientificName string    `json:"scientificName"`
+	CommonName     string    `json:"commonName"`
+	SpeciesCode    string    `json:"speciesCode,omitempty"`
+	Confidence     float64   `json:"confidence"`
+	Source         string    `json:"source,omitempty"`   // display name of the audio source
+	SourceID       string    `json:"sourceId,omitempty"` // ID of the audio source
+	ClipPath       string    `json:"clipPath,omitempty"` // clip file relative to the export path, written shortly after
+	ClipURL        string    `json:"clipUrl,omitempty"`  // API route serving the clip
+	CommittedAt    time.Time `json:"committedAt"`
+}
+
+// newDetectionStreamMessage returns the stream message of a committed detection
+func newDetectionStreamMessage(note *datastore.Note, committedAt time.Time) DetectionStreamMessage {
+	message := DetectionStreamMessage{
+		ID:             note.ID,
+		Date:           note.Date,
+		Time:           note.Time,
+		BeginTime:      note.BeginTime,
+		EndTime:        note.EndTime,
+		ScientificName: note.ScientificName,
+		CommonName:     note.CommonName,
+		SpeciesCode:    note.SpeciesCode,
+		Confidence:     note.Confidence,
+		Source:         note.Source.DisplayName,
+		SourceID:       note.Source.ID,
+		ClipPath:       note.ClipName,
+		CommittedAt:    committedAt,
+	}
+	if note.ClipName != "" && note.ID != 0 {
+		message.ClipURL = "/api/v2/audio/" + strconv.FormatUint(uint64(note.ID), 10)
+	}
+	return message
+}
+
+// detectionStreamHub fans committed detections out to the WebSocket clients
+type detectionStreamHub struct {
+	mu      sync.Mutex
+	clients map[*Client]struct{}
+}
+
+// newDetectionStreamHub returns a hub without clients
+func newDetectionStreamHub() *detectionStreamHub {
+	return &detectionStreamHub{clients: make(map[*Client]struct{})}
+}
+
+// add registers a client for the detections broadcast from now on
+func (h *detectionStreamHub) add(client *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.clients[client] = struct{}{}
+}
+
+// remove unregisters a client and closes its send channel, which closes the
+// connection. Removing a client twice is a no-op.
+func (h *detectionStreamHub) remove(client *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.clients[client]; ok {
+		delete(h.clients, client)
+		close(client.send)
+	}
+}
+
+// count returns the number of connected clients
+func (h *detectionStreamHub) count() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.clients)
+}
+
+// broadcast queues a message for every client without blocking the analyzer,
+// dropping clients whose queue is full
+func (h *detectionStreamHub) broadcast(message []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for client := range h.clients {
+		select {
+		case client.send <- message:
+		default:
+			client.logger.Printf("Detection stream client %s is not keeping up, disconnecting", client.clientID)
+			delete(h.clients, client)
+			close(client.send)
+		}
+	}
+}
+
+// StreamDetectionsWebSocket handles WebSocket connections to
+// /api/v2/detections/stream, pushing each detection as it is committed in a
+// WebSocket text message of its own
+func (c *Controller) StreamDetectionsWebSocket(ctx echo.Context) error {
+	conn, err := detectionStreamUpgrader.Upgrade(ctx.Response(), ctx.Request(), nil)
+	if err != nil {
+		c.logger.Printf("Error upgrading connection to WebSocket: %v", err)
+		return err
+	}
+
+	client := &Client{
+		conn:       conn,
+		send:       make(chan []byte, detectionStreamBufferSize),
+		clientID:   ctx.Request().RemoteAddr,
+		streamType: "detections",
+		lastSeen:   time.Now(),
+		logger:     c.logger,
+	}
+	c.detectionStream.add(client)
+	c.Debug("Client %s connected to detections WebSocket stream", client.clientID)
+
+	go writeDetections(client)
+	go func() {
+		client.readPump(c.logger)
+		c.detectionStream.remove(client)
+		c.Debug("Client %s disconnected from detections WebSocket stream", client.clientID)
+	}()
+
+	return nil
+}
+
+// writeDetections writes each detection queued for a client as a WebSocket
+// message of its own and pings the client, until the hub closes the queue.
+// Unlike Client.writePump, queued messages are never joined into one frame.
+func writeDetections(client *Client) {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		if err := client.conn.Close(); err != nil {
+			client.logger.Printf("Failed to close connection: %v", err)
+		}
+	}()
+
+	for {
+		select {
+		case message, ok := <-client.send:
+			if err := client.conn.SetWriteDeadline(time.Now().Add(writeWait)); err != nil {
+				client.logger.Printf("Failed to set write deadline: %v", err)
+				return
+			}
+			if !ok {
+				// The hub closed the queue
+				if err := client.conn.WriteMessage(websocket.CloseMessage, []byte{}); err != nil {
+					client.logger.Printf("Error writing close message: %v", err)
+				}
+				return
+			}
+			if err := client.conn.WriteMessage(websocket.TextMessage, message); err != nil {
+				client.logger.Printf("Error writing detection: %v", err)
+				return
+			}
+		case <-ticker.C:
+			if err := client.conn.SetWriteDeadline(time.Now().Add(writeWait)); err != nil {
+				client.logger.Printf("Failed to set write deadline for ping: %v", err)
+				return
+			}
+			if err := client.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				client.logger.Printf("Error writing ping message: %v", err)
+				return
+			}
+		}
+	}
+}
+
+// PublishCommittedDetection pushes a detection committed by the analyzer to
+// the WebSocket detection stream
+func (c *Controller) PublishCommittedDetection(note *datastore.Note) {
+	if note == nil || c.detectionStream == nil || c.detectionStream.count() == 0 {
+		return
+	}
+	message, err := json.Marshal(newDetectionStreamMessage(note, time.Now()))
+	if err != nil {
+		c.logger.Printf("Error encoding detection for the detection stream: %v", err)
+		return
+	}
+	c.detectionStream.broadcast(message)
+}
+
+// isWebSocketRequest reports whether a request asks to upgrade to WebSocket
+func isWebSocketRequest(ctx echo.Context) bool {
+	return websocket.IsWebSocketUpgrade(ctx.Request())
+}
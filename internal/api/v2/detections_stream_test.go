@@ -0,0 +1,144 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+func TestStreamDetectionsWebSocket(t *testing.T) {
+	t.Parallel()
+
+	controller, url := startDetectionStreamServer(t)
+	conn, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	defer conn.Close()
+	require.Eventually(t, func() bool { return controller.detectionStream.count() == 1 }, time.Second, 10*time.Millisecond)
+
+	note := &datastore.Note{
+		ID:             42,
+		Date:           "2026-05-01",
+		Time:           "06:12:30",
+		ScientificName: "Turdus merula",
+		CommonName:     "Eurasian Blackbird",
+		Confidence:     0.91,
+		Source:         datastore.AudioSource{ID: "rtsp_87b89761", DisplayName: "Garden"},
+		ClipName:       "2026/05/turdus_merula_91p_20260501T061230Z.wav",
+	}
+	controller.PublishCommittedDetection(note)
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	_, data, err := conn.ReadMessage()
+	require.NoError(t, err)
+	var message DetectionStreamMessage
+	require.NoError(t, json.Unmarshal(data, &message))
+	assert.Equal(t, uint(42), message.ID)
+	assert.Equal(t, "Turdus merula", message.ScientificName)
+	assert.Equal(t, "Eurasian Blackbird", message.CommonName)
+	assert.InDelta(t, 0.91, message.Confidence, 0.0001)
+	assert.Equal(t, "Garden", message.Source)
+	assert.Equal(t, "rtsp_87b89761", message.SourceID)
+	assert.Equal(t, note.ClipName, message.ClipPath)
+	assert.Equal(t, "/api/v2/audio/42", message.ClipURL)
+
+	// Closing the connection unregisters the client
+	require.NoError(t, conn.Close())
+	assert.Eventually(t, func() bool { return controller.detectionStream.count() == 0 }, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestDetectionStreamHubDropsSlowClients(t *testing.T) {
+	t.Parallel()
+
+	hub := newDetectionStreamHub()
+	client := &Client{clientID: "slow", send: make(chan []byte, 1), logger: log.New(io.Discard, "", 0)}
+	hub.add(client)
+
+	hub.broadcast([]byte("first"))
+	assert.Equal(t, 1, hub.count())
+	hub.broadcast([]byte("second"))
+	assert.Zero(t, hub.count(), "a client with a full queue is dropped instead of blocking the analyzer")
+
+	hub.remove(client) // already removed, must not close the channel twice
+	_, open := <-client.send
+	assert.True(t, open, "the queued detection is still delivered")
+	_, open = <-client.send
+	assert.False(t, open)
+}
+
+// startDetectionStreamServer serves the detection stream of a new controller
+// and returns the controller and the WebSocket URL of the stream
+func startDetectionStreamServer(t *testing.T) (*Controller, string) {
+	t.Helper()
+
+	e := echo.New()
+	controller := mockController()
+	controller.detectionStream = newDetectionStreamHub()
+	controller.logger = log.New(io.Discard, "", 0)
+	e.GET("/api/v2/detections/stream", controller.StreamDetections)
+	server := httptest.NewServer(e)
+	t.Cleanup(server.Close)
+
+	return controller, "ws" + strings.TrimPrefix(server.URL, "http") + "/api/v2/detections/stream"
+}
+
+func TestDetectionStreamSendsOneMessagePerDetection(t *testing.T) {
+	t.Parallel()
+
+	controller, url := startDetectionStreamServer(t)
+	conn, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	defer conn.Close()
+	require.Eventually(t, func() bool { return controller.detectionStream.count() == 1 }, time.Second, 10*time.Millisecond)
+
+	// Detections published back to back are queued together
+	for id := uint(1); id <= 5; id++ {
+		controller.PublishCommittedDetection(&datastore.Note{ID: id, ScientificName: "Turdus merula"})
+	}
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	for id := uint(1); id <= 5; id++ {
+		messageType, data, err := conn.ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, websocket.TextMessage, messageType)
+		var message DetectionStreamMessage
+		require.NoError(t, json.Unmarshal(data, &message), "each message holds exactly one detection")
+		assert.Equal(t, id, message.ID)
+	}
+}
+
+func TestDetectionStreamRejectsOtherOrigins(t *testing.T) {
+	t.Parallel()
+
+	controller, url := startDetectionStreamServer(t)
+
+	header := http.Header{"Origin": []string{"https://attacker.example"}}
+	conn, resp, err := websocket.DefaultDialer.Dial(url, header)
+	if conn != nil {
+		conn.Close()
+	}
+	require.Error(t, err)
+	require.NotNil(t, resp)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Zero(t, controller.detectionStream.count())
+
+	// The page served by the same host can connect
+	header.Set("Origin", "http"+strings.TrimPrefix(strings.TrimSuffix(url, "/api/v2/detections/stream"), "ws"))
+	conn, resp, err = websocket.DefaultDialer.Dial(url, header)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	defer conn.Close()
+}
@@ -0,0 +1,528 @@
+// internal/api/v2/file_analysis.go
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/errors"
+	"github.com/tphakala/birdnet-go/internal/myaudio"
+)
+
+// File analysis limits
+const (
+	maxAnalysisUploadSize = 4 << 30   // bytes, a few hours of 48 kHz stereo WAV
+	analysisJobTTL        = time.Hour // how long finished analyses can be fetched
+)
+
+// File analysis job states
+const (
+	analysisStatusRunning   = "running"
+	analysisStatusCompleted = "completed"
+	analysisStatusFailed    = "failed"
+	analysisStatusCanceled  = "canceled"
+)
+
+// analysisFormats are the audio formats the file reader decodes
+var analysisFormats = []string{".wav", ".flac"}
+
+// chunkAnalyzer predicts the species of a 3 second segment starting at start;
+// implemented by birdnet.BirdNET.ProcessChunkWithContext
+type chunkAnalyzer func(ctx context.Context, chunk []float32, start time.Time) ([]datastore.Note, error)
+
+// FileAnalysisDetection is a detection in an analysed file, positioned by its
+// offset from the start of the recording
+type FileAnalysisDetection struct {
+	Start          float64 `json:"start"` // seconds from the start of the file
+	End            float64 `json:"end"`   // seconds from the start of the file
+	ScientificName string  `json:"scientificName"`
+	CommonName     string  `json:"commonName"`
+	SpeciesCode    string  `json:"speciesCode,omitempty"`
+	Confidence     float64 `json:"confidence"`
+}
+
+// FileAnalysisJob is the progress and results of the analysis of an uploaded
+// audio file
+type FileAnalysisJob struct {
+	ID          string                  `json:"id"`
+	Filename    string                  `json:"filename"`
+	Status      string                  `json:"status"`
+	Segments    int                     `json:"segments"`  // 3 second segments in the file, estimated before decoding
+	Processed   int                     `json:"processed"` // segments analysed so far
+	Detections  []FileAnalysisDetection `json:"detections"`
+	Error       string                  `json:"error,omitempty"`
+	CreatedAt   time.Time               `json:"createdAt"`
+	CompletedAt *time.Time              `json:"completedAt,omitempty"`
+	StreamURL   string                  `json:"streamUrl"`
+
+	cancel  context.CancelFunc
+	changed chan struct{} // closed and replaced whenever the job changes
+}
+
+// FileAnalysisProgress is the SSE event streamed as segments complete. It
+// carries the detections found since the previous event; Offset is the index
+// of the first of them in the detections of the job, so clients reconnecting
+// to the stream, which starts with all detections so far, can skip those
+// they already have.
+type FileAnalysisProgress struct {
+	Status     string                  `json:"status"`
+	Segments   int                     `json:"segments"`
+	Processed  int                     `json:"processed"`
+	Offset     int                     `json:"offset"`
+	Detections []FileAnalysisDetection `json:"detections"`
+	Error      string                  `json:"error,omitempty"`
+}
+
+// fileAnalysisJobs holds the file analyses of the last hour
+type fileAnalysisJobs struct {
+	mu   sync.Mutex
+	jobs map[string]*FileAnalysisJob
+}
+
+var fileAnalyses = &fileAnalysisJobs{jobs: make(map[string]*FileAnalysisJob)}
+
+// get returns a copy of a job
+func (s *fileAnalysisJobs) get(id string) (FileAnalysisJob, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return FileAnalysisJob{}, false
+	}
+	snapshot := *job
+	snapshot.Detections = append([]FileAnalysisDetection{}, job.Detections...)
+	return snapshot, true
+}
+
+// progress returns the progress of a job with the detections from index from,
+// and a channel closed on the next change of the job
+func (s *fileAnalysisJobs) progress(id string, from int) (FileAnalysisProgress, <-chan struct{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return FileAnalysisProgress{}, nil, false
+	}
+	from = min(from, len(job.Detections))
+	return FileAnalysisProgress{
+		Status:     job.Status,
+		Segments:   job.Segments,
+		Processed:  job.Processed,
+		Offset:     from,
+		Detections: append([]FileAnalysisDetection{}, job.Detections[from:]...),
+		Error:      job.Error,
+	}, job.changed, true
+}
+
+// update applies a change to a job and wakes up its streams
+func (s *fileAnalysisJobs) update(id string, change func(job *FileAnalysisJob)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if job, ok := s.jobs[id]; ok {
+		change(job)
+		close(job.changed)
+		job.changed = make(chan struct{})
+	}
+}
+
+// cancel stops a running job
+func (s *fileAnalysisJobs) cancel(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if ok && job.Status == analysisStatusRunning {
+		job.cancel()
+	}
+	return ok
+}
+
+// start registers a new job unless another analysis is still running. Jobs
+// finished longer than the retention period ago are removed.
+func (s *fileAnalysisJobs) start(filename string, segments int, cancel context.CancelFunc) (*FileAnalysisJob, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, job := range s.jobs {
+		if job.Status == analysisStatusRunning {
+			return nil, false
+		}
+		if job.CompletedAt != nil && time.Since(*job.CompletedAt) > analysisJobTTL {
+			delete(s.jobs, id)
+		}
+	}
+	id := uuid.New().String()
+	job := &FileAnalysisJob{
+		ID:         id,
+		Filename:   filename,
+		Status:     analysisStatusRunning,
+		Segments:   segments,
+		Detections: []FileAnalysisDetection{},
+		CreatedAt:  time.Now(),
+		StreamURL:  fmt.Sprintf("/api/v2/analysis/files/%s/stream", id),
+		cancel:     cancel,
+		changed:    make(chan struct{}),
+	}
+	s.jobs[id] = job
+	return job, true
+}
+
+// initFileAnalysisRoutes registers the file analysis endpoints
+func (c *Controller) initFileAnalysisRoutes() {
+	authMiddleware := c.getEffectiveAuthMiddleware()
+	c.Group.POST("/analysis/files", c.StartFileAnalysis, authMiddleware)
+	c.Group.GET("/analysis/files/:id", c.GetFileAnalysis, authMiddleware)
+	c.Group.GET("/analysis/files/:id/stream", c.StreamFileAnalysis, authMiddleware)
+	c.Group.DELETE("/analysis/files/:id", c.CancelFileAnalysis, authMiddleware)
+}
+
+// StartFileAnalysis handles POST /api/v2/analysis/files
+// It stores the WAV or FLAC file of the multipart field "file", or takes the
+// complete resumable upload of a JSON body {"uploadId": ...}, and analyses
+// it in the background with the model of the realtime analysis, segment by
+// segment between the segments of the live audio. The returned job can be
+// polled, or its stream followed for detections as segments complete. Only
+// one file is analysed at a time.
+func (c *Controller) StartFileAnalysis(ctx echo.Context) error {
+	if c.Processor == nil || c.Processor.GetBirdNET() == nil {
+		return c.HandleError(ctx, errors.Newf("BirdNET model not loaded").
+			Component("api").
+			Category(errors.CategoryModelInit).
+			Build(), "The BirdNET model is not loaded", http.StatusServiceUnavailable)
+	}
+	bn := c.Processor.GetBirdNET()
+	return c.startFileAnalysis(ctx, bn.ProcessChunkWithContext)
+}
+
+// startFileAnalysis stores the upload and starts analysing it with analyze
+func (c *Controller) startFileAnalysis(ctx echo.Context, analyze chunkAnalyzer) error {
+	path, filename, err := analysisInput(ctx)
+	if err != nil {
+		var enhancedErr *errors.EnhancedError
+		if errors.As(err, &enhancedErr) {
+			switch enhancedErr.Category {
+			case errors.CategoryValidation:
+				return c.HandleError(ctx, err, err.Error(), http.StatusBadRequest)
+			case errors.CategoryNotFound:
+				return c.HandleError(ctx, err, "Upload not found", http.StatusNotFound)
+			}
+		}
+		return c.HandleError(ctx, err, "Failed to store the audio file", http.StatusInternalServerError)
+	}
+
+	info, err := myaudio.GetAudioInfo(path)
+	if err != nil {
+		_ = os.Remove(path)
+		return c.HandleError(ctx, err, "The file is not a readable WAV or FLAC file", http.StatusBadRequest)
+	}
+	segments := myaudio.GetTotalChunks(info.SampleRate, info.TotalSamples, c.Settings.BirdNET.Overlap)
+
+	jobCtx := c.ctx
+	if jobCtx == nil {
+		jobCtx = context.Background()
+	}
+	jobCtx, cancel := context.WithCancel(jobCtx)
+	job, ok := fileAnalyses.start(filename, segments, cancel)
+	if !ok {
+		cancel()
+		_ = os.Remove(path)
+		return c.HandleError(ctx, errors.Newf("a file analysis is already running").
+			Component("api").
+			Category(errors.CategoryConflict).
+			Build(), "Another file is still being analysed", http.StatusConflict)
+	}
+
+	c.wg.Go(func() {
+		defer cancel()
+		defer func() { _ = os.Remove(path) }()
+		c.runFileAnalysis(jobCtx, job.ID, path, analyze)
+	})
+
+	snapshot, _ := fileAnalyses.get(job.ID)
+	return ctx.JSON(http.StatusAccepted, snapshot)
+}
+
+// startFromUploadRequest is the JSON body starting the analysis of a
+// complete resumable upload
+type startFromUploadRequest struct {
+	UploadID string `json:"uploadId"`
+}
+
+// analysisInput returns the file to analyse: the complete resumable upload
+// named by a JSON body, or else the file of a multipart request
+func analysisInput(ctx echo.Context) (path, filename string, err error) {
+	if !strings.HasPrefix(ctx.Request().Header.Get(echo.HeaderContentType), echo.MIMEApplicationJSON) {
+		return storeAnalysisUpload(ctx)
+	}
+	var req startFromUploadRequest
+	if err := ctx.Bind(&req); err != nil || req.UploadID == "" {
+		return "", "", errors.Newf("the request names no uploadId").
+			Component("api").
+			Category(errors.CategoryValidation).
+			Build()
+	}
+	return resumableUploads.take(req.UploadID)
+}
+
+// storeAnalysisUpload copies the "file" part of a multipart request to a
+// temporary file, without buffering the upload in memory
+func storeAnalysisUpload(ctx echo.Context) (path, filename string, err error) {
+	req := ctx.Request()
+	req.Body = http.MaxBytesReader(ctx.Response(), req.Body, maxAnalysisUploadSize)
+	reader, err := req.MultipartReader()
+	if err != nil {
+		return "", "", errors.New(err).
+			Component("api").
+			Category(errors.CategoryValidation).
+			Build()
+	}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return "", "", errors.Newf("the request has no file field").
+				Component("api").
+				Category(errors.CategoryValidation).
+				Build()
+		}
+		if err != nil {
+			return "", "", errors.New(err).
+				Component("api").
+				Category(errors.CategoryValidation).
+				Build()
+		}
+		if part.FormName() == "file" {
+			return storeAnalysisPart(part)
+		}
+		_ = part.Close()
+	}
+}
+
+// storeAnalysisPart writes an uploaded audio file to a temporary file
+func storeAnalysisPart(part *multipart.Part) (path, filename string, err error) {
+	defer func() { _ = part.Close() }()
+	filename = filepath.Base(part.FileName())
+	ext := strings.ToLower(filepath.Ext(filename))
+	if !isAnalysisFormat(ext) {
+		return "", "", errors.Newf("unsupported audio format %q, upload a WAV or FLAC file", ext).
+			Component("api").
+			Category(errors.CategoryValidation).
+			Build()
+	}
+
+	file, err := os.CreateTemp("", "birdnet-go-analysis-*"+ext)
+	if err != nil {
+		return "", "", errors.New(err).
+			Component("api").
+			Category(errors.CategoryFileIO).
+			Build()
+	}
+	_, copyErr := io.Copy(file, part)
+	closeErr := file.Close()
+	if err := errors.Join(copyErr, closeErr); err != nil {
+		_ = os.Remove(file.Name())
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return "", "", errors.Newf("the file is larger than %d bytes", maxAnalysisUploadSize).
+				Component("api").
+				Category(errors.CategoryValidation).
+				Build()
+		}
+		return "", "", errors.New(err).
+			Component("api").
+			Category(errors.CategoryFileIO).
+			Build()
+	}
+	return file.Name(), filename, nil
+}
+
+// isAnalysisFormat reports whether files with the extension can be analysed
+func isAnalysisFormat(ext string) bool {
+	for _, format := range analysisFormats {
+		if ext == format {
+			return true
+		}
+	}
+	return false
+}
+
+// runFileAnalysis analyses a file segment by segment, publishing the
+// detections of each segment to the job as soon as it completes
+func (c *Controller) runFileAnalysis(ctx context.Context, jobID, path string, analyze chunkAnalyzer) {
+	segment := 0
+	err := c.analyzeFileSegments(ctx, path, analyze, func(start float64, notes []datastore.Note) {
+		detections := c.fileAnalysisDetections(notes, start)
+		segment++
+		fileAnalyses.update(jobID, func(job *FileAnalysisJob) {
+			job.Processed = segment
+			job.Segments = max(job.Segments, segment)
+			job.Detections = append(job.Detections, detections...)
+		})
+	})
+
+	now := time.Now()
+	fileAnalyses.update(jobID, func(job *FileAnalysisJob) {
+		job.CompletedAt = &now
+		switch {
+		case err == nil:
+			job.Status = analysisStatusCompleted
+			job.Segments = job.Processed
+		case ctx.Err() != nil:
+			job.Status = analysisStatusCanceled
+		default:
+			job.Status = analysisStatusFailed
+			job.Error = err.Error()
+		}
+	})
+	if err != nil && ctx.Err() == nil && c.apiLogger != nil {
+		c.apiLogger.Error("File analysis failed", "job_id", jobID, "error", err)
+	}
+}
+
+// analyzeFileSegments reads a file in 3 second segments, overlapping as the
+// realtime analysis does, and passes the predictions of each segment to each
+// with the start of the segment in seconds from the start of the file
+func (c *Controller) analyzeFileSegments(ctx context.Context, path string, analyze chunkAnalyzer, each func(start float64, notes []datastore.Note)) error {
+	readSettings := &conf.Settings{
+		Debug:   c.Settings.Debug,
+		BirdNET: conf.BirdNETConfig{Overlap: c.Settings.BirdNET.Overlap},
+		Input:   conf.InputConfig{Path: path},
+	}
+	step := 3 - c.Settings.BirdNET.Overlap
+	segment := 0
+
+	return myaudio.ReadAudioFileBuffered(readSettings, func(chunk []float32, _ bool) error {
+		if len(chunk) == 0 {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		start := float64(segment) * step
+		offset := time.Duration(start * float64(time.Second))
+		notes, err := analyze(ctx, chunk, time.Time{}.Add(offset))
+		if err != nil {
+			return err
+		}
+		segment++
+		each(start, notes)
+		return nil
+	})
+}
+
+// fileAnalysisDetections keeps the predictions of a segment above the
+// confidence threshold for included species
+func (c *Controller) fileAnalysisDetections(notes []datastore.Note, start float64) []FileAnalysisDetection {
+	var detections []FileAnalysisDetection
+	for i := range notes {
+		if notes[i].Confidence <= c.Settings.BirdNET.Threshold || !c.Settings.IsSpeciesIncluded(notes[i].ScientificName) {
+			continue
+		}
+		detections = append(detections, FileAnalysisDetection{
+			Start:          start,
+			End:            start + 3,
+			ScientificName: notes[i].ScientificName,
+			CommonName:     notes[i].CommonName,
+			SpeciesCode:    notes[i].SpeciesCode,
+			Confidence:     notes[i].Confidence,
+		})
+	}
+	return detections
+}
+
+// GetFileAnalysis handles GET /api/v2/analysis/files/:id
+func (c *Controller) GetFileAnalysis(ctx echo.Context) error {
+	job, ok := fileAnalyses.get(ctx.Param("id"))
+	if !ok {
+		return c.fileAnalysisNotFound(ctx)
+	}
+	return ctx.JSON(http.StatusOK, job)
+}
+
+// CancelFileAnalysis handles DELETE /api/v2/analysis/files/:id
+// It stops a running analysis; the detections found so far are kept.
+func (c *Controller) CancelFileAnalysis(ctx echo.Context) error {
+	if !fileAnalyses.cancel(ctx.Param("id")) {
+		return c.fileAnalysisNotFound(ctx)
+	}
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// StreamFileAnalysis handles GET /api/v2/analysis/files/:id/stream
+// It streams "progress" events as segments complete, starting with all
+// detections so far, and a final "done" event when the analysis ends.
+// Progress made while a client is busy is merged into the next event, so a
+// slow client never holds up the analysis.
+func (c *Controller) StreamFileAnalysis(ctx echo.Context) error {
+	id := ctx.Param("id")
+	if _, _, ok := fileAnalyses.progress(id, 0); !ok {
+		return c.fileAnalysisNotFound(ctx)
+	}
+
+	setSSEHeaders(ctx)
+	ctx.Response().WriteHeader(http.StatusOK)
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	sent := 0
+	for {
+		progress, changed, ok := fileAnalyses.progress(id, sent)
+		if !ok {
+			return nil
+		}
+		if progress.Status != analysisStatusRunning {
+			if len(progress.Detections) > 0 {
+				if err := c.sendSSEMessage(ctx, "progress", progress); err != nil {
+					return nil
+				}
+			}
+			progress.Offset += len(progress.Detections)
+			progress.Detections = []FileAnalysisDetection{}
+			_ = c.sendSSEMessage(ctx, "done", progress)
+			return nil
+		}
+		if err := c.sendSSEMessage(ctx, "progress", progress); err != nil {
+			return nil
+		}
+		sent = progress.Offset + len(progress.Detections)
+
+		if !c.awaitFileAnalysisChange(ctx, changed, heartbeat.C) {
+			return nil
+		}
+	}
+}
+
+// awaitFileAnalysisChange waits for the next change of a job, sending
+// heartbeats meanwhile. It returns false when the client has gone.
+func (c *Controller) awaitFileAnalysisChange(ctx echo.Context, changed <-chan struct{}, heartbeat <-chan time.Time) bool {
+	for {
+		select {
+		case <-changed:
+			return true
+		case <-heartbeat:
+			if err := c.sendSSEMessage(ctx, "heartbeat", map[string]int64{"timestamp": time.Now().Unix()}); err != nil {
+				return false
+			}
+		case <-ctx.Request().Context().Done():
+			return false
+		}
+	}
+}
+
+// fileAnalysisNotFound responds to requests for unknown analyses
+func (c *Controller) fileAnalysisNotFound(ctx echo.Context) error {
+	return c.HandleError(ctx, errors.Newf("file analysis %s not found", ctx.Param("id")).
+		Component("api").
+		Category(errors.CategoryNotFound).
+		Build(), "File analysis not found", http.StatusNotFound)
+}
@@ -0,0 +1,213 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-audio/audio"
+	"github.com/go-audio/wav"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+// writeSilentWAV writes a mono 16-bit 48 kHz WAV file of the given length
+func writeSilentWAV(t *testing.T, path string, seconds int) {
+	t.Helper()
+	file, err := os.Create(path)
+	require.NoError(t, err)
+	encoder := wav.NewEncoder(file, conf.SampleRate, 16, 1, 1)
+	buf := &audio.IntBuffer{
+		Format:         &audio.Format{NumChannels: 1, SampleRate: conf.SampleRate},
+		Data:           make([]int, seconds*conf.SampleRate),
+		SourceBitDepth: 16,
+	}
+	require.NoError(t, encoder.Write(buf))
+	require.NoError(t, encoder.Close())
+	require.NoError(t, file.Close())
+}
+
+// analysisUpload builds a multipart request uploading a file
+func analysisUpload(t *testing.T, filename string, content []byte) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	require.NoError(t, writer.WriteField("note", "dawn chorus"))
+	part, err := writer.CreateFormFile("file", filename)
+	require.NoError(t, err)
+	_, err = part.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/analysis/files", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+// TestFileAnalysisStreaming analyses a file with a fake model. It replaces
+// the package level job registry so it does not run in parallel.
+func TestFileAnalysisStreaming(t *testing.T) {
+	e, _, controller := setupTestEnvironment(t)
+	controller.Settings.BirdNET.Threshold = 0.5
+	controller.Settings.BirdNET.RangeFilter.Species = []string{"Turdus merula_Eurasian Blackbird"}
+
+	origJobs := fileAnalyses
+	fileAnalyses = &fileAnalysisJobs{jobs: make(map[string]*FileAnalysisJob)}
+	t.Cleanup(func() { fileAnalyses = origJobs })
+
+	wavPath := filepath.Join(t.TempDir(), "dawn.wav")
+	writeSilentWAV(t, wavPath, 12)
+	content, err := os.ReadFile(wavPath)
+	require.NoError(t, err)
+
+	// The model finds a blackbird in the second segment and holds the third
+	// until released, so the results of the first segments can be checked
+	// while the analysis runs
+	release := make(chan struct{})
+	var segmentStarts []time.Duration
+	analyze := func(ctx context.Context, chunk []float32, start time.Time) ([]datastore.Note, error) {
+		offset := start.Sub(time.Time{})
+		segmentStarts = append(segmentStarts, offset)
+		switch offset {
+		case 3 * time.Second:
+			return []datastore.Note{
+				{ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", Confidence: 0.9},
+				{ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", Confidence: 0.3},
+				{ScientificName: "Pica pica", CommonName: "Eurasian Magpie", Confidence: 0.8},
+			}, nil
+		case 6 * time.Second:
+			select {
+			case <-release:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		return nil, nil
+	}
+
+	rec := httptest.NewRecorder()
+	require.NoError(t, controller.startFileAnalysis(e.NewContext(analysisUpload(t, "dawn.wav", content), rec), analyze))
+	require.Equal(t, http.StatusAccepted, rec.Code)
+	var job FileAnalysisJob
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &job))
+	assert.Equal(t, "dawn.wav", job.Filename)
+	assert.InDelta(t, 4, job.Segments, 1, "segments are estimated from the file size")
+	assert.Equal(t, "/api/v2/analysis/files/"+job.ID+"/stream", job.StreamURL)
+
+	// A second file is rejected while the first one is analysed
+	rec = httptest.NewRecorder()
+	require.NoError(t, controller.startFileAnalysis(e.NewContext(analysisUpload(t, "dusk.wav", content), rec), analyze))
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	// Early results are available before the analysis ends
+	require.Eventually(t, func() bool {
+		current, _ := fileAnalyses.get(job.ID)
+		return current.Processed == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	progress, changed, ok := fileAnalyses.progress(job.ID, 0)
+	require.True(t, ok)
+	assert.Equal(t, analysisStatusRunning, progress.Status)
+	require.Len(t, progress.Detections, 1)
+	assert.Equal(t, FileAnalysisDetection{Start: 3, End: 6, ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", Confidence: 0.9}, progress.Detections[0])
+
+	close(release)
+	select {
+	case <-changed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("streams were not woken up by the next segment")
+	}
+	require.Eventually(t, func() bool {
+		current, _ := fileAnalyses.get(job.ID)
+		return current.Status == analysisStatusCompleted
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []time.Duration{0, 3 * time.Second, 6 * time.Second, 9 * time.Second}, segmentStarts)
+
+	request := func(handler func(ctx echo.Context) error, path, id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, http.NoBody)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(id)
+		require.NoError(t, handler(c))
+		return rec
+	}
+
+	// A stream of a finished analysis replays the detections and ends
+	rec = request(controller.StreamFileAnalysis, job.StreamURL, job.ID)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/event-stream", rec.Header().Get("Content-Type"))
+	events := strings.Split(strings.TrimSpace(rec.Body.String()), "\n\n")
+	require.Len(t, events, 2)
+	assert.True(t, strings.HasPrefix(events[0], "event: progress\ndata: "))
+	assert.Contains(t, events[0], `"offset":0`)
+	assert.Contains(t, events[0], `"scientificName":"Turdus merula"`)
+	assert.True(t, strings.HasPrefix(events[1], "event: done\ndata: "))
+	assert.Contains(t, events[1], `"status":"completed"`)
+	assert.Contains(t, events[1], `"offset":1`)
+
+	rec = request(controller.GetFileAnalysis, "/api/v2/analysis/files/"+job.ID, job.ID)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &job))
+	assert.Equal(t, 4, job.Processed)
+	assert.Equal(t, 4, job.Segments)
+	assert.Len(t, job.Detections, 1)
+	assert.NotNil(t, job.CompletedAt)
+
+	rec = request(controller.StreamFileAnalysis, "/api/v2/analysis/files/unknown/stream", "unknown")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestFileAnalysisUploadValidation(t *testing.T) {
+	e, _, controller := setupTestEnvironment(t)
+
+	origJobs := fileAnalyses
+	fileAnalyses = &fileAnalysisJobs{jobs: make(map[string]*FileAnalysisJob)}
+	t.Cleanup(func() { fileAnalyses = origJobs })
+
+	analyze := func(context.Context, []float32, time.Time) ([]datastore.Note, error) {
+		return nil, nil
+	}
+	tests := []struct {
+		name     string
+		filename string
+		content  []byte
+	}{
+		{"unsupported format", "dawn.mp3", []byte("ID3")},
+		{"not audio", "dawn.wav", []byte("not a wav file")},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		require.NoError(t, controller.startFileAnalysis(e.NewContext(analysisUpload(t, tt.filename, tt.content), rec), analyze))
+		assert.Equal(t, http.StatusBadRequest, rec.Code, tt.name)
+	}
+	assert.Empty(t, fileAnalyses.jobs)
+}
+
+func TestFileAnalysisUploadBodyLimit(t *testing.T) {
+	t.Parallel()
+
+	e, _, controller := setupTestEnvironment(t)
+	controller.initFileAnalysisRoutes()
+
+	// Recordings are larger than the request body limit of the group. Past
+	// the limit the handler answers that no model is loaded.
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, analysisUpload(t, "dawn.wav", make([]byte, 2<<20)))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/detections/ignore", bytes.NewReader(make([]byte, 2<<20)))
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code, "other routes keep the limit")
+}
@@ -0,0 +1,346 @@
+// internal/api/v2/file_analysis_upload.go
+package api
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// Resumable upload protocol, the core of tus 1.0.0 with the creation,
+// termination and expiration extensions
+const (
+	tusVersion            = "1.0.0"
+	tusExtensions         = "creation,termination,expiration"
+	tusOffsetContentType  = "application/offset+octet-stream"
+	resumableUploadTTL    = 24 * time.Hour // how long an upload can be resumed after its last chunk
+	resumableUploadsRoute = "/api/v2/analysis/uploads/"
+)
+
+// resumableUpload is an audio file uploaded in chunks for file analysis
+type resumableUpload struct {
+	id        string
+	filename  string
+	path      string // temporary file the chunks are appended to
+	length    int64  // size of the whole file
+	offset    int64  // bytes received so far
+	expiresAt time.Time
+	busy      bool // a chunk is being written
+}
+
+// resumableUploadStore holds the uploads that can be resumed
+type resumableUploadStore struct {
+	mu      sync.Mutex
+	uploads map[string]*resumableUpload
+}
+
+var resumableUploads = &resumableUploadStore{uploads: make(map[string]*resumableUpload)}
+
+// create registers a new upload, removing the expired ones
+func (s *resumableUploadStore) create(filename, path string, length int64) *resumableUpload {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, upload := range s.uploads {
+		if !upload.busy && time.Now().After(upload.expiresAt) {
+			_ = os.Remove(upload.path)
+			delete(s.uploads, id)
+		}
+	}
+	upload := &resumableUpload{
+		id:        uuid.New().String(),
+		filename:  filename,
+		path:      path,
+		length:    length,
+		expiresAt: time.Now().Add(resumableUploadTTL),
+	}
+	s.uploads[upload.id] = upload
+	return upload
+}
+
+// get returns a copy of an upload
+func (s *resumableUploadStore) get(id string) (resumableUpload, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	upload, ok := s.uploads[id]
+	if !ok {
+		return resumableUpload{}, false
+	}
+	return *upload, true
+}
+
+// acquire marks an upload busy for writing a chunk at offset. It fails when
+// the upload is unknown, already being written or at another offset.
+func (s *resumableUploadStore) acquire(id string, offset int64) (resumableUpload, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	upload, ok := s.uploads[id]
+	switch {
+	case !ok:
+		return resumableUpload{}, http.StatusNotFound, errors.Newf("upload %s not found", id).
+			Component("api").
+			Category(errors.CategoryNotFound).
+			Build()
+	case upload.busy:
+		return resumableUpload{}, http.StatusConflict, errors.Newf("upload %s is already receiving a chunk", id).
+			Component("api").
+			Category(errors.CategoryConflict).
+			Build()
+	case upload.offset != offset:
+		return resumableUpload{}, http.StatusConflict, errors.Newf("upload offset is %d, not %d", upload.offset, offset).
+			Component("api").
+			Category(errors.CategoryConflict).
+			Build()
+	}
+	upload.busy = true
+	return *upload, 0, nil
+}
+
+// release records the bytes written by a chunk and extends the expiry
+func (s *resumableUploadStore) release(id string, written int64) resumableUpload {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	upload := s.uploads[id]
+	upload.busy = false
+	upload.offset += written
+	upload.expiresAt = time.Now().Add(resumableUploadTTL)
+	return *upload
+}
+
+// take removes a complete upload and returns its file, which the caller then
+// owns
+func (s *resumableUploadStore) take(id string) (path, filename string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	upload, ok := s.uploads[id]
+	switch {
+	case !ok:
+		return "", "", errors.Newf("upload %s not found", id).
+			Component("api").
+			Category(errors.CategoryNotFound).
+			Build()
+	case upload.busy || upload.offset < upload.length:
+		return "", "", errors.Newf("upload %s is incomplete, %d of %d bytes received", id, upload.offset, upload.length).
+			Component("api").
+			Category(errors.CategoryValidation).
+			Build()
+	}
+	delete(s.uploads, id)
+	return upload.path, upload.filename, nil
+}
+
+// remove deletes an upload and its file, unless a chunk is being written
+func (s *resumableUploadStore) remove(id string) (found, removed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	upload, ok := s.uploads[id]
+	if !ok || upload.busy {
+		return ok, false
+	}
+	_ = os.Remove(upload.path)
+	delete(s.uploads, id)
+	return true, true
+}
+
+// initResumableUploadRoutes registers the resumable upload endpoints
+func (c *Controller) initResumableUploadRoutes() {
+	authMiddleware := c.getEffectiveAuthMiddleware()
+	c.Group.OPTIONS("/analysis/uploads", c.GetResumableUploadOptions)
+	c.Group.POST("/analysis/uploads", c.CreateResumableUpload, authMiddleware)
+	c.Group.HEAD("/analysis/uploads/:id", c.GetResumableUploadOffset, authMiddleware)
+	c.Group.PATCH("/analysis/uploads/:id", c.AppendResumableUpload, authMiddleware)
+	c.Group.DELETE("/analysis/uploads/:id", c.DeleteResumableUpload, authMiddleware)
+}
+
+// GetResumableUploadOptions handles OPTIONS /api/v2/analysis/uploads
+// It describes the supported protocol version, extensions and file size.
+func (c *Controller) GetResumableUploadOptions(ctx echo.Context) error {
+	header := ctx.Response().Header()
+	header.Set("Tus-Resumable", tusVersion)
+	header.Set("Tus-Version", tusVersion)
+	header.Set("Tus-Extension", tusExtensions)
+	header.Set("Tus-Max-Size", strconv.FormatInt(maxAnalysisUploadSize, 10))
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// CreateResumableUpload handles POST /api/v2/analysis/uploads
+// It creates an upload of Upload-Length bytes, named by the filename key of
+// Upload-Metadata, and returns its URL in the Location header. The chunks are
+// then sent with PATCH, and the complete file analysed by posting its upload
+// ID to /analysis/files.
+func (c *Controller) CreateResumableUpload(ctx echo.Context) error {
+	ctx.Response().Header().Set("Tus-Resumable", tusVersion)
+	req := ctx.Request()
+
+	length, err := strconv.ParseInt(req.Header.Get("Upload-Length"), 10, 64)
+	if err != nil || length <= 0 {
+		return c.resumableUploadError(ctx, "Upload-Length must be a positive number of bytes", http.StatusBadRequest)
+	}
+	if length > maxAnalysisUploadSize {
+		return c.resumableUploadError(ctx, fmt.Sprintf("the file is larger than %d bytes", maxAnalysisUploadSize), http.StatusRequestEntityTooLarge)
+	}
+
+	filename := filepath.Base(parseUploadMetadata(req.Header.Get("Upload-Metadata"))["filename"])
+	ext := strings.ToLower(filepath.Ext(filename))
+	if !isAnalysisFormat(ext) {
+		return c.resumableUploadError(ctx, fmt.Sprintf("unsupported audio format %q, upload a WAV or FLAC file", ext), http.StatusBadRequest)
+	}
+
+	file, err := os.CreateTemp("", "birdnet-go-upload-*"+ext)
+	if err != nil {
+		return c.HandleError(ctx, errors.New(err).
+			Component("api").
+			Category(errors.CategoryFileIO).
+			Build(), "Failed to create the upload", http.StatusInternalServerError)
+	}
+	_ = file.Close()
+
+	upload := resumableUploads.create(filename, file.Name(), length)
+	header := ctx.Response().Header()
+	header.Set("Location", resumableUploadsRoute+upload.id)
+	header.Set("Upload-Offset", "0")
+	header.Set("Upload-Expires", upload.expiresAt.UTC().Format(http.TimeFormat))
+	return ctx.JSON(http.StatusCreated, map[string]string{"uploadId": upload.id})
+}
+
+// GetResumableUploadOffset handles HEAD /api/v2/analysis/uploads/:id
+// It returns the bytes received so far in Upload-Offset, where the client
+// resumes the upload.
+func (c *Controller) GetResumableUploadOffset(ctx echo.Context) error {
+	header := ctx.Response().Header()
+	header.Set("Tus-Resumable", tusVersion)
+	header.Set("Cache-Control", "no-store")
+	upload, ok := resumableUploads.get(ctx.Param("id"))
+	if !ok {
+		return ctx.NoContent(http.StatusNotFound)
+	}
+	header.Set("Upload-Offset", strconv.FormatInt(upload.offset, 10))
+	header.Set("Upload-Length", strconv.FormatInt(upload.length, 10))
+	header.Set("Upload-Expires", upload.expiresAt.UTC().Format(http.TimeFormat))
+	return ctx.NoContent(http.StatusOK)
+}
+
+// AppendResumableUpload handles PATCH /api/v2/analysis/uploads/:id
+// It appends a chunk at Upload-Offset, which must be the bytes received so
+// far. The bytes received before a connection drops are kept, so the client
+// resumes from the offset returned by HEAD.
+func (c *Controller) AppendResumableUpload(ctx echo.Context) error {
+	ctx.Response().Header().Set("Tus-Resumable", tusVersion)
+	req := ctx.Request()
+	if !strings.EqualFold(req.Header.Get(echo.HeaderContentType), tusOffsetContentType) {
+		return c.resumableUploadError(ctx, "Content-Type must be "+tusOffsetContentType, http.StatusUnsupportedMediaType)
+	}
+	offset, err := strconv.ParseInt(req.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		return c.resumableUploadError(ctx, "Upload-Offset must be a number of bytes", http.StatusBadRequest)
+	}
+
+	id := ctx.Param("id")
+	upload, status, err := resumableUploads.acquire(id, offset)
+	if err != nil {
+		return c.HandleError(ctx, err, err.Error(), status)
+	}
+
+	written, writeErr := appendUploadChunk(upload.path, offset, req.Body, upload.length-offset)
+	upload = resumableUploads.release(id, written)
+
+	header := ctx.Response().Header()
+	header.Set("Upload-Offset", strconv.FormatInt(upload.offset, 10))
+	header.Set("Upload-Expires", upload.expiresAt.UTC().Format(http.TimeFormat))
+	if writeErr != nil {
+		var enhancedErr *errors.EnhancedError
+		if errors.As(writeErr, &enhancedErr) && enhancedErr.Category == errors.CategoryValidation {
+			return c.HandleError(ctx, writeErr, writeErr.Error(), http.StatusRequestEntityTooLarge)
+		}
+		return c.HandleError(ctx, writeErr, "Failed to store the chunk", http.StatusInternalServerError)
+	}
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// appendUploadChunk writes a chunk to the upload file at offset, at most
+// remaining bytes. It returns the bytes written, also when reading the chunk
+// fails part way.
+func appendUploadChunk(path string, offset int64, body io.Reader, remaining int64) (int64, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY, 0o600)
+	if err != nil {
+		return 0, errors.New(err).
+			Component("api").
+			Category(errors.CategoryFileIO).
+			Build()
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		_ = file.Close()
+		return 0, errors.New(err).
+			Component("api").
+			Category(errors.CategoryFileIO).
+			Build()
+	}
+
+	written, copyErr := io.Copy(file, io.LimitReader(body, remaining))
+	if err := errors.Join(copyErr, file.Close()); err != nil {
+		return written, errors.New(err).
+			Component("api").
+			Category(errors.CategoryFileIO).
+			Build()
+	}
+	if n, _ := body.Read(make([]byte, 1)); n > 0 {
+		return written, errors.Newf("the chunk extends past the upload length").
+			Component("api").
+			Category(errors.CategoryValidation).
+			Build()
+	}
+	return written, nil
+}
+
+// DeleteResumableUpload handles DELETE /api/v2/analysis/uploads/:id
+// It abandons an upload and deletes the bytes received.
+func (c *Controller) DeleteResumableUpload(ctx echo.Context) error {
+	ctx.Response().Header().Set("Tus-Resumable", tusVersion)
+	found, removed := resumableUploads.remove(ctx.Param("id"))
+	switch {
+	case !found:
+		return c.HandleError(ctx, errors.Newf("upload %s not found", ctx.Param("id")).
+			Component("api").
+			Category(errors.CategoryNotFound).
+			Build(), "Upload not found", http.StatusNotFound)
+	case !removed:
+		return c.resumableUploadError(ctx, "the upload is receiving a chunk", http.StatusConflict)
+	}
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// resumableUploadError responds to an invalid upload request
+func (c *Controller) resumableUploadError(ctx echo.Context, message string, status int) error {
+	return c.HandleError(ctx, errors.Newf("%s", message).
+		Component("api").
+		Category(errors.CategoryValidation).
+		Build(), message, status)
+}
+
+// parseUploadMetadata decodes an Upload-Metadata header, comma separated
+// keys each followed by a space and its base64 encoded value
+func parseUploadMetadata(header string) map[string]string {
+	metadata := make(map[string]string)
+	for pair := range strings.SplitSeq(header, ",") {
+		key, encoded, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			continue
+		}
+		value, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			continue
+		}
+		metadata[key] = string(value)
+	}
+	return metadata
+}
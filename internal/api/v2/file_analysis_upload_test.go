@@ -0,0 +1,218 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+// droppedReader returns its data and then fails like a dropped connection
+type droppedReader struct {
+	data []byte
+}
+
+func (r *droppedReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+// TestResumableUpload uploads a file in chunks, resuming after a dropped
+// chunk, and analyses it. It replaces the package level registries so it
+// does not run in parallel.
+func TestResumableUpload(t *testing.T) {
+	e, _, controller := setupTestEnvironment(t)
+
+	origUploads, origJobs := resumableUploads, fileAnalyses
+	resumableUploads = &resumableUploadStore{uploads: make(map[string]*resumableUpload)}
+	fileAnalyses = &fileAnalysisJobs{jobs: make(map[string]*FileAnalysisJob)}
+	t.Cleanup(func() { resumableUploads, fileAnalyses = origUploads, origJobs })
+
+	wavPath := filepath.Join(t.TempDir(), "field.wav")
+	writeSilentWAV(t, wavPath, 6)
+	content, err := os.ReadFile(wavPath)
+	require.NoError(t, err)
+
+	request := func(handler echo.HandlerFunc, method, id string, body io.Reader, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/v2/analysis/uploads/"+id, body)
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(id)
+		require.NoError(t, handler(c))
+		return rec
+	}
+	chunk := func(id string, offset int, body io.Reader) *httptest.ResponseRecorder {
+		return request(controller.AppendResumableUpload, http.MethodPatch, id, body, map[string]string{
+			"Content-Type":  tusOffsetContentType,
+			"Upload-Offset": strconv.Itoa(offset),
+			"Tus-Resumable": tusVersion,
+		})
+	}
+
+	rec := request(controller.GetResumableUploadOptions, http.MethodOptions, "", http.NoBody, nil)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, tusExtensions, rec.Header().Get("Tus-Extension"))
+
+	rec = request(controller.CreateResumableUpload, http.MethodPost, "", http.NoBody, map[string]string{
+		"Upload-Length":   strconv.Itoa(len(content)),
+		"Upload-Metadata": "filename " + base64.StdEncoding.EncodeToString([]byte("field.wav")) + ",note",
+	})
+	require.Equal(t, http.StatusCreated, rec.Code)
+	id := strings.TrimPrefix(rec.Header().Get("Location"), resumableUploadsRoute)
+	require.NotEmpty(t, id)
+	assert.Equal(t, "0", rec.Header().Get("Upload-Offset"))
+
+	// The connection drops part way through the first chunk, the bytes
+	// received are kept
+	half := len(content) / 2
+	rec = chunk(id, 0, &droppedReader{data: content[:half]})
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	rec = request(controller.GetResumableUploadOffset, http.MethodHead, id, http.NoBody, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, strconv.Itoa(half), rec.Header().Get("Upload-Offset"))
+	assert.Equal(t, strconv.Itoa(len(content)), rec.Header().Get("Upload-Length"))
+
+	// The file cannot be analysed before it is complete
+	start := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v2/analysis/files", strings.NewReader(`{"uploadId":"`+id+`"}`))
+		req.Header.Set("Content-Type", echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		analyze := func(context.Context, []float32, time.Time) ([]datastore.Note, error) { return nil, nil }
+		require.NoError(t, controller.startFileAnalysis(e.NewContext(req, rec), analyze))
+		return rec
+	}
+	assert.Equal(t, http.StatusBadRequest, start().Code)
+
+	// Chunks must continue from the offset, with the offset content type
+	assert.Equal(t, http.StatusConflict, chunk(id, 0, bytes.NewReader(content)).Code)
+	rec = request(controller.AppendResumableUpload, http.MethodPatch, id, bytes.NewReader(content[half:]), map[string]string{
+		"Content-Type":  "application/octet-stream",
+		"Upload-Offset": strconv.Itoa(half),
+	})
+	assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+
+	// The upload resumes from the offset
+	rec = chunk(id, half, bytes.NewReader(content[half:]))
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, strconv.Itoa(len(content)), rec.Header().Get("Upload-Offset"))
+
+	rec = start()
+	require.Equal(t, http.StatusAccepted, rec.Code)
+	var job FileAnalysisJob
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &job))
+	assert.Equal(t, "field.wav", job.Filename)
+	require.Eventually(t, func() bool {
+		current, _ := fileAnalyses.get(job.ID)
+		return current.Status == analysisStatusCompleted
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Empty(t, resumableUploads.uploads, "the upload is taken by the analysis")
+	assert.Equal(t, http.StatusNotFound, start().Code)
+}
+
+func TestResumableUploadValidation(t *testing.T) {
+	e, _, controller := setupTestEnvironment(t)
+
+	origUploads := resumableUploads
+	resumableUploads = &resumableUploadStore{uploads: make(map[string]*resumableUpload)}
+	t.Cleanup(func() { resumableUploads = origUploads })
+
+	create := func(length, filename string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v2/analysis/uploads", http.NoBody)
+		req.Header.Set("Upload-Length", length)
+		req.Header.Set("Upload-Metadata", "filename "+base64.StdEncoding.EncodeToString([]byte(filename)))
+		rec := httptest.NewRecorder()
+		require.NoError(t, controller.CreateResumableUpload(e.NewContext(req, rec)))
+		return rec
+	}
+	assert.Equal(t, http.StatusBadRequest, create("", "dawn.wav").Code, "no length")
+	assert.Equal(t, http.StatusRequestEntityTooLarge, create(strconv.Itoa(maxAnalysisUploadSize+1), "dawn.wav").Code)
+	assert.Equal(t, http.StatusBadRequest, create("100", "dawn.mp3").Code, "unsupported format")
+
+	rec := create("4", "dawn.flac")
+	require.Equal(t, http.StatusCreated, rec.Code)
+	id := strings.TrimPrefix(rec.Header().Get("Location"), resumableUploadsRoute)
+	path := resumableUploads.uploads[id].path
+
+	// A chunk past the upload length is cut at the length
+	req := httptest.NewRequest(http.MethodPatch, "/api/v2/analysis/uploads/"+id, strings.NewReader("fLaC and more"))
+	req.Header.Set("Content-Type", tusOffsetContentType)
+	req.Header.Set("Upload-Offset", "0")
+	rec = httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues(id)
+	require.NoError(t, controller.AppendResumableUpload(c))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	assert.Equal(t, "4", rec.Header().Get("Upload-Offset"))
+
+	// Terminating the upload deletes the bytes received
+	req = httptest.NewRequest(http.MethodDelete, "/api/v2/analysis/uploads/"+id, http.NoBody)
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues(id)
+	require.NoError(t, controller.DeleteResumableUpload(c))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.NoFileExists(t, path)
+}
+
+func TestParseUploadMetadata(t *testing.T) {
+	t.Parallel()
+
+	encoded := base64.StdEncoding.EncodeToString([]byte("dawn chorus.wav"))
+	metadata := parseUploadMetadata("filename " + encoded + ", is_confidential, broken %%%")
+	assert.Equal(t, map[string]string{"filename": "dawn chorus.wav", "is_confidential": ""}, metadata)
+}
+
+// TestResumableUploadLargeChunk uploads a chunk larger than the request body
+// limit of the API group through the registered routes. It replaces the
+// package level registry so it does not run in parallel.
+func TestResumableUploadLargeChunk(t *testing.T) {
+	e, _, controller := setupTestEnvironment(t)
+	controller.initResumableUploadRoutes()
+
+	origUploads := resumableUploads
+	resumableUploads = &resumableUploadStore{uploads: make(map[string]*resumableUpload)}
+	t.Cleanup(func() { resumableUploads = origUploads })
+
+	const size = 3 << 20
+	req := httptest.NewRequest(http.MethodPost, strings.TrimSuffix(resumableUploadsRoute, "/"), http.NoBody)
+	req.Header.Set("Tus-Resumable", tusVersion)
+	req.Header.Set("Upload-Length", strconv.Itoa(size))
+	req.Header.Set("Upload-Metadata", "filename "+base64.StdEncoding.EncodeToString([]byte("field.wav")))
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	location := rec.Header().Get("Location")
+
+	req = httptest.NewRequest(http.MethodPatch, location, bytes.NewReader(make([]byte, size)))
+	req.Header.Set("Tus-Resumable", tusVersion)
+	req.Header.Set("Content-Type", tusOffsetContentType)
+	req.Header.Set("Upload-Offset", "0")
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, strconv.Itoa(size), rec.Header().Get("Upload-Offset"))
+}
@@ -0,0 +1,80 @@
+// internal/api/v2/forecast.go
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/birdnet"
+	"github.com/tphakala/birdnet-go/internal/errors"
+	"github.com/tphakala/birdnet-go/internal/forecast"
+)
+
+// maxForecastDays is the longest forecast period a request may ask for
+const maxForecastDays = 28
+
+// lockedRangeFilter serializes range filter predictions with the range
+// filter test endpoints, which change the location temporarily
+type lockedRangeFilter struct {
+	bn *birdnet.BirdNET
+}
+
+// GetProbableSpecies implements forecast.RangeFilter
+func (r lockedRangeFilter) GetProbableSpecies(date time.Time, week float32) ([]birdnet.SpeciesScore, error) {
+	rangeFilterMutex.Lock()
+	defer rangeFilterMutex.Unlock()
+	return r.bn.GetProbableSpecies(date, week)
+}
+
+// initForecastRoutes registers the species occurrence forecast endpoint
+func (c *Controller) initForecastRoutes() {
+	c.Group.GET("/forecast", c.GetSpeciesForecast)
+}
+
+// GetSpeciesForecast handles GET /api/v2/forecast
+// It returns the species to expect in the coming days (default from the
+// forecast settings, at most 28), predicted from the detections of past years
+// around the same dates and the range filter. Optional limit caps the number
+// of species returned.
+func (c *Controller) GetSpeciesForecast(ctx echo.Context) error {
+	config := forecast.ConfigFromSettings(&c.Settings.Forecast)
+	if param := ctx.QueryParam("days"); param != "" {
+		days, err := strconv.Atoi(param)
+		if err != nil || days < 1 || days > maxForecastDays {
+			return c.HandleError(ctx, errors.Newf("invalid days parameter: %s", param).
+				Component("api").
+				Category(errors.CategoryValidation).
+				Build(), "Days must be a number between 1 and 28", http.StatusBadRequest)
+		}
+		config.Days = days
+	}
+	limit := 0
+	if param := ctx.QueryParam("limit"); param != "" {
+		parsed, err := strconv.Atoi(param)
+		if err != nil || parsed < 1 {
+			return c.HandleError(ctx, errors.Newf("invalid limit parameter: %s", param).
+				Component("api").
+				Category(errors.CategoryValidation).
+				Build(), "Limit must be a positive number", http.StatusBadRequest)
+		}
+		limit = parsed
+	}
+
+	var rangeFilter forecast.RangeFilter
+	if c.Processor != nil {
+		if bn := c.Processor.GetBirdNET(); bn != nil {
+			rangeFilter = lockedRangeFilter{bn: bn}
+		}
+	}
+
+	result, err := forecast.New(config, c.DS, rangeFilter).Predict(ctx.Request().Context(), time.Now())
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to forecast species", http.StatusInternalServerError)
+	}
+	if limit > 0 && len(result.Species) > limit {
+		result.Species = result.Species[:limit]
+	}
+	return ctx.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/forecast"
+)
+
+// forecastMockDataStore returns the same species for every past year
+type forecastMockDataStore struct {
+	*MockDataStore
+}
+
+func (m *forecastMockDataStore) GetSpeciesSummaryData(ctx context.Context, startDate, endDate string) ([]datastore.SpeciesSummaryData, error) {
+	return []datastore.SpeciesSummaryData{
+		{ScientificName: "Parus major", CommonName: "Great Tit", Count: 10},
+		{ScientificName: "Sitta europaea", CommonName: "Eurasian Nuthatch", Count: 2},
+	}, nil
+}
+
+func TestGetSpeciesForecast(t *testing.T) {
+	t.Parallel()
+
+	_, mockDS, controller := setupTestEnvironment(t)
+	controller.DS = &forecastMockDataStore{MockDataStore: mockDS}
+	controller.Settings.Forecast.Days = 7
+	controller.Settings.Forecast.Years = 2
+
+	get := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v2/forecast?"+query, http.NoBody)
+		rec := httptest.NewRecorder()
+		require.NoError(t, controller.GetSpeciesForecast(controller.Echo.NewContext(req, rec)))
+		return rec
+	}
+
+	rec := get("days=3&limit=1")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp forecast.Forecast
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, 2, resp.YearsWithData)
+	assert.False(t, resp.RangeFilter, "no BirdNET instance in tests")
+	require.Len(t, resp.Species, 1)
+	assert.Equal(t, "Great Tit", resp.Species[0].CommonName)
+
+	assert.Equal(t, http.StatusBadRequest, get("days=60").Code)
+	assert.Equal(t, http.StatusBadRequest, get("limit=none").Code)
+	mockDS.AssertNotCalled(t, "GetSpeciesSummaryData", mock.Anything, mock.Anything, mock.Anything)
+}
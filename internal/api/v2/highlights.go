@@ -0,0 +1,536 @@
+// internal/api/v2/highlights.go
+package api
+
+import (
+	"archive/zip"
+	"cmp"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// Highlights compilation limits and defaults
+const (
+	defaultHighlightClips   = 10
+	maxHighlightClips       = 50
+	maxHighlightCandidates  = 2000 // detections looked at when picking the best clips
+	maxHighlightCrossfade   = 5.0  // seconds
+	defaultHighlightsFormat = "mp3"
+	highlightSampleRate     = 48000
+)
+
+// highlightFormats maps the supported output formats to their FFmpeg encoder arguments
+var highlightFormats = map[string][]string{
+	"mp3":  {"-c:a", "libmp3lame", "-q:a", "2"},
+	"m4a":  {"-c:a", "aac", "-b:a", "192k"},
+	"flac": {"-c:a", "flac"},
+}
+
+// HighlightsRequest selects the clips of a highlights compilation: the best
+// clips of a species, the best clip of each species detected on a day, or the
+// best clips of a species on a day. Detections reviewed as false positives
+// are left out.
+type HighlightsRequest struct {
+	Species       string  `json:"species"`       // scientific name or species code
+	Date          string  `json:"date"`          // YYYY-MM-DD
+	Clips         int     `json:"clips"`         // default 10, at most 50
+	MinConfidence float64 `json:"minConfidence"` // percent
+	Crossfade     float64 `json:"crossfade"`     // seconds of crossfade between clips, 0 to join them directly
+	Format        string  `json:"format"`        // mp3, m4a or flac, default mp3
+}
+
+// HighlightChapter is a clip of a highlights compilation
+type HighlightChapter struct {
+	Index          int     `json:"index"`
+	DetectionID    uint    `json:"detectionId"`
+	Start          float64 `json:"start"` // seconds from the start of the compilation
+	End            float64 `json:"end"`
+	ScientificName string  `json:"scientificName"`
+	CommonName     string  `json:"commonName"`
+	Date           string  `json:"date"`
+	Time           string  `json:"time"`
+	Confidence     float64 `json:"confidence"`
+}
+
+// HighlightsManifest describes a highlights compilation and its chapters
+type HighlightsManifest struct {
+	Title     string             `json:"title"`
+	Species   string             `json:"species,omitempty"`
+	Date      string             `json:"date,omitempty"`
+	Format    string             `json:"format"`
+	Crossfade float64            `json:"crossfade"`
+	Duration  float64            `json:"duration"` // seconds
+	Chapters  []HighlightChapter `json:"chapters"`
+}
+
+var highlightCompilations = &exportJobs{jobs: make(map[string]*ExportJob)}
+
+// prepareHighlightClip converts an audio clip to a mono 48 kHz WAV file,
+// replaceable in tests
+var prepareHighlightClip = prepareHighlightClipFFmpeg
+
+// encodeHighlights joins the prepared clips into the compilation, replaceable
+// in tests
+var encodeHighlights = encodeHighlightsFFmpeg
+
+// initHighlightsRoutes registers the highlights compilation endpoints
+func (c *Controller) initHighlightsRoutes() {
+	highlightsGroup := c.Group.Group("/highlights", c.getEffectiveAuthMiddleware())
+	highlightsGroup.POST("", c.StartHighlightsCompilation)
+	highlightsGroup.GET("/:id", c.GetHighlightsCompilation)
+	highlightsGroup.GET("/:id/download", c.DownloadHighlightsCompilation)
+}
+
+// StartHighlightsCompilation handles POST /api/v2/highlights
+// It starts joining the best clips of a species or a day into a single audio
+// file in the background. The zip of the audio file, with its chapters
+// embedded, and a chapters.json manifest can be downloaded once the job has
+// completed. Only one compilation runs at a time.
+func (c *Controller) StartHighlightsCompilation(ctx echo.Context) error {
+	var req HighlightsRequest
+	if err := ctx.Bind(&req); err != nil {
+		return c.HandleError(ctx, err, "Invalid request body", http.StatusBadRequest)
+	}
+	if err := req.normalize(); err != nil {
+		return c.HandleError(ctx, err, err.Error(), http.StatusBadRequest)
+	}
+
+	notes, err := c.highlightNotes(&req)
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to select detections", http.StatusInternalServerError)
+	}
+	if len(notes) == 0 {
+		return c.HandleError(ctx, errors.Newf("no detections with clips match the highlights filters").
+			Component("api").
+			Category(errors.CategoryNotFound).
+			Build(), "No detections with audio clips match the filters", http.StatusNotFound)
+	}
+
+	job, ok := highlightCompilations.start(len(notes))
+	if !ok {
+		return c.HandleError(ctx, errors.Newf("a highlights compilation is already running").
+			Component("api").
+			Category(errors.CategoryConflict).
+			Build(), "Another highlights compilation is still running", http.StatusConflict)
+	}
+
+	jobCtx := c.ctx
+	if jobCtx == nil {
+		jobCtx = context.Background()
+	}
+	c.wg.Go(func() {
+		c.runHighlightsCompilation(jobCtx, job.ID, notes, &req)
+	})
+
+	snapshot, _ := highlightCompilations.get(job.ID)
+	return ctx.JSON(http.StatusAccepted, snapshot)
+}
+
+// GetHighlightsCompilation handles GET /api/v2/highlights/:id
+func (c *Controller) GetHighlightsCompilation(ctx echo.Context) error {
+	job, ok := highlightCompilations.get(ctx.Param("id"))
+	if !ok {
+		return c.HandleError(ctx, errors.Newf("highlights compilation %s not found", ctx.Param("id")).
+			Component("api").
+			Category(errors.CategoryNotFound).
+			Build(), "Highlights compilation not found", http.StatusNotFound)
+	}
+	return ctx.JSON(http.StatusOK, job)
+}
+
+// DownloadHighlightsCompilation handles GET /api/v2/highlights/:id/download
+func (c *Controller) DownloadHighlightsCompilation(ctx echo.Context) error {
+	job, ok := highlightCompilations.get(ctx.Param("id"))
+	if !ok || job.Status != exportStatusCompleted {
+		return c.HandleError(ctx, errors.Newf("highlights compilation %s not available", ctx.Param("id")).
+			Component("api").
+			Category(errors.CategoryNotFound).
+			Build(), "Highlights compilation not found or not completed", http.StatusNotFound)
+	}
+	return ctx.Attachment(job.path, fmt.Sprintf("highlights-%s.zip", job.CreatedAt.Format("20060102-150405")))
+}
+
+// normalize applies defaults and validates the request
+func (req *HighlightsRequest) normalize() error {
+	invalid := func(format string, args ...any) error {
+		return errors.Newf(format, args...).
+			Component("api").
+			Category(errors.CategoryValidation).
+			Build()
+	}
+
+	req.Species = strings.TrimSpace(req.Species)
+	if req.Species == "" && req.Date == "" {
+		return invalid("species or date is required")
+	}
+	if _, err := time.Parse(time.DateOnly, req.Date); req.Date != "" && err != nil {
+		return invalid("date must use the YYYY-MM-DD format")
+	}
+	if req.Clips == 0 {
+		req.Clips = defaultHighlightClips
+	}
+	if req.Clips < 1 || req.Clips > maxHighlightClips {
+		return invalid("clips must be between 1 and %d", maxHighlightClips)
+	}
+	if req.MinConfidence < 0 || req.MinConfidence > 100 {
+		return invalid("minConfidence must be a percentage between 0 and 100")
+	}
+	if req.Crossfade < 0 || req.Crossfade > maxHighlightCrossfade {
+		return invalid("crossfade must be between 0 and %g seconds", maxHighlightCrossfade)
+	}
+	req.Format = cmp.Or(strings.ToLower(req.Format), defaultHighlightsFormat)
+	if _, ok := highlightFormats[req.Format]; !ok {
+		return invalid("format must be mp3, m4a or flac")
+	}
+	return nil
+}
+
+// highlightNotes returns the detections of a compilation. The best clips of a
+// species are ordered by confidence; a day without a species contributes the
+// best clip of each species, in the order they were heard.
+func (c *Controller) highlightNotes(req *HighlightsRequest) ([]datastore.Note, error) {
+	var species []string
+	if req.Species != "" {
+		species = []string{req.Species}
+	}
+	filters := c.exportSearchFilters(species, req.Date, req.Date, req.MinConfidence, nil, maxHighlightCandidates)
+	notes, _, err := c.DS.SearchNotesAdvanced(&filters)
+	if err != nil {
+		return nil, err
+	}
+	return selectHighlights(notes, req), nil
+}
+
+// selectHighlights picks the clips of a compilation from the candidate detections
+func selectHighlights(notes []datastore.Note, req *HighlightsRequest) []datastore.Note {
+	candidates := slices.DeleteFunc(notes, func(note datastore.Note) bool {
+		verified := note.Verified
+		if verified == "" && note.Review != nil {
+			verified = note.Review.Verified
+		}
+		return note.ClipName == "" || verified == "false_positive"
+	})
+	slices.SortStableFunc(candidates, func(a, b datastore.Note) int {
+		return cmp.Compare(b.Confidence, a.Confidence)
+	})
+
+	if req.Species == "" {
+		seen := make(map[string]bool)
+		candidates = slices.DeleteFunc(candidates, func(note datastore.Note) bool {
+			if seen[note.ScientificName] {
+				return true
+			}
+			seen[note.ScientificName] = true
+			return false
+		})
+	}
+	candidates = candidates[:min(len(candidates), req.Clips)]
+
+	if req.Date != "" && req.Species == "" {
+		slices.SortStableFunc(candidates, func(a, b datastore.Note) int {
+			return cmp.Compare(a.Time, b.Time)
+		})
+	}
+	return candidates
+}
+
+// highlightsTitle returns the title of a compilation
+func highlightsTitle(notes []datastore.Note, req *HighlightsRequest) string {
+	switch {
+	case req.Species != "" && req.Date != "":
+		return fmt.Sprintf("%s highlights of %s", notes[0].CommonName, req.Date)
+	case req.Species != "":
+		return notes[0].CommonName + " highlights"
+	default:
+		return "Highlights of " + req.Date
+	}
+}
+
+// highlightChapters places clips of the given durations one after the other,
+// each overlapping the previous one by the crossfade, and returns the
+// chapters with the total duration
+func highlightChapters(notes []datastore.Note, durations []float64, crossfade float64) ([]HighlightChapter, float64) {
+	chapters := make([]HighlightChapter, 0, len(notes))
+	start := 0.0
+	for i := range notes {
+		chapters = append(chapters, HighlightChapter{
+			Index:          i + 1,
+			DetectionID:    notes[i].ID,
+			Start:          start,
+			End:            start + durations[i],
+			ScientificName: notes[i].ScientificName,
+			CommonName:     notes[i].CommonName,
+			Date:           notes[i].Date,
+			Time:           notes[i].Time,
+			Confidence:     notes[i].Confidence,
+		})
+		start += durations[i] - crossfade
+	}
+	if len(chapters) == 0 {
+		return chapters, 0
+	}
+	return chapters, chapters[len(chapters)-1].End
+}
+
+// runHighlightsCompilation writes the zip file of a compilation
+func (c *Controller) runHighlightsCompilation(ctx context.Context, jobID string, notes []datastore.Note, req *HighlightsRequest) {
+	zipPath := filepath.Join(os.TempDir(), fmt.Sprintf("birdnet-go-highlights-%s.zip", jobID))
+	err := c.writeHighlightsCompilation(ctx, jobID, zipPath, notes, req)
+	downloadURL := fmt.Sprintf("/api/v2/highlights/%s/download", jobID)
+	var checksum string
+	if err == nil {
+		checksum = c.recordExport(zipPath, downloadURL)
+	}
+	highlightCompilations.update(jobID, func(job *ExportJob) {
+		if err != nil {
+			job.Status = exportStatusFailed
+			job.Error = err.Error()
+			return
+		}
+		job.Status = exportStatusCompleted
+		job.path = zipPath
+		job.DownloadURL = downloadURL
+		job.SHA256 = checksum
+	})
+	if err != nil {
+		_ = os.Remove(zipPath)
+		if c.apiLogger != nil {
+			c.apiLogger.Error("Highlights compilation failed", "job_id", jobID, "error", err)
+		}
+	}
+}
+
+// writeHighlightsCompilation prepares the clips of a compilation, joins them
+// and writes the audio file with a chapters.json manifest into a zip file.
+// Clips that cannot be read are left out.
+func (c *Controller) writeHighlightsCompilation(ctx context.Context, jobID, zipPath string, notes []datastore.Note, req *HighlightsRequest) error {
+	workDir, err := os.MkdirTemp("", "birdnet-go-highlights-")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.RemoveAll(workDir) }()
+
+	var included []datastore.Note
+	var clips []string
+	var durations []float64
+	for i := range notes {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		clipPath := filepath.Join(workDir, fmt.Sprintf("%d.wav", i))
+		duration, prepareErr := c.prepareHighlightNote(ctx, &notes[i], clipPath)
+		highlightCompilations.update(jobID, func(job *ExportJob) {
+			if prepareErr != nil {
+				job.Failed++
+			} else {
+				job.Rendered++
+			}
+		})
+		if prepareErr == nil {
+			included = append(included, notes[i])
+			clips = append(clips, clipPath)
+			durations = append(durations, duration)
+		}
+	}
+	if len(clips) == 0 {
+		return errors.Newf("none of the %d clips could be read", len(notes)).
+			Component("api").
+			Category(errors.CategoryFileIO).
+			Build()
+	}
+
+	// A crossfade cannot be longer than the clips it joins
+	crossfade := req.Crossfade
+	if len(clips) > 1 {
+		crossfade = min(crossfade, slices.Min(durations)/2)
+	} else {
+		crossfade = 0
+	}
+	chapters, duration := highlightChapters(included, durations, crossfade)
+	manifest := HighlightsManifest{
+		Title:     highlightsTitle(included, req),
+		Species:   req.Species,
+		Date:      req.Date,
+		Format:    req.Format,
+		Crossfade: crossfade,
+		Duration:  duration,
+		Chapters:  chapters,
+	}
+
+	metadataPath := filepath.Join(workDir, "chapters.txt")
+	if err := os.WriteFile(metadataPath, []byte(highlightsMetadata(&manifest)), 0o600); err != nil {
+		return err
+	}
+	audioName := "highlights." + req.Format
+	audioPath := filepath.Join(workDir, audioName)
+	if err := encodeHighlights(ctx, c.Settings, clips, metadataPath, audioPath, crossfade, req.Format); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(zipPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = file.Close() }()
+	archive := zip.NewWriter(file)
+	if err := addFileToZip(archive, audioPath, audioName); err != nil {
+		return err
+	}
+	w, err := archive.Create("chapters.json")
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(&manifest); err != nil {
+		return err
+	}
+	if err := archive.Close(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// prepareHighlightNote converts the clip of one detection and returns its duration
+func (c *Controller) prepareHighlightNote(ctx context.Context, note *datastore.Note, clipPath string) (float64, error) {
+	absAudioPath, cleanup, err := c.exportClipPath(note)
+	if err != nil {
+		return 0, err
+	}
+	defer cleanup()
+	if err := prepareHighlightClip(ctx, c.Settings, absAudioPath, clipPath); err != nil {
+		return 0, err
+	}
+	return wavDuration(clipPath)
+}
+
+// wavDuration returns the length of a WAV file in seconds from the byte rate
+// of its fmt chunk and the size of its data chunk
+func wavDuration(path string) (float64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = file.Close() }()
+
+	var riff [12]byte
+	if _, err := io.ReadFull(file, riff[:]); err != nil || string(riff[0:4]) != "RIFF" || string(riff[8:12]) != "WAVE" {
+		return 0, fmt.Errorf("clip is not a WAV file")
+	}
+	var byteRate uint32
+	for {
+		var header [8]byte
+		if _, err := io.ReadFull(file, header[:]); err != nil {
+			return 0, fmt.Errorf("WAV file has no data chunk")
+		}
+		size := int64(binary.LittleEndian.Uint32(header[4:8]))
+		switch string(header[0:4]) {
+		case "fmt ":
+			var format [16]byte
+			if size < 16 {
+				return 0, fmt.Errorf("WAV file has an invalid fmt chunk")
+			}
+			if _, err := io.ReadFull(file, format[:]); err != nil {
+				return 0, err
+			}
+			byteRate = binary.LittleEndian.Uint32(format[8:12])
+			size -= 16
+		case "data":
+			if byteRate == 0 || size == 0 {
+				return 0, fmt.Errorf("clip is empty")
+			}
+			return float64(size) / float64(byteRate), nil
+		}
+		if _, err := file.Seek(size+size%2, io.SeekCurrent); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// highlightsMetadata returns the FFmpeg metadata file with the title and
+// chapters of a compilation
+func highlightsMetadata(manifest *HighlightsManifest) string {
+	escape := strings.NewReplacer(`\`, `\\`, "=", `\=`, ";", `\;`, "#", `\#`, "\n", `\`+"\n").Replace
+	var b strings.Builder
+	b.WriteString(";FFMETADATA1\n")
+	fmt.Fprintf(&b, "title=%s\n", escape(manifest.Title))
+	for i := range manifest.Chapters {
+		chapter := &manifest.Chapters[i]
+		end := chapter.End
+		if i+1 < len(manifest.Chapters) {
+			end = manifest.Chapters[i+1].Start // chapters must not overlap
+		}
+		title := fmt.Sprintf("%s, %s %s (%.0f%%)", chapter.CommonName, chapter.Date, chapter.Time, chapter.Confidence*100)
+		fmt.Fprintf(&b, "[CHAPTER]\nTIMEBASE=1/1000\nSTART=%d\nEND=%d\ntitle=%s\n",
+			int64(chapter.Start*1000), int64(end*1000), escape(title))
+	}
+	return b.String()
+}
+
+// prepareHighlightClipFFmpeg converts an audio clip to a mono 48 kHz WAV file
+func prepareHighlightClipFFmpeg(ctx context.Context, settings *conf.Settings, absAudioPath, absClipPath string) error {
+	return runExportFFmpeg(ctx, settings, []string{
+		"-hide_banner", "-y", "-i", absAudioPath,
+		"-ac", "1", "-ar", strconv.Itoa(highlightSampleRate),
+		"-c:a", "pcm_s16le", absClipPath,
+	})
+}
+
+// encodeHighlightsFFmpeg joins the prepared clips into the compilation
+func encodeHighlightsFFmpeg(ctx context.Context, settings *conf.Settings, clips []string, metadataPath, outputPath string, crossfade float64, format string) error {
+	return runExportFFmpeg(ctx, settings, highlightsArgs(clips, metadataPath, outputPath, crossfade, format))
+}
+
+// highlightsArgs returns the FFmpeg arguments joining clips, with crossfades
+// when crossfade is above zero, and embedding the chapters of the metadata file
+func highlightsArgs(clips []string, metadataPath, outputPath string, crossfade float64, format string) []string {
+	args := []string{"-hide_banner", "-y"}
+	for _, clip := range clips {
+		args = append(args, "-i", clip)
+	}
+	args = append(args, "-i", metadataPath)
+
+	var filter strings.Builder
+	switch {
+	case len(clips) == 1:
+		filter.WriteString("[0:a]anull[out]")
+	case crossfade > 0:
+		previous := "[0:a]"
+		for i := 1; i < len(clips); i++ {
+			label := fmt.Sprintf("[x%d]", i)
+			if i == len(clips)-1 {
+				label = "[out]"
+			}
+			fmt.Fprintf(&filter, "%s[%d:a]acrossfade=d=%s:c1=tri:c2=tri%s;", previous, i, strconv.FormatFloat(crossfade, 'f', 3, 64), label)
+			previous = label
+		}
+	default:
+		for i := range clips {
+			fmt.Fprintf(&filter, "[%d:a]", i)
+		}
+		fmt.Fprintf(&filter, "concat=n=%d:v=0:a=1[out]", len(clips))
+	}
+
+	args = append(args,
+		"-filter_complex", strings.TrimSuffix(filter.String(), ";"),
+		"-map", "[out]",
+		"-map_metadata", strconv.Itoa(len(clips)),
+		"-map_chapters", strconv.Itoa(len(clips)),
+	)
+	args = append(args, highlightFormats[format]...)
+	return append(args, outputPath)
+}
@@ -0,0 +1,191 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+func TestHighlightsRequestNormalize(t *testing.T) {
+	t.Parallel()
+
+	req := HighlightsRequest{Species: " Turdus merula ", Format: "FLAC"}
+	require.NoError(t, req.normalize())
+	assert.Equal(t, "Turdus merula", req.Species)
+	assert.Equal(t, defaultHighlightClips, req.Clips)
+	assert.Equal(t, "flac", req.Format)
+
+	invalid := map[string]HighlightsRequest{
+		"no selection": {},
+		"date":         {Date: "01.05.2024"},
+		"clips":        {Date: "2024-05-01", Clips: maxHighlightClips + 1},
+		"confidence":   {Date: "2024-05-01", MinConfidence: 120},
+		"crossfade":    {Date: "2024-05-01", Crossfade: 10},
+		"format":       {Date: "2024-05-01", Format: "wav"},
+	}
+	for name, req := range invalid {
+		assert.Error(t, req.normalize(), name)
+	}
+}
+
+func TestSelectHighlights(t *testing.T) {
+	t.Parallel()
+
+	notes := func() []datastore.Note {
+		return []datastore.Note{
+			{ID: 1, ScientificName: "Turdus merula", Time: "08:00:00", Confidence: 0.80, ClipName: "1.wav"},
+			{ID: 2, ScientificName: "Turdus merula", Time: "06:00:00", Confidence: 0.95, ClipName: "2.wav"},
+			{ID: 3, ScientificName: "Pica pica", Time: "07:00:00", Confidence: 0.70, ClipName: "3.wav"},
+			{ID: 4, ScientificName: "Strix aluco", Time: "05:00:00", Confidence: 0.99, ClipName: "4.wav", Review: &datastore.NoteReview{Verified: "false_positive"}},
+			{ID: 5, ScientificName: "Sitta europaea", Time: "04:00:00", Confidence: 0.99},
+		}
+	}
+	ids := func(notes []datastore.Note) []uint {
+		var ids []uint
+		for i := range notes {
+			ids = append(ids, notes[i].ID)
+		}
+		return ids
+	}
+
+	day := selectHighlights(notes(), &HighlightsRequest{Date: "2024-05-01", Clips: 10})
+	assert.Equal(t, []uint{2, 3}, ids(day), "best clip of each species in the order heard, without false positives or missing clips")
+
+	species := selectHighlights(notes(), &HighlightsRequest{Species: "Turdus merula", Clips: 10})
+	assert.Equal(t, []uint{2, 1, 3}, ids(species), "best clips first")
+
+	limited := selectHighlights(notes(), &HighlightsRequest{Species: "Turdus merula", Clips: 1})
+	assert.Equal(t, []uint{2}, ids(limited))
+}
+
+func TestHighlightChapters(t *testing.T) {
+	t.Parallel()
+
+	notes := []datastore.Note{
+		{ID: 1, CommonName: "Eurasian Blackbird", Date: "2024-05-01", Time: "06:00:00", Confidence: 0.9},
+		{ID: 2, CommonName: "Eurasian Magpie", Date: "2024-05-01", Time: "07:00:00", Confidence: 0.8},
+		{ID: 3, CommonName: "Tawny Owl", Date: "2024-05-01", Time: "23:00:00", Confidence: 0.7},
+	}
+	chapters, duration := highlightChapters(notes, []float64{15, 15, 10}, 2)
+	require.Len(t, chapters, 3)
+	assert.InDelta(t, 13.0, chapters[1].Start, 1e-9)
+	assert.InDelta(t, 28.0, chapters[1].End, 1e-9)
+	assert.InDelta(t, 26.0, chapters[2].Start, 1e-9)
+	assert.InDelta(t, 36.0, duration, 1e-9)
+
+	metadata := highlightsMetadata(&HighlightsManifest{Title: "Highlights of 2024-05-01; dawn=chorus", Chapters: chapters})
+	assert.True(t, strings.HasPrefix(metadata, ";FFMETADATA1\n"))
+	assert.Contains(t, metadata, `title=Highlights of 2024-05-01\; dawn\=chorus`)
+	assert.Contains(t, metadata, "START=13000\nEND=26000\ntitle=Eurasian Magpie, 2024-05-01 07:00:00 (80%)", "chapters end where the next one starts")
+	assert.Equal(t, 3, strings.Count(metadata, "[CHAPTER]"))
+}
+
+func TestHighlightsArgs(t *testing.T) {
+	t.Parallel()
+
+	args := strings.Join(highlightsArgs([]string{"a.wav", "b.wav", "c.wav"}, "chapters.txt", "out.mp3", 1.5, "mp3"), " ")
+	assert.Contains(t, args, "[0:a][1:a]acrossfade=d=1.500:c1=tri:c2=tri[x1];[x1][2:a]acrossfade=d=1.500:c1=tri:c2=tri[out]")
+	assert.Contains(t, args, "-map_metadata 3 -map_chapters 3")
+	assert.Contains(t, args, "libmp3lame")
+
+	args = strings.Join(highlightsArgs([]string{"a.wav", "b.wav"}, "chapters.txt", "out.flac", 0, "flac"), " ")
+	assert.Contains(t, args, "[0:a][1:a]concat=n=2:v=0:a=1[out]")
+	assert.True(t, strings.HasSuffix(args, "-c:a flac out.flac"))
+}
+
+// TestHighlightsCompilation runs a compilation with fake FFmpeg steps. It
+// replaces package level functions and the job registry so it does not run
+// in parallel.
+func TestHighlightsCompilation(t *testing.T) {
+	e, controller, tempDir := setupMediaTestEnvironment(t)
+	mockDS := controller.DS.(*MockDataStore)
+
+	require.NoError(t, createTestAudioFile(t, filepath.Join(tempDir, "blackbird.wav")))
+	require.NoError(t, createTestAudioFile(t, filepath.Join(tempDir, "magpie.wav")))
+	notes := []datastore.Note{
+		{ID: 1, Date: "2024-05-01", Time: "06:10:00", ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", Confidence: 0.9, ClipName: "blackbird.wav"},
+		{ID: 2, Date: "2024-05-01", Time: "06:20:00", ScientificName: "Pica pica", CommonName: "Eurasian Magpie", Confidence: 0.8, ClipName: "magpie.wav"},
+		{ID: 3, Date: "2024-05-01", Time: "06:30:00", ScientificName: "Strix aluco", CommonName: "Tawny Owl", Confidence: 0.7, ClipName: "missing.wav"},
+	}
+	mockDS.On("SearchNotesAdvanced", mock.MatchedBy(func(f *datastore.AdvancedSearchFilters) bool {
+		return f.DateRange != nil && f.Limit == maxHighlightCandidates
+	})).Return(notes, int64(len(notes)), nil)
+
+	var encodedCrossfade float64
+	origPrepare, origEncode, origJobs := prepareHighlightClip, encodeHighlights, highlightCompilations
+	prepareHighlightClip = func(_ context.Context, _ *conf.Settings, absAudioPath, absClipPath string) error {
+		if _, err := os.Stat(absAudioPath); err != nil {
+			return err
+		}
+		return createTestAudioFile(t, absClipPath)
+	}
+	encodeHighlights = func(_ context.Context, _ *conf.Settings, clips []string, metadataPath, outputPath string, crossfade float64, format string) error {
+		encodedCrossfade = crossfade
+		return os.WriteFile(outputPath, []byte(format), 0o600)
+	}
+	highlightCompilations = &exportJobs{jobs: make(map[string]*ExportJob)}
+	t.Cleanup(func() {
+		prepareHighlightClip, encodeHighlights, highlightCompilations = origPrepare, origEncode, origJobs
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/highlights", strings.NewReader(`{"date":"2024-05-01","crossfade":1}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	require.NoError(t, controller.StartHighlightsCompilation(e.NewContext(req, rec)))
+	require.Equal(t, http.StatusAccepted, rec.Code)
+	var job ExportJob
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &job))
+	assert.Equal(t, 3, job.Total)
+
+	require.Eventually(t, func() bool {
+		current, _ := highlightCompilations.get(job.ID)
+		return current.Status != exportStatusRunning
+	}, 5*time.Second, 10*time.Millisecond)
+	job, _ = highlightCompilations.get(job.ID)
+	require.Equal(t, exportStatusCompleted, job.Status, job.Error)
+	assert.Equal(t, 2, job.Rendered)
+	assert.Equal(t, 1, job.Failed, "the missing clip is left out")
+	assert.InDelta(t, 0.05, encodedCrossfade, 1e-9, "the crossfade is limited to half of the shortest clip")
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v2/highlights/"+job.ID+"/download", http.NoBody)
+	rec = httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues(job.ID)
+	require.NoError(t, controller.DownloadHighlightsCompilation(c))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	archive, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+	require.NoError(t, err)
+	files := make(map[string][]byte)
+	for _, f := range archive.File {
+		r, err := f.Open()
+		require.NoError(t, err)
+		files[f.Name], err = io.ReadAll(r)
+		require.NoError(t, err)
+		_ = r.Close()
+	}
+	assert.Equal(t, "mp3", string(files["highlights.mp3"]))
+	var manifest HighlightsManifest
+	require.NoError(t, json.Unmarshal(files["chapters.json"], &manifest))
+	assert.Equal(t, "Highlights of 2024-05-01", manifest.Title)
+	require.Len(t, manifest.Chapters, 2)
+	assert.Equal(t, uint(2), manifest.Chapters[1].DetectionID)
+	assert.InDelta(t, 0.05, manifest.Chapters[1].Start, 1e-9)
+	assert.InDelta(t, 0.15, manifest.Duration, 1e-9)
+}
@@ -0,0 +1,169 @@
+// internal/api/v2/idempotency.go
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/api/v2/auth"
+	"github.com/tphakala/birdnet-go/internal/errors"
+	"github.com/tphakala/birdnet-go/internal/security"
+)
+
+// Idempotency keys let clients retry a mutating request without applying it
+// twice. The first response to a key is cached and replayed for retries with
+// the same key, method, path, caller and body.
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotentReplayedHeader  = "Idempotent-Replayed"
+	idempotencyTTL            = 24 * time.Hour
+	maxIdempotencyKeyLength   = 255
+	maxIdempotentResponseSize = 1024 * 1024
+)
+
+// idempotentResponse is the cached outcome of the first request with a key
+type idempotentResponse struct {
+	fingerprint string // hash of the request query and body
+	done        bool   // false while the first request is in flight
+	status      int
+	header      http.Header
+	body        []byte
+}
+
+// responseRecorder copies a response while it is written
+type responseRecorder struct {
+	http.ResponseWriter
+	body     bytes.Buffer
+	overflow bool
+}
+
+// Write implements http.ResponseWriter
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if !r.overflow {
+		if r.body.Len()+len(b) > maxIdempotentResponseSize {
+			r.overflow = true
+			r.body.Reset()
+		} else {
+			r.body.Write(b)
+		}
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher
+func (r *responseRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// idempotencyCaller identifies the principal a request was authenticated as:
+// the API key or the session user, so a retry matches after the session
+// cookie is rotated. Bearer tokens carry no user and are identified by the
+// token itself, unauthenticated callers by their address.
+func idempotencyCaller(ctx echo.Context) string {
+	if key, ok := ctx.Get(auth.APIKeyContextKey).(security.APIKey); ok {
+		return "apikey:" + key.ID
+	}
+	if username := currentUsername(ctx); username != "" {
+		return "user:" + username
+	}
+	if header := ctx.Request().Header.Get(echo.HeaderAuthorization); header != "" {
+		return "token:" + header
+	}
+	return "ip:" + ctx.RealIP()
+}
+
+// idempotencyCacheKey scopes a key to the caller, method and path, so one
+// caller cannot replay the response of another.
+func idempotencyCacheKey(ctx echo.Context, key string) string {
+	req := ctx.Request()
+	sum := sha256.Sum256([]byte(idempotencyCaller(ctx) + "\x00" + req.Method + " " + req.URL.Path + "\x00" + key))
+	return "idempotency:" + hex.EncodeToString(sum[:])
+}
+
+// IdempotencyMiddleware makes a mutating endpoint safe to retry. Requests with
+// an Idempotency-Key header run once; retries get the stored response with an
+// Idempotent-Replayed header. Reusing a key with a different body fails with
+// 422, a retry while the first request still runs with 409. Server errors are
+// not stored so the request can be retried. Attach it after authentication.
+func (c *Controller) IdempotencyMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		key := ctx.Request().Header.Get(IdempotencyKeyHeader)
+		if key == "" {
+			return next(ctx)
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			return c.HandleError(ctx, errors.Newf("idempotency key longer than %d characters", maxIdempotencyKeyLength).
+				Component("api").
+				Category(errors.CategoryValidation).
+				Build(), "Invalid Idempotency-Key header", http.StatusBadRequest)
+		}
+
+		body, err := io.ReadAll(ctx.Request().Body)
+		if err != nil {
+			return c.HandleError(ctx, err, "Failed to read request body", http.StatusBadRequest)
+		}
+		ctx.Request().Body = io.NopCloser(bytes.NewReader(body))
+		sum := sha256.Sum256(append([]byte(ctx.Request().URL.RawQuery+"\x00"), body...))
+		fingerprint := hex.EncodeToString(sum[:])
+
+		cacheKey := idempotencyCacheKey(ctx, key)
+		if err := c.idempotencyCache.Add(cacheKey, &idempotentResponse{fingerprint: fingerprint}, idempotencyTTL); err != nil {
+			return c.replayIdempotentResponse(ctx, cacheKey, fingerprint)
+		}
+
+		recorder := &responseRecorder{ResponseWriter: ctx.Response().Writer}
+		ctx.Response().Writer = recorder
+		err = next(ctx)
+		ctx.Response().Writer = recorder.ResponseWriter
+
+		res := ctx.Response()
+		if err != nil || !res.Committed || res.Status >= http.StatusInternalServerError || recorder.overflow {
+			c.idempotencyCache.Delete(cacheKey)
+			return err
+		}
+		c.idempotencyCache.Set(cacheKey, &idempotentResponse{
+			fingerprint: fingerprint,
+			done:        true,
+			status:      res.Status,
+			header:      res.Header().Clone(),
+			body:        recorder.body.Bytes(),
+		}, idempotencyTTL)
+		return nil
+	}
+}
+
+// replayIdempotentResponse answers a retry with the stored response
+func (c *Controller) replayIdempotentResponse(ctx echo.Context, cacheKey, fingerprint string) error {
+	cached, found := c.idempotencyCache.Get(cacheKey)
+	stored, ok := cached.(*idempotentResponse)
+	if found && ok && stored.fingerprint != fingerprint {
+		return c.HandleError(ctx, errors.Newf("idempotency key reused with a different request").
+			Component("api").
+			Category(errors.CategoryValidation).
+			Build(), "Idempotency-Key was already used for a different request", http.StatusUnprocessableEntity)
+	}
+	if !found || !ok || !stored.done {
+		return c.HandleError(ctx, errors.Newf("request with the same idempotency key is in progress").
+			Component("api").
+			Category(errors.CategoryConflict).
+			Build(), "A request with this Idempotency-Key is still being processed", http.StatusConflict)
+	}
+
+	c.logAPIRequest(ctx, slog.LevelInfo, "Replayed idempotent response", "status", stored.status)
+	header := ctx.Response().Header()
+	for name, values := range stored.header {
+		header[name] = values
+	}
+	header.Set(IdempotentReplayedHeader, "true")
+	ctx.Response().WriteHeader(stored.status)
+	_, err := ctx.Response().Write(stored.body)
+	return err
+}
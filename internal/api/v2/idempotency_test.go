@@ -0,0 +1,141 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/api/v2/auth"
+	"github.com/tphakala/birdnet-go/internal/security"
+)
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	t.Parallel()
+
+	_, _, controller := setupTestEnvironment(t)
+	calls := 0
+	handler := controller.IdempotencyMiddleware(func(ctx echo.Context) error {
+		calls++
+		var body map[string]any
+		if err := ctx.Bind(&body); err != nil {
+			return err
+		}
+		if body["fail"] == true {
+			return ctx.JSON(http.StatusInternalServerError, map[string]int{"call": calls})
+		}
+		return ctx.JSON(http.StatusCreated, map[string]int{"call": calls})
+	})
+
+	post := func(key, auth, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v2/detections/1/review", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(echo.HeaderAuthorization, auth)
+		if key != "" {
+			req.Header.Set(IdempotencyKeyHeader, key)
+		}
+		rec := httptest.NewRecorder()
+		require.NoError(t, handler(controller.Echo.NewContext(req, rec)))
+		return rec
+	}
+
+	// Without a key every request runs
+	post("", "Bearer a", `{}`)
+	post("", "Bearer a", `{}`)
+	assert.Equal(t, 2, calls)
+
+	first := post("key-1", "Bearer a", `{"verified":"correct"}`)
+	require.Equal(t, http.StatusCreated, first.Code)
+	retry := post("key-1", "Bearer a", `{"verified":"correct"}`)
+	assert.Equal(t, 3, calls, "retries are not applied again")
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+	assert.Equal(t, "true", retry.Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, echo.MIMEApplicationJSON, retry.Header().Get(echo.HeaderContentType))
+
+	assert.Equal(t, http.StatusUnprocessableEntity, post("key-1", "Bearer a", `{"verified":"false_positive"}`).Code)
+
+	post("key-1", "Bearer b", `{"verified":"correct"}`)
+	assert.Equal(t, 4, calls, "keys are scoped to the caller")
+
+	// Server errors are not stored
+	assert.Equal(t, http.StatusInternalServerError, post("key-2", "Bearer a", `{"fail":true}`).Code)
+	assert.Equal(t, http.StatusInternalServerError, post("key-2", "Bearer a", `{"fail":true}`).Code)
+	assert.Equal(t, 6, calls)
+
+	assert.Equal(t, http.StatusBadRequest, post(strings.Repeat("k", 300), "Bearer a", `{}`).Code)
+}
+
+func TestIdempotencyMiddleware_InProgress(t *testing.T) {
+	t.Parallel()
+
+	_, _, controller := setupTestEnvironment(t)
+	var inner *httptest.ResponseRecorder
+	var handler echo.HandlerFunc
+	handler = controller.IdempotencyMiddleware(func(ctx echo.Context) error {
+		// A retry arriving while the first request runs
+		req := httptest.NewRequest(http.MethodPost, "/api/v2/sync/detections", strings.NewReader(`{}`))
+		req.Header.Set(IdempotencyKeyHeader, "key")
+		inner = httptest.NewRecorder()
+		if err := handler(controller.Echo.NewContext(req, inner)); err != nil {
+			return err
+		}
+		return ctx.NoContent(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/sync/detections", strings.NewReader(`{}`))
+	req.Header.Set(IdempotencyKeyHeader, "key")
+	rec := httptest.NewRecorder()
+	require.NoError(t, handler(controller.Echo.NewContext(req, rec)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	require.NotNil(t, inner)
+	assert.Equal(t, http.StatusConflict, inner.Code)
+}
+
+func TestIdempotencyMiddleware_Principal(t *testing.T) {
+	t.Parallel()
+
+	_, _, controller := setupTestEnvironment(t)
+	calls := 0
+	handler := controller.IdempotencyMiddleware(func(ctx echo.Context) error {
+		calls++
+		return ctx.JSON(http.StatusCreated, map[string]int{"call": calls})
+	})
+
+	post := func(cookie string, principal func(ctx echo.Context)) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v2/detections/1/review", strings.NewReader(`{}`))
+		req.Header.Set(IdempotencyKeyHeader, "key")
+		req.Header.Set("Cookie", cookie)
+		rec := httptest.NewRecorder()
+		ctx := controller.Echo.NewContext(req, rec)
+		principal(ctx)
+		require.NoError(t, handler(ctx))
+		return rec
+	}
+	user := func(name string) func(echo.Context) {
+		return func(ctx echo.Context) { ctx.Set("username", name) }
+	}
+	apiKey := func(id string) func(echo.Context) {
+		return func(ctx echo.Context) {
+			ctx.Set(auth.APIKeyContextKey, security.APIKey{ID: id, Name: "station"})
+			ctx.Set("username", "apikey:station")
+		}
+	}
+
+	post("session=1", user("alice"))
+	retry := post("session=2", user("alice"))
+	assert.Equal(t, 1, calls, "a rotated session cookie replays for the same user")
+	assert.Equal(t, "true", retry.Header().Get(IdempotentReplayedHeader))
+
+	post("session=1", user("bob"))
+	assert.Equal(t, 2, calls, "keys are scoped to the session user")
+
+	post("", apiKey("k1"))
+	post("", apiKey("k1"))
+	assert.Equal(t, 3, calls)
+	post("", apiKey("k2"))
+	assert.Equal(t, 4, calls, "keys are scoped to the API key, not its name")
+}
@@ -0,0 +1,337 @@
+// internal/api/v2/labelstudio.go
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/errors"
+	"github.com/tphakala/birdnet-go/internal/mediaurl"
+)
+
+// Label Studio limits
+const (
+	defaultLabelStudioTasks  = 500
+	maxLabelStudioTasks      = 10000
+	labelStudioModelVersion  = "birdnet-go"
+	labelStudioChoiceControl = "verification" // name of the Choices control in the labeling config
+	labelStudioTextControl   = "comment"      // name of the TextArea control in the labeling config
+	labelStudioAudioTTL      = 7 * 24 * time.Hour
+)
+
+// labelStudioConfig is the Label Studio labeling interface matching the
+// exported tasks and the imported annotations
+const labelStudioConfig = `<View>
+  <Header value="$label"/>
+  <Audio name="audio" value="$audio"/>
+  <Choices name="verification" toName="audio" choice="single" required="true" showInline="true">
+    <Choice value="correct" alias="correct"/>
+    <Choice value="false_positive" alias="false_positive"/>
+  </Choices>
+  <TextArea name="comment" toName="audio" rows="2" maxSubmissions="1" placeholder="Comment"/>
+</View>
+`
+
+// LabelStudioTask is a Label Studio task for one detection
+type LabelStudioTask struct {
+	Data        LabelStudioTaskData     `json:"data"`
+	Predictions []LabelStudioPrediction `json:"predictions,omitempty"`
+}
+
+// LabelStudioTaskData is the data of a task referenced by the labeling config
+type LabelStudioTaskData struct {
+	Audio          string  `json:"audio"` // URL of the audio clip
+	Label          string  `json:"label"` // species shown to annotators
+	DetectionID    uint    `json:"detection_id"`
+	ScientificName string  `json:"scientific_name"`
+	CommonName     string  `json:"common_name"`
+	Confidence     float64 `json:"confidence"`
+	Date           string  `json:"date"`
+	Time           string  `json:"time"`
+}
+
+// LabelStudioPrediction pre-annotates a task with the detection of BirdNET
+type LabelStudioPrediction struct {
+	ModelVersion string              `json:"model_version"`
+	Score        float64             `json:"score"`
+	Result       []LabelStudioResult `json:"result"`
+}
+
+// LabelStudioResult is one region or choice of an annotation
+type LabelStudioResult struct {
+	FromName string                 `json:"from_name"`
+	ToName   string                 `json:"to_name"`
+	Type     string                 `json:"type"`
+	Value    LabelStudioResultValue `json:"value"`
+}
+
+// LabelStudioResultValue holds the value of a Choices or TextArea result
+type LabelStudioResultValue struct {
+	Choices []string `json:"choices,omitempty"`
+	Text    []string `json:"text,omitempty"`
+}
+
+// LabelStudioAnnotation is an annotation of a task in a Label Studio export
+type LabelStudioAnnotation struct {
+	WasCancelled bool                `json:"was_cancelled"`
+	UpdatedAt    time.Time           `json:"updated_at"`
+	Result       []LabelStudioResult `json:"result"`
+}
+
+// LabelStudioExportedTask is a task of a Label Studio JSON export
+type LabelStudioExportedTask struct {
+	ID          int                     `json:"id"`
+	Data        LabelStudioTaskData     `json:"data"`
+	Annotations []LabelStudioAnnotation `json:"annotations"`
+}
+
+// LabelStudioImportResult summarizes an import of annotations
+type LabelStudioImportResult struct {
+	Imported int      `json:"imported"` // review decisions saved
+	Comments int      `json:"comments"` // comments added
+	Skipped  int      `json:"skipped"`  // tasks without a usable annotation
+	Locked   int      `json:"locked"`   // tasks of locked detections
+	Errors   []string `json:"errors,omitempty"`
+}
+
+// initLabelStudioRoutes registers the Label Studio bridge endpoints
+func (c *Controller) initLabelStudioRoutes() {
+	labelStudioGroup := c.Group.Group("/labelstudio", c.AuthMiddleware)
+	labelStudioGroup.GET("/config", c.GetLabelStudioConfig)
+	labelStudioGroup.GET("/tasks", c.GetLabelStudioTasks)
+	labelStudioGroup.POST("/annotations", c.ImportLabelStudioAnnotations)
+}
+
+// GetLabelStudioConfig handles GET /api/v2/labelstudio/config
+// It returns the labeling interface to paste into a Label Studio project.
+func (c *Controller) GetLabelStudioConfig(ctx echo.Context) error {
+	return ctx.Blob(http.StatusOK, "application/xml; charset=utf-8", []byte(labelStudioConfig))
+}
+
+// GetLabelStudioTasks handles GET /api/v2/labelstudio/tasks
+// It returns detections with audio clips as Label Studio tasks for import into
+// a project. Unreviewed detections are exported unless verified=true or
+// verified=all is given. Filters are species, start_date, end_date,
+// min_confidence (percent) and limit. Audio URLs use base_url when given,
+// otherwise the configured host or the host of the request, and are signed to
+// stay valid for ttl minutes (default 7 days) without authentication.
+func (c *Controller) GetLabelStudioTasks(ctx echo.Context) error {
+	limit := defaultLabelStudioTasks
+	if value := ctx.QueryParam("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxLabelStudioTasks {
+			return c.HandleError(ctx, fmt.Errorf("invalid limit %q", value),
+				fmt.Sprintf("Limit must be between 1 and %d", maxLabelStudioTasks), http.StatusBadRequest)
+		}
+		limit = parsed
+	}
+
+	var minConfidence float64
+	if value := ctx.QueryParam("min_confidence"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed < 0 || parsed > 100 {
+			return c.HandleError(ctx, fmt.Errorf("invalid min_confidence %q", value),
+				"min_confidence must be a percentage between 0 and 100", http.StatusBadRequest)
+		}
+		minConfidence = parsed
+	}
+
+	startDate, endDate := ctx.QueryParam("start_date"), ctx.QueryParam("end_date")
+	for _, date := range []string{startDate, endDate} {
+		if _, err := time.Parse(time.DateOnly, date); date != "" && err != nil {
+			return c.HandleError(ctx, err, "Dates must use the YYYY-MM-DD format", http.StatusBadRequest)
+		}
+	}
+
+	var verified *bool
+	switch ctx.QueryParam("verified") {
+	case "", "false":
+		verified = new(bool)
+	case "true":
+		verified = new(bool)
+		*verified = true
+	case "all":
+	default:
+		return c.HandleError(ctx, fmt.Errorf("invalid verified %q", ctx.QueryParam("verified")),
+			"verified must be true, false or all", http.StatusBadRequest)
+	}
+
+	ttl := labelStudioAudioTTL
+	if value := ctx.QueryParam("ttl"); value != "" {
+		minutes, err := strconv.Atoi(value)
+		if err != nil || minutes < 1 || time.Duration(minutes)*time.Minute > maxSignedMediaTTL {
+			return c.HandleError(ctx, fmt.Errorf("invalid ttl %q", value),
+				"ttl must be between 1 and 43200 minutes", http.StatusBadRequest)
+		}
+		ttl = time.Duration(minutes) * time.Minute
+	}
+
+	var species []string
+	if value := ctx.QueryParam("species"); value != "" {
+		species = strings.Split(value, ",")
+	}
+
+	filters := c.exportSearchFilters(species, startDate, endDate, minConfidence, verified, limit)
+	notes, _, err := c.DS.SearchNotesAdvanced(&filters)
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to select detections", http.StatusInternalServerError)
+	}
+
+	baseURL := strings.TrimSuffix(ctx.QueryParam("base_url"), "/")
+	if baseURL == "" {
+		baseURL = c.publicBaseURL(ctx)
+	}
+	signer := mediaurl.FromSettings(c.Settings)
+
+	tasks := make([]LabelStudioTask, 0, len(notes))
+	for i := range notes {
+		if notes[i].ClipName == "" {
+			continue
+		}
+		id := strconv.FormatUint(uint64(notes[i].ID), 10)
+		audioURL := baseURL + "/api/v2/audio/" + id
+		if signer != nil {
+			path, _ := signer.Sign(mediaurl.KindAudio, id, ttl)
+			audioURL = baseURL + path
+		}
+		tasks = append(tasks, labelStudioTask(&notes[i], audioURL))
+	}
+	return ctx.JSON(http.StatusOK, tasks)
+}
+
+// labelStudioTask converts a detection into a task pre-annotated as correct
+// with the confidence of the detection as score
+func labelStudioTask(note *datastore.Note, audioURL string) LabelStudioTask {
+	return LabelStudioTask{
+		Data: LabelStudioTaskData{
+			Audio:          audioURL,
+			Label:          fmt.Sprintf("%s (%s)", note.CommonName, note.ScientificName),
+			DetectionID:    note.ID,
+			ScientificName: note.ScientificName,
+			CommonName:     note.CommonName,
+			Confidence:     note.Confidence,
+			Date:           note.Date,
+			Time:           note.Time,
+		},
+		Predictions: []LabelStudioPrediction{{
+			ModelVersion: labelStudioModelVersion,
+			Score:        note.Confidence,
+			Result: []LabelStudioResult{{
+				FromName: labelStudioChoiceControl,
+				ToName:   "audio",
+				Type:     "choices",
+				Value:    LabelStudioResultValue{Choices: []string{"correct"}},
+			}},
+		}},
+	}
+}
+
+// ImportLabelStudioAnnotations handles POST /api/v2/labelstudio/annotations
+// It takes a Label Studio JSON export and saves the latest annotation of each
+// task as the review decision of its detection, adding annotation comments as
+// detection comments. Locked detections are left unchanged.
+func (c *Controller) ImportLabelStudioAnnotations(ctx echo.Context) error {
+	var tasks []LabelStudioExportedTask
+	if err := ctx.Bind(&tasks); err != nil {
+		return c.HandleError(ctx, err, "Invalid Label Studio export, expected a JSON array of tasks", http.StatusBadRequest)
+	}
+	if len(tasks) > maxLabelStudioTasks {
+		return c.HandleError(ctx, errors.Newf("%d tasks exceed the import limit of %d", len(tasks), maxLabelStudioTasks).
+			Component("api").
+			Category(errors.CategoryValidation).
+			Build(), fmt.Sprintf("At most %d tasks can be imported at once", maxLabelStudioTasks), http.StatusBadRequest)
+	}
+
+	var result LabelStudioImportResult
+	for i := range tasks {
+		task := &tasks[i]
+		verified, comment, ok := latestLabelStudioDecision(task.Annotations)
+		if !ok || task.Data.DetectionID == 0 {
+			result.Skipped++
+			continue
+		}
+
+		id := strconv.FormatUint(uint64(task.Data.DetectionID), 10)
+		if _, err := c.DS.Get(id); err != nil {
+			result.Skipped++
+			result.Errors = append(result.Errors, fmt.Sprintf("task %d: detection %s not found", task.ID, id))
+			continue
+		}
+		locked, err := c.DS.IsNoteLocked(id)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("task %d: failed to check lock of detection %s: %v", task.ID, id, err))
+			continue
+		}
+		if locked {
+			result.Locked++
+			continue
+		}
+
+		if verified != "" {
+			if err := c.AddReview(task.Data.DetectionID, verified == "correct"); err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("task %d: failed to save review of detection %s: %v", task.ID, id, err))
+				continue
+			}
+			result.Imported++
+		}
+		if comment != "" {
+			if err := c.AddComment(task.Data.DetectionID, comment); err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("task %d: failed to save comment of detection %s: %v", task.ID, id, err))
+				continue
+			}
+			result.Comments++
+		}
+	}
+
+	if result.Imported > 0 || result.Comments > 0 {
+		c.invalidateDetectionCache()
+	}
+	if c.apiLogger != nil {
+		c.apiLogger.Info("Imported Label Studio annotations",
+			"tasks", len(tasks),
+			"imported", result.Imported,
+			"comments", result.Comments,
+			"skipped", result.Skipped,
+			"locked", result.Locked,
+			"errors", len(result.Errors),
+			"ip", ctx.RealIP())
+	}
+	return ctx.JSON(http.StatusOK, result)
+}
+
+// latestLabelStudioDecision returns the verification ("correct" or
+// "false_positive") and comment of the most recently updated annotation that
+// was not cancelled. ok is false when no annotation holds either.
+func latestLabelStudioDecision(annotations []LabelStudioAnnotation) (verified, comment string, ok bool) {
+	var latest *LabelStudioAnnotation
+	for i := range annotations {
+		annotation := &annotations[i]
+		if annotation.WasCancelled {
+			continue
+		}
+		if latest == nil || !annotation.UpdatedAt.Before(latest.UpdatedAt) {
+			latest = annotation
+		}
+	}
+	if latest == nil {
+		return "", "", false
+	}
+
+	for _, r := range latest.Result {
+		switch {
+		case r.Type == "choices" && r.FromName == labelStudioChoiceControl && len(r.Value.Choices) > 0:
+			switch choice := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(r.Value.Choices[0]), " ", "_")); choice {
+			case "correct", "false_positive":
+				verified = choice
+			}
+		case r.Type == "textarea" && r.FromName == labelStudioTextControl:
+			comment = strings.TrimSpace(strings.Join(r.Value.Text, "\n"))
+		}
+	}
+	return verified, comment, verified != "" || comment != ""
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+func TestGetLabelStudioTasks(t *testing.T) {
+	t.Parallel()
+
+	e, mockDS, controller := setupTestEnvironment(t)
+	notes := []datastore.Note{
+		{ID: 7, Date: "2024-05-01", Time: "06:10:00", ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", Confidence: 0.85, ClipName: "clip.wav"},
+		{ID: 8, Date: "2024-05-01", Time: "06:20:00", ScientificName: "Pica pica", CommonName: "Eurasian Magpie", Confidence: 0.7},
+	}
+	mockDS.On("SearchNotesAdvanced", mock.MatchedBy(func(f *datastore.AdvancedSearchFilters) bool {
+		return f.Verified != nil && !*f.Verified && f.Limit == 50 && len(f.Species) == 2
+	})).Return(notes, int64(len(notes)), nil)
+
+	req := httptest.NewRequest(http.MethodGet,
+		"/api/v2/labelstudio/tasks?limit=50&species=Turdus%20merula,Pica%20pica&base_url=https://birds.example.org/", http.NoBody)
+	rec := httptest.NewRecorder()
+	require.NoError(t, controller.GetLabelStudioTasks(e.NewContext(req, rec)))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var tasks []LabelStudioTask
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &tasks))
+	require.Len(t, tasks, 1, "detections without clips should be skipped")
+	assert.Equal(t, "https://birds.example.org/api/v2/audio/7", tasks[0].Data.Audio)
+	assert.Equal(t, "Eurasian Blackbird (Turdus merula)", tasks[0].Data.Label)
+	assert.Equal(t, uint(7), tasks[0].Data.DetectionID)
+	require.Len(t, tasks[0].Predictions, 1)
+	assert.InDelta(t, 0.85, tasks[0].Predictions[0].Score, 0.001)
+	assert.Equal(t, []string{"correct"}, tasks[0].Predictions[0].Result[0].Value.Choices)
+
+	for _, query := range []string{"?limit=0", "?min_confidence=120", "?start_date=May", "?verified=maybe"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v2/labelstudio/tasks"+query, http.NoBody)
+		rec := httptest.NewRecorder()
+		require.NoError(t, controller.GetLabelStudioTasks(e.NewContext(req, rec)))
+		assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+}
+
+func TestImportLabelStudioAnnotations(t *testing.T) {
+	t.Parallel()
+
+	e, mockDS, controller := setupTestEnvironment(t)
+	mockDS.On("Get", "1").Return(datastore.Note{ID: 1}, nil)
+	mockDS.On("Get", "2").Return(datastore.Note{ID: 2}, nil)
+	mockDS.On("Get", "3").Return(datastore.Note{ID: 3}, nil)
+	mockDS.On("Get", "9").Return(nil, errors.New("record not found"))
+	mockDS.On("IsNoteLocked", "1").Return(false, nil)
+	mockDS.On("IsNoteLocked", "2").Return(false, nil)
+	mockDS.On("IsNoteLocked", "3").Return(true, nil)
+	mockDS.On("SaveNoteReview", mock.MatchedBy(func(r *datastore.NoteReview) bool {
+		return r.NoteID == 1 && r.Verified == "false_positive"
+	})).Return(nil).Once()
+	mockDS.On("SaveNoteReview", mock.MatchedBy(func(r *datastore.NoteReview) bool {
+		return r.NoteID == 2 && r.Verified == "correct"
+	})).Return(nil).Once()
+	mockDS.On("SaveNoteComment", mock.MatchedBy(func(c *datastore.NoteComment) bool {
+		return c.NoteID == 1 && c.Entry == "Car alarm"
+	})).Return(nil).Once()
+
+	body := `[
+	  {"id": 101, "data": {"detection_id": 1}, "annotations": [
+	    {"was_cancelled": false, "updated_at": "2024-05-02T10:00:00Z", "result": [
+	      {"from_name": "verification", "to_name": "audio", "type": "choices", "value": {"choices": ["correct"]}}]},
+	    {"was_cancelled": false, "updated_at": "2024-05-03T10:00:00Z", "result": [
+	      {"from_name": "verification", "to_name": "audio", "type": "choices", "value": {"choices": ["false_positive"]}},
+	      {"from_name": "comment", "to_name": "audio", "type": "textarea", "value": {"text": ["Car alarm"]}}]}]},
+	  {"id": 102, "data": {"detection_id": 2}, "annotations": [
+	    {"was_cancelled": false, "result": [
+	      {"from_name": "verification", "to_name": "audio", "type": "choices", "value": {"choices": ["Correct"]}}]}]},
+	  {"id": 103, "data": {"detection_id": 3}, "annotations": [
+	    {"was_cancelled": false, "result": [
+	      {"from_name": "verification", "to_name": "audio", "type": "choices", "value": {"choices": ["correct"]}}]}]},
+	  {"id": 104, "data": {"detection_id": 4}, "annotations": [{"was_cancelled": true, "result": []}]},
+	  {"id": 105, "data": {"detection_id": 9}, "annotations": [
+	    {"was_cancelled": false, "result": [
+	      {"from_name": "verification", "to_name": "audio", "type": "choices", "value": {"choices": ["correct"]}}]}]}
+	]`
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/labelstudio/annotations", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	require.NoError(t, controller.ImportLabelStudioAnnotations(e.NewContext(req, rec)))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var result LabelStudioImportResult
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+	assert.Equal(t, 2, result.Imported)
+	assert.Equal(t, 1, result.Comments)
+	assert.Equal(t, 1, result.Locked)
+	assert.Equal(t, 2, result.Skipped, "cancelled annotations and unknown detections should be skipped")
+	assert.Len(t, result.Errors, 1)
+	mockDS.AssertExpectations(t)
+}
+
+func TestLatestLabelStudioDecision(t *testing.T) {
+	t.Parallel()
+
+	_, _, ok := latestLabelStudioDecision(nil)
+	assert.False(t, ok)
+
+	verified, comment, ok := latestLabelStudioDecision([]LabelStudioAnnotation{{
+		UpdatedAt: time.Now(),
+		Result: []LabelStudioResult{
+			{FromName: "verification", Type: "choices", Value: LabelStudioResultValue{Choices: []string{"maybe"}}},
+		},
+	}})
+	assert.False(t, ok, "unknown choices should not be imported")
+	assert.Empty(t, verified)
+	assert.Empty(t, comment)
+}
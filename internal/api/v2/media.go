@@ -20,9 +20,12 @@ import (
 	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/atrest"
 	"github.com/tphakala/birdnet-go/internal/conf"
 	"github.com/tphakala/birdnet-go/internal/errors"
+	"github.com/tphakala/birdnet-go/internal/imageprovider"
 	"github.com/tphakala/birdnet-go/internal/logging"
+	"github.com/tphakala/birdnet-go/internal/mediaurl"
 	"github.com/tphakala/birdnet-go/internal/myaudio"
 	"github.com/tphakala/birdnet-go/internal/securefs"
 	"golang.org/x/sync/singleflight"
@@ -145,11 +148,26 @@ func (c *Controller) initMediaRoutes() {
 	c.Echo.GET("/api/v2/spectrogram/:id", c.ServeSpectrogramByID)
 	c.Echo.GET("/api/v2/spectrogram/:id/status", c.GetSpectrogramStatus)
 
+	// Signed short-lived URLs, verified by signature instead of authentication
+	c.Echo.GET(mediaurl.PathPrefix+"/:kind/:id", c.ServeSignedMedia)
+	c.Group.POST("/media/sign", c.SignMediaURL, c.getEffectiveAuthMiddleware())
+
 	// Convenient combined endpoint (redirects to ID-based internally)
 	c.Group.GET("/media/audio", c.ServeAudioByQueryID)
 
 	// Bird image endpoint
 	c.Group.GET("/media/species-image", c.GetSpeciesImage)
+	c.Group.GET("/media/species-image/info", c.GetSpeciesImageInfo)
+	c.Group.GET("/media/species-image/prefetch", c.GetSpeciesImagePrefetch)
+	c.Group.GET("/media/species-image/bundle/:file", c.ServeBundleImage)
+
+	// Batch export of spectrograms into a zip file
+	c.Group.POST("/spectrograms/export", c.StartSpectrogramExport, c.getEffectiveAuthMiddleware())
+	c.Group.GET("/spectrograms/export/:id", c.GetSpectrogramExport, c.getEffectiveAuthMiddleware())
+	c.Group.GET("/spectrograms/export/:id/download", c.DownloadSpectrogramExport, c.getEffectiveAuthMiddleware())
+	c.Group.POST("/training/export", c.StartTrainingExport, c.getEffectiveAuthMiddleware())
+	c.Group.GET("/training/export/:id", c.GetTrainingExport, c.getEffectiveAuthMiddleware())
+	c.Group.GET("/training/export/:id/download", c.DownloadTrainingExport, c.getEffectiveAuthMiddleware())
 
 	if c.apiLogger != nil {
 		c.apiLogger.Info("Media routes initialized successfully")
@@ -1618,8 +1636,14 @@ func (c *Controller) generateSpectrogram(ctx context.Context, audioPath string,
 		return "", err
 	}
 
-	// Step 5: Validate that the audio file is complete and ready for processing
-	absAudioPath := filepath.Join(c.SFS.BaseDir(), relAudioPath)
+	// Step 5: Validate that the audio file is complete and ready for processing.
+	// FFprobe, SoX and FFmpeg read the clip directly, so encrypted clips are
+	// decrypted to a temporary file first.
+	absAudioPath, cleanup, err := atrest.PlaintextPath(filepath.Join(c.SFS.BaseDir(), relAudioPath))
+	if err != nil {
+		return "", fmt.Errorf("failed to read audio file: %w", err)
+	}
+	defer cleanup()
 	_, err = c.validateSpectrogramInputs(ctx, absAudioPath, audioPath, spectrogramKey)
 	if err != nil {
 		return "", err
@@ -2148,4 +2172,34 @@ func (c *Controller) GetSpeciesImage(ctx echo.Context) error {
 	return ctx.Redirect(http.StatusFound, birdImage.URL)
 }
 
+// ServeBundleImage serves a species image from the offline image bundle
+func (c *Controller) ServeBundleImage(ctx echo.Context) error {
+	bundle := imageprovider.GetActiveBundle()
+	if bundle == nil {
+		return c.HandleError(ctx, ErrImageProviderNotAvailable, "Offline image bundle not configured", http.StatusNotFound)
+	}
+
+	imagePath, ok := bundle.ImagePath(ctx.Param("file"))
+	if !ok {
+		return c.HandleError(ctx, ErrImageNotFound, "Image not found in bundle", http.StatusNotFound)
+	}
+
+	ctx.Response().Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d, immutable", ImageCacheSeconds))
+	return ctx.File(imagePath)
+}
+
+// GetSpeciesImagePrefetch returns the progress of pre-fetching the images of
+// the species in the range filter
+func (c *Controller) GetSpeciesImagePrefetch(ctx echo.Context) error {
+	if c.BirdImageCache == nil {
+		return c.HandleError(ctx, ErrImageProviderNotAvailable, "Image service unavailable", http.StatusServiceUnavailable)
+	}
+
+	progress, started := c.BirdImageCache.PrefetchProgress()
+	return ctx.JSON(http.StatusOK, map[string]any{
+		"enabled":  started,
+		"progress": progress,
+	})
+}
+
 // HandleError method should exist on Controller, typically defined in controller.go or api.go
@@ -0,0 +1,115 @@
+// internal/api/v2/media_attribution.go
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/errors"
+	"github.com/tphakala/birdnet-go/internal/imageprovider"
+)
+
+// ImageAttribution holds the license and author details that must be displayed
+// with a species image. Wikimedia and Avicommons images are published under
+// Creative Commons licenses which require crediting the author and license.
+type ImageAttribution struct {
+	AuthorName  string `json:"author_name,omitempty"`
+	AuthorURL   string `json:"author_url,omitempty"`
+	LicenseName string `json:"license_name,omitempty"`
+	LicenseURL  string `json:"license_url,omitempty"`
+	SourceURL   string `json:"source_url,omitempty"`
+	Provider    string `json:"provider,omitempty"`
+	Text        string `json:"text"`     // Ready-to-display attribution line
+	Complete    bool   `json:"complete"` // True when both author and license are known
+}
+
+// SpeciesImageInfoResponse is the response for GET /api/v2/media/species-image/info
+type SpeciesImageInfoResponse struct {
+	ScientificName string           `json:"scientific_name"`
+	URL            string           `json:"url"`
+	Attribution    ImageAttribution `json:"attribution"`
+}
+
+// providerDisplayNames maps image provider identifiers to the names used in attribution text
+var providerDisplayNames = map[string]string{
+	"wikimedia":  "Wikimedia Commons",
+	"avicommons": "Avicommons",
+}
+
+// NewImageAttribution builds the attribution for a bird image.
+// It returns nil for empty or negative cache entries.
+func NewImageAttribution(img *imageprovider.BirdImage) *ImageAttribution {
+	if img == nil || img.URL == "" || img.IsNegativeEntry() {
+		return nil
+	}
+
+	attribution := &ImageAttribution{
+		AuthorName:  strings.TrimSpace(img.AuthorName),
+		AuthorURL:   img.AuthorURL,
+		LicenseName: strings.TrimSpace(img.LicenseName),
+		LicenseURL:  img.LicenseURL,
+		SourceURL:   img.SourceURL,
+		Provider:    img.SourceProvider,
+	}
+	attribution.Complete = attribution.AuthorName != "" && attribution.LicenseName != ""
+	attribution.Text = attributionText(attribution)
+
+	return attribution
+}
+
+// attributionText formats an attribution line such as
+// "Photo by Jane Doe, CC BY-SA 4.0, via Wikimedia Commons"
+func attributionText(a *ImageAttribution) string {
+	parts := make([]string, 0, 3)
+	if a.AuthorName != "" {
+		parts = append(parts, "Photo by "+a.AuthorName)
+	} else {
+		parts = append(parts, "Photo by unknown author")
+	}
+	if a.LicenseName != "" {
+		parts = append(parts, a.LicenseName)
+	}
+	if a.Provider != "" {
+		name, ok := providerDisplayNames[a.Provider]
+		if !ok {
+			name = a.Provider
+		}
+		parts = append(parts, "via "+name)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// GetSpeciesImageInfo returns the image URL and its license attribution for a species
+// without redirecting, so the frontend can display the required credit next to the image.
+func (c *Controller) GetSpeciesImageInfo(ctx echo.Context) error {
+	scientificName := strings.TrimSpace(ctx.QueryParam("name"))
+	if scientificName == "" {
+		return c.HandleError(ctx, fmt.Errorf("missing scientific name"), "Scientific name is required", http.StatusBadRequest)
+	}
+
+	if c.BirdImageCache == nil {
+		return c.HandleError(ctx, ErrImageProviderNotAvailable, "Image service unavailable", http.StatusServiceUnavailable)
+	}
+
+	birdImage, err := c.BirdImageCache.Get(scientificName)
+	if err != nil {
+		if errors.Is(err, ErrImageNotFound) {
+			ctx.Response().Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", NotFoundCacheSeconds))
+			return c.HandleError(ctx, err, "Image not found for species", http.StatusNotFound)
+		}
+		return c.HandleError(ctx, err, "Failed to fetch species image", http.StatusInternalServerError)
+	}
+
+	attribution := NewImageAttribution(&birdImage)
+	if attribution == nil {
+		return c.HandleError(ctx, ErrImageNotFound, "Image not found for species", http.StatusNotFound)
+	}
+
+	return ctx.JSON(http.StatusOK, SpeciesImageInfoResponse{
+		ScientificName: scientificName,
+		URL:            birdImage.URL,
+		Attribution:    *attribution,
+	})
+}
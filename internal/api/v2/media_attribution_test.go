@@ -0,0 +1,84 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/imageprovider"
+)
+
+func TestNewImageAttribution(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		image        *imageprovider.BirdImage
+		wantNil      bool
+		wantText     string
+		wantComplete bool
+	}{
+		{
+			name:    "nil image",
+			image:   nil,
+			wantNil: true,
+		},
+		{
+			name:    "empty URL",
+			image:   &imageprovider.BirdImage{ScientificName: "Turdus merula"},
+			wantNil: true,
+		},
+		{
+			name: "wikimedia image with full attribution",
+			image: &imageprovider.BirdImage{
+				URL:            "https://upload.wikimedia.org/thumb.jpg",
+				AuthorName:     " Jane Doe ",
+				AuthorURL:      "https://commons.wikimedia.org/wiki/User:JaneDoe",
+				LicenseName:    "CC BY-SA 4.0",
+				LicenseURL:     "https://creativecommons.org/licenses/by-sa/4.0",
+				SourceURL:      "https://commons.wikimedia.org/wiki/File:Turdus_merula.jpg",
+				SourceProvider: "wikimedia",
+			},
+			wantText:     "Photo by Jane Doe, CC BY-SA 4.0, via Wikimedia Commons",
+			wantComplete: true,
+		},
+		{
+			name: "missing author",
+			image: &imageprovider.BirdImage{
+				URL:            "https://static.avicommons.org/eurbla-abc-320.jpg",
+				LicenseName:    "CC BY-NC 4.0",
+				SourceProvider: "avicommons",
+			},
+			wantText:     "Photo by unknown author, CC BY-NC 4.0, via Avicommons",
+			wantComplete: false,
+		},
+		{
+			name: "unknown provider",
+			image: &imageprovider.BirdImage{
+				URL:            "https://example.com/bird.jpg",
+				AuthorName:     "John Smith",
+				SourceProvider: "custom",
+			},
+			wantText:     "Photo by John Smith, via custom",
+			wantComplete: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := NewImageAttribution(tt.image)
+			if tt.wantNil {
+				assert.Nil(t, got)
+				return
+			}
+
+			require.NotNil(t, got)
+			assert.Equal(t, tt.wantText, got.Text)
+			assert.Equal(t, tt.wantComplete, got.Complete)
+			assert.Equal(t, tt.image.SourceURL, got.SourceURL)
+			assert.Equal(t, tt.image.LicenseURL, got.LicenseURL)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+// internal/api/v2/newsletter.go
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/errors"
+	"github.com/tphakala/birdnet-go/internal/newsletter"
+)
+
+// initNewsletterRoutes registers the weekly newsletter endpoint
+func (c *Controller) initNewsletterRoutes() {
+	c.Group.GET("/newsletter", c.GetNewsletter)
+}
+
+// GetNewsletter handles GET /api/v2/newsletter
+// It renders the newsletter of the week (Monday to Sunday) containing the week
+// parameter (YYYY-MM-DD), by default the previous week, as an HTML page with
+// embedded charts. With format=json the content is returned without charts.
+func (c *Controller) GetNewsletter(ctx echo.Context) error {
+	week := newsletter.WeekStart(time.Now()).AddDate(0, 0, -7)
+	if param := ctx.QueryParam("week"); param != "" {
+		date, err := time.ParseInLocation(time.DateOnly, param, time.Local)
+		if err != nil {
+			return c.HandleError(ctx, errors.New(err).
+				Component("api").
+				Category(errors.CategoryValidation).
+				Context("week", param).
+				Build(), "Invalid week format, use YYYY-MM-DD", http.StatusBadRequest)
+		}
+		week = date
+	}
+
+	format := ctx.QueryParam("format")
+	if format != "" && format != "html" && format != "json" {
+		return c.HandleError(ctx, errors.Newf("invalid format parameter: %s", format).
+			Component("api").
+			Category(errors.CategoryValidation).
+			Build(), "Format must be html or json", http.StatusBadRequest)
+	}
+
+	builder := newsletter.NewBuilder(newsletter.ConfigFromSettings(c.Settings), c.DS)
+	n, err := builder.Build(ctx.Request().Context(), week)
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to build the newsletter", http.StatusInternalServerError)
+	}
+	if format == "json" {
+		return ctx.JSON(http.StatusOK, n)
+	}
+
+	page, err := newsletter.Render(n)
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to render the newsletter", http.StatusInternalServerError)
+	}
+	return ctx.HTMLBlob(http.StatusOK, page)
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/newsletter"
+)
+
+func TestGetNewsletter(t *testing.T) {
+	t.Parallel()
+
+	e, mockDS, controller := setupTestEnvironment(t)
+	controller.Settings.Newsletter.TopSpecies = 5
+
+	mockDS.On("GetSpeciesSummaryData", mock.Anything, "2025-03-10", "2025-03-16").Return([]datastore.SpeciesSummaryData{
+		{ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", Count: 30},
+	}, nil)
+	mockDS.On("GetSpeciesSummaryData", mock.Anything, mock.Anything, mock.Anything).Return([]datastore.SpeciesSummaryData{}, nil)
+	mockDS.On("GetNewSpeciesDetections", mock.Anything, "2025-03-10", "2025-03-16", 0, 0).Return([]datastore.NewSpeciesData{}, nil)
+
+	get := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v2/newsletter"+query, http.NoBody)
+		rec := httptest.NewRecorder()
+		require.NoError(t, controller.GetNewsletter(e.NewContext(req, rec)))
+		return rec
+	}
+
+	rec := get("?week=2025-03-12&format=json")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var n newsletter.Newsletter
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &n))
+	assert.Equal(t, "2025-03-10", n.Start)
+	assert.Equal(t, 30, n.Detections)
+
+	rec = get("?week=2025-03-12")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, strings.HasPrefix(rec.Header().Get("Content-Type"), "text/html"))
+	assert.Contains(t, rec.Body.String(), "30 detections of 1 species")
+
+	assert.Equal(t, http.StatusBadRequest, get("?week=March").Code)
+	assert.Equal(t, http.StatusBadRequest, get("?format=pdf").Code)
+}
@@ -0,0 +1,122 @@
+// notification_deliveries.go: Inspection and retry of failed outbound deliveries
+package api
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/errors"
+	"github.com/tphakala/birdnet-go/internal/notification"
+)
+
+// deliveryStatuses are the statuses accepted by the status filter
+var deliveryStatuses = []notification.DeliveryStatus{
+	notification.DeliveryPending,
+	notification.DeliveryDelivered,
+	notification.DeliveryDeadLetter,
+}
+
+// GetDeliveries lists the deliveries of the delivery queue, newest first.
+// Query parameters: status (comma separated pending, delivered or
+// dead_letter), target ("push:<provider>", "mqtt" or "birdweather"), limit
+// and offset.
+func (c *Controller) GetDeliveries(ctx echo.Context) error {
+	queue := notification.GetDeliveryQueue()
+	if queue == nil {
+		return ctx.JSON(http.StatusServiceUnavailable, map[string]string{
+			"error": "Delivery queue not enabled",
+		})
+	}
+
+	filter := &notification.DeliveryFilter{
+		Target: ctx.QueryParam("target"),
+		Limit:  50, // Default limit
+	}
+	if statusParam := ctx.QueryParam("status"); statusParam != "" {
+		for _, s := range strings.Split(statusParam, ",") {
+			status := notification.DeliveryStatus(strings.TrimSpace(s))
+			if !slices.Contains(deliveryStatuses, status) {
+				return ctx.JSON(http.StatusBadRequest, map[string]string{
+					"error": "status must be pending, delivered or dead_letter",
+				})
+			}
+			filter.Statuses = append(filter.Statuses, status)
+		}
+	}
+	if limitParam := ctx.QueryParam("limit"); limitParam != "" {
+		if limit, err := strconv.Atoi(limitParam); err == nil && limit > 0 {
+			filter.Limit = min(limit, maxNotificationsPageSize)
+		}
+	}
+	if offsetParam := ctx.QueryParam("offset"); offsetParam != "" {
+		if offset, err := strconv.Atoi(offsetParam); err == nil && offset >= 0 {
+			filter.Offset = offset
+		}
+	}
+
+	deliveries, total, err := queue.List(filter)
+	if err != nil {
+		if c.apiLogger != nil {
+			c.apiLogger.Error("failed to list deliveries", "error", err)
+		}
+		return ctx.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to retrieve deliveries",
+		})
+	}
+
+	return ctx.JSON(http.StatusOK, map[string]any{
+		"deliveries": deliveries,
+		"count":      len(deliveries),
+		"total":      total,
+		"limit":      filter.Limit,
+		"offset":     filter.Offset,
+		"has_more":   filter.Offset+len(deliveries) < total,
+	})
+}
+
+// RetryDelivery attempts a pending or dead-lettered delivery now, with a new
+// set of attempts, and returns the delivery as updated by the attempt. A
+// failed attempt is not an error of the request; the delivery shows it.
+func (c *Controller) RetryDelivery(ctx echo.Context) error {
+	queue := notification.GetDeliveryQueue()
+	if queue == nil {
+		return ctx.JSON(http.StatusServiceUnavailable, map[string]string{
+			"error": "Delivery queue not enabled",
+		})
+	}
+
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 0)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid delivery ID",
+		})
+	}
+
+	delivery, err := queue.Retry(ctx.Request().Context(), uint(id))
+	switch {
+	case err == nil:
+		return ctx.JSON(http.StatusOK, delivery)
+	case errors.Is(err, notification.ErrDeliveryNotFound):
+		return ctx.JSON(http.StatusNotFound, map[string]string{
+			"error": "Delivery not found",
+		})
+	case errors.Is(err, notification.ErrDeliveryAlreadySent):
+		return ctx.JSON(http.StatusConflict, map[string]string{
+			"error": "Delivery already delivered",
+		})
+	case errors.Is(err, notification.ErrDeliveryTargetNotFound), errors.Is(err, notification.ErrDeliveryDeferred):
+		return ctx.JSON(http.StatusConflict, map[string]string{
+			"error": "Delivery target is not available, try again later",
+		})
+	}
+
+	if c.apiLogger != nil {
+		c.apiLogger.Error("failed to retry delivery", "error", err, "id", id)
+	}
+	return ctx.JSON(http.StatusInternalServerError, map[string]string{
+		"error": "Failed to retry delivery",
+	})
+}
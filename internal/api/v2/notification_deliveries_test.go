@@ -0,0 +1,96 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/errors"
+	"github.com/tphakala/birdnet-go/internal/notification"
+	"github.com/tphakala/birdnet-go/internal/notification/history"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestDeliveriesEndpoints(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&datastore.DeliveryRecord{}))
+	settings := conf.DeliveryQueueSettings{Enabled: true, MaxAttempts: 1, InitialDelay: time.Minute, MaxDelay: time.Hour, MaxPending: 10}
+	queue := notification.NewDeliveryQueue(history.NewDeliveryStore(&datastore.DataStore{DB: db}), &settings, false)
+	notification.SetDeliveryQueue(queue)
+	t.Cleanup(func() { notification.SetDeliveryQueue(nil) })
+
+	online := false
+	queue.Register(notification.DeliveryTargetMQTT, func(ctx context.Context, payload []byte) error {
+		if !online {
+			return errors.NewStd("broker unavailable")
+		}
+		return nil
+	})
+	failed, err := queue.Enqueue(notification.DeliveryTargetMQTT, "Eurasian Wryneck", []byte(`{}`), errors.NewStd("not connected"))
+	require.NoError(t, err)
+	queue.ProcessDue(context.Background(), failed.NextAttemptAt)
+	_, err = queue.Enqueue(notification.DeliveryTargetBirdWeather, "Common Blackbird", []byte(`{}`), nil)
+	require.NoError(t, err)
+
+	e := echo.New()
+	controller := mockController()
+	list := func(query string) (int, map[string]any) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v2/notifications/deliveries?"+query, http.NoBody)
+		rec := httptest.NewRecorder()
+		require.NoError(t, controller.GetDeliveries(e.NewContext(req, rec)))
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		return rec.Code, body
+	}
+	retry := func(id string) (int, map[string]any) {
+		req := httptest.NewRequest(http.MethodPost, "/api/v2/notifications/deliveries/"+id+"/retry", http.NoBody)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.SetParamNames("id")
+		ctx.SetParamValues(id)
+		require.NoError(t, controller.RetryDelivery(ctx))
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		return rec.Code, body
+	}
+
+	code, body := list("")
+	require.Equal(t, http.StatusOK, code)
+	assert.InDelta(t, 2, body["total"], 0)
+
+	code, body = list("status=dead_letter")
+	require.Equal(t, http.StatusOK, code)
+	require.InDelta(t, 1, body["total"], 0)
+	deadLetter := body["deliveries"].([]any)[0].(map[string]any)
+	assert.Equal(t, "mqtt", deadLetter["target"])
+	assert.Equal(t, "broker unavailable", deadLetter["last_error"])
+	assert.NotContains(t, deadLetter, "payload", "payloads are not listed")
+
+	code, _ = list("status=failed")
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// Retrying the dead letter delivers it once the target is back
+	online = true
+	id := fmt.Sprint(failed.ID)
+	code, body = retry(id)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "delivered", body["status"])
+
+	code, _ = retry(id)
+	assert.Equal(t, http.StatusConflict, code, "delivered deliveries are not retried")
+	code, _ = retry("99")
+	assert.Equal(t, http.StatusNotFound, code)
+	code, _ = retry("x")
+	assert.Equal(t, http.StatusBadRequest, code)
+}
@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"net/http"
+	"slices"
 	"strconv"
 	"time"
 
@@ -11,6 +12,7 @@ import (
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/tphakala/birdnet-go/internal/errors"
 	"github.com/tphakala/birdnet-go/internal/notification"
+	"github.com/tphakala/birdnet-go/internal/notification/templatefuncs"
 	"github.com/tphakala/birdnet-go/internal/observability/metrics"
 	"github.com/tphakala/birdnet-go/internal/privacy"
 )
@@ -29,6 +31,10 @@ const (
 	// Buffer sizes
 	notificationChannelBuffer = 10 // Buffer size for notification channels
 
+	// Pagination
+	maxNotificationsPageSize = 500 // Maximum notifications returned per request
+	maxReplayedNotifications = 100 // Maximum missed notifications sent to a resuming stream client
+
 	// Rate limits
 	rateLimitRequestsPerWindow = 10 // Maximum requests per rate limit window for notifications (increased from 1 to match other SSE endpoints)
 	rateLimitBurst             = 15 // Rate limit burst allowance (increased to handle quick navigation)
@@ -96,11 +102,20 @@ func (c *Controller) SetupNotificationRoutes() {
 	c.Group.GET("/notifications/:id", c.GetNotification)
 	c.Group.PUT("/notifications/:id/read", c.MarkNotificationRead)
 	c.Group.PUT("/notifications/:id/acknowledge", c.MarkNotificationAcknowledged)
+	c.Group.PATCH("/notifications/:id", c.UpdateNotificationStatus)
+	c.Group.POST("/notifications/read-all", c.MarkAllNotificationsRead)
 	c.Group.DELETE("/notifications/:id", c.DeleteNotification)
 	c.Group.GET("/notifications/unread/count", c.GetUnreadCount)
+	c.Group.GET("/notifications/stats", c.GetNotificationStats)
+	c.Group.GET("/notifications/template-functions", c.GetNotificationTemplateFunctions)
+
+	// Failed outbound deliveries waiting for a retry or dead-lettered
+	c.Group.GET("/notifications/deliveries", c.GetDeliveries, c.getEffectiveAuthMiddleware())
+	c.Group.POST("/notifications/deliveries/:id/retry", c.RetryDelivery, c.getEffectiveAuthMiddleware())
 
 	// Test endpoints for notification system
 	c.Group.POST("/notifications/test/new-species", c.CreateTestNewSpeciesNotification, c.getEffectiveAuthMiddleware())
+	c.Group.POST("/notifications/test/all", c.TestAllNotificationProviders, c.getEffectiveAuthMiddleware())
 }
 
 // StreamNotifications handles the SSE connection for real-time notification streaming
@@ -151,6 +166,14 @@ func (c *Controller) StreamNotifications(ctx echo.Context) error {
 		// The buffered channel will signal shutdown and be reclaimed by GC
 	}()
 
+	// Resume from the last notification a reconnecting client received. The
+	// client is subscribed first, so nothing created meanwhile is missed.
+	if lastEventID := notificationLastEventID(ctx); lastEventID != "" {
+		if err := c.replayMissedNotifications(ctx, client.ID, service, lastEventID); err != nil {
+			return err
+		}
+	}
+
 	// Setup disconnect handler with proper cleanup
 	c.setupNotificationDisconnectHandler(ctx, client)
 
@@ -200,6 +223,56 @@ func (c *Controller) setupNotificationSSEClient(ctx echo.Context) (*Notification
 	return client, service, nil
 }
 
+// notificationLastEventID returns the ID of the last notification a
+// reconnecting stream client received: the Last-Event-ID header, or the
+// lastEventId query parameter of clients that reconnect with a new EventSource
+func notificationLastEventID(ctx echo.Context) string {
+	if id := ctx.Request().Header.Get("Last-Event-ID"); id != "" {
+		return id
+	}
+	return ctx.QueryParam("lastEventId")
+}
+
+// replayMissedNotifications sends the notifications created since the last
+// notification a reconnecting client received, oldest first. Nothing is sent
+// when that notification has expired or was deleted.
+func (c *Controller) replayMissedNotifications(ctx echo.Context, clientID string, service *notification.Service, lastEventID string) error {
+	last, err := service.Get(lastEventID)
+	if err != nil {
+		if c.apiLogger != nil {
+			c.apiLogger.Debug("notification stream resume point not found",
+				"clientId", clientID)
+		}
+		return nil
+	}
+
+	since := last.Timestamp
+	missed, err := service.List(&notification.FilterOptions{Since: &since, Limit: maxReplayedNotifications})
+	if err != nil {
+		c.logNotificationError("failed to list missed notifications", err, clientID)
+		return nil
+	}
+
+	replayed := 0
+	for i := len(missed) - 1; i >= 0; i-- {
+		notif := missed[i]
+		if notif.ID == last.ID {
+			continue
+		}
+		if err := c.sendNotificationEvent(ctx, clientID, notif); err != nil {
+			return err
+		}
+		replayed++
+	}
+
+	if c.apiLogger != nil {
+		c.apiLogger.Info("notification stream resumed",
+			"clientId", clientID,
+			"replayed", replayed)
+	}
+	return nil
+}
+
 // setNotificationSSEHeaders sets the required headers for notification SSE
 func (c *Controller) setNotificationSSEHeaders(ctx echo.Context) {
 	ctx.Response().Header().Set("Content-Type", "text/event-stream")
@@ -314,7 +387,7 @@ func (c *Controller) sendNotificationEvent(ctx echo.Context, clientID string, no
 		EventType:    "notification",
 	}
 
-	if err := c.sendSSEMessage(ctx, "notification", event); err != nil {
+	if err := c.sendSSEEvent(ctx, notif.ID, "notification", event); err != nil {
 		c.logNotificationError("failed to send notification SSE", err, clientID)
 		if c.metrics != nil && c.metrics.HTTP != nil {
 			c.metrics.HTTP.RecordSSEError(sseEndpoint, "send_failed")
@@ -426,9 +499,11 @@ func (c *Controller) GetNotifications(ctx echo.Context) error {
 	// Build filter options from query parameters
 	filter := &notification.FilterOptions{}
 
-	// Parse status filter
+	// Parse status filter; dismissed notifications are only listed on request
 	if statusParam := ctx.QueryParam("status"); statusParam != "" {
 		filter.Status = []notification.Status{notification.Status(statusParam)}
+	} else {
+		filter.Status = []notification.Status{notification.StatusUnread, notification.StatusRead, notification.StatusAcknowledged}
 	}
 
 	// Parse type filter
@@ -444,7 +519,7 @@ func (c *Controller) GetNotifications(ctx echo.Context) error {
 	// Parse limit
 	if limitParam := ctx.QueryParam("limit"); limitParam != "" {
 		if limit, err := strconv.Atoi(limitParam); err == nil && limit > 0 {
-			filter.Limit = limit
+			filter.Limit = min(limit, maxNotificationsPageSize)
 		}
 	} else {
 		filter.Limit = 50 // Default limit
@@ -457,6 +532,19 @@ func (c *Controller) GetNotifications(ctx echo.Context) error {
 		}
 	}
 
+	// Parse time range
+	var err error
+	if filter.Since, err = parseNotificationTime(ctx.QueryParam("since")); err != nil {
+		return ctx.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid since time, expected RFC3339",
+		})
+	}
+	if filter.Until, err = parseNotificationTime(ctx.QueryParam("until")); err != nil {
+		return ctx.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid until time, expected RFC3339",
+		})
+	}
+
 	if c.apiLogger != nil && c.Settings != nil && c.Settings.WebServer.Debug {
 		c.apiLogger.Debug("listing notifications",
 			"status", filter.Status,
@@ -477,6 +565,17 @@ func (c *Controller) GetNotifications(ctx echo.Context) error {
 		})
 	}
 
+	// Total number of matching notifications for pagination
+	total, err := service.Count(filter)
+	if err != nil {
+		if c.apiLogger != nil {
+			c.apiLogger.Error("failed to count notifications", "error", err)
+		}
+		return ctx.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to retrieve notifications",
+		})
+	}
+
 	if c.apiLogger != nil && c.Settings != nil && c.Settings.WebServer.Debug {
 		unreadCount, err := service.GetUnreadCount()
 		if err != nil {
@@ -491,11 +590,25 @@ func (c *Controller) GetNotifications(ctx echo.Context) error {
 	return ctx.JSON(http.StatusOK, map[string]any{
 		"notifications": notifications,
 		"count":         len(notifications),
+		"total":         total,
 		"limit":         filter.Limit,
 		"offset":        filter.Offset,
+		"has_more":      filter.Offset+len(notifications) < total,
 	})
 }
 
+// parseNotificationTime parses an optional RFC3339 time query parameter
+func parseNotificationTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
 // GetNotification returns a single notification by ID
 func (c *Controller) GetNotification(ctx echo.Context) error {
 	if !notification.IsInitialized() {
@@ -595,6 +708,86 @@ func (c *Controller) MarkNotificationAcknowledged(ctx echo.Context) error {
 	})
 }
 
+// NotificationStatusRequest is the body of a notification status update
+type NotificationStatusRequest struct {
+	Status notification.Status `json:"status"`
+}
+
+// UpdateNotificationStatus marks a notification as read, unread,
+// acknowledged or dismissed and returns the updated notification
+func (c *Controller) UpdateNotificationStatus(ctx echo.Context) error {
+	if !notification.IsInitialized() {
+		return ctx.JSON(http.StatusServiceUnavailable, map[string]string{
+			"error": "Notification service not available",
+		})
+	}
+
+	id := ctx.Param("id")
+	if id == "" {
+		return ctx.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Notification ID is required",
+		})
+	}
+
+	var req NotificationStatusRequest
+	if err := ctx.Bind(&req); err != nil || !slices.Contains(notification.Statuses, req.Status) {
+		return ctx.JSON(http.StatusBadRequest, map[string]string{
+			"error": "status must be unread, read, acknowledged or dismissed",
+		})
+	}
+
+	notif, err := notification.GetService().SetStatus(id, req.Status)
+	if err != nil {
+		if errors.Is(err, notification.ErrNotificationNotFound) {
+			return ctx.JSON(http.StatusNotFound, map[string]string{
+				"error": "Notification not found",
+			})
+		}
+		if c.apiLogger != nil {
+			c.apiLogger.Error("failed to update notification status", "error", err, "id", id, "status", req.Status)
+		}
+		return ctx.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to update notification status",
+		})
+	}
+
+	if c.apiLogger != nil && c.Settings != nil && c.Settings.WebServer.Debug {
+		c.apiLogger.Debug("notification status updated", "id", id, "status", req.Status)
+	}
+
+	return ctx.JSON(http.StatusOK, notif)
+}
+
+// MarkAllNotificationsRead marks all unread notifications as read, optionally
+// only those of the type given by the type query parameter
+func (c *Controller) MarkAllNotificationsRead(ctx echo.Context) error {
+	if !notification.IsInitialized() {
+		return ctx.JSON(http.StatusServiceUnavailable, map[string]string{
+			"error": "Notification service not available",
+		})
+	}
+
+	filter := &notification.FilterOptions{}
+	if typeParam := ctx.QueryParam("type"); typeParam != "" {
+		filter.Types = []notification.Type{notification.Type(typeParam)}
+	}
+
+	updated, err := notification.GetService().MarkAllAsRead(filter)
+	if err != nil {
+		if c.apiLogger != nil {
+			c.apiLogger.Error("failed to mark notifications as read", "error", err, "updated", updated)
+		}
+		return ctx.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to mark notifications as read",
+		})
+	}
+
+	return ctx.JSON(http.StatusOK, map[string]any{
+		"message": "Notifications marked as read",
+		"updated": updated,
+	})
+}
+
 // DeleteNotification deletes a notification
 func (c *Controller) DeleteNotification(ctx echo.Context) error {
 	if !notification.IsInitialized() {
@@ -625,7 +818,8 @@ func (c *Controller) DeleteNotification(ctx echo.Context) error {
 	})
 }
 
-// GetUnreadCount returns the count of unread notifications
+// GetUnreadCount returns the count of unread notifications, in total and by
+// type
 func (c *Controller) GetUnreadCount(ctx echo.Context) error {
 	if !notification.IsInitialized() {
 		return ctx.JSON(http.StatusServiceUnavailable, map[string]string{
@@ -644,8 +838,78 @@ func (c *Controller) GetUnreadCount(ctx echo.Context) error {
 		})
 	}
 
+	byType, err := service.GetUnreadCountsByType()
+	if err != nil {
+		if c.apiLogger != nil {
+			c.apiLogger.Error("failed to get unread count by type", "error", err)
+		}
+		return ctx.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get unread count",
+		})
+	}
+
 	return ctx.JSON(http.StatusOK, map[string]any{
 		"unreadCount": count,
+		"byType":      byType,
+	})
+}
+
+// maxNotificationStatsDays is the longest period of notification statistics,
+// matching how long push delivery outcomes are kept
+const maxNotificationStatsDays = 31
+
+// GetNotificationStats summarizes notifications by type, priority and
+// component and push deliveries by provider and outcome over the last days,
+// with a timeline by hour or day
+func (c *Controller) GetNotificationStats(ctx echo.Context) error {
+	if !notification.IsInitialized() {
+		return ctx.JSON(http.StatusServiceUnavailable, map[string]string{
+			"error": "Notification service not available",
+		})
+	}
+
+	days := 7
+	if daysStr := ctx.QueryParam("days"); daysStr != "" {
+		parsed, err := strconv.Atoi(daysStr)
+		if err != nil || parsed < 1 || parsed > maxNotificationStatsDays {
+			return ctx.JSON(http.StatusBadRequest, map[string]string{
+				"error": "days must be between 1 and 31",
+			})
+		}
+		days = parsed
+	}
+
+	interval := 24 * time.Hour
+	switch ctx.QueryParam("interval") {
+	case "", "day":
+	case "hour":
+		interval = time.Hour
+	default:
+		return ctx.JSON(http.StatusBadRequest, map[string]string{
+			"error": "interval must be hour or day",
+		})
+	}
+
+	now := time.Now()
+	since := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).AddDate(0, 0, 1-days)
+	stats, err := notification.GetService().Stats(since, interval)
+	if err != nil {
+		if c.apiLogger != nil {
+			c.apiLogger.Error("failed to get notification stats", "error", err)
+		}
+		return ctx.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to get notification stats",
+		})
+	}
+
+	return ctx.JSON(http.StatusOK, stats)
+}
+
+// GetNotificationTemplateFunctions returns the documented helper functions
+// available in notification templates
+func (c *Controller) GetNotificationTemplateFunctions(ctx echo.Context) error {
+	return ctx.JSON(http.StatusOK, map[string]any{
+		"functions": templatefuncs.Functions(),
 	})
 }
 
@@ -690,6 +954,8 @@ func (c *Controller) CreateTestNewSpeciesNotification(ctx echo.Context) error {
 		Location:           "Test Location (Sample Data)",
 		DetectionURL:       baseURL + "/ui/detections/test",
 		ImageURL:           "https://static.avicommons.org/houfin-DzFZcHoKwyx9JOmg-320.jpg",
+		ClipURL:            baseURL + "/api/v2/media/signed/audio/test?expires=0&sig=sample",
+		SpectrogramURL:     baseURL + "/api/v2/media/signed/spectrogram/test?expires=0&sig=sample",
 		DaysSinceFirstSeen: 0,
 	}
 
@@ -769,3 +1035,56 @@ func (c *Controller) CreateTestNewSpeciesNotification(ctx echo.Context) error {
 
 	return ctx.JSON(http.StatusOK, testNotification)
 }
+
+// providerTestTimeout bounds the time a test of all notification providers
+// waits for the slowest provider
+const providerTestTimeout = 30 * time.Second
+
+// TestAllNotificationProviders sends a test message through every enabled
+// push provider in parallel and reports the latency and outcome of each
+func (c *Controller) TestAllNotificationProviders(ctx echo.Context) error {
+	dispatcher := notification.GetPushDispatcher()
+	if dispatcher == nil {
+		return ctx.JSON(http.StatusServiceUnavailable, map[string]string{
+			"error": "Push notifications not enabled",
+		})
+	}
+
+	testNotification := notification.NewNotification(notification.TypeSystem, notification.PriorityLow,
+		"BirdNET-Go test notification",
+		"This is a test message sent to verify the notification settings.").
+		WithComponent("notification-test").
+		WithMetadata("test", true)
+
+	testCtx, cancel := context.WithTimeout(ctx.Request().Context(), providerTestTimeout)
+	defer cancel()
+	start := time.Now()
+	results := dispatcher.TestProviders(testCtx, testNotification)
+
+	var succeeded, failed, skipped int
+	for i := range results {
+		switch {
+		case results[i].Skipped:
+			skipped++
+		case results[i].Success:
+			succeeded++
+		default:
+			failed++
+		}
+	}
+	if c.apiLogger != nil {
+		c.apiLogger.Info("notification providers tested",
+			"providers", len(results),
+			"succeeded", succeeded,
+			"failed", failed)
+	}
+
+	return ctx.JSON(http.StatusOK, map[string]any{
+		"results":     results,
+		"total":       len(results),
+		"succeeded":   succeeded,
+		"failed":      failed,
+		"skipped":     skipped,
+		"duration_ms": time.Since(start).Milliseconds(),
+	})
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/notification"
+)
+
+func TestGetNotificationStats(t *testing.T) {
+	service := notification.NewService(notification.DefaultServiceConfig())
+	if err := notification.SetServiceForTesting(service); err != nil {
+		service = notification.GetService()
+		require.NotNil(t, service, "Expected notification service to be available")
+	}
+	_, err := service.CreateWithComponent(notification.TypeError, notification.PriorityHigh, "Stats test", "Disk almost full", "stats-test")
+	require.NoError(t, err)
+
+	e := echo.New()
+	controller := mockController()
+
+	tests := []struct {
+		name  string
+		query string
+		code  int
+	}{
+		{"defaults", "", http.StatusOK},
+		{"hourly", "?days=2&interval=hour", http.StatusOK},
+		{"too many days", "?days=90", http.StatusBadRequest},
+		{"invalid interval", "?interval=week", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v2/notifications/stats"+tt.query, http.NoBody)
+			rec := httptest.NewRecorder()
+			require.NoError(t, controller.GetNotificationStats(e.NewContext(req, rec)))
+			require.Equal(t, tt.code, rec.Code, rec.Body.String())
+			if tt.code != http.StatusOK {
+				return
+			}
+
+			var stats notification.Stats
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &stats))
+			assert.GreaterOrEqual(t, stats.ByComponent["stats-test"], 1)
+			assert.NotEmpty(t, stats.Timeline)
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/notification"
+)
+
+func TestUpdateNotificationStatus(t *testing.T) {
+	service := notification.NewService(notification.DefaultServiceConfig())
+	if err := notification.SetServiceForTesting(service); err != nil {
+		service = notification.GetService()
+		require.NotNil(t, service, "Expected notification service to be available")
+	}
+	notif, err := service.CreateWithComponent(notification.TypeWarning, notification.PriorityMedium, "Status test", "Microphone disconnected", "status-test")
+	require.NoError(t, err)
+
+	e := echo.New()
+	controller := mockController()
+
+	tests := []struct {
+		name string
+		id   string
+		body string
+		code int
+	}{
+		{"read", notif.ID, `{"status":"read"}`, http.StatusOK},
+		{"unread", notif.ID, `{"status":"unread"}`, http.StatusOK},
+		{"dismissed", notif.ID, `{"status":"dismissed"}`, http.StatusOK},
+		{"unknown status", notif.ID, `{"status":"archived"}`, http.StatusBadRequest},
+		{"missing notification", "missing", `{"status":"read"}`, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPatch, "/api/v2/notifications/"+tt.id, strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			ctx.SetParamNames("id")
+			ctx.SetParamValues(tt.id)
+			require.NoError(t, controller.UpdateNotificationStatus(ctx))
+			require.Equal(t, tt.code, rec.Code, rec.Body.String())
+			if tt.code != http.StatusOK {
+				return
+			}
+
+			var updated notification.Notification
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &updated))
+			assert.Equal(t, notif.ID, updated.ID)
+			assert.Equal(t, tt.name, string(updated.Status))
+		})
+	}
+
+	// Dismissed notifications are left out of the list unless requested
+	req := httptest.NewRequest(http.MethodGet, "/api/v2/notifications?limit=500", http.NoBody)
+	rec := httptest.NewRecorder()
+	require.NoError(t, controller.GetNotifications(e.NewContext(req, rec)))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), notif.ID)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v2/notifications?status=dismissed&limit=500", http.NoBody)
+	rec = httptest.NewRecorder()
+	require.NoError(t, controller.GetNotifications(e.NewContext(req, rec)))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), notif.ID)
+
+	// Unread counts by type, then marking all of a type as read
+	_, err = service.SetStatus(notif.ID, notification.StatusUnread)
+	require.NoError(t, err)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v2/notifications/unread/count", http.NoBody)
+	rec = httptest.NewRecorder()
+	require.NoError(t, controller.GetUnreadCount(e.NewContext(req, rec)))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var counts struct {
+		UnreadCount int                       `json:"unreadCount"`
+		ByType      map[notification.Type]int `json:"byType"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &counts))
+	assert.GreaterOrEqual(t, counts.ByType[notification.TypeWarning], 1)
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v2/notifications/read-all?type=warning", http.NoBody)
+	rec = httptest.NewRecorder()
+	require.NoError(t, controller.MarkAllNotificationsRead(e.NewContext(req, rec)))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	byType, err := service.GetUnreadCountsByType()
+	require.NoError(t, err)
+	assert.Zero(t, byType[notification.TypeWarning])
+}
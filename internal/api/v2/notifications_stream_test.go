@@ -0,0 +1,65 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/notification"
+)
+
+func TestReplayMissedNotifications(t *testing.T) {
+	t.Parallel()
+
+	service := notification.NewService(notification.DefaultServiceConfig())
+	defer service.Stop()
+
+	var created []*notification.Notification
+	for i, title := range []string{"First", "Second", "Third"} {
+		notif := notification.NewNotification(notification.TypeInfo, notification.PriorityMedium, title, "Stream test")
+		notif.Timestamp = notif.Timestamp.Add(time.Duration(i) * time.Second)
+		require.NoError(t, service.CreateWithMetadata(notif))
+		created = append(created, notif)
+	}
+
+	e := echo.New()
+	controller := mockController()
+	replay := func(lastEventID string) string {
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(httptest.NewRequest(http.MethodGet, sseEndpoint, http.NoBody), rec)
+		require.NoError(t, controller.replayMissedNotifications(ctx, "client", service, lastEventID))
+		return rec.Body.String()
+	}
+
+	// The notifications after the last one received are sent oldest first,
+	// with their IDs for the next resume
+	body := replay(created[0].ID)
+	assert.NotContains(t, body, "id: "+created[0].ID)
+	second := strings.Index(body, "id: "+created[1].ID+"\nevent: notification\n")
+	third := strings.Index(body, "id: "+created[2].ID+"\nevent: notification\n")
+	require.GreaterOrEqual(t, second, 0, body)
+	assert.Greater(t, third, second, "missed notifications are replayed in order")
+
+	assert.Empty(t, replay(created[2].ID), "nothing is missed after the latest notification")
+	assert.Empty(t, replay("unknown"), "unknown resume points are ignored")
+}
+
+func TestNotificationLastEventID(t *testing.T) {
+	t.Parallel()
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, sseEndpoint+"?lastEventId=from-query", http.NoBody)
+	assert.Equal(t, "from-query", notificationLastEventID(e.NewContext(req, httptest.NewRecorder())))
+
+	req.Header.Set("Last-Event-ID", "from-header")
+	assert.Equal(t, "from-header", notificationLastEventID(e.NewContext(req, httptest.NewRecorder())),
+		"the header of a native EventSource reconnect takes precedence")
+
+	req = httptest.NewRequest(http.MethodGet, sseEndpoint, http.NoBody)
+	assert.Empty(t, notificationLastEventID(e.NewContext(req, httptest.NewRecorder())))
+}
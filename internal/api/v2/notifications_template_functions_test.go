@@ -0,0 +1,32 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/notification/templatefuncs"
+)
+
+func TestGetNotificationTemplateFunctions(t *testing.T) {
+	t.Parallel()
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/api/v2/notifications/template-functions", http.NoBody)
+	rec := httptest.NewRecorder()
+
+	controller := mockController()
+	require.NoError(t, controller.GetNotificationTemplateFunctions(e.NewContext(req, rec)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response struct {
+		Functions []templatefuncs.Function `json:"functions"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, templatefuncs.Functions(), response.Functions)
+	assert.NotEmpty(t, response.Functions)
+}
@@ -0,0 +1,26 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/notification"
+)
+
+func TestTestAllNotificationProviders_PushDisabled(t *testing.T) {
+	if notification.GetPushDispatcher() != nil {
+		t.Skip("push dispatcher initialized by another test")
+	}
+
+	e := echo.New()
+	controller := mockController()
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/notifications/test/all", http.NoBody)
+	rec := httptest.NewRecorder()
+	require.NoError(t, controller.TestAllNotificationProviders(e.NewContext(req, rec)))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Push notifications not enabled")
+}
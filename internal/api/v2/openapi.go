@@ -0,0 +1,308 @@
+// internal/api/v2/openapi.go
+package api
+
+import (
+	_ "embed"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/security"
+)
+
+// apiReadme documents every endpoint in a table row with its handler, whether
+// it needs authentication and a description, which the OpenAPI document reuses
+//
+//go:embed README.md
+var apiReadme string
+
+// openAPIVersion is the OpenAPI specification version of the document
+const openAPIVersion = "3.0.3"
+
+// swaggerUIVersion is the swagger-ui-dist release the API docs page loads
+const swaggerUIVersion = "5.17.14"
+
+// OpenAPIDocument is an OpenAPI 3 description of the API
+type OpenAPIDocument struct {
+	OpenAPI    string                                 `json:"openapi"`
+	Info       OpenAPIInfo                            `json:"info"`
+	Servers    []OpenAPIServer                        `json:"servers"`
+	Tags       []OpenAPITag                           `json:"tags"`
+	Paths      map[string]map[string]OpenAPIOperation `json:"paths"` // path, then lower case method
+	Components OpenAPIComponents                      `json:"components"`
+}
+
+// OpenAPIInfo describes the API
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Version     string `json:"version"`
+}
+
+// OpenAPIServer is a base URL of the API
+type OpenAPIServer struct {
+	URL string `json:"url"`
+}
+
+// OpenAPITag groups the operations of an area of the API
+type OpenAPITag struct {
+	Name string `json:"name"`
+}
+
+// OpenAPIOperation describes an endpoint
+type OpenAPIOperation struct {
+	OperationID string                     `json:"operationId"`
+	Summary     string                     `json:"summary,omitempty"`
+	Tags        []string                   `json:"tags"`
+	Parameters  []OpenAPIParameter         `json:"parameters,omitempty"`
+	Security    []map[string][]string      `json:"security,omitempty"`
+	Deprecated  bool                       `json:"deprecated,omitempty"`
+	Responses   map[string]OpenAPIResponse `json:"responses"`
+}
+
+// OpenAPIParameter describes a path parameter
+type OpenAPIParameter struct {
+	Name     string        `json:"name"`
+	In       string        `json:"in"`
+	Required bool          `json:"required"`
+	Schema   OpenAPISchema `json:"schema"`
+}
+
+// OpenAPIResponse describes a response of an operation
+type OpenAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]OpenAPIMediaType `json:"content,omitempty"`
+}
+
+// OpenAPIMediaType is the schema of a response body
+type OpenAPIMediaType struct {
+	Schema OpenAPISchema `json:"schema"`
+}
+
+// OpenAPISchema is a JSON schema, or a reference to one
+type OpenAPISchema struct {
+	Ref        string                   `json:"$ref,omitempty"`
+	Type       string                   `json:"type,omitempty"`
+	Properties map[string]OpenAPISchema `json:"properties,omitempty"`
+}
+
+// OpenAPIComponents holds the schemas and security schemes referenced by operations
+type OpenAPIComponents struct {
+	Schemas         map[string]OpenAPISchema         `json:"schemas"`
+	SecuritySchemes map[string]OpenAPISecurityScheme `json:"securitySchemes"`
+}
+
+// OpenAPISecurityScheme describes how a client authenticates
+type OpenAPISecurityScheme struct {
+	Type   string `json:"type"`
+	Scheme string `json:"scheme,omitempty"`
+	In     string `json:"in,omitempty"`
+	Name   string `json:"name,omitempty"`
+}
+
+// endpointDoc is an endpoint as documented in the README
+type endpointDoc struct {
+	tag     string
+	summary string
+	auth    bool
+}
+
+// readmeSectionPattern matches a README section of endpoints, such as
+// "### Analytics (`analytics.go`)"
+var readmeSectionPattern = regexp.MustCompile("^###\\s+(.+?)\\s+\\(`")
+
+// readmeRowPattern matches an endpoint row of a README table:
+// | Method | Route | Handler | Auth | Description |
+var readmeRowPattern = regexp.MustCompile("^\\|\\s*(GET|POST|PUT|PATCH|DELETE|HEAD|OPTIONS)\\s*\\|\\s*`([^`]+)`\\s*\\|\\s*`([^`]+)`\\s*\\|([^|]*)\\|([^|]*)\\|")
+
+// parseEndpointDocs returns the endpoints documented in README tables by
+// method and handler name
+func parseEndpointDocs(readme string) map[string]endpointDoc {
+	docs := map[string]endpointDoc{}
+	tag := ""
+	for line := range strings.SplitSeq(readme, "\n") {
+		line = strings.TrimSpace(line)
+		if m := readmeSectionPattern.FindStringSubmatch(line); m != nil {
+			tag = m[1]
+			continue
+		}
+		m := readmeRowPattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		docs[m[1]+" "+m[3]] = endpointDoc{
+			tag:     tag,
+			summary: strings.TrimSpace(m[5]),
+			auth:    strings.Contains(m[4], "✅"),
+		}
+	}
+	return docs
+}
+
+// handlerName returns the method name of a route handler from the function
+// name Echo records, such as api.(*Controller).GetDetection-fm
+func handlerName(routeName string) string {
+	name := strings.TrimSuffix(routeName, "-fm")
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+// openAPIPath converts an Echo route to an OpenAPI path and its parameters,
+// such as /detections/:id to /detections/{id}
+func openAPIPath(route string) (string, []OpenAPIParameter) {
+	segments := strings.Split(route, "/")
+	var params []OpenAPIParameter
+	for i, segment := range segments {
+		name := ""
+		switch {
+		case strings.HasPrefix(segment, ":"):
+			name = segment[1:]
+		case segment == "*":
+			name = "path"
+		default:
+			continue
+		}
+		segments[i] = "{" + name + "}"
+		params = append(params, OpenAPIParameter{Name: name, In: "path", Required: true, Schema: OpenAPISchema{Type: "string"}})
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// buildOpenAPIDocument describes the registered v2 routes, documented in the
+// README or not
+func (c *Controller) buildOpenAPIDocument() *OpenAPIDocument {
+	docs := parseEndpointDocs(apiReadme)
+	version := ""
+	if c.Settings != nil {
+		version = c.Settings.Version
+	}
+
+	doc := &OpenAPIDocument{
+		OpenAPI: openAPIVersion,
+		Info: OpenAPIInfo{
+			Title:       "BirdNET-Go API",
+			Description: "REST API of BirdNET-Go for bird detections, analytics and station management",
+			Version:     version,
+		},
+		Servers: []OpenAPIServer{{URL: "/api/v2"}},
+		Tags:    []OpenAPITag{},
+		Paths:   map[string]map[string]OpenAPIOperation{},
+		Components: OpenAPIComponents{
+			Schemas: map[string]OpenAPISchema{
+				"ErrorResponse": {Type: "object", Properties: map[string]OpenAPISchema{
+					"error":          {Type: "string"},
+					"message":        {Type: "string"},
+					"code":           {Type: "integer"},
+					"correlation_id": {Type: "string"},
+				}},
+			},
+			SecuritySchemes: map[string]OpenAPISecurityScheme{
+				"bearerAuth": {Type: "http", Scheme: "bearer"},
+				"apiKeyAuth": {Type: "apiKey", In: "header", Name: security.APIKeyHeader},
+			},
+		},
+	}
+	if c.Echo == nil {
+		return doc
+	}
+
+	var tags []string
+	for _, route := range c.Echo.Routes() {
+		relative, ok := strings.CutPrefix(route.Path, "/api/v2")
+		if !ok || relative == "" || route.Method == echo.RouteNotFound {
+			continue
+		}
+		name := handlerName(route.Name)
+		path, params := openAPIPath(relative)
+
+		endpoint, documented := docs[route.Method+" "+name]
+		if !documented || endpoint.tag == "" {
+			first, _, _ := strings.Cut(strings.TrimPrefix(relative, "/"), "/")
+			endpoint.tag = first
+		}
+		if !slices.Contains(tags, endpoint.tag) {
+			tags = append(tags, endpoint.tag)
+		}
+
+		operation := OpenAPIOperation{
+			OperationID: name,
+			Summary:     endpoint.summary,
+			Tags:        []string{endpoint.tag},
+			Parameters:  params,
+			Responses: map[string]OpenAPIResponse{
+				"200": {Description: "Success"},
+				"default": {Description: "Error", Content: map[string]OpenAPIMediaType{
+					"application/json": {Schema: OpenAPISchema{Ref: "#/components/schemas/ErrorResponse"}},
+				}},
+			},
+		}
+		if endpoint.auth {
+			operation.Security = []map[string][]string{{"bearerAuth": {}}, {"apiKeyAuth": {}}}
+		}
+		if _, deprecated := c.deprecations[deprecationKey(route.Method, route.Path)]; deprecated {
+			operation.Deprecated = true
+		}
+
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = map[string]OpenAPIOperation{}
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = operation
+	}
+
+	slices.Sort(tags)
+	for _, tag := range tags {
+		doc.Tags = append(doc.Tags, OpenAPITag{Name: tag})
+	}
+	return doc
+}
+
+// initOpenAPIRoutes registers the OpenAPI document and the API docs page
+func (c *Controller) initOpenAPIRoutes() {
+	c.Group.GET("/openapi.json", c.GetOpenAPIDocument)
+	c.Group.GET("/docs", c.GetAPIDocs)
+}
+
+// GetOpenAPIDocument handles GET /api/v2/openapi.json
+// It returns an OpenAPI 3 document of the registered routes, for generating
+// client SDKs. Summaries and authentication come from the endpoint tables of
+// the API README.
+func (c *Controller) GetOpenAPIDocument(ctx echo.Context) error {
+	return ctx.JSON(http.StatusOK, c.buildOpenAPIDocument())
+}
+
+// GetAPIDocs handles GET /api/v2/docs
+// It serves Swagger UI for the OpenAPI document when webserver.apidocs is
+// enabled. Swagger UI is loaded from unpkg.com, which the page's own
+// Content-Security-Policy allows.
+func (c *Controller) GetAPIDocs(ctx echo.Context) error {
+	if c.Settings == nil || !c.Settings.WebServer.APIDocs {
+		return ctx.JSON(http.StatusNotFound, map[string]string{"error": "API docs are disabled"})
+	}
+
+	cdn := "https://unpkg.com/swagger-ui-dist@" + swaggerUIVersion
+	ctx.Response().Header().Set("Content-Security-Policy", "default-src 'self'; "+
+		"script-src 'self' 'unsafe-inline' https://unpkg.com; "+
+		"style-src 'self' 'unsafe-inline' https://unpkg.com; "+
+		"img-src 'self' data: https:; "+
+		"object-src 'none'")
+	return ctx.HTML(http.StatusOK, `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>BirdNET-Go API</title>
+  <link rel="stylesheet" href="`+cdn+`/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="`+cdn+`/swagger-ui-bundle.js"></script>
+  <script>
+    SwaggerUIBundle({ url: "/api/v2/openapi.json", dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>
+`)
+}
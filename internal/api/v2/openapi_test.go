@@ -0,0 +1,90 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEndpointDocs(t *testing.T) {
+	t.Parallel()
+
+	docs := parseEndpointDocs(apiReadme)
+	heatHours, ok := docs["GET GetHeatHours"]
+	require.True(t, ok, "README rows are parsed")
+	assert.Equal(t, "Analytics", heatHours.tag)
+	assert.False(t, heatHours.auth)
+	assert.NotEmpty(t, heatHours.summary)
+
+	schema, ok := docs["GET GetDatabaseSchema"]
+	require.True(t, ok)
+	assert.True(t, schema.auth)
+}
+
+func TestOpenAPIPath(t *testing.T) {
+	t.Parallel()
+
+	path, params := openAPIPath("/detections/:id/review")
+	assert.Equal(t, "/detections/{id}/review", path)
+	require.Len(t, params, 1)
+	assert.Equal(t, "id", params[0].Name)
+	assert.True(t, params[0].Required)
+
+	path, params = openAPIPath("/media/*")
+	assert.Equal(t, "/media/{path}", path)
+	require.Len(t, params, 1)
+
+	assert.Equal(t, "GetHeatHours", handlerName("github.com/tphakala/birdnet-go/internal/api/v2.(*Controller).GetHeatHours-fm"))
+}
+
+func TestGetOpenAPIDocument(t *testing.T) {
+	t.Parallel()
+
+	e, _, controller := setupTestEnvironment(t)
+	controller.initAnalyticsRoutes()
+	controller.initSystemRoutes()
+	controller.initOpenAPIRoutes()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v2/openapi.json", http.NoBody)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var doc OpenAPIDocument
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+	assert.Equal(t, openAPIVersion, doc.OpenAPI)
+
+	heatHours, ok := doc.Paths["/analytics/heat-hours"]["get"]
+	require.True(t, ok)
+	assert.Equal(t, "GetHeatHours", heatHours.OperationID)
+	assert.Equal(t, []string{"Analytics"}, heatHours.Tags)
+	assert.Empty(t, heatHours.Security)
+
+	schema, ok := doc.Paths["/system/schema"]["get"]
+	require.True(t, ok)
+	assert.NotEmpty(t, schema.Security, "authenticated endpoints list their security schemes")
+}
+
+func TestGetAPIDocs(t *testing.T) {
+	t.Parallel()
+
+	e, _, controller := setupTestEnvironment(t)
+	get := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v2/docs", http.NoBody)
+		rec := httptest.NewRecorder()
+		require.NoError(t, controller.GetAPIDocs(e.NewContext(req, rec)))
+		return rec
+	}
+
+	assert.Equal(t, http.StatusNotFound, get().Code)
+
+	controller.Settings.WebServer.APIDocs = true
+	rec := get()
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "/api/v2/openapi.json")
+	assert.Contains(t, rec.Header().Get("Content-Security-Policy"), "https://unpkg.com")
+}
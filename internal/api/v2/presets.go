@@ -0,0 +1,101 @@
+// internal/api/v2/presets.go
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/errors"
+	"github.com/tphakala/birdnet-go/internal/regionpreset"
+	"github.com/tphakala/birdnet-go/internal/telemetry"
+)
+
+// initPresetRoutes registers the regional configuration preset endpoints
+func (c *Controller) initPresetRoutes() {
+	presetGroup := c.Group.Group("/settings/presets", c.AuthMiddleware)
+	presetGroup.GET("", c.ListPresets)
+	presetGroup.GET("/:id", c.GetPreset)
+	presetGroup.POST("/:id/apply", c.ApplyPreset, c.IdempotencyMiddleware)
+}
+
+// ListPresets handles GET /api/v2/settings/presets
+// It returns the regional presets the setup wizard offers.
+func (c *Controller) ListPresets(ctx echo.Context) error {
+	presets, err := regionpreset.All()
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to load presets", http.StatusInternalServerError)
+	}
+	return ctx.JSON(http.StatusOK, presets)
+}
+
+// GetPreset handles GET /api/v2/settings/presets/:id
+func (c *Controller) GetPreset(ctx echo.Context) error {
+	preset, err := c.lookupPreset(ctx)
+	if preset == nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, preset)
+}
+
+// ApplyPreset handles POST /api/v2/settings/presets/:id/apply
+// It sets the locale, species thresholds, sensitive species and notification
+// quiet hours of a preset and saves the settings, rolling back when applying
+// or saving them fails.
+func (c *Controller) ApplyPreset(ctx echo.Context) error {
+	preset, err := c.lookupPreset(ctx)
+	if preset == nil {
+		return err
+	}
+
+	c.settingsMutex.Lock()
+	defer c.settingsMutex.Unlock()
+
+	settings := c.Settings
+	if settings == nil {
+		if settings = conf.Setting(); settings == nil {
+			return c.HandleError(ctx, errors.Newf("settings not initialized").
+				Component("api").
+				Category(errors.CategoryConfiguration).
+				Build(), "Failed to get settings", http.StatusInternalServerError)
+		}
+	}
+	// Deep copy so the rollback does not share slices and maps with the
+	// settings the preset changes
+	oldSettings := settings.Clone()
+
+	applied, err := preset.Apply(settings)
+	if err != nil {
+		*settings = *oldSettings
+		return c.HandleError(ctx, err, "Failed to apply preset", http.StatusInternalServerError)
+	}
+	if err := c.handleSettingsChanges(oldSettings, settings); err != nil {
+		*settings = *oldSettings
+		return c.HandleError(ctx, err, "Failed to apply settings changes, rolled back to previous settings", http.StatusInternalServerError)
+	}
+	if !c.DisableSaveSettings {
+		if err := conf.SaveSettings(); err != nil {
+			*settings = *oldSettings
+			return c.HandleError(ctx, err, "Failed to save settings, rolled back to previous settings", http.StatusInternalServerError)
+		}
+	}
+	telemetry.UpdateTelemetryEnabled()
+
+	return ctx.JSON(http.StatusOK, applied)
+}
+
+// lookupPreset returns the preset of the id path parameter, or nil with the
+// error response sent
+func (c *Controller) lookupPreset(ctx echo.Context) (*regionpreset.Preset, error) {
+	preset, found, err := regionpreset.Get(ctx.Param("id"))
+	if err != nil {
+		return nil, c.HandleError(ctx, err, "Failed to load presets", http.StatusInternalServerError)
+	}
+	if !found {
+		return nil, c.HandleError(ctx, errors.Newf("preset %q not found", ctx.Param("id")).
+			Component("api").
+			Category(errors.CategoryNotFound).
+			Build(), "Preset not found", http.StatusNotFound)
+	}
+	return &preset, nil
+}
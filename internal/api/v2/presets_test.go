@@ -0,0 +1,49 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/regionpreset"
+)
+
+func TestPresetEndpoints(t *testing.T) {
+	t.Parallel()
+
+	e, _, controller := setupTestEnvironment(t)
+	controller.DisableSaveSettings = true
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v2/settings/presets", http.NoBody)
+	rec := httptest.NewRecorder()
+	require.NoError(t, controller.ListPresets(e.NewContext(req, rec)))
+	var presets []regionpreset.Preset
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &presets))
+	require.NotEmpty(t, presets)
+
+	get := func(handler echo.HandlerFunc, method, id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/v2/settings/presets/"+id, http.NoBody)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.SetParamNames("id")
+		ctx.SetParamValues(id)
+		require.NoError(t, handler(ctx))
+		return rec
+	}
+
+	rec = get(controller.GetPreset, http.MethodGet, "nowhere")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = get(controller.ApplyPreset, http.MethodPost, "uk")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var applied regionpreset.Applied
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &applied))
+	assert.Equal(t, "uk", applied.Preset)
+	assert.Equal(t, "en-uk", controller.Settings.BirdNET.Locale)
+	assert.Contains(t, controller.Settings.Realtime.Species.Sensitive, "Circus cyaneus")
+	assert.True(t, controller.Settings.Notification.Email.QuietHours.Enabled)
+}
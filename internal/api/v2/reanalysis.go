@@ -0,0 +1,83 @@
+// internal/api/v2/reanalysis.go
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// maxReanalysisDetections limits the re-analysis results compared in one request
+const maxReanalysisDetections = 50000
+
+// ReanalysisDiffer is implemented by datastores that can compare stored
+// detections with re-analysis results
+type ReanalysisDiffer interface {
+	DiffReanalysis(start, end time.Time, sourceNode string, results []datastore.ReanalysisDetection) (*datastore.ReanalysisDiff, error)
+}
+
+// ReanalysisDiffRequest represents the results of re-analysing the audio
+// recorded between Start and End, for example with an upgraded model
+type ReanalysisDiffRequest struct {
+	Start      time.Time                       `json:"start"`
+	End        time.Time                       `json:"end"`
+	SourceNode string                          `json:"source_node,omitempty"` // Limits stored detections to one node
+	Detections []datastore.ReanalysisDetection `json:"detections"`
+}
+
+// DiffReanalysis handles POST /api/v2/detections/reanalysis/diff
+// It compares the stored detections of a period with the results of
+// re-analysing its audio per segment: species changed, confidence deltas and
+// detections gained or lost. Stored detections are not changed, so the impact
+// of a model upgrade can be evaluated before the new results are accepted.
+func (c *Controller) DiffReanalysis(ctx echo.Context) error {
+	var req ReanalysisDiffRequest
+	if err := ctx.Bind(&req); err != nil {
+		return c.HandleError(ctx, err, "Failed to parse request body", http.StatusBadRequest)
+	}
+	if req.Start.IsZero() || req.End.IsZero() {
+		return c.HandleError(ctx, errors.Newf("start and end are required").
+			Component("api").
+			Category(errors.CategoryValidation).
+			Build(), "Start and end of the re-analysed period are required", http.StatusBadRequest)
+	}
+	if len(req.Detections) > maxReanalysisDetections {
+		return c.HandleError(ctx, errors.Newf("too many re-analysis detections: %d", len(req.Detections)).
+			Component("api").
+			Category(errors.CategoryValidation).
+			Context("max", maxReanalysisDetections).
+			Build(), "Too many detections, compare a shorter period", http.StatusRequestEntityTooLarge)
+	}
+
+	differ, ok := c.DS.(ReanalysisDiffer)
+	if !ok {
+		return c.HandleError(ctx, errors.Newf("datastore does not support re-analysis comparison").
+			Component("api").
+			Category(errors.CategoryConfiguration).
+			Build(), "Re-analysis comparison is not supported by this datastore", http.StatusNotImplemented)
+	}
+
+	diff, err := differ.DiffReanalysis(req.Start, req.End, req.SourceNode, req.Detections)
+	if err != nil {
+		var enhancedErr *errors.EnhancedError
+		if errors.As(err, &enhancedErr) && enhancedErr.Category == errors.CategoryValidation {
+			return c.HandleError(ctx, err, "Invalid re-analysis results", http.StatusBadRequest)
+		}
+		return c.HandleError(ctx, err, "Failed to compare re-analysis results", http.StatusInternalServerError)
+	}
+
+	c.logAPIRequest(ctx, slog.LevelInfo, "Re-analysis results compared",
+		"start", req.Start.Format(time.RFC3339),
+		"end", req.End.Format(time.RFC3339),
+		"source_node", req.SourceNode,
+		"unchanged", diff.Summary.Unchanged,
+		"species_changed", diff.Summary.SpeciesChanged,
+		"gained", diff.Summary.Gained,
+		"lost", diff.Summary.Lost)
+
+	return ctx.JSON(http.StatusOK, diff)
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+// reanalysisMockDataStore adds re-analysis comparison to MockDataStore
+type reanalysisMockDataStore struct {
+	*MockDataStore
+	notes      []datastore.Note
+	sourceNode string
+}
+
+func (m *reanalysisMockDataStore) DiffReanalysis(start, end time.Time, sourceNode string, results []datastore.ReanalysisDetection) (*datastore.ReanalysisDiff, error) {
+	m.sourceNode = sourceNode
+	return datastore.DiffDetections(m.notes, results), nil
+}
+
+// postReanalysisDiff sends a re-analysis comparison request and returns the response recorder
+func postReanalysisDiff(t *testing.T, controller *Controller, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/detections/reanalysis/diff", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	require.NoError(t, controller.DiffReanalysis(controller.Echo.NewContext(req, rec)))
+	return rec
+}
+
+func TestDiffReanalysis(t *testing.T) {
+	t.Parallel()
+
+	_, mockDS, controller := setupTestEnvironment(t)
+	begin := time.Date(2024, 5, 1, 6, 0, 0, 0, time.UTC)
+	store := &reanalysisMockDataStore{
+		MockDataStore: mockDS,
+		notes: []datastore.Note{
+			{ID: 7, BeginTime: begin, EndTime: begin.Add(3 * time.Second), ScientificName: "Parus major", Confidence: 0.8},
+		},
+	}
+	controller.DS = store
+
+	rec := postReanalysisDiff(t, controller, `{
+		"start": "2024-05-01T00:00:00Z",
+		"end": "2024-05-02T00:00:00Z",
+		"source_node": "garden",
+		"detections": [
+			{"begin_time": "2024-05-01T06:00:00Z", "end_time": "2024-05-01T06:00:03Z", "scientific_name": "Cyanistes caeruleus", "confidence": 0.7}
+		]
+	}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var diff datastore.ReanalysisDiff
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &diff))
+	assert.Equal(t, 1, diff.Summary.SpeciesChanged)
+	require.Len(t, diff.Segments, 1)
+	assert.Equal(t, uint(7), diff.Segments[0].NoteID)
+	assert.Equal(t, "garden", store.sourceNode)
+
+	rec = postReanalysisDiff(t, controller, `{"detections": []}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestDiffReanalysis_Unsupported(t *testing.T) {
+	t.Parallel()
+
+	_, _, controller := setupTestEnvironment(t)
+	rec := postReanalysisDiff(t, controller, `{"start": "2024-05-01T00:00:00Z", "end": "2024-05-02T00:00:00Z"}`)
+	assert.Equal(t, http.StatusNotImplemented, rec.Code)
+}
@@ -0,0 +1,52 @@
+// internal/api/v2/roles.go
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/api/v2/auth"
+	"github.com/tphakala/birdnet-go/internal/security"
+)
+
+// SessionRoleMiddleware limits browser sessions of editors and viewers to what
+// a detections:write or read-only API key may do. Requests with a bearer token
+// or API key carry their own permissions, and logging in and out is open to
+// every role.
+func (c *Controller) SessionRoleMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			req := ctx.Request()
+			if auth.IsAPIKeyAuthenticated(ctx) || req.Header.Get("Authorization") != "" {
+				return next(ctx)
+			}
+			if routeHasPrefix(strings.TrimPrefix(req.URL.Path, "/api/v2"), []string{"/auth"}) {
+				return next(ctx)
+			}
+			role := security.SessionRole(req)
+			if role == "" || role == security.RoleAdmin {
+				return next(ctx)
+			}
+			// Clients that need no authentication are not limited by a login
+			if c.AuthService == nil || !c.AuthService.IsAuthRequired(ctx) {
+				return next(ctx)
+			}
+
+			if !security.RoleAllows(role, requiredAPIKeyScope(req.Method, req.URL.Path)) {
+				if c.apiLogger != nil {
+					c.apiLogger.Warn("Session role denied a request",
+						"role", role,
+						"method", req.Method,
+						"path", req.URL.Path,
+						"ip", ctx.RealIP(),
+					)
+				}
+				return ctx.JSON(http.StatusForbidden, map[string]string{
+					"error": "Your role does not allow this action",
+				})
+			}
+			return next(ctx)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/sessions"
+	"github.com/labstack/echo/v4"
+	"github.com/markbates/goth/gothic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/security"
+)
+
+func TestSessionRoleMiddleware(t *testing.T) {
+	gothic.Store = sessions.NewCookieStore([]byte("test-secret"))
+
+	e := echo.New()
+	controller := &Controller{
+		AuthService: &tokenAuthService{tokens: map[string]bool{"oauth-token": true}},
+		logger:      log.New(io.Discard, "", 0),
+	}
+	ok := func(ctx echo.Context) error { return ctx.NoContent(http.StatusOK) }
+	group := e.Group("/api/v2", controller.SessionRoleMiddleware())
+	group.GET("/detections", ok)
+	group.POST("/detections/:id/review", ok)
+	group.DELETE("/detections/:id", ok)
+	group.GET("/settings", ok)
+	group.POST("/auth/logout", ok)
+	group.POST("/dashboards", ok)
+	group.PUT("/dashboards/:id", ok)
+	group.DELETE("/saved-searches/:id", ok)
+	group.PUT("/notifications/:id/read", ok)
+	group.PUT("/notifications/:id/acknowledge", ok)
+	group.PATCH("/notifications/:id", ok)
+	group.POST("/notifications/read-all", ok)
+	group.DELETE("/notifications/:id", ok)
+	group.POST("/detections/:id/restore", ok)
+
+	sessionCookie := func(role string) *http.Cookie {
+		req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+		rec := httptest.NewRecorder()
+		require.NoError(t, gothic.StoreInSession(security.RoleSessionKey, role, req, rec))
+		return rec.Result().Cookies()[0]
+	}
+	request := func(method, path string, cookie *http.Cookie, authorization string) int {
+		req := httptest.NewRequest(method, path, http.NoBody)
+		req.AddCookie(cookie)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	viewer := sessionCookie(security.RoleViewer)
+	assert.Equal(t, http.StatusOK, request(http.MethodGet, "/api/v2/detections", viewer, ""))
+	assert.Equal(t, http.StatusForbidden, request(http.MethodPost, "/api/v2/detections/1/review", viewer, ""))
+	assert.Equal(t, http.StatusForbidden, request(http.MethodGet, "/api/v2/settings", viewer, ""))
+	assert.Equal(t, http.StatusOK, request(http.MethodPost, "/api/v2/detections/1/review", viewer, "Bearer oauth-token"), "tokens carry their own permissions")
+
+	// Viewers log out and change their own dashboards, saved searches and notification state
+	for _, r := range []struct{ method, path string }{
+		{http.MethodPost, "/api/v2/auth/logout"},
+		{http.MethodPost, "/api/v2/dashboards"},
+		{http.MethodPut, "/api/v2/dashboards/1"},
+		{http.MethodDelete, "/api/v2/saved-searches/1"},
+		{http.MethodPut, "/api/v2/notifications/1/read"},
+		{http.MethodPut, "/api/v2/notifications/1/acknowledge"},
+		{http.MethodPatch, "/api/v2/notifications/1"},
+		{http.MethodPost, "/api/v2/notifications/read-all"},
+	} {
+		assert.Equal(t, http.StatusOK, request(r.method, r.path, viewer, ""), "%s %s", r.method, r.path)
+	}
+	assert.Equal(t, http.StatusForbidden, request(http.MethodDelete, "/api/v2/notifications/1", viewer, ""))
+	assert.Equal(t, http.StatusForbidden, request(http.MethodPost, "/api/v2/detections/1/restore", viewer, ""))
+
+	editor := sessionCookie(security.RoleEditor)
+	assert.Equal(t, http.StatusOK, request(http.MethodPost, "/api/v2/detections/1/restore", editor, ""))
+	assert.Equal(t, http.StatusOK, request(http.MethodPost, "/api/v2/auth/logout", editor, ""))
+	assert.Equal(t, http.StatusOK, request(http.MethodPut, "/api/v2/dashboards/1", editor, ""))
+	assert.Equal(t, http.StatusOK, request(http.MethodPost, "/api/v2/detections/1/review", editor, ""))
+	assert.Equal(t, http.StatusOK, request(http.MethodDelete, "/api/v2/detections/1", editor, ""))
+	assert.Equal(t, http.StatusForbidden, request(http.MethodGet, "/api/v2/settings", editor, ""))
+	assert.Equal(t, http.StatusForbidden, request(http.MethodDelete, "/api/v2/detections/1", viewer, ""))
+
+	admin := sessionCookie(security.RoleAdmin)
+	assert.Equal(t, http.StatusOK, request(http.MethodPost, "/api/v2/detections/1/review", admin, ""))
+	assert.Equal(t, http.StatusOK, request(http.MethodGet, "/api/v2/settings", admin, ""))
+}
@@ -0,0 +1,272 @@
+// internal/api/v2/saved_searches.go
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// maxSavedSearchCooldownMinutes is the longest time between notifications of
+// a species by a subscription, a week
+const maxSavedSearchCooldownMinutes = 7 * 24 * 60
+
+// SavedSearchStore is implemented by datastores that keep saved detection searches
+type SavedSearchStore interface {
+	SaveSavedSearch(search *datastore.SavedSearch) error
+	UpdateSavedSearch(search *datastore.SavedSearch) error
+	GetSavedSearch(id uint) (*datastore.SavedSearch, error)
+	GetSavedSearches(owner string) ([]datastore.SavedSearch, error)
+	DeleteSavedSearch(id uint) error
+}
+
+// SavedSearchRequest creates or replaces a saved search
+type SavedSearchRequest struct {
+	Name            string `json:"name"`
+	Query           string `json:"query"`            // filter expression, as q of GET /detections
+	Subscribed      bool   `json:"subscribed"`       // notify of new detections matching the query
+	CooldownMinutes int    `json:"cooldown_minutes"` // minimum time between notifications of a species, 0 for none
+}
+
+// SavedSearchResponse is a saved search
+type SavedSearchResponse struct {
+	ID              uint      `json:"id"`
+	Name            string    `json:"name"`
+	Query           string    `json:"query"`
+	Subscribed      bool      `json:"subscribed"`
+	CooldownMinutes int       `json:"cooldown_minutes"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// initSavedSearchRoutes registers the saved search endpoints. Saved searches
+// belong to users, so reading them requires authentication as well.
+func (c *Controller) initSavedSearchRoutes() {
+	searchGroup := c.Group.Group("/saved-searches", c.getEffectiveAuthMiddleware())
+	searchGroup.GET("", c.ListSavedSearches)
+	searchGroup.GET("/:id", c.GetSavedSearch)
+	searchGroup.GET("/:id/detections", c.GetSavedSearchDetections)
+	searchGroup.POST("", c.CreateSavedSearch, c.IdempotencyMiddleware)
+	searchGroup.PUT("/:id", c.UpdateSavedSearch)
+	searchGroup.DELETE("/:id", c.DeleteSavedSearch)
+}
+
+// errSavedSearchesUnsupported reports that the datastore keeps no saved searches
+func (c *Controller) errSavedSearchesUnsupported(ctx echo.Context) error {
+	return c.HandleError(ctx, errors.Newf("datastore does not support saved searches").
+		Component("api").
+		Category(errors.CategoryConfiguration).
+		Build(), "Saved searches are not supported by this datastore", http.StatusNotImplemented)
+}
+
+// savedSearchResponse converts a stored saved search
+func savedSearchResponse(search *datastore.SavedSearch) SavedSearchResponse {
+	return SavedSearchResponse{
+		ID:              search.ID,
+		Name:            search.Name,
+		Query:           search.Query,
+		Subscribed:      search.Subscribed,
+		CooldownMinutes: int(search.Cooldown / time.Minute),
+		CreatedAt:       search.CreatedAt,
+		UpdatedAt:       search.UpdatedAt,
+	}
+}
+
+// bindSavedSearchRequest decodes and validates a saved search request into
+// search, returning false with the error response sent
+func (c *Controller) bindSavedSearchRequest(ctx echo.Context, search *datastore.SavedSearch) (bool, error) {
+	var req SavedSearchRequest
+	if err := ctx.Bind(&req); err != nil {
+		return false, c.HandleError(ctx, err, "Invalid request body", http.StatusBadRequest)
+	}
+	req.Name, req.Query = strings.TrimSpace(req.Name), strings.TrimSpace(req.Query)
+	if req.Name == "" || len(req.Name) > 128 {
+		return false, c.HandleError(ctx, errors.Newf("saved search name must have 1 to 128 characters").
+			Component("api").
+			Category(errors.CategoryValidation).
+			Build(), "Name is required and must be at most 128 characters", http.StatusBadRequest)
+	}
+	if req.CooldownMinutes < 0 || req.CooldownMinutes > maxSavedSearchCooldownMinutes {
+		return false, c.HandleError(ctx, errors.Newf("invalid cooldown of %d minutes", req.CooldownMinutes).
+			Component("api").
+			Category(errors.CategoryValidation).
+			Build(), "cooldown_minutes must be between 0 and "+strconv.Itoa(maxSavedSearchCooldownMinutes), http.StatusBadRequest)
+	}
+	if _, err := datastore.ParseNoteQuery(req.Query); err != nil {
+		return false, c.HandleError(ctx, err, "Invalid query", http.StatusBadRequest)
+	}
+
+	search.Name = req.Name
+	search.Query = req.Query
+	search.Subscribed = req.Subscribed
+	search.Cooldown = time.Duration(req.CooldownMinutes) * time.Minute
+	return true, nil
+}
+
+// ownedSavedSearch returns a saved search of the current user, or nil with
+// the error response sent. Other users' searches are reported as not found.
+func (c *Controller) ownedSavedSearch(ctx echo.Context, store SavedSearchStore) (*datastore.SavedSearch, error) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return nil, c.HandleError(ctx, err, "Invalid saved search ID", http.StatusBadRequest)
+	}
+	search, err := store.GetSavedSearch(uint(id))
+	if err == nil && search.Owner != currentUsername(ctx) {
+		err = datastore.ErrSavedSearchNotFound
+	}
+	if err != nil {
+		if errors.Is(err, datastore.ErrSavedSearchNotFound) {
+			return nil, c.HandleError(ctx, err, "Saved search not found", http.StatusNotFound)
+		}
+		return nil, c.HandleError(ctx, err, "Failed to get saved search", http.StatusInternalServerError)
+	}
+	return search, nil
+}
+
+// ListSavedSearches handles GET /api/v2/saved-searches
+// It returns the saved searches of the current user ordered by name.
+func (c *Controller) ListSavedSearches(ctx echo.Context) error {
+	store, ok := c.DS.(SavedSearchStore)
+	if !ok {
+		return c.errSavedSearchesUnsupported(ctx)
+	}
+	searches, err := store.GetSavedSearches(currentUsername(ctx))
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to get saved searches", http.StatusInternalServerError)
+	}
+	resp := make([]SavedSearchResponse, 0, len(searches))
+	for i := range searches {
+		resp = append(resp, savedSearchResponse(&searches[i]))
+	}
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+// GetSavedSearch handles GET /api/v2/saved-searches/:id
+func (c *Controller) GetSavedSearch(ctx echo.Context) error {
+	store, ok := c.DS.(SavedSearchStore)
+	if !ok {
+		return c.errSavedSearchesUnsupported(ctx)
+	}
+	search, err := c.ownedSavedSearch(ctx, store)
+	if search == nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, savedSearchResponse(search))
+}
+
+// GetSavedSearchDetections handles GET /api/v2/saved-searches/:id/detections
+// It returns a page of the detections matching a saved search, newest first,
+// paged by numResults and offset as the detections list.
+func (c *Controller) GetSavedSearchDetections(ctx echo.Context) error {
+	store, ok := c.DS.(SavedSearchStore)
+	if !ok {
+		return c.errSavedSearchesUnsupported(ctx)
+	}
+	querier, ok := c.DS.(DetectionQuerier)
+	if !ok {
+		return c.errSavedSearchesUnsupported(ctx)
+	}
+	search, err := c.ownedSavedSearch(ctx, store)
+	if search == nil {
+		return err
+	}
+	numResults, err := c.parseNumResults(ctx.QueryParam("numResults"))
+	if err != nil {
+		return c.HandleError(ctx, err, err.Error(), http.StatusBadRequest)
+	}
+	offset, err := c.parseOffset(ctx.QueryParam("offset"))
+	if err != nil {
+		return c.HandleError(ctx, err, err.Error(), http.StatusBadRequest)
+	}
+
+	query, err := datastore.ParseNoteQuery(search.Query)
+	if err != nil {
+		return c.HandleError(ctx, err, "Saved search has an invalid query", http.StatusInternalServerError)
+	}
+	notes, total, err := querier.QueryNotes(ctx.Request().Context(), query, numResults, offset)
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to get detections", http.StatusInternalServerError)
+	}
+	detections := c.convertNotesToDetectionResponses(notes, false)
+	return ctx.JSON(http.StatusOK, c.createPaginatedResponse(detections, total, numResults, offset))
+}
+
+// CreateSavedSearch handles POST /api/v2/saved-searches
+// The saved search is owned by the current user.
+func (c *Controller) CreateSavedSearch(ctx echo.Context) error {
+	store, ok := c.DS.(SavedSearchStore)
+	if !ok {
+		return c.errSavedSearchesUnsupported(ctx)
+	}
+	search := &datastore.SavedSearch{Owner: currentUsername(ctx)}
+	if ok, err := c.bindSavedSearchRequest(ctx, search); !ok {
+		return err
+	}
+
+	if err := store.SaveSavedSearch(search); err != nil {
+		return c.HandleError(ctx, err, "Failed to save search", http.StatusInternalServerError)
+	}
+	c.logAPIRequest(ctx, slog.LevelInfo, "Saved search created",
+		"id", search.ID,
+		"subscribed", search.Subscribed)
+	return ctx.JSON(http.StatusCreated, savedSearchResponse(search))
+}
+
+// UpdateSavedSearch handles PUT /api/v2/saved-searches/:id
+// It replaces the name, query, subscription and cooldown of a saved search.
+func (c *Controller) UpdateSavedSearch(ctx echo.Context) error {
+	store, ok := c.DS.(SavedSearchStore)
+	if !ok {
+		return c.errSavedSearchesUnsupported(ctx)
+	}
+	search, err := c.ownedSavedSearch(ctx, store)
+	if search == nil {
+		return err
+	}
+	if ok, err := c.bindSavedSearchRequest(ctx, search); !ok {
+		return err
+	}
+
+	if err := store.UpdateSavedSearch(search); err != nil {
+		if errors.Is(err, datastore.ErrSavedSearchNotFound) {
+			return c.HandleError(ctx, err, "Saved search not found", http.StatusNotFound)
+		}
+		return c.HandleError(ctx, err, "Failed to update saved search", http.StatusInternalServerError)
+	}
+	c.logAPIRequest(ctx, slog.LevelInfo, "Saved search updated",
+		"id", search.ID,
+		"subscribed", search.Subscribed)
+
+	// The datastore sets the update time
+	if updated, err := store.GetSavedSearch(search.ID); err == nil {
+		search = updated
+	}
+	return ctx.JSON(http.StatusOK, savedSearchResponse(search))
+}
+
+// DeleteSavedSearch handles DELETE /api/v2/saved-searches/:id
+func (c *Controller) DeleteSavedSearch(ctx echo.Context) error {
+	store, ok := c.DS.(SavedSearchStore)
+	if !ok {
+		return c.errSavedSearchesUnsupported(ctx)
+	}
+	search, err := c.ownedSavedSearch(ctx, store)
+	if search == nil {
+		return err
+	}
+
+	if err := store.DeleteSavedSearch(search.ID); err != nil {
+		if errors.Is(err, datastore.ErrSavedSearchNotFound) {
+			return c.HandleError(ctx, err, "Saved search not found", http.StatusNotFound)
+		}
+		return c.HandleError(ctx, err, "Failed to delete saved search", http.StatusInternalServerError)
+	}
+	c.logAPIRequest(ctx, slog.LevelInfo, "Saved search deleted", "id", search.ID)
+	return ctx.NoContent(http.StatusNoContent)
+}
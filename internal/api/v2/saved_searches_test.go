@@ -0,0 +1,144 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+// savedSearchMockDataStore adds saved searches and filter expressions to MockDataStore
+type savedSearchMockDataStore struct {
+	*MockDataStore
+	searches []datastore.SavedSearch
+	query    *datastore.NoteQuery
+}
+
+func (m *savedSearchMockDataStore) SaveSavedSearch(search *datastore.SavedSearch) error {
+	search.ID = uint(len(m.searches) + 1)
+	m.searches = append(m.searches, *search)
+	return nil
+}
+
+func (m *savedSearchMockDataStore) UpdateSavedSearch(search *datastore.SavedSearch) error {
+	for i := range m.searches {
+		if m.searches[i].ID == search.ID {
+			owner := m.searches[i].Owner
+			m.searches[i] = *search
+			m.searches[i].Owner = owner
+			return nil
+		}
+	}
+	return datastore.ErrSavedSearchNotFound
+}
+
+func (m *savedSearchMockDataStore) GetSavedSearch(id uint) (*datastore.SavedSearch, error) {
+	for i := range m.searches {
+		if m.searches[i].ID == id {
+			search := m.searches[i]
+			return &search, nil
+		}
+	}
+	return nil, datastore.ErrSavedSearchNotFound
+}
+
+func (m *savedSearchMockDataStore) GetSavedSearches(owner string) ([]datastore.SavedSearch, error) {
+	var searches []datastore.SavedSearch
+	for _, search := range m.searches {
+		if search.Owner == owner {
+			searches = append(searches, search)
+		}
+	}
+	return searches, nil
+}
+
+func (m *savedSearchMockDataStore) DeleteSavedSearch(id uint) error {
+	for i := range m.searches {
+		if m.searches[i].ID == id {
+			m.searches = append(m.searches[:i], m.searches[i+1:]...)
+			return nil
+		}
+	}
+	return datastore.ErrSavedSearchNotFound
+}
+
+func (m *savedSearchMockDataStore) QueryNotes(_ context.Context, query *datastore.NoteQuery, _, _ int) ([]datastore.Note, int64, error) {
+	m.query = query
+	return []datastore.Note{{ID: 3, Date: "2024-05-01", Time: "02:00:00", CommonName: "Tawny Owl"}}, 1, nil
+}
+
+func TestSavedSearches(t *testing.T) {
+	t.Parallel()
+
+	_, mockDS, controller := setupTestEnvironment(t)
+	store := &savedSearchMockDataStore{MockDataStore: mockDS}
+	controller.DS = store
+
+	call := func(handler echo.HandlerFunc, method, id, user, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/v2/saved-searches", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		ctx := controller.Echo.NewContext(req, rec)
+		ctx.Set("username", user)
+		if id != "" {
+			ctx.SetParamNames("id")
+			ctx.SetParamValues(id)
+		}
+		require.NoError(t, handler(ctx))
+		return rec
+	}
+
+	rec := call(controller.CreateSavedSearch, http.MethodPost, "", "alice",
+		`{"name":" Night owls ","query":"owl hour:22..4","subscribed":true,"cooldown_minutes":60}`)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	var created SavedSearchResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	assert.Equal(t, "Night owls", created.Name)
+	assert.True(t, created.Subscribed)
+	assert.Equal(t, 60, created.CooldownMinutes)
+	assert.Equal(t, "alice", store.searches[0].Owner)
+
+	for _, body := range []string{
+		`{"name":"","query":"owl"}`,
+		`{"name":"x","query":"confidence>high"}`,
+		`{"name":"x","query":"owl","cooldown_minutes":-1}`,
+		`{"name":"x","query":"owl","cooldown_minutes":100000}`,
+	} {
+		assert.Equal(t, http.StatusBadRequest, call(controller.CreateSavedSearch, http.MethodPost, "", "alice", body).Code, body)
+	}
+
+	rec = call(controller.GetSavedSearchDetections, http.MethodGet, "1", "alice", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NotNil(t, store.query)
+	assert.Equal(t, "owl hour:22..4", store.query.String())
+	assert.Contains(t, rec.Body.String(), "Tawny Owl")
+
+	// Saved searches are private to their owner
+	assert.Equal(t, http.StatusNotFound, call(controller.GetSavedSearch, http.MethodGet, "1", "bob", "").Code)
+	assert.Equal(t, http.StatusNotFound, call(controller.DeleteSavedSearch, http.MethodDelete, "1", "bob", "").Code)
+	rec = call(controller.ListSavedSearches, http.MethodGet, "", "bob", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `[]`, rec.Body.String())
+
+	rec = call(controller.UpdateSavedSearch, http.MethodPut, "1", "alice", `{"name":"Owls","query":"owl"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var updated SavedSearchResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &updated))
+	assert.Equal(t, "Owls", updated.Name)
+	assert.False(t, updated.Subscribed)
+	assert.Equal(t, 0, updated.CooldownMinutes)
+
+	assert.Equal(t, http.StatusNoContent, call(controller.DeleteSavedSearch, http.MethodDelete, "1", "alice", "").Code)
+	assert.Equal(t, http.StatusNotFound, call(controller.GetSavedSearch, http.MethodGet, "1", "alice", "").Code)
+	assert.Equal(t, http.StatusBadRequest, call(controller.GetSavedSearch, http.MethodGet, "first", "alice", "").Code)
+
+	controller.DS = mockDS
+	assert.Equal(t, http.StatusNotImplemented, call(controller.ListSavedSearches, http.MethodGet, "", "alice", "").Code)
+}
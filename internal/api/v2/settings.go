@@ -18,6 +18,7 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/tphakala/birdnet-go/internal/conf"
 	"github.com/tphakala/birdnet-go/internal/imageprovider"
+	"github.com/tphakala/birdnet-go/internal/notification/templatefuncs"
 	"github.com/tphakala/birdnet-go/internal/telemetry"
 )
 
@@ -48,9 +49,9 @@ func (c *Controller) initSettingsRoutes() {
 	// GET /api/v2/settings/:section - Retrieves settings for a specific section (e.g., birdnet, webserver)
 	settingsGroup.GET("/:section", c.GetSectionSettings)
 	// PUT /api/v2/settings - Updates multiple settings sections with complete replacement
-	settingsGroup.PUT("", c.UpdateSettings)
+	settingsGroup.PUT("", c.UpdateSettings, c.IdempotencyMiddleware)
 	// PATCH /api/v2/settings/:section - Updates a specific settings section with partial replacement
-	settingsGroup.PATCH("/:section", c.UpdateSectionSettings)
+	settingsGroup.PATCH("/:section", c.UpdateSectionSettings, c.IdempotencyMiddleware)
 
 	if c.apiLogger != nil {
 		c.apiLogger.Info("Settings routes initialized successfully")
@@ -1139,17 +1140,47 @@ func validateNotificationSection(data json.RawMessage) error {
 
 	// Validate new species notification templates if present
 	if notificationConfig.Templates.NewSpecies.Title != "" {
-		if _, err := template.New("title").Parse(notificationConfig.Templates.NewSpecies.Title); err != nil {
+		if _, err := template.New("title").Funcs(templatefuncs.FuncMap()).Parse(notificationConfig.Templates.NewSpecies.Title); err != nil {
 			return fmt.Errorf("invalid template syntax in new species title: %w", err)
 		}
 	}
 
 	if notificationConfig.Templates.NewSpecies.Message != "" {
-		if _, err := template.New("message").Parse(notificationConfig.Templates.NewSpecies.Message); err != nil {
+		if _, err := template.New("message").Funcs(templatefuncs.FuncMap()).Parse(notificationConfig.Templates.NewSpecies.Message); err != nil {
 			return fmt.Errorf("invalid template syntax in new species message: %w", err)
 		}
 	}
 
+	// Validate daily digest templates if present
+	if notificationConfig.Templates.DailyDigest.Title != "" {
+		if _, err := template.New("title").Funcs(templatefuncs.FuncMap()).Parse(notificationConfig.Templates.DailyDigest.Title); err != nil {
+			return fmt.Errorf("invalid template syntax in daily digest title: %w", err)
+		}
+	}
+
+	if notificationConfig.Templates.DailyDigest.Message != "" {
+		if _, err := template.New("message").Funcs(templatefuncs.FuncMap()).Parse(notificationConfig.Templates.DailyDigest.Message); err != nil {
+			return fmt.Errorf("invalid template syntax in daily digest message: %w", err)
+		}
+	}
+
+	// Validate per-channel templates if present
+	for channel, templates := range notificationConfig.Templates.Channels {
+		for name, tmpl := range map[string]string{
+			"new species title":    templates.NewSpecies.Title,
+			"new species message":  templates.NewSpecies.Message,
+			"daily digest title":   templates.DailyDigest.Title,
+			"daily digest message": templates.DailyDigest.Message,
+		} {
+			if tmpl == "" {
+				continue
+			}
+			if _, err := template.New(name).Funcs(templatefuncs.FuncMap()).Parse(tmpl); err != nil {
+				return fmt.Errorf("invalid template syntax in %s %s: %w", channel, name, err)
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -1363,16 +1394,29 @@ func (c *Controller) handleSettingsChanges(oldSettings, currentSettings *conf.Se
 	}
 	reconfigActions = append(reconfigActions, audioActions...)
 
-	// Trigger reconfigurations asynchronously
+	// Trigger reconfigurations asynchronously. The sender is tracked by the
+	// controller so that Shutdown waits for it before the control channel closes.
 	if len(reconfigActions) > 0 {
-		go func(actions []string) {
-			for _, action := range actions {
+		var done <-chan struct{}
+		if c.ctx != nil {
+			done = c.ctx.Done()
+		}
+		c.wg.Go(func() {
+			for _, action := range reconfigActions {
 				c.Debug("Asynchronously executing action: %s", action)
-				c.controlChan <- action
+				select {
+				case c.controlChan <- action:
+				case <-done:
+					return
+				}
 				// Add a small delay between actions to avoid overwhelming the system
-				time.Sleep(100 * time.Millisecond)
+				select {
+				case <-time.After(100 * time.Millisecond):
+				case <-done:
+					return
+				}
 			}
-		}(reconfigActions)
+		})
 	}
 
 	return nil
@@ -1470,7 +1514,11 @@ func rtspSettingsChanged(oldSettings, currentSettings *conf.Settings) bool {
 		}
 	}
 
-	return false
+	// Check for changes in stream capture sample rates
+	if len(oldRTSP.CaptureRates) == 0 && len(newRTSP.CaptureRates) == 0 {
+		return false
+	}
+	return !reflect.DeepEqual(oldRTSP.CaptureRates, newRTSP.CaptureRates)
 }
 
 // speciesIntervalSettingsChanged checks if any species-specific interval settings have changed
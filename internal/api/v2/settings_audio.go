@@ -11,7 +11,9 @@ import (
 
 // audioDeviceSettingChanged checks if audio device settings have changed
 func audioDeviceSettingChanged(oldSettings, currentSettings *conf.Settings) bool {
-	return oldSettings.Realtime.Audio.Source != currentSettings.Realtime.Audio.Source
+	return oldSettings.Realtime.Audio.Source != currentSettings.Realtime.Audio.Source ||
+		oldSettings.Realtime.Audio.Channel != currentSettings.Realtime.Audio.Channel ||
+		oldSettings.Realtime.Audio.CaptureRate != currentSettings.Realtime.Audio.CaptureRate
 }
 
 // soundLevelSettingsChanged checks if sound level monitoring settings have changed
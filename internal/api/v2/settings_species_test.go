@@ -426,13 +426,6 @@ func newAPIContext(t *testing.T, e *echo.Echo, method, path string, body any) (e
 
 	controller := &Controller{
 		Settings: &conf.Settings{
-			Main: struct {
-				Name      string         `json:"name"`
-				TimeAs24h bool           `json:"timeAs24h"`
-				Log       conf.LogConfig `json:"log"`
-			}{
-				Name: "TestNode",
-			},
 			Realtime: conf.RealtimeSettings{
 				Species: conf.SpeciesSettings{
 					Include: []string{},
@@ -443,6 +436,7 @@ func newAPIContext(t *testing.T, e *echo.Echo, method, path string, body any) (e
 		},
 		DisableSaveSettings: true, // Disable file save for testing
 	}
+	controller.Settings.Main.Name = "TestNode"
 
 	// Use default values if method or path are empty
 	if method == "" {
@@ -0,0 +1,110 @@
+// internal/api/v2/signed_media.go
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/errors"
+	"github.com/tphakala/birdnet-go/internal/mediaurl"
+	"github.com/tphakala/birdnet-go/internal/notification"
+)
+
+// maxSignedMediaTTL is the longest lifetime of a signed media URL
+const maxSignedMediaTTL = 30 * 24 * time.Hour
+
+// SignMediaRequest asks for a signed URL of a detection's clip or spectrogram
+type SignMediaRequest struct {
+	Kind       string `json:"kind"` // audio or spectrogram
+	ID         uint   `json:"id"`   // detection ID
+	TTLMinutes int    `json:"ttlMinutes"`
+}
+
+// SignMediaResponse is a signed media URL
+type SignMediaResponse struct {
+	URL       string    `json:"url"`  // absolute URL
+	Path      string    `json:"path"` // path and query relative to the host
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// SignMediaURL handles POST /api/v2/media/sign
+// It returns a short-lived URL of a detection's audio clip or spectrogram that
+// can be fetched without authentication until it expires. The lifetime
+// defaults to security.signedmedia.ttl and is at most 30 days.
+func (c *Controller) SignMediaURL(ctx echo.Context) error {
+	var req SignMediaRequest
+	if err := ctx.Bind(&req); err != nil {
+		return c.HandleError(ctx, err, "Invalid request body", http.StatusBadRequest)
+	}
+	if req.Kind != mediaurl.KindAudio && req.Kind != mediaurl.KindSpectrogram {
+		return c.HandleError(ctx, errors.Newf("invalid media kind %q", req.Kind).
+			Component("api").
+			Category(errors.CategoryValidation).
+			Build(), "Kind must be audio or spectrogram", http.StatusBadRequest)
+	}
+	ttl := time.Duration(req.TTLMinutes) * time.Minute
+	if ttl < 0 || ttl > maxSignedMediaTTL {
+		return c.HandleError(ctx, errors.Newf("invalid lifetime of %d minutes", req.TTLMinutes).
+			Component("api").
+			Category(errors.CategoryValidation).
+			Build(), "ttlMinutes must be between 0 and 43200", http.StatusBadRequest)
+	}
+
+	signer := mediaurl.FromSettings(c.Settings)
+	if signer == nil {
+		return c.HandleError(ctx, mediaurl.ErrNoSecret, "Signed media URLs need a session secret", http.StatusServiceUnavailable)
+	}
+
+	id := strconv.FormatUint(uint64(req.ID), 10)
+	if _, err := c.DS.Get(id); err != nil {
+		return c.HandleError(ctx, err, "Detection not found", http.StatusNotFound)
+	}
+
+	path, expiresAt := signer.Sign(req.Kind, id, ttl)
+	return ctx.JSON(http.StatusOK, SignMediaResponse{
+		URL:       c.publicBaseURL(ctx) + path,
+		Path:      path,
+		ExpiresAt: expiresAt,
+	})
+}
+
+// ServeSignedMedia handles GET /api/v2/media/signed/:kind/:id
+// It serves the clip or spectrogram of a detection to anyone holding a valid,
+// unexpired signed URL.
+func (c *Controller) ServeSignedMedia(ctx echo.Context) error {
+	signer := mediaurl.FromSettings(c.Settings)
+	if signer == nil {
+		return c.HandleError(ctx, mediaurl.ErrNoSecret, "Signed media URLs are not available", http.StatusNotFound)
+	}
+
+	kind := ctx.Param("kind")
+	err := signer.Verify(kind, ctx.Param("id"), ctx.QueryParam("expires"), ctx.QueryParam("sig"), time.Now())
+	switch {
+	case errors.Is(err, mediaurl.ErrExpired):
+		return c.HandleError(ctx, err, "Signed media URL has expired", http.StatusGone)
+	case err != nil:
+		return c.HandleError(ctx, err, "Invalid media URL signature", http.StatusForbidden)
+	}
+
+	switch kind {
+	case mediaurl.KindAudio:
+		return c.ServeAudioByID(ctx)
+	case mediaurl.KindSpectrogram:
+		return c.ServeSpectrogramByID(ctx)
+	}
+	return c.HandleError(ctx, errors.Newf("invalid media kind %q", kind).
+		Component("api").
+		Category(errors.CategoryValidation).
+		Build(), "Unknown media kind", http.StatusNotFound)
+}
+
+// publicBaseURL returns the URL under which external clients reach this
+// instance, the configured host or else the host of the request
+func (c *Controller) publicBaseURL(ctx echo.Context) string {
+	if c.Settings.Security.Host != "" {
+		return notification.BuildBaseURL(c.Settings.Security.Host, c.Settings.WebServer.Port, c.Settings.Security.AutoTLS)
+	}
+	return ctx.Scheme() + "://" + ctx.Request().Host
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/mediaurl"
+)
+
+func TestSignMediaURL_NoSecret(t *testing.T) {
+	t.Parallel()
+
+	e, _, controller := setupTestEnvironment(t)
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/media/sign", strings.NewReader(`{"kind":"audio","id":5}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	require.NoError(t, controller.SignMediaURL(e.NewContext(req, rec)))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
+
+func TestSignedMedia(t *testing.T) {
+	t.Parallel()
+
+	e, controller, tempDir := setupMediaTestEnvironment(t)
+	mockDS := controller.DS.(*MockDataStore)
+	controller.Settings.Security.SessionSecret = "session-secret"
+	controller.Settings.Security.Host = "birds.example.org"
+	controller.Settings.Security.AutoTLS = true
+	controller.Settings.WebServer.Port = "443"
+
+	require.NoError(t, createTestAudioFile(t, filepath.Join(tempDir, "clip.wav")))
+	mockDS.On("Get", "5").Return(datastore.Note{ID: 5}, nil)
+	mockDS.On("GetNoteClipPath", "5").Return("clip.wav", nil)
+
+	sign := func(body string) (*httptest.ResponseRecorder, SignMediaResponse) {
+		req := httptest.NewRequest(http.MethodPost, "/api/v2/media/sign", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		require.NoError(t, controller.SignMediaURL(e.NewContext(req, rec)))
+		var resp SignMediaResponse
+		if rec.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		}
+		return rec, resp
+	}
+
+	rec, signed := sign(`{"kind":"audio","id":5,"ttlMinutes":10}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "https://birds.example.org"+signed.Path, signed.URL)
+	assert.True(t, strings.HasPrefix(signed.Path, "/api/v2/media/signed/audio/5?"))
+	assert.WithinDuration(t, time.Now().Add(10*time.Minute), signed.ExpiresAt, 2*time.Second)
+
+	for _, body := range []string{`{"kind":"video","id":5}`, `{"kind":"audio","id":5,"ttlMinutes":50000}`} {
+		rec, _ := sign(body)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, body)
+	}
+
+	serve := func(rawURL string) *httptest.ResponseRecorder {
+		u, err := url.Parse(rawURL)
+		require.NoError(t, err)
+		parts := strings.Split(strings.TrimPrefix(u.Path, mediaurl.PathPrefix+"/"), "/")
+		req := httptest.NewRequest(http.MethodGet, rawURL, http.NoBody)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("kind", "id")
+		c.SetParamValues(parts[0], parts[1])
+		require.NoError(t, controller.ServeSignedMedia(c))
+		return rec
+	}
+
+	rec = serve(signed.Path)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, MimeTypeWAV, rec.Header().Get("Content-Type"))
+
+	// The signature is bound to the detection and kind
+	assert.Equal(t, http.StatusForbidden, serve(strings.Replace(signed.Path, "/audio/5", "/audio/6", 1)).Code)
+	assert.Equal(t, http.StatusForbidden, serve(strings.Replace(signed.Path, "/audio/", "/spectrogram/", 1)).Code)
+
+	// Expired URLs are rejected
+	signer := mediaurl.FromSettings(controller.Settings)
+	require.NotNil(t, signer)
+	expired, _ := signer.Sign(mediaurl.KindAudio, "5", time.Nanosecond) // expiry is truncated to the second
+	assert.Equal(t, http.StatusGone, serve(expired).Code)
+	u, err := url.Parse(signed.Path)
+	require.NoError(t, err)
+	query := u.Query()
+	query.Set("expires", "1000")
+	u.RawQuery = query.Encode()
+	assert.Equal(t, http.StatusForbidden, serve(u.String()).Code, "changing the expiry invalidates the signature")
+}
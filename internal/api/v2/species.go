@@ -62,6 +62,9 @@ func (c *Controller) initSpeciesRoutes() {
 	
 	// RESTful thumbnail endpoint - uses species code from path
 	c.Group.GET("/species/:code/thumbnail", c.GetSpeciesThumbnail)
+
+	// Aggregated detection history for the species page
+	c.Group.GET("/species/:code/summary", c.GetSpeciesSummaryByCode)
 }
 
 // GetSpeciesInfo retrieves extended information about a bird species
@@ -0,0 +1,24 @@
+// internal/api/v2/species_aliases.go
+package api
+
+import "github.com/tphakala/birdnet-go/internal/speciesalias"
+
+// speciesAliases returns the species alias table, the embedded table extended
+// with birdnet.aliaspath. It is loaded on first use; a custom file that cannot
+// be loaded is logged and the embedded table used instead.
+func (c *Controller) speciesAliases() *speciesalias.Index {
+	c.aliasesOnce.Do(func() {
+		aliases, err := speciesalias.Load(c.Settings.BirdNET.AliasPath)
+		if err != nil {
+			if c.apiLogger != nil {
+				c.apiLogger.Error("Failed to load species alias file, using embedded aliases",
+					"path", c.Settings.BirdNET.AliasPath,
+					"error", err.Error(),
+				)
+			}
+			aliases = speciesalias.Default()
+		}
+		c.aliases = aliases
+	})
+	return c.aliases
+}
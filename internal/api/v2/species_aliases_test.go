@@ -0,0 +1,48 @@
+package api
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+// TestSearchExpandsSpeciesAliases verifies that species searches also match
+// detections stored under former names
+func TestSearchExpandsSpeciesAliases(t *testing.T) {
+	t.Parallel()
+
+	_, mockDS, controller := setupTestEnvironment(t)
+	mockDS.On("SearchNotesAdvanced", mock.MatchedBy(func(f *datastore.AdvancedSearchFilters) bool {
+		return assert.ElementsMatch(t, []string{"Spinus tristis", "Carduelis tristis"}, f.Species)
+	})).Return([]datastore.Note{}, int64(0), nil)
+
+	_, _, err := controller.getSearchDetectionsAdvanced(&detectionQueryParams{Species: "Spinus tristis", NumResults: 10})
+	require.NoError(t, err)
+	mockDS.AssertExpectations(t)
+
+	filters := controller.exportSearchFilters([]string{"Gray Jay"}, "", "", 0, nil, 10)
+	assert.ElementsMatch(t, []string{"Gray Jay", "Perisoreus canadensis", "gryjay"}, filters.Species)
+}
+
+// TestSpeciesAliasesCustomFile verifies that a custom alias file extends the
+// embedded table and that an unreadable file falls back to it
+func TestSpeciesAliasesCustomFile(t *testing.T) {
+	t.Parallel()
+
+	_, _, controller := setupTestEnvironment(t)
+	path := filepath.Join(t.TempDir(), "aliases.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[{"alias": "Merle", "scientificName": "Turdus merula", "commonName": "Eurasian Blackbird", "code": "eurbla"}]`), 0o600))
+	controller.Settings.BirdNET.AliasPath = path
+	assert.Len(t, controller.speciesAliases().Resolve("Merle"), 1)
+	assert.Len(t, controller.speciesAliases().Resolve("Carduelis tristis"), 1)
+
+	_, _, fallback := setupTestEnvironment(t)
+	fallback.Settings.BirdNET.AliasPath = filepath.Join(t.TempDir(), "missing.json")
+	assert.Nil(t, fallback.speciesAliases().Resolve("Merle"))
+	assert.Len(t, fallback.speciesAliases().Resolve("Carduelis tristis"), 1)
+}
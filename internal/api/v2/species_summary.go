@@ -0,0 +1,145 @@
+// internal/api/v2/species_summary.go
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/patrickmn/go-cache"
+	"github.com/tphakala/birdnet-go/internal/birdnet"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// speciesSummaryRecentLimit is the number of recent detections in a species summary
+const speciesSummaryRecentLimit = 10
+
+// SpeciesDetailReader is implemented by datastores that aggregate the detection history of a species
+type SpeciesDetailReader interface {
+	GetSpeciesDetail(ctx context.Context, scientificName string, recentLimit int) (*datastore.SpeciesDetail, error)
+}
+
+// SpeciesSummaryDetection is a detection listed in a species summary
+type SpeciesSummaryDetection struct {
+	ID         uint    `json:"id"`
+	Date       string  `json:"date"`
+	Time       string  `json:"time"`
+	Confidence float64 `json:"confidence"`
+	ClipURL    string  `json:"clipUrl,omitempty"`
+}
+
+// SpeciesSummaryResponse combines the detection history of a species
+type SpeciesSummaryResponse struct {
+	SpeciesCode     string                    `json:"speciesCode"`
+	ScientificName  string                    `json:"scientificName"`
+	CommonName      string                    `json:"commonName"`
+	FirstSeen       string                    `json:"firstSeen,omitempty"`
+	LastSeen        string                    `json:"lastSeen,omitempty"`
+	TotalDetections int64                     `json:"totalDetections"`
+	Monthly         [12]int                   `json:"monthly"` // detections by month, January first
+	Hourly          [24]int                   `json:"hourly"`  // detections by hour of the day
+	BestClip        *SpeciesSummaryDetection  `json:"bestClip,omitempty"`
+	Recent          []SpeciesSummaryDetection `json:"recent"`
+}
+
+// GetSpeciesSummaryByCode handles GET /api/v2/species/:code/summary
+// It returns the first and last detection, totals, monthly histogram, hourly
+// activity, best clip and recent detections of a species in one response.
+// Summaries are cached with the detection queries.
+func (c *Controller) GetSpeciesSummaryByCode(ctx echo.Context) error {
+	speciesCode := ctx.Param("code")
+	if speciesCode == "" {
+		return c.HandleError(ctx, errors.Newf("species code parameter is required").
+			Category(errors.CategoryValidation).
+			Component("api-species").
+			Build(), "Missing species code", http.StatusBadRequest)
+	}
+
+	cacheKey := "species-summary:" + speciesCode
+	if c.detectionCache != nil {
+		if cached, found := c.detectionCache.Get(cacheKey); found {
+			return ctx.JSON(http.StatusOK, cached)
+		}
+	}
+
+	if c.Processor == nil || c.Processor.Bn == nil {
+		return c.HandleError(ctx, errors.Newf("BirdNET processor not available").
+			Category(errors.CategorySystem).
+			Component("api-species").
+			Build(), "BirdNET service unavailable", http.StatusServiceUnavailable)
+	}
+
+	speciesName, exists := birdnet.GetSpeciesNameFromCode(c.Processor.Bn.TaxonomyMap, speciesCode)
+	if !exists {
+		return c.HandleError(ctx, errors.Newf("species code '%s' not found in taxonomy", speciesCode).
+			Category(errors.CategoryNotFound).
+			Context("species_code", speciesCode).
+			Component("api-species").
+			Build(), "Species not found", http.StatusNotFound)
+	}
+	scientificName, commonName := birdnet.SplitSpeciesName(speciesName)
+	if scientificName == "" {
+		return c.HandleError(ctx, errors.Newf("invalid species name format for code '%s'", speciesCode).
+			Category(errors.CategoryValidation).
+			Context("species_code", speciesCode).
+			Context("species_name", speciesName).
+			Component("api-species").
+			Build(), "Invalid species data", http.StatusInternalServerError)
+	}
+
+	reader, ok := c.DS.(SpeciesDetailReader)
+	if !ok {
+		return c.HandleError(ctx, errors.Newf("datastore does not support species summaries").
+			Component("api-species").
+			Category(errors.CategoryConfiguration).
+			Build(), "Species summaries are not supported by this datastore", http.StatusNotImplemented)
+	}
+
+	detail, err := reader.GetSpeciesDetail(ctx.Request().Context(), scientificName, speciesSummaryRecentLimit)
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to load species summary", http.StatusInternalServerError)
+	}
+
+	resp := SpeciesSummaryResponse{
+		SpeciesCode:     speciesCode,
+		ScientificName:  scientificName,
+		CommonName:      commonName,
+		FirstSeen:       detail.FirstSeen,
+		LastSeen:        detail.LastSeen,
+		TotalDetections: detail.TotalDetections,
+		Monthly:         detail.Monthly,
+		Hourly:          detail.Hourly,
+		Recent:          make([]SpeciesSummaryDetection, 0, len(detail.Recent)),
+	}
+	if detail.CommonName != "" {
+		resp.CommonName = detail.CommonName
+	}
+	if detail.BestClip != nil {
+		best := speciesSummaryDetection(detail.BestClip)
+		resp.BestClip = &best
+	}
+	for i := range detail.Recent {
+		resp.Recent = append(resp.Recent, speciesSummaryDetection(&detail.Recent[i]))
+	}
+
+	if c.detectionCache != nil {
+		c.detectionCache.Set(cacheKey, resp, cache.DefaultExpiration)
+	}
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+// speciesSummaryDetection converts a note to a species summary detection
+func speciesSummaryDetection(note *datastore.Note) SpeciesSummaryDetection {
+	detection := SpeciesSummaryDetection{
+		ID:         note.ID,
+		Date:       note.Date,
+		Time:       note.Time,
+		Confidence: note.Confidence,
+	}
+	if note.ClipName != "" {
+		detection.ClipURL = fmt.Sprintf("/api/v2/audio/%d", note.ID)
+	}
+	return detection
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/analysis/processor"
+	"github.com/tphakala/birdnet-go/internal/birdnet"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+// speciesDetailMockDataStore adds species detail aggregation to MockDataStore
+type speciesDetailMockDataStore struct {
+	*MockDataStore
+	detail *datastore.SpeciesDetail
+	calls  int
+}
+
+func (m *speciesDetailMockDataStore) GetSpeciesDetail(_ context.Context, scientificName string, recentLimit int) (*datastore.SpeciesDetail, error) {
+	m.calls++
+	detail := *m.detail
+	detail.ScientificName = scientificName
+	if len(detail.Recent) > recentLimit {
+		detail.Recent = detail.Recent[:recentLimit]
+	}
+	return &detail, nil
+}
+
+func TestGetSpeciesSummaryByCode(t *testing.T) {
+	t.Parallel()
+
+	e, mockDS, controller := setupTestEnvironment(t)
+	controller.Processor = &processor.Processor{Bn: &birdnet.BirdNET{TaxonomyMap: birdnet.TaxonomyMap{
+		"amerob": "Turdus migratorius_American Robin",
+	}}}
+
+	detail := &datastore.SpeciesDetail{
+		CommonName:      "American Robin",
+		FirstSeen:       "2024-03-01 06:12:00",
+		LastSeen:        "2025-05-10 19:40:00",
+		TotalDetections: 3,
+		BestClip:        &datastore.Note{ID: 2, Date: "2024-04-02", Time: "07:00:00", Confidence: 0.97, ClipName: "clip.wav"},
+		Recent: []datastore.Note{
+			{ID: 3, Date: "2025-05-10", Time: "19:40:00", Confidence: 0.8},
+			{ID: 2, Date: "2024-04-02", Time: "07:00:00", Confidence: 0.97, ClipName: "clip.wav"},
+		},
+	}
+	detail.Monthly[4] = 1
+	detail.Monthly[3] = 1
+	detail.Monthly[2] = 1
+	detail.Hourly[6] = 1
+	detail.Hourly[7] = 1
+	detail.Hourly[19] = 1
+	store := &speciesDetailMockDataStore{MockDataStore: mockDS, detail: detail}
+	controller.DS = store
+
+	get := func(code string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v2/species/"+code+"/summary", http.NoBody)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.SetParamNames("code")
+		ctx.SetParamValues(code)
+		require.NoError(t, controller.GetSpeciesSummaryByCode(ctx))
+		return rec
+	}
+
+	rec := get("amerob")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp SpeciesSummaryResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "amerob", resp.SpeciesCode)
+	assert.Equal(t, "Turdus migratorius", resp.ScientificName)
+	assert.Equal(t, "American Robin", resp.CommonName)
+	assert.Equal(t, "2024-03-01 06:12:00", resp.FirstSeen)
+	assert.Equal(t, int64(3), resp.TotalDetections)
+	assert.Equal(t, 1, resp.Monthly[4])
+	assert.Equal(t, 1, resp.Hourly[19])
+	require.NotNil(t, resp.BestClip)
+	assert.Equal(t, "/api/v2/audio/2", resp.BestClip.ClipURL)
+	require.Len(t, resp.Recent, 2)
+	assert.Empty(t, resp.Recent[0].ClipURL, "detections without clips should have no clip URL")
+
+	// A second request is served from the cache
+	require.Equal(t, http.StatusOK, get("amerob").Code)
+	assert.Equal(t, 1, store.calls)
+
+	assert.Equal(t, http.StatusNotFound, get("unknown").Code)
+
+	// Datastores without species aggregation are not supported
+	controller.DS = mockDS
+	controller.detectionCache.Flush()
+	assert.Equal(t, http.StatusNotImplemented, get("amerob").Code)
+}
+
+func TestGetSpeciesSummaryByCode_NoProcessor(t *testing.T) {
+	t.Parallel()
+
+	e, _, controller := setupTestEnvironment(t)
+	controller.Processor = nil
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v2/species/amerob/summary", http.NoBody)
+	rec := httptest.NewRecorder()
+	ctx := e.NewContext(req, rec)
+	ctx.SetParamNames("code")
+	ctx.SetParamValues("amerob")
+	require.NoError(t, controller.GetSpeciesSummaryByCode(ctx))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
@@ -0,0 +1,527 @@
+// internal/api/v2/spectrogram_export.go
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/atrest"
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/errors"
+	"github.com/tphakala/birdnet-go/internal/manifest"
+)
+
+// Spectrogram export limits and defaults
+const (
+	defaultExportLimit    = 100
+	maxExportLimit        = 1000
+	defaultExportWidth    = 800
+	maxExportDimension    = 4096
+	maxExportFrequency    = 96000
+	defaultExportColormap = "intensity"
+	exportJobTTL          = time.Hour // how long finished exports can be downloaded
+)
+
+// Export job states
+const (
+	exportStatusRunning   = "running"
+	exportStatusCompleted = "completed"
+	exportStatusFailed    = "failed"
+)
+
+// exportColormaps are the colormaps supported by FFmpeg showspectrumpic
+var exportColormaps = []string{
+	"channel", "intensity", "rainbow", "moreland", "nebulae", "fire", "fiery", "fruit",
+	"cool", "magma", "green", "viridis", "plasma", "cividis", "terrain",
+}
+
+// SpectrogramExportRequest selects the detections of a spectrogram export and
+// how their spectrograms are rendered. Detections are selected by ID, or by
+// species, date range, confidence and verification when no IDs are given.
+type SpectrogramExportRequest struct {
+	IDs           []uint   `json:"ids"`
+	Species       []string `json:"species"`       // scientific names or species codes
+	StartDate     string   `json:"startDate"`     // YYYY-MM-DD
+	EndDate       string   `json:"endDate"`       // YYYY-MM-DD
+	MinConfidence float64  `json:"minConfidence"` // percent
+	Verified      *bool    `json:"verified"`
+	Limit         int      `json:"limit"` // default 100, at most 1000
+
+	Width        int    `json:"width"`        // pixels, default 800
+	Height       int    `json:"height"`       // pixels, default half the width
+	Colormap     string `json:"colormap"`     // FFmpeg showspectrumpic color, default intensity
+	MinFrequency int    `json:"minFrequency"` // Hz, 0 for the lowest frequency
+	MaxFrequency int    `json:"maxFrequency"` // Hz, 0 for the highest frequency of the clip
+	Legend       bool   `json:"legend"`       // true to draw axes and a legend
+}
+
+// ExportJob is the progress of a background export producing a zip file
+type ExportJob struct {
+	ID          string    `json:"id"`
+	Status      string    `json:"status"`
+	Total       int       `json:"total"`
+	Rendered    int       `json:"rendered"`
+	Failed      int       `json:"failed"`
+	Error       string    `json:"error,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+	DownloadURL string    `json:"downloadUrl,omitempty"`
+	SHA256      string    `json:"sha256,omitempty"` // checksum of the zip file when recorded in the manifest
+
+	path string // zip file of a completed export
+}
+
+// exportJobs holds the exports of the last hour
+type exportJobs struct {
+	mu   sync.Mutex
+	jobs map[string]*ExportJob
+}
+
+var spectrogramExports = &exportJobs{jobs: make(map[string]*ExportJob)}
+
+// renderExportSpectrogram renders the spectrogram of an audio clip to a PNG
+// file, replaceable in tests
+var renderExportSpectrogram = renderSpectrogramWithOptions
+
+// get returns a copy of a job
+func (s *exportJobs) get(id string) (ExportJob, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return ExportJob{}, false
+	}
+	return *job, true
+}
+
+// update applies a change to a job
+func (s *exportJobs) update(id string, change func(job *ExportJob)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if job, ok := s.jobs[id]; ok {
+		change(job)
+	}
+}
+
+// start registers a new job unless another export is still running. Jobs
+// older than the download period are removed together with their files.
+func (s *exportJobs) start(total int) (*ExportJob, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, job := range s.jobs {
+		if job.Status == exportStatusRunning {
+			return nil, false
+		}
+		if time.Since(job.CreatedAt) > exportJobTTL {
+			if job.path != "" {
+				_ = os.Remove(job.path)
+			}
+			delete(s.jobs, id)
+		}
+	}
+	job := &ExportJob{
+		ID:        uuid.New().String(),
+		Status:    exportStatusRunning,
+		Total:     total,
+		CreatedAt: time.Now(),
+	}
+	s.jobs[job.ID] = job
+	return job, true
+}
+
+// StartSpectrogramExport handles POST /api/v2/spectrograms/export
+// It starts rendering the spectrograms of the selected detections into a zip
+// file in the background and returns the job, whose status can be polled
+// until the zip is ready for download. Only one export runs at a time.
+func (c *Controller) StartSpectrogramExport(ctx echo.Context) error {
+	var req SpectrogramExportRequest
+	if err := ctx.Bind(&req); err != nil {
+		return c.HandleError(ctx, err, "Invalid request body", http.StatusBadRequest)
+	}
+	if err := req.normalize(); err != nil {
+		return c.HandleError(ctx, err, err.Error(), http.StatusBadRequest)
+	}
+
+	notes, err := c.exportNotes(&req)
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to select detections", http.StatusInternalServerError)
+	}
+	if len(notes) == 0 {
+		return c.HandleError(ctx, errors.Newf("no detections match the export filters").
+			Component("api").
+			Category(errors.CategoryNotFound).
+			Build(), "No detections with audio clips match the filters", http.StatusNotFound)
+	}
+
+	job, ok := spectrogramExports.start(len(notes))
+	if !ok {
+		return c.HandleError(ctx, errors.Newf("a spectrogram export is already running").
+			Component("api").
+			Category(errors.CategoryConflict).
+			Build(), "Another spectrogram export is still running", http.StatusConflict)
+	}
+
+	jobCtx := c.ctx
+	if jobCtx == nil {
+		jobCtx = context.Background()
+	}
+	c.wg.Go(func() {
+		c.runSpectrogramExport(jobCtx, job.ID, notes, &req)
+	})
+
+	snapshot, _ := spectrogramExports.get(job.ID)
+	return ctx.JSON(http.StatusAccepted, snapshot)
+}
+
+// GetSpectrogramExport handles GET /api/v2/spectrograms/export/:id
+func (c *Controller) GetSpectrogramExport(ctx echo.Context) error {
+	job, ok := spectrogramExports.get(ctx.Param("id"))
+	if !ok {
+		return c.HandleError(ctx, errors.Newf("spectrogram export %s not found", ctx.Param("id")).
+			Component("api").
+			Category(errors.CategoryNotFound).
+			Build(), "Spectrogram export not found", http.StatusNotFound)
+	}
+	return ctx.JSON(http.StatusOK, job)
+}
+
+// DownloadSpectrogramExport handles GET /api/v2/spectrograms/export/:id/download
+func (c *Controller) DownloadSpectrogramExport(ctx echo.Context) error {
+	job, ok := spectrogramExports.get(ctx.Param("id"))
+	if !ok || job.Status != exportStatusCompleted {
+		return c.HandleError(ctx, errors.Newf("spectrogram export %s not available", ctx.Param("id")).
+			Component("api").
+			Category(errors.CategoryNotFound).
+			Build(), "Spectrogram export not found or not completed", http.StatusNotFound)
+	}
+	return ctx.Attachment(job.path, fmt.Sprintf("spectrograms-%s.zip", job.CreatedAt.Format("20060102-150405")))
+}
+
+// normalize applies defaults and validates the request
+func (req *SpectrogramExportRequest) normalize() error {
+	invalid := func(format string, args ...any) error {
+		return errors.Newf(format, args...).
+			Component("api").
+			Category(errors.CategoryValidation).
+			Build()
+	}
+
+	if req.Limit == 0 {
+		req.Limit = defaultExportLimit
+	}
+	if req.Limit < 0 || req.Limit > maxExportLimit {
+		return invalid("limit must be between 1 and %d", maxExportLimit)
+	}
+	if len(req.IDs) > maxExportLimit {
+		return invalid("at most %d detection IDs can be exported at once", maxExportLimit)
+	}
+	for _, date := range []string{req.StartDate, req.EndDate} {
+		if _, err := time.Parse(time.DateOnly, date); date != "" && err != nil {
+			return invalid("dates must use the YYYY-MM-DD format")
+		}
+	}
+	if req.MinConfidence < 0 || req.MinConfidence > 100 {
+		return invalid("minConfidence must be a percentage between 0 and 100")
+	}
+
+	if req.Width == 0 {
+		req.Width = defaultExportWidth
+	}
+	if req.Height == 0 {
+		req.Height = req.Width / 2
+	}
+	if req.Width < 100 || req.Width > maxExportDimension || req.Height < 50 || req.Height > maxExportDimension {
+		return invalid("width must be between 100 and %d and height between 50 and %d pixels", maxExportDimension, maxExportDimension)
+	}
+
+	if req.Colormap == "" {
+		req.Colormap = defaultExportColormap
+	}
+	if !slices.Contains(exportColormaps, req.Colormap) {
+		return invalid("colormap must be one of %s", strings.Join(exportColormaps, ", "))
+	}
+
+	if req.MinFrequency < 0 || req.MaxFrequency < 0 || req.MinFrequency > maxExportFrequency || req.MaxFrequency > maxExportFrequency ||
+		(req.MaxFrequency > 0 && req.MaxFrequency <= req.MinFrequency) {
+		return invalid("frequency range must be within 0 to %d Hz with maxFrequency above minFrequency", maxExportFrequency)
+	}
+	return nil
+}
+
+// exportNotes returns the detections with audio clips selected by an export request
+func (c *Controller) exportNotes(req *SpectrogramExportRequest) ([]datastore.Note, error) {
+	var notes []datastore.Note
+	if len(req.IDs) > 0 {
+		for _, id := range req.IDs {
+			note, err := c.DS.Get(strconv.FormatUint(uint64(id), 10))
+			if err != nil {
+				continue
+			}
+			notes = append(notes, note)
+		}
+	} else {
+		filters := c.exportSearchFilters(req.Species, req.StartDate, req.EndDate, req.MinConfidence, req.Verified, req.Limit)
+		var err error
+		if notes, _, err = c.DS.SearchNotesAdvanced(&filters); err != nil {
+			return nil, err
+		}
+	}
+
+	withClips := notes[:0]
+	for i := range notes {
+		if notes[i].ClipName != "" {
+			withClips = append(withClips, notes[i])
+		}
+	}
+	return withClips, nil
+}
+
+// exportSearchFilters returns the search filters selecting the detections of
+// an export. Dates are YYYY-MM-DD and the minimum confidence is a percentage.
+// Species also match detections stored under their former names.
+func (c *Controller) exportSearchFilters(species []string, startDate, endDate string, minConfidence float64, verified *bool, limit int) datastore.AdvancedSearchFilters {
+	filters := datastore.AdvancedSearchFilters{
+		Species:  c.speciesAliases().Expand(species),
+		Verified: verified,
+		Limit:    limit,
+	}
+	if minConfidence > 0 {
+		filters.Confidence = &datastore.ConfidenceFilter{Operator: ">=", Value: minConfidence / 100}
+	}
+	if startDate != "" || endDate != "" {
+		start, _ := time.Parse(time.DateOnly, cmp.Or(startDate, "0001-01-01"))
+		end, _ := time.Parse(time.DateOnly, cmp.Or(endDate, "9999-12-31"))
+		filters.DateRange = &datastore.DateRange{Start: start, End: end}
+	}
+	return filters
+}
+
+// runSpectrogramExport renders the spectrograms of an export into a zip file
+// with a manifest.csv describing each detection
+func (c *Controller) runSpectrogramExport(ctx context.Context, jobID string, notes []datastore.Note, req *SpectrogramExportRequest) {
+	zipPath := filepath.Join(os.TempDir(), fmt.Sprintf("birdnet-go-spectrograms-%s.zip", jobID))
+	err := c.writeSpectrogramExport(ctx, jobID, zipPath, notes, req)
+	downloadURL := fmt.Sprintf("/api/v2/spectrograms/export/%s/download", jobID)
+	var checksum string
+	if err == nil {
+		checksum = c.recordExport(zipPath, downloadURL)
+	}
+	spectrogramExports.update(jobID, func(job *ExportJob) {
+		if err != nil {
+			job.Status = exportStatusFailed
+			job.Error = err.Error()
+			return
+		}
+		job.Status = exportStatusCompleted
+		job.path = zipPath
+		job.DownloadURL = downloadURL
+		job.SHA256 = checksum
+	})
+	if err != nil {
+		_ = os.Remove(zipPath)
+		if c.apiLogger != nil {
+			c.apiLogger.Error("Spectrogram export failed", "job_id", jobID, "error", err)
+		}
+	}
+}
+
+// recordExport records the zip file of a completed export in the manifest of
+// uploads and exports and returns its checksum, empty when not recorded
+func (c *Controller) recordExport(zipPath, downloadURL string) string {
+	if !manifest.Enabled() {
+		return ""
+	}
+	checksum, size, err := manifest.ChecksumFile(zipPath)
+	if err != nil {
+		if c.apiLogger != nil {
+			c.apiLogger.Warn("Failed to checksum export for the manifest", "path", zipPath, "error", err)
+		}
+		return ""
+	}
+	manifest.Record(&datastore.ManifestEntry{
+		Kind:        manifest.KindExport,
+		Name:        filepath.Base(zipPath),
+		Destination: downloadURL,
+		Path:        zipPath,
+		SHA256:      checksum,
+		Size:        size,
+	})
+	return checksum
+}
+
+// writeSpectrogramExport writes the zip file of an export
+func (c *Controller) writeSpectrogramExport(ctx context.Context, jobID, zipPath string, notes []datastore.Note, req *SpectrogramExportRequest) error {
+	workDir, err := os.MkdirTemp("", "birdnet-go-spectrogram-export-")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.RemoveAll(workDir) }()
+
+	file, err := os.OpenFile(zipPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = file.Close() }()
+	archive := zip.NewWriter(file)
+
+	var manifest bytes.Buffer
+	csvWriter := csv.NewWriter(&manifest)
+	_ = csvWriter.Write([]string{"id", "file", "scientific_name", "common_name", "date", "time", "confidence", "error"})
+
+	names := strings.NewReplacer(" ", "_", ":", "-", "/", "-")
+	for i := range notes {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		note := &notes[i]
+		name := fmt.Sprintf("%d_%s_%s_%s.png", note.ID, names.Replace(note.ScientificName), note.Date, names.Replace(note.Time))
+
+		renderErr := c.renderExportNote(ctx, note, filepath.Join(workDir, name), req)
+		if renderErr == nil {
+			renderErr = addFileToZip(archive, filepath.Join(workDir, name), name)
+		}
+		record := []string{
+			strconv.FormatUint(uint64(note.ID), 10), name, note.ScientificName, note.CommonName,
+			note.Date, note.Time, strconv.FormatFloat(note.Confidence, 'f', 4, 64), "",
+		}
+		if renderErr != nil {
+			record[1], record[7] = "", renderErr.Error()
+		}
+		_ = csvWriter.Write(record)
+
+		spectrogramExports.update(jobID, func(job *ExportJob) {
+			if renderErr != nil {
+				job.Failed++
+			} else {
+				job.Rendered++
+			}
+		})
+	}
+
+	if err := c.addStationToZip(archive); err != nil {
+		return err
+	}
+
+	csvWriter.Flush()
+	w, err := archive.Create("manifest.csv")
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(manifest.Bytes()); err != nil {
+		return err
+	}
+	if err := archive.Close(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// renderExportNote renders the spectrogram of one detection
+func (c *Controller) renderExportNote(ctx context.Context, note *datastore.Note, imagePath string, req *SpectrogramExportRequest) error {
+	absAudioPath, cleanup, err := c.exportClipPath(note)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	return renderExportSpectrogram(ctx, c.Settings, absAudioPath, imagePath, req)
+}
+
+// exportClipPath returns the absolute path of the plaintext audio clip of a
+// detection. The cleanup function removes any decrypted copy.
+func (c *Controller) exportClipPath(note *datastore.Note) (string, func(), error) {
+	relAudioPath, err := c.normalizeAndValidatePath(note.ClipName)
+	if err != nil {
+		return "", nil, err
+	}
+	absAudioPath, cleanup, err := atrest.PlaintextPath(filepath.Join(c.SFS.BaseDir(), relAudioPath))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read audio clip: %w", err)
+	}
+	return absAudioPath, cleanup, nil
+}
+
+// addFileToZip copies a file into a zip archive
+func addFileToZip(archive *zip.Writer, path, name string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = src.Close() }()
+	w, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, src)
+	return err
+}
+
+// renderSpectrogramWithOptions renders a spectrogram with FFmpeg showspectrumpic
+// using the dimensions, colormap and frequency range of an export request
+func renderSpectrogramWithOptions(ctx context.Context, settings *conf.Settings, absAudioPath, absImagePath string, req *SpectrogramExportRequest) error {
+	if err := runExportFFmpeg(ctx, settings, exportSpectrogramArgs(absAudioPath, absImagePath, req)); err != nil {
+		return fmt.Errorf("%w: %w", ErrSpectrogramGeneration, err)
+	}
+	return nil
+}
+
+// runExportFFmpeg runs FFmpeg at the lowest priority for an export
+func runExportFFmpeg(ctx context.Context, settings *conf.Settings, args []string) error {
+	ffmpegBinary := settings.Realtime.Audio.FfmpegPath
+	if ffmpegBinary == "" {
+		return ErrFFmpegNotConfigured
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		// #nosec G204 - ffmpegBinary is validated by ValidateToolPath/exec.LookPath
+		cmd = exec.CommandContext(ctx, ffmpegBinary, args...)
+	} else {
+		// #nosec G204 - ffmpegBinary is validated by ValidateToolPath/exec.LookPath
+		cmd = exec.CommandContext(ctx, "nice", append([]string{"-n", "19", ffmpegBinary}, args...)...)
+	}
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("ffmpeg failed: %w (output: %s)", err, output.String())
+	}
+	return nil
+}
+
+// exportSpectrogramArgs returns the FFmpeg arguments rendering a spectrogram
+// for an export request
+func exportSpectrogramArgs(absAudioPath, absImagePath string, req *SpectrogramExportRequest) []string {
+	legend := 0
+	if req.Legend {
+		legend = 1
+	}
+	filter := fmt.Sprintf("showspectrumpic=s=%dx%d:legend=%d:color=%s:gain=3:drange=100", req.Width, req.Height, legend, req.Colormap)
+	if req.MinFrequency > 0 {
+		filter += fmt.Sprintf(":start=%d", req.MinFrequency)
+	}
+	if req.MaxFrequency > 0 {
+		filter += fmt.Sprintf(":stop=%d", req.MaxFrequency)
+	}
+	return []string{"-hide_banner", "-y", "-i", absAudioPath, "-lavfi", filter, "-frames:v", "1", absImagePath}
+}
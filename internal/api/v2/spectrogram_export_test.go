@@ -0,0 +1,165 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+func TestSpectrogramExportRequestNormalize(t *testing.T) {
+	t.Parallel()
+
+	req := SpectrogramExportRequest{}
+	require.NoError(t, req.normalize())
+	assert.Equal(t, defaultExportLimit, req.Limit)
+	assert.Equal(t, defaultExportWidth, req.Width)
+	assert.Equal(t, defaultExportWidth/2, req.Height)
+	assert.Equal(t, defaultExportColormap, req.Colormap)
+
+	invalid := map[string]SpectrogramExportRequest{
+		"limit":           {Limit: maxExportLimit + 1},
+		"date":            {StartDate: "01.05.2024"},
+		"confidence":      {MinConfidence: 150},
+		"width":           {Width: 50},
+		"height":          {Height: maxExportDimension + 1},
+		"colormap":        {Colormap: "sepia"},
+		"frequency order": {MinFrequency: 8000, MaxFrequency: 2000},
+		"frequency limit": {MaxFrequency: maxExportFrequency + 1},
+	}
+	for name, req := range invalid {
+		assert.Error(t, req.normalize(), name)
+	}
+}
+
+func TestExportSpectrogramArgs(t *testing.T) {
+	t.Parallel()
+
+	req := SpectrogramExportRequest{Width: 1200, Height: 400, Colormap: "viridis", MinFrequency: 1000, MaxFrequency: 12000, Legend: true}
+	args := exportSpectrogramArgs("in.wav", "out.png", &req)
+	filter := args[5]
+	assert.Contains(t, filter, "s=1200x400")
+	assert.Contains(t, filter, "legend=1")
+	assert.Contains(t, filter, "color=viridis")
+	assert.Contains(t, filter, ":start=1000")
+	assert.Contains(t, filter, ":stop=12000")
+	assert.Equal(t, "out.png", args[len(args)-1])
+}
+
+// TestSpectrogramExport runs an export with a fake renderer. It replaces the
+// package level renderer and job registry so it does not run in parallel.
+func TestSpectrogramExport(t *testing.T) {
+	e, controller, tempDir := setupMediaTestEnvironment(t)
+	mockDS := controller.DS.(*MockDataStore)
+
+	require.NoError(t, createTestAudioFile(t, filepath.Join(tempDir, "blackbird.wav")))
+	notes := []datastore.Note{
+		{ID: 1, Date: "2024-05-01", Time: "06:10:00", ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", Confidence: 0.9, ClipName: "blackbird.wav"},
+		{ID: 2, Date: "2024-05-01", Time: "06:20:00", ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", Confidence: 0.8, ClipName: "missing.wav"},
+		{ID: 3, Date: "2024-05-01", Time: "06:30:00", ScientificName: "Pica pica", CommonName: "Eurasian Magpie", Confidence: 0.7},
+	}
+	mockDS.On("SearchNotesAdvanced", mock.MatchedBy(func(f *datastore.AdvancedSearchFilters) bool {
+		return f.Confidence != nil && f.Confidence.Value == 0.5 && f.DateRange != nil
+	})).Return(notes, int64(len(notes)), nil)
+
+	var rendered SpectrogramExportRequest
+	origRenderer := renderExportSpectrogram
+	renderExportSpectrogram = func(_ context.Context, _ *conf.Settings, absAudioPath, absImagePath string, req *SpectrogramExportRequest) error {
+		rendered = *req
+		if _, err := os.Stat(absAudioPath); err != nil {
+			return err
+		}
+		return os.WriteFile(absImagePath, []byte("png"), 0o600)
+	}
+	origExports := spectrogramExports
+	spectrogramExports = &exportJobs{jobs: make(map[string]*ExportJob)}
+	t.Cleanup(func() {
+		renderExportSpectrogram = origRenderer
+		spectrogramExports = origExports
+	})
+
+	body := `{"startDate":"2024-05-01","endDate":"2024-05-01","minConfidence":50,"colormap":"magma","width":400}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/spectrograms/export", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	require.NoError(t, controller.StartSpectrogramExport(e.NewContext(req, rec)))
+	require.Equal(t, http.StatusAccepted, rec.Code)
+
+	var job ExportJob
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &job))
+	assert.Equal(t, 2, job.Total, "detections without clips should be skipped")
+
+	// A second export is rejected while the first one runs
+	if current, _ := spectrogramExports.get(job.ID); current.Status == exportStatusRunning {
+		req = httptest.NewRequest(http.MethodPost, "/api/v2/spectrograms/export", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec = httptest.NewRecorder()
+		require.NoError(t, controller.StartSpectrogramExport(e.NewContext(req, rec)))
+		assert.Contains(t, []int{http.StatusConflict, http.StatusAccepted}, rec.Code)
+	}
+
+	require.Eventually(t, func() bool {
+		current, _ := spectrogramExports.get(job.ID)
+		return current.Status != exportStatusRunning
+	}, 5*time.Second, 10*time.Millisecond)
+
+	status := func(path, id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, http.NoBody)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(id)
+		if strings.HasSuffix(path, "/download") {
+			require.NoError(t, controller.DownloadSpectrogramExport(c))
+		} else {
+			require.NoError(t, controller.GetSpectrogramExport(c))
+		}
+		return rec
+	}
+
+	rec = status("/api/v2/spectrograms/export/"+job.ID, job.ID)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &job))
+	assert.Equal(t, exportStatusCompleted, job.Status)
+	assert.Equal(t, 1, job.Rendered)
+	assert.Equal(t, 1, job.Failed)
+	assert.Equal(t, "magma", rendered.Colormap)
+	assert.Equal(t, 200, rendered.Height)
+
+	rec = status("/api/v2/spectrograms/export/"+job.ID+"/download", job.ID)
+	require.Equal(t, http.StatusOK, rec.Code)
+	archive, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+	require.NoError(t, err)
+	files := make(map[string]string)
+	for _, f := range archive.File {
+		r, err := f.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(r)
+		require.NoError(t, err)
+		_ = r.Close()
+		files[f.Name] = string(content)
+	}
+	assert.Equal(t, "png", files["1_Turdus_merula_2024-05-01_06-10-00.png"])
+	require.Contains(t, files, "manifest.csv")
+	assert.Contains(t, files["manifest.csv"], "1,1_Turdus_merula_2024-05-01_06-10-00.png,Turdus merula")
+	assert.Len(t, strings.Split(strings.TrimSpace(files["manifest.csv"]), "\n"), 3)
+
+	rec = status("/api/v2/spectrograms/export/unknown", "unknown")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	rec = status("/api/v2/spectrograms/export/unknown/download", "unknown")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
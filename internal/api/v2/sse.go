@@ -386,8 +386,12 @@ func (c *Controller) handleSSEStream(ctx echo.Context, streamType, message, logP
 	return eventLoop(ctx, client, clientID)
 }
 
-// StreamDetections handles the SSE connection for real-time detection streaming
+// StreamDetections handles the SSE connection for real-time detection streaming,
+// or a WebSocket connection when the client asks to upgrade
 func (c *Controller) StreamDetections(ctx echo.Context) error {
+	if isWebSocketRequest(ctx) && c.detectionStream != nil {
+		return c.StreamDetectionsWebSocket(ctx)
+	}
 	return c.handleSSEStream(ctx, "detections", "Connected to detection stream", "detection",
 		func(client *SSEClient) {
 			client.Channel = make(chan SSEDetectionData, sseDetectionBufferSize) // Buffer for high detection periods
@@ -497,6 +501,12 @@ func (c *Controller) runSSEEventLoop(ctx echo.Context, client *SSEClient, client
 
 // sendSSEMessage sends a Server-Sent Event message
 func (c *Controller) sendSSEMessage(ctx echo.Context, event string, data any) error {
+	return c.sendSSEEvent(ctx, "", event, data)
+}
+
+// sendSSEEvent sends a Server-Sent Event message with an event ID, which the
+// client sends back as Last-Event-ID when it reconnects. An empty ID is left out.
+func (c *Controller) sendSSEEvent(ctx echo.Context, id, event string, data any) error {
 	// Convert data to JSON
 	jsonData, err := json.Marshal(data)
 	if err != nil {
@@ -505,6 +515,9 @@ func (c *Controller) sendSSEMessage(ctx echo.Context, event string, data any) er
 
 	// Format SSE message
 	message := fmt.Sprintf("event: %s\ndata: %s\n\n", event, string(jsonData))
+	if id != "" {
+		message = "id: " + id + "\n" + message
+	}
 
 	// Set write deadline to prevent hanging on slow/disconnected clients
 	if conn, ok := ctx.Response().Writer.(WriteDeadlineSetter); ok {
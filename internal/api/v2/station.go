@@ -0,0 +1,325 @@
+// internal/api/v2/station.go
+package api
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+const (
+	// maxStationPhotoSize bounds an uploaded station photo, within the API request body limit
+	maxStationPhotoSize = 1000 * 1024
+
+	// stationPhotoURL is where the photo of the station is served
+	stationPhotoURL = "/api/v2/station/photo"
+)
+
+// stationPhotoTypes are the accepted content types of a station photo
+var stationPhotoTypes = []string{"image/jpeg", "image/png", "image/webp"}
+
+// StationProfiler is implemented by datastores that store a station profile
+// and its equipment history
+type StationProfiler interface {
+	GetStationProfile() (*datastore.StationProfile, error)
+	SaveStationProfile(profile *datastore.StationProfile, changedAt time.Time, note string) error
+	SetStationPhoto(data []byte, contentType string) error
+	GetStationEquipmentHistory() ([]datastore.StationEquipmentChange, error)
+}
+
+// StationProfileResponse describes the site and equipment of the station
+type StationProfileResponse struct {
+	Name            string     `json:"name"`
+	Description     string     `json:"description"`
+	Habitat         string     `json:"habitat"`
+	MicrophoneModel string     `json:"microphone_model"`
+	MountingHeight  float64    `json:"mounting_height"` // meters above ground
+	PhotoURL        string     `json:"photo_url,omitempty"`
+	Latitude        float64    `json:"latitude"`
+	Longitude       float64    `json:"longitude"`
+	UpdatedAt       *time.Time `json:"updated_at,omitempty"`
+}
+
+// StationProfileRequest updates the station profile. A change of microphone
+// model or mounting height is recorded in the equipment history at ChangedAt
+// (RFC 3339, default now) with EquipmentNote as its reason.
+type StationProfileRequest struct {
+	Name            string  `json:"name"`
+	Description     string  `json:"description"`
+	Habitat         string  `json:"habitat"`
+	MicrophoneModel string  `json:"microphone_model"`
+	MountingHeight  float64 `json:"mounting_height"`
+	EquipmentNote   string  `json:"equipment_note,omitempty"`
+	ChangedAt       string  `json:"changed_at,omitempty"`
+}
+
+// StationEquipmentResponse represents a recorded equipment change
+type StationEquipmentResponse struct {
+	ChangedAt       time.Time `json:"changed_at"`
+	MicrophoneModel string    `json:"microphone_model"`
+	MountingHeight  float64   `json:"mounting_height"`
+	Note            string    `json:"note,omitempty"`
+}
+
+// initStationRoutes registers the station profile endpoints. The profile is
+// public so public and federated views can show the site metadata.
+func (c *Controller) initStationRoutes() {
+	stationGroup := c.Group.Group("/station")
+	stationGroup.GET("", c.GetStationProfile)
+	stationGroup.GET("/photo", c.GetStationPhoto)
+	stationGroup.GET("/equipment", c.GetStationEquipment)
+
+	protectedGroup := stationGroup.Group("", c.getEffectiveAuthMiddleware())
+	protectedGroup.PUT("", c.UpdateStationProfile)
+	protectedGroup.PUT("/photo", c.UploadStationPhoto)
+	protectedGroup.DELETE("/photo", c.DeleteStationPhoto)
+}
+
+// errStationUnsupported reports that the datastore does not store a station profile
+func (c *Controller) errStationUnsupported(ctx echo.Context) error {
+	return c.HandleError(ctx, errors.Newf("datastore does not support station profiles").
+		Component("api").
+		Category(errors.CategoryConfiguration).
+		Build(), "Station profiles are not supported by this datastore", http.StatusNotImplemented)
+}
+
+// stationProfileResponse converts a stored profile, adding the station location
+func (c *Controller) stationProfileResponse(profile *datastore.StationProfile) StationProfileResponse {
+	resp := StationProfileResponse{
+		Name:            profile.Name,
+		Description:     profile.Description,
+		Habitat:         profile.Habitat,
+		MicrophoneModel: profile.MicrophoneModel,
+		MountingHeight:  profile.MountingHeight,
+		Latitude:        c.Settings.BirdNET.Latitude,
+		Longitude:       c.Settings.BirdNET.Longitude,
+	}
+	if len(profile.Photo) > 0 {
+		resp.PhotoURL = stationPhotoURL
+	}
+	if !profile.UpdatedAt.IsZero() {
+		updatedAt := profile.UpdatedAt
+		resp.UpdatedAt = &updatedAt
+	}
+	return resp
+}
+
+// GetStationProfile handles GET /api/v2/station
+func (c *Controller) GetStationProfile(ctx echo.Context) error {
+	profiler, ok := c.DS.(StationProfiler)
+	if !ok {
+		return c.errStationUnsupported(ctx)
+	}
+	profile, err := profiler.GetStationProfile()
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to get station profile", http.StatusInternalServerError)
+	}
+	return ctx.JSON(http.StatusOK, c.stationProfileResponse(profile))
+}
+
+// UpdateStationProfile handles PUT /api/v2/station
+func (c *Controller) UpdateStationProfile(ctx echo.Context) error {
+	profiler, ok := c.DS.(StationProfiler)
+	if !ok {
+		return c.errStationUnsupported(ctx)
+	}
+
+	var req StationProfileRequest
+	if err := ctx.Bind(&req); err != nil {
+		return c.HandleError(ctx, err, "Invalid request body", http.StatusBadRequest)
+	}
+	if err := validateStationProfileRequest(&req); err != nil {
+		return c.HandleError(ctx, err, err.Error(), http.StatusBadRequest)
+	}
+	changedAt := time.Now()
+	if req.ChangedAt != "" {
+		var err error
+		if changedAt, err = time.Parse(time.RFC3339, req.ChangedAt); err != nil {
+			return c.HandleError(ctx, err, "Invalid changed_at time, use RFC 3339 format", http.StatusBadRequest)
+		}
+	}
+
+	profile := &datastore.StationProfile{
+		Name:            strings.TrimSpace(req.Name),
+		Description:     strings.TrimSpace(req.Description),
+		Habitat:         strings.TrimSpace(req.Habitat),
+		MicrophoneModel: strings.TrimSpace(req.MicrophoneModel),
+		MountingHeight:  req.MountingHeight,
+	}
+	if err := profiler.SaveStationProfile(profile, changedAt, strings.TrimSpace(req.EquipmentNote)); err != nil {
+		return c.HandleError(ctx, err, "Failed to save station profile", http.StatusInternalServerError)
+	}
+	c.logAPIRequest(ctx, slog.LevelInfo, "Station profile updated", "name", profile.Name)
+
+	return ctx.JSON(http.StatusOK, c.stationProfileResponse(profile))
+}
+
+// validateStationProfileRequest checks the lengths and mounting height of a
+// profile update
+func validateStationProfileRequest(req *StationProfileRequest) error {
+	fields := []struct {
+		name, value string
+		maxLength   int
+	}{
+		{"name", req.Name, 128},
+		{"description", req.Description, 2048},
+		{"habitat", req.Habitat, 64},
+		{"microphone_model", req.MicrophoneModel, 128},
+		{"equipment_note", req.EquipmentNote, 512},
+	}
+	for _, f := range fields {
+		if len(strings.TrimSpace(f.value)) > f.maxLength {
+			return errors.Newf("%s must be at most %d characters", f.name, f.maxLength).
+				Component("api").
+				Category(errors.CategoryValidation).
+				Context("field", f.name).
+				Build()
+		}
+	}
+	if req.MountingHeight < 0 {
+		return errors.Newf("mounting_height cannot be negative").
+			Component("api").
+			Category(errors.CategoryValidation).
+			Context("field", "mounting_height").
+			Build()
+	}
+	return nil
+}
+
+// GetStationEquipment handles GET /api/v2/station/equipment
+// It returns the equipment changes of the station, most recent first.
+func (c *Controller) GetStationEquipment(ctx echo.Context) error {
+	profiler, ok := c.DS.(StationProfiler)
+	if !ok {
+		return c.errStationUnsupported(ctx)
+	}
+	changes, err := profiler.GetStationEquipmentHistory()
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to get equipment history", http.StatusInternalServerError)
+	}
+
+	return ctx.JSON(http.StatusOK, stationEquipmentResponses(changes))
+}
+
+// stationEquipmentResponses converts recorded equipment changes
+func stationEquipmentResponses(changes []datastore.StationEquipmentChange) []StationEquipmentResponse {
+	resp := make([]StationEquipmentResponse, 0, len(changes))
+	for i := range changes {
+		resp = append(resp, StationEquipmentResponse{
+			ChangedAt:       changes[i].ChangedAt,
+			MicrophoneModel: changes[i].MicrophoneModel,
+			MountingHeight:  changes[i].MountingHeight,
+			Note:            changes[i].Note,
+		})
+	}
+	return resp
+}
+
+// GetStationPhoto handles GET /api/v2/station/photo
+func (c *Controller) GetStationPhoto(ctx echo.Context) error {
+	profiler, ok := c.DS.(StationProfiler)
+	if !ok {
+		return c.errStationUnsupported(ctx)
+	}
+	profile, err := profiler.GetStationProfile()
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to get station photo", http.StatusInternalServerError)
+	}
+	if len(profile.Photo) == 0 {
+		return c.HandleError(ctx, errors.Newf("station photo not found").
+			Component("api").
+			Category(errors.CategoryNotFound).
+			Build(), "No station photo has been uploaded", http.StatusNotFound)
+	}
+	ctx.Response().Header().Set("Cache-Control", "public, max-age=300")
+	return ctx.Blob(http.StatusOK, profile.PhotoType, profile.Photo)
+}
+
+// UploadStationPhoto handles PUT /api/v2/station/photo
+// The body is the image, with a JPEG, PNG or WebP content type.
+func (c *Controller) UploadStationPhoto(ctx echo.Context) error {
+	profiler, ok := c.DS.(StationProfiler)
+	if !ok {
+		return c.errStationUnsupported(ctx)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(ctx.Request().Body, maxStationPhotoSize+1))
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to read station photo", http.StatusBadRequest)
+	}
+	if len(data) == 0 || len(data) > maxStationPhotoSize {
+		return c.HandleError(ctx, errors.Newf("station photo must be between 1 and %d bytes", maxStationPhotoSize).
+			Component("api").
+			Category(errors.CategoryValidation).
+			Context("size", len(data)).
+			Build(), "Station photo is empty or too large", http.StatusBadRequest)
+	}
+	contentType := http.DetectContentType(data)
+	if !slices.Contains(stationPhotoTypes, contentType) {
+		return c.HandleError(ctx, errors.Newf("unsupported station photo type %s", contentType).
+			Component("api").
+			Category(errors.CategoryValidation).
+			Context("content_type", contentType).
+			Build(), "Station photo must be a JPEG, PNG or WebP image", http.StatusUnsupportedMediaType)
+	}
+
+	if err := profiler.SetStationPhoto(data, contentType); err != nil {
+		return c.HandleError(ctx, err, "Failed to save station photo", http.StatusInternalServerError)
+	}
+	c.logAPIRequest(ctx, slog.LevelInfo, "Station photo uploaded", "size", len(data), "content_type", contentType)
+	return ctx.JSON(http.StatusOK, map[string]string{"photo_url": stationPhotoURL})
+}
+
+// DeleteStationPhoto handles DELETE /api/v2/station/photo
+func (c *Controller) DeleteStationPhoto(ctx echo.Context) error {
+	profiler, ok := c.DS.(StationProfiler)
+	if !ok {
+		return c.errStationUnsupported(ctx)
+	}
+	if err := profiler.SetStationPhoto(nil, ""); err != nil {
+		return c.HandleError(ctx, err, "Failed to delete station photo", http.StatusInternalServerError)
+	}
+	c.logAPIRequest(ctx, slog.LevelInfo, "Station photo deleted")
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// addStationToZip writes the station profile and equipment history to an
+// export as station.json, so exported data carries its site metadata. Nothing
+// is written when the datastore has no station profile.
+func (c *Controller) addStationToZip(archive *zip.Writer) error {
+	profiler, ok := c.DS.(StationProfiler)
+	if !ok {
+		return nil
+	}
+	profile, err := profiler.GetStationProfile()
+	if err != nil {
+		return err
+	}
+	changes, err := profiler.GetStationEquipmentHistory()
+	if err != nil {
+		return err
+	}
+
+	station := struct {
+		StationProfileResponse
+		Equipment []StationEquipmentResponse `json:"equipment"`
+	}{c.stationProfileResponse(profile), stationEquipmentResponses(changes)}
+	station.PhotoURL = "" // The photo is not part of the export
+
+	w, err := archive.Create("station.json")
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(station)
+}
@@ -0,0 +1,154 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+// stationMockDataStore adds station profile support to MockDataStore
+type stationMockDataStore struct {
+	*MockDataStore
+	profile datastore.StationProfile
+	changes []datastore.StationEquipmentChange
+}
+
+func (m *stationMockDataStore) GetStationProfile() (*datastore.StationProfile, error) {
+	profile := m.profile
+	return &profile, nil
+}
+
+func (m *stationMockDataStore) SaveStationProfile(profile *datastore.StationProfile, changedAt time.Time, note string) error {
+	if profile.MicrophoneModel != m.profile.MicrophoneModel || profile.MountingHeight != m.profile.MountingHeight {
+		m.changes = append([]datastore.StationEquipmentChange{{
+			ChangedAt: changedAt, MicrophoneModel: profile.MicrophoneModel, MountingHeight: profile.MountingHeight, Note: note,
+		}}, m.changes...)
+	}
+	profile.Photo, profile.PhotoType = m.profile.Photo, m.profile.PhotoType
+	profile.UpdatedAt = time.Now()
+	m.profile = *profile
+	return nil
+}
+
+func (m *stationMockDataStore) SetStationPhoto(data []byte, contentType string) error {
+	m.profile.Photo, m.profile.PhotoType = data, contentType
+	return nil
+}
+
+func (m *stationMockDataStore) GetStationEquipmentHistory() ([]datastore.StationEquipmentChange, error) {
+	return m.changes, nil
+}
+
+// stationRequest sends a request to a station handler and returns the response recorder
+func stationRequest(t *testing.T, controller *Controller, handler echo.HandlerFunc, method, contentType string, body io.Reader) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, "/api/v2/station", body)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	rec := httptest.NewRecorder()
+	require.NoError(t, handler(controller.Echo.NewContext(req, rec)))
+	return rec
+}
+
+func TestStationProfile(t *testing.T) {
+	t.Parallel()
+
+	_, mockDS, controller := setupTestEnvironment(t)
+	store := &stationMockDataStore{MockDataStore: mockDS}
+	controller.DS = store
+
+	body := `{"name":"Garden","habitat":"urban garden","microphone_model":"AudioMoth","mounting_height":2.5,` +
+		`"equipment_note":"installed","changed_at":"2024-03-01T12:00:00Z"}`
+	rec := stationRequest(t, controller, controller.UpdateStationProfile, http.MethodPut, "application/json", strings.NewReader(body))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec = stationRequest(t, controller, controller.GetStationProfile, http.MethodGet, "", http.NoBody)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var profile StationProfileResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &profile))
+	assert.Equal(t, "Garden", profile.Name)
+	assert.Equal(t, "AudioMoth", profile.MicrophoneModel)
+	assert.InDelta(t, 2.5, profile.MountingHeight, 1e-9)
+	assert.Empty(t, profile.PhotoURL)
+
+	rec = stationRequest(t, controller, controller.GetStationEquipment, http.MethodGet, "", http.NoBody)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var equipment []StationEquipmentResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &equipment))
+	require.Len(t, equipment, 1)
+	assert.Equal(t, "installed", equipment[0].Note)
+	assert.Equal(t, time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), equipment[0].ChangedAt.UTC())
+
+	rec = stationRequest(t, controller, controller.UpdateStationProfile, http.MethodPut, "application/json", strings.NewReader(`{"mounting_height":-1}`))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	rec = stationRequest(t, controller, controller.UpdateStationProfile, http.MethodPut, "application/json", strings.NewReader(`{"changed_at":"yesterday"}`))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestStationPhoto(t *testing.T) {
+	t.Parallel()
+
+	_, mockDS, controller := setupTestEnvironment(t)
+	store := &stationMockDataStore{MockDataStore: mockDS}
+	controller.DS = store
+
+	rec := stationRequest(t, controller, controller.GetStationPhoto, http.MethodGet, "", http.NoBody)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = stationRequest(t, controller, controller.UploadStationPhoto, http.MethodPut, "image/png", strings.NewReader("not an image"))
+	assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+
+	png := append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 32)...)
+	rec = stationRequest(t, controller, controller.UploadStationPhoto, http.MethodPut, "image/png", bytes.NewReader(png))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec = stationRequest(t, controller, controller.GetStationPhoto, http.MethodGet, "", http.NoBody)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "image/png", rec.Header().Get("Content-Type"))
+	assert.Equal(t, png, rec.Body.Bytes())
+
+	rec = stationRequest(t, controller, controller.DeleteStationPhoto, http.MethodDelete, "", http.NoBody)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Empty(t, store.profile.Photo)
+}
+
+func TestAddStationToZip(t *testing.T) {
+	t.Parallel()
+
+	_, mockDS, controller := setupTestEnvironment(t)
+	controller.DS = &stationMockDataStore{
+		MockDataStore: mockDS,
+		profile:       datastore.StationProfile{Name: "Garden", Photo: []byte("photo")},
+		changes:       []datastore.StationEquipmentChange{{MicrophoneModel: "AudioMoth"}},
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	require.NoError(t, controller.addStationToZip(archive))
+	require.NoError(t, archive.Close())
+
+	reader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	require.Len(t, reader.File, 1)
+	assert.Equal(t, "station.json", reader.File[0].Name)
+	f, err := reader.File[0].Open()
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+	var station map[string]any
+	require.NoError(t, json.NewDecoder(f).Decode(&station))
+	assert.Equal(t, "Garden", station["name"])
+	assert.NotContains(t, station, "photo_url")
+	assert.Len(t, station["equipment"], 1)
+}
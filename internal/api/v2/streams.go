@@ -216,8 +216,10 @@ func (client *Client) writePump() {
 
 // readPump pumps messages from the WebSocket connection to the hub
 func (client *Client) readPump(logger *log.Logger) {
-	// Store the logger in the client for consistency
-	client.logger = logger
+	// The logger is set when the client is created, writePump reads it concurrently
+	if client.logger == nil {
+		client.logger = logger
+	}
 
 	defer func() {
 		client.mu.Lock()
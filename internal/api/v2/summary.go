@@ -0,0 +1,427 @@
+// internal/api/v2/summary.go
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/patrickmn/go-cache"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+const (
+	// dailySummaryTopClips is the number of top clips in a daily summary
+	dailySummaryTopClips = 5
+
+	// dailySummaryTodayTTL is how long the summary of the current day is cached,
+	// summaries of past days are cached with the detection queries
+	dailySummaryTodayTTL = time.Minute
+)
+
+// DailySummaryReader is implemented by datastores that aggregate the detections of a day
+type DailySummaryReader interface {
+	GetDailySummary(ctx context.Context, date string, minConfidence float64, topClips int) (*datastore.DailySummary, error)
+}
+
+// DailySummarySpecies is a species detected on the summarized day
+type DailySummarySpecies struct {
+	ScientificName string  `json:"scientificName"`
+	CommonName     string  `json:"commonName"`
+	SpeciesCode    string  `json:"speciesCode"`
+	Count          int     `json:"count"`
+	MaxConfidence  float64 `json:"maxConfidence"`
+	FirstHeard     string  `json:"firstHeard"`
+	LatestHeard    string  `json:"latestHeard"`
+	Hourly         [24]int `json:"hourly"`
+	IsNew          bool    `json:"isNew"`
+}
+
+// DailySummaryClip is one of the best clips of the summarized day
+type DailySummaryClip struct {
+	ID             uint    `json:"id"`
+	Time           string  `json:"time"`
+	ScientificName string  `json:"scientificName"`
+	CommonName     string  `json:"commonName"`
+	Confidence     float64 `json:"confidence"`
+	ClipURL        string  `json:"clipUrl"`
+}
+
+// DailyWeatherOverview summarizes the weather of the summarized day
+type DailyWeatherOverview struct {
+	Sunrise      time.Time `json:"sunrise"`
+	Sunset       time.Time `json:"sunset"`
+	CityName     string    `json:"cityName,omitempty"`
+	TempMin      float64   `json:"tempMin"`
+	TempMax      float64   `json:"tempMax"`
+	MaxWindSpeed float64   `json:"maxWindSpeed"`
+	Condition    string    `json:"condition,omitempty"` // most frequent hourly weather condition
+	Icon         string    `json:"icon,omitempty"`
+}
+
+// DailySummaryResponse is the dashboard summary of one day
+type DailySummaryResponse struct {
+	Date            string                `json:"date"`
+	TotalDetections int                   `json:"totalDetections"`
+	Species         []DailySummarySpecies `json:"species"`
+	Hourly          [24]int               `json:"hourly"`
+	NewSpecies      []string              `json:"newSpecies"` // scientific names of species first detected on this day
+	TopClips        []DailySummaryClip    `json:"topClips"`
+	Weather         *DailyWeatherOverview `json:"weather,omitempty"`
+}
+
+// SpokenHighlight is the species a spoken summary mentions as the highlight
+type SpokenHighlight struct {
+	ScientificName string `json:"scientificName"`
+	CommonName     string `json:"commonName"`
+	Time           string `json:"time"`  // "15:04:05"
+	IsNew          bool   `json:"isNew"` // first ever detection of the species
+}
+
+// SpokenSummaryResponse is a one-sentence summary for text-to-speech
+type SpokenSummaryResponse struct {
+	Text      string           `json:"text"`
+	Date      string           `json:"date"`
+	Since     string           `json:"since"`     // "sunrise" or "midnight"
+	SinceTime string           `json:"sinceTime"` // "15:04:05", the start of the summarized period
+	Species   int              `json:"species"`
+	Highlight *SpokenHighlight `json:"highlight,omitempty"`
+}
+
+// initSummaryRoutes registers the dashboard summary endpoints
+func (c *Controller) initSummaryRoutes() {
+	c.Group.GET("/summary/daily", c.GetDailySummary)
+	c.Group.GET("/summary/spoken", c.GetSpokenSummary)
+}
+
+// GetDailySummary handles GET /api/v2/summary/daily
+// It returns the species with counts and hourly distribution, new species,
+// weather overview and top clips of a date (default today) in one response.
+// Optional min_confidence is a percentage as in the analytics endpoints.
+func (c *Controller) GetDailySummary(ctx echo.Context) error {
+	today := time.Now().Format(time.DateOnly)
+	date := ctx.QueryParam("date")
+	if date == "" {
+		date = today
+	} else if _, err := time.Parse(time.DateOnly, date); err != nil {
+		return c.HandleError(ctx, errors.New(err).
+			Component("api").
+			Category(errors.CategoryValidation).
+			Context("date", date).
+			Build(), "Invalid date format. Use YYYY-MM-DD", http.StatusBadRequest)
+	}
+
+	minConfidence := 0.0
+	if param := ctx.QueryParam("min_confidence"); param != "" {
+		parsed, err := strconv.ParseFloat(param, 64)
+		if err != nil || parsed < 0 || parsed > 100 {
+			return c.HandleError(ctx, errors.Newf("invalid min_confidence parameter: %s", param).
+				Component("api").
+				Category(errors.CategoryValidation).
+				Build(), "min_confidence must be a percentage between 0 and 100", http.StatusBadRequest)
+		}
+		minConfidence = parsed / 100.0
+	}
+
+	cacheKey := fmt.Sprintf("daily-summary:%s:%g", date, minConfidence)
+	if c.detectionCache != nil {
+		if cached, found := c.detectionCache.Get(cacheKey); found {
+			return ctx.JSON(http.StatusOK, cached)
+		}
+	}
+
+	reader, ok := c.DS.(DailySummaryReader)
+	if !ok {
+		return c.HandleError(ctx, errors.Newf("datastore does not support daily summaries").
+			Component("api").
+			Category(errors.CategoryConfiguration).
+			Build(), "Daily summaries are not supported by this datastore", http.StatusNotImplemented)
+	}
+
+	summary, err := reader.GetDailySummary(ctx.Request().Context(), date, minConfidence, dailySummaryTopClips)
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to load daily summary", http.StatusInternalServerError)
+	}
+
+	resp := buildDailySummaryResponse(summary)
+	resp.Weather = c.dailyWeatherOverview(date)
+
+	if c.detectionCache != nil {
+		ttl := cache.DefaultExpiration
+		if date >= today {
+			ttl = dailySummaryTodayTTL
+		}
+		c.detectionCache.Set(cacheKey, resp, ttl)
+	}
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+// buildDailySummaryResponse converts a datastore daily summary to the API response
+func buildDailySummaryResponse(summary *datastore.DailySummary) DailySummaryResponse {
+	resp := DailySummaryResponse{
+		Date:            summary.Date,
+		TotalDetections: summary.TotalDetections,
+		Species:         make([]DailySummarySpecies, 0, len(summary.Species)),
+		Hourly:          summary.Hourly,
+		NewSpecies:      []string{},
+		TopClips:        make([]DailySummaryClip, 0, len(summary.TopClips)),
+	}
+	for i := range summary.Species {
+		s := &summary.Species[i]
+		resp.Species = append(resp.Species, DailySummarySpecies{
+			ScientificName: s.ScientificName,
+			CommonName:     s.CommonName,
+			SpeciesCode:    s.SpeciesCode,
+			Count:          s.Count,
+			MaxConfidence:  s.MaxConfidence,
+			FirstHeard:     s.FirstHeard,
+			LatestHeard:    s.LatestHeard,
+			Hourly:         s.Hourly,
+			IsNew:          s.IsNew,
+		})
+		if s.IsNew {
+			resp.NewSpecies = append(resp.NewSpecies, s.ScientificName)
+		}
+	}
+	for i := range summary.TopClips {
+		note := &summary.TopClips[i]
+		resp.TopClips = append(resp.TopClips, DailySummaryClip{
+			ID:             note.ID,
+			Time:           note.Time,
+			ScientificName: note.ScientificName,
+			CommonName:     note.CommonName,
+			Confidence:     note.Confidence,
+			ClipURL:        fmt.Sprintf("/api/v2/audio/%d", note.ID),
+		})
+	}
+	return resp
+}
+
+// dailyWeatherOverview summarizes the stored weather of a date, nil when no
+// weather was recorded
+func (c *Controller) dailyWeatherOverview(date string) *DailyWeatherOverview {
+	events, err := c.DS.GetDailyEvents(date)
+	if err != nil || events.Date == "" {
+		return nil
+	}
+	overview := &DailyWeatherOverview{
+		Sunrise:  time.Unix(events.Sunrise, 0).UTC(),
+		Sunset:   time.Unix(events.Sunset, 0).UTC(),
+		CityName: events.CityName,
+	}
+
+	hourly, err := c.DS.GetHourlyWeather(date)
+	if err != nil {
+		if c.apiLogger != nil {
+			c.apiLogger.Debug("Hourly weather unavailable for daily summary", "date", date, "error", err.Error())
+		}
+		return overview
+	}
+
+	conditions := make(map[string]int)
+	for i := range hourly {
+		hw := &hourly[i]
+		if i == 0 || hw.Temperature < overview.TempMin {
+			overview.TempMin = hw.Temperature
+		}
+		if i == 0 || hw.Temperature > overview.TempMax {
+			overview.TempMax = hw.Temperature
+		}
+		overview.MaxWindSpeed = max(overview.MaxWindSpeed, hw.WindSpeed)
+		if hw.WeatherMain == "" {
+			continue
+		}
+		conditions[hw.WeatherMain]++
+		if conditions[hw.WeatherMain] > conditions[overview.Condition] {
+			overview.Condition = hw.WeatherMain
+			overview.Icon = hw.WeatherIcon
+		}
+	}
+	return overview
+}
+
+// GetSpokenSummary handles GET /api/v2/summary/spoken
+// It returns a short natural-language summary of a date (default today) for
+// smart speakers and text-to-speech integrations, such as "Since sunrise
+// you've had 23 species; the highlight was a Eurasian Wryneck at 6:40."
+// The period starts at sunrise unless since=midnight is given or the sun has
+// not risen yet. With format=text the sentence is returned as plain text.
+func (c *Controller) GetSpokenSummary(ctx echo.Context) error {
+	now := time.Now()
+	today := now.Format(time.DateOnly)
+	date := ctx.QueryParam("date")
+	if date == "" {
+		date = today
+	}
+	day, err := time.ParseInLocation(time.DateOnly, date, time.Local)
+	if err != nil {
+		return c.HandleError(ctx, errors.New(err).
+			Component("api").
+			Category(errors.CategoryValidation).
+			Context("date", date).
+			Build(), "Invalid date format. Use YYYY-MM-DD", http.StatusBadRequest)
+	}
+
+	since := ctx.QueryParam("since")
+	if since == "" {
+		since = "sunrise"
+	}
+	if since != "sunrise" && since != "midnight" {
+		return c.HandleError(ctx, errors.Newf("invalid since parameter: %s", since).
+			Component("api").
+			Category(errors.CategoryValidation).
+			Build(), "since must be sunrise or midnight", http.StatusBadRequest)
+	}
+	format := ctx.QueryParam("format")
+	if format != "" && format != "json" && format != "text" {
+		return c.HandleError(ctx, errors.Newf("invalid format parameter: %s", format).
+			Component("api").
+			Category(errors.CategoryValidation).
+			Build(), "format must be json or text", http.StatusBadRequest)
+	}
+
+	reader, ok := c.DS.(DailySummaryReader)
+	if !ok {
+		return c.HandleError(ctx, errors.Newf("datastore does not support daily summaries").
+			Component("api").
+			Category(errors.CategoryConfiguration).
+			Build(), "Daily summaries are not supported by this datastore", http.StatusNotImplemented)
+	}
+	summary, err := reader.GetDailySummary(ctx.Request().Context(), date, 0, 0)
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to load daily summary", http.StatusInternalServerError)
+	}
+
+	sinceTime := "00:00:00"
+	if since == "sunrise" {
+		sunrise, ok := c.sunriseOf(day)
+		if ok && (date != today || now.After(sunrise)) {
+			sinceTime = sunrise.Format(time.TimeOnly)
+		} else {
+			since = "midnight"
+		}
+	}
+
+	resp := buildSpokenSummary(summary, since, sinceTime, date == today)
+	if format == "text" {
+		return ctx.String(http.StatusOK, resp.Text)
+	}
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+// sunriseOf returns the sunrise of a day at the station location
+func (c *Controller) sunriseOf(day time.Time) (time.Time, bool) {
+	if c.SunCalc == nil {
+		return time.Time{}, false
+	}
+	sunTimes, err := c.SunCalc.GetSunEventTimes(day)
+	if err != nil || sunTimes.Sunrise.IsZero() {
+		return time.Time{}, false
+	}
+	return sunTimes.Sunrise, true
+}
+
+// buildSpokenSummary counts the species heard from sinceTime on and picks the
+// highlight: the first species ever detected, otherwise the species heard
+// least often
+func buildSpokenSummary(summary *datastore.DailySummary, since, sinceTime string, isToday bool) SpokenSummaryResponse {
+	resp := SpokenSummaryResponse{Date: summary.Date, Since: since, SinceTime: sinceTime}
+
+	var highlight *datastore.DailySpeciesCount
+	for i := range summary.Species {
+		s := &summary.Species[i]
+		if s.LatestHeard < sinceTime {
+			continue
+		}
+		resp.Species++
+		switch {
+		case highlight == nil:
+			highlight = s
+		case s.IsNew != highlight.IsNew:
+			if s.IsNew {
+				highlight = s
+			}
+		case s.Count < highlight.Count || (s.Count == highlight.Count && s.MaxConfidence > highlight.MaxConfidence):
+			highlight = s
+		}
+	}
+	if highlight != nil {
+		heard := highlight.FirstHeard
+		if heard < sinceTime {
+			heard = highlight.LatestHeard
+		}
+		resp.Highlight = &SpokenHighlight{
+			ScientificName: highlight.ScientificName,
+			CommonName:     highlight.CommonName,
+			Time:           heard,
+			IsNew:          highlight.IsNew,
+		}
+	}
+
+	resp.Text = spokenSummaryText(&resp, isToday)
+	return resp
+}
+
+// spokenSummaryText phrases a spoken summary as one sentence
+func spokenSummaryText(resp *SpokenSummaryResponse, isToday bool) string {
+	var period string
+	switch {
+	case !isToday:
+		day, _ := time.Parse(time.DateOnly, resp.Date)
+		period = "On " + day.Format("January 2")
+	case resp.Since == "sunrise":
+		period = "Since sunrise"
+	default:
+		period = "Today"
+	}
+
+	if resp.Species == 0 {
+		if isToday {
+			return fmt.Sprintf("%s no birds have been heard yet.", period)
+		}
+		return fmt.Sprintf("%s no birds were heard.", period)
+	}
+
+	verb := "you've had"
+	if !isToday {
+		verb = "you had"
+	}
+	text := fmt.Sprintf("%s %s %d species", period, verb, resp.Species)
+	if h := resp.Highlight; h != nil {
+		name := indefiniteArticle(h.CommonName) + " " + h.CommonName
+		if h.IsNew {
+			name = "a first-ever " + h.CommonName
+		}
+		text += fmt.Sprintf("; the highlight was %s at %s", name, spokenClockTime(h.Time))
+	}
+	return text + "."
+}
+
+// spokenClockTime formats "15:04:05" as "15:04" without a leading zero
+func spokenClockTime(value string) string {
+	t, err := time.Parse(time.TimeOnly, value)
+	if err != nil {
+		return value
+	}
+	return strconv.Itoa(t.Hour()) + t.Format(":04")
+}
+
+// indefiniteArticle returns "a" or "an" for a species name, treating names
+// starting with a "you" sound such as Eurasian as consonants
+func indefiniteArticle(name string) string {
+	lower := strings.ToLower(name)
+	for _, prefix := range []string{"eu", "uni", "usu", "one"} {
+		if strings.HasPrefix(lower, prefix) {
+			return "a"
+		}
+	}
+	if lower != "" && strings.ContainsRune("aeiou", rune(lower[0])) {
+		return "an"
+	}
+	return "a"
+}
@@ -0,0 +1,171 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+// dailySummaryMockDataStore adds daily summary aggregation to MockDataStore
+type dailySummaryMockDataStore struct {
+	*MockDataStore
+	summary       *datastore.DailySummary
+	minConfidence float64
+	calls         int
+}
+
+func (m *dailySummaryMockDataStore) GetDailySummary(_ context.Context, date string, minConfidence float64, _ int) (*datastore.DailySummary, error) {
+	m.calls++
+	m.minConfidence = minConfidence
+	summary := *m.summary
+	summary.Date = date
+	return &summary, nil
+}
+
+func TestGetDailySummary(t *testing.T) {
+	t.Parallel()
+
+	e, mockDS, controller := setupTestEnvironment(t)
+	store := &dailySummaryMockDataStore{MockDataStore: mockDS, summary: &datastore.DailySummary{
+		TotalDetections: 3,
+		Species: []datastore.DailySpeciesCount{
+			{ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", Count: 2, FirstHeard: "05:10:00", LatestHeard: "06:20:00"},
+			{ScientificName: "Pica pica", CommonName: "Eurasian Magpie", Count: 1, FirstHeard: "06:00:00", LatestHeard: "06:00:00", IsNew: true},
+		},
+		TopClips: []datastore.Note{{ID: 7, Time: "06:20:00", ScientificName: "Turdus merula", Confidence: 0.95, ClipName: "clip.wav"}},
+	}}
+	controller.DS = store
+
+	mockDS.On("GetDailyEvents", "2024-05-01").Return(datastore.DailyEvents{Date: "2024-05-01", Sunrise: 1714532400, CityName: "Helsinki"}, nil)
+	mockDS.On("GetHourlyWeather", "2024-05-01").Return([]datastore.HourlyWeather{
+		{Temperature: 4, WindSpeed: 2, WeatherMain: "Clouds"},
+		{Temperature: 12, WindSpeed: 5, WeatherMain: "Clear"},
+		{Temperature: 9, WindSpeed: 3, WeatherMain: "Clouds"},
+	}, nil)
+
+	get := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v2/summary/daily"+query, http.NoBody)
+		rec := httptest.NewRecorder()
+		require.NoError(t, controller.GetDailySummary(e.NewContext(req, rec)))
+		return rec
+	}
+
+	rec := get("?date=2024-05-01&min_confidence=70")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp DailySummaryResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.InDelta(t, 0.7, store.minConfidence, 1e-9)
+	assert.Equal(t, "2024-05-01", resp.Date)
+	assert.Equal(t, 3, resp.TotalDetections)
+	require.Len(t, resp.Species, 2)
+	assert.Equal(t, []string{"Pica pica"}, resp.NewSpecies)
+	require.Len(t, resp.TopClips, 1)
+	assert.Equal(t, "/api/v2/audio/7", resp.TopClips[0].ClipURL)
+
+	require.NotNil(t, resp.Weather)
+	assert.Equal(t, "Helsinki", resp.Weather.CityName)
+	assert.InDelta(t, 4.0, resp.Weather.TempMin, 1e-9)
+	assert.InDelta(t, 12.0, resp.Weather.TempMax, 1e-9)
+	assert.InDelta(t, 5.0, resp.Weather.MaxWindSpeed, 1e-9)
+	assert.Equal(t, "Clouds", resp.Weather.Condition)
+
+	// Repeated requests are served from the cache
+	require.Equal(t, http.StatusOK, get("?date=2024-05-01&min_confidence=70").Code)
+	assert.Equal(t, 1, store.calls)
+
+	assert.Equal(t, http.StatusBadRequest, get("?date=05/01/2024").Code)
+	assert.Equal(t, http.StatusBadRequest, get("?date=2024-05-01&min_confidence=150").Code)
+}
+
+func TestGetDailySummary_Unsupported(t *testing.T) {
+	t.Parallel()
+
+	e, _, controller := setupTestEnvironment(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v2/summary/daily?date=2024-05-01", http.NoBody)
+	rec := httptest.NewRecorder()
+	require.NoError(t, controller.GetDailySummary(e.NewContext(req, rec)))
+	assert.Equal(t, http.StatusNotImplemented, rec.Code)
+}
+
+func TestBuildSpokenSummary(t *testing.T) {
+	t.Parallel()
+
+	summary := &datastore.DailySummary{
+		Date: "2024-05-01",
+		Species: []datastore.DailySpeciesCount{
+			{ScientificName: "Strix aluco", CommonName: "Tawny Owl", Count: 1, FirstHeard: "02:10:00", LatestHeard: "02:10:00"},
+			{ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", Count: 40, FirstHeard: "03:50:00", LatestHeard: "09:20:00"},
+			{ScientificName: "Jynx torquilla", CommonName: "Eurasian Wryneck", Count: 2, FirstHeard: "06:40:00", LatestHeard: "07:15:00", MaxConfidence: 0.8},
+			{ScientificName: "Upupa epops", CommonName: "Eurasian Hoopoe", Count: 2, FirstHeard: "08:00:00", LatestHeard: "08:00:00", MaxConfidence: 0.7},
+		},
+	}
+
+	resp := buildSpokenSummary(summary, "sunrise", "04:30:00", true)
+	assert.Equal(t, 3, resp.Species, "species last heard before sunrise are left out")
+	require.NotNil(t, resp.Highlight)
+	assert.Equal(t, "Jynx torquilla", resp.Highlight.ScientificName, "the least heard species, the more confident one on ties")
+	assert.Equal(t, "Since sunrise you've had 3 species; the highlight was a Eurasian Wryneck at 6:40.", resp.Text)
+
+	summary.Species[3].IsNew = true
+	resp = buildSpokenSummary(summary, "midnight", "00:00:00", false)
+	assert.Equal(t, 4, resp.Species)
+	assert.Equal(t, "On May 1 you had 4 species; the highlight was a first-ever Eurasian Hoopoe at 8:00.", resp.Text)
+
+	resp = buildSpokenSummary(summary, "sunrise", "10:00:00", true)
+	assert.Nil(t, resp.Highlight)
+	assert.Equal(t, "Since sunrise no birds have been heard yet.", resp.Text)
+}
+
+func TestIndefiniteArticle(t *testing.T) {
+	t.Parallel()
+
+	for name, article := range map[string]string{
+		"Eurasian Wryneck": "a",
+		"American Robin":   "an",
+		"Osprey":           "an",
+		"Great Tit":        "a",
+		"":                 "a",
+	} {
+		assert.Equal(t, article, indefiniteArticle(name), name)
+	}
+}
+
+func TestGetSpokenSummary(t *testing.T) {
+	t.Parallel()
+
+	e, mockDS, controller := setupTestEnvironment(t)
+	controller.SunCalc = nil
+	controller.DS = &dailySummaryMockDataStore{MockDataStore: mockDS, summary: &datastore.DailySummary{
+		Species: []datastore.DailySpeciesCount{
+			{ScientificName: "Pica pica", CommonName: "Eurasian Magpie", Count: 1, FirstHeard: "06:00:00", LatestHeard: "06:00:00"},
+		},
+	}}
+
+	get := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v2/summary/spoken"+query, http.NoBody)
+		rec := httptest.NewRecorder()
+		require.NoError(t, controller.GetSpokenSummary(e.NewContext(req, rec)))
+		return rec
+	}
+
+	rec := get("?date=2024-05-01&format=text")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "On May 1 you had 1 species; the highlight was a Eurasian Magpie at 6:00.", rec.Body.String())
+
+	rec = get("?date=2024-05-01")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp SpokenSummaryResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "midnight", resp.Since, "without the station location the summary starts at midnight")
+	assert.Equal(t, 1, resp.Species)
+
+	assert.Equal(t, http.StatusBadRequest, get("?since=noon").Code)
+	assert.Equal(t, http.StatusBadRequest, get("?format=xml").Code)
+}
@@ -0,0 +1,234 @@
+// internal/api/v2/sync.go
+package api
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/atrest"
+	"github.com/tphakala/birdnet-go/internal/errors"
+	"github.com/tphakala/birdnet-go/internal/fieldsync"
+)
+
+// syncUnitContextKey stores the authenticated field unit ID in the request context
+const syncUnitContextKey = "syncUnit"
+
+// maxSyncChunkSize bounds a single clip chunk, matching the API request body limit
+const maxSyncChunkSize = 1024 * 1024
+
+// initSyncRoutes registers the aggregator endpoints used by field units.
+// Field units authenticate with per-unit tokens from fieldsync.aggregator.units
+// instead of the regular API authentication.
+func (c *Controller) initSyncRoutes() {
+	syncGroup := c.Group.Group("/sync", c.FieldSyncAuthMiddleware)
+
+	syncGroup.GET("/state", c.GetSyncState)
+	syncGroup.POST("/detections", c.PostSyncDetections, c.IdempotencyMiddleware)
+	syncGroup.HEAD("/clips/:source_id", c.HeadSyncClip)
+	syncGroup.PUT("/clips/:source_id", c.PutSyncClip)
+}
+
+// FieldSyncAuthMiddleware authenticates field units by their bearer token
+func (c *Controller) FieldSyncAuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		aggregator := c.Settings.FieldSync.Aggregator
+		if !aggregator.Enabled {
+			return ctx.JSON(http.StatusNotFound, map[string]string{"error": "Field sync aggregator is not enabled"})
+		}
+
+		token, ok := strings.CutPrefix(ctx.Request().Header.Get("Authorization"), "Bearer ")
+		if ok && token != "" {
+			for _, unit := range aggregator.Units {
+				if subtle.ConstantTimeCompare([]byte(token), []byte(unit.Token)) == 1 {
+					ctx.Set(syncUnitContextKey, unit.ID)
+					return next(ctx)
+				}
+			}
+		}
+
+		c.logAPIRequest(ctx, slog.LevelWarn, "Rejected field sync request with invalid token")
+		ctx.Response().Header().Set("WWW-Authenticate", `Bearer realm="sync"`)
+		return ctx.JSON(http.StatusUnauthorized, map[string]string{"error": "Invalid field unit token"})
+	}
+}
+
+// syncAggregator returns an aggregator over the controller's datastore
+func (c *Controller) syncAggregator() (*fieldsync.Aggregator, error) {
+	store, ok := c.DS.(fieldsync.AggregatorStore)
+	if !ok {
+		return nil, errors.Newf("datastore does not support field sync").
+			Component("api").
+			Category(errors.CategoryConfiguration).
+			Build()
+	}
+	aggregator := fieldsync.NewAggregator(store, c.Settings.Realtime.Audio.Export.Path).
+		ResolveAliases(c.speciesAliases())
+	if c.Settings.Encryption.Clips {
+		cipher := atrest.Active()
+		if cipher == nil {
+			return nil, atrest.ErrNoKey
+		}
+		aggregator.EncryptClips(cipher)
+	}
+	return aggregator, nil
+}
+
+// syncUnitID returns the authenticated field unit ID
+func syncUnitID(ctx echo.Context) string {
+	unitID, _ := ctx.Get(syncUnitContextKey).(string)
+	return unitID
+}
+
+// GetSyncState handles GET /api/v2/sync/state
+func (c *Controller) GetSyncState(ctx echo.Context) error {
+	aggregator, err := c.syncAggregator()
+	if err != nil {
+		return c.HandleError(ctx, err, "Field sync is not available", http.StatusServiceUnavailable)
+	}
+
+	state, err := aggregator.State(syncUnitID(ctx))
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to get sync state", http.StatusInternalServerError)
+	}
+	return ctx.JSON(http.StatusOK, state)
+}
+
+// PostSyncDetections handles POST /api/v2/sync/detections
+func (c *Controller) PostSyncDetections(ctx echo.Context) error {
+	aggregator, err := c.syncAggregator()
+	if err != nil {
+		return c.HandleError(ctx, err, "Field sync is not available", http.StatusServiceUnavailable)
+	}
+
+	var batch fieldsync.Batch
+	if err := ctx.Bind(&batch); err != nil {
+		return c.HandleError(ctx, err, "Invalid sync batch", http.StatusBadRequest)
+	}
+
+	unitID := syncUnitID(ctx)
+	resp, err := aggregator.ApplyBatch(unitID, &batch)
+	if err != nil {
+		var enhancedErr *errors.EnhancedError
+		if errors.As(err, &enhancedErr) && (enhancedErr.Category == errors.CategoryValidation || enhancedErr.Category == errors.CategoryLimit) {
+			return c.HandleError(ctx, err, "Invalid sync batch", http.StatusBadRequest)
+		}
+		return c.HandleError(ctx, err, "Failed to store sync batch", http.StatusInternalServerError)
+	}
+
+	c.logAPIRequest(ctx, slog.LevelInfo, "Received field sync batch",
+		"unit_id", unitID,
+		"accepted", resp.Accepted,
+		"duplicates", resp.Duplicates,
+		"token", resp.Token)
+
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+// HeadSyncClip handles HEAD /api/v2/sync/clips/:source_id
+// It reports how many bytes of the clip are stored so an interrupted upload can resume.
+func (c *Controller) HeadSyncClip(ctx echo.Context) error {
+	aggregator, sourceID, ok := c.syncClipRequest(ctx)
+	if !ok {
+		return nil
+	}
+
+	status, err := aggregator.ClipStatus(syncUnitID(ctx), sourceID)
+	if err != nil {
+		return c.handleSyncClipError(ctx, err)
+	}
+	setUploadHeaders(ctx, status, -1)
+	return ctx.NoContent(http.StatusOK)
+}
+
+// PutSyncClip handles PUT /api/v2/sync/clips/:source_id
+// The body is a chunk described by a "Content-Range: bytes start-end/total" header.
+func (c *Controller) PutSyncClip(ctx echo.Context) error {
+	aggregator, sourceID, ok := c.syncClipRequest(ctx)
+	if !ok {
+		return nil
+	}
+
+	start, end, total, err := parseContentRange(ctx.Request().Header.Get("Content-Range"))
+	if err != nil {
+		return c.HandleError(ctx, err, "Invalid Content-Range header", http.StatusBadRequest)
+	}
+	if end-start+1 > maxSyncChunkSize {
+		return c.HandleError(ctx, fmt.Errorf("chunk of %d bytes exceeds limit of %d", end-start+1, maxSyncChunkSize),
+			"Clip chunk too large", http.StatusRequestEntityTooLarge)
+	}
+
+	unitID := syncUnitID(ctx)
+	body := io.LimitReader(ctx.Request().Body, end-start+1)
+	status, err := aggregator.WriteClipChunk(unitID, sourceID, start, total, body)
+	if errors.Is(err, fieldsync.ErrOffsetMismatch) && status != nil {
+		setUploadHeaders(ctx, status, total)
+		return c.HandleError(ctx, err, "Clip chunk does not continue the stored upload", http.StatusConflict)
+	}
+	if err != nil {
+		return c.handleSyncClipError(ctx, err)
+	}
+
+	if status.Complete {
+		c.logAPIRequest(ctx, slog.LevelInfo, "Received field sync clip",
+			"unit_id", unitID,
+			"source_id", sourceID,
+			"size", status.Offset)
+	}
+
+	setUploadHeaders(ctx, status, total)
+	return ctx.NoContent(http.StatusOK)
+}
+
+// syncClipRequest resolves the aggregator and source note ID of a clip request.
+// When ok is false the error response has already been written.
+func (c *Controller) syncClipRequest(ctx echo.Context) (aggregator *fieldsync.Aggregator, sourceID uint, ok bool) {
+	aggregator, err := c.syncAggregator()
+	if err != nil {
+		_ = c.HandleError(ctx, err, "Field sync is not available", http.StatusServiceUnavailable)
+		return nil, 0, false
+	}
+	id, err := strconv.ParseUint(ctx.Param("source_id"), 10, 0)
+	if err != nil || id == 0 {
+		_ = c.HandleError(ctx, fmt.Errorf("invalid source ID %q", ctx.Param("source_id")), "Invalid source ID", http.StatusBadRequest)
+		return nil, 0, false
+	}
+	return aggregator, uint(id), true
+}
+
+// handleSyncClipError maps clip upload errors to HTTP responses
+func (c *Controller) handleSyncClipError(ctx echo.Context, err error) error {
+	var enhancedErr *errors.EnhancedError
+	if errors.Is(err, fieldsync.ErrNoClip) || errors.As(err, &enhancedErr) && enhancedErr.Category == errors.CategoryNotFound {
+		return c.HandleError(ctx, err, "Clip not found for this unit", http.StatusNotFound)
+	}
+	return c.HandleError(ctx, err, "Failed to store clip", http.StatusInternalServerError)
+}
+
+// setUploadHeaders reports the stored clip offset, and the total size when known
+func setUploadHeaders(ctx echo.Context, status *fieldsync.ClipStatus, total int64) {
+	header := ctx.Response().Header()
+	header.Set(fieldsync.HeaderUploadOffset, strconv.FormatInt(status.Offset, 10))
+	if status.Complete {
+		total = status.Offset
+	}
+	if total >= 0 {
+		header.Set(fieldsync.HeaderUploadLength, strconv.FormatInt(total, 10))
+	}
+}
+
+// parseContentRange parses a "bytes start-end/total" Content-Range header
+func parseContentRange(value string) (start, end, total int64, err error) {
+	if _, err := fmt.Sscanf(value, "bytes %d-%d/%d", &start, &end, &total); err != nil {
+		return 0, 0, 0, fmt.Errorf("malformed Content-Range %q", value)
+	}
+	if start < 0 || end < start || end >= total {
+		return 0, 0, 0, fmt.Errorf("invalid Content-Range %q", value)
+	}
+	return start, end, total, nil
+}
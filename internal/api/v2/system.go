@@ -43,6 +43,8 @@ type SystemInfo struct {
 	TimeZone      string    `json:"time_zone,omitempty"`
 	OSDisplay     string    `json:"os_display"`
 	Architecture  string    `json:"architecture"`
+
+	AudioEncoding *myaudio.EncodingCapabilities `json:"audio_encoding,omitempty"`
 }
 
 // ResourceInfo represents system resource usage data
@@ -305,6 +307,18 @@ func (c *Controller) initSystemRoutes() {
 	protectedGroup.GET("/jobs", c.GetJobQueueStats)
 	protectedGroup.GET("/processes", c.GetProcessInfo)
 	protectedGroup.GET("/temperature/cpu", c.GetSystemCPUTemperature)
+	protectedGroup.GET("/readonly", c.GetReadOnlyMode)
+	protectedGroup.PUT("/readonly", c.SetReadOnlyMode)
+	protectedGroup.GET("/offline", c.GetOfflineMode)
+	protectedGroup.PUT("/offline", c.SetOfflineMode)
+	protectedGroup.GET("/databudget", c.GetDataBudget)
+	protectedGroup.GET("/schema", c.GetDatabaseSchema)
+	protectedGroup.POST("/purge", c.PurgeData)
+	protectedGroup.POST("/taxonomy/remap", c.RemapTaxonomy)
+	protectedGroup.GET("/taxonomy/remaps", c.GetTaxonomyRemaps)
+	protectedGroup.GET("/shadow", c.GetShadowModelStatus)
+	protectedGroup.GET("/shadow/report", c.GetShadowModelReport)
+	protectedGroup.POST("/taxonomy/remaps/:id/revert", c.RevertTaxonomyRemap)
 
 	// Audio device routes (all protected)
 	audioGroup := protectedGroup.Group("/audio")
@@ -426,6 +440,10 @@ func (c *Controller) GetSystemInfo(ctx echo.Context) error {
 		TimeZone:      timeZoneStr,
 		OSDisplay:     osDisplay,
 	}
+	if c.Settings != nil {
+		encoding := myaudio.GetEncodingCapabilities(&c.Settings.Realtime.Audio)
+		info.AudioEncoding = &encoding
+	}
 
 	if c.apiLogger != nil {
 		c.apiLogger.Info("System information retrieved successfully",
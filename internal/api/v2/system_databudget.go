@@ -0,0 +1,32 @@
+// internal/api/v2/system_databudget.go
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/analysis/processor"
+	"github.com/tphakala/birdnet-go/internal/databudget"
+)
+
+// DataBudgetResponse represents the metered connection data budget in API responses
+type DataBudgetResponse struct {
+	Enabled         bool                         `json:"enabled"`
+	Budget          *databudget.Status           `json:"budget,omitempty"`
+	DeferredUploads *processor.OfflineQueueStats `json:"deferred_uploads,omitempty"` // Uploads held until the budget allows them
+}
+
+// GetDataBudget handles GET /api/v2/system/databudget
+func (c *Controller) GetDataBudget(ctx echo.Context) error {
+	budget := databudget.Active()
+	if budget == nil {
+		return ctx.JSON(http.StatusOK, DataBudgetResponse{Enabled: false})
+	}
+
+	status := budget.Status()
+	return ctx.JSON(http.StatusOK, DataBudgetResponse{
+		Enabled:         true,
+		Budget:          &status,
+		DeferredUploads: c.offlineQueueStats(),
+	})
+}
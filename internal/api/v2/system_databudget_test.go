@@ -0,0 +1,46 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/databudget"
+)
+
+// TestGetDataBudget covers the data budget endpoint with and without a configured budget.
+// Not parallel: the active budget is process-wide state.
+func TestGetDataBudget(t *testing.T) {
+	e, _, controller := setupTestEnvironment(t)
+	t.Cleanup(func() { databudget.SetActive(nil) })
+
+	get := func() DataBudgetResponse {
+		req := httptest.NewRequest(http.MethodGet, "/api/v2/system/databudget", http.NoBody)
+		rec := httptest.NewRecorder()
+		require.NoError(t, controller.GetDataBudget(e.NewContext(req, rec)))
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var resp DataBudgetResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		return resp
+	}
+
+	resp := get()
+	assert.False(t, resp.Enabled)
+	assert.Nil(t, resp.Budget)
+
+	budget, err := databudget.NewManager(databudget.Config{CapBytes: 1000, ThrottlePercent: 50}, "")
+	require.NoError(t, err)
+	databudget.SetActive(budget)
+	budget.Record(databudget.Images, 600)
+
+	resp = get()
+	assert.True(t, resp.Enabled)
+	require.NotNil(t, resp.Budget)
+	assert.Equal(t, int64(600), resp.Budget.UsedBytes)
+	assert.True(t, resp.Budget.Throttled)
+	assert.Equal(t, int64(600), resp.Budget.Integrations[databudget.Images].Bytes)
+}
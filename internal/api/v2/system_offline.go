@@ -0,0 +1,73 @@
+// internal/api/v2/system_offline.go
+package api
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/analysis/processor"
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+// OfflineModeResponse represents the offline mode state in API responses
+type OfflineModeResponse struct {
+	Enabled bool                         `json:"enabled"`
+	Queue   *processor.OfflineQueueStats `json:"queue,omitempty"` // Outbound tasks held until offline mode is disabled
+	Flushed int                          `json:"flushed,omitempty"`
+}
+
+// OfflineModeRequest represents a request to toggle offline mode
+type OfflineModeRequest struct {
+	Enabled *bool `json:"enabled"`
+}
+
+// offlineQueueStats returns the processor's offline queue counters, if available
+func (c *Controller) offlineQueueStats() *processor.OfflineQueueStats {
+	if c.Processor == nil {
+		return nil
+	}
+	stats := c.Processor.OfflineQueueStats()
+	return &stats
+}
+
+// GetOfflineMode handles GET /api/v2/system/offline
+func (c *Controller) GetOfflineMode(ctx echo.Context) error {
+	return ctx.JSON(http.StatusOK, OfflineModeResponse{
+		Enabled: conf.IsOffline(),
+		Queue:   c.offlineQueueStats(),
+	})
+}
+
+// SetOfflineMode handles PUT /api/v2/system/offline
+// The new state applies immediately and lasts until the next restart. Disabling
+// offline mode submits outbound tasks that were queued while offline.
+func (c *Controller) SetOfflineMode(ctx echo.Context) error {
+	var req OfflineModeRequest
+	if err := ctx.Bind(&req); err != nil {
+		return c.HandleError(ctx, err, "Failed to parse request body", http.StatusBadRequest)
+	}
+	if req.Enabled == nil {
+		return c.HandleError(ctx, echo.NewHTTPError(http.StatusBadRequest, "enabled is required"),
+			"Missing 'enabled' field", http.StatusBadRequest)
+	}
+
+	previous := conf.IsOffline()
+	conf.SetOffline(*req.Enabled)
+
+	flushed := 0
+	if !*req.Enabled && c.Processor != nil {
+		flushed, _ = c.Processor.FlushOfflineQueue()
+	}
+
+	c.logAPIRequest(ctx, slog.LevelInfo, "Offline mode changed",
+		"previous", previous,
+		"enabled", *req.Enabled,
+		"flushed_tasks", flushed)
+
+	return ctx.JSON(http.StatusOK, OfflineModeResponse{
+		Enabled: *req.Enabled,
+		Queue:   c.offlineQueueStats(),
+		Flushed: flushed,
+	})
+}
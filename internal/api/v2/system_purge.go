@@ -0,0 +1,294 @@
+// internal/api/v2/system_purge.go
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// purgeTokenTTL is how long a confirmation token issued by a purge preview stays valid
+const purgeTokenTTL = 5 * time.Minute
+
+// purgeMediaExtensions are the clip and spectrogram files removed by the clips scope
+var purgeMediaExtensions = []string{".wav", ".flac", ".aac", ".opus", ".mp3", ".m4a", ".ogg", ".png"}
+
+// DataPurger is implemented by datastores that support bulk data removal
+type DataPurger interface {
+	CountPurge(req *datastore.PurgeRequest) (datastore.PurgeCounts, error)
+	Purge(req *datastore.PurgeRequest) (*datastore.PurgeResult, error)
+}
+
+// PurgeDataRequest represents a data purge request. A request without a
+// confirmation token only previews the purge and returns a token; repeating
+// the same request with the token executes it.
+type PurgeDataRequest struct {
+	Scopes            []datastore.PurgeScope `json:"scopes"`
+	Before            string                 `json:"before,omitempty"` // YYYY-MM-DD, required for the detections scope
+	ConfirmationToken string                 `json:"confirmation_token,omitempty"`
+}
+
+// PurgeDataResponse represents a purge preview or the outcome of an executed purge
+type PurgeDataResponse struct {
+	Executed          bool                   `json:"executed"`
+	Scopes            []datastore.PurgeScope `json:"scopes"`
+	Before            string                 `json:"before,omitempty"`
+	Counts            datastore.PurgeCounts  `json:"counts"`
+	ConfirmationToken string                 `json:"confirmation_token,omitempty"` // Preview only
+	ExpiresAt         *time.Time             `json:"expires_at,omitempty"`         // Preview only
+	FilesRemoved      int                    `json:"files_removed,omitempty"`
+	FilesFailed       int                    `json:"files_failed,omitempty"`
+}
+
+// purgeConfirmation is a pending purge waiting for confirmation
+type purgeConfirmation struct {
+	request string // canonical form of the previewed request
+	expires time.Time
+}
+
+// purgeTokens holds confirmation tokens issued by purge previews
+var purgeTokens = struct {
+	sync.Mutex
+	pending map[string]purgeConfirmation
+}{pending: make(map[string]purgeConfirmation)}
+
+// canonicalPurgeRequest returns a form of the request that ignores scope order and
+// a date given without the detections scope, for matching a confirmation to its preview
+func canonicalPurgeRequest(req *datastore.PurgeRequest) string {
+	scopes := make([]string, 0, len(req.Scopes))
+	for _, scope := range req.Scopes {
+		scopes = append(scopes, string(scope))
+	}
+	slices.Sort(scopes)
+	canonical := strings.Join(slices.Compact(scopes), ",")
+	if req.Has(datastore.PurgeDetections) {
+		canonical += "|" + req.Before
+	}
+	return canonical
+}
+
+// issuePurgeToken returns a single-use token confirming req
+func issuePurgeToken(req *datastore.PurgeRequest) (token string, expires time.Time, err error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", time.Time{}, errors.New(err).
+			Component("api").
+			Category(errors.CategorySystem).
+			Context("operation", "generate_purge_token").
+			Build()
+	}
+	token = hex.EncodeToString(b)
+	now := time.Now()
+	expires = now.Add(purgeTokenTTL)
+
+	purgeTokens.Lock()
+	defer purgeTokens.Unlock()
+	for t, pending := range purgeTokens.pending {
+		if now.After(pending.expires) {
+			delete(purgeTokens.pending, t)
+		}
+	}
+	purgeTokens.pending[token] = purgeConfirmation{request: canonicalPurgeRequest(req), expires: expires}
+	return token, expires, nil
+}
+
+// consumePurgeToken reports whether token confirms req. A token is invalidated
+// by its first use, whether or not it matches.
+func consumePurgeToken(token string, req *datastore.PurgeRequest) bool {
+	purgeTokens.Lock()
+	defer purgeTokens.Unlock()
+
+	pending, ok := purgeTokens.pending[token]
+	delete(purgeTokens.pending, token)
+	return ok && time.Now().Before(pending.expires) && pending.request == canonicalPurgeRequest(req)
+}
+
+// PurgeData handles POST /api/v2/system/purge
+// It removes clips, detections before a date and user data (reviews, comments,
+// locks and tags) for decommissioning a station or answering a privacy request.
+func (c *Controller) PurgeData(ctx echo.Context) error {
+	var req PurgeDataRequest
+	if err := ctx.Bind(&req); err != nil {
+		return c.HandleError(ctx, err, "Failed to parse request body", http.StatusBadRequest)
+	}
+
+	purge := &datastore.PurgeRequest{Scopes: req.Scopes, Before: req.Before}
+	if err := purge.Validate(); err != nil {
+		return c.HandleError(ctx, err, "Invalid purge request", http.StatusBadRequest)
+	}
+
+	purger, ok := c.DS.(DataPurger)
+	if !ok {
+		return c.HandleError(ctx, errors.Newf("datastore does not support data purge").
+			Component("api").
+			Category(errors.CategoryConfiguration).
+			Build(), "Data purge is not supported by this datastore", http.StatusNotImplemented)
+	}
+
+	resp := PurgeDataResponse{Scopes: purge.Scopes}
+	if purge.Has(datastore.PurgeDetections) {
+		resp.Before = purge.Before
+	}
+
+	if req.ConfirmationToken == "" {
+		counts, err := purger.CountPurge(purge)
+		if err != nil {
+			return c.HandleError(ctx, err, "Failed to preview data purge", http.StatusInternalServerError)
+		}
+		token, expires, err := issuePurgeToken(purge)
+		if err != nil {
+			return c.HandleError(ctx, err, "Failed to issue confirmation token", http.StatusInternalServerError)
+		}
+
+		c.logAPIRequest(ctx, slog.LevelInfo, "Data purge previewed",
+			"scopes", purge.Scopes,
+			"before", resp.Before,
+			"detections", counts.Detections,
+			"clips", counts.Clips)
+
+		resp.Counts = counts
+		resp.ConfirmationToken = token
+		resp.ExpiresAt = &expires
+		return ctx.JSON(http.StatusOK, resp)
+	}
+
+	if !consumePurgeToken(req.ConfirmationToken, purge) {
+		return c.HandleError(ctx, errors.Newf("invalid or expired confirmation token").
+			Component("api").
+			Category(errors.CategoryValidation).
+			Build(), "Invalid or expired confirmation token, preview the purge again", http.StatusForbidden)
+	}
+
+	result, err := purger.Purge(purge)
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to purge data", http.StatusInternalServerError)
+	}
+
+	// Files are removed once the database changes are committed, so a failed
+	// purge never leaves detections pointing at missing clips
+	resp.FilesRemoved, resp.FilesFailed = c.removePurgedFiles(ctx, purge, result.ClipNames)
+
+	c.invalidateDetectionCache()
+	if purge.Has(datastore.PurgeDetections) && c.Processor != nil && c.Processor.NewSpeciesTracker != nil {
+		if err := c.Processor.NewSpeciesTracker.InitFromDatabase(); err != nil {
+			c.logAPIRequest(ctx, slog.LevelWarn, "Failed to reload species tracker after purge",
+				"error", err.Error())
+		}
+	}
+
+	c.logAPIRequest(ctx, slog.LevelWarn, "Data purged",
+		"scopes", purge.Scopes,
+		"before", resp.Before,
+		"detections", result.Counts.Detections,
+		"clips", result.Counts.Clips,
+		"reviews", result.Counts.Reviews,
+		"comments", result.Counts.Comments,
+		"locks", result.Counts.Locks,
+		"files_removed", resp.FilesRemoved,
+		"files_failed", resp.FilesFailed)
+
+	resp.Executed = true
+	resp.Counts = result.Counts
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+// removePurgedFiles deletes the files of a committed purge: every clip and
+// spectrogram for the clips scope, otherwise those of the deleted detections.
+func (c *Controller) removePurgedFiles(ctx echo.Context, purge *datastore.PurgeRequest, clipNames []string) (removed, failed int) {
+	exportPath := c.Settings.Realtime.Audio.Export.Path
+	if exportPath == "" || c.SFS == nil {
+		return 0, 0
+	}
+
+	remove := func(path string) {
+		if err := c.SFS.Remove(path); err != nil {
+			if !os.IsNotExist(err) {
+				failed++
+				c.logAPIRequest(ctx, slog.LevelWarn, "Failed to remove purged file",
+					"path", path,
+					"error", err.Error())
+			}
+			return
+		}
+		removed++
+	}
+
+	if purge.Has(datastore.PurgeClips) {
+		_ = filepath.WalkDir(exportPath, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				failed++
+				return nil
+			}
+			if !d.IsDir() && slices.Contains(purgeMediaExtensions, strings.ToLower(filepath.Ext(path))) {
+				remove(path)
+			}
+			return nil
+		})
+		return removed, failed
+	}
+
+	// Group clips by directory, so each directory is listed once for spectrograms
+	clipsByDir := make(map[string]map[string]bool)
+	for _, clipName := range clipNames {
+		normalized, valid := NormalizeClipPathStrict(clipName, exportPath)
+		if !valid || normalized == "" {
+			failed++
+			continue
+		}
+		relPath, err := c.SFS.ValidateRelativePath(normalized)
+		if err != nil {
+			failed++
+			continue
+		}
+		remove(filepath.Join(exportPath, relPath))
+
+		dir := filepath.Dir(relPath)
+		if clipsByDir[dir] == nil {
+			clipsByDir[dir] = make(map[string]bool)
+		}
+		clipsByDir[dir][strings.TrimSuffix(filepath.Base(relPath), filepath.Ext(relPath))] = true
+	}
+
+	for dir, bases := range clipsByDir {
+		entries, err := os.ReadDir(filepath.Join(exportPath, dir))
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() && isSpectrogramOf(entry.Name(), bases) {
+				remove(filepath.Join(exportPath, dir, entry.Name()))
+			}
+		}
+	}
+	return removed, failed
+}
+
+// isSpectrogramOf reports whether name is a spectrogram of one of the clips,
+// either legacy (clip.png) or sized (clip_400px.png, clip_400px-legend.png)
+func isSpectrogramOf(name string, clipBases map[string]bool) bool {
+	stem, ok := strings.CutSuffix(name, ".png")
+	if !ok {
+		return false
+	}
+	if clipBases[stem] {
+		return true
+	}
+	i := strings.LastIndex(stem, "_")
+	if i < 0 {
+		return false
+	}
+	suffix := strings.TrimSuffix(stem[i+1:], "-legend")
+	return strings.HasSuffix(suffix, "px") && clipBases[stem[:i]]
+}
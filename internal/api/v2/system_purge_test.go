@@ -0,0 +1,162 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+// purgeMockDataStore adds data purge support to MockDataStore
+type purgeMockDataStore struct {
+	*MockDataStore
+	counts    datastore.PurgeCounts
+	clipNames []string
+	purged    []*datastore.PurgeRequest
+}
+
+func (m *purgeMockDataStore) CountPurge(req *datastore.PurgeRequest) (datastore.PurgeCounts, error) {
+	return m.counts, nil
+}
+
+func (m *purgeMockDataStore) Purge(req *datastore.PurgeRequest) (*datastore.PurgeResult, error) {
+	m.purged = append(m.purged, req)
+	return &datastore.PurgeResult{Counts: m.counts, ClipNames: m.clipNames}, nil
+}
+
+// postPurge sends a purge request and returns the response recorder
+func postPurge(t *testing.T, controller *Controller, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/system/purge", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	require.NoError(t, controller.PurgeData(controller.Echo.NewContext(req, rec)))
+	return rec
+}
+
+// writeTestFiles creates empty files relative to dir
+func writeTestFiles(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte("data"), 0o600))
+	}
+}
+
+func TestPurgeData_RequiresConfirmation(t *testing.T) {
+	t.Parallel()
+
+	_, mockDS, controller := setupTestEnvironment(t)
+	store := &purgeMockDataStore{
+		MockDataStore: mockDS,
+		counts:        datastore.PurgeCounts{Detections: 2, Clips: 1},
+		clipNames:     []string{"2024/01/old.wav"},
+	}
+	controller.DS = store
+
+	exportPath := controller.Settings.Realtime.Audio.Export.Path
+	writeTestFiles(t, exportPath,
+		"2024/01/old.wav", "2024/01/old.png", "2024/01/old_400px.png", "2024/01/old_800px-legend.png",
+		"2024/01/older.wav", "2024/01/older_400px.png")
+
+	body := `{"scopes":["detections"],"before":"2024-02-01"}`
+	rec := postPurge(t, controller, body)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var preview PurgeDataResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &preview))
+	assert.False(t, preview.Executed)
+	assert.Equal(t, int64(2), preview.Counts.Detections)
+	require.NotEmpty(t, preview.ConfirmationToken)
+	require.NotNil(t, preview.ExpiresAt)
+	assert.Empty(t, store.purged, "a preview must not purge anything")
+
+	// A token only confirms the request it was issued for
+	rec = postPurge(t, controller, `{"scopes":["detections"],"before":"2024-03-01","confirmation_token":"`+preview.ConfirmationToken+`"}`)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Empty(t, store.purged)
+
+	// The mismatched attempt used up the token
+	rec = postPurge(t, controller, `{"scopes":["detections"],"before":"2024-02-01","confirmation_token":"`+preview.ConfirmationToken+`"}`)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = postPurge(t, controller, body)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &preview))
+	rec = postPurge(t, controller, `{"scopes":["detections"],"before":"2024-02-01","confirmation_token":"`+preview.ConfirmationToken+`"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var result PurgeDataResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+	assert.True(t, result.Executed)
+	assert.Equal(t, 4, result.FilesRemoved, "the clip and its spectrograms should be removed")
+	require.Len(t, store.purged, 1)
+
+	for _, name := range []string{"old.wav", "old.png", "old_400px.png", "old_800px-legend.png"} {
+		assert.NoFileExists(t, filepath.Join(exportPath, "2024/01", name))
+	}
+	assert.FileExists(t, filepath.Join(exportPath, "2024/01/older.wav"), "clips of other detections should be kept")
+	assert.FileExists(t, filepath.Join(exportPath, "2024/01/older_400px.png"))
+}
+
+func TestPurgeData_ClipsScopeRemovesAllMedia(t *testing.T) {
+	t.Parallel()
+
+	_, mockDS, controller := setupTestEnvironment(t)
+	store := &purgeMockDataStore{MockDataStore: mockDS}
+	controller.DS = store
+
+	exportPath := controller.Settings.Realtime.Audio.Export.Path
+	writeTestFiles(t, exportPath, "2024/01/a.wav", "2024/02/b.flac", "2024/02/b_400px.png", "notes.txt")
+
+	rec := postPurge(t, controller, `{"scopes":["clips"]}`)
+	var preview PurgeDataResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &preview))
+
+	rec = postPurge(t, controller, `{"scopes":["clips"],"confirmation_token":"`+preview.ConfirmationToken+`"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var result PurgeDataResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+	assert.Equal(t, 3, result.FilesRemoved)
+	assert.FileExists(t, filepath.Join(exportPath, "notes.txt"), "only clips and spectrograms should be removed")
+}
+
+func TestPurgeData_InvalidRequests(t *testing.T) {
+	t.Parallel()
+
+	_, mockDS, controller := setupTestEnvironment(t)
+	controller.DS = &purgeMockDataStore{MockDataStore: mockDS}
+
+	for _, body := range []string{
+		`{}`,
+		`{"scopes":["everything"]}`,
+		`{"scopes":["detections"]}`,
+		`{"scopes":["detections"],"before":"last year"}`,
+	} {
+		rec := postPurge(t, controller, body)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, body)
+	}
+
+	rec := postPurge(t, controller, `{"scopes":["user_data"],"confirmation_token":"guessed"}`)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestCanonicalPurgeRequest(t *testing.T) {
+	t.Parallel()
+
+	a := canonicalPurgeRequest(&datastore.PurgeRequest{Scopes: []datastore.PurgeScope{datastore.PurgeUserData, datastore.PurgeClips}, Before: "2024-01-01"})
+	b := canonicalPurgeRequest(&datastore.PurgeRequest{Scopes: []datastore.PurgeScope{datastore.PurgeClips, datastore.PurgeUserData}})
+	assert.Equal(t, a, b, "scope order and an unused date should not matter")
+
+	c := canonicalPurgeRequest(&datastore.PurgeRequest{Scopes: []datastore.PurgeScope{datastore.PurgeDetections}, Before: "2024-01-01"})
+	d := canonicalPurgeRequest(&datastore.PurgeRequest{Scopes: []datastore.PurgeScope{datastore.PurgeDetections}, Before: "2024-06-01"})
+	assert.NotEqual(t, c, d)
+}
@@ -0,0 +1,88 @@
+// internal/api/v2/system_readonly.go
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+// ReadOnlyModeResponse represents the read-only mode state in API responses
+type ReadOnlyModeResponse struct {
+	Enabled bool `json:"enabled"`
+}
+
+// ReadOnlyModeRequest represents a request to toggle read-only mode
+type ReadOnlyModeRequest struct {
+	Enabled *bool `json:"enabled"`
+}
+
+// GetReadOnlyMode handles GET /api/v2/system/readonly
+func (c *Controller) GetReadOnlyMode(ctx echo.Context) error {
+	return ctx.JSON(http.StatusOK, ReadOnlyModeResponse{Enabled: conf.IsReadOnly()})
+}
+
+// SetReadOnlyMode handles PUT /api/v2/system/readonly
+// The new state applies immediately and lasts until the next restart.
+func (c *Controller) SetReadOnlyMode(ctx echo.Context) error {
+	var req ReadOnlyModeRequest
+	if err := ctx.Bind(&req); err != nil {
+		return c.HandleError(ctx, err, "Failed to parse request body", http.StatusBadRequest)
+	}
+	if req.Enabled == nil {
+		return c.HandleError(ctx, echo.NewHTTPError(http.StatusBadRequest, "enabled is required"),
+			"Missing 'enabled' field", http.StatusBadRequest)
+	}
+
+	previous := conf.IsReadOnly()
+	conf.SetReadOnly(*req.Enabled)
+
+	c.logAPIRequest(ctx, slog.LevelInfo, "Read-only mode changed",
+		"previous", previous,
+		"enabled", *req.Enabled)
+
+	return ctx.JSON(http.StatusOK, ReadOnlyModeResponse{Enabled: *req.Enabled})
+}
+
+// readOnlyAllowedRoutes are the changes accepted while read-only mode is
+// active: switching runtime modes and requests that only read through POST.
+// Routes under /auth, to log in and out, are always accepted.
+var readOnlyAllowedRoutes = []string{
+	"PUT /system/readonly",
+	"PUT /system/offline",
+	"POST /search",
+	"POST /detections/reanalysis/diff",
+	"POST /range/species/test",
+	"POST /media/sign",
+	"POST /integrations/mqtt/test",
+	"POST /integrations/birdweather/test",
+	"POST /integrations/weather/test",
+}
+
+// ReadOnlyMiddleware rejects requests other than GET, HEAD and OPTIONS with
+// 403 Forbidden while read-only mode is active, except for the routes of
+// readOnlyAllowedRoutes. It is applied to the whole v2 group, so new routes
+// are covered without opting in.
+func (c *Controller) ReadOnlyMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		if !conf.IsReadOnly() {
+			return next(ctx)
+		}
+		req := ctx.Request()
+		switch req.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			return next(ctx)
+		}
+		route := strings.TrimPrefix(req.URL.Path, "/api/v2")
+		if routeHasPrefix(route, []string{"/auth"}) || slices.Contains(readOnlyAllowedRoutes, req.Method+" "+route) {
+			return next(ctx)
+		}
+
+		c.logAPIRequest(ctx, slog.LevelWarn, "Write rejected in read-only mode")
+		return c.HandleError(ctx, conf.ErrReadOnlyMode, "Read-only mode is enabled", http.StatusForbidden)
+	}
+}
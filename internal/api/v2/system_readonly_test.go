@@ -0,0 +1,77 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+// TestReadOnlyMode covers toggling read-only mode and the write-blocking middleware.
+// Not parallel: read-only mode is process-wide state.
+func TestReadOnlyMode(t *testing.T) {
+	e, _, controller := setupTestEnvironment(t)
+	t.Cleanup(func() { conf.SetReadOnly(false) })
+
+	setMode := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/api/v2/system/readonly", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		require.NoError(t, controller.SetReadOnlyMode(e.NewContext(req, rec)))
+		return rec
+	}
+
+	writeHandler := controller.ReadOnlyMiddleware(func(ctx echo.Context) error {
+		return ctx.NoContent(http.StatusNoContent)
+	})
+	call := func(method, path string) int {
+		req := httptest.NewRequest(method, path, http.NoBody)
+		rec := httptest.NewRecorder()
+		require.NoError(t, writeHandler(e.NewContext(req, rec)))
+		return rec.Code
+	}
+	callWrite := func() int { return call(http.MethodPatch, "/api/v2/settings/birdnet") }
+
+	// Writes are allowed by default
+	assert.Equal(t, http.StatusNoContent, callWrite())
+
+	rec := setMode(`{"enabled": true}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"enabled": true}`, rec.Body.String())
+	assert.True(t, conf.IsReadOnly())
+	assert.Equal(t, http.StatusForbidden, callWrite())
+	assert.ErrorIs(t, conf.SaveSettings(), conf.ErrReadOnlyMode)
+	assert.NotErrorIs(t, conf.ErrOfflineMode, conf.ErrReadOnlyMode, "other state errors are told apart")
+
+	// Every change is rejected, except for the allowed routes
+	assert.Equal(t, http.StatusForbidden, call(http.MethodDelete, "/api/v2/detections/1"))
+	assert.Equal(t, http.StatusForbidden, call(http.MethodPost, "/api/v2/detections/1/comments"))
+	assert.Equal(t, http.StatusForbidden, call(http.MethodPost, "/api/v2/sync/detections"))
+	assert.Equal(t, http.StatusForbidden, call(http.MethodPut, "/api/v2/dashboards/1"))
+	assert.Equal(t, http.StatusNoContent, call(http.MethodGet, "/api/v2/detections"))
+	assert.Equal(t, http.StatusNoContent, call(http.MethodPost, "/api/v2/auth/login"))
+	assert.Equal(t, http.StatusNoContent, call(http.MethodPost, "/api/v2/auth/logout"))
+	assert.Equal(t, http.StatusNoContent, call(http.MethodPost, "/api/v2/search"))
+	assert.Equal(t, http.StatusNoContent, call(http.MethodPut, "/api/v2/system/readonly"))
+
+	// Missing field is rejected and leaves the mode unchanged
+	rec = setMode(`{}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.True(t, conf.IsReadOnly())
+
+	rec = setMode(`{"enabled": false}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.False(t, conf.IsReadOnly())
+	assert.Equal(t, http.StatusNoContent, callWrite())
+
+	// GET reflects the current state
+	req := httptest.NewRequest(http.MethodGet, "/api/v2/system/readonly", http.NoBody)
+	getRec := httptest.NewRecorder()
+	require.NoError(t, controller.GetReadOnlyMode(e.NewContext(req, getRec)))
+	assert.JSONEq(t, `{"enabled": false}`, getRec.Body.String())
+}
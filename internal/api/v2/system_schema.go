@@ -0,0 +1,56 @@
+// internal/api/v2/system_schema.go
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// SchemaInspector is implemented by datastores that can report their live schema
+type SchemaInspector interface {
+	GetDatabaseSchema(ctx context.Context) (*datastore.DatabaseSchema, error)
+}
+
+// DatabaseSchemaResponse represents the live database schema in API responses
+type DatabaseSchemaResponse struct {
+	*datastore.DatabaseSchema
+	Relations []datastore.SchemaRelation `json:"relations"`
+}
+
+// GetDatabaseSchema handles GET /api/v2/system/schema
+// It returns the tables, columns, indexes and row counts of the database as
+// JSON, or an entity relationship diagram in Mermaid syntax with format=mermaid.
+func (c *Controller) GetDatabaseSchema(ctx echo.Context) error {
+	format := ctx.QueryParam("format")
+	if format != "" && format != "json" && format != "mermaid" {
+		return c.HandleError(ctx, errors.Newf("unsupported schema format: %s", format).
+			Component("api").
+			Category(errors.CategoryValidation).
+			Build(), "Format must be json or mermaid", http.StatusBadRequest)
+	}
+
+	inspector, ok := c.DS.(SchemaInspector)
+	if !ok {
+		return c.HandleError(ctx, errors.Newf("datastore does not support schema inspection").
+			Component("api").
+			Category(errors.CategoryConfiguration).
+			Build(), "Schema inspection is not supported by this datastore", http.StatusNotImplemented)
+	}
+
+	schema, err := inspector.GetDatabaseSchema(ctx.Request().Context())
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to read database schema", http.StatusInternalServerError)
+	}
+
+	if format == "mermaid" {
+		return ctx.String(http.StatusOK, schema.Mermaid())
+	}
+	return ctx.JSON(http.StatusOK, DatabaseSchemaResponse{
+		DatabaseSchema: schema,
+		Relations:      schema.Relations(),
+	})
+}
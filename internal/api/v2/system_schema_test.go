@@ -0,0 +1,57 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+// schemaMockDataStore adds schema inspection to MockDataStore
+type schemaMockDataStore struct {
+	*MockDataStore
+}
+
+func (m *schemaMockDataStore) GetDatabaseSchema(ctx context.Context) (*datastore.DatabaseSchema, error) {
+	return &datastore.DatabaseSchema{
+		Dialect: "sqlite",
+		Tables: []datastore.TableSchema{
+			{Name: "notes", RowCount: 42, Columns: []datastore.ColumnSchema{{Name: "id", Type: "integer", PrimaryKey: true}}},
+			{Name: "note_reviews", Columns: []datastore.ColumnSchema{{Name: "note_id", Type: "integer"}}},
+		},
+	}, nil
+}
+
+func TestGetDatabaseSchema(t *testing.T) {
+	t.Parallel()
+
+	_, mockDS, controller := setupTestEnvironment(t)
+	get := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v2/system/schema"+query, http.NoBody)
+		rec := httptest.NewRecorder()
+		require.NoError(t, controller.GetDatabaseSchema(controller.Echo.NewContext(req, rec)))
+		return rec
+	}
+
+	assert.Equal(t, http.StatusNotImplemented, get("").Code, "the mock datastore cannot report its schema")
+
+	controller.DS = &schemaMockDataStore{MockDataStore: mockDS}
+	rec := get("")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp DatabaseSchemaResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.Tables, 2)
+	assert.Equal(t, int64(42), resp.Tables[0].RowCount)
+	assert.Equal(t, []datastore.SchemaRelation{{Table: "note_reviews", Column: "note_id", ReferencedTable: "notes"}}, resp.Relations)
+
+	rec = get("?format=mermaid")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "notes ||--o{ note_reviews : note_id")
+
+	assert.Equal(t, http.StatusBadRequest, get("?format=svg").Code)
+}
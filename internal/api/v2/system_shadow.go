@@ -0,0 +1,111 @@
+// internal/api/v2/system_shadow.go
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/errors"
+	"github.com/tphakala/birdnet-go/internal/shadowmodel"
+)
+
+// defaultShadowReportDays is the period compared when neither the request nor
+// the trial gives a start
+const defaultShadowReportDays = 7
+
+// ShadowModelComparer is implemented by datastores that log the would-be
+// detections of a candidate model
+type ShadowModelComparer interface {
+	CompareShadowModel(model string, start, end time.Time) (*datastore.ReanalysisDiff, error)
+}
+
+// ShadowModelReport compares a candidate model with the production model
+type ShadowModelReport struct {
+	Status shadowmodel.Status `json:"status"`
+	Start  time.Time          `json:"start"`
+	End    time.Time          `json:"end"`
+	*datastore.ReanalysisDiff
+}
+
+// shadowModel returns the candidate model running in shadow mode, or nil
+func (c *Controller) shadowModel() *shadowmodel.Runner {
+	if c.Processor == nil {
+		return nil
+	}
+	return c.Processor.GetShadowModel()
+}
+
+// errShadowModelDisabled reports that no candidate model is running
+func (c *Controller) errShadowModelDisabled(ctx echo.Context) error {
+	return c.HandleError(ctx, errors.Newf("shadow model is not running").
+		Component("api").
+		Category(errors.CategoryNotFound).
+		Build(), "No shadow model is running, enable birdnet.shadow in the settings", http.StatusNotFound)
+}
+
+// GetShadowModelStatus handles GET /api/v2/system/shadow
+func (c *Controller) GetShadowModelStatus(ctx echo.Context) error {
+	runner := c.shadowModel()
+	if runner == nil {
+		return c.errShadowModelDisabled(ctx)
+	}
+	return ctx.JSON(http.StatusOK, runner.Status())
+}
+
+// GetShadowModelReport handles GET /api/v2/system/shadow/report
+// It compares the would-be detections of the candidate model with the
+// detections of the production model per audio segment. The period defaults
+// to the trial so far, given by the optional start and end (RFC 3339) query
+// parameters otherwise.
+func (c *Controller) GetShadowModelReport(ctx echo.Context) error {
+	runner := c.shadowModel()
+	if runner == nil {
+		return c.errShadowModelDisabled(ctx)
+	}
+	comparer, ok := c.DS.(ShadowModelComparer)
+	if !ok {
+		return c.HandleError(ctx, errors.Newf("datastore does not support shadow model comparison").
+			Component("api").
+			Category(errors.CategoryConfiguration).
+			Build(), "Shadow model comparison is not supported by this datastore", http.StatusNotImplemented)
+	}
+
+	status := runner.Status()
+	end := time.Now()
+	start := end.AddDate(0, 0, -defaultShadowReportDays)
+	if status.TrialStart != nil {
+		start = *status.TrialStart
+	}
+	if status.TrialEnd != nil && status.TrialEnd.Before(end) {
+		end = *status.TrialEnd
+	}
+	for param, value := range map[string]*time.Time{"start": &start, "end": &end} {
+		if raw := ctx.QueryParam(param); raw != "" {
+			parsed, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				return c.HandleError(ctx, err, "Invalid "+param+" time, use RFC 3339 format", http.StatusBadRequest)
+			}
+			*value = parsed
+		}
+	}
+	if !start.Before(end) {
+		return c.HandleError(ctx, errors.Newf("start must be before end").
+			Component("api").
+			Category(errors.CategoryValidation).
+			Build(), "Start must be before end", http.StatusBadRequest)
+	}
+
+	diff, err := comparer.CompareShadowModel(runner.Model(), start, end)
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to compare shadow model", http.StatusInternalServerError)
+	}
+
+	return ctx.JSON(http.StatusOK, ShadowModelReport{
+		Status:         status,
+		Start:          start,
+		End:            end,
+		ReanalysisDiff: diff,
+	})
+}
@@ -0,0 +1,26 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShadowModel_NotRunning(t *testing.T) {
+	t.Parallel()
+
+	_, _, controller := setupTestEnvironment(t)
+	for path, handler := range map[string]echo.HandlerFunc{
+		"/api/v2/system/shadow":        controller.GetShadowModelStatus,
+		"/api/v2/system/shadow/report": controller.GetShadowModelReport,
+	} {
+		req := httptest.NewRequest(http.MethodGet, path, http.NoBody)
+		rec := httptest.NewRecorder()
+		require.NoError(t, handler(controller.Echo.NewContext(req, rec)))
+		assert.Equal(t, http.StatusNotFound, rec.Code, path)
+	}
+}
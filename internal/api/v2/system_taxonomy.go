@@ -0,0 +1,242 @@
+// internal/api/v2/system_taxonomy.go
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/errors"
+	"github.com/tphakala/birdnet-go/internal/speciesalias"
+)
+
+// TaxonomyRemapper is implemented by datastores that support renaming stored
+// species after taxonomy changes
+type TaxonomyRemapper interface {
+	CountTaxonomyRemap(mappings []datastore.TaxonomyMapping) (*datastore.TaxonomyRemapReport, error)
+	RemapTaxonomy(runID, source string, mappings []datastore.TaxonomyMapping) (*datastore.TaxonomyRemapReport, error)
+	RevertTaxonomyRemap(runID string) (int64, error)
+	GetTaxonomyRemapRuns() ([]datastore.TaxonomyRemapRun, error)
+}
+
+// TaxonomyRemapRequest represents a taxonomy remapping request. Mappings are
+// read from MappingFile, a file on the server in the species alias format, or
+// given inline; without either the configured species alias table is used.
+type TaxonomyRemapRequest struct {
+	MappingFile string               `json:"mapping_file,omitempty"`
+	Mappings    []speciesalias.Alias `json:"mappings,omitempty"`
+	DryRun      bool                 `json:"dry_run"`
+}
+
+// TaxonomyRemapResponse represents a remapping preview or the outcome of an
+// executed remapping
+type TaxonomyRemapResponse struct {
+	Executed bool   `json:"executed"`
+	Source   string `json:"source"`
+	datastore.TaxonomyRemapReport
+	Skipped []string `json:"skipped,omitempty"` // Split species, which need review
+}
+
+// TaxonomyRemapRunResponse represents a recorded taxonomy remapping
+type TaxonomyRemapRunResponse struct {
+	RunID      string     `json:"run_id"`
+	Source     string     `json:"source"`
+	Detections int64      `json:"detections"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RevertedAt *time.Time `json:"reverted_at,omitempty"`
+}
+
+// taxonomyMappings converts aliases to datastore mappings in their order.
+// Aliases mapped to several species, left behind by splits, cannot be applied
+// without review and are returned as skipped.
+func taxonomyMappings(aliases []speciesalias.Alias) (mappings []datastore.TaxonomyMapping, skipped []string) {
+	species := make(map[string][]speciesalias.Species, len(aliases))
+	var order []string
+	for _, a := range aliases {
+		key := strings.ToLower(strings.TrimSpace(a.Alias))
+		if _, ok := species[key]; !ok {
+			order = append(order, a.Alias)
+		}
+		species[key] = append(species[key], a.Species)
+	}
+
+	for _, alias := range order {
+		current := species[strings.ToLower(strings.TrimSpace(alias))]
+		if len(current) != 1 {
+			skipped = append(skipped, alias)
+			continue
+		}
+		mappings = append(mappings, datastore.TaxonomyMapping{
+			From:           strings.TrimSpace(alias),
+			ScientificName: current[0].ScientificName,
+			CommonName:     current[0].CommonName,
+			SpeciesCode:    current[0].Code,
+		})
+	}
+	return mappings, skipped
+}
+
+// taxonomyRemapper returns the datastore as a TaxonomyRemapper, or false when
+// the datastore does not support taxonomy remapping
+func (c *Controller) taxonomyRemapper() (TaxonomyRemapper, bool) {
+	remapper, ok := c.DS.(TaxonomyRemapper)
+	return remapper, ok
+}
+
+// errTaxonomyRemapUnsupported reports a datastore without taxonomy remapping
+func (c *Controller) errTaxonomyRemapUnsupported(ctx echo.Context) error {
+	return c.HandleError(ctx, errors.Newf("datastore does not support taxonomy remapping").
+		Component("api").
+		Category(errors.CategoryConfiguration).
+		Build(), "Taxonomy remapping is not supported by this datastore", http.StatusNotImplemented)
+}
+
+// RemapTaxonomy handles POST /api/v2/system/taxonomy/remap
+// It renames detections stored under former species names after a model or
+// label taxonomy change. Changed rows are backed up so the remapping can be
+// reverted; a dry run only reports the affected detections.
+func (c *Controller) RemapTaxonomy(ctx echo.Context) error {
+	var req TaxonomyRemapRequest
+	if err := ctx.Bind(&req); err != nil {
+		return c.HandleError(ctx, err, "Failed to parse request body", http.StatusBadRequest)
+	}
+
+	remapper, ok := c.taxonomyRemapper()
+	if !ok {
+		return c.errTaxonomyRemapUnsupported(ctx)
+	}
+
+	var aliases []speciesalias.Alias
+	var source string
+	switch {
+	case req.MappingFile != "" && len(req.Mappings) > 0:
+		return c.HandleError(ctx, errors.Newf("mapping_file and mappings are mutually exclusive").
+			Component("api").
+			Category(errors.CategoryValidation).
+			Build(), "Give either a mapping file or inline mappings", http.StatusBadRequest)
+	case req.MappingFile != "":
+		var err error
+		if aliases, err = speciesalias.ReadFile(req.MappingFile); err != nil {
+			return c.HandleError(ctx, err, "Failed to read mapping file", http.StatusBadRequest)
+		}
+		source = req.MappingFile
+	case len(req.Mappings) > 0:
+		aliases = req.Mappings
+		source = "request"
+	default:
+		aliases = c.speciesAliases().Aliases()
+		source = "species aliases"
+	}
+
+	mappings, skipped := taxonomyMappings(aliases)
+	resp := TaxonomyRemapResponse{Source: source, Skipped: skipped}
+	if len(mappings) == 0 {
+		resp.Mappings = []datastore.TaxonomyRemapCount{}
+		return ctx.JSON(http.StatusOK, resp)
+	}
+
+	if req.DryRun {
+		report, err := remapper.CountTaxonomyRemap(mappings)
+		if err != nil {
+			return c.HandleError(ctx, err, "Failed to preview taxonomy remapping", remapErrorStatus(err))
+		}
+		c.logAPIRequest(ctx, slog.LevelInfo, "Taxonomy remapping previewed",
+			"source", source,
+			"mappings", len(mappings),
+			"skipped", len(skipped),
+			"detections", report.Detections)
+
+		resp.TaxonomyRemapReport = *report
+		return ctx.JSON(http.StatusOK, resp)
+	}
+
+	report, err := remapper.RemapTaxonomy(uuid.New().String(), source, mappings)
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to remap taxonomy", remapErrorStatus(err))
+	}
+	if report.Detections > 0 {
+		c.invalidateDetectionCache()
+	}
+
+	c.logAPIRequest(ctx, slog.LevelWarn, "Taxonomy remapped",
+		"run_id", report.RunID,
+		"source", source,
+		"mappings", len(mappings),
+		"skipped", len(skipped),
+		"detections", report.Detections)
+
+	resp.Executed = true
+	resp.TaxonomyRemapReport = *report
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+// GetTaxonomyRemaps handles GET /api/v2/system/taxonomy/remaps
+func (c *Controller) GetTaxonomyRemaps(ctx echo.Context) error {
+	remapper, ok := c.taxonomyRemapper()
+	if !ok {
+		return c.errTaxonomyRemapUnsupported(ctx)
+	}
+
+	runs, err := remapper.GetTaxonomyRemapRuns()
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to get taxonomy remappings", http.StatusInternalServerError)
+	}
+
+	resp := make([]TaxonomyRemapRunResponse, 0, len(runs))
+	for i := range runs {
+		resp = append(resp, TaxonomyRemapRunResponse{
+			RunID:      runs[i].RunID,
+			Source:     runs[i].Source,
+			Detections: runs[i].Detections,
+			CreatedAt:  runs[i].CreatedAt,
+			RevertedAt: runs[i].RevertedAt,
+		})
+	}
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+// RevertTaxonomyRemap handles POST /api/v2/system/taxonomy/remaps/:id/revert
+// It restores the species of the detections changed by a remapping.
+func (c *Controller) RevertTaxonomyRemap(ctx echo.Context) error {
+	remapper, ok := c.taxonomyRemapper()
+	if !ok {
+		return c.errTaxonomyRemapUnsupported(ctx)
+	}
+
+	runID := ctx.Param("id")
+	restored, err := remapper.RevertTaxonomyRemap(runID)
+	switch {
+	case errors.Is(err, datastore.ErrTaxonomyRemapNotFound):
+		return c.HandleError(ctx, err, "Taxonomy remapping not found", http.StatusNotFound)
+	case errors.Is(err, datastore.ErrTaxonomyRemapReverted):
+		return c.HandleError(ctx, err, "Taxonomy remapping was already reverted", http.StatusConflict)
+	case err != nil:
+		return c.HandleError(ctx, err, "Failed to revert taxonomy remapping", http.StatusInternalServerError)
+	}
+	if restored > 0 {
+		c.invalidateDetectionCache()
+	}
+
+	c.logAPIRequest(ctx, slog.LevelWarn, "Taxonomy remapping reverted",
+		"run_id", runID,
+		"detections", restored)
+
+	return ctx.JSON(http.StatusOK, map[string]any{
+		"run_id":     runID,
+		"detections": restored,
+	})
+}
+
+// remapErrorStatus returns the HTTP status of a remapping error: mappings
+// rejected by the datastore are client errors
+func remapErrorStatus(err error) int {
+	var enhancedErr *errors.EnhancedError
+	if errors.As(err, &enhancedErr) && enhancedErr.Category == errors.CategoryValidation {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
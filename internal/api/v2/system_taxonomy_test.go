@@ -0,0 +1,173 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/speciesalias"
+)
+
+// taxonomyMockDataStore adds taxonomy remapping support to MockDataStore
+type taxonomyMockDataStore struct {
+	*MockDataStore
+	remapped [][]datastore.TaxonomyMapping
+	sources  []string
+	reverted []string
+}
+
+func (m *taxonomyMockDataStore) CountTaxonomyRemap(mappings []datastore.TaxonomyMapping) (*datastore.TaxonomyRemapReport, error) {
+	report := &datastore.TaxonomyRemapReport{}
+	for i := range mappings {
+		report.Mappings = append(report.Mappings, datastore.TaxonomyRemapCount{From: mappings[i].From, Detections: 2})
+		report.Detections += 2
+	}
+	return report, nil
+}
+
+func (m *taxonomyMockDataStore) RemapTaxonomy(runID, source string, mappings []datastore.TaxonomyMapping) (*datastore.TaxonomyRemapReport, error) {
+	m.remapped = append(m.remapped, mappings)
+	m.sources = append(m.sources, source)
+	report, _ := m.CountTaxonomyRemap(mappings)
+	report.RunID = runID
+	return report, nil
+}
+
+func (m *taxonomyMockDataStore) RevertTaxonomyRemap(runID string) (int64, error) {
+	switch runID {
+	case "reverted":
+		return 0, datastore.ErrTaxonomyRemapReverted
+	case "run-1":
+		m.reverted = append(m.reverted, runID)
+		return 3, nil
+	}
+	return 0, datastore.ErrTaxonomyRemapNotFound
+}
+
+func (m *taxonomyMockDataStore) GetTaxonomyRemapRuns() ([]datastore.TaxonomyRemapRun, error) {
+	return []datastore.TaxonomyRemapRun{{RunID: "run-1", Source: "request", Detections: 3}}, nil
+}
+
+// postTaxonomyRemap sends a remapping request and returns the response recorder
+func postTaxonomyRemap(t *testing.T, controller *Controller, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/system/taxonomy/remap", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	require.NoError(t, controller.RemapTaxonomy(controller.Echo.NewContext(req, rec)))
+	return rec
+}
+
+func TestTaxonomyMappings_SkipsSplits(t *testing.T) {
+	t.Parallel()
+
+	mappings, skipped := taxonomyMappings([]speciesalias.Alias{
+		{Alias: "Gray Jay", Species: speciesalias.Species{ScientificName: "Perisoreus canadensis", CommonName: "Canada Jay", Code: "gryjay"}},
+		{Alias: "Western Scrub-Jay", Species: speciesalias.Species{ScientificName: "Aphelocoma californica", CommonName: "California Scrub-Jay", Code: "cowscj1"}},
+		{Alias: "western scrub-jay", Species: speciesalias.Species{ScientificName: "Aphelocoma woodhouseii", CommonName: "Woodhouse's Scrub-Jay", Code: "wooscj2"}},
+	})
+
+	require.Len(t, mappings, 1)
+	assert.Equal(t, datastore.TaxonomyMapping{From: "Gray Jay", ScientificName: "Perisoreus canadensis", CommonName: "Canada Jay", SpeciesCode: "gryjay"}, mappings[0])
+	assert.Equal(t, []string{"Western Scrub-Jay"}, skipped)
+}
+
+func TestRemapTaxonomy(t *testing.T) {
+	t.Parallel()
+
+	_, mockDS, controller := setupTestEnvironment(t)
+	store := &taxonomyMockDataStore{MockDataStore: mockDS}
+	controller.DS = store
+
+	mapping := `{"alias":"Carduelis tristis","scientificName":"Spinus tristis","commonName":"American Goldfinch","code":"amegfi"}`
+
+	// A dry run only reports the affected detections
+	rec := postTaxonomyRemap(t, controller, `{"mappings":[`+mapping+`],"dry_run":true}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp TaxonomyRemapResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.False(t, resp.Executed)
+	assert.Equal(t, int64(2), resp.Detections)
+	assert.Empty(t, store.remapped)
+
+	rec = postTaxonomyRemap(t, controller, `{"mappings":[`+mapping+`]}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	resp = TaxonomyRemapResponse{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.True(t, resp.Executed)
+	assert.NotEmpty(t, resp.RunID)
+	require.Len(t, store.remapped, 1)
+	assert.Equal(t, "Carduelis tristis", store.remapped[0][0].From)
+	assert.Equal(t, "request", store.sources[0])
+
+	// Mappings read from a file on the server
+	path := filepath.Join(t.TempDir(), "taxonomy.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[`+mapping+`]`), 0o600))
+	file, err := json.Marshal(path)
+	require.NoError(t, err)
+	rec = postTaxonomyRemap(t, controller, `{"mapping_file":`+string(file)+`}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, path, store.sources[1])
+
+	// Without mappings the species alias table is used
+	rec = postTaxonomyRemap(t, controller, `{"dry_run":true}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	resp = TaxonomyRemapResponse{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.NotEmpty(t, resp.Mappings)
+	assert.Contains(t, resp.Skipped, "Western Scrub-Jay", "split species need review")
+
+	rec = postTaxonomyRemap(t, controller, `{"mapping_file":"missing-taxonomy.json"}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	rec = postTaxonomyRemap(t, controller, `{"mapping_file":"x.json","mappings":[`+mapping+`]}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestRevertTaxonomyRemap(t *testing.T) {
+	t.Parallel()
+
+	_, mockDS, controller := setupTestEnvironment(t)
+	store := &taxonomyMockDataStore{MockDataStore: mockDS}
+	controller.DS = store
+
+	revert := func(id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v2/system/taxonomy/remaps/"+id+"/revert", http.NoBody)
+		rec := httptest.NewRecorder()
+		ctx := controller.Echo.NewContext(req, rec)
+		ctx.SetParamNames("id")
+		ctx.SetParamValues(id)
+		require.NoError(t, controller.RevertTaxonomyRemap(ctx))
+		return rec
+	}
+
+	assert.Equal(t, http.StatusOK, revert("run-1").Code)
+	assert.Equal(t, []string{"run-1"}, store.reverted)
+	assert.Equal(t, http.StatusConflict, revert("reverted").Code)
+	assert.Equal(t, http.StatusNotFound, revert("unknown").Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v2/system/taxonomy/remaps", http.NoBody)
+	rec := httptest.NewRecorder()
+	require.NoError(t, controller.GetTaxonomyRemaps(controller.Echo.NewContext(req, rec)))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var runs []TaxonomyRemapRunResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &runs))
+	require.Len(t, runs, 1)
+	assert.Equal(t, "run-1", runs[0].RunID)
+}
+
+func TestRemapTaxonomy_Unsupported(t *testing.T) {
+	t.Parallel()
+
+	_, _, controller := setupTestEnvironment(t)
+	req := httptest.NewRequest(http.MethodGet, "/api/v2/system/taxonomy/remaps", http.NoBody)
+	rec := httptest.NewRecorder()
+	require.NoError(t, controller.GetTaxonomyRemaps(controller.Echo.NewContext(req, rec)))
+	assert.Equal(t, http.StatusNotImplemented, rec.Code)
+}
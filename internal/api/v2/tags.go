@@ -0,0 +1,247 @@
+// internal/api/v2/tags.go
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// maxTagsPerRequest is the most tags attached to a detection in one request
+const maxTagsPerRequest = 32
+
+// TagStore is implemented by datastores that keep tags attached to detections
+type TagStore interface {
+	CreateTag(name string) (*datastore.Tag, error)
+	GetTags() ([]datastore.TagUsage, error)
+	DeleteTag(id uint) error
+	GetNoteTags(noteID uint) ([]datastore.Tag, error)
+	AddNoteTags(noteID uint, tagIDs []uint) error
+	RemoveNoteTag(noteID, tagID uint) error
+}
+
+// TagRequest creates a tag
+type TagRequest struct {
+	Name string `json:"name"`
+}
+
+// DetectionTagsRequest attaches tags to a detection
+type DetectionTagsRequest struct {
+	TagIDs []uint `json:"tagIds"`
+}
+
+// TagResponse is a tag, with the number of detections it is attached to when listed
+type TagResponse struct {
+	ID         uint      `json:"id"`
+	Name       string    `json:"name"`
+	Detections *int64    `json:"detections,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// initTagRoutes registers the tag endpoints. Tags are read publicly like
+// detections; creating and attaching them requires authentication.
+func (c *Controller) initTagRoutes() {
+	c.Group.GET("/tags", c.ListTags)
+	c.Group.GET("/detections/:id/tags", c.GetDetectionTags)
+
+	protected := []echo.MiddlewareFunc{c.getEffectiveAuthMiddleware()}
+	c.Group.POST("/tags", c.CreateTag, append(protected, c.IdempotencyMiddleware)...)
+	c.Group.DELETE("/tags/:id", c.DeleteTag, protected...)
+	c.Group.POST("/detections/:id/tags", c.AddDetectionTags, append(protected, c.IdempotencyMiddleware)...)
+	c.Group.DELETE("/detections/:id/tags/:tagId", c.RemoveDetectionTag, protected...)
+}
+
+// errTagsUnsupported reports that the datastore keeps no tags
+func (c *Controller) errTagsUnsupported(ctx echo.Context) error {
+	return c.HandleError(ctx, errors.Newf("datastore does not support tags").
+		Component("api").
+		Category(errors.CategoryConfiguration).
+		Build(), "Tags are not supported by this datastore", http.StatusNotImplemented)
+}
+
+// tagResponses converts tags attached to a detection
+func tagResponses(tags []datastore.Tag) []TagResponse {
+	resp := make([]TagResponse, 0, len(tags))
+	for i := range tags {
+		resp = append(resp, TagResponse{ID: tags[i].ID, Name: tags[i].Name, CreatedAt: tags[i].CreatedAt})
+	}
+	return resp
+}
+
+// parseIDParam parses a numeric path parameter, or returns false with the
+// error response sent
+func (c *Controller) parseIDParam(ctx echo.Context, name, label string) (uint, bool, error) {
+	id, err := strconv.ParseUint(ctx.Param(name), 10, 32)
+	if err != nil || id == 0 {
+		return 0, false, c.HandleError(ctx, errors.Newf("invalid %s %q", label, ctx.Param(name)).
+			Component("api").
+			Category(errors.CategoryValidation).
+			Build(), "Invalid "+label, http.StatusBadRequest)
+	}
+	return uint(id), true, nil
+}
+
+// ListTags handles GET /api/v2/tags
+// It returns all tags ordered by name with the number of detections each is attached to.
+func (c *Controller) ListTags(ctx echo.Context) error {
+	store, ok := c.DS.(TagStore)
+	if !ok {
+		return c.errTagsUnsupported(ctx)
+	}
+	tags, err := store.GetTags()
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to get tags", http.StatusInternalServerError)
+	}
+	resp := make([]TagResponse, 0, len(tags))
+	for i := range tags {
+		resp = append(resp, TagResponse{
+			ID:         tags[i].ID,
+			Name:       tags[i].Name,
+			Detections: &tags[i].Detections,
+			CreatedAt:  tags[i].CreatedAt,
+		})
+	}
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+// CreateTag handles POST /api/v2/tags
+func (c *Controller) CreateTag(ctx echo.Context) error {
+	store, ok := c.DS.(TagStore)
+	if !ok {
+		return c.errTagsUnsupported(ctx)
+	}
+	var req TagRequest
+	if err := ctx.Bind(&req); err != nil {
+		return c.HandleError(ctx, err, "Invalid request body", http.StatusBadRequest)
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > 64 || strings.Contains(req.Name, `"`) {
+		return c.HandleError(ctx, errors.Newf("invalid tag name %q", req.Name).
+			Component("api").
+			Category(errors.CategoryValidation).
+			Build(), "Name is required, must be at most 64 characters and cannot contain quotes", http.StatusBadRequest)
+	}
+
+	tag, err := store.CreateTag(req.Name)
+	if err != nil {
+		if errors.Is(err, datastore.ErrTagExists) {
+			return c.HandleError(ctx, err, "A tag with this name already exists", http.StatusConflict)
+		}
+		return c.HandleError(ctx, err, "Failed to create tag", http.StatusInternalServerError)
+	}
+	c.logAPIRequest(ctx, slog.LevelInfo, "Tag created", "id", tag.ID, "name", tag.Name)
+	return ctx.JSON(http.StatusCreated, TagResponse{ID: tag.ID, Name: tag.Name, CreatedAt: tag.CreatedAt})
+}
+
+// DeleteTag handles DELETE /api/v2/tags/:id
+// The tag is detached from all detections.
+func (c *Controller) DeleteTag(ctx echo.Context) error {
+	store, ok := c.DS.(TagStore)
+	if !ok {
+		return c.errTagsUnsupported(ctx)
+	}
+	id, ok, err := c.parseIDParam(ctx, "id", "tag ID")
+	if !ok {
+		return err
+	}
+	if err := store.DeleteTag(id); err != nil {
+		if errors.Is(err, datastore.ErrTagNotFound) {
+			return c.HandleError(ctx, err, "Tag not found", http.StatusNotFound)
+		}
+		return c.HandleError(ctx, err, "Failed to delete tag", http.StatusInternalServerError)
+	}
+	c.invalidateDetectionCache()
+	c.logAPIRequest(ctx, slog.LevelInfo, "Tag deleted", "id", id)
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// GetDetectionTags handles GET /api/v2/detections/:id/tags
+func (c *Controller) GetDetectionTags(ctx echo.Context) error {
+	store, ok := c.DS.(TagStore)
+	if !ok {
+		return c.errTagsUnsupported(ctx)
+	}
+	note, err := c.DS.Get(ctx.Param("id"))
+	if err != nil {
+		return c.HandleError(ctx, err, "Detection not found", http.StatusNotFound)
+	}
+	tags, err := store.GetNoteTags(note.ID)
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to get tags", http.StatusInternalServerError)
+	}
+	return ctx.JSON(http.StatusOK, tagResponses(tags))
+}
+
+// AddDetectionTags handles POST /api/v2/detections/:id/tags
+// It attaches existing tags to a detection and returns all its tags. Tags
+// already attached are kept.
+func (c *Controller) AddDetectionTags(ctx echo.Context) error {
+	store, ok := c.DS.(TagStore)
+	if !ok {
+		return c.errTagsUnsupported(ctx)
+	}
+	var req DetectionTagsRequest
+	if err := ctx.Bind(&req); err != nil {
+		return c.HandleError(ctx, err, "Invalid request body", http.StatusBadRequest)
+	}
+	if len(req.TagIDs) == 0 || len(req.TagIDs) > maxTagsPerRequest {
+		return c.HandleError(ctx, errors.Newf("%d tags requested", len(req.TagIDs)).
+			Component("api").
+			Category(errors.CategoryValidation).
+			Build(), "tagIds must list 1 to "+strconv.Itoa(maxTagsPerRequest)+" tags", http.StatusBadRequest)
+	}
+	note, err := c.DS.Get(ctx.Param("id"))
+	if err != nil {
+		return c.HandleError(ctx, err, "Detection not found", http.StatusNotFound)
+	}
+
+	if err := store.AddNoteTags(note.ID, req.TagIDs); err != nil {
+		if errors.Is(err, datastore.ErrTagNotFound) {
+			return c.HandleError(ctx, err, "Tag not found", http.StatusNotFound)
+		}
+		return c.HandleError(ctx, err, "Failed to add tags", http.StatusInternalServerError)
+	}
+	c.invalidateDetectionCache()
+	c.logAPIRequest(ctx, slog.LevelInfo, "Detection tagged",
+		"detection_id", note.ID,
+		"tag_ids", req.TagIDs)
+
+	tags, err := store.GetNoteTags(note.ID)
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to get tags", http.StatusInternalServerError)
+	}
+	return ctx.JSON(http.StatusOK, tagResponses(tags))
+}
+
+// RemoveDetectionTag handles DELETE /api/v2/detections/:id/tags/:tagId
+func (c *Controller) RemoveDetectionTag(ctx echo.Context) error {
+	store, ok := c.DS.(TagStore)
+	if !ok {
+		return c.errTagsUnsupported(ctx)
+	}
+	noteID, ok, err := c.parseIDParam(ctx, "id", "detection ID")
+	if !ok {
+		return err
+	}
+	tagID, ok, err := c.parseIDParam(ctx, "tagId", "tag ID")
+	if !ok {
+		return err
+	}
+	if err := store.RemoveNoteTag(noteID, tagID); err != nil {
+		if errors.Is(err, datastore.ErrTagNotFound) {
+			return c.HandleError(ctx, err, "Tag is not attached to the detection", http.StatusNotFound)
+		}
+		return c.HandleError(ctx, err, "Failed to remove tag", http.StatusInternalServerError)
+	}
+	c.invalidateDetectionCache()
+	c.logAPIRequest(ctx, slog.LevelInfo, "Detection tag removed",
+		"detection_id", noteID,
+		"tag_id", tagID)
+	return ctx.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+// tagMockDataStore adds tags to MockDataStore
+type tagMockDataStore struct {
+	*MockDataStore
+	tags     []datastore.Tag
+	attached map[uint][]uint // tag IDs by note ID
+}
+
+func (m *tagMockDataStore) CreateTag(name string) (*datastore.Tag, error) {
+	for i := range m.tags {
+		if strings.EqualFold(m.tags[i].Name, name) {
+			return nil, datastore.ErrTagExists
+		}
+	}
+	m.tags = append(m.tags, datastore.Tag{ID: uint(len(m.tags) + 1), Name: name})
+	return &m.tags[len(m.tags)-1], nil
+}
+
+func (m *tagMockDataStore) GetTags() ([]datastore.TagUsage, error) {
+	usage := make([]datastore.TagUsage, 0, len(m.tags))
+	for _, tag := range m.tags {
+		var detections int64
+		for _, ids := range m.attached {
+			for _, id := range ids {
+				if id == tag.ID {
+					detections++
+				}
+			}
+		}
+		usage = append(usage, datastore.TagUsage{ID: tag.ID, Name: tag.Name, Detections: detections})
+	}
+	return usage, nil
+}
+
+func (m *tagMockDataStore) DeleteTag(id uint) error {
+	for i := range m.tags {
+		if m.tags[i].ID == id {
+			m.tags = append(m.tags[:i], m.tags[i+1:]...)
+			return nil
+		}
+	}
+	return datastore.ErrTagNotFound
+}
+
+func (m *tagMockDataStore) GetNoteTags(noteID uint) ([]datastore.Tag, error) {
+	var tags []datastore.Tag
+	for _, id := range m.attached[noteID] {
+		tags = append(tags, m.tags[id-1])
+	}
+	return tags, nil
+}
+
+func (m *tagMockDataStore) AddNoteTags(noteID uint, tagIDs []uint) error {
+	for _, id := range tagIDs {
+		if id == 0 || int(id) > len(m.tags) {
+			return datastore.ErrTagNotFound
+		}
+	}
+	m.attached[noteID] = append(m.attached[noteID], tagIDs...)
+	return nil
+}
+
+func (m *tagMockDataStore) RemoveNoteTag(noteID, tagID uint) error {
+	for i, id := range m.attached[noteID] {
+		if id == tagID {
+			m.attached[noteID] = append(m.attached[noteID][:i], m.attached[noteID][i+1:]...)
+			return nil
+		}
+	}
+	return datastore.ErrTagNotFound
+}
+
+func TestTags(t *testing.T) {
+	t.Parallel()
+
+	_, mockDS, controller := setupTestEnvironment(t)
+	store := &tagMockDataStore{MockDataStore: mockDS, attached: map[uint][]uint{}}
+	controller.DS = store
+	mockDS.On("Get", "1").Return(datastore.Note{ID: 1}, nil)
+
+	call := func(handler echo.HandlerFunc, method, body string, params ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/v2/tags", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		ctx := controller.Echo.NewContext(req, rec)
+		var names, values []string
+		for i := 0; i+1 < len(params); i += 2 {
+			names, values = append(names, params[i]), append(values, params[i+1])
+		}
+		ctx.SetParamNames(names...)
+		ctx.SetParamValues(values...)
+		require.NoError(t, handler(ctx))
+		return rec
+	}
+
+	rec := call(controller.CreateTag, http.MethodPost, `{"name":" nestbox "}`)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	var created TagResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	assert.Equal(t, "nestbox", created.Name)
+	assert.Nil(t, created.Detections)
+	assert.Equal(t, http.StatusConflict, call(controller.CreateTag, http.MethodPost, `{"name":"Nestbox"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, call(controller.CreateTag, http.MethodPost, `{"name":"say \"hi\""}`).Code)
+	require.Equal(t, http.StatusCreated, call(controller.CreateTag, http.MethodPost, `{"name":"banded bird"}`).Code)
+
+	rec = call(controller.AddDetectionTags, http.MethodPost, `{"tagIds":[1,2]}`, "id", "1")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var tags []TagResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &tags))
+	require.Len(t, tags, 2)
+	assert.Equal(t, http.StatusNotFound, call(controller.AddDetectionTags, http.MethodPost, `{"tagIds":[7]}`, "id", "1").Code)
+	assert.Equal(t, http.StatusBadRequest, call(controller.AddDetectionTags, http.MethodPost, `{"tagIds":[]}`, "id", "1").Code)
+
+	rec = call(controller.ListTags, http.MethodGet, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &tags))
+	require.Len(t, tags, 2)
+	require.NotNil(t, tags[0].Detections)
+	assert.Equal(t, int64(1), *tags[0].Detections)
+
+	assert.Equal(t, http.StatusNoContent, call(controller.RemoveDetectionTag, http.MethodDelete, "", "id", "1", "tagId", "2").Code)
+	assert.Equal(t, http.StatusNotFound, call(controller.RemoveDetectionTag, http.MethodDelete, "", "id", "1", "tagId", "2").Code)
+	assert.Equal(t, http.StatusBadRequest, call(controller.RemoveDetectionTag, http.MethodDelete, "", "id", "1", "tagId", "x").Code)
+	rec = call(controller.GetDetectionTags, http.MethodGet, "", "id", "1")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &tags))
+	require.Len(t, tags, 1)
+	assert.Equal(t, "nestbox", tags[0].Name)
+
+	assert.Equal(t, http.StatusNoContent, call(controller.DeleteTag, http.MethodDelete, "", "id", "2").Code)
+	assert.Equal(t, http.StatusNotFound, call(controller.DeleteTag, http.MethodDelete, "", "id", "2").Code)
+
+	controller.DS = mockDS
+	assert.Equal(t, http.StatusNotImplemented, call(controller.ListTags, http.MethodGet, "").Code)
+}
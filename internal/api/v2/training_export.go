@@ -0,0 +1,325 @@
+// internal/api/v2/training_export.go
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// Training data export limits and defaults
+const (
+	defaultTrainingExportLimit = 1000
+	maxTrainingExportLimit     = 10000
+	trainingClipSeconds        = 3.0     // length of the BirdNET analysis window
+	trainingSampleRate         = 48000   // sample rate of BirdNET models
+	trainingNegativeLabel      = "Noise" // class folder treated as non-event by the BirdNET trainer
+)
+
+// TrainingExportRequest selects the reviewed detections of a training data
+// export. Only detections verified as correct are exported as samples of
+// their species; false positives are exported as negative samples on request.
+type TrainingExportRequest struct {
+	Species       []string `json:"species"`       // scientific names or species codes
+	StartDate     string   `json:"startDate"`     // YYYY-MM-DD
+	EndDate       string   `json:"endDate"`       // YYYY-MM-DD
+	MinConfidence float64  `json:"minConfidence"` // percent
+	Limit         int      `json:"limit"`         // default 1000, at most 10000
+	Negatives     bool     `json:"negatives"`     // true to export false positives into the Noise class
+	OffsetSeconds *float64 `json:"offsetSeconds"` // start of the detection within the clip, default the pre-capture length
+
+	offset float64 // resolved detection offset
+}
+
+var trainingExports = &exportJobs{jobs: make(map[string]*ExportJob)}
+
+// extractTrainingClip cuts a training sample from an audio clip, replaceable
+// in tests
+var extractTrainingClip = extractTrainingClipFFmpeg
+
+// StartTrainingExport handles POST /api/v2/training/export
+// It starts exporting 3 second clips of reviewed detections into a zip file
+// with one folder per species, named "<scientific name>_<common name>" as
+// expected by the BirdNET trainer. The job status can be polled until the zip
+// is ready for download. Only one training export runs at a time.
+func (c *Controller) StartTrainingExport(ctx echo.Context) error {
+	var req TrainingExportRequest
+	if err := ctx.Bind(&req); err != nil {
+		return c.HandleError(ctx, err, "Invalid request body", http.StatusBadRequest)
+	}
+	if err := req.normalize(&c.Settings.Realtime.Audio.Export); err != nil {
+		return c.HandleError(ctx, err, err.Error(), http.StatusBadRequest)
+	}
+
+	notes, err := c.trainingNotes(&req)
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to select detections", http.StatusInternalServerError)
+	}
+	if len(notes) == 0 {
+		return c.HandleError(ctx, errors.Newf("no reviewed detections match the export filters").
+			Component("api").
+			Category(errors.CategoryNotFound).
+			Build(), "No reviewed detections with audio clips match the filters", http.StatusNotFound)
+	}
+
+	job, ok := trainingExports.start(len(notes))
+	if !ok {
+		return c.HandleError(ctx, errors.Newf("a training data export is already running").
+			Component("api").
+			Category(errors.CategoryConflict).
+			Build(), "Another training data export is still running", http.StatusConflict)
+	}
+
+	jobCtx := c.ctx
+	if jobCtx == nil {
+		jobCtx = context.Background()
+	}
+	c.wg.Go(func() {
+		c.runTrainingExport(jobCtx, job.ID, notes, &req)
+	})
+
+	snapshot, _ := trainingExports.get(job.ID)
+	return ctx.JSON(http.StatusAccepted, snapshot)
+}
+
+// GetTrainingExport handles GET /api/v2/training/export/:id
+func (c *Controller) GetTrainingExport(ctx echo.Context) error {
+	job, ok := trainingExports.get(ctx.Param("id"))
+	if !ok {
+		return c.HandleError(ctx, errors.Newf("training data export %s not found", ctx.Param("id")).
+			Component("api").
+			Category(errors.CategoryNotFound).
+			Build(), "Training data export not found", http.StatusNotFound)
+	}
+	return ctx.JSON(http.StatusOK, job)
+}
+
+// DownloadTrainingExport handles GET /api/v2/training/export/:id/download
+func (c *Controller) DownloadTrainingExport(ctx echo.Context) error {
+	job, ok := trainingExports.get(ctx.Param("id"))
+	if !ok || job.Status != exportStatusCompleted {
+		return c.HandleError(ctx, errors.Newf("training data export %s not available", ctx.Param("id")).
+			Component("api").
+			Category(errors.CategoryNotFound).
+			Build(), "Training data export not found or not completed", http.StatusNotFound)
+	}
+	return ctx.Attachment(job.path, fmt.Sprintf("training-data-%s.zip", job.CreatedAt.Format("20060102-150405")))
+}
+
+// normalize applies defaults and validates the request. The detection offset
+// defaults to the pre-capture length and is limited so that the sample fits
+// within the saved clip.
+func (req *TrainingExportRequest) normalize(export *conf.ExportSettings) error {
+	invalid := func(format string, args ...any) error {
+		return errors.Newf(format, args...).
+			Component("api").
+			Category(errors.CategoryValidation).
+			Build()
+	}
+
+	if req.Limit == 0 {
+		req.Limit = defaultTrainingExportLimit
+	}
+	if req.Limit < 0 || req.Limit > maxTrainingExportLimit {
+		return invalid("limit must be between 1 and %d", maxTrainingExportLimit)
+	}
+	for _, date := range []string{req.StartDate, req.EndDate} {
+		if _, err := time.Parse(time.DateOnly, date); date != "" && err != nil {
+			return invalid("dates must use the YYYY-MM-DD format")
+		}
+	}
+	if req.MinConfidence < 0 || req.MinConfidence > 100 {
+		return invalid("minConfidence must be a percentage between 0 and 100")
+	}
+
+	req.offset = float64(export.PreCapture)
+	if req.OffsetSeconds != nil {
+		if *req.OffsetSeconds < 0 {
+			return invalid("offsetSeconds must not be negative")
+		}
+		req.offset = *req.OffsetSeconds
+	}
+	if clipLength := float64(export.Length); clipLength >= trainingClipSeconds {
+		req.offset = min(req.offset, clipLength-trainingClipSeconds)
+	}
+	return nil
+}
+
+// trainingNotes returns the reviewed detections with audio clips selected by
+// a training export request
+func (c *Controller) trainingNotes(req *TrainingExportRequest) ([]datastore.Note, error) {
+	verified := true
+	filters := c.exportSearchFilters(req.Species, req.StartDate, req.EndDate, req.MinConfidence, &verified, req.Limit)
+	notes, _, err := c.DS.SearchNotesAdvanced(&filters)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := notes[:0]
+	for i := range notes {
+		if notes[i].ClipName != "" && trainingLabel(&notes[i], req.Negatives) != "" {
+			selected = append(selected, notes[i])
+		}
+	}
+	return selected, nil
+}
+
+// trainingLabel returns the class folder of a reviewed detection, empty when
+// the detection is not exported
+func trainingLabel(note *datastore.Note, negatives bool) string {
+	verified := note.Verified
+	if verified == "" && note.Review != nil {
+		verified = note.Review.Verified
+	}
+	switch verified {
+	case "correct":
+		// The trainer splits folder names at the first underscore
+		return strings.NewReplacer("/", "-", "\\", "-").Replace(note.ScientificName + "_" + note.CommonName)
+	case "false_positive":
+		if negatives {
+			return trainingNegativeLabel
+		}
+	}
+	return ""
+}
+
+// runTrainingExport writes the samples of a training export into a zip file
+func (c *Controller) runTrainingExport(ctx context.Context, jobID string, notes []datastore.Note, req *TrainingExportRequest) {
+	zipPath := filepath.Join(os.TempDir(), fmt.Sprintf("birdnet-go-training-%s.zip", jobID))
+	err := c.writeTrainingExport(ctx, jobID, zipPath, notes, req)
+	downloadURL := fmt.Sprintf("/api/v2/training/export/%s/download", jobID)
+	var checksum string
+	if err == nil {
+		checksum = c.recordExport(zipPath, downloadURL)
+	}
+	trainingExports.update(jobID, func(job *ExportJob) {
+		if err != nil {
+			job.Status = exportStatusFailed
+			job.Error = err.Error()
+			return
+		}
+		job.Status = exportStatusCompleted
+		job.path = zipPath
+		job.DownloadURL = downloadURL
+		job.SHA256 = checksum
+	})
+	if err != nil {
+		_ = os.Remove(zipPath)
+		if c.apiLogger != nil {
+			c.apiLogger.Error("Training data export failed", "job_id", jobID, "error", err)
+		}
+	}
+}
+
+// writeTrainingExport writes the zip file of a training export with one
+// folder per class and a manifest.csv describing each sample
+func (c *Controller) writeTrainingExport(ctx context.Context, jobID, zipPath string, notes []datastore.Note, req *TrainingExportRequest) error {
+	workDir, err := os.MkdirTemp("", "birdnet-go-training-export-")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.RemoveAll(workDir) }()
+
+	file, err := os.OpenFile(zipPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = file.Close() }()
+	archive := zip.NewWriter(file)
+
+	var manifest bytes.Buffer
+	csvWriter := csv.NewWriter(&manifest)
+	_ = csvWriter.Write([]string{"id", "file", "label", "scientific_name", "common_name", "date", "time", "confidence", "error"})
+
+	names := strings.NewReplacer(":", "-", "/", "-")
+	for i := range notes {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		note := &notes[i]
+		label := trainingLabel(note, req.Negatives)
+		name := fmt.Sprintf("%s/%d_%s_%s.wav", label, note.ID, note.Date, names.Replace(note.Time))
+		samplePath := filepath.Join(workDir, fmt.Sprintf("%d.wav", note.ID))
+
+		exportErr := c.exportTrainingSample(ctx, note, samplePath, req)
+		if exportErr == nil {
+			exportErr = addFileToZip(archive, samplePath, name)
+			_ = os.Remove(samplePath)
+		}
+		record := []string{
+			strconv.FormatUint(uint64(note.ID), 10), name, label, note.ScientificName, note.CommonName,
+			note.Date, note.Time, strconv.FormatFloat(note.Confidence, 'f', 4, 64), "",
+		}
+		if exportErr != nil {
+			record[1], record[8] = "", exportErr.Error()
+		}
+		_ = csvWriter.Write(record)
+
+		trainingExports.update(jobID, func(job *ExportJob) {
+			if exportErr != nil {
+				job.Failed++
+			} else {
+				job.Rendered++
+			}
+		})
+	}
+
+	if err := c.addStationToZip(archive); err != nil {
+		return err
+	}
+
+	csvWriter.Flush()
+	w, err := archive.Create("manifest.csv")
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(manifest.Bytes()); err != nil {
+		return err
+	}
+	if err := archive.Close(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// exportTrainingSample cuts the training sample of one detection
+func (c *Controller) exportTrainingSample(ctx context.Context, note *datastore.Note, samplePath string, req *TrainingExportRequest) error {
+	absAudioPath, cleanup, err := c.exportClipPath(note)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	return extractTrainingClip(ctx, c.Settings, absAudioPath, samplePath, req.offset)
+}
+
+// extractTrainingClipFFmpeg cuts a 3 second mono 48 kHz WAV sample starting
+// at offset seconds into an audio clip
+func extractTrainingClipFFmpeg(ctx context.Context, settings *conf.Settings, absAudioPath, absSamplePath string, offset float64) error {
+	return runExportFFmpeg(ctx, settings, trainingClipArgs(absAudioPath, absSamplePath, offset))
+}
+
+// trainingClipArgs returns the FFmpeg arguments cutting a training sample
+func trainingClipArgs(absAudioPath, absSamplePath string, offset float64) []string {
+	return []string{
+		"-hide_banner", "-y",
+		"-ss", strconv.FormatFloat(offset, 'f', 3, 64),
+		"-t", strconv.FormatFloat(trainingClipSeconds, 'f', 3, 64),
+		"-i", absAudioPath,
+		"-ac", "1", "-ar", strconv.Itoa(trainingSampleRate),
+		"-c:a", "pcm_s16le",
+		absSamplePath,
+	}
+}
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+func TestTrainingExportRequestNormalize(t *testing.T) {
+	t.Parallel()
+
+	export := conf.ExportSettings{Length: 15, PreCapture: 3}
+	req := TrainingExportRequest{}
+	require.NoError(t, req.normalize(&export))
+	assert.Equal(t, defaultTrainingExportLimit, req.Limit)
+	assert.InDelta(t, 3.0, req.offset, 0.001, "offset should default to the pre-capture length")
+
+	offset := 14.0
+	req = TrainingExportRequest{OffsetSeconds: &offset}
+	require.NoError(t, req.normalize(&export))
+	assert.InDelta(t, 12.0, req.offset, 0.001, "sample should fit within the clip")
+
+	negative := -1.0
+	invalid := map[string]TrainingExportRequest{
+		"limit":      {Limit: maxTrainingExportLimit + 1},
+		"date":       {EndDate: "2024/05/01"},
+		"confidence": {MinConfidence: -5},
+		"offset":     {OffsetSeconds: &negative},
+	}
+	for name, req := range invalid {
+		assert.Error(t, req.normalize(&export), name)
+	}
+}
+
+func TestTrainingLabel(t *testing.T) {
+	t.Parallel()
+
+	correct := &datastore.Note{ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", Verified: "correct"}
+	assert.Equal(t, "Turdus merula_Eurasian Blackbird", trainingLabel(correct, false))
+
+	falsePositive := &datastore.Note{ScientificName: "Turdus merula", Review: &datastore.NoteReview{Verified: "false_positive"}}
+	assert.Empty(t, trainingLabel(falsePositive, false))
+	assert.Equal(t, trainingNegativeLabel, trainingLabel(falsePositive, true))
+
+	assert.Empty(t, trainingLabel(&datastore.Note{ScientificName: "Pica pica"}, true), "unreviewed detections are not exported")
+}
+
+func TestTrainingClipArgs(t *testing.T) {
+	t.Parallel()
+
+	args := strings.Join(trainingClipArgs("in.wav", "out.wav", 3), " ")
+	assert.Contains(t, args, "-ss 3.000 -t 3.000 -i in.wav")
+	assert.Contains(t, args, "-ac 1 -ar 48000")
+}
+
+// TestTrainingExport runs an export with a fake clip extractor. It replaces
+// the package level extractor and job registry so it does not run in parallel.
+func TestTrainingExport(t *testing.T) {
+	e, controller, tempDir := setupMediaTestEnvironment(t)
+	mockDS := controller.DS.(*MockDataStore)
+	controller.Settings.Realtime.Audio.Export.Length = 15
+	controller.Settings.Realtime.Audio.Export.PreCapture = 3
+
+	require.NoError(t, createTestAudioFile(t, filepath.Join(tempDir, "blackbird.wav")))
+	require.NoError(t, createTestAudioFile(t, filepath.Join(tempDir, "noise.wav")))
+	notes := []datastore.Note{
+		{ID: 1, Date: "2024-05-01", Time: "06:10:00", ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", ClipName: "blackbird.wav", Verified: "correct"},
+		{ID: 2, Date: "2024-05-01", Time: "06:20:00", ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", ClipName: "noise.wav", Verified: "false_positive"},
+		{ID: 3, Date: "2024-05-01", Time: "06:30:00", ScientificName: "Pica pica", CommonName: "Eurasian Magpie", Verified: "correct"},
+	}
+	mockDS.On("SearchNotesAdvanced", mock.MatchedBy(func(f *datastore.AdvancedSearchFilters) bool {
+		return f.Verified != nil && *f.Verified && len(f.Species) == 1
+	})).Return(notes, int64(len(notes)), nil)
+
+	var offsets []float64
+	origExtractor := extractTrainingClip
+	extractTrainingClip = func(_ context.Context, _ *conf.Settings, absAudioPath, absSamplePath string, offset float64) error {
+		offsets = append(offsets, offset)
+		return os.WriteFile(absSamplePath, []byte(filepath.Base(absAudioPath)), 0o600)
+	}
+	origExports := trainingExports
+	trainingExports = &exportJobs{jobs: make(map[string]*ExportJob)}
+	t.Cleanup(func() {
+		extractTrainingClip = origExtractor
+		trainingExports = origExports
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/training/export",
+		strings.NewReader(`{"species":["Turdus merula"],"negatives":true}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	require.NoError(t, controller.StartTrainingExport(e.NewContext(req, rec)))
+	require.Equal(t, http.StatusAccepted, rec.Code)
+
+	var job ExportJob
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &job))
+	assert.Equal(t, 2, job.Total, "detections without clips should be skipped")
+
+	require.Eventually(t, func() bool {
+		current, _ := trainingExports.get(job.ID)
+		return current.Status != exportStatusRunning
+	}, 5*time.Second, 10*time.Millisecond)
+
+	request := func(id string, handler echo.HandlerFunc) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v2/training/export/"+id, http.NoBody)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(id)
+		require.NoError(t, handler(c))
+		return rec
+	}
+	status, download := controller.GetTrainingExport, controller.DownloadTrainingExport
+
+	rec = request(job.ID, status)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &job))
+	assert.Equal(t, exportStatusCompleted, job.Status)
+	assert.Equal(t, 2, job.Rendered)
+	assert.Equal(t, []float64{3, 3}, offsets)
+
+	rec = request(job.ID, download)
+	require.Equal(t, http.StatusOK, rec.Code)
+	archive, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+	require.NoError(t, err)
+	var names []string
+	for _, f := range archive.File {
+		names = append(names, f.Name)
+	}
+	assert.ElementsMatch(t, []string{
+		"Turdus merula_Eurasian Blackbird/1_2024-05-01_06-10-00.wav",
+		"Noise/2_2024-05-01_06-20-00.wav",
+		"manifest.csv",
+	}, names)
+
+	assert.Equal(t, http.StatusNotFound, request("unknown", status).Code)
+	assert.Equal(t, http.StatusNotFound, request("unknown", download).Code)
+}
@@ -0,0 +1,195 @@
+// internal/api/v2/trash.go
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// Trash listing limits
+const (
+	defaultTrashLimit = 50
+	maxTrashLimit     = 500
+)
+
+// DetectionTrash is implemented by datastores that move deleted detections to
+// a trash they can be restored from
+type DetectionTrash interface {
+	TrashNote(id string) error
+	GetTrashedNotes(limit, offset int) ([]datastore.TrashedNote, int64, error)
+	RestoreNote(noteID uint) (*datastore.Note, error)
+	PurgeTrash(before time.Time) (*datastore.PurgeResult, error)
+}
+
+// TrashedDetectionResponse is a detection in the trash
+type TrashedDetectionResponse struct {
+	ID             uint      `json:"id"` // ID of the detection, kept on restore
+	Date           string    `json:"date"`
+	Time           string    `json:"time"`
+	ScientificName string    `json:"scientificName"`
+	CommonName     string    `json:"commonName"`
+	Confidence     float64   `json:"confidence"`
+	HasAudio       bool      `json:"hasAudio"`
+	DeletedAt      time.Time `json:"deletedAt"`
+	PurgeAt        time.Time `json:"purgeAt"` // when the detection is removed permanently
+}
+
+// EmptyTrashResponse is the outcome of emptying the trash
+type EmptyTrashResponse struct {
+	Detections   int64 `json:"detections"`
+	FilesRemoved int   `json:"files_removed"`
+	FilesFailed  int   `json:"files_failed"`
+}
+
+// initTrashRoutes registers the detection trash endpoints
+func (c *Controller) initTrashRoutes() {
+	trashGroup := c.Group.Group("/detections", c.AuthMiddleware)
+	trashGroup.GET("/trash", c.GetTrashedDetections)
+	trashGroup.DELETE("/trash", c.EmptyTrash)
+	trashGroup.POST("/:id/restore", c.RestoreDetection, c.IdempotencyMiddleware)
+}
+
+// detectionTrash returns the trash of the datastore, or false when deleted
+// detections are removed permanently
+func (c *Controller) detectionTrash() (DetectionTrash, bool) {
+	if c.Settings == nil || !c.Settings.Trash.Enabled {
+		return nil, false
+	}
+	trash, ok := c.DS.(DetectionTrash)
+	return trash, ok
+}
+
+// deleteNote moves a detection to the trash, or removes it when the trash is
+// disabled or permanent is set
+func (c *Controller) deleteNote(id string, permanent bool) error {
+	if trash, ok := c.detectionTrash(); ok && !permanent {
+		return trash.TrashNote(id)
+	}
+	return c.DS.Delete(id)
+}
+
+// errTrashUnsupported reports that deleted detections are not kept
+func (c *Controller) errTrashUnsupported(ctx echo.Context) error {
+	return c.HandleError(ctx, errors.Newf("detection trash is disabled or not supported by the datastore").
+		Component("api").
+		Category(errors.CategoryConfiguration).
+		Build(), "The detection trash is not enabled", http.StatusNotImplemented)
+}
+
+// GetTrashedDetections handles GET /api/v2/detections/trash
+// It lists the deleted detections that can still be restored, most recently
+// deleted first.
+func (c *Controller) GetTrashedDetections(ctx echo.Context) error {
+	trash, ok := c.detectionTrash()
+	if !ok {
+		return c.errTrashUnsupported(ctx)
+	}
+
+	limit, offset := defaultTrashLimit, 0
+	var err error
+	if value := ctx.QueryParam("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit < 1 || limit > maxTrashLimit {
+			return c.HandleError(ctx, errors.Newf("invalid limit %q", value).
+				Component("api").
+				Category(errors.CategoryValidation).
+				Build(), "limit must be between 1 and 500", http.StatusBadRequest)
+		}
+	}
+	if value := ctx.QueryParam("offset"); value != "" {
+		if offset, err = strconv.Atoi(value); err != nil || offset < 0 {
+			return c.HandleError(ctx, errors.Newf("invalid offset %q", value).
+				Component("api").
+				Category(errors.CategoryValidation).
+				Build(), "offset must be a non-negative number", http.StatusBadRequest)
+		}
+	}
+
+	trashed, total, err := trash.GetTrashedNotes(limit, offset)
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to list the trash", http.StatusInternalServerError)
+	}
+
+	retention := time.Duration(c.Settings.Trash.RetentionDays) * 24 * time.Hour
+	detections := make([]TrashedDetectionResponse, 0, len(trashed))
+	for i := range trashed {
+		t := &trashed[i]
+		detections = append(detections, TrashedDetectionResponse{
+			ID:             t.NoteID,
+			Date:           t.Date,
+			Time:           t.Time,
+			ScientificName: t.ScientificName,
+			CommonName:     t.CommonName,
+			Confidence:     t.Confidence,
+			HasAudio:       t.ClipName != "",
+			DeletedAt:      t.DeletedAt,
+			PurgeAt:        t.DeletedAt.Add(retention),
+		})
+	}
+
+	return ctx.JSON(http.StatusOK, PaginatedResponse{
+		Data:        detections,
+		Total:       total,
+		Limit:       limit,
+		Offset:      offset,
+		CurrentPage: offset/limit + 1,
+		TotalPages:  int((total + int64(limit) - 1) / int64(limit)),
+	})
+}
+
+// RestoreDetection handles POST /api/v2/detections/:id/restore
+// It moves a detection from the trash back to the detections with its ID,
+// results, review and comments.
+func (c *Controller) RestoreDetection(ctx echo.Context) error {
+	trash, ok := c.detectionTrash()
+	if !ok {
+		return c.errTrashUnsupported(ctx)
+	}
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		return c.HandleError(ctx, err, "Invalid detection ID", http.StatusBadRequest)
+	}
+
+	note, err := trash.RestoreNote(uint(id))
+	if err != nil {
+		var enhancedErr *errors.EnhancedError
+		switch {
+		case errors.Is(err, datastore.ErrTrashedNoteNotFound):
+			return c.HandleError(ctx, err, "Detection not found in the trash", http.StatusNotFound)
+		case errors.As(err, &enhancedErr) && enhancedErr.Category == errors.CategoryConflict:
+			return c.HandleError(ctx, err, "A detection with this ID already exists", http.StatusConflict)
+		}
+		return c.HandleError(ctx, err, "Failed to restore detection", http.StatusInternalServerError)
+	}
+
+	c.invalidateDetectionCache()
+	c.logAPIRequest(ctx, slog.LevelInfo, "Restored detection from trash", "detection_id", note.ID)
+	return ctx.JSON(http.StatusOK, c.noteToDetectionResponse(note, false, nil))
+}
+
+// EmptyTrash handles DELETE /api/v2/detections/trash
+// It permanently removes every detection in the trash with its clip and
+// spectrograms.
+func (c *Controller) EmptyTrash(ctx echo.Context) error {
+	trash, ok := c.detectionTrash()
+	if !ok {
+		return c.errTrashUnsupported(ctx)
+	}
+
+	result, err := trash.PurgeTrash(time.Now())
+	if err != nil {
+		return c.HandleError(ctx, err, "Failed to empty the trash", http.StatusInternalServerError)
+	}
+
+	resp := EmptyTrashResponse{Detections: result.Counts.Detections}
+	resp.FilesRemoved, resp.FilesFailed = c.removePurgedFiles(ctx, &datastore.PurgeRequest{}, result.ClipNames)
+	c.logAPIRequest(ctx, slog.LevelInfo, "Emptied detection trash",
+		"detections", resp.Detections,
+		"files_removed", resp.FilesRemoved)
+	return ctx.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+// trashStore keeps trashed notes of the mock datastore in memory
+type trashStore struct {
+	*MockDataStore
+	trash map[uint]datastore.Note
+}
+
+func (s *trashStore) TrashNote(id string) error {
+	note, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+	s.trash[note.ID] = note
+	return nil
+}
+
+func (s *trashStore) GetTrashedNotes(limit, offset int) ([]datastore.TrashedNote, int64, error) {
+	var trashed []datastore.TrashedNote
+	for id, note := range s.trash {
+		trashed = append(trashed, datastore.TrashedNote{NoteID: id, CommonName: note.CommonName, ClipName: note.ClipName, DeletedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)})
+	}
+	return trashed, int64(len(trashed)), nil
+}
+
+func (s *trashStore) RestoreNote(noteID uint) (*datastore.Note, error) {
+	note, ok := s.trash[noteID]
+	if !ok {
+		return nil, datastore.ErrTrashedNoteNotFound
+	}
+	delete(s.trash, noteID)
+	return &note, nil
+}
+
+func (s *trashStore) PurgeTrash(before time.Time) (*datastore.PurgeResult, error) {
+	result := &datastore.PurgeResult{}
+	result.Counts.Detections = int64(len(s.trash))
+	clear(s.trash)
+	return result, nil
+}
+
+// callWithID calls a handler with the id path parameter set
+func callWithID(t *testing.T, controller *Controller, method, id string, handler echo.HandlerFunc) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, "/api/v2/detections/"+id, http.NoBody)
+	rec := httptest.NewRecorder()
+	ctx := controller.Echo.NewContext(req, rec)
+	ctx.SetParamNames("id")
+	ctx.SetParamValues(id)
+	require.NoError(t, handler(ctx))
+	return rec
+}
+
+func TestDetectionTrash(t *testing.T) {
+	t.Parallel()
+
+	_, mockDS, controller := setupTestEnvironment(t)
+	store := &trashStore{MockDataStore: mockDS, trash: make(map[uint]datastore.Note)}
+	controller.DS = store
+	note := datastore.Note{ID: 7, Date: "2024-05-01", Time: "06:00:00", ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", ClipName: "clip.wav"}
+	mockDS.On("Get", "7").Return(note, nil)
+
+	// Without the trash detections cannot be listed or restored
+	rec := callWithID(t, controller, http.MethodPost, "7", controller.RestoreDetection)
+	assert.Equal(t, http.StatusNotImplemented, rec.Code)
+
+	controller.Settings.Trash.Enabled = true
+	controller.Settings.Trash.RetentionDays = 30
+
+	rec = callWithID(t, controller, http.MethodDelete, "7", controller.DeleteDetection)
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Contains(t, store.trash, uint(7), "deleted detections are moved to the trash")
+	mockDS.AssertNotCalled(t, "Delete", "7")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v2/detections/trash", http.NoBody)
+	rec = httptest.NewRecorder()
+	require.NoError(t, controller.GetTrashedDetections(controller.Echo.NewContext(req, rec)))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var page struct {
+		Data  []TrashedDetectionResponse `json:"data"`
+		Total int64                      `json:"total"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+	assert.Equal(t, int64(1), page.Total)
+	require.Len(t, page.Data, 1)
+	assert.Equal(t, uint(7), page.Data[0].ID)
+	assert.True(t, page.Data[0].HasAudio)
+	assert.Equal(t, time.Date(2024, 5, 31, 12, 0, 0, 0, time.UTC), page.Data[0].PurgeAt.UTC())
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v2/detections/trash?limit=1000", http.NoBody)
+	rec = httptest.NewRecorder()
+	require.NoError(t, controller.GetTrashedDetections(controller.Echo.NewContext(req, rec)))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = callWithID(t, controller, http.MethodPost, "7", controller.RestoreDetection)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var restored DetectionResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &restored))
+	assert.Equal(t, uint(7), restored.ID)
+	assert.Empty(t, store.trash)
+
+	rec = callWithID(t, controller, http.MethodPost, strconv.Itoa(7), controller.RestoreDetection)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
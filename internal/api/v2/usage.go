@@ -0,0 +1,293 @@
+// internal/api/v2/usage.go
+package api
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/api/v2/auth"
+	"github.com/tphakala/birdnet-go/internal/errors"
+	"github.com/tphakala/birdnet-go/internal/privacy"
+	"github.com/tphakala/birdnet-go/internal/security"
+)
+
+// API usage metering limits
+const (
+	maxUsageConsumers    = 500 // consumers metered, the least recently seen is forgotten beyond it
+	maxUsageEndpoints    = 200 // endpoints metered per consumer, later ones are counted as "other"
+	defaultUsageTopCount = 10  // endpoints listed in a usage report by default
+	usageOtherEndpoint   = "other"
+)
+
+// API consumer kinds
+const (
+	usageConsumerToken   = "token"   // requests with a valid bearer token
+	usageConsumerAddress = "address" // other requests, by anonymized client address
+)
+
+// APIUsageEndpoint is the usage of one endpoint, a method and route
+type APIUsageEndpoint struct {
+	Endpoint string `json:"endpoint"` // e.g. "GET /api/v2/analytics/species/daily"
+	Requests int64  `json:"requests"`
+	Errors   int64  `json:"errors"`   // responses with status 400 and above
+	BytesIn  int64  `json:"bytesIn"`  // request bodies
+	BytesOut int64  `json:"bytesOut"` // response bodies
+}
+
+// APIConsumerUsage is the API usage of a consumer since the server started
+type APIConsumerUsage struct {
+	ID               string             `json:"id"`
+	Kind             string             `json:"kind"` // "token" or "address"
+	Requests         int64              `json:"requests"`
+	RequestsLastHour int64              `json:"requestsLastHour"`
+	Errors           int64              `json:"errors"`
+	BytesIn          int64              `json:"bytesIn"`
+	BytesOut         int64              `json:"bytesOut"`
+	FirstSeen        time.Time          `json:"firstSeen"`
+	LastSeen         time.Time          `json:"lastSeen"`
+	TopEndpoints     []APIUsageEndpoint `json:"topEndpoints,omitempty"` // most requested first
+}
+
+// consumerUsage is the usage metered for a consumer
+type consumerUsage struct {
+	kind      string
+	total     APIUsageEndpoint
+	endpoints map[string]*APIUsageEndpoint
+	minutes   [60]int64 // requests per minute of the last hour, by minute of the hour
+	minuteAt  [60]int64 // the Unix minute each count is of
+	firstSeen time.Time
+	lastSeen  time.Time
+}
+
+// apiUsageMeter meters the requests, bytes and endpoints of each API
+// consumer in memory, from the start of the server
+type apiUsageMeter struct {
+	mu        sync.Mutex
+	since     time.Time
+	consumers map[string]*consumerUsage
+}
+
+// newAPIUsageMeter returns an empty meter
+func newAPIUsageMeter() *apiUsageMeter {
+	return &apiUsageMeter{since: time.Now(), consumers: make(map[string]*consumerUsage)}
+}
+
+// record counts a request of a consumer to an endpoint
+func (m *apiUsageMeter) record(id, kind, endpoint string, status int, bytesIn, bytesOut int64, at time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	usage, ok := m.consumers[id]
+	if !ok {
+		if len(m.consumers) >= maxUsageConsumers {
+			m.forgetLeastRecent()
+		}
+		usage = &consumerUsage{kind: kind, endpoints: make(map[string]*APIUsageEndpoint), firstSeen: at}
+		m.consumers[id] = usage
+	}
+	usage.lastSeen = at
+
+	minute := at.Unix() / 60
+	slot := minute % 60
+	if usage.minuteAt[slot] != minute {
+		usage.minuteAt[slot] = minute
+		usage.minutes[slot] = 0
+	}
+	usage.minutes[slot]++
+
+	counts, ok := usage.endpoints[endpoint]
+	if !ok {
+		if len(usage.endpoints) >= maxUsageEndpoints {
+			endpoint = usageOtherEndpoint
+			counts = usage.endpoints[endpoint]
+		}
+		if counts == nil {
+			counts = &APIUsageEndpoint{Endpoint: endpoint}
+			usage.endpoints[endpoint] = counts
+		}
+	}
+	for _, c := range []*APIUsageEndpoint{&usage.total, counts} {
+		c.Requests++
+		if status >= http.StatusBadRequest {
+			c.Errors++
+		}
+		c.BytesIn += bytesIn
+		c.BytesOut += bytesOut
+	}
+}
+
+// forgetLeastRecent drops the consumer seen longest ago
+func (m *apiUsageMeter) forgetLeastRecent() {
+	var oldest string
+	for id, usage := range m.consumers {
+		if oldest == "" || usage.lastSeen.Before(m.consumers[oldest].lastSeen) {
+			oldest = id
+		}
+	}
+	delete(m.consumers, oldest)
+}
+
+// report returns the usage of a consumer with its top endpoints, false when
+// it has made no requests
+func (m *apiUsageMeter) report(id string, top int, now time.Time) (APIConsumerUsage, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	usage, ok := m.consumers[id]
+	if !ok {
+		return APIConsumerUsage{}, false
+	}
+	report := usage.summary(id, now)
+	report.TopEndpoints = make([]APIUsageEndpoint, 0, len(usage.endpoints))
+	for _, endpoint := range usage.endpoints {
+		report.TopEndpoints = append(report.TopEndpoints, *endpoint)
+	}
+	slices.SortFunc(report.TopEndpoints, func(a, b APIUsageEndpoint) int {
+		return cmp.Or(cmp.Compare(b.Requests, a.Requests), strings.Compare(a.Endpoint, b.Endpoint))
+	})
+	if len(report.TopEndpoints) > top {
+		report.TopEndpoints = report.TopEndpoints[:top]
+	}
+	return report, true
+}
+
+// list returns the usage of all consumers, the most requests first
+func (m *apiUsageMeter) list(now time.Time) []APIConsumerUsage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	consumers := make([]APIConsumerUsage, 0, len(m.consumers))
+	for id, usage := range m.consumers {
+		consumers = append(consumers, usage.summary(id, now))
+	}
+	slices.SortFunc(consumers, func(a, b APIConsumerUsage) int {
+		return cmp.Or(cmp.Compare(b.Requests, a.Requests), strings.Compare(a.ID, b.ID))
+	})
+	return consumers
+}
+
+// summary returns the totals of a consumer
+func (u *consumerUsage) summary(id string, now time.Time) APIConsumerUsage {
+	summary := APIConsumerUsage{
+		ID:        id,
+		Kind:      u.kind,
+		Requests:  u.total.Requests,
+		Errors:    u.total.Errors,
+		BytesIn:   u.total.BytesIn,
+		BytesOut:  u.total.BytesOut,
+		FirstSeen: u.firstSeen,
+		LastSeen:  u.lastSeen,
+	}
+	current := now.Unix() / 60
+	for slot, minute := range u.minuteAt {
+		if current-minute < 60 {
+			summary.RequestsLastHour += u.minutes[slot]
+		}
+	}
+	return summary
+}
+
+// usageTokenID returns the ID of a bearer token in usage reports: a short
+// hash, so the token itself is never shown
+func usageTokenID(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return "tok-" + hex.EncodeToString(sum[:8])
+}
+
+// usageConsumer identifies the consumer of a request: its API key or bearer
+// token when valid, else its anonymized client address
+func (c *Controller) usageConsumer(ctx echo.Context) (id, kind string) {
+	if auth.IsAPIKeyAuthenticated(ctx) {
+		return usageTokenID(security.APIKeyFromRequest(ctx.Request())), usageConsumerToken
+	}
+	scheme, token, ok := strings.Cut(ctx.Request().Header.Get("Authorization"), " ")
+	if ok && strings.EqualFold(scheme, "bearer") && c.AuthService != nil {
+		if token = strings.TrimSpace(token); token != "" && c.AuthService.ValidateToken(token) == nil {
+			return usageTokenID(token), usageConsumerToken
+		}
+	}
+	return privacy.AnonymizeIP(ctx.RealIP()), usageConsumerAddress
+}
+
+// UsageMiddleware meters the API usage of each consumer: requests, errors,
+// bytes and endpoints, reported by GetTokenUsage
+func (c *Controller) UsageMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			err := next(ctx)
+			if c.usage == nil {
+				return err
+			}
+
+			endpoint := ctx.Path()
+			if endpoint == "" {
+				endpoint = usageOtherEndpoint
+			}
+			status := ctx.Response().Status
+			if err != nil && !ctx.Response().Committed {
+				status = http.StatusInternalServerError
+				var httpErr *echo.HTTPError
+				if errors.As(err, &httpErr) {
+					status = httpErr.Code
+				}
+			}
+			id, kind := c.usageConsumer(ctx)
+			c.usage.record(id, kind, ctx.Request().Method+" "+endpoint, status,
+				max(ctx.Request().ContentLength, 0), ctx.Response().Size, time.Now())
+			return err
+		}
+	}
+}
+
+// ListTokenUsage handles GET /api/v2/auth/tokens
+// It lists the API usage of each consumer since the server started, the most
+// requests first: bearer tokens by the ID of their usage reports, other
+// clients by anonymized address.
+func (c *Controller) ListTokenUsage(ctx echo.Context) error {
+	consumers := []APIConsumerUsage{}
+	var since time.Time
+	if c.usage != nil {
+		consumers = c.usage.list(time.Now())
+		since = c.usage.since
+	}
+	return ctx.JSON(http.StatusOK, map[string]any{
+		"since":     since,
+		"consumers": consumers,
+	})
+}
+
+// GetTokenUsage handles GET /api/v2/auth/tokens/:id/usage
+// It returns the requests, errors and bytes of a consumer since the server
+// started and its most requested endpoints, 10 unless limit is given.
+func (c *Controller) GetTokenUsage(ctx echo.Context) error {
+	top := defaultUsageTopCount
+	if limit := ctx.QueryParam("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > maxUsageEndpoints {
+			return c.HandleError(ctx, errors.Newf("limit must be between 1 and %d", maxUsageEndpoints).
+				Component("api").
+				Category(errors.CategoryValidation).
+				Build(), "Invalid limit", http.StatusBadRequest)
+		}
+		top = n
+	}
+
+	id := ctx.Param("id")
+	if c.usage != nil {
+		if report, ok := c.usage.report(id, top, time.Now()); ok {
+			return ctx.JSON(http.StatusOK, report)
+		}
+	}
+	return c.HandleError(ctx, errors.Newf("no API usage of consumer %s", id).
+		Component("api").
+		Category(errors.CategoryNotFound).
+		Build(), "No API usage recorded for this token", http.StatusNotFound)
+}
@@ -0,0 +1,135 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/api/v2/auth"
+)
+
+// tokenAuthService accepts the bearer tokens it holds
+type tokenAuthService struct {
+	auth.Service
+	tokens map[string]bool
+}
+
+func (s *tokenAuthService) IsAuthRequired(echo.Context) bool {
+	return true
+}
+
+func (s *tokenAuthService) ValidateToken(token string) error {
+	if s.tokens[token] {
+		return nil
+	}
+	return auth.ErrInvalidToken
+}
+
+func TestUsageMiddleware(t *testing.T) {
+	t.Parallel()
+
+	e := echo.New()
+	controller := &Controller{
+		AuthService: &tokenAuthService{tokens: map[string]bool{"integration-token": true}},
+		usage:       newAPIUsageMeter(),
+		logger:      log.New(io.Discard, "", 0),
+	}
+	group := e.Group("/api/v2", controller.UsageMiddleware())
+	group.GET("/analytics/species/daily", func(ctx echo.Context) error {
+		return ctx.String(http.StatusOK, "0123456789")
+	})
+	group.POST("/detections/:id/review", func(ctx echo.Context) error {
+		return ctx.NoContent(http.StatusBadRequest)
+	})
+	group.GET("/auth/tokens", controller.ListTokenUsage)
+	group.GET("/auth/tokens/:id/usage", controller.GetTokenUsage)
+
+	request := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		req.RemoteAddr = "192.168.1.20:5000"
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// The integration hammers the analytics with its token
+	for range 5 {
+		request(http.MethodGet, "/api/v2/analytics/species/daily?date=2026-05-01", "integration-token", "")
+	}
+	request(http.MethodPost, "/api/v2/detections/42/review", "integration-token", `{"verified":"correct"}`)
+	// A browser without a token, and an invalid token, count by address
+	request(http.MethodGet, "/api/v2/analytics/species/daily", "", "")
+	request(http.MethodGet, "/api/v2/analytics/species/daily", "revoked-token", "")
+
+	tokenID := usageTokenID("integration-token")
+	rec := request(http.MethodGet, "/api/v2/auth/tokens/"+tokenID+"/usage", "", "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var usage APIConsumerUsage
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &usage))
+	assert.Equal(t, tokenID, usage.ID)
+	assert.Equal(t, usageConsumerToken, usage.Kind)
+	assert.Equal(t, int64(6), usage.Requests)
+	assert.Equal(t, int64(6), usage.RequestsLastHour)
+	assert.Equal(t, int64(1), usage.Errors)
+	assert.Equal(t, int64(len(`{"verified":"correct"}`)), usage.BytesIn)
+	assert.Equal(t, int64(50), usage.BytesOut)
+	require.Len(t, usage.TopEndpoints, 2)
+	assert.Equal(t, APIUsageEndpoint{Endpoint: "GET /api/v2/analytics/species/daily", Requests: 5, BytesOut: 50}, usage.TopEndpoints[0],
+		"endpoints are metered by route, the most requested first")
+	assert.Equal(t, "POST /api/v2/detections/:id/review", usage.TopEndpoints[1].Endpoint)
+
+	rec = request(http.MethodGet, "/api/v2/auth/tokens/"+tokenID+"/usage?limit=1", "", "")
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &usage))
+	assert.Len(t, usage.TopEndpoints, 1)
+
+	rec = request(http.MethodGet, "/api/v2/auth/tokens", "", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var list struct {
+		Consumers []APIConsumerUsage `json:"consumers"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	require.Len(t, list.Consumers, 2)
+	assert.Equal(t, tokenID, list.Consumers[0].ID, "the busiest consumer is listed first")
+	assert.Equal(t, usageConsumerAddress, list.Consumers[1].Kind)
+	assert.NotContains(t, list.Consumers[1].ID, "192.168.1.20", "addresses are anonymized")
+
+	assert.Equal(t, http.StatusNotFound, request(http.MethodGet, "/api/v2/auth/tokens/tok-unknown/usage", "", "").Code)
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodGet, "/api/v2/auth/tokens/"+tokenID+"/usage?limit=0", "", "").Code)
+}
+
+func TestAPIUsageMeterLimits(t *testing.T) {
+	t.Parallel()
+
+	meter := newAPIUsageMeter()
+	start := time.Now()
+	for i := range maxUsageConsumers + 1 {
+		meter.record(usageTokenID(strconv.Itoa(i)), usageConsumerToken, "GET /api/v2/health",
+			http.StatusOK, 0, 0, start.Add(time.Duration(i)*time.Second))
+	}
+	assert.Len(t, meter.consumers, maxUsageConsumers, "the least recently seen consumer is forgotten")
+
+	for i := range maxUsageEndpoints + 5 {
+		meter.record("tok-busy", usageConsumerToken, "GET /api/v2/endpoint/"+strconv.Itoa(i), http.StatusOK, 0, 0, start)
+	}
+	report, ok := meter.report("tok-busy", maxUsageEndpoints+5, start)
+	require.True(t, ok)
+	assert.Len(t, report.TopEndpoints, maxUsageEndpoints+1, "endpoints beyond the limit are counted as other")
+	assert.Equal(t, APIUsageEndpoint{Endpoint: usageOtherEndpoint, Requests: 5}, report.TopEndpoints[0])
+
+	// Requests older than an hour drop out of the last hour
+	report, _ = meter.report("tok-busy", 1, start.Add(61*time.Minute))
+	assert.Zero(t, report.RequestsLastHour)
+	assert.Equal(t, int64(maxUsageEndpoints+5), report.Requests)
+}
@@ -0,0 +1,201 @@
+// internal/api/v2/versioning.go
+package api
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// API version negotiation. Clients may send the version they were written for
+// in the API-Version request header as "2" or "2.<minor>"; minor versions only
+// add to the API, so any minor version up to the current one is accepted.
+// Responses carry the version that served them and the supported versions.
+const (
+	APIVersionHeader           = "API-Version"
+	APISupportedVersionsHeader = "API-Supported-Versions"
+
+	apiMajorVersion = 2
+	apiMinorVersion = 1
+)
+
+// deprecationLogInterval limits how often the use of a deprecated endpoint is logged
+const deprecationLogInterval = time.Hour
+
+// EndpointDeprecation announces the planned removal or breaking change of an
+// endpoint. Its responses carry Deprecation (RFC 9745), Sunset (RFC 8594), Link
+// and Warning headers, and once the sunset has passed it answers 410 Gone.
+type EndpointDeprecation struct {
+	Method      string    `json:"method"`
+	Path        string    `json:"path"`                  // route as registered, e.g. /api/v2/detections/:id
+	Since       time.Time `json:"since"`                 // when the endpoint was deprecated
+	Sunset      time.Time `json:"sunset,omitzero"`       // when it stops working, zero when not planned yet
+	Replacement string    `json:"replacement,omitempty"` // path of the endpoint to use instead
+	Message     string    `json:"message"`               // what changes and how to migrate
+}
+
+// deprecatedEndpoints lists the v2 endpoints scheduled for removal or breaking
+// changes. Add an entry at least one release before the change ships.
+var deprecatedEndpoints = []EndpointDeprecation{}
+
+// APIVersionResponse describes the API version and its deprecated endpoints
+type APIVersionResponse struct {
+	Version           string                `json:"version"`
+	SupportedVersions []string              `json:"supported_versions"`
+	Deprecations      []EndpointDeprecation `json:"deprecations"`
+}
+
+// apiVersion returns the current API version
+func apiVersion() string {
+	return fmt.Sprintf("%d.%d", apiMajorVersion, apiMinorVersion)
+}
+
+// supportedAPIVersions returns the accepted values of the API-Version header
+func supportedAPIVersions() []string {
+	versions := []string{strconv.Itoa(apiMajorVersion)}
+	for minor := range apiMinorVersion + 1 {
+		versions = append(versions, fmt.Sprintf("%d.%d", apiMajorVersion, minor))
+	}
+	return versions
+}
+
+// parseAPIVersion checks a requested API version
+func parseAPIVersion(version string) error {
+	major, minor, hasMinor := strings.Cut(strings.TrimSpace(version), ".")
+	majorNumber, err := strconv.Atoi(major)
+	if err == nil && majorNumber == apiMajorVersion {
+		if !hasMinor {
+			return nil
+		}
+		if minorNumber, err := strconv.Atoi(minor); err == nil && minorNumber >= 0 && minorNumber <= apiMinorVersion {
+			return nil
+		}
+	}
+	return errors.Newf("unsupported API version %q", version).
+		Component("api").
+		Category(errors.CategoryValidation).
+		Context("supported_versions", strings.Join(supportedAPIVersions(), ", ")).
+		Build()
+}
+
+// deprecationKey identifies an endpoint in the deprecation index
+func deprecationKey(method, path string) string {
+	return method + " " + path
+}
+
+// setDeprecations replaces the deprecated endpoints announced by the controller
+func (c *Controller) setDeprecations(deprecations []EndpointDeprecation) {
+	index := make(map[string]EndpointDeprecation, len(deprecations))
+	for _, d := range deprecations {
+		index[deprecationKey(d.Method, d.Path)] = d
+	}
+	c.deprecations = index
+	c.deprecationsLogged.Clear()
+}
+
+// VersioningMiddleware negotiates the API version and announces deprecated
+// endpoints. Requests for an unsupported version are rejected with 400, calls
+// to an endpoint past its sunset with 410.
+func (c *Controller) VersioningMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			header := ctx.Response().Header()
+			header.Set(APIVersionHeader, apiVersion())
+			header.Set(APISupportedVersionsHeader, strings.Join(supportedAPIVersions(), ", "))
+
+			if requested := ctx.Request().Header.Get(APIVersionHeader); requested != "" {
+				if err := parseAPIVersion(requested); err != nil {
+					return c.HandleError(ctx, err, "Unsupported API version, supported: "+strings.Join(supportedAPIVersions(), ", "), http.StatusBadRequest)
+				}
+			}
+
+			d, ok := c.deprecations[deprecationKey(ctx.Request().Method, ctx.Path())]
+			if !ok {
+				return next(ctx)
+			}
+
+			header.Set("Deprecation", fmt.Sprintf("@%d", d.Since.Unix()))
+			if !d.Sunset.IsZero() {
+				header.Set("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+			}
+			if d.Replacement != "" {
+				header.Add("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", d.Replacement))
+			}
+			header.Add("Warning", fmt.Sprintf("299 - %q", "Deprecated API: "+d.Message))
+			c.logDeprecatedCall(ctx, &d)
+
+			if !d.Sunset.IsZero() && time.Now().After(d.Sunset) {
+				return c.HandleError(ctx, errors.Newf("endpoint %s %s was removed on %s", d.Method, d.Path, d.Sunset.Format(time.DateOnly)).
+					Component("api").
+					Category(errors.CategoryNotFound).
+					Build(), "This endpoint is no longer available: "+d.Message, http.StatusGone)
+			}
+			return next(ctx)
+		}
+	}
+}
+
+// logDeprecatedCall logs the use of a deprecated endpoint, at most once per
+// deprecationLogInterval for each endpoint
+func (c *Controller) logDeprecatedCall(ctx echo.Context, d *EndpointDeprecation) {
+	now := time.Now()
+	key := deprecationKey(d.Method, d.Path)
+	if last, ok := c.deprecationsLogged.Load(key); ok && now.Sub(last.(time.Time)) < deprecationLogInterval {
+		return
+	}
+	c.deprecationsLogged.Store(key, now)
+
+	attrs := []any{
+		"endpoint", key,
+		"replacement", d.Replacement,
+		"user_agent", ctx.Request().UserAgent(),
+	}
+	if !d.Sunset.IsZero() {
+		attrs = append(attrs, "sunset", d.Sunset.Format(time.DateOnly))
+	}
+	c.logAPIRequest(ctx, slog.LevelWarn, "Deprecated API endpoint called", attrs...)
+}
+
+// initVersionRoutes registers the API version endpoint
+func (c *Controller) initVersionRoutes() {
+	c.Group.GET("/version", c.GetAPIVersion)
+}
+
+// GetAPIVersion handles GET /api/v2/version
+// It returns the current and supported API versions with the deprecated
+// endpoints, so integrations can check for upcoming breaking changes.
+func (c *Controller) GetAPIVersion(ctx echo.Context) error {
+	deprecations := make([]EndpointDeprecation, 0, len(c.deprecations))
+	for _, d := range c.deprecations {
+		deprecations = append(deprecations, d)
+	}
+	sortDeprecations(deprecations)
+	return ctx.JSON(http.StatusOK, APIVersionResponse{
+		Version:           apiVersion(),
+		SupportedVersions: supportedAPIVersions(),
+		Deprecations:      deprecations,
+	})
+}
+
+// sortDeprecations orders deprecations by sunset, unplanned sunsets last
+func sortDeprecations(deprecations []EndpointDeprecation) {
+	slices.SortFunc(deprecations, func(a, b EndpointDeprecation) int {
+		switch {
+		case a.Sunset.IsZero() != b.Sunset.IsZero():
+			if a.Sunset.IsZero() {
+				return 1
+			}
+			return -1
+		case !a.Sunset.Equal(b.Sunset):
+			return a.Sunset.Compare(b.Sunset)
+		}
+		return strings.Compare(deprecationKey(a.Method, a.Path), deprecationKey(b.Method, b.Path))
+	})
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAPIVersion(t *testing.T) {
+	t.Parallel()
+
+	for _, version := range []string{"2", "2.0", "2.1", " 2.1 "} {
+		require.NoError(t, parseAPIVersion(version), version)
+	}
+	for _, version := range []string{"1", "3.0", "2.9", "2.x", "v2", ""} {
+		require.Error(t, parseAPIVersion(version), version)
+	}
+}
+
+func TestVersioningMiddleware(t *testing.T) {
+	t.Parallel()
+
+	_, _, controller := setupTestEnvironment(t)
+	since := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	controller.setDeprecations([]EndpointDeprecation{
+		{Method: http.MethodGet, Path: "/api/v2/old", Since: since, Sunset: time.Now().AddDate(1, 0, 0),
+			Replacement: "/api/v2/new", Message: "use /api/v2/new"},
+		{Method: http.MethodGet, Path: "/api/v2/removed", Since: since, Sunset: since.AddDate(0, 6, 0),
+			Message: "removed in favor of /api/v2/new"},
+	})
+
+	handler := controller.VersioningMiddleware()(func(ctx echo.Context) error {
+		return ctx.String(http.StatusOK, "ok")
+	})
+	call := func(path, version string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, http.NoBody)
+		if version != "" {
+			req.Header.Set(APIVersionHeader, version)
+		}
+		rec := httptest.NewRecorder()
+		ctx := controller.Echo.NewContext(req, rec)
+		ctx.SetPath(path)
+		require.NoError(t, handler(ctx))
+		return rec
+	}
+
+	rec := call("/api/v2/current", "2.0")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "2.1", rec.Header().Get(APIVersionHeader))
+	assert.Equal(t, "2, 2.0, 2.1", rec.Header().Get(APISupportedVersionsHeader))
+	assert.Empty(t, rec.Header().Get("Deprecation"))
+
+	assert.Equal(t, http.StatusBadRequest, call("/api/v2/current", "3").Code)
+
+	rec = call("/api/v2/old", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "@1735689600", rec.Header().Get("Deprecation"))
+	assert.NotEmpty(t, rec.Header().Get("Sunset"))
+	assert.Equal(t, `</api/v2/new>; rel="successor-version"`, rec.Header().Get("Link"))
+	assert.Contains(t, rec.Header().Get("Warning"), "use /api/v2/new")
+
+	rec = call("/api/v2/removed", "")
+	assert.Equal(t, http.StatusGone, rec.Code, "endpoints past their sunset are gone")
+	assert.Equal(t, "Tue, 01 Jul 2025 00:00:00 GMT", rec.Header().Get("Sunset"))
+}
+
+func TestGetAPIVersion(t *testing.T) {
+	t.Parallel()
+
+	_, _, controller := setupTestEnvironment(t)
+	controller.setDeprecations([]EndpointDeprecation{
+		{Method: http.MethodGet, Path: "/api/v2/b", Message: "b"},
+		{Method: http.MethodGet, Path: "/api/v2/a", Sunset: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC), Message: "a"},
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v2/version", http.NoBody)
+	rec := httptest.NewRecorder()
+	require.NoError(t, controller.GetAPIVersion(controller.Echo.NewContext(req, rec)))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp APIVersionResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "2.1", resp.Version)
+	require.Len(t, resp.Deprecations, 2)
+	assert.Equal(t, "/api/v2/a", resp.Deprecations[0].Path, "planned sunsets first")
+}
@@ -0,0 +1,132 @@
+// Package atrest provides optional at-rest encryption of stored data, for
+// deployments on devices that could be stolen from remote sites.
+//
+// Audio clips are encrypted with AES-256-GCM in fixed-size segments, so files
+// can be decrypted with random access (e.g. for HTTP range requests) without
+// holding them in memory. Files without the encryption header are treated as
+// plaintext, which keeps clips saved before encryption was enabled readable.
+//
+// The SQLite database is encrypted with SQLCipher, see SQLCipherDriver.
+package atrest
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"sync/atomic"
+
+	"github.com/tphakala/birdnet-go/internal/errors"
+	"github.com/tphakala/birdnet-go/internal/secrets"
+)
+
+// KeySize is the size of the configured key in bytes
+const KeySize = 32
+
+// clipKeyInfo separates the clip encryption key from the configured key,
+// which is used as is for the database so it can be opened with the sqlcipher shell
+const clipKeyInfo = "birdnet-go clip encryption v1"
+
+// Sentinel errors
+var (
+	ErrInvalidKey = errors.NewStd("encryption key must be 32 bytes encoded as 64 hex characters or base64")
+	ErrNoKey      = errors.NewStd("file is encrypted but no encryption key is configured")
+	ErrCorrupt    = errors.NewStd("encrypted file is corrupt or was encrypted with a different key")
+)
+
+// Cipher encrypts and decrypts stored data with a configured key
+type Cipher struct {
+	clipKey []byte // derives the per-file keys of clips
+	key     []byte // configured key, used for the database
+}
+
+// NewCipher creates a cipher from a 32-byte key
+func NewCipher(key []byte) (*Cipher, error) {
+	if len(key) != KeySize {
+		return nil, ErrInvalidKey
+	}
+
+	clipKey, err := hkdf.Key(sha256.New, key, nil, clipKeyInfo, KeySize)
+	if err != nil {
+		return nil, cipherError(err, "derive_clip_key")
+	}
+	return &Cipher{clipKey: clipKey, key: append([]byte(nil), key...)}, nil
+}
+
+// fileAEAD returns the AES-256-GCM cipher of a file, keyed by the salt in its header
+func (c *Cipher) fileAEAD(header []byte) (cipher.AEAD, error) {
+	fileKey, err := hkdf.Key(sha256.New, c.clipKey, header[len(magic)+1:headerSize], "", KeySize)
+	if err != nil {
+		return nil, cipherError(err, "derive_file_key")
+	}
+	block, err := aes.NewCipher(fileKey)
+	if err != nil {
+		return nil, cipherError(err, "create_block_cipher")
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, cipherError(err, "create_gcm")
+	}
+	return aead, nil
+}
+
+// ParseKey decodes a key given as 64 hex characters or as base64
+func ParseKey(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if len(s) == hex.EncodedLen(KeySize) {
+		if key, err := hex.DecodeString(s); err == nil {
+			return key, nil
+		}
+	}
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if key, err := enc.DecodeString(s); err == nil && len(key) == KeySize {
+			return key, nil
+		}
+	}
+	return nil, ErrInvalidKey
+}
+
+// LoadKey creates a cipher from a key file or a key value, which may reference
+// environment variables. The key file takes precedence.
+func LoadKey(keyFile, key string) (*Cipher, error) {
+	value, err := secrets.MustResolve("encryption key", keyFile, key)
+	if err != nil {
+		return nil, errors.New(err).
+			Component("atrest").
+			Category(errors.CategoryConfiguration).
+			Context("operation", "load_key").
+			Context("key_file", keyFile).
+			Build()
+	}
+	raw, err := ParseKey(value)
+	if err != nil {
+		return nil, err
+	}
+	return NewCipher(raw)
+}
+
+// active is the cipher used for files read and written by the application
+var active atomic.Pointer[Cipher]
+
+// SetActive installs the cipher used to read and write encrypted files.
+// Passing nil removes it.
+func SetActive(c *Cipher) {
+	active.Store(c)
+}
+
+// Active returns the installed cipher, or nil when no key is configured
+func Active() *Cipher {
+	return active.Load()
+}
+
+// cipherError wraps a cipher setup error with context
+func cipherError(err error, operation string) error {
+	return errors.New(err).
+		Component("atrest").
+		Category(errors.CategorySystem).
+		Context("operation", operation).
+		Build()
+}
@@ -0,0 +1,236 @@
+package atrest
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCipher returns a cipher with a random key
+func testCipher(t *testing.T) *Cipher {
+	t.Helper()
+	key := make([]byte, KeySize)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	c, err := NewCipher(key)
+	require.NoError(t, err)
+	return c
+}
+
+// randomBytes returns n random bytes
+func randomBytes(t *testing.T, n int) []byte {
+	t.Helper()
+	data := make([]byte, n)
+	_, err := rand.Read(data)
+	require.NoError(t, err)
+	return data
+}
+
+// encrypt returns the encrypted form of plain
+func encrypt(t *testing.T, c *Cipher, plain []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, c.Encrypt(&buf, bytes.NewReader(plain)))
+	return buf.Bytes()
+}
+
+func TestCipher_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	c := testCipher(t)
+	for _, size := range []int{0, 1, segmentSize - 1, segmentSize, segmentSize + 1, 3*segmentSize + 123} {
+		plain := randomBytes(t, size)
+		sealed := encrypt(t, c, plain)
+		assert.True(t, hasHeader(sealed), "size %d", size)
+
+		r, err := c.NewReader(bytes.NewReader(sealed), int64(len(sealed)))
+		require.NoError(t, err, "size %d", size)
+		assert.Equal(t, int64(size), r.Size())
+
+		got, err := io.ReadAll(r)
+		require.NoError(t, err, "size %d", size)
+		assert.Equal(t, plain, got, "size %d", size)
+	}
+}
+
+func TestReader_RandomAccess(t *testing.T) {
+	t.Parallel()
+
+	c := testCipher(t)
+	plain := randomBytes(t, 2*segmentSize+500)
+	sealed := encrypt(t, c, plain)
+	r, err := c.NewReader(bytes.NewReader(sealed), int64(len(sealed)))
+	require.NoError(t, err)
+
+	// A read spanning a segment boundary
+	buf := make([]byte, 1000)
+	n, err := r.ReadAt(buf, segmentSize-400)
+	require.NoError(t, err)
+	assert.Equal(t, plain[segmentSize-400:segmentSize+600], buf[:n])
+
+	// A read past the end returns the remaining bytes and io.EOF
+	n, err = r.ReadAt(buf, int64(len(plain)-10))
+	assert.Equal(t, 10, n)
+	assert.ErrorIs(t, err, io.EOF)
+
+	pos, err := r.Seek(-20, io.SeekEnd)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(plain)-20), pos)
+	rest, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, plain[len(plain)-20:], rest)
+}
+
+func TestReader_DetectsTampering(t *testing.T) {
+	t.Parallel()
+
+	c := testCipher(t)
+	plain := randomBytes(t, 2*segmentSize+10)
+	sealed := encrypt(t, c, plain)
+
+	readAll := func(data []byte, c *Cipher) error {
+		r, err := c.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return err
+		}
+		_, err = io.ReadAll(r)
+		return err
+	}
+
+	flipped := bytes.Clone(sealed)
+	flipped[headerSize+segmentSize/2] ^= 0x01
+	require.ErrorIs(t, readAll(flipped, c), ErrCorrupt, "modified ciphertext")
+
+	truncated := sealed[:headerSize+2*sealedSize]
+	require.ErrorIs(t, readAll(truncated, c), ErrCorrupt, "file truncated at a segment boundary")
+
+	require.ErrorIs(t, readAll(sealed, testCipher(t)), ErrCorrupt, "different key")
+	require.NotErrorIs(t, readAll(sealed, testCipher(t)), ErrNoKey)
+}
+
+func TestCipher_EncryptFile(t *testing.T) {
+	t.Parallel()
+
+	c := testCipher(t)
+	path := filepath.Join(t.TempDir(), "clip.wav")
+	plain := randomBytes(t, segmentSize+42)
+	require.NoError(t, os.WriteFile(path, plain, 0o640))
+
+	require.NoError(t, c.EncryptFile(path))
+	encrypted, err := IsEncryptedFile(path)
+	require.NoError(t, err)
+	assert.True(t, encrypted)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o640), info.Mode().Perm(), "file mode should be preserved")
+
+	// Encrypting again leaves the file unchanged
+	before, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, c.EncryptFile(path))
+	after, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, before, after)
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "temporary files should be removed")
+}
+
+// Not parallel: installs the global active cipher
+func TestDecrypting_PlaintextAndEncrypted(t *testing.T) {
+	c := testCipher(t)
+	SetActive(c)
+	t.Cleanup(func() { SetActive(nil) })
+
+	dir := t.TempDir()
+	plain := randomBytes(t, 5000)
+	plainPath := filepath.Join(dir, "plain.wav")
+	encPath := filepath.Join(dir, "encrypted.wav")
+	require.NoError(t, os.WriteFile(plainPath, plain, 0o600))
+	require.NoError(t, os.WriteFile(encPath, plain, 0o600))
+	require.NoError(t, c.EncryptFile(encPath))
+
+	for _, path := range []string{plainPath, encPath} {
+		f, err := os.Open(path)
+		require.NoError(t, err)
+		content, err := Decrypting(f)
+		require.NoError(t, err, path)
+		assert.Equal(t, int64(len(plain)), content.Size())
+		got, err := io.ReadAll(content)
+		require.NoError(t, err)
+		assert.Equal(t, plain, got, path)
+		require.NoError(t, f.Close())
+	}
+
+	tmpPath, cleanup, err := PlaintextPath(encPath)
+	require.NoError(t, err)
+	assert.Equal(t, ".wav", filepath.Ext(tmpPath), "extension should be kept for audio tools")
+	got, err := os.ReadFile(tmpPath)
+	require.NoError(t, err)
+	assert.Equal(t, plain, got)
+	cleanup()
+	assert.NoFileExists(t, tmpPath)
+
+	samePath, cleanup, err := PlaintextPath(plainPath)
+	require.NoError(t, err)
+	assert.Equal(t, plainPath, samePath)
+	cleanup()
+	assert.FileExists(t, plainPath)
+
+	SetActive(nil)
+	f, err := os.Open(encPath)
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+	_, err = Decrypting(f)
+	assert.ErrorIs(t, err, ErrNoKey)
+	assert.NotErrorIs(t, err, ErrCorrupt, "a missing key is told apart from a corrupt file")
+}
+
+func TestParseKey(t *testing.T) {
+	t.Parallel()
+
+	key := make([]byte, KeySize)
+	for i := range key {
+		key[i] = byte(i)
+	}
+
+	for _, encoded := range []string{
+		hex.EncodeToString(key),
+		base64.StdEncoding.EncodeToString(key),
+		base64.RawURLEncoding.EncodeToString(key),
+		hex.EncodeToString(key) + "\n",
+	} {
+		got, err := ParseKey(encoded)
+		require.NoError(t, err, encoded)
+		assert.Equal(t, key, got)
+	}
+
+	for _, invalid := range []string{"", "secret", hex.EncodeToString(key[:16]), base64.StdEncoding.EncodeToString(key[:31])} {
+		_, err := ParseKey(invalid)
+		assert.ErrorIs(t, err, ErrInvalidKey, invalid)
+	}
+}
+
+func TestLoadKey_FromFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "key")
+	require.NoError(t, os.WriteFile(path, []byte(hex.EncodeToString(randomBytes(t, KeySize))+"\n"), 0o600))
+
+	c, err := LoadKey(path, "ignored when a key file is set")
+	require.NoError(t, err)
+	assert.NotNil(t, c)
+
+	_, err = LoadKey("", "")
+	assert.Error(t, err)
+}
@@ -0,0 +1,50 @@
+// sqlcipher.go: SQLCipher keyed SQLite connections
+package atrest
+
+import (
+	"database/sql"
+	"encoding/hex"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// SQLCipherDriver is the database/sql driver name for SQLite connections keyed
+// with the active cipher. Encryption requires the binary to be linked against
+// SQLCipher (go build -tags libsqlite3 with libsqlcipher installed as libsqlite3);
+// use SQLCipherAvailable to check.
+const SQLCipherDriver = "sqlite3_sqlcipher"
+
+func init() {
+	sql.Register(SQLCipherDriver, &sqlite3.SQLiteDriver{ConnectHook: keyConnection})
+}
+
+// keyConnection keys a new connection, which must happen before any other statement
+func keyConnection(conn *sqlite3.SQLiteConn) error {
+	c := Active()
+	if c == nil {
+		return ErrNoKey
+	}
+	_, err := conn.Exec(c.KeyPragma("main"), nil)
+	return err
+}
+
+// KeyPragma returns the statement keying a database with the configured key.
+// The key is passed as a raw hex key, so it can also be used in the sqlcipher shell.
+func (c *Cipher) KeyPragma(schema string) string {
+	return "PRAGMA " + schema + `.key = "x'` + hex.EncodeToString(c.key) + `'"`
+}
+
+// RawKeyLiteral returns the configured key as a SQLCipher raw key literal, for
+// statements such as ATTACH DATABASE ... KEY
+func (c *Cipher) RawKeyLiteral() string {
+	return `"x'` + hex.EncodeToString(c.key) + `'"`
+}
+
+// SQLCipherAvailable reports whether db is backed by a SQLite library with SQLCipher support
+func SQLCipherAvailable(db *sql.DB) bool {
+	var version string
+	if err := db.QueryRow("PRAGMA cipher_version").Scan(&version); err != nil {
+		return false
+	}
+	return version != ""
+}
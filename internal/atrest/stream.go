@@ -0,0 +1,384 @@
+// stream.go: Segmented AES-GCM file format
+package atrest
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// File layout: header | segment 0 | segment 1 | ... where every segment holds
+// segmentSize bytes of plaintext (the last one may hold less) sealed with its own
+// nonce. Each file is encrypted with a key derived from a random salt in its
+// header. A nonce is the segment index and a flag marking the last segment, so
+// reordering or truncating segments is detected.
+const (
+	magic         = "BNGENC"
+	formatVersion = 1
+	saltSize      = 16
+	headerSize    = len(magic) + 1 + saltSize
+	segmentSize   = 64 * 1024
+	tagSize       = 16
+	sealedSize    = segmentSize + tagSize
+)
+
+// Encrypt writes the encrypted form of src to dst
+func (c *Cipher) Encrypt(dst io.Writer, src io.Reader) error {
+	header := make([]byte, headerSize)
+	copy(header, magic)
+	header[len(magic)] = formatVersion
+	if _, err := rand.Read(header[len(magic)+1:]); err != nil {
+		return cipherError(err, "generate_salt")
+	}
+	aead, err := c.fileAEAD(header)
+	if err != nil {
+		return err
+	}
+	if _, err := dst.Write(header); err != nil {
+		return err
+	}
+
+	br := bufio.NewReaderSize(src, segmentSize)
+	plain := make([]byte, segmentSize)
+	sealed := make([]byte, 0, sealedSize)
+	for index := uint32(0); ; index++ {
+		n, err := io.ReadFull(br, plain)
+		last := errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+		if err != nil && !last {
+			return err
+		}
+		if !last {
+			if _, err := br.Peek(1); errors.Is(err, io.EOF) {
+				last = true
+			} else if err != nil {
+				return err
+			}
+		}
+		if !last && index == math.MaxUint32 {
+			return errors.Newf("file is too large to encrypt").
+				Component("atrest").
+				Category(errors.CategoryLimit).
+				Build()
+		}
+
+		sealed = aead.Seal(sealed[:0], segmentNonce(index, last), plain[:n], header)
+		if _, err := dst.Write(sealed); err != nil {
+			return err
+		}
+		if last {
+			return nil
+		}
+	}
+}
+
+// EncryptFile encrypts a file in place. The encrypted copy replaces the
+// original atomically; files that are already encrypted are left unchanged.
+func (c *Cipher) EncryptFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return fileError(err, "open_file", path)
+	}
+	defer func() { _ = src.Close() }()
+
+	encrypted, err := isEncrypted(src)
+	if err != nil {
+		return fileError(err, "read_header", path)
+	}
+	if encrypted {
+		return nil
+	}
+	info, err := src.Stat()
+	if err != nil {
+		return fileError(err, "stat_file", path)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".enc-*")
+	if err != nil {
+		return fileError(err, "create_temp", path)
+	}
+	tmpPath := tmp.Name()
+	defer func() { _ = os.Remove(tmpPath) }()
+
+	w := bufio.NewWriterSize(tmp, sealedSize)
+	err = c.Encrypt(w, src)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = tmp.Chmod(info.Mode().Perm())
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fileError(err, "encrypt_file", path)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fileError(err, "replace_file", path)
+	}
+	return nil
+}
+
+// Reader provides random access to the plaintext of an encrypted file.
+// It is not safe for concurrent use.
+type Reader struct {
+	aead     cipher.AEAD
+	src      io.ReaderAt
+	header   []byte
+	size     int64 // plaintext size
+	segments int64
+	offset   int64 // position for Read and Seek
+
+	cached    int64 // index of the segment in plain, -1 when none
+	plain     []byte
+	sealedBuf []byte
+}
+
+// NewReader returns a reader over the plaintext of an encrypted file of the given size
+func (c *Cipher) NewReader(src io.ReaderAt, size int64) (*Reader, error) {
+	header := make([]byte, headerSize)
+	if _, err := src.ReadAt(header, 0); err != nil || !hasHeader(header) {
+		return nil, ErrCorrupt
+	}
+
+	body := size - int64(headerSize)
+	if body < tagSize {
+		return nil, ErrCorrupt
+	}
+	segments := (body + sealedSize - 1) / sealedSize
+	if lastSealed := body - (segments-1)*sealedSize; lastSealed < tagSize {
+		return nil, ErrCorrupt
+	}
+	aead, err := c.fileAEAD(header)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Reader{
+		aead:      aead,
+		src:       src,
+		header:    header,
+		size:      body - segments*tagSize,
+		segments:  segments,
+		cached:    -1,
+		sealedBuf: make([]byte, sealedSize),
+	}
+	// Authenticating the last segment detects truncation and a wrong key up front
+	if err := r.loadSegment(segments - 1); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Size returns the plaintext size
+func (r *Reader) Size() int64 {
+	return r.size
+}
+
+// ReadAt implements io.ReaderAt
+func (r *Reader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.Newf("negative offset").Component("atrest").Category(errors.CategoryValidation).Build()
+	}
+
+	n := 0
+	for n < len(p) {
+		pos := off + int64(n)
+		if pos >= r.size {
+			return n, io.EOF
+		}
+		index := pos / segmentSize
+		if err := r.loadSegment(index); err != nil {
+			return n, err
+		}
+		n += copy(p[n:], r.plain[pos-index*segmentSize:])
+	}
+	return n, nil
+}
+
+// Read implements io.Reader
+func (r *Reader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	n, err := r.ReadAt(p, r.offset)
+	r.offset += int64(n)
+	if n > 0 && errors.Is(err, io.EOF) {
+		err = nil
+	}
+	return n, err
+}
+
+// Seek implements io.Seeker
+func (r *Reader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.size
+	default:
+		return 0, errors.Newf("invalid whence %d", whence).Component("atrest").Category(errors.CategoryValidation).Build()
+	}
+	if offset < 0 {
+		return 0, errors.Newf("negative position").Component("atrest").Category(errors.CategoryValidation).Build()
+	}
+	r.offset = offset
+	return offset, nil
+}
+
+// loadSegment decrypts a segment into the cache
+func (r *Reader) loadSegment(index int64) error {
+	if r.cached == index {
+		return nil
+	}
+
+	start := int64(headerSize) + index*sealedSize
+	sealed := r.sealedBuf
+	if index == r.segments-1 {
+		sealed = sealed[:int64(headerSize)+r.size+r.segments*tagSize-start]
+	}
+	if _, err := r.src.ReadAt(sealed, start); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	last := index == r.segments-1
+	plain, err := r.aead.Open(r.plain[:0], segmentNonce(uint32(index), last), sealed, r.header) // #nosec G115 -- segment count bounded by Encrypt
+	if err != nil {
+		r.cached = -1
+		return ErrCorrupt
+	}
+	r.plain = plain
+	r.cached = index
+	return nil
+}
+
+// segmentNonce returns the nonce of a segment. Nonces only need to be unique
+// per file because every file has its own key.
+func segmentNonce(index uint32, last bool) []byte {
+	nonce := make([]byte, 12)
+	binary.BigEndian.PutUint32(nonce, index)
+	if last {
+		nonce[4] = 1
+	}
+	return nonce
+}
+
+// hasHeader reports whether data starts with the encryption header
+func hasHeader(data []byte) bool {
+	return len(data) >= headerSize && bytes.HasPrefix(data, []byte(magic)) && data[len(magic)] == formatVersion
+}
+
+// isEncrypted reports whether a file starts with the encryption header
+func isEncrypted(f io.ReaderAt) (bool, error) {
+	header := make([]byte, headerSize)
+	n, err := f.ReadAt(header, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, err
+	}
+	return hasHeader(header[:n]), nil
+}
+
+// IsEncryptedFile reports whether the file at path is encrypted
+func IsEncryptedFile(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = f.Close() }()
+	return isEncrypted(f)
+}
+
+// Content is the readable plaintext of a stored file
+type Content interface {
+	io.ReadSeeker
+	io.ReaderAt
+	Size() int64
+}
+
+// Decrypting returns the plaintext of an open file, decrypting it with the
+// active cipher when the file is encrypted. Plaintext files are returned as is.
+func Decrypting(f *os.File) (Content, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := isEncrypted(f)
+	if err != nil {
+		return nil, err
+	}
+	if !encrypted {
+		return io.NewSectionReader(f, 0, info.Size()), nil
+	}
+
+	c := Active()
+	if c == nil {
+		return nil, ErrNoKey
+	}
+	r, err := c.NewReader(f, info.Size())
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// PlaintextPath returns a path to the plaintext of a file for tools that read
+// files directly, such as SoX and FFmpeg. Encrypted files are decrypted to a
+// temporary file readable only by the current user, which cleanup removes;
+// for plaintext files path itself is returned.
+func PlaintextPath(path string) (plainPath string, cleanup func(), err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	encrypted, err := isEncrypted(f)
+	if err != nil {
+		return "", nil, fileError(err, "read_header", path)
+	}
+	if !encrypted {
+		return path, func() {}, nil
+	}
+
+	content, err := Decrypting(f)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Keep the extension, tools detect the audio format from it
+	tmp, err := os.CreateTemp("", "birdnet-go-clip-*"+filepath.Ext(path))
+	if err != nil {
+		return "", nil, fileError(err, "create_temp", path)
+	}
+	cleanup = func() { _ = os.Remove(tmp.Name()) }
+
+	_, err = io.Copy(tmp, content)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		cleanup()
+		return "", nil, fileError(err, "decrypt_file", path)
+	}
+	return tmp.Name(), cleanup, nil
+}
+
+// fileError wraps a file error with context
+func fileError(err error, operation, path string) error {
+	return errors.New(err).
+		Component("atrest").
+		Category(errors.CategoryFileIO).
+		Context("operation", operation).
+		Context("path", path).
+		Build()
+}
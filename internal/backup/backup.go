@@ -19,7 +19,9 @@ import (
 	"time"
 
 	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
 	"github.com/tphakala/birdnet-go/internal/errors"
+	"github.com/tphakala/birdnet-go/internal/manifest"
 	"gopkg.in/yaml.v3"
 )
 
@@ -442,13 +444,14 @@ func (m *Manager) processBackupSource(ctx context.Context, sourceName string, so
 	metadata.Size = fileInfo.Size()
 	m.logger.Debug("Updated metadata with final size", "source_name", sourceName, "size", metadata.Size)
 
-	// Calculate checksum if needed (optional, can be time-consuming)
-	// checksum, err := calculateChecksum(finalArchivePath)
-	// if err == nil {
-	//     metadata.Checksum = checksum
-	// } else {
-	//     m.logger.Warn("Failed to calculate checksum", "path", finalArchivePath, "error", err)
-	// }
+	// Calculate the checksum for the manifest of uploads and exports (can be time-consuming)
+	if manifest.Enabled() {
+		if checksum, _, err := manifest.ChecksumFile(finalArchivePath); err == nil {
+			metadata.Checksum = checksum
+		} else {
+			m.logger.Warn("Failed to calculate checksum", "path", finalArchivePath, "error", err)
+		}
+	}
 
 	// 8. Store the final archive in all registered targets
 	if err := m.storeBackupInTargets(ctx, finalArchivePath, metadata); err != nil {
@@ -567,6 +570,7 @@ func (m *Manager) storeBackupInTargets(ctx context.Context, archivePath string,
 					"backup_id", metadata.ID,
 					"target_name", targetName,
 					"duration_ms", time.Since(startTargetTime).Milliseconds())
+				m.recordInManifest(t, archivePath, metadata)
 				// Update state for this specific target success
 				if m.stateManager != nil {
 					if err := m.stateManager.UpdateTargetState(targetName, metadata, "success"); err != nil {
@@ -594,6 +598,35 @@ func (m *Manager) storeBackupInTargets(ctx context.Context, archivePath string,
 	return nil
 }
 
+// LocalPathTarget is implemented by targets storing backups on a local filesystem,
+// whose copies can be verified against the manifest
+type LocalPathTarget interface {
+	// StoredPath returns the path of the copy of an archive stored by Store
+	StoredPath(archivePath string) string
+}
+
+// recordInManifest records a backup stored in a target in the manifest of
+// uploads and exports
+func (m *Manager) recordInManifest(target Target, archivePath string, metadata *Metadata) {
+	if metadata.Checksum == "" {
+		return
+	}
+	entry := &datastore.ManifestEntry{
+		Kind:        manifest.KindBackup,
+		Name:        filepath.Base(archivePath),
+		Destination: target.Name(),
+		SHA256:      metadata.Checksum,
+		Size:        metadata.Size,
+	}
+	if local, ok := target.(LocalPathTarget); ok {
+		entry.Path = local.StoredPath(archivePath)
+		if abs, err := filepath.Abs(entry.Path); err == nil {
+			entry.Path = abs
+		}
+	}
+	manifest.Record(entry)
+}
+
 // performBackupCleanup triggers the cleanup process for old backups across all targets.
 func (m *Manager) performBackupCleanup(ctx context.Context) error {
 	m.logger.Info("Starting backup cleanup process...")
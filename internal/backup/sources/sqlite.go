@@ -15,6 +15,7 @@ import (
 	"time"
 
 	"github.com/mattn/go-sqlite3"
+	"github.com/tphakala/birdnet-go/internal/atrest"
 	"github.com/tphakala/birdnet-go/internal/backup"
 	"github.com/tphakala/birdnet-go/internal/conf"
 	"github.com/tphakala/birdnet-go/internal/errors"
@@ -65,6 +66,15 @@ func (dc *DatabaseConnection) Close() error {
 	return nil
 }
 
+// driverName returns the SQLite driver, keying connections when the database is encrypted.
+// Backups of an encrypted database are encrypted with the same key.
+func (s *SQLiteSource) driverName() string {
+	if s.config.Encryption.Database {
+		return atrest.SQLCipherDriver
+	}
+	return "sqlite3"
+}
+
 // openDatabase opens a database connection with the given path
 func (s *SQLiteSource) openDatabase(dbPath string, readOnly bool) (*DatabaseConnection, error) {
 	// Build DSN with additional safety parameters
@@ -76,7 +86,7 @@ func (s *SQLiteSource) openDatabase(dbPath string, readOnly bool) (*DatabaseConn
 	dsn += "&_journal_mode=WAL"   // Ensure WAL mode
 	dsn += "&_sync=NORMAL"        // Less aggressive syncing for better performance
 
-	db, err := sql.Open("sqlite3", dsn)
+	db, err := sql.Open(s.driverName(), dsn)
 	if err != nil {
 		if isMediaError(err) {
 			return nil, errors.New(err).
@@ -480,7 +490,7 @@ func (s *SQLiteSource) streamBackupToWriter(ctx context.Context, db *sql.DB, w i
 	}()
 
 	// Open the destination database (using the temp path)
-	destDB, err := sql.Open("sqlite3", tempPath+"?_journal_mode=WAL&_sync=OFF") // Turn off sync for backup target
+	destDB, err := sql.Open(s.driverName(), tempPath+"?_journal_mode=WAL&_sync=OFF") // Turn off sync for backup target
 	if err != nil {
 		return errors.New(err).
 			Component("backup").
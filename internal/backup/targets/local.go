@@ -310,6 +310,11 @@ func (t *LocalTarget) Name() string {
 	return "local"
 }
 
+// StoredPath returns the path of the copy of a backup archive stored by Store
+func (t *LocalTarget) StoredPath(sourcePath string) string {
+	return filepath.Join(t.path, filepath.Base(sourcePath))
+}
+
 // Store stores a backup file in the local filesystem
 func (t *LocalTarget) Store(ctx context.Context, sourcePath string, metadata *backup.Metadata) error {
 	// Check context cancellation
@@ -382,7 +387,7 @@ func (t *LocalTarget) Store(ctx context.Context, sourcePath string, metadata *ba
 	}
 
 	// Copy the backup file with retries and atomic operations
-	dstPath := filepath.Join(t.path, filepath.Base(sourcePath))
+	dstPath := t.StoredPath(sourcePath)
 	err = t.withRetry(func() error {
 		return atomicWriteFile(dstPath, "backup-*.tmp", filePermissions, func(tempFile *os.File) error {
 			// Open source file with secure path validation
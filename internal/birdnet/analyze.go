@@ -30,15 +30,29 @@ func (bn *BirdNET) Predict(sample [][]float32) ([]datastore.Results, error) {
 
 // PredictWithContext performs inference with tracing support
 func (bn *BirdNET) PredictWithContext(ctx context.Context, sample [][]float32) ([]datastore.Results, error) {
+	return bn.predict(ctx, len(sample[0]), func(input []float32) error {
+		copy(input, sample[0])
+		return nil
+	})
+}
+
+// PredictWithInput performs inference on a sample that fill writes straight
+// into the model input tensor, such as PCM data converted in place, which
+// saves converting to an intermediate float32 buffer and copying it. fill is
+// called with the interpreter locked and must not retain the input slice.
+func (bn *BirdNET) PredictWithInput(ctx context.Context, sampleLength int, fill func(input []float32) error) ([]datastore.Results, error) {
+	return bn.predict(ctx, sampleLength, fill)
+}
+
+// predict runs inference on the input written by fill
+func (bn *BirdNET) predict(ctx context.Context, sampleLength int, fill func(input []float32) error) ([]datastore.Results, error) {
 	span, _ := StartSpan(ctx, "birdnet.predict", "Species prediction")
 	defer span.Finish()
 
 	start := time.Now()
 	span.SetTag("model", bn.ModelInfo.ID)
-	span.SetData("sample_count", len(sample))
-	if len(sample) > 0 {
-		span.SetData("sample_size", len(sample[0]))
-	}
+	span.SetData("sample_count", 1)
+	span.SetData("sample_size", sampleLength)
 
 	// implement locking to prevent concurrent access to the interpreter, not
 	// necessarily best way to manage multiple audio sources but works for now
@@ -67,10 +81,25 @@ func (bn *BirdNET) PredictWithContext(ctx context.Context, sample [][]float32) (
 	}
 
 	// Preparing input tensor with the sample data
-	copy(inputTensor.Float32s(), sample[0])
+	if err := fill(inputTensor.Float32s()); err != nil {
+		err = errors.New(err).
+			Category(errors.CategoryAudio).
+			ModelContext(bn.Settings.BirdNET.ModelPath, bn.ModelInfo.ID).
+			Context("sample_length", sampleLength).
+			Build()
+
+		span.SetTag("error", "true")
+		span.SetData("error_type", "input_conversion_failed")
+
+		if globalMetrics != nil {
+			globalMetrics.RecordPrediction(bn.ModelInfo.ID, time.Since(start).Seconds(), err)
+		}
+
+		return nil, err
+	}
 
 	// DEBUG: Log the length of the sample data
-	//log.Printf("Invoking tensor with sample length: %d", len(sample[0]))
+	//log.Printf("Invoking tensor with sample length: %d", sampleLength)
 
 	// Invoke the interpreter to perform inference
 	invokeStart := time.Now()
@@ -78,7 +107,7 @@ func (bn *BirdNET) PredictWithContext(ctx context.Context, sample [][]float32) (
 		err := errors.Newf("tensor invoke failed: %v", status).
 			Category(errors.CategoryAudio).
 			ModelContext(bn.Settings.BirdNET.ModelPath, bn.ModelInfo.ID).
-			Context("sample_length", len(sample[0])).
+			Context("sample_length", sampleLength).
 			Context("status_code", status).
 			Timing("prediction-invoke", time.Since(start)).
 			Build()
@@ -96,6 +125,7 @@ func (bn *BirdNET) PredictWithContext(ctx context.Context, sample [][]float32) (
 	}
 
 	invokeDuration := time.Since(invokeStart)
+	bn.lastInference = time.Now()
 	span.SetData("invoke_duration_ms", invokeDuration.Milliseconds())
 
 	// Record model invoke timing separately
@@ -53,6 +53,10 @@ type BirdNET struct {
 	// Species occurrence cache to avoid repeated GetProbableSpecies calls within same day
 	speciesCacheMu      sync.RWMutex
 	speciesCache        map[string]*speciesCacheEntry
+
+	lastInference time.Time    // time of the last analysis model inference, guarded by mu
+	warmupMu      sync.RWMutex
+	warmup        WarmupStatus // warm-up of the current analysis interpreter
 }
 
 // NewBirdNET initializes a new BirdNET instance with given settings.
@@ -373,7 +377,9 @@ func (bn *BirdNET) initializeMetaModel() error {
 
 // determineThreadCount calculates the appropriate number of threads to use based on settings and system capabilities.
 func (bn *BirdNET) determineThreadCount(configuredThreads int) int {
-	systemCpuCount := runtime.NumCPU()
+	// GOMAXPROCS follows the cgroup CPU quota and the maxprocs limit, keep the
+	// interpreter threads within it too
+	systemCpuCount := min(runtime.NumCPU(), runtime.GOMAXPROCS(0))
 
 	// If threads are configured to 0, try to get optimal count from cpuspec
 	if configuredThreads == 0 {
@@ -962,6 +968,9 @@ func (bn *BirdNET) ReloadModel() error {
 	// Clear species cache as model/labels have changed
 	bn.clearSpeciesCache()
 
+	// The new interpreter is cold until warmed up again
+	bn.setWarmupStatus(WarmupStatus{})
+
 	bn.Debug("\033[32m✅ Model reload completed successfully\033[0m")
 	return nil
 }
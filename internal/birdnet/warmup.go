@@ -0,0 +1,138 @@
+package birdnet
+
+import (
+	"context"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// WarmupState is the state of the analysis model warm-up
+type WarmupState string
+
+const (
+	WarmupPending  WarmupState = "pending"  // the model has not been warmed up
+	WarmupRunning  WarmupState = "running"  // dummy inferences are running
+	WarmupReady    WarmupState = "ready"    // the model is warm
+	WarmupFailed   WarmupState = "failed"   // a dummy inference failed
+	WarmupDisabled WarmupState = "disabled" // warm-up is disabled in settings
+)
+
+// WarmupStatus reports the warm-up of the analysis model
+type WarmupStatus struct {
+	State            WarmupState `json:"state"`
+	Runs             int         `json:"runs"`                         // dummy inferences run
+	FirstInferenceMs float64     `json:"first_inference_ms,omitempty"` // duration of the first, cold inference
+	LastInferenceMs  float64     `json:"last_inference_ms,omitempty"`  // duration of the last, warm inference
+	CompletedAt      *time.Time  `json:"completed_at,omitempty"`
+	Error            string      `json:"error,omitempty"`
+}
+
+// WarmupStatus returns the warm-up status of the analysis model
+func (bn *BirdNET) WarmupStatus() WarmupStatus {
+	bn.warmupMu.RLock()
+	defer bn.warmupMu.RUnlock()
+	if bn.warmup.State == "" {
+		if !bn.Settings.BirdNET.Warmup.Enabled {
+			return WarmupStatus{State: WarmupDisabled}
+		}
+		return WarmupStatus{State: WarmupPending}
+	}
+	return bn.warmup
+}
+
+func (bn *BirdNET) setWarmupStatus(status WarmupStatus) {
+	bn.warmupMu.Lock()
+	bn.warmup = status
+	bn.warmupMu.Unlock()
+}
+
+// Warmup runs dummy inferences on silence so buffers are allocated and
+// weights packed before the first real detection. The status is reported by
+// WarmupStatus.
+func (bn *BirdNET) Warmup(ctx context.Context, runs int) error {
+	return bn.runWarmup(ctx, runs, bn.dummyInference)
+}
+
+// runWarmup runs infer runs times, recording the warm-up status
+func (bn *BirdNET) runWarmup(ctx context.Context, runs int, infer func(context.Context) error) error {
+	runs = max(runs, 1)
+	bn.setWarmupStatus(WarmupStatus{State: WarmupRunning})
+
+	status := WarmupStatus{State: WarmupReady}
+	for range runs {
+		if err := ctx.Err(); err != nil {
+			status.State = WarmupPending
+			bn.setWarmupStatus(status)
+			return err
+		}
+		start := time.Now()
+		if err := infer(ctx); err != nil {
+			status.State = WarmupFailed
+			status.Error = err.Error()
+			bn.setWarmupStatus(status)
+			return errors.New(err).
+				Component("birdnet").
+				Category(errors.CategoryModelInit).
+				ModelContext(bn.Settings.BirdNET.ModelPath, bn.ModelInfo.ID).
+				Context("operation", "model_warmup").
+				Context("run", status.Runs+1).
+				Build()
+		}
+		elapsed := float64(time.Since(start).Microseconds()) / 1000
+		if status.Runs == 0 {
+			status.FirstInferenceMs = elapsed
+		}
+		status.LastInferenceMs = elapsed
+		status.Runs++
+	}
+	now := time.Now()
+	status.CompletedAt = &now
+	bn.setWarmupStatus(status)
+
+	bn.Debug("Model warm-up completed in %d runs, first inference %.1f ms, last %.1f ms",
+		status.Runs, status.FirstInferenceMs, status.LastInferenceMs)
+	return nil
+}
+
+// dummyInference runs the analysis model on a chunk of silence
+func (bn *BirdNET) dummyInference(ctx context.Context) error {
+	_, err := bn.predict(ctx, conf.SampleRate*conf.CaptureLength, func(input []float32) error {
+		clear(input)
+		return nil
+	})
+	return err
+}
+
+// KeepWarm runs a dummy inference whenever the analysis model has been idle
+// for the given time, so it stays warm while no audio is analyzed, such as
+// when the audio source is unavailable. It returns when ctx is cancelled.
+func (bn *BirdNET) KeepWarm(ctx context.Context, idle time.Duration) {
+	if idle <= 0 {
+		return
+	}
+	ticker := time.NewTicker(idle / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if bn.idleFor() < idle {
+				continue
+			}
+			if err := bn.dummyInference(ctx); err != nil {
+				bn.Debug("Keep warm inference failed: %v", err)
+			}
+		}
+	}
+}
+
+// idleFor returns the time since the last inference
+func (bn *BirdNET) idleFor() time.Duration {
+	bn.mu.Lock()
+	defer bn.mu.Unlock()
+	return time.Since(bn.lastInference)
+}
@@ -0,0 +1,52 @@
+package birdnet
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+func TestWarmupStatus(t *testing.T) {
+	t.Parallel()
+
+	settings := &conf.Settings{}
+	bn := &BirdNET{Settings: settings}
+	assert.Equal(t, WarmupDisabled, bn.WarmupStatus().State)
+	settings.BirdNET.Warmup.Enabled = true
+	assert.Equal(t, WarmupPending, bn.WarmupStatus().State)
+
+	// The first inference is slow, the next ones are fast
+	calls := 0
+	infer := func(context.Context) error {
+		calls++
+		if calls == 1 {
+			assert.Equal(t, WarmupRunning, bn.WarmupStatus().State)
+			time.Sleep(20 * time.Millisecond)
+		}
+		return nil
+	}
+	require.NoError(t, bn.runWarmup(context.Background(), 3, infer))
+	status := bn.WarmupStatus()
+	assert.Equal(t, WarmupReady, status.State)
+	assert.Equal(t, 3, status.Runs)
+	assert.GreaterOrEqual(t, status.FirstInferenceMs, 20.0)
+	assert.Less(t, status.LastInferenceMs, status.FirstInferenceMs)
+	assert.NotNil(t, status.CompletedAt)
+
+	failing := func(context.Context) error { return fmt.Errorf("tensor invoke failed") }
+	require.Error(t, bn.runWarmup(context.Background(), 2, failing))
+	status = bn.WarmupStatus()
+	assert.Equal(t, WarmupFailed, status.State)
+	assert.Zero(t, status.Runs)
+	assert.Equal(t, "tensor invoke failed", status.Error)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.ErrorIs(t, bn.runWarmup(ctx, 2, infer), context.Canceled)
+	assert.Equal(t, WarmupPending, bn.WarmupStatus().State, "a cancelled warm-up leaves the model cold")
+}
@@ -23,9 +23,11 @@ import (
 	"time"
 
 	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/databudget"
 	"github.com/tphakala/birdnet-go/internal/datastore"
 	"github.com/tphakala/birdnet-go/internal/errors"
 	"github.com/tphakala/birdnet-go/internal/logging" // Import the new logging package
+	"github.com/tphakala/birdnet-go/internal/manifest"
 	"github.com/tphakala/birdnet-go/internal/myaudio"
 )
 
@@ -114,7 +116,7 @@ func New(settings *conf.Settings) (*BwClient, error) {
 		Accuracy:      settings.Realtime.Birdweather.LocationAccuracy,
 		Latitude:      settings.BirdNET.Latitude,
 		Longitude:     settings.BirdNET.Longitude,
-		HTTPClient:    &http.Client{Timeout: 45 * time.Second},
+		HTTPClient:    &http.Client{Timeout: 45 * time.Second, Transport: databudget.Transport(databudget.Uploads, nil)},
 	}
 	return client, nil
 }
@@ -333,6 +335,7 @@ func handleHTTPResponse(resp *http.Response, expectedStatus int, operation, mask
 
 // encodeFlacUsingFFmpeg converts PCM data to FLAC format using FFmpeg directly into a bytes buffer.
 // It applies a simple gain adjustment instead of dynamic loudness normalization to avoid pumping effects.
+// Bit depth and compression level follow the BirdWeather encoding profile.
 // This avoids writing temporary files to disk.
 // It accepts a context for timeout/cancellation control and the explicit path to the FFmpeg executable.
 func encodeFlacUsingFFmpeg(ctx context.Context, pcmData []byte, ffmpegPath string, settings *conf.Settings) (*bytes.Buffer, error) {
@@ -346,6 +349,10 @@ func encodeFlacUsingFFmpeg(ctx context.Context, pcmData []byte, ffmpegPath strin
 	// ffmpegPath is now passed directly
 	serviceLogger.Debug("Using ffmpeg path", "path", ffmpegPath)
 
+	profile := myaudio.EncodingProfileFor(&settings.Realtime.Audio, myaudio.DestinationBirdWeather)
+	profile.Type = "flac"
+	encoderArgs := myaudio.EncoderArgs(profile)
+
 	// --- Pass 1: Analyze Loudness ---
 	// Use the provided context for the analysis
 	serviceLogger.Debug("Performing loudness analysis (Pass 1)")
@@ -362,11 +369,7 @@ func encodeFlacUsingFFmpeg(ctx context.Context, pcmData []byte, ffmpegPath strin
 		// A fixed gain of 15dB is a reasonable middle ground for bird call recordings
 		gainValue := 15.0
 		volumeArgs := fmt.Sprintf("volume=%.1fdB", gainValue)
-		customArgs := []string{
-			"-af", volumeArgs, // Simple gain adjustment
-			"-c:a", "flac",
-			"-f", "flac",
-		}
+		customArgs := append([]string{"-af", volumeArgs}, encoderArgs...) // Simple gain adjustment
 
 		// Use the provided context for the fallback export operation
 		serviceLogger.Debug("Starting fallback FLAC export with fixed gain", "gain_db", gainValue)
@@ -411,11 +414,7 @@ func encodeFlacUsingFFmpeg(ctx context.Context, pcmData []byte, ffmpegPath strin
 	// Use simple volume filter instead of loudnorm
 	volumeArgs := fmt.Sprintf("volume=%.2fdB", gainNeeded)
 
-	customArgs := []string{
-		"-af", volumeArgs, // Simple gain adjustment filter
-		"-c:a", "flac", // Output codec: FLAC
-		"-f", "flac", // Output format: FLAC
-	}
+	customArgs := append([]string{"-af", volumeArgs}, encoderArgs...) // Simple gain adjustment filter, FLAC output
 
 	// Use the provided context for the final encoding operation
 	buffer, err := myaudio.ExportAudioWithCustomFFmpegArgsContext(ctx, pcmData, ffmpegPath, customArgs)
@@ -495,8 +494,23 @@ func (b *BwClient) UploadSoundscape(timestamp string, pcmData []byte) (soundscap
 	ffmpegAvailable := ffmpegPathForExec != ""
 	serviceLogger.Debug("Checking FFmpeg availability", "path", ffmpegPathForExec, "available", ffmpegAvailable)
 
-	// Use FLAC if FFmpeg is available, otherwise fall back to WAV
-	if ffmpegAvailable {
+	// Use FLAC unless the BirdWeather profile asks for WAV, encoded natively when FFmpeg is not available
+	profile := myaudio.EncodingProfileFor(&b.Settings.Realtime.Audio, myaudio.DestinationBirdWeather)
+	if profile.Type == "wav" {
+		serviceLogger.Debug("Encoding to WAV as configured", "timestamp", timestamp, "bit_depth", profile.BitDepth)
+		audioBuffer, audioExt, err = myaudio.EncodeForDestination(ctx, pcmData, &b.Settings.Realtime.Audio, myaudio.DestinationBirdWeather)
+		if err != nil {
+			enhancedErr := errors.New(err).
+				Component("birdweather").
+				Category(errors.CategoryAudio).
+				Context("timestamp", timestamp).
+				Context("encoding_format", "wav").
+				Build()
+			serviceLogger.Error("Failed to encode PCM to WAV", "timestamp", timestamp, "error", err)
+			return "", enhancedErr
+		}
+		serviceLogger.Info("Using WAV format for upload", "timestamp", timestamp)
+	} else if ffmpegAvailable {
 		// Encode PCM data to FLAC format with normalization, passing the context and validated path
 		audioBuffer, err = encodeFlacUsingFFmpeg(ctx, pcmData, ffmpegPathForExec, b.Settings)
 		if err != nil {
@@ -530,24 +544,21 @@ func (b *BwClient) UploadSoundscape(timestamp string, pcmData []byte) (soundscap
 			serviceLogger.Info("Using FLAC format for upload", "timestamp", timestamp)
 		}
 	} else {
-		log.Println("🔊 FFmpeg not available (checked configured path and system PATH), encoding to WAV format")
-		serviceLogger.Info("FFmpeg not available, encoding to WAV format", "timestamp", timestamp)
-		// Encode PCM data to WAV format using a dedicated context
-		wavCtx, cancelWav := context.WithTimeout(context.Background(), 30*time.Second) // Fresh timeout for WAV
-		defer cancelWav()
-		audioBuffer, err = myaudio.EncodePCMtoWAVWithContext(wavCtx, pcmData)
+		log.Println("🔊 FFmpeg not available (checked configured path and system PATH), encoding to FLAC natively")
+		serviceLogger.Info("FFmpeg not available, encoding to FLAC natively", "timestamp", timestamp)
+		audioBuffer, err = myaudio.EncodePCMtoFLAC(pcmData, profile)
 		if err != nil {
 			enhancedErr := errors.New(err).
 				Component("birdweather").
 				Category(errors.CategoryAudio).
 				Context("timestamp", timestamp).
-				Context("encoding_format", "wav").
+				Context("encoding_format", "flac").
 				Build()
-			serviceLogger.Error("Failed to encode PCM to WAV", "timestamp", timestamp, "error", err)
+			serviceLogger.Error("Failed to encode PCM to FLAC", "timestamp", timestamp, "error", err)
 			return "", enhancedErr
 		}
-		audioExt = "wav"
-		serviceLogger.Info("Using WAV format for upload", "timestamp", timestamp)
+		audioExt = "flac"
+		serviceLogger.Info("Using natively encoded FLAC format for upload", "timestamp", timestamp)
 	}
 
 	// If debug is enabled, save the audio file locally with timestamp information
@@ -577,6 +588,13 @@ func (b *BwClient) UploadSoundscape(timestamp string, pcmData []byte) (soundscap
 		}
 	}
 
+	// Keep the checksum of the uploaded audio for the manifest
+	var audioChecksum string
+	audioSize := int64(audioBuffer.Len())
+	if manifest.Enabled() {
+		audioChecksum = manifest.Checksum(audioBuffer.Bytes())
+	}
+
 	// Compress the audio data
 	var gzipAudioData bytes.Buffer
 	gzipWriter := gzip.NewWriter(&gzipAudioData)
@@ -660,6 +678,15 @@ func (b *BwClient) UploadSoundscape(timestamp string, pcmData []byte) (soundscap
 	}
 
 	soundscapeID = fmt.Sprintf("%d", sdata.Soundscape.ID)
+	if audioChecksum != "" {
+		manifest.Record(&datastore.ManifestEntry{
+			Kind:        manifest.KindBirdWeather,
+			Name:        fmt.Sprintf("soundscape %s.%s", timestamp, audioExt),
+			Destination: "birdweather:soundscape/" + soundscapeID,
+			SHA256:      audioChecksum,
+			Size:        audioSize,
+		})
+	}
 	serviceLogger.Info("Soundscape uploaded successfully", "timestamp", timestamp, "soundscape_id", soundscapeID, "url", maskedURL)
 	return soundscapeID, nil
 }
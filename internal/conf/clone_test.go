@@ -0,0 +1,35 @@
+package conf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSettingsClone(t *testing.T) {
+	t.Parallel()
+
+	settings := &Settings{}
+	settings.BirdNET.Locale = "en-uk"
+	settings.Realtime.Species.Include = []string{"Parus major"}
+	settings.Realtime.Species.Sensitive = []string{"Circus cyaneus"}
+	settings.Realtime.Species.Config = map[string]SpeciesConfig{
+		"great tit": {Threshold: 0.7, Actions: []SpeciesAction{{Type: "ExecuteCommand"}}},
+	}
+
+	clone := settings.Clone()
+	require.Equal(t, settings, clone)
+
+	clone.BirdNET.Locale = "fi"
+	clone.Realtime.Species.Include[0] = "Turdus merula"
+	clone.Realtime.Species.Sensitive = append(clone.Realtime.Species.Sensitive, "Bubo bubo")
+	clone.Realtime.Species.Config["great tit"].Actions[0].Type = "Notify"
+	clone.Realtime.Species.Config["blackbird"] = SpeciesConfig{Threshold: 0.5}
+
+	assert.Equal(t, "en-uk", settings.BirdNET.Locale)
+	assert.Equal(t, []string{"Parus major"}, settings.Realtime.Species.Include)
+	assert.Equal(t, []string{"Circus cyaneus"}, settings.Realtime.Species.Sensitive)
+	assert.Equal(t, "ExecuteCommand", settings.Realtime.Species.Config["great tit"].Actions[0].Type)
+	assert.Len(t, settings.Realtime.Species.Config, 1)
+}
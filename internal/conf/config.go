@@ -47,16 +47,64 @@ type EqualizerSettings struct {
 }
 
 type ExportSettings struct {
-	Debug         bool                  `json:"debug" mapstructure:"debug"`                 // true to enable audio export debug
-	Enabled       bool                  `json:"enabled" mapstructure:"enabled"`             // export audio clips containing indentified bird calls
-	Path          string                `json:"path" mapstructure:"path"`                   // path to audio clip export directory
-	Type          string                `json:"type" mapstructure:"type"`                   // audio file type, wav, mp3 or flac
-	Bitrate       string                `json:"bitrate" mapstructure:"bitrate"`             // bitrate for audio export
-	Retention     RetentionSettings     `json:"retention" mapstructure:"retention"`         // retention settings
-	Length        int                   `json:"length" mapstructure:"length"`               // audio capture length in seconds
-	PreCapture    int                   `json:"preCapture" mapstructure:"preCapture"`       // pre-capture in seconds
-	Gain          float64               `json:"gain" mapstructure:"gain"`                   // gain in dB for audio capture
-	Normalization NormalizationSettings `json:"normalization" mapstructure:"normalization"` // audio normalization settings (EBU R128)
+	Debug            bool                   `json:"debug" mapstructure:"debug"`                       // true to enable audio export debug
+	Enabled          bool                   `json:"enabled" mapstructure:"enabled"`                   // export audio clips containing indentified bird calls
+	Path             string                 `json:"path" mapstructure:"path"`                         // path to audio clip export directory
+	Type             string                 `json:"type" mapstructure:"type"`                         // audio file type, wav, mp3 or flac
+	Bitrate          string                 `json:"bitrate" mapstructure:"bitrate"`                   // bitrate for audio export
+	BitDepth         int                    `json:"bitDepth" mapstructure:"bitdepth"`                 // bits per sample of wav and flac exports, 16 or 24
+	CompressionLevel int                    `json:"compressionLevel" mapstructure:"compressionlevel"` // flac compression level, 0 (fastest) to 12 (smallest)
+	Destinations     ExportDestinations     `json:"destinations" mapstructure:"destinations"`         // encoding of audio sent elsewhere than local storage
+	Snippet          SnippetSettings        `json:"snippet" mapstructure:"snippet"`                   // compressed snippet of every detection while export is disabled
+	Retention        RetentionSettings      `json:"retention" mapstructure:"retention"`               // retention settings
+	Length           int                    `json:"length" mapstructure:"length"`                     // audio capture length in seconds
+	PreCapture       int                    `json:"preCapture" mapstructure:"preCapture"`             // pre-capture in seconds
+	Gain             float64                `json:"gain" mapstructure:"gain"`                         // gain in dB for audio capture
+	Normalization    NormalizationSettings  `json:"normalization" mapstructure:"normalization"`       // audio normalization settings (EBU R128)
+	DiskProtection   DiskProtectionSettings `json:"diskProtection" mapstructure:"diskProtection"`     // low disk space protective mode
+	Writer           ClipWriterSettings     `json:"writer" mapstructure:"writer"`                     // asynchronous clip writer
+}
+
+// ExportDestinations contains the encoding of audio per destination other than
+// local storage, which uses the type, bitrate, bit depth and compression level
+// of the export settings
+type ExportDestinations struct {
+	BirdWeather  EncodingProfile `json:"birdweather" mapstructure:"birdweather"`   // soundscapes uploaded to BirdWeather, wav or flac
+	Notification EncodingProfile `json:"notification" mapstructure:"notification"` // clips attached to notifications
+}
+
+// SnippetSettings controls the short, heavily compressed snippet retained for
+// every detection while full clip export is disabled, so detections can still
+// be reviewed later with minimal disk usage
+type SnippetSettings struct {
+	Enabled bool   `json:"enabled" mapstructure:"enabled"` // true to retain a snippet when clips are not exported
+	Length  int    `json:"length" mapstructure:"length"`   // snippet length in seconds, centered on the detection
+	Type    string `json:"type" mapstructure:"type"`       // opus, aac or mp3; FLAC is used without FFmpeg
+	Bitrate string `json:"bitrate" mapstructure:"bitrate"` // bitrate, e.g. 24k
+}
+
+// EncodingProfile describes how audio is encoded for one destination
+type EncodingProfile struct {
+	Type             string `json:"type" mapstructure:"type"`                         // wav, flac, aac, opus or mp3
+	BitDepth         int    `json:"bitDepth" mapstructure:"bitdepth"`                 // bits per sample of wav and flac, 16 or 24
+	CompressionLevel int    `json:"compressionLevel" mapstructure:"compressionlevel"` // flac compression level, 0 (fastest) to 12 (smallest)
+	Bitrate          string `json:"bitrate" mapstructure:"bitrate"`                   // bitrate of aac, opus and mp3, e.g. 96k
+}
+
+// DiskProtectionSettings controls the protective mode entered when free disk space runs low.
+// While active, audio clips are not written but detection metadata is still stored.
+type DiskProtectionSettings struct {
+	Enabled         bool   `json:"enabled" mapstructure:"enabled"`                 // true to enable disk-full protective mode
+	MinFreeSpace    string `json:"minFreeSpace" mapstructure:"minfreespace"`       // free space floor that activates protective mode, e.g. "1GB"
+	ResumeFreeSpace string `json:"resumeFreeSpace" mapstructure:"resumefreespace"` // free space required to leave protective mode, e.g. "2GB"
+	CheckInterval   int    `json:"checkInterval" mapstructure:"checkinterval"`     // free space check interval in seconds
+}
+
+// ClipWriterSettings controls the queue of clips waiting to be encoded and
+// written to disk, so slow storage does not hold up detection processing
+type ClipWriterSettings struct {
+	QueueSize  int    `json:"queueSize" mapstructure:"queuesize"`   // clips waiting to be written before clips are dropped
+	DropPolicy string `json:"dropPolicy" mapstructure:"droppolicy"` // clip dropped when the queue is full: "oldest" or "newest"
 }
 
 // NormalizationSettings contains audio normalization configuration based on EBU R128 standard
@@ -87,21 +135,56 @@ type SoundLevelSettings struct {
 }
 
 type AudioSettings struct {
-	Source          string             `yaml:"source" mapstructure:"source" json:"source"`                   // audio source to use for analysis
-	FfmpegPath      string             `yaml:"ffmpegpath" mapstructure:"ffmpegpath" json:"ffmpegPath"`       // path to ffmpeg, runtime value
-	FfmpegVersion   string             `yaml:"-" json:"ffmpegVersion,omitempty"`                             // ffmpeg version string, runtime value
-	FfmpegMajor     int                `yaml:"-" json:"ffmpegMajor,omitempty"`                               // ffmpeg major version number, runtime value
-	FfmpegMinor     int                `yaml:"-" json:"ffmpegMinor,omitempty"`                               // ffmpeg minor version number, runtime value
-	SoxPath         string             `yaml:"soxpath" mapstructure:"soxpath" json:"soxPath"`                // path to sox, runtime value
-	SoxAudioTypes   []string           `yaml:"-" json:"-"`                                                   // supported audio types of sox, runtime value
-	StreamTransport string             `json:"streamTransport"`                                              // preferred transport for audio streaming: "auto", "sse", or "ws"
-	Export          ExportSettings     `json:"export"`                                                       // export settings
-	SoundLevel      SoundLevelSettings `json:"soundLevel"`                                                   // sound level monitoring settings
-	UseAudioCore    bool               `yaml:"useaudiocore" mapstructure:"useaudiocore" json:"useAudioCore"` // true to use new audiocore package instead of myaudio
+	Source          string              `yaml:"source" mapstructure:"source" json:"source"`                   // audio source to use for analysis
+	FfmpegPath      string              `yaml:"ffmpegpath" mapstructure:"ffmpegpath" json:"ffmpegPath"`       // path to ffmpeg, runtime value
+	FfmpegVersion   string              `yaml:"-" json:"ffmpegVersion,omitempty"`                             // ffmpeg version string, runtime value
+	FfmpegMajor     int                 `yaml:"-" json:"ffmpegMajor,omitempty"`                               // ffmpeg major version number, runtime value
+	FfmpegMinor     int                 `yaml:"-" json:"ffmpegMinor,omitempty"`                               // ffmpeg minor version number, runtime value
+	SoxPath         string              `yaml:"soxpath" mapstructure:"soxpath" json:"soxPath"`                // path to sox, runtime value
+	SoxAudioTypes   []string            `yaml:"-" json:"-"`                                                   // supported audio types of sox, runtime value
+	StreamTransport string              `json:"streamTransport"`                                              // preferred transport for audio streaming: "auto", "sse", or "ws"
+	Export          ExportSettings      `json:"export"`                                                       // export settings
+	SoundLevel      SoundLevelSettings  `json:"soundLevel"`                                                   // sound level monitoring settings
+	UseAudioCore    bool                `yaml:"useaudiocore" mapstructure:"useaudiocore" json:"useAudioCore"` // true to use new audiocore package instead of myaudio
+	Channel         string              `json:"channel"`                                                      // channel of a stereo sound card to analyze: "mix", "left", "right" or "split"
+	DOA             DOASettings         `json:"doa"`                                                          // direction of arrival estimation
+	CaptureRate     CaptureRateSettings `json:"captureRate"`                                                  // capture above the analyzed sample rate
 
 	Equalizer EqualizerSettings `json:"equalizer"` // equalizer settings
 }
 
+// CaptureRateSettings contains settings for capturing a source above the 48 kHz
+// sample rate analyzed by BirdNET. The audio is decimated to 48 kHz for
+// analysis; detection clips may keep the original rate.
+type CaptureRateSettings struct {
+	SampleRate      int  `json:"sampleRate"`      // capture sample rate in Hz, 48000 or a multiple of it up to MaxCaptureSampleRate
+	ArchiveOriginal bool `json:"archiveOriginal"` // true to save WAV and FLAC detection clips at the capture sample rate
+}
+
+// RTSPCaptureRate sets the capture sample rate of one RTSP stream
+type RTSPCaptureRate struct {
+	URL             string `json:"url"`             // RTSP stream URL as listed in urls
+	SampleRate      int    `json:"sampleRate"`      // capture sample rate in Hz, 48000 or a multiple of it up to MaxCaptureSampleRate
+	ArchiveOriginal bool   `json:"archiveOriginal"` // true to save WAV and FLAC detection clips at the capture sample rate
+}
+
+// DOASettings contains settings for estimating the bearing of a calling bird
+// from the time difference of arrival between the two microphones of a
+// synchronized stereo sound card
+type DOASettings struct {
+	Enabled     bool    `json:"enabled"`     // true to estimate and store a bearing per detection
+	MicSpacing  float64 `json:"micSpacing"`  // distance between the left and right microphone in meters
+	Orientation float64 `json:"orientation"` // compass bearing in degrees the array faces, perpendicular to the left to right microphone axis
+}
+
+// Channels of a stereo sound card to analyze
+const (
+	AudioChannelMix   = "mix"   // both channels mixed to mono
+	AudioChannelLeft  = "left"  // left channel only
+	AudioChannelRight = "right" // right channel only
+	AudioChannelSplit = "split" // each channel analyzed as a separate source
+)
+
 // NeedsFfprobeWorkaround returns true if the current FFmpeg version requires
 // using ffprobe to get audio file length for spectrograms (FFmpeg 5.x bug).
 // FFmpeg 7.x and later have this issue fixed.
@@ -120,8 +203,10 @@ type Thumbnails struct {
 	Debug          bool   `json:"debug"`          // true to enable debug mode
 	Summary        bool   `json:"summary"`        // show thumbnails on summary table
 	Recent         bool   `json:"recent"`         // show thumbnails on recent table
-	ImageProvider  string `json:"imageProvider"`  // preferred image provider: "auto", "wikimedia", "avicommons"
+	ImageProvider  string `json:"imageProvider"`  // preferred image provider: "auto", "wikimedia", "avicommons", "localbundle"
 	FallbackPolicy string `json:"fallbackPolicy"` // fallback policy: "none", "all" - try all available providers if preferred fails
+	ImageBundle    string `json:"imageBundle"`    // path to an offline image bundle directory or tarball, empty to disable
+	Prefetch       bool   `json:"prefetch"`       // pre-fetch the images of all species in the range filter in the background
 }
 
 // Dashboard contains settings for the web dashboard.
@@ -170,24 +255,162 @@ type EBirdSettings struct {
 
 // WeatherSettings contains all weather-related settings
 type WeatherSettings struct {
-	Provider     string               `json:"provider"`     // "none", "yrno", "openweather", or "wunderground"
-	PollInterval int                  `json:"pollInterval"` // weather data polling interval in minutes
-	Debug        bool                 `json:"debug"`        // true to enable debug mode
-	OpenWeather  OpenWeatherSettings  `json:"openWeather"`  // OpenWeather integration settings
-	Wunderground WundergroundSettings `json:"wunderground"` // WeatherUnderground integration settings
+	Provider     string                  `json:"provider"`     // "none", "yrno", "openweather", or "wunderground"
+	PollInterval int                     `json:"pollInterval"` // weather data polling interval in minutes
+	Debug        bool                    `json:"debug"`        // true to enable debug mode
+	OpenWeather  OpenWeatherSettings     `json:"openWeather"`  // OpenWeather integration settings
+	Wunderground WundergroundSettings    `json:"wunderground"` // WeatherUnderground integration settings
+	Adaptive     WeatherAdaptiveSettings `json:"adaptive"`     // Weather-adaptive confidence threshold settings
+}
+
+// WeatherAdaptiveSettings raises the confidence threshold from the latest
+// weather reading while wind noise and rain make false positives likely
+type WeatherAdaptiveSettings struct {
+	Enabled        bool    `json:"enabled"`        // true to adjust the threshold to wind and rain
+	WindMin        float64 `json:"windMin"`        // wind speed in m/s above which the threshold is raised
+	WindMax        float64 `json:"windMax"`        // wind speed in m/s at which the full wind adjustment applies
+	WindAdjustment float64 `json:"windAdjustment"` // threshold increase at windMax and above
+	RainAdjustment float64 `json:"rainAdjustment"` // threshold increase while it rains
+	MaxThreshold   float64 `json:"maxThreshold"`   // highest threshold the adjustment raises to
+	MaxAge         int     `json:"maxAge"`         // minutes a weather reading is used for
 }
 
 // ---------------- Notification push configuration -----------------
 
 // NotificationConfig is the root for notification-specific settings.
 type NotificationConfig struct {
-	Push      PushSettings          `json:"push" yaml:"push"`
-	Templates NotificationTemplates `json:"templates" yaml:"templates"`
+	Push        PushSettings          `json:"push" yaml:"push"`
+	Templates   NotificationTemplates `json:"templates" yaml:"templates"`
+	Email       EmailSettings         `json:"email" yaml:"email"`
+	Rules       []NotificationRule    `json:"rules" yaml:"rules"` // detection notification rules, evaluated in order
+	DailyDigest DailyDigestSettings   `json:"daily_digest" yaml:"daily_digest" mapstructure:"daily_digest"`
+	Deliveries  DeliveryQueueSettings `json:"deliveries"`
+	Dedup       DedupSettings         `json:"dedup" yaml:"dedup"`
+}
+
+// DedupSettings collapses repeated notifications of the same species, or of
+// the same component and title, into the first one with an occurrence count.
+// Repeats update the notification in the UI but are not sent to the push
+// channels again.
+type DedupSettings struct {
+	Enabled bool          `json:"enabled"`
+	Window  time.Duration `json:"window"` // repeats within the window after the first notification are collapsed into it
+}
+
+// DeliveryQueueSettings configures the persistent queue of failed outbound
+// deliveries. Push notifications, MQTT messages and BirdWeather uploads that
+// still fail after their immediate retries are stored in the database and
+// retried with exponential backoff. Deliveries failing maxAttempts times are
+// kept as dead letters, to be inspected and retried by hand.
+type DeliveryQueueSettings struct {
+	Enabled      bool          `json:"enabled"`
+	MaxAttempts  int           `json:"max_attempts" mapstructure:"max_attempts"`   // queued attempts before a delivery is dead-lettered
+	InitialDelay time.Duration `json:"initial_delay" mapstructure:"initial_delay"` // delay before the first queued attempt, doubled after each attempt
+	MaxDelay     time.Duration `json:"max_delay" mapstructure:"max_delay"`         // longest delay between attempts
+	MaxPending   int           `json:"max_pending" mapstructure:"max_pending"`     // pending deliveries kept, later failures are dropped
+	Retention    time.Duration `json:"retention"`                                  // how long delivered and dead-lettered deliveries are kept
+}
+
+// DailyDigestSettings configures a single summary notification of the day's
+// detections, sent at a fixed time of day. It is rendered with the daily
+// digest templates.
+type DailyDigestSettings struct {
+	Enabled  bool   `json:"enabled"`
+	Time     string `json:"time"`                               // local time of day to send the digest, "HH:MM"; it covers the day until then
+	TopClips int    `json:"top_clips" mapstructure:"top_clips"` // number of highest confidence clips listed
+}
+
+// NotificationRule sends a detection notification when a detection matches
+// all of its conditions. Rules are evaluated in order and the first matching
+// rule sends the notification. Without rules, a notification is sent for the
+// first detection of each species.
+type NotificationRule struct {
+	Name          string        `json:"name"`
+	Enabled       bool          `json:"enabled"`
+	Species       []string      `json:"species"`                                      // common or scientific names or patterns like "*owl", empty for all species
+	NewSpecies    bool          `json:"new_species" mapstructure:"new_species"`       // true to match first detections of a species only
+	MinConfidence float64       `json:"min_confidence" mapstructure:"min_confidence"` // minimum confidence from 0 to 1
+	From          string        `json:"from"`                                         // start of the time of day window, "HH:MM", "sunrise", "sunset", "dawn" or "dusk"
+	Until         string        `json:"until"`                                        // end of the window, may be past midnight; empty from and until match all day
+	Channels      []string      `json:"channels"`                                     // push provider names to notify, empty for the push routes to decide
+	Priority      string        `json:"priority"`                                     // low, medium, high or critical, default high
+	Cooldown      time.Duration `json:"cooldown"`                                     // minimum time between notifications of a species by the rule
+	Title         string        `json:"title"`                                        // title template, empty for the default
+	Message       string        `json:"message"`                                      // message template, empty for the default
+}
+
+// NeedsAllDetections reports whether a notification rule may match detections
+// of known species, so that every detection has to be evaluated and not only
+// the first detections of new species
+func (n *NotificationConfig) NeedsAllDetections() bool {
+	for i := range n.Rules {
+		if n.Rules[i].Enabled && !n.Rules[i].NewSpecies {
+			return true
+		}
+	}
+	return false
+}
+
+// EmailSettings configures delivery of notifications by email over SMTP. The
+// email channel is registered as the push provider "email", so routes and
+// escalation policies can name it.
+type EmailSettings struct {
+	Enabled      bool              `json:"enabled"`
+	Host         string            `json:"host"`                                       // SMTP server host name
+	Port         int               `json:"port"`                                       // SMTP server port, usually 587 for STARTTLS and 465 for TLS
+	Security     string            `json:"security"`                                   // "starttls", "tls" or "none"
+	Username     string            `json:"username"`                                   // SMTP user, empty to send without authentication
+	Password     string            `json:"password"`                                   // Password value or ${ENV_VAR}
+	PasswordFile string            `json:"password_file" mapstructure:"password_file"` // Path to file containing the password
+	From         string            `json:"from"`                                       // sender address, e.g. "BirdNET-Go <birdnet@example.com>"
+	To           []string          `json:"to"`                                         // recipient addresses
+	Timeout      time.Duration     `json:"timeout"`                                    // timeout of sending one email
+	Types        EmailTypeSettings `json:"types"`                                      // notification types sent by email
+	Templates    EmailTemplates    `json:"templates"`                                  // empty templates use the built-in ones
+	QuietHours   QuietHoursConfig  `json:"quiet_hours" mapstructure:"quiet_hours"`     // notifications held overnight
+}
+
+// EmailTypeSettings enables email delivery per notification type
+type EmailTypeSettings struct {
+	Error       bool `json:"error"`
+	Warning     bool `json:"warning"`
+	Info        bool `json:"info"`
+	Detection   bool `json:"detection"` // detections, including new species alerts
+	System      bool `json:"system"`
+	RareSpecies bool `json:"rare_species" mapstructure:"rare_species"` // rare species alerts
+}
+
+// EmailTemplates are Go templates of the emails, executed with the notification
+type EmailTemplates struct {
+	Subject string `json:"subject"`
+	Text    string `json:"text"` // plain text body
+	HTML    string `json:"html"` // HTML body, escaped as HTML
 }
 
 // NotificationTemplates contains customizable notification message templates.
 type NotificationTemplates struct {
-	NewSpecies NewSpeciesTemplate `json:"newSpecies" yaml:"newspecies"`
+	NewSpecies  NewSpeciesTemplate  `json:"newSpecies" yaml:"newspecies"`
+	DailyDigest DailyDigestTemplate `json:"dailyDigest" yaml:"dailydigest"`
+	// Locale is the language of the built-in templates, such as "de"; empty
+	// uses the UI language. Templates left at their English defaults follow it.
+	Locale string `json:"locale" yaml:"locale"`
+	// Channels overrides the templates of a channel: ui, email, chatops or a
+	// push provider type such as telegram, webhook, ntfy, pushover or script
+	Channels map[string]ChannelTemplates `json:"channels" yaml:"channels"`
+}
+
+// ChannelTemplates contains the templates of one notification channel.
+// Empty templates use the shared ones.
+type ChannelTemplates struct {
+	NewSpecies  NewSpeciesTemplate  `json:"newSpecies" yaml:"newspecies"`
+	DailyDigest DailyDigestTemplate `json:"dailyDigest" yaml:"dailydigest"`
+}
+
+// DailyDigestTemplate contains templates for the daily digest notification.
+// Empty templates use the built-in ones.
+type DailyDigestTemplate struct {
+	Title   string `json:"title" yaml:"title"`
+	Message string `json:"message" yaml:"message"`
 }
 
 // NewSpeciesTemplate contains templates for new species detection notifications.
@@ -206,6 +429,26 @@ type PushSettings struct {
 	HealthCheck    HealthCheckConfig    `json:"health_check" mapstructure:"health_check"`
 	RateLimiting   RateLimitingConfig   `json:"rate_limiting" mapstructure:"rate_limiting"`
 	Providers      []PushProviderConfig `json:"providers"`
+	Routes         []PushRouteConfig    `json:"routes"` // routing rules, evaluated in order
+	Escalation     []EscalationPolicy   `json:"escalation"`
+}
+
+// EscalationPolicy re-sends notifications that stay unacknowledged, for
+// example critical errors of an unattended field station to an SMS gateway.
+type EscalationPolicy struct {
+	Name      string           `json:"name"`
+	Match     PushFilterConfig `json:"match"`     // notifications to escalate, critical priority when no priorities are set
+	After     time.Duration    `json:"after"`     // time without acknowledgement before escalating
+	Providers []string         `json:"providers"` // secondary provider names
+}
+
+// PushRouteConfig routes matching notifications to a set of providers.
+// The first route matching a notification selects its providers, which still
+// apply their own filters; notifications matching no route go to all providers.
+type PushRouteConfig struct {
+	Name      string           `json:"name"`
+	Match     PushFilterConfig `json:"match"`     // empty matches every notification
+	Providers []string         `json:"providers"` // provider names, empty to send to none
 }
 
 // CircuitBreakerConfig holds circuit breaker configuration.
@@ -232,10 +475,11 @@ type RateLimitingConfig struct {
 
 // PushProviderConfig configures a single push provider instance.
 type PushProviderConfig struct {
-	Type    string           `json:"type"`
-	Enabled bool             `json:"enabled"`
-	Name    string           `json:"name"`
-	Filter  PushFilterConfig `json:"filter"`
+	Type       string           `json:"type"`
+	Enabled    bool             `json:"enabled"`
+	Name       string           `json:"name"`
+	Filter     PushFilterConfig `json:"filter"`
+	QuietHours QuietHoursConfig `json:"quiet_hours" mapstructure:"quiet_hours"` // notifications held overnight
 	// Shoutrrr-specific
 	URLs    []string      `json:"urls"`
 	Timeout time.Duration `json:"timeout"`
@@ -246,7 +490,27 @@ type PushProviderConfig struct {
 	InputFormat string            `json:"input_format" mapstructure:"input_format"`
 	// Webhook-specific
 	Endpoints []WebhookEndpointConfig `json:"endpoints"`
-	Template  string                  `json:"template"` // Custom JSON template
+	Template  string                  `json:"template"`  // Custom JSON template
+	Broadcast bool                    `json:"broadcast"` // Send to every endpoint instead of failing over
+	// Telegram-specific
+	BotToken          string `json:"bot_token" mapstructure:"bot_token"`                   // Bot token value or ${ENV_VAR}
+	BotTokenFile      string `json:"bot_token_file" mapstructure:"bot_token_file"`         // Path to file containing the bot token
+	ChatID            string `json:"chat_id" mapstructure:"chat_id"`                       // Numeric chat ID or @channelusername
+	AttachClip        bool   `json:"attach_clip" mapstructure:"attach_clip"`               // Send the audio clip of detections
+	AttachSpectrogram bool   `json:"attach_spectrogram" mapstructure:"attach_spectrogram"` // Send the spectrogram of detections
+	// ntfy-specific
+	ServerURL     string `json:"server_url" mapstructure:"server_url"`           // ntfy server, default https://ntfy.sh
+	Topic         string `json:"topic"`                                          // topic the notifications are published to
+	AuthToken     string `json:"auth_token" mapstructure:"auth_token"`           // Access token value or ${ENV_VAR}
+	AuthTokenFile string `json:"auth_token_file" mapstructure:"auth_token_file"` // Path to file containing the access token
+	// Pushover-specific
+	UserKey         string        `json:"user_key" mapstructure:"user_key"`                 // User or group key value or ${ENV_VAR}
+	UserKeyFile     string        `json:"user_key_file" mapstructure:"user_key_file"`       // Path to file containing the user key
+	AppToken        string        `json:"app_token" mapstructure:"app_token"`               // Application API token value or ${ENV_VAR}
+	AppTokenFile    string        `json:"app_token_file" mapstructure:"app_token_file"`     // Path to file containing the application token
+	Device          string        `json:"device"`                                           // Optional device name, all devices when empty
+	EmergencyRetry  time.Duration `json:"emergency_retry" mapstructure:"emergency_retry"`   // Interval between emergency repeats, min 30s, default 60s
+	EmergencyExpire time.Duration `json:"emergency_expire" mapstructure:"emergency_expire"` // Stop repeating unacknowledged emergencies after, max 3h, default 1h
 }
 
 // WebhookEndpointConfig configures a single webhook endpoint.
@@ -256,6 +520,7 @@ type WebhookEndpointConfig struct {
 	Headers map[string]string      `json:"headers"` // Custom HTTP headers
 	Timeout time.Duration          `json:"timeout"` // Per-endpoint timeout (default: use provider timeout)
 	Auth    WebhookAuthConfig      `json:"auth"`    // Authentication configuration
+	Signing WebhookSigningConfig   `json:"signing"` // HMAC-SHA256 signature of the payload
 }
 
 // WebhookAuthConfig configures authentication for webhook requests.
@@ -284,6 +549,27 @@ type WebhookAuthConfig struct {
 	ValueFile  string `json:"value_file"`   // Path to file containing header value
 }
 
+// WebhookSigningConfig configures an HMAC-SHA256 signature of the request body,
+// sent as "sha256=<hex>" so receivers can verify that the request came from
+// BirdNET-Go. The secret supports the same sources as WebhookAuthConfig.
+type WebhookSigningConfig struct {
+	Secret     string `json:"secret"`                                 // Secret value or ${ENV_VAR}, empty to disable signing
+	SecretFile string `json:"secret_file" mapstructure:"secret_file"` // Path to file containing the secret
+	Header     string `json:"header"`                                 // Signature header (default: X-BirdNET-Signature)
+}
+
+// QuietHoursConfig holds the notifications of a channel during a daily
+// window, delivering them when the window ends. Notifications of other types
+// and priorities are delivered as usual.
+type QuietHoursConfig struct {
+	Enabled    bool     `json:"enabled"`
+	From       string   `json:"from"`       // HH:MM local time the window starts
+	Until      string   `json:"until"`      // HH:MM local time the window ends, before from for windows spanning midnight
+	Mode       string   `json:"mode"`       // "hold" sends each held notification, "digest" one summary of them; default hold
+	Types      []string `json:"types"`      // notification types held, default detection
+	Priorities []string `json:"priorities"` // priorities held, default high and critical
+}
+
 // PushFilterConfig limits which notifications a provider receives.
 type PushFilterConfig struct {
 	Types           []string       `json:"types" mapstructure:"types"`
@@ -337,6 +623,18 @@ type RTSPSettings struct {
 	URLs             []string           `json:"urls"`             // RTSP stream URL
 	Health           RTSPHealthSettings `json:"health"`           // health monitoring settings
 	FFmpegParameters []string           `json:"ffmpegParameters"` // optional custom FFmpeg parameters
+	CaptureRates     []RTSPCaptureRate  `json:"captureRates"`     // streams captured above the analyzed sample rate
+}
+
+// CaptureRateFor returns the capture rate settings of an RTSP stream, the
+// analyzed sample rate for streams without their own
+func (s *RTSPSettings) CaptureRateFor(url string) CaptureRateSettings {
+	for _, rate := range s.CaptureRates {
+		if rate.URL == url {
+			return CaptureRateSettings{SampleRate: rate.SampleRate, ArchiveOriginal: rate.ArchiveOriginal}
+		}
+	}
+	return CaptureRateSettings{SampleRate: SampleRate}
 }
 
 // MQTTSettings contains settings for MQTT integration.
@@ -399,6 +697,209 @@ type SentrySettings struct {
 	Debug   bool `json:"debug"`   // true to enable transparent telemetry logging
 }
 
+// DataBudgetSettings contains settings for tracking outbound traffic against a
+// monthly data cap on metered connections such as LTE.
+type DataBudgetSettings struct {
+	Enabled           bool     `json:"enabled"`           // true to meter outbound traffic against the monthly cap
+	MonthlyCapMB      int      `json:"monthlyCapMB"`      // monthly data cap in megabytes
+	ThrottleThreshold int      `json:"throttleThreshold"` // percent of the cap above which non-essential traffic is deferred
+	ResetDay          int      `json:"resetDay"`          // day of month the billing period starts, 1-28
+	Essential         []string `json:"essential"`         // integrations allowed until the hard cap: uploads, images, weather
+}
+
+// EncryptionSettings contains settings for at-rest encryption of stored data
+type EncryptionSettings struct {
+	Clips    bool   `json:"clips"`    // true to encrypt saved audio clips with AES-256-GCM
+	Database bool   `json:"database"` // true to encrypt the SQLite database, requires a SQLCipher build
+	Key      string `json:"key"`      // 256-bit key as 64 hex characters or base64, or ${ENV_VAR}
+	KeyFile  string `json:"keyFile"`  // path to a file containing the key, takes precedence over key
+}
+
+// DataQualitySettings contains thresholds of the daily data quality report
+type DataQualitySettings struct {
+	Enabled          bool    `json:"enabled"`          // true to generate a data quality report for every day
+	GapMinutes       int     `json:"gapMinutes"`       // minutes without audio reported as a coverage gap
+	SilenceThreshold float64 `json:"silenceThreshold"` // RMS level in dBFS below which a minute counts as silent
+	SilentMinutes    int     `json:"silentMinutes"`    // consecutive silent minutes reported as a silent microphone
+	ClippingPercent  float64 `json:"clippingPercent"`  // percent of clipped samples above which audio is flagged as overdriven
+	RateLowPercent   int     `json:"rateLowPercent"`   // detections below this percent of the baseline are flagged
+	RateHighPercent  int     `json:"rateHighPercent"`  // detections above this percent of the baseline are flagged
+	BaselineDays     int     `json:"baselineDays"`     // previous days averaged for the detection rate baseline
+
+	MicFailure  MicFailureSettings  `json:"micFailure"`  // Failed microphone detection
+	Calibration CalibrationSettings `json:"calibration"` // Calibration tone level tracking
+	Anomaly     AnomalySettings     `json:"anomaly"`     // Hourly detection rate anomalies
+	Absence     AbsenceSettings     `json:"absence"`     // Prolonged absences of usually daily species
+}
+
+// AnomalySettings contains settings for flagging hours with unusually many or
+// few detections compared to the same hour on previous days
+type AnomalySettings struct {
+	Enabled      bool    `json:"enabled"`      // true to notify about detection rate anomalies
+	BaselineDays int     `json:"baselineDays"` // previous days the typical rate of each hour is learned from
+	Threshold    float64 `json:"threshold"`    // standard deviations from the typical rate flagged as an anomaly
+	MinSpike     int     `json:"minSpike"`     // fewest detections in an hour flagged as a spike
+	MinExpected  float64 `json:"minExpected"`  // fewest typical detections of an hour for flagging a silence
+	Species      bool    `json:"species"`      // true to also flag spikes of individual species
+}
+
+// AbsenceSettings contains settings for notifying when a species detected on
+// most days has not been detected for several days
+type AbsenceSettings struct {
+	Enabled      bool     `json:"enabled"`      // true to notify about absences of usually daily species
+	Days         int      `json:"days"`         // days without detections of a species notified as an absence
+	BaselineDays int      `json:"baselineDays"` // days before the absence the usual presence is learned from
+	MinPresence  float64  `json:"minPresence"`  // fraction of the baseline days a species must be detected on to be expected
+	Species      []string `json:"species"`      // scientific or common names of the species watched, empty for all
+}
+
+// DetectionRetentionSettings contains policies for replacing old detections
+// with hourly aggregates that preserve long-term statistics
+type DetectionRetentionSettings struct {
+	Enabled  bool                       `json:"enabled"`  // true to thin old detections daily
+	Policies []DetectionRetentionPolicy `json:"policies"` // thinning policies, applied in order
+}
+
+// ForecastSettings contains settings for the species occurrence forecast
+// predicted from the local phenology of past years and the range filter
+type ForecastSettings struct {
+	Days          int     `json:"days"`          // days ahead covered by the forecast
+	Years         int     `json:"years"`         // past years of local detections the phenology is learned from
+	MinLikelihood float64 `json:"minLikelihood"` // likelihood (0-1) below which species are left out
+	Notify        bool    `json:"notify"`        // true to send the birds to expect as a weekly notification
+	NotifyWeekday string  `json:"notifyWeekday"` // day of the week the notification is sent, e.g. "monday"
+	NotifyHour    int     `json:"notifyHour"`    // hour of the day the notification is sent, 0-23
+	NotifyLimit   int     `json:"notifyLimit"`   // most species listed in the notification
+}
+
+// OnThisDaySettings contains settings for the highlights of past years on
+// today's date
+type OnThisDaySettings struct {
+	Years             int  `json:"years"`             // past years looked at
+	RareMaxDetections int  `json:"rareMaxDetections"` // species with at most this many detections ever are rarities
+	Notify            bool `json:"notify"`            // true to send the highlights as a morning notification
+	NotifyHour        int  `json:"notifyHour"`        // hour of the day the notification is sent, 0-23
+}
+
+// NewsletterSettings contains settings for the weekly newsletter, an HTML page
+// with charts of the species counts, new arrivals and the comparison with the
+// week before
+type NewsletterSettings struct {
+	Enabled    bool   `json:"enabled"`    // true to generate a newsletter after every week
+	Hour       int    `json:"hour"`       // hour of the day on Monday the newsletter of the past week is generated, 0-23
+	Path       string `json:"path"`       // directory the newsletters are written to
+	TopSpecies int    `json:"topSpecies"` // species shown in the species count chart
+}
+
+// TrashSettings contains settings for the trash of deleted detections
+type TrashSettings struct {
+	Enabled       bool `json:"enabled"`       // true to move deleted detections to the trash instead of removing them
+	RetentionDays int  `json:"retentionDays"` // days a deleted detection stays in the trash before it is purged
+}
+
+// ManifestSettings contains settings for the manifest of uploads and exports
+type ManifestSettings struct {
+	Enabled bool `json:"enabled"` // true to record the SHA-256 checksum and destination of every BirdWeather upload, backup and export
+}
+
+// TimeLapseSettings contains settings for the daily soundscape time-lapse, an
+// audio montage with one clip of every hour with detections and a strip of
+// their spectrograms
+type TimeLapseSettings struct {
+	Enabled        bool    `json:"enabled"`        // true to generate a time-lapse after every day
+	Hour           int     `json:"hour"`           // hour of the day the time-lapse of the previous day is generated, 0-23
+	Path           string  `json:"path"`           // directory the time-lapses are written to
+	SegmentSeconds float64 `json:"segmentSeconds"` // length of the clip of each hour
+	Notify         bool    `json:"notify"`         // true to send a notification with the time-lapse files
+}
+
+// ChatOpsSettings contains settings for the Slack and Discord slash commands,
+// such as "/birds today", and for posting new species alerts to a channel
+type ChatOpsSettings struct {
+	Slack   SlackChatOpsSettings   `json:"slack"`
+	Discord DiscordChatOpsSettings `json:"discord"`
+}
+
+// SlackChatOpsSettings contains settings for the Slack slash command
+type SlackChatOpsSettings struct {
+	Enabled           bool   `json:"enabled"`           // true to answer slash commands
+	SigningSecret     string `json:"signingSecret"`     // app signing secret, value or ${ENV_VAR}
+	SigningSecretFile string `json:"signingSecretFile"` // path to file containing the signing secret
+	AlertWebhookURL   string `json:"alertWebhookUrl"`   // incoming webhook new species alerts are posted to, empty to disable
+}
+
+// DiscordChatOpsSettings contains settings for the Discord slash command
+type DiscordChatOpsSettings struct {
+	Enabled         bool   `json:"enabled"`         // true to answer slash commands
+	PublicKey       string `json:"publicKey"`       // hex encoded application public key verifying interactions
+	AlertWebhookURL string `json:"alertWebhookUrl"` // channel webhook new species alerts are posted to, empty to disable
+}
+
+// AlertsEnabled reports whether new species alerts are posted to a chat channel
+func (c *ChatOpsSettings) AlertsEnabled() bool {
+	return c.Slack.AlertWebhookURL != "" || c.Discord.AlertWebhookURL != ""
+}
+
+// DetectionRetentionPolicy selects detections older than a given age that are
+// replaced by hourly aggregates
+type DetectionRetentionPolicy struct {
+	Name          string   `json:"name"`          // name shown in logs
+	MaxAgeDays    int      `json:"maxAgeDays"`    // detections older than this many days are thinned
+	Species       []string `json:"species"`       // common or scientific names, all species when empty
+	MinDetections int      `json:"minDetections"` // only thin species with at least this many detections, 0 for all
+}
+
+// CalibrationSettings contains settings for tracking the measured level of a
+// periodic calibration tone to notice drift of the input gain
+type CalibrationSettings struct {
+	Enabled        bool    `json:"enabled"`        // true to detect calibration tones and track their level
+	Frequency      float64 `json:"frequency"`      // frequency of the calibration tone in Hz
+	MinSeconds     int     `json:"minSeconds"`     // shortest tone in seconds counted as a calibration tone
+	ReferenceLevel float64 `json:"referenceLevel"` // expected tone level in dBFS, 0 to use the first measurement
+	Tolerance      float64 `json:"tolerance"`      // drift from the reference level in dB that raises a notification
+}
+
+// MicFailureSettings contains settings for telling a failed microphone from a
+// quiet one by the spectrum of its audio
+type MicFailureSettings struct {
+	Enabled       bool    `json:"enabled"`       // true to notify when a microphone has likely failed
+	WindowMinutes int     `json:"windowMinutes"` // minutes a failure must persist before notifying
+	HumPercent    float64 `json:"humPercent"`    // percent of spectral power at mains hum frequencies above which audio counts as hum
+}
+
+// FieldSyncSettings contains settings for delta sync of detections and clips
+// from field units to a central aggregator. A node can be a field unit, an
+// aggregator, or both.
+type FieldSyncSettings struct {
+	Enabled       bool               `json:"enabled"`       // true to push new detections to an aggregator
+	AggregatorURL string             `json:"aggregatorUrl"` // base URL of the aggregator, e.g. https://aggregator.example.org
+	Token         string             `json:"token"`         // bearer token issued by the aggregator for this unit
+	Interval      int                `json:"interval"`      // minutes between sync runs
+	BatchSize     int                `json:"batchSize"`     // detections sent per request
+	ChunkSizeKB   int                `json:"chunkSizeKB"`   // clip upload chunk size in kilobytes
+	Clips         FieldSyncClips     `json:"clips"`         // which clips to upload with their detections
+	Aggregator    FieldSyncAggregate `json:"aggregator"`    // settings for receiving syncs from field units
+}
+
+// FieldSyncClips selects the audio clips a field unit uploads
+type FieldSyncClips struct {
+	Enabled       bool     `json:"enabled"`       // true to upload clips of synced detections
+	MinConfidence float64  `json:"minConfidence"` // only upload clips at or above this confidence, 0-1
+	Species       []string `json:"species"`       // only upload clips of these species (common or scientific name), all when empty
+}
+
+// FieldSyncAggregate contains the aggregator side of field sync
+type FieldSyncAggregate struct {
+	Enabled bool            `json:"enabled"` // true to accept syncs from field units
+	Units   []FieldSyncUnit `json:"units"`   // field units allowed to sync
+}
+
+// FieldSyncUnit identifies a field unit allowed to sync to this aggregator
+type FieldSyncUnit struct {
+	ID    string `json:"id"`    // unit identifier, stored as the detection source node
+	Token string `json:"token"` // bearer token the unit authenticates with
+}
+
 // RealtimeSettings contains all settings related to realtime processing.
 type RealtimeSettings struct {
 	Interval         int                      `json:"interval"`         // minimum interval between log messages in seconds
@@ -406,6 +907,8 @@ type RealtimeSettings struct {
 	Audio            AudioSettings            `json:"audio"`            // Audio processing settings
 	Dashboard        Dashboard                `json:"dashboard"`        // Dashboard settings
 	DynamicThreshold DynamicThresholdSettings `json:"dynamicThreshold"` // Dynamic threshold settings
+	TimePriors       TimePriorSettings        `json:"timePriors"`       // Time-of-day detection prior settings
+	RareSpecies      RareSpeciesSettings      `json:"rareSpecies"`      // Rare species alert settings
 	Log              struct {
 		Enabled bool   `json:"enabled"` // true to enable OBS chat log
 		Path    string `json:"path"`    // path to OBS chat log
@@ -423,6 +926,44 @@ type RealtimeSettings struct {
 	Species          SpeciesSettings          `json:"species"`          // Custom thresholds and actions for species
 	Weather          WeatherSettings          `json:"weather"`          // Weather provider related settings
 	SpeciesTracking  SpeciesTrackingSettings  `json:"speciesTracking"`  // New species tracking settings
+	Shutdown         ShutdownSettings         `json:"shutdown"`         // Graceful shutdown settings
+}
+
+// TimePriorSettings raises the confidence threshold of a species at hours of
+// the day it is rarely active, so nocturnal species reported at midday need a
+// higher confidence
+type TimePriorSettings struct {
+	Enabled       bool                 `json:"enabled"`       // true to apply time-of-day priors
+	Learn         bool                 `json:"learn"`         // true to learn priors from the stored detections
+	LearnDays     int                  `json:"learnDays"`     // days of stored detections the priors are learned from
+	MinDetections int                  `json:"minDetections"` // detections of a species needed to learn its prior
+	Adjustment    float64              `json:"adjustment"`    // threshold increase at hours with a prior of 0
+	MaxThreshold  float64              `json:"maxThreshold"`  // highest threshold the prior raises to
+	Species       map[string]TimePrior `json:"species"`       // configured priors by common or scientific name, used over learned ones
+}
+
+// TimePrior is the configured time-of-day prior of a species
+type TimePrior struct {
+	ActiveHours []int   `json:"activeHours"` // hours of the day (0-23) the species is active, with a prior of 1
+	Inactive    float64 `json:"inactive"`    // prior at the other hours, 0 for the full adjustment
+}
+
+// RareSpeciesSettings configures alerts of detections of species that are
+// locally unusual according to eBird frequency data
+type RareSpeciesSettings struct {
+	Enabled       bool    `json:"enabled"`       // true to alert of rare species
+	Source        string  `json:"source"`        // "ebird" to query the eBird API, "barchart" to read a bar chart download
+	BarChartFile  string  `json:"barChartFile"`  // eBird bar chart download of the region, used by the barchart source
+	RegionCode    string  `json:"regionCode"`    // eBird region of the ebird source, found from the coordinates when empty
+	MaxFrequency  float64 `json:"maxFrequency"`  // species with at most this expected frequency at the week of a detection are rare, 0-1
+	MinConfidence float64 `json:"minConfidence"` // minimum confidence of a detection to alert of
+	Cooldown      int     `json:"cooldown"`      // hours before the same species is alerted of again
+}
+
+// ShutdownSettings controls how pending work is drained when the application stops.
+type ShutdownSettings struct {
+	Timeout      int  `json:"timeout"`      // total time in seconds allowed for graceful shutdown
+	DrainPending bool `json:"drainPending"` // true to flush held detections and wait for queued jobs before exiting
 }
 
 // SpeciesAction represents a single action configuration
@@ -445,6 +986,19 @@ type SpeciesSettings struct {
 	Include []string                 `yaml:"include" json:"include"` // Always include these species
 	Exclude []string                 `yaml:"exclude" json:"exclude"` // Always exclude these species
 	Config  map[string]SpeciesConfig `yaml:"config" json:"config"`   // Per-species configuration
+	// Sensitive species are not uploaded to BirdWeather, keeping where they
+	// occur private; scientific or common names
+	Sensitive []string `yaml:"sensitive" json:"sensitive"`
+}
+
+// IsSensitive reports whether a species is in the sensitive species list
+func (s *SpeciesSettings) IsSensitive(scientificName, commonName string) bool {
+	for _, name := range s.Sensitive {
+		if strings.EqualFold(name, scientificName) || strings.EqualFold(name, commonName) {
+			return true
+		}
+	}
+	return false
 }
 
 // LogDeduplicationSettings contains settings for log deduplication
@@ -461,6 +1015,16 @@ type SpeciesTrackingSettings struct {
 	NotificationSuppressionHours int                      `json:"notificationSuppressionHours"` // Hours to suppress duplicate notifications (default: 168)
 	YearlyTracking               YearlyTrackingSettings   `json:"yearlyTracking"`               // Settings for yearly species tracking
 	SeasonalTracking             SeasonalTrackingSettings `json:"seasonalTracking"`             // Settings for seasonal species tracking
+	ContextCheck                 ContextCheckSettings     `json:"contextCheck"`                 // Cross-check of new species detections against the audio around them
+}
+
+// ContextCheckSettings re-analyzes the audio windows before and after a new
+// species detection and holds its notification back unless they corroborate it
+type ContextCheckSettings struct {
+	Enabled        bool    `json:"enabled"`        // true to cross-check new species detections before notifying
+	MinConfidence  float64 `json:"minConfidence"`  // confidence of the species in a neighbouring window that corroborates it
+	MinWindows     int     `json:"minWindows"`     // neighbouring windows, 1 or 2, that must corroborate it
+	SkipConfidence float64 `json:"skipConfidence"` // detections at or above this confidence are notified without the cross-check
 }
 
 // YearlyTrackingSettings contains settings for tracking first arrivals each year
@@ -724,8 +1288,32 @@ type BirdNETConfig struct {
 	RangeFilter RangeFilterSettings `json:"rangeFilter"` // range filter settings
 	ModelPath   string              `json:"modelPath"`   // path to external model file (empty for embedded)
 	LabelPath   string              `json:"labelPath"`   // path to external label file (empty for embedded)
+	AliasPath   string              `json:"aliasPath"`   // path to additional species alias file (empty for embedded only)
 	Labels      []string            `yaml:"-" json:"-"`  // list of available species labels, runtime value
 	UseXNNPACK  bool                `json:"useXnnpack"`  // true to use XNNPACK delegate for inference acceleration
+	Shadow      ShadowModelSettings `json:"shadow"`      // candidate model run in parallel before an upgrade
+	Warmup      WarmupSettings      `json:"warmup"`      // model warm-up at startup and while idle
+}
+
+// WarmupSettings contains settings for warming up the analysis model. The
+// first inference of a TensorFlow Lite interpreter is slow as buffers are
+// allocated and weights packed lazily, so dummy inferences run at startup
+// and after a model reload, and again whenever the model has been idle.
+type WarmupSettings struct {
+	Enabled  bool          `json:"enabled"`  // true to run dummy inferences at startup
+	Runs     int           `json:"runs"`     // number of dummy inferences at startup
+	KeepWarm time.Duration `json:"keepWarm"` // idle time after which a dummy inference keeps the model warm, 0 to disable
+}
+
+// ShadowModelSettings contains settings for a candidate model that analyzes the
+// live audio in parallel with the production model for a trial period. Its
+// detections are logged separately and never stored as detections.
+type ShadowModelSettings struct {
+	Enabled   bool    `json:"enabled"`   // true to run the candidate model
+	ModelPath string  `json:"modelPath"` // path to the candidate model file
+	LabelPath string  `json:"labelPath"` // path to the candidate label file (empty for the model's embedded labels)
+	Threshold float64 `json:"threshold"` // confidence threshold of the candidate, 0 to use the production threshold
+	TrialDays int     `json:"trialDays"` // days to run the candidate from its first detection, 0 for no limit
 }
 
 // RangeFilterSettings contains settings for the range filter
@@ -758,6 +1346,30 @@ type SocialProvider struct {
 	UserId       string `json:"userId"`       // valid user id for OAuth2
 }
 
+// OIDCProvider holds settings for a generic OpenID Connect identity provider,
+// such as Authelia, Keycloak or Google
+type OIDCProvider struct {
+	Enabled      bool     `json:"enabled"`      // true to enable OpenID Connect login
+	Name         string   `json:"name"`         // provider name shown on the login page
+	Issuer       string   `json:"issuer"`       // issuer URL, serving /.well-known/openid-configuration
+	ClientID     string   `json:"clientId"`     // client id registered at the provider
+	ClientSecret string   `json:"clientSecret"` // client secret registered at the provider
+	RedirectURI  string   `json:"redirectUri"`  // callback URL, empty for https://<host>/auth/oidc/callback
+	Scopes       []string `json:"scopes"`       // scopes to request, openid is always requested
+	UserId       string   `json:"userId"`       // comma separated user IDs or emails granted the admin role
+	GroupsClaim  string   `json:"groupsClaim"`  // ID token or userinfo claim listing the groups of the user
+	AdminGroups  []string `json:"adminGroups"`  // groups granted the admin role
+	EditorGroups []string `json:"editorGroups"` // groups granted the editor role, changing detections but not settings
+	ViewerGroups []string `json:"viewerGroups"` // groups granted the read-only viewer role
+}
+
+// RoleSettings limits users of the social and OpenID Connect logins to a role
+// below admin, which users not listed keep
+type RoleSettings struct {
+	Editors string `json:"editors"` // comma separated user IDs or emails granted the editor role
+	Viewers string `json:"viewers"` // comma separated user IDs or emails granted the read-only viewer role
+}
+
 type AllowSubnetBypass struct {
 	Enabled bool   `json:"enabled"` // true to enable subnet bypass
 	Subnet  string `json:"subnet"`  // disable OAuth2 in subnet
@@ -780,13 +1392,37 @@ type Security struct {
 	// Let's Encrypt. Requires Host to be set and port 80/443 access.
 	AutoTLS bool `json:"autoTls"`
 
-	RedirectToHTTPS   bool              `json:"redirectToHttps"`   // true to redirect to HTTPS
-	AllowSubnetBypass AllowSubnetBypass `json:"allowSubnetBypass"` // subnet bypass configuration
-	BasicAuth         BasicAuth         `json:"basicAuth"`         // password authentication configuration
-	GoogleAuth        SocialProvider    `json:"googleAuth"`        // Google OAuth2 configuration
-	GithubAuth        SocialProvider    `json:"githubAuth"`        // Github OAuth2 configuration
-	SessionSecret     string            `json:"sessionSecret"`     // secret for session cookie
-	SessionDuration   time.Duration     `json:"sessionDuration"`   // duration for browser session cookies
+	RedirectToHTTPS   bool                    `json:"redirectToHttps"`   // true to redirect to HTTPS
+	AllowSubnetBypass AllowSubnetBypass       `json:"allowSubnetBypass"` // subnet bypass configuration
+	BasicAuth         BasicAuth               `json:"basicAuth"`         // password authentication configuration
+	GoogleAuth        SocialProvider          `json:"googleAuth"`        // Google OAuth2 configuration
+	GithubAuth        SocialProvider          `json:"githubAuth"`        // Github OAuth2 configuration
+	OIDCAuth          OIDCProvider            `json:"oidcAuth"`          // OpenID Connect configuration
+	Roles             RoleSettings            `json:"roles"`             // roles of social and OpenID Connect users
+	SessionSecret     string                  `json:"sessionSecret"`     // secret for session cookie
+	SessionDuration   time.Duration           `json:"sessionDuration"`   // duration for browser session cookies
+	SignedMedia       SignedMediaSettings     `json:"signedMedia"`       // pre-signed temporary URLs for clips and spectrograms
+	Headers           SecurityHeadersSettings `json:"headers"`           // security response headers
+}
+
+// SecurityHeadersSettings contains the settings of the security response
+// headers, for deployments without a reverse proxy that sets them
+type SecurityHeadersSettings struct {
+	Enabled               bool     `json:"enabled"`               // true to send security headers
+	ContentSecurityPolicy string   `json:"contentSecurityPolicy"` // Content-Security-Policy, empty for the built-in policy
+	CSPReportOnly         bool     `json:"cspReportOnly"`         // true to report policy violations without enforcing the policy
+	FrameAncestors        []string `json:"frameAncestors"`        // sources allowed to embed the UI in frames, empty for same origin only
+	HSTSMaxAge            int      `json:"hstsMaxAge"`            // Strict-Transport-Security max-age in seconds, 0 to disable HSTS
+	HSTSIncludeSubdomains bool     `json:"hstsIncludeSubdomains"` // true to apply HSTS to subdomains
+	HSTSPreload           bool     `json:"hstsPreload"`           // true to allow inclusion in browser HSTS preload lists
+	ReferrerPolicy        string   `json:"referrerPolicy"`        // Referrer-Policy, empty for strict-origin-when-cross-origin
+}
+
+// SignedMediaSettings contains the settings of pre-signed temporary URLs for
+// audio clips and spectrograms, signed with the session secret
+type SignedMediaSettings struct {
+	Required bool `json:"required"` // true to serve clips and spectrograms to unauthenticated clients only through signed URLs
+	TTL      int  `json:"ttl"`      // default lifetime of signed URLs in minutes, 0 for 60 minutes
 }
 
 type WebServerSettings struct {
@@ -795,6 +1431,7 @@ type WebServerSettings struct {
 	Port       string             `json:"port"`       // port for web server
 	Log        LogConfig          `json:"log"`        // logging configuration for web server
 	LiveStream LiveStreamSettings `json:"liveStream"` // live stream configuration
+	APIDocs    bool               `json:"apiDocs"`    // true to serve Swagger UI of the API at /api/v2/docs
 }
 
 type LiveStreamSettings struct {
@@ -979,9 +1616,12 @@ type Settings struct {
 	ValidationWarnings []string `yaml:"-" json:"validationWarnings,omitempty"` // Configuration validation warnings for telemetry
 
 	Main struct {
-		Name      string    `json:"name"`      // name of BirdNET-Go node, can be used to identify source of notes
-		TimeAs24h bool      `json:"timeAs24h"` // true 24-hour time format, false 12-hour time format
-		Log       LogConfig `json:"log"`       // logging configuration
+		Name      string           `json:"name"`      // name of BirdNET-Go node, can be used to identify source of notes
+		TimeAs24h bool             `json:"timeAs24h"` // true 24-hour time format, false 12-hour time format
+		Log       LogConfig        `json:"log"`       // logging configuration
+		ReadOnly  bool             `json:"readOnly"`  // true to start in read-only mode, no detections or settings are written
+		Offline   bool             `json:"offline"`   // true to start in air-gapped mode, no outbound network calls are made
+		Resources ResourceSettings `json:"resources"` // CPU limits for sharing the host with other services
 	} `json:"main"`
 
 	BirdNET BirdNETConfig `json:"birdnet"` // BirdNET configuration
@@ -1018,6 +1658,47 @@ type Settings struct {
 	Backup BackupConfig `json:"backup"` // Backup configuration
 
 	Notification NotificationConfig `json:"notification"` // Configuration for push notifications
+
+	DataBudget DataBudgetSettings `json:"dataBudget"` // Metered connection data budget
+
+	FieldSync FieldSyncSettings `json:"fieldSync"` // Delta sync between field units and an aggregator
+
+	Encryption EncryptionSettings `json:"encryption"` // At-rest encryption of clips and database
+
+	DataQuality DataQualitySettings `json:"dataQuality"` // Daily data quality report
+
+	DetectionRetention DetectionRetentionSettings `json:"detectionRetention"` // Thinning of old detections into hourly aggregates
+
+	Forecast ForecastSettings `json:"forecast"` // Species occurrence forecast
+
+	OnThisDay OnThisDaySettings `json:"onThisDay"` // Highlights of past years on today's date
+
+	Newsletter NewsletterSettings `json:"newsletter"` // Weekly newsletter with charts
+
+	Trash TrashSettings `json:"trash"` // Restorable trash of deleted detections
+
+	TimeLapse TimeLapseSettings `json:"timeLapse"` // Daily soundscape time-lapse
+
+	ChatOps ChatOpsSettings `json:"chatOps"` // Slack and Discord slash commands and alerts
+
+	Manifest ManifestSettings `json:"manifest"` // Checksums of uploads and exports for provenance
+}
+
+// ResourceSettings caps the CPU used by BirdNET-Go, so that analysis during
+// the dawn chorus does not starve other services on a small shared host
+type ResourceSettings struct {
+	MaxProcs int            `json:"maxProcs"` // max CPUs running Go code at once, 0 for the CPU quota or all CPUs
+	Nice     int            `json:"nice"`     // process nice level from 0 to 19, higher yields to other processes
+	Cgroup   CgroupSettings `json:"cgroup"`   // limits set on the cgroup of the process
+}
+
+// CgroupSettings are CPU limits written to the cgroup v2 of the process. The
+// cgroup must be writable, such as a systemd unit with Delegate=yes or a
+// container with a writable cgroup mount.
+type CgroupSettings struct {
+	Enabled   bool    `json:"enabled"`   // true to set the limits on the cgroup
+	CPUQuota  float64 `json:"cpuQuota"`  // CPUs the process may use in total, such as 1.5, 0 for no limit
+	CPUWeight int     `json:"cpuWeight"` // share of CPU time under contention, 1 to 10000, 0 leaves it unchanged
 }
 
 // LogConfig defines the configuration for a log file
@@ -1262,6 +1943,10 @@ func GetSettings() *Settings {
 // SaveSettings saves the current settings to the configuration file.
 // It uses UpdateYAMLConfig to handle the atomic write process.
 func SaveSettings() error {
+	if IsReadOnly() {
+		return ErrReadOnlyMode
+	}
+
 	settingsMutex.RLock()
 	defer settingsMutex.RUnlock()
 
@@ -160,13 +160,6 @@ func TestSettingsSaveAndLoad(t *testing.T) {
 
 	// Create settings with species config containing zero values
 	settings := &Settings{
-		Main: struct {
-			Name      string    `json:"name"`
-			TimeAs24h bool      `json:"timeAs24h"`
-			Log       LogConfig `json:"log"`
-		}{
-			Name: "TestNode",
-		},
 		BirdNET: BirdNETConfig{
 			Threshold: 0.3,
 			Locale:    "en",
@@ -189,6 +182,7 @@ func TestSettingsSaveAndLoad(t *testing.T) {
 			},
 		},
 	}
+	settings.Main.Name = "TestNode"
 
 	// Save settings
 	err := SaveYAMLConfig(configPath, settings)
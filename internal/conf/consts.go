@@ -7,6 +7,8 @@ const (
 	NumChannels   = 1     // Number of channels of the audio fed to BirdNET Analyzer
 	CaptureLength = 3     // Length of audio data fed to BirdNET Analyzer in seconds
 
+	MaxCaptureSampleRate = 192000 // Highest sample rate a source can be captured at, decimated to SampleRate
+
 	SpeciesConfigCSV  = "species_config.csv"
 	SpeciesActionsCSV = "species_actions.csv"
 
@@ -14,6 +14,13 @@ func setDefaultConfig() {
 	// Main configuration
 	viper.SetDefault("main.name", "BirdNET-Go")
 	viper.SetDefault("main.timeas24h", true)
+	viper.SetDefault("main.readonly", false)
+	viper.SetDefault("main.offline", false)
+	viper.SetDefault("main.resources.maxprocs", 0)
+	viper.SetDefault("main.resources.nice", 0)
+	viper.SetDefault("main.resources.cgroup.enabled", false)
+	viper.SetDefault("main.resources.cgroup.cpuquota", 0)
+	viper.SetDefault("main.resources.cgroup.cpuweight", 0)
 	viper.SetDefault("main.log.enabled", true)
 	viper.SetDefault("main.log.path", "birdnet.log")
 	viper.SetDefault("main.log.rotation", RotationDaily)
@@ -31,8 +38,19 @@ func setDefaultConfig() {
 	viper.SetDefault("birdnet.longitude", 0.000)
 	viper.SetDefault("birdnet.modelpath", "")
 	viper.SetDefault("birdnet.labelpath", "")
+	viper.SetDefault("birdnet.aliaspath", "")
 	viper.SetDefault("birdnet.usexnnpack", true)
 
+	// Shadow model configuration
+	viper.SetDefault("birdnet.shadow.enabled", false)
+	viper.SetDefault("birdnet.shadow.modelpath", "")
+	viper.SetDefault("birdnet.shadow.labelpath", "")
+	viper.SetDefault("birdnet.shadow.threshold", 0.0)
+	viper.SetDefault("birdnet.shadow.trialdays", 14)
+	viper.SetDefault("birdnet.warmup.enabled", true)
+	viper.SetDefault("birdnet.warmup.runs", 2)
+	viper.SetDefault("birdnet.warmup.keepwarm", "30m")
+
 	// Range filter configuration
 	viper.SetDefault("birdnet.rangefilter.debug", false)
 	viper.SetDefault("birdnet.rangefilter.model", "latest")
@@ -45,6 +63,12 @@ func setDefaultConfig() {
 	// Audio source configuration
 	viper.SetDefault("realtime.audio.useaudiocore", false) // true to use new audiocore package instead of myaudio
 	viper.SetDefault("realtime.audio.source", "sysdefault")
+	viper.SetDefault("realtime.audio.channel", AudioChannelMix)
+	viper.SetDefault("realtime.audio.doa.enabled", false)
+	viper.SetDefault("realtime.audio.doa.micspacing", 0.2)
+	viper.SetDefault("realtime.audio.doa.orientation", 0.0)
+	viper.SetDefault("realtime.audio.capturerate.samplerate", SampleRate)
+	viper.SetDefault("realtime.audio.capturerate.archiveoriginal", false)
 	viper.SetDefault("realtime.audio.streamtransport", "sse")
 
 	// Sound level monitoring configuration
@@ -57,6 +81,17 @@ func setDefaultConfig() {
 	viper.SetDefault("realtime.audio.export.path", "clips/")
 	viper.SetDefault("realtime.audio.export.type", "wav")
 	viper.SetDefault("realtime.audio.export.bitrate", "96k")
+	viper.SetDefault("realtime.audio.export.bitdepth", 16)
+	viper.SetDefault("realtime.audio.export.compressionlevel", 5)
+	viper.SetDefault("realtime.audio.export.destinations.birdweather.type", "flac")
+	viper.SetDefault("realtime.audio.export.destinations.birdweather.bitdepth", 16)
+	viper.SetDefault("realtime.audio.export.destinations.birdweather.compressionlevel", 5)
+	viper.SetDefault("realtime.audio.export.destinations.notification.type", "mp3")
+	viper.SetDefault("realtime.audio.export.destinations.notification.bitrate", "64k")
+	viper.SetDefault("realtime.audio.export.snippet.enabled", false)
+	viper.SetDefault("realtime.audio.export.snippet.length", 6)
+	viper.SetDefault("realtime.audio.export.snippet.type", "opus")
+	viper.SetDefault("realtime.audio.export.snippet.bitrate", "24k")
 	viper.SetDefault("realtime.audio.export.length", 15)
 	viper.SetDefault("realtime.audio.export.preCapture", 3)
 	viper.SetDefault("realtime.audio.export.gain", 0.0)
@@ -90,6 +125,8 @@ func setDefaultConfig() {
 	viper.SetDefault("realtime.dashboard.thumbnails.recent", true)
 	viper.SetDefault("realtime.dashboard.thumbnails.imageprovider", "avicommons")
 	viper.SetDefault("realtime.dashboard.thumbnails.fallbackpolicy", "none")
+	viper.SetDefault("realtime.dashboard.thumbnails.imagebundle", "")
+	viper.SetDefault("realtime.dashboard.thumbnails.prefetch", true)
 	viper.SetDefault("realtime.dashboard.summarylimit", 30)
 	viper.SetDefault("realtime.dashboard.locale", "en") // Default UI locale
 	viper.SetDefault("realtime.dashboard.newui", false) // Enable redirect from old HTMX UI to new Svelte UI
@@ -104,6 +141,14 @@ func setDefaultConfig() {
 	viper.SetDefault("realtime.audio.export.retention.keepspectrograms", true)
 	viper.SetDefault("realtime.audio.export.retention.checkinterval", DefaultCleanupCheckInterval)
 
+	// Disk-full protective mode configuration
+	viper.SetDefault("realtime.audio.export.diskprotection.enabled", true)
+	viper.SetDefault("realtime.audio.export.diskprotection.minfreespace", "1GB")
+	viper.SetDefault("realtime.audio.export.diskprotection.resumefreespace", "2GB")
+	viper.SetDefault("realtime.audio.export.diskprotection.checkinterval", DefaultDiskProtectionCheckInterval)
+	viper.SetDefault("realtime.audio.export.writer.queuesize", 32)
+	viper.SetDefault("realtime.audio.export.writer.droppolicy", "oldest")
+
 	// Dynamic threshold configuration
 	viper.SetDefault("realtime.dynamicthreshold.enabled", true)
 	viper.SetDefault("realtime.dynamicthreshold.debug", false)
@@ -111,6 +156,23 @@ func setDefaultConfig() {
 	viper.SetDefault("realtime.dynamicthreshold.min", 0.20)
 	viper.SetDefault("realtime.dynamicthreshold.validhours", 24)
 
+	// Time-of-day prior configuration
+	viper.SetDefault("realtime.timepriors.enabled", false)
+	viper.SetDefault("realtime.timepriors.learn", true)
+	viper.SetDefault("realtime.timepriors.learndays", 60)
+	viper.SetDefault("realtime.timepriors.mindetections", 50)
+	viper.SetDefault("realtime.timepriors.adjustment", 0.2)
+	viper.SetDefault("realtime.timepriors.maxthreshold", 0.95)
+
+	// Rare species alert configuration
+	viper.SetDefault("realtime.rarespecies.enabled", false)
+	viper.SetDefault("realtime.rarespecies.source", "ebird")
+	viper.SetDefault("realtime.rarespecies.barchartfile", "")
+	viper.SetDefault("realtime.rarespecies.regioncode", "")
+	viper.SetDefault("realtime.rarespecies.maxfrequency", 0.02)
+	viper.SetDefault("realtime.rarespecies.minconfidence", 0.8)
+	viper.SetDefault("realtime.rarespecies.cooldown", 24)
+
 	// Log configuration
 	viper.SetDefault("realtime.log.enabled", false)
 	viper.SetDefault("realtime.log.path", "birdnet.txt")
@@ -149,6 +211,15 @@ func setDefaultConfig() {
 	viper.SetDefault("realtime.weather.pollinterval", 60)
 	viper.SetDefault("realtime.weather.provider", "yrno")
 
+	// Weather-adaptive threshold configuration
+	viper.SetDefault("realtime.weather.adaptive.enabled", false)
+	viper.SetDefault("realtime.weather.adaptive.windmin", 4.0)
+	viper.SetDefault("realtime.weather.adaptive.windmax", 10.0)
+	viper.SetDefault("realtime.weather.adaptive.windadjustment", 0.15)
+	viper.SetDefault("realtime.weather.adaptive.rainadjustment", 0.1)
+	viper.SetDefault("realtime.weather.adaptive.maxthreshold", 0.95)
+	viper.SetDefault("realtime.weather.adaptive.maxage", 120)
+
 	// OpenWeather specific configuration
 	viper.SetDefault("realtime.weather.openweather.apikey", "")
 	viper.SetDefault("realtime.weather.openweather.endpoint", "https://api.openweathermap.org/data/2.5/weather")
@@ -167,6 +238,7 @@ func setDefaultConfig() {
 	viper.SetDefault("realtime.rtsp.health.healthydatathreshold", 60)
 	viper.SetDefault("realtime.rtsp.health.monitoringinterval", 30)
 	viper.SetDefault("realtime.rtsp.ffmpegparameters", []string{})
+	viper.SetDefault("realtime.rtsp.capturerates", []RTSPCaptureRate{})
 
 	// MQTT configuration
 	viper.SetDefault("realtime.mqtt.enabled", false)
@@ -198,6 +270,10 @@ func setDefaultConfig() {
 	viper.SetDefault("realtime.telemetry.enabled", false)
 	viper.SetDefault("realtime.telemetry.listen", "0.0.0.0:8090")
 
+	// Graceful shutdown configuration
+	viper.SetDefault("realtime.shutdown.timeout", 9) // fits within Docker's default 10s stop grace period
+	viper.SetDefault("realtime.shutdown.drainpending", true)
+
 	// System monitoring configuration
 	viper.SetDefault("realtime.monitoring.enabled", true)
 	viper.SetDefault("realtime.monitoring.checkinterval", 60)
@@ -223,6 +299,12 @@ func setDefaultConfig() {
 	viper.SetDefault("realtime.speciestracking.syncintervalminutes", 60)
 	viper.SetDefault("realtime.speciestracking.notificationsuppressionhours", 168) // 7 days
 
+	// New species context check defaults
+	viper.SetDefault("realtime.speciestracking.contextcheck.enabled", false)
+	viper.SetDefault("realtime.speciestracking.contextcheck.minconfidence", 0.3)
+	viper.SetDefault("realtime.speciestracking.contextcheck.minwindows", 1)
+	viper.SetDefault("realtime.speciestracking.contextcheck.skipconfidence", 0.9)
+
 	// Yearly tracking defaults
 	viper.SetDefault("realtime.speciestracking.yearlytracking.enabled", true)
 	viper.SetDefault("realtime.speciestracking.yearlytracking.resetmonth", 1)
@@ -247,6 +329,7 @@ func setDefaultConfig() {
 	viper.SetDefault("webserver.debug", false)
 	viper.SetDefault("webserver.enabled", true)
 	viper.SetDefault("webserver.port", "8080")
+	viper.SetDefault("webserver.apidocs", false)
 
 	// Webserver log configuration
 	viper.SetDefault("webserver.log.enabled", false)
@@ -287,6 +370,16 @@ func setDefaultConfig() {
 	viper.SetDefault("security.allowsubnetbypass.enabled", false)
 	viper.SetDefault("security.allowsubnetbypass.subnet", "")
 	viper.SetDefault("security.sessionduration", "168h") // 7 days
+	viper.SetDefault("security.signedmedia.required", false)
+	viper.SetDefault("security.signedmedia.ttl", 60)
+	viper.SetDefault("security.headers.enabled", false)
+	viper.SetDefault("security.headers.contentsecuritypolicy", "")
+	viper.SetDefault("security.headers.cspreportonly", false)
+	viper.SetDefault("security.headers.frameancestors", []string{})
+	viper.SetDefault("security.headers.hstsmaxage", 0)
+	viper.SetDefault("security.headers.hstsincludesubdomains", false)
+	viper.SetDefault("security.headers.hstspreload", false)
+	viper.SetDefault("security.headers.referrerpolicy", "strict-origin-when-cross-origin")
 
 	// Basic authentication configuration
 	viper.SetDefault("security.basicauth.enabled", false)
@@ -310,12 +403,128 @@ func setDefaultConfig() {
 	viper.SetDefault("security.githubauth.redirecturi", "/settings")
 	viper.SetDefault("security.githubauth.userid", "")
 
+	// OpenID Connect configuration
+	viper.SetDefault("security.oidcauth.enabled", false)
+	viper.SetDefault("security.oidcauth.name", "SSO")
+	viper.SetDefault("security.oidcauth.issuer", "")
+	viper.SetDefault("security.oidcauth.clientid", "")
+	viper.SetDefault("security.oidcauth.clientsecret", "")
+	viper.SetDefault("security.oidcauth.redirecturi", "")
+	viper.SetDefault("security.oidcauth.scopes", []string{"openid", "profile", "email", "groups"})
+	viper.SetDefault("security.oidcauth.userid", "")
+	viper.SetDefault("security.oidcauth.groupsclaim", "groups")
+	viper.SetDefault("security.oidcauth.admingroups", []string{})
+	viper.SetDefault("security.oidcauth.editorgroups", []string{})
+	viper.SetDefault("security.oidcauth.viewergroups", []string{})
+	viper.SetDefault("security.roles.editors", "")
+	viper.SetDefault("security.roles.viewers", "")
+
 	// Sentry configuration
 	viper.SetDefault("sentry.enabled", false)
 	viper.SetDefault("sentry.dsn", "")
 	viper.SetDefault("sentry.samplerate", 1.0)
 	viper.SetDefault("sentry.debug", false)
 
+	// Data budget configuration
+	viper.SetDefault("databudget.enabled", false)
+	viper.SetDefault("databudget.monthlycapmb", 1024)
+	viper.SetDefault("databudget.throttlethreshold", 80)
+	viper.SetDefault("databudget.resetday", 1)
+	viper.SetDefault("databudget.essential", []string{"uploads"})
+
+	// Field sync configuration
+	viper.SetDefault("fieldsync.enabled", false)
+	viper.SetDefault("fieldsync.aggregatorurl", "")
+	viper.SetDefault("fieldsync.token", "")
+	viper.SetDefault("fieldsync.interval", 15)
+	viper.SetDefault("fieldsync.batchsize", 250)
+	viper.SetDefault("fieldsync.chunksizekb", 256)
+	viper.SetDefault("fieldsync.clips.enabled", false)
+	viper.SetDefault("fieldsync.clips.minconfidence", 0.0)
+	viper.SetDefault("fieldsync.clips.species", []string{})
+	viper.SetDefault("fieldsync.aggregator.enabled", false)
+
+	// At-rest encryption configuration
+	viper.SetDefault("encryption.clips", false)
+	viper.SetDefault("encryption.database", false)
+	viper.SetDefault("encryption.key", "")
+	viper.SetDefault("encryption.keyfile", "")
+
+	// Data quality report configuration
+	viper.SetDefault("dataquality.enabled", true)
+	viper.SetDefault("dataquality.gapminutes", 5)
+	viper.SetDefault("dataquality.silencethreshold", -70.0)
+	viper.SetDefault("dataquality.silentminutes", 30)
+	viper.SetDefault("dataquality.clippingpercent", 0.1)
+	viper.SetDefault("dataquality.ratelowpercent", 25)
+	viper.SetDefault("dataquality.ratehighpercent", 400)
+	viper.SetDefault("dataquality.baselinedays", 7)
+	viper.SetDefault("dataquality.micfailure.enabled", true)
+	viper.SetDefault("dataquality.micfailure.windowminutes", 30)
+	viper.SetDefault("dataquality.micfailure.humpercent", 80.0)
+	viper.SetDefault("dataquality.calibration.enabled", false)
+	viper.SetDefault("dataquality.calibration.frequency", 1000.0)
+	viper.SetDefault("dataquality.calibration.minseconds", 3)
+	viper.SetDefault("dataquality.calibration.referencelevel", 0.0)
+	viper.SetDefault("dataquality.calibration.tolerance", 3.0)
+	viper.SetDefault("dataquality.anomaly.enabled", true)
+	viper.SetDefault("dataquality.anomaly.baselinedays", 28)
+	viper.SetDefault("dataquality.anomaly.threshold", 3.0)
+	viper.SetDefault("dataquality.anomaly.minspike", 10)
+	viper.SetDefault("dataquality.anomaly.minexpected", 5.0)
+	viper.SetDefault("dataquality.anomaly.species", true)
+
+	viper.SetDefault("dataquality.absence.enabled", false)
+	viper.SetDefault("dataquality.absence.days", 3)
+	viper.SetDefault("dataquality.absence.baselinedays", 28)
+	viper.SetDefault("dataquality.absence.minpresence", 0.8)
+	viper.SetDefault("dataquality.absence.species", []string{})
+
+	// Detection retention configuration
+	viper.SetDefault("detectionretention.enabled", false)
+	viper.SetDefault("detectionretention.policies", []map[string]any{})
+
+	// Species occurrence forecast configuration
+	viper.SetDefault("forecast.days", 7)
+	viper.SetDefault("forecast.years", 5)
+	viper.SetDefault("forecast.minlikelihood", 0.1)
+	viper.SetDefault("forecast.notify", false)
+	viper.SetDefault("forecast.notifyweekday", "monday")
+	viper.SetDefault("forecast.notifyhour", 7)
+	viper.SetDefault("forecast.notifylimit", 10)
+
+	// On this day highlights configuration
+	viper.SetDefault("onthisday.years", 10)
+	viper.SetDefault("onthisday.raremaxdetections", 5)
+	viper.SetDefault("onthisday.notify", false)
+	viper.SetDefault("onthisday.notifyhour", 8)
+
+	// Weekly newsletter configuration
+	viper.SetDefault("newsletter.enabled", false)
+	viper.SetDefault("newsletter.hour", 6)
+	viper.SetDefault("newsletter.path", "newsletters/")
+	viper.SetDefault("newsletter.topspecies", 10)
+
+	// Detection trash configuration
+	viper.SetDefault("trash.enabled", true)
+	viper.SetDefault("trash.retentiondays", 30)
+
+	// Manifest of uploads and exports
+	viper.SetDefault("manifest.enabled", false)
+
+	// Daily soundscape time-lapse configuration
+	viper.SetDefault("timelapse.enabled", false)
+	viper.SetDefault("timelapse.hour", 1)
+	viper.SetDefault("timelapse.path", "timelapse/")
+	viper.SetDefault("timelapse.segmentseconds", 4.0)
+	viper.SetDefault("timelapse.notify", false)
+
+	// Chat-ops slash commands and alerts
+	viper.SetDefault("chatops.slack.enabled", false)
+	viper.SetDefault("chatops.slack.alertwebhookurl", "")
+	viper.SetDefault("chatops.discord.enabled", false)
+	viper.SetDefault("chatops.discord.alertwebhookurl", "")
+
 	// Notification push configuration
 	viper.SetDefault("notification.push.enabled", false)
 	viper.SetDefault("notification.push.default_timeout", "30s")
@@ -339,8 +548,50 @@ func setDefaultConfig() {
 	viper.SetDefault("notification.push.rate_limiting.burst_size", 10)
 
 	viper.SetDefault("notification.push.providers", []map[string]any{})
+	viper.SetDefault("notification.push.routes", []map[string]any{})
+	viper.SetDefault("notification.push.escalation", []map[string]any{})
+
+	// Notification email configuration
+	viper.SetDefault("notification.email.enabled", false)
+	viper.SetDefault("notification.email.port", 587)
+	viper.SetDefault("notification.email.security", "starttls")
+	viper.SetDefault("notification.email.timeout", "30s")
+	viper.SetDefault("notification.email.to", []string{})
+	viper.SetDefault("notification.email.types.error", true)
+	viper.SetDefault("notification.email.types.warning", false)
+	viper.SetDefault("notification.email.types.info", false)
+	viper.SetDefault("notification.email.types.detection", true)
+	viper.SetDefault("notification.email.types.system", false)
+	viper.SetDefault("notification.email.types.rare_species", true)
+	viper.SetDefault("notification.email.quiet_hours.enabled", false)
+	viper.SetDefault("notification.email.quiet_hours.from", "22:00")
+	viper.SetDefault("notification.email.quiet_hours.until", "07:00")
+	viper.SetDefault("notification.email.quiet_hours.mode", "hold")
+
+	// Detection notification rules, none uses the built-in new species rule
+	viper.SetDefault("notification.rules", []map[string]any{})
+
+	// Daily digest of detections
+	viper.SetDefault("notification.daily_digest.enabled", false)
+	viper.SetDefault("notification.daily_digest.time", "21:00")
+	viper.SetDefault("notification.daily_digest.top_clips", 3)
+
+	// Persistent retry queue of failed outbound deliveries
+	viper.SetDefault("notification.deliveries.enabled", true)
+	viper.SetDefault("notification.deliveries.max_attempts", 8)
+	viper.SetDefault("notification.deliveries.initial_delay", "1m")
+	viper.SetDefault("notification.deliveries.max_delay", "6h")
+	viper.SetDefault("notification.deliveries.max_pending", 200)
+	viper.SetDefault("notification.deliveries.retention", "168h")
+
+	// Notification deduplication
+	viper.SetDefault("notification.dedup.enabled", false)
+	viper.SetDefault("notification.dedup.window", "10m")
 
 	// Notification templates
 	viper.SetDefault("notification.templates.newspecies.title", "New Species: {{.CommonName}}")
 	viper.SetDefault("notification.templates.newspecies.message", "{{.ImageURL}}\n\nFirst detection of {{.CommonName}} ({{.ScientificName}}) with {{.ConfidencePercent}}% confidence at {{.DetectionTime}}. \n{{.DetectionURL}}")
+	viper.SetDefault("notification.templates.dailydigest.title", "")
+	viper.SetDefault("notification.templates.dailydigest.message", "")
+	viper.SetDefault("notification.templates.locale", "")
 }
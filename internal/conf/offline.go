@@ -0,0 +1,28 @@
+// offline.go: runtime air-gapped operating mode
+package conf
+
+import (
+	"sync/atomic"
+
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// offlineMode holds the runtime offline state. It is seeded from Main.Offline
+// (config file or --offline flag) and can be toggled at runtime through the API.
+var offlineMode atomic.Bool
+
+// ErrOfflineMode is returned when an outbound network call is attempted while offline mode is active
+var ErrOfflineMode = errors.NewStd("outbound network access disabled: offline mode is enabled")
+
+// IsOffline reports whether the application is running in air-gapped mode.
+// In offline mode no outbound calls are made to weather, image, BirdWeather,
+// push notification or telemetry services.
+func IsOffline() bool {
+	return offlineMode.Load()
+}
+
+// SetOffline enables or disables offline mode at runtime. The change is not
+// persisted to the configuration file.
+func SetOffline(enabled bool) {
+	offlineMode.Store(enabled)
+}
@@ -0,0 +1,27 @@
+// readonly.go: runtime read-only operating mode
+package conf
+
+import (
+	"sync/atomic"
+
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// readOnlyMode holds the runtime read-only state. It is seeded from Main.ReadOnly
+// (config file or --read-only flag) and can be toggled at runtime through the API.
+var readOnlyMode atomic.Bool
+
+// ErrReadOnlyMode is returned when a write is attempted while read-only mode is active
+var ErrReadOnlyMode = errors.NewStd("operation not permitted: read-only mode is enabled")
+
+// IsReadOnly reports whether the application is running in read-only mode.
+// In read-only mode new detections are not stored and settings are not written.
+func IsReadOnly() bool {
+	return readOnlyMode.Load()
+}
+
+// SetReadOnly enables or disables read-only mode at runtime. The change is not
+// persisted to the configuration file, since settings writes are themselves blocked.
+func SetReadOnly(enabled bool) {
+	readOnlyMode.Store(enabled)
+}
@@ -285,6 +285,49 @@ func ParsePercentage(percentage string) (float64, error) {
 		Build()
 }
 
+// ParseByteSize converts a size string such as "500MB", "1.5GB" or "1024" to bytes.
+// Units are binary multiples (1KB = 1024 bytes) and are case-insensitive.
+func ParseByteSize(size string) (uint64, error) {
+	s := strings.ToUpper(strings.TrimSpace(size))
+	if s == "" {
+		return 0, errors.Newf("size cannot be empty").
+			Component("conf").
+			Category(errors.CategoryValidation).
+			Build()
+	}
+
+	multipliers := []struct {
+		suffix string
+		factor float64
+	}{
+		{"TB", 1 << 40},
+		{"GB", 1 << 30},
+		{"MB", 1 << 20},
+		{"KB", 1 << 10},
+		{"B", 1},
+	}
+
+	factor := 1.0
+	for _, m := range multipliers {
+		if strings.HasSuffix(s, m.suffix) {
+			factor = m.factor
+			s = strings.TrimSpace(strings.TrimSuffix(s, m.suffix))
+			break
+		}
+	}
+
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil || value < 0 {
+		return 0, errors.Newf("invalid size format").
+			Component("conf").
+			Category(errors.CategoryValidation).
+			Context("input", size).
+			Build()
+	}
+
+	return uint64(value * factor), nil
+}
+
 // ParseRetentionPeriod converts a string like "24h", "7d", "1w", "3m", "1y" to hours.
 func ParseRetentionPeriod(retention string) (int, error) {
 	if retention == "" {
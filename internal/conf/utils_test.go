@@ -127,3 +127,42 @@ func TestGetFfmpegVersion(t *testing.T) {
 
 	t.Logf("Detected FFmpeg version: %s (major: %d, minor: %d)", version, major, minor)
 }
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    uint64
+		wantErr bool
+	}{
+		{name: "plain bytes", input: "1024", want: 1024},
+		{name: "bytes suffix", input: "512B", want: 512},
+		{name: "kilobytes", input: "2KB", want: 2048},
+		{name: "megabytes", input: "500MB", want: 500 << 20},
+		{name: "gigabytes", input: "1GB", want: 1 << 30},
+		{name: "fractional gigabytes", input: "1.5GB", want: 3 << 29},
+		{name: "terabytes lowercase", input: "1tb", want: 1 << 40},
+		{name: "whitespace", input: " 10 MB ", want: 10 << 20},
+		{name: "empty", input: "", wantErr: true},
+		{name: "invalid number", input: "lotsGB", wantErr: true},
+		{name: "negative", input: "-1GB", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseByteSize(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseByteSize(%q) expected error, got %d", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseByteSize(%q) unexpected error: %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseByteSize(%q) = %d, want %d", tt.input, got, tt.want)
+			}
+		})
+	}
+}
@@ -3,16 +3,23 @@
 package conf
 
 import (
+	"crypto/ed25519"
+	"encoding/hex"
 	"fmt"
 	"log"
 	"net"
+	"net/mail"
+	"net/url"
 	"os/exec"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"text/template"
+	"time"
 
 	"github.com/tphakala/birdnet-go/internal/errors"
+	"github.com/tphakala/birdnet-go/internal/notification/templatefuncs"
 )
 
 // MinSoundLevelInterval is the minimum sound level interval in seconds to prevent excessive CPU usage
@@ -21,12 +28,19 @@ const MinSoundLevelInterval = 5
 // DefaultCleanupCheckInterval is the default disk cleanup check interval in minutes
 const DefaultCleanupCheckInterval = 15
 
+// DefaultDiskProtectionCheckInterval is the default free space check interval in seconds for disk protective mode
+const DefaultDiskProtectionCheckInterval = 60
+
 // Audio gain limits in dB
 const (
 	MinAudioGain = -40.0 // Minimum allowed audio gain in dB
 	MaxAudioGain = 40.0  // Maximum allowed audio gain in dB
 )
 
+// MaxDOAMicSpacing is the largest microphone spacing in meters for direction of
+// arrival estimation; the delay search grows with the spacing
+const MaxDOAMicSpacing = 2.0
+
 // EBU R128 normalization limits
 const (
 	MinTargetLUFS    = -40.0 // Minimum target loudness in LUFS
@@ -61,6 +75,11 @@ func logValidationWarning(err error, validationType, warningType string) {
 func ValidateSettings(settings *Settings) error {
 	ve := ValidationError{}
 
+	// Validate CPU limits
+	if err := validateResourceSettings(&settings.Main.Resources); err != nil {
+		ve.Errors = append(ve.Errors, err.Error())
+	}
+
 	// Validate BirdNET settings
 	if err := validateBirdNETSettings(&settings.BirdNET, settings); err != nil {
 		ve.Errors = append(ve.Errors, err.Error())
@@ -101,13 +120,82 @@ func ValidateSettings(settings *Settings) error {
 		ve.Errors = append(ve.Errors, err.Error())
 	}
 
+	// Validate Time Prior settings
+	if err := validateTimePriorSettings(&settings.Realtime.TimePriors); err != nil {
+		ve.Errors = append(ve.Errors, err.Error())
+	}
+
+	// Validate Rare Species settings
+	if err := validateRareSpeciesSettings(&settings.Realtime.RareSpecies); err != nil {
+		ve.Errors = append(ve.Errors, err.Error())
+	}
+
 	// Validate Species Tracking settings
 	if err := validateSpeciesTrackingSettings(&settings.Realtime.SpeciesTracking); err != nil {
 		ve.Errors = append(ve.Errors, err.Error())
 	}
 
 	// Validate Notification settings
-	if err := validateNotificationSettings(&settings.Notification); err != nil {
+	var builtinProviders []string
+	if settings.ChatOps.AlertsEnabled() {
+		builtinProviders = append(builtinProviders, ChatOpsProviderName)
+	}
+	if err := validateNotificationSettings(&settings.Notification, builtinProviders...); err != nil {
+		ve.Errors = append(ve.Errors, err.Error())
+	}
+
+	// Validate Data Budget settings
+	if err := validateDataBudgetSettings(&settings.DataBudget); err != nil {
+		ve.Errors = append(ve.Errors, err.Error())
+	}
+
+	// Validate Field Sync settings
+	if err := validateFieldSyncSettings(&settings.FieldSync); err != nil {
+		ve.Errors = append(ve.Errors, err.Error())
+	}
+
+	// Validate at-rest encryption settings
+	if err := validateEncryptionSettings(&settings.Encryption, settings.Output.SQLite.Enabled); err != nil {
+		ve.Errors = append(ve.Errors, err.Error())
+	}
+
+	// Validate data quality report settings
+	if err := validateDataQualitySettings(&settings.DataQuality); err != nil {
+		ve.Errors = append(ve.Errors, err.Error())
+	}
+
+	// Validate detection retention settings
+	if err := validateDetectionRetentionSettings(&settings.DetectionRetention); err != nil {
+		ve.Errors = append(ve.Errors, err.Error())
+	}
+
+	// Validate species occurrence forecast settings
+	if err := validateForecastSettings(&settings.Forecast); err != nil {
+		ve.Errors = append(ve.Errors, err.Error())
+	}
+
+	// Validate on this day highlights settings
+	if err := validateOnThisDaySettings(&settings.OnThisDay); err != nil {
+		ve.Errors = append(ve.Errors, err.Error())
+	}
+
+	// Validate weekly newsletter settings
+	if err := validateNewsletterSettings(&settings.Newsletter); err != nil {
+		ve.Errors = append(ve.Errors, err.Error())
+	}
+
+	// Validate detection trash settings
+	if err := validateTrashSettings(&settings.Trash); err != nil {
+		ve.Errors = append(ve.Errors, err.Error())
+	}
+
+	// Validate daily time-lapse settings
+	if err := validateTimeLapseSettings(&settings.TimeLapse); err != nil {
+		ve.Errors = append(ve.Errors, err.Error())
+	}
+
+	// Validate chat-ops settings
+	if err := validateChatOpsSettings(&settings.ChatOps); err != nil {
 		ve.Errors = append(ve.Errors, err.Error())
 	}
 
@@ -118,6 +206,43 @@ func ValidateSettings(settings *Settings) error {
 	return nil
 }
 
+// validateResourceSettings validates the CPU limits
+func validateResourceSettings(settings *ResourceSettings) error {
+	if settings.MaxProcs < 0 {
+		return errors.New(fmt.Errorf("resources maxprocs must not be negative, got %d", settings.MaxProcs)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "resources-maxprocs").
+			Build()
+	}
+
+	if settings.Nice < 0 || settings.Nice > 19 {
+		return errors.New(fmt.Errorf("resources nice level must be between 0 and 19, got %d", settings.Nice)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "resources-nice").
+			Build()
+	}
+
+	if !settings.Cgroup.Enabled {
+		return nil
+	}
+
+	if settings.Cgroup.CPUQuota != 0 && settings.Cgroup.CPUQuota < 0.01 {
+		return errors.New(fmt.Errorf("resources cgroup CPU quota must be at least 0.01 CPUs, or 0 for no limit, got %g", settings.Cgroup.CPUQuota)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "resources-cgroup-cpu-quota").
+			Build()
+	}
+
+	if settings.Cgroup.CPUWeight < 0 || settings.Cgroup.CPUWeight > 10000 {
+		return errors.New(fmt.Errorf("resources cgroup CPU weight must be between 1 and 10000, or 0 to leave it unchanged, got %d", settings.Cgroup.CPUWeight)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "resources-cgroup-cpu-weight").
+			Build()
+	}
+
+	return nil
+}
+
 // validateBirdNETSettings validates the BirdNET-specific settings
 func validateBirdNETSettings(birdnetSettings *BirdNETConfig, settings *Settings) error {
 	var errs []string
@@ -162,6 +287,25 @@ func validateBirdNETSettings(birdnetSettings *BirdNETConfig, settings *Settings)
 		errs = append(errs, "RangeFilter threshold must be between 0 and 1")
 	}
 
+	// Validate shadow model settings
+	if birdnetSettings.Shadow.Enabled && birdnetSettings.Shadow.ModelPath == "" {
+		errs = append(errs, "BirdNET shadow model path is required when the shadow model is enabled")
+	}
+	if birdnetSettings.Shadow.Threshold < 0 || birdnetSettings.Shadow.Threshold > 1 {
+		errs = append(errs, "BirdNET shadow model threshold must be between 0 and 1")
+	}
+	if birdnetSettings.Shadow.TrialDays < 0 {
+		errs = append(errs, "BirdNET shadow model trial days must be at least 0")
+	}
+
+	// Validate model warm-up settings
+	if birdnetSettings.Warmup.Enabled && (birdnetSettings.Warmup.Runs < 1 || birdnetSettings.Warmup.Runs > 10) {
+		errs = append(errs, "BirdNET warm-up runs must be between 1 and 10")
+	}
+	if birdnetSettings.Warmup.KeepWarm < 0 {
+		errs = append(errs, "BirdNET keep warm interval must not be negative")
+	}
+
 	// Validate locale setting
 	if birdnetSettings.Locale != "" {
 		normalizedLocale, err := NormalizeLocale(birdnetSettings.Locale)
@@ -240,6 +384,22 @@ func validateSecuritySettings(settings *Security) error {
 			Build()
 	}
 
+	// OpenID Connect needs the issuer for discovery and a callback URL
+	if settings.OIDCAuth.Enabled {
+		if settings.OIDCAuth.Issuer == "" || settings.OIDCAuth.ClientID == "" {
+			return errors.New(fmt.Errorf("security.oidcauth.issuer and security.oidcauth.clientid must be set when OpenID Connect is enabled")).
+				Category(errors.CategoryValidation).
+				Context("validation_type", "security-oidc-provider").
+				Build()
+		}
+		if settings.OIDCAuth.RedirectURI == "" && settings.Host == "" {
+			return errors.New(fmt.Errorf("security.host or security.oidcauth.redirecturi must be set when OpenID Connect is enabled")).
+				Category(errors.CategoryValidation).
+				Context("validation_type", "security-oidc-host").
+				Build()
+		}
+	}
+
 	// AutoTLS validation
 	if settings.AutoTLS {
 		// Host is required for AutoTLS
@@ -276,6 +436,19 @@ func validateSecuritySettings(settings *Security) error {
 		}
 	}
 
+	// Validate the lifetime of signed media URLs, at most 30 days with 0 for the default
+	if settings.SignedMedia.TTL < 0 || settings.SignedMedia.TTL > 30*24*60 {
+		return errors.New(fmt.Errorf("security.signedmedia.ttl must be between 0 and %d minutes", 30*24*60)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "security-signed-media-ttl").
+			Context("ttl", settings.SignedMedia.TTL).
+			Build()
+	}
+
+	if err := validateSecurityHeaders(&settings.Headers); err != nil {
+		return err
+	}
+
 	// Validate session duration
 	if settings.SessionDuration <= 0 {
 		return errors.New(fmt.Errorf("security.sessionduration must be a positive duration")).
@@ -287,6 +460,52 @@ func validateSecuritySettings(settings *Security) error {
 	return nil
 }
 
+// referrerPolicies are the valid values of the Referrer-Policy header
+var referrerPolicies = []string{
+	"no-referrer", "no-referrer-when-downgrade", "origin", "origin-when-cross-origin",
+	"same-origin", "strict-origin", "strict-origin-when-cross-origin", "unsafe-url",
+}
+
+// validateSecurityHeaders validates the security response header settings
+func validateSecurityHeaders(settings *SecurityHeadersSettings) error {
+	if settings.HSTSMaxAge < 0 {
+		return errors.New(fmt.Errorf("security.headers.hstsmaxage must not be negative")).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "security-headers-hsts").
+			Context("hsts_max_age", settings.HSTSMaxAge).
+			Build()
+	}
+
+	if settings.ReferrerPolicy != "" && !slices.Contains(referrerPolicies, settings.ReferrerPolicy) {
+		return errors.New(fmt.Errorf("security.headers.referrerpolicy %q is not a valid referrer policy", settings.ReferrerPolicy)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "security-headers-referrer-policy").
+			Context("referrer_policy", settings.ReferrerPolicy).
+			Build()
+	}
+
+	// Each frame ancestor is a single CSP source expression
+	for _, source := range settings.FrameAncestors {
+		if source == "" || strings.ContainsAny(source, " \t\r\n;,") {
+			return errors.New(fmt.Errorf("security.headers.frameancestors entry %q is not a valid source", source)).
+				Category(errors.CategoryValidation).
+				Context("validation_type", "security-headers-frame-ancestors").
+				Context("source", source).
+				Build()
+		}
+	}
+
+	// The policy is sent as a single header value
+	if strings.ContainsAny(settings.ContentSecurityPolicy, "\r\n") {
+		return errors.New(fmt.Errorf("security.headers.contentsecuritypolicy must be a single line")).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "security-headers-csp").
+			Build()
+	}
+
+	return nil
+}
+
 // validateRealtimeSettings validates the Realtime-specific settings
 func validateRealtimeSettings(settings *RealtimeSettings) error {
 	// Check if interval is non-negative
@@ -312,10 +531,38 @@ func validateRealtimeSettings(settings *RealtimeSettings) error {
 		return err
 	}
 
+	// Validate RTSP stream capture sample rates
+	for i, rate := range settings.RTSP.CaptureRates {
+		if rate.URL == "" {
+			return errors.New(fmt.Errorf("RTSP capture rate %d requires a stream URL", i+1)).
+				Category(errors.CategoryValidation).
+				Context("validation_type", "rtsp-capture-rate-url").
+				Build()
+		}
+		if err := validateCaptureSampleRate(rate.SampleRate, fmt.Sprintf("rtsp capture rate %d", i+1)); err != nil {
+			return err
+		}
+	}
+
 	// Add more realtime settings validation as needed
 	return nil
 }
 
+// validateCaptureSampleRate validates the capture sample rate of a source,
+// which is decimated by a whole factor to the analyzed sample rate. 0 captures
+// at the analyzed sample rate.
+func validateCaptureSampleRate(sampleRate int, source string) error {
+	if sampleRate == 0 || (sampleRate%SampleRate == 0 && sampleRate > 0 && sampleRate <= MaxCaptureSampleRate) {
+		return nil
+	}
+	return errors.New(fmt.Errorf("capture sample rate must be a multiple of %d Hz up to %d Hz, got %d", SampleRate, MaxCaptureSampleRate, sampleRate)).
+		Category(errors.CategoryValidation).
+		Context("validation_type", "audio-capture-rate").
+		Context("source", source).
+		Context("sample_rate", sampleRate).
+		Build()
+}
+
 // validateMQTTSettings validates the MQTT-specific settings
 func validateMQTTSettings(settings *MQTTSettings) error {
 	if settings.Enabled {
@@ -462,6 +709,75 @@ func validateAudioSettings(settings *AudioSettings) error {
 		settings.SoxAudioTypes = formats
 	}
 
+	// Validate stereo channel selection
+	switch settings.Channel {
+	case "", AudioChannelMix, AudioChannelLeft, AudioChannelRight, AudioChannelSplit:
+	default:
+		return errors.New(fmt.Errorf("audio channel must be one of mix, left, right or split, got %q", settings.Channel)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "audio-channel").
+			Context("channel", settings.Channel).
+			Build()
+	}
+
+	// Validate direction of arrival estimation
+	if settings.DOA.Enabled {
+		if settings.DOA.MicSpacing <= 0 || settings.DOA.MicSpacing > MaxDOAMicSpacing {
+			return errors.New(fmt.Errorf("direction of arrival microphone spacing must be above 0 and at most %g meters, got %g", MaxDOAMicSpacing, settings.DOA.MicSpacing)).
+				Category(errors.CategoryValidation).
+				Context("validation_type", "audio-doa-spacing").
+				Context("mic_spacing", settings.DOA.MicSpacing).
+				Build()
+		}
+		if settings.DOA.Orientation < 0 || settings.DOA.Orientation >= 360 {
+			return errors.New(fmt.Errorf("direction of arrival orientation must be between 0 and 360 degrees, got %g", settings.DOA.Orientation)).
+				Category(errors.CategoryValidation).
+				Context("validation_type", "audio-doa-orientation").
+				Context("orientation", settings.DOA.Orientation).
+				Build()
+		}
+	}
+
+	// Validate the sound card capture sample rate
+	if err := validateCaptureSampleRate(settings.CaptureRate.SampleRate, settings.Source); err != nil {
+		return err
+	}
+
+	// Validate encoding of audio sent to other destinations, also used with local export disabled
+	if err := validateEncodingProfile(&settings.Export.Destinations.BirdWeather, "birdweather", "wav", "flac"); err != nil {
+		return err
+	}
+	if err := validateEncodingProfile(&settings.Export.Destinations.Notification, "notification", "wav", "flac", "aac", "opus", "mp3"); err != nil {
+		return err
+	}
+
+	// Validate the detection snippet, retained only while clip export is disabled
+	if settings.Export.Snippet.Enabled && !settings.Export.Enabled {
+		if err := validateSnippetSettings(&settings.Export.Snippet); err != nil {
+			return err
+		}
+	}
+
+	// Only WAV and FLAC are encoded without FFmpeg, lossy destinations get FLAC
+	if settings.FfmpegPath == "" {
+		lossy := map[string]string{"notification": settings.Export.Destinations.Notification.Type}
+		if settings.Export.Snippet.Enabled && !settings.Export.Enabled {
+			lossy["snippet"] = settings.Export.Snippet.Type
+		}
+		for destination, audioType := range lossy {
+			if audioType != "" && audioType != "wav" && audioType != "flac" {
+				log.Printf("FFmpeg not available, using native FLAC encoding instead of %s for %s audio", audioType, destination)
+			}
+		}
+	}
+
+	// Validate the queue of clips and snippets waiting to be written
+	if settings.Export.Enabled || settings.Export.Snippet.Enabled {
+		if err := validateClipWriterSettings(&settings.Export.Writer); err != nil {
+			return err
+		}
+	}
+
 	// Validate audio export settings
 	if settings.Export.Enabled {
 		// Validate capture length (10-60 seconds)
@@ -538,8 +854,11 @@ func validateAudioSettings(settings *AudioSettings) error {
 		}
 
 		if settings.FfmpegPath == "" {
-			settings.Export.Type = "wav"
-			log.Printf("FFmpeg not available, using WAV format for audio export")
+			// WAV and FLAC are encoded natively, lossy formats need FFmpeg
+			if settings.Export.Type != "wav" && settings.Export.Type != "flac" {
+				log.Printf("FFmpeg not available, using native FLAC encoding instead of %s for audio export", settings.Export.Type)
+				settings.Export.Type = "flac"
+			}
 		} else {
 			// Validate audio type and bitrate
 			switch settings.Export.Type {
@@ -578,6 +897,112 @@ func validateAudioSettings(settings *AudioSettings) error {
 					Build()
 			}
 		}
+
+		// Bit depth and compression level apply to the local storage type only
+		if err := validateEncodingProfile(&EncodingProfile{
+			BitDepth:         settings.Export.BitDepth,
+			CompressionLevel: settings.Export.CompressionLevel,
+		}, "storage"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateEncodingProfile validates how audio is encoded for a destination. An
+// empty type uses the local export settings and is always accepted.
+func validateEncodingProfile(profile *EncodingProfile, destination string, types ...string) error {
+	if profile.Type != "" && !slices.Contains(types, profile.Type) {
+		return errors.New(fmt.Errorf("unsupported audio type for %s: %s, supported types are %s", destination, profile.Type, strings.Join(types, ", "))).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "audio-export-encoding-type").
+			Context("destination", destination).
+			Context("export_type", profile.Type).
+			Build()
+	}
+
+	if profile.BitDepth != 0 && profile.BitDepth != 16 && profile.BitDepth != 24 {
+		return errors.New(fmt.Errorf("bit depth for %s must be 16 or 24, got %d", destination, profile.BitDepth)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "audio-export-encoding-bitdepth").
+			Context("destination", destination).
+			Context("bit_depth", profile.BitDepth).
+			Build()
+	}
+
+	if profile.CompressionLevel < 0 || profile.CompressionLevel > 12 {
+		return errors.New(fmt.Errorf("flac compression level for %s must be between 0 and 12, got %d", destination, profile.CompressionLevel)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "audio-export-encoding-compression").
+			Context("destination", destination).
+			Context("compression_level", profile.CompressionLevel).
+			Build()
+	}
+
+	switch profile.Type {
+	case "aac", "opus", "mp3":
+		bitrate, err := strconv.Atoi(strings.TrimSuffix(profile.Bitrate, "k"))
+		if !strings.HasSuffix(profile.Bitrate, "k") || err != nil || bitrate < 32 || bitrate > 320 {
+			return errors.New(fmt.Errorf("bitrate for %s must be between 32k and 320k, got %q", destination, profile.Bitrate)).
+				Category(errors.CategoryValidation).
+				Context("validation_type", "audio-export-encoding-bitrate").
+				Context("destination", destination).
+				Context("bitrate", profile.Bitrate).
+				Build()
+		}
+	}
+
+	return nil
+}
+
+// validateClipWriterSettings validates the clip writer queue size and drop
+// policy; zero values use the defaults
+func validateClipWriterSettings(settings *ClipWriterSettings) error {
+	if settings.QueueSize < 0 || settings.QueueSize > 1000 {
+		return errors.New(fmt.Errorf("clip writer queue size must be between 1 and 1000, got %d", settings.QueueSize)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "audio-export-writer-queue-size").
+			Context("queue_size", settings.QueueSize).
+			Build()
+	}
+	switch settings.DropPolicy {
+	case "", "oldest", "newest":
+	default:
+		return errors.New(fmt.Errorf("clip writer drop policy must be oldest or newest, got %q", settings.DropPolicy)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "audio-export-writer-drop-policy").
+			Context("drop_policy", settings.DropPolicy).
+			Build()
+	}
+	return nil
+}
+
+// validateSnippetSettings validates the length and encoding of detection snippets
+func validateSnippetSettings(settings *SnippetSettings) error {
+	if settings.Length < 3 || settings.Length > 15 {
+		return errors.New(fmt.Errorf("snippet length must be between 3 and 15 seconds, got %d", settings.Length)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "audio-export-snippet-length").
+			Context("length", settings.Length).
+			Build()
+	}
+
+	if !slices.Contains([]string{"opus", "aac", "mp3"}, settings.Type) {
+		return errors.New(fmt.Errorf("unsupported snippet type: %s, supported types are opus, aac, mp3", settings.Type)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "audio-export-snippet-type").
+			Context("export_type", settings.Type).
+			Build()
+	}
+
+	bitrate, err := strconv.Atoi(strings.TrimSuffix(settings.Bitrate, "k"))
+	if !strings.HasSuffix(settings.Bitrate, "k") || err != nil || bitrate < 8 || bitrate > 128 {
+		return errors.New(fmt.Errorf("snippet bitrate must be between 8k and 128k, got %q", settings.Bitrate)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "audio-export-snippet-bitrate").
+			Context("bitrate", settings.Bitrate).
+			Build()
 	}
 
 	return nil
@@ -635,51 +1060,614 @@ func validateWeatherSettings(settings *WeatherSettings) error {
 		}
 	}
 
-	return nil
+	return validateWeatherAdaptiveSettings(&settings.Adaptive)
 }
 
-// validateSpeciesTrackingSettings validates the species tracking settings
-func validateSpeciesTrackingSettings(settings *SpeciesTrackingSettings) error {
-	if settings.Enabled {
-		// Validate window days
-		if settings.NewSpeciesWindowDays < 1 || settings.NewSpeciesWindowDays > 365 {
-			return errors.New(fmt.Errorf("species tracking window days must be between 1 and 365, got %d", settings.NewSpeciesWindowDays)).
-				Category(errors.CategoryValidation).
-				Context("validation_type", "species-tracking-window-days").
-				Context("window_days", settings.NewSpeciesWindowDays).
-				Build()
-		}
-
-		// Validate sync interval
-		if settings.SyncIntervalMinutes < 5 || settings.SyncIntervalMinutes > 1440 {
-			return errors.New(fmt.Errorf("species tracking sync interval must be between 5 and 1440 minutes (24 hours), got %d", settings.SyncIntervalMinutes)).
-				Category(errors.CategoryValidation).
-				Context("validation_type", "species-tracking-sync-interval").
-				Context("sync_interval", settings.SyncIntervalMinutes).
-				Build()
-		}
+// validateWeatherAdaptiveSettings validates the weather-adaptive threshold settings
+func validateWeatherAdaptiveSettings(settings *WeatherAdaptiveSettings) error {
+	if !settings.Enabled {
+		return nil
+	}
 
-		// Validate notification suppression hours
-		if settings.NotificationSuppressionHours < 0 || settings.NotificationSuppressionHours > 720 {
-			return errors.New(fmt.Errorf("notification suppression hours must be between 0 and 720 (30 days), got %d", settings.NotificationSuppressionHours)).
-				Category(errors.CategoryValidation).
-				Context("validation_type", "notification-suppression-hours").
-				Context("suppression_hours", settings.NotificationSuppressionHours).
-				Build()
-		}
+	if settings.WindMin < 0 || settings.WindMax <= settings.WindMin {
+		return errors.New(fmt.Errorf("weather adaptive windMax must be above windMin and windMin not negative, got %.1f and %.1f", settings.WindMax, settings.WindMin)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "weather-adaptive-wind").
+			Build()
+	}
 
-		// Validate yearly tracking settings
-		if err := validateYearlyTrackingSettings(&settings.YearlyTracking); err != nil {
-			return err
-		}
+	if settings.WindAdjustment < 0 || settings.WindAdjustment > 1 || settings.RainAdjustment < 0 || settings.RainAdjustment > 1 {
+		return errors.New(fmt.Errorf("weather adaptive adjustments must be between 0 and 1, got wind %.2f and rain %.2f", settings.WindAdjustment, settings.RainAdjustment)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "weather-adaptive-adjustment").
+			Build()
+	}
 
-		// Validate seasonal tracking settings
-		if err := validateSeasonalTrackingSettings(&settings.SeasonalTracking); err != nil {
-			return err
-		}
+	if settings.MaxThreshold <= 0 || settings.MaxThreshold > 1 {
+		return errors.New(fmt.Errorf("weather adaptive maxThreshold must be above 0 and at most 1, got %.2f", settings.MaxThreshold)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "weather-adaptive-max-threshold").
+			Build()
 	}
-	return nil
-}
+
+	if settings.MaxAge < 1 {
+		return errors.New(fmt.Errorf("weather adaptive maxAge must be at least 1 minute, got %d", settings.MaxAge)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "weather-adaptive-max-age").
+			Build()
+	}
+
+	return nil
+}
+
+// validateTimePriorSettings validates the time-of-day prior settings
+func validateTimePriorSettings(settings *TimePriorSettings) error {
+	if !settings.Enabled {
+		return nil
+	}
+
+	if settings.Learn && (settings.LearnDays < 1 || settings.MinDetections < 1) {
+		return errors.New(fmt.Errorf("time priors learnDays and minDetections must be at least 1, got %d and %d", settings.LearnDays, settings.MinDetections)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "time-priors-learn").
+			Build()
+	}
+
+	if settings.Adjustment < 0 || settings.Adjustment > 1 {
+		return errors.New(fmt.Errorf("time priors adjustment must be between 0 and 1, got %.2f", settings.Adjustment)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "time-priors-adjustment").
+			Build()
+	}
+
+	if settings.MaxThreshold <= 0 || settings.MaxThreshold > 1 {
+		return errors.New(fmt.Errorf("time priors maxThreshold must be above 0 and at most 1, got %.2f", settings.MaxThreshold)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "time-priors-max-threshold").
+			Build()
+	}
+
+	for species, prior := range settings.Species {
+		if prior.Inactive < 0 || prior.Inactive > 1 {
+			return errors.New(fmt.Errorf("time prior of %s: inactive must be between 0 and 1, got %.2f", species, prior.Inactive)).
+				Category(errors.CategoryValidation).
+				Context("validation_type", "time-priors-species").
+				Build()
+		}
+		for _, hour := range prior.ActiveHours {
+			if hour < 0 || hour > 23 {
+				return errors.New(fmt.Errorf("time prior of %s: active hours must be between 0 and 23, got %d", species, hour)).
+					Category(errors.CategoryValidation).
+					Context("validation_type", "time-priors-species").
+					Build()
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateRareSpeciesSettings validates the rare species alert settings
+func validateRareSpeciesSettings(settings *RareSpeciesSettings) error {
+	if !settings.Enabled {
+		return nil
+	}
+
+	switch settings.Source {
+	case "ebird":
+	case "barchart":
+		if strings.TrimSpace(settings.BarChartFile) == "" {
+			return errors.New(fmt.Errorf("rare species barChartFile is required with the barchart source")).
+				Category(errors.CategoryValidation).
+				Context("validation_type", "rare-species-barchart-file").
+				Build()
+		}
+	default:
+		return errors.New(fmt.Errorf("rare species source must be ebird or barchart, got %q", settings.Source)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "rare-species-source").
+			Build()
+	}
+
+	if settings.MaxFrequency < 0 || settings.MaxFrequency >= 1 {
+		return errors.New(fmt.Errorf("rare species maxFrequency must be at least 0 and below 1, got %.2f", settings.MaxFrequency)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "rare-species-max-frequency").
+			Build()
+	}
+
+	if settings.MinConfidence < 0 || settings.MinConfidence > 1 {
+		return errors.New(fmt.Errorf("rare species minConfidence must be between 0 and 1, got %.2f", settings.MinConfidence)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "rare-species-min-confidence").
+			Build()
+	}
+
+	if settings.Cooldown < 0 {
+		return errors.New(fmt.Errorf("rare species cooldown must not be negative, got %d", settings.Cooldown)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "rare-species-cooldown").
+			Build()
+	}
+
+	return nil
+}
+
+// validateDataBudgetSettings validates the metered connection data budget settings
+func validateDataBudgetSettings(settings *DataBudgetSettings) error {
+	if !settings.Enabled {
+		return nil
+	}
+
+	if settings.MonthlyCapMB <= 0 {
+		return errors.New(fmt.Errorf("data budget monthly cap must be positive, got %d MB", settings.MonthlyCapMB)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "databudget-monthly-cap").
+			Build()
+	}
+
+	if settings.ThrottleThreshold < 1 || settings.ThrottleThreshold > 100 {
+		return errors.New(fmt.Errorf("data budget throttle threshold must be between 1 and 100 percent, got %d", settings.ThrottleThreshold)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "databudget-throttle-threshold").
+			Build()
+	}
+
+	if settings.ResetDay < 1 || settings.ResetDay > 28 {
+		return errors.New(fmt.Errorf("data budget reset day must be between 1 and 28, got %d", settings.ResetDay)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "databudget-reset-day").
+			Build()
+	}
+
+	for _, name := range settings.Essential {
+		switch name {
+		case "uploads", "images", "weather":
+		default:
+			return errors.New(fmt.Errorf("unknown data budget integration %q, use uploads, images or weather", name)).
+				Category(errors.CategoryValidation).
+				Context("validation_type", "databudget-essential").
+				Build()
+		}
+	}
+
+	return nil
+}
+
+// fieldSyncUnitIDPattern restricts unit IDs to characters safe in file paths
+var fieldSyncUnitIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+// validateFieldSyncSettings validates the field unit and aggregator sync settings
+func validateFieldSyncSettings(settings *FieldSyncSettings) error {
+	if settings.Enabled {
+		if settings.AggregatorURL == "" || settings.Token == "" {
+			return errors.New(fmt.Errorf("field sync requires an aggregator URL and token")).
+				Category(errors.CategoryValidation).
+				Context("validation_type", "fieldsync-aggregator").
+				Build()
+		}
+		if settings.Interval < 1 {
+			return errors.New(fmt.Errorf("field sync interval must be at least 1 minute, got %d", settings.Interval)).
+				Category(errors.CategoryValidation).
+				Context("validation_type", "fieldsync-interval").
+				Build()
+		}
+		if settings.BatchSize < 1 || settings.BatchSize > 1000 {
+			return errors.New(fmt.Errorf("field sync batch size must be between 1 and 1000, got %d", settings.BatchSize)).
+				Category(errors.CategoryValidation).
+				Context("validation_type", "fieldsync-batch-size").
+				Build()
+		}
+		if settings.ChunkSizeKB < 1 || settings.ChunkSizeKB > 1024 {
+			return errors.New(fmt.Errorf("field sync chunk size must be between 1 and 1024 KB, got %d", settings.ChunkSizeKB)).
+				Category(errors.CategoryValidation).
+				Context("validation_type", "fieldsync-chunk-size").
+				Build()
+		}
+	}
+
+	if settings.Aggregator.Enabled {
+		seen := make(map[string]bool, len(settings.Aggregator.Units))
+		for _, unit := range settings.Aggregator.Units {
+			if !fieldSyncUnitIDPattern.MatchString(unit.ID) || unit.Token == "" || seen[unit.ID] {
+				return errors.New(fmt.Errorf("field sync unit %q needs a unique ID of letters, digits, '.', '_' or '-' and a token", unit.ID)).
+					Category(errors.CategoryValidation).
+					Context("validation_type", "fieldsync-unit").
+					Build()
+			}
+			seen[unit.ID] = true
+		}
+	}
+
+	return nil
+}
+
+// validateEncryptionSettings validates the at-rest encryption settings.
+// The key itself is decoded and checked when it is loaded at startup.
+func validateEncryptionSettings(settings *EncryptionSettings, sqliteEnabled bool) error {
+	if !settings.Clips && !settings.Database {
+		return nil
+	}
+
+	if settings.Key == "" && settings.KeyFile == "" {
+		return errors.New(fmt.Errorf("encryption requires a key or key file")).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "encryption-key").
+			Build()
+	}
+	if settings.Database && !sqliteEnabled {
+		return errors.New(fmt.Errorf("database encryption is only supported for SQLite")).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "encryption-database").
+			Build()
+	}
+
+	return nil
+}
+
+// validateDataQualitySettings validates the data quality report thresholds
+func validateDataQualitySettings(settings *DataQualitySettings) error {
+	if mic := &settings.MicFailure; mic.Enabled {
+		if mic.WindowMinutes < 5 || mic.WindowMinutes > 1440 {
+			return errors.New(fmt.Errorf("microphone failure window must be between 5 and 1440 minutes, got %d", mic.WindowMinutes)).
+				Category(errors.CategoryValidation).
+				Context("validation_type", "dataquality-micfailure-window").
+				Build()
+		}
+		if mic.HumPercent <= 0 || mic.HumPercent > 100 {
+			return errors.New(fmt.Errorf("microphone failure hum percent must be above 0 and at most 100, got %g", mic.HumPercent)).
+				Category(errors.CategoryValidation).
+				Context("validation_type", "dataquality-micfailure-hum").
+				Build()
+		}
+	}
+
+	if cal := &settings.Calibration; cal.Enabled {
+		if cal.Frequency < 100 || cal.Frequency > SampleRate/2 {
+			return errors.New(fmt.Errorf("calibration tone frequency must be between 100 and %d Hz, got %g", SampleRate/2, cal.Frequency)).
+				Category(errors.CategoryValidation).
+				Context("validation_type", "dataquality-calibration-frequency").
+				Build()
+		}
+		if cal.MinSeconds < 1 || cal.MinSeconds > 60 {
+			return errors.New(fmt.Errorf("calibration tone duration must be between 1 and 60 seconds, got %d", cal.MinSeconds)).
+				Category(errors.CategoryValidation).
+				Context("validation_type", "dataquality-calibration-duration").
+				Build()
+		}
+		if cal.ReferenceLevel > 0 || cal.Tolerance <= 0 || cal.Tolerance > 20 {
+			return errors.New(fmt.Errorf("calibration reference level must be at most 0 dBFS and tolerance between 0 and 20 dB, got %g and %g", cal.ReferenceLevel, cal.Tolerance)).
+				Category(errors.CategoryValidation).
+				Context("validation_type", "dataquality-calibration-level").
+				Build()
+		}
+	}
+
+	if anomaly := &settings.Anomaly; anomaly.Enabled {
+		if anomaly.BaselineDays < 7 || anomaly.BaselineDays > 90 {
+			return errors.New(fmt.Errorf("anomaly baseline must be between 7 and 90 days, got %d", anomaly.BaselineDays)).
+				Category(errors.CategoryValidation).
+				Context("validation_type", "dataquality-anomaly-baseline").
+				Build()
+		}
+		if anomaly.Threshold < 1 || anomaly.MinSpike < 1 || anomaly.MinExpected < 1 {
+			return errors.New(fmt.Errorf("anomaly threshold, minimum spike and minimum expected detections must be at least 1, got %g, %d and %g", anomaly.Threshold, anomaly.MinSpike, anomaly.MinExpected)).
+				Category(errors.CategoryValidation).
+				Context("validation_type", "dataquality-anomaly-threshold").
+				Build()
+		}
+	}
+
+	if absence := &settings.Absence; absence.Enabled {
+		if absence.Days < 1 || absence.BaselineDays < 7 || absence.BaselineDays > 90 {
+			return errors.New(fmt.Errorf("absence must be at least 1 day and its baseline between 7 and 90 days, got %d and %d", absence.Days, absence.BaselineDays)).
+				Category(errors.CategoryValidation).
+				Context("validation_type", "dataquality-absence-days").
+				Build()
+		}
+		if absence.MinPresence <= 0 || absence.MinPresence > 1 {
+			return errors.New(fmt.Errorf("absence minimum presence must be above 0 and at most 1, got %g", absence.MinPresence)).
+				Category(errors.CategoryValidation).
+				Context("validation_type", "dataquality-absence-presence").
+				Build()
+		}
+	}
+
+	if !settings.Enabled {
+		return nil
+	}
+
+	if settings.GapMinutes < 1 || settings.SilentMinutes < 1 {
+		return errors.New(fmt.Errorf("data quality gap and silent periods must be at least 1 minute, got %d and %d", settings.GapMinutes, settings.SilentMinutes)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "dataquality-period").
+			Build()
+	}
+	if settings.SilenceThreshold >= 0 {
+		return errors.New(fmt.Errorf("data quality silence threshold must be below 0 dBFS, got %g", settings.SilenceThreshold)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "dataquality-silence-threshold").
+			Build()
+	}
+	if settings.ClippingPercent <= 0 || settings.ClippingPercent > 100 {
+		return errors.New(fmt.Errorf("data quality clipping percent must be above 0 and at most 100, got %g", settings.ClippingPercent)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "dataquality-clipping").
+			Build()
+	}
+	if settings.RateLowPercent < 0 || settings.RateLowPercent >= 100 || settings.RateHighPercent <= 100 {
+		return errors.New(fmt.Errorf("data quality detection rate limits must satisfy 0 <= low < 100 < high, got %d and %d", settings.RateLowPercent, settings.RateHighPercent)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "dataquality-detection-rate").
+			Build()
+	}
+	if settings.BaselineDays < 1 || settings.BaselineDays > 90 {
+		return errors.New(fmt.Errorf("data quality baseline must be between 1 and 90 days, got %d", settings.BaselineDays)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "dataquality-baseline-days").
+			Build()
+	}
+
+	return nil
+}
+
+// validateDetectionRetentionSettings validates the detection thinning policies
+func validateDetectionRetentionSettings(settings *DetectionRetentionSettings) error {
+	if !settings.Enabled {
+		return nil
+	}
+	for i := range settings.Policies {
+		policy := &settings.Policies[i]
+		if policy.MaxAgeDays < 30 {
+			return errors.New(fmt.Errorf("detection retention policy %q must keep detections for at least 30 days, got %d", policy.Name, policy.MaxAgeDays)).
+				Category(errors.CategoryValidation).
+				Context("validation_type", "detection-retention-age").
+				Build()
+		}
+		if policy.MinDetections < 0 {
+			return errors.New(fmt.Errorf("detection retention policy %q minimum detections must not be negative, got %d", policy.Name, policy.MinDetections)).
+				Category(errors.CategoryValidation).
+				Context("validation_type", "detection-retention-min-detections").
+				Build()
+		}
+	}
+	return nil
+}
+
+// validateForecastSettings validates the species occurrence forecast settings
+func validateForecastSettings(settings *ForecastSettings) error {
+	if settings.Days < 1 || settings.Days > 28 {
+		return errors.New(fmt.Errorf("forecast days must be between 1 and 28, got %d", settings.Days)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "forecast-days").
+			Build()
+	}
+	if settings.Years < 1 || settings.Years > 20 {
+		return errors.New(fmt.Errorf("forecast years must be between 1 and 20, got %d", settings.Years)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "forecast-years").
+			Build()
+	}
+	if settings.MinLikelihood < 0 || settings.MinLikelihood > 1 {
+		return errors.New(fmt.Errorf("forecast minimum likelihood must be between 0 and 1, got %g", settings.MinLikelihood)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "forecast-min-likelihood").
+			Build()
+	}
+	if !settings.Notify {
+		return nil
+	}
+	if _, err := ParseWeekday(settings.NotifyWeekday); err != nil {
+		return errors.New(fmt.Errorf("forecast notification weekday %q is not a day of the week", settings.NotifyWeekday)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "forecast-notify-weekday").
+			Build()
+	}
+	if settings.NotifyHour < 0 || settings.NotifyHour > 23 {
+		return errors.New(fmt.Errorf("forecast notification hour must be between 0 and 23, got %d", settings.NotifyHour)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "forecast-notify-hour").
+			Build()
+	}
+	if settings.NotifyLimit < 1 || settings.NotifyLimit > 50 {
+		return errors.New(fmt.Errorf("forecast notification limit must be between 1 and 50, got %d", settings.NotifyLimit)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "forecast-notify-limit").
+			Build()
+	}
+	return nil
+}
+
+// validateOnThisDaySettings validates the on this day highlights settings
+func validateOnThisDaySettings(settings *OnThisDaySettings) error {
+	if settings.Years < 1 || settings.Years > 50 {
+		return errors.New(fmt.Errorf("on this day years must be between 1 and 50, got %d", settings.Years)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "onthisday-years").
+			Build()
+	}
+	if settings.RareMaxDetections < 0 {
+		return errors.New(fmt.Errorf("on this day rare max detections cannot be negative, got %d", settings.RareMaxDetections)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "onthisday-rare-max-detections").
+			Build()
+	}
+	if settings.Notify && (settings.NotifyHour < 0 || settings.NotifyHour > 23) {
+		return errors.New(fmt.Errorf("on this day notification hour must be between 0 and 23, got %d", settings.NotifyHour)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "onthisday-notify-hour").
+			Build()
+	}
+	return nil
+}
+
+// validateNewsletterSettings validates the weekly newsletter settings
+func validateNewsletterSettings(settings *NewsletterSettings) error {
+	if !settings.Enabled {
+		return nil
+	}
+	if settings.Hour < 0 || settings.Hour > 23 {
+		return errors.New(fmt.Errorf("newsletter hour must be between 0 and 23, got %d", settings.Hour)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "newsletter-hour").
+			Build()
+	}
+	if strings.TrimSpace(settings.Path) == "" {
+		return errors.New(fmt.Errorf("newsletter path cannot be empty")).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "newsletter-path").
+			Build()
+	}
+	if settings.TopSpecies < 1 || settings.TopSpecies > 50 {
+		return errors.New(fmt.Errorf("newsletter top species must be between 1 and 50, got %d", settings.TopSpecies)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "newsletter-top-species").
+			Build()
+	}
+	return nil
+}
+
+// validateTrashSettings validates the detection trash settings
+func validateTrashSettings(settings *TrashSettings) error {
+	if settings.Enabled && (settings.RetentionDays < 1 || settings.RetentionDays > 3650) {
+		return errors.New(fmt.Errorf("trash retention days must be between 1 and 3650, got %d", settings.RetentionDays)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "trash-retention-days").
+			Build()
+	}
+	return nil
+}
+
+// validateTimeLapseSettings validates the daily soundscape time-lapse settings
+func validateTimeLapseSettings(settings *TimeLapseSettings) error {
+	if !settings.Enabled {
+		return nil
+	}
+	if settings.Hour < 0 || settings.Hour > 23 {
+		return errors.New(fmt.Errorf("time-lapse hour must be between 0 and 23, got %d", settings.Hour)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "timelapse-hour").
+			Build()
+	}
+	if strings.TrimSpace(settings.Path) == "" {
+		return errors.New(fmt.Errorf("time-lapse path cannot be empty")).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "timelapse-path").
+			Build()
+	}
+	if settings.SegmentSeconds < 1 || settings.SegmentSeconds > 15 {
+		return errors.New(fmt.Errorf("time-lapse segment seconds must be between 1 and 15, got %g", settings.SegmentSeconds)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "timelapse-segment-seconds").
+			Build()
+	}
+	return nil
+}
+
+// ChatOpsProviderName is the push provider name of the chat-ops alert channel
+const ChatOpsProviderName = "chatops"
+
+// validateChatOpsSettings validates the slash command and alert settings
+func validateChatOpsSettings(settings *ChatOpsSettings) error {
+	if settings.Slack.Enabled && settings.Slack.SigningSecret == "" && settings.Slack.SigningSecretFile == "" {
+		return errors.New(fmt.Errorf("chatops.slack requires a signing secret when enabled")).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "chatops-slack-signing-secret").
+			Build()
+	}
+	if settings.Discord.Enabled {
+		key, err := hex.DecodeString(strings.TrimSpace(settings.Discord.PublicKey))
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			return errors.New(fmt.Errorf("chatops.discord.publickey must be the hex encoded application public key")).
+				Category(errors.CategoryValidation).
+				Context("validation_type", "chatops-discord-public-key").
+				Build()
+		}
+	}
+	for name, webhook := range map[string]string{"slack": settings.Slack.AlertWebhookURL, "discord": settings.Discord.AlertWebhookURL} {
+		if webhook == "" {
+			continue
+		}
+		if u, err := url.Parse(webhook); err != nil || u.Scheme != "https" || u.Host == "" {
+			return errors.New(fmt.Errorf("chatops.%s.alertwebhookurl must be an https URL", name)).
+				Category(errors.CategoryValidation).
+				Context("validation_type", "chatops-alert-webhook-url").
+				Build()
+		}
+	}
+	return nil
+}
+
+// validateSpeciesTrackingSettings validates the species tracking settings
+func validateSpeciesTrackingSettings(settings *SpeciesTrackingSettings) error {
+	if settings.Enabled {
+		// Validate window days
+		if settings.NewSpeciesWindowDays < 1 || settings.NewSpeciesWindowDays > 365 {
+			return errors.New(fmt.Errorf("species tracking window days must be between 1 and 365, got %d", settings.NewSpeciesWindowDays)).
+				Category(errors.CategoryValidation).
+				Context("validation_type", "species-tracking-window-days").
+				Context("window_days", settings.NewSpeciesWindowDays).
+				Build()
+		}
+
+		// Validate sync interval
+		if settings.SyncIntervalMinutes < 5 || settings.SyncIntervalMinutes > 1440 {
+			return errors.New(fmt.Errorf("species tracking sync interval must be between 5 and 1440 minutes (24 hours), got %d", settings.SyncIntervalMinutes)).
+				Category(errors.CategoryValidation).
+				Context("validation_type", "species-tracking-sync-interval").
+				Context("sync_interval", settings.SyncIntervalMinutes).
+				Build()
+		}
+
+		// Validate notification suppression hours
+		if settings.NotificationSuppressionHours < 0 || settings.NotificationSuppressionHours > 720 {
+			return errors.New(fmt.Errorf("notification suppression hours must be between 0 and 720 (30 days), got %d", settings.NotificationSuppressionHours)).
+				Category(errors.CategoryValidation).
+				Context("validation_type", "notification-suppression-hours").
+				Context("suppression_hours", settings.NotificationSuppressionHours).
+				Build()
+		}
+
+		// Validate yearly tracking settings
+		if err := validateYearlyTrackingSettings(&settings.YearlyTracking); err != nil {
+			return err
+		}
+
+		// Validate seasonal tracking settings
+		if err := validateSeasonalTrackingSettings(&settings.SeasonalTracking); err != nil {
+			return err
+		}
+
+		// Validate new species context check settings
+		if err := validateContextCheckSettings(&settings.ContextCheck); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateContextCheckSettings validates the new species context check settings
+func validateContextCheckSettings(settings *ContextCheckSettings) error {
+	if !settings.Enabled {
+		return nil
+	}
+
+	if settings.MinWindows < 1 || settings.MinWindows > 2 {
+		return errors.New(fmt.Errorf("context check minWindows must be 1 or 2, got %d", settings.MinWindows)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "context-check-min-windows").
+			Build()
+	}
+
+	if settings.MinConfidence <= 0 || settings.MinConfidence > 1 || settings.SkipConfidence <= 0 || settings.SkipConfidence > 1 {
+		return errors.New(fmt.Errorf("context check confidences must be above 0 and at most 1, got minConfidence %.2f and skipConfidence %.2f", settings.MinConfidence, settings.SkipConfidence)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "context-check-confidence").
+			Build()
+	}
+
+	return nil
+}
 
 func validateYearlyTrackingSettings(settings *YearlyTrackingSettings) error {
 	if settings.Enabled {
@@ -795,8 +1783,33 @@ func validateSpeciesConfigSettings(settings *SpeciesSettings) error {
 	return nil
 }
 
-// validateNotificationSettings validates notification push configuration
-func validateNotificationSettings(n *NotificationConfig) error {
+// validateNotificationSettings validates notification push configuration.
+// Routes may also name the builtin channels enabled outside the notification
+// settings.
+func validateNotificationSettings(n *NotificationConfig, builtin ...string) error {
+	if err := validateEmailSettings(&n.Email); err != nil {
+		return err
+	}
+	if n.Email.Enabled {
+		if err := validateQuietHours(EmailProviderName, &n.Email.QuietHours); err != nil {
+			return err
+		}
+	}
+	if err := validateDailyDigest(&n.DailyDigest); err != nil {
+		return err
+	}
+	if err := validateDeliveryQueue(&n.Deliveries); err != nil {
+		return err
+	}
+	if n.Dedup.Enabled && n.Dedup.Window <= 0 {
+		return errors.New(fmt.Errorf("notification.dedup.window must be > 0")).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "notification-dedup-window").
+			Build()
+	}
+	if err := validateChannelTemplates(&n.Templates); err != nil {
+		return err
+	}
 	if !n.Push.Enabled {
 		return nil
 	}
@@ -835,16 +1848,361 @@ func validateNotificationSettings(n *NotificationConfig) error {
 			if err := validateWebhookProvider(p); err != nil {
 				return err
 			}
+		case "telegram":
+			if err := validateTelegramProvider(p); err != nil {
+				return err
+			}
+		case "ntfy":
+			if err := validateNtfyProvider(p); err != nil {
+				return err
+			}
+		case "pushover":
+			if err := validatePushoverProvider(p); err != nil {
+				return err
+			}
 		default:
 			return errors.New(fmt.Errorf("unknown push provider type: %s", p.Type)).
 				Category(errors.CategoryValidation).
 				Context("validation_type", "notification-push-provider-type").
 				Build()
 		}
+		if err := validateQuietHours(PushProviderName(p), &p.QuietHours); err != nil {
+			return err
+		}
+	}
+	if n.Email.Enabled {
+		builtin = append(builtin, EmailProviderName)
+	}
+	if err := validatePushRoutes(&n.Push, builtin...); err != nil {
+		return err
+	}
+	return validateNotificationRules(n, builtin...)
+}
+
+// quietHoursTimePattern matches the HH:MM bounds of a quiet hours window
+var quietHoursTimePattern = regexp.MustCompile(`^([01][0-9]|2[0-3]):[0-5][0-9]$`)
+
+// validateQuietHours checks the window and mode of the quiet hours of a channel
+func validateQuietHours(channel string, q *QuietHoursConfig) error {
+	if !q.Enabled {
+		return nil
+	}
+	if !quietHoursTimePattern.MatchString(q.From) || !quietHoursTimePattern.MatchString(q.Until) {
+		return errors.New(fmt.Errorf("quiet hours of %s: from and until must be HH:MM times, got %q and %q", channel, q.From, q.Until)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "notification-quiet-hours-window").
+			Build()
+	}
+	if q.From == q.Until {
+		return errors.New(fmt.Errorf("quiet hours of %s: from and until must differ", channel)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "notification-quiet-hours-window").
+			Build()
+	}
+	switch strings.ToLower(q.Mode) {
+	case "", "hold", "digest":
+	default:
+		return errors.New(fmt.Errorf("quiet hours of %s: mode must be hold or digest, got %q", channel, q.Mode)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "notification-quiet-hours-mode").
+			Build()
+	}
+	return nil
+}
+
+// validateDailyDigest checks the send time of the daily digest
+func validateDailyDigest(d *DailyDigestSettings) error {
+	if !d.Enabled {
+		return nil
+	}
+	if !quietHoursTimePattern.MatchString(d.Time) {
+		return errors.New(fmt.Errorf("notification.daily_digest.time must be HH:MM, got %q", d.Time)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "notification-daily-digest-time").
+			Build()
+	}
+	if d.TopClips < 0 {
+		return errors.New(fmt.Errorf("notification.daily_digest.top_clips must be >= 0")).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "notification-daily-digest-clips").
+			Build()
+	}
+	return nil
+}
+
+// templateChannels are the channels notification templates can be
+// overridden for: the web UI, email, chat-ops and the push provider types
+var templateChannels = []string{"ui", EmailProviderName, ChatOpsProviderName, "script", "shoutrrr", "webhook", "telegram", "ntfy", "pushover"}
+
+// validateChannelTemplates checks the channels of the per-channel templates
+// and the syntax of their templates
+func validateChannelTemplates(t *NotificationTemplates) error {
+	for channel, templates := range t.Channels {
+		if !slices.Contains(templateChannels, strings.ToLower(channel)) {
+			return errors.New(fmt.Errorf("notification.templates.channels: unknown channel %q, must be one of %s", channel, strings.Join(templateChannels, ", "))).
+				Category(errors.CategoryValidation).
+				Context("validation_type", "notification-template-channel").
+				Build()
+		}
+		fields := map[string]string{
+			"newspecies.title":    templates.NewSpecies.Title,
+			"newspecies.message":  templates.NewSpecies.Message,
+			"dailydigest.title":   templates.DailyDigest.Title,
+			"dailydigest.message": templates.DailyDigest.Message,
+		}
+		for name, tmpl := range fields {
+			if tmpl == "" {
+				continue
+			}
+			if _, err := template.New("validation").Funcs(templatefuncs.FuncMap()).Parse(tmpl); err != nil {
+				return errors.New(fmt.Errorf("notification.templates.channels.%s.%s: invalid template syntax: %w", channel, name, err)).
+					Category(errors.CategoryValidation).
+					Context("validation_type", "notification-channel-template").
+					Context("error_detail", err.Error()).
+					Build()
+			}
+		}
+	}
+	return nil
+}
+
+// validateDeliveryQueue checks the retry limits of the delivery queue
+func validateDeliveryQueue(d *DeliveryQueueSettings) error {
+	if !d.Enabled {
+		return nil
+	}
+	if d.MaxAttempts < 1 {
+		return errors.New(fmt.Errorf("notification.deliveries.max_attempts must be >= 1")).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "notification-deliveries-attempts").
+			Build()
+	}
+	if d.InitialDelay <= 0 || d.MaxDelay < d.InitialDelay {
+		return errors.New(fmt.Errorf("notification.deliveries.initial_delay must be > 0 and <= max_delay")).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "notification-deliveries-delay").
+			Build()
+	}
+	if d.MaxPending < 1 {
+		return errors.New(fmt.Errorf("notification.deliveries.max_pending must be >= 1")).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "notification-deliveries-pending").
+			Build()
+	}
+	return nil
+}
+
+// notificationRuleTimePattern matches the bounds of a rule time of day window
+var notificationRuleTimePattern = regexp.MustCompile(`^(([01][0-9]|2[0-3]):[0-5][0-9]|sunrise|sunset|dawn|dusk)$`)
+
+// validateNotificationRules checks the conditions of detection notification
+// rules and that their channels name configured providers
+func validateNotificationRules(n *NotificationConfig, builtin ...string) error {
+	names := make(map[string]bool, len(n.Push.Providers)+len(builtin))
+	for i := range n.Push.Providers {
+		names[PushProviderName(&n.Push.Providers[i])] = true
+	}
+	for _, name := range builtin {
+		names[name] = true
+	}
+
+	for i := range n.Rules {
+		r := &n.Rules[i]
+		if !r.Enabled {
+			continue
+		}
+		if r.MinConfidence < 0 || r.MinConfidence > 1 {
+			return errors.New(fmt.Errorf("notification rule '%s': min_confidence must be between 0 and 1, got %g", r.Name, r.MinConfidence)).
+				Category(errors.CategoryValidation).
+				Context("validation_type", "notification-rule-confidence").
+				Context("rule_name", r.Name).
+				Build()
+		}
+		if (r.From == "") != (r.Until == "") {
+			return errors.New(fmt.Errorf("notification rule '%s': from and until must be set together", r.Name)).
+				Category(errors.CategoryValidation).
+				Context("validation_type", "notification-rule-window").
+				Context("rule_name", r.Name).
+				Build()
+		}
+		for _, bound := range []string{r.From, r.Until} {
+			if bound != "" && !notificationRuleTimePattern.MatchString(strings.ToLower(bound)) {
+				return errors.New(fmt.Errorf("notification rule '%s': time %q must be HH:MM, sunrise, sunset, dawn or dusk", r.Name, bound)).
+					Category(errors.CategoryValidation).
+					Context("validation_type", "notification-rule-window").
+					Context("rule_name", r.Name).
+					Build()
+			}
+		}
+		switch strings.ToLower(r.Priority) {
+		case "", "low", "medium", "high", "critical":
+		default:
+			return errors.New(fmt.Errorf("notification rule '%s': priority must be low, medium, high or critical, got %q", r.Name, r.Priority)).
+				Category(errors.CategoryValidation).
+				Context("validation_type", "notification-rule-priority").
+				Context("rule_name", r.Name).
+				Build()
+		}
+		if r.Cooldown < 0 {
+			return errors.New(fmt.Errorf("notification rule '%s': cooldown must not be negative", r.Name)).
+				Category(errors.CategoryValidation).
+				Context("validation_type", "notification-rule-cooldown").
+				Context("rule_name", r.Name).
+				Build()
+		}
+		for _, channel := range r.Channels {
+			if !names[channel] {
+				return errors.New(fmt.Errorf("notification rule %d (%s): unknown channel %q", i, r.Name, channel)).
+					Category(errors.CategoryValidation).
+					Context("validation_type", "notification-rule-channel").
+					Context("rule_name", r.Name).
+					Context("provider_name", channel).
+					Build()
+			}
+		}
+		for _, tmpl := range []string{r.Title, r.Message} {
+			if _, err := template.New("rule").Funcs(templatefuncs.FuncMap()).Parse(tmpl); err != nil {
+				return errors.New(fmt.Errorf("notification rule '%s' has an invalid template: %w", r.Name, err)).
+					Category(errors.CategoryValidation).
+					Context("validation_type", "notification-rule-template").
+					Context("rule_name", r.Name).
+					Build()
+			}
+		}
+	}
+	return nil
+}
+
+// validatePushRoutes checks that routing rules and escalation policies name
+// configured providers or enabled builtin channels
+func validatePushRoutes(push *PushSettings, builtin ...string) error {
+	names := make(map[string]bool, len(push.Providers)+len(builtin))
+	for i := range push.Providers {
+		names[PushProviderName(&push.Providers[i])] = true
+	}
+	for _, name := range builtin {
+		names[name] = true
+	}
+	for i := range push.Routes {
+		route := &push.Routes[i]
+		for _, provider := range route.Providers {
+			if !names[provider] {
+				return errors.New(fmt.Errorf("notification route %d (%s): unknown provider %q", i, route.Name, provider)).
+					Category(errors.CategoryValidation).
+					Context("validation_type", "notification-push-route-provider").
+					Context("route", route.Name).
+					Context("provider_name", provider).
+					Build()
+			}
+		}
+	}
+	for i := range push.Escalation {
+		policy := &push.Escalation[i]
+		if policy.After <= 0 {
+			return errors.New(fmt.Errorf("notification escalation %d (%s): after must be positive", i, policy.Name)).
+				Category(errors.CategoryValidation).
+				Context("validation_type", "notification-push-escalation-after").
+				Context("policy", policy.Name).
+				Build()
+		}
+		if len(policy.Providers) == 0 {
+			return errors.New(fmt.Errorf("notification escalation %d (%s) requires at least one provider", i, policy.Name)).
+				Category(errors.CategoryValidation).
+				Context("validation_type", "notification-push-escalation-provider").
+				Context("policy", policy.Name).
+				Build()
+		}
+		for _, provider := range policy.Providers {
+			if !names[provider] {
+				return errors.New(fmt.Errorf("notification escalation %d (%s): unknown provider %q", i, policy.Name, provider)).
+					Category(errors.CategoryValidation).
+					Context("validation_type", "notification-push-escalation-provider").
+					Context("policy", policy.Name).
+					Context("provider_name", provider).
+					Build()
+			}
+		}
+	}
+	return nil
+}
+
+// EmailProviderName is the push provider name of the email channel
+const EmailProviderName = "email"
+
+// validateEmailSettings validates the SMTP email channel settings
+func validateEmailSettings(email *EmailSettings) error {
+	if !email.Enabled {
+		return nil
+	}
+	if strings.TrimSpace(email.Host) == "" {
+		return errors.New(fmt.Errorf("notification.email.host is required when email is enabled")).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "notification-email-host").
+			Build()
+	}
+	if email.Port < 1 || email.Port > 65535 {
+		return errors.New(fmt.Errorf("notification.email.port must be between 1 and 65535, got %d", email.Port)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "notification-email-port").
+			Build()
+	}
+	switch email.Security {
+	case "starttls", "tls", "none":
+	default:
+		return errors.New(fmt.Errorf("notification.email.security must be starttls, tls or none, got %q", email.Security)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "notification-email-security").
+			Build()
+	}
+	if _, err := mail.ParseAddress(email.From); err != nil {
+		return errors.New(fmt.Errorf("notification.email.from is not a valid address: %w", err)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "notification-email-from").
+			Build()
+	}
+	if len(email.To) == 0 {
+		return errors.New(fmt.Errorf("notification.email.to requires at least one recipient")).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "notification-email-to").
+			Build()
+	}
+	for _, to := range email.To {
+		if _, err := mail.ParseAddress(to); err != nil {
+			return errors.New(fmt.Errorf("notification.email.to %q is not a valid address: %w", to, err)).
+				Category(errors.CategoryValidation).
+				Context("validation_type", "notification-email-to").
+				Build()
+		}
+	}
+	templates := map[string]string{
+		"subject": email.Templates.Subject,
+		"text":    email.Templates.Text,
+		"html":    email.Templates.HTML,
+	}
+	for name, tmpl := range templates {
+		if tmpl == "" {
+			continue
+		}
+		if _, err := template.New("validation").Funcs(templatefuncs.FuncMap()).Parse(tmpl); err != nil {
+			return errors.New(fmt.Errorf("notification.email.templates.%s: invalid template syntax: %w", name, err)).
+				Category(errors.CategoryValidation).
+				Context("validation_type", "notification-email-template").
+				Context("error_detail", err.Error()).
+				Build()
+		}
 	}
 	return nil
 }
 
+// PushProviderName returns the name a push provider is registered with: its
+// configured name, or its type when the name is empty
+func PushProviderName(p *PushProviderConfig) string {
+	if strings.TrimSpace(p.Name) == "" {
+		return strings.ToLower(p.Type)
+	}
+	return p.Name
+}
+
 // validateWebhookProvider validates webhook provider configuration
 func validateWebhookProvider(p *PushProviderConfig) error {
 	if !p.Enabled {
@@ -862,7 +2220,7 @@ func validateWebhookProvider(p *PushProviderConfig) error {
 
 	// Validate custom template if specified
 	if p.Template != "" {
-		if _, err := template.New("validation").Parse(p.Template); err != nil {
+		if _, err := template.New("validation").Funcs(templatefuncs.FuncMap()).Parse(p.Template); err != nil {
 			return errors.New(fmt.Errorf("webhook provider '%s': invalid template syntax: %w", p.Name, err)).
 				Category(errors.CategoryValidation).
 				Context("validation_type", "notification-push-webhook-template").
@@ -929,6 +2287,102 @@ func validateWebhookProvider(p *PushProviderConfig) error {
 	return nil
 }
 
+// telegramChatIDPattern matches a numeric chat ID, negative for groups, or the
+// @username of a public channel
+var telegramChatIDPattern = regexp.MustCompile(`^(-?[0-9]+|@[A-Za-z][A-Za-z0-9_]{4,})$`)
+
+// validateTelegramProvider validates a Telegram bot provider configuration
+func validateTelegramProvider(p *PushProviderConfig) error {
+	if !p.Enabled {
+		return nil
+	}
+	if strings.TrimSpace(p.BotToken) == "" && strings.TrimSpace(p.BotTokenFile) == "" {
+		return errors.New(fmt.Errorf("telegram provider '%s' requires bot_token or bot_token_file when enabled", p.Name)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "notification-push-telegram-token").
+			Context("provider_name", p.Name).
+			Build()
+	}
+	if !telegramChatIDPattern.MatchString(strings.TrimSpace(p.ChatID)) {
+		return errors.New(fmt.Errorf("telegram provider '%s': chat_id must be a numeric chat ID or @channelusername, got %q", p.Name, p.ChatID)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "notification-push-telegram-chat-id").
+			Context("provider_name", p.Name).
+			Build()
+	}
+	return nil
+}
+
+// ntfyTopicPattern matches the topic names accepted by ntfy servers
+var ntfyTopicPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// validateNtfyProvider validates an ntfy provider configuration
+func validateNtfyProvider(p *PushProviderConfig) error {
+	if !p.Enabled {
+		return nil
+	}
+	if !ntfyTopicPattern.MatchString(p.Topic) {
+		return errors.New(fmt.Errorf("ntfy provider '%s': topic must be 1 to 64 letters, digits, '-' or '_', got %q", p.Name, p.Topic)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "notification-push-ntfy-topic").
+			Context("provider_name", p.Name).
+			Build()
+	}
+	if p.ServerURL != "" {
+		u, err := url.Parse(p.ServerURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.New(fmt.Errorf("ntfy provider '%s': server_url must be an http or https URL, got %q", p.Name, p.ServerURL)).
+				Category(errors.CategoryValidation).
+				Context("validation_type", "notification-push-ntfy-server").
+				Context("provider_name", p.Name).
+				Build()
+		}
+	}
+	return nil
+}
+
+// Pushover limits on repeating emergency notifications until acknowledged
+const (
+	pushoverMinEmergencyRetry  = 30 * time.Second
+	pushoverMaxEmergencyExpire = 3 * time.Hour
+)
+
+// validatePushoverProvider validates a Pushover provider configuration
+func validatePushoverProvider(p *PushProviderConfig) error {
+	if !p.Enabled {
+		return nil
+	}
+	if strings.TrimSpace(p.UserKey) == "" && strings.TrimSpace(p.UserKeyFile) == "" {
+		return errors.New(fmt.Errorf("pushover provider '%s' requires user_key or user_key_file when enabled", p.Name)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "notification-push-pushover-user").
+			Context("provider_name", p.Name).
+			Build()
+	}
+	if strings.TrimSpace(p.AppToken) == "" && strings.TrimSpace(p.AppTokenFile) == "" {
+		return errors.New(fmt.Errorf("pushover provider '%s' requires app_token or app_token_file when enabled", p.Name)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "notification-push-pushover-token").
+			Context("provider_name", p.Name).
+			Build()
+	}
+	if p.EmergencyRetry != 0 && p.EmergencyRetry < pushoverMinEmergencyRetry {
+		return errors.New(fmt.Errorf("pushover provider '%s': emergency_retry must be at least %v, got %v", p.Name, pushoverMinEmergencyRetry, p.EmergencyRetry)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "notification-push-pushover-retry").
+			Context("provider_name", p.Name).
+			Build()
+	}
+	if p.EmergencyExpire < 0 || p.EmergencyExpire > pushoverMaxEmergencyExpire {
+		return errors.New(fmt.Errorf("pushover provider '%s': emergency_expire must be between 0 and %v, got %v", p.Name, pushoverMaxEmergencyExpire, p.EmergencyExpire)).
+			Category(errors.CategoryValidation).
+			Context("validation_type", "notification-push-pushover-expire").
+			Context("provider_name", p.Name).
+			Build()
+	}
+	return nil
+}
+
 // validateWebhookAuth validates webhook authentication configuration.
 // Checks that required fields are provided but does NOT resolve secrets here.
 // Secret resolution happens at runtime in the webhook provider.
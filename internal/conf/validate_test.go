@@ -3,6 +3,7 @@ package conf
 import (
 	stderrors "errors"
 	"testing"
+	"time"
 
 	"github.com/tphakala/birdnet-go/internal/errors"
 )
@@ -192,6 +193,683 @@ func TestValidateSoundLevelSettingsErrorMessage(t *testing.T) {
 	}
 }
 
+func TestValidateDataBudgetSettings(t *testing.T) {
+	valid := DataBudgetSettings{Enabled: true, MonthlyCapMB: 1024, ThrottleThreshold: 80, ResetDay: 1, Essential: []string{"uploads"}}
+
+	tests := []struct {
+		name    string
+		modify  func(s *DataBudgetSettings)
+		errType string // expected validation_type, empty when valid
+	}{
+		{name: "valid settings", modify: func(s *DataBudgetSettings) {}},
+		{name: "disabled ignores invalid values", modify: func(s *DataBudgetSettings) { s.Enabled = false; s.MonthlyCapMB = 0 }},
+		{name: "zero cap", modify: func(s *DataBudgetSettings) { s.MonthlyCapMB = 0 }, errType: "databudget-monthly-cap"},
+		{name: "threshold above 100", modify: func(s *DataBudgetSettings) { s.ThrottleThreshold = 101 }, errType: "databudget-throttle-threshold"},
+		{name: "reset day 29", modify: func(s *DataBudgetSettings) { s.ResetDay = 29 }, errType: "databudget-reset-day"},
+		{name: "unknown integration", modify: func(s *DataBudgetSettings) { s.Essential = []string{"mqtt"} }, errType: "databudget-essential"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := valid
+			tt.modify(&settings)
+			err := validateDataBudgetSettings(&settings)
+
+			if tt.errType == "" {
+				if err != nil {
+					t.Errorf("validateDataBudgetSettings() unexpected error = %v", err)
+				}
+				return
+			}
+
+			var enhancedErr *errors.EnhancedError
+			if !stderrors.As(err, &enhancedErr) {
+				t.Fatalf("expected EnhancedError, got %T (%v)", err, err)
+			}
+			if ctx := enhancedErr.Context["validation_type"]; ctx != tt.errType {
+				t.Errorf("expected validation_type = %s, got %v", tt.errType, ctx)
+			}
+		})
+	}
+}
+
+func TestValidateFieldSyncSettings(t *testing.T) {
+	valid := FieldSyncSettings{
+		Enabled: true, AggregatorURL: "https://hub.example.org", Token: "secret",
+		Interval: 15, BatchSize: 250, ChunkSizeKB: 256,
+		Aggregator: FieldSyncAggregate{Enabled: true, Units: []FieldSyncUnit{{ID: "meadow-1", Token: "t1"}}},
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(s *FieldSyncSettings)
+		errType string // expected validation_type, empty when valid
+	}{
+		{name: "valid settings", modify: func(s *FieldSyncSettings) {}},
+		{name: "disabled ignores invalid values", modify: func(s *FieldSyncSettings) { s.Enabled = false; s.Token = "" }},
+		{name: "missing token", modify: func(s *FieldSyncSettings) { s.Token = "" }, errType: "fieldsync-aggregator"},
+		{name: "zero interval", modify: func(s *FieldSyncSettings) { s.Interval = 0 }, errType: "fieldsync-interval"},
+		{name: "batch too large", modify: func(s *FieldSyncSettings) { s.BatchSize = 1001 }, errType: "fieldsync-batch-size"},
+		{name: "chunk too large", modify: func(s *FieldSyncSettings) { s.ChunkSizeKB = 2048 }, errType: "fieldsync-chunk-size"},
+		{name: "unsafe unit ID", modify: func(s *FieldSyncSettings) {
+			s.Aggregator.Units = []FieldSyncUnit{{ID: "../x", Token: "t1"}}
+		}, errType: "fieldsync-unit"},
+		{name: "duplicate unit ID", modify: func(s *FieldSyncSettings) {
+			s.Aggregator.Units = []FieldSyncUnit{{ID: "a", Token: "t1"}, {ID: "a", Token: "t2"}}
+		}, errType: "fieldsync-unit"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := valid
+			settings.Aggregator.Units = append([]FieldSyncUnit(nil), valid.Aggregator.Units...)
+			tt.modify(&settings)
+			err := validateFieldSyncSettings(&settings)
+
+			if tt.errType == "" {
+				if err != nil {
+					t.Errorf("validateFieldSyncSettings() unexpected error = %v", err)
+				}
+				return
+			}
+
+			var enhancedErr *errors.EnhancedError
+			if !stderrors.As(err, &enhancedErr) {
+				t.Fatalf("expected EnhancedError, got %T (%v)", err, err)
+			}
+			if ctx := enhancedErr.Context["validation_type"]; ctx != tt.errType {
+				t.Errorf("expected validation_type = %s, got %v", tt.errType, ctx)
+			}
+		})
+	}
+}
+
+func TestValidateEncryptionSettings(t *testing.T) {
+	tests := []struct {
+		name          string
+		settings      EncryptionSettings
+		sqliteEnabled bool
+		errType       string // expected validation_type, empty when valid
+	}{
+		{name: "disabled without key", settings: EncryptionSettings{}},
+		{name: "clips with key", settings: EncryptionSettings{Clips: true, Key: "${BIRDNET_KEY}"}},
+		{name: "database with key file", settings: EncryptionSettings{Database: true, KeyFile: "/run/secrets/key"}, sqliteEnabled: true},
+		{name: "clips without key", settings: EncryptionSettings{Clips: true}, errType: "encryption-key"},
+		{name: "database without sqlite", settings: EncryptionSettings{Database: true, Key: "k"}, errType: "encryption-database"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateEncryptionSettings(&tt.settings, tt.sqliteEnabled)
+
+			if tt.errType == "" {
+				if err != nil {
+					t.Errorf("validateEncryptionSettings() unexpected error = %v", err)
+				}
+				return
+			}
+
+			var enhancedErr *errors.EnhancedError
+			if !stderrors.As(err, &enhancedErr) {
+				t.Fatalf("expected EnhancedError, got %T (%v)", err, err)
+			}
+			if ctx := enhancedErr.Context["validation_type"]; ctx != tt.errType {
+				t.Errorf("expected validation_type = %s, got %v", tt.errType, ctx)
+			}
+		})
+	}
+}
+
+func TestValidateDataQualitySettings(t *testing.T) {
+	valid := DataQualitySettings{
+		Enabled:          true,
+		GapMinutes:       5,
+		SilenceThreshold: -70,
+		SilentMinutes:    30,
+		ClippingPercent:  0.1,
+		RateLowPercent:   25,
+		RateHighPercent:  400,
+		BaselineDays:     7,
+		MicFailure:       MicFailureSettings{Enabled: true, WindowMinutes: 30, HumPercent: 80},
+	}
+	modify := func(f func(s *DataQualitySettings)) DataQualitySettings {
+		s := valid
+		f(&s)
+		return s
+	}
+
+	tests := []struct {
+		name     string
+		settings DataQualitySettings
+		errType  string // expected validation_type, empty when valid
+	}{
+		{name: "defaults", settings: valid},
+		{name: "disabled with invalid values", settings: DataQualitySettings{}},
+		{name: "zero gap minutes", settings: modify(func(s *DataQualitySettings) { s.GapMinutes = 0 }), errType: "dataquality-period"},
+		{name: "positive silence threshold", settings: modify(func(s *DataQualitySettings) { s.SilenceThreshold = 3 }), errType: "dataquality-silence-threshold"},
+		{name: "zero clipping percent", settings: modify(func(s *DataQualitySettings) { s.ClippingPercent = 0 }), errType: "dataquality-clipping"},
+		{name: "high limit below baseline", settings: modify(func(s *DataQualitySettings) { s.RateHighPercent = 90 }), errType: "dataquality-detection-rate"},
+		{name: "baseline too long", settings: modify(func(s *DataQualitySettings) { s.BaselineDays = 365 }), errType: "dataquality-baseline-days"},
+		{name: "short mic failure window", settings: modify(func(s *DataQualitySettings) { s.MicFailure.WindowMinutes = 1 }), errType: "dataquality-micfailure-window"},
+		{name: "mic failure checked when report disabled", settings: DataQualitySettings{MicFailure: MicFailureSettings{Enabled: true, WindowMinutes: 30}}, errType: "dataquality-micfailure-hum"},
+		{name: "calibration tone above nyquist", settings: modify(func(s *DataQualitySettings) {
+			s.Calibration = CalibrationSettings{Enabled: true, Frequency: 30000, MinSeconds: 3, Tolerance: 3}
+		}), errType: "dataquality-calibration-frequency"},
+		{name: "calibration tone too long", settings: modify(func(s *DataQualitySettings) {
+			s.Calibration = CalibrationSettings{Enabled: true, Frequency: 1000, MinSeconds: 120, Tolerance: 3}
+		}), errType: "dataquality-calibration-duration"},
+		{name: "calibration without tolerance", settings: modify(func(s *DataQualitySettings) {
+			s.Calibration = CalibrationSettings{Enabled: true, Frequency: 1000, MinSeconds: 3, ReferenceLevel: -20}
+		}), errType: "dataquality-calibration-level"},
+		{name: "calibration", settings: modify(func(s *DataQualitySettings) {
+			s.Calibration = CalibrationSettings{Enabled: true, Frequency: 1000, MinSeconds: 3, ReferenceLevel: -20, Tolerance: 3}
+		})},
+		{name: "anomaly", settings: modify(func(s *DataQualitySettings) {
+			s.Anomaly = AnomalySettings{Enabled: true, BaselineDays: 28, Threshold: 4, MinSpike: 10, MinExpected: 5}
+		})},
+		{name: "anomaly baseline too short", settings: modify(func(s *DataQualitySettings) {
+			s.Anomaly = AnomalySettings{Enabled: true, BaselineDays: 3, Threshold: 4, MinSpike: 10, MinExpected: 5}
+		}), errType: "dataquality-anomaly-baseline"},
+		{name: "anomaly without threshold", settings: modify(func(s *DataQualitySettings) {
+			s.Anomaly = AnomalySettings{Enabled: true, BaselineDays: 28, MinSpike: 10, MinExpected: 5}
+		}), errType: "dataquality-anomaly-threshold"},
+		{name: "absence", settings: modify(func(s *DataQualitySettings) {
+			s.Absence = AbsenceSettings{Enabled: true, Days: 3, BaselineDays: 28, MinPresence: 0.8}
+		})},
+		{name: "absence of no days", settings: modify(func(s *DataQualitySettings) {
+			s.Absence = AbsenceSettings{Enabled: true, BaselineDays: 28, MinPresence: 0.8}
+		}), errType: "dataquality-absence-days"},
+		{name: "absence presence above 1", settings: modify(func(s *DataQualitySettings) {
+			s.Absence = AbsenceSettings{Enabled: true, Days: 3, BaselineDays: 28, MinPresence: 80}
+		}), errType: "dataquality-absence-presence"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateDataQualitySettings(&tt.settings)
+
+			if tt.errType == "" {
+				if err != nil {
+					t.Errorf("validateDataQualitySettings() unexpected error = %v", err)
+				}
+				return
+			}
+
+			var enhancedErr *errors.EnhancedError
+			if !stderrors.As(err, &enhancedErr) {
+				t.Fatalf("expected EnhancedError, got %T (%v)", err, err)
+			}
+			if ctx := enhancedErr.Context["validation_type"]; ctx != tt.errType {
+				t.Errorf("expected validation_type = %s, got %v", tt.errType, ctx)
+			}
+		})
+	}
+}
+
+func TestValidateDetectionRetentionSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings DetectionRetentionSettings
+		errType  string
+	}{
+		{name: "disabled with invalid policy", settings: DetectionRetentionSettings{
+			Policies: []DetectionRetentionPolicy{{Name: "all", MaxAgeDays: 1}},
+		}},
+		{name: "valid policies", settings: DetectionRetentionSettings{Enabled: true, Policies: []DetectionRetentionPolicy{
+			{Name: "common", MaxAgeDays: 730, MinDetections: 1000},
+			{Name: "sparrows", MaxAgeDays: 365, Species: []string{"House Sparrow"}},
+		}}},
+		{name: "age too short", settings: DetectionRetentionSettings{Enabled: true, Policies: []DetectionRetentionPolicy{
+			{Name: "all", MaxAgeDays: 7},
+		}}, errType: "detection-retention-age"},
+		{name: "negative minimum detections", settings: DetectionRetentionSettings{Enabled: true, Policies: []DetectionRetentionPolicy{
+			{Name: "common", MaxAgeDays: 730, MinDetections: -1},
+		}}, errType: "detection-retention-min-detections"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateDetectionRetentionSettings(&tt.settings)
+
+			if tt.errType == "" {
+				if err != nil {
+					t.Errorf("validateDetectionRetentionSettings() unexpected error = %v", err)
+				}
+				return
+			}
+
+			var enhancedErr *errors.EnhancedError
+			if !stderrors.As(err, &enhancedErr) {
+				t.Fatalf("expected EnhancedError, got %T (%v)", err, err)
+			}
+			if ctx := enhancedErr.Context["validation_type"]; ctx != tt.errType {
+				t.Errorf("expected validation_type = %s, got %v", tt.errType, ctx)
+			}
+		})
+	}
+}
+
+func TestValidateAudioSettings_Channel(t *testing.T) {
+	tests := []struct {
+		channel string
+		wantErr bool
+	}{
+		{"", false},
+		{AudioChannelMix, false},
+		{AudioChannelLeft, false},
+		{AudioChannelRight, false},
+		{AudioChannelSplit, false},
+		{"center", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.channel, func(t *testing.T) {
+			err := validateAudioSettings(&AudioSettings{Channel: tt.channel})
+			if !tt.wantErr {
+				if err != nil {
+					t.Errorf("validateAudioSettings() unexpected error = %v", err)
+				}
+				return
+			}
+
+			var enhancedErr *errors.EnhancedError
+			if !stderrors.As(err, &enhancedErr) {
+				t.Fatalf("expected EnhancedError, got %T (%v)", err, err)
+			}
+			if ctx := enhancedErr.Context["validation_type"]; ctx != "audio-channel" {
+				t.Errorf("expected validation_type = audio-channel, got %v", ctx)
+			}
+		})
+	}
+}
+
+func TestValidateAudioSettings_DOA(t *testing.T) {
+	tests := []struct {
+		name    string
+		doa     DOASettings
+		errType string // expected validation_type, empty when valid
+	}{
+		{name: "disabled", doa: DOASettings{}},
+		{name: "valid", doa: DOASettings{Enabled: true, MicSpacing: 0.2, Orientation: 90}},
+		{name: "no spacing", doa: DOASettings{Enabled: true}, errType: "audio-doa-spacing"},
+		{name: "spacing too wide", doa: DOASettings{Enabled: true, MicSpacing: 5}, errType: "audio-doa-spacing"},
+		{name: "orientation out of range", doa: DOASettings{Enabled: true, MicSpacing: 0.2, Orientation: 360}, errType: "audio-doa-orientation"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateAudioSettings(&AudioSettings{DOA: tt.doa})
+			if tt.errType == "" {
+				if err != nil {
+					t.Errorf("validateAudioSettings() unexpected error = %v", err)
+				}
+				return
+			}
+
+			var enhancedErr *errors.EnhancedError
+			if !stderrors.As(err, &enhancedErr) {
+				t.Fatalf("expected EnhancedError, got %T (%v)", err, err)
+			}
+			if ctx := enhancedErr.Context["validation_type"]; ctx != tt.errType {
+				t.Errorf("expected validation_type = %s, got %v", tt.errType, ctx)
+			}
+		})
+	}
+}
+
+func TestValidateAudioSettings_EncodingProfiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*ExportSettings)
+		errType string // expected validation_type, empty when valid
+	}{
+		{name: "defaults", modify: func(*ExportSettings) {}},
+		{name: "birdweather flac 24 bit", modify: func(e *ExportSettings) {
+			e.Destinations.BirdWeather = EncodingProfile{Type: "flac", BitDepth: 24, CompressionLevel: 8}
+		}},
+		{name: "birdweather lossy", modify: func(e *ExportSettings) {
+			e.Destinations.BirdWeather = EncodingProfile{Type: "mp3", Bitrate: "128k"}
+		}, errType: "audio-export-encoding-type"},
+		{name: "notification opus", modify: func(e *ExportSettings) {
+			e.Destinations.Notification = EncodingProfile{Type: "opus", Bitrate: "32k"}
+		}},
+		{name: "notification bitrate missing", modify: func(e *ExportSettings) {
+			e.Destinations.Notification = EncodingProfile{Type: "mp3"}
+		}, errType: "audio-export-encoding-bitrate"},
+		{name: "notification bit depth", modify: func(e *ExportSettings) {
+			e.Destinations.Notification = EncodingProfile{Type: "wav", BitDepth: 32}
+		}, errType: "audio-export-encoding-bitdepth"},
+		{name: "storage compression level", modify: func(e *ExportSettings) {
+			e.Enabled = true
+			e.CompressionLevel = 13
+		}, errType: "audio-export-encoding-compression"},
+		{name: "snippet low bitrate", modify: func(e *ExportSettings) {
+			e.Snippet = SnippetSettings{Enabled: true, Length: 6, Type: "opus", Bitrate: "16k"}
+		}},
+		{name: "snippet too long", modify: func(e *ExportSettings) {
+			e.Snippet = SnippetSettings{Enabled: true, Length: 30, Type: "opus", Bitrate: "24k"}
+		}, errType: "audio-export-snippet-length"},
+		{name: "snippet ignored with clip export", modify: func(e *ExportSettings) {
+			e.Enabled = true
+			e.Snippet = SnippetSettings{Enabled: true, Length: 30}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := &AudioSettings{Export: ExportSettings{Type: "wav", Length: 15, PreCapture: 3}}
+			tt.modify(&settings.Export)
+			err := validateAudioSettings(settings)
+			if tt.errType == "" {
+				if err != nil {
+					t.Errorf("validateAudioSettings() unexpected error = %v", err)
+				}
+				return
+			}
+
+			var enhancedErr *errors.EnhancedError
+			if !stderrors.As(err, &enhancedErr) {
+				t.Fatalf("expected EnhancedError, got %T (%v)", err, err)
+			}
+			if ctx := enhancedErr.Context["validation_type"]; ctx != tt.errType {
+				t.Errorf("expected validation_type = %s, got %v", tt.errType, ctx)
+			}
+		})
+	}
+}
+
+func TestValidateNotificationSettings_Routes(t *testing.T) {
+	tests := []struct {
+		name       string
+		routes     []PushRouteConfig
+		escalation []EscalationPolicy
+		errType    string // expected validation_type, empty when valid
+	}{
+		{name: "no routes"},
+		{name: "named and default provider names", routes: []PushRouteConfig{
+			{Name: "errors", Match: PushFilterConfig{Types: []string{"error"}}, Providers: []string{"telegram", "script"}},
+			{Name: "muted", Match: PushFilterConfig{Priorities: []string{"low"}}},
+		}},
+		{name: "unknown provider", routes: []PushRouteConfig{
+			{Name: "detections", Providers: []string{"email"}},
+		}, errType: "notification-push-route-provider"},
+		{name: "escalation", escalation: []EscalationPolicy{
+			{Name: "field", After: 15 * time.Minute, Providers: []string{"telegram"}},
+		}},
+		{name: "escalation without delay", escalation: []EscalationPolicy{
+			{Name: "field", Providers: []string{"telegram"}},
+		}, errType: "notification-push-escalation-after"},
+		{name: "escalation without providers", escalation: []EscalationPolicy{
+			{Name: "field", After: time.Minute},
+		}, errType: "notification-push-escalation-provider"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := &NotificationConfig{Push: PushSettings{
+				Enabled: true,
+				Providers: []PushProviderConfig{
+					{Type: "shoutrrr", Name: "telegram", URLs: []string{"telegram://token@telegram?chats=1"}},
+					{Type: "script", Command: "notify.sh"},
+				},
+				Routes:     tt.routes,
+				Escalation: tt.escalation,
+			}}
+			err := validateNotificationSettings(settings)
+			if tt.errType == "" {
+				if err != nil {
+					t.Errorf("validateNotificationSettings() unexpected error = %v", err)
+				}
+				return
+			}
+
+			var enhancedErr *errors.EnhancedError
+			if !stderrors.As(err, &enhancedErr) {
+				t.Fatalf("expected EnhancedError, got %T (%v)", err, err)
+			}
+			if ctx := enhancedErr.Context["validation_type"]; ctx != tt.errType {
+				t.Errorf("expected validation_type = %s, got %v", tt.errType, ctx)
+			}
+		})
+	}
+}
+
+func TestValidateQuietHours(t *testing.T) {
+	tests := []struct {
+		name    string
+		quiet   QuietHoursConfig
+		errType string // expected validation_type, empty when valid
+	}{
+		{name: "disabled with empty window", quiet: QuietHoursConfig{}},
+		{name: "overnight", quiet: QuietHoursConfig{Enabled: true, From: "22:00", Until: "07:00"}},
+		{name: "digest", quiet: QuietHoursConfig{Enabled: true, From: "13:00", Until: "15:30", Mode: "digest"}},
+		{name: "invalid time", quiet: QuietHoursConfig{Enabled: true, From: "10pm", Until: "07:00"}, errType: "notification-quiet-hours-window"},
+		{name: "empty window", quiet: QuietHoursConfig{Enabled: true, From: "07:00", Until: "07:00"}, errType: "notification-quiet-hours-window"},
+		{name: "unknown mode", quiet: QuietHoursConfig{Enabled: true, From: "22:00", Until: "07:00", Mode: "drop"}, errType: "notification-quiet-hours-mode"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := &NotificationConfig{Push: PushSettings{
+				Enabled:   true,
+				Providers: []PushProviderConfig{{Type: "script", Command: "notify.sh", QuietHours: tt.quiet}},
+			}}
+			err := validateNotificationSettings(settings)
+			if tt.errType == "" {
+				if err != nil {
+					t.Errorf("validateNotificationSettings() unexpected error = %v", err)
+				}
+				return
+			}
+
+			var enhancedErr *errors.EnhancedError
+			if !stderrors.As(err, &enhancedErr) {
+				t.Fatalf("expected EnhancedError, got %T (%v)", err, err)
+			}
+			if ctx := enhancedErr.Context["validation_type"]; ctx != tt.errType {
+				t.Errorf("expected validation_type = %s, got %v", tt.errType, ctx)
+			}
+		})
+	}
+}
+
+func TestValidateDailyDigest(t *testing.T) {
+	tests := []struct {
+		name    string
+		digest  DailyDigestSettings
+		errType string // expected validation_type, empty when valid
+	}{
+		{name: "disabled with empty time", digest: DailyDigestSettings{}},
+		{name: "evening", digest: DailyDigestSettings{Enabled: true, Time: "21:00", TopClips: 3}},
+		{name: "invalid time", digest: DailyDigestSettings{Enabled: true, Time: "9pm"}, errType: "notification-daily-digest-time"},
+		{name: "negative clips", digest: DailyDigestSettings{Enabled: true, Time: "21:00", TopClips: -1}, errType: "notification-daily-digest-clips"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateNotificationSettings(&NotificationConfig{DailyDigest: tt.digest})
+			if tt.errType == "" {
+				if err != nil {
+					t.Errorf("validateNotificationSettings() unexpected error = %v", err)
+				}
+				return
+			}
+
+			var enhancedErr *errors.EnhancedError
+			if !stderrors.As(err, &enhancedErr) {
+				t.Fatalf("expected EnhancedError, got %T (%v)", err, err)
+			}
+			if ctx := enhancedErr.Context["validation_type"]; ctx != tt.errType {
+				t.Errorf("expected validation_type = %s, got %v", tt.errType, ctx)
+			}
+		})
+	}
+}
+
+func TestValidateDeliveryQueue(t *testing.T) {
+	valid := DeliveryQueueSettings{Enabled: true, MaxAttempts: 8, InitialDelay: time.Minute, MaxDelay: 6 * time.Hour, MaxPending: 200}
+	tests := []struct {
+		name    string
+		modify  func(d *DeliveryQueueSettings)
+		errType string // expected validation_type, empty when valid
+	}{
+		{name: "defaults", modify: func(d *DeliveryQueueSettings) {}},
+		{name: "disabled without limits", modify: func(d *DeliveryQueueSettings) { *d = DeliveryQueueSettings{} }},
+		{name: "no attempts", modify: func(d *DeliveryQueueSettings) { d.MaxAttempts = 0 }, errType: "notification-deliveries-attempts"},
+		{name: "no initial delay", modify: func(d *DeliveryQueueSettings) { d.InitialDelay = 0 }, errType: "notification-deliveries-delay"},
+		{name: "max delay below initial", modify: func(d *DeliveryQueueSettings) { d.MaxDelay = time.Second }, errType: "notification-deliveries-delay"},
+		{name: "no pending", modify: func(d *DeliveryQueueSettings) { d.MaxPending = 0 }, errType: "notification-deliveries-pending"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deliveries := valid
+			tt.modify(&deliveries)
+			err := validateNotificationSettings(&NotificationConfig{Deliveries: deliveries})
+			if tt.errType == "" {
+				if err != nil {
+					t.Errorf("validateNotificationSettings() unexpected error = %v", err)
+				}
+				return
+			}
+
+			var enhancedErr *errors.EnhancedError
+			if !stderrors.As(err, &enhancedErr) {
+				t.Fatalf("expected EnhancedError, got %T (%v)", err, err)
+			}
+			if ctx := enhancedErr.Context["validation_type"]; ctx != tt.errType {
+				t.Errorf("expected validation_type = %s, got %v", tt.errType, ctx)
+			}
+		})
+	}
+}
+
+func TestValidateNotificationDedup(t *testing.T) {
+	tests := []struct {
+		name    string
+		dedup   DedupSettings
+		errType string // expected validation_type, empty when valid
+	}{
+		{name: "disabled", dedup: DedupSettings{}},
+		{name: "window", dedup: DedupSettings{Enabled: true, Window: 10 * time.Minute}},
+		{name: "no window", dedup: DedupSettings{Enabled: true}, errType: "notification-dedup-window"},
+		{name: "negative window", dedup: DedupSettings{Enabled: true, Window: -time.Minute}, errType: "notification-dedup-window"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateNotificationSettings(&NotificationConfig{Dedup: tt.dedup})
+			if tt.errType == "" {
+				if err != nil {
+					t.Errorf("validateNotificationSettings() unexpected error = %v", err)
+				}
+				return
+			}
+
+			var enhancedErr *errors.EnhancedError
+			if !stderrors.As(err, &enhancedErr) {
+				t.Fatalf("expected EnhancedError, got %T (%v)", err, err)
+			}
+			if ctx := enhancedErr.Context["validation_type"]; ctx != tt.errType {
+				t.Errorf("expected validation_type = %s, got %v", tt.errType, ctx)
+			}
+		})
+	}
+}
+
+func TestValidateChannelTemplates(t *testing.T) {
+	tests := []struct {
+		name     string
+		channels map[string]ChannelTemplates
+		errType  string // expected validation_type, empty when valid
+	}{
+		{name: "no channels"},
+		{name: "telegram title", channels: map[string]ChannelTemplates{
+			"telegram": {NewSpecies: NewSpeciesTemplate{Title: "🐦 {{.CommonName}}"}},
+		}},
+		{name: "ui digest", channels: map[string]ChannelTemplates{
+			"ui": {DailyDigest: DailyDigestTemplate{Title: "{{.Detections}} detections"}},
+		}},
+		{name: "unknown channel", channels: map[string]ChannelTemplates{
+			"pager": {NewSpecies: NewSpeciesTemplate{Title: "{{.CommonName}}"}},
+		}, errType: "notification-template-channel"},
+		{name: "invalid template", channels: map[string]ChannelTemplates{
+			"email": {DailyDigest: DailyDigestTemplate{Message: "{{.Species"}},
+		}, errType: "notification-channel-template"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateNotificationSettings(&NotificationConfig{Templates: NotificationTemplates{Channels: tt.channels}})
+			if tt.errType == "" {
+				if err != nil {
+					t.Errorf("validateNotificationSettings() unexpected error = %v", err)
+				}
+				return
+			}
+
+			var enhancedErr *errors.EnhancedError
+			if !stderrors.As(err, &enhancedErr) {
+				t.Fatalf("expected EnhancedError, got %T (%v)", err, err)
+			}
+			if ctx := enhancedErr.Context["validation_type"]; ctx != tt.errType {
+				t.Errorf("expected validation_type = %s, got %v", tt.errType, ctx)
+			}
+		})
+	}
+}
+
+func TestValidateCaptureRate(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings RealtimeSettings
+		wantType string
+	}{
+		{"default", RealtimeSettings{Audio: AudioSettings{CaptureRate: CaptureRateSettings{SampleRate: SampleRate}}}, ""},
+		{"unset", RealtimeSettings{}, ""},
+		{"96 kHz card", RealtimeSettings{Audio: AudioSettings{CaptureRate: CaptureRateSettings{SampleRate: 96000, ArchiveOriginal: true}}}, ""},
+		{"44.1 kHz card", RealtimeSettings{Audio: AudioSettings{CaptureRate: CaptureRateSettings{SampleRate: 44100}}}, "audio-capture-rate"},
+		{"384 kHz card", RealtimeSettings{Audio: AudioSettings{CaptureRate: CaptureRateSettings{SampleRate: 384000}}}, "audio-capture-rate"},
+		{"192 kHz stream", RealtimeSettings{RTSP: RTSPSettings{CaptureRates: []RTSPCaptureRate{{URL: "rtsp://cam/1", SampleRate: 192000}}}}, ""},
+		{"stream rate", RealtimeSettings{RTSP: RTSPSettings{CaptureRates: []RTSPCaptureRate{{URL: "rtsp://cam/1", SampleRate: 100000}}}}, "audio-capture-rate"},
+		{"stream without URL", RealtimeSettings{RTSP: RTSPSettings{CaptureRates: []RTSPCaptureRate{{SampleRate: 96000}}}}, "rtsp-capture-rate-url"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRealtimeSettings(&tt.settings)
+			if err == nil {
+				err = validateAudioSettings(&tt.settings.Audio)
+			}
+			if tt.wantType == "" {
+				if err != nil {
+					t.Errorf("unexpected error = %v", err)
+				}
+				return
+			}
+
+			var enhancedErr *errors.EnhancedError
+			if !stderrors.As(err, &enhancedErr) {
+				t.Fatalf("expected EnhancedError, got %T (%v)", err, err)
+			}
+			if ctx := enhancedErr.Context["validation_type"]; ctx != tt.wantType {
+				t.Errorf("expected validation_type = %s, got %v", tt.wantType, ctx)
+			}
+		})
+	}
+}
+
+func TestRTSPCaptureRateFor(t *testing.T) {
+	settings := RTSPSettings{CaptureRates: []RTSPCaptureRate{{URL: "rtsp://cam/1", SampleRate: 96000, ArchiveOriginal: true}}}
+
+	if got := settings.CaptureRateFor("rtsp://cam/1"); got != (CaptureRateSettings{SampleRate: 96000, ArchiveOriginal: true}) {
+		t.Errorf("CaptureRateFor() = %+v, want 96 kHz archived", got)
+	}
+	if got := settings.CaptureRateFor("rtsp://cam/2"); got != (CaptureRateSettings{SampleRate: SampleRate}) {
+		t.Errorf("CaptureRateFor() = %+v, want the analyzed sample rate", got)
+	}
+}
+
 func BenchmarkValidateSoundLevelSettings(b *testing.B) {
 	// Create test settings
 	settings := &SoundLevelSettings{
@@ -220,4 +898,261 @@ func BenchmarkValidateSoundLevelSettingsWithError(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		_ = validateSoundLevelSettings(settings)
 	}
-}
\ No newline at end of file
+}
+func TestValidateSecuritySettings_SignedMediaTTL(t *testing.T) {
+	tests := []struct {
+		name    string
+		ttl     int
+		wantErr bool
+	}{
+		{"default", 0, false},
+		{"one hour", 60, false},
+		{"thirty days", 30 * 24 * 60, false},
+		{"negative", -1, true},
+		{"too long", 30*24*60 + 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := Security{SessionDuration: time.Hour, SignedMedia: SignedMediaSettings{TTL: tt.ttl}}
+			err := validateSecuritySettings(&settings)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateSecuritySettings() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var enhancedErr *errors.EnhancedError
+			if tt.wantErr && stderrors.As(err, &enhancedErr) && enhancedErr.Context["validation_type"] != "security-signed-media-ttl" {
+				t.Errorf("expected validation_type = security-signed-media-ttl, got %v", enhancedErr.Context["validation_type"])
+			}
+		})
+	}
+}
+
+func TestValidateSecurityHeaders(t *testing.T) {
+	tests := []struct {
+		name           string
+		headers        SecurityHeadersSettings
+		validationType string
+	}{
+		{"defaults", SecurityHeadersSettings{}, ""},
+		{"hardened", SecurityHeadersSettings{
+			Enabled:        true,
+			HSTSMaxAge:     31536000,
+			FrameAncestors: []string{"'self'", "https://ha.example.org"},
+			ReferrerPolicy: "no-referrer",
+		}, ""},
+		{"negative hsts", SecurityHeadersSettings{HSTSMaxAge: -1}, "security-headers-hsts"},
+		{"unknown referrer policy", SecurityHeadersSettings{ReferrerPolicy: "everywhere"}, "security-headers-referrer-policy"},
+		{"injected frame ancestor", SecurityHeadersSettings{FrameAncestors: []string{"'self'; script-src *"}}, "security-headers-frame-ancestors"},
+		{"empty frame ancestor", SecurityHeadersSettings{FrameAncestors: []string{""}}, "security-headers-frame-ancestors"},
+		{"multi-line policy", SecurityHeadersSettings{ContentSecurityPolicy: "default-src 'self'\r\nX-Injected: 1"}, "security-headers-csp"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := Security{SessionDuration: time.Hour, Headers: tt.headers}
+			err := validateSecuritySettings(&settings)
+			if (err != nil) != (tt.validationType != "") {
+				t.Fatalf("validateSecuritySettings() error = %v, want validation_type %q", err, tt.validationType)
+			}
+			var enhancedErr *errors.EnhancedError
+			if err != nil && stderrors.As(err, &enhancedErr) && enhancedErr.Context["validation_type"] != tt.validationType {
+				t.Errorf("expected validation_type = %s, got %v", tt.validationType, enhancedErr.Context["validation_type"])
+			}
+		})
+	}
+}
+
+func TestValidateSecuritySettings_OIDC(t *testing.T) {
+	tests := []struct {
+		name           string
+		host           string
+		oidc           OIDCProvider
+		validationType string
+	}{
+		{"disabled", "", OIDCProvider{}, ""},
+		{"configured", "birdnet.example.org", OIDCProvider{Enabled: true, Issuer: "https://auth.example.org", ClientID: "birdnet"}, ""},
+		{"redirect without host", "", OIDCProvider{Enabled: true, Issuer: "https://auth.example.org", ClientID: "birdnet", RedirectURI: "https://birdnet.lan/auth/oidc/callback"}, ""},
+		{"missing issuer", "birdnet.example.org", OIDCProvider{Enabled: true, ClientID: "birdnet"}, "security-oidc-provider"},
+		{"missing client id", "birdnet.example.org", OIDCProvider{Enabled: true, Issuer: "https://auth.example.org"}, "security-oidc-provider"},
+		{"missing host", "", OIDCProvider{Enabled: true, Issuer: "https://auth.example.org", ClientID: "birdnet"}, "security-oidc-host"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := Security{SessionDuration: time.Hour, Host: tt.host, OIDCAuth: tt.oidc}
+			err := validateSecuritySettings(&settings)
+			if (err != nil) != (tt.validationType != "") {
+				t.Fatalf("validateSecuritySettings() error = %v, want validation_type %q", err, tt.validationType)
+			}
+			var enhancedErr *errors.EnhancedError
+			if err != nil && stderrors.As(err, &enhancedErr) && enhancedErr.Context["validation_type"] != tt.validationType {
+				t.Errorf("expected validation_type = %s, got %v", tt.validationType, enhancedErr.Context["validation_type"])
+			}
+		})
+	}
+}
+
+func TestValidateWeatherAdaptiveSettings(t *testing.T) {
+	valid := WeatherAdaptiveSettings{Enabled: true, WindMin: 4, WindMax: 10, WindAdjustment: 0.15, RainAdjustment: 0.1, MaxThreshold: 0.95, MaxAge: 120}
+
+	tests := []struct {
+		name     string
+		modify   func(s *WeatherAdaptiveSettings)
+		wantType string
+	}{
+		{"valid", func(s *WeatherAdaptiveSettings) {}, ""},
+		{"disabled with invalid values", func(s *WeatherAdaptiveSettings) { *s = WeatherAdaptiveSettings{} }, ""},
+		{"wind range inverted", func(s *WeatherAdaptiveSettings) { s.WindMax = 3 }, "weather-adaptive-wind"},
+		{"negative adjustment", func(s *WeatherAdaptiveSettings) { s.RainAdjustment = -0.1 }, "weather-adaptive-adjustment"},
+		{"max threshold above 1", func(s *WeatherAdaptiveSettings) { s.MaxThreshold = 1.2 }, "weather-adaptive-max-threshold"},
+		{"no max age", func(s *WeatherAdaptiveSettings) { s.MaxAge = 0 }, "weather-adaptive-max-age"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := valid
+			tt.modify(&settings)
+			err := validateWeatherAdaptiveSettings(&settings)
+			if tt.wantType == "" {
+				if err != nil {
+					t.Errorf("unexpected error = %v", err)
+				}
+				return
+			}
+
+			var enhancedErr *errors.EnhancedError
+			if !stderrors.As(err, &enhancedErr) {
+				t.Fatalf("expected EnhancedError, got %T (%v)", err, err)
+			}
+			if ctx := enhancedErr.Context["validation_type"]; ctx != tt.wantType {
+				t.Errorf("expected validation_type = %s, got %v", tt.wantType, ctx)
+			}
+		})
+	}
+}
+
+func TestValidateContextCheckSettings(t *testing.T) {
+	valid := ContextCheckSettings{Enabled: true, MinConfidence: 0.3, MinWindows: 1, SkipConfidence: 0.9}
+
+	tests := []struct {
+		name     string
+		modify   func(s *ContextCheckSettings)
+		wantType string
+	}{
+		{"valid", func(s *ContextCheckSettings) {}, ""},
+		{"disabled with invalid values", func(s *ContextCheckSettings) { *s = ContextCheckSettings{} }, ""},
+		{"no windows", func(s *ContextCheckSettings) { s.MinWindows = 0 }, "context-check-min-windows"},
+		{"three windows", func(s *ContextCheckSettings) { s.MinWindows = 3 }, "context-check-min-windows"},
+		{"no min confidence", func(s *ContextCheckSettings) { s.MinConfidence = 0 }, "context-check-confidence"},
+		{"skip confidence above 1", func(s *ContextCheckSettings) { s.SkipConfidence = 1.1 }, "context-check-confidence"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := valid
+			tt.modify(&settings)
+			err := validateContextCheckSettings(&settings)
+			if tt.wantType == "" {
+				if err != nil {
+					t.Errorf("unexpected error = %v", err)
+				}
+				return
+			}
+
+			var enhancedErr *errors.EnhancedError
+			if !stderrors.As(err, &enhancedErr) {
+				t.Fatalf("expected EnhancedError, got %T (%v)", err, err)
+			}
+			if ctx := enhancedErr.Context["validation_type"]; ctx != tt.wantType {
+				t.Errorf("expected validation_type = %s, got %v", tt.wantType, ctx)
+			}
+		})
+	}
+}
+
+func TestValidateTimePriorSettings(t *testing.T) {
+	valid := TimePriorSettings{Enabled: true, Learn: true, LearnDays: 60, MinDetections: 50, Adjustment: 0.2, MaxThreshold: 0.95,
+		Species: map[string]TimePrior{"tawny owl": {ActiveHours: []int{0, 1, 22, 23}, Inactive: 0.1}}}
+
+	tests := []struct {
+		name     string
+		modify   func(s *TimePriorSettings)
+		wantType string
+	}{
+		{"valid", func(s *TimePriorSettings) {}, ""},
+		{"disabled with invalid values", func(s *TimePriorSettings) { *s = TimePriorSettings{} }, ""},
+		{"not learning without learn days", func(s *TimePriorSettings) { s.Learn = false; s.LearnDays = 0 }, ""},
+		{"no learn days", func(s *TimePriorSettings) { s.LearnDays = 0 }, "time-priors-learn"},
+		{"adjustment above 1", func(s *TimePriorSettings) { s.Adjustment = 1.5 }, "time-priors-adjustment"},
+		{"no max threshold", func(s *TimePriorSettings) { s.MaxThreshold = 0 }, "time-priors-max-threshold"},
+		{"hour out of range", func(s *TimePriorSettings) {
+			s.Species = map[string]TimePrior{"tawny owl": {ActiveHours: []int{24}}}
+		}, "time-priors-species"},
+		{"negative inactive prior", func(s *TimePriorSettings) {
+			s.Species = map[string]TimePrior{"tawny owl": {ActiveHours: []int{22}, Inactive: -0.1}}
+		}, "time-priors-species"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := valid
+			tt.modify(&settings)
+			err := validateTimePriorSettings(&settings)
+			if tt.wantType == "" {
+				if err != nil {
+					t.Errorf("unexpected error = %v", err)
+				}
+				return
+			}
+
+			var enhancedErr *errors.EnhancedError
+			if !stderrors.As(err, &enhancedErr) {
+				t.Fatalf("expected EnhancedError, got %T (%v)", err, err)
+			}
+			if ctx := enhancedErr.Context["validation_type"]; ctx != tt.wantType {
+				t.Errorf("expected validation_type = %s, got %v", tt.wantType, ctx)
+			}
+		})
+	}
+}
+
+func TestValidateRareSpeciesSettings(t *testing.T) {
+	valid := RareSpeciesSettings{Enabled: true, Source: "ebird", MaxFrequency: 0.02, MinConfidence: 0.8, Cooldown: 24}
+
+	tests := []struct {
+		name     string
+		modify   func(s *RareSpeciesSettings)
+		wantType string
+	}{
+		{"valid", func(s *RareSpeciesSettings) {}, ""},
+		{"disabled with invalid values", func(s *RareSpeciesSettings) { *s = RareSpeciesSettings{Source: "none"} }, ""},
+		{"barchart with file", func(s *RareSpeciesSettings) { s.Source = "barchart"; s.BarChartFile = "barchart.txt" }, ""},
+		{"barchart without file", func(s *RareSpeciesSettings) { s.Source = "barchart" }, "rare-species-barchart-file"},
+		{"unknown source", func(s *RareSpeciesSettings) { s.Source = "inaturalist" }, "rare-species-source"},
+		{"max frequency of 1", func(s *RareSpeciesSettings) { s.MaxFrequency = 1 }, "rare-species-max-frequency"},
+		{"min confidence above 1", func(s *RareSpeciesSettings) { s.MinConfidence = 1.5 }, "rare-species-min-confidence"},
+		{"negative cooldown", func(s *RareSpeciesSettings) { s.Cooldown = -1 }, "rare-species-cooldown"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := valid
+			tt.modify(&settings)
+			err := validateRareSpeciesSettings(&settings)
+			if tt.wantType == "" {
+				if err != nil {
+					t.Errorf("unexpected error = %v", err)
+				}
+				return
+			}
+
+			var enhancedErr *errors.EnhancedError
+			if !stderrors.As(err, &enhancedErr) {
+				t.Fatalf("expected EnhancedError, got %T (%v)", err, err)
+			}
+			if ctx := enhancedErr.Context["validation_type"]; ctx != tt.wantType {
+				t.Errorf("expected validation_type = %s, got %v", tt.wantType, ctx)
+			}
+		})
+	}
+}
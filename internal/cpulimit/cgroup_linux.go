@@ -0,0 +1,83 @@
+package cpulimit
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// cgroupPeriodMicros is the period of the cgroup CPU quota
+const cgroupPeriodMicros = 100000
+
+// Locations of the cgroup v2 hierarchy, variables for tests
+var (
+	cgroupRoot     = "/sys/fs/cgroup"
+	procSelfCgroup = "/proc/self/cgroup"
+)
+
+// setCgroupLimits writes the CPU quota and weight to the cgroup v2 of the
+// process and returns its path
+func setCgroupLimits(quota float64, weight int) (string, error) {
+	path, err := ownCgroup()
+	if err != nil {
+		return "", err
+	}
+
+	if quota > 0 {
+		quotaMicros := max(1000, int(quota*cgroupPeriodMicros))
+		if err := writeCgroupFile(path, "cpu.max", fmt.Sprintf("%d %d", quotaMicros, cgroupPeriodMicros)); err != nil {
+			return path, err
+		}
+	}
+	if weight > 0 {
+		if err := writeCgroupFile(path, "cpu.weight", strconv.Itoa(weight)); err != nil {
+			return path, err
+		}
+	}
+	return path, nil
+}
+
+// ownCgroup returns the directory of the cgroup v2 of the process
+func ownCgroup() (string, error) {
+	f, err := os.Open(procSelfCgroup)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the cgroup of the process: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	// The unified hierarchy has a single line of the form "0::/path"
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if rel, ok := strings.CutPrefix(scanner.Text(), "0::"); ok {
+			return filepath.Join(cgroupRoot, filepath.Clean("/"+rel)), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read the cgroup of the process: %w", err)
+	}
+	return "", fmt.Errorf("the process is not in a cgroup v2 hierarchy")
+}
+
+// writeCgroupFile writes an existing cgroup control file
+func writeCgroupFile(dir, name, value string) error {
+	f, err := os.OpenFile(filepath.Join(dir, name), os.O_WRONLY|os.O_TRUNC, 0)
+	if err == nil {
+		_, err = f.WriteString(value)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	switch {
+	case err == nil:
+		return nil
+	case os.IsPermission(err):
+		return fmt.Errorf("cgroup %s is not writable, delegate it to the service (systemd Delegate=yes) or set the limit in the service manager: %w", dir, err)
+	case os.IsNotExist(err):
+		return fmt.Errorf("cgroup %s has no %s, enable the cpu controller in the parent cgroup: %w", dir, name, err)
+	default:
+		return fmt.Errorf("failed to write %s of cgroup %s: %w", name, dir, err)
+	}
+}
@@ -0,0 +1,57 @@
+package cpulimit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCgroup points the cgroup lookups at a temporary hierarchy holding the
+// cgroup of the process, described by the /proc/self/cgroup content
+func fakeCgroup(t *testing.T, selfCgroup string) string {
+	t.Helper()
+	root := t.TempDir()
+	own := filepath.Join(root, "system.slice", "birdnet-go.service")
+	require.NoError(t, os.MkdirAll(own, 0o755))
+	for _, name := range []string{"cpu.max", "cpu.weight"} {
+		require.NoError(t, os.WriteFile(filepath.Join(own, name), []byte("max 100000\n"), 0o644))
+	}
+	self := filepath.Join(root, "self-cgroup")
+	require.NoError(t, os.WriteFile(self, []byte(selfCgroup), 0o644))
+
+	oldRoot, oldSelf := cgroupRoot, procSelfCgroup
+	cgroupRoot, procSelfCgroup = root, self
+	t.Cleanup(func() { cgroupRoot, procSelfCgroup = oldRoot, oldSelf })
+	return own
+}
+
+func TestSetCgroupLimits(t *testing.T) {
+	own := fakeCgroup(t, "0::/system.slice/birdnet-go.service\n")
+
+	path, err := setCgroupLimits(1.5, 50)
+	require.NoError(t, err)
+	assert.Equal(t, own, path)
+
+	quota, err := os.ReadFile(filepath.Join(own, "cpu.max"))
+	require.NoError(t, err)
+	assert.Equal(t, "150000 100000", string(quota))
+	weight, err := os.ReadFile(filepath.Join(own, "cpu.weight"))
+	require.NoError(t, err)
+	assert.Equal(t, "50", string(weight))
+}
+
+func TestSetCgroupLimitsErrors(t *testing.T) {
+	// Only cgroup v1 controllers
+	fakeCgroup(t, "12:cpu,cpuacct:/docker/abc\n")
+	_, err := setCgroupLimits(1, 0)
+	require.ErrorContains(t, err, "not in a cgroup v2 hierarchy")
+
+	// The cpu controller is not enabled for the cgroup
+	fakeCgroup(t, "0::/user.slice\n")
+	require.NoError(t, os.MkdirAll(filepath.Join(cgroupRoot, "user.slice"), 0o755))
+	_, err = setCgroupLimits(0, 200)
+	require.ErrorContains(t, err, "enable the cpu controller")
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package cpulimit
+
+import "fmt"
+
+// setCgroupLimits is not supported, cgroups exist on Linux only
+func setCgroupLimits(float64, int) (string, error) {
+	return "", fmt.Errorf("cgroup limits are only supported on Linux")
+}
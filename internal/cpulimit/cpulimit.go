@@ -0,0 +1,66 @@
+// Package cpulimit applies the configured CPU limits to the process, so that
+// BirdNET-Go can share a small host with other services without starving
+// them while it analyzes the dawn chorus.
+package cpulimit
+
+import (
+	"runtime"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// Result describes the limits in effect after Apply
+type Result struct {
+	MaxProcs  int     // GOMAXPROCS of the process
+	Nice      int     // nice level set on the process, 0 when unchanged
+	Cgroup    string  // path of the cgroup whose limits were set, empty when unchanged
+	CPUQuota  float64 // CPUs allowed by the cgroup quota, 0 for no limit set
+	CPUWeight int     // CPU weight set on the cgroup, 0 when unchanged
+}
+
+// Apply sets the CPU limits of the process. It should be called at startup,
+// before the model interpreter starts its threads, as threads inherit the
+// nice level of the thread creating them. Each limit is applied on its own:
+// when one cannot be applied, the others still are and the errors are
+// returned together.
+func Apply(settings *conf.ResourceSettings) (Result, error) {
+	var result Result
+	var errs []error
+
+	if settings.MaxProcs > 0 {
+		runtime.GOMAXPROCS(min(settings.MaxProcs, runtime.NumCPU()))
+	}
+	result.MaxProcs = runtime.GOMAXPROCS(0)
+
+	if settings.Nice > 0 {
+		if err := setNice(settings.Nice); err != nil {
+			errs = append(errs, errors.New(err).
+				Component("cpulimit").
+				Category(errors.CategorySystem).
+				Context("operation", "set_nice").
+				Context("nice", settings.Nice).
+				Build())
+		} else {
+			result.Nice = settings.Nice
+		}
+	}
+
+	if settings.Cgroup.Enabled && (settings.Cgroup.CPUQuota > 0 || settings.Cgroup.CPUWeight > 0) {
+		path, err := setCgroupLimits(settings.Cgroup.CPUQuota, settings.Cgroup.CPUWeight)
+		if err != nil {
+			errs = append(errs, errors.New(err).
+				Component("cpulimit").
+				Category(errors.CategorySystem).
+				Context("operation", "set_cgroup_limits").
+				Context("cgroup", path).
+				Build())
+		} else {
+			result.Cgroup = path
+			result.CPUQuota = settings.Cgroup.CPUQuota
+			result.CPUWeight = settings.Cgroup.CPUWeight
+		}
+	}
+
+	return result, errors.Join(errs...)
+}
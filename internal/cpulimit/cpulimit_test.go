@@ -0,0 +1,35 @@
+package cpulimit
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+func TestApplyMaxProcs(t *testing.T) {
+	previous := runtime.GOMAXPROCS(0)
+	t.Cleanup(func() { runtime.GOMAXPROCS(previous) })
+
+	result, err := Apply(&conf.ResourceSettings{MaxProcs: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.MaxProcs)
+	assert.Equal(t, 1, runtime.GOMAXPROCS(0))
+	assert.Zero(t, result.Nice)
+	assert.Empty(t, result.Cgroup)
+
+	// More than the CPUs of the host is capped
+	result, err = Apply(&conf.ResourceSettings{MaxProcs: runtime.NumCPU() + 8})
+	require.NoError(t, err)
+	assert.Equal(t, runtime.NumCPU(), result.MaxProcs)
+}
+
+func TestApplyDisabledCgroup(t *testing.T) {
+	// Limits of a disabled cgroup integration are ignored
+	result, err := Apply(&conf.ResourceSettings{Cgroup: conf.CgroupSettings{CPUQuota: 1, CPUWeight: 50}})
+	require.NoError(t, err)
+	assert.Empty(t, result.Cgroup)
+	assert.Equal(t, runtime.GOMAXPROCS(0), result.MaxProcs)
+}
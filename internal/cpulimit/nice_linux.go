@@ -0,0 +1,30 @@
+package cpulimit
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"golang.org/x/sys/unix"
+)
+
+// setNice sets the nice level of every thread of the process. On Linux the
+// nice level belongs to a thread rather than to the process, so it is set on
+// each running thread; threads started later inherit it from their creator.
+func setNice(nice int) error {
+	tasks, err := os.ReadDir("/proc/self/task")
+	if err != nil {
+		return fmt.Errorf("failed to list process threads: %w", err)
+	}
+	for _, task := range tasks {
+		tid, err := strconv.Atoi(task.Name())
+		if err != nil {
+			continue
+		}
+		// Threads may exit while the list is walked
+		if err := unix.Setpriority(unix.PRIO_PROCESS, tid, nice); err != nil && err != unix.ESRCH { //nolint:errorlint // syscall errno comparison
+			return fmt.Errorf("failed to set nice level %d: %w", nice, err)
+		}
+	}
+	return nil
+}
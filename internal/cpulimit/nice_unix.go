@@ -0,0 +1,17 @@
+//go:build unix && !linux
+
+package cpulimit
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+// setNice sets the nice level of the process
+func setNice(nice int) error {
+	if err := unix.Setpriority(unix.PRIO_PROCESS, 0, nice); err != nil {
+		return fmt.Errorf("failed to set nice level %d: %w", nice, err)
+	}
+	return nil
+}
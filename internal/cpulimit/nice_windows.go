@@ -0,0 +1,17 @@
+//go:build windows
+
+package cpulimit
+
+import (
+	"golang.org/x/sys/windows"
+)
+
+// setNice lowers the priority class of the process, as Windows has no nice
+// levels: nice levels up to 9 map to below normal and higher ones to idle
+func setNice(nice int) error {
+	class := uint32(windows.BELOW_NORMAL_PRIORITY_CLASS)
+	if nice >= 10 {
+		class = windows.IDLE_PRIORITY_CLASS
+	}
+	return windows.SetPriorityClass(windows.CurrentProcess(), class)
+}
@@ -0,0 +1,385 @@
+// Package databudget tracks outbound network traffic per integration against a
+// monthly data cap, for stations connected over cellular or other metered links.
+//
+// When usage crosses the throttle threshold only essential integrations may
+// keep sending; once the cap is reached all tracked traffic is deferred until
+// the next billing period starts.
+package databudget
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// Integration identifies a source of outbound traffic
+type Integration string
+
+const (
+	Uploads Integration = "uploads" // BirdWeather soundscape and detection uploads
+	Images  Integration = "images"  // species image metadata and thumbnails
+	Weather Integration = "weather" // weather provider polling
+)
+
+// Integrations lists all tracked integrations
+var Integrations = []Integration{Uploads, Images, Weather}
+
+// stateVersion is the version of the persisted usage file format
+const stateVersion = 1
+
+// saveInterval limits how often usage is written to disk while traffic flows
+const saveInterval = time.Minute
+
+// ErrBudgetExceeded is returned for requests deferred by the data budget
+var ErrBudgetExceeded = errors.NewStd("data budget exceeded, request deferred")
+
+// Config configures a Manager
+type Config struct {
+	CapBytes        int64         // monthly data cap in bytes
+	ThrottlePercent int           // percent of the cap at which non-essential traffic is deferred
+	ResetDay        int           // day of month the billing period starts, clamped to 1-28
+	Essential       []Integration // integrations allowed to send until the hard cap
+}
+
+// IntegrationUsage holds the traffic counters of one integration
+type IntegrationUsage struct {
+	Bytes    int64 `json:"bytes"`
+	Requests int64 `json:"requests"`
+	Deferred int64 `json:"deferred"`
+}
+
+// Status is a snapshot of the budget for the current billing period
+type Status struct {
+	CapBytes        int64                            `json:"cap_bytes"`
+	UsedBytes       int64                            `json:"used_bytes"`
+	UsedPercent     float64                          `json:"used_percent"`
+	ThrottlePercent int                              `json:"throttle_percent"`
+	Throttled       bool                             `json:"throttled"` // non-essential traffic is deferred
+	Exhausted       bool                             `json:"exhausted"` // all tracked traffic is deferred
+	PeriodStart     time.Time                        `json:"period_start"`
+	PeriodEnd       time.Time                        `json:"period_end"`
+	Essential       []Integration                    `json:"essential"`
+	Integrations    map[Integration]IntegrationUsage `json:"integrations"`
+	LastSaveError   string                           `json:"last_save_error,omitempty"`
+}
+
+// persistedState is the on-disk format of the usage file
+type persistedState struct {
+	Version      int                              `json:"version"`
+	PeriodStart  time.Time                        `json:"period_start"`
+	Integrations map[Integration]IntegrationUsage `json:"integrations"`
+}
+
+// Manager tracks outbound bytes per integration for the current billing period
+type Manager struct {
+	mu          sync.Mutex
+	cfg         Config
+	essential   map[Integration]bool
+	periodStart time.Time
+	usage       map[Integration]*IntegrationUsage
+	statePath   string
+	lastSave    time.Time
+	saveErr     error
+	onReset     func()
+	now         func() time.Time
+}
+
+// NewManager creates a manager and restores usage for the current period from
+// statePath. An empty statePath keeps usage in memory only. If the state file
+// cannot be read the manager is still returned, starting from zero, along with the error.
+func NewManager(cfg Config, statePath string) (*Manager, error) {
+	return newManager(cfg, statePath, time.Now)
+}
+
+// newManager creates a manager with a custom clock
+func newManager(cfg Config, statePath string, now func() time.Time) (*Manager, error) {
+	if cfg.CapBytes <= 0 {
+		return nil, errors.Newf("data budget cap must be positive, got %d bytes", cfg.CapBytes).
+			Component("databudget").
+			Category(errors.CategoryValidation).
+			Build()
+	}
+	if cfg.ThrottlePercent <= 0 || cfg.ThrottlePercent > 100 {
+		cfg.ThrottlePercent = 100
+	}
+	cfg.ResetDay = max(1, min(cfg.ResetDay, 28))
+
+	m := &Manager{
+		cfg:       cfg,
+		essential: make(map[Integration]bool, len(cfg.Essential)),
+		usage:     make(map[Integration]*IntegrationUsage),
+		statePath: statePath,
+		now:       now,
+	}
+	for _, integration := range cfg.Essential {
+		m.essential[integration] = true
+	}
+	m.periodStart = periodStart(now(), cfg.ResetDay)
+
+	if err := m.load(); err != nil {
+		return m, err
+	}
+	return m, nil
+}
+
+// periodStart returns the start of the billing period containing t
+func periodStart(t time.Time, resetDay int) time.Time {
+	start := time.Date(t.Year(), t.Month(), resetDay, 0, 0, 0, 0, t.Location())
+	if t.Before(start) {
+		start = start.AddDate(0, -1, 0)
+	}
+	return start
+}
+
+// SetResetHook registers fn to run, in its own goroutine, when a new billing period starts
+func (m *Manager) SetResetHook(fn func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onReset = fn
+}
+
+// Allow reports whether the integration may send traffic now
+func (m *Manager) Allow(integration Integration) bool {
+	m.mu.Lock()
+	hook := m.rolloverLocked()
+	allowed := m.allowLocked(integration)
+	m.mu.Unlock()
+
+	runHook(hook)
+	return allowed
+}
+
+// allowLocked applies the cap and throttle threshold, caller must hold m.mu
+func (m *Manager) allowLocked(integration Integration) bool {
+	used := m.usedLocked()
+	if used >= m.cfg.CapBytes {
+		return false
+	}
+	if m.essential[integration] {
+		return true
+	}
+	return used*100 < m.cfg.CapBytes*int64(m.cfg.ThrottlePercent)
+}
+
+// Record adds transferred bytes for an integration
+func (m *Manager) Record(integration Integration, bytes int64) {
+	m.mu.Lock()
+	hook := m.rolloverLocked()
+	u := m.usageLocked(integration)
+	u.Bytes += bytes
+	u.Requests++
+	m.mu.Unlock()
+
+	runHook(hook)
+	m.maybeSave()
+}
+
+// RecordDeferred counts a request or task held back by the budget
+func (m *Manager) RecordDeferred(integration Integration) {
+	m.mu.Lock()
+	m.usageLocked(integration).Deferred++
+	m.mu.Unlock()
+}
+
+// Status returns a snapshot of the current billing period
+func (m *Manager) Status() Status {
+	m.mu.Lock()
+	hook := m.rolloverLocked()
+	defer runHook(hook)
+	defer m.mu.Unlock()
+
+	used := m.usedLocked()
+	status := Status{
+		CapBytes:        m.cfg.CapBytes,
+		UsedBytes:       used,
+		UsedPercent:     float64(used) * 100 / float64(m.cfg.CapBytes),
+		ThrottlePercent: m.cfg.ThrottlePercent,
+		Exhausted:       used >= m.cfg.CapBytes,
+		PeriodStart:     m.periodStart,
+		PeriodEnd:       m.periodStart.AddDate(0, 1, 0),
+		Integrations:    make(map[Integration]IntegrationUsage, len(Integrations)),
+	}
+	status.Throttled = status.Exhausted || used*100 >= m.cfg.CapBytes*int64(m.cfg.ThrottlePercent)
+
+	for integration := range m.essential {
+		status.Essential = append(status.Essential, integration)
+	}
+	sort.Slice(status.Essential, func(i, j int) bool { return status.Essential[i] < status.Essential[j] })
+
+	for _, integration := range Integrations {
+		status.Integrations[integration] = IntegrationUsage{}
+	}
+	for integration, u := range m.usage {
+		status.Integrations[integration] = *u
+	}
+	if m.saveErr != nil {
+		status.LastSaveError = m.saveErr.Error()
+	}
+	return status
+}
+
+// usedLocked returns total bytes used in the period, caller must hold m.mu
+func (m *Manager) usedLocked() int64 {
+	var total int64
+	for _, u := range m.usage {
+		total += u.Bytes
+	}
+	return total
+}
+
+// usageLocked returns the counters for an integration, caller must hold m.mu
+func (m *Manager) usageLocked(integration Integration) *IntegrationUsage {
+	u, ok := m.usage[integration]
+	if !ok {
+		u = &IntegrationUsage{}
+		m.usage[integration] = u
+	}
+	return u
+}
+
+// rolloverLocked starts a new billing period when the current one has ended and
+// returns the reset hook to run, caller must hold m.mu
+func (m *Manager) rolloverLocked() func() {
+	start := periodStart(m.now(), m.cfg.ResetDay)
+	if !start.After(m.periodStart) {
+		return nil
+	}
+	m.periodStart = start
+	m.usage = make(map[Integration]*IntegrationUsage)
+	m.lastSave = time.Time{}
+	return m.onReset
+}
+
+// runHook runs a reset hook without blocking the caller
+func runHook(hook func()) {
+	if hook != nil {
+		go hook()
+	}
+}
+
+// maybeSave persists usage if the last save is older than saveInterval
+func (m *Manager) maybeSave() {
+	m.mu.Lock()
+	due := m.statePath != "" && m.now().Sub(m.lastSave) >= saveInterval
+	m.mu.Unlock()
+	if due {
+		_ = m.Save()
+	}
+}
+
+// Save writes the usage of the current period to the state file
+func (m *Manager) Save() error {
+	m.mu.Lock()
+	if m.statePath == "" {
+		m.mu.Unlock()
+		return nil
+	}
+	state := persistedState{
+		Version:      stateVersion,
+		PeriodStart:  m.periodStart,
+		Integrations: make(map[Integration]IntegrationUsage, len(m.usage)),
+	}
+	for integration, u := range m.usage {
+		state.Integrations[integration] = *u
+	}
+	m.lastSave = m.now()
+	path := m.statePath
+	m.mu.Unlock()
+
+	err := writeState(path, &state)
+
+	m.mu.Lock()
+	m.saveErr = err
+	m.mu.Unlock()
+	return err
+}
+
+// writeState atomically replaces the state file
+func writeState(path string, state *persistedState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return stateError(err, "encode_state", path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return stateError(err, "create_dir", path)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return stateError(err, "write_state", path)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return stateError(err, "rename_state", path)
+	}
+	return nil
+}
+
+// load restores usage from the state file if it belongs to the current period
+func (m *Manager) load() error {
+	if m.statePath == "" {
+		return nil
+	}
+	data, err := os.ReadFile(m.statePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return stateError(err, "read_state", m.statePath)
+	}
+
+	var state persistedState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return stateError(err, "decode_state", m.statePath)
+	}
+	if state.Version != stateVersion || !state.PeriodStart.Equal(m.periodStart) {
+		// Usage from an earlier period or an unknown format starts fresh
+		return nil
+	}
+	for integration, u := range state.Integrations {
+		m.usage[integration] = &IntegrationUsage{Bytes: u.Bytes, Requests: u.Requests, Deferred: u.Deferred}
+	}
+	return nil
+}
+
+// stateError wraps a state file error with context
+func stateError(err error, operation, path string) error {
+	return errors.New(err).
+		Component("databudget").
+		Category(errors.CategoryFileIO).
+		Context("operation", operation).
+		Context("path", path).
+		Build()
+}
+
+// active is the process-wide manager used by Transport and the package helpers
+var active atomic.Pointer[Manager]
+
+// SetActive installs the process-wide manager, nil disables budget enforcement
+func SetActive(m *Manager) {
+	active.Store(m)
+}
+
+// Active returns the process-wide manager, or nil when no budget is configured
+func Active() *Manager {
+	return active.Load()
+}
+
+// Allow reports whether the integration may send traffic under the active budget.
+// It always returns true when no budget is configured.
+func Allow(integration Integration) bool {
+	m := active.Load()
+	return m == nil || m.Allow(integration)
+}
+
+// RecordDeferred counts deferred work against the active budget, if any
+func RecordDeferred(integration Integration) {
+	if m := active.Load(); m != nil {
+		m.RecordDeferred(integration)
+	}
+}
@@ -0,0 +1,141 @@
+package databudget
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// fakeClock is a settable time source for tests
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func TestManager_ThrottlesNonEssentialTraffic(t *testing.T) {
+	t.Parallel()
+
+	clock := &fakeClock{t: time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)}
+	m, err := newManager(Config{CapBytes: 1000, ThrottlePercent: 80, ResetDay: 1, Essential: []Integration{Uploads}}, "", clock.now)
+	require.NoError(t, err)
+
+	assert.True(t, m.Allow(Images))
+	assert.True(t, m.Allow(Uploads))
+
+	m.Record(Weather, 800)
+	assert.False(t, m.Allow(Images), "non-essential traffic should be deferred above the threshold")
+	assert.True(t, m.Allow(Uploads), "essential traffic should continue until the cap")
+	assert.True(t, m.Status().Throttled)
+	assert.False(t, m.Status().Exhausted)
+
+	m.Record(Uploads, 200)
+	assert.False(t, m.Allow(Uploads), "all traffic should be deferred at the cap")
+	assert.True(t, m.Status().Exhausted)
+}
+
+func TestManager_ResetsOnNewPeriod(t *testing.T) {
+	t.Parallel()
+
+	clock := &fakeClock{t: time.Date(2026, 3, 14, 12, 0, 0, 0, time.UTC)}
+	m, err := newManager(Config{CapBytes: 100, ResetDay: 15}, "", clock.now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 2, 15, 0, 0, 0, 0, time.UTC), m.Status().PeriodStart)
+
+	reset := make(chan struct{}, 1)
+	m.SetResetHook(func() { reset <- struct{}{} })
+
+	m.Record(Images, 100)
+	assert.False(t, m.Allow(Images))
+
+	clock.t = time.Date(2026, 3, 15, 0, 0, 1, 0, time.UTC)
+	assert.True(t, m.Allow(Images))
+	assert.Zero(t, m.Status().UsedBytes)
+
+	select {
+	case <-reset:
+	case <-time.After(time.Second):
+		t.Fatal("reset hook was not called")
+	}
+}
+
+func TestManager_PersistsUsageWithinPeriod(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "databudget.json")
+	clock := &fakeClock{t: time.Date(2026, 5, 2, 8, 0, 0, 0, time.UTC)}
+	cfg := Config{CapBytes: 1 << 20, ResetDay: 1}
+
+	m, err := newManager(cfg, path, clock.now)
+	require.NoError(t, err)
+	m.Record(Uploads, 4096)
+	m.RecordDeferred(Images)
+	require.NoError(t, m.Save())
+
+	restored, err := newManager(cfg, path, clock.now)
+	require.NoError(t, err)
+	status := restored.Status()
+	assert.Equal(t, int64(4096), status.UsedBytes)
+	assert.Equal(t, int64(1), status.Integrations[Images].Deferred)
+
+	// Usage saved in an earlier period is discarded
+	clock.t = time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	next, err := newManager(cfg, path, clock.now)
+	require.NoError(t, err)
+	assert.Zero(t, next.Status().UsedBytes)
+}
+
+func TestNewManager_RejectsInvalidCap(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewManager(Config{}, "")
+	require.Error(t, err)
+}
+
+// TestTransport_MetersAndDefers uses the process-wide manager, so it is not parallel
+func TestTransport_MetersAndDefers(t *testing.T) {
+	body := strings.Repeat("x", 500)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, body)
+	}))
+	t.Cleanup(server.Close)
+
+	m, err := NewManager(Config{CapBytes: 700, ThrottlePercent: 50}, "")
+	require.NoError(t, err)
+	SetActive(m)
+	t.Cleanup(func() { SetActive(nil) })
+
+	client := &http.Client{Transport: Transport(Images, nil)}
+
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	_, err = io.Copy(io.Discard, resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	usage := m.Status().Integrations[Images]
+	assert.Equal(t, int64(1), usage.Requests)
+	assert.Greater(t, usage.Bytes, int64(len(body)), "headers should be counted on top of the body")
+
+	// Usage is now above the 50% threshold, so image traffic is deferred
+	_, err = client.Get(server.URL)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrBudgetExceeded))
+	assert.False(t, errors.Is(errors.Newf("rate limited").Category(errors.CategoryLimit).Build(), ErrBudgetExceeded),
+		"other limit errors are not deferred requests")
+	assert.Equal(t, int64(1), m.Status().Integrations[Images].Deferred)
+}
+
+// TestAllow_WithoutManager verifies traffic is unrestricted when no budget is set.
+// Not parallel: reads the process-wide manager.
+func TestAllow_WithoutManager(t *testing.T) {
+	assert.Nil(t, Active())
+	assert.True(t, Allow(Images))
+	RecordDeferred(Images) // must not panic
+}
@@ -0,0 +1,107 @@
+// transport.go: HTTP transport that meters traffic against the data budget
+package databudget
+
+import (
+	"io"
+	"net/http"
+)
+
+// headerOverhead approximates the bytes of a request line and status line
+// not covered by the header map
+const headerOverhead = 32
+
+// meteredTransport counts request and response bytes for one integration
+type meteredTransport struct {
+	integration Integration
+	base        http.RoundTripper
+}
+
+// Transport wraps base so traffic is recorded against the active budget and
+// requests are rejected with ErrBudgetExceeded when the integration is deferred.
+// The budget is looked up per request, so clients may be created before a
+// budget is installed. A nil base uses http.DefaultTransport.
+func Transport(integration Integration, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &meteredTransport{integration: integration, base: base}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *meteredTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	m := active.Load()
+	if m == nil {
+		return t.base.RoundTrip(req)
+	}
+	if !m.Allow(t.integration) {
+		m.RecordDeferred(t.integration)
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		return nil, ErrBudgetExceeded
+	}
+
+	sent := headerOverhead + len(req.URL.String()) + headerSize(req.Header)
+	if req.ContentLength > 0 {
+		sent += int(req.ContentLength)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		m.Record(t.integration, int64(sent))
+		return resp, err
+	}
+
+	resp.Body = &countingBody{
+		ReadCloser:  resp.Body,
+		manager:     m,
+		integration: t.integration,
+		count:       int64(sent + headerOverhead + headerSize(resp.Header)),
+	}
+	return resp, nil
+}
+
+// CloseIdleConnections closes idle connections of the wrapped transport
+func (t *meteredTransport) CloseIdleConnections() {
+	type closeIdler interface{ CloseIdleConnections() }
+	if base, ok := t.base.(closeIdler); ok {
+		base.CloseIdleConnections()
+	}
+}
+
+// headerSize returns the approximate wire size of an HTTP header block
+func headerSize(h http.Header) int {
+	size := 0
+	for k, values := range h {
+		for _, v := range values {
+			size += len(k) + len(v) + 4 // ": " and CRLF
+		}
+	}
+	return size
+}
+
+// countingBody records the bytes read from a response body when it is closed
+type countingBody struct {
+	io.ReadCloser
+	manager     *Manager
+	integration Integration
+	count       int64
+	closed      bool
+}
+
+// Read implements io.Reader
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.count += int64(n)
+	return n, err
+}
+
+// Close implements io.Closer and records the transferred bytes once
+func (b *countingBody) Close() error {
+	err := b.ReadCloser.Close()
+	if !b.closed {
+		b.closed = true
+		b.manager.Record(b.integration, b.count)
+	}
+	return err
+}
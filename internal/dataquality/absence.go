@@ -0,0 +1,202 @@
+// absence.go: Detection of prolonged absences of usually daily species
+package dataquality
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+// absenceCheckInterval is how often the monitor looks for a newly completed day
+const absenceCheckInterval = time.Hour
+
+// AbsenceConfig holds the thresholds of absence detection
+type AbsenceConfig struct {
+	Days         int
+	BaselineDays int
+	MinPresence  float64  // fraction of the active baseline days with detections
+	Species      []string // scientific or common names, empty for all species
+}
+
+// AbsenceConfigFromSettings returns the absence thresholds of the configuration
+func AbsenceConfigFromSettings(settings *conf.AbsenceSettings) AbsenceConfig {
+	return AbsenceConfig{
+		Days:         settings.Days,
+		BaselineDays: settings.BaselineDays,
+		MinPresence:  settings.MinPresence,
+		Species:      settings.Species,
+	}
+}
+
+// Absence is a species usually detected on most days that has not been
+// detected for the configured number of days
+type Absence struct {
+	ScientificName string  `json:"scientificName"`
+	CommonName     string  `json:"commonName"`
+	LastSeen       string  `json:"lastSeen"`   // last date with detections (YYYY-MM-DD)
+	DaysAbsent     int     `json:"daysAbsent"` // days since the last detection up to the checked day
+	Presence       float64 `json:"presence"`   // fraction of the baseline days the species was detected on
+}
+
+// AbsenceMonitor flags species that went missing after being detected on most
+// days. Each absence is reported once, until the species is detected again.
+type AbsenceMonitor struct {
+	config   AbsenceConfig
+	store    AnomalyStore
+	checked  string          // last day checked by Run (YYYY-MM-DD)
+	reported map[string]bool // species absent at the previous check
+
+	now func() time.Time // current time, replaceable in tests
+}
+
+// NewAbsenceMonitor creates an absence monitor
+func NewAbsenceMonitor(config AbsenceConfig, store AnomalyStore) *AbsenceMonitor {
+	return &AbsenceMonitor{config: config, store: store, reported: make(map[string]bool), now: time.Now}
+}
+
+// Run checks each day once it is complete until ctx is cancelled. onAbsences
+// is called with the species that went missing on a day, or with an error when
+// the check failed.
+func (m *AbsenceMonitor) Run(ctx context.Context, onAbsences func([]Absence, error)) {
+	ticker := time.NewTicker(absenceCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		absences, err := m.CheckCompletedDay(ctx)
+		switch {
+		case err != nil:
+			onAbsences(nil, err)
+		case len(absences) > 0:
+			onAbsences(absences, nil)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckCompletedDay returns the absences that started with the last completed
+// day, nothing when that day was already checked
+func (m *AbsenceMonitor) CheckCompletedDay(ctx context.Context) ([]Absence, error) {
+	now := m.now()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).AddDate(0, 0, -1)
+	if date := day.Format(time.DateOnly); date == m.checked {
+		return nil, nil
+	}
+
+	absences, err := m.Detect(ctx, day)
+	if err != nil {
+		return nil, err
+	}
+	m.checked = day.Format(time.DateOnly)
+
+	current := make(map[string]bool, len(absences))
+	var started []Absence
+	for i := range absences {
+		current[absences[i].ScientificName] = true
+		if !m.reported[absences[i].ScientificName] {
+			started = append(started, absences[i])
+		}
+	}
+	m.reported = current
+	return started, nil
+}
+
+// Detect returns the species absent in the days up to and including day,
+// ordered by scientific name. Days without any detections count as downtime:
+// the station must have detected something on every day of the absence.
+func (m *AbsenceMonitor) Detect(ctx context.Context, day time.Time) ([]Absence, error) {
+	absenceStart := day.AddDate(0, 0, -(m.config.Days - 1))
+	baselineStart := absenceStart.AddDate(0, 0, -m.config.BaselineDays)
+	rows, err := m.store.GetHourlySpeciesCounts(ctx, baselineStart.Format(time.DateOnly), day.Format(time.DateOnly))
+	if err != nil {
+		return nil, err
+	}
+
+	activeDays := make(map[string]bool)
+	speciesDays := make(map[string]map[string]bool) // dates with detections by species
+	commonNames := make(map[string]string)
+	for i := range rows {
+		row := &rows[i]
+		if row.Count <= 0 {
+			continue
+		}
+		activeDays[row.Date] = true
+		if speciesDays[row.ScientificName] == nil {
+			speciesDays[row.ScientificName] = make(map[string]bool)
+		}
+		speciesDays[row.ScientificName][row.Date] = true
+		commonNames[row.ScientificName] = row.CommonName
+	}
+
+	for d := absenceStart; !d.After(day); d = d.AddDate(0, 0, 1) {
+		if !activeDays[d.Format(time.DateOnly)] {
+			return nil, nil
+		}
+	}
+	var baselineDates []string
+	for d := baselineStart; d.Before(absenceStart); d = d.AddDate(0, 0, 1) {
+		if date := d.Format(time.DateOnly); activeDays[date] {
+			baselineDates = append(baselineDates, date)
+		}
+	}
+	// A usual presence needs detections on at least half of the baseline days
+	if len(baselineDates)*2 < m.config.BaselineDays {
+		return nil, nil
+	}
+
+	var absences []Absence
+	for species, dates := range speciesDays {
+		if !m.watched(species, commonNames[species]) {
+			continue
+		}
+		present, lastSeen := 0, ""
+		for _, date := range baselineDates {
+			if dates[date] {
+				present++
+				lastSeen = date
+			}
+		}
+		presence := float64(present) / float64(len(baselineDates))
+		if presence < m.config.MinPresence || detectedSince(dates, absenceStart) {
+			continue
+		}
+		last, _ := time.ParseInLocation(time.DateOnly, lastSeen, day.Location())
+		absences = append(absences, Absence{
+			ScientificName: species,
+			CommonName:     commonNames[species],
+			LastSeen:       lastSeen,
+			DaysAbsent:     int(day.Sub(last).Hours()/24 + 0.5),
+			Presence:       round2(presence),
+		})
+	}
+	slices.SortFunc(absences, func(a, b Absence) int { return strings.Compare(a.ScientificName, b.ScientificName) })
+	return absences, nil
+}
+
+// detectedSince reports whether a species was detected on or after a day
+func detectedSince(dates map[string]bool, day time.Time) bool {
+	from := day.Format(time.DateOnly)
+	for date := range dates {
+		if date >= from {
+			return true
+		}
+	}
+	return false
+}
+
+// watched reports whether a species is checked for absences
+func (m *AbsenceMonitor) watched(scientificName, commonName string) bool {
+	if len(m.config.Species) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(m.config.Species, func(name string) bool {
+		return strings.EqualFold(name, scientificName) || strings.EqualFold(name, commonName)
+	})
+}
@@ -0,0 +1,94 @@
+package dataquality
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+var testAbsenceConfig = AbsenceConfig{Days: 3, BaselineDays: 14, MinPresence: 0.8}
+
+// absenceDays adds daily detections of a species on the days before end,
+// from days before up to but not including skip days before
+func (s *fakeAnomalyStore) absenceDays(end time.Time, scientificName, commonName string, days, skip int) {
+	for day := skip + 1; day <= days; day++ {
+		s.counts = append(s.counts, datastore.HourlySpeciesCount{
+			Date: end.AddDate(0, 0, -day).Format(time.DateOnly), Hour: 6,
+			ScientificName: scientificName, CommonName: commonName, Count: 3,
+		})
+	}
+}
+
+func TestAbsenceMonitor_Detect(t *testing.T) {
+	t.Parallel()
+
+	end := time.Date(2025, time.May, 21, 0, 0, 0, 0, time.Local)
+	day := end.AddDate(0, 0, -1)
+	store := &fakeAnomalyStore{}
+	store.absenceDays(end, "Turdus merula", "Eurasian Blackbird", 17, 0)
+	// Missing for the last 3 days
+	store.absenceDays(end, "Erithacus rubecula", "European Robin", 17, 3)
+	// Missing for 2 days only
+	store.absenceDays(end, "Parus major", "Great Tit", 17, 2)
+	// Detected on a few days only, its absence is not unusual
+	store.counts = append(store.counts, datastore.HourlySpeciesCount{Date: "2025-05-10", Hour: 7, ScientificName: "Cuculus canorus", CommonName: "Common Cuckoo", Count: 1})
+
+	monitor := NewAbsenceMonitor(testAbsenceConfig, store)
+	absences, err := monitor.Detect(context.Background(), day)
+	require.NoError(t, err)
+	require.Len(t, absences, 1)
+	assert.Equal(t, "Erithacus rubecula", absences[0].ScientificName)
+	assert.Equal(t, "2025-05-17", absences[0].LastSeen)
+	assert.Equal(t, 3, absences[0].DaysAbsent)
+	assert.InDelta(t, 1.0, absences[0].Presence, 0.001)
+
+	// Watching other species only
+	monitor = NewAbsenceMonitor(AbsenceConfig{Days: 3, BaselineDays: 14, MinPresence: 0.8, Species: []string{"great tit"}}, store)
+	absences, err = monitor.Detect(context.Background(), day)
+	require.NoError(t, err)
+	assert.Empty(t, absences)
+}
+
+func TestAbsenceMonitor_Downtime(t *testing.T) {
+	t.Parallel()
+
+	// Nothing was detected in the last 3 days: a silent station, not an absence
+	end := time.Date(2025, time.May, 21, 0, 0, 0, 0, time.Local)
+	store := &fakeAnomalyStore{}
+	store.absenceDays(end, "Turdus merula", "Eurasian Blackbird", 17, 3)
+
+	absences, err := NewAbsenceMonitor(testAbsenceConfig, store).Detect(context.Background(), end.AddDate(0, 0, -1))
+	require.NoError(t, err)
+	assert.Empty(t, absences)
+}
+
+func TestAbsenceMonitor_CheckCompletedDay(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, time.May, 21, 9, 0, 0, 0, time.Local)
+	end := time.Date(2025, time.May, 21, 0, 0, 0, 0, time.Local)
+	store := &fakeAnomalyStore{}
+	store.absenceDays(end.AddDate(0, 0, 1), "Turdus merula", "Eurasian Blackbird", 18, 0)
+	store.absenceDays(end, "Erithacus rubecula", "European Robin", 17, 3)
+
+	monitor := NewAbsenceMonitor(testAbsenceConfig, store)
+	monitor.now = func() time.Time { return now }
+
+	absences, err := monitor.CheckCompletedDay(context.Background())
+	require.NoError(t, err)
+	require.Len(t, absences, 1)
+
+	absences, err = monitor.CheckCompletedDay(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, absences, "a day is checked once")
+
+	// Still missing the next day, reported once
+	now = now.AddDate(0, 0, 1)
+	absences, err = monitor.CheckCompletedDay(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, absences)
+}
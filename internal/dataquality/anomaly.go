@@ -0,0 +1,241 @@
+// anomaly.go: Detection of unusual hourly detection rates
+package dataquality
+
+import (
+	"context"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+// AnomalyKind is the kind of a detection rate anomaly
+type AnomalyKind string
+
+// Detection rate anomalies
+const (
+	AnomalySpike   AnomalyKind = "spike"   // far more detections than usual, e.g. a passing flock or noise
+	AnomalySilence AnomalyKind = "silence" // far fewer detections than usual, e.g. a failed recorder
+)
+
+// anomalyCheckInterval is how often completed hours are checked for anomalies
+const anomalyCheckInterval = 10 * time.Minute
+
+// AnomalyConfig holds the anomaly detection thresholds
+type AnomalyConfig struct {
+	BaselineDays int
+	Threshold    float64 // standard deviations from the typical count
+	MinSpike     int
+	MinExpected  float64
+	Species      bool
+}
+
+// AnomalyConfigFromSettings returns the anomaly detection thresholds of the configuration
+func AnomalyConfigFromSettings(settings *conf.AnomalySettings) AnomalyConfig {
+	return AnomalyConfig{
+		BaselineDays: settings.BaselineDays,
+		Threshold:    settings.Threshold,
+		MinSpike:     settings.MinSpike,
+		MinExpected:  settings.MinExpected,
+		Species:      settings.Species,
+	}
+}
+
+// AnomalyStore is the datastore capability needed to learn detection rates
+type AnomalyStore interface {
+	GetHourlySpeciesCounts(ctx context.Context, startDate, endDate string) ([]datastore.HourlySpeciesCount, error)
+}
+
+// Anomaly is an hour with unusually many or few detections of all species
+// together or of one species
+type Anomaly struct {
+	Hour           time.Time   `json:"hour"` // start of the hour
+	Kind           AnomalyKind `json:"kind"`
+	ScientificName string      `json:"scientificName,omitempty"` // empty for all species together
+	CommonName     string      `json:"commonName,omitempty"`
+	Count          int         `json:"count"`
+	Expected       float64     `json:"expected"` // average count of the same hour on the baseline days
+	Score          float64     `json:"score"`    // deviation from the expected count in standard deviations
+}
+
+// AnomalyDetector learns the typical detections of each hour of the day from
+// the previous days and flags hours that deviate from them
+type AnomalyDetector struct {
+	config AnomalyConfig
+	store  AnomalyStore
+	next   time.Time // first hour not checked yet by Run
+
+	now func() time.Time // current time, replaceable in tests
+}
+
+// NewAnomalyDetector creates an anomaly detector
+func NewAnomalyDetector(config AnomalyConfig, store AnomalyStore) *AnomalyDetector {
+	return &AnomalyDetector{config: config, store: store, now: time.Now}
+}
+
+// Run checks each hour once it is complete until ctx is cancelled. onAnomaly
+// is called with each anomaly found, or with an error when the check failed.
+func (d *AnomalyDetector) Run(ctx context.Context, onAnomaly func(*Anomaly, error)) {
+	ticker := time.NewTicker(anomalyCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		anomalies, err := d.CheckCompletedHours(ctx)
+		if err != nil {
+			onAnomaly(nil, err)
+		}
+		for i := range anomalies {
+			onAnomaly(&anomalies[i], nil)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckCompletedHours detects anomalies in the hours completed since the
+// previous check. The first check covers the last completed hour only.
+func (d *AnomalyDetector) CheckCompletedHours(ctx context.Context) ([]Anomaly, error) {
+	current := startOfHour(d.now())
+	if d.next.IsZero() {
+		d.next = current.Add(-time.Hour)
+	}
+	if !d.next.Before(current) {
+		return nil, nil
+	}
+	anomalies, err := d.Detect(ctx, d.next, current)
+	if err != nil {
+		return nil, err
+	}
+	d.next = current
+	return anomalies, nil
+}
+
+// Detect returns the anomalies of the hours starting from from up to but not
+// including to, ordered by hour
+func (d *AnomalyDetector) Detect(ctx context.Context, from, to time.Time) ([]Anomaly, error) {
+	from = startOfHour(from)
+	if !from.Before(to) {
+		return nil, nil
+	}
+
+	baselineStart := from.AddDate(0, 0, -d.config.BaselineDays).Format(time.DateOnly)
+	rows, err := d.store.GetHourlySpeciesCounts(ctx, baselineStart, to.Format(time.DateOnly))
+	if err != nil {
+		return nil, err
+	}
+
+	// Detections by date and hour, overall and by species
+	type slot struct {
+		date    string
+		hour    int
+		species string // empty for all species
+	}
+	counts := make(map[slot]int)
+	activeDays := make(map[string]bool) // days with any detections, others are treated as downtime
+	commonNames := make(map[string]string)
+	for i := range rows {
+		row := &rows[i]
+		counts[slot{row.Date, row.Hour, ""}] += row.Count
+		counts[slot{row.Date, row.Hour, row.ScientificName}] += row.Count
+		activeDays[row.Date] = true
+		commonNames[row.ScientificName] = row.CommonName
+	}
+
+	var anomalies []Anomaly
+	for hour := from; hour.Before(to); hour = hour.Add(time.Hour) {
+		date := hour.Format(time.DateOnly)
+
+		var baselineDates []string
+		for day := 1; day <= d.config.BaselineDays; day++ {
+			if baselineDate := hour.AddDate(0, 0, -day).Format(time.DateOnly); activeDays[baselineDate] {
+				baselineDates = append(baselineDates, baselineDate)
+			}
+		}
+		// A typical rate needs detections on at least half of the baseline days
+		if len(baselineDates)*2 < d.config.BaselineDays {
+			continue
+		}
+
+		check := func(species string) {
+			observed := counts[slot{date, hour.Hour(), species}]
+			baseline := make([]int, len(baselineDates))
+			for i, baselineDate := range baselineDates {
+				baseline[i] = counts[slot{baselineDate, hour.Hour(), species}]
+			}
+			kind, expected, score := classifyRate(observed, baseline, d.config, species == "")
+			if kind == "" {
+				return
+			}
+			anomalies = append(anomalies, Anomaly{
+				Hour:           hour,
+				Kind:           kind,
+				ScientificName: species,
+				CommonName:     commonNames[species],
+				Count:          observed,
+				Expected:       round2(expected),
+				Score:          round2(score),
+			})
+		}
+
+		check("")
+		if !d.config.Species {
+			continue
+		}
+		var species []string
+		for key := range counts {
+			if key.species != "" && key.date == date && key.hour == hour.Hour() {
+				species = append(species, key.species)
+			}
+		}
+		slices.Sort(species)
+		for _, name := range species {
+			check(name)
+		}
+	}
+
+	return anomalies, nil
+}
+
+// classifyRate compares an observed hourly count with the counts of the same
+// hour on the baseline days. The deviation is measured in standard deviations
+// of the baseline, at least that of a Poisson process with the same mean so
+// that rare species do not produce spikes from a single extra detection.
+// Silences are only flagged for all species together, single species are
+// often absent for natural reasons.
+func classifyRate(observed int, baseline []int, config AnomalyConfig, overall bool) (kind AnomalyKind, expected, score float64) {
+	if len(baseline) == 0 {
+		return "", 0, 0
+	}
+	var sum float64
+	for _, n := range baseline {
+		sum += float64(n)
+	}
+	expected = sum / float64(len(baseline))
+
+	var variance float64
+	for _, n := range baseline {
+		variance += (float64(n) - expected) * (float64(n) - expected)
+	}
+	stdDev := max(math.Sqrt(variance/float64(len(baseline))), math.Sqrt(expected), 1)
+	score = (float64(observed) - expected) / stdDev
+
+	switch {
+	case score >= config.Threshold && observed >= config.MinSpike:
+		return AnomalySpike, expected, score
+	case overall && score <= -config.Threshold && expected >= config.MinExpected:
+		return AnomalySilence, expected, score
+	default:
+		return "", expected, score
+	}
+}
+
+// startOfHour returns the start of the local hour containing t
+func startOfHour(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+}
@@ -0,0 +1,146 @@
+package dataquality
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+var testAnomalyConfig = AnomalyConfig{BaselineDays: 14, Threshold: 3, MinSpike: 10, MinExpected: 5, Species: true}
+
+// fakeAnomalyStore returns stored hourly counts within the requested dates
+type fakeAnomalyStore struct {
+	counts []datastore.HourlySpeciesCount
+}
+
+func (s *fakeAnomalyStore) GetHourlySpeciesCounts(_ context.Context, startDate, endDate string) ([]datastore.HourlySpeciesCount, error) {
+	var result []datastore.HourlySpeciesCount
+	for _, c := range s.counts {
+		if c.Date >= startDate && c.Date <= endDate {
+			result = append(result, c)
+		}
+	}
+	return result, nil
+}
+
+// typicalDays adds days with 20 blackbird detections at 6 and 2 robin
+// detections at 7 ending the day before end
+func (s *fakeAnomalyStore) typicalDays(end time.Time, days int) {
+	for day := 1; day <= days; day++ {
+		date := end.AddDate(0, 0, -day).Format(time.DateOnly)
+		s.counts = append(s.counts,
+			datastore.HourlySpeciesCount{Date: date, Hour: 6, ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", Count: 18 + day%5},
+			datastore.HourlySpeciesCount{Date: date, Hour: 7, ScientificName: "Erithacus rubecula", CommonName: "European Robin", Count: 2},
+		)
+	}
+}
+
+func TestAnomalyDetector_Detect(t *testing.T) {
+	t.Parallel()
+
+	day := time.Date(2025, time.May, 20, 0, 0, 0, 0, time.Local)
+	store := &fakeAnomalyStore{}
+	store.typicalDays(day, 14)
+	// Blackbirds silent at 6 and a flock of waxwings at 7
+	store.counts = append(store.counts,
+		datastore.HourlySpeciesCount{Date: "2025-05-20", Hour: 7, ScientificName: "Erithacus rubecula", CommonName: "European Robin", Count: 3},
+		datastore.HourlySpeciesCount{Date: "2025-05-20", Hour: 7, ScientificName: "Bombycilla garrulus", CommonName: "Bohemian Waxwing", Count: 40},
+	)
+
+	detector := NewAnomalyDetector(testAnomalyConfig, store)
+	anomalies, err := detector.Detect(context.Background(), day, day.Add(24*time.Hour))
+	require.NoError(t, err)
+
+	require.Len(t, anomalies, 3)
+	assert.Equal(t, AnomalySilence, anomalies[0].Kind)
+	assert.Equal(t, 6, anomalies[0].Hour.Hour())
+	assert.Empty(t, anomalies[0].ScientificName, "silences should be flagged for all species together")
+	assert.Zero(t, anomalies[0].Count)
+	assert.InDelta(t, 20, anomalies[0].Expected, 0.5)
+
+	assert.Equal(t, AnomalySpike, anomalies[1].Kind)
+	assert.Equal(t, 7, anomalies[1].Hour.Hour())
+	assert.Empty(t, anomalies[1].ScientificName)
+
+	assert.Equal(t, AnomalySpike, anomalies[2].Kind)
+	assert.Equal(t, "Bombycilla garrulus", anomalies[2].ScientificName)
+	assert.Equal(t, "Bohemian Waxwing", anomalies[2].CommonName)
+	assert.Equal(t, 40, anomalies[2].Count)
+	assert.Greater(t, anomalies[2].Score, 3.0)
+}
+
+func TestAnomalyDetector_DetectWithoutBaseline(t *testing.T) {
+	t.Parallel()
+
+	day := time.Date(2025, time.May, 20, 0, 0, 0, 0, time.Local)
+	store := &fakeAnomalyStore{}
+	store.typicalDays(day, 3) // too few days to know the typical rates
+	store.counts = append(store.counts, datastore.HourlySpeciesCount{Date: "2025-05-20", Hour: 7, ScientificName: "Bombycilla garrulus", Count: 40})
+
+	anomalies, err := NewAnomalyDetector(testAnomalyConfig, store).Detect(context.Background(), day, day.Add(24*time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, anomalies)
+}
+
+func TestAnomalyDetector_CheckCompletedHours(t *testing.T) {
+	t.Parallel()
+
+	day := time.Date(2025, time.May, 20, 0, 0, 0, 0, time.Local)
+	store := &fakeAnomalyStore{}
+	store.typicalDays(day, 14)
+
+	now := day.Add(7*time.Hour + 5*time.Minute)
+	detector := NewAnomalyDetector(testAnomalyConfig, store)
+	detector.now = func() time.Time { return now }
+
+	// The first check covers the last completed hour, 6-7, when blackbirds were silent
+	anomalies, err := detector.CheckCompletedHours(context.Background())
+	require.NoError(t, err)
+	require.Len(t, anomalies, 1)
+	assert.Equal(t, AnomalySilence, anomalies[0].Kind)
+
+	// Hours are checked only once
+	now = now.Add(30 * time.Minute)
+	anomalies, err = detector.CheckCompletedHours(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, anomalies)
+
+	// Robins as usual at 7
+	store.counts = append(store.counts, datastore.HourlySpeciesCount{Date: "2025-05-20", Hour: 7, ScientificName: "Erithacus rubecula", Count: 2})
+	now = now.Add(time.Hour)
+	anomalies, err = detector.CheckCompletedHours(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, anomalies)
+}
+
+func TestClassifyRate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		observed int
+		baseline []int
+		overall  bool
+		want     AnomalyKind
+	}{
+		{name: "typical", observed: 21, baseline: []int{20, 22, 18, 19, 21}, overall: true},
+		{name: "spike", observed: 60, baseline: []int{20, 22, 18, 19, 21}, overall: true, want: AnomalySpike},
+		{name: "silence", observed: 0, baseline: []int{20, 22, 18, 19, 21}, overall: true, want: AnomalySilence},
+		{name: "species silence not flagged", observed: 0, baseline: []int{20, 22, 18, 19, 21}},
+		{name: "small spike below minimum", observed: 8, baseline: []int{0, 0, 1, 0, 0}},
+		{name: "rare species spike", observed: 12, baseline: []int{0, 0, 1, 0, 0}, want: AnomalySpike},
+		{name: "silence of a quiet hour not flagged", observed: 0, baseline: []int{3, 2, 4, 3, 3}, overall: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			kind, _, _ := classifyRate(tt.observed, tt.baseline, testAnomalyConfig, tt.overall)
+			assert.Equal(t, tt.want, kind)
+		})
+	}
+}
@@ -0,0 +1,152 @@
+// calibration.go: Tracking of calibration tone levels to notice input gain drift
+package dataquality
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/myaudio"
+)
+
+// calibrationCheckInterval is how often measured calibration tones are stored
+const calibrationCheckInterval = time.Minute
+
+// CalibrationStore is the datastore capability needed to track calibration tones
+type CalibrationStore interface {
+	SaveCalibrationMeasurement(measurement *datastore.CalibrationMeasurement) error
+	GetFirstCalibrationMeasurement(source string) (*datastore.CalibrationMeasurement, error)
+}
+
+// CalibrationConfig holds the calibration tone reference
+type CalibrationConfig struct {
+	ReferenceLevel float64 // expected tone level in dBFS, 0 to use the first measurement of each source
+	Tolerance      float64 // allowed drift in dB
+}
+
+// CalibrationConfigFromSettings returns the calibration tone reference of the configuration
+func CalibrationConfigFromSettings(settings *conf.CalibrationSettings) CalibrationConfig {
+	return CalibrationConfig{
+		ReferenceLevel: settings.ReferenceLevel,
+		Tolerance:      settings.Tolerance,
+	}
+}
+
+// CalibrationStatus is the calibration state of the input gain of one audio source
+type CalibrationStatus struct {
+	Source     string    `json:"source"`
+	Level      float64   `json:"level"`     // measured tone level in dBFS
+	Reference  float64   `json:"reference"` // expected tone level in dBFS
+	Drift      float64   `json:"drift"`     // difference from the reference in dB
+	Drifted    bool      `json:"drifted"`   // true when the drift exceeds the tolerance
+	MeasuredAt time.Time `json:"measuredAt"`
+}
+
+// CalibrationMonitor stores the calibration tones measured by myaudio and
+// watches their level for drift of the input gain
+type CalibrationMonitor struct {
+	config     CalibrationConfig
+	store      CalibrationStore
+	references map[string]float64 // reference level by source
+	drifted    map[string]bool    // last reported state by source
+
+	// Source of measured tones, replaceable in tests
+	take func() []myaudio.CalibrationMeasurement
+}
+
+// NewCalibrationMonitor creates a calibration tone monitor
+func NewCalibrationMonitor(config CalibrationConfig, store CalibrationStore) *CalibrationMonitor {
+	return &CalibrationMonitor{
+		config:     config,
+		store:      store,
+		references: make(map[string]float64),
+		drifted:    make(map[string]bool),
+		take:       myaudio.TakeCalibrationMeasurements,
+	}
+}
+
+// Run stores measured tones until ctx is cancelled. onChange is called for
+// each source whose gain drifted beyond or returned within the tolerance, or
+// with an error.
+func (m *CalibrationMonitor) Run(ctx context.Context, onChange func(*CalibrationStatus, error)) {
+	ticker := time.NewTicker(calibrationCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		changes, err := m.Check()
+		for i := range changes {
+			onChange(&changes[i], nil)
+		}
+		if err != nil {
+			onChange(nil, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check stores the tones measured since the previous check and returns the
+// sources whose drift state changed. The first source whose tone could not be
+// stored ends the check with an error.
+func (m *CalibrationMonitor) Check() ([]CalibrationStatus, error) {
+	var changed []CalibrationStatus
+	for _, measured := range m.take() {
+		reference, err := m.reference(measured.Source, measured.Level)
+		if err != nil {
+			return changed, err
+		}
+
+		drift := measured.Level - reference
+		if err := m.store.SaveCalibrationMeasurement(&datastore.CalibrationMeasurement{
+			Source:     measured.Source,
+			MeasuredAt: measured.Time,
+			Frequency:  measured.Frequency,
+			Level:      measured.Level,
+			Drift:      drift,
+		}); err != nil {
+			return changed, err
+		}
+
+		drifted := math.Abs(drift) > m.config.Tolerance
+		if drifted == m.drifted[measured.Source] {
+			continue
+		}
+		m.drifted[measured.Source] = drifted
+		changed = append(changed, CalibrationStatus{
+			Source:     measured.Source,
+			Level:      measured.Level,
+			Reference:  reference,
+			Drift:      drift,
+			Drifted:    drifted,
+			MeasuredAt: measured.Time,
+		})
+	}
+	return changed, nil
+}
+
+// reference returns the reference level of a source: the configured level, or
+// the first stored measurement of the source, or level when there is none yet
+func (m *CalibrationMonitor) reference(source string, level float64) (float64, error) {
+	if m.config.ReferenceLevel != 0 {
+		return m.config.ReferenceLevel, nil
+	}
+	if reference, ok := m.references[source]; ok {
+		return reference, nil
+	}
+
+	first, err := m.store.GetFirstCalibrationMeasurement(source)
+	switch {
+	case err == nil:
+		level = first.Level
+	case !isNotFound(err):
+		return 0, err
+	}
+	m.references[source] = level
+	return level, nil
+}
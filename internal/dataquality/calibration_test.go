@@ -0,0 +1,99 @@
+package dataquality
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/errors"
+	"github.com/tphakala/birdnet-go/internal/myaudio"
+)
+
+// calibrationMemoryStore keeps calibration measurements in memory
+type calibrationMemoryStore struct {
+	measurements []datastore.CalibrationMeasurement
+}
+
+func (s *calibrationMemoryStore) SaveCalibrationMeasurement(measurement *datastore.CalibrationMeasurement) error {
+	s.measurements = append(s.measurements, *measurement)
+	return nil
+}
+
+func (s *calibrationMemoryStore) GetFirstCalibrationMeasurement(source string) (*datastore.CalibrationMeasurement, error) {
+	for i := range s.measurements {
+		if s.measurements[i].Source == source {
+			return &s.measurements[i], nil
+		}
+	}
+	return nil, errors.Newf("no calibration measurement").Category(errors.CategoryNotFound).Build()
+}
+
+// testCalibrationMonitor returns a monitor taking the tones queued in pending
+func testCalibrationMonitor(config CalibrationConfig, store CalibrationStore, pending *[]myaudio.CalibrationMeasurement) *CalibrationMonitor {
+	m := NewCalibrationMonitor(config, store)
+	m.take = func() []myaudio.CalibrationMeasurement {
+		taken := *pending
+		*pending = nil
+		return taken
+	}
+	return m
+}
+
+func TestCalibrationMonitor_FirstMeasurementReference(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.Local)
+	tone := func(day int, level float64) myaudio.CalibrationMeasurement {
+		return myaudio.CalibrationMeasurement{Source: "mic", Time: start.AddDate(0, 0, day), Frequency: 1000, Level: level}
+	}
+
+	store := &calibrationMemoryStore{}
+	var pending []myaudio.CalibrationMeasurement
+	monitor := testCalibrationMonitor(CalibrationConfig{Tolerance: 3}, store, &pending)
+
+	pending = []myaudio.CalibrationMeasurement{tone(0, -20), tone(1, -21.5)}
+	changes, err := monitor.Check()
+	require.NoError(t, err)
+	assert.Empty(t, changes, "drift within tolerance should not be reported")
+	require.Len(t, store.measurements, 2)
+	assert.InDelta(t, 0, store.measurements[0].Drift, 1e-9)
+	assert.InDelta(t, -1.5, store.measurements[1].Drift, 1e-9)
+
+	pending = []myaudio.CalibrationMeasurement{tone(2, -24), tone(3, -24.5)}
+	changes, err = monitor.Check()
+	require.NoError(t, err)
+	require.Len(t, changes, 1, "drift should be reported once")
+	assert.True(t, changes[0].Drifted)
+	assert.InDelta(t, -4, changes[0].Drift, 1e-9)
+	assert.InDelta(t, -20, changes[0].Reference, 1e-9)
+
+	// A restarted monitor takes the reference from the stored measurements
+	restarted := testCalibrationMonitor(CalibrationConfig{Tolerance: 3}, store, &pending)
+	pending = []myaudio.CalibrationMeasurement{tone(4, -19)}
+	changes, err = restarted.Check()
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+	assert.InDelta(t, 1, store.measurements[len(store.measurements)-1].Drift, 1e-9)
+}
+
+func TestCalibrationMonitor_ConfiguredReference(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.Local)
+	var pending []myaudio.CalibrationMeasurement
+	monitor := testCalibrationMonitor(CalibrationConfig{ReferenceLevel: -20, Tolerance: 3}, &calibrationMemoryStore{}, &pending)
+
+	pending = []myaudio.CalibrationMeasurement{{Source: "mic", Time: now, Level: -16}}
+	changes, err := monitor.Check()
+	require.NoError(t, err)
+	require.Len(t, changes, 1, "the first tone should already be compared to the configured level")
+	assert.True(t, changes[0].Drifted)
+
+	pending = []myaudio.CalibrationMeasurement{{Source: "mic", Time: now.Add(time.Hour), Level: -21}}
+	changes, err = monitor.Check()
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.False(t, changes[0].Drifted, "a recovered gain should be reported")
+}
@@ -0,0 +1,123 @@
+// job.go: Daily generation and storage of data quality reports
+package dataquality
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// checkInterval is how often the job checks whether the previous day needs a report
+const checkInterval = 10 * time.Minute
+
+// Store is the datastore capability needed to persist reports
+type Store interface {
+	SaveDataQualityReport(report *datastore.DataQualityReport) error
+	GetDataQualityReport(date string) (*datastore.DataQualityReport, error)
+}
+
+// Save stores a report
+func Save(store Store, report *Report) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return errors.New(err).
+			Component("dataquality").
+			Category(errors.CategorySystem).
+			Context("operation", "encode_report").
+			Context("date", report.Date).
+			Build()
+	}
+	return store.SaveDataQualityReport(&datastore.DataQualityReport{
+		Date:      report.Date,
+		Flags:     strings.Join(report.Flags, ","),
+		Report:    string(data),
+		CreatedAt: report.GeneratedAt,
+	})
+}
+
+// Load returns the stored report of a date (YYYY-MM-DD)
+func Load(store Store, date string) (*Report, error) {
+	stored, err := store.GetDataQualityReport(date)
+	if err != nil {
+		return nil, err
+	}
+	var report Report
+	if err := json.Unmarshal([]byte(stored.Report), &report); err != nil {
+		return nil, errors.New(err).
+			Component("dataquality").
+			Category(errors.CategoryFileParsing).
+			Context("operation", "decode_report").
+			Context("date", date).
+			Build()
+	}
+	return &report, nil
+}
+
+// Job generates the report of each day shortly after midnight
+type Job struct {
+	builder *Builder
+	store   Store
+}
+
+// NewJob creates a daily report job
+func NewJob(builder *Builder, store Store) *Job {
+	return &Job{builder: builder, store: store}
+}
+
+// Run generates missing reports until ctx is cancelled. onReport is called
+// with each generated report or error and may be nil.
+func (j *Job) Run(ctx context.Context, onReport func(*Report, error)) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		report, err := j.ReportPreviousDay(ctx)
+		if onReport != nil && (report != nil || err != nil) {
+			onReport(report, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ReportPreviousDay generates and stores the report of the previous day unless
+// it already exists or no audio statistics were collected that day. It returns
+// the new report, or nil when none was generated.
+func (j *Job) ReportPreviousDay(ctx context.Context) (*Report, error) {
+	yesterday := j.builder.now().AddDate(0, 0, -1)
+	date := yesterday.Format(time.DateOnly)
+
+	_, err := j.store.GetDataQualityReport(date)
+	if err == nil {
+		return nil, nil
+	}
+	if !isNotFound(err) {
+		return nil, err
+	}
+
+	report, err := j.builder.Build(ctx, yesterday)
+	if errors.Is(err, ErrNoData) {
+		return nil, nil // Started today, nothing was monitored yesterday
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := Save(j.store, report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// isNotFound reports whether err is a not found error
+func isNotFound(err error) bool {
+	var enhanced *errors.EnhancedError
+	return errors.As(err, &enhanced) && enhanced.Category == errors.CategoryNotFound
+}
@@ -0,0 +1,170 @@
+// micfailure.go: Detection of failed microphones from the spectrum of their audio
+package dataquality
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/myaudio"
+)
+
+// MicFailure is the kind of failure of a microphone
+type MicFailure string
+
+// Microphone failures
+const (
+	MicFlatline    MicFailure = "flatline"     // no signal at all, not even the self-noise of a quiet microphone
+	MicConstantHum MicFailure = "constant_hum" // nothing but mains hum or a single tone
+	MicNoAudio     MicFailure = "no_audio"     // the source stopped delivering audio
+)
+
+const (
+	// micCheckInterval is how often microphones are checked
+	micCheckInterval = time.Minute
+	// flatlineLevel is the level in dBFS below which audio counts as a flatline.
+	// Even in silence a working microphone produces self-noise above the
+	// 16-bit quantization level of about -96 dBFS.
+	flatlineLevel = -100.0
+	// tonalFlatness is the spectral flatness below which audio counts as a single
+	// tone; birdsong is tonal too but does not last a whole window
+	tonalFlatness = 0.01
+	// micFailureShare is the share of the measured minutes of the window that
+	// must show a failure
+	micFailureShare = 0.9
+	// micLookback is how far back a source that stopped delivering audio is remembered
+	micLookback = 24 * time.Hour
+)
+
+// MicConfig holds the microphone failure thresholds
+type MicConfig struct {
+	Window   time.Duration
+	HumRatio float64 // share of power at mains hum frequencies counted as hum
+}
+
+// MicConfigFromSettings returns the microphone failure thresholds of the configuration
+func MicConfigFromSettings(settings *conf.MicFailureSettings) MicConfig {
+	return MicConfig{
+		Window:   time.Duration(settings.WindowMinutes) * time.Minute,
+		HumRatio: settings.HumPercent / 100,
+	}
+}
+
+// MicStatus is the state of the microphone of one audio source
+type MicStatus struct {
+	Source  string     `json:"source"`
+	Failure MicFailure `json:"failure,omitempty"` // empty when the microphone works
+	Detail  string     `json:"detail,omitempty"`
+	Since   time.Time  `json:"since"`
+}
+
+// MicMonitor watches the audio statistics collected by myaudio for signs of a
+// failed microphone
+type MicMonitor struct {
+	config MicConfig
+	status map[string]MicStatus // last reported state by source
+
+	// Source of audio statistics and the current time, replaceable in tests
+	minutes func(from, to time.Time) map[string][]myaudio.AudioQualityMinute
+	now     func() time.Time
+}
+
+// NewMicMonitor creates a microphone monitor
+func NewMicMonitor(config MicConfig) *MicMonitor {
+	return &MicMonitor{
+		config:  config,
+		status:  make(map[string]MicStatus),
+		minutes: myaudio.AudioQualityMinutes,
+		now:     time.Now,
+	}
+}
+
+// Run checks the microphones until ctx is cancelled. onChange is called for
+// each source that failed or recovered.
+func (m *MicMonitor) Run(ctx context.Context, onChange func(MicStatus)) {
+	ticker := time.NewTicker(micCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		for _, status := range m.Check() {
+			onChange(status)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check classifies the microphone of every source over the last window and
+// returns the sources whose state changed since the previous check
+func (m *MicMonitor) Check() []MicStatus {
+	to := m.now().Truncate(time.Minute) // the current minute is incomplete
+	windowStart := to.Add(-m.config.Window)
+
+	var changed []MicStatus
+	for source, minutes := range m.minutes(to.Add(-micLookback), to) {
+		failure, detail, since := m.classify(minutes, windowStart)
+		if failure == "" {
+			since = to
+		}
+		previous, known := m.status[source]
+		if previous.Failure == failure && (known || failure == "") {
+			continue
+		}
+		status := MicStatus{Source: source, Failure: failure, Detail: detail, Since: since}
+		m.status[source] = status
+		changed = append(changed, status)
+	}
+	return changed
+}
+
+// classify returns the failure shown by the minutes of a source since
+// windowStart, with a description and when it started, or an empty failure
+// when the microphone works
+func (m *MicMonitor) classify(minutes []myaudio.AudioQualityMinute, windowStart time.Time) (failure MicFailure, detail string, since time.Time) {
+	var recent []myaudio.AudioQualityMinute
+	for i := range minutes {
+		if !minutes[i].Start.Before(windowStart) {
+			recent = append(recent, minutes[i])
+		}
+	}
+	if len(recent) == 0 {
+		if len(minutes) == 0 {
+			return "", "", time.Time{}
+		}
+		last := minutes[len(minutes)-1].Start.Add(time.Minute)
+		return MicNoAudio, fmt.Sprintf("no audio received since %s", last.Format(time.DateTime)), last
+	}
+
+	var measured, flat, hum int
+	for i := range recent {
+		s := recent[i].Spectrum
+		if s == nil {
+			continue
+		}
+		measured++
+		switch {
+		case s.ACdBFS < flatlineLevel:
+			flat++
+		case s.HumRatio >= m.config.HumRatio || s.Flatness < tonalFlatness:
+			hum++
+		}
+	}
+
+	// Judge only windows mostly covered by audio, gaps are reported by the daily report
+	if measured*2 < int(m.config.Window/time.Minute) {
+		return "", "", time.Time{}
+	}
+	threshold := micFailureShare * float64(measured)
+	switch {
+	case float64(flat) >= threshold:
+		return MicFlatline, fmt.Sprintf("no signal in %d of %d minutes, a working microphone always produces some noise", flat, measured), windowStart
+	case float64(flat+hum) >= threshold:
+		return MicConstantHum, fmt.Sprintf("only hum or a single tone in %d of %d minutes", flat+hum, measured), windowStart
+	}
+	return "", "", time.Time{}
+}
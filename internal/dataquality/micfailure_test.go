@@ -0,0 +1,106 @@
+package dataquality
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/myaudio"
+)
+
+var testMicConfig = MicConfig{Window: 30 * time.Minute, HumRatio: 0.8}
+
+// spectrumMinute returns one minute of audio statistics with the given spectrum
+func spectrumMinute(start time.Time, spectrum myaudio.SpectralStats) myaudio.AudioQualityMinute {
+	m := minute(start, spectrum.ACdBFS, 0)
+	m.Spectrum = &spectrum
+	return m
+}
+
+var (
+	quietSpectrum    = myaudio.SpectralStats{ACdBFS: -75, Flatness: 0.4, HumRatio: 0.02}
+	flatlineSpectrum = myaudio.SpectralStats{ACdBFS: math.Inf(-1)}
+	humSpectrum      = myaudio.SpectralStats{ACdBFS: -50, Flatness: 0.001, HumRatio: 0.95}
+)
+
+// testMicMonitor returns a monitor reading minutes from audio at the time returned by now
+func testMicMonitor(audio map[string][]myaudio.AudioQualityMinute, now *time.Time) *MicMonitor {
+	m := NewMicMonitor(testMicConfig)
+	m.minutes = func(from, to time.Time) map[string][]myaudio.AudioQualityMinute {
+		result := make(map[string][]myaudio.AudioQualityMinute, len(audio))
+		for source, minutes := range audio {
+			var selected []myaudio.AudioQualityMinute
+			for _, minute := range minutes {
+				if !minute.Start.Before(from) && minute.Start.Before(to) {
+					selected = append(selected, minute)
+				}
+			}
+			result[source] = selected
+		}
+		return result
+	}
+	m.now = func() time.Time { return *now }
+	return m
+}
+
+func TestMicMonitor_Check(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local)
+	appendMinutes := func(minutes []myaudio.AudioQualityMinute, from time.Time, count int, spectrum myaudio.SpectralStats) []myaudio.AudioQualityMinute {
+		for i := range count {
+			minutes = append(minutes, spectrumMinute(from.Add(time.Duration(i)*time.Minute), spectrum))
+		}
+		return minutes
+	}
+
+	// A quiet hour, a dead hour, then a working microphone again
+	var mic []myaudio.AudioQualityMinute
+	mic = appendMinutes(mic, start, 60, quietSpectrum)
+	mic = appendMinutes(mic, start.Add(time.Hour), 60, flatlineSpectrum)
+	mic = appendMinutes(mic, start.Add(2*time.Hour), 60, quietSpectrum)
+	// A line input picking up nothing but hum
+	hum := appendMinutes(nil, start, 180, humSpectrum)
+	// A stream that stopped after an hour
+	stream := appendMinutes(nil, start, 60, quietSpectrum)
+
+	now := start.Add(50 * time.Minute)
+	monitor := testMicMonitor(map[string][]myaudio.AudioQualityMinute{"mic": mic, "hum": hum, "stream": stream}, &now)
+
+	changes := monitor.Check()
+	require.Len(t, changes, 1, "healthy microphones should not be reported")
+	assert.Equal(t, "hum", changes[0].Source)
+	assert.Equal(t, MicConstantHum, changes[0].Failure)
+
+	now = start.Add(time.Hour + 40*time.Minute)
+	byStatus := make(map[string]MicStatus)
+	for _, status := range monitor.Check() {
+		byStatus[status.Source] = status
+	}
+	require.Len(t, byStatus, 2, "a failure should be reported once")
+	assert.Equal(t, MicFlatline, byStatus["mic"].Failure)
+	assert.Equal(t, MicNoAudio, byStatus["stream"].Failure)
+	assert.Equal(t, start.Add(time.Hour), byStatus["stream"].Since)
+
+	now = start.Add(2*time.Hour + 40*time.Minute)
+	changes = monitor.Check()
+	require.Len(t, changes, 1)
+	assert.Equal(t, "mic", changes[0].Source)
+	assert.Empty(t, changes[0].Failure, "a recovered microphone should be reported")
+}
+
+func TestMicMonitor_IgnoresSparseAudio(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local)
+	var minutes []myaudio.AudioQualityMinute
+	for i := range 10 {
+		minutes = append(minutes, spectrumMinute(start.Add(time.Duration(i)*time.Minute), flatlineSpectrum))
+	}
+	now := start.Add(30 * time.Minute)
+	monitor := testMicMonitor(map[string][]myaudio.AudioQualityMinute{"mic": minutes}, &now)
+
+	assert.Empty(t, monitor.Check(), "a window mostly without audio should not be judged")
+}
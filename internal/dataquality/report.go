@@ -0,0 +1,305 @@
+// Package dataquality builds daily data quality reports that flag gaps in
+// audio coverage, silent microphones, clipped audio and abnormal detection
+// rates, so problems with a station are noticed before days of data are lost.
+package dataquality
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/errors"
+	"github.com/tphakala/birdnet-go/internal/myaudio"
+)
+
+// Flags raised by a report
+const (
+	FlagCoverageGap       = "coverage_gap"
+	FlagSilentMicrophone  = "silent_microphone"
+	FlagClipping          = "clipping"
+	FlagLowDetectionRate  = "low_detection_rate"
+	FlagHighDetectionRate = "high_detection_rate"
+)
+
+// minBaseline is the smallest average number of daily detections the detection
+// rate is compared against; quieter stations vary too much day to day
+const minBaseline = 10
+
+// ErrNoData is returned when no audio statistics cover the requested day
+var ErrNoData = errors.NewStd("no audio statistics are available for the day")
+
+// Config holds the report thresholds
+type Config struct {
+	GapMinutes       int
+	SilenceThreshold float64 // dBFS
+	SilentMinutes    int
+	ClippingPercent  float64
+	RateLowPercent   int
+	RateHighPercent  int
+	BaselineDays     int
+}
+
+// ConfigFromSettings returns the report thresholds of the configuration
+func ConfigFromSettings(settings *conf.DataQualitySettings) Config {
+	return Config{
+		GapMinutes:       settings.GapMinutes,
+		SilenceThreshold: settings.SilenceThreshold,
+		SilentMinutes:    settings.SilentMinutes,
+		ClippingPercent:  settings.ClippingPercent,
+		RateLowPercent:   settings.RateLowPercent,
+		RateHighPercent:  settings.RateHighPercent,
+		BaselineDays:     settings.BaselineDays,
+	}
+}
+
+// Period is a run of consecutive minutes
+type Period struct {
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+	Minutes int       `json:"minutes"`
+}
+
+// SourceReport holds the audio quality of one audio source
+type SourceReport struct {
+	Source          string   `json:"source"`
+	CoverageMinutes int      `json:"coverageMinutes"` // minutes with audio
+	CoveragePercent float64  `json:"coveragePercent"` // of the monitored minutes
+	Gaps            []Period `json:"gaps"`            // periods without audio
+	SilentPeriods   []Period `json:"silentPeriods"`   // periods of audio below the silence threshold
+	ClippedPercent  float64  `json:"clippedPercent"`  // of the samples received
+}
+
+// Report is the data quality report of a day
+type Report struct {
+	Date               string         `json:"date"`
+	Partial            bool           `json:"partial"`          // the day is not over or was not monitored from midnight
+	MonitoredMinutes   int            `json:"monitoredMinutes"` // minutes of the day covered by the report
+	Sources            []SourceReport `json:"sources"`
+	Detections         int            `json:"detections"`
+	BaselineDetections float64        `json:"baselineDetections"` // average daily detections of the previous days, 0 when unknown
+	Flags              []string       `json:"flags"`
+	GeneratedAt        time.Time      `json:"generatedAt"`
+}
+
+// Summary returns a one-line summary of the report, for digests and logs
+func (r *Report) Summary() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s:", r.Date)
+	for i := range r.Sources {
+		s := &r.Sources[i]
+		fmt.Fprintf(&b, " %s %.1f%% audio coverage, %.2f%% clipped;", s.Source, s.CoveragePercent, s.ClippedPercent)
+	}
+	fmt.Fprintf(&b, " %d detections", r.Detections)
+	if r.BaselineDetections > 0 {
+		fmt.Fprintf(&b, " (baseline %.0f)", r.BaselineDetections)
+	}
+	if len(r.Flags) == 0 {
+		b.WriteString("; no issues")
+	} else {
+		fmt.Fprintf(&b, "; issues: %s", strings.Join(r.Flags, ", "))
+	}
+	return b.String()
+}
+
+// DetectionCounter is the datastore capability needed to count daily detections
+type DetectionCounter interface {
+	GetDailyAnalyticsData(ctx context.Context, startDate, endDate, species string) ([]datastore.DailyAnalyticsData, error)
+}
+
+// Builder builds reports from the audio statistics collected by myaudio
+type Builder struct {
+	config  Config
+	counter DetectionCounter
+
+	// Sources of audio statistics and the current time, replaceable in tests
+	minutes      func(from, to time.Time) map[string][]myaudio.AudioQualityMinute
+	trackedSince func() time.Time
+	now          func() time.Time
+}
+
+// NewBuilder creates a report builder
+func NewBuilder(config Config, counter DetectionCounter) *Builder {
+	return &Builder{
+		config:       config,
+		counter:      counter,
+		minutes:      myaudio.AudioQualityMinutes,
+		trackedSince: myaudio.AudioQualityTrackedSince,
+		now:          time.Now,
+	}
+}
+
+// Build builds the report of the day containing day, in local time. The
+// report of the current day covers the day so far and is marked partial.
+func (b *Builder) Build(ctx context.Context, day time.Time) (*Report, error) {
+	now := b.now()
+	dayStart := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, now.Location())
+	dayEnd := dayStart.AddDate(0, 0, 1)
+
+	from := dayStart
+	if since := b.trackedSince().Truncate(time.Minute); since.After(from) {
+		from = since
+	}
+	to := dayEnd
+	if current := now.Truncate(time.Minute); current.Before(to) {
+		to = current
+	}
+	if !to.After(from) {
+		return nil, ErrNoData
+	}
+
+	report := &Report{
+		Date:             dayStart.Format(time.DateOnly),
+		Partial:          from.After(dayStart) || to.Before(dayEnd),
+		MonitoredMinutes: int(to.Sub(from) / time.Minute),
+		GeneratedAt:      now,
+	}
+
+	minutes := b.minutes(from, to)
+	sources := make([]string, 0, len(minutes))
+	for source := range minutes {
+		sources = append(sources, source)
+	}
+	slices.Sort(sources)
+	for _, source := range sources {
+		report.Sources = append(report.Sources, b.sourceReport(source, minutes[source], from, to))
+	}
+
+	if err := b.addDetectionRate(ctx, report, dayStart); err != nil {
+		return nil, err
+	}
+	b.flag(report)
+	return report, nil
+}
+
+// sourceReport analyzes the minutes of one source between from and to
+func (b *Builder) sourceReport(source string, minutes []myaudio.AudioQualityMinute, from, to time.Time) SourceReport {
+	report := SourceReport{Source: source, Gaps: []Period{}, SilentPeriods: []Period{}}
+	byStart := make(map[time.Time]*myaudio.AudioQualityMinute, len(minutes))
+	for i := range minutes {
+		byStart[minutes[i].Start] = &minutes[i]
+	}
+
+	var samples, clipped int64
+	var gapStart, silentStart time.Time
+	closeRun := func(start *time.Time, end time.Time, minLength int, periods *[]Period) {
+		if start.IsZero() {
+			return
+		}
+		if length := int(end.Sub(*start) / time.Minute); length >= minLength {
+			*periods = append(*periods, Period{Start: *start, End: end, Minutes: length})
+		}
+		*start = time.Time{}
+	}
+
+	for t := from; t.Before(to); t = t.Add(time.Minute) {
+		m, ok := byStart[t]
+		if !ok {
+			closeRun(&silentStart, t, b.config.SilentMinutes, &report.SilentPeriods)
+			if gapStart.IsZero() {
+				gapStart = t
+			}
+			continue
+		}
+
+		closeRun(&gapStart, t, b.config.GapMinutes, &report.Gaps)
+		report.CoverageMinutes++
+		samples += m.Samples
+		clipped += m.Clipped
+		if m.RMSdBFS() < b.config.SilenceThreshold {
+			if silentStart.IsZero() {
+				silentStart = t
+			}
+		} else {
+			closeRun(&silentStart, t, b.config.SilentMinutes, &report.SilentPeriods)
+		}
+	}
+	closeRun(&gapStart, to, b.config.GapMinutes, &report.Gaps)
+	closeRun(&silentStart, to, b.config.SilentMinutes, &report.SilentPeriods)
+
+	if total := int(to.Sub(from) / time.Minute); total > 0 {
+		report.CoveragePercent = round2(float64(report.CoverageMinutes) * 100 / float64(total))
+	}
+	if samples > 0 {
+		report.ClippedPercent = round2(float64(clipped) * 100 / float64(samples))
+	}
+	return report
+}
+
+// addDetectionRate adds the detections of the day and the baseline of the previous days
+func (b *Builder) addDetectionRate(ctx context.Context, report *Report, dayStart time.Time) error {
+	if b.counter == nil {
+		return nil
+	}
+
+	baselineStart := dayStart.AddDate(0, 0, -b.config.BaselineDays).Format(time.DateOnly)
+	counts, err := b.counter.GetDailyAnalyticsData(ctx, baselineStart, report.Date, "")
+	if err != nil {
+		return err
+	}
+
+	var baselineTotal, baselineDays int
+	for _, day := range counts {
+		if day.Date == report.Date {
+			report.Detections = day.Count
+			continue
+		}
+		// Days without detections are left out, the station was likely not running
+		if day.Count > 0 {
+			baselineTotal += day.Count
+			baselineDays++
+		}
+	}
+	// Require detections on at least half of the baseline days for a meaningful average
+	if baselineDays > 0 && baselineDays*2 >= b.config.BaselineDays {
+		report.BaselineDetections = round2(float64(baselineTotal) / float64(baselineDays))
+	}
+	return nil
+}
+
+// flag sets the flags of the report
+func (b *Builder) flag(report *Report) {
+	report.Flags = []string{}
+	add := func(flag string) {
+		if !slices.Contains(report.Flags, flag) {
+			report.Flags = append(report.Flags, flag)
+		}
+	}
+
+	if len(report.Sources) == 0 && report.MonitoredMinutes >= b.config.GapMinutes {
+		add(FlagCoverageGap) // No audio from any source
+	}
+	for i := range report.Sources {
+		s := &report.Sources[i]
+		if len(s.Gaps) > 0 {
+			add(FlagCoverageGap)
+		}
+		if len(s.SilentPeriods) > 0 {
+			add(FlagSilentMicrophone)
+		}
+		if s.ClippedPercent > b.config.ClippingPercent {
+			add(FlagClipping)
+		}
+	}
+
+	// A partial day cannot be compared with full days
+	if report.Partial || report.BaselineDetections < minBaseline {
+		return
+	}
+	detections := float64(report.Detections) * 100
+	switch {
+	case detections < report.BaselineDetections*float64(b.config.RateLowPercent):
+		add(FlagLowDetectionRate)
+	case detections > report.BaselineDetections*float64(b.config.RateHighPercent):
+		add(FlagHighDetectionRate)
+	}
+}
+
+// round2 rounds to two decimals
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,187 @@
+package dataquality
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/errors"
+	"github.com/tphakala/birdnet-go/internal/myaudio"
+)
+
+var testConfig = Config{
+	GapMinutes:       5,
+	SilenceThreshold: -70,
+	SilentMinutes:    30,
+	ClippingPercent:  0.1,
+	RateLowPercent:   25,
+	RateHighPercent:  400,
+	BaselineDays:     7,
+}
+
+// fixedCounter returns fixed daily detection counts
+type fixedCounter []datastore.DailyAnalyticsData
+
+func (f fixedCounter) GetDailyAnalyticsData(_ context.Context, _, _, _ string) ([]datastore.DailyAnalyticsData, error) {
+	return f, nil
+}
+
+// memoryStore keeps reports in memory
+type memoryStore map[string]*datastore.DataQualityReport
+
+func (m memoryStore) SaveDataQualityReport(report *datastore.DataQualityReport) error {
+	m[report.Date] = report
+	return nil
+}
+
+func (m memoryStore) GetDataQualityReport(date string) (*datastore.DataQualityReport, error) {
+	if report, ok := m[date]; ok {
+		return report, nil
+	}
+	return nil, errors.Newf("data quality report for %s not found", date).
+		Component("dataquality").
+		Category(errors.CategoryNotFound).
+		Build()
+}
+
+// minute returns one minute of audio statistics at the given RMS level and clipping ratio
+func minute(start time.Time, rmsDB, clippedRatio float64) myaudio.AudioQualityMinute {
+	const samples = 48000 * 60
+	power := math.Pow(10, rmsDB/10)
+	return myaudio.AudioQualityMinute{
+		Start:      start,
+		Samples:    samples,
+		Clipped:    int64(clippedRatio * samples),
+		SumSquares: power * samples,
+	}
+}
+
+// testBuilder returns a builder for 2024-05-01 with the given minutes, monitored since midnight
+func testBuilder(minutes []myaudio.AudioQualityMinute, counter DetectionCounter, now time.Time) *Builder {
+	b := NewBuilder(testConfig, counter)
+	b.minutes = func(from, to time.Time) map[string][]myaudio.AudioQualityMinute {
+		var selected []myaudio.AudioQualityMinute
+		for _, m := range minutes {
+			if !m.Start.Before(from) && m.Start.Before(to) {
+				selected = append(selected, m)
+			}
+		}
+		return map[string][]myaudio.AudioQualityMinute{"mic": selected}
+	}
+	b.trackedSince = func() time.Time { return time.Date(2024, 4, 20, 0, 0, 0, 0, time.Local) }
+	b.now = func() time.Time { return now }
+	return b
+}
+
+func TestBuild_FlagsGapsSilenceAndClipping(t *testing.T) {
+	t.Parallel()
+
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local)
+	var minutes []myaudio.AudioQualityMinute
+	for i := range 24 * 60 {
+		start := day.Add(time.Duration(i) * time.Minute)
+		switch {
+		case i >= 60 && i < 70: // 10 minute gap
+			continue
+		case i >= 120 && i < 180: // an hour of silence
+			minutes = append(minutes, minute(start, -90, 0))
+		case i == 600: // one heavily clipped minute
+			minutes = append(minutes, minute(start, -10, 0.5))
+		default:
+			minutes = append(minutes, minute(start, -40, 0))
+		}
+	}
+
+	counter := fixedCounter{{Date: "2024-04-30", Count: 100}, {Date: "2024-04-29", Count: 100}, {Date: "2024-04-28", Count: 100}, {Date: "2024-04-27", Count: 100}, {Date: "2024-05-01", Count: 110}}
+	report, err := testBuilder(minutes, counter, day.AddDate(0, 0, 1).Add(5*time.Minute)).Build(context.Background(), day)
+	require.NoError(t, err)
+
+	assert.Equal(t, "2024-05-01", report.Date)
+	assert.False(t, report.Partial)
+	require.Len(t, report.Sources, 1)
+	source := report.Sources[0]
+	assert.Equal(t, 24*60-10, source.CoverageMinutes)
+	require.Len(t, source.Gaps, 1)
+	assert.Equal(t, 10, source.Gaps[0].Minutes)
+	assert.Equal(t, day.Add(60*time.Minute), source.Gaps[0].Start)
+	require.Len(t, source.SilentPeriods, 1)
+	assert.Equal(t, 60, source.SilentPeriods[0].Minutes)
+	assert.InDelta(t, 0.03, source.ClippedPercent, 0.001, "half of one minute out of 1430, rounded to two decimals")
+
+	assert.Equal(t, 110, report.Detections)
+	assert.InDelta(t, 100, report.BaselineDetections, 0.01)
+	assert.Equal(t, []string{FlagCoverageGap, FlagSilentMicrophone}, report.Flags,
+		"clipping below the threshold and a normal detection rate should not be flagged")
+	assert.Contains(t, report.Summary(), "coverage_gap")
+}
+
+func TestBuild_DetectionRate(t *testing.T) {
+	t.Parallel()
+
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local)
+	var minutes []myaudio.AudioQualityMinute
+	for i := range 24 * 60 {
+		minutes = append(minutes, minute(day.Add(time.Duration(i)*time.Minute), -40, 0))
+	}
+	baseline := fixedCounter{{Date: "2024-04-30", Count: 200}, {Date: "2024-04-29", Count: 200}, {Date: "2024-04-28", Count: 200}, {Date: "2024-04-27", Count: 200}}
+	afterDay := day.AddDate(0, 0, 1).Add(time.Hour)
+
+	tests := []struct {
+		name      string
+		counter   fixedCounter
+		now       time.Time
+		wantFlags []string
+	}{
+		{"normal", append(baseline, datastore.DailyAnalyticsData{Date: "2024-05-01", Count: 180}), afterDay, []string{}},
+		{"low", append(baseline, datastore.DailyAnalyticsData{Date: "2024-05-01", Count: 20}), afterDay, []string{FlagLowDetectionRate}},
+		{"high", append(baseline, datastore.DailyAnalyticsData{Date: "2024-05-01", Count: 1000}), afterDay, []string{FlagHighDetectionRate}},
+		{"partial day is not compared", append(baseline, datastore.DailyAnalyticsData{Date: "2024-05-01", Count: 20}), day.Add(12 * time.Hour), []string{}},
+		{"too little history", fixedCounter{{Date: "2024-04-30", Count: 200}, {Date: "2024-05-01", Count: 20}}, afterDay, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			report, err := testBuilder(minutes, tt.counter, tt.now).Build(context.Background(), day)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantFlags, report.Flags)
+		})
+	}
+}
+
+func TestBuild_NoData(t *testing.T) {
+	t.Parallel()
+
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local)
+	b := testBuilder(nil, nil, day.AddDate(0, 0, 3))
+	b.trackedSince = func() time.Time { return day.AddDate(0, 0, 2) }
+
+	_, err := b.Build(context.Background(), day)
+	assert.ErrorIs(t, err, ErrNoData)
+}
+
+func TestJob_ReportPreviousDayOnce(t *testing.T) {
+	t.Parallel()
+
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local)
+	minutes := []myaudio.AudioQualityMinute{minute(day, -40, 0)}
+	store := memoryStore{}
+	job := NewJob(testBuilder(minutes, fixedCounter{}, day.AddDate(0, 0, 1).Add(5*time.Minute)), store)
+
+	report, err := job.ReportPreviousDay(context.Background())
+	require.NoError(t, err)
+	require.NotNil(t, report)
+	assert.Contains(t, report.Flags, FlagCoverageGap)
+
+	stored, err := Load(store, "2024-05-01")
+	require.NoError(t, err)
+	assert.Equal(t, report.Flags, stored.Flags)
+	assert.Equal(t, "coverage_gap", store["2024-05-01"].Flags)
+
+	report, err = job.ReportPreviousDay(context.Background())
+	require.NoError(t, err)
+	assert.Nil(t, report, "an existing report should not be regenerated")
+}
@@ -77,6 +77,11 @@ func (ds *DataStore) GetSpeciesSummaryData(ctx context.Context, startDate, endDa
 			"end_date", endDate)
 	}
 
+	// Use the materialized daily aggregates when they are built
+	if ds.useDailyAggregates(ctx) {
+		return ds.speciesSummaryFromAggregates(ctx, startDate, endDate)
+	}
+
 	// Get database-specific datetime formatting
 	// TODO: Consider using GetDateTimeExpr("notes.date", "notes.time") for future JOIN support
 	dateTimeFormat := ds.GetDateTimeFormat()
@@ -292,6 +297,11 @@ func (ds *DataStore) GetHourlyAnalyticsData(ctx context.Context, date, species s
 
 // GetDailyAnalyticsData retrieves detection counts grouped by day
 func (ds *DataStore) GetDailyAnalyticsData(ctx context.Context, startDate, endDate, species string) ([]DailyAnalyticsData, error) {
+	// Use the materialized daily aggregates when they are built
+	if ds.useDailyAggregates(ctx) {
+		return ds.dailyAnalyticsFromAggregates(ctx, startDate, endDate, species)
+	}
+
 	var analytics []DailyAnalyticsData
 
 	// Base query
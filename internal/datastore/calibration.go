@@ -0,0 +1,56 @@
+// calibration.go: Storage of calibration tone measurements
+package datastore
+
+import (
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/errors"
+	"gorm.io/gorm"
+)
+
+// SaveCalibrationMeasurement stores the measured level of a calibration tone
+func (ds *DataStore) SaveCalibrationMeasurement(measurement *CalibrationMeasurement) error {
+	if measurement.Source == "" {
+		return validationError("calibration source cannot be empty", "source", measurement.Source)
+	}
+
+	if err := ds.DB.Create(measurement).Error; err != nil {
+		return dbError(err, "save_calibration_measurement", errors.PriorityLow,
+			"source", measurement.Source,
+			"table", "calibration_measurements",
+			"action", "store_calibration_tone_level")
+	}
+	return nil
+}
+
+// GetCalibrationMeasurements returns the measurements since a time in
+// ascending order, of all sources when source is empty
+func (ds *DataStore) GetCalibrationMeasurements(source string, since time.Time) ([]CalibrationMeasurement, error) {
+	query := ds.DB.Where("measured_at >= ?", since)
+	if source != "" {
+		query = query.Where("source = ?", source)
+	}
+
+	var measurements []CalibrationMeasurement
+	if err := query.Order("measured_at ASC").Find(&measurements).Error; err != nil {
+		return nil, dbError(err, "get_calibration_measurements", errors.PriorityLow,
+			"source", source,
+			"table", "calibration_measurements")
+	}
+	return measurements, nil
+}
+
+// GetFirstCalibrationMeasurement returns the earliest measurement of a source
+func (ds *DataStore) GetFirstCalibrationMeasurement(source string) (*CalibrationMeasurement, error) {
+	var measurement CalibrationMeasurement
+	err := ds.DB.Where("source = ?", source).Order("measured_at ASC").First(&measurement).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, notFoundError("calibration measurement", source)
+	}
+	if err != nil {
+		return nil, dbError(err, "get_first_calibration_measurement", errors.PriorityLow,
+			"source", source,
+			"table", "calibration_measurements")
+	}
+	return &measurement, nil
+}
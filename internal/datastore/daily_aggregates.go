@@ -0,0 +1,332 @@
+// daily_aggregates.go: Materialized per-day species statistics
+package datastore
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/errors"
+	"gorm.io/gorm"
+)
+
+// dailyAggregateBatchSize is the number of daily aggregates inserted per statement
+const dailyAggregateBatchSize = 200
+
+// dailyAggregateState tracks whether the materialized daily aggregates are
+// built and which days changed since they were last updated
+type dailyAggregateState struct {
+	refresh sync.Mutex // serializes refreshes so that an older one cannot overwrite a newer one
+	mu      sync.Mutex
+	ready   bool                // aggregates are built and can answer queries
+	rebuild bool                // all days are outdated
+	dirty   map[string]struct{} // outdated days
+}
+
+// markDailyAggregatesDirty marks the aggregates of the given days as outdated
+func (ds *DataStore) markDailyAggregatesDirty(dates ...string) {
+	ds.aggregates.mu.Lock()
+	defer ds.aggregates.mu.Unlock()
+	if ds.aggregates.dirty == nil {
+		ds.aggregates.dirty = make(map[string]struct{})
+	}
+	for _, date := range dates {
+		if date != "" {
+			ds.aggregates.dirty[date] = struct{}{}
+		}
+	}
+}
+
+// markAllDailyAggregatesDirty marks the aggregates of all days as outdated
+func (ds *DataStore) markAllDailyAggregatesDirty() {
+	ds.aggregates.mu.Lock()
+	defer ds.aggregates.mu.Unlock()
+	ds.aggregates.rebuild = true
+}
+
+// noteAggregateDates returns the days whose daily aggregates are outdated by an
+// update of a note, nil when the update changes no aggregated field. Called
+// before the update, the days are marked once the update is done.
+func (ds *DataStore) noteAggregateDates(id string, updates map[string]interface{}) []string {
+	affected := false
+	for _, field := range []string{"date", "time", "scientific_name", "common_name", "species_code", "confidence"} {
+		if _, ok := updates[field]; ok {
+			affected = true
+			break
+		}
+	}
+	if !affected {
+		return nil
+	}
+
+	var dates []string
+	if err := ds.DB.Model(&Note{}).Where("id = ?", id).Pluck("date", &dates).Error; err != nil {
+		ds.markAllDailyAggregatesDirty()
+	}
+	if date, ok := updates["date"].(string); ok {
+		dates = append(dates, date)
+	}
+	return dates
+}
+
+// BuildDailyAggregates rebuilds the daily aggregates of all days from the
+// detections and detection aggregates. Analytics queries use the daily
+// aggregates instead of scanning detections once they are built.
+func (ds *DataStore) BuildDailyAggregates(ctx context.Context) error {
+	ds.markAllDailyAggregatesDirty()
+	if err := ds.RefreshDailyAggregates(ctx); err != nil {
+		return err
+	}
+	ds.aggregates.mu.Lock()
+	ds.aggregates.ready = true
+	ds.aggregates.mu.Unlock()
+	return nil
+}
+
+// RefreshDailyAggregates updates the daily aggregates of the days changed
+// since the previous refresh
+func (ds *DataStore) RefreshDailyAggregates(ctx context.Context) error {
+	ds.aggregates.refresh.Lock()
+	defer ds.aggregates.refresh.Unlock()
+
+	ds.aggregates.mu.Lock()
+	rebuild, dirty := ds.aggregates.rebuild, ds.aggregates.dirty
+	ds.aggregates.rebuild, ds.aggregates.dirty = false, nil
+	ds.aggregates.mu.Unlock()
+
+	if rebuild {
+		if err := ds.recomputeDailyAggregates(ctx, ""); err != nil {
+			ds.markAllDailyAggregatesDirty()
+			return err
+		}
+		return nil
+	}
+
+	for date := range dirty {
+		if err := ds.recomputeDailyAggregates(ctx, date); err != nil {
+			for date := range dirty {
+				ds.markDailyAggregatesDirty(date)
+			}
+			return err
+		}
+		delete(dirty, date)
+	}
+	return nil
+}
+
+// useDailyAggregates reports whether analytics queries can be answered from
+// the daily aggregates, refreshing outdated days first
+func (ds *DataStore) useDailyAggregates(ctx context.Context) bool {
+	ds.aggregates.mu.Lock()
+	ready := ds.aggregates.ready
+	ds.aggregates.mu.Unlock()
+	if !ready {
+		return false
+	}
+	if err := ds.RefreshDailyAggregates(ctx); err != nil {
+		getLogger().Warn("Failed to refresh daily aggregates, scanning detections",
+			"error", err,
+			"operation", "refresh_daily_aggregates")
+		return false
+	}
+	return true
+}
+
+// recomputeDailyAggregates replaces the daily aggregates of a day, or of all
+// days when date is empty
+func (ds *DataStore) recomputeDailyAggregates(ctx context.Context, date string) error {
+	scope := func(db *gorm.DB) *gorm.DB {
+		if date != "" {
+			return db.Where("date = ?", date)
+		}
+		return db
+	}
+
+	type key struct{ date, species string }
+	aggregates := make(map[key]*DailyAggregate)
+	var order []key
+	add := func(row *DailyAggregate) {
+		k := key{row.Date, row.ScientificName}
+		aggregate, ok := aggregates[k]
+		if !ok {
+			aggregates[k] = row
+			order = append(order, k)
+			return
+		}
+		aggregate.Count += row.Count
+		aggregate.ConfidenceSum += row.ConfidenceSum
+		aggregate.MaxConfidence = max(aggregate.MaxConfidence, row.MaxConfidence)
+		aggregate.FirstTime = min(aggregate.FirstTime, row.FirstTime)
+		aggregate.LastTime = max(aggregate.LastTime, row.LastTime)
+		if aggregate.CommonName == "" {
+			aggregate.CommonName = row.CommonName
+		}
+		if aggregate.SpeciesCode == "" {
+			aggregate.SpeciesCode = row.SpeciesCode
+		}
+	}
+
+	aggregateError := func(err error, action string) error {
+		return dbError(err, "recompute_daily_aggregates", errors.PriorityMedium,
+			"date", date,
+			"table", "daily_aggregates",
+			"action", action)
+	}
+
+	var notes []DailyAggregate
+	if err := scope(ds.DB.WithContext(ctx).Table("notes")).
+		Select("date, scientific_name, MAX(common_name) AS common_name, MAX(species_code) AS species_code, " +
+			"COUNT(*) AS count, SUM(confidence) AS confidence_sum, MAX(confidence) AS max_confidence, " +
+			"MIN(time) AS first_time, MAX(time) AS last_time").
+		Group("date, scientific_name").
+		Scan(&notes).Error; err != nil {
+		return aggregateError(err, "aggregate_notes")
+	}
+	for i := range notes {
+		add(&notes[i])
+	}
+
+	// Detections thinned by a retention policy only have their hour left
+	var thinned []struct {
+		DetectionAggregate
+		FirstHour int
+		LastHour  int
+	}
+	if err := scope(ds.DB.WithContext(ctx).Table("detection_aggregates")).
+		Select("date, scientific_name, MAX(common_name) AS common_name, MAX(species_code) AS species_code, " +
+			"SUM(count) AS count, SUM(confidence_sum) AS confidence_sum, MAX(max_confidence) AS max_confidence, " +
+			"MIN(hour) AS first_hour, MAX(hour) AS last_hour").
+		Group("date, scientific_name").
+		Scan(&thinned).Error; err != nil {
+		return aggregateError(err, "aggregate_thinned_detections")
+	}
+	for i := range thinned {
+		row := &thinned[i]
+		add(&DailyAggregate{
+			Date:           row.Date,
+			ScientificName: row.ScientificName,
+			CommonName:     row.CommonName,
+			SpeciesCode:    row.SpeciesCode,
+			Count:          row.Count,
+			ConfidenceSum:  row.ConfidenceSum,
+			MaxConfidence:  row.MaxConfidence,
+			FirstTime:      fmt.Sprintf("%02d:00:00", row.FirstHour),
+			LastTime:       fmt.Sprintf("%02d:59:59", row.LastHour),
+		})
+	}
+
+	rows := make([]DailyAggregate, 0, len(order))
+	for _, k := range order {
+		rows = append(rows, *aggregates[k])
+	}
+
+	err := ds.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := scope(tx.Session(&gorm.Session{AllowGlobalUpdate: true})).Delete(&DailyAggregate{}).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(rows, dailyAggregateBatchSize).Error
+	})
+	if err != nil {
+		return aggregateError(err, "replace_daily_aggregates")
+	}
+	return nil
+}
+
+// speciesSummaryFromAggregates answers GetSpeciesSummaryData from the daily aggregates
+func (ds *DataStore) speciesSummaryFromAggregates(ctx context.Context, startDate, endDate string) ([]SpeciesSummaryData, error) {
+	var rows []DailyAggregate
+	if err := dateRangeScope(ds.DB.WithContext(ctx), startDate, endDate).
+		Order("date, first_time").
+		Find(&rows).Error; err != nil {
+		return nil, dbError(err, "get_species_summary_data", errors.PriorityMedium,
+			"start_date", startDate,
+			"end_date", endDate,
+			"table", "daily_aggregates",
+			"action", "generate_species_analytics_report")
+	}
+
+	type species struct {
+		summary       SpeciesSummaryData
+		confidenceSum float64
+		first, last   string
+	}
+	bySpecies := make(map[string]*species)
+	var order []string
+	for i := range rows {
+		row := &rows[i]
+		s, ok := bySpecies[row.ScientificName]
+		if !ok {
+			s = &species{
+				summary: SpeciesSummaryData{ScientificName: row.ScientificName},
+				first:   row.Date + " " + row.FirstTime,
+			}
+			bySpecies[row.ScientificName] = s
+			order = append(order, row.ScientificName)
+		}
+		s.summary.CommonName = max(s.summary.CommonName, row.CommonName)
+		s.summary.SpeciesCode = max(s.summary.SpeciesCode, row.SpeciesCode)
+		s.summary.Count += row.Count
+		s.summary.MaxConfidence = max(s.summary.MaxConfidence, row.MaxConfidence)
+		s.confidenceSum += row.ConfidenceSum
+		s.first = min(s.first, row.Date+" "+row.FirstTime)
+		s.last = max(s.last, row.Date+" "+row.LastTime)
+	}
+
+	summaries := make([]SpeciesSummaryData, 0, len(order))
+	for _, name := range order {
+		s := bySpecies[name]
+		if s.summary.Count > 0 {
+			s.summary.AvgConfidence = s.confidenceSum / float64(s.summary.Count)
+		}
+		// Database stores local time strings, parse as local time
+		if t, err := time.ParseInLocation(time.DateTime, s.first, time.Local); err == nil {
+			s.summary.FirstSeen = t
+		}
+		if t, err := time.ParseInLocation(time.DateTime, s.last, time.Local); err == nil {
+			s.summary.LastSeen = t
+		}
+		summaries = append(summaries, s.summary)
+	}
+
+	slices.SortStableFunc(summaries, func(a, b SpeciesSummaryData) int {
+		return cmp.Compare(b.Count, a.Count)
+	})
+	return summaries, nil
+}
+
+// dailyAnalyticsFromAggregates answers GetDailyAnalyticsData from the daily aggregates
+func (ds *DataStore) dailyAnalyticsFromAggregates(ctx context.Context, startDate, endDate, species string) ([]DailyAnalyticsData, error) {
+	var analytics []DailyAnalyticsData
+	query := dateRangeScope(ds.DB.WithContext(ctx).Model(&DailyAggregate{}), startDate, endDate).
+		Select("date, SUM(count) AS count").
+		Group("date").
+		Order("date")
+	if species != "" {
+		query = query.Where("scientific_name = ? OR common_name = ?", species, species)
+	}
+	if err := query.Scan(&analytics).Error; err != nil {
+		return nil, dbError(err, "get_daily_analytics_data", errors.PriorityMedium,
+			"start_date", startDate,
+			"end_date", endDate,
+			"species", species,
+			"table", "daily_aggregates")
+	}
+	return analytics, nil
+}
+
+// dateRangeScope filters a query by an optional date range, both dates inclusive
+func dateRangeScope(db *gorm.DB, startDate, endDate string) *gorm.DB {
+	if startDate != "" {
+		db = db.Where("date >= ?", startDate)
+	}
+	if endDate != "" {
+		db = db.Where("date <= ?", endDate)
+	}
+	return db
+}
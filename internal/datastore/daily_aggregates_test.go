@@ -0,0 +1,69 @@
+// daily_aggregates_test.go: Tests for the materialized daily aggregates
+package datastore
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDailyAggregates(t *testing.T) {
+	t.Parallel()
+
+	ds := setupTestDB(t)
+	require.NoError(t, ds.DB.AutoMigrate(&Results{}, &NoteLock{}, &DetectionAggregate{}, &DailyAggregate{}))
+
+	notes := []Note{
+		{Date: "2024-05-01", Time: "06:10:00", ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", Confidence: 0.9},
+		{Date: "2024-05-01", Time: "18:20:00", ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", Confidence: 0.7},
+		{Date: "2024-05-02", Time: "07:00:00", ScientificName: "Pica pica", CommonName: "Eurasian Magpie", Confidence: 0.8},
+	}
+	require.NoError(t, ds.DB.Create(&notes).Error)
+	require.NoError(t, ds.DB.Create(&DetectionAggregate{
+		Date: "2024-04-30", Hour: 5, ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird",
+		Count: 3, ConfidenceSum: 2.4, MaxConfidence: 0.95,
+	}).Error)
+
+	ctx := context.Background()
+	assert.False(t, ds.useDailyAggregates(ctx), "aggregates should not be used before they are built")
+	require.NoError(t, ds.BuildDailyAggregates(ctx))
+
+	daily, err := ds.GetDailyAnalyticsData(ctx, "2024-04-01", "2024-05-31", "")
+	require.NoError(t, err)
+	assert.Equal(t, []DailyAnalyticsData{
+		{Date: "2024-04-30", Count: 3},
+		{Date: "2024-05-01", Count: 2},
+		{Date: "2024-05-02", Count: 1},
+	}, daily)
+
+	summaries, err := ds.GetSpeciesSummaryData(ctx, "", "")
+	require.NoError(t, err)
+	require.Len(t, summaries, 2)
+	blackbird := summaries[0]
+	assert.Equal(t, "Turdus merula", blackbird.ScientificName)
+	assert.Equal(t, 5, blackbird.Count, "thinned detections should be included")
+	assert.InDelta(t, 0.8, blackbird.AvgConfidence, 0.001)
+	assert.InDelta(t, 0.95, blackbird.MaxConfidence, 0.001)
+	assert.Equal(t, "2024-04-30 05:00:00", blackbird.FirstSeen.Format("2006-01-02 15:04:05"))
+	assert.Equal(t, "2024-05-01 18:20:00", blackbird.LastSeen.Format("2006-01-02 15:04:05"))
+
+	// Deleting a detection updates the aggregates of its day
+	require.NoError(t, ds.Delete(fmt.Sprintf("%d", notes[1].ID)))
+	daily, err = ds.GetDailyAnalyticsData(ctx, "2024-05-01", "2024-05-01", "Eurasian Blackbird")
+	require.NoError(t, err)
+	assert.Equal(t, []DailyAnalyticsData{{Date: "2024-05-01", Count: 1}}, daily)
+
+	// Updating the species of a detection moves it between aggregates
+	require.NoError(t, ds.UpdateNote(fmt.Sprintf("%d", notes[2].ID), map[string]interface{}{
+		"scientific_name": "Corvus corone",
+		"common_name":     "Carrion Crow",
+	}))
+	var aggregates []DailyAggregate
+	require.NoError(t, ds.RefreshDailyAggregates(ctx))
+	require.NoError(t, ds.DB.Where("date = ?", "2024-05-02").Find(&aggregates).Error)
+	require.Len(t, aggregates, 1)
+	assert.Equal(t, "Corvus corone", aggregates[0].ScientificName)
+}
@@ -0,0 +1,139 @@
+// daily_summary.go: Aggregated detections of a single day for the dashboard
+package datastore
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// DailySpeciesCount holds the detections of one species on a day
+type DailySpeciesCount struct {
+	ScientificName string
+	CommonName     string
+	SpeciesCode    string
+	Count          int
+	MaxConfidence  float64
+	FirstHeard     string  // time of the first detection, "15:04:05"
+	LatestHeard    string  // time of the latest detection
+	Hourly         [24]int // detections by hour of the day
+	IsNew          bool    // first ever detection of the species was on this day
+}
+
+// DailySummary aggregates the detections of one day
+type DailySummary struct {
+	Date            string
+	TotalDetections int
+	Species         []DailySpeciesCount // ordered by count, then latest detection
+	Hourly          [24]int             // detections of all species by hour of the day
+	TopClips        []Note              // highest confidence detections with an audio clip, one per species
+}
+
+// GetDailySummary aggregates the detections of a date (YYYY-MM-DD) with at
+// least minConfidence in one pass over the notes of the day. Up to topClips
+// clips are returned.
+func (ds *DataStore) GetDailySummary(ctx context.Context, date string, minConfidence float64, topClips int) (*DailySummary, error) {
+	if date == "" {
+		return nil, validationError("date cannot be empty", "date", date)
+	}
+
+	var notes []Note
+	if err := ds.DB.WithContext(ctx).
+		Select("id, date, time, species_code, scientific_name, common_name, confidence, clip_name").
+		Where("date = ? AND confidence >= ?", date, minConfidence).
+		Order("time ASC").
+		Find(&notes).Error; err != nil {
+		return nil, dbError(err, "get_daily_summary", errors.PriorityLow,
+			"date", date,
+			"table", "notes")
+	}
+
+	summary := summarizeDay(date, notes, topClips)
+	if len(summary.Species) == 0 {
+		return summary, nil
+	}
+
+	// Species are new when no detection precedes this day
+	names := make([]string, len(summary.Species))
+	for i := range summary.Species {
+		names[i] = summary.Species[i].ScientificName
+	}
+	var seenBefore []string
+	if err := ds.DB.WithContext(ctx).Model(&Note{}).
+		Distinct("scientific_name").
+		Where("scientific_name IN ? AND date < ?", names, date).
+		Pluck("scientific_name", &seenBefore).Error; err != nil {
+		return nil, dbError(err, "get_daily_summary_new_species", errors.PriorityLow,
+			"date", date,
+			"table", "notes")
+	}
+	for i := range summary.Species {
+		summary.Species[i].IsNew = !slices.Contains(seenBefore, summary.Species[i].ScientificName)
+	}
+	return summary, nil
+}
+
+// summarizeDay aggregates notes ordered by time into a daily summary
+func summarizeDay(date string, notes []Note, topClips int) *DailySummary {
+	summary := &DailySummary{Date: date, TotalDetections: len(notes), Species: []DailySpeciesCount{}}
+
+	index := make(map[string]int)
+	best := make(map[string]int) // best clip note by species
+	for i := range notes {
+		note := &notes[i]
+		pos, ok := index[note.ScientificName]
+		if !ok {
+			pos = len(summary.Species)
+			index[note.ScientificName] = pos
+			summary.Species = append(summary.Species, DailySpeciesCount{
+				ScientificName: note.ScientificName,
+				CommonName:     note.CommonName,
+				SpeciesCode:    note.SpeciesCode,
+				FirstHeard:     note.Time,
+			})
+		}
+		species := &summary.Species[pos]
+		species.Count++
+		species.LatestHeard = note.Time
+		species.MaxConfidence = max(species.MaxConfidence, note.Confidence)
+
+		if len(note.Time) >= 2 {
+			if hour, err := strconv.Atoi(note.Time[:2]); err == nil && hour >= 0 && hour < 24 {
+				species.Hourly[hour]++
+				summary.Hourly[hour]++
+			}
+		}
+
+		if note.ClipName != "" {
+			if b, ok := best[note.ScientificName]; !ok || note.Confidence > notes[b].Confidence {
+				best[note.ScientificName] = i
+			}
+		}
+	}
+
+	slices.SortStableFunc(summary.Species, func(a, b DailySpeciesCount) int {
+		if a.Count != b.Count {
+			return b.Count - a.Count
+		}
+		return strings.Compare(b.LatestHeard, a.LatestHeard)
+	})
+
+	summary.TopClips = make([]Note, 0, len(best))
+	for _, i := range best {
+		summary.TopClips = append(summary.TopClips, notes[i])
+	}
+	slices.SortFunc(summary.TopClips, func(a, b Note) int {
+		if c := cmp.Compare(b.Confidence, a.Confidence); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+	if topClips >= 0 && len(summary.TopClips) > topClips {
+		summary.TopClips = summary.TopClips[:topClips]
+	}
+	return summary
+}
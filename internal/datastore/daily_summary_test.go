@@ -0,0 +1,54 @@
+// daily_summary_test.go: Tests for daily summary aggregation
+package datastore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetDailySummary(t *testing.T) {
+	t.Parallel()
+
+	ds := setupTestDB(t)
+	notes := []Note{
+		{ID: 1, Date: "2024-04-30", Time: "06:00:00", ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", Confidence: 0.9},
+		{ID: 2, Date: "2024-05-01", Time: "05:10:00", ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", Confidence: 0.8, ClipName: "a.wav"},
+		{ID: 3, Date: "2024-05-01", Time: "06:20:00", ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", Confidence: 0.95, ClipName: "b.wav"},
+		{ID: 4, Date: "2024-05-01", Time: "06:00:00", ScientificName: "Pica pica", CommonName: "Eurasian Magpie", Confidence: 0.85, ClipName: "c.wav"},
+		{ID: 5, Date: "2024-05-01", Time: "07:00:00", ScientificName: "Parus major", CommonName: "Great Tit", Confidence: 0.4},
+	}
+	require.NoError(t, ds.DB.Create(&notes).Error)
+
+	summary, err := ds.GetDailySummary(context.Background(), "2024-05-01", 0.5, 5)
+	require.NoError(t, err)
+	assert.Equal(t, 3, summary.TotalDetections, "detections below the minimum confidence should be left out")
+	assert.Equal(t, 1, summary.Hourly[5])
+	assert.Equal(t, 2, summary.Hourly[6])
+
+	require.Len(t, summary.Species, 2)
+	blackbird := summary.Species[0]
+	assert.Equal(t, "Turdus merula", blackbird.ScientificName)
+	assert.Equal(t, 2, blackbird.Count)
+	assert.Equal(t, "05:10:00", blackbird.FirstHeard)
+	assert.Equal(t, "06:20:00", blackbird.LatestHeard)
+	assert.InDelta(t, 0.95, blackbird.MaxConfidence, 1e-9)
+	assert.False(t, blackbird.IsNew, "species detected on earlier days should not be new")
+	assert.True(t, summary.Species[1].IsNew)
+
+	require.Len(t, summary.TopClips, 2, "top clips should hold one clip per species")
+	assert.Equal(t, uint(3), summary.TopClips[0].ID)
+	assert.Equal(t, uint(4), summary.TopClips[1].ID)
+
+	limited, err := ds.GetDailySummary(context.Background(), "2024-05-01", 0, 1)
+	require.NoError(t, err)
+	assert.Len(t, limited.TopClips, 1)
+	assert.Len(t, limited.Species, 3)
+
+	empty, err := ds.GetDailySummary(context.Background(), "2024-06-01", 0, 5)
+	require.NoError(t, err)
+	assert.Zero(t, empty.TotalDetections)
+	assert.Empty(t, empty.Species)
+}
@@ -0,0 +1,106 @@
+// dashboards.go: User-composed dashboard layouts
+package datastore
+
+import (
+	"strings"
+
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// ErrDashboardNotFound is returned when a dashboard does not exist
+var ErrDashboardNotFound = errors.NewStd("dashboard not found")
+
+// validateDashboard checks the fields every stored dashboard needs
+func validateDashboard(dashboard *Dashboard) error {
+	if strings.TrimSpace(dashboard.Name) == "" {
+		return validationError("name cannot be empty", "name", dashboard.Name)
+	}
+	if len(dashboard.Name) > 128 {
+		return validationError("name is longer than 128 characters", "name", dashboard.Name)
+	}
+	if dashboard.Layout == "" {
+		return validationError("layout cannot be empty", "layout", dashboard.Layout)
+	}
+	return nil
+}
+
+// SaveDashboard stores a new dashboard
+func (ds *DataStore) SaveDashboard(dashboard *Dashboard) error {
+	if err := validateDashboard(dashboard); err != nil {
+		return err
+	}
+	dashboard.ID = 0
+
+	if err := ds.DB.Create(dashboard).Error; err != nil {
+		return dbError(err, "save_dashboard", errors.PriorityLow,
+			"owner", dashboard.Owner,
+			"table", "dashboards",
+			"action", "save_dashboard")
+	}
+	return nil
+}
+
+// UpdateDashboard replaces the name, sharing and layout of a dashboard. The
+// owner and creation time are kept.
+func (ds *DataStore) UpdateDashboard(dashboard *Dashboard) error {
+	if err := validateDashboard(dashboard); err != nil {
+		return err
+	}
+
+	res := ds.DB.Model(&Dashboard{}).Where("id = ?", dashboard.ID).Updates(map[string]any{
+		"name":   dashboard.Name,
+		"shared": dashboard.Shared,
+		"layout": dashboard.Layout,
+	})
+	if res.Error != nil {
+		return dbError(res.Error, "update_dashboard", errors.PriorityLow,
+			"id", dashboard.ID,
+			"table", "dashboards")
+	}
+	if res.RowsAffected == 0 {
+		return ErrDashboardNotFound
+	}
+	return nil
+}
+
+// GetDashboard returns a dashboard by ID
+func (ds *DataStore) GetDashboard(id uint) (*Dashboard, error) {
+	var dashboards []Dashboard
+	if err := ds.DB.Where("id = ?", id).Limit(1).Find(&dashboards).Error; err != nil {
+		return nil, dbError(err, "get_dashboard", errors.PriorityLow,
+			"id", id,
+			"table", "dashboards")
+	}
+	if len(dashboards) == 0 {
+		return nil, ErrDashboardNotFound
+	}
+	return &dashboards[0], nil
+}
+
+// GetDashboards returns the dashboards of an owner and those shared by other
+// users, ordered by name
+func (ds *DataStore) GetDashboards(owner string) ([]Dashboard, error) {
+	var dashboards []Dashboard
+	if err := ds.DB.Where("owner = ? OR shared = ?", owner, true).
+		Order("name ASC, id ASC").
+		Find(&dashboards).Error; err != nil {
+		return nil, dbError(err, "get_dashboards", errors.PriorityLow,
+			"owner", owner,
+			"table", "dashboards")
+	}
+	return dashboards, nil
+}
+
+// DeleteDashboard removes a dashboard
+func (ds *DataStore) DeleteDashboard(id uint) error {
+	res := ds.DB.Delete(&Dashboard{}, id)
+	if res.Error != nil {
+		return dbError(res.Error, "delete_dashboard", errors.PriorityLow,
+			"id", id,
+			"table", "dashboards")
+	}
+	if res.RowsAffected == 0 {
+		return ErrDashboardNotFound
+	}
+	return nil
+}
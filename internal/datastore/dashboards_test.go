@@ -0,0 +1,49 @@
+// dashboards_test.go: Tests for dashboard layouts
+package datastore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDashboards(t *testing.T) {
+	t.Parallel()
+
+	ds := setupTestDB(t)
+	require.NoError(t, ds.DB.AutoMigrate(&Dashboard{}))
+
+	layout := `[{"type":"daily-summary"}]`
+	mine := &Dashboard{Name: "Mornings", Owner: "alice", Layout: layout}
+	require.NoError(t, ds.SaveDashboard(mine))
+	require.NotZero(t, mine.ID)
+	require.NoError(t, ds.SaveDashboard(&Dashboard{Name: "Bob private", Owner: "bob", Layout: layout}))
+	shared := &Dashboard{Name: "Bob shared", Owner: "bob", Shared: true, Layout: layout}
+	require.NoError(t, ds.SaveDashboard(shared))
+
+	dashboards, err := ds.GetDashboards("alice")
+	require.NoError(t, err)
+	require.Len(t, dashboards, 2, "own and shared dashboards are listed")
+	assert.Equal(t, "Bob shared", dashboards[0].Name)
+	assert.Equal(t, "Mornings", dashboards[1].Name)
+
+	mine.Name = "Evenings"
+	mine.Shared = true
+	mine.Owner = "mallory"
+	require.NoError(t, ds.UpdateDashboard(mine))
+	got, err := ds.GetDashboard(mine.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Evenings", got.Name)
+	assert.True(t, got.Shared)
+	assert.Equal(t, "alice", got.Owner, "updates keep the owner")
+
+	require.ErrorIs(t, ds.UpdateDashboard(&Dashboard{ID: 999, Name: "x", Layout: layout}), ErrDashboardNotFound)
+	require.Error(t, ds.SaveDashboard(&Dashboard{Name: " ", Layout: layout}))
+	require.Error(t, ds.SaveDashboard(&Dashboard{Name: "Empty"}))
+
+	require.NoError(t, ds.DeleteDashboard(shared.ID))
+	require.ErrorIs(t, ds.DeleteDashboard(shared.ID), ErrDashboardNotFound)
+	_, err = ds.GetDashboard(shared.ID)
+	require.ErrorIs(t, err, ErrDashboardNotFound)
+}
@@ -0,0 +1,41 @@
+// dataquality.go: Storage of daily data quality reports
+package datastore
+
+import (
+	"github.com/tphakala/birdnet-go/internal/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SaveDataQualityReport stores the report of a day, replacing an existing report of the same day
+func (ds *DataStore) SaveDataQualityReport(report *DataQualityReport) error {
+	if report.Date == "" {
+		return validationError("report date cannot be empty", "date", report.Date)
+	}
+
+	if err := ds.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"flags", "report", "created_at"}),
+	}).Create(report).Error; err != nil {
+		return dbError(err, "save_data_quality_report", errors.PriorityLow,
+			"date", report.Date,
+			"table", "data_quality_reports",
+			"action", "store_daily_quality_report")
+	}
+	return nil
+}
+
+// GetDataQualityReport returns the stored report of a day
+func (ds *DataStore) GetDataQualityReport(date string) (*DataQualityReport, error) {
+	var report DataQualityReport
+	err := ds.DB.Where("date = ?", date).First(&report).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, notFoundError("data quality report", date)
+	}
+	if err != nil {
+		return nil, dbError(err, "get_data_quality_report", errors.PriorityLow,
+			"date", date,
+			"table", "data_quality_reports")
+	}
+	return &report, nil
+}
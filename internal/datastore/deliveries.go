@@ -0,0 +1,119 @@
+// deliveries.go: Storage of the outbound delivery queue
+package datastore
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/errors"
+	"gorm.io/gorm"
+)
+
+// DeliveryFilter selects deliveries of the delivery queue. Empty fields match
+// all deliveries.
+type DeliveryFilter struct {
+	Statuses []string
+	Target   string
+	Limit    int // 0 for no limit
+	Offset   int
+}
+
+// SaveDelivery stores a delivery, creating it when its ID is zero
+func (ds *DataStore) SaveDelivery(record *DeliveryRecord) error {
+	if record.Target == "" {
+		return validationError("delivery target cannot be empty", "target", record.Target)
+	}
+	if err := ds.DB.Save(record).Error; err != nil {
+		return dbError(err, "save_delivery", errors.PriorityLow,
+			"delivery_id", record.ID,
+			"table", "delivery_records")
+	}
+	return nil
+}
+
+// GetDelivery returns a stored delivery
+func (ds *DataStore) GetDelivery(id uint) (*DeliveryRecord, error) {
+	var record DeliveryRecord
+	err := ds.DB.Where("id = ?", id).First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, notFoundError("delivery", strconv.FormatUint(uint64(id), 10))
+	}
+	if err != nil {
+		return nil, dbError(err, "get_delivery", errors.PriorityLow,
+			"delivery_id", id,
+			"table", "delivery_records")
+	}
+	return &record, nil
+}
+
+// ListDeliveries returns the deliveries matching filter, newest first
+func (ds *DataStore) ListDeliveries(filter *DeliveryFilter) ([]DeliveryRecord, error) {
+	query := deliveryQuery(ds.DB, filter).Order("created_at DESC, id DESC")
+	if filter != nil {
+		if filter.Limit > 0 {
+			query = query.Limit(filter.Limit)
+		}
+		if filter.Offset > 0 {
+			query = query.Offset(filter.Offset)
+		}
+	}
+
+	var records []DeliveryRecord
+	if err := query.Find(&records).Error; err != nil {
+		return nil, dbError(err, "list_deliveries", errors.PriorityLow,
+			"table", "delivery_records")
+	}
+	return records, nil
+}
+
+// CountDeliveries returns the number of deliveries matching filter, ignoring
+// its limit and offset
+func (ds *DataStore) CountDeliveries(filter *DeliveryFilter) (int64, error) {
+	var count int64
+	if err := deliveryQuery(ds.DB, filter).Count(&count).Error; err != nil {
+		return 0, dbError(err, "count_deliveries", errors.PriorityLow,
+			"table", "delivery_records")
+	}
+	return count, nil
+}
+
+// DueDeliveries returns up to limit deliveries with the given status due at
+// now, the longest due first
+func (ds *DataStore) DueDeliveries(status string, now time.Time, limit int) ([]DeliveryRecord, error) {
+	var records []DeliveryRecord
+	err := ds.DB.Where("status = ? AND next_attempt_at <= ?", status, now).
+		Order("next_attempt_at ASC").
+		Limit(limit).
+		Find(&records).Error
+	if err != nil {
+		return nil, dbError(err, "due_deliveries", errors.PriorityLow,
+			"table", "delivery_records")
+	}
+	return records, nil
+}
+
+// PruneDeliveries removes the deliveries with the given statuses last updated
+// before the given time and returns the number removed
+func (ds *DataStore) PruneDeliveries(statuses []string, before time.Time) (int64, error) {
+	result := ds.DB.Where("status IN ? AND updated_at < ?", statuses, before).Delete(&DeliveryRecord{})
+	if result.Error != nil {
+		return 0, dbError(result.Error, "prune_deliveries", errors.PriorityLow,
+			"table", "delivery_records")
+	}
+	return result.RowsAffected, nil
+}
+
+// deliveryQuery returns a query of the deliveries matching filter
+func deliveryQuery(db *gorm.DB, filter *DeliveryFilter) *gorm.DB {
+	query := db.Model(&DeliveryRecord{})
+	if filter == nil {
+		return query
+	}
+	if len(filter.Statuses) > 0 {
+		query = query.Where("status IN ?", filter.Statuses)
+	}
+	if filter.Target != "" {
+		query = query.Where("target = ?", filter.Target)
+	}
+	return query
+}
@@ -0,0 +1,67 @@
+// deliveries_test.go: Tests for the delivery queue storage
+package datastore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeliveryQueueStorage(t *testing.T) {
+	t.Parallel()
+
+	ds := setupTestDB(t)
+	require.NoError(t, ds.DB.AutoMigrate(&DeliveryRecord{}))
+
+	start := time.Date(2024, 5, 1, 6, 0, 0, 0, time.UTC)
+	targets := []string{"push:telegram", "mqtt", "birdweather", "mqtt"}
+	for i, target := range targets {
+		record := DeliveryRecord{
+			Target:        target,
+			Description:   "Eurasian Wryneck",
+			Payload:       []byte(`{"id":1}`),
+			Status:        "pending",
+			MaxAttempts:   3,
+			NextAttemptAt: start.Add(time.Duration(i) * time.Minute),
+			CreatedAt:     start.Add(time.Duration(i) * time.Second),
+			UpdatedAt:     start,
+		}
+		require.NoError(t, ds.SaveDelivery(&record))
+		assert.NotZero(t, record.ID, "created deliveries get an ID")
+	}
+	require.Error(t, ds.SaveDelivery(&DeliveryRecord{}), "deliveries need a target")
+
+	// Saving again updates the stored delivery
+	read, err := ds.GetDelivery(1)
+	require.NoError(t, err)
+	assert.Equal(t, []byte(`{"id":1}`), read.Payload)
+	read.Status = "delivered"
+	require.NoError(t, ds.SaveDelivery(read))
+
+	due, err := ds.DueDeliveries("pending", start.Add(2*time.Minute), 10)
+	require.NoError(t, err)
+	require.Len(t, due, 2, "the first delivery is delivered and the last is not yet due")
+	assert.Equal(t, uint(2), due[0].ID, "longest due first")
+	assert.Equal(t, uint(3), due[1].ID)
+
+	records, err := ds.ListDeliveries(&DeliveryFilter{Target: "mqtt", Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, uint(4), records[0].ID, "newest first")
+	pending, err := ds.CountDeliveries(&DeliveryFilter{Statuses: []string{"pending"}, Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), pending, "counts ignore the limit")
+
+	_, err = ds.GetDelivery(99)
+	require.Error(t, err)
+
+	// Only finished deliveries are pruned
+	removed, err := ds.PruneDeliveries([]string{"delivered", "dead_letter"}, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), removed)
+	total, err := ds.CountDeliveries(nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+}
@@ -0,0 +1,108 @@
+// deployment_journal.go: Journal of equipment and configuration changes
+package datastore
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// Deployment change categories
+const (
+	DeploymentChangeEquipment     = "equipment"
+	DeploymentChangeGain          = "gain"
+	DeploymentChangeLocation      = "location"
+	DeploymentChangeConfiguration = "configuration"
+	DeploymentChangeOther         = "other"
+)
+
+// DeploymentChangeCategories lists the valid deployment change categories
+var DeploymentChangeCategories = []string{
+	DeploymentChangeEquipment,
+	DeploymentChangeGain,
+	DeploymentChangeLocation,
+	DeploymentChangeConfiguration,
+	DeploymentChangeOther,
+}
+
+// ErrDeploymentChangeNotFound is returned when a journal entry does not exist
+var ErrDeploymentChangeNotFound = errors.NewStd("deployment change not found")
+
+// SaveDeploymentChange adds an entry to the deployment journal
+func (ds *DataStore) SaveDeploymentChange(change *DeploymentChange) error {
+	if change.Summary == "" {
+		return validationError("summary cannot be empty", "summary", change.Summary)
+	}
+	if !slices.Contains(DeploymentChangeCategories, change.Category) {
+		return validationError("unknown category", "category", change.Category)
+	}
+	if change.OccurredAt.IsZero() {
+		return validationError("time of the change is required", "occurred_at", change.OccurredAt)
+	}
+
+	if err := ds.DB.Create(change).Error; err != nil {
+		return dbError(err, "save_deployment_change", errors.PriorityLow,
+			"category", change.Category,
+			"table", "deployment_changes",
+			"action", "record_deployment_change")
+	}
+	return nil
+}
+
+// GetDeploymentChanges returns the journal entries between start and end in
+// ascending order. A zero start or end leaves that side of the period open.
+func (ds *DataStore) GetDeploymentChanges(start, end time.Time) ([]DeploymentChange, error) {
+	// Times may be stored in another time zone than the period, so the query
+	// is widened by a day and the entries are filtered here
+	query := ds.DB.Model(&DeploymentChange{})
+	if !start.IsZero() {
+		query = query.Where("occurred_at >= ?", start.AddDate(0, 0, -1))
+	}
+	if !end.IsZero() {
+		query = query.Where("occurred_at < ?", end.AddDate(0, 0, 1))
+	}
+
+	var changes []DeploymentChange
+	if err := query.Order("occurred_at ASC, id ASC").Find(&changes).Error; err != nil {
+		return nil, dbError(err, "get_deployment_changes", errors.PriorityLow,
+			"table", "deployment_changes")
+	}
+	return slices.DeleteFunc(changes, func(c DeploymentChange) bool {
+		return (!start.IsZero() && c.OccurredAt.Before(start)) || (!end.IsZero() && !c.OccurredAt.Before(end))
+	}), nil
+}
+
+// DeleteDeploymentChange removes an entry from the deployment journal
+func (ds *DataStore) DeleteDeploymentChange(id uint) error {
+	res := ds.DB.Delete(&DeploymentChange{}, id)
+	if res.Error != nil {
+		return dbError(res.Error, "delete_deployment_change", errors.PriorityLow,
+			"id", id,
+			"table", "deployment_changes")
+	}
+	if res.RowsAffected == 0 {
+		return ErrDeploymentChangeNotFound
+	}
+	return nil
+}
+
+// equipmentDeploymentChange describes a station equipment change as a journal entry
+func equipmentDeploymentChange(change *StationEquipmentChange) *DeploymentChange {
+	summary := "Equipment changed"
+	switch {
+	case change.MicrophoneModel != "" && change.MountingHeight > 0:
+		summary = fmt.Sprintf("Microphone %s mounted at %g m", change.MicrophoneModel, change.MountingHeight)
+	case change.MicrophoneModel != "":
+		summary = "Microphone " + change.MicrophoneModel
+	case change.MountingHeight > 0:
+		summary = fmt.Sprintf("Microphone mounted at %g m", change.MountingHeight)
+	}
+	return &DeploymentChange{
+		OccurredAt: change.ChangedAt,
+		Category:   DeploymentChangeEquipment,
+		Summary:    summary,
+		Details:    change.Note,
+	}
+}
@@ -0,0 +1,37 @@
+// deployment_journal_test.go: Tests for the deployment journal
+package datastore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeploymentJournal(t *testing.T) {
+	t.Parallel()
+
+	ds := setupTestDB(t)
+	require.NoError(t, ds.DB.AutoMigrate(&DeploymentChange{}))
+
+	moved := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	require.NoError(t, ds.SaveDeploymentChange(&DeploymentChange{OccurredAt: moved, Category: DeploymentChangeLocation, Summary: "Moved to the hedge"}))
+	gain := &DeploymentChange{OccurredAt: moved.AddDate(0, 0, 10), Category: DeploymentChangeGain, Summary: "Gain +6 dB"}
+	require.NoError(t, ds.SaveDeploymentChange(gain))
+
+	require.Error(t, ds.SaveDeploymentChange(&DeploymentChange{OccurredAt: moved, Category: "weather", Summary: "Rain"}))
+	require.Error(t, ds.SaveDeploymentChange(&DeploymentChange{OccurredAt: moved, Category: DeploymentChangeOther}))
+
+	changes, err := ds.GetDeploymentChanges(moved.AddDate(0, 0, 1), time.Time{})
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Equal(t, "Gain +6 dB", changes[0].Summary)
+
+	changes, err = ds.GetDeploymentChanges(time.Time{}, time.Time{})
+	require.NoError(t, err)
+	assert.Len(t, changes, 2)
+
+	require.NoError(t, ds.DeleteDeploymentChange(gain.ID))
+	require.ErrorIs(t, ds.DeleteDeploymentChange(gain.ID), ErrDeploymentChangeNotFound)
+}
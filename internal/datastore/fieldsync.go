@@ -0,0 +1,172 @@
+// fieldsync.go: Database operations for delta sync between field units and an aggregator
+package datastore
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/errors"
+	"gorm.io/gorm"
+)
+
+// SyncBatchItem is a detection received from a field unit
+type SyncBatchItem struct {
+	SourceID uint   // Note ID on the field unit
+	ClipName string // Clip name on the field unit, empty when the detection has no clip
+	Note     Note
+	Results  []Results
+}
+
+// GetNotesAfterID returns up to limit notes with an ID greater than afterID in
+// ascending ID order, with their secondary results preloaded.
+func (ds *DataStore) GetNotesAfterID(afterID uint, limit int) ([]Note, error) {
+	if limit <= 0 {
+		return nil, validationError("limit must be positive", "limit", limit)
+	}
+
+	var notes []Note
+	if err := ds.DB.Preload("Results").
+		Where("id > ?", afterID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&notes).Error; err != nil {
+		return nil, dbError(err, "get_notes_after_id", errors.PriorityMedium,
+			"after_id", afterID,
+			"table", "notes",
+			"action", "read_delta_sync_batch")
+	}
+	return notes, nil
+}
+
+// GetSyncUnit returns the sync state of a field unit. A unit that has never
+// synced is returned with a zero cursor.
+func (ds *DataStore) GetSyncUnit(unitID string) (*SyncUnit, error) {
+	var unit SyncUnit
+	err := ds.DB.Where("unit_id = ?", unitID).First(&unit).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &SyncUnit{UnitID: unitID}, nil
+	}
+	if err != nil {
+		return nil, dbError(err, "get_sync_unit", errors.PriorityMedium,
+			"unit_id", unitID,
+			"table", "sync_units")
+	}
+	return &unit, nil
+}
+
+// SaveSyncBatch stores detections received from a field unit and advances its
+// cursor in a single transaction. Detections already received are skipped, so a
+// batch resent after a dropped connection is not duplicated.
+func (ds *DataStore) SaveSyncBatch(unitID string, items []SyncBatchItem, cursor uint) (accepted, duplicates int, err error) {
+	if unitID == "" {
+		return 0, 0, validationError("unit ID cannot be empty", "unit_id", "")
+	}
+
+	var dates []string
+	err = ds.DB.Transaction(func(tx *gorm.DB) error {
+		dates = dates[:0]
+		for i := range items {
+			item := &items[i]
+
+			var existing int64
+			if err := tx.Model(&SyncedNote{}).
+				Where("unit_id = ? AND source_id = ?", unitID, item.SourceID).
+				Count(&existing).Error; err != nil {
+				return err
+			}
+			if existing > 0 {
+				duplicates++
+				continue
+			}
+
+			note := item.Note
+			note.ID = 0
+			note.SourceNode = unitID
+			note.ClipName = "" // Set once the clip itself has been uploaded
+			if err := tx.Omit("Results", "Review", "Comments", "Lock").Create(&note).Error; err != nil {
+				return err
+			}
+
+			for j := range item.Results {
+				result := item.Results[j]
+				result.ID = 0
+				result.NoteID = note.ID
+				if err := tx.Create(&result).Error; err != nil {
+					return err
+				}
+			}
+
+			if err := tx.Create(&SyncedNote{
+				UnitID:   unitID,
+				SourceID: item.SourceID,
+				NoteID:   note.ID,
+				ClipName: item.ClipName,
+			}).Error; err != nil {
+				return err
+			}
+			dates = append(dates, note.Date)
+			accepted++
+		}
+
+		var unit SyncUnit
+		if err := tx.Where("unit_id = ?", unitID).
+			Attrs(SyncUnit{UnitID: unitID}).
+			FirstOrCreate(&unit).Error; err != nil {
+			return err
+		}
+		return tx.Model(&unit).Updates(map[string]interface{}{
+			"cursor":       max(unit.Cursor, cursor),
+			"detections":   unit.Detections + int64(accepted),
+			"last_sync_at": time.Now(),
+		}).Error
+	})
+	if err != nil {
+		return 0, 0, dbError(err, "save_sync_batch", errors.PriorityHigh,
+			"unit_id", unitID,
+			"batch_size", len(items),
+			"table", "notes",
+			"action", "store_field_unit_detections")
+	}
+	ds.markDailyAggregatesDirty(dates...)
+	return accepted, duplicates, nil
+}
+
+// GetSyncedNote returns the mapping of a detection received from a field unit
+func (ds *DataStore) GetSyncedNote(unitID string, sourceID uint) (*SyncedNote, error) {
+	var synced SyncedNote
+	err := ds.DB.Where("unit_id = ? AND source_id = ?", unitID, sourceID).First(&synced).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, notFoundError("synced note", fmt.Sprintf("%s/%d", unitID, sourceID))
+	}
+	if err != nil {
+		return nil, dbError(err, "get_synced_note", errors.PriorityMedium,
+			"unit_id", unitID,
+			"source_id", sourceID,
+			"table", "synced_notes")
+	}
+	return &synced, nil
+}
+
+// SetSyncedClip records the clip uploaded for a detection received from a field
+// unit. clipName is relative to the audio export path.
+func (ds *DataStore) SetSyncedClip(unitID string, sourceID uint, clipName string) error {
+	synced, err := ds.GetSyncedNote(unitID, sourceID)
+	if err != nil {
+		return err
+	}
+
+	err = ds.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&Note{}).Where("id = ?", synced.NoteID).Update("clip_name", clipName).Error; err != nil {
+			return err
+		}
+		return tx.Model(&SyncUnit{}).Where("unit_id = ?", unitID).
+			Update("clips", gorm.Expr("clips + ?", 1)).Error
+	})
+	if err != nil {
+		return dbError(err, "set_synced_clip", errors.PriorityMedium,
+			"unit_id", unitID,
+			"source_id", sourceID,
+			"table", "notes")
+	}
+	return nil
+}
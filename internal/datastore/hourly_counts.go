@@ -0,0 +1,67 @@
+// hourly_counts.go: Detection counts by date, hour and species
+package datastore
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// HourlySpeciesCount is the number of detections of a species in one hour of a day
+type HourlySpeciesCount struct {
+	Date           string // YYYY-MM-DD
+	Hour           int
+	ScientificName string
+	CommonName     string
+	Count          int
+}
+
+// GetHourlySpeciesCounts returns the detection counts of every species by date
+// and hour between two dates (YYYY-MM-DD), both inclusive. Hours without
+// detections are left out.
+func (ds *DataStore) GetHourlySpeciesCounts(ctx context.Context, startDate, endDate string) ([]HourlySpeciesCount, error) {
+	hourExpr := ds.GetHourFormat()
+	if hourExpr == "" {
+		return nil, errors.Newf("unsupported database type for hourly counts").
+			Component("datastore").
+			Category(errors.CategoryConfiguration).
+			Context("operation", "get_hourly_species_counts").
+			Build()
+	}
+
+	var rows []struct {
+		Date           string
+		Hour           string
+		ScientificName string
+		CommonName     string
+		Count          int
+	}
+	if err := ds.DB.WithContext(ctx).Table("notes").
+		Select(fmt.Sprintf("date, %s AS hour, scientific_name, MAX(common_name) AS common_name, COUNT(*) AS count", hourExpr)).
+		Where("date BETWEEN ? AND ?", startDate, endDate).
+		Group(fmt.Sprintf("date, %s, scientific_name", hourExpr)).
+		Scan(&rows).Error; err != nil {
+		return nil, dbError(err, "get_hourly_species_counts", errors.PriorityLow,
+			"start_date", startDate,
+			"end_date", endDate,
+			"table", "notes")
+	}
+
+	counts := make([]HourlySpeciesCount, 0, len(rows))
+	for i := range rows {
+		hour, err := strconv.Atoi(rows[i].Hour)
+		if err != nil || hour < 0 || hour > 23 {
+			continue
+		}
+		counts = append(counts, HourlySpeciesCount{
+			Date:           rows[i].Date,
+			Hour:           hour,
+			ScientificName: rows[i].ScientificName,
+			CommonName:     rows[i].CommonName,
+			Count:          rows[i].Count,
+		})
+	}
+	return counts, nil
+}
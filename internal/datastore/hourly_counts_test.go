@@ -0,0 +1,36 @@
+// hourly_counts_test.go: Tests for hourly species counts
+package datastore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetHourlySpeciesCounts(t *testing.T) {
+	t.Parallel()
+
+	ds := setupTestDB(t)
+	notes := []Note{
+		{Date: "2024-05-01", Time: "06:10:00", ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird"},
+		{Date: "2024-05-01", Time: "06:50:00", ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird"},
+		{Date: "2024-05-01", Time: "07:00:00", ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird"},
+		{Date: "2024-05-01", Time: "06:30:00", ScientificName: "Pica pica", CommonName: "Eurasian Magpie"},
+		{Date: "2024-05-03", Time: "06:30:00", ScientificName: "Pica pica", CommonName: "Eurasian Magpie"},
+	}
+	require.NoError(t, ds.DB.Create(&notes).Error)
+
+	counts, err := ds.GetHourlySpeciesCounts(context.Background(), "2024-05-01", "2024-05-02")
+	require.NoError(t, err)
+
+	got := make(map[HourlySpeciesCount]bool, len(counts))
+	for _, c := range counts {
+		got[c] = true
+	}
+	assert.Len(t, counts, 3, "the detections after the end date should be left out")
+	assert.True(t, got[HourlySpeciesCount{Date: "2024-05-01", Hour: 6, ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", Count: 2}])
+	assert.True(t, got[HourlySpeciesCount{Date: "2024-05-01", Hour: 7, ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", Count: 1}])
+	assert.True(t, got[HourlySpeciesCount{Date: "2024-05-01", Hour: 6, ScientificName: "Pica pica", CommonName: "Eurasian Magpie", Count: 1}])
+}
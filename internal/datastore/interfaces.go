@@ -114,6 +114,8 @@ type DataStore struct {
 	monitoringCtx    context.Context    // Context for monitoring goroutines
 	monitoringCancel context.CancelFunc // Function to cancel monitoring
 	monitoringMu     sync.Mutex         // Mutex to protect monitoring state
+
+	aggregates dailyAggregateState // Materialized daily aggregates state
 }
 
 // NewDataStore creates a new DataStore instance based on the provided configuration context.
@@ -211,6 +213,7 @@ func (ds *DataStore) Save(note *Note, results []Results) error {
 
 		// Success - record metrics
 		ds.recordTransactionSuccess(txStart, attempt+1, len(results), txLogger)
+		ds.markDailyAggregatesDirty(note.Date)
 		return nil
 	}
 
@@ -273,7 +276,15 @@ func (ds *DataStore) Delete(id string) error {
 	}
 
 	// Perform the deletion within a transaction
-	return ds.DB.Transaction(func(tx *gorm.DB) error {
+	var dates []string
+	err = ds.DB.Transaction(func(tx *gorm.DB) error {
+		// Remember the day of the note for updating its daily aggregates
+		if err := tx.Model(&Note{}).Where("id = ?", noteID).Pluck("date", &dates).Error; err != nil {
+			return dbError(err, "get_note_date", errors.PriorityMedium,
+				"note_id", fmt.Sprintf("%d", noteID),
+				"table", "notes",
+				"action", "delete_detection_record")
+		}
 		// Delete the full results entry associated with the note
 		if err := tx.Where("note_id = ?", noteID).Delete(&Results{}).Error; err != nil {
 			return dbError(err, "delete_results", errors.PriorityMedium,
@@ -290,6 +301,10 @@ func (ds *DataStore) Delete(id string) error {
 		}
 		return nil
 	})
+	if err == nil {
+		ds.markDailyAggregatesDirty(dates...)
+	}
+	return err
 }
 
 // GetNoteClipPath retrieves the path to the audio clip associated with a note.
@@ -896,6 +911,7 @@ func (ds *DataStore) UpdateNote(id string, updates map[string]interface{}) error
 			Build()
 	}
 
+	defer ds.markDailyAggregatesDirty(ds.noteAggregateDates(id, updates)...)
 	result := ds.DB.Model(&Note{}).Where("id = ?", id).Updates(updates)
 	if result.Error != nil {
 		return errors.New(result.Error).
@@ -1353,7 +1369,7 @@ func (ds *DataStore) SaveImageCache(cache *ImageCache) error {
 	// Update all columns except primary key on conflict
 	if err := ds.DB.Clauses(clause.OnConflict{
 		Columns:   []clause.Column{{Name: "provider_name"}, {Name: "scientific_name"}},
-		DoUpdates: clause.AssignmentColumns([]string{"url", "license_name", "license_url", "author_name", "author_url", "cached_at"}),
+		DoUpdates: clause.AssignmentColumns([]string{"url", "license_name", "license_url", "author_name", "author_url", "source_url", "cached_at"}),
 	}).Create(cache).Error; err != nil {
 		// Detect constraint violations
 		if isConstraintViolation(err) {
@@ -462,6 +462,25 @@ func migrateTables(db *gorm.DB, dbType string, lgr *slog.Logger) (int, error) {
 		{&NoteLock{}, "note_locks"},
 		{&ImageCache{}, "image_caches"},
 		{&DynamicThreshold{}, "dynamic_thresholds"},
+		{&SyncUnit{}, "sync_units"},
+		{&SyncedNote{}, "synced_notes"},
+		{&DataQualityReport{}, "data_quality_reports"},
+		{&CalibrationMeasurement{}, "calibration_measurements"},
+		{&DetectionAggregate{}, "detection_aggregates"},
+		{&DailyAggregate{}, "daily_aggregates"},
+		{&TaxonomyRemapRun{}, "taxonomy_remap_runs"},
+		{&TaxonomyRemapBackup{}, "taxonomy_remap_backups"},
+		{&ShadowDetection{}, "shadow_detections"},
+		{&StationProfile{}, "station_profiles"},
+		{&StationEquipmentChange{}, "station_equipment_changes"},
+		{&DeploymentChange{}, "deployment_changes"},
+		{&Dashboard{}, "dashboards"},
+		{&SavedSearch{}, "saved_searches"},
+		{&Tag{}, "tags"},
+		{&TrashedNote{}, "trashed_notes"},
+		{&NotificationRecord{}, "notification_records"},
+		{&DeliveryRecord{}, "delivery_records"},
+		{&ManifestEntry{}, "manifest_entries"},
 	}
 	
 	lgr.Info("Starting table migrations",
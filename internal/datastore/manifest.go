@@ -0,0 +1,89 @@
+// manifest.go: Storage of the manifest of uploads and exports
+package datastore
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/errors"
+	"gorm.io/gorm"
+)
+
+// ManifestFilter selects manifest entries. Empty fields match all entries.
+type ManifestFilter struct {
+	Kind   string
+	SHA256 string
+	IDs    []uint
+	Limit  int // 0 for no limit
+}
+
+// SaveManifestEntry stores a manifest entry, creating it when its ID is zero
+func (ds *DataStore) SaveManifestEntry(entry *ManifestEntry) error {
+	if entry.SHA256 == "" {
+		return validationError("manifest entry checksum cannot be empty", "sha256", entry.SHA256)
+	}
+	if err := ds.DB.Save(entry).Error; err != nil {
+		return dbError(err, "save_manifest_entry", errors.PriorityLow,
+			"manifest_id", entry.ID,
+			"table", "manifest_entries")
+	}
+	return nil
+}
+
+// GetManifestEntry returns a stored manifest entry
+func (ds *DataStore) GetManifestEntry(id uint) (*ManifestEntry, error) {
+	var entry ManifestEntry
+	err := ds.DB.Where("id = ?", id).First(&entry).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, notFoundError("manifest entry", strconv.FormatUint(uint64(id), 10))
+	}
+	if err != nil {
+		return nil, dbError(err, "get_manifest_entry", errors.PriorityLow,
+			"manifest_id", id,
+			"table", "manifest_entries")
+	}
+	return &entry, nil
+}
+
+// ListManifestEntries returns the manifest entries matching filter, newest
+// first
+func (ds *DataStore) ListManifestEntries(filter *ManifestFilter) ([]ManifestEntry, error) {
+	query := ds.DB.Model(&ManifestEntry{}).Order("created_at DESC, id DESC")
+	if filter != nil {
+		if filter.Kind != "" {
+			query = query.Where("kind = ?", filter.Kind)
+		}
+		if filter.SHA256 != "" {
+			query = query.Where("sha256 = ?", filter.SHA256)
+		}
+		if len(filter.IDs) > 0 {
+			query = query.Where("id IN ?", filter.IDs)
+		}
+		if filter.Limit > 0 {
+			query = query.Limit(filter.Limit)
+		}
+	}
+
+	var entries []ManifestEntry
+	if err := query.Find(&entries).Error; err != nil {
+		return nil, dbError(err, "list_manifest_entries", errors.PriorityLow,
+			"table", "manifest_entries")
+	}
+	return entries, nil
+}
+
+// UpdateManifestVerification records the outcome of verifying a manifest
+// entry
+func (ds *DataStore) UpdateManifestVerification(id uint, status string, at time.Time) error {
+	result := ds.DB.Model(&ManifestEntry{}).Where("id = ?", id).
+		Updates(map[string]any{"verify_status": status, "verified_at": at})
+	if result.Error != nil {
+		return dbError(result.Error, "update_manifest_verification", errors.PriorityLow,
+			"manifest_id", id,
+			"table", "manifest_entries")
+	}
+	if result.RowsAffected == 0 {
+		return notFoundError("manifest entry", strconv.FormatUint(uint64(id), 10))
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+// manifest_test.go: Tests for the manifest storage
+package datastore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManifestStorage(t *testing.T) {
+	t.Parallel()
+
+	ds := setupTestDB(t)
+	require.NoError(t, ds.DB.AutoMigrate(&ManifestEntry{}))
+
+	start := time.Date(2024, 5, 1, 6, 0, 0, 0, time.UTC)
+	entries := []ManifestEntry{
+		{Kind: "birdweather", Name: "soundscape", Destination: "birdweather:soundscape/1", SHA256: "aa", Size: 10},
+		{Kind: "backup", Name: "backup.tar.gz", Destination: "local", Path: "/backups/backup.tar.gz", SHA256: "bb", Size: 20},
+		{Kind: "export", Name: "training.zip", Destination: "/api/v2/training/export/1/download", SHA256: "aa", Size: 10},
+	}
+	for i := range entries {
+		entries[i].CreatedAt = start.Add(time.Duration(i) * time.Minute)
+		require.NoError(t, ds.SaveManifestEntry(&entries[i]))
+		assert.NotZero(t, entries[i].ID)
+	}
+	require.Error(t, ds.SaveManifestEntry(&ManifestEntry{Kind: "export"}), "entries need a checksum")
+
+	all, err := ds.ListManifestEntries(nil)
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	assert.Equal(t, "training.zip", all[0].Name, "newest first")
+
+	same, err := ds.ListManifestEntries(&ManifestFilter{SHA256: "aa"})
+	require.NoError(t, err)
+	assert.Len(t, same, 2)
+	backups, err := ds.ListManifestEntries(&ManifestFilter{Kind: "backup", IDs: []uint{entries[1].ID}})
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+	assert.Equal(t, "/backups/backup.tar.gz", backups[0].Path)
+
+	verifiedAt := start.Add(time.Hour)
+	require.NoError(t, ds.UpdateManifestVerification(entries[1].ID, "ok", verifiedAt))
+	read, err := ds.GetManifestEntry(entries[1].ID)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", read.VerifyStatus)
+	require.NotNil(t, read.VerifiedAt)
+	assert.True(t, verifiedAt.Equal(*read.VerifiedAt))
+
+	_, err = ds.GetManifestEntry(99)
+	require.Error(t, err)
+	require.Error(t, ds.UpdateManifestVerification(99, "ok", verifiedAt))
+}
@@ -15,10 +15,12 @@ type AudioSource struct {
 type Note struct {
 	ID         uint `gorm:"primaryKey"`
 	SourceNode string
-	Date       string `gorm:"index:idx_notes_date;index:idx_notes_date_commonname_confidence;index:idx_notes_sciname_date;index:idx_notes_sciname_date_optimized,priority:2"`
-	Time       string `gorm:"index:idx_notes_time"`
+	Date       string `gorm:"index:idx_notes_date;index:idx_notes_date_commonname_confidence;index:idx_notes_sciname_date;index:idx_notes_sciname_date_optimized,priority:2;index:idx_notes_date_time,priority:1"`
+	Time       string `gorm:"index:idx_notes_time;index:idx_notes_date_time,priority:2"` // idx_notes_date_time serves cursor pagination
 	//InputFile      string
-	Source      AudioSource `gorm:"-"` // Runtime only, not stored in database
+	Source      AudioSource `gorm:"-"`      // Runtime only, not stored in database
+	Channel     string      `gorm:"size:8"` // Analyzed channel of a stereo source ("left" or "right"), empty when mixed
+	Bearing     *float64    // Estimated compass bearing of the caller in degrees, nil when unknown
 	BeginTime   time.Time
 	EndTime     time.Time
 	SpeciesCode string
@@ -37,6 +39,10 @@ type Note struct {
 	Review         *NoteReview   `gorm:"foreignKey:NoteID;constraint:OnDelete:CASCADE"` // One-to-one relationship with cascade delete
 	Comments       []NoteComment `gorm:"foreignKey:NoteID;constraint:OnDelete:CASCADE"` // One-to-many relationship with cascade delete
 	Lock           *NoteLock     `gorm:"foreignKey:NoteID;constraint:OnDelete:CASCADE"` // One-to-one relationship with cascade delete
+	Tags           []Tag         `gorm:"many2many:note_tags;constraint:OnDelete:CASCADE"` // Many-to-many relationship through note_tags
+
+	// WeatherAdjustment is the confidence threshold increase applied for wind and rain, 0 when none
+	WeatherAdjustment float64
 
 	// Virtual fields to maintain compatibility with templates
 	Verified string `gorm:"-"` // This will be populated from Review.Verified
@@ -77,6 +83,7 @@ type NoteComment struct {
 	ID        uint      `gorm:"primaryKey"`
 	NoteID    uint      `gorm:"index;not null;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;foreignKey:NoteID;references:ID"` // Foreign key to associate with Note
 	Entry     string    `gorm:"type:text"`                                                                                   // The actual comment text
+	Author    string    `gorm:"size:128"`                                                                                    // Username of the writer, empty when authentication is disabled
 	CreatedAt time.Time `gorm:"index"`                                                                                       // When the comment was created
 	UpdatedAt time.Time // When the comment was last updated
 }
@@ -131,6 +138,7 @@ type ImageCache struct {
 	LicenseURL     string    // The URL of the license details
 	AuthorName     string    // The name of the image author
 	AuthorURL      string    // The URL of the author's page or profile
+	SourceURL      string    // The URL of the page the image was published on
 	CachedAt       time.Time `gorm:"index"` // When the image was cached
 }
 
@@ -176,3 +184,245 @@ type DynamicThreshold struct {
 	UpdatedAt     time.Time `gorm:"not null"`                      // Last update time
 	TriggerCount  int       `gorm:"not null;default:0"`            // Total number of times triggered (for statistics)
 }
+
+// SyncUnit tracks delta sync progress of a field unit on an aggregator
+type SyncUnit struct {
+	ID         uint      `gorm:"primaryKey"`
+	UnitID     string    `gorm:"uniqueIndex;not null;size:64"` // Field unit identifier
+	Cursor     uint      `gorm:"not null;default:0"`           // Highest source note ID received from the unit
+	Detections int64     `gorm:"not null;default:0"`           // Total detections accepted from the unit
+	Clips      int64     `gorm:"not null;default:0"`           // Total clips received from the unit
+	LastSyncAt time.Time // When the unit last synced
+}
+
+// SyncedNote maps a detection received from a field unit to the local note
+type SyncedNote struct {
+	ID       uint   `gorm:"primaryKey"`
+	UnitID   string `gorm:"uniqueIndex:idx_synced_notes_unit_source;not null;size:64"`
+	SourceID uint   `gorm:"uniqueIndex:idx_synced_notes_unit_source;not null"` // Note ID on the field unit
+	NoteID   uint   `gorm:"index;not null"`                                    // Local note ID
+	ClipName string // Clip name on the field unit, uploaded separately
+}
+
+// DataQualityReport stores the data quality report of a day
+type DataQualityReport struct {
+	ID        uint      `gorm:"primaryKey"`
+	Date      string    `gorm:"uniqueIndex;not null;size:10"` // Report day (YYYY-MM-DD)
+	Flags     string    `gorm:"size:255"`                     // Comma separated quality flags, empty when none were raised
+	Report    string    `gorm:"type:text"`                    // Full report as JSON
+	CreatedAt time.Time // When the report was generated
+}
+
+// CalibrationMeasurement stores the measured level of a calibration tone
+type CalibrationMeasurement struct {
+	ID         uint      `gorm:"primaryKey"`
+	Source     string    `gorm:"index;not null;size:64"` // Audio source the tone was heard on
+	MeasuredAt time.Time `gorm:"index;not null"`         // Start of the tone
+	Frequency  float64   // Tone frequency in Hz
+	Level      float64   // RMS level of the tone in dBFS
+	Drift      float64   // Difference from the reference level in dB
+}
+
+// DetectionAggregate stores the hourly statistics of detections removed by a
+// detection retention policy
+type DetectionAggregate struct {
+	ID             uint    `gorm:"primaryKey"`
+	Date           string  `gorm:"uniqueIndex:idx_detection_aggregates_slot,priority:1;not null;size:10"` // Day of the detections (YYYY-MM-DD)
+	Hour           int     `gorm:"uniqueIndex:idx_detection_aggregates_slot,priority:2;not null"`         // Hour of the day, 0-23
+	ScientificName string  `gorm:"uniqueIndex:idx_detection_aggregates_slot,priority:3;not null;size:128"`
+	CommonName     string  `gorm:"size:128"`
+	SpeciesCode    string  `gorm:"size:16"`
+	Count          int     // Number of removed detections
+	ConfidenceSum  float64 // Sum of the confidences, for averaging across merged aggregates
+	MaxConfidence  float64
+}
+
+// DailyAggregate stores the materialized detection statistics of a species on
+// a day, including detections thinned into detection aggregates
+type DailyAggregate struct {
+	ID             uint    `gorm:"primaryKey"`
+	Date           string  `gorm:"uniqueIndex:idx_daily_aggregates_day,priority:1;not null;size:10"` // Day of the detections (YYYY-MM-DD)
+	ScientificName string  `gorm:"uniqueIndex:idx_daily_aggregates_day,priority:2;not null;size:128"`
+	CommonName     string  `gorm:"index;size:128"`
+	SpeciesCode    string  `gorm:"size:16"`
+	Count          int     // Number of detections
+	ConfidenceSum  float64 // Sum of the confidences, for averaging across days
+	MaxConfidence  float64
+	FirstTime      string `gorm:"size:8"` // Time of the first detection (HH:MM:SS)
+	LastTime       string `gorm:"size:8"` // Time of the last detection (HH:MM:SS)
+}
+
+// TaxonomyRemapRun records a taxonomy remapping applied to the detections
+type TaxonomyRemapRun struct {
+	ID         uint       `gorm:"primaryKey"`
+	RunID      string     `gorm:"uniqueIndex;not null;size:36"`
+	Source     string     `gorm:"size:255"` // Mapping file or description of the mapping
+	Detections int64      // Number of remapped detections
+	CreatedAt  time.Time  // When the remapping was applied
+	RevertedAt *time.Time // When the remapping was reverted, nil while applied
+}
+
+// TaxonomyRemapBackup stores the species of a detection before a taxonomy
+// remapping, so the remapping can be reverted
+type TaxonomyRemapBackup struct {
+	ID             uint   `gorm:"primaryKey"`
+	RunID          string `gorm:"index;not null;size:36"`
+	NoteID         uint   `gorm:"index;not null"`
+	SpeciesCode    string `gorm:"size:16"`
+	ScientificName string `gorm:"size:128"`
+	CommonName     string `gorm:"size:128"`
+}
+
+// ShadowDetection is a would-be detection of a candidate model running in
+// shadow mode, logged separately from the detections of the production model
+type ShadowDetection struct {
+	ID             uint      `gorm:"primaryKey"`
+	Model          string    `gorm:"index:idx_shadow_detections_model_begin,priority:1;not null;size:255"` // Candidate model path
+	Source         string    `gorm:"size:255"`                                                             // Audio source ID
+	BeginTime      time.Time `gorm:"index:idx_shadow_detections_model_begin,priority:2"`
+	EndTime        time.Time
+	ScientificName string `gorm:"size:128"`
+	CommonName     string `gorm:"size:128"`
+	SpeciesCode    string `gorm:"size:16"`
+	Confidence     float64
+	CreatedAt      time.Time
+}
+
+// StationProfile describes the site and equipment of the station. A station
+// has a single profile.
+type StationProfile struct {
+	ID              uint    `gorm:"primaryKey"`
+	Name            string  `gorm:"size:128"`
+	Description     string  `gorm:"size:2048"`
+	Habitat         string  `gorm:"size:64"`  // Habitat type, e.g. "woodland" or "urban garden"
+	MicrophoneModel string  `gorm:"size:128"` // Microphone in use
+	MountingHeight  float64 // Height of the microphone above ground in meters
+	Photo           []byte  // Photo of the site, nil when not uploaded
+	PhotoType       string  `gorm:"size:32"` // Content type of the photo
+	UpdatedAt       time.Time
+}
+
+// StationEquipmentChange records the equipment of the station from a point in
+// time, so detections can be related to the equipment that made them
+type StationEquipmentChange struct {
+	ID              uint      `gorm:"primaryKey"`
+	ChangedAt       time.Time `gorm:"index;not null"`
+	MicrophoneModel string    `gorm:"size:128"`
+	MountingHeight  float64
+	Note            string `gorm:"size:512"` // Reason for the change
+}
+
+// DeploymentChange is an entry of the deployment journal, recording a change
+// of equipment or configuration that may affect how detections are interpreted
+type DeploymentChange struct {
+	ID         uint      `gorm:"primaryKey"`
+	OccurredAt time.Time `gorm:"index;not null"`
+	Category   string    `gorm:"size:32;not null"` // equipment, gain, location, configuration or other
+	Summary    string    `gorm:"size:255;not null"`
+	Details    string    `gorm:"size:2048"`
+	CreatedAt  time.Time
+}
+
+// Dashboard is a user-composed layout of analytics widgets, kept server-side
+// so it follows the user across browsers and can be shared with other users
+type Dashboard struct {
+	ID        uint   `gorm:"primaryKey"`
+	Name      string `gorm:"size:128;not null"`
+	Owner     string `gorm:"size:128;index"` // username of the creator, empty when authentication is disabled
+	Shared    bool   `gorm:"index"`          // visible to all users, editable by the owner only
+	Layout    string `gorm:"type:text"`      // JSON array of widgets
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// SavedSearch is a detection filter expression saved by a user. Subscribed
+// searches notify of new detections matching them.
+type SavedSearch struct {
+	ID         uint          `gorm:"primaryKey"`
+	Name       string        `gorm:"size:128;not null"`
+	Owner      string        `gorm:"size:128;index"`     // username of the creator, empty when authentication is disabled
+	Query      string        `gorm:"size:1000;not null"` // filter expression, as the q parameter of the detections list
+	Subscribed bool          `gorm:"index"`
+	Cooldown   time.Duration // minimum time between notifications of a species, 0 for none
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// Tag is a label users attach to detections, such as "banded bird" or
+// "nestbox". Names are unique regardless of case.
+type Tag struct {
+	ID        uint   `gorm:"primaryKey"`
+	Name      string `gorm:"size:64;uniqueIndex;not null"`
+	CreatedAt time.Time
+}
+
+// NoteTag attaches a tag to a detection. It is the join table of Note.Tags,
+// created with the notes table.
+type NoteTag struct {
+	NoteID uint `gorm:"primaryKey"`
+	TagID  uint `gorm:"primaryKey;index"`
+}
+
+// TrashedNote is a deleted detection kept in the trash until it is restored
+// or purged. The detection with its results, review and comments is stored as
+// JSON, so the detection tables and their queries are not affected.
+type TrashedNote struct {
+	ID             uint      `gorm:"primaryKey"`
+	NoteID         uint      `gorm:"uniqueIndex;not null"` // ID of the deleted detection, reused on restore
+	DeletedAt      time.Time `gorm:"index;not null"`
+	Date           string    `gorm:"size:10"`
+	Time           string    `gorm:"size:8"`
+	ScientificName string    `gorm:"size:128"`
+	CommonName     string    `gorm:"size:128"`
+	Confidence     float64
+	ClipName       string
+	Data           string `gorm:"type:text"` // The detection as JSON
+}
+
+// NotificationRecord is a notification kept in the notification history, so
+// notifications survive restarts. Metadata is stored as JSON.
+type NotificationRecord struct {
+	ID        string     `gorm:"primaryKey;size:36"`
+	Type      string     `gorm:"size:32;index"`
+	Priority  string     `gorm:"size:16"`
+	Status    string     `gorm:"size:16;index"`
+	Title     string     `gorm:"size:512"`
+	Message   string     `gorm:"type:text"`
+	Component string     `gorm:"size:64"`
+	Timestamp time.Time  `gorm:"index;not null"`
+	Metadata  string     `gorm:"type:text"`
+	ExpiresAt *time.Time `gorm:"index"` // nil for notifications that do not expire
+}
+
+// DeliveryRecord is an outbound delivery waiting in the delivery queue for
+// its next attempt, or kept after it was delivered or dead-lettered. The
+// payload is what the delivery target needs to send it again.
+type DeliveryRecord struct {
+	ID            uint      `gorm:"primaryKey"`
+	Target        string    `gorm:"size:128;index"`
+	Description   string    `gorm:"size:512"`
+	Payload       []byte    // encoded by the delivery target
+	Status        string    `gorm:"size:16;index"`
+	Attempts      int       `gorm:"not null;default:0"`
+	MaxAttempts   int       `gorm:"not null;default:0"`
+	NextAttemptAt time.Time `gorm:"index;not null"`
+	LastError     string    `gorm:"type:text"`
+	CreatedAt     time.Time `gorm:"index;not null"`
+	UpdatedAt     time.Time
+}
+
+// ManifestEntry records an upload or export with the SHA-256 checksum and
+// size of what was sent and where it went, so its integrity can be
+// re-checked later
+type ManifestEntry struct {
+	ID           uint       `gorm:"primaryKey"`
+	Kind         string     `gorm:"size:32;index"` // birdweather, backup or export
+	Name         string     `gorm:"size:256"`
+	Destination  string     `gorm:"size:512"`
+	Path         string     `gorm:"size:1024"` // local copy re-checked by verification, empty when only stored remotely
+	SHA256       string     `gorm:"size:64;index"`
+	Size         int64      `gorm:"not null;default:0"`
+	CreatedAt    time.Time  `gorm:"index;not null"`
+	VerifiedAt   *time.Time // nil until verified
+	VerifyStatus string     `gorm:"size:16"`
+}
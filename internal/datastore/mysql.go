@@ -224,6 +224,7 @@ func (store *MySQLStore) Optimize(ctx context.Context) error {
 
 // UpdateNote updates specific fields of a note in MySQL
 func (m *MySQLStore) UpdateNote(id string, updates map[string]interface{}) error {
+	defer m.markDailyAggregatesDirty(m.noteAggregateDates(id, updates)...)
 	return m.DB.Model(&Note{}).Where("id = ?", id).Updates(updates).Error
 }
 
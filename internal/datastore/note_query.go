@@ -0,0 +1,501 @@
+// note_query.go: Filter expression language for detections
+package datastore
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/errors"
+	"gorm.io/gorm"
+)
+
+// Limits of a filter expression, keeping the generated SQL small
+const (
+	maxNoteQueryLength = 1000
+	maxNoteQueryTerms  = 32
+	maxNoteQueryDepth  = 8
+)
+
+// NoteQuery is a parsed filter expression over detections, such as
+//
+//	species:"Great Tit" confidence>=0.8 hour:4..9 source:rtsp1
+//
+// Terms are joined by AND unless separated by OR, can be negated with a
+// leading - or NOT and grouped with parentheses. A word without a field
+// matches part of the common or scientific name. Fields map to fixed columns
+// and values are always bound as SQL parameters.
+type NoteQuery struct {
+	expr  string
+	where string
+	args  []any
+}
+
+// String returns the expression the query was parsed from
+func (q *NoteQuery) String() string {
+	return q.expr
+}
+
+// scope restricts a notes query to the detections matching the expression
+func (q *NoteQuery) scope(db *gorm.DB) *gorm.DB {
+	if q == nil {
+		return db
+	}
+	return db.Where("("+q.where+")", q.args...)
+}
+
+// ParseNoteQuery parses a filter expression. Fields are:
+//
+//	species:NAME            exact common or scientific name, or species code
+//	confidence>=0.8         also 80%, with :, =, <, <=, >, >= or a range a..b
+//	hour:4..9               hours of day, inclusive, may wrap past midnight
+//	date:2024-05-01..       dates, with an open start or end
+//	source:NODE             name of the node that made the detection
+//	verified:correct        correct, false_positive, true (reviewed) or false
+//	locked:true             true or false
+//	tag:NAME                attached tag, regardless of case
+func ParseNoteQuery(expr string) (*NoteQuery, error) {
+	if len(expr) > maxNoteQueryLength {
+		return nil, noteQueryError(expr, 0, fmt.Sprintf("longer than %d characters", maxNoteQueryLength))
+	}
+	tokens, err := lexNoteQuery(expr)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, noteQueryError(expr, 0, "empty expression")
+	}
+
+	p := &noteQueryParser{expr: expr, tokens: tokens}
+	where, args, err := p.parseOr(0)
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok != nil {
+		return nil, noteQueryError(expr, tok.pos, fmt.Sprintf("unexpected %q", tok.text))
+	}
+	return &NoteQuery{expr: expr, where: where, args: args}, nil
+}
+
+// QueryNotes returns detections matching a filter expression, newest first,
+// with the total number of matches
+func (ds *DataStore) QueryNotes(ctx context.Context, query *NoteQuery, limit, offset int) ([]Note, int64, error) {
+	base := query.scope(ds.DB.WithContext(ctx).Model(&Note{}))
+
+	var total int64
+	if err := base.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, dbError(err, "count_query_notes", errors.PriorityMedium,
+			"query", query.String(),
+			"table", "notes")
+	}
+
+	var notes []Note
+	err := base.Preload("Review").Preload("Lock").Preload("Comments", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at DESC")
+	}).Order("date DESC, time DESC, id DESC").Limit(limit).Offset(offset).Find(&notes).Error
+	if err != nil {
+		return nil, 0, dbError(err, "query_notes", errors.PriorityMedium,
+			"query", query.String(),
+			"limit", limit,
+			"offset", offset,
+			"table", "notes")
+	}
+
+	// Populate virtual fields
+	for i := range notes {
+		if notes[i].Review != nil {
+			notes[i].Verified = notes[i].Review.Verified
+		}
+		notes[i].Locked = notes[i].Lock != nil
+	}
+	return notes, total, nil
+}
+
+// noteQueryError returns a validation error pointing at a position of the expression
+func noteQueryError(expr string, pos int, message string) error {
+	return errors.Newf("invalid query at position %d: %s", pos+1, message).
+		Component("datastore").
+		Category(errors.CategoryValidation).
+		Context("query", expr).
+		Build()
+}
+
+// noteQueryToken is a keyword, parenthesis or term of an expression
+type noteQueryToken struct {
+	pos   int
+	text  string // keyword or parenthesis, or the whole term
+	field string // lower case field of a term, empty for a bare word
+	op    string // operator of a term with a field
+	value string // value of a term, unquoted
+}
+
+// isKeyword reports whether the token is the keyword or parenthesis s
+func (t *noteQueryToken) isKeyword(s string) bool {
+	return t.field == "" && t.op == "" && t.value == "" && t.text == s
+}
+
+// noteQueryOperators are the operators between a field and its value,
+// longest first
+var noteQueryOperators = []string{">=", "<=", ":", "=", ">", "<"}
+
+// lexNoteQuery splits an expression into tokens
+func lexNoteQuery(expr string) ([]noteQueryToken, error) {
+	var tokens []noteQueryToken
+	i := 0
+	for i < len(expr) {
+		switch c := expr[i]; {
+		case isNoteQuerySpace(c):
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, noteQueryToken{pos: i, text: string(c)})
+			i++
+		case c == '-' && i+1 < len(expr) && !isNoteQuerySpace(expr[i+1]):
+			tokens = append(tokens, noteQueryToken{pos: i, text: "NOT"})
+			i++
+		default:
+			tok, next, err := lexNoteQueryTerm(expr, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, tok)
+			i = next
+		}
+	}
+	return tokens, nil
+}
+
+// isNoteQuerySpace reports whether a byte separates tokens. Only ASCII white
+// space does, leaving names with non-ASCII letters intact.
+func isNoteQuerySpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// lexNoteQueryTerm reads the term starting at start, returning it and the
+// position after it
+func lexNoteQueryTerm(expr string, start int) (noteQueryToken, int, error) {
+	tok := noteQueryToken{pos: start}
+
+	// A field is a run of letters followed by an operator
+	i := start
+	for i < len(expr) && (expr[i] >= 'a' && expr[i] <= 'z' || expr[i] >= 'A' && expr[i] <= 'Z' || expr[i] == '_') {
+		i++
+	}
+	if i > start {
+		for _, op := range noteQueryOperators {
+			if strings.HasPrefix(expr[i:], op) {
+				tok.field, tok.op = strings.ToLower(expr[start:i]), op
+				i += len(op)
+				break
+			}
+		}
+	}
+	if tok.op == "" {
+		i = start
+	}
+
+	valueStart := i
+	if i < len(expr) && expr[i] == '"' {
+		end := strings.IndexByte(expr[i+1:], '"')
+		if end < 0 {
+			return tok, 0, noteQueryError(expr, i, "unterminated quote")
+		}
+		tok.value = expr[i+1 : i+1+end]
+		i += end + 2
+	} else {
+		for i < len(expr) && !isNoteQuerySpace(expr[i]) && expr[i] != '(' && expr[i] != ')' {
+			i++
+		}
+		tok.value = expr[valueStart:i]
+		// Unquoted AND, OR and NOT are keywords
+		if tok.op == "" && (tok.value == "AND" || tok.value == "OR" || tok.value == "NOT") {
+			tok.text, tok.value = tok.value, ""
+			return tok, i, nil
+		}
+	}
+	tok.text = expr[start:i]
+	if tok.value == "" {
+		return tok, 0, noteQueryError(expr, start, fmt.Sprintf("missing value in %q", tok.text))
+	}
+	return tok, i, nil
+}
+
+// noteQueryParser compiles tokens to an SQL condition by recursive descent
+type noteQueryParser struct {
+	expr   string
+	tokens []noteQueryToken
+	next   int
+	terms  int
+}
+
+// peek returns the next token, nil at the end
+func (p *noteQueryParser) peek() *noteQueryToken {
+	if p.next >= len(p.tokens) {
+		return nil
+	}
+	return &p.tokens[p.next]
+}
+
+// parseOr parses terms separated by OR
+func (p *noteQueryParser) parseOr(depth int) (string, []any, error) {
+	where, args, err := p.parseAnd(depth)
+	if err != nil {
+		return "", nil, err
+	}
+	for tok := p.peek(); tok != nil && tok.isKeyword("OR"); tok = p.peek() {
+		p.next++
+		right, rightArgs, err := p.parseAnd(depth)
+		if err != nil {
+			return "", nil, err
+		}
+		where, args = where+" OR "+right, append(args, rightArgs...)
+	}
+	return where, args, nil
+}
+
+// parseAnd parses terms joined by AND, which may be left out
+func (p *noteQueryParser) parseAnd(depth int) (string, []any, error) {
+	var conditions []string
+	var args []any
+	for {
+		tok := p.peek()
+		if tok == nil || tok.isKeyword("OR") || tok.isKeyword(")") {
+			break
+		}
+		if tok.isKeyword("AND") {
+			if len(conditions) == 0 {
+				return "", nil, noteQueryError(p.expr, tok.pos, "AND without a term before it")
+			}
+			p.next++
+			continue
+		}
+		where, termArgs, err := p.parseUnary(depth)
+		if err != nil {
+			return "", nil, err
+		}
+		conditions, args = append(conditions, where), append(args, termArgs...)
+	}
+	if len(conditions) == 0 {
+		pos := len(p.expr)
+		if tok := p.peek(); tok != nil {
+			pos = tok.pos
+		}
+		return "", nil, noteQueryError(p.expr, pos, "expected a term")
+	}
+	return strings.Join(conditions, " AND "), args, nil
+}
+
+// parseUnary parses a negated term, a group or a term
+func (p *noteQueryParser) parseUnary(depth int) (string, []any, error) {
+	tok := p.peek()
+	if depth >= maxNoteQueryDepth {
+		return "", nil, noteQueryError(p.expr, tok.pos, fmt.Sprintf("nested deeper than %d levels", maxNoteQueryDepth))
+	}
+	p.next++
+
+	switch {
+	case tok.isKeyword("NOT"):
+		if p.peek() == nil {
+			return "", nil, noteQueryError(p.expr, tok.pos, "NOT without a term after it")
+		}
+		where, args, err := p.parseUnary(depth + 1)
+		if err != nil {
+			return "", nil, err
+		}
+		return "NOT (" + where + ")", args, nil
+	case tok.isKeyword("("):
+		where, args, err := p.parseOr(depth + 1)
+		if err != nil {
+			return "", nil, err
+		}
+		if closing := p.peek(); closing == nil || !closing.isKeyword(")") {
+			return "", nil, noteQueryError(p.expr, tok.pos, "unclosed parenthesis")
+		}
+		p.next++
+		return "(" + where + ")", args, nil
+	}
+
+	p.terms++
+	if p.terms > maxNoteQueryTerms {
+		return "", nil, noteQueryError(p.expr, tok.pos, fmt.Sprintf("more than %d terms", maxNoteQueryTerms))
+	}
+	where, args, err := compileNoteQueryTerm(tok)
+	if err != nil {
+		return "", nil, noteQueryError(p.expr, tok.pos, err.Error())
+	}
+	return "(" + where + ")", args, nil
+}
+
+// compileNoteQueryTerm returns the SQL condition of a term
+func compileNoteQueryTerm(tok *noteQueryToken) (string, []any, error) {
+	equality := tok.op == ":" || tok.op == "="
+	switch tok.field {
+	case "":
+		if tok.isKeyword("(") || tok.isKeyword(")") || tok.isKeyword("AND") || tok.isKeyword("OR") {
+			return "", nil, errors.NewStd(fmt.Sprintf("unexpected %q", tok.text))
+		}
+		return "notes.common_name LIKE ? OR notes.scientific_name LIKE ?", []any{"%" + tok.value + "%", "%" + tok.value + "%"}, nil
+	case "species":
+		if !equality {
+			return "", nil, errors.NewStd("species only supports :")
+		}
+		return "notes.scientific_name = ? OR notes.common_name = ? OR notes.species_code = ?", []any{tok.value, tok.value, tok.value}, nil
+	case "source":
+		if !equality {
+			return "", nil, errors.NewStd("source only supports :")
+		}
+		return "notes.source_node = ?", []any{tok.value}, nil
+	case "confidence":
+		return compileNoteQueryRange("notes.confidence", tok, parseNoteQueryConfidence)
+	case "date":
+		return compileNoteQueryRange("notes.date", tok, parseNoteQueryDate)
+	case "hour":
+		return compileNoteQueryHour(tok)
+	case "verified":
+		if !equality {
+			return "", nil, errors.NewStd("verified only supports :")
+		}
+		reviewed := "EXISTS (SELECT 1 FROM note_reviews WHERE note_reviews.note_id = notes.id AND note_reviews.verified "
+		switch strings.ToLower(tok.value) {
+		case "correct", "false_positive":
+			return reviewed + "= ?)", []any{strings.ToLower(tok.value)}, nil
+		case "true":
+			return reviewed + "!= '')", nil, nil
+		case "false":
+			return "NOT " + reviewed + "!= '')", nil, nil
+		}
+		return "", nil, errors.NewStd("verified must be correct, false_positive, true or false")
+	case "tag":
+		if !equality {
+			return "", nil, errors.NewStd("tag only supports :")
+		}
+		return "EXISTS (SELECT 1 FROM note_tags JOIN tags ON tags.id = note_tags.tag_id WHERE note_tags.note_id = notes.id AND LOWER(tags.name) = LOWER(?))", []any{tok.value}, nil
+	case "locked":
+		locked := "EXISTS (SELECT 1 FROM note_locks WHERE note_locks.note_id = notes.id)"
+		value, err := strconv.ParseBool(tok.value)
+		if !equality || err != nil {
+			return "", nil, errors.NewStd("locked must be locked:true or locked:false")
+		}
+		if !value {
+			locked = "NOT " + locked
+		}
+		return locked, nil, nil
+	}
+	return "", nil, errors.NewStd(fmt.Sprintf("unknown field %q", tok.field))
+}
+
+// compileNoteQueryRange returns the condition of a comparison or range of an
+// ordered column, with values converted by parse
+func compileNoteQueryRange(column string, tok *noteQueryToken, parse func(string) (any, error)) (string, []any, error) {
+	if tok.op != ":" && tok.op != "=" {
+		value, err := parse(tok.value)
+		if err != nil {
+			return "", nil, err
+		}
+		return column + " " + tok.op + " ?", []any{value}, nil
+	}
+
+	lo, hi, isRange := strings.Cut(tok.value, "..")
+	if !isRange {
+		value, err := parse(tok.value)
+		if err != nil {
+			return "", nil, err
+		}
+		return column + " = ?", []any{value}, nil
+	}
+	if lo == "" && hi == "" {
+		return "", nil, errors.NewStd("range needs a start or an end")
+	}
+	var conditions []string
+	var args []any
+	for _, bound := range []struct{ value, op string }{{lo, ">="}, {hi, "<="}} {
+		if bound.value == "" {
+			continue
+		}
+		value, err := parse(bound.value)
+		if err != nil {
+			return "", nil, err
+		}
+		conditions, args = append(conditions, column+" "+bound.op+" ?"), append(args, value)
+	}
+	return strings.Join(conditions, " AND "), args, nil
+}
+
+// parseNoteQueryConfidence parses a confidence as a fraction or a percentage
+func parseNoteQueryConfidence(value string) (any, error) {
+	percent := strings.HasSuffix(value, "%")
+	confidence, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+	if percent {
+		confidence /= 100
+	}
+	if err != nil || confidence < 0 || confidence > 1 {
+		return nil, errors.NewStd(fmt.Sprintf("confidence %q must be between 0 and 1, or 0%% and 100%%", value))
+	}
+	return confidence, nil
+}
+
+// parseNoteQueryDate validates a date
+func parseNoteQueryDate(value string) (any, error) {
+	if _, err := time.Parse(time.DateOnly, value); err != nil {
+		return nil, errors.NewStd(fmt.Sprintf("date %q must be YYYY-MM-DD", value))
+	}
+	return value, nil
+}
+
+// parseNoteQueryHour parses an hour of day
+func parseNoteQueryHour(value string) (int, error) {
+	hour, err := strconv.Atoi(value)
+	if err != nil || hour < 0 || hour > 23 {
+		return 0, errors.NewStd(fmt.Sprintf("hour %q must be between 0 and 23", value))
+	}
+	return hour, nil
+}
+
+// compileNoteQueryHour returns the condition of an hour term on the time column
+func compileNoteQueryHour(tok *noteQueryToken) (string, []any, error) {
+	clock := func(hour int) string { return fmt.Sprintf("%02d:00:00", hour) }
+
+	if tok.op != ":" && tok.op != "=" {
+		hour, err := parseNoteQueryHour(tok.value)
+		if err != nil {
+			return "", nil, err
+		}
+		switch tok.op {
+		case ">":
+			hour++
+		case "<=":
+			hour++
+		}
+		op := ">="
+		if tok.op == "<" || tok.op == "<=" {
+			op = "<"
+		}
+		return "notes.time " + op + " ?", []any{clock(hour)}, nil
+	}
+
+	lo, hi, isRange := strings.Cut(tok.value, "..")
+	switch {
+	case !isRange:
+		hi = lo
+	case lo == "" && hi == "":
+		return "", nil, errors.NewStd("range needs a start or an end")
+	case lo == "":
+		lo = "0"
+	case hi == "":
+		hi = "23"
+	}
+	start, err := parseNoteQueryHour(lo)
+	if err != nil {
+		return "", nil, err
+	}
+	end, err := parseNoteQueryHour(hi)
+	if err != nil {
+		return "", nil, err
+	}
+	if start <= end {
+		return "notes.time >= ? AND notes.time < ?", []any{clock(start), clock(end + 1)}, nil
+	}
+	// Wraps past midnight, such as hour:22..3
+	return "notes.time >= ? OR notes.time < ?", []any{clock(start), clock(end + 1)}, nil
+}
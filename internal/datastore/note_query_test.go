@@ -0,0 +1,114 @@
+// note_query_test.go: Tests for the detection filter expression language
+package datastore
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNoteQueryErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"", "empty expression"},
+		{"colour:red", `unknown field "colour"`},
+		{`species:"Great Tit`, "unterminated quote"},
+		{"confidence>=1.5", "between 0 and 1"},
+		{"species>Great", "species only supports :"},
+		{"hour:25", "between 0 and 23"},
+		{"date:2024-13-01", "YYYY-MM-DD"},
+		{"date:..", "start or an end"},
+		{"(hour:5", "unclosed parenthesis"},
+		{"hour:5)", `unexpected ")"`},
+		{"hour:5 OR", "expected a term"},
+		{"AND hour:5", "AND without a term"},
+		{"verified:maybe", "verified must be"},
+		{"source:", "missing value"},
+		{strings.Repeat("(", 10) + "robin" + strings.Repeat(")", 10), "nested deeper"},
+		{strings.Repeat("robin ", 33), "more than 32 terms"},
+	}
+	for _, tt := range tests {
+		_, err := ParseNoteQuery(tt.expr)
+		require.Error(t, err, tt.expr)
+		assert.Contains(t, err.Error(), tt.want, tt.expr)
+	}
+}
+
+func TestParseNoteQueryBindsValues(t *testing.T) {
+	t.Parallel()
+
+	q, err := ParseNoteQuery(`species:"Robin'; DROP TABLE notes; --" OR -hour:22..3`)
+	require.NoError(t, err)
+	assert.NotContains(t, q.where, "DROP")
+	assert.Equal(t, []any{"Robin'; DROP TABLE notes; --", "Robin'; DROP TABLE notes; --", "Robin'; DROP TABLE notes; --", "22:00:00", "04:00:00"}, q.args)
+	assert.Contains(t, q.where, "NOT ((notes.time >= ? OR notes.time < ?))")
+}
+
+func TestQueryNotes(t *testing.T) {
+	t.Parallel()
+
+	ds := setupTestDB(t)
+	require.NoError(t, ds.DB.AutoMigrate(&NoteReview{}, &NoteComment{}, &NoteLock{}))
+	notes := []Note{
+		{Date: "2024-05-01", Time: "04:30:00", ScientificName: "Parus major", CommonName: "Great Tit", Confidence: 0.85, SourceNode: "rtsp1"},
+		{Date: "2024-05-01", Time: "09:59:59", ScientificName: "Parus major", CommonName: "Great Tit", Confidence: 0.9, SourceNode: "rtsp2"},
+		{Date: "2024-05-02", Time: "10:00:00", ScientificName: "Parus major", CommonName: "Great Tit", Confidence: 0.95, SourceNode: "rtsp1"},
+		{Date: "2024-05-02", Time: "06:00:00", ScientificName: "Parus major", CommonName: "Great Tit", Confidence: 0.7, SourceNode: "rtsp1"},
+		{Date: "2024-05-03", Time: "23:15:00", ScientificName: "Strix aluco", CommonName: "Tawny Owl", SpeciesCode: "tawowl1", Confidence: 0.8, SourceNode: "rtsp1"},
+		{Date: "2024-06-01", Time: "05:00:00", ScientificName: "Erithacus rubecula", CommonName: "European Robin", Confidence: 0.6, SourceNode: "rtsp2"},
+	}
+	require.NoError(t, ds.DB.Create(&notes).Error)
+	require.NoError(t, ds.DB.Create(&NoteReview{NoteID: notes[4].ID, Verified: "correct"}).Error)
+	require.NoError(t, ds.DB.Create(&NoteLock{NoteID: notes[5].ID}).Error)
+
+	tests := []struct {
+		expr string
+		want []int // indexes into notes, newest first
+	}{
+		{`species:"Great Tit" confidence>=0.8 hour:4..9 source:rtsp1`, []int{0}},
+		{"species:Parus_major", nil},
+		{"species:tawowl1 OR robin", []int{5, 4}},
+		{"tit confidence<80%", []int{3}},
+		{"hour:23..4", []int{4, 0}},
+		{"hour>=10 -owl", []int{2}},
+		{"date:2024-05-02.. NOT (robin OR owl)", []int{2, 3}},
+		{"date:..2024-05-01 AND source=rtsp2", []int{1}},
+		{"verified:correct", []int{4}},
+		{"verified:false locked:false", []int{2, 3, 1, 0}},
+		{"locked:true", []int{5}},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		q, err := ParseNoteQuery(tt.expr)
+		require.NoError(t, err, tt.expr)
+		got, total, err := ds.QueryNotes(ctx, q, 10, 0)
+		require.NoError(t, err, tt.expr)
+
+		var want []uint
+		for _, i := range tt.want {
+			want = append(want, notes[i].ID)
+		}
+		var ids []uint
+		for i := range got {
+			ids = append(ids, got[i].ID)
+		}
+		assert.Equal(t, want, ids, tt.expr)
+		assert.Equal(t, int64(len(tt.want)), total, tt.expr)
+	}
+
+	// Pages keep the total of all matches
+	q, err := ParseNoteQuery("tit")
+	require.NoError(t, err)
+	page, total, err := ds.QueryNotes(ctx, q, 2, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), total)
+	require.Len(t, page, 2)
+	assert.Equal(t, notes[1].ID, page[0].ID)
+}
@@ -0,0 +1,72 @@
+// notes_cursor.go: Keyset pagination of detections
+package datastore
+
+import (
+	"context"
+
+	"github.com/tphakala/birdnet-go/internal/errors"
+	"gorm.io/gorm"
+)
+
+// NoteCursor is the position of a detection in the newest first order by
+// date, time and ID, from which the next page continues
+type NoteCursor struct {
+	Date string `json:"d"`
+	Time string `json:"t"`
+	ID   uint   `json:"i"`
+}
+
+// NotePageFilter narrows the detections paged through
+type NotePageFilter struct {
+	Search    string // part of the common or scientific name
+	Species   string // exact common or scientific name
+	StartDate string // YYYY-MM-DD, inclusive
+	EndDate   string // YYYY-MM-DD, inclusive
+	Query     *NoteQuery
+}
+
+// GetNotesAfter returns up to limit detections matching the filter, newest
+// first by date, time and ID, starting after the cursor or from the newest
+// without one. Unlike an offset, the cursor is resolved through the date and
+// time index, so later pages of large databases are as fast as the first.
+func (ds *DataStore) GetNotesAfter(ctx context.Context, filter NotePageFilter, after *NoteCursor, limit int) ([]Note, error) {
+	query := ds.DB.WithContext(ctx).Preload("Review").Preload("Lock").Preload("Comments", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at DESC")
+	})
+	query = speciesScope(query, filter.Species)
+	query = filter.Query.scope(query)
+	if filter.Search != "" {
+		query = query.Where("common_name LIKE ? OR scientific_name LIKE ?", "%"+filter.Search+"%", "%"+filter.Search+"%")
+	}
+	if filter.StartDate != "" {
+		query = query.Where("date >= ?", filter.StartDate)
+	}
+	if filter.EndDate != "" {
+		query = query.Where("date <= ?", filter.EndDate)
+	}
+	if after != nil {
+		query = query.Where("date < ? OR (date = ? AND time < ?) OR (date = ? AND time = ? AND id < ?)",
+			after.Date, after.Date, after.Time, after.Date, after.Time, after.ID)
+	}
+
+	var notes []Note
+	if err := query.Order("date DESC, time DESC, id DESC").Limit(limit).Find(&notes).Error; err != nil {
+		return nil, dbError(err, "get_notes_after", errors.PriorityMedium,
+			"limit", limit,
+			"table", "notes")
+	}
+
+	// Populate virtual fields
+	for i := range notes {
+		if notes[i].Review != nil {
+			notes[i].Verified = notes[i].Review.Verified
+		}
+		notes[i].Locked = notes[i].Lock != nil
+	}
+	return notes, nil
+}
+
+// Cursor returns the position of a detection for continuing after it
+func (n *Note) Cursor() NoteCursor {
+	return NoteCursor{Date: n.Date, Time: n.Time, ID: n.ID}
+}
@@ -0,0 +1,54 @@
+// notes_cursor_test.go: Tests for keyset pagination of detections
+package datastore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetNotesAfter(t *testing.T) {
+	t.Parallel()
+
+	ds := setupTestDB(t)
+	require.NoError(t, ds.DB.AutoMigrate(&NoteReview{}, &NoteComment{}, &NoteLock{}))
+	notes := []Note{
+		{Date: "2024-05-01", Time: "06:10:00", ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird"},
+		{Date: "2024-05-02", Time: "07:00:00", ScientificName: "Pica pica", CommonName: "Eurasian Magpie"},
+		{Date: "2024-05-02", Time: "07:00:00", ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird"},
+		{Date: "2024-05-02", Time: "05:30:00", ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird"},
+		{Date: "2024-05-03", Time: "04:00:00", ScientificName: "Erithacus rubecula", CommonName: "European Robin"},
+	}
+	require.NoError(t, ds.DB.Create(&notes).Error)
+	ctx := context.Background()
+
+	// Page through all detections two at a time
+	var ids []uint
+	var after *NoteCursor
+	for range 3 {
+		page, err := ds.GetNotesAfter(ctx, NotePageFilter{}, after, 2)
+		require.NoError(t, err)
+		for i := range page {
+			ids = append(ids, page[i].ID)
+		}
+		if len(page) == 0 {
+			break
+		}
+		cursor := page[len(page)-1].Cursor()
+		after = &cursor
+	}
+	// Same date and time are ordered by ID
+	assert.Equal(t, []uint{notes[4].ID, notes[2].ID, notes[1].ID, notes[3].ID, notes[0].ID}, ids)
+
+	page, err := ds.GetNotesAfter(ctx, NotePageFilter{Species: "Turdus merula", EndDate: "2024-05-02"}, nil, 10)
+	require.NoError(t, err)
+	require.Len(t, page, 3)
+	assert.Equal(t, notes[2].ID, page[0].ID)
+
+	page, err = ds.GetNotesAfter(ctx, NotePageFilter{Search: "robin", StartDate: "2024-05-03"}, nil, 10)
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	assert.Equal(t, "European Robin", page[0].CommonName)
+}
@@ -0,0 +1,153 @@
+// notifications.go: Storage of the notification history
+package datastore
+
+import (
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/errors"
+	"gorm.io/gorm"
+)
+
+// NotificationFilter selects notifications of the history. Empty fields match
+// all notifications.
+type NotificationFilter struct {
+	Types      []string
+	Priorities []string
+	Statuses   []string
+	Component  string
+	Since      *time.Time
+	Until      *time.Time
+	Limit      int // 0 for no limit
+	Offset     int
+}
+
+// SaveNotification stores a notification, replacing a stored notification
+// with the same ID
+func (ds *DataStore) SaveNotification(record *NotificationRecord) error {
+	if record.ID == "" {
+		return validationError("notification ID cannot be empty", "id", record.ID)
+	}
+	if err := ds.DB.Save(record).Error; err != nil {
+		return dbError(err, "save_notification", errors.PriorityLow,
+			"notification_id", record.ID,
+			"table", "notification_records")
+	}
+	return nil
+}
+
+// GetNotification returns a stored notification
+func (ds *DataStore) GetNotification(id string) (*NotificationRecord, error) {
+	var record NotificationRecord
+	err := ds.DB.Where("id = ?", id).First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, notFoundError("notification", id)
+	}
+	if err != nil {
+		return nil, dbError(err, "get_notification", errors.PriorityLow,
+			"notification_id", id,
+			"table", "notification_records")
+	}
+	return &record, nil
+}
+
+// ListNotifications returns the notifications matching filter, newest first
+func (ds *DataStore) ListNotifications(filter *NotificationFilter) ([]NotificationRecord, error) {
+	query := notificationQuery(ds.DB, filter).Order("timestamp DESC")
+	if filter != nil {
+		if filter.Limit > 0 {
+			query = query.Limit(filter.Limit)
+		}
+		if filter.Offset > 0 {
+			query = query.Offset(filter.Offset)
+		}
+	}
+
+	var records []NotificationRecord
+	if err := query.Find(&records).Error; err != nil {
+		return nil, dbError(err, "list_notifications", errors.PriorityLow,
+			"table", "notification_records")
+	}
+	return records, nil
+}
+
+// CountNotifications returns the number of notifications matching filter,
+// ignoring its limit and offset
+func (ds *DataStore) CountNotifications(filter *NotificationFilter) (int64, error) {
+	var count int64
+	if err := notificationQuery(ds.DB, filter).Count(&count).Error; err != nil {
+		return 0, dbError(err, "count_notifications", errors.PriorityLow,
+			"table", "notification_records")
+	}
+	return count, nil
+}
+
+// DeleteNotification removes a notification from the history
+func (ds *DataStore) DeleteNotification(id string) error {
+	if err := ds.DB.Where("id = ?", id).Delete(&NotificationRecord{}).Error; err != nil {
+		return dbError(err, "delete_notification", errors.PriorityLow,
+			"notification_id", id,
+			"table", "notification_records")
+	}
+	return nil
+}
+
+// PruneNotifications removes the notifications expired at now and then the
+// oldest notifications beyond the newest keep, 0 to keep all. It returns the
+// number of notifications removed.
+func (ds *DataStore) PruneNotifications(now time.Time, keep int) (int64, error) {
+	result := ds.DB.Where("expires_at IS NOT NULL AND expires_at < ?", now).Delete(&NotificationRecord{})
+	if result.Error != nil {
+		return 0, dbError(result.Error, "delete_expired_notifications", errors.PriorityLow,
+			"table", "notification_records")
+	}
+	removed := result.RowsAffected
+	if keep <= 0 {
+		return removed, nil
+	}
+
+	// The timestamp of the oldest notification kept
+	var cutoff NotificationRecord
+	err := ds.DB.Select("timestamp").Order("timestamp DESC").Offset(keep - 1).Limit(1).Take(&cutoff).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return removed, nil
+	}
+	if err != nil {
+		return removed, dbError(err, "find_notification_cutoff", errors.PriorityLow,
+			"table", "notification_records",
+			"keep", keep)
+	}
+	result = ds.DB.Where("timestamp < ?", cutoff.Timestamp).Delete(&NotificationRecord{})
+	if result.Error != nil {
+		return removed, dbError(result.Error, "trim_notifications", errors.PriorityLow,
+			"table", "notification_records",
+			"keep", keep)
+	}
+	return removed + result.RowsAffected, nil
+}
+
+// notificationQuery returns a query of the notifications matching filter
+func notificationQuery(db *gorm.DB, filter *NotificationFilter) *gorm.DB {
+	query := db.Model(&NotificationRecord{})
+	if filter == nil {
+		return query
+	}
+	if len(filter.Types) > 0 {
+		query = query.Where("type IN ?", filter.Types)
+	}
+	if len(filter.Priorities) > 0 {
+		query = query.Where("priority IN ?", filter.Priorities)
+	}
+	if len(filter.Statuses) > 0 {
+		query = query.Where("status IN ?", filter.Statuses)
+	}
+	if filter.Component != "" {
+		query = query.Where("component = ?", filter.Component)
+	}
+	if filter.Since != nil {
+		query = query.Where("timestamp >= ?", *filter.Since)
+	}
+	if filter.Until != nil {
+		query = query.Where("timestamp <= ?", *filter.Until)
+	}
+	return query
+}
@@ -0,0 +1,72 @@
+// notifications_test.go: Tests for the notification history
+package datastore
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotificationHistory(t *testing.T) {
+	t.Parallel()
+
+	ds := setupTestDB(t)
+	require.NoError(t, ds.DB.AutoMigrate(&NotificationRecord{}))
+
+	start := time.Date(2024, 5, 1, 6, 0, 0, 0, time.UTC)
+	expired := start.Add(time.Hour)
+	for i := range 5 {
+		record := NotificationRecord{
+			ID:        fmt.Sprintf("n%d", i),
+			Type:      "detection",
+			Priority:  "high",
+			Status:    "unread",
+			Title:     fmt.Sprintf("Detection %d", i),
+			Timestamp: start.Add(time.Duration(i) * time.Minute),
+			Metadata:  `{"species":"Turdus merula"}`,
+		}
+		if i == 0 {
+			record.Type, record.Priority = "error", "critical"
+			record.ExpiresAt = &expired
+		}
+		require.NoError(t, ds.SaveNotification(&record))
+	}
+
+	// Saving again replaces the stored notification
+	read, err := ds.GetNotification("n3")
+	require.NoError(t, err)
+	read.Status = "read"
+	require.NoError(t, ds.SaveNotification(read))
+
+	records, err := ds.ListNotifications(&NotificationFilter{Types: []string{"detection"}, Limit: 2, Offset: 1})
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, "n3", records[0].ID, "newest first")
+	assert.Equal(t, "read", records[0].Status)
+	assert.Equal(t, "n2", records[1].ID)
+
+	unread, err := ds.CountNotifications(&NotificationFilter{Statuses: []string{"unread"}, Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), unread, "counts ignore the limit")
+
+	_, err = ds.GetNotification("missing")
+	require.Error(t, err)
+
+	// Expired notifications go first, then the oldest beyond the kept ones
+	removed, err := ds.PruneNotifications(start.Add(2*time.Hour), 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), removed)
+	records, err = ds.ListNotifications(nil)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, "n4", records[0].ID)
+	assert.Equal(t, "n3", records[1].ID)
+
+	require.NoError(t, ds.DeleteNotification("n4"))
+	total, err := ds.CountNotifications(nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+}
@@ -0,0 +1,210 @@
+// purge.go: Bulk data removal for decommissioning and privacy requests
+package datastore
+
+import (
+	"slices"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/errors"
+	"gorm.io/gorm"
+)
+
+// PurgeScope selects a category of data removed by Purge
+type PurgeScope string
+
+const (
+	PurgeClips      PurgeScope = "clips"      // Clip references of all detections
+	PurgeDetections PurgeScope = "detections" // Detections recorded before a date
+	PurgeUserData   PurgeScope = "user_data"  // Reviews, comments, locks and tags of all detections
+)
+
+// PurgeRequest describes the data to remove
+type PurgeRequest struct {
+	Scopes []PurgeScope
+	Before string // YYYY-MM-DD, detections recorded before this date are removed with PurgeDetections
+}
+
+// Has reports whether the request includes scope
+func (r *PurgeRequest) Has(scope PurgeScope) bool {
+	return slices.Contains(r.Scopes, scope)
+}
+
+// Validate checks the scopes and the date of the request
+func (r *PurgeRequest) Validate() error {
+	if len(r.Scopes) == 0 {
+		return validationError("at least one purge scope is required", "scopes", r.Scopes)
+	}
+	for _, scope := range r.Scopes {
+		switch scope {
+		case PurgeClips, PurgeDetections, PurgeUserData:
+		default:
+			return validationError("unknown purge scope", "scopes", scope)
+		}
+	}
+	if r.Has(PurgeDetections) {
+		if _, err := time.Parse(time.DateOnly, r.Before); err != nil {
+			return validationError("before must be a date in YYYY-MM-DD format", "before", r.Before)
+		}
+	}
+	return nil
+}
+
+// PurgeCounts is the number of records removed by a purge
+type PurgeCounts struct {
+	Detections int64 `json:"detections"`
+	Clips      int64 `json:"clips"` // Detections whose clip is removed
+	Reviews    int64 `json:"reviews"`
+	Comments   int64 `json:"comments"`
+	Locks      int64 `json:"locks"`
+	Tags       int64 `json:"tags"` // Tags detached from detections
+}
+
+// PurgeResult is the outcome of a purge
+type PurgeResult struct {
+	Counts PurgeCounts
+	// ClipNames lists the clips of deleted detections. Clip files are not
+	// touched by the datastore; the caller removes them once the purge is committed.
+	ClipNames []string
+}
+
+// CountPurge returns the number of records Purge would remove, without changing anything
+func (ds *DataStore) CountPurge(req *PurgeRequest) (PurgeCounts, error) {
+	var counts PurgeCounts
+	if err := req.Validate(); err != nil {
+		return counts, err
+	}
+
+	count := func(model interface{}, dest *int64, query string, args ...interface{}) error {
+		q := ds.DB.Model(model)
+		if query != "" {
+			q = q.Where(query, args...)
+		}
+		return q.Count(dest).Error
+	}
+
+	notesBefore := ds.DB.Model(&Note{}).Select("id").Where("date < ?", req.Before)
+	childQuery, childArgs := "", []interface{}(nil)
+	if !req.Has(PurgeUserData) {
+		// Without the user data scope only the user data of deleted detections is removed
+		childQuery, childArgs = "note_id IN (?)", []interface{}{notesBefore}
+	}
+
+	var err error
+	if req.Has(PurgeDetections) {
+		err = count(&Note{}, &counts.Detections, "date < ?", req.Before)
+		if err == nil && !req.Has(PurgeClips) {
+			err = count(&Note{}, &counts.Clips, "date < ? AND clip_name <> ''", req.Before)
+		}
+	}
+	if err == nil && req.Has(PurgeClips) {
+		err = count(&Note{}, &counts.Clips, "clip_name <> ''")
+	}
+	if err == nil && (req.Has(PurgeDetections) || req.Has(PurgeUserData)) {
+		err = count(&NoteReview{}, &counts.Reviews, childQuery, childArgs...)
+		if err == nil {
+			err = count(&NoteComment{}, &counts.Comments, childQuery, childArgs...)
+		}
+		if err == nil {
+			err = count(&NoteLock{}, &counts.Locks, childQuery, childArgs...)
+		}
+		if err == nil {
+			err = count(&NoteTag{}, &counts.Tags, childQuery, childArgs...)
+		}
+	}
+	if err != nil {
+		return PurgeCounts{}, dbError(err, "count_purge", errors.PriorityMedium,
+			"scopes", req.Scopes,
+			"before", req.Before,
+			"action", "preview_data_purge")
+	}
+	return counts, nil
+}
+
+// Purge removes the requested data in a single transaction. Locked detections
+// are removed as well, since a purge serves decommissioning and privacy requests.
+func (ds *DataStore) Purge(req *PurgeRequest) (*PurgeResult, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	result := &PurgeResult{}
+	err := ds.DB.Transaction(func(tx *gorm.DB) error {
+		counts := &result.Counts
+
+		if req.Has(PurgeDetections) {
+			if err := tx.Model(&Note{}).
+				Where("date < ? AND clip_name <> ''", req.Before).
+				Pluck("clip_name", &result.ClipNames).Error; err != nil {
+				return err
+			}
+			counts.Clips = int64(len(result.ClipNames))
+
+			notesBefore := tx.Model(&Note{}).Select("id").Where("date < ?", req.Before)
+			children := []struct {
+				model interface{}
+				count *int64
+			}{
+				{&Results{}, nil},
+				{&NoteReview{}, &counts.Reviews},
+				{&NoteComment{}, &counts.Comments},
+				{&NoteLock{}, &counts.Locks},
+				{&NoteTag{}, &counts.Tags},
+				{&SyncedNote{}, nil},
+			}
+			for _, child := range children {
+				res := tx.Where("note_id IN (?)", notesBefore).Delete(child.model)
+				if res.Error != nil {
+					return res.Error
+				}
+				if child.count != nil {
+					*child.count = res.RowsAffected
+				}
+			}
+
+			res := tx.Where("date < ?", req.Before).Delete(&Note{})
+			if res.Error != nil {
+				return res.Error
+			}
+			counts.Detections = res.RowsAffected
+		}
+
+		if req.Has(PurgeUserData) {
+			all := tx.Session(&gorm.Session{AllowGlobalUpdate: true})
+			userData := []struct {
+				model interface{}
+				count *int64
+			}{
+				{&NoteReview{}, &counts.Reviews},
+				{&NoteComment{}, &counts.Comments},
+				{&NoteLock{}, &counts.Locks},
+				{&NoteTag{}, &counts.Tags},
+			}
+			for _, data := range userData {
+				res := all.Delete(data.model)
+				if res.Error != nil {
+					return res.Error
+				}
+				*data.count += res.RowsAffected
+			}
+		}
+
+		if req.Has(PurgeClips) {
+			res := tx.Model(&Note{}).Where("clip_name <> ''").Update("clip_name", "")
+			if res.Error != nil {
+				return res.Error
+			}
+			counts.Clips += res.RowsAffected
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, dbError(err, "purge", errors.PriorityHigh,
+			"scopes", req.Scopes,
+			"before", req.Before,
+			"action", "purge_data")
+	}
+	if req.Has(PurgeDetections) {
+		ds.markAllDailyAggregatesDirty()
+	}
+	return result, nil
+}
@@ -0,0 +1,119 @@
+// purge_test.go: Tests for bulk data removal
+package datastore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupPurgeTestDB creates a database with two detections before 2024-02-01 and one after,
+// each with a clip, a review, a comment and a lock
+func setupPurgeTestDB(t *testing.T) *DataStore {
+	t.Helper()
+
+	ds := setupTestDB(t)
+	require.NoError(t, ds.DB.AutoMigrate(&Results{}, &NoteReview{}, &NoteComment{}, &NoteLock{}, &SyncedNote{}))
+
+	for i, date := range []string{"2024-01-10", "2024-01-20", "2024-02-05"} {
+		note := Note{
+			Date:           date,
+			Time:           "06:00:00",
+			ScientificName: "Turdus merula",
+			CommonName:     "Eurasian Blackbird",
+			Confidence:     0.9,
+			ClipName:       "2024/01/clip" + date + ".wav",
+		}
+		require.NoError(t, ds.DB.Create(&note).Error)
+		require.NoError(t, ds.DB.Create(&Results{NoteID: note.ID, Species: "Turdus merula", Confidence: 0.9}).Error)
+		require.NoError(t, ds.DB.Create(&NoteReview{NoteID: note.ID, Verified: "correct"}).Error)
+		require.NoError(t, ds.DB.Create(&NoteComment{NoteID: note.ID, Entry: "comment"}).Error)
+		require.NoError(t, ds.DB.Create(&NoteLock{NoteID: note.ID, LockedAt: time.Now()}).Error)
+		require.NoError(t, ds.DB.Create(&SyncedNote{UnitID: "unit", SourceID: uint(i + 1), NoteID: note.ID}).Error)
+	}
+	return ds
+}
+
+func TestPurgeRequest_Validate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		req     PurgeRequest
+		wantErr bool
+	}{
+		{"clips", PurgeRequest{Scopes: []PurgeScope{PurgeClips}}, false},
+		{"detections with date", PurgeRequest{Scopes: []PurgeScope{PurgeDetections}, Before: "2024-01-01"}, false},
+		{"no scopes", PurgeRequest{}, true},
+		{"unknown scope", PurgeRequest{Scopes: []PurgeScope{"weather"}}, true},
+		{"detections without date", PurgeRequest{Scopes: []PurgeScope{PurgeDetections}}, true},
+		{"detections with invalid date", PurgeRequest{Scopes: []PurgeScope{PurgeDetections}, Before: "01/01/2024"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := tt.req.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPurge_DetectionsBeforeDate(t *testing.T) {
+	t.Parallel()
+
+	ds := setupPurgeTestDB(t)
+	req := &PurgeRequest{Scopes: []PurgeScope{PurgeDetections}, Before: "2024-02-01"}
+	want := PurgeCounts{Detections: 2, Clips: 2, Reviews: 2, Comments: 2, Locks: 2}
+
+	counts, err := ds.CountPurge(req)
+	require.NoError(t, err)
+	assert.Equal(t, want, counts)
+
+	result, err := ds.Purge(req)
+	require.NoError(t, err)
+	assert.Equal(t, want, result.Counts)
+	assert.ElementsMatch(t, []string{"2024/01/clip2024-01-10.wav", "2024/01/clip2024-01-20.wav"}, result.ClipNames)
+
+	var notes []Note
+	require.NoError(t, ds.DB.Find(&notes).Error)
+	require.Len(t, notes, 1)
+	assert.Equal(t, "2024-02-05", notes[0].Date)
+
+	for _, model := range []interface{}{&Results{}, &NoteReview{}, &NoteComment{}, &NoteLock{}, &SyncedNote{}} {
+		var n int64
+		require.NoError(t, ds.DB.Model(model).Count(&n).Error)
+		assert.Equal(t, int64(1), n, "records of the remaining detection should be kept: %T", model)
+	}
+}
+
+func TestPurge_AllScopes(t *testing.T) {
+	t.Parallel()
+
+	ds := setupPurgeTestDB(t)
+	req := &PurgeRequest{Scopes: []PurgeScope{PurgeClips, PurgeDetections, PurgeUserData}, Before: "2024-02-01"}
+	want := PurgeCounts{Detections: 2, Clips: 3, Reviews: 3, Comments: 3, Locks: 3}
+
+	counts, err := ds.CountPurge(req)
+	require.NoError(t, err)
+	assert.Equal(t, want, counts)
+
+	result, err := ds.Purge(req)
+	require.NoError(t, err)
+	assert.Equal(t, want, result.Counts)
+
+	var note Note
+	require.NoError(t, ds.DB.First(&note).Error)
+	assert.Empty(t, note.ClipName, "clip references should be cleared")
+
+	for _, model := range []interface{}{&NoteReview{}, &NoteComment{}, &NoteLock{}} {
+		var n int64
+		require.NoError(t, ds.DB.Model(model).Count(&n).Error)
+		assert.Zero(t, n, "user data should be removed: %T", model)
+	}
+}
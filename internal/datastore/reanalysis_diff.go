@@ -0,0 +1,202 @@
+// reanalysis_diff.go: Comparison of stored detections with re-analysis results
+package datastore
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// reanalysisSegmentLength is the length assumed for detections without an end time
+const reanalysisSegmentLength = 3 * time.Second
+
+// SegmentChange describes how re-analysis changed an audio segment
+type SegmentChange string
+
+const (
+	SegmentUnchanged      SegmentChange = "unchanged"       // Same species, possibly with another confidence
+	SegmentSpeciesChanged SegmentChange = "species_changed" // Another species was detected
+	SegmentGained         SegmentChange = "gained"          // Detected only by the re-analysis
+	SegmentLost           SegmentChange = "lost"            // No longer detected
+)
+
+// ReanalysisDetection is a detection produced by re-analysing recorded audio
+type ReanalysisDetection struct {
+	BeginTime      time.Time `json:"begin_time"`
+	EndTime        time.Time `json:"end_time"`
+	ScientificName string    `json:"scientific_name"`
+	CommonName     string    `json:"common_name"`
+	SpeciesCode    string    `json:"species_code,omitempty"`
+	Confidence     float64   `json:"confidence"`
+}
+
+// SegmentDiff compares the stored and re-analysed detection of an audio segment
+type SegmentDiff struct {
+	BeginTime       time.Time     `json:"begin_time"`
+	EndTime         time.Time     `json:"end_time"`
+	Change          SegmentChange `json:"change"`
+	NoteID          uint          `json:"note_id,omitempty"` // Stored detection, zero when gained
+	OldSpecies      string        `json:"old_species,omitempty"`
+	NewSpecies      string        `json:"new_species,omitempty"`
+	OldConfidence   float64       `json:"old_confidence,omitempty"`
+	NewConfidence   float64       `json:"new_confidence,omitempty"`
+	ConfidenceDelta float64       `json:"confidence_delta,omitempty"` // New minus old, for unchanged species
+}
+
+// ReanalysisDiffSummary counts the segments of a diff by change
+type ReanalysisDiffSummary struct {
+	Unchanged           int     `json:"unchanged"`
+	SpeciesChanged      int     `json:"species_changed"`
+	Gained              int     `json:"gained"`
+	Lost                int     `json:"lost"`
+	MeanConfidenceDelta float64 `json:"mean_confidence_delta"` // Over unchanged segments
+}
+
+// ReanalysisDiff compares stored detections with re-analysis results
+type ReanalysisDiff struct {
+	Summary  ReanalysisDiffSummary `json:"summary"`
+	Segments []SegmentDiff         `json:"segments"`
+}
+
+// DiffReanalysis compares the detections stored between start and end with
+// the results of re-analysing the same audio. sourceNode limits the stored
+// detections to one node when not empty. Nothing is changed.
+func (ds *DataStore) DiffReanalysis(start, end time.Time, sourceNode string, results []ReanalysisDetection) (*ReanalysisDiff, error) {
+	if !start.Before(end) {
+		return nil, validationError("start must be before end", "start", start)
+	}
+	for i := range results {
+		if strings.TrimSpace(results[i].ScientificName) == "" {
+			return nil, validationError("re-analysis detection needs a scientific name", "detections", i)
+		}
+	}
+
+	// Dates are stored in local time, so the date range is widened by a day
+	// and detections are filtered by their begin time
+	query := ds.DB.Model(&Note{}).
+		Select("id", "begin_time", "end_time", "scientific_name", "common_name", "confidence").
+		Where("date BETWEEN ? AND ?",
+			start.AddDate(0, 0, -1).Format(time.DateOnly),
+			end.AddDate(0, 0, 1).Format(time.DateOnly))
+	if sourceNode != "" {
+		query = query.Where("source_node = ?", sourceNode)
+	}
+	var notes []Note
+	if err := query.Find(&notes).Error; err != nil {
+		return nil, dbError(err, "diff_reanalysis", errors.PriorityMedium,
+			"start", start.Format(time.RFC3339),
+			"end", end.Format(time.RFC3339),
+			"action", "compare_reanalysis_results")
+	}
+	notes = slices.DeleteFunc(notes, func(n Note) bool {
+		return n.BeginTime.Before(start) || !n.BeginTime.Before(end)
+	})
+
+	return DiffDetections(notes, results), nil
+}
+
+// DiffDetections pairs stored detections with re-analysis results of
+// overlapping audio. A stored detection is first paired with a result of the
+// same species, then with any other overlapping result; what remains is lost
+// or gained.
+func DiffDetections(notes []Note, results []ReanalysisDetection) *ReanalysisDiff {
+	diff := &ReanalysisDiff{Segments: make([]SegmentDiff, 0, max(len(notes), len(results)))}
+	usedNotes := make([]bool, len(notes))
+	usedResults := make([]bool, len(results))
+
+	pair := func(sameSpecies bool) {
+		for i := range notes {
+			if usedNotes[i] {
+				continue
+			}
+			begin, end := segmentBounds(notes[i].BeginTime, notes[i].EndTime)
+			best := -1
+			for j := range results {
+				if usedResults[j] || strings.EqualFold(notes[i].ScientificName, results[j].ScientificName) != sameSpecies {
+					continue
+				}
+				rBegin, rEnd := segmentBounds(results[j].BeginTime, results[j].EndTime)
+				if !rBegin.Before(end) || !begin.Before(rEnd) {
+					continue
+				}
+				if best < 0 || results[j].Confidence > results[best].Confidence {
+					best = j
+				}
+			}
+			if best < 0 {
+				continue
+			}
+			usedNotes[i], usedResults[best] = true, true
+
+			segment := SegmentDiff{
+				BeginTime:     begin,
+				EndTime:       end,
+				Change:        SegmentSpeciesChanged,
+				NoteID:        notes[i].ID,
+				OldSpecies:    notes[i].ScientificName,
+				NewSpecies:    results[best].ScientificName,
+				OldConfidence: notes[i].Confidence,
+				NewConfidence: results[best].Confidence,
+			}
+			if sameSpecies {
+				segment.Change = SegmentUnchanged
+				segment.ConfidenceDelta = results[best].Confidence - notes[i].Confidence
+				diff.Summary.Unchanged++
+				diff.Summary.MeanConfidenceDelta += segment.ConfidenceDelta
+			} else {
+				diff.Summary.SpeciesChanged++
+			}
+			diff.Segments = append(diff.Segments, segment)
+		}
+	}
+	pair(true)
+	pair(false)
+
+	for i := range notes {
+		if !usedNotes[i] {
+			begin, end := segmentBounds(notes[i].BeginTime, notes[i].EndTime)
+			diff.Segments = append(diff.Segments, SegmentDiff{
+				BeginTime:     begin,
+				EndTime:       end,
+				Change:        SegmentLost,
+				NoteID:        notes[i].ID,
+				OldSpecies:    notes[i].ScientificName,
+				OldConfidence: notes[i].Confidence,
+			})
+			diff.Summary.Lost++
+		}
+	}
+	for j := range results {
+		if !usedResults[j] {
+			begin, end := segmentBounds(results[j].BeginTime, results[j].EndTime)
+			diff.Segments = append(diff.Segments, SegmentDiff{
+				BeginTime:     begin,
+				EndTime:       end,
+				Change:        SegmentGained,
+				NewSpecies:    results[j].ScientificName,
+				NewConfidence: results[j].Confidence,
+			})
+			diff.Summary.Gained++
+		}
+	}
+
+	if diff.Summary.Unchanged > 0 {
+		diff.Summary.MeanConfidenceDelta /= float64(diff.Summary.Unchanged)
+	}
+	slices.SortStableFunc(diff.Segments, func(a, b SegmentDiff) int {
+		return cmp.Or(a.BeginTime.Compare(b.BeginTime), cmp.Compare(a.NoteID, b.NoteID))
+	})
+	return diff
+}
+
+// segmentBounds returns the audio segment of a detection, assuming the
+// default segment length when the end time is missing
+func segmentBounds(begin, end time.Time) (time.Time, time.Time) {
+	if !end.After(begin) {
+		end = begin.Add(reanalysisSegmentLength)
+	}
+	return begin, end
+}
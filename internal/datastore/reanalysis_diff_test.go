@@ -0,0 +1,88 @@
+// reanalysis_diff_test.go: Tests for comparing detections with re-analysis results
+package datastore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffDetections(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2024, 5, 1, 6, 0, 0, 0, time.UTC)
+	at := func(seconds int) time.Time { return base.Add(time.Duration(seconds) * time.Second) }
+
+	notes := []Note{
+		{ID: 1, BeginTime: at(0), EndTime: at(3), ScientificName: "Turdus merula", Confidence: 0.8},
+		{ID: 2, BeginTime: at(10), EndTime: at(13), ScientificName: "Parus major", Confidence: 0.7},
+		{ID: 3, BeginTime: at(20), ScientificName: "Erithacus rubecula", Confidence: 0.9}, // No end time
+	}
+	results := []ReanalysisDetection{
+		{BeginTime: at(1), EndTime: at(4), ScientificName: "turdus merula", Confidence: 0.9},
+		{BeginTime: at(10), EndTime: at(13), ScientificName: "Cyanistes caeruleus", Confidence: 0.6},
+		{BeginTime: at(30), EndTime: at(33), ScientificName: "Fringilla coelebs", Confidence: 0.75},
+	}
+
+	diff := DiffDetections(notes, results)
+	require.Len(t, diff.Segments, 4)
+
+	assert.Equal(t, SegmentUnchanged, diff.Segments[0].Change)
+	assert.Equal(t, uint(1), diff.Segments[0].NoteID)
+	assert.InDelta(t, 0.1, diff.Segments[0].ConfidenceDelta, 1e-9)
+
+	assert.Equal(t, SegmentSpeciesChanged, diff.Segments[1].Change)
+	assert.Equal(t, "Parus major", diff.Segments[1].OldSpecies)
+	assert.Equal(t, "Cyanistes caeruleus", diff.Segments[1].NewSpecies)
+
+	assert.Equal(t, SegmentLost, diff.Segments[2].Change)
+	assert.Equal(t, at(23), diff.Segments[2].EndTime, "missing end times span a default segment")
+
+	assert.Equal(t, SegmentGained, diff.Segments[3].Change)
+	assert.Zero(t, diff.Segments[3].NoteID)
+
+	assert.Equal(t, ReanalysisDiffSummary{Unchanged: 1, SpeciesChanged: 1, Gained: 1, Lost: 1, MeanConfidenceDelta: diff.Segments[0].ConfidenceDelta}, diff.Summary)
+}
+
+func TestDiffDetections_PrefersSameSpecies(t *testing.T) {
+	t.Parallel()
+
+	begin := time.Date(2024, 5, 1, 6, 0, 0, 0, time.UTC)
+	notes := []Note{{ID: 1, BeginTime: begin, EndTime: begin.Add(3 * time.Second), ScientificName: "Turdus merula", Confidence: 0.8}}
+	results := []ReanalysisDetection{
+		{BeginTime: begin, EndTime: begin.Add(3 * time.Second), ScientificName: "Parus major", Confidence: 0.95},
+		{BeginTime: begin, EndTime: begin.Add(3 * time.Second), ScientificName: "Turdus merula", Confidence: 0.6},
+	}
+
+	diff := DiffDetections(notes, results)
+	assert.Equal(t, ReanalysisDiffSummary{Unchanged: 1, Gained: 1, MeanConfidenceDelta: diff.Summary.MeanConfidenceDelta}, diff.Summary)
+	assert.InDelta(t, -0.2, diff.Summary.MeanConfidenceDelta, 1e-9)
+}
+
+func TestDiffReanalysis(t *testing.T) {
+	t.Parallel()
+
+	ds := setupTestDB(t)
+	begin := time.Date(2024, 5, 1, 6, 0, 0, 0, time.UTC)
+	notes := []Note{
+		{Date: "2024-05-01", Time: "06:00:00", SourceNode: "garden", BeginTime: begin, EndTime: begin.Add(3 * time.Second), ScientificName: "Turdus merula", Confidence: 0.8},
+		{Date: "2024-05-01", Time: "06:00:00", SourceNode: "forest", BeginTime: begin, EndTime: begin.Add(3 * time.Second), ScientificName: "Parus major", Confidence: 0.8},
+		{Date: "2024-05-01", Time: "08:00:00", SourceNode: "garden", BeginTime: begin.Add(2 * time.Hour), ScientificName: "Parus major", Confidence: 0.8},
+	}
+	require.NoError(t, ds.DB.Create(&notes).Error)
+
+	diff, err := ds.DiffReanalysis(begin, begin.Add(time.Hour), "garden", []ReanalysisDetection{
+		{BeginTime: begin, EndTime: begin.Add(3 * time.Second), ScientificName: "Turdus merula", Confidence: 0.85},
+	})
+	require.NoError(t, err)
+	require.Len(t, diff.Segments, 1, "detections of other nodes and outside the period are ignored")
+	assert.Equal(t, SegmentUnchanged, diff.Segments[0].Change)
+	assert.Equal(t, notes[0].ID, diff.Segments[0].NoteID)
+
+	_, err = ds.DiffReanalysis(begin, begin, "", nil)
+	require.Error(t, err)
+	_, err = ds.DiffReanalysis(begin, begin.Add(time.Hour), "", []ReanalysisDetection{{BeginTime: begin}})
+	require.Error(t, err)
+}
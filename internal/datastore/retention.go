@@ -0,0 +1,206 @@
+// retention.go: Thinning of old detections into hourly aggregates
+package datastore
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ThinningPolicy selects old detections replaced by hourly aggregates
+type ThinningPolicy struct {
+	Before        string   // YYYY-MM-DD, detections recorded before this date are thinned
+	Species       []string // common or scientific names, all species when empty
+	MinDetections int      // only species with at least this many detections in total, 0 for all
+}
+
+// ThinningResult is the outcome of thinning detections
+type ThinningResult struct {
+	Detections int64 // detections replaced by aggregates
+	Days       int   // days thinned
+}
+
+// ThinDetections replaces the detections selected by a policy with hourly
+// aggregates of their count and confidence, one day per transaction. Locked
+// detections and the first detection of each species are kept, so new species
+// tracking is not affected. Audio clips of thinned detections are left to the
+// clip retention policy.
+func (ds *DataStore) ThinDetections(ctx context.Context, policy *ThinningPolicy) (*ThinningResult, error) {
+	if _, err := time.Parse(time.DateOnly, policy.Before); err != nil {
+		return nil, validationError("before must be a date in YYYY-MM-DD format", "before", policy.Before)
+	}
+	hourExpr := ds.GetHourFormat()
+	if hourExpr == "" {
+		return nil, errors.Newf("unsupported database type for detection thinning").
+			Component("datastore").
+			Category(errors.CategoryConfiguration).
+			Context("operation", "thin_detections").
+			Build()
+	}
+
+	thinError := func(err error, action string) error {
+		return dbError(err, "thin_detections", errors.PriorityMedium,
+			"before", policy.Before,
+			"table", "notes",
+			"action", action)
+	}
+
+	species, err := ds.thinnedSpecies(ctx, policy)
+	if err != nil {
+		return nil, thinError(err, "select_species")
+	}
+	result := &ThinningResult{}
+	if species != nil && len(species) == 0 {
+		return result, nil
+	}
+	keep, err := ds.firstDetectionIDs(ctx)
+	if err != nil {
+		return nil, thinError(err, "select_first_detections")
+	}
+
+	// candidates selects the thinned detections. Subqueries only refer to other
+	// tables, MySQL cannot delete from a table it selects from in a subquery.
+	candidates := func(tx *gorm.DB) *gorm.DB {
+		q := tx.Model(&Note{}).
+			Where("id NOT IN (?)", tx.Model(&NoteLock{}).Select("note_id"))
+		if len(keep) > 0 {
+			q = q.Where("id NOT IN ?", keep)
+		}
+		if species != nil {
+			q = q.Where("scientific_name IN ?", species)
+		}
+		return q
+	}
+
+	var dates []string
+	if err := candidates(ds.DB.WithContext(ctx)).
+		Where("date < ?", policy.Before).
+		Distinct("date").
+		Order("date").
+		Pluck("date", &dates).Error; err != nil {
+		return nil, thinError(err, "select_dates")
+	}
+
+	for _, date := range dates {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		var thinned int64
+		err := ds.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			var rows []struct {
+				Hour           string
+				ScientificName string
+				CommonName     string
+				SpeciesCode    string
+				Count          int
+				ConfidenceSum  float64
+				MaxConfidence  float64
+			}
+			if err := candidates(tx).Where("date = ?", date).
+				Select(fmt.Sprintf("%s AS hour, scientific_name, MAX(common_name) AS common_name, MAX(species_code) AS species_code, "+
+					"COUNT(*) AS count, SUM(confidence) AS confidence_sum, MAX(confidence) AS max_confidence", hourExpr)).
+				Group(fmt.Sprintf("%s, scientific_name", hourExpr)).
+				Scan(&rows).Error; err != nil {
+				return err
+			}
+
+			for i := range rows {
+				row := &rows[i]
+				hour, err := strconv.Atoi(row.Hour)
+				if err != nil {
+					continue
+				}
+				aggregate := DetectionAggregate{
+					Date:           date,
+					Hour:           hour,
+					ScientificName: row.ScientificName,
+					CommonName:     row.CommonName,
+					SpeciesCode:    row.SpeciesCode,
+					Count:          row.Count,
+					ConfidenceSum:  row.ConfidenceSum,
+					MaxConfidence:  row.MaxConfidence,
+				}
+				if err := tx.Clauses(clause.OnConflict{
+					Columns: []clause.Column{{Name: "date"}, {Name: "hour"}, {Name: "scientific_name"}},
+					DoUpdates: clause.Assignments(map[string]interface{}{
+						"count":          gorm.Expr("count + ?", row.Count),
+						"confidence_sum": gorm.Expr("confidence_sum + ?", row.ConfidenceSum),
+						"max_confidence": gorm.Expr("CASE WHEN max_confidence > ? THEN max_confidence ELSE ? END", row.MaxConfidence, row.MaxConfidence),
+					}),
+				}).Create(&aggregate).Error; err != nil {
+					return err
+				}
+			}
+
+			// Synced note mappings are kept so that field units do not resend
+			// the thinned detections
+			ids := candidates(tx).Where("date = ?", date).Select("id")
+			for _, child := range []interface{}{&Results{}, &NoteReview{}, &NoteComment{}, &NoteTag{}} {
+				if err := tx.Where("note_id IN (?)", ids).Delete(child).Error; err != nil {
+					return err
+				}
+			}
+			res := candidates(tx).Where("date = ?", date).Delete(&Note{})
+			if res.Error != nil {
+				return res.Error
+			}
+			thinned = res.RowsAffected
+			return nil
+		})
+		if err != nil {
+			return result, dbError(err, "thin_detections", errors.PriorityMedium,
+				"before", policy.Before,
+				"date", date,
+				"table", "notes",
+				"action", "aggregate_day")
+		}
+		ds.markDailyAggregatesDirty(date)
+		result.Detections += thinned
+		result.Days++
+	}
+	return result, nil
+}
+
+// thinnedSpecies returns the scientific names of the species a policy applies
+// to, or nil when it applies to all species
+func (ds *DataStore) thinnedSpecies(ctx context.Context, policy *ThinningPolicy) ([]string, error) {
+	if len(policy.Species) == 0 && policy.MinDetections <= 0 {
+		return nil, nil
+	}
+	q := ds.DB.WithContext(ctx).Model(&Note{}).Group("scientific_name")
+	if len(policy.Species) > 0 {
+		q = q.Where("scientific_name IN ? OR common_name IN ?", policy.Species, policy.Species)
+	}
+	if policy.MinDetections > 0 {
+		q = q.Having("COUNT(*) >= ?", policy.MinDetections)
+	}
+	species := []string{}
+	err := q.Pluck("scientific_name", &species).Error
+	return species, err
+}
+
+// firstDetectionIDs returns the IDs of the first detection of each species
+func (ds *DataStore) firstDetectionIDs(ctx context.Context) ([]uint, error) {
+	var notes []Note
+	if err := ds.DB.WithContext(ctx).
+		Select("id, scientific_name, date, time").
+		Where("date = (SELECT MIN(n2.date) FROM notes n2 WHERE n2.scientific_name = notes.scientific_name)").
+		Order("date, time, id").
+		Find(&notes).Error; err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var ids []uint
+	for i := range notes {
+		if !seen[notes[i].ScientificName] {
+			seen[notes[i].ScientificName] = true
+			ids = append(ids, notes[i].ID)
+		}
+	}
+	return ids, nil
+}
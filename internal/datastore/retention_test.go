@@ -0,0 +1,77 @@
+// retention_test.go: Tests for thinning old detections into hourly aggregates
+package datastore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestThinDetections(t *testing.T) {
+	t.Parallel()
+
+	ds := setupTestDB(t)
+	require.NoError(t, ds.DB.AutoMigrate(&Results{}, &NoteReview{}, &NoteComment{}, &NoteLock{}, &DetectionAggregate{}))
+
+	notes := []Note{
+		{Date: "2020-05-01", Time: "06:10:00", ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", Confidence: 0.9},
+		{Date: "2020-05-01", Time: "06:20:00", ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", Confidence: 0.8},
+		{Date: "2020-05-01", Time: "06:40:00", ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", Confidence: 0.6},
+		{Date: "2020-05-02", Time: "07:00:00", ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", Confidence: 0.7},
+		{Date: "2020-05-02", Time: "07:30:00", ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", Confidence: 0.75},
+		{Date: "2020-05-02", Time: "08:00:00", ScientificName: "Pica pica", CommonName: "Eurasian Magpie", Confidence: 0.9},
+		{Date: "2020-05-03", Time: "08:00:00", ScientificName: "Pica pica", CommonName: "Eurasian Magpie", Confidence: 0.9},
+		{Date: "2024-05-01", Time: "06:00:00", ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", Confidence: 0.9},
+	}
+	require.NoError(t, ds.DB.Create(&notes).Error)
+	require.NoError(t, ds.DB.Create(&Results{NoteID: notes[1].ID, Species: "Turdus merula", Confidence: 0.8}).Error)
+	require.NoError(t, ds.DB.Create(&NoteLock{NoteID: notes[4].ID}).Error)
+
+	// Only the blackbird has enough detections to count as a common species
+	ctx := context.Background()
+	result, err := ds.ThinDetections(ctx, &ThinningPolicy{Before: "2022-01-01", MinDetections: 4})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), result.Detections, "the first and the locked detection should be kept")
+	assert.Equal(t, 2, result.Days)
+
+	var remaining []uint
+	require.NoError(t, ds.DB.Model(&Note{}).Order("id").Pluck("id", &remaining).Error)
+	assert.Equal(t, []uint{notes[0].ID, notes[4].ID, notes[5].ID, notes[6].ID, notes[7].ID}, remaining)
+
+	var results int64
+	require.NoError(t, ds.DB.Model(&Results{}).Count(&results).Error)
+	assert.Zero(t, results, "results of thinned detections should be deleted")
+
+	var aggregates []DetectionAggregate
+	require.NoError(t, ds.DB.Order("date, hour").Find(&aggregates).Error)
+	require.Len(t, aggregates, 2)
+	assert.Equal(t, "2020-05-01", aggregates[0].Date)
+	assert.Equal(t, 6, aggregates[0].Hour)
+	assert.Equal(t, 2, aggregates[0].Count)
+	assert.InDelta(t, 1.4, aggregates[0].ConfidenceSum, 0.001)
+	assert.InDelta(t, 0.8, aggregates[0].MaxConfidence, 0.001)
+	assert.Equal(t, "2020-05-02", aggregates[1].Date)
+	assert.Equal(t, 7, aggregates[1].Hour)
+	assert.Equal(t, 1, aggregates[1].Count)
+
+	// Thinning a day again merges into the existing aggregate
+	require.NoError(t, ds.DB.Create(&Note{Date: "2020-05-01", Time: "06:50:00", ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", Confidence: 0.95}).Error)
+	result, err = ds.ThinDetections(ctx, &ThinningPolicy{Before: "2022-01-01", Species: []string{"Eurasian Blackbird"}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), result.Detections)
+
+	var merged DetectionAggregate
+	require.NoError(t, ds.DB.Where("date = ? AND hour = ?", "2020-05-01", 6).First(&merged).Error)
+	assert.Equal(t, 3, merged.Count)
+	assert.InDelta(t, 0.95, merged.MaxConfidence, 0.001)
+}
+
+func TestThinDetections_InvalidDate(t *testing.T) {
+	t.Parallel()
+
+	ds := setupTestDB(t)
+	_, err := ds.ThinDetections(context.Background(), &ThinningPolicy{Before: "last year"})
+	require.Error(t, err)
+}
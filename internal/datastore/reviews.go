@@ -0,0 +1,114 @@
+// reviews.go: Review queue and per-species precision of review verdicts
+package datastore
+
+import (
+	"context"
+	"strings"
+
+	"github.com/tphakala/birdnet-go/internal/errors"
+	"gorm.io/gorm"
+)
+
+// ReviewQueueFilter narrows the detections sampled for review
+type ReviewQueueFilter struct {
+	Species       string  // exact common or scientific name
+	StartDate     string  // YYYY-MM-DD, inclusive
+	EndDate       string  // YYYY-MM-DD, inclusive
+	MinConfidence float64 // 0 for no lower bound
+	MaxConfidence float64 // 0 for no upper bound
+}
+
+// SpeciesPrecision counts the detections of a species and their review verdicts
+type SpeciesPrecision struct {
+	ScientificName string
+	CommonName     string
+	Detections     int64
+	Confirmed      int64 // reviewed as correct
+	FalsePositives int64
+}
+
+// DeleteNoteReview removes the review of a detection, returning it to the
+// unverified state. Detections without a review are left as they are.
+func (ds *DataStore) DeleteNoteReview(noteID uint) error {
+	if err := ds.DB.Where("note_id = ?", noteID).Delete(&NoteReview{}).Error; err != nil {
+		return dbError(err, "delete_note_review", errors.PriorityLow,
+			"note_id", noteID,
+			"table", "note_reviews")
+	}
+	return nil
+}
+
+// GetReviewQueue returns a random sample of up to limit detections matching
+// the filter that have neither been reviewed nor locked, with the number of
+// such detections
+func (ds *DataStore) GetReviewQueue(ctx context.Context, filter ReviewQueueFilter, limit int) ([]Note, int64, error) {
+	query := ds.DB.WithContext(ctx).Model(&Note{}).
+		Where("NOT EXISTS (SELECT 1 FROM note_reviews WHERE note_reviews.note_id = notes.id)").
+		Where("NOT EXISTS (SELECT 1 FROM note_locks WHERE note_locks.note_id = notes.id)")
+	query = speciesScope(query, filter.Species)
+	if filter.StartDate != "" {
+		query = query.Where("date >= ?", filter.StartDate)
+	}
+	if filter.EndDate != "" {
+		query = query.Where("date <= ?", filter.EndDate)
+	}
+	if filter.MinConfidence > 0 {
+		query = query.Where("confidence >= ?", filter.MinConfidence)
+	}
+	if filter.MaxConfidence > 0 {
+		query = query.Where("confidence <= ?", filter.MaxConfidence)
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, dbError(err, "count_review_queue", errors.PriorityLow,
+			"table", "notes")
+	}
+	if total == 0 {
+		return []Note{}, 0, nil
+	}
+
+	var notes []Note
+	if err := query.Order(ds.randomOrder()).Limit(limit).Find(&notes).Error; err != nil {
+		return nil, 0, dbError(err, "get_review_queue", errors.PriorityLow,
+			"limit", limit,
+			"table", "notes")
+	}
+	return notes, total, nil
+}
+
+// GetSpeciesPrecision returns the detections and review verdicts of each
+// species detected between startDate and endDate, most detected first. An
+// empty date leaves that side of the period open.
+func (ds *DataStore) GetSpeciesPrecision(ctx context.Context, startDate, endDate string) ([]SpeciesPrecision, error) {
+	query := ds.DB.WithContext(ctx).Table("notes").
+		Select("notes.scientific_name, MAX(notes.common_name) AS common_name, COUNT(*) AS detections, " +
+			"SUM(CASE WHEN note_reviews.verified = 'correct' THEN 1 ELSE 0 END) AS confirmed, " +
+			"SUM(CASE WHEN note_reviews.verified = 'false_positive' THEN 1 ELSE 0 END) AS false_positives").
+		Joins("LEFT JOIN note_reviews ON note_reviews.note_id = notes.id")
+	if startDate != "" {
+		query = query.Where("notes.date >= ?", startDate)
+	}
+	if endDate != "" {
+		query = query.Where("notes.date <= ?", endDate)
+	}
+
+	var precision []SpeciesPrecision
+	if err := query.Group("notes.scientific_name").
+		Order("detections DESC, notes.scientific_name ASC").
+		Scan(&precision).Error; err != nil {
+		return nil, dbError(err, "get_species_precision", errors.PriorityLow,
+			"start_date", startDate,
+			"end_date", endDate,
+			"table", "notes")
+	}
+	return precision, nil
+}
+
+// randomOrder returns the ORDER BY expression shuffling rows in the database
+func (ds *DataStore) randomOrder() string {
+	if d := ds.Dialector(); d != nil && strings.EqualFold(d.Name(), "mysql") {
+		return "RAND()"
+	}
+	return "RANDOM()"
+}
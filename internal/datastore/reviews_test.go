@@ -0,0 +1,64 @@
+// reviews_test.go: Tests for the review queue and species precision
+package datastore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReviewQueueAndPrecision(t *testing.T) {
+	t.Parallel()
+
+	ds := setupTestDB(t)
+	require.NoError(t, ds.DB.AutoMigrate(&NoteReview{}, &NoteComment{}, &NoteLock{}))
+
+	notes := []Note{
+		{Date: "2024-05-01", Time: "06:00:00", ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", Confidence: 0.9},
+		{Date: "2024-05-01", Time: "06:05:00", ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", Confidence: 0.8},
+		{Date: "2024-05-01", Time: "06:10:00", ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", Confidence: 0.7},
+		{Date: "2024-05-02", Time: "07:00:00", ScientificName: "Parus major", CommonName: "Great Tit", Confidence: 0.6},
+		{Date: "2024-05-03", Time: "08:00:00", ScientificName: "Parus major", CommonName: "Great Tit", Confidence: 0.95},
+	}
+	require.NoError(t, ds.DB.Create(&notes).Error)
+	require.NoError(t, ds.SaveNoteReview(&NoteReview{NoteID: notes[0].ID, Verified: "correct"}))
+	require.NoError(t, ds.SaveNoteReview(&NoteReview{NoteID: notes[1].ID, Verified: "false_positive"}))
+	require.NoError(t, ds.SaveNoteReview(&NoteReview{NoteID: notes[3].ID, Verified: "correct"}))
+	require.NoError(t, ds.DB.Create(&NoteLock{NoteID: notes[4].ID}).Error)
+
+	ctx := context.Background()
+	queue, total, err := ds.GetReviewQueue(ctx, ReviewQueueFilter{}, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total, "reviewed and locked detections are not queued")
+	require.Len(t, queue, 1)
+	assert.Equal(t, notes[2].ID, queue[0].ID)
+
+	queue, total, err = ds.GetReviewQueue(ctx, ReviewQueueFilter{Species: "Great Tit"}, 10)
+	require.NoError(t, err)
+	assert.Zero(t, total)
+	assert.Empty(t, queue)
+
+	require.NoError(t, ds.DeleteNoteReview(notes[1].ID))
+	require.NoError(t, ds.DeleteNoteReview(notes[1].ID), "deleting a missing review is not an error")
+	queue, total, err = ds.GetReviewQueue(ctx, ReviewQueueFilter{MinConfidence: 0.75}, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	require.Len(t, queue, 1)
+	assert.Equal(t, notes[1].ID, queue[0].ID)
+
+	precision, err := ds.GetSpeciesPrecision(ctx, "", "")
+	require.NoError(t, err)
+	require.Len(t, precision, 2)
+	assert.Equal(t, SpeciesPrecision{
+		ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", Detections: 3, Confirmed: 1,
+	}, precision[0])
+	assert.Equal(t, int64(1), precision[1].Confirmed)
+
+	precision, err = ds.GetSpeciesPrecision(ctx, "2024-05-03", "")
+	require.NoError(t, err)
+	require.Len(t, precision, 1)
+	assert.Equal(t, int64(1), precision[0].Detections)
+	assert.Zero(t, precision[0].Confirmed)
+}
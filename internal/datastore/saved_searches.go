@@ -0,0 +1,134 @@
+// saved_searches.go: Saved detection searches and their subscriptions
+package datastore
+
+import (
+	"context"
+	"strings"
+
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// ErrSavedSearchNotFound is returned when a saved search does not exist
+var ErrSavedSearchNotFound = errors.NewStd("saved search not found")
+
+// validateSavedSearch checks the name, expression and cooldown of a saved search
+func validateSavedSearch(search *SavedSearch) error {
+	if strings.TrimSpace(search.Name) == "" {
+		return validationError("name cannot be empty", "name", search.Name)
+	}
+	if len(search.Name) > 128 {
+		return validationError("name is longer than 128 characters", "name", search.Name)
+	}
+	if search.Cooldown < 0 {
+		return validationError("cooldown cannot be negative", "cooldown", search.Cooldown)
+	}
+	if _, err := ParseNoteQuery(search.Query); err != nil {
+		return err
+	}
+	return nil
+}
+
+// SaveSavedSearch stores a new saved search
+func (ds *DataStore) SaveSavedSearch(search *SavedSearch) error {
+	if err := validateSavedSearch(search); err != nil {
+		return err
+	}
+	search.ID = 0
+
+	if err := ds.DB.Create(search).Error; err != nil {
+		return dbError(err, "save_saved_search", errors.PriorityLow,
+			"owner", search.Owner,
+			"table", "saved_searches",
+			"action", "save_search")
+	}
+	return nil
+}
+
+// UpdateSavedSearch replaces the name, expression, subscription and cooldown
+// of a saved search. The owner and creation time are kept.
+func (ds *DataStore) UpdateSavedSearch(search *SavedSearch) error {
+	if err := validateSavedSearch(search); err != nil {
+		return err
+	}
+
+	res := ds.DB.Model(&SavedSearch{}).Where("id = ?", search.ID).Updates(map[string]any{
+		"name":       search.Name,
+		"query":      search.Query,
+		"subscribed": search.Subscribed,
+		"cooldown":   search.Cooldown,
+	})
+	if res.Error != nil {
+		return dbError(res.Error, "update_saved_search", errors.PriorityLow,
+			"id", search.ID,
+			"table", "saved_searches")
+	}
+	if res.RowsAffected == 0 {
+		return ErrSavedSearchNotFound
+	}
+	return nil
+}
+
+// GetSavedSearch returns a saved search by ID
+func (ds *DataStore) GetSavedSearch(id uint) (*SavedSearch, error) {
+	var searches []SavedSearch
+	if err := ds.DB.Where("id = ?", id).Limit(1).Find(&searches).Error; err != nil {
+		return nil, dbError(err, "get_saved_search", errors.PriorityLow,
+			"id", id,
+			"table", "saved_searches")
+	}
+	if len(searches) == 0 {
+		return nil, ErrSavedSearchNotFound
+	}
+	return &searches[0], nil
+}
+
+// GetSavedSearches returns the saved searches of an owner ordered by name
+func (ds *DataStore) GetSavedSearches(owner string) ([]SavedSearch, error) {
+	var searches []SavedSearch
+	if err := ds.DB.Where("owner = ?", owner).Order("name ASC, id ASC").Find(&searches).Error; err != nil {
+		return nil, dbError(err, "get_saved_searches", errors.PriorityLow,
+			"owner", owner,
+			"table", "saved_searches")
+	}
+	return searches, nil
+}
+
+// GetSubscribedSearches returns the saved searches of all users that are
+// subscribed to, ordered by ID
+func (ds *DataStore) GetSubscribedSearches() ([]SavedSearch, error) {
+	var searches []SavedSearch
+	if err := ds.DB.Where("subscribed = ?", true).Order("id ASC").Find(&searches).Error; err != nil {
+		return nil, dbError(err, "get_subscribed_searches", errors.PriorityLow,
+			"table", "saved_searches")
+	}
+	return searches, nil
+}
+
+// DeleteSavedSearch removes a saved search
+func (ds *DataStore) DeleteSavedSearch(id uint) error {
+	res := ds.DB.Delete(&SavedSearch{}, id)
+	if res.Error != nil {
+		return dbError(res.Error, "delete_saved_search", errors.PriorityLow,
+			"id", id,
+			"table", "saved_searches")
+	}
+	if res.RowsAffected == 0 {
+		return ErrSavedSearchNotFound
+	}
+	return nil
+}
+
+// NoteMatchesQuery reports whether the stored detection with an ID matches a
+// filter expression
+func (ds *DataStore) NoteMatchesQuery(ctx context.Context, query *NoteQuery, noteID uint) (bool, error) {
+	var count int64
+	if err := query.scope(ds.DB.WithContext(ctx).Model(&Note{})).
+		Where("notes.id = ?", noteID).
+		Count(&count).Error; err != nil {
+		return false, dbError(err, "note_matches_query", errors.PriorityLow,
+			"query", query.String(),
+			"note_id", noteID,
+			"table", "notes")
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,70 @@
+// saved_searches_test.go: Tests for saved searches
+package datastore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSavedSearches(t *testing.T) {
+	t.Parallel()
+
+	ds := setupTestDB(t)
+	require.NoError(t, ds.DB.AutoMigrate(&SavedSearch{}))
+
+	owls := &SavedSearch{Name: "Owls", Owner: "alice", Query: "owl confidence>high", Subscribed: true, Cooldown: time.Hour}
+	require.Error(t, ds.SaveSavedSearch(owls), "the expression must parse")
+	owls.Query = "owl confidence>0.9"
+	require.NoError(t, ds.SaveSavedSearch(owls))
+	require.NoError(t, ds.SaveSavedSearch(&SavedSearch{Name: "Tits", Owner: "alice", Query: `species:"Great Tit"`}))
+	require.NoError(t, ds.SaveSavedSearch(&SavedSearch{Name: "Bob", Owner: "bob", Query: "hour:22..4", Subscribed: true}))
+	require.Error(t, ds.SaveSavedSearch(&SavedSearch{Name: "", Query: "owl"}))
+
+	searches, err := ds.GetSavedSearches("alice")
+	require.NoError(t, err)
+	require.Len(t, searches, 2, "only the searches of the owner are listed")
+	assert.Equal(t, "Owls", searches[0].Name)
+
+	subscribed, err := ds.GetSubscribedSearches()
+	require.NoError(t, err)
+	require.Len(t, subscribed, 2, "subscriptions of all users")
+	assert.Equal(t, time.Hour, subscribed[0].Cooldown)
+
+	owls.Subscribed = false
+	owls.Owner = "mallory"
+	require.NoError(t, ds.UpdateSavedSearch(owls))
+	got, err := ds.GetSavedSearch(owls.ID)
+	require.NoError(t, err)
+	assert.False(t, got.Subscribed)
+	assert.Equal(t, "alice", got.Owner, "updates keep the owner")
+
+	require.NoError(t, ds.DeleteSavedSearch(owls.ID))
+	require.ErrorIs(t, ds.DeleteSavedSearch(owls.ID), ErrSavedSearchNotFound)
+	require.ErrorIs(t, ds.UpdateSavedSearch(owls), ErrSavedSearchNotFound)
+}
+
+func TestNoteMatchesQuery(t *testing.T) {
+	t.Parallel()
+
+	ds := setupTestDB(t)
+	require.NoError(t, ds.DB.AutoMigrate(&NoteReview{}, &NoteComment{}, &NoteLock{}))
+	notes := []Note{
+		{Date: "2024-05-01", Time: "02:00:00", ScientificName: "Strix aluco", CommonName: "Tawny Owl", Confidence: 0.95},
+		{Date: "2024-05-01", Time: "02:10:00", ScientificName: "Strix aluco", CommonName: "Tawny Owl", Confidence: 0.7},
+	}
+	require.NoError(t, ds.DB.Create(&notes).Error)
+
+	query, err := ParseNoteQuery("owl confidence>0.9")
+	require.NoError(t, err)
+	ctx := context.Background()
+	match, err := ds.NoteMatchesQuery(ctx, query, notes[0].ID)
+	require.NoError(t, err)
+	assert.True(t, match)
+	match, err = ds.NoteMatchesQuery(ctx, query, notes[1].ID)
+	require.NoError(t, err)
+	assert.False(t, match)
+}
@@ -0,0 +1,175 @@
+// schema.go: Inspection of the live database schema
+package datastore
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// DatabaseSchema is the schema of the live database as reported by the database
+// itself, which may differ from the models after a failed or partial migration
+type DatabaseSchema struct {
+	Dialect string        `json:"dialect"`
+	Tables  []TableSchema `json:"tables"`
+}
+
+// TableSchema describes a table of the database
+type TableSchema struct {
+	Name     string         `json:"name"`
+	RowCount int64          `json:"rowCount"`
+	Columns  []ColumnSchema `json:"columns"`
+	Indexes  []IndexSchema  `json:"indexes"`
+}
+
+// ColumnSchema describes a column of a table
+type ColumnSchema struct {
+	Name       string  `json:"name"`
+	Type       string  `json:"type"` // database type, such as VARCHAR(255)
+	Nullable   bool    `json:"nullable"`
+	PrimaryKey bool    `json:"primaryKey"`
+	Unique     bool    `json:"unique"`
+	Default    *string `json:"default,omitempty"`
+}
+
+// IndexSchema describes an index of a table
+type IndexSchema struct {
+	Name       string   `json:"name"`
+	Columns    []string `json:"columns"`
+	Unique     bool     `json:"unique"`
+	PrimaryKey bool     `json:"primaryKey"`
+}
+
+// SchemaRelation is a reference between tables, inferred from a column named
+// after the referenced table in the GORM convention, such as note_id to notes
+type SchemaRelation struct {
+	Table           string `json:"table"`
+	Column          string `json:"column"`
+	ReferencedTable string `json:"referencedTable"`
+}
+
+// GetDatabaseSchema reads the tables, columns, indexes and row counts of the
+// live database
+func (ds *DataStore) GetDatabaseSchema(ctx context.Context) (*DatabaseSchema, error) {
+	db := ds.DB.WithContext(ctx)
+	migrator := db.Migrator()
+
+	tables, err := migrator.GetTables()
+	if err != nil {
+		return nil, dbError(err, "get_schema_tables", errors.PriorityLow)
+	}
+	// SQLite internal tables, such as sqlite_sequence, are not part of the schema
+	tables = slices.DeleteFunc(tables, func(table string) bool { return strings.HasPrefix(table, "sqlite_") })
+	slices.Sort(tables)
+
+	schema := &DatabaseSchema{Dialect: db.Name(), Tables: make([]TableSchema, 0, len(tables))}
+	for _, table := range tables {
+		tableSchema := TableSchema{Name: table, Columns: []ColumnSchema{}, Indexes: []IndexSchema{}}
+
+		columnTypes, err := migrator.ColumnTypes(table)
+		if err != nil {
+			return nil, dbError(err, "get_schema_columns", errors.PriorityLow, "table", table)
+		}
+		for _, columnType := range columnTypes {
+			column := ColumnSchema{Name: columnType.Name(), Type: columnType.DatabaseTypeName()}
+			if fullType, ok := columnType.ColumnType(); ok && fullType != "" {
+				column.Type = fullType
+			}
+			column.Nullable, _ = columnType.Nullable()
+			column.PrimaryKey, _ = columnType.PrimaryKey()
+			column.Unique, _ = columnType.Unique()
+			if value, ok := columnType.DefaultValue(); ok {
+				column.Default = &value
+			}
+			tableSchema.Columns = append(tableSchema.Columns, column)
+		}
+
+		indexes, err := migrator.GetIndexes(table)
+		if err != nil {
+			return nil, dbError(err, "get_schema_indexes", errors.PriorityLow, "table", table)
+		}
+		for _, index := range indexes {
+			indexSchema := IndexSchema{Name: index.Name(), Columns: index.Columns()}
+			indexSchema.Unique, _ = index.Unique()
+			indexSchema.PrimaryKey, _ = index.PrimaryKey()
+			tableSchema.Indexes = append(tableSchema.Indexes, indexSchema)
+		}
+		slices.SortFunc(tableSchema.Indexes, func(a, b IndexSchema) int { return strings.Compare(a.Name, b.Name) })
+
+		if err := db.Table(table).Count(&tableSchema.RowCount).Error; err != nil {
+			return nil, dbError(err, "get_schema_row_count", errors.PriorityLow, "table", table)
+		}
+		schema.Tables = append(schema.Tables, tableSchema)
+	}
+	return schema, nil
+}
+
+// Relations returns the references between the tables of the schema
+func (s *DatabaseSchema) Relations() []SchemaRelation {
+	tables := make(map[string]bool, len(s.Tables))
+	for i := range s.Tables {
+		tables[s.Tables[i].Name] = true
+	}
+
+	relations := []SchemaRelation{}
+	for i := range s.Tables {
+		for _, column := range s.Tables[i].Columns {
+			name, ok := strings.CutSuffix(column.Name, "_id")
+			if !ok || name == "" {
+				continue
+			}
+			if referenced := name + "s"; tables[referenced] && referenced != s.Tables[i].Name {
+				relations = append(relations, SchemaRelation{
+					Table:           s.Tables[i].Name,
+					Column:          column.Name,
+					ReferencedTable: referenced,
+				})
+			}
+		}
+	}
+	return relations
+}
+
+// Mermaid returns the schema as a Mermaid entity relationship diagram
+func (s *DatabaseSchema) Mermaid() string {
+	relations := s.Relations()
+	foreignKeys := make(map[string]bool, len(relations))
+	for _, relation := range relations {
+		foreignKeys[relation.Table+"."+relation.Column] = true
+	}
+
+	var b strings.Builder
+	b.WriteString("erDiagram\n")
+	for i := range s.Tables {
+		fmt.Fprintf(&b, "    %s {\n", s.Tables[i].Name)
+		for _, column := range s.Tables[i].Columns {
+			fmt.Fprintf(&b, "        %s %s", mermaidType(column.Type), column.Name)
+			switch {
+			case column.PrimaryKey:
+				b.WriteString(" PK")
+			case foreignKeys[s.Tables[i].Name+"."+column.Name]:
+				b.WriteString(" FK")
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("    }\n")
+	}
+	for _, relation := range relations {
+		fmt.Fprintf(&b, "    %s ||--o{ %s : %s\n", relation.ReferencedTable, relation.Table, relation.Column)
+	}
+	return b.String()
+}
+
+// mermaidType returns a column type as a Mermaid attribute type, which allows
+// no spaces or parentheses
+func mermaidType(columnType string) string {
+	name, _, _ := strings.Cut(columnType, "(")
+	name = strings.ReplaceAll(strings.TrimSpace(name), " ", "_")
+	if name == "" {
+		return "unknown"
+	}
+	return strings.ToLower(name)
+}
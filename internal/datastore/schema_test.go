@@ -0,0 +1,51 @@
+// schema_test.go: Tests for the live database schema inspection
+package datastore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetDatabaseSchema(t *testing.T) {
+	t.Parallel()
+
+	ds := setupTestDB(t)
+	require.NoError(t, ds.DB.AutoMigrate(&NoteReview{}))
+	require.NoError(t, ds.DB.Create(&[]Note{
+		{Date: "2024-05-01", Time: "06:00:00", ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", Confidence: 0.9},
+		{Date: "2024-05-01", Time: "06:10:00", ScientificName: "Parus major", CommonName: "Great Tit", Confidence: 0.8},
+	}).Error)
+
+	schema, err := ds.GetDatabaseSchema(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "sqlite", schema.Dialect)
+
+	tables := make(map[string]TableSchema)
+	for _, table := range schema.Tables {
+		tables[table.Name] = table
+	}
+	require.Contains(t, tables, "notes")
+	require.Contains(t, tables, "note_reviews")
+
+	notes := tables["notes"]
+	assert.Equal(t, int64(2), notes.RowCount)
+	columns := make(map[string]ColumnSchema)
+	for _, column := range notes.Columns {
+		columns[column.Name] = column
+	}
+	assert.True(t, columns["id"].PrimaryKey)
+	assert.Contains(t, columns, "scientific_name")
+	assert.NotEmpty(t, notes.Indexes)
+	assert.Zero(t, tables["note_reviews"].RowCount)
+
+	assert.Contains(t, schema.Relations(), SchemaRelation{Table: "note_reviews", Column: "note_id", ReferencedTable: "notes"})
+	diagram := schema.Mermaid()
+	assert.Contains(t, diagram, "erDiagram\n")
+	assert.Contains(t, diagram, "integer id PK")
+	assert.Contains(t, diagram, "note_id FK")
+	assert.Contains(t, diagram, "notes ||--o{ note_reviews : note_id")
+	assert.NotContains(t, diagram, "sqlite_sequence")
+}
@@ -85,7 +85,7 @@ func (ds *DataStore) SearchNotesAdvanced(filters *AdvancedSearchFilters) ([]Note
 
 	// Apply species filter
 	if len(filters.Species) > 0 {
-		query = query.Where("species_code IN ? OR scientific_name IN ?", filters.Species, filters.Species)
+		query = query.Where("species_code IN ? OR scientific_name IN ? OR common_name IN ?", filters.Species, filters.Species, filters.Species)
 	}
 
 	// Apply location/source filter
@@ -0,0 +1,70 @@
+// shadow.go: Would-be detections of a candidate model running in shadow mode
+package datastore
+
+import (
+	"slices"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// shadowDetectionBatchSize is the number of shadow detections inserted per statement
+const shadowDetectionBatchSize = 100
+
+// SaveShadowDetections stores would-be detections of a candidate model
+func (ds *DataStore) SaveShadowDetections(detections []ShadowDetection) error {
+	if len(detections) == 0 {
+		return nil
+	}
+	if err := ds.DB.CreateInBatches(&detections, shadowDetectionBatchSize).Error; err != nil {
+		return dbError(err, "save_shadow_detections", errors.PriorityLow,
+			"model", detections[0].Model,
+			"count", len(detections),
+			"action", "log_shadow_model_detections")
+	}
+	return nil
+}
+
+// GetShadowTrialStart returns the time of the first would-be detection of a
+// candidate model, or false when it has not detected anything yet
+func (ds *DataStore) GetShadowTrialStart(model string) (time.Time, bool, error) {
+	var first ShadowDetection
+	res := ds.DB.Where("model = ?", model).Order("begin_time").Limit(1).Find(&first)
+	if res.Error != nil {
+		return time.Time{}, false, dbError(res.Error, "get_shadow_trial_start", errors.PriorityLow,
+			"model", model,
+			"action", "resume_shadow_model_trial")
+	}
+	return first.BeginTime, res.RowsAffected > 0, nil
+}
+
+// CompareShadowModel compares the would-be detections of a candidate model
+// between start and end with the detections stored by the production model
+func (ds *DataStore) CompareShadowModel(model string, start, end time.Time) (*ReanalysisDiff, error) {
+	// Begin times may be stored in another time zone than the period, so the
+	// query is widened by a day and the detections are filtered here
+	var shadow []ShadowDetection
+	if err := ds.DB.Where("model = ? AND begin_time >= ? AND begin_time < ?",
+		model, start.AddDate(0, 0, -1), end.AddDate(0, 0, 1)).
+		Order("begin_time").Find(&shadow).Error; err != nil {
+		return nil, dbError(err, "compare_shadow_model", errors.PriorityMedium,
+			"model", model,
+			"action", "compare_shadow_model_detections")
+	}
+	shadow = slices.DeleteFunc(shadow, func(d ShadowDetection) bool {
+		return d.BeginTime.Before(start) || !d.BeginTime.Before(end)
+	})
+
+	results := make([]ReanalysisDetection, 0, len(shadow))
+	for i := range shadow {
+		results = append(results, ReanalysisDetection{
+			BeginTime:      shadow[i].BeginTime,
+			EndTime:        shadow[i].EndTime,
+			ScientificName: shadow[i].ScientificName,
+			CommonName:     shadow[i].CommonName,
+			SpeciesCode:    shadow[i].SpeciesCode,
+			Confidence:     shadow[i].Confidence,
+		})
+	}
+	return ds.DiffReanalysis(start, end, "", results)
+}
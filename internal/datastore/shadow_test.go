@@ -0,0 +1,43 @@
+// shadow_test.go: Tests for shadow model detections
+package datastore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareShadowModel(t *testing.T) {
+	t.Parallel()
+
+	ds := setupTestDB(t)
+	require.NoError(t, ds.DB.AutoMigrate(&ShadowDetection{}))
+
+	begin := time.Date(2024, 5, 1, 6, 0, 0, 0, time.UTC)
+	_, ok, err := ds.GetShadowTrialStart("candidate.tflite")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, ds.DB.Create(&Note{
+		Date: "2024-05-01", Time: "06:00:00", BeginTime: begin, EndTime: begin.Add(3 * time.Second),
+		ScientificName: "Parus major", Confidence: 0.8,
+	}).Error)
+	require.NoError(t, ds.SaveShadowDetections([]ShadowDetection{
+		{Model: "candidate.tflite", BeginTime: begin, EndTime: begin.Add(3 * time.Second), ScientificName: "Parus major", Confidence: 0.9},
+		{Model: "candidate.tflite", BeginTime: begin.Add(time.Minute), EndTime: begin.Add(time.Minute + 3*time.Second), ScientificName: "Sitta europaea", Confidence: 0.7},
+		{Model: "other.tflite", BeginTime: begin.Add(-time.Hour), ScientificName: "Sitta europaea", Confidence: 0.7},
+	}))
+
+	start, ok, err := ds.GetShadowTrialStart("candidate.tflite")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.True(t, begin.Equal(start))
+
+	diff, err := ds.CompareShadowModel("candidate.tflite", begin.Add(-time.Hour), begin.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, diff.Summary.Unchanged)
+	assert.Equal(t, 1, diff.Summary.Gained, "detections of other candidates are ignored")
+	assert.InDelta(t, 0.1, diff.Summary.MeanConfidenceDelta, 1e-9)
+}
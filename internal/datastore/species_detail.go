@@ -0,0 +1,134 @@
+// species_detail.go: Aggregated detection history of a single species
+package datastore
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// SpeciesDetail aggregates all detections of one species
+type SpeciesDetail struct {
+	ScientificName  string
+	CommonName      string
+	FirstSeen       string // date and time of the first detection, "2006-01-02 15:04:05"
+	LastSeen        string // date and time of the latest detection
+	TotalDetections int64
+	Monthly         [12]int // detections by month of the year, January first
+	Hourly          [24]int // detections by hour of the day
+	BestClip        *Note   // highest confidence detection with an audio clip, nil when there is none
+	Recent          []Note  // latest detections, newest first
+}
+
+// GetSpeciesDetail aggregates the detections of a species by scientific name
+// with up to recentLimit latest detections. A species without detections has
+// a zero TotalDetections.
+func (ds *DataStore) GetSpeciesDetail(ctx context.Context, scientificName string, recentLimit int) (*SpeciesDetail, error) {
+	if scientificName == "" {
+		return nil, validationError("scientific name cannot be empty", "scientific_name", scientificName)
+	}
+	detail := &SpeciesDetail{ScientificName: scientificName}
+
+	if err := ds.DB.WithContext(ctx).Model(&Note{}).
+		Where("scientific_name = ?", scientificName).
+		Count(&detail.TotalDetections).Error; err != nil {
+		return nil, speciesDetailError(err, scientificName, "total")
+	}
+	if detail.TotalDetections == 0 {
+		return detail, nil
+	}
+
+	for _, seen := range []struct {
+		order  string
+		target *string
+	}{
+		{"date ASC, time ASC", &detail.FirstSeen},
+		{"date DESC, time DESC", &detail.LastSeen},
+	} {
+		var note Note
+		if err := ds.DB.WithContext(ctx).
+			Select("date, time").
+			Where("scientific_name = ?", scientificName).
+			Order(seen.order).
+			Limit(1).
+			Find(&note).Error; err != nil {
+			return nil, speciesDetailError(err, scientificName, "first_last_seen")
+		}
+		*seen.target = note.Date + " " + note.Time
+	}
+
+	// Monthly histogram, dates are stored as YYYY-MM-DD
+	var monthly []struct {
+		Month string
+		Count int
+	}
+	if err := ds.DB.WithContext(ctx).Table("notes").
+		Select("SUBSTR(date, 6, 2) AS month, COUNT(*) AS count").
+		Where("scientific_name = ?", scientificName).
+		Group("SUBSTR(date, 6, 2)").
+		Scan(&monthly).Error; err != nil {
+		return nil, speciesDetailError(err, scientificName, "monthly")
+	}
+	for _, m := range monthly {
+		if month, err := strconv.Atoi(m.Month); err == nil && month >= 1 && month <= 12 {
+			detail.Monthly[month-1] = m.Count
+		}
+	}
+
+	// Hourly activity pattern, skipped on databases without an hour expression
+	if hourExpr := ds.GetHourFormat(); hourExpr != "" {
+		var hourly []struct {
+			Hour  string
+			Count int
+		}
+		if err := ds.DB.WithContext(ctx).Table("notes").
+			Select(fmt.Sprintf("%s AS hour, COUNT(*) AS count", hourExpr)).
+			Where("scientific_name = ?", scientificName).
+			Group(hourExpr).
+			Scan(&hourly).Error; err != nil {
+			return nil, speciesDetailError(err, scientificName, "hourly")
+		}
+		for _, h := range hourly {
+			if hour, err := strconv.Atoi(h.Hour); err == nil && hour >= 0 && hour < 24 {
+				detail.Hourly[hour] = h.Count
+			}
+		}
+	}
+
+	var best []Note
+	if err := ds.DB.WithContext(ctx).
+		Where("scientific_name = ? AND clip_name <> ''", scientificName).
+		Order("confidence DESC, date DESC, time DESC").
+		Limit(1).
+		Find(&best).Error; err != nil {
+		return nil, speciesDetailError(err, scientificName, "best_clip")
+	}
+	if len(best) > 0 {
+		detail.BestClip = &best[0]
+		detail.CommonName = best[0].CommonName
+	}
+
+	if recentLimit > 0 {
+		if err := ds.DB.WithContext(ctx).
+			Where("scientific_name = ?", scientificName).
+			Order("date DESC, time DESC").
+			Limit(recentLimit).
+			Find(&detail.Recent).Error; err != nil {
+			return nil, speciesDetailError(err, scientificName, "recent")
+		}
+		if len(detail.Recent) > 0 {
+			detail.CommonName = detail.Recent[0].CommonName
+		}
+	}
+	return detail, nil
+}
+
+// speciesDetailError wraps a failed species detail query
+func speciesDetailError(err error, scientificName, part string) error {
+	return dbError(err, "get_species_detail", errors.PriorityLow,
+		"scientific_name", scientificName,
+		"part", part,
+		"table", "notes")
+}
@@ -0,0 +1,49 @@
+// species_detail_test.go: Tests for species detail aggregation
+package datastore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSpeciesDetail(t *testing.T) {
+	t.Parallel()
+
+	ds := setupTestDB(t)
+	notes := []Note{
+		{ID: 1, Date: "2024-03-01", Time: "06:12:00", ScientificName: "Turdus migratorius", CommonName: "American Robin", Confidence: 0.7},
+		{ID: 2, Date: "2024-04-02", Time: "07:00:00", ScientificName: "Turdus migratorius", CommonName: "American Robin", Confidence: 0.97, ClipName: "robin.wav"},
+		{ID: 3, Date: "2025-05-10", Time: "19:40:00", ScientificName: "Turdus migratorius", CommonName: "American Robin", Confidence: 0.99},
+		{ID: 4, Date: "2025-05-11", Time: "07:30:00", ScientificName: "Cyanocitta cristata", CommonName: "Blue Jay", Confidence: 0.9},
+	}
+	require.NoError(t, ds.DB.Create(&notes).Error)
+
+	detail, err := ds.GetSpeciesDetail(context.Background(), "Turdus migratorius", 2)
+	require.NoError(t, err)
+	assert.Equal(t, "American Robin", detail.CommonName)
+	assert.Equal(t, int64(3), detail.TotalDetections)
+	assert.Equal(t, "2024-03-01 06:12:00", detail.FirstSeen)
+	assert.Equal(t, "2025-05-10 19:40:00", detail.LastSeen)
+	assert.Equal(t, [12]int{0, 0, 1, 1, 1, 0, 0, 0, 0, 0, 0, 0}, detail.Monthly)
+	assert.Equal(t, 1, detail.Hourly[6])
+	assert.Equal(t, 1, detail.Hourly[7])
+	assert.Equal(t, 1, detail.Hourly[19])
+
+	require.NotNil(t, detail.BestClip, "the highest confidence detection with a clip should be the best clip")
+	assert.Equal(t, uint(2), detail.BestClip.ID)
+
+	require.Len(t, detail.Recent, 2)
+	assert.Equal(t, uint(3), detail.Recent[0].ID)
+	assert.Equal(t, uint(2), detail.Recent[1].ID)
+
+	empty, err := ds.GetSpeciesDetail(context.Background(), "Pica pica", 2)
+	require.NoError(t, err)
+	assert.Zero(t, empty.TotalDetections)
+	assert.Nil(t, empty.BestClip)
+
+	_, err = ds.GetSpeciesDetail(context.Background(), "", 2)
+	require.Error(t, err)
+}
@@ -0,0 +1,161 @@
+// sqlcipher.go: SQLCipher encrypted SQLite databases
+package datastore
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"os"
+
+	"github.com/tphakala/birdnet-go/internal/atrest"
+	"github.com/tphakala/birdnet-go/internal/errors"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// sqlitePlaintextHeader starts every unencrypted SQLite database file
+const sqlitePlaintextHeader = "SQLite format 3\x00"
+
+// sqliteDialector returns the GORM dialector for the database, keying
+// connections with SQLCipher when database encryption is enabled. An existing
+// unencrypted database is encrypted first.
+func (s *SQLiteStore) sqliteDialector(dbPath string) (gorm.Dialector, error) {
+	settings := s.Settings.Encryption
+	if !settings.Database {
+		return sqlite.Open(dbPath), nil
+	}
+
+	if atrest.Active() == nil {
+		cipher, err := atrest.LoadKey(settings.KeyFile, settings.Key)
+		if err != nil {
+			return nil, err
+		}
+		atrest.SetActive(cipher)
+	}
+
+	if err := encryptPlaintextDatabase(dbPath); err != nil {
+		return nil, err
+	}
+	return sqlite.New(sqlite.Config{DriverName: atrest.SQLCipherDriver, DSN: dbPath}), nil
+}
+
+// verifySQLCipher ensures an encrypted database is really encrypted. Without
+// SQLCipher, SQLite ignores the key and would store data in plaintext.
+func verifySQLCipher(sqlDB *sql.DB, dbPath string) error {
+	if atrest.SQLCipherAvailable(sqlDB) {
+		return nil
+	}
+	return errSQLCipherUnavailable(dbPath)
+}
+
+// encryptPlaintextDatabase encrypts an existing unencrypted database in place
+// with sqlcipher_export. New and already encrypted databases are left as is.
+func encryptPlaintextDatabase(dbPath string) error {
+	plaintext, err := isPlaintextSQLite(dbPath)
+	if err != nil || !plaintext {
+		return err
+	}
+
+	getLogger().Info("Encrypting existing SQLite database",
+		"path", dbPath)
+
+	tmpPath := dbPath + ".encrypting"
+	_ = os.Remove(tmpPath)
+	if err := exportEncrypted(dbPath, tmpPath); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+
+	// The write-ahead log belongs to the plaintext database and must not be
+	// applied to the encrypted one
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Remove(dbPath + suffix); err != nil && !os.IsNotExist(err) {
+			_ = os.Remove(tmpPath)
+			return encryptionError(err, "remove_plaintext_wal", dbPath)
+		}
+	}
+	if err := os.Rename(tmpPath, dbPath); err != nil {
+		_ = os.Remove(tmpPath)
+		return encryptionError(err, "replace_plaintext_database", dbPath)
+	}
+
+	getLogger().Info("SQLite database encrypted",
+		"path", dbPath)
+	return nil
+}
+
+// exportEncrypted copies a plaintext database into a new encrypted database
+func exportEncrypted(dbPath, encryptedPath string) error {
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		return encryptionError(err, "open_plaintext_database", dbPath)
+	}
+	defer func() { _ = db.Close() }()
+
+	if !atrest.SQLCipherAvailable(db) {
+		return errSQLCipherUnavailable(dbPath)
+	}
+
+	// ATTACH is per connection, so all statements must use the same one
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return encryptionError(err, "open_plaintext_database", dbPath)
+	}
+	defer func() { _ = conn.Close() }()
+
+	statements := []struct {
+		query string
+		args  []any
+	}{
+		{"PRAGMA wal_checkpoint(TRUNCATE)", nil},
+		{"ATTACH DATABASE ? AS encrypted KEY " + atrest.Active().RawKeyLiteral(), []any{encryptedPath}},
+		{"SELECT sqlcipher_export('encrypted')", nil},
+		{"DETACH DATABASE encrypted", nil},
+	}
+	for _, stmt := range statements {
+		if _, err := conn.ExecContext(ctx, stmt.query, stmt.args...); err != nil {
+			return encryptionError(err, "export_encrypted_database", dbPath)
+		}
+	}
+	return nil
+}
+
+// isPlaintextSQLite reports whether the file at path is an unencrypted SQLite database
+func isPlaintextSQLite(path string) (bool, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, encryptionError(err, "read_database_header", path)
+	}
+	defer func() { _ = f.Close() }()
+
+	header := make([]byte, len(sqlitePlaintextHeader))
+	if _, err := io.ReadFull(f, header); err != nil {
+		// Empty or truncated files are not plaintext databases with content to keep
+		return false, nil
+	}
+	return string(header) == sqlitePlaintextHeader, nil
+}
+
+// errSQLCipherUnavailable reports a binary built without SQLCipher
+func errSQLCipherUnavailable(dbPath string) error {
+	return errors.Newf("database encryption requires a build linked against SQLCipher").
+		Component("datastore").
+		Category(errors.CategoryConfiguration).
+		Context("operation", "verify_sqlcipher").
+		Context("db_path", dbPath).
+		Build()
+}
+
+// encryptionError wraps a database encryption error with context
+func encryptionError(err error, operation, dbPath string) error {
+	return errors.New(err).
+		Component("datastore").
+		Category(errors.CategoryDatabase).
+		Context("operation", operation).
+		Context("db_path", dbPath).
+		Build()
+}
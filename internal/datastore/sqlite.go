@@ -13,7 +13,6 @@ import (
 	"github.com/tphakala/birdnet-go/internal/conf"
 	"github.com/tphakala/birdnet-go/internal/diskmanager"
 	"github.com/tphakala/birdnet-go/internal/errors"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
@@ -208,8 +207,17 @@ func (s *SQLiteStore) Open() error {
 		gormLogger = NewGormLogger(200*time.Millisecond, logger.Warn, s.metrics)
 	}
 
+	// Select the driver, encrypted databases are keyed on every new connection
+	dialector, err := s.sqliteDialector(dbPath)
+	if err != nil {
+		if s.telemetry != nil {
+			s.telemetry.CaptureEnhancedError(err, "open_sqlite_database", s)
+		}
+		return err
+	}
+
 	// Open SQLite database with GORM
-	db, err := gorm.Open(sqlite.Open(dbPath), &gorm.Config{
+	db, err := gorm.Open(dialector, &gorm.Config{
 		Logger: gormLogger,
 	})
 	if err != nil {
@@ -237,6 +245,12 @@ func (s *SQLiteStore) Open() error {
 			Context("operation", "get_underlying_sqldb").
 			Build()
 	}
+	if s.Settings.Encryption.Database {
+		if err := verifySQLCipher(sqlDB, dbPath); err != nil {
+			_ = sqlDB.Close()
+			return err
+		}
+	}
 
 	// Set pragmas
 	pragmas := []string{
@@ -454,6 +468,7 @@ func (s *SQLiteStore) Optimize(ctx context.Context) error {
 
 // UpdateNote updates specific fields of a note in SQLite
 func (s *SQLiteStore) UpdateNote(id string, updates map[string]interface{}) error {
+	defer s.markDailyAggregatesDirty(s.noteAggregateDates(id, updates)...)
 	return s.DB.Model(&Note{}).Where("id = ?", id).Updates(updates).Error
 }
 
@@ -0,0 +1,100 @@
+// station.go: Station profile and equipment history
+package datastore
+
+import (
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/errors"
+	"gorm.io/gorm"
+)
+
+// stationProfileID is the primary key of the single station profile
+const stationProfileID = 1
+
+// GetStationProfile returns the station profile, empty when none has been saved
+func (ds *DataStore) GetStationProfile() (*StationProfile, error) {
+	var profile StationProfile
+	if err := ds.DB.Where("id = ?", stationProfileID).Limit(1).Find(&profile).Error; err != nil {
+		return nil, dbError(err, "get_station_profile", errors.PriorityLow,
+			"table", "station_profiles")
+	}
+	return &profile, nil
+}
+
+// SaveStationProfile stores the station profile, keeping the photo. A change
+// of microphone model or mounting height is recorded in the equipment history
+// and the deployment journal at changedAt with note as its reason.
+func (ds *DataStore) SaveStationProfile(profile *StationProfile, changedAt time.Time, note string) error {
+	if profile.MountingHeight < 0 {
+		return validationError("mounting height cannot be negative", "mounting_height", profile.MountingHeight)
+	}
+
+	err := ds.DB.Transaction(func(tx *gorm.DB) error {
+		var current StationProfile
+		res := tx.Where("id = ?", stationProfileID).Limit(1).Find(&current)
+		if res.Error != nil {
+			return res.Error
+		}
+
+		equipmentChanged := current.MicrophoneModel != profile.MicrophoneModel || current.MountingHeight != profile.MountingHeight
+		if equipmentChanged && (res.RowsAffected > 0 || profile.MicrophoneModel != "" || profile.MountingHeight != 0) {
+			change := &StationEquipmentChange{
+				ChangedAt:       changedAt,
+				MicrophoneModel: profile.MicrophoneModel,
+				MountingHeight:  profile.MountingHeight,
+				Note:            note,
+			}
+			if err := tx.Create(change).Error; err != nil {
+				return err
+			}
+			// Equipment changes are also journaled, so they show up as analytics markers
+			if err := tx.Create(equipmentDeploymentChange(change)).Error; err != nil {
+				return err
+			}
+		}
+
+		profile.ID = stationProfileID
+		profile.Photo, profile.PhotoType = current.Photo, current.PhotoType
+		return tx.Save(profile).Error
+	})
+	if err != nil {
+		return dbError(err, "save_station_profile", errors.PriorityMedium,
+			"table", "station_profiles",
+			"action", "update_station_metadata")
+	}
+	return nil
+}
+
+// SetStationPhoto stores the photo of the station site, or removes it when
+// data is empty
+func (ds *DataStore) SetStationPhoto(data []byte, contentType string) error {
+	if len(data) == 0 {
+		data, contentType = nil, ""
+	}
+
+	err := ds.DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&StationProfile{}).Where("id = ?", stationProfileID).
+			Updates(map[string]interface{}{"photo": data, "photo_type": contentType, "updated_at": time.Now()})
+		if res.Error != nil || res.RowsAffected > 0 {
+			return res.Error
+		}
+		return tx.Create(&StationProfile{ID: stationProfileID, Photo: data, PhotoType: contentType}).Error
+	})
+	if err != nil {
+		return dbError(err, "set_station_photo", errors.PriorityLow,
+			"table", "station_profiles",
+			"size", len(data))
+	}
+	return nil
+}
+
+// GetStationEquipmentHistory returns the recorded equipment changes, most
+// recent first
+func (ds *DataStore) GetStationEquipmentHistory() ([]StationEquipmentChange, error) {
+	var changes []StationEquipmentChange
+	if err := ds.DB.Order("changed_at DESC, id DESC").Find(&changes).Error; err != nil {
+		return nil, dbError(err, "get_station_equipment_history", errors.PriorityLow,
+			"table", "station_equipment_changes")
+	}
+	return changes, nil
+}
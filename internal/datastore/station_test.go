@@ -0,0 +1,57 @@
+// station_test.go: Tests for the station profile
+package datastore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStationProfile(t *testing.T) {
+	t.Parallel()
+
+	ds := setupTestDB(t)
+	require.NoError(t, ds.DB.AutoMigrate(&StationProfile{}, &StationEquipmentChange{}, &DeploymentChange{}))
+
+	profile, err := ds.GetStationProfile()
+	require.NoError(t, err)
+	assert.Empty(t, profile.Name, "no profile has been saved yet")
+
+	installed := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, ds.SetStationPhoto([]byte("jpeg"), "image/jpeg"))
+	require.NoError(t, ds.SaveStationProfile(&StationProfile{
+		Name: "Garden", Habitat: "urban garden", MicrophoneModel: "AudioMoth", MountingHeight: 2,
+	}, installed, "installed"))
+
+	// Editing the description keeps the photo and records no equipment change
+	require.NoError(t, ds.SaveStationProfile(&StationProfile{
+		Name: "Garden", Description: "Back garden", Habitat: "urban garden", MicrophoneModel: "AudioMoth", MountingHeight: 2,
+	}, installed.AddDate(0, 1, 0), ""))
+	require.NoError(t, ds.SaveStationProfile(&StationProfile{
+		Name: "Garden", Description: "Back garden", Habitat: "urban garden", MicrophoneModel: "AudioMoth", MountingHeight: 4,
+	}, installed.AddDate(0, 2, 0), "moved up the tree"))
+
+	profile, err = ds.GetStationProfile()
+	require.NoError(t, err)
+	assert.Equal(t, "Back garden", profile.Description)
+	assert.InDelta(t, 4.0, profile.MountingHeight, 1e-9)
+	assert.Equal(t, []byte("jpeg"), profile.Photo)
+	assert.Equal(t, "image/jpeg", profile.PhotoType)
+
+	history, err := ds.GetStationEquipmentHistory()
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, "moved up the tree", history[0].Note)
+	assert.InDelta(t, 4.0, history[0].MountingHeight, 1e-9)
+	assert.Equal(t, "installed", history[1].Note)
+
+	journal, err := ds.GetDeploymentChanges(time.Time{}, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, journal, 2)
+	assert.Equal(t, "Microphone AudioMoth mounted at 4 m", journal[1].Summary)
+	assert.Equal(t, DeploymentChangeEquipment, journal[1].Category)
+
+	require.Error(t, ds.SaveStationProfile(&StationProfile{MountingHeight: -1}, installed, ""))
+}
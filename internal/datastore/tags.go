@@ -0,0 +1,175 @@
+// tags.go: Tags attached to detections
+package datastore
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/tphakala/birdnet-go/internal/errors"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrTagNotFound = errors.NewStd("tag not found")
+	ErrTagExists   = errors.NewStd("tag already exists")
+)
+
+// TagUsage is a tag with the number of detections it is attached to
+type TagUsage struct {
+	ID         uint
+	Name       string
+	CreatedAt  time.Time
+	Detections int64
+}
+
+// validateTagName checks that a tag name can be stored and used in filter
+// expressions, as tag:"name"
+func validateTagName(name string) error {
+	if name == "" {
+		return validationError("tag name cannot be empty", "name", name)
+	}
+	if len(name) > 64 {
+		return validationError("tag name is longer than 64 characters", "name", name)
+	}
+	if strings.ContainsFunc(name, func(r rune) bool { return r == '"' || unicode.IsControl(r) }) {
+		return validationError("tag name cannot contain quotes or control characters", "name", name)
+	}
+	return nil
+}
+
+// CreateTag stores a new tag. Surrounding spaces are removed from the name,
+// and a tag differing only in case from an existing one is rejected.
+func (ds *DataStore) CreateTag(name string) (*Tag, error) {
+	name = strings.TrimSpace(name)
+	if err := validateTagName(name); err != nil {
+		return nil, err
+	}
+
+	tag := &Tag{Name: name}
+	err := ds.DB.Transaction(func(tx *gorm.DB) error {
+		var existing int64
+		if err := tx.Model(&Tag{}).Where("LOWER(name) = LOWER(?)", name).Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return ErrTagExists
+		}
+		return tx.Create(tag).Error
+	})
+	if errors.Is(err, ErrTagExists) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, dbError(err, "create_tag", errors.PriorityLow,
+			"name", name,
+			"table", "tags")
+	}
+	return tag, nil
+}
+
+// GetTags returns all tags ordered by name with the number of detections
+// each is attached to
+func (ds *DataStore) GetTags() ([]TagUsage, error) {
+	var tags []TagUsage
+	if err := ds.DB.Model(&Tag{}).
+		Select("tags.id, tags.name, tags.created_at, (SELECT COUNT(*) FROM note_tags WHERE note_tags.tag_id = tags.id) AS detections").
+		Order("LOWER(tags.name) ASC").
+		Scan(&tags).Error; err != nil {
+		return nil, dbError(err, "get_tags", errors.PriorityLow,
+			"table", "tags")
+	}
+	return tags, nil
+}
+
+// DeleteTag removes a tag and detaches it from all detections
+func (ds *DataStore) DeleteTag(id uint) error {
+	err := ds.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tag_id = ?", id).Delete(&NoteTag{}).Error; err != nil {
+			return err
+		}
+		res := tx.Delete(&Tag{}, id)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrTagNotFound
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, ErrTagNotFound) {
+		return dbError(err, "delete_tag", errors.PriorityLow,
+			"id", id,
+			"table", "tags")
+	}
+	return err
+}
+
+// GetNoteTags returns the tags attached to a detection ordered by name
+func (ds *DataStore) GetNoteTags(noteID uint) ([]Tag, error) {
+	var tags []Tag
+	if err := ds.DB.Joins("JOIN note_tags ON note_tags.tag_id = tags.id").
+		Where("note_tags.note_id = ?", noteID).
+		Order("LOWER(tags.name) ASC").
+		Find(&tags).Error; err != nil {
+		return nil, dbError(err, "get_note_tags", errors.PriorityLow,
+			"note_id", noteID,
+			"table", "note_tags")
+	}
+	return tags, nil
+}
+
+// AddNoteTags attaches tags to a detection. Tags already attached are kept,
+// and every tag must exist.
+func (ds *DataStore) AddNoteTags(noteID uint, tagIDs []uint) error {
+	var notes int64
+	if err := ds.DB.Model(&Note{}).Where("id = ?", noteID).Count(&notes).Error; err != nil {
+		return dbError(err, "add_note_tags", errors.PriorityLow,
+			"note_id", noteID,
+			"table", "notes")
+	}
+	if notes == 0 {
+		return notFoundError("note", strconv.FormatUint(uint64(noteID), 10))
+	}
+
+	var tags []Tag
+	if err := ds.DB.Where("id IN ?", tagIDs).Find(&tags).Error; err != nil {
+		return dbError(err, "add_note_tags", errors.PriorityLow,
+			"note_id", noteID,
+			"table", "tags")
+	}
+	if len(tags) != len(uniqueIDs(tagIDs)) {
+		return ErrTagNotFound
+	}
+	if err := ds.DB.Model(&Note{ID: noteID}).Association("Tags").Append(tags); err != nil {
+		return dbError(err, "add_note_tags", errors.PriorityLow,
+			"note_id", noteID,
+			"table", "note_tags")
+	}
+	return nil
+}
+
+// RemoveNoteTag detaches a tag from a detection
+func (ds *DataStore) RemoveNoteTag(noteID, tagID uint) error {
+	res := ds.DB.Where("note_id = ? AND tag_id = ?", noteID, tagID).Delete(&NoteTag{})
+	if res.Error != nil {
+		return dbError(res.Error, "remove_note_tag", errors.PriorityLow,
+			"note_id", noteID,
+			"tag_id", tagID,
+			"table", "note_tags")
+	}
+	if res.RowsAffected == 0 {
+		return ErrTagNotFound
+	}
+	return nil
+}
+
+// uniqueIDs returns the distinct IDs of a list
+func uniqueIDs(ids []uint) map[uint]struct{} {
+	unique := make(map[uint]struct{}, len(ids))
+	for _, id := range ids {
+		unique[id] = struct{}{}
+	}
+	return unique
+}
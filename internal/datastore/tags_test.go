@@ -0,0 +1,66 @@
+// tags_test.go: Tests for tags attached to detections
+package datastore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTags(t *testing.T) {
+	t.Parallel()
+
+	ds := setupTestDB(t)
+	require.NoError(t, ds.DB.AutoMigrate(&NoteReview{}, &NoteComment{}, &NoteLock{}))
+	notes := []Note{
+		{Date: "2024-05-01", Time: "06:00:00", ScientificName: "Parus major", CommonName: "Great Tit", Confidence: 0.9},
+		{Date: "2024-05-01", Time: "07:00:00", ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", Confidence: 0.8},
+	}
+	require.NoError(t, ds.DB.Create(&notes).Error)
+
+	nestbox, err := ds.CreateTag(" Nestbox ")
+	require.NoError(t, err)
+	assert.Equal(t, "Nestbox", nestbox.Name)
+	banded, err := ds.CreateTag("banded bird")
+	require.NoError(t, err)
+	_, err = ds.CreateTag("nestbox")
+	require.ErrorIs(t, err, ErrTagExists, "names are unique regardless of case")
+	require.NotErrorIs(t, err, ErrTagNotFound)
+	_, err = ds.CreateTag(`say "hi"`)
+	require.Error(t, err, "quotes cannot be used in filter expressions")
+
+	require.NoError(t, ds.AddNoteTags(notes[0].ID, []uint{nestbox.ID, banded.ID}))
+	require.NoError(t, ds.AddNoteTags(notes[0].ID, []uint{nestbox.ID}), "attaching a tag again is a no-op")
+	require.NoError(t, ds.AddNoteTags(notes[1].ID, []uint{banded.ID}))
+	require.ErrorIs(t, ds.AddNoteTags(notes[1].ID, []uint{banded.ID, 99}), ErrTagNotFound)
+	require.Error(t, ds.AddNoteTags(999, []uint{banded.ID}))
+
+	tags, err := ds.GetNoteTags(notes[0].ID)
+	require.NoError(t, err)
+	require.Len(t, tags, 2)
+	assert.Equal(t, "Nestbox", tags[1].Name, "ordered by name regardless of case")
+
+	usage, err := ds.GetTags()
+	require.NoError(t, err)
+	require.Len(t, usage, 2)
+	assert.Equal(t, "banded bird", usage[0].Name)
+	assert.Equal(t, int64(2), usage[0].Detections)
+	assert.Equal(t, int64(1), usage[1].Detections)
+
+	query, err := ParseNoteQuery(`(tag:nestbox OR tag:"Banded Bird") -species:"Great Tit"`)
+	require.NoError(t, err)
+	found, total, err := ds.QueryNotes(context.Background(), query, 10, 0)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), total)
+	assert.Equal(t, notes[1].ID, found[0].ID)
+
+	require.NoError(t, ds.RemoveNoteTag(notes[0].ID, banded.ID))
+	require.ErrorIs(t, ds.RemoveNoteTag(notes[0].ID, banded.ID), ErrTagNotFound)
+	require.NoError(t, ds.DeleteTag(nestbox.ID))
+	require.ErrorIs(t, ds.DeleteTag(nestbox.ID), ErrTagNotFound)
+	tags, err = ds.GetNoteTags(notes[0].ID)
+	require.NoError(t, err)
+	assert.Empty(t, tags)
+}
@@ -0,0 +1,246 @@
+// taxonomy_remap.go: Remapping of stored species after taxonomy changes
+package datastore
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/errors"
+	"gorm.io/gorm"
+)
+
+// taxonomyRemapBatchSize is the number of detections updated per statement
+const taxonomyRemapBatchSize = 500
+
+// Sentinel errors for reverting taxonomy remappings
+var (
+	ErrTaxonomyRemapNotFound = errors.NewStd("taxonomy remapping not found")
+	ErrTaxonomyRemapReverted = errors.NewStd("taxonomy remapping was already reverted")
+)
+
+// TaxonomyMapping renames the detections stored under a former species name
+type TaxonomyMapping struct {
+	From           string // Former scientific or common name
+	ScientificName string // Current species
+	CommonName     string
+	SpeciesCode    string
+}
+
+// TaxonomyRemapCount is the number of detections renamed by a mapping
+type TaxonomyRemapCount struct {
+	From           string `json:"from"`
+	ScientificName string `json:"scientific_name"`
+	CommonName     string `json:"common_name"`
+	SpeciesCode    string `json:"species_code"`
+	Detections     int64  `json:"detections"`
+}
+
+// TaxonomyRemapReport is the outcome of a taxonomy remapping
+type TaxonomyRemapReport struct {
+	RunID      string               `json:"run_id"`
+	Detections int64                `json:"detections"` // Total remapped detections
+	Mappings   []TaxonomyRemapCount `json:"mappings"`
+}
+
+// validateTaxonomyMappings checks that each mapping names a former and a
+// current species and that no former name is mapped twice
+func validateTaxonomyMappings(mappings []TaxonomyMapping) error {
+	if len(mappings) == 0 {
+		return validationError("at least one mapping is required", "mappings", 0)
+	}
+	seen := make(map[string]bool, len(mappings))
+	for i := range mappings {
+		m := &mappings[i]
+		if strings.TrimSpace(m.From) == "" || strings.TrimSpace(m.ScientificName) == "" {
+			return validationError("mapping needs a former name and a scientific name", "mappings", i)
+		}
+		if seen[m.From] {
+			return validationError("former name is mapped more than once", "from", m.From)
+		}
+		seen[m.From] = true
+	}
+	return nil
+}
+
+// taxonomyRemapScope selects the detections stored under the former name of a
+// mapping that are not already stored as the current species
+func taxonomyRemapScope(m *TaxonomyMapping) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("(scientific_name = ? OR common_name = ?) AND NOT (scientific_name = ? AND common_name = ? AND species_code = ?)",
+			m.From, m.From, m.ScientificName, m.CommonName, m.SpeciesCode)
+	}
+}
+
+// CountTaxonomyRemap returns the number of detections each mapping would
+// rename, without changing anything
+func (ds *DataStore) CountTaxonomyRemap(mappings []TaxonomyMapping) (*TaxonomyRemapReport, error) {
+	if err := validateTaxonomyMappings(mappings); err != nil {
+		return nil, err
+	}
+
+	report := &TaxonomyRemapReport{Mappings: make([]TaxonomyRemapCount, 0, len(mappings))}
+	for i := range mappings {
+		m := &mappings[i]
+		var count int64
+		if err := ds.DB.Model(&Note{}).Scopes(taxonomyRemapScope(m)).Count(&count).Error; err != nil {
+			return nil, dbError(err, "count_taxonomy_remap", errors.PriorityMedium,
+				"from", m.From,
+				"action", "preview_taxonomy_remap")
+		}
+		report.Mappings = append(report.Mappings, taxonomyRemapCount(m, count))
+		report.Detections += count
+	}
+	return report, nil
+}
+
+// RemapTaxonomy renames the detections of each mapping in a single
+// transaction, backing up the species of every changed detection under runID
+// so the remapping can be reverted. Mappings are applied in order. Only
+// detections are remapped; results and thinned detection aggregates keep
+// the labels they were recorded with.
+func (ds *DataStore) RemapTaxonomy(runID, source string, mappings []TaxonomyMapping) (*TaxonomyRemapReport, error) {
+	if err := validateTaxonomyMappings(mappings); err != nil {
+		return nil, err
+	}
+	if runID == "" {
+		return nil, validationError("run ID is required", "run_id", runID)
+	}
+
+	report := &TaxonomyRemapReport{RunID: runID, Mappings: make([]TaxonomyRemapCount, 0, len(mappings))}
+	err := ds.DB.Transaction(func(tx *gorm.DB) error {
+		for i := range mappings {
+			m := &mappings[i]
+			var notes []Note
+			if err := tx.Model(&Note{}).Scopes(taxonomyRemapScope(m)).
+				Select("id", "species_code", "scientific_name", "common_name").
+				Find(&notes).Error; err != nil {
+				return err
+			}
+
+			for batch := range slices.Chunk(notes, taxonomyRemapBatchSize) {
+				backups := make([]TaxonomyRemapBackup, 0, len(batch))
+				ids := make([]uint, 0, len(batch))
+				for j := range batch {
+					backups = append(backups, TaxonomyRemapBackup{
+						RunID:          runID,
+						NoteID:         batch[j].ID,
+						SpeciesCode:    batch[j].SpeciesCode,
+						ScientificName: batch[j].ScientificName,
+						CommonName:     batch[j].CommonName,
+					})
+					ids = append(ids, batch[j].ID)
+				}
+				if err := tx.Create(&backups).Error; err != nil {
+					return err
+				}
+				if err := tx.Model(&Note{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+					"species_code":    m.SpeciesCode,
+					"scientific_name": m.ScientificName,
+					"common_name":     m.CommonName,
+				}).Error; err != nil {
+					return err
+				}
+			}
+
+			report.Mappings = append(report.Mappings, taxonomyRemapCount(m, int64(len(notes))))
+			report.Detections += int64(len(notes))
+		}
+
+		return tx.Create(&TaxonomyRemapRun{
+			RunID:      runID,
+			Source:     source,
+			Detections: report.Detections,
+		}).Error
+	})
+	if err != nil {
+		return nil, dbError(err, "remap_taxonomy", errors.PriorityHigh,
+			"run_id", runID,
+			"mappings", len(mappings),
+			"action", "remap_species_taxonomy")
+	}
+
+	if report.Detections > 0 {
+		ds.markAllDailyAggregatesDirty()
+	}
+	return report, nil
+}
+
+// RevertTaxonomyRemap restores the species of the detections changed by a
+// remapping from its backups and returns the number of restored detections.
+// Detections deleted since the remapping are skipped.
+func (ds *DataStore) RevertTaxonomyRemap(runID string) (int64, error) {
+	var restored int64
+	err := ds.DB.Transaction(func(tx *gorm.DB) error {
+		var run TaxonomyRemapRun
+		if err := tx.Where("run_id = ?", runID).First(&run).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrTaxonomyRemapNotFound
+			}
+			return err
+		}
+		if run.RevertedAt != nil {
+			return ErrTaxonomyRemapReverted
+		}
+
+		var backups []TaxonomyRemapBackup
+		if err := tx.Where("run_id = ?", runID).Order("id DESC").Find(&backups).Error; err != nil {
+			return err
+		}
+
+		// A detection renamed by chained mappings has several backups; the
+		// first one holds the species it had before the remapping
+		original := make(map[uint]TaxonomyRemapBackup, len(backups))
+		for _, b := range backups {
+			original[b.NoteID] = b
+		}
+		for noteID, b := range original {
+			res := tx.Model(&Note{}).Where("id = ?", noteID).Updates(map[string]interface{}{
+				"species_code":    b.SpeciesCode,
+				"scientific_name": b.ScientificName,
+				"common_name":     b.CommonName,
+			})
+			if res.Error != nil {
+				return res.Error
+			}
+			restored += res.RowsAffected
+		}
+
+		now := time.Now()
+		return tx.Model(&run).Update("reverted_at", &now).Error
+	})
+	if err != nil {
+		if errors.Is(err, ErrTaxonomyRemapNotFound) || errors.Is(err, ErrTaxonomyRemapReverted) {
+			return 0, err
+		}
+		return 0, dbError(err, "revert_taxonomy_remap", errors.PriorityHigh,
+			"run_id", runID,
+			"action", "revert_species_taxonomy_remap")
+	}
+
+	if restored > 0 {
+		ds.markAllDailyAggregatesDirty()
+	}
+	return restored, nil
+}
+
+// GetTaxonomyRemapRuns returns the taxonomy remappings, newest first
+func (ds *DataStore) GetTaxonomyRemapRuns() ([]TaxonomyRemapRun, error) {
+	var runs []TaxonomyRemapRun
+	if err := ds.DB.Order("id DESC").Find(&runs).Error; err != nil {
+		return nil, dbError(err, "get_taxonomy_remap_runs", errors.PriorityLow,
+			"action", "list_taxonomy_remaps")
+	}
+	return runs, nil
+}
+
+// taxonomyRemapCount returns the report entry of a mapping
+func taxonomyRemapCount(m *TaxonomyMapping, detections int64) TaxonomyRemapCount {
+	return TaxonomyRemapCount{
+		From:           m.From,
+		ScientificName: m.ScientificName,
+		CommonName:     m.CommonName,
+		SpeciesCode:    m.SpeciesCode,
+		Detections:     detections,
+	}
+}
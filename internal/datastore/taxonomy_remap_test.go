@@ -0,0 +1,130 @@
+// taxonomy_remap_test.go: Tests for taxonomy remapping and reverting
+package datastore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+func TestRemapTaxonomy(t *testing.T) {
+	t.Parallel()
+
+	ds := setupTestDB(t)
+	require.NoError(t, ds.DB.AutoMigrate(&TaxonomyRemapRun{}, &TaxonomyRemapBackup{}))
+
+	notes := []Note{
+		{Date: "2020-05-01", Time: "06:00:00", SpeciesCode: "amegfi", ScientificName: "Carduelis tristis", CommonName: "American Goldfinch", Confidence: 0.9},
+		{Date: "2020-05-01", Time: "06:10:00", SpeciesCode: "amegfi", ScientificName: "Carduelis tristis", CommonName: "American Goldfinch", Confidence: 0.8},
+		{Date: "2020-05-01", Time: "06:20:00", SpeciesCode: "gryjay", ScientificName: "Perisoreus canadensis", CommonName: "Gray Jay", Confidence: 0.7},
+		{Date: "2020-05-01", Time: "06:30:00", SpeciesCode: "eurbla", ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", Confidence: 0.9},
+		{Date: "2020-05-01", Time: "06:40:00", SpeciesCode: "amegfi", ScientificName: "Spinus tristis", CommonName: "American Goldfinch", Confidence: 0.9},
+	}
+	require.NoError(t, ds.DB.Create(&notes).Error)
+
+	mappings := []TaxonomyMapping{
+		{From: "Carduelis tristis", ScientificName: "Spinus tristis", CommonName: "American Goldfinch", SpeciesCode: "amegfi"},
+		{From: "Gray Jay", ScientificName: "Perisoreus canadensis", CommonName: "Canada Jay", SpeciesCode: "gryjay"},
+	}
+
+	preview, err := ds.CountTaxonomyRemap(mappings)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), preview.Detections)
+	assert.Equal(t, int64(2), preview.Mappings[0].Detections)
+	assert.Equal(t, int64(1), preview.Mappings[1].Detections)
+
+	report, err := ds.RemapTaxonomy("run-1", "aliases.json", mappings)
+	require.NoError(t, err)
+	assert.Equal(t, "run-1", report.RunID)
+	assert.Equal(t, int64(3), report.Detections)
+
+	var remapped []Note
+	require.NoError(t, ds.DB.Order("id").Find(&remapped).Error)
+	assert.Equal(t, "Spinus tristis", remapped[0].ScientificName)
+	assert.Equal(t, "Spinus tristis", remapped[1].ScientificName)
+	assert.Equal(t, "Canada Jay", remapped[2].CommonName)
+	assert.Equal(t, "Turdus merula", remapped[3].ScientificName)
+
+	// Remapping again finds nothing left to rename
+	preview, err = ds.CountTaxonomyRemap(mappings)
+	require.NoError(t, err)
+	assert.Zero(t, preview.Detections)
+
+	runs, err := ds.GetTaxonomyRemapRuns()
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, int64(3), runs[0].Detections)
+	assert.Nil(t, runs[0].RevertedAt)
+
+	restored, err := ds.RevertTaxonomyRemap("run-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), restored)
+
+	var reverted []Note
+	require.NoError(t, ds.DB.Order("id").Find(&reverted).Error)
+	for i := range notes {
+		assert.Equal(t, notes[i].ScientificName, reverted[i].ScientificName)
+		assert.Equal(t, notes[i].CommonName, reverted[i].CommonName)
+		assert.Equal(t, notes[i].SpeciesCode, reverted[i].SpeciesCode)
+	}
+
+	_, err = ds.RevertTaxonomyRemap("run-1")
+	require.ErrorIs(t, err, ErrTaxonomyRemapReverted)
+	_, err = ds.RevertTaxonomyRemap("unknown")
+	require.ErrorIs(t, err, ErrTaxonomyRemapNotFound)
+	require.NotErrorIs(t, err, ErrTaxonomyRemapReverted)
+	require.NotErrorIs(t, notFoundError("note", "1"), ErrTaxonomyRemapNotFound)
+}
+
+func TestRemapTaxonomy_ChainedMappingsRevertToOriginal(t *testing.T) {
+	t.Parallel()
+
+	ds := setupTestDB(t)
+	require.NoError(t, ds.DB.AutoMigrate(&TaxonomyRemapRun{}, &TaxonomyRemapBackup{}))
+
+	note := Note{Date: "2015-06-01", Time: "05:00:00", SpeciesCode: "haiwoo", ScientificName: "Picoides villosus", CommonName: "Hairy Woodpecker"}
+	require.NoError(t, ds.DB.Create(&note).Error)
+
+	report, err := ds.RemapTaxonomy("run-2", "", []TaxonomyMapping{
+		{From: "Picoides villosus", ScientificName: "Leuconotopicus villosus", CommonName: "Hairy Woodpecker", SpeciesCode: "haiwoo"},
+		{From: "Leuconotopicus villosus", ScientificName: "Dryobates villosus", CommonName: "Hairy Woodpecker", SpeciesCode: "haiwoo"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), report.Detections, "each mapping reports the detections it renamed")
+
+	var got Note
+	require.NoError(t, ds.DB.First(&got, note.ID).Error)
+	assert.Equal(t, "Dryobates villosus", got.ScientificName)
+
+	_, err = ds.RevertTaxonomyRemap("run-2")
+	require.NoError(t, err)
+	require.NoError(t, ds.DB.First(&got, note.ID).Error)
+	assert.Equal(t, "Picoides villosus", got.ScientificName)
+}
+
+func TestRemapTaxonomy_Validation(t *testing.T) {
+	t.Parallel()
+
+	ds := setupTestDB(t)
+	tests := map[string][]TaxonomyMapping{
+		"no mappings":     nil,
+		"no former name":  {{ScientificName: "Spinus tristis"}},
+		"no current name": {{From: "Carduelis tristis"}},
+		"duplicate": {
+			{From: "Carduelis tristis", ScientificName: "Spinus tristis"},
+			{From: "Carduelis tristis", ScientificName: "Spinus tristis"},
+		},
+	}
+	for name, mappings := range tests {
+		_, err := ds.CountTaxonomyRemap(mappings)
+		require.Error(t, err, name)
+		var enhancedErr *errors.EnhancedError
+		require.ErrorAs(t, err, &enhancedErr, name)
+		assert.Equal(t, errors.CategoryValidation, enhancedErr.Category, name)
+	}
+
+	_, err := ds.RemapTaxonomy("", "", []TaxonomyMapping{{From: "Carduelis tristis", ScientificName: "Spinus tristis"}})
+	require.Error(t, err)
+}
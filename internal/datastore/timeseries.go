@@ -0,0 +1,141 @@
+// timeseries.go: Detection counts by minute and hour for time series charts
+package datastore
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/tphakala/birdnet-go/internal/errors"
+	"gorm.io/gorm"
+)
+
+// TimeCount is the number of detections in a minute or an hour of a day
+type TimeCount struct {
+	Date   string // YYYY-MM-DD
+	Minute int    // minute of the day the count starts at
+	Count  int
+}
+
+// GetDetectionCountsByMinute returns the detection counts by date and minute
+// between two dates (YYYY-MM-DD), both inclusive, optionally of one species by
+// common or scientific name. Minutes without detections are left out.
+func (ds *DataStore) GetDetectionCountsByMinute(ctx context.Context, startDate, endDate, species string) ([]TimeCount, error) {
+	var rows []struct {
+		Date   string
+		Minute string
+		Count  int
+	}
+	if err := speciesScope(ds.DB.WithContext(ctx).Table("notes"), species).
+		Select("date, SUBSTR(time, 1, 5) AS minute, COUNT(*) AS count").
+		Where("date BETWEEN ? AND ?", startDate, endDate).
+		Group("date, SUBSTR(time, 1, 5)").
+		Scan(&rows).Error; err != nil {
+		return nil, dbError(err, "get_detection_counts_by_minute", errors.PriorityLow,
+			"start_date", startDate,
+			"end_date", endDate,
+			"species", species,
+			"table", "notes")
+	}
+
+	counts := make([]TimeCount, 0, len(rows))
+	for i := range rows {
+		hour, minute, ok := strings.Cut(rows[i].Minute, ":")
+		h, errH := strconv.Atoi(hour)
+		m, errM := strconv.Atoi(minute)
+		if !ok || errH != nil || errM != nil || h < 0 || h > 23 || m < 0 || m > 59 {
+			continue
+		}
+		counts = append(counts, TimeCount{Date: rows[i].Date, Minute: h*60 + m, Count: rows[i].Count})
+	}
+	return counts, nil
+}
+
+// GetDetectionCountsByHour returns the detection counts by date and hour
+// between two dates (YYYY-MM-DD), both inclusive, optionally of one species by
+// common or scientific name. Detections thinned into detection aggregates are
+// included. Hours without detections are left out.
+func (ds *DataStore) GetDetectionCountsByHour(ctx context.Context, startDate, endDate, species string) ([]TimeCount, error) {
+	hourExpr := ds.GetHourFormat()
+	if hourExpr == "" {
+		return nil, errors.Newf("unsupported database type for hourly counts").
+			Component("datastore").
+			Category(errors.CategoryConfiguration).
+			Context("operation", "get_detection_counts_by_hour").
+			Build()
+	}
+
+	countError := func(err error, table string) error {
+		return dbError(err, "get_detection_counts_by_hour", errors.PriorityLow,
+			"start_date", startDate,
+			"end_date", endDate,
+			"species", species,
+			"table", table)
+	}
+
+	var rows []struct {
+		Date  string
+		Hour  string
+		Count int
+	}
+	if err := speciesScope(ds.DB.WithContext(ctx).Table("notes"), species).
+		Select(fmt.Sprintf("date, %s AS hour, COUNT(*) AS count", hourExpr)).
+		Where("date BETWEEN ? AND ?", startDate, endDate).
+		Group(fmt.Sprintf("date, %s", hourExpr)).
+		Scan(&rows).Error; err != nil {
+		return nil, countError(err, "notes")
+	}
+
+	type slot struct {
+		date string
+		hour int
+	}
+	bySlot := make(map[slot]int, len(rows))
+	var order []slot
+	add := func(date string, hour, count int) {
+		if hour < 0 || hour > 23 {
+			return
+		}
+		s := slot{date, hour}
+		if _, ok := bySlot[s]; !ok {
+			order = append(order, s)
+		}
+		bySlot[s] += count
+	}
+	for i := range rows {
+		if hour, err := strconv.Atoi(rows[i].Hour); err == nil {
+			add(rows[i].Date, hour, rows[i].Count)
+		}
+	}
+
+	var thinned []struct {
+		Date  string
+		Hour  int
+		Count int
+	}
+	if err := speciesScope(ds.DB.WithContext(ctx).Model(&DetectionAggregate{}), species).
+		Select("date, hour, SUM(count) AS count").
+		Where("date BETWEEN ? AND ?", startDate, endDate).
+		Group("date, hour").
+		Scan(&thinned).Error; err != nil {
+		return nil, countError(err, "detection_aggregates")
+	}
+	for i := range thinned {
+		add(thinned[i].Date, thinned[i].Hour, thinned[i].Count)
+	}
+
+	counts := make([]TimeCount, 0, len(order))
+	for _, s := range order {
+		counts = append(counts, TimeCount{Date: s.date, Minute: s.hour * 60, Count: bySlot[s]})
+	}
+	return counts, nil
+}
+
+// speciesScope filters a query by common or scientific name when species is set
+func speciesScope(db *gorm.DB, species string) *gorm.DB {
+	if species == "" {
+		return db
+	}
+	return db.Where("scientific_name = ? OR common_name = ?", species, species)
+}
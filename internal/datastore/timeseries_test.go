@@ -0,0 +1,64 @@
+// timeseries_test.go: Tests for detection counts by minute and hour
+package datastore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetDetectionCountsByMinute(t *testing.T) {
+	t.Parallel()
+
+	ds := setupTestDB(t)
+	notes := []Note{
+		{Date: "2024-05-01", Time: "06:10:05", ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird"},
+		{Date: "2024-05-01", Time: "06:10:40", ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird"},
+		{Date: "2024-05-01", Time: "06:11:00", ScientificName: "Pica pica", CommonName: "Eurasian Magpie"},
+		{Date: "2024-05-03", Time: "06:10:00", ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird"},
+	}
+	require.NoError(t, ds.DB.Create(&notes).Error)
+
+	counts, err := ds.GetDetectionCountsByMinute(context.Background(), "2024-05-01", "2024-05-02", "")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []TimeCount{
+		{Date: "2024-05-01", Minute: 6*60 + 10, Count: 2},
+		{Date: "2024-05-01", Minute: 6*60 + 11, Count: 1},
+	}, counts)
+
+	counts, err = ds.GetDetectionCountsByMinute(context.Background(), "2024-05-01", "2024-05-03", "Eurasian Blackbird")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []TimeCount{
+		{Date: "2024-05-01", Minute: 6*60 + 10, Count: 2},
+		{Date: "2024-05-03", Minute: 6*60 + 10, Count: 1},
+	}, counts)
+}
+
+func TestGetDetectionCountsByHour(t *testing.T) {
+	t.Parallel()
+
+	ds := setupTestDB(t)
+	require.NoError(t, ds.DB.AutoMigrate(&DetectionAggregate{}))
+	notes := []Note{
+		{Date: "2024-05-01", Time: "06:10:05", ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird"},
+		{Date: "2024-05-01", Time: "06:50:40", ScientificName: "Pica pica", CommonName: "Eurasian Magpie"},
+		{Date: "2024-05-01", Time: "07:00:00", ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird"},
+	}
+	require.NoError(t, ds.DB.Create(&notes).Error)
+	require.NoError(t, ds.DB.Create(&DetectionAggregate{
+		Date: "2024-05-01", Hour: 6, ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", Count: 4,
+	}).Error)
+
+	counts, err := ds.GetDetectionCountsByHour(context.Background(), "2024-05-01", "2024-05-01", "")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []TimeCount{
+		{Date: "2024-05-01", Minute: 6 * 60, Count: 6},
+		{Date: "2024-05-01", Minute: 7 * 60, Count: 1},
+	}, counts, "thinned detections should be included")
+
+	counts, err = ds.GetDetectionCountsByHour(context.Background(), "2024-05-01", "2024-05-01", "Pica pica")
+	require.NoError(t, err)
+	assert.Equal(t, []TimeCount{{Date: "2024-05-01", Minute: 6 * 60, Count: 1}}, counts)
+}
@@ -0,0 +1,194 @@
+// trash.go: Soft deletion of detections into a restorable trash
+package datastore
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/errors"
+	"gorm.io/gorm"
+)
+
+// Trash errors
+var (
+	ErrTrashedNoteNotFound = errors.NewStd("detection not found in trash")
+	ErrNoteLocked          = errors.NewStd("detection is locked")
+)
+
+// TrashNote moves a detection with its results, review, comments and tags to
+// the trash. Locked detections cannot be trashed. Clip files are kept so the
+// detection can be restored with its audio.
+func (ds *DataStore) TrashNote(id string) error {
+	noteID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		return validationError("invalid note ID format for deletion", "id", id)
+	}
+
+	var date string
+	err = ds.DB.Transaction(func(tx *gorm.DB) error {
+		var locks int64
+		if err := tx.Model(&NoteLock{}).Where("note_id = ?", noteID).Count(&locks).Error; err != nil {
+			return dbError(err, "check_note_lock", errors.PriorityMedium,
+				"note_id", id,
+				"action", "validate_trash_permissions")
+		}
+		if locks > 0 {
+			return ErrNoteLocked
+		}
+
+		var note Note
+		if err := tx.Preload("Results").Preload("Review").Preload("Comments").Preload("Tags").First(&note, noteID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return notFoundError("note", id)
+			}
+			return dbError(err, "get_note", errors.PriorityMedium,
+				"note_id", id,
+				"table", "notes")
+		}
+		data, err := json.Marshal(&note)
+		if err != nil {
+			return errors.New(err).
+				Component("datastore").
+				Category(errors.CategorySystem).
+				Context("operation", "trash_note").
+				Context("note_id", id).
+				Build()
+		}
+
+		trashed := TrashedNote{
+			NoteID:         note.ID,
+			DeletedAt:      time.Now(),
+			Date:           note.Date,
+			Time:           note.Time,
+			ScientificName: note.ScientificName,
+			CommonName:     note.CommonName,
+			Confidence:     note.Confidence,
+			ClipName:       note.ClipName,
+			Data:           string(data),
+		}
+		if err := tx.Create(&trashed).Error; err != nil {
+			return dbError(err, "trash_note", errors.PriorityMedium,
+				"note_id", id,
+				"table", "trashed_notes")
+		}
+		for _, model := range []interface{}{&Results{}, &NoteReview{}, &NoteComment{}, &NoteTag{}} {
+			if err := tx.Where("note_id = ?", noteID).Delete(model).Error; err != nil {
+				return dbError(err, "trash_note_relations", errors.PriorityMedium,
+					"note_id", id)
+			}
+		}
+		if err := tx.Delete(&Note{}, noteID).Error; err != nil {
+			return dbError(err, "trash_note", errors.PriorityMedium,
+				"note_id", id,
+				"table", "notes")
+		}
+		date = note.Date
+		return nil
+	})
+	if err == nil {
+		ds.markDailyAggregatesDirty(date)
+	}
+	return err
+}
+
+// GetTrashedNotes returns the detections in the trash, most recently deleted
+// first, and the total number of detections in the trash. The JSON of the
+// detections is not loaded.
+func (ds *DataStore) GetTrashedNotes(limit, offset int) ([]TrashedNote, int64, error) {
+	var total int64
+	if err := ds.DB.Model(&TrashedNote{}).Count(&total).Error; err != nil {
+		return nil, 0, dbError(err, "count_trashed_notes", errors.PriorityLow,
+			"table", "trashed_notes")
+	}
+
+	var trashed []TrashedNote
+	if err := ds.DB.Omit("data").Order("deleted_at DESC, id DESC").Limit(limit).Offset(offset).Find(&trashed).Error; err != nil {
+		return nil, 0, dbError(err, "get_trashed_notes", errors.PriorityLow,
+			"table", "trashed_notes")
+	}
+	return trashed, total, nil
+}
+
+// RestoreNote moves a detection from the trash back to the detections with
+// its original ID, results, review and comments
+func (ds *DataStore) RestoreNote(noteID uint) (*Note, error) {
+	var note Note
+	err := ds.DB.Transaction(func(tx *gorm.DB) error {
+		var trashed TrashedNote
+		if err := tx.Where("note_id = ?", noteID).First(&trashed).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrTrashedNoteNotFound
+			}
+			return dbError(err, "get_trashed_note", errors.PriorityMedium,
+				"note_id", noteID,
+				"table", "trashed_notes")
+		}
+		if err := json.Unmarshal([]byte(trashed.Data), &note); err != nil {
+			return errors.New(err).
+				Component("datastore").
+				Category(errors.CategorySystem).
+				Context("operation", "restore_note").
+				Context("note_id", noteID).
+				Build()
+		}
+
+		var existing int64
+		if err := tx.Model(&Note{}).Where("id = ?", noteID).Count(&existing).Error; err != nil {
+			return dbError(err, "check_note_exists", errors.PriorityMedium,
+				"note_id", noteID,
+				"table", "notes")
+		}
+		if existing > 0 {
+			return conflictError(fmt.Errorf("a detection with ID %d already exists", noteID),
+				"restore_note", "note_exists",
+				"note_id", noteID)
+		}
+
+		if err := tx.Create(&note).Error; err != nil {
+			return dbError(err, "restore_note", errors.PriorityMedium,
+				"note_id", noteID,
+				"table", "notes")
+		}
+		if err := tx.Delete(&trashed).Error; err != nil {
+			return dbError(err, "remove_trashed_note", errors.PriorityMedium,
+				"note_id", noteID,
+				"table", "trashed_notes")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	ds.markDailyAggregatesDirty(note.Date)
+	return &note, nil
+}
+
+// PurgeTrash permanently removes the detections deleted before a time from
+// the trash. Clip files are not touched by the datastore; the caller removes
+// the returned clips.
+func (ds *DataStore) PurgeTrash(before time.Time) (*PurgeResult, error) {
+	result := &PurgeResult{}
+	err := ds.DB.Transaction(func(tx *gorm.DB) error {
+		expired := func() *gorm.DB {
+			return tx.Model(&TrashedNote{}).Where("deleted_at < ?", before)
+		}
+		if err := expired().Where("clip_name <> ''").Pluck("clip_name", &result.ClipNames).Error; err != nil {
+			return dbError(err, "get_trashed_clips", errors.PriorityLow,
+				"table", "trashed_notes")
+		}
+		res := expired().Delete(&TrashedNote{})
+		if res.Error != nil {
+			return dbError(res.Error, "purge_trash", errors.PriorityLow,
+				"table", "trashed_notes")
+		}
+		result.Counts.Detections = res.RowsAffected
+		result.Counts.Clips = int64(len(result.ClipNames))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
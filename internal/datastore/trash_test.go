@@ -0,0 +1,96 @@
+// trash_test.go: Tests for the detection trash
+package datastore
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrashAndRestoreNote(t *testing.T) {
+	t.Parallel()
+
+	ds := setupTestDB(t)
+	require.NoError(t, ds.DB.AutoMigrate(&Results{}, &NoteReview{}, &NoteComment{}, &NoteLock{}, &TrashedNote{}))
+
+	note := Note{
+		Date:           "2024-05-01",
+		Time:           "06:00:00",
+		ScientificName: "Turdus merula",
+		CommonName:     "Eurasian Blackbird",
+		Confidence:     0.9,
+		ClipName:       "2024/05/blackbird.wav",
+		Results:        []Results{{Species: "Turdus merula", Confidence: 0.9}},
+		Review:         &NoteReview{Verified: "correct"},
+		Comments:       []NoteComment{{Entry: "singing on the roof"}},
+		Tags:           []Tag{{Name: "roof"}},
+	}
+	require.NoError(t, ds.DB.Create(&note).Error)
+	locked := Note{Date: "2024-05-01", Time: "07:00:00", ScientificName: "Pica pica"}
+	require.NoError(t, ds.DB.Create(&locked).Error)
+	require.NoError(t, ds.DB.Create(&NoteLock{NoteID: locked.ID, LockedAt: time.Now()}).Error)
+
+	id := strconv.FormatUint(uint64(note.ID), 10)
+	require.NoError(t, ds.TrashNote(id))
+	require.ErrorIs(t, ds.TrashNote(strconv.FormatUint(uint64(locked.ID), 10)), ErrNoteLocked)
+
+	var count int64
+	require.NoError(t, ds.DB.Model(&Note{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count, "the trashed detection is removed from the detections")
+	require.NoError(t, ds.DB.Model(&NoteComment{}).Count(&count).Error)
+	assert.Zero(t, count)
+	require.NoError(t, ds.DB.Model(&NoteTag{}).Count(&count).Error)
+	assert.Zero(t, count)
+
+	trashed, total, err := ds.GetTrashedNotes(10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	require.Len(t, trashed, 1)
+	assert.Equal(t, note.ID, trashed[0].NoteID)
+	assert.Equal(t, "Eurasian Blackbird", trashed[0].CommonName)
+	assert.Empty(t, trashed[0].Data, "the detection JSON is not listed")
+
+	restored, err := ds.RestoreNote(note.ID)
+	require.NoError(t, err)
+	assert.Equal(t, note.ID, restored.ID)
+
+	var reloaded Note
+	require.NoError(t, ds.DB.Preload("Results").Preload("Review").Preload("Comments").Preload("Tags").First(&reloaded, note.ID).Error)
+	assert.Equal(t, "2024/05/blackbird.wav", reloaded.ClipName)
+	require.Len(t, reloaded.Results, 1)
+	require.NotNil(t, reloaded.Review)
+	assert.Equal(t, "correct", reloaded.Review.Verified)
+	require.Len(t, reloaded.Comments, 1)
+	assert.Equal(t, "singing on the roof", reloaded.Comments[0].Entry)
+	require.Len(t, reloaded.Tags, 1)
+	assert.Equal(t, "roof", reloaded.Tags[0].Name)
+
+	_, err = ds.RestoreNote(note.ID)
+	require.ErrorIs(t, err, ErrTrashedNoteNotFound)
+	require.NotErrorIs(t, notFoundError("note", "1"), ErrTrashedNoteNotFound, "other missing records are told apart")
+}
+
+func TestPurgeTrash(t *testing.T) {
+	t.Parallel()
+
+	ds := setupTestDB(t)
+	require.NoError(t, ds.DB.AutoMigrate(&TrashedNote{}))
+
+	now := time.Now()
+	require.NoError(t, ds.DB.Create(&TrashedNote{NoteID: 1, DeletedAt: now.AddDate(0, 0, -40), ClipName: "old.wav"}).Error)
+	require.NoError(t, ds.DB.Create(&TrashedNote{NoteID: 2, DeletedAt: now.AddDate(0, 0, -35)}).Error)
+	require.NoError(t, ds.DB.Create(&TrashedNote{NoteID: 3, DeletedAt: now.AddDate(0, 0, -1), ClipName: "recent.wav"}).Error)
+
+	result, err := ds.PurgeTrash(now.AddDate(0, 0, -30))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), result.Counts.Detections)
+	assert.Equal(t, []string{"old.wav"}, result.ClipNames)
+
+	trashed, total, err := ds.GetTrashedNotes(10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, uint(3), trashed[0].NoteID)
+}
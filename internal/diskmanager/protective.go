@@ -0,0 +1,264 @@
+// protective.go - disk-full protective mode
+
+package diskmanager
+
+import (
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// protectivePolicy is the policy name used for logging and metrics by emergency cleanup
+const protectivePolicy = "emergency"
+
+// ProtectiveState is a snapshot of the disk-full protective mode state
+type ProtectiveState struct {
+	Active          bool       `json:"active"`
+	Path            string     `json:"path"`
+	FreeBytes       uint64     `json:"free_bytes"`
+	MinFreeBytes    uint64     `json:"min_free_bytes"`
+	ResumeFreeBytes uint64     `json:"resume_free_bytes"`
+	ActiveSince     *time.Time `json:"active_since,omitempty"`
+	LastCheck       time.Time  `json:"last_check"`
+	ClipsSkipped    int64      `json:"clips_skipped"`
+	LastError       string     `json:"last_error,omitempty"`
+}
+
+// ProtectiveGuard tracks free space on the clip export filesystem and switches
+// into protective mode when it drops below the configured floor. Protective mode
+// is left only once free space recovers above the resume threshold, so the state
+// does not flap around the floor.
+type ProtectiveGuard struct {
+	mu          sync.RWMutex
+	path        string
+	minFree     uint64
+	resumeFree  uint64
+	active      bool
+	activeSince time.Time
+	lastFree    uint64
+	lastCheck   time.Time
+	lastErr     error
+
+	clipsSkipped atomic.Int64
+
+	// freeSpace returns available bytes for a path, replaceable in tests
+	freeSpace func(path string) (uint64, error)
+}
+
+// NewProtectiveGuard creates a guard for path. resumeFree is raised to minFree if lower.
+func NewProtectiveGuard(path string, minFree, resumeFree uint64) *ProtectiveGuard {
+	return &ProtectiveGuard{
+		path:       path,
+		minFree:    minFree,
+		resumeFree: max(resumeFree, minFree),
+		freeSpace:  GetAvailableSpace,
+	}
+}
+
+// NewProtectiveGuardFromSettings creates a guard from disk protection settings.
+// It returns nil if protective mode is disabled.
+func NewProtectiveGuardFromSettings(settings *conf.Settings) (*ProtectiveGuard, error) {
+	export := settings.Realtime.Audio.Export
+	if !export.DiskProtection.Enabled {
+		return nil, nil
+	}
+
+	minFree, err := conf.ParseByteSize(export.DiskProtection.MinFreeSpace)
+	if err != nil {
+		return nil, errors.New(err).
+			Component("diskmanager").
+			Category(errors.CategoryConfiguration).
+			Context("setting", "minFreeSpace").
+			Build()
+	}
+
+	resumeFree := minFree
+	if export.DiskProtection.ResumeFreeSpace != "" {
+		if resumeFree, err = conf.ParseByteSize(export.DiskProtection.ResumeFreeSpace); err != nil {
+			return nil, errors.New(err).
+				Component("diskmanager").
+				Category(errors.CategoryConfiguration).
+				Context("setting", "resumeFreeSpace").
+				Build()
+		}
+	}
+
+	return NewProtectiveGuard(export.Path, minFree, resumeFree), nil
+}
+
+// Check samples free space and updates the protective state.
+// It returns whether the state changed and whether protective mode is now active.
+func (g *ProtectiveGuard) Check() (changed, active bool, err error) {
+	free, err := g.freeSpace(g.path)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.lastCheck = time.Now()
+	g.lastErr = err
+	if err != nil {
+		// Keep the previous state when free space cannot be determined
+		return false, g.active, err
+	}
+	g.lastFree = free
+
+	switch {
+	case !g.active && free < g.minFree:
+		g.active = true
+		g.activeSince = g.lastCheck
+		changed = true
+	case g.active && free >= g.resumeFree:
+		g.active = false
+		g.activeSince = time.Time{}
+		changed = true
+	}
+
+	if changed {
+		serviceLogger.Warn("Disk protective mode state changed",
+			"active", g.active,
+			"path", g.path,
+			"free_bytes", free,
+			"min_free_bytes", g.minFree,
+			"resume_free_bytes", g.resumeFree)
+	}
+
+	return changed, g.active, nil
+}
+
+// IsActive reports whether protective mode is currently active
+func (g *ProtectiveGuard) IsActive() bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.active
+}
+
+// RecordSkippedClip counts a clip that was not written due to protective mode
+func (g *ProtectiveGuard) RecordSkippedClip() {
+	g.clipsSkipped.Add(1)
+}
+
+// ResumeFreeBytes returns the free space required to leave protective mode
+func (g *ProtectiveGuard) ResumeFreeBytes() uint64 {
+	return g.resumeFree
+}
+
+// State returns a snapshot of the guard state
+func (g *ProtectiveGuard) State() ProtectiveState {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	state := ProtectiveState{
+		Active:          g.active,
+		Path:            g.path,
+		FreeBytes:       g.lastFree,
+		MinFreeBytes:    g.minFree,
+		ResumeFreeBytes: g.resumeFree,
+		LastCheck:       g.lastCheck,
+		ClipsSkipped:    g.clipsSkipped.Load(),
+	}
+	if g.active {
+		since := g.activeSince
+		state.ActiveSince = &since
+	}
+	if g.lastErr != nil {
+		state.LastError = g.lastErr.Error()
+	}
+	return state
+}
+
+// activeGuard is the process-wide guard consulted before writing clips
+var activeGuard atomic.Pointer[ProtectiveGuard]
+
+// SetProtectiveGuard installs the process-wide protective guard. Pass nil to remove it.
+func SetProtectiveGuard(g *ProtectiveGuard) {
+	activeGuard.Store(g)
+}
+
+// GetProtectiveGuard returns the process-wide protective guard, or nil if none is installed
+func GetProtectiveGuard() *ProtectiveGuard {
+	return activeGuard.Load()
+}
+
+// ClipWritesAllowed reports whether audio clips may be written. It returns true
+// when no guard is installed or protective mode is inactive.
+func ClipWritesAllowed() bool {
+	g := activeGuard.Load()
+	return g == nil || !g.IsActive()
+}
+
+// EmergencyCleanup deletes the oldest unlocked clips until free space reaches
+// targetFree bytes or no more candidates remain. Unlike the regular retention
+// policies it ignores per-species minimum clip counts, since running out of
+// space would otherwise stop detections being written altogether.
+func EmergencyCleanup(quit <-chan struct{}, db Interface, baseDir string, targetFree uint64) CleanupResult {
+	settings := conf.Setting()
+	debug := settings.Realtime.Audio.Export.Retention.Debug
+	keepSpectrograms := settings.Realtime.Audio.Export.Retention.KeepSpectrograms
+
+	serviceLogger.Warn("Emergency cleanup started",
+		"policy", protectivePolicy,
+		"base_dir", baseDir,
+		"target_free_bytes", targetFree)
+
+	files, err := GetAudioFiles(baseDir, allowedFileTypes, db, debug)
+	if err != nil {
+		return CleanupResult{Err: errors.New(err).
+			Component("diskmanager").
+			Category(errors.CategoryDiskCleanup).
+			Context("operation", "emergency_cleanup").
+			Build()}
+	}
+
+	// Oldest clips first
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Timestamp.Before(files[j].Timestamp)
+	})
+
+	removed := 0
+	for i := range files {
+		select {
+		case <-quit:
+			return emergencyResult(baseDir, removed, nil)
+		default:
+		}
+
+		free, err := GetAvailableSpace(baseDir)
+		if err == nil && free >= targetFree {
+			break
+		}
+
+		if files[i].Locked {
+			continue
+		}
+
+		if err := deleteFileAndOptionalSpectrogram(&files[i], "emergency cleanup", keepSpectrograms, debug, protectivePolicy); err != nil {
+			serviceLogger.Warn("Emergency cleanup failed to delete file",
+				"path", filepath.Base(files[i].Path),
+				"error", err)
+			continue
+		}
+		removed++
+	}
+
+	return emergencyResult(baseDir, removed, nil)
+}
+
+// emergencyResult builds a cleanup result with the current disk utilization
+func emergencyResult(baseDir string, removed int, err error) CleanupResult {
+	utilization := 0
+	if usage, usageErr := GetDiskUsage(baseDir); usageErr == nil {
+		utilization = int(usage)
+	}
+
+	serviceLogger.Info("Emergency cleanup finished",
+		"policy", protectivePolicy,
+		"clips_removed", removed,
+		"disk_utilization", utilization)
+
+	return CleanupResult{Err: err, ClipsRemoved: removed, DiskUtilization: utilization}
+}
@@ -0,0 +1,99 @@
+package diskmanager
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestProtectiveGuardHysteresis verifies protective mode is entered below the floor
+// and only left once free space reaches the resume threshold
+func TestProtectiveGuardHysteresis(t *testing.T) {
+	t.Parallel()
+
+	var free uint64 = 5000
+	var freeErr error
+	guard := NewProtectiveGuard("/clips", 1000, 2000)
+	guard.freeSpace = func(string) (uint64, error) { return free, freeErr }
+
+	steps := []struct {
+		name        string
+		free        uint64
+		wantChanged bool
+		wantActive  bool
+	}{
+		{"plenty of space", 5000, false, false},
+		{"drops below floor", 999, true, true},
+		{"recovers above floor but below resume", 1500, false, true},
+		{"reaches resume threshold", 2000, true, false},
+		{"between floor and resume while inactive", 1500, false, false},
+	}
+
+	for _, step := range steps {
+		free = step.free
+		changed, active, err := guard.Check()
+		require.NoError(t, err, step.name)
+		assert.Equal(t, step.wantChanged, changed, step.name)
+		assert.Equal(t, step.wantActive, active, step.name)
+		assert.Equal(t, step.wantActive, guard.IsActive(), step.name)
+	}
+
+	// Errors keep the previous state
+	free = 10
+	freeErr = errors.New("statfs failed")
+	changed, active, err := guard.Check()
+	require.Error(t, err)
+	assert.False(t, changed)
+	assert.False(t, active)
+	assert.Equal(t, "statfs failed", guard.State().LastError)
+}
+
+// TestProtectiveGuardState verifies the state snapshot reported in health checks
+func TestProtectiveGuardState(t *testing.T) {
+	t.Parallel()
+
+	guard := NewProtectiveGuard("/clips", 1000, 500)
+	guard.freeSpace = func(string) (uint64, error) { return 100, nil }
+
+	// Resume threshold is never lower than the floor
+	assert.Equal(t, uint64(1000), guard.ResumeFreeBytes())
+
+	_, _, err := guard.Check()
+	require.NoError(t, err)
+	guard.RecordSkippedClip()
+	guard.RecordSkippedClip()
+
+	state := guard.State()
+	assert.True(t, state.Active)
+	assert.Equal(t, "/clips", state.Path)
+	assert.Equal(t, uint64(100), state.FreeBytes)
+	assert.Equal(t, int64(2), state.ClipsSkipped)
+	require.NotNil(t, state.ActiveSince)
+	assert.False(t, state.LastCheck.IsZero())
+}
+
+// TestClipWritesAllowed verifies the process-wide guard gates clip writes.
+// Not parallel: the active guard is process-wide state.
+func TestClipWritesAllowed(t *testing.T) {
+	t.Cleanup(func() { SetProtectiveGuard(nil) })
+
+	SetProtectiveGuard(nil)
+	assert.True(t, ClipWritesAllowed(), "no guard installed")
+
+	var free uint64 = 100
+	guard := NewProtectiveGuard("/clips", 1000, 2000)
+	guard.freeSpace = func(string) (uint64, error) { return free, nil }
+	SetProtectiveGuard(guard)
+	assert.True(t, ClipWritesAllowed(), "guard not checked yet")
+
+	_, _, err := guard.Check()
+	require.NoError(t, err)
+	assert.False(t, ClipWritesAllowed())
+
+	free = 3000
+	_, _, err = guard.Check()
+	require.NoError(t, err)
+	assert.True(t, ClipWritesAllowed())
+}
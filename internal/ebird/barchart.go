@@ -0,0 +1,132 @@
+package ebird
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// BarChartPeriods is the number of periods of an eBird bar chart, four per month
+const BarChartPeriods = 48
+
+// htmlTag matches the markup around scientific names in bar chart downloads
+var htmlTag = regexp.MustCompile(`<[^>]*>`)
+
+// BarChart holds an eBird bar chart download ("Download Histogram Data" on
+// the bar chart page of a region): the fraction of the checklists of the
+// region reporting each species in each quarter of a month
+type BarChart struct {
+	frequencies map[string][BarChartPeriods]float64 // by lowercase scientific name
+}
+
+// LoadBarChart reads a bar chart download from a file
+func LoadBarChart(path string) (*BarChart, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.New(err).
+			Category(errors.CategoryFileIO).
+			Context("operation", "load_barchart").
+			Context("path", path).
+			Component("ebird").
+			Build()
+	}
+	defer func() { _ = file.Close() }()
+
+	chart, err := ParseBarChart(file)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("eBird bar chart loaded",
+		"path", path,
+		"species", chart.Species())
+	return chart, nil
+}
+
+// ParseBarChart parses a bar chart download, a tab separated file with a row
+// of 48 frequencies per taxon named like "Common Name (<em>Scientific name</em>)".
+// Rows without a scientific name or 48 frequencies, such as the sample
+// sizes, are skipped.
+func ParseBarChart(r io.Reader) (*BarChart, error) {
+	chart := &BarChart{frequencies: make(map[string][BarChartPeriods]float64)}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+		name := barChartScientificName(fields[0])
+		if name == "" {
+			continue
+		}
+
+		var row [BarChartPeriods]float64
+		period := 0
+		valid := true
+		for _, field := range fields[1:] {
+			field = strings.TrimSpace(field)
+			if field == "" {
+				continue
+			}
+			value, err := strconv.ParseFloat(field, 64)
+			if err != nil || period >= BarChartPeriods {
+				valid = false
+				break
+			}
+			row[period] = value
+			period++
+		}
+		if valid && period == BarChartPeriods {
+			chart.frequencies[strings.ToLower(name)] = row
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.New(err).
+			Category(errors.CategoryFileParsing).
+			Context("operation", "parse_barchart").
+			Component("ebird").
+			Build()
+	}
+	if len(chart.frequencies) == 0 {
+		return nil, errors.Newf("no species frequencies found in eBird bar chart").
+			Category(errors.CategoryFileParsing).
+			Context("operation", "parse_barchart").
+			Component("ebird").
+			Build()
+	}
+	return chart, nil
+}
+
+// barChartScientificName returns the scientific name in parentheses of the
+// name of a bar chart row, empty when there is none
+func barChartScientificName(name string) string {
+	name = htmlTag.ReplaceAllString(name, "")
+	open := strings.LastIndex(name, "(")
+	closing := strings.LastIndex(name, ")")
+	if open < 0 || closing < open {
+		return ""
+	}
+	return strings.TrimSpace(name[open+1 : closing])
+}
+
+// Species returns the number of taxa in the bar chart
+func (b *BarChart) Species() int {
+	return len(b.frequencies)
+}
+
+// Frequency returns the fraction of the checklists reporting the species in
+// the quarter month of at, 0 for species not in the bar chart
+func (b *BarChart) Frequency(_ context.Context, scientificName string, at time.Time) (float64, error) {
+	return b.frequencies[strings.ToLower(scientificName)][barChartPeriod(at)], nil
+}
+
+// barChartPeriod returns the bar chart period of a time: the days 1-7, 8-14,
+// 15-21 and from 22 to the end of each month
+func barChartPeriod(t time.Time) int {
+	return (int(t.Month())-1)*4 + min((t.Day()-1)/7, 3)
+}
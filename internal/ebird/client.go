@@ -345,6 +345,10 @@ func (c *Client) findSubspecies(taxonomy []TaxonomyEntry, speciesCode string) []
 
 // doRequest performs an HTTP request with rate limiting and auth
 func (c *Client) doRequest(ctx context.Context, method, url string, body io.Reader, result interface{}) error {
+	if conf.IsOffline() {
+		return conf.ErrOfflineMode
+	}
+
 	// Rate limiting
 	c.mu.Lock()
 	<-c.rateLimiter.C
@@ -578,6 +582,10 @@ func (c *Client) doRequest(ctx context.Context, method, url string, body io.Read
 
 // doRequestWithRetry wraps doRequest with retry logic for transient failures
 func (c *Client) doRequestWithRetry(ctx context.Context, method, url string, body io.Reader, result interface{}) error {
+	if conf.IsOffline() {
+		return conf.ErrOfflineMode
+	}
+
 	const maxRetries = 3
 	var lastErr error
 	
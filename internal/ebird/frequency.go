@@ -0,0 +1,225 @@
+package ebird
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+const (
+	// frequencyYears is the number of previous years the weekly frequencies
+	// are computed from
+	frequencyYears = 2
+
+	// regionSearchDistance is the radius in kilometers searched for the
+	// hotspot the region of a location is taken from, the API maximum
+	regionSearchDistance = 50
+)
+
+// FrequencySource returns the expected frequency of a species around the
+// station at a time of year, from 0 for species never reported to 1
+type FrequencySource interface {
+	Frequency(ctx context.Context, scientificName string, at time.Time) (float64, error)
+}
+
+// GetNearbyHotspots retrieves the hotspots within distKm kilometers of a location
+func (c *Client) GetNearbyHotspots(ctx context.Context, lat, lng float64, distKm int) ([]Hotspot, error) {
+	cacheKey := fmt.Sprintf("hotspots:%.4f:%.4f:%d", lat, lng, distKm)
+	if cached, found := c.cache.Get(cacheKey); found {
+		if hotspots, ok := cached.([]Hotspot); ok {
+			c.recordCacheHit()
+			return hotspots, nil
+		}
+	}
+	c.recordCacheMiss()
+
+	reqCtx, cancel := context.WithTimeout(ctx, c.config.Timeout)
+	defer cancel()
+
+	url := fmt.Sprintf("%s/ref/hotspot/geo?lat=%.4f&lng=%.4f&dist=%d&fmt=json", c.config.BaseURL, lat, lng, distKm)
+	var hotspots []Hotspot
+	if err := c.doRequestWithRetry(reqCtx, "GET", url, nil, &hotspots); err != nil {
+		return nil, err
+	}
+
+	c.cache.Set(cacheKey, hotspots, cache.DefaultExpiration)
+	return hotspots, nil
+}
+
+// GetHistoricObservations retrieves the species reported in a region on a
+// date, one observation per species
+func (c *Client) GetHistoricObservations(ctx context.Context, regionCode string, date time.Time) ([]Observation, error) {
+	cacheKey := fmt.Sprintf("historic:%s:%s", regionCode, date.Format(time.DateOnly))
+	if cached, found := c.cache.Get(cacheKey); found {
+		if observations, ok := cached.([]Observation); ok {
+			c.recordCacheHit()
+			return observations, nil
+		}
+	}
+	c.recordCacheMiss()
+
+	reqCtx, cancel := context.WithTimeout(ctx, c.config.Timeout)
+	defer cancel()
+
+	url := fmt.Sprintf("%s/data/obs/%s/historic/%d/%d/%d?cat=species&detail=simple&fmt=json",
+		c.config.BaseURL, regionCode, date.Year(), int(date.Month()), date.Day())
+	var observations []Observation
+	if err := c.doRequestWithRetry(reqCtx, "GET", url, nil, &observations); err != nil {
+		return nil, err
+	}
+
+	c.cache.Set(cacheKey, observations, cache.DefaultExpiration)
+	return observations, nil
+}
+
+// RegionAt returns the eBird region of a location, the county or else the
+// state or country of the nearest hotspot
+func (c *Client) RegionAt(ctx context.Context, lat, lng float64) (string, error) {
+	hotspots, err := c.GetNearbyHotspots(ctx, lat, lng, regionSearchDistance)
+	if err != nil {
+		return "", err
+	}
+
+	var nearest *Hotspot
+	nearestDistance := math.Inf(1)
+	for i := range hotspots {
+		// Scale longitude differences by latitude, enough to compare distances
+		dLat := hotspots[i].Latitude - lat
+		dLng := (hotspots[i].Longitude - lng) * math.Cos(lat*math.Pi/180)
+		if distance := dLat*dLat + dLng*dLng; distance < nearestDistance && hotspots[i].RegionCode() != "" {
+			nearest, nearestDistance = &hotspots[i], distance
+		}
+	}
+	if nearest == nil {
+		return "", errors.Newf("no eBird hotspots within %d km to find the region from", regionSearchDistance).
+			Category(errors.CategoryNotFound).
+			Context("latitude", lat).
+			Context("longitude", lng).
+			Component("ebird").
+			Build()
+	}
+	return nearest.RegionCode(), nil
+}
+
+// WeeklyFrequencies returns the frequency of the species reported in a region
+// in the week of the year at, the fraction of its days in the previous years
+// each species was reported on, by lowercase scientific name
+func (c *Client) WeeklyFrequencies(ctx context.Context, regionCode string, at time.Time) (map[string]float64, error) {
+	week := weekOfYear(at)
+	cacheKey := fmt.Sprintf("frequency:%s:%d:%d", regionCode, at.Year(), week)
+	if cached, found := c.cache.Get(cacheKey); found {
+		if frequencies, ok := cached.(map[string]float64); ok {
+			c.recordCacheHit()
+			return frequencies, nil
+		}
+	}
+	c.recordCacheMiss()
+
+	days := make(map[string]int)
+	var total int
+	for year := at.Year() - frequencyYears; year < at.Year(); year++ {
+		start := time.Date(year, time.January, 1+7*week, 0, 0, 0, 0, time.UTC)
+		for day := range 7 {
+			observations, err := c.GetHistoricObservations(ctx, regionCode, start.AddDate(0, 0, day))
+			if err != nil {
+				return nil, err
+			}
+			total++
+			for i := range observations {
+				days[strings.ToLower(observations[i].ScientificName)]++
+			}
+		}
+	}
+
+	frequencies := make(map[string]float64, len(days))
+	for name, count := range days {
+		frequencies[name] = float64(count) / float64(total)
+	}
+	c.cache.Set(cacheKey, frequencies, cache.DefaultExpiration)
+
+	logger.Debug("eBird weekly frequencies computed",
+		"region", regionCode,
+		"week", week,
+		"species", len(frequencies))
+	return frequencies, nil
+}
+
+// weekOfYear returns the week of the year of a time from 0 to 51, the last
+// one or two days of the year belonging to the last week
+func weekOfYear(t time.Time) int {
+	return min((t.YearDay()-1)/7, 51)
+}
+
+// recordCacheHit counts a request answered from the cache
+func (c *Client) recordCacheHit() {
+	c.metrics.mu.Lock()
+	c.metrics.cacheHits++
+	c.metrics.mu.Unlock()
+}
+
+// recordCacheMiss counts a request not answered from the cache
+func (c *Client) recordCacheMiss() {
+	c.metrics.mu.Lock()
+	c.metrics.cacheMisses++
+	c.metrics.mu.Unlock()
+}
+
+// LocalFrequencies is the frequency source of a location, computed from the
+// eBird observations of its region in the same week of the previous years
+type LocalFrequencies struct {
+	client    *Client
+	latitude  float64
+	longitude float64
+
+	mu     sync.Mutex
+	region string // found from the location when not configured
+}
+
+// NewLocalFrequencies returns the frequency source of a location. An empty
+// region code is found from the nearest hotspot on first use.
+func NewLocalFrequencies(client *Client, latitude, longitude float64, regionCode string) *LocalFrequencies {
+	return &LocalFrequencies{
+		client:    client,
+		latitude:  latitude,
+		longitude: longitude,
+		region:    strings.TrimSpace(regionCode),
+	}
+}
+
+// Frequency returns the fraction of days in the week of at the species was
+// reported in the region in the previous years
+func (f *LocalFrequencies) Frequency(ctx context.Context, scientificName string, at time.Time) (float64, error) {
+	region, err := f.regionCode(ctx)
+	if err != nil {
+		return 0, err
+	}
+	frequencies, err := f.client.WeeklyFrequencies(ctx, region, at)
+	if err != nil {
+		return 0, err
+	}
+	return frequencies[strings.ToLower(scientificName)], nil
+}
+
+// regionCode returns the region of the location, finding it once
+func (f *LocalFrequencies) regionCode(ctx context.Context) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.region != "" {
+		return f.region, nil
+	}
+	region, err := f.client.RegionAt(ctx, f.latitude, f.longitude)
+	if err != nil {
+		return "", err
+	}
+	logger.Info("eBird region of the station found",
+		"region", region)
+	f.region = region
+	return region, nil
+}
@@ -0,0 +1,111 @@
+package ebird
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalFrequencies(t *testing.T) {
+	disableLogging(t)
+
+	responses := map[string]mockResponse{
+		"/v2/ref/hotspot/geo?lat=60.1700&lng=24.9400&dist=50&fmt=json": {status: 200, body: `[
+			{"locId": "L2", "locName": "Far", "countryCode": "FI", "subnational1Code": "FI-18", "subnational2Code": "", "lat": 60.6, "lng": 25.5},
+			{"locId": "L1", "locName": "Near", "countryCode": "FI", "subnational1Code": "FI-18", "subnational2Code": "FI-18-091", "lat": 60.18, "lng": 24.95}
+		]`},
+	}
+	// Great Tits are reported every day, Hawfinches on two of the 14 days
+	at := time.Date(2026, time.January, 10, 8, 0, 0, 0, time.UTC)
+	for year := 2024; year <= 2025; year++ {
+		for day := 8; day <= 14; day++ {
+			body := `[{"speciesCode": "gretit1", "comName": "Great Tit", "sciName": "Parus major"}`
+			if day == 10 {
+				body += `, {"speciesCode": "hawfin", "comName": "Hawfinch", "sciName": "Coccothraustes coccothraustes"}`
+			}
+			key := fmt.Sprintf("/v2/data/obs/FI-18-091/historic/%d/1/%d?cat=species&detail=simple&fmt=json", year, day)
+			responses[key] = mockResponse{status: 200, body: body + "]"}
+		}
+	}
+	server := setupMockServer(t, responses)
+	defer server.Close()
+
+	client := setupTestClient(t, server)
+	client.config.BaseURL = server.URL + "/v2"
+	frequencies := NewLocalFrequencies(client, 60.17, 24.94, "")
+
+	ctx := context.Background()
+	frequency, err := frequencies.Frequency(ctx, "Parus major", at)
+	require.NoError(t, err)
+	assert.InDelta(t, 1.0, frequency, 0.001)
+
+	frequency, err = frequencies.Frequency(ctx, "COCCOTHRAUSTES coccothraustes", at)
+	require.NoError(t, err)
+	assert.InDelta(t, 2.0/14, frequency, 0.001)
+
+	frequency, err = frequencies.Frequency(ctx, "Bombycilla garrulus", at)
+	require.NoError(t, err)
+	assert.Zero(t, frequency, "species never reported have a frequency of 0")
+
+	// Another week is outside the mocked days
+	_, err = frequencies.Frequency(ctx, "Parus major", at.AddDate(0, 0, 7))
+	require.Error(t, err)
+}
+
+func TestWeekOfYear(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, 0, weekOfYear(time.Date(2026, time.January, 7, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, 1, weekOfYear(time.Date(2026, time.January, 8, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, 51, weekOfYear(time.Date(2026, time.December, 31, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, 51, weekOfYear(time.Date(2024, time.December, 31, 0, 0, 0, 0, time.UTC)), "leap year")
+}
+
+func TestParseBarChart(t *testing.T) {
+	t.Parallel()
+
+	frequencies := func(value string) string {
+		return strings.Repeat(value+"\t", BarChartPeriods)
+	}
+	// Hawfinches are only reported in the second week of January
+	hawfinch := "0.0\t0.05\t" + strings.Repeat("0.0\t", BarChartPeriods-2)
+	data := "\n" +
+		"Frequency of observations in the selected location(s).:\n" +
+		"Number of taxa: 3\n\n" +
+		"\tJan\t\t\t\tFeb\n" +
+		"Sample Size:\t" + frequencies("120") + "\n\n" +
+		"Great Tit (<em class=\"sci\">Parus major</em>)\t" + frequencies("0.8") + "\n" +
+		"Hawfinch (<em class=\"sci\">Coccothraustes coccothraustes</em>)\t" + hawfinch + "\n" +
+		"Short row (<em class=\"sci\">Incomplete row</em>)\t0.5\t0.5\n"
+
+	chart, err := ParseBarChart(strings.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, 2, chart.Species(), "the sample sizes and incomplete rows are skipped")
+
+	ctx := context.Background()
+	frequency, _ := chart.Frequency(ctx, "parus major", time.Date(2026, time.June, 30, 0, 0, 0, 0, time.UTC))
+	assert.InDelta(t, 0.8, frequency, 0.001)
+	frequency, _ = chart.Frequency(ctx, "Coccothraustes coccothraustes", time.Date(2026, time.January, 10, 0, 0, 0, 0, time.UTC))
+	assert.InDelta(t, 0.05, frequency, 0.001)
+	frequency, _ = chart.Frequency(ctx, "Coccothraustes coccothraustes", time.Date(2026, time.January, 20, 0, 0, 0, 0, time.UTC))
+	assert.Zero(t, frequency)
+	frequency, _ = chart.Frequency(ctx, "Bombycilla garrulus", time.Date(2026, time.January, 10, 0, 0, 0, 0, time.UTC))
+	assert.Zero(t, frequency, "species not in the bar chart have a frequency of 0")
+
+	_, err = ParseBarChart(strings.NewReader("not a bar chart\n"))
+	require.Error(t, err)
+}
+
+func TestBarChartPeriod(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, 0, barChartPeriod(time.Date(2026, time.January, 7, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, 1, barChartPeriod(time.Date(2026, time.January, 8, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, 3, barChartPeriod(time.Date(2026, time.January, 31, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, 47, barChartPeriod(time.Date(2026, time.December, 29, 0, 0, 0, 0, time.UTC)))
+}
@@ -73,4 +73,40 @@ func DefaultConfig() Config {
 		CacheTTL:    24 * time.Hour, // Taxonomy rarely changes
 		RateLimitMS: 100,             // 10 requests per second max
 	}
-}
\ No newline at end of file
+}
+
+// Observation is an observation of a species reported to eBird
+type Observation struct {
+	SpeciesCode    string  `json:"speciesCode"`
+	CommonName     string  `json:"comName"`
+	ScientificName string  `json:"sciName"`
+	LocationID     string  `json:"locId"`
+	LocationName   string  `json:"locName"`
+	ObservedAt     string  `json:"obsDt"` // local date and time of the observation, "2006-01-02 15:04"
+	HowMany        int     `json:"howMany,omitempty"`
+	Latitude       float64 `json:"lat"`
+	Longitude      float64 `json:"lng"`
+}
+
+// Hotspot is an eBird hotspot with the regions it is in
+type Hotspot struct {
+	LocationID       string  `json:"locId"`
+	LocationName     string  `json:"locName"`
+	CountryCode      string  `json:"countryCode"`
+	Subnational1Code string  `json:"subnational1Code"` // state or province
+	Subnational2Code string  `json:"subnational2Code"` // county, empty in countries without counties
+	Latitude         float64 `json:"lat"`
+	Longitude        float64 `json:"lng"`
+}
+
+// RegionCode returns the most specific eBird region of the hotspot
+func (h *Hotspot) RegionCode() string {
+	switch {
+	case h.Subnational2Code != "":
+		return h.Subnational2Code
+	case h.Subnational1Code != "":
+		return h.Subnational1Code
+	default:
+		return h.CountryCode
+	}
+}
@@ -401,6 +401,7 @@ func init() {
 	RegisterComponent("telemetry", "telemetry")
 	RegisterComponent("birdweather", "birdweather")
 	RegisterComponent("backup", "backup")
+	RegisterComponent("manifest", "manifest")
 	RegisterComponent("audiocore", "audiocore")
 	RegisterComponent("api", "api")
 	
@@ -0,0 +1,265 @@
+// aggregator.go: Receives detections and clips from field units
+package fieldsync
+
+import (
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/tphakala/birdnet-go/internal/atrest"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/errors"
+	"github.com/tphakala/birdnet-go/internal/speciesalias"
+)
+
+// clipsSubdir is the directory under the clip export path that holds synced clips
+const clipsSubdir = "sync"
+
+// partialSuffix marks clips whose upload has not completed
+const partialSuffix = ".part"
+
+// MaxBatchSize is the largest number of detections accepted in one batch,
+// keeping requests within the API request body limit
+const MaxBatchSize = 1000
+
+// AggregatorStore is the datastore capability needed by an Aggregator
+type AggregatorStore interface {
+	GetSyncUnit(unitID string) (*datastore.SyncUnit, error)
+	SaveSyncBatch(unitID string, items []datastore.SyncBatchItem, cursor uint) (accepted, duplicates int, err error)
+	GetSyncedNote(unitID string, sourceID uint) (*datastore.SyncedNote, error)
+	SetSyncedClip(unitID string, sourceID uint, clipName string) error
+}
+
+// ClipStatus describes the upload progress of a clip
+type ClipStatus struct {
+	Offset   int64 // bytes stored so far
+	Complete bool  // the clip has been fully received
+}
+
+// Sentinel errors for clip uploads
+var (
+	ErrOffsetMismatch = errors.NewStd("clip chunk does not start at the stored offset")
+	ErrNoClip         = errors.NewStd("detection has no clip to upload")
+)
+
+// Aggregator applies syncs from field units to the local datastore
+type Aggregator struct {
+	store    AggregatorStore
+	clipRoot string              // audio clip export path
+	cipher   *atrest.Cipher      // encrypts completed clips, nil to store them as received
+	aliases  *speciesalias.Index // renames former species names, nil to store them as received
+}
+
+// NewAggregator creates an aggregator storing clips below clipRoot
+func NewAggregator(store AggregatorStore, clipRoot string) *Aggregator {
+	return &Aggregator{store: store, clipRoot: clipRoot}
+}
+
+// EncryptClips makes the aggregator encrypt clips at rest once they are complete
+func (a *Aggregator) EncryptClips(c *atrest.Cipher) *Aggregator {
+	a.cipher = c
+	return a
+}
+
+// ResolveAliases makes the aggregator store detections of field units running
+// older labels under the current species names
+func (a *Aggregator) ResolveAliases(aliases *speciesalias.Index) *Aggregator {
+	a.aliases = aliases
+	return a
+}
+
+// State returns the sync state of a unit
+func (a *Aggregator) State(unitID string) (*StateResponse, error) {
+	unit, err := a.store.GetSyncUnit(unitID)
+	if err != nil {
+		return nil, err
+	}
+	resp := &StateResponse{
+		UnitID:     unitID,
+		Token:      EncodeToken(unit.Cursor),
+		Detections: unit.Detections,
+		Clips:      unit.Clips,
+	}
+	if !unit.LastSyncAt.IsZero() {
+		resp.LastSyncAt = &unit.LastSyncAt
+	}
+	return resp, nil
+}
+
+// ApplyBatch stores a batch of detections from a unit and returns the new token
+func (a *Aggregator) ApplyBatch(unitID string, batch *Batch) (*BatchResponse, error) {
+	if batch.Version != ProtocolVersion {
+		return nil, errors.Newf("unsupported sync protocol version %d", batch.Version).
+			Component("fieldsync").
+			Category(errors.CategoryValidation).
+			Context("unit_id", unitID).
+			Build()
+	}
+	if len(batch.Detections) > MaxBatchSize {
+		return nil, errors.Newf("sync batch of %d detections exceeds the limit of %d", len(batch.Detections), MaxBatchSize).
+			Component("fieldsync").
+			Category(errors.CategoryLimit).
+			Context("unit_id", unitID).
+			Build()
+	}
+	if _, err := DecodeToken(batch.Since); err != nil {
+		return nil, err
+	}
+
+	var cursor uint
+	items := make([]datastore.SyncBatchItem, 0, len(batch.Detections))
+	for i := range batch.Detections {
+		d := &batch.Detections[i]
+		if d.SourceID == 0 || d.ScientificName == "" {
+			return nil, errors.Newf("sync detection %d is missing its source ID or species", i).
+				Component("fieldsync").
+				Category(errors.CategoryValidation).
+				Context("unit_id", unitID).
+				Build()
+		}
+		if d.ClipName != "" && !isSafeClipName(d.ClipName) {
+			return nil, errors.Newf("sync detection %d has an invalid clip name", d.SourceID).
+				Component("fieldsync").
+				Category(errors.CategoryValidation).
+				Context("unit_id", unitID).
+				Build()
+		}
+		cursor = max(cursor, d.SourceID)
+		item := d.batchItem()
+		a.resolveAliases(&item)
+		items = append(items, item)
+	}
+
+	accepted, duplicates, err := a.store.SaveSyncBatch(unitID, items, cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	unit, err := a.store.GetSyncUnit(unitID)
+	if err != nil {
+		return nil, err
+	}
+	return &BatchResponse{Token: EncodeToken(unit.Cursor), Accepted: accepted, Duplicates: duplicates}, nil
+}
+
+// resolveAliases renames a detection and its results stored under former
+// species names that map to a single current species
+func (a *Aggregator) resolveAliases(item *datastore.SyncBatchItem) {
+	if current, ok := a.aliases.Current(item.Note.ScientificName, item.Note.CommonName); ok {
+		item.Note.ScientificName = current.ScientificName
+		item.Note.CommonName = current.CommonName
+		if current.Code != "" {
+			item.Note.SpeciesCode = current.Code
+		}
+	}
+	for i := range item.Results {
+		item.Results[i].Species = a.aliases.ResolveLabel(item.Results[i].Species)
+	}
+}
+
+// ClipStatus returns how much of a clip has been received
+func (a *Aggregator) ClipStatus(unitID string, sourceID uint) (*ClipStatus, error) {
+	finalPath, relPath, err := a.clipPaths(unitID, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(finalPath); err == nil {
+		return &ClipStatus{Offset: info.Size(), Complete: true}, nil
+	}
+	info, err := os.Stat(finalPath + partialSuffix)
+	if os.IsNotExist(err) {
+		return &ClipStatus{}, nil
+	}
+	if err != nil {
+		return nil, clipError(err, "stat_clip", relPath)
+	}
+	return &ClipStatus{Offset: info.Size()}, nil
+}
+
+// WriteClipChunk appends a chunk starting at offset to a clip. total is the full
+// clip size; the clip is finalized and linked to its detection once complete.
+func (a *Aggregator) WriteClipChunk(unitID string, sourceID uint, offset, total int64, chunk io.Reader) (*ClipStatus, error) {
+	finalPath, relPath, err := a.clipPaths(unitID, sourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := a.ClipStatus(unitID, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	if status.Complete {
+		return status, nil
+	}
+	if offset != status.Offset || offset > total {
+		return status, ErrOffsetMismatch
+	}
+
+	if err := os.MkdirAll(filepath.Dir(finalPath), 0o755); err != nil {
+		return nil, clipError(err, "create_dir", relPath)
+	}
+	f, err := os.OpenFile(finalPath+partialSuffix, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, clipError(err, "open_clip", relPath)
+	}
+	written, copyErr := io.Copy(f, io.LimitReader(chunk, total-offset))
+	if closeErr := f.Close(); copyErr == nil {
+		copyErr = closeErr
+	}
+	status.Offset += written
+	if copyErr != nil {
+		return status, clipError(copyErr, "write_clip", relPath)
+	}
+
+	if status.Offset < total {
+		return status, nil
+	}
+
+	if a.cipher != nil {
+		if err := a.cipher.EncryptFile(finalPath + partialSuffix); err != nil {
+			return status, err
+		}
+	}
+	if err := os.Rename(finalPath+partialSuffix, finalPath); err != nil {
+		return status, clipError(err, "finalize_clip", relPath)
+	}
+	if err := a.store.SetSyncedClip(unitID, sourceID, relPath); err != nil {
+		return status, err
+	}
+	status.Complete = true
+	return status, nil
+}
+
+// clipPaths returns the absolute path of a synced clip and its path relative to the clip root
+func (a *Aggregator) clipPaths(unitID string, sourceID uint) (absPath, relPath string, err error) {
+	synced, err := a.store.GetSyncedNote(unitID, sourceID)
+	if err != nil {
+		return "", "", err
+	}
+	if synced.ClipName == "" || !isSafeClipName(synced.ClipName) {
+		return "", "", ErrNoClip
+	}
+	relPath = path.Join(clipsSubdir, unitID, synced.ClipName)
+	return filepath.Join(a.clipRoot, filepath.FromSlash(relPath)), relPath, nil
+}
+
+// isSafeClipName reports whether a clip name is a relative path that stays within its directory
+func isSafeClipName(name string) bool {
+	if name == "" || strings.Contains(name, "\\") || path.IsAbs(name) {
+		return false
+	}
+	clean := path.Clean(name)
+	return clean == name && clean != "." && !strings.HasPrefix(clean, "../") && clean != ".."
+}
+
+// clipError wraps a clip file error with context
+func clipError(err error, operation, relPath string) error {
+	return errors.New(err).
+		Component("fieldsync").
+		Category(errors.CategoryFileIO).
+		Context("operation", operation).
+		Context("clip", relPath).
+		Build()
+}
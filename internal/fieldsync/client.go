@@ -0,0 +1,485 @@
+// client.go: Pushes new detections and selected clips from a field unit to an aggregator
+package fieldsync
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/atrest"
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/databudget"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// Client defaults
+const (
+	DefaultBatchSize = 250
+	DefaultChunkSize = 256 * 1024
+	MaxChunkSize     = 1024 * 1024
+	requestTimeout   = 60 * time.Second
+)
+
+// SourceStore is the datastore capability needed by a Client
+type SourceStore interface {
+	GetNotesAfterID(afterID uint, limit int) ([]datastore.Note, error)
+}
+
+// ClipSelection selects which clips are uploaded with their detections
+type ClipSelection struct {
+	Enabled       bool
+	MinConfidence float64
+	Species       []string // common or scientific names, all species when empty
+}
+
+// ClientOptions configures a Client
+type ClientOptions struct {
+	AggregatorURL string
+	Token         string
+	BatchSize     int
+	ChunkSize     int
+	ClipRoot      string // audio clip export path
+	Clips         ClipSelection
+	StatePath     string       // file persisting clips waiting for upload, in memory only when empty
+	HTTPClient    *http.Client // a metered client with a default timeout is used when nil
+}
+
+// PendingClip is a clip waiting to be uploaded
+type PendingClip struct {
+	SourceID uint   `json:"source_id"`
+	ClipName string `json:"clip_name"`
+}
+
+// Report summarizes a sync run
+type Report struct {
+	Token        string `json:"token"`
+	Detections   int    `json:"detections"`
+	Duplicates   int    `json:"duplicates"`
+	Clips        int    `json:"clips"`
+	PendingClips int    `json:"pending_clips"`
+}
+
+// clientState is the persisted part of the client state
+type clientState struct {
+	PendingClips []PendingClip `json:"pending_clips"`
+}
+
+// Client syncs a field unit to an aggregator
+type Client struct {
+	opts    ClientOptions
+	store   SourceStore
+	http    *http.Client
+	baseURL string
+
+	runMu sync.Mutex // serializes sync runs
+	mu    sync.Mutex // protects state
+	state clientState
+}
+
+// NewClient creates a field unit sync client and restores clips waiting for upload
+func NewClient(store SourceStore, opts ClientOptions) (*Client, error) {
+	if opts.AggregatorURL == "" || opts.Token == "" {
+		return nil, errors.Newf("field sync requires an aggregator URL and token").
+			Component("fieldsync").
+			Category(errors.CategoryConfiguration).
+			Build()
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+	opts.BatchSize = min(opts.BatchSize, MaxBatchSize)
+	if opts.ChunkSize <= 0 {
+		opts.ChunkSize = DefaultChunkSize
+	}
+	opts.ChunkSize = min(opts.ChunkSize, MaxChunkSize)
+
+	httpClient := opts.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: requestTimeout, Transport: databudget.Transport(databudget.Uploads, nil)}
+	}
+
+	c := &Client{
+		opts:    opts,
+		store:   store,
+		http:    httpClient,
+		baseURL: strings.TrimRight(opts.AggregatorURL, "/"),
+	}
+	if err := c.loadState(); err != nil {
+		return c, err
+	}
+	return c, nil
+}
+
+// PendingClips returns the number of clips waiting for upload
+func (c *Client) PendingClips() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.state.PendingClips)
+}
+
+// Sync sends detections newer than the aggregator's token, then uploads pending
+// clips. A run interrupted by a connection drop is resumed by the next call.
+func (c *Client) Sync(ctx context.Context) (*Report, error) {
+	c.runMu.Lock()
+	defer c.runMu.Unlock()
+
+	if conf.IsOffline() {
+		return nil, conf.ErrOfflineMode
+	}
+
+	report := &Report{}
+	var state StateResponse
+	if err := c.doJSON(ctx, http.MethodGet, StatePath, nil, &state); err != nil {
+		return report, err
+	}
+	report.Token = state.Token
+
+	cursor, err := DecodeToken(state.Token)
+	if err != nil {
+		return report, err
+	}
+
+	for {
+		notes, err := c.store.GetNotesAfterID(cursor, c.opts.BatchSize)
+		if err != nil {
+			return report, err
+		}
+		if len(notes) == 0 {
+			break
+		}
+
+		batch := Batch{Version: ProtocolVersion, Since: report.Token, Detections: make([]Detection, 0, len(notes))}
+		for i := range notes {
+			batch.Detections = append(batch.Detections, DetectionFromNote(&notes[i]))
+		}
+
+		var resp BatchResponse
+		if err := c.doJSON(ctx, http.MethodPost, DetectionsPath, &batch, &resp); err != nil {
+			return report, err
+		}
+		next, err := DecodeToken(resp.Token)
+		if err != nil {
+			return report, err
+		}
+
+		report.Token = resp.Token
+		report.Detections += resp.Accepted
+		report.Duplicates += resp.Duplicates
+		c.queueClips(notes)
+
+		if next <= cursor {
+			// The aggregator did not advance, avoid resending the same batch forever
+			break
+		}
+		cursor = next
+	}
+
+	clips, err := c.uploadPendingClips(ctx)
+	report.Clips = clips
+	report.PendingClips = c.PendingClips()
+	return report, err
+}
+
+// Run syncs every interval until ctx is cancelled. onResult is called after
+// each run and may be nil.
+func (c *Client) Run(ctx context.Context, interval time.Duration, onResult func(*Report, error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		report, err := c.Sync(ctx)
+		if onResult != nil {
+			onResult(report, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// selectsClip reports whether the clip of a note should be uploaded
+func (c *Client) selectsClip(note *datastore.Note) bool {
+	sel := c.opts.Clips
+	if !sel.Enabled || note.ClipName == "" || note.Confidence < sel.MinConfidence {
+		return false
+	}
+	if len(sel.Species) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(sel.Species, func(name string) bool {
+		return strings.EqualFold(name, note.CommonName) || strings.EqualFold(name, note.ScientificName)
+	})
+}
+
+// queueClips adds selected clips of acknowledged notes to the upload queue
+func (c *Client) queueClips(notes []datastore.Note) {
+	c.mu.Lock()
+	added := false
+	for i := range notes {
+		if c.selectsClip(&notes[i]) {
+			c.state.PendingClips = append(c.state.PendingClips, PendingClip{SourceID: notes[i].ID, ClipName: notes[i].ClipName})
+			added = true
+		}
+	}
+	c.mu.Unlock()
+
+	if added {
+		_ = c.saveState()
+	}
+}
+
+// uploadPendingClips uploads queued clips in order, stopping at the first
+// network error so the remaining clips are retried on the next run
+func (c *Client) uploadPendingClips(ctx context.Context) (uploaded int, err error) {
+	for {
+		c.mu.Lock()
+		if len(c.state.PendingClips) == 0 {
+			c.mu.Unlock()
+			return uploaded, nil
+		}
+		clip := c.state.PendingClips[0]
+		c.mu.Unlock()
+
+		err := c.uploadClip(ctx, clip)
+		switch {
+		case err == nil:
+			uploaded++
+		case errors.Is(err, errClipGone):
+			// Clip was removed locally or rejected by the aggregator, nothing to resume
+		default:
+			return uploaded, err
+		}
+
+		c.mu.Lock()
+		c.state.PendingClips = c.state.PendingClips[1:]
+		c.mu.Unlock()
+		_ = c.saveState()
+	}
+}
+
+// errClipGone marks clips that can no longer be uploaded
+var errClipGone = errors.NewStd("clip is no longer available for upload")
+
+// uploadClip sends a clip in chunks, resuming from the offset stored by the aggregator
+func (c *Client) uploadClip(ctx context.Context, clip PendingClip) error {
+	f, err := os.Open(filepath.Join(c.opts.ClipRoot, filepath.FromSlash(clip.ClipName)))
+	if os.IsNotExist(err) {
+		return errClipGone
+	}
+	if err != nil {
+		return clipError(err, "open_clip", clip.ClipName)
+	}
+	defer func() { _ = f.Close() }()
+
+	// Clips encrypted at rest are sent decrypted, the aggregator applies its own encryption
+	content, err := atrest.Decrypting(f)
+	if err != nil {
+		return clipError(err, "read_clip", clip.ClipName)
+	}
+	total := content.Size()
+	clipURL := c.baseURL + ClipsPath + strconv.FormatUint(uint64(clip.SourceID), 10)
+
+	offset, complete, err := c.clipOffset(ctx, clipURL)
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, c.opts.ChunkSize)
+	for !complete && offset < total {
+		n, err := content.ReadAt(buf, offset)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return clipError(err, "read_clip", clip.ClipName)
+		}
+		if n == 0 {
+			return errClipGone // file shrank since it was opened
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPut, clipURL, bytes.NewReader(buf[:n]))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/octet-stream")
+		req.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, offset+int64(n)-1, total))
+
+		resp, err := c.do(req)
+		if err != nil {
+			return err
+		}
+		_ = resp.Body.Close()
+
+		switch resp.StatusCode {
+		case http.StatusOK, http.StatusNoContent, http.StatusConflict:
+			// A conflict means the stored offset moved, continue from the reported one
+			offset, complete, err = parseUploadOffset(resp)
+			if err != nil {
+				return err
+			}
+		case http.StatusNotFound:
+			return errClipGone
+		default:
+			return statusError(resp.StatusCode, "upload_clip")
+		}
+	}
+	return nil
+}
+
+// clipOffset asks the aggregator how much of a clip it already has
+func (c *Client) clipOffset(ctx context.Context, clipURL string) (offset int64, complete bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, clipURL, http.NoBody)
+	if err != nil {
+		return 0, false, err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return 0, false, err
+	}
+	_ = resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent:
+		return parseUploadOffset(resp)
+	case http.StatusNotFound:
+		return 0, false, errClipGone
+	default:
+		return 0, false, statusError(resp.StatusCode, "clip_offset")
+	}
+}
+
+// parseUploadOffset reads the stored offset and completion from upload response headers
+func parseUploadOffset(resp *http.Response) (offset int64, complete bool, err error) {
+	offset, err = strconv.ParseInt(resp.Header.Get(HeaderUploadOffset), 10, 64)
+	if err != nil {
+		return 0, false, errors.Newf("aggregator returned an invalid upload offset").
+			Component("fieldsync").
+			Category(errors.CategoryNetwork).
+			Build()
+	}
+	length, lengthErr := strconv.ParseInt(resp.Header.Get(HeaderUploadLength), 10, 64)
+	return offset, lengthErr == nil && offset >= length, nil
+}
+
+// doJSON sends a JSON request to the aggregator and decodes the JSON response
+func (c *Client) doJSON(ctx context.Context, method, apiPath string, body, out any) error {
+	var reader io.Reader = http.NoBody
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+apiPath, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return statusError(resp.StatusCode, method+" "+apiPath)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// do authenticates and sends a request
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	req.Header.Set("Authorization", "Bearer "+c.opts.Token)
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, errors.New(err).
+			Component("fieldsync").
+			Category(errors.CategoryNetwork).
+			Context("operation", "sync_request").
+			Context("method", req.Method).
+			Context("path", req.URL.Path).
+			Build()
+	}
+	return resp, nil
+}
+
+// statusError describes an unexpected aggregator response
+func statusError(status int, operation string) error {
+	category := errors.CategoryNetwork
+	if status == http.StatusUnauthorized || status == http.StatusForbidden {
+		category = errors.CategoryConfiguration
+	}
+	return errors.Newf("aggregator returned status %d", status).
+		Component("fieldsync").
+		Category(category).
+		Context("operation", operation).
+		Context("status_code", status).
+		Build()
+}
+
+// loadState restores clips waiting for upload
+func (c *Client) loadState() error {
+	if c.opts.StatePath == "" {
+		return nil
+	}
+	data, err := os.ReadFile(c.opts.StatePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return stateError(err, "read_state", c.opts.StatePath)
+	}
+	if err := json.Unmarshal(data, &c.state); err != nil {
+		return stateError(err, "decode_state", c.opts.StatePath)
+	}
+	return nil
+}
+
+// saveState persists clips waiting for upload
+func (c *Client) saveState() error {
+	if c.opts.StatePath == "" {
+		return nil
+	}
+	c.mu.Lock()
+	data, err := json.Marshal(&c.state)
+	c.mu.Unlock()
+	if err != nil {
+		return stateError(err, "encode_state", c.opts.StatePath)
+	}
+
+	tmp := c.opts.StatePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return stateError(err, "write_state", c.opts.StatePath)
+	}
+	if err := os.Rename(tmp, c.opts.StatePath); err != nil {
+		_ = os.Remove(tmp)
+		return stateError(err, "write_state", c.opts.StatePath)
+	}
+	return nil
+}
+
+// stateError wraps a client state file error with context
+func stateError(err error, operation, path string) error {
+	return errors.New(err).
+		Component("fieldsync").
+		Category(errors.CategoryFileIO).
+		Context("operation", operation).
+		Context("path", path).
+		Build()
+}
@@ -0,0 +1,383 @@
+package fieldsync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/errors"
+	"github.com/tphakala/birdnet-go/internal/speciesalias"
+)
+
+// memoryAggregatorStore is an in-memory AggregatorStore
+type memoryAggregatorStore struct {
+	mu     sync.Mutex
+	units  map[string]*datastore.SyncUnit
+	synced map[string]*datastore.SyncedNote
+	notes  []datastore.Note
+}
+
+func newMemoryAggregatorStore() *memoryAggregatorStore {
+	return &memoryAggregatorStore{
+		units:  make(map[string]*datastore.SyncUnit),
+		synced: make(map[string]*datastore.SyncedNote),
+	}
+}
+
+func syncedKey(unitID string, sourceID uint) string {
+	return fmt.Sprintf("%s/%d", unitID, sourceID)
+}
+
+func (s *memoryAggregatorStore) GetSyncUnit(unitID string) (*datastore.SyncUnit, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if unit, ok := s.units[unitID]; ok {
+		copied := *unit
+		return &copied, nil
+	}
+	return &datastore.SyncUnit{UnitID: unitID}, nil
+}
+
+func (s *memoryAggregatorStore) SaveSyncBatch(unitID string, items []datastore.SyncBatchItem, cursor uint) (accepted, duplicates int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unit, ok := s.units[unitID]
+	if !ok {
+		unit = &datastore.SyncUnit{UnitID: unitID}
+		s.units[unitID] = unit
+	}
+	for _, item := range items {
+		key := syncedKey(unitID, item.SourceID)
+		if _, ok := s.synced[key]; ok {
+			duplicates++
+			continue
+		}
+		note := item.Note
+		note.ID = uint(len(s.notes) + 1)
+		note.SourceNode = unitID
+		s.notes = append(s.notes, note)
+		s.synced[key] = &datastore.SyncedNote{UnitID: unitID, SourceID: item.SourceID, NoteID: note.ID, ClipName: item.ClipName}
+		accepted++
+	}
+	unit.Cursor = max(unit.Cursor, cursor)
+	unit.Detections += int64(accepted)
+	return accepted, duplicates, nil
+}
+
+func (s *memoryAggregatorStore) GetSyncedNote(unitID string, sourceID uint) (*datastore.SyncedNote, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	synced, ok := s.synced[syncedKey(unitID, sourceID)]
+	if !ok {
+		return nil, errors.Newf("synced note not found").Category(errors.CategoryNotFound).Build()
+	}
+	return synced, nil
+}
+
+func (s *memoryAggregatorStore) SetSyncedClip(unitID string, sourceID uint, clipName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.units[unitID].Clips++
+	return nil
+}
+
+// memorySourceStore is an in-memory SourceStore
+type memorySourceStore struct {
+	notes []datastore.Note // sorted by ID
+}
+
+func (s *memorySourceStore) GetNotesAfterID(afterID uint, limit int) ([]datastore.Note, error) {
+	var out []datastore.Note
+	for i := range s.notes {
+		if s.notes[i].ID > afterID && len(out) < limit {
+			out = append(out, s.notes[i])
+		}
+	}
+	return out, nil
+}
+
+// newTestAggregatorServer serves the sync protocol for a single unit
+func newTestAggregatorServer(t *testing.T, agg *Aggregator, unitID, token string) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	auth := func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer "+token {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			next(w, r)
+		}
+	}
+	writeJSON := func(w http.ResponseWriter, v any, err error) {
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(v)
+	}
+
+	mux.HandleFunc(StatePath, auth(func(w http.ResponseWriter, r *http.Request) {
+		state, err := agg.State(unitID)
+		writeJSON(w, state, err)
+	}))
+	mux.HandleFunc(DetectionsPath, auth(func(w http.ResponseWriter, r *http.Request) {
+		var batch Batch
+		if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		resp, err := agg.ApplyBatch(unitID, &batch)
+		writeJSON(w, resp, err)
+	}))
+	mux.HandleFunc(ClipsPath, auth(func(w http.ResponseWriter, r *http.Request) {
+		id, _ := strconv.ParseUint(strings.TrimPrefix(r.URL.Path, ClipsPath), 10, 0)
+		var status *ClipStatus
+		var err error
+		total := int64(-1)
+		if r.Method == http.MethodPut {
+			var start, end int64
+			_, _ = fmt.Sscanf(r.Header.Get("Content-Range"), "bytes %d-%d/%d", &start, &end, &total)
+			status, err = agg.WriteClipChunk(unitID, uint(id), start, total, r.Body)
+		} else {
+			status, err = agg.ClipStatus(unitID, uint(id))
+		}
+		if status != nil {
+			w.Header().Set(HeaderUploadOffset, strconv.FormatInt(status.Offset, 10))
+			if status.Complete {
+				total = status.Offset
+			}
+			if total >= 0 {
+				w.Header().Set(HeaderUploadLength, strconv.FormatInt(total, 10))
+			}
+		}
+		switch {
+		case errors.Is(err, ErrOffsetMismatch):
+			w.WriteHeader(http.StatusConflict)
+		case err != nil:
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestToken_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	cursor, err := DecodeToken(EncodeToken(42))
+	require.NoError(t, err)
+	assert.Equal(t, uint(42), cursor)
+
+	cursor, err = DecodeToken("")
+	require.NoError(t, err)
+	assert.Zero(t, cursor)
+
+	for _, token := range []string{"42", "v1.", "v1.abc", "v2.42", "v1.-1"} {
+		_, err := DecodeToken(token)
+		assert.ErrorIs(t, err, ErrInvalidToken, "token %q", token)
+	}
+}
+
+func TestSentinelErrorsAreDistinct(t *testing.T) {
+	t.Parallel()
+
+	// Other errors of the same category must not match the sentinels
+	conflict := errors.Newf("unrelated conflict").Category(errors.CategoryConflict).Build()
+	notFound := errors.Newf("unrelated not found").Category(errors.CategoryNotFound).Build()
+	assert.NotErrorIs(t, conflict, ErrOffsetMismatch)
+	assert.NotErrorIs(t, notFound, ErrNoClip)
+	assert.NotErrorIs(t, notFound, errClipGone)
+	assert.NotErrorIs(t, ErrNoClip, errClipGone)
+	assert.ErrorIs(t, errors.New(ErrOffsetMismatch).Context("offset", 10).Build(), ErrOffsetMismatch)
+}
+
+func TestIsSafeClipName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"2026/03/robin_80p_20260310T120000Z.wav", true},
+		{"clip.flac", true},
+		{"", false},
+		{".", false},
+		{"..", false},
+		{"../etc/passwd", false},
+		{"/etc/passwd", false},
+		{"2026/../../clip.wav", false},
+		{"2026//clip.wav", false},
+		{"2026\\clip.wav", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, isSafeClipName(tt.name), "clip name %q", tt.name)
+	}
+}
+
+func TestAggregator_ApplyBatchSkipsDuplicates(t *testing.T) {
+	t.Parallel()
+
+	agg := NewAggregator(newMemoryAggregatorStore(), t.TempDir())
+	batch := &Batch{Version: ProtocolVersion, Detections: []Detection{
+		{SourceID: 1, ScientificName: "Turdus merula"},
+		{SourceID: 2, ScientificName: "Erithacus rubecula"},
+	}}
+
+	resp, err := agg.ApplyBatch("unit-a", batch)
+	require.NoError(t, err)
+	assert.Equal(t, 2, resp.Accepted)
+	assert.Equal(t, EncodeToken(2), resp.Token)
+
+	// Resending the batch after a dropped acknowledgement must not duplicate detections
+	resp, err = agg.ApplyBatch("unit-a", batch)
+	require.NoError(t, err)
+	assert.Zero(t, resp.Accepted)
+	assert.Equal(t, 2, resp.Duplicates)
+	assert.Equal(t, EncodeToken(2), resp.Token)
+}
+
+func TestAggregator_ApplyBatchResolvesAliases(t *testing.T) {
+	t.Parallel()
+
+	store := newMemoryAggregatorStore()
+	agg := NewAggregator(store, t.TempDir()).ResolveAliases(speciesalias.Default())
+	batch := &Batch{Version: ProtocolVersion, Detections: []Detection{
+		{
+			SourceID: 1, ScientificName: "Carduelis tristis", CommonName: "American Goldfinch",
+			Results: []Result{{Species: "Carduelis tristis_American Goldfinch", Confidence: 0.9}},
+		},
+		{SourceID: 2, ScientificName: "Aphelocoma californica", CommonName: "Western Scrub-Jay"},
+		{SourceID: 3, ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird"},
+	}}
+
+	_, err := agg.ApplyBatch("unit-a", batch)
+	require.NoError(t, err)
+	require.Len(t, store.notes, 3)
+
+	assert.Equal(t, "Spinus tristis", store.notes[0].ScientificName)
+	assert.Equal(t, "amegfi", store.notes[0].SpeciesCode)
+	item := batch.Detections[0].batchItem()
+	agg.resolveAliases(&item)
+	assert.Equal(t, "Spinus tristis_American Goldfinch", item.Results[0].Species)
+
+	// Split species are kept as received for review
+	assert.Equal(t, "Western Scrub-Jay", store.notes[1].CommonName)
+	assert.Equal(t, "Turdus merula", store.notes[2].ScientificName)
+}
+
+func TestAggregator_ApplyBatchValidates(t *testing.T) {
+	t.Parallel()
+
+	agg := NewAggregator(newMemoryAggregatorStore(), t.TempDir())
+	tests := []struct {
+		name  string
+		batch Batch
+	}{
+		{"unsupported version", Batch{Version: ProtocolVersion + 1}},
+		{"invalid token", Batch{Version: ProtocolVersion, Since: "bogus"}},
+		{"missing source ID", Batch{Version: ProtocolVersion, Detections: []Detection{{ScientificName: "Turdus merula"}}}},
+		{"unsafe clip name", Batch{Version: ProtocolVersion, Detections: []Detection{{SourceID: 1, ScientificName: "Turdus merula", ClipName: "../x.wav"}}}},
+		{"oversized batch", Batch{Version: ProtocolVersion, Detections: make([]Detection, MaxBatchSize+1)}},
+	}
+	for _, tt := range tests {
+		_, err := agg.ApplyBatch("unit-a", &tt.batch)
+		assert.Error(t, err, tt.name)
+	}
+}
+
+func TestClient_SyncResumesInterruptedClip(t *testing.T) {
+	t.Parallel()
+
+	const unitID, token = "unit-a", "secret"
+	clipData := []byte(strings.Repeat("0123456789", 100))
+
+	sourceRoot := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(sourceRoot, "2026"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(sourceRoot, "2026", "robin.wav"), clipData, 0o644))
+
+	source := &memorySourceStore{notes: []datastore.Note{
+		{ID: 1, ScientificName: "Erithacus rubecula", CommonName: "European Robin", Confidence: 0.9, ClipName: "2026/robin.wav"},
+		{ID: 2, ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", Confidence: 0.5, ClipName: "2026/blackbird.wav"},
+		{ID: 3, ScientificName: "Parus major", CommonName: "Great Tit", Confidence: 0.8},
+	}}
+
+	aggRoot := t.TempDir()
+	store := newMemoryAggregatorStore()
+	agg := NewAggregator(store, aggRoot)
+	srv := newTestAggregatorServer(t, agg, unitID, token)
+
+	// Simulate an earlier run that synced the first detection and part of its clip
+	_, err := agg.ApplyBatch(unitID, &Batch{Version: ProtocolVersion, Detections: []Detection{DetectionFromNote(&source.notes[0])}})
+	require.NoError(t, err)
+	status, err := agg.WriteClipChunk(unitID, 1, 0, int64(len(clipData)), strings.NewReader(string(clipData[:300])))
+	require.NoError(t, err)
+	require.False(t, status.Complete)
+
+	statePath := filepath.Join(t.TempDir(), "fieldsync.json")
+	require.NoError(t, os.WriteFile(statePath, []byte(`{"pending_clips":[{"source_id":1,"clip_name":"2026/robin.wav"}]}`), 0o644))
+
+	client, err := NewClient(source, ClientOptions{
+		AggregatorURL: srv.URL,
+		Token:         token,
+		BatchSize:     1,
+		ChunkSize:     256,
+		ClipRoot:      sourceRoot,
+		Clips:         ClipSelection{Enabled: true, MinConfidence: 0.7},
+		StatePath:     statePath,
+		HTTPClient:    srv.Client(),
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, client.PendingClips(), "pending clips should be restored from the state file")
+
+	report, err := client.Sync(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, EncodeToken(3), report.Token)
+	assert.Equal(t, 2, report.Detections, "only detections after the aggregator token should be sent")
+	assert.Equal(t, 1, report.Clips)
+	assert.Zero(t, report.PendingClips)
+
+	stored, err := os.ReadFile(filepath.Join(aggRoot, clipsSubdir, unitID, "2026", "robin.wav"))
+	require.NoError(t, err)
+	assert.Equal(t, clipData, stored)
+
+	unit, err := store.GetSyncUnit(unitID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), unit.Detections)
+	assert.Equal(t, int64(1), unit.Clips)
+
+	// A second run has nothing new to send
+	report, err = client.Sync(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, report.Detections)
+	assert.Zero(t, report.Clips)
+}
+
+func TestClient_RejectedToken(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestAggregatorServer(t, NewAggregator(newMemoryAggregatorStore(), t.TempDir()), "unit-a", "secret")
+	client, err := NewClient(&memorySourceStore{}, ClientOptions{AggregatorURL: srv.URL, Token: "wrong", HTTPClient: srv.Client()})
+	require.NoError(t, err)
+
+	_, err = client.Sync(context.Background())
+	var enhancedErr *errors.EnhancedError
+	require.ErrorAs(t, err, &enhancedErr)
+	assert.Equal(t, errors.CategoryConfiguration, enhancedErr.Category)
+}
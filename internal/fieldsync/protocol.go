@@ -0,0 +1,163 @@
+// Package fieldsync implements delta sync of detections and clips from field
+// units to a central aggregator.
+//
+// A field unit asks the aggregator for its sync token, sends detections newer
+// than the token in batches, and receives an updated token for each accepted
+// batch. The aggregator is the source of truth for the token, so a unit that
+// loses its connection or local state resumes where the aggregator left off.
+// Selected clips are uploaded afterwards in chunks that can be resumed from the
+// last byte the aggregator stored.
+package fieldsync
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// ProtocolVersion is the version of the sync wire format
+const ProtocolVersion = 1
+
+// API paths served by the aggregator, relative to its base URL
+const (
+	StatePath      = "/api/v2/sync/state"
+	DetectionsPath = "/api/v2/sync/detections"
+	ClipsPath      = "/api/v2/sync/clips/" // followed by the source note ID
+)
+
+// Clip upload headers
+const (
+	HeaderUploadOffset = "Upload-Offset" // bytes of the clip stored by the aggregator
+	HeaderUploadLength = "Upload-Length" // total clip size, once known
+)
+
+// tokenPrefix versions the opaque sync token format
+const tokenPrefix = "v1."
+
+// Result is a secondary species prediction of a detection
+type Result struct {
+	Species    string  `json:"species"`
+	Confidence float32 `json:"confidence"`
+}
+
+// Detection is a detection as sent from a field unit
+type Detection struct {
+	SourceID          uint      `json:"source_id"` // Note ID on the field unit
+	Date              string    `json:"date"`
+	Time              string    `json:"time"`
+	BeginTime         time.Time `json:"begin_time"`
+	EndTime           time.Time `json:"end_time"`
+	SpeciesCode       string    `json:"species_code"`
+	ScientificName    string    `json:"scientific_name"`
+	CommonName        string    `json:"common_name"`
+	Confidence        float64   `json:"confidence"`
+	Latitude          float64   `json:"latitude"`
+	Longitude         float64   `json:"longitude"`
+	Threshold         float64   `json:"threshold"`
+	Sensitivity       float64   `json:"sensitivity"`
+	WeatherAdjustment float64   `json:"weather_adjustment,omitempty"` // threshold increase for wind and rain
+	ClipName          string    `json:"clip_name,omitempty"`
+	Results           []Result  `json:"results,omitempty"`
+}
+
+// Batch is a set of detections sent in one request
+type Batch struct {
+	Version    int         `json:"version"`
+	Since      string      `json:"since"` // Token the batch continues from
+	Detections []Detection `json:"detections"`
+}
+
+// BatchResponse acknowledges a batch
+type BatchResponse struct {
+	Token      string `json:"token"`
+	Accepted   int    `json:"accepted"`
+	Duplicates int    `json:"duplicates"`
+}
+
+// StateResponse describes the sync state of a unit on the aggregator
+type StateResponse struct {
+	UnitID     string     `json:"unit_id"`
+	Token      string     `json:"token"`
+	Detections int64      `json:"detections"`
+	Clips      int64      `json:"clips"`
+	LastSyncAt *time.Time `json:"last_sync_at,omitempty"`
+}
+
+// ErrInvalidToken is returned for sync tokens that cannot be decoded
+var ErrInvalidToken = errors.NewStd("invalid sync token")
+
+// EncodeToken returns the opaque sync token for a cursor
+func EncodeToken(cursor uint) string {
+	return tokenPrefix + strconv.FormatUint(uint64(cursor), 10)
+}
+
+// DecodeToken returns the cursor of a sync token. An empty token decodes to zero.
+func DecodeToken(token string) (uint, error) {
+	if token == "" {
+		return 0, nil
+	}
+	value, ok := strings.CutPrefix(token, tokenPrefix)
+	if !ok {
+		return 0, ErrInvalidToken
+	}
+	cursor, err := strconv.ParseUint(value, 10, 0)
+	if err != nil {
+		return 0, ErrInvalidToken
+	}
+	return uint(cursor), nil
+}
+
+// DetectionFromNote converts a stored note into its wire format
+func DetectionFromNote(note *datastore.Note) Detection {
+	d := Detection{
+		SourceID:          note.ID,
+		Date:              note.Date,
+		Time:              note.Time,
+		BeginTime:         note.BeginTime,
+		EndTime:           note.EndTime,
+		SpeciesCode:       note.SpeciesCode,
+		ScientificName:    note.ScientificName,
+		CommonName:        note.CommonName,
+		Confidence:        note.Confidence,
+		Latitude:          note.Latitude,
+		Longitude:         note.Longitude,
+		Threshold:         note.Threshold,
+		Sensitivity:       note.Sensitivity,
+		ClipName:          note.ClipName,
+		WeatherAdjustment: note.WeatherAdjustment,
+	}
+	for _, r := range note.Results {
+		d.Results = append(d.Results, Result{Species: r.Species, Confidence: r.Confidence})
+	}
+	return d
+}
+
+// batchItem converts a received detection into a datastore batch item
+func (d *Detection) batchItem() datastore.SyncBatchItem {
+	item := datastore.SyncBatchItem{
+		SourceID: d.SourceID,
+		ClipName: d.ClipName,
+		Note: datastore.Note{
+			Date:              d.Date,
+			Time:              d.Time,
+			BeginTime:         d.BeginTime,
+			EndTime:           d.EndTime,
+			SpeciesCode:       d.SpeciesCode,
+			ScientificName:    d.ScientificName,
+			CommonName:        d.CommonName,
+			Confidence:        d.Confidence,
+			Latitude:          d.Latitude,
+			Longitude:         d.Longitude,
+			Threshold:         d.Threshold,
+			Sensitivity:       d.Sensitivity,
+			WeatherAdjustment: d.WeatherAdjustment,
+		},
+	}
+	for _, r := range d.Results {
+		item.Results = append(item.Results, datastore.Results{Species: r.Species, Confidence: r.Confidence})
+	}
+	return item
+}
@@ -0,0 +1,221 @@
+// Package forecast predicts the species likely to be detected in the coming
+// days. The prediction combines the local phenology, the detections of past
+// years around the same dates, with the weekly occurrence probabilities of
+// the BirdNET range filter, which cover species not detected locally yet.
+package forecast
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/birdnet"
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/observation"
+)
+
+const (
+	// seasonPaddingDays widens the dates looked at in past years, as
+	// migration does not happen on the same day every year
+	seasonPaddingDays = 3
+
+	// maxHistoryWeight is the weight of the local phenology once enough years
+	// of detections exist, the range filter makes up the rest
+	maxHistoryWeight = 0.7
+
+	// fullHistoryYears is the number of past years of detections after which
+	// the local phenology gets its full weight
+	fullHistoryYears = 3
+)
+
+// History is the datastore capability needed to learn the local phenology
+type History interface {
+	GetSpeciesSummaryData(ctx context.Context, startDate, endDate string) ([]datastore.SpeciesSummaryData, error)
+}
+
+// RangeFilter gives the occurrence probability of species at the station
+// location in the week of a date, *birdnet.BirdNET implements it
+type RangeFilter interface {
+	GetProbableSpecies(date time.Time, week float32) ([]birdnet.SpeciesScore, error)
+}
+
+// Config holds the forecast parameters
+type Config struct {
+	Days          int     // days ahead covered by the forecast, including today
+	Years         int     // past years the phenology is learned from
+	MinLikelihood float64 // species below this likelihood are left out
+}
+
+// ConfigFromSettings returns the forecast parameters of the configuration
+func ConfigFromSettings(settings *conf.ForecastSettings) Config {
+	return Config{
+		Days:          settings.Days,
+		Years:         settings.Years,
+		MinLikelihood: settings.MinLikelihood,
+	}
+}
+
+// Species is a species expected during the forecast period
+type Species struct {
+	ScientificName   string  `json:"scientificName"`
+	CommonName       string  `json:"commonName"`
+	SpeciesCode      string  `json:"speciesCode,omitempty"`
+	Likelihood       float64 `json:"likelihood"`       // 0-1
+	RangeProbability float64 `json:"rangeProbability"` // highest weekly range filter probability of the period
+	YearsDetected    int     `json:"yearsDetected"`    // past years with detections around the same dates
+	AvgDetections    float64 `json:"avgDetections"`    // average detections per past year around the same dates
+}
+
+// Forecast lists the species expected during a period, most likely first
+type Forecast struct {
+	Start         string    `json:"start"` // first day (YYYY-MM-DD)
+	End           string    `json:"end"`   // last day (YYYY-MM-DD)
+	YearsWithData int       `json:"yearsWithData"`
+	RangeFilter   bool      `json:"rangeFilter"` // false when no range filter probabilities were available
+	Species       []Species `json:"species"`
+}
+
+// Forecaster predicts the species of the coming days
+type Forecaster struct {
+	config      Config
+	history     History
+	rangeFilter RangeFilter
+}
+
+// New creates a forecaster. rangeFilter may be nil, the forecast then relies
+// on the local phenology alone.
+func New(config Config, history History, rangeFilter RangeFilter) *Forecaster {
+	return &Forecaster{config: config, history: history, rangeFilter: rangeFilter}
+}
+
+// species collects the evidence for one species
+type species struct {
+	Species
+	detections int
+}
+
+// Predict returns the forecast of the days starting on the day of now
+func (f *Forecaster) Predict(ctx context.Context, now time.Time) (*Forecast, error) {
+	days := max(f.config.Days, 1)
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	end := start.AddDate(0, 0, days-1)
+	forecast := &Forecast{Start: start.Format(time.DateOnly), End: end.Format(time.DateOnly)}
+
+	bySpecies := make(map[string]*species)
+	get := func(scientific, common, code string) *species {
+		key := strings.ToLower(scientific)
+		s, ok := bySpecies[key]
+		if !ok {
+			s = &species{Species: Species{ScientificName: scientific}}
+			bySpecies[key] = s
+		}
+		s.CommonName = cmp.Or(s.CommonName, common)
+		s.SpeciesCode = cmp.Or(s.SpeciesCode, code)
+		return s
+	}
+
+	for year := 1; year <= max(f.config.Years, 1); year++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		from := start.AddDate(-year, 0, -seasonPaddingDays).Format(time.DateOnly)
+		to := end.AddDate(-year, 0, seasonPaddingDays).Format(time.DateOnly)
+		summaries, err := f.history.GetSpeciesSummaryData(ctx, from, to)
+		if err != nil {
+			return nil, err
+		}
+		if len(summaries) == 0 {
+			continue
+		}
+		forecast.YearsWithData++
+		for i := range summaries {
+			s := get(summaries[i].ScientificName, summaries[i].CommonName, summaries[i].SpeciesCode)
+			s.YearsDetected++
+			s.detections += summaries[i].Count
+		}
+	}
+
+	if f.rangeFilter != nil {
+		ok, err := f.addRangeProbabilities(start, days, get)
+		if err != nil {
+			return nil, err
+		}
+		forecast.RangeFilter = ok
+	}
+
+	forecast.Species = make([]Species, 0, len(bySpecies))
+	for _, s := range bySpecies {
+		s.Likelihood = likelihood(s.YearsDetected, forecast.YearsWithData, s.RangeProbability, forecast.RangeFilter)
+		if s.Likelihood < f.config.MinLikelihood || s.Likelihood == 0 {
+			continue
+		}
+		if forecast.YearsWithData > 0 {
+			s.AvgDetections = float64(s.detections) / float64(forecast.YearsWithData)
+		}
+		forecast.Species = append(forecast.Species, s.Species)
+	}
+	slices.SortFunc(forecast.Species, func(a, b Species) int {
+		return cmp.Or(
+			cmp.Compare(b.Likelihood, a.Likelihood),
+			cmp.Compare(b.AvgDetections, a.AvgDetections),
+			cmp.Compare(a.CommonName, b.CommonName),
+		)
+	})
+	return forecast, nil
+}
+
+// addRangeProbabilities records the highest range filter probability of each
+// species during the period. It reports false when the range filter gave no
+// probabilities, for example because the station location is not set.
+func (f *Forecaster) addRangeProbabilities(start time.Time, days int, get func(scientific, common, code string) *species) (bool, error) {
+	seen := make(map[float32]bool)
+	available := false
+	for day := range days {
+		date := start.AddDate(0, 0, day)
+		week := rangeFilterWeek(date)
+		if seen[week] {
+			continue
+		}
+		seen[week] = true
+
+		scores, err := f.rangeFilter.GetProbableSpecies(date, week)
+		if err != nil {
+			return false, err
+		}
+		for _, score := range scores {
+			if score.Score <= 0 {
+				continue
+			}
+			available = true
+			scientific, common, code := observation.ParseSpeciesString(score.Label)
+			s := get(scientific, common, code)
+			s.RangeProbability = max(s.RangeProbability, min(score.Score, 1))
+		}
+	}
+	return available, nil
+}
+
+// likelihood combines the share of past years a species was detected around
+// the same dates with its range filter probability. The local phenology gets
+// more weight the more years of detections there are.
+func likelihood(yearsDetected, yearsWithData int, rangeProbability float64, rangeFilter bool) float64 {
+	if yearsWithData == 0 {
+		return rangeProbability
+	}
+	history := float64(yearsDetected) / float64(yearsWithData)
+	if !rangeFilter {
+		return history
+	}
+	weight := maxHistoryWeight * float64(min(yearsWithData, fullHistoryYears)) / fullHistoryYears
+	return weight*history + (1-weight)*rangeProbability
+}
+
+// rangeFilterWeek returns the week of a date as numbered for the range filter
+// model, which counts four weeks per month
+func rangeFilterWeek(date time.Time) float32 {
+	weekInMonth := (date.Day()-1)/7 + 1
+	return float32((int(date.Month())-1)*4 + weekInMonth)
+}
@@ -0,0 +1,120 @@
+package forecast
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/birdnet"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+// fakeHistory returns the species detected in the windows starting in given years
+type fakeHistory struct {
+	byYear map[int][]datastore.SpeciesSummaryData
+	ranges [][2]string
+}
+
+func (h *fakeHistory) GetSpeciesSummaryData(ctx context.Context, startDate, endDate string) ([]datastore.SpeciesSummaryData, error) {
+	h.ranges = append(h.ranges, [2]string{startDate, endDate})
+	start, err := time.Parse(time.DateOnly, startDate)
+	if err != nil {
+		return nil, err
+	}
+	return h.byYear[start.Year()], nil
+}
+
+// fakeRangeFilter returns fixed scores and records the weeks asked for
+type fakeRangeFilter struct {
+	scores []birdnet.SpeciesScore
+	weeks  []float32
+}
+
+func (r *fakeRangeFilter) GetProbableSpecies(date time.Time, week float32) ([]birdnet.SpeciesScore, error) {
+	r.weeks = append(r.weeks, week)
+	return r.scores, nil
+}
+
+func TestPredict(t *testing.T) {
+	t.Parallel()
+
+	history := &fakeHistory{byYear: map[int][]datastore.SpeciesSummaryData{
+		2023: {
+			{ScientificName: "Ficedula hypoleuca", CommonName: "European Pied Flycatcher", Count: 12},
+			{ScientificName: "Parus major", CommonName: "Great Tit", Count: 40},
+		},
+		2022: {{ScientificName: "Parus major", CommonName: "Great Tit", Count: 20}},
+	}}
+	rangeFilter := &fakeRangeFilter{scores: []birdnet.SpeciesScore{
+		{Label: "Parus major_Great Tit", Score: 0.9},
+		{Label: "Upupa epops_Eurasian Hoopoe", Score: 0.5},
+		{Label: "Lanius collurio_Red-backed Shrike", Score: 0.1},
+	}}
+	f := New(Config{Days: 7, Years: 3, MinLikelihood: 0.1}, history, rangeFilter)
+
+	now := time.Date(2024, 4, 26, 15, 0, 0, 0, time.UTC)
+	forecast, err := f.Predict(context.Background(), now)
+	require.NoError(t, err)
+
+	assert.Equal(t, "2024-04-26", forecast.Start)
+	assert.Equal(t, "2024-05-02", forecast.End)
+	assert.Equal(t, [2]string{"2023-04-23", "2023-05-05"}, history.ranges[0], "past years are padded around the period")
+	assert.Len(t, history.ranges, 3)
+	assert.Equal(t, 2, forecast.YearsWithData)
+	assert.True(t, forecast.RangeFilter)
+	assert.Equal(t, []float32{16, 17}, rangeFilter.weeks, "each range filter week of the period is asked once")
+
+	names := make([]string, 0, len(forecast.Species))
+	for _, s := range forecast.Species {
+		names = append(names, s.CommonName)
+	}
+	assert.Equal(t, []string{"Great Tit", "Eurasian Hoopoe", "European Pied Flycatcher"}, names,
+		"species below the minimum likelihood are left out")
+
+	tit := forecast.Species[0]
+	assert.Equal(t, 2, tit.YearsDetected)
+	assert.InDelta(t, 30.0, tit.AvgDetections, 1e-9)
+	assert.InDelta(t, 0.9, tit.RangeProbability, 1e-9)
+	weight := maxHistoryWeight * 2 / 3
+	assert.InDelta(t, weight+(1-weight)*0.9, tit.Likelihood, 1e-9)
+}
+
+func TestPredict_WithoutRangeFilter(t *testing.T) {
+	t.Parallel()
+
+	history := &fakeHistory{byYear: map[int][]datastore.SpeciesSummaryData{
+		2023: {{ScientificName: "Parus major", CommonName: "Great Tit", Count: 4}},
+	}}
+	forecast, err := New(Config{Days: 1, Years: 2}, history, nil).Predict(context.Background(), time.Date(2024, 1, 10, 8, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.False(t, forecast.RangeFilter)
+	require.Len(t, forecast.Species, 1)
+	assert.InDelta(t, 1.0, forecast.Species[0].Likelihood, 1e-9)
+}
+
+func TestNotifier_Due(t *testing.T) {
+	t.Parallel()
+
+	n := NewNotifier(nil, time.Monday, 7)
+	monday := time.Date(2024, 4, 29, 6, 0, 0, 0, time.UTC)
+	assert.False(t, n.due(monday), "before the hour")
+	assert.True(t, n.due(monday.Add(time.Hour)))
+	n.lastSent = monday.Format(time.DateOnly)
+	assert.False(t, n.due(monday.Add(2*time.Hour)), "sent once a day")
+	assert.False(t, n.due(monday.AddDate(0, 0, 1).Add(time.Hour)), "only on the weekday")
+}
+
+func TestMessage(t *testing.T) {
+	t.Parallel()
+
+	forecast := &Forecast{Start: "2024-04-29", End: "2024-05-05", Species: []Species{
+		{CommonName: "Great Tit", Likelihood: 0.95},
+		{CommonName: "Eurasian Hoopoe", Likelihood: 0.4},
+		{CommonName: "Red-backed Shrike", Likelihood: 0.2},
+	}}
+	assert.Equal(t, "Birds to expect between 2024-04-29 and 2024-05-05: Great Tit (95%), Eurasian Hoopoe (40%) and 1 more",
+		Message(forecast, 2))
+	assert.Contains(t, Message(&Forecast{Start: "a", End: "b"}, 5), "No species")
+}
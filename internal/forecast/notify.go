@@ -0,0 +1,71 @@
+// notify.go: Weekly notification of the birds to expect
+package forecast
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// notifyCheckInterval is how often the notifier checks whether the weekly
+// forecast is due
+const notifyCheckInterval = 10 * time.Minute
+
+// Notifier predicts the coming days once a week
+type Notifier struct {
+	forecaster *Forecaster
+	weekday    time.Weekday
+	hour       int
+	lastSent   string // date of the last forecast (YYYY-MM-DD)
+
+	now func() time.Time // current time, replaceable in tests
+}
+
+// NewNotifier creates a notifier predicting on weekday from hour on
+func NewNotifier(forecaster *Forecaster, weekday time.Weekday, hour int) *Notifier {
+	return &Notifier{forecaster: forecaster, weekday: weekday, hour: hour, now: time.Now}
+}
+
+// Run calls onForecast with the forecast, or the error predicting it, once a
+// week until ctx is cancelled
+func (n *Notifier) Run(ctx context.Context, onForecast func(*Forecast, error)) {
+	ticker := time.NewTicker(notifyCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if now := n.now(); n.due(now) {
+			n.lastSent = now.Format(time.DateOnly)
+			onForecast(n.forecaster.Predict(ctx, now))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// due reports whether the forecast of the week has not been sent yet
+func (n *Notifier) due(now time.Time) bool {
+	return now.Weekday() == n.weekday && now.Hour() >= n.hour && n.lastSent != now.Format(time.DateOnly)
+}
+
+// Message describes the most likely species of a forecast for a
+// notification, at most limit species
+func Message(forecast *Forecast, limit int) string {
+	if len(forecast.Species) == 0 {
+		return fmt.Sprintf("No species are expected between %s and %s", forecast.Start, forecast.End)
+	}
+	names := make([]string, 0, min(limit, len(forecast.Species)))
+	for i := range forecast.Species[:min(limit, len(forecast.Species))] {
+		s := &forecast.Species[i]
+		names = append(names, fmt.Sprintf("%s (%.0f%%)", s.CommonName, s.Likelihood*100))
+	}
+	message := fmt.Sprintf("Birds to expect between %s and %s: %s", forecast.Start, forecast.End, strings.Join(names, ", "))
+	if more := len(forecast.Species) - len(names); more > 0 {
+		message += fmt.Sprintf(" and %d more", more)
+	}
+	return message
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/markbates/goth/gothic"
+	"github.com/tphakala/birdnet-go/internal/conf"
 	"github.com/tphakala/birdnet-go/internal/security"
 )
 
@@ -90,6 +91,24 @@ func handleGothCallback(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "Authentication failed. See server logs for details.") // More generic user message
 	}
 
+	// OpenID Connect users need to be listed or in a group mapped to a role,
+	// users of the other providers are admins unless listed with a lower role
+	settings := conf.Setting()
+	role := security.RoleAdmin
+	if providerName == security.OIDCProviderName {
+		oidcRole, ok := security.OIDCRole(&settings.Security.OIDCAuth, &user)
+		if !ok {
+			security.LogWarn("OpenID Connect login denied: user not allowed and in no mapped group",
+				"provider", providerName,
+				"user_email", user.Email,
+				"groups_claim", settings.Security.OIDCAuth.GroupsClaim)
+			_ = gothic.Logout(c.Response().Writer, c.Request()) // Drop the provider session
+			return echo.NewHTTPError(http.StatusForbidden, "Your account is not allowed to access this BirdNET-Go instance.")
+		}
+		role = oidcRole
+	}
+	role = security.UserRole(&settings.Security.Roles, &user, role)
+
 	// Log session regeneration attempt (Security relevant: Session Fixation Mitigation)
 	if err := gothic.Logout(c.Response().Writer, c.Request()); err != nil {
 		// Log warning but continue - Use Security Logger
@@ -158,6 +177,21 @@ func handleGothCallback(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, "Session error after social login (code: EMAIL)")
 	}
 
+	if !storeInGothicSession(c, security.RoleSessionKey, role, user.Email, providerName) {
+		security.LogError("Rolling back session due to failure storing role",
+			"provider", providerName,
+			"user_email", user.Email,
+			"key_failed", security.RoleSessionKey,
+		)
+		if err := gothic.Logout(c.Response().Writer, c.Request()); err != nil {
+			security.LogError("Failed to logout session during rollback after role failure",
+				"provider", providerName,
+				"user_email", user.Email,
+				"rollback_error", err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Session error after social login (code: ROLE)")
+	}
+
 	// Optional: Store raw data (Consider logging this via security.LogInfo if enabled)
 	// rawDataKey := fmt.Sprintf("%s_raw", providerName)
 	// if err := gothic.StoreInSession(rawDataKey, user.RawData, request, response); err != nil {
@@ -187,6 +221,7 @@ func handleGothCallback(c echo.Context) error {
 	security.LogInfo("Social login successful, redirecting",
 		"provider", providerName,
 		"user_email", user.Email,
+		"role", role,
 		"redirect_to", redirectURL,
 	)
 
@@ -223,6 +258,8 @@ func (s *Server) handleLoginPage(c echo.Context) error {
 			"BasicEnabled":  s.Settings.Security.BasicAuth.Enabled,
 			"GoogleEnabled": s.Settings.Security.GoogleAuth.Enabled,
 			"GithubEnabled": s.Settings.Security.GithubAuth.Enabled,
+			"OIDCEnabled":   s.Settings.Security.OIDCAuth.Enabled,
+			"OIDCName":      s.Settings.Security.OIDCAuth.Name,
 			"CSRFToken":     c.Get(CSRFContextKey),
 		})
 	}
@@ -313,6 +350,13 @@ func (s *Server) handleLogout(c echo.Context) error {
 			"provider", provider,
 			"error", err.Error())
 	}
+	if err := gothic.StoreInSession(security.RoleSessionKey, "", c.Request(), c.Response()); err != nil {
+		security.LogWarn("Failed to clear session key during logout",
+			"key", security.RoleSessionKey,
+			"user_identifier", userIdentifier,
+			"provider", provider,
+			"error", err.Error())
+	}
 	if err := gothic.StoreInSession("userEmail", "", c.Request(), c.Response()); err != nil { // Clear email too
 		security.LogWarn("Failed to clear session key during logout",
 			"key", "userEmail",
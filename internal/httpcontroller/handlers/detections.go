@@ -188,7 +188,7 @@ func (h *Handlers) Detections(c echo.Context) error {
 		ItemsPerPage:      itemsPerPage,
 		WeatherEnabled:    weatherEnabled,
 		Security: map[string]interface{}{
-			"Enabled":       h.Settings.Security.BasicAuth.Enabled || h.Settings.Security.GoogleAuth.Enabled || h.Settings.Security.GithubAuth.Enabled,
+			"Enabled":       h.Settings.Security.BasicAuth.Enabled || h.Settings.Security.GoogleAuth.Enabled || h.Settings.Security.GithubAuth.Enabled || h.Settings.Security.OIDCAuth.Enabled,
 			"AccessAllowed": h.Server.IsAccessAllowed(c),
 		},
 	}
@@ -391,7 +391,7 @@ func (h *Handlers) RecentDetections(c echo.Context) error {
 		Notes:             notes,
 		DashboardSettings: *h.DashboardSettings,
 		Security: map[string]interface{}{
-			"Enabled":       h.Settings.Security.BasicAuth.Enabled || h.Settings.Security.GoogleAuth.Enabled || h.Settings.Security.GithubAuth.Enabled,
+			"Enabled":       h.Settings.Security.BasicAuth.Enabled || h.Settings.Security.GoogleAuth.Enabled || h.Settings.Security.GithubAuth.Enabled || h.Settings.Security.OIDCAuth.Enabled,
 			"AccessAllowed": h.Server.IsAccessAllowed(c),
 		},
 	}
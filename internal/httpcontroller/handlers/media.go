@@ -22,6 +22,7 @@ import (
 	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/atrest"
 	"github.com/tphakala/birdnet-go/internal/conf"
 	"github.com/tphakala/birdnet-go/internal/imageprovider"
 	"github.com/tphakala/birdnet-go/internal/logging"
@@ -474,9 +475,20 @@ func (h *Handlers) ServeSpectrogram(c echo.Context) error {
 			h.Debug("ServeSpectrogram: released semaphore slot")
 		}()
 
+		// SoX and FFmpeg read the clip directly, decrypt encrypted clips to a temporary file
+		audioPath, cleanup, err := atrest.PlaintextPath(fullPath)
+		if err != nil {
+			logger.Debug("Failed to read audio clip, serving placeholder",
+				slog.String("audio_path", fullPath),
+				slog.String("error", err.Error()),
+			)
+			return serveSpectrogramPlaceholder(c)
+		}
+		defer cleanup()
+
 		// Try to create the spectrogram
 		generationStartTime := time.Now()
-		if err := createSpectrogramWithSoX(fullPath, spectrogramPath, spectrogramWidth); err != nil {
+		if err := createSpectrogramWithSoX(audioPath, spectrogramPath, spectrogramWidth); err != nil {
 			generationDuration := time.Since(generationStartTime)
 			logger.Debug("Spectrogram generation failed, serving placeholder",
 				slog.String("audio_path", fullPath),
@@ -1203,9 +1215,15 @@ func serveFileEfficiently(c echo.Context, filePath string) error {
 		return echo.NewHTTPError(http.StatusForbidden, "Not a regular file")
 	}
 
+	// Encrypted clips are decrypted on the fly
+	content, err := atrest.Decrypting(file)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to read file")
+	}
+
 	// Use http.ServeContent for efficient serving
 	// This handles Range requests, caching, and proper buffer management
-	http.ServeContent(c.Response(), c.Request(), filepath.Base(filePath), stat.ModTime(), file)
+	http.ServeContent(c.Response(), c.Request(), filepath.Base(filePath), stat.ModTime(), content)
 	return nil
 }
 
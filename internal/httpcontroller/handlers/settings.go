@@ -212,7 +212,7 @@ func (h *Handlers) updateAuthenticationSettings(settings *conf.Settings) {
 	basicAuth := &settings.Security.BasicAuth
 
 	// Check if any authentication settings are enabled
-	if !settings.Security.GoogleAuth.Enabled && !settings.Security.GithubAuth.Enabled && !basicAuth.Enabled {
+	if !settings.Security.GoogleAuth.Enabled && !settings.Security.GithubAuth.Enabled && !settings.Security.OIDCAuth.Enabled && !basicAuth.Enabled {
 		return
 	}
 
@@ -229,6 +229,9 @@ func (h *Handlers) updateAuthenticationSettings(settings *conf.Settings) {
 	settings.Security.BasicAuth.RedirectURI = host
 	settings.Security.GoogleAuth.RedirectURI = fmt.Sprintf("%s/auth/google/callback", host)
 	settings.Security.GithubAuth.RedirectURI = fmt.Sprintf("%s/auth/github/callback", host)
+	if settings.Security.OIDCAuth.RedirectURI == "" {
+		settings.Security.OIDCAuth.RedirectURI = fmt.Sprintf("%s/auth/oidc/callback", host)
+	}
 
 	// Generate secrets if they are empty
 	if basicAuth.Enabled {
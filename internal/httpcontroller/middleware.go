@@ -27,14 +27,40 @@ var publicV2ApiPrefixes = map[string]struct{}{
 	"/api/v2/media/audio":         {},
 	"/api/v2/spectrogram":         {},
 	"/api/v2/audio":               {},
+	"/api/v2/media/signed":        {}, // Signed media URLs are verified by their signature
 	"/api/v2/health":              {}, // Health check should always be public
 	"/api/v2/weather":             {}, // Weather endpoints should be public
 }
 
+// unsignedMediaPrefixes are the permanent paths of clips and spectrograms,
+// restricted to authenticated clients when signed media URLs are required
+var unsignedMediaPrefixes = []string{
+	"/api/v1/media/audio",
+	"/api/v1/media/spectrogram",
+	"/api/v2/media/audio",
+	"/api/v2/spectrogram",
+	"/api/v2/audio",
+}
+
+// isUnsignedMediaRoute returns true for the permanent paths of clips and spectrograms
+func isUnsignedMediaRoute(path string) bool {
+	for _, prefix := range unsignedMediaPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
 // configureMiddleware sets up middleware for the server.
 func (s *Server) configureMiddleware() {
 	s.Echo.Use(middleware.Recover())
 
+	// Send security headers when configured, for deployments without a reverse proxy setting them
+	if s.Settings.Security.Headers.Enabled {
+		s.Echo.Use(s.SecurityHeadersMiddleware())
+	}
+
 	// Add Sentry middleware if enabled (before other middleware to catch all errors)
 	if s.Settings.Sentry.Enabled {
 		s.Echo.Use(sentryecho.New(sentryecho.Options{
@@ -69,6 +95,10 @@ func (s *Server) CSRFMiddleware() echo.MiddlewareFunc {
 		TokenLength:  32,
 		ContextKey:   CSRFContextKey,
 		Skipper: func(c echo.Context) bool {
+			// Browsers never send API keys on their own, so requests with a valid one cannot be forged
+			if s.hasValidAPIKey(c) {
+				return true
+			}
 			path := c.Request().URL.Path
 			// Skip CSRF for static assets and auth endpoints only
 			return strings.HasPrefix(path, "/assets/") ||
@@ -79,7 +109,9 @@ func (s *Server) CSRFMiddleware() echo.MiddlewareFunc {
 				strings.HasPrefix(path, "/api/v1/auth/") ||
 				strings.HasPrefix(path, "/api/v1/oauth2/token") ||
 				path == "/api/v1/oauth2/callback" ||
-				path == "/api/v2/auth/login" // Skip CSRF for V2 login endpoint
+				path == "/api/v2/auth/login" || // Skip CSRF for V2 login endpoint
+				strings.HasPrefix(path, "/api/v2/sync/") || // Field units authenticate with bearer tokens, no cookies
+				strings.HasPrefix(path, "/api/v2/chatops/") // Slack and Discord sign their requests
 		},
 		ErrorHandler: func(err error, c echo.Context) error {
 			// Keep the original debug logging for backward compatibility
@@ -102,6 +134,17 @@ func (s *Server) CSRFMiddleware() echo.MiddlewareFunc {
 	return middleware.CSRFWithConfig(config)
 }
 
+// hasValidAPIKey reports whether the request carries a valid API key. Its
+// scopes are checked by the API v2 middleware.
+func (s *Server) hasValidAPIKey(c echo.Context) bool {
+	secret := security.APIKeyFromRequest(c.Request())
+	if secret == "" || s.OAuth2Server == nil || s.OAuth2Server.APIKeys == nil {
+		return false
+	}
+	_, err := s.OAuth2Server.APIKeys.Authenticate(secret)
+	return err == nil
+}
+
 // GzipMiddleware configures Gzip compression for the server
 func (s *Server) GzipMiddleware() echo.MiddlewareFunc {
 	return middleware.GzipWithConfig(middleware.GzipConfig{
@@ -143,7 +186,8 @@ func (s *Server) CacheControlMiddleware() echo.MiddlewareFunc {
 				strings.HasSuffix(path, ".ico"), strings.HasSuffix(path, ".svg"):
 				c.Response().Header().Set("Cache-Control", "public, max-age=604800, immutable")
 			case strings.HasPrefix(path, "/api/v1/media/audio"),
-				strings.HasPrefix(path, "/api/v2/audio"):
+				strings.HasPrefix(path, "/api/v2/audio"),
+				strings.HasPrefix(path, "/api/v2/media/signed/audio"):
 				// iOS Safari requires Accept-Ranges header for audio playback
 				c.Response().Header().Set("Cache-Control", "no-store")
 				c.Response().Header().Set("X-Content-Type-Options", "nosniff")
@@ -244,8 +288,12 @@ func (s *Server) AuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		path := c.Request().URL.Path
 
+		// When signed media URLs are required, clips and spectrograms are only
+		// served to unauthenticated clients through signed URLs
+		signedMediaOnly := s.Settings.Security.SignedMedia.Required && isUnsignedMediaRoute(path)
+
 		// Skip check for non-protected routes
-		if !isProtectedRoute(path) {
+		if !isProtectedRoute(path) && !signedMediaOnly {
 			return next(c)
 		}
 
@@ -253,7 +301,7 @@ func (s *Server) AuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 		// isPublicApiRoute checks both the path prefix and the HTTP method.
 		// Therefore, even for paths matching public prefixes, mutating methods (POST, PUT, DELETE etc.)
 		// will NOT bypass the main authentication check below if authentication is enabled.
-		if isPublicApiRoute(c) {
+		if isPublicApiRoute(c) && !signedMediaOnly {
 			return next(c)
 		}
 
@@ -308,12 +356,38 @@ func (s *Server) AuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 				}
 				return c.Redirect(http.StatusFound, "/login?redirect="+redirectPath)
 			}
+
+			// Editors and viewers may not see the settings, viewers may not change anything
+			if role := security.SessionRole(c.Request()); role != "" {
+				method := c.Request().Method
+				if !security.RoleAllows(role, sessionRouteScope(method, path)) {
+					s.Debug("Client %s has the %s role, denying %s %s", clientIPString, role, method, path)
+					return echo.NewHTTPError(http.StatusForbidden, "Your role does not allow this action")
+				}
+			}
 		}
 
 		return next(c)
 	}
 }
 
+// sessionRouteScope returns the API key scope whose permissions a role needs
+// for a request to the web interface
+func sessionRouteScope(method, path string) security.APIKeyScope {
+	isSafeMethod := method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+	switch {
+	case path == "/settings" || strings.HasPrefix(path, "/settings/") || strings.HasPrefix(path, "/api/v1/settings/"):
+		return security.ScopeSettingsAdmin
+	case isSafeMethod || strings.HasPrefix(path, "/api/v1/audio-stream-hls/"):
+		// Starting and stopping the live stream changes nothing
+		return security.ScopeReadOnly
+	case strings.HasPrefix(path, "/api/v1/detections/"):
+		return security.ScopeDetectionsWrite
+	default:
+		return security.ScopeSettingsAdmin
+	}
+}
+
 // generateETag creates a simple hash-based ETag for a given path
 func generateETag(path string) string {
 	h := sha256.New()
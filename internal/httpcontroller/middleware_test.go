@@ -9,6 +9,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/security"
 )
 
 // TestCacheControlMiddleware_V2AudioHeaders verifies that v2 audio routes
@@ -166,3 +167,75 @@ func TestCacheControlMiddleware_ContentTypePreservation(t *testing.T) {
 	cacheControl := rec.Header().Get("Cache-Control")
 	assert.Equal(t, "no-store", cacheControl, "Cache-Control should still be set")
 }
+
+// TestSignedMediaRoutes verifies which media paths are public when signed
+// media URLs are required
+func TestSignedMediaRoutes(t *testing.T) {
+	t.Parallel()
+
+	for _, path := range []string{"/api/v2/audio/1", "/api/v2/spectrogram/1", "/api/v2/media/audio?id=1", "/api/v1/media/audio/clip.wav"} {
+		assert.True(t, isUnsignedMediaRoute(path), path)
+	}
+	assert.False(t, isUnsignedMediaRoute("/api/v2/media/signed/audio/1"))
+	assert.False(t, isUnsignedMediaRoute("/api/v2/detections"))
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/api/v2/media/signed/audio/1?expires=1&sig=x", http.NoBody)
+	assert.True(t, isPublicApiRoute(e.NewContext(req, httptest.NewRecorder())), "signed URLs are verified by their handler")
+}
+
+func TestSessionRouteScope(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		want   security.APIKeyScope
+	}{
+		{http.MethodGet, "/dashboard", security.ScopeReadOnly},
+		{http.MethodGet, "/settings/main", security.ScopeSettingsAdmin},
+		{http.MethodPost, "/api/v1/settings/save", security.ScopeSettingsAdmin},
+		{http.MethodPost, "/api/v1/audio-stream-hls/mic/start", security.ScopeReadOnly},
+		{http.MethodDelete, "/api/v1/detections/delete", security.ScopeDetectionsWrite},
+		{http.MethodPost, "/api/v1/detections/review", security.ScopeDetectionsWrite},
+		{http.MethodPost, "/api/v1/mqtt/test", security.ScopeSettingsAdmin},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, sessionRouteScope(tt.method, tt.path), "%s %s", tt.method, tt.path)
+	}
+}
+
+func TestCSRFMiddleware_APIKey(t *testing.T) {
+	t.Parallel()
+
+	keys, err := security.NewAPIKeyStore("")
+	require.NoError(t, err)
+	_, secret, err := keys.Create("station", []security.APIKeyScope{security.ScopeDetectionsWrite})
+	require.NoError(t, err)
+
+	s := &Server{
+		Echo:         echo.New(),
+		Settings:     &conf.Settings{},
+		OAuth2Server: &security.OAuth2Server{APIKeys: keys},
+	}
+	handler := s.CSRFMiddleware()(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	post := func(header, value string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/v2/detections/1/review", http.NoBody)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		rec := httptest.NewRecorder()
+		if err := handler(s.Echo.NewContext(req, rec)); err != nil {
+			var httpErr *echo.HTTPError
+			require.ErrorAs(t, err, &httpErr)
+			return httpErr.Code
+		}
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, post(echo.HeaderAuthorization, "Bearer "+secret), "requests with a valid API key need no CSRF token")
+	assert.Equal(t, http.StatusOK, post(security.APIKeyHeader, secret))
+	assert.Equal(t, http.StatusForbidden, post(echo.HeaderAuthorization, "Bearer "+security.APIKeyPrefix+"revoked"))
+	assert.Equal(t, http.StatusForbidden, post("", ""))
+}
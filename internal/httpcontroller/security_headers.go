@@ -0,0 +1,127 @@
+package httpcontroller
+
+import (
+	"crypto/sha512"
+	"encoding/base64"
+	"io"
+	"io/fs"
+	"slices"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/tphakala/birdnet-go/frontend"
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+// defaultContentSecurityPolicy is the policy sent when none is configured.
+// Inline scripts and eval are needed by the HTMX templates and Alpine.js, bird
+// images are loaded from external providers and HLS streams play from blobs.
+const defaultContentSecurityPolicy = "default-src 'self'; " +
+	"script-src 'self' 'unsafe-inline' 'unsafe-eval'; " +
+	"style-src 'self' 'unsafe-inline'; " +
+	"img-src 'self' data: blob: https:; " +
+	"media-src 'self' blob:; " +
+	"worker-src 'self' blob:; " +
+	"connect-src 'self'; " +
+	"font-src 'self' data:; " +
+	"object-src 'none'; " +
+	"base-uri 'self'; " +
+	"form-action 'self'"
+
+// SecurityHeadersMiddleware sets the Content-Security-Policy, HSTS and other
+// security headers configured in security.headers
+func (s *Server) SecurityHeadersMiddleware() echo.MiddlewareFunc {
+	settings := s.Settings.Security.Headers
+	return middleware.SecureWithConfig(middleware.SecureConfig{
+		XSSProtection:         "0", // The legacy XSS auditor is superseded by the CSP
+		ContentTypeNosniff:    "nosniff",
+		XFrameOptions:         xFrameOptions(settings.FrameAncestors),
+		HSTSMaxAge:            settings.HSTSMaxAge,
+		HSTSExcludeSubdomains: !settings.HSTSIncludeSubdomains,
+		HSTSPreloadEnabled:    settings.HSTSPreload,
+		ContentSecurityPolicy: contentSecurityPolicy(&settings),
+		CSPReportOnly:         settings.CSPReportOnly,
+		ReferrerPolicy:        settings.ReferrerPolicy,
+	})
+}
+
+// contentSecurityPolicy returns the configured or default policy with the
+// frame-ancestors directive added unless the policy already has one
+func contentSecurityPolicy(settings *conf.SecurityHeadersSettings) string {
+	policy := strings.TrimSpace(settings.ContentSecurityPolicy)
+	if policy == "" {
+		policy = defaultContentSecurityPolicy
+	}
+	if strings.Contains(policy, "frame-ancestors") {
+		return policy
+	}
+
+	ancestors := "'self'"
+	if len(settings.FrameAncestors) > 0 {
+		ancestors = strings.Join(settings.FrameAncestors, " ")
+	}
+	return strings.TrimSuffix(policy, ";") + "; frame-ancestors " + ancestors
+}
+
+// xFrameOptions returns the X-Frame-Options header for browsers without
+// frame-ancestors support. The header cannot list origins, so it is omitted
+// when other origins may frame the UI.
+func xFrameOptions(frameAncestors []string) string {
+	switch {
+	case len(frameAncestors) == 0, slices.Equal(frameAncestors, []string{"'self'"}):
+		return "SAMEORIGIN"
+	case slices.Equal(frameAncestors, []string{"'none'"}):
+		return "DENY"
+	default:
+		return ""
+	}
+}
+
+// assetIntegrity returns the subresource integrity hash of an embedded static
+// asset, for the integrity attribute of script and stylesheet tags. It returns
+// an empty string, which browsers treat as no integrity check, for unknown
+// assets. Hashes are computed on first use and cached.
+func (s *Server) assetIntegrity(urlPath string) string {
+	urlPath, _, _ = strings.Cut(urlPath, "?")
+	if hash, ok := s.assetHashes.Load(urlPath); ok {
+		return hash.(string)
+	}
+
+	var fsys fs.FS
+	var name string
+	switch {
+	case strings.HasPrefix(urlPath, "/ui/assets/"):
+		fsys, name = frontend.DistFS, strings.TrimPrefix(urlPath, "/ui/assets/")
+	case strings.HasPrefix(urlPath, "/assets/"):
+		fsys, name = AssetsFs, "assets/"+strings.TrimPrefix(urlPath, "/assets/")
+	default:
+		return ""
+	}
+	if fsys == nil {
+		return ""
+	}
+
+	hash, err := subresourceIntegrity(fsys, name)
+	if err != nil {
+		s.Debug("Failed to compute integrity of %s: %v", urlPath, err)
+		return ""
+	}
+	s.assetHashes.Store(urlPath, hash)
+	return hash
+}
+
+// subresourceIntegrity returns the sha384 integrity metadata of a file
+func subresourceIntegrity(fsys fs.FS, name string) (string, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = file.Close() }()
+
+	hash := sha512.New384()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return "sha384-" + base64.StdEncoding.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,139 @@
+package httpcontroller
+
+import (
+	"crypto/sha512"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+// TestSecurityHeadersMiddleware verifies the headers sent for the configured
+// policy, frame ancestors and HSTS settings
+func TestSecurityHeadersMiddleware(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		headers     conf.SecurityHeadersSettings
+		tls         bool
+		wantHeaders map[string]string
+	}{
+		{
+			name:    "defaults",
+			headers: conf.SecurityHeadersSettings{Enabled: true, ReferrerPolicy: "strict-origin-when-cross-origin"},
+			wantHeaders: map[string]string{
+				"Content-Security-Policy":   defaultContentSecurityPolicy + "; frame-ancestors 'self'",
+				"X-Frame-Options":           "SAMEORIGIN",
+				"X-Content-Type-Options":    "nosniff",
+				"Referrer-Policy":           "strict-origin-when-cross-origin",
+				"Strict-Transport-Security": "",
+			},
+		},
+		{
+			name: "custom policy in report-only mode",
+			headers: conf.SecurityHeadersSettings{
+				Enabled:               true,
+				ContentSecurityPolicy: "default-src 'self';",
+				CSPReportOnly:         true,
+				FrameAncestors:        []string{"'self'", "https://ha.example.org"},
+			},
+			wantHeaders: map[string]string{
+				"Content-Security-Policy-Report-Only": "default-src 'self'; frame-ancestors 'self' https://ha.example.org",
+				"Content-Security-Policy":             "",
+				"X-Frame-Options":                     "",
+			},
+		},
+		{
+			name: "policy with its own frame-ancestors",
+			headers: conf.SecurityHeadersSettings{
+				Enabled:               true,
+				ContentSecurityPolicy: "default-src 'self'; frame-ancestors 'none'",
+				FrameAncestors:        []string{"'none'"},
+			},
+			wantHeaders: map[string]string{
+				"Content-Security-Policy": "default-src 'self'; frame-ancestors 'none'",
+				"X-Frame-Options":         "DENY",
+			},
+		},
+		{
+			name:    "HSTS over TLS",
+			headers: conf.SecurityHeadersSettings{Enabled: true, HSTSMaxAge: 31536000, HSTSIncludeSubdomains: true, HSTSPreload: true},
+			tls:     true,
+			wantHeaders: map[string]string{
+				"Strict-Transport-Security": "max-age=31536000; includeSubdomains; preload",
+			},
+		},
+		{
+			name:    "HSTS over TLS without subdomains",
+			headers: conf.SecurityHeadersSettings{Enabled: true, HSTSMaxAge: 600},
+			tls:     true,
+			wantHeaders: map[string]string{
+				"Strict-Transport-Security": "max-age=600",
+			},
+		},
+		{
+			name:    "no HSTS over plain HTTP",
+			headers: conf.SecurityHeadersSettings{Enabled: true, HSTSMaxAge: 600},
+			wantHeaders: map[string]string{
+				"Strict-Transport-Security": "",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			settings := &conf.Settings{}
+			settings.Security.Headers = tt.headers
+			s := &Server{Echo: echo.New(), Settings: settings}
+
+			req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+			if tt.tls {
+				req.Header.Set(echo.HeaderXForwardedProto, "https")
+			}
+			rec := httptest.NewRecorder()
+			handler := s.SecurityHeadersMiddleware()(func(c echo.Context) error {
+				return c.String(http.StatusOK, "ok")
+			})
+			require.NoError(t, handler(s.Echo.NewContext(req, rec)))
+
+			for header, want := range tt.wantHeaders {
+				assert.Equal(t, want, rec.Header().Get(header), header)
+			}
+		})
+	}
+}
+
+// TestSubresourceIntegrity verifies the integrity metadata of an asset
+func TestSubresourceIntegrity(t *testing.T) {
+	t.Parallel()
+
+	content := []byte("console.log('birdnet');")
+	fsys := fstest.MapFS{"assets/util.js": {Data: content}}
+
+	sum := sha512.Sum384(content)
+	got, err := subresourceIntegrity(fsys, "assets/util.js")
+	require.NoError(t, err)
+	assert.Equal(t, "sha384-"+base64.StdEncoding.EncodeToString(sum[:]), got)
+
+	_, err = subresourceIntegrity(fsys, "assets/missing.js")
+	require.Error(t, err)
+}
+
+// TestAssetIntegrity_UnknownAssets verifies that assets outside the embedded
+// file systems get no integrity metadata
+func TestAssetIntegrity_UnknownAssets(t *testing.T) {
+	t.Parallel()
+
+	s := &Server{Echo: echo.New(), Settings: &conf.Settings{}}
+	assert.Empty(t, s.assetIntegrity("https://cdn.example.org/lib.js"))
+	assert.Empty(t, s.assetIntegrity("/assets/does-not-exist.js?v=1.0"))
+}
@@ -9,6 +9,7 @@ import (
 	"net"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/labstack/echo/v4"
@@ -56,6 +57,8 @@ type Server struct {
 	// New structured loggers
 	webLogger      *slog.Logger // Structured logger for web operations
 	webLoggerClose func() error // Function to close the log file
+
+	assetHashes sync.Map // Subresource integrity hashes of static assets by URL path
 }
 
 // New initializes a new HTTP server with given context and datastore.
@@ -230,6 +233,7 @@ func (s *Server) initializeServer() {
 	s.Debug("Setting up SSE broadcaster connection")
 	if s.Processor != nil && s.APIV2 != nil {
 		s.Processor.SetSSEBroadcaster(s.APIV2.BroadcastDetection)
+		s.Processor.SetDetectionStreamer(s.APIV2.PublishCommittedDetection)
 		s.Debug("SSE broadcaster connected to processor")
 	}
 }
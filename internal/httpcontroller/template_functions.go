@@ -51,6 +51,7 @@ func (s *Server) GetTemplateFunctions() template.FuncMap {
 		"timeOfDayToInt":        s.Handlers.TimeOfDayToInt,
 		"getAudioMimeType":      getAudioMimeType,
 		"urlsafe":               urlSafe,
+		"sri":                   s.assetIntegrity,
 		"ffmpegAvailable":       conf.IsFfmpegAvailable,
 		"formatDateTime":        formatDateTime,
 		"getHourlyHeaderData":   getHourlyHeaderData,
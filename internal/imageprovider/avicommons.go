@@ -17,6 +17,7 @@ import (
 const (
 	aviCommonsProviderName = "avicommons"
 	aviCommonsBaseURL      = "https://static.avicommons.org"
+	aviCommonsSiteURL      = "https://avicommons.org"
 	aviCommonsDefaultSize  = "320" // Default image size (e.g., 240, 320, 480, 900)
 )
 
@@ -196,6 +197,7 @@ func (p *AviCommonsProvider) Fetch(scientificName string) (BirdImage, error) {
 		LicenseURL:     licenseURL,
 		AuthorName:     entry.By,
 		AuthorURL:      "",                     // Avicommons doesn't provide author URLs
+		SourceURL:      aviCommonsSiteURL,      // Avicommons doesn't provide per-photo pages
 		SourceProvider: aviCommonsProviderName, // Set the provider name
 		// CachedAt is set by the BirdImageCache
 	}, nil
@@ -77,6 +77,7 @@ type BirdImage struct {
 	LicenseURL     string    // URL to the full license text
 	AuthorName     string    // Name of the image author/photographer
 	AuthorURL      string    // URL to the author's profile or homepage
+	SourceURL      string    // URL of the page the image was published on (e.g., the Wikimedia Commons file page)
 	CachedAt       time.Time // Timestamp when the image was cached
 	SourceProvider string    // Name of the provider that supplied the image (e.g., "wikimedia", "avicommons")
 }
@@ -110,6 +111,7 @@ type BirdImageCache struct {
 	quit         chan struct{}                         // Channel to signal shutdown
 	Initializing sync.Map                              // Track which species are being initialized
 	registry     atomic.Pointer[ImageProviderRegistry] // Use atomic pointer
+	prefetcher   atomic.Pointer[prefetcher]            // Species list prefetch, nil until started
 }
 
 // Package-level logger for image provider related events
@@ -493,6 +495,7 @@ func (c *BirdImageCache) loadFromDBCache(scientificName string) (*BirdImage, err
 		LicenseURL:     cachedImage.LicenseURL,
 		AuthorName:     cachedImage.AuthorName,
 		AuthorURL:      cachedImage.AuthorURL,
+		SourceURL:      cachedImage.SourceURL,
 		CachedAt:       cachedImage.CachedAt,
 		SourceProvider: cachedImage.ProviderName, // Store the original provider
 	}
@@ -531,7 +534,7 @@ func (c *BirdImageCache) batchLoadFromDB(scientificNames []string) (map[string]B
 				logger.Debug("No images found with primary provider, trying fallback providers (policy: all)")
 			}
 			// Try common provider names as fallback
-			fallbackProviders := []string{"avicommons", "wikimedia"}
+			fallbackProviders := []string{LocalBundleProviderName, "avicommons", "wikimedia"}
 			for _, fallbackProvider := range fallbackProviders {
 				if fallbackProvider == c.providerName {
 					continue // Skip our own provider name
@@ -566,6 +569,7 @@ func (c *BirdImageCache) batchLoadFromDB(scientificNames []string) (map[string]B
 			LicenseURL:     dbImage.LicenseURL,
 			AuthorName:     dbImage.AuthorName,
 			AuthorURL:      dbImage.AuthorURL,
+			SourceURL:      dbImage.SourceURL,
 			CachedAt:       dbImage.CachedAt,
 			SourceProvider: dbImage.ProviderName,
 		}
@@ -631,6 +635,7 @@ func (c *BirdImageCache) saveToDB(image *BirdImage) {
 		LicenseURL:     image.LicenseURL,
 		AuthorName:     image.AuthorName,
 		AuthorURL:      image.AuthorURL,
+		SourceURL:      image.SourceURL,
 		CachedAt:       time.Now(), // Update cached timestamp
 	}
 
@@ -690,6 +695,7 @@ func (c *BirdImageCache) loadCachedImages() error {
 			LicenseURL:     entries[i].LicenseURL,
 			AuthorName:     entries[i].AuthorName,
 			AuthorURL:      entries[i].AuthorURL,
+			SourceURL:      entries[i].SourceURL,
 			CachedAt:       entries[i].CachedAt,
 			SourceProvider: entries[i].ProviderName,
 		}
@@ -1097,6 +1103,7 @@ func (img *BirdImage) EstimateSize() int {
 	size += len(img.LicenseURL)
 	size += len(img.AuthorName)
 	size += len(img.AuthorURL)
+	size += len(img.SourceURL)
 	size += len(img.SourceProvider)
 	return size
 }
@@ -0,0 +1,325 @@
+// localbundle.go: Offline species image bundle provider
+package imageprovider
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/errors"
+	"github.com/tphakala/birdnet-go/internal/observability"
+)
+
+const (
+	// LocalBundleProviderName is the registry name of the offline image bundle provider
+	LocalBundleProviderName = "localbundle"
+
+	// BundleImageURLPrefix is the API path bundle images are served from
+	BundleImageURLPrefix = "/api/v2/media/species-image/bundle/"
+
+	bundleManifestName    = "manifest.json"
+	bundleImagesDir       = "images"
+	bundleManifestVersion = 1
+
+	// maxBundleFileSize limits the size of a single file extracted from a bundle tarball
+	maxBundleFileSize = 20 << 20
+
+	// maxBundleExtractSize limits the total size of the files extracted from a bundle tarball
+	maxBundleExtractSize = 2 << 30
+)
+
+// BundleEntry describes one species image in an offline image bundle
+type BundleEntry struct {
+	ScientificName string `json:"scientificName"`
+	SpeciesCode    string `json:"speciesCode,omitempty"`
+	File           string `json:"file"` // Path relative to the bundle root, e.g. "images/eurbla.jpg"
+	AuthorName     string `json:"authorName,omitempty"`
+	AuthorURL      string `json:"authorUrl,omitempty"`
+	LicenseName    string `json:"licenseName,omitempty"`
+	LicenseURL     string `json:"licenseUrl,omitempty"`
+	SourceURL      string `json:"sourceUrl,omitempty"`
+	SourceProvider string `json:"sourceProvider,omitempty"` // Provider the image was originally downloaded from
+}
+
+// BundleManifest is the index stored as manifest.json at the root of an image bundle
+type BundleManifest struct {
+	Version   int           `json:"version"`
+	CreatedAt time.Time     `json:"createdAt"`
+	Entries   []BundleEntry `json:"entries"`
+}
+
+// ImageBundle is a pre-downloaded, species-code indexed set of images used when the
+// instance has no internet access. A bundle is either a directory containing
+// manifest.json and an images/ folder, or a .tar/.tar.gz/.tgz archive of one.
+type ImageBundle struct {
+	root       string
+	extracted  bool // root is a temporary directory extracted from a tarball
+	createdAt  time.Time
+	bySciName  map[string]*BundleEntry
+	byFileName map[string]*BundleEntry
+}
+
+// OpenImageBundle opens an image bundle directory or tarball
+func OpenImageBundle(bundlePath string) (*ImageBundle, error) {
+	opened := false
+	info, err := os.Stat(bundlePath)
+	if err != nil {
+		return nil, bundleError(err, "open_bundle", bundlePath)
+	}
+
+	bundle := &ImageBundle{root: bundlePath}
+	if !info.IsDir() {
+		if !IsBundleTarball(bundlePath) {
+			return nil, errors.Newf("image bundle must be a directory or a .tar, .tar.gz or .tgz archive").
+				Component("imageprovider").
+				Category(errors.CategoryValidation).
+				Context("bundle_path", bundlePath).
+				Build()
+		}
+		tmpDir, err := os.MkdirTemp("", "birdnet-go-imagebundle-*")
+		if err != nil {
+			return nil, bundleError(err, "create_extract_dir", bundlePath)
+		}
+		// Once opened the extracted files are removed by Close
+		defer func() {
+			if !opened {
+				_ = os.RemoveAll(tmpDir)
+			}
+		}()
+		if err := extractBundleTarball(bundlePath, tmpDir, maxBundleExtractSize); err != nil {
+			return nil, err
+		}
+		bundle.root = tmpDir
+		bundle.extracted = true
+	}
+
+	if err := bundle.loadManifest(); err != nil {
+		return nil, err
+	}
+	opened = true
+
+	imageProviderLogger.Info("Opened offline image bundle",
+		"provider", LocalBundleProviderName,
+		"bundle_path", bundlePath,
+		"images", len(bundle.bySciName),
+		"created_at", bundle.createdAt)
+
+	return bundle, nil
+}
+
+// loadManifest reads manifest.json and builds the lookup indexes
+func (b *ImageBundle) loadManifest() error {
+	data, err := os.ReadFile(filepath.Join(b.root, bundleManifestName))
+	if err != nil {
+		return bundleError(err, "read_manifest", b.root)
+	}
+
+	var manifest BundleManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return bundleError(err, "parse_manifest", b.root)
+	}
+	if manifest.Version > bundleManifestVersion {
+		return errors.Newf("unsupported image bundle version %d", manifest.Version).
+			Component("imageprovider").
+			Category(errors.CategoryValidation).
+			Context("bundle_version", manifest.Version).
+			Context("supported_version", bundleManifestVersion).
+			Build()
+	}
+
+	b.createdAt = manifest.CreatedAt
+	b.bySciName = make(map[string]*BundleEntry, len(manifest.Entries))
+	b.byFileName = make(map[string]*BundleEntry, len(manifest.Entries))
+	for i := range manifest.Entries {
+		entry := &manifest.Entries[i]
+		if entry.ScientificName == "" || !isSafeBundlePath(entry.File) {
+			imageProviderLogger.Warn("Skipping invalid image bundle entry",
+				"scientific_name", entry.ScientificName,
+				"file", entry.File)
+			continue
+		}
+		b.bySciName[strings.ToLower(entry.ScientificName)] = entry
+		b.byFileName[path.Base(entry.File)] = entry
+	}
+	return nil
+}
+
+// Fetch implements ImageProvider by looking up the species in the bundle manifest
+func (b *ImageBundle) Fetch(scientificName string) (BirdImage, error) {
+	entry, ok := b.bySciName[strings.ToLower(strings.TrimSpace(scientificName))]
+	if !ok {
+		return BirdImage{}, ErrImageNotFound
+	}
+
+	return BirdImage{
+		URL:            BundleImageURLPrefix + path.Base(entry.File),
+		ScientificName: entry.ScientificName,
+		LicenseName:    entry.LicenseName,
+		LicenseURL:     entry.LicenseURL,
+		AuthorName:     entry.AuthorName,
+		AuthorURL:      entry.AuthorURL,
+		SourceURL:      entry.SourceURL,
+		SourceProvider: LocalBundleProviderName,
+	}, nil
+}
+
+// Len returns the number of species images in the bundle
+func (b *ImageBundle) Len() int {
+	return len(b.bySciName)
+}
+
+// ImagePath returns the filesystem path of a bundle image by file name.
+// Only files listed in the manifest are resolved, which keeps requests
+// from reaching anything else on disk.
+func (b *ImageBundle) ImagePath(fileName string) (string, bool) {
+	entry, ok := b.byFileName[fileName]
+	if !ok {
+		return "", false
+	}
+	return filepath.Join(b.root, filepath.FromSlash(entry.File)), true
+}
+
+// Close removes the temporary extraction directory of a tarball bundle
+func (b *ImageBundle) Close() error {
+	if !b.extracted {
+		return nil
+	}
+	return os.RemoveAll(b.root)
+}
+
+// IsBundleTarball reports whether the path names a supported bundle archive
+func IsBundleTarball(p string) bool {
+	lower := strings.ToLower(p)
+	return strings.HasSuffix(lower, ".tar") || strings.HasSuffix(lower, ".tar.gz") || strings.HasSuffix(lower, ".tgz")
+}
+
+// isSafeBundlePath reports whether a manifest or archive path stays inside the bundle root
+func isSafeBundlePath(p string) bool {
+	if p == "" || path.IsAbs(p) || strings.Contains(p, "\\") {
+		return false
+	}
+	cleaned := path.Clean(p)
+	return cleaned != "." && cleaned != ".." && !strings.HasPrefix(cleaned, "../")
+}
+
+// extractBundleTarball extracts regular files from a bundle archive into dest,
+// failing when they add up to more than maxSize bytes
+func extractBundleTarball(archivePath, dest string, maxSize int64) error {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return bundleError(err, "open_archive", archivePath)
+	}
+	defer func() { _ = f.Close() }()
+
+	var r io.Reader = f
+	if !strings.HasSuffix(strings.ToLower(archivePath), ".tar") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return bundleError(err, "open_gzip", archivePath)
+		}
+		defer func() { _ = gz.Close() }()
+		r = gz
+	}
+
+	tr := tar.NewReader(r)
+	var total int64
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return bundleError(err, "read_archive", archivePath)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := strings.TrimPrefix(hdr.Name, "./")
+		if !isSafeBundlePath(name) {
+			return errors.Newf("image bundle archive contains unsafe path %q", hdr.Name).
+				Component("imageprovider").
+				Category(errors.CategoryValidation).
+				Context("bundle_path", archivePath).
+				Build()
+		}
+		if hdr.Size > maxBundleFileSize {
+			return errors.Newf("image bundle file %q exceeds size limit", hdr.Name).
+				Component("imageprovider").
+				Category(errors.CategoryLimit).
+				Context("bundle_path", archivePath).
+				Context("size", hdr.Size).
+				Build()
+		}
+		total += hdr.Size
+		if total > maxSize {
+			return errors.Newf("image bundle archive exceeds extracted size limit of %d bytes", maxSize).
+				Component("imageprovider").
+				Category(errors.CategoryLimit).
+				Context("bundle_path", archivePath).
+				Build()
+		}
+
+		target := filepath.Join(dest, filepath.FromSlash(path.Clean(name)))
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return bundleError(err, "create_dir", target)
+		}
+		out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+		if err != nil {
+			return bundleError(err, "create_file", target)
+		}
+		_, copyErr := io.Copy(out, io.LimitReader(tr, maxBundleFileSize))
+		closeErr := out.Close()
+		if copyErr != nil {
+			return bundleError(copyErr, "extract_file", target)
+		}
+		if closeErr != nil {
+			return bundleError(closeErr, "extract_file", target)
+		}
+	}
+}
+
+// bundleError wraps a file error from bundle handling
+func bundleError(err error, operation, p string) error {
+	return errors.New(err).
+		Component("imageprovider").
+		Category(errors.CategoryFileIO).
+		Context("provider", LocalBundleProviderName).
+		Context("operation", operation).
+		Context("path", p).
+		Build()
+}
+
+// activeBundle is the bundle served by the API, set when the provider is registered
+var activeBundle atomic.Pointer[ImageBundle]
+
+// GetActiveBundle returns the registered offline image bundle, or nil if none is configured
+func GetActiveBundle() *ImageBundle {
+	return activeBundle.Load()
+}
+
+// RegisterLocalBundleProvider opens the image bundle at bundlePath and registers
+// it with the registry as the "localbundle" provider.
+func RegisterLocalBundleProvider(registry *ImageProviderRegistry, bundlePath string, metrics *observability.Metrics, store datastore.Interface) error {
+	bundle, err := OpenImageBundle(bundlePath)
+	if err != nil {
+		return err
+	}
+
+	if err := registry.Register(LocalBundleProviderName, InitCache(LocalBundleProviderName, bundle, metrics, store)); err != nil {
+		_ = bundle.Close()
+		return err
+	}
+
+	if previous := activeBundle.Swap(bundle); previous != nil {
+		_ = previous.Close()
+	}
+	return nil
+}
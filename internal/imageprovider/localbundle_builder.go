@@ -0,0 +1,280 @@
+// localbundle_builder.go: Builds offline species image bundles from an online provider
+package imageprovider
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// BundleSpecies identifies a species to include in an image bundle
+type BundleSpecies struct {
+	ScientificName string
+	SpeciesCode    string // eBird code used as file name; a sanitized scientific name is used when empty
+}
+
+// BundleBuildOptions configures BuildImageBundle
+type BundleBuildOptions struct {
+	Output   string        // Directory, or .tar/.tar.gz/.tgz archive path
+	Provider ImageProvider // Online provider to fetch image metadata from
+	Species  []BundleSpecies
+	Client   *http.Client // HTTP client for image downloads, a default client is used when nil
+
+	// AppVersion is reported in the User-Agent header as required by Wikimedia
+	AppVersion string
+
+	// Progress is called after each species is processed; err is nil on success
+	Progress func(done, total int, scientificName string, err error)
+}
+
+// BundleBuildReport summarizes a bundle build
+type BundleBuildReport struct {
+	Added    int
+	NotFound int
+	Failed   int
+	Bytes    int64
+}
+
+// bundleDownloadTimeout bounds a single image download
+const bundleDownloadTimeout = 30 * time.Second
+
+// unsafeFileChars matches characters not allowed in generated bundle file names
+var unsafeFileChars = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+// BuildImageBundle fetches images for the given species from an online provider and
+// writes them, together with a manifest carrying attribution, to opts.Output.
+func BuildImageBundle(ctx context.Context, opts *BundleBuildOptions) (*BundleBuildReport, error) {
+	if opts.Provider == nil || opts.Output == "" {
+		return nil, errors.Newf("image bundle build requires a provider and an output path").
+			Component("imageprovider").
+			Category(errors.CategoryValidation).
+			Build()
+	}
+
+	client := opts.Client
+	if client == nil {
+		client = &http.Client{Timeout: bundleDownloadTimeout}
+	}
+
+	// Archives are staged in a temporary directory and packed at the end
+	stageDir := opts.Output
+	asArchive := IsBundleTarball(opts.Output)
+	if asArchive {
+		tmpDir, err := os.MkdirTemp("", "birdnet-go-imagebundle-build-*")
+		if err != nil {
+			return nil, bundleError(err, "create_stage_dir", opts.Output)
+		}
+		defer func() { _ = os.RemoveAll(tmpDir) }()
+		stageDir = tmpDir
+	}
+	if err := os.MkdirAll(filepath.Join(stageDir, bundleImagesDir), 0o755); err != nil {
+		return nil, bundleError(err, "create_dir", stageDir)
+	}
+
+	userAgent := buildUserAgent(opts.AppVersion)
+	manifest := BundleManifest{Version: bundleManifestVersion, CreatedAt: time.Now().UTC()}
+	report := &BundleBuildReport{}
+
+	for i, sp := range opts.Species {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		entry, size, err := addBundleImage(ctx, client, userAgent, opts.Provider, stageDir, sp)
+		switch {
+		case err == nil:
+			manifest.Entries = append(manifest.Entries, *entry)
+			report.Added++
+			report.Bytes += size
+		case errors.Is(err, ErrImageNotFound):
+			report.NotFound++
+		default:
+			report.Failed++
+		}
+
+		if opts.Progress != nil {
+			opts.Progress(i+1, len(opts.Species), sp.ScientificName, err)
+		}
+	}
+
+	data, err := json.MarshalIndent(&manifest, "", "  ")
+	if err != nil {
+		return report, bundleError(err, "encode_manifest", stageDir)
+	}
+	if err := os.WriteFile(filepath.Join(stageDir, bundleManifestName), data, 0o644); err != nil {
+		return report, bundleError(err, "write_manifest", stageDir)
+	}
+
+	if asArchive {
+		if err := writeBundleTarball(stageDir, opts.Output); err != nil {
+			return report, err
+		}
+	}
+
+	return report, nil
+}
+
+// addBundleImage fetches metadata for one species, downloads its image and returns the manifest entry
+func addBundleImage(ctx context.Context, client *http.Client, userAgent string, provider ImageProvider, stageDir string, sp BundleSpecies) (*BundleEntry, int64, error) {
+	img, err := provider.Fetch(sp.ScientificName)
+	if err != nil {
+		return nil, 0, err
+	}
+	if img.URL == "" || img.IsNegativeEntry() {
+		return nil, 0, ErrImageNotFound
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, img.URL, http.NoBody)
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, errors.New(err).
+			Component("imageprovider").
+			Category(errors.CategoryNetwork).
+			Context("operation", "download_bundle_image").
+			Context("url", img.URL).
+			Build()
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, errors.Newf("image download failed with status %d", resp.StatusCode).
+			Component("imageprovider").
+			Category(errors.CategoryImageFetch).
+			Context("url", img.URL).
+			Build()
+	}
+
+	fileName := bundleFileName(sp) + bundleImageExt(img.URL, resp.Header.Get("Content-Type"))
+	relPath := path.Join(bundleImagesDir, fileName)
+
+	out, err := os.Create(filepath.Join(stageDir, filepath.FromSlash(relPath)))
+	if err != nil {
+		return nil, 0, bundleError(err, "create_file", relPath)
+	}
+	size, copyErr := io.Copy(out, io.LimitReader(resp.Body, maxBundleFileSize))
+	if closeErr := out.Close(); copyErr == nil {
+		copyErr = closeErr
+	}
+	if copyErr != nil {
+		return nil, 0, bundleError(copyErr, "write_file", relPath)
+	}
+
+	return &BundleEntry{
+		ScientificName: img.ScientificName,
+		SpeciesCode:    sp.SpeciesCode,
+		File:           relPath,
+		AuthorName:     img.AuthorName,
+		AuthorURL:      img.AuthorURL,
+		LicenseName:    img.LicenseName,
+		LicenseURL:     img.LicenseURL,
+		SourceURL:      img.SourceURL,
+		SourceProvider: img.SourceProvider,
+	}, size, nil
+}
+
+// bundleFileName returns the base file name for a species, preferring the species code
+func bundleFileName(sp BundleSpecies) string {
+	name := strings.ToLower(sp.SpeciesCode)
+	if name == "" {
+		name = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(sp.ScientificName)), " ", "_")
+	}
+	return unsafeFileChars.ReplaceAllString(name, "")
+}
+
+// bundleImageExt picks a file extension from the content type, falling back to the URL
+func bundleImageExt(imageURL, contentType string) string {
+	if contentType != "" {
+		if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+			switch mediaType {
+			case "image/jpeg":
+				return ".jpg"
+			case "image/png":
+				return ".png"
+			case "image/webp":
+				return ".webp"
+			}
+		}
+	}
+	if ext := strings.ToLower(path.Ext(strings.SplitN(imageURL, "?", 2)[0])); ext == ".png" || ext == ".webp" {
+		return ext
+	}
+	return ".jpg"
+}
+
+// writeBundleTarball packs srcDir into a tar archive, gzip compressed unless dest ends in .tar
+func writeBundleTarball(srcDir, dest string) (err error) {
+	f, err := os.Create(dest)
+	if err != nil {
+		return bundleError(err, "create_archive", dest)
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil && closeErr != nil {
+			err = bundleError(closeErr, "close_archive", dest)
+		}
+	}()
+
+	var w io.Writer = f
+	if !strings.HasSuffix(strings.ToLower(dest), ".tar") {
+		gz := gzip.NewWriter(f)
+		defer func() {
+			if closeErr := gz.Close(); err == nil && closeErr != nil {
+				err = bundleError(closeErr, "close_archive", dest)
+			}
+		}()
+		w = gz
+	}
+
+	tw := tar.NewWriter(w)
+	defer func() {
+		if closeErr := tw.Close(); err == nil && closeErr != nil {
+			err = bundleError(closeErr, "close_archive", dest)
+		}
+	}()
+
+	return filepath.WalkDir(srcDir, func(p string, d os.DirEntry, walkErr error) error {
+		if walkErr != nil || d.IsDir() {
+			return walkErr
+		}
+		rel, err := filepath.Rel(srcDir, p)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(rel)
+		if err := tw.WriteHeader(hdr); err != nil {
+			return fmt.Errorf("failed to write archive header for %s: %w", rel, err)
+		}
+		src, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = src.Close() }()
+		_, err = io.Copy(tw, src)
+		return err
+	})
+}
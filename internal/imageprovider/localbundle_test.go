@@ -0,0 +1,205 @@
+package imageprovider
+
+import (
+	"archive/tar"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// bundleTestProvider returns fixed image metadata for known species
+type bundleTestProvider struct {
+	images map[string]BirdImage
+}
+
+func (p *bundleTestProvider) Fetch(scientificName string) (BirdImage, error) {
+	img, ok := p.images[scientificName]
+	if !ok {
+		return BirdImage{}, ErrImageNotFound
+	}
+	return img, nil
+}
+
+// buildTestBundle builds a bundle at output from a local HTTP server
+func buildTestBundle(t *testing.T, output string) *BundleBuildReport {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/jpeg")
+		_, _ = w.Write([]byte("jpeg-data"))
+	}))
+	t.Cleanup(server.Close)
+
+	provider := &bundleTestProvider{images: map[string]BirdImage{
+		"Turdus merula": {
+			URL:            server.URL + "/blackbird.jpg",
+			ScientificName: "Turdus merula",
+			AuthorName:     "Jane Doe",
+			LicenseName:    "CC BY-SA 4.0",
+			SourceURL:      "https://commons.wikimedia.org/wiki/File:Turdus_merula.jpg",
+			SourceProvider: "wikimedia",
+		},
+	}}
+
+	report, err := BuildImageBundle(context.Background(), &BundleBuildOptions{
+		Output:   output,
+		Provider: provider,
+		Client:   server.Client(),
+		Species: []BundleSpecies{
+			{ScientificName: "Turdus merula", SpeciesCode: "eurbla"},
+			{ScientificName: "Parus major"},
+		},
+	})
+	require.NoError(t, err)
+	return report
+}
+
+func TestImageBundleDirectoryRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join(t.TempDir(), "bundle")
+	report := buildTestBundle(t, dir)
+	assert.Equal(t, 1, report.Added)
+	assert.Equal(t, 1, report.NotFound)
+	assert.Equal(t, 0, report.Failed)
+	assert.FileExists(t, filepath.Join(dir, "images", "eurbla.jpg"))
+
+	bundle, err := OpenImageBundle(dir)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = bundle.Close() })
+	assert.Equal(t, 1, bundle.Len())
+
+	img, err := bundle.Fetch("turdus MERULA")
+	require.NoError(t, err)
+	assert.Equal(t, BundleImageURLPrefix+"eurbla.jpg", img.URL)
+	assert.Equal(t, "Jane Doe", img.AuthorName)
+	assert.Equal(t, "CC BY-SA 4.0", img.LicenseName)
+	assert.Equal(t, LocalBundleProviderName, img.SourceProvider)
+
+	_, err = bundle.Fetch("Parus major")
+	require.ErrorIs(t, err, ErrImageNotFound)
+
+	imagePath, ok := bundle.ImagePath("eurbla.jpg")
+	require.True(t, ok)
+	data, err := os.ReadFile(imagePath)
+	require.NoError(t, err)
+	assert.Equal(t, "jpeg-data", string(data))
+
+	// Only files listed in the manifest are served
+	_, ok = bundle.ImagePath("manifest.json")
+	assert.False(t, ok)
+	_, ok = bundle.ImagePath("../manifest.json")
+	assert.False(t, ok)
+}
+
+func TestImageBundleTarballRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	archive := filepath.Join(t.TempDir(), "images.tar.gz")
+	buildTestBundle(t, archive)
+	assert.FileExists(t, archive)
+
+	bundle, err := OpenImageBundle(archive)
+	require.NoError(t, err)
+
+	img, err := bundle.Fetch("Turdus merula")
+	require.NoError(t, err)
+	assert.Equal(t, BundleImageURLPrefix+"eurbla.jpg", img.URL)
+
+	imagePath, ok := bundle.ImagePath("eurbla.jpg")
+	require.True(t, ok)
+	assert.FileExists(t, imagePath)
+
+	// Closing removes the extracted files
+	require.NoError(t, bundle.Close())
+	assert.NoFileExists(t, imagePath)
+}
+
+func TestIsSafeBundlePath(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]bool{
+		"images/eurbla.jpg":      true,
+		"manifest.json":          true,
+		"":                       false,
+		"/etc/passwd":            false,
+		"../outside.jpg":         false,
+		"images/../../escape":    false,
+		"..":                     false,
+		"images\\windows.jpg":    false,
+		"images/./eurbla.jpg":    true,
+		"images/sub/../ok.jpg":   true,
+		"images/../manifest.txt": true,
+	}
+
+	for p, want := range tests {
+		assert.Equal(t, want, isSafeBundlePath(p), p)
+	}
+}
+
+func TestOpenImageBundleRejectsInvalidPath(t *testing.T) {
+	t.Parallel()
+
+	_, err := OpenImageBundle(filepath.Join(t.TempDir(), "missing"))
+	require.Error(t, err)
+
+	file := filepath.Join(t.TempDir(), "bundle.zip")
+	require.NoError(t, os.WriteFile(file, []byte("zip"), 0o600))
+	_, err = OpenImageBundle(file)
+	require.Error(t, err)
+}
+
+// writeTestTarball writes a tar archive of the given files
+func writeTestTarball(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	archive := filepath.Join(t.TempDir(), "bundle.tar")
+	f, err := os.Create(archive)
+	require.NoError(t, err)
+	tw := tar.NewWriter(f)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, f.Close())
+	return archive
+}
+
+func TestExtractBundleTarballSizeLimit(t *testing.T) {
+	t.Parallel()
+
+	archive := writeTestTarball(t, map[string]string{
+		"images/a.jpg": strings.Repeat("a", 600),
+		"images/b.jpg": strings.Repeat("b", 600),
+	})
+
+	require.NoError(t, extractBundleTarball(archive, t.TempDir(), 1200))
+
+	err := extractBundleTarball(archive, t.TempDir(), 1000)
+	var enhancedErr *errors.EnhancedError
+	require.ErrorAs(t, err, &enhancedErr, "files are limited in total, not only one by one")
+	assert.Equal(t, errors.CategoryLimit, enhancedErr.Category)
+}
+
+func TestOpenImageBundleRemovesExtractedFilesOnError(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+
+	archive := writeTestTarball(t, map[string]string{"images/a.jpg": "jpeg-data"})
+	_, err := OpenImageBundle(archive)
+	require.Error(t, err, "the archive has no manifest")
+
+	entries, err := os.ReadDir(tmp)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
@@ -0,0 +1,211 @@
+// prefetch.go: Background pre-fetching of the images of the local species list
+package imageprovider
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	prefetchInterval = 1 * time.Hour          // Check for changes of the species list every hour
+	prefetchDelay    = 500 * time.Millisecond // Delay between fetches from the provider, to spare slow links
+)
+
+// Prefetch results, the label values of the prefetch metrics
+const (
+	PrefetchResultHit     = "hit"     // already in the memory cache
+	PrefetchResultFetched = "fetched" // loaded from the database cache or the provider
+	PrefetchResultFailed  = "failed"  // not found or the fetch failed
+)
+
+// PrefetchProgress is the progress of pre-fetching the species images
+type PrefetchProgress struct {
+	Running    bool      `json:"running"`
+	Total      int       `json:"total"`     // species in the current or last run
+	Done       int       `json:"done"`      // species processed so far
+	CacheHits  int       `json:"cacheHits"` // species already in the cache
+	Fetched    int       `json:"fetched"`
+	Failed     int       `json:"failed"`
+	StartedAt  time.Time `json:"startedAt,omitzero"`
+	FinishedAt time.Time `json:"finishedAt,omitzero"`
+}
+
+// prefetcher warms the image cache with all species of a species list, such
+// as the range filter, instead of fetching each image on its first view
+type prefetcher struct {
+	cache   *BirdImageCache
+	species func() []string // species labels, "Scientific name_Common name"
+	delay   time.Duration
+
+	mu       sync.Mutex
+	progress PrefetchProgress
+	last     []string // scientific names of the last run without failures
+}
+
+// StartPrefetch starts pre-fetching the images of the species returned by
+// species in the background, now and whenever the list changes. Only the
+// first call starts a prefetcher.
+func (c *BirdImageCache) StartPrefetch(species func() []string) {
+	p := &prefetcher{cache: c, species: species, delay: prefetchDelay}
+	if !c.prefetcher.CompareAndSwap(nil, p) {
+		return
+	}
+	imageProviderLogger.Info("Starting species image prefetch", "provider", c.providerName, "interval", prefetchInterval)
+
+	go func() {
+		ticker := time.NewTicker(prefetchInterval)
+		defer ticker.Stop()
+		for {
+			p.run()
+			select {
+			case <-c.quit:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// PrefetchProgress returns the progress of pre-fetching, false when no
+// prefetch was started
+func (c *BirdImageCache) PrefetchProgress() (PrefetchProgress, bool) {
+	p := c.prefetcher.Load()
+	if p == nil {
+		return PrefetchProgress{}, false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.progress, true
+}
+
+// run fetches the images of the species list unless it is unchanged since
+// the last run without failures
+func (p *prefetcher) run() {
+	names := prefetchNames(p.species())
+	p.mu.Lock()
+	if len(names) == 0 || slices.Equal(names, p.last) {
+		p.mu.Unlock()
+		return
+	}
+	p.progress = PrefetchProgress{Running: true, Total: len(names), StartedAt: time.Now()}
+	p.mu.Unlock()
+
+	logger := imageProviderLogger.With("provider", p.cache.providerName)
+	logger.Info("Pre-fetching species images", "species_count", len(names))
+	p.cache.setPrefetchMetrics(0, len(names))
+
+	for i, name := range names {
+		select {
+		case <-p.cache.quit:
+			logger.Info("Species image prefetch stopped", "done", i, "total", len(names))
+			p.finish(false)
+			return
+		default:
+		}
+
+		result := p.fetch(name)
+		p.mu.Lock()
+		p.progress.Done++
+		switch result {
+		case PrefetchResultHit:
+			p.progress.CacheHits++
+		case PrefetchResultFetched:
+			p.progress.Fetched++
+		default:
+			p.progress.Failed++
+		}
+		p.mu.Unlock()
+		p.cache.setPrefetchMetrics(i+1, len(names))
+		if p.cache.metrics != nil {
+			p.cache.metrics.IncrementPrefetchResult(result)
+		}
+
+		// Only fetches go over the network, cache hits need no pause
+		if result != PrefetchResultHit && p.delay > 0 {
+			timer := time.NewTimer(p.delay)
+			select {
+			case <-p.cache.quit:
+				timer.Stop()
+				p.finish(false)
+				return
+			case <-timer.C:
+			}
+		}
+	}
+
+	// Species that failed are tried again on the next check
+	p.mu.Lock()
+	if p.progress.Failed == 0 {
+		p.last = names
+	}
+	progress := p.progress
+	p.mu.Unlock()
+	p.finish(true)
+	logger.Info("Species image prefetch complete",
+		"species_count", progress.Total,
+		"cache_hits", progress.CacheHits,
+		"fetched", progress.Fetched,
+		"failed", progress.Failed,
+		"duration", time.Since(progress.StartedAt))
+}
+
+// finish marks the current run as ended
+func (p *prefetcher) finish(complete bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.progress.Running = false
+	if complete {
+		p.progress.FinishedAt = time.Now()
+	}
+}
+
+// fetch loads the image of a species into the cache and returns the result
+func (p *prefetcher) fetch(scientificName string) string {
+	if p.cache.inMemory(scientificName) {
+		return PrefetchResultHit
+	}
+	if _, err := p.cache.Get(scientificName); err != nil {
+		imageProviderLogger.Debug("Species image prefetch failed",
+			"provider", p.cache.providerName,
+			"scientific_name", scientificName,
+			"error", err)
+		return PrefetchResultFailed
+	}
+	return PrefetchResultFetched
+}
+
+// inMemory reports whether a species has a current entry in the memory cache,
+// a negative entry included
+func (c *BirdImageCache) inMemory(scientificName string) bool {
+	val, ok := c.dataMap.Load(scientificName)
+	if !ok {
+		return false
+	}
+	img, ok := val.(*BirdImage)
+	if !ok || img == nil || img.URL == "" {
+		return false
+	}
+	return !img.CachedAt.Before(time.Now().Add(-img.GetTTL()))
+}
+
+// setPrefetchMetrics updates the prefetch progress gauges
+func (c *BirdImageCache) setPrefetchMetrics(done, total int) {
+	if c.metrics != nil {
+		c.metrics.SetPrefetchProgress(done, total)
+	}
+}
+
+// prefetchNames returns the sorted, unique scientific names of species labels
+func prefetchNames(labels []string) []string {
+	names := make([]string, 0, len(labels))
+	for _, label := range labels {
+		name, _, _ := strings.Cut(label, "_")
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return slices.Compact(names)
+}
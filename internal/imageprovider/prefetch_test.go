@@ -0,0 +1,72 @@
+package imageprovider
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrefetchNames(t *testing.T) {
+	t.Parallel()
+
+	names := prefetchNames([]string{
+		"Turdus merula_Eurasian Blackbird",
+		"Parus major_Great Tit",
+		"Turdus merula_Common Blackbird",
+		"Erithacus rubecula",
+		"_Unknown",
+	})
+	assert.Equal(t, []string{"Erithacus rubecula", "Parus major", "Turdus merula"}, names)
+}
+
+func TestPrefetcherRun(t *testing.T) {
+	provider := &bundleTestProvider{images: map[string]BirdImage{
+		"Turdus merula": {URL: "https://example.com/blackbird.jpg", ScientificName: "Turdus merula"},
+		"Parus major":   {URL: "https://example.com/great-tit.jpg", ScientificName: "Parus major"},
+	}}
+	cache := InitCache("prefetch-test", provider, nil, nil)
+	t.Cleanup(func() { _ = cache.Close() })
+
+	// One species is already cached, one is fetched and one is unknown
+	_, err := cache.Get("Turdus merula")
+	require.NoError(t, err)
+
+	species := []string{"Turdus merula_Eurasian Blackbird", "Parus major_Great Tit", "Avis ignota_Unknown Bird"}
+	p := &prefetcher{cache: cache, species: func() []string { return species }}
+	require.True(t, cache.prefetcher.CompareAndSwap(nil, p))
+
+	p.run()
+	progress, ok := cache.PrefetchProgress()
+	require.True(t, ok)
+	assert.False(t, progress.Running)
+	assert.Equal(t, 3, progress.Total)
+	assert.Equal(t, 3, progress.Done)
+	assert.Equal(t, 1, progress.CacheHits)
+	assert.Equal(t, 1, progress.Fetched)
+	assert.Equal(t, 1, progress.Failed)
+	assert.False(t, progress.FinishedAt.IsZero())
+	assert.True(t, cache.inMemory("Parus major"), "fetched images are kept in the cache")
+
+	// Runs with failures are repeated, now all known species are hits
+	species = species[:2]
+	p.run()
+	progress, _ = cache.PrefetchProgress()
+	assert.Equal(t, 2, progress.CacheHits)
+	assert.Zero(t, progress.Fetched)
+	assert.Zero(t, progress.Failed)
+
+	// An unchanged list without failures is not fetched again
+	finished := progress.FinishedAt
+	p.run()
+	progress, _ = cache.PrefetchProgress()
+	assert.Equal(t, finished, progress.FinishedAt)
+}
+
+func TestPrefetchProgressNotStarted(t *testing.T) {
+	t.Parallel()
+
+	cache := &BirdImageCache{}
+	_, ok := cache.PrefetchProgress()
+	assert.False(t, ok)
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/k3a/html2text"
 	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/databudget"
 	"github.com/tphakala/birdnet-go/internal/errors"
 	"golang.org/x/net/html"
 	"golang.org/x/time/rate"
@@ -27,6 +28,7 @@ import (
 const (
 	wikiProviderName = "wikimedia"
 	wikipediaAPIURL  = "https://en.wikipedia.org/w/api.php"
+	commonsFileURL   = "https://commons.wikimedia.org/wiki/File:"
 
 	// User-Agent constants following Wikimedia robot policy
 	// https://foundation.wikimedia.org/wiki/Policy:Wikimedia_Foundation_User-Agent_Policy
@@ -419,7 +421,7 @@ func (l *LazyWikiMediaProvider) FetchWithContext(ctx context.Context, scientific
 // for UI discovery while preventing unnecessary cache operations when disabled.
 func (l *LazyWikiMediaProvider) ShouldRefreshCache() bool {
 	settings := conf.Setting()
-	if settings == nil {
+	if settings == nil || conf.IsOffline() {
 		return false
 	}
 
@@ -819,12 +821,12 @@ func NewWikiMediaProvider() (*wikiMediaProvider, error) {
 	// Create HTTP client with reasonable timeouts
 	httpClient := &http.Client{
 		Timeout: httpClientTimeout,
-		Transport: &http.Transport{
+		Transport: databudget.Transport(databudget.Images, &http.Transport{
 			MaxIdleConns:        httpClientMaxIdleConns,
 			IdleConnTimeout:     httpClientIdleConnTimeout,
 			DisableCompression:  false, // Allow gzip compression
 			TLSHandshakeTimeout: httpClientTLSTimeout,
-		},
+		}),
 	}
 
 	// Global rate limiting for ALL Wikipedia requests to respect their API limits
@@ -1133,6 +1135,13 @@ func (l *wikiMediaProvider) queryAndGetFirstPageWithLimiter(ctx context.Context,
 // based on the current configuration. This prevents unnecessary API calls to Wikipedia
 // when the provider is not configured for use.
 func (l *wikiMediaProvider) isAllowedToFetch() (allowed bool, reason string) {
+	if conf.IsOffline() {
+		return false, "offline mode"
+	}
+	if !databudget.Allow(databudget.Images) {
+		return false, "data budget exceeded"
+	}
+
 	settings := conf.Setting()
 	if settings == nil {
 		// If settings are not available, allow for backward compatibility
@@ -1280,6 +1289,7 @@ func (l *wikiMediaProvider) fetchWithLimiter(ctx context.Context, scientificName
 		AuthorURL:      authorInfo.URL,
 		LicenseName:    authorInfo.licenseName,
 		LicenseURL:     authorInfo.licenseURL,
+		SourceURL:      wikiMediaFilePageURL(thumbnailSourceFile),
 		SourceProvider: wikiProviderName, // Set the provider name
 	}
 
@@ -1364,6 +1374,15 @@ func (l *wikiMediaProvider) queryThumbnail(ctx context.Context, reqID, scientifi
 	return thumbnailURL, fileName, nil
 }
 
+// wikiMediaFilePageURL returns the Wikimedia Commons description page for an image file.
+// The page carries the full license terms and must be linked when the image is displayed.
+func wikiMediaFilePageURL(fileName string) string {
+	if fileName == "" {
+		return ""
+	}
+	return commonsFileURL + url.PathEscape(strings.ReplaceAll(fileName, " ", "_"))
+}
+
 // queryAuthorInfo queries Wikipedia for the author information of the given thumbnail URL.
 // It returns a wikiMediaAuthor struct containing the author and license information.
 func (l *wikiMediaProvider) queryAuthorInfo(ctx context.Context, reqID, thumbnailFileName string, limiter *rate.Limiter) (*wikiMediaAuthor, error) {
@@ -0,0 +1,107 @@
+package loadtest
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+func TestConfigValidateDefaults(t *testing.T) {
+	t.Parallel()
+
+	cfg := Config{}
+	require.NoError(t, cfg.Validate())
+	assert.InDelta(t, DefaultRate, cfg.Rate, 0.0001)
+	assert.Equal(t, DefaultDuration, cfg.Duration)
+	assert.Equal(t, DefaultConcurrency, cfg.Concurrency)
+	assert.NotEmpty(t, cfg.Species)
+	assert.NotZero(t, cfg.Seed)
+}
+
+func TestConfigValidateRejectsExcessiveRate(t *testing.T) {
+	t.Parallel()
+
+	cfg := Config{Rate: maxRate + 1}
+	require.Error(t, cfg.Validate())
+}
+
+func TestNewGeneratorRequiresSink(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewGenerator(Config{}, nil)
+	require.Error(t, err)
+}
+
+func TestGeneratorRun(t *testing.T) {
+	t.Parallel()
+
+	var saved atomic.Int64
+	sink := SinkFunc(func(_ context.Context, note *datastore.Note, results []datastore.Results) error {
+		assert.Equal(t, sourceNodeName, note.SourceNode)
+		assert.Len(t, results, 1)
+		saved.Add(1)
+		return nil
+	})
+
+	gen, err := NewGenerator(Config{Rate: 200, Duration: 200 * time.Millisecond, Seed: 1}, sink)
+	require.NoError(t, err)
+
+	report := gen.Run(t.Context())
+	assert.Positive(t, report.Succeeded)
+	assert.Equal(t, saved.Load(), report.Succeeded)
+	assert.Zero(t, report.Failed)
+	assert.Positive(t, report.Throughput)
+	assert.LessOrEqual(t, report.LatencyP50, report.LatencyP99)
+	assert.LessOrEqual(t, report.LatencyP99, report.LatencyMax)
+}
+
+func TestGeneratorRecordsFailures(t *testing.T) {
+	t.Parallel()
+
+	sinkErr := errors.New("disk full")
+	sink := SinkFunc(func(context.Context, *datastore.Note, []datastore.Results) error {
+		return sinkErr
+	})
+
+	gen, err := NewGenerator(Config{Rate: 100, Duration: 100 * time.Millisecond, Seed: 1}, sink)
+	require.NoError(t, err)
+
+	report := gen.Run(t.Context())
+	assert.Zero(t, report.Succeeded)
+	assert.Equal(t, report.Submitted, report.Failed)
+	assert.Equal(t, "disk full", report.FirstError)
+}
+
+func TestPercentile(t *testing.T) {
+	t.Parallel()
+
+	sorted := make([]time.Duration, 100)
+	for i := range sorted {
+		sorted[i] = time.Duration(i+1) * time.Millisecond
+	}
+
+	tests := []struct {
+		name string
+		p    int
+		want time.Duration
+	}{
+		{"p50", 50, 50 * time.Millisecond},
+		{"p95", 95, 95 * time.Millisecond},
+		{"p99", 99, 99 * time.Millisecond},
+		{"p100", 100, 100 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, percentile(sorted, tt.p))
+		})
+	}
+
+	assert.Zero(t, percentile(nil, 50))
+}
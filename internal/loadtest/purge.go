@@ -0,0 +1,47 @@
+package loadtest
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+// purgeBatchSize is the number of synthetic detections looked up at a time when purging
+const purgeBatchSize = 500
+
+// PurgeStore finds and deletes detections; implemented by the SQLite and MySQL stores
+type PurgeStore interface {
+	QueryNotes(ctx context.Context, query *datastore.NoteQuery, limit, offset int) ([]datastore.Note, int64, error)
+	Delete(id string) error
+}
+
+// Purge deletes the synthetic detections, marked by their source node, from a
+// database and returns how many were deleted. Detections are deleted through the datastore so their results
+// and daily aggregates are updated like for any other deletion.
+func Purge(ctx context.Context, store PurgeStore) (int, error) {
+	query, err := datastore.ParseNoteQuery("source:" + sourceNodeName)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return deleted, err
+		}
+		notes, _, err := store.QueryNotes(ctx, query, purgeBatchSize, 0)
+		if err != nil {
+			return deleted, err
+		}
+		if len(notes) == 0 {
+			return deleted, nil
+		}
+		for i := range notes {
+			if err := store.Delete(strconv.FormatUint(uint64(notes[i].ID), 10)); err != nil {
+				return deleted, err
+			}
+			deleted++
+		}
+	}
+}
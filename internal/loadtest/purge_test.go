@@ -0,0 +1,45 @@
+package loadtest
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+func TestPurgeDeletesOnlySyntheticDetections(t *testing.T) {
+	t.Parallel()
+
+	settings := &conf.Settings{}
+	settings.Output.SQLite.Enabled = true
+	settings.Output.SQLite.Path = filepath.Join(t.TempDir(), "loadtest.db")
+	ds := datastore.New(settings)
+	require.NoError(t, ds.Open())
+	t.Cleanup(func() { _ = ds.Close() })
+
+	gen, err := NewGenerator(Config{Seed: 1}, NewPipelineSink(ds, nil))
+	require.NoError(t, err)
+	for range 3 {
+		note := gen.newNote(time.Now())
+		require.NoError(t, ds.Save(note, []datastore.Results{{Species: note.ScientificName, Confidence: 0.9}}))
+	}
+	garden := &datastore.Note{SourceNode: "garden", Date: "2026-05-01", Time: "06:00:00",
+		ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", Confidence: 0.8}
+	require.NoError(t, ds.Save(garden, nil))
+
+	store, ok := ds.(PurgeStore)
+	require.True(t, ok)
+	deleted, err := Purge(context.Background(), store)
+	require.NoError(t, err)
+	assert.Equal(t, 3, deleted)
+
+	notes, err := ds.GetAllNotes()
+	require.NoError(t, err)
+	require.Len(t, notes, 1)
+	assert.Equal(t, "garden", notes[0].SourceNode)
+}
@@ -0,0 +1,47 @@
+package loadtest
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/notification"
+)
+
+// PipelineSink persists synthetic detections through the datastore and optionally
+// publishes detection notifications, mirroring the realtime DatabaseAction path.
+// Outbound integrations (MQTT, BirdWeather, push providers) are never invoked.
+type PipelineSink struct {
+	ds       datastore.Interface
+	notifier *notification.Service
+}
+
+// NewPipelineSink creates a sink writing to ds. When notifier is non-nil each
+// stored detection also creates an in-app detection notification on it. Callers
+// should pass a sandboxed service rather than the global one so that load test
+// notifications never reach push providers.
+func NewPipelineSink(ds datastore.Interface, notifier *notification.Service) *PipelineSink {
+	return &PipelineSink{ds: ds, notifier: notifier}
+}
+
+// Submit stores the detection and publishes the notification if enabled
+func (s *PipelineSink) Submit(ctx context.Context, note *datastore.Note, results []datastore.Results) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := s.ds.Save(note, results); err != nil {
+		return err
+	}
+
+	if s.notifier != nil {
+		title := fmt.Sprintf("Synthetic detection: %s", note.CommonName)
+		message := fmt.Sprintf("%s (%s) detected with %.0f%% confidence",
+			note.CommonName, note.ScientificName, note.Confidence*100)
+		if _, err := s.notifier.CreateWithComponent(notification.TypeDetection, notification.PriorityLow,
+			title, message, sourceNodeName); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
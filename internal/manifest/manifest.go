@@ -0,0 +1,123 @@
+// Package manifest keeps a manifest of BirdWeather uploads, backups and
+// exports with the SHA-256 checksum, size and destination of what was sent,
+// for research users who need to show that data was not altered after it left
+// the station.
+//
+// Entries with a local copy, such as backups in a local target, can be
+// verified again later. Copies downloaded from remote destinations are checked
+// by looking up their checksum with FindFile.
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/errors"
+	"github.com/tphakala/birdnet-go/internal/logging"
+)
+
+// Kinds of manifest entries
+const (
+	KindBirdWeather = "birdweather" // soundscape uploaded to BirdWeather
+	KindBackup      = "backup"      // backup archive stored in a backup target
+	KindExport      = "export"      // zip file of an export
+)
+
+// Verification statuses of manifest entries
+const (
+	StatusOK       = "ok"       // the local copy matches the checksum
+	StatusMismatch = "mismatch" // the local copy no longer matches the checksum
+	StatusMissing  = "missing"  // the local copy no longer exists
+	StatusRemote   = "remote"   // only stored remotely, check a downloaded copy with FindFile
+)
+
+// Store is the datastore capability of keeping the manifest; implemented by
+// datastore.DataStore
+type Store interface {
+	SaveManifestEntry(entry *datastore.ManifestEntry) error
+	ListManifestEntries(filter *datastore.ManifestFilter) ([]datastore.ManifestEntry, error)
+	UpdateManifestVerification(id uint, status string, at time.Time) error
+}
+
+var (
+	mu    sync.RWMutex
+	store Store // nil when the manifest is disabled
+)
+
+// SetStore installs the process-wide manifest store, nil disables recording
+func SetStore(s Store) {
+	mu.Lock()
+	defer mu.Unlock()
+	store = s
+}
+
+// Enabled reports whether uploads and exports are recorded, so callers can
+// skip computing checksums otherwise
+func Enabled() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return store != nil
+}
+
+// Record adds an upload or export to the manifest, stamped with the current
+// time when its creation time is zero. It does nothing when the manifest is
+// disabled. Failures are logged rather than returned, as the upload itself
+// succeeded.
+func Record(entry *datastore.ManifestEntry) {
+	mu.RLock()
+	s := store
+	mu.RUnlock()
+	if s == nil {
+		return
+	}
+
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	if err := s.SaveManifestEntry(entry); err != nil {
+		if logger := logging.ForService("manifest"); logger != nil {
+			logger.Warn("Failed to record manifest entry",
+				"kind", entry.Kind,
+				"name", entry.Name,
+				"destination", entry.Destination,
+				"error", err)
+		}
+	}
+}
+
+// Checksum returns the hex encoded SHA-256 checksum of data
+func Checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// ChecksumFile returns the hex encoded SHA-256 checksum and the size of a file
+func ChecksumFile(path string) (sum string, size int64, err error) {
+	f, err := os.Open(path) //nolint:gosec // G304: paths of uploads and exports written by BirdNET-Go or given on the command line
+	if err != nil {
+		return "", 0, fileError(err, "open_file", path)
+	}
+	defer func() { _ = f.Close() }()
+
+	h := sha256.New()
+	size, err = io.Copy(h, f)
+	if err != nil {
+		return "", 0, fileError(err, "checksum_file", path)
+	}
+	return hex.EncodeToString(h.Sum(nil)), size, nil
+}
+
+// fileError wraps a failure to read a file of the manifest
+func fileError(err error, operation, path string) error {
+	return errors.New(err).
+		Component("manifest").
+		Category(errors.CategoryFileIO).
+		Context("operation", operation).
+		Context("path", path).
+		Build()
+}
@@ -0,0 +1,103 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newTestStore(t *testing.T) *datastore.DataStore {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&datastore.ManifestEntry{}))
+	return &datastore.DataStore{DB: db}
+}
+
+func TestChecksum(t *testing.T) {
+	t.Parallel()
+
+	const abc = "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"
+	assert.Equal(t, abc, Checksum([]byte("abc")))
+
+	path := filepath.Join(t.TempDir(), "abc.txt")
+	require.NoError(t, os.WriteFile(path, []byte("abc"), 0o600))
+	sum, size, err := ChecksumFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, abc, sum)
+	assert.Equal(t, int64(3), size)
+
+	_, _, err = ChecksumFile(filepath.Join(t.TempDir(), "missing"))
+	require.Error(t, err)
+}
+
+func TestRecordAndVerify(t *testing.T) {
+	// Not parallel: the manifest store is process-wide
+	s := newTestStore(t)
+	dir := t.TempDir()
+
+	// Nothing is recorded while disabled
+	Record(&datastore.ManifestEntry{Kind: KindExport, SHA256: Checksum([]byte("x"))})
+	SetStore(s)
+	t.Cleanup(func() { SetStore(nil) })
+	require.True(t, Enabled())
+
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+	intact := write("intact.tar.gz", "backup")
+	changed := write("changed.zip", "export")
+	missing := write("missing.zip", "export")
+	for _, path := range []string{intact, changed, missing} {
+		sum, size, err := ChecksumFile(path)
+		require.NoError(t, err)
+		Record(&datastore.ManifestEntry{Kind: KindBackup, Name: filepath.Base(path), Destination: "local", Path: path, SHA256: sum, Size: size})
+	}
+	Record(&datastore.ManifestEntry{Kind: KindBirdWeather, Name: "soundscape", Destination: "birdweather:soundscape/1", SHA256: Checksum([]byte("flac")), Size: 4})
+	write("changed.zip", "tampered")
+	require.NoError(t, os.Remove(missing))
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	results, err := Verify(s, nil, now)
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+	statuses := map[string]string{}
+	for _, r := range results {
+		statuses[r.Entry.Name] = r.Status
+	}
+	assert.Equal(t, map[string]string{
+		"intact.tar.gz": StatusOK,
+		"changed.zip":   StatusMismatch,
+		"missing.zip":   StatusMissing,
+		"soundscape":    StatusRemote,
+	}, statuses)
+
+	entries, err := s.ListManifestEntries(&datastore.ManifestFilter{Kind: KindBackup})
+	require.NoError(t, err)
+	for i := range entries {
+		require.NotNil(t, entries[i].VerifiedAt, entries[i].Name)
+		assert.Equal(t, statuses[entries[i].Name], entries[i].VerifyStatus)
+	}
+
+	// A downloaded copy is found by its checksum
+	copied := write("copy.tar.gz", "backup")
+	sum, found, err := FindFile(s, copied)
+	require.NoError(t, err)
+	assert.Equal(t, Checksum([]byte("backup")), sum)
+	require.Len(t, found, 1)
+	assert.Equal(t, "intact.tar.gz", found[0].Name)
+
+	_, found, err = FindFile(s, changed)
+	require.NoError(t, err)
+	assert.Empty(t, found, "altered files match no entry")
+}
@@ -0,0 +1,73 @@
+package manifest
+
+import (
+	"os"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// Result is the outcome of verifying a manifest entry
+type Result struct {
+	Entry  datastore.ManifestEntry
+	Status string
+	Actual string // checksum of the local copy when it does not match
+	Err    error  // failure to read the local copy
+}
+
+// Verify re-checks the entries matching filter against their local copies
+// and records the outcome of each in the manifest
+func Verify(s Store, filter *datastore.ManifestFilter, now time.Time) ([]Result, error) {
+	entries, err := s.ListManifestEntries(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]Result, 0, len(entries))
+	for i := range entries {
+		result := verifyEntry(&entries[i])
+		if err := s.UpdateManifestVerification(entries[i].ID, result.Status, now); err != nil {
+			return results, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// verifyEntry compares the checksum of the local copy of an entry with the
+// recorded one
+func verifyEntry(entry *datastore.ManifestEntry) Result {
+	result := Result{Entry: *entry}
+	if entry.Path == "" {
+		result.Status = StatusRemote
+		return result
+	}
+
+	sum, size, err := ChecksumFile(entry.Path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		result.Status = StatusMissing
+	case err != nil:
+		result.Status = StatusMismatch
+		result.Err = err
+	case sum != entry.SHA256 || size != entry.Size:
+		result.Status = StatusMismatch
+		result.Actual = sum
+	default:
+		result.Status = StatusOK
+	}
+	return result
+}
+
+// FindFile returns the checksum of a file, such as a downloaded export or a
+// backup copied back from a remote target, and the manifest entries recorded
+// with it. No entries means the file is not the one that was sent.
+func FindFile(s Store, path string) (sum string, entries []datastore.ManifestEntry, err error) {
+	sum, _, err = ChecksumFile(path)
+	if err != nil {
+		return "", nil, err
+	}
+	entries, err = s.ListManifestEntries(&datastore.ManifestFilter{SHA256: sum})
+	return sum, entries, err
+}
@@ -0,0 +1,67 @@
+package mediaurl
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+// parseSigned splits a signed URL into its kind, ID, expiry and signature
+func parseSigned(t *testing.T, signed string) (kind, id, expires, sig string) {
+	t.Helper()
+	u, err := url.Parse(signed)
+	require.NoError(t, err)
+	parts := strings.Split(strings.TrimPrefix(u.Path, PathPrefix+"/"), "/")
+	require.Len(t, parts, 2)
+	return parts[0], parts[1], u.Query().Get("expires"), u.Query().Get("sig")
+}
+
+func TestSignAndVerify(t *testing.T) {
+	t.Parallel()
+
+	signer, err := NewSigner("session-secret", 0)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultTTL, signer.TTL())
+
+	signed, expiresAt := signer.Sign(KindAudio, "42", 10*time.Minute)
+	assert.True(t, strings.HasPrefix(signed, PathPrefix+"/audio/42?"))
+	assert.WithinDuration(t, time.Now().Add(10*time.Minute), expiresAt, 2*time.Second)
+
+	kind, id, expires, sig := parseSigned(t, signed)
+	require.NoError(t, signer.Verify(kind, id, expires, sig, time.Now()))
+
+	// The signature covers the kind, ID and expiry
+	require.ErrorIs(t, signer.Verify(KindSpectrogram, id, expires, sig, time.Now()), ErrInvalidSignature)
+	require.ErrorIs(t, signer.Verify(kind, "43", expires, sig, time.Now()), ErrInvalidSignature)
+	require.ErrorIs(t, signer.Verify(kind, id, expires+"0", sig, time.Now()), ErrInvalidSignature)
+	require.ErrorIs(t, signer.Verify(kind, id, expires, "", time.Now()), ErrInvalidSignature)
+
+	require.ErrorIs(t, signer.Verify(kind, id, expires, sig, time.Now().Add(11*time.Minute)), ErrExpired)
+
+	// A different secret does not accept the URL
+	other, err := NewSigner("other-secret", 0)
+	require.NoError(t, err)
+	require.ErrorIs(t, other.Verify(kind, id, expires, sig, time.Now()), ErrInvalidSignature)
+}
+
+func TestFromSettings(t *testing.T) {
+	t.Parallel()
+
+	assert.Nil(t, FromSettings(nil))
+	assert.Nil(t, FromSettings(&conf.Settings{}), "signing needs a session secret")
+
+	settings := &conf.Settings{}
+	settings.Security.SessionSecret = "session-secret"
+	settings.Security.SignedMedia.TTL = 15
+	signer := FromSettings(settings)
+	require.NotNil(t, signer)
+	assert.Equal(t, 15*time.Minute, signer.TTL())
+
+	_, expiresAt := signer.Sign(KindSpectrogram, "1", 0)
+	assert.WithinDuration(t, time.Now().Add(15*time.Minute), expiresAt, 2*time.Second)
+}
@@ -356,6 +356,10 @@ func WriteToAnalysisBuffer(sourceID string, data []byte) error {
 		}
 	}
 
+	// Track coverage, level and clipping for the data quality report
+	recordAudioQuality(sourceID, data, start)
+	recordCalibrationTone(sourceID, data, start)
+
 	// Write data to the ring buffer with retry logic
 	var lastErr error
 	var n int
@@ -1,7 +1,6 @@
 package myaudio
 
 import (
-	"encoding/binary"
 	"sync"
 	"time"
 
@@ -27,6 +26,24 @@ var (
 	ErrIntervalIncomplete = errors.Newf("audio interval window not yet complete").Component("myaudio").Category(errors.CategoryNotFound).Build()
 )
 
+// filterScratchPool holds float64 buffers for filtering, reused between
+// chunks to avoid allocating a buffer for every chunk of every source
+var filterScratchPool = sync.Pool{
+	New: func() any { return new([]float64) },
+}
+
+// getFilterScratch returns a pooled buffer with capacity for size samples
+func getFilterScratch(size int) *[]float64 {
+	buf, ok := filterScratchPool.Get().(*[]float64)
+	if !ok {
+		buf = new([]float64)
+	}
+	if cap(*buf) < size {
+		*buf = make([]float64, size)
+	}
+	return buf
+}
+
 // SetFilterMetrics sets the metrics instance for filter operations.
 // This function is thread-safe and ensures metrics are only set once per process lifetime.
 // Subsequent calls will be ignored due to sync.Once (idempotent behavior).
@@ -342,27 +359,18 @@ func ApplyFilters(samples []byte) error {
 		return nil
 	}
 
-	// Convert byte slice to float64 slice
+	// Convert byte slice to float64 samples in a reused scratch buffer
 	sampleCount := len(samples) / 2
-	floatSamples := make([]float64, sampleCount)
-	for i := 0; i < len(samples); i += 2 {
-		floatSamples[i/2] = float64(int16(binary.LittleEndian.Uint16(samples[i:]))) / 32768.0 //nolint:gosec // G115: audio sample conversion within 16-bit range
-	}
+	scratch := getFilterScratch(sampleCount)
+	defer filterScratchPool.Put(scratch)
+	floatSamples := (*scratch)[:sampleCount]
+	pcm16ToFloat(floatSamples, samples)
 
 	// Apply filters to the float samples in batch
 	filterChain.ApplyBatch(floatSamples)
 
-	// Convert back to byte slice
-	for i, sample := range floatSamples {
-		// Clamp the sample to valid range
-		if sample > 1.0 {
-			sample = 1.0
-		} else if sample < -1.0 {
-			sample = -1.0
-		}
-		intSample := int16(sample * 32767.0)
-		binary.LittleEndian.PutUint16(samples[i*2:], uint16(intSample)) //nolint:gosec // G115: audio sample conversion within 16-bit range
-	}
+	// Convert back to byte slice, clamping the samples to the valid range
+	floatToPCM16(samples, floatSamples)
 
 	// Record successful filter application
 	if m := getFilterMetrics(); m != nil {
@@ -0,0 +1,155 @@
+// calibration.go: Detection of periodic calibration tones and measurement of their level
+package myaudio
+
+import (
+	"encoding/binary"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+const (
+	// calibrationBlockSize is the number of samples in each block checked for the tone, 100 ms
+	calibrationBlockSize = conf.SampleRate / 10
+	// calibrationToneShare is the share of the power of a block that must be at
+	// the tone frequency for the block to count as part of a calibration tone
+	calibrationToneShare = 0.8
+	// calibrationQueueSize caps the measurements waiting to be taken
+	calibrationQueueSize = 100
+)
+
+// CalibrationMeasurement is the measured level of one calibration tone
+type CalibrationMeasurement struct {
+	Source    string
+	Time      time.Time // start of the tone
+	Frequency float64   // tone frequency in Hz
+	Level     float64   // RMS level of the tone in dBFS
+	Duration  time.Duration
+}
+
+// toneRun tracks the tone blocks of a source in a row
+type toneRun struct {
+	block      []float64 // samples of the current block
+	blockStart time.Time // capture time of the first sample of the current block
+	start      time.Time // start of the first tone block in the run
+	blocks     int       // tone blocks in the run
+	sumPower   float64   // sum of the tone power of the run blocks
+}
+
+// calibrationDetector finds calibration tones in the audio of all sources
+type calibrationDetector struct {
+	mu           sync.Mutex
+	frequency    float64
+	coefficient  float64 // Goertzel coefficient of the tone frequency
+	minBlocks    int
+	sources      map[string]*toneRun
+	measurements []CalibrationMeasurement
+}
+
+// calibration is the active detector, nil when calibration tone tracking is disabled
+var calibration atomic.Pointer[calibrationDetector]
+
+// ConfigureCalibrationTone starts or stops detecting calibration tones
+func ConfigureCalibrationTone(settings *conf.CalibrationSettings) {
+	if !settings.Enabled {
+		calibration.Store(nil)
+		return
+	}
+	calibration.Store(&calibrationDetector{
+		frequency:   settings.Frequency,
+		coefficient: 2 * math.Cos(2*math.Pi*settings.Frequency/conf.SampleRate),
+		minBlocks:   settings.MinSeconds * conf.SampleRate / calibrationBlockSize,
+		sources:     make(map[string]*toneRun),
+	})
+}
+
+// TakeCalibrationMeasurements returns and clears the tones measured since the last call
+func TakeCalibrationMeasurements() []CalibrationMeasurement {
+	d := calibration.Load()
+	if d == nil {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	measurements := d.measurements
+	d.measurements = nil
+	return measurements
+}
+
+// recordCalibrationTone checks 16-bit PCM audio received from a source for the calibration tone
+func recordCalibrationTone(sourceID string, data []byte, now time.Time) {
+	if d := calibration.Load(); d != nil {
+		d.write(sourceID, data, now)
+	}
+}
+
+// write adds audio captured from now on to the current block of a source
+func (d *calibrationDetector) write(sourceID string, data []byte, now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	run := d.sources[sourceID]
+	if run == nil {
+		run = &toneRun{block: make([]float64, 0, calibrationBlockSize)}
+		d.sources[sourceID] = run
+	}
+
+	for i := 0; i+1 < len(data); i += 2 {
+		if len(run.block) == 0 {
+			run.blockStart = now.Add(time.Duration(i/2) * time.Second / conf.SampleRate)
+		}
+		sample := int16(binary.LittleEndian.Uint16(data[i:])) // #nosec G115 -- reinterpreting PCM bytes
+		run.block = append(run.block, float64(sample)/32768)
+		if len(run.block) == calibrationBlockSize {
+			d.endBlock(sourceID, run)
+			run.block = run.block[:0]
+		}
+	}
+}
+
+// endBlock checks a full block for the tone, ending the run when the tone stopped
+func (d *calibrationDetector) endBlock(sourceID string, run *toneRun) {
+	if power, ok := d.tonePower(run.block); ok {
+		if run.blocks == 0 {
+			run.start = run.blockStart
+		}
+		run.blocks++
+		run.sumPower += power
+		return
+	}
+
+	if run.blocks >= d.minBlocks && run.sumPower > 0 {
+		d.measurements = append(d.measurements, CalibrationMeasurement{
+			Source:    sourceID,
+			Time:      run.start,
+			Frequency: d.frequency,
+			Level:     10 * math.Log10(run.sumPower/float64(run.blocks)),
+			Duration:  time.Duration(run.blocks) * time.Second * calibrationBlockSize / conf.SampleRate,
+		})
+		if excess := len(d.measurements) - calibrationQueueSize; excess > 0 {
+			d.measurements = d.measurements[excess:]
+		}
+	}
+	run.blocks = 0
+	run.sumPower = 0
+}
+
+// tonePower returns the mean power of the tone in a block by the Goertzel
+// algorithm, and whether the tone dominates the block
+func (d *calibrationDetector) tonePower(block []float64) (float64, bool) {
+	var s1, s2, total float64
+	for _, x := range block {
+		s1, s2 = x+d.coefficient*s1-s2, s1
+		total += x * x
+	}
+	if total == 0 {
+		return 0, false
+	}
+	n := float64(len(block))
+	magnitude := s1*s1 + s2*s2 - d.coefficient*s1*s2 // squared magnitude of the tone bin
+	power := 2 * magnitude / (n * n)                 // a sine of amplitude A has power A²/2
+	return power, power >= calibrationToneShare*total/n
+}
@@ -0,0 +1,66 @@
+package myaudio
+
+import (
+	"encoding/binary"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+// toneWithNoise returns 16-bit PCM of noise with a sine of the given frequency
+// and amplitude from toneStart for toneLength seconds
+func toneWithNoise(seconds, toneStart, toneLength, frequency, amplitude float64) []byte {
+	rng := rand.New(rand.NewSource(1)) // #nosec G404 -- deterministic test signal
+	samples := int(seconds * conf.SampleRate)
+	data := make([]byte, samples*2)
+	for i := range samples {
+		t := float64(i) / conf.SampleRate
+		v := rng.NormFloat64() * 0.01
+		if t >= toneStart && t < toneStart+toneLength {
+			v += amplitude * math.Sin(2*math.Pi*frequency*t)
+		}
+		binary.LittleEndian.PutUint16(data[i*2:], uint16(int16(v*32767))) // #nosec G115 -- test signal
+	}
+	return data
+}
+
+func TestCalibrationTone(t *testing.T) {
+	defer ConfigureCalibrationTone(&conf.CalibrationSettings{})
+
+	ConfigureCalibrationTone(&conf.CalibrationSettings{Enabled: true, Frequency: 1000, MinSeconds: 2})
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.Local)
+
+	// Written in chunks like captured audio
+	data := toneWithNoise(6, 1.03, 3, 1000, 0.2)
+	const chunk = 4096
+	for offset := 0; offset < len(data); offset += chunk {
+		end := min(offset+chunk, len(data))
+		recordCalibrationTone("mic", data[offset:end], start.Add(time.Duration(offset/2)*time.Second/conf.SampleRate))
+	}
+
+	measurements := TakeCalibrationMeasurements()
+	require.Len(t, measurements, 1)
+	m := measurements[0]
+	assert.Equal(t, "mic", m.Source)
+	assert.InDelta(t, 20*math.Log10(0.2/math.Sqrt2), m.Level, 0.3)
+	assert.InDelta(t, 3*time.Second, m.Duration, float64(200*time.Millisecond))
+	assert.WithinDuration(t, start.Add(1030*time.Millisecond), m.Time, 100*time.Millisecond)
+	assert.Empty(t, TakeCalibrationMeasurements(), "measurements should be taken once")
+
+	// A tone that is too short or at another frequency is not a calibration tone
+	recordCalibrationTone("mic", toneWithNoise(4, 1, 1, 1000, 0.2), start)
+	recordCalibrationTone("mic", toneWithNoise(6, 1, 3, 2000, 0.2), start)
+	assert.Empty(t, TakeCalibrationMeasurements())
+}
+
+func TestCalibrationTone_Disabled(t *testing.T) {
+	ConfigureCalibrationTone(&conf.CalibrationSettings{})
+
+	recordCalibrationTone("mic", toneWithNoise(6, 1, 3, 1000, 0.2), time.Now())
+	assert.Nil(t, TakeCalibrationMeasurements())
+}
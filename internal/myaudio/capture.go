@@ -299,23 +299,24 @@ func CaptureAudio(settings *conf.Settings, wg *sync.WaitGroup, quitChan, restart
 			return
 		}
 
-		source, err := registry.RegisterSource(settings.Realtime.Audio.Source, SourceConfig{
-			Type: SourceTypeAudioCard,
-		})
+		// Analyzing the channels of a stereo card separately registers a source per channel
+		sources, err := RegisterAudioCardSources(registry, &settings.Realtime.Audio)
 		if err != nil {
 			log.Printf("❌ Failed to register audio device source: %v", err)
 			return
 		}
 
-		// Initialize buffers using the registry source ID (UUID-based)
+		// Initialize buffers using the registry source IDs (UUID-based)
 		// This ensures consistency with the AnalysisBufferMonitor
-		if err := initializeBuffersForSource(source.ID); err != nil {
-			log.Printf("❌ Failed to initialize buffers for device capture: %v", err)
-			return
+		for _, source := range sources {
+			if err := initializeBuffersForSource(source.ID); err != nil {
+				log.Printf("❌ Failed to initialize buffers for device capture: %v", err)
+				return
+			}
 		}
 
-		// Device audio capture - pass source ID for buffer operations
-		go captureAudioMalgo(settings, selectedSource, source.ID, wg, quitChan, restartChan, unifiedAudioChan)
+		// Device audio capture - pass sources for buffer operations
+		go captureAudioMalgo(settings, selectedSource, sources, wg, quitChan, restartChan, unifiedAudioChan)
 	}
 }
 
@@ -525,7 +526,8 @@ func processAudioFrame(
 	convertBuffer []byte, // Can be nil, used if provided
 	settings *conf.Settings,
 	source captureSource,
-	sourceID string, // Registry source ID for buffer operations
+	routes []*channelRoute, // Registry source IDs for buffer operations and their channels
+	highRate *highRateCapture, // Decimation of audio captured above the analyzed sample rate, nil if none
 	unifiedAudioChan chan UnifiedAudioData,
 ) (finalBufferPtr *[]byte, fromPool bool, err error) { // Updated return signature
 
@@ -549,6 +551,29 @@ func processAudioFrame(
 		needsReturn = poolUsed
 	}
 
+	// Audio captured above the analyzed sample rate is decimated to a new buffer
+	if highRate != nil {
+		decimated := highRate.downsample(processedSamples, routes)
+		ReturnBufferToPool(currentBufferPtr, needsReturn)
+		if len(decimated) == 0 {
+			return nil, false, nil
+		}
+		processedSamples = decimated
+		currentBufferPtr = &decimated
+		needsReturn = false
+	}
+
+	// Channels extracted from stereo audio are new buffers and need no safe copy
+	if len(routes) > 0 && routes[0].channel != channelAsCaptured {
+		// The filter chain keeps the state of a single signal
+		applyEQ := settings.Realtime.Audio.Equalizer.Enabled && len(routes) == 1
+		recordDOAAudio(processedSamples, time.Now())
+		for _, route := range routes {
+			processSourceAudio(extractChannel(processedSamples, route.channel), applyEQ, source, route.sourceID, unifiedAudioChan)
+		}
+		return currentBufferPtr, needsReturn, nil
+	}
+
 	// --- Buffer Safety Handling ---
 	var bufferToUse []byte
 	// Check if currentBufferPtr points to the same underlying data as pSamples
@@ -591,8 +616,18 @@ func processAudioFrame(
 	}
 	// --- End Buffer Safety Handling ---
 
+	for _, route := range routes {
+		processSourceAudio(bufferToUse, settings.Realtime.Audio.Equalizer.Enabled, source, route.sourceID, unifiedAudioChan)
+	}
+
+	return finalBufferPtr, fromPool, nil // Return pointer, pool status, and nil error
+}
+
+// processSourceAudio writes 16-bit mono audio of one source to its buffers and
+// listeners
+func processSourceAudio(bufferToUse []byte, applyEQ bool, source captureSource, sourceID string, unifiedAudioChan chan UnifiedAudioData) {
 	// Apply audio EQ filters if enabled (use the safe bufferToUse)
-	if settings.Realtime.Audio.Equalizer.Enabled {
+	if applyEQ {
 		if eqErr := ApplyFilters(bufferToUse); eqErr != nil {
 			log.Printf("❌ Error applying audio EQ filters: %v", eqErr)
 			// Non-fatal, just log
@@ -649,8 +684,6 @@ func processAudioFrame(
 			log.Printf("⚠️ Unified audio channel full even after clearing for source %s", source.Name)
 		}
 	}
-
-	return finalBufferPtr, fromPool, nil // Return pointer, pool status, and nil error
 }
 
 // handleDeviceStop contains the logic for attempting to restart the audio device
@@ -690,12 +723,16 @@ func handleDeviceStop(captureDevice *malgo.Device, quitChan, restartChan chan st
 	}
 }
 
-func captureAudioMalgo(settings *conf.Settings, source captureSource, sourceID string, wg *sync.WaitGroup, quitChan, restartChan chan struct{}, unifiedAudioChan chan UnifiedAudioData) {
+func captureAudioMalgo(settings *conf.Settings, source captureSource, sources []*AudioSource, wg *sync.WaitGroup, quitChan, restartChan chan struct{}, unifiedAudioChan chan UnifiedAudioData) {
 	wg.Add(1)
 	defer wg.Done()
 
-	// Clean up sound level processor when function exits
-	defer UnregisterSoundLevelProcessor(sourceID)
+	// Clean up sound level processors when function exits
+	defer func() {
+		for _, s := range sources {
+			UnregisterSoundLevelProcessor(s.ID)
+		}
+	}()
 
 	if settings.Debug {
 		fmt.Println("Initializing context")
@@ -726,20 +763,48 @@ func captureAudioMalgo(settings *conf.Settings, source captureSource, sourceID s
 
 	deviceConfig := malgo.DefaultDeviceConfig(malgo.Capture)
 	// deviceConfig.Capture.Format = malgo.FormatS16 // Let malgo choose or use default
-	deviceConfig.Capture.Channels = conf.NumChannels
+	deviceConfig.Capture.Channels = captureChannelCount(&settings.Realtime.Audio)
 	deviceConfig.SampleRate = conf.SampleRate
 	deviceConfig.Alsa.NoMMap = 1
 	deviceConfig.Capture.DeviceID = source.Pointer
 
+	// Route the captured channels to their sources
+	routes := channelRoutes(sources, deviceConfig.Capture.Channels)
+
+	// Capture above the analyzed sample rate is decimated for analysis
+	sourceIDs := make([]string, 0, len(sources))
+	for _, s := range sources {
+		sourceIDs = append(sourceIDs, s.ID)
+	}
+	highRate, err := newHighRateCapture(settings.Realtime.Audio.CaptureRate, int(deviceConfig.Capture.Channels), sourceIDs)
+	if err != nil {
+		log.Printf("❌ Error setting up capture at %d Hz, capturing at %d Hz: %v", settings.Realtime.Audio.CaptureRate.SampleRate, conf.SampleRate, err)
+	}
+	if highRate != nil {
+		deviceConfig.SampleRate = uint32(highRate.sampleRate) // #nosec G115 -- validated capture sample rate
+		log.Printf("🎚️ Capturing at %d Hz, decimated to %d Hz for analysis", highRate.sampleRate, conf.SampleRate)
+	}
+
+	// Keep the captured stereo audio for estimating the bearing of detections
+	if settings.Realtime.Audio.DOA.Enabled {
+		enableDOA(sources)
+		defer disableDOA()
+	}
+
 	// Initialize the filter chain
 	if err := InitializeFilterChain(settings); err != nil {
 		log.Printf("❌ Error initializing filter chain: %v", err)
 	}
+	if settings.Realtime.Audio.Equalizer.Enabled && len(routes) > 1 {
+		log.Printf("⚠️ Audio equalizer is not applied when stereo channels are analyzed separately")
+	}
 
-	// Initialize sound level processor for this source if enabled
+	// Initialize sound level processors for the sources if enabled
 	if settings.Realtime.Audio.SoundLevel.Enabled {
-		if err := RegisterSoundLevelProcessor(sourceID, source.Name); err != nil {
-			log.Printf("❌ Error initializing sound level processor: %v", err)
+		for _, s := range sources {
+			if err := RegisterSoundLevelProcessor(s.ID, s.DisplayName); err != nil {
+				log.Printf("❌ Error initializing sound level processor: %v", err)
+			}
 		}
 	}
 
@@ -752,7 +817,7 @@ func captureAudioMalgo(settings *conf.Settings, source captureSource, sourceID s
 		// processAudioFrame now handles pooling internally and returns buffer info
 		// Pass scratchBuffer as the potential destination for conversion
 		finalBufferPtr, fromPool, err := processAudioFrame(
-			pSamples, formatType, scratchBuffer, settings, source, sourceID, unifiedAudioChan,
+			pSamples, formatType, scratchBuffer, settings, source, routes, highRate, unifiedAudioChan,
 		)
 		if err != nil {
 			// Error already logged in processAudioFrame
@@ -0,0 +1,116 @@
+// capture_rate.go: Capture of sources above the sample rate analyzed by BirdNET
+package myaudio
+
+import (
+	"sync"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+// originalRateBuffers keep the audio of sources archived at their capture
+// sample rate, for saving detection clips at that rate
+var (
+	originalRateBuffers   = make(map[string]*CaptureBuffer)
+	originalRateBuffersMu sync.RWMutex
+)
+
+// highRateCapture decimates the audio of a source captured above the analyzed
+// sample rate, keeping the original audio of its sources when archived
+type highRateCapture struct {
+	sampleRate int
+	decimator  *Decimator
+	archive    bool
+}
+
+// newHighRateCapture returns the high rate capture of sources captured with
+// the given number of interleaved channels, or nil when they are captured at
+// the analyzed sample rate. It allocates the original rate buffers of archived
+// sources and removes those no longer archived.
+func newHighRateCapture(rate conf.CaptureRateSettings, channels int, sourceIDs []string) (*highRateCapture, error) {
+	if rate.SampleRate == 0 || rate.SampleRate == conf.SampleRate {
+		for _, id := range sourceIDs {
+			removeOriginalRateBuffer(id)
+		}
+		return nil, nil
+	}
+
+	decimator, err := NewDecimator(rate.SampleRate, channels)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range sourceIDs {
+		if rate.ArchiveOriginal {
+			allocateOriginalRateBuffer(id, rate.SampleRate)
+		} else {
+			removeOriginalRateBuffer(id)
+		}
+	}
+	return &highRateCapture{sampleRate: rate.SampleRate, decimator: decimator, archive: rate.ArchiveOriginal}, nil
+}
+
+// downsample archives the original audio of the routed sources when enabled
+// and returns the audio decimated to the analyzed sample rate as a new buffer
+func (h *highRateCapture) downsample(samples []byte, routes []*channelRoute) []byte {
+	if h.archive {
+		for _, route := range routes {
+			original := samples
+			if route.channel != channelAsCaptured {
+				original = extractChannel(samples, route.channel)
+			}
+			writeToOriginalRateBuffer(route.sourceID, original)
+		}
+	}
+	return h.decimator.Process(samples)
+}
+
+// allocateOriginalRateBuffer allocates the original rate buffer of a source,
+// replacing a buffer of another sample rate
+func allocateOriginalRateBuffer(sourceID string, sampleRate int) {
+	originalRateBuffersMu.Lock()
+	defer originalRateBuffersMu.Unlock()
+
+	if cb, exists := originalRateBuffers[sourceID]; exists && cb.sampleRate == sampleRate {
+		return
+	}
+	// Same length as the capture buffer clips are read from
+	originalRateBuffers[sourceID] = NewCaptureBuffer(60, sampleRate, conf.BitDepth/8, sourceID)
+}
+
+// removeOriginalRateBuffer removes the original rate buffer of a source
+func removeOriginalRateBuffer(sourceID string) {
+	originalRateBuffersMu.Lock()
+	defer originalRateBuffersMu.Unlock()
+	delete(originalRateBuffers, sourceID)
+}
+
+// writeToOriginalRateBuffer adds audio at the capture sample rate to the
+// original rate buffer of a source
+func writeToOriginalRateBuffer(sourceID string, data []byte) {
+	originalRateBuffersMu.RLock()
+	cb, exists := originalRateBuffers[sourceID]
+	originalRateBuffersMu.RUnlock()
+
+	if exists {
+		cb.Write(data)
+	}
+}
+
+// ReadClipSegment reads the audio of a detection clip of a source and returns
+// it with its sample rate: the capture sample rate when the source archives
+// its original audio and clips can keep it, otherwise the analyzed sample rate
+func ReadClipSegment(settings *conf.AudioSettings, sourceID string, start time.Time, duration int) (pcm []byte, sampleRate int, err error) {
+	if KeepsCaptureRate(settings) {
+		originalRateBuffersMu.RLock()
+		cb, exists := originalRateBuffers[sourceID]
+		originalRateBuffersMu.RUnlock()
+
+		if exists {
+			pcm, err = cb.ReadSegment(start, duration)
+			return pcm, cb.sampleRate, err
+		}
+	}
+
+	pcm, err = ReadSegmentFromCaptureBuffer(sourceID, start, duration)
+	return pcm, conf.SampleRate, err
+}
@@ -0,0 +1,59 @@
+package myaudio
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+func TestHighRateCaptureAtAnalyzedRate(t *testing.T) {
+	t.Parallel()
+
+	h, err := newHighRateCapture(conf.CaptureRateSettings{SampleRate: conf.SampleRate}, 1, []string{"rate_test_analyzed"})
+	require.NoError(t, err)
+	assert.Nil(t, h)
+
+	_, err = newHighRateCapture(conf.CaptureRateSettings{SampleRate: 44100}, 1, nil)
+	require.Error(t, err)
+}
+
+func TestHighRateCaptureArchivesOriginal(t *testing.T) {
+	t.Parallel()
+
+	const sourceID = "rate_test_archive"
+	h, err := newHighRateCapture(conf.CaptureRateSettings{SampleRate: 2 * conf.SampleRate, ArchiveOriginal: true}, 1, []string{sourceID})
+	require.NoError(t, err)
+	require.NotNil(t, h)
+	t.Cleanup(func() { removeOriginalRateBuffer(sourceID) })
+
+	originalRateBuffersMu.RLock()
+	cb, exists := originalRateBuffers[sourceID]
+	originalRateBuffersMu.RUnlock()
+	require.True(t, exists)
+	assert.Equal(t, 2*conf.SampleRate, cb.sampleRate)
+
+	pcm := sinePCM(2*conf.SampleRate, 2*conf.SampleRate, 5000, 1000)
+	out := h.downsample(pcm, []*channelRoute{{sourceID: sourceID, channel: channelAsCaptured}})
+	// The decimated audio lags by the filter delay
+	assert.InDelta(t, len(pcm)/2, len(out), float64(len(h.decimator.taps)*2))
+
+	cb.lock.Lock()
+	start := cb.startTime
+	cb.lock.Unlock()
+
+	settings := &conf.AudioSettings{Export: conf.ExportSettings{Type: "wav"}}
+	clip, sampleRate, err := ReadClipSegment(settings, sourceID, start, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2*conf.SampleRate, sampleRate)
+	assert.Len(t, clip, 2*conf.SampleRate*2)
+
+	// Without archiving the buffer is released
+	_, err = newHighRateCapture(conf.CaptureRateSettings{SampleRate: 2 * conf.SampleRate}, 1, []string{sourceID})
+	require.NoError(t, err)
+	originalRateBuffersMu.RLock()
+	_, exists = originalRateBuffers[sourceID]
+	originalRateBuffersMu.RUnlock()
+	assert.False(t, exists)
+}
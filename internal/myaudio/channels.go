@@ -0,0 +1,125 @@
+// channels.go: Channel selection and per-channel analysis for stereo sound cards
+package myaudio
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+const (
+	// channelAsCaptured marks a route that passes captured audio through unchanged
+	channelAsCaptured = -1
+	// channelMixed marks a route that mixes captured stereo audio to mono
+	channelMixed = -2
+)
+
+// channelRoute sends one channel of the captured audio to an audio source
+type channelRoute struct {
+	sourceID string
+	channel  int // index of the channel in interleaved stereo audio, channelAsCaptured or channelMixed
+}
+
+// captureChannelCount returns the number of channels to capture from a sound
+// card. Mixing is left to the audio backend unless the stereo audio is needed
+// for direction of arrival estimation.
+func captureChannelCount(settings *conf.AudioSettings) uint32 {
+	if (settings.Channel == "" || settings.Channel == conf.AudioChannelMix) && !settings.DOA.Enabled {
+		return conf.NumChannels
+	}
+	return 2
+}
+
+// channelConnection returns the connection string registered for one channel of a sound card
+func channelConnection(device, channel string) string {
+	return device + "#" + channel
+}
+
+// RegisterAudioCardSources registers the sources analyzed from the configured
+// sound card: one source, or one source per channel when the channels are
+// analyzed separately.
+func RegisterAudioCardSources(registry *AudioSourceRegistry, settings *conf.AudioSettings) ([]*AudioSource, error) {
+	if registry == nil {
+		return nil, errors.Newf("audio source registry not available").
+			Component("myaudio").
+			Category(errors.CategorySystem).
+			Context("operation", "register_audio_card_sources").
+			Build()
+	}
+
+	if settings.Channel != conf.AudioChannelSplit {
+		channel := settings.Channel
+		if channel == conf.AudioChannelMix {
+			channel = ""
+		}
+		source, err := registry.RegisterSource(settings.Source, SourceConfig{
+			Type:        SourceTypeAudioCard,
+			DisplayName: settings.Source,
+			Channel:     channel,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return []*AudioSource{source}, nil
+	}
+
+	sources := make([]*AudioSource, 0, 2)
+	for _, channel := range []string{conf.AudioChannelLeft, conf.AudioChannelRight} {
+		source, err := registry.RegisterSource(channelConnection(settings.Source, channel), SourceConfig{
+			Type:        SourceTypeAudioCard,
+			DisplayName: fmt.Sprintf("%s (%s)", settings.Source, channel),
+			Channel:     channel,
+		})
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, source)
+	}
+	return sources, nil
+}
+
+// channelRoutes returns the routes sending captured audio to the given sources
+func channelRoutes(sources []*AudioSource, captureChannels uint32) []*channelRoute {
+	routes := make([]*channelRoute, 0, len(sources))
+	for _, source := range sources {
+		route := &channelRoute{sourceID: source.ID, channel: channelAsCaptured}
+		if captureChannels == 2 {
+			switch source.Channel {
+			case conf.AudioChannelLeft:
+				route.channel = 0
+			case conf.AudioChannelRight:
+				route.channel = 1
+			default:
+				route.channel = channelMixed
+			}
+		}
+		routes = append(routes, route)
+	}
+	return routes
+}
+
+// extractChannel returns one channel, or the mix of both, of interleaved 16-bit
+// stereo PCM as a new buffer of mono audio. Downstream consumers may keep the buffer.
+func extractChannel(stereo []byte, channel int) []byte {
+	const frameSize = 4 // two 16-bit samples
+	frames := len(stereo) / frameSize
+	dst := make([]byte, frames*2)
+
+	if channel == channelMixed {
+		for i := range frames {
+			left := int32(int16(binary.LittleEndian.Uint16(stereo[i*frameSize:])))    // #nosec G115 -- reinterpreting PCM bytes
+			right := int32(int16(binary.LittleEndian.Uint16(stereo[i*frameSize+2:]))) // #nosec G115 -- reinterpreting PCM bytes
+			binary.LittleEndian.PutUint16(dst[i*2:], uint16(int16((left+right)/2)))   // #nosec G115 -- average fits in 16 bits
+		}
+		return dst
+	}
+
+	offset := channel * 2
+	for i := range frames {
+		dst[i*2] = stereo[i*frameSize+offset]
+		dst[i*2+1] = stereo[i*frameSize+offset+1]
+	}
+	return dst
+}
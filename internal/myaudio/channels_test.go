@@ -0,0 +1,96 @@
+package myaudio
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+func newChannelTestRegistry() *AudioSourceRegistry {
+	return &AudioSourceRegistry{
+		sources:       make(map[string]*AudioSource),
+		connectionMap: make(map[string]string),
+		refCounts:     make(map[string]*int32),
+		logger:        getTestLogger(),
+	}
+}
+
+func TestExtractChannel(t *testing.T) {
+	t.Parallel()
+
+	// Two frames of interleaved stereo: L0 R0 L1 R1, plus a trailing partial frame
+	stereo := []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09}
+
+	assert.Equal(t, []byte{0x01, 0x02, 0x05, 0x06}, extractChannel(stereo, 0))
+	assert.Equal(t, []byte{0x03, 0x04, 0x07, 0x08}, extractChannel(stereo, 1))
+
+	// Mixing averages the samples of both channels: (100 + 300) / 2 and (-100 + -301) / 2
+	mixed := []byte{0x64, 0x00, 0x2c, 0x01, 0x9c, 0xff, 0xd3, 0xfe}
+	assert.Equal(t, []byte{0xc8, 0x00, 0x38, 0xff}, extractChannel(mixed, channelMixed))
+}
+
+func TestRegisterAudioCardSources(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		channel      string
+		wantChannels []string
+		wantCapture  uint32
+		wantRoutes   []int
+	}{
+		{conf.AudioChannelMix, []string{""}, conf.NumChannels, []int{channelAsCaptured}},
+		{"", []string{""}, conf.NumChannels, []int{channelAsCaptured}},
+		{conf.AudioChannelLeft, []string{conf.AudioChannelLeft}, 2, []int{0}},
+		{conf.AudioChannelRight, []string{conf.AudioChannelRight}, 2, []int{1}},
+		{conf.AudioChannelSplit, []string{conf.AudioChannelLeft, conf.AudioChannelRight}, 2, []int{0, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.channel, func(t *testing.T) {
+			t.Parallel()
+
+			registry := newChannelTestRegistry()
+			settings := &conf.AudioSettings{Source: "hw:1,0", Channel: tt.channel}
+			sources, err := RegisterAudioCardSources(registry, settings)
+			require.NoError(t, err)
+			require.Len(t, sources, len(tt.wantChannels))
+			for i, source := range sources {
+				assert.Equal(t, tt.wantChannels[i], source.Channel)
+				assert.Equal(t, SourceTypeAudioCard, source.Type)
+			}
+
+			// Registering again returns the same sources
+			again, err := RegisterAudioCardSources(registry, settings)
+			require.NoError(t, err)
+			for i := range sources {
+				assert.Equal(t, sources[i].ID, again[i].ID)
+			}
+
+			captureChannels := captureChannelCount(settings)
+			assert.Equal(t, tt.wantCapture, captureChannels)
+			routes := channelRoutes(sources, captureChannels)
+			require.Len(t, routes, len(tt.wantRoutes))
+			for i, route := range routes {
+				assert.Equal(t, sources[i].ID, route.sourceID)
+				assert.Equal(t, tt.wantRoutes[i], route.channel)
+			}
+		})
+	}
+}
+
+func TestChannelRoutes_MixWithDOA(t *testing.T) {
+	t.Parallel()
+
+	// Direction of arrival needs stereo audio, so the mix is made from captured stereo
+	settings := &conf.AudioSettings{Source: "hw:1,0", Channel: conf.AudioChannelMix, DOA: conf.DOASettings{Enabled: true}}
+	sources, err := RegisterAudioCardSources(newChannelTestRegistry(), settings)
+	require.NoError(t, err)
+
+	captureChannels := captureChannelCount(settings)
+	assert.Equal(t, uint32(2), captureChannels)
+	routes := channelRoutes(sources, captureChannels)
+	require.Len(t, routes, 1)
+	assert.Equal(t, channelMixed, routes[0].channel)
+}
@@ -0,0 +1,144 @@
+// decimate.go: Decimation of audio captured above the sample rate analyzed by BirdNET
+package myaudio
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+const (
+	// decimatorTapsPerFactor sets the filter length: the transition band stays
+	// about 4 kHz wide whatever the decimation factor
+	decimatorTapsPerFactor = 64
+	// decimatorCutoff is the filter cutoff in Hz, between the top of the
+	// passband at 20 kHz and the Nyquist frequency of the output at 24 kHz
+	decimatorCutoff = 22000.0
+)
+
+// Decimator converts interleaved 16-bit PCM captured at a multiple of the
+// analyzed sample rate to that rate. The audio is low-pass filtered with a
+// Blackman windowed-sinc FIR filter before dropping samples, so ultrasonic
+// content does not alias into the analyzed band. The filter state is kept
+// between calls, so a stream can be decimated in chunks of any size.
+type Decimator struct {
+	factor   int
+	channels int
+	taps     []float32
+	history  [][]float32 // samples of each channel not yet consumed by the filter
+	partial  []byte      // bytes of an incomplete frame left from the previous chunk
+}
+
+// NewDecimator creates a decimator from inputRate, a multiple of the analyzed
+// sample rate, to the analyzed sample rate for audio with the given number of
+// interleaved channels
+func NewDecimator(inputRate, channels int) (*Decimator, error) {
+	if inputRate <= conf.SampleRate || inputRate%conf.SampleRate != 0 || channels < 1 {
+		return nil, errors.Newf("cannot decimate %d Hz audio with %d channels to %d Hz", inputRate, channels, conf.SampleRate).
+			Component("myaudio").
+			Category(errors.CategoryValidation).
+			Context("operation", "new_decimator").
+			Context("input_rate", inputRate).
+			Context("channels", channels).
+			Build()
+	}
+
+	factor := inputRate / conf.SampleRate
+	d := &Decimator{
+		factor:   factor,
+		channels: channels,
+		taps:     lowPassTaps(decimatorTapsPerFactor*factor+1, decimatorCutoff/float64(inputRate)),
+		history:  make([][]float32, channels),
+	}
+	// Start from silence so the output keeps the timing of the input
+	for ch := range d.history {
+		d.history[ch] = make([]float32, len(d.taps)-1)
+	}
+	return d, nil
+}
+
+// Factor returns the ratio of the input to the output sample rate
+func (d *Decimator) Factor() int {
+	return d.factor
+}
+
+// Process decimates a chunk of interleaved 16-bit PCM and returns the
+// decimated audio as a new buffer. It may return an empty buffer when the
+// chunk is too short to produce a frame.
+func (d *Decimator) Process(pcm []byte) []byte {
+	frameSize := 2 * d.channels
+	if len(d.partial) > 0 {
+		pcm = append(d.partial, pcm...)
+		d.partial = nil
+	}
+	frames := len(pcm) / frameSize
+	if rest := pcm[frames*frameSize:]; len(rest) > 0 {
+		d.partial = append([]byte(nil), rest...)
+	}
+
+	for ch := range d.channels {
+		for i := range frames {
+			sample := int16(binary.LittleEndian.Uint16(pcm[i*frameSize+ch*2:])) // #nosec G115 -- reinterpreting PCM bytes
+			d.history[ch] = append(d.history[ch], float32(sample))
+		}
+	}
+
+	// Every channel holds the same number of samples
+	available := len(d.history[0]) - len(d.taps) + 1
+	outFrames := 0
+	if available > 0 {
+		outFrames = (available + d.factor - 1) / d.factor
+	}
+	out := make([]byte, outFrames*frameSize)
+	for ch, samples := range d.history {
+		for i := range outFrames {
+			window := samples[i*d.factor : i*d.factor+len(d.taps)]
+			var acc float32
+			for k, tap := range d.taps {
+				acc += tap * window[k]
+			}
+			binary.LittleEndian.PutUint16(out[i*frameSize+ch*2:], uint16(clampInt16(acc))) // #nosec G115 -- clamped to 16 bits
+		}
+		// Keep the samples the next outputs still need
+		consumed := outFrames * d.factor
+		d.history[ch] = append(samples[:0], samples[consumed:]...)
+	}
+	return out
+}
+
+// lowPassTaps returns the taps of a Blackman windowed-sinc low-pass filter
+// with unity gain at DC. cutoff is relative to the sample rate.
+func lowPassTaps(n int, cutoff float64) []float32 {
+	taps := make([]float64, n)
+	center := float64(n-1) / 2
+	var sum float64
+	for i := range taps {
+		x := float64(i) - center
+		sinc := 2 * cutoff
+		if x != 0 {
+			sinc = math.Sin(2*math.Pi*cutoff*x) / (math.Pi * x)
+		}
+		window := 0.42 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(n-1)) + 0.08*math.Cos(4*math.Pi*float64(i)/float64(n-1))
+		taps[i] = sinc * window
+		sum += taps[i]
+	}
+
+	result := make([]float32, n)
+	for i, tap := range taps {
+		result[i] = float32(tap / sum)
+	}
+	return result
+}
+
+// clampInt16 rounds a sample to the nearest 16-bit value
+func clampInt16(v float32) int16 {
+	switch {
+	case v >= math.MaxInt16:
+		return math.MaxInt16
+	case v <= math.MinInt16:
+		return math.MinInt16
+	}
+	return int16(math.Round(float64(v)))
+}
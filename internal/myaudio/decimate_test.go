@@ -0,0 +1,104 @@
+package myaudio
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+// sinePCM returns interleaved 16-bit PCM of a sine per channel at the given
+// sample rate
+func sinePCM(sampleRate, frames int, amplitude float64, frequencies ...float64) []byte {
+	channels := len(frequencies)
+	pcm := make([]byte, frames*channels*2)
+	for i := range frames {
+		for ch, frequency := range frequencies {
+			v := amplitude * math.Sin(2*math.Pi*frequency*float64(i)/float64(sampleRate))
+			binary.LittleEndian.PutUint16(pcm[(i*channels+ch)*2:], uint16(int16(v))) // #nosec G115 -- test signal within 16 bits
+		}
+	}
+	return pcm
+}
+
+// channelPeak returns the peak amplitude of a channel of interleaved 16-bit
+// PCM, skipping the first frames affected by the filter start
+func channelPeak(pcm []byte, channels, channel, skipFrames int) float64 {
+	var peak float64
+	for i := skipFrames; (i+1)*channels*2 <= len(pcm); i++ {
+		sample := int16(binary.LittleEndian.Uint16(pcm[(i*channels+channel)*2:])) // #nosec G115 -- reinterpreting PCM bytes
+		peak = math.Max(peak, math.Abs(float64(sample)))
+	}
+	return peak
+}
+
+func TestNewDecimatorRejectsUnsupportedRates(t *testing.T) {
+	t.Parallel()
+
+	for _, rate := range []int{44100, conf.SampleRate, 88200} {
+		_, err := NewDecimator(rate, 1)
+		require.Error(t, err, "rate %d", rate)
+	}
+
+	d, err := NewDecimator(4*conf.SampleRate, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 4, d.Factor())
+}
+
+func TestDecimatorKeepsPassband(t *testing.T) {
+	t.Parallel()
+
+	d, err := NewDecimator(2*conf.SampleRate, 1)
+	require.NoError(t, err)
+
+	frames := 2 * conf.SampleRate / 2 // half a second
+	out := d.Process(sinePCM(2*conf.SampleRate, frames, 10000, 1000))
+	assert.Len(t, out, frames/2*2, "half the frames remain")
+	assert.InDelta(t, 10000, channelPeak(out, 1, 0, 500), 200)
+}
+
+func TestDecimatorSuppressesAliasing(t *testing.T) {
+	t.Parallel()
+
+	d, err := NewDecimator(2*conf.SampleRate, 1)
+	require.NoError(t, err)
+
+	// 30 kHz would alias to 18 kHz without filtering
+	out := d.Process(sinePCM(2*conf.SampleRate, conf.SampleRate, 10000, 30000))
+	assert.Less(t, channelPeak(out, 1, 0, 500), 10.0)
+}
+
+func TestDecimatorChunked(t *testing.T) {
+	t.Parallel()
+
+	pcm := sinePCM(2*conf.SampleRate, 9600, 8000, 440, 3000)
+
+	whole, err := NewDecimator(2*conf.SampleRate, 2)
+	require.NoError(t, err)
+	expected := whole.Process(pcm)
+
+	chunked, err := NewDecimator(2*conf.SampleRate, 2)
+	require.NoError(t, err)
+	var got []byte
+	// Odd chunk sizes split frames between calls
+	for start := 0; start < len(pcm); start += 1001 {
+		end := min(start+1001, len(pcm))
+		got = append(got, chunked.Process(pcm[start:end])...)
+	}
+	assert.Equal(t, expected, got)
+}
+
+func TestDecimatorStereoChannelsIndependent(t *testing.T) {
+	t.Parallel()
+
+	d, err := NewDecimator(2*conf.SampleRate, 2)
+	require.NoError(t, err)
+
+	// Left carries a tone above the passband, right an audible tone
+	out := d.Process(sinePCM(2*conf.SampleRate, conf.SampleRate, 10000, 30000, 2000))
+	assert.Less(t, channelPeak(out, 2, 0, 500), 10.0)
+	assert.InDelta(t, 10000, channelPeak(out, 2, 1, 500), 200)
+}
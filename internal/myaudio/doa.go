@@ -0,0 +1,216 @@
+// doa.go: Direction of arrival estimation from a synchronized stereo microphone pair
+package myaudio
+
+import (
+	"encoding/binary"
+	"math"
+	"math/cmplx"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+const (
+	// speedOfSound is the speed of sound in air at 20 °C in m/s
+	speedOfSound = 343.0
+	// doaHistoryDuration is how much stereo audio is kept for estimating the
+	// bearing of detections, covering the delay between capture and detection
+	doaHistoryDuration = 30 * time.Second
+	// doaFrameSize is the FFT size of the cross-correlation, a power of two
+	doaFrameSize = 4096
+	// Frequency band of bird vocalizations used for the estimate, leaving out
+	// low frequency wind and traffic noise
+	doaMinFrequency = 1000.0
+	doaMaxFrequency = 10000.0
+	// doaMinPeak is the smallest normalized correlation peak accepted as a
+	// bearing; weaker peaks mean the channels share no clear source
+	doaMinPeak = 0.1
+)
+
+// stereoHistory keeps the latest stereo audio captured from a sound card
+type stereoHistory struct {
+	mu        sync.Mutex
+	sourceIDs map[string]bool // sources analyzed from the sound card
+	samples   []int16         // interleaved ring buffer
+	frames    int64           // capacity in frames
+	written   int64           // total frames written
+	lastWrite time.Time       // capture time of the end of the last write
+}
+
+// doaHistory is the stereo history of the sound card, nil when direction of arrival is disabled
+var doaHistory atomic.Pointer[stereoHistory]
+
+// newStereoHistory creates a stereo history of the given duration for sources of a sound card
+func newStereoHistory(sourceIDs []string, duration time.Duration) *stereoHistory {
+	frames := int64(duration.Seconds() * conf.SampleRate)
+	h := &stereoHistory{
+		sourceIDs: make(map[string]bool, len(sourceIDs)),
+		samples:   make([]int16, frames*2),
+		frames:    frames,
+	}
+	for _, id := range sourceIDs {
+		h.sourceIDs[id] = true
+	}
+	return h
+}
+
+// write adds interleaved 16-bit stereo PCM captured until now
+func (h *stereoHistory) write(data []byte, now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for i := 0; i+3 < len(data); i += 4 {
+		pos := (h.written % h.frames) * 2
+		h.samples[pos] = int16(binary.LittleEndian.Uint16(data[i:]))     // #nosec G115 -- reinterpreting PCM bytes
+		h.samples[pos+1] = int16(binary.LittleEndian.Uint16(data[i+2:])) // #nosec G115 -- reinterpreting PCM bytes
+		h.written++
+	}
+	h.lastWrite = now
+}
+
+// window returns the left and right channels of the audio captured between
+// begin and end, limited to the audio still kept
+func (h *stereoHistory) window(begin, end time.Time) (left, right []float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	frameAt := func(t time.Time) int64 {
+		return h.written - int64(h.lastWrite.Sub(t).Seconds()*conf.SampleRate)
+	}
+	from := max(frameAt(begin), h.written-h.frames, 0)
+	to := min(frameAt(end), h.written)
+	if to <= from {
+		return nil, nil
+	}
+
+	left = make([]float64, to-from)
+	right = make([]float64, to-from)
+	for i := range left {
+		pos := ((from + int64(i)) % h.frames) * 2
+		left[i] = float64(h.samples[pos]) / 32768
+		right[i] = float64(h.samples[pos+1]) / 32768
+	}
+	return left, right
+}
+
+// enableDOA starts keeping stereo audio of the sources of a sound card
+func enableDOA(sources []*AudioSource) {
+	ids := make([]string, 0, len(sources))
+	for _, source := range sources {
+		ids = append(ids, source.ID)
+	}
+	doaHistory.Store(newStereoHistory(ids, doaHistoryDuration))
+}
+
+// disableDOA stops keeping stereo audio
+func disableDOA() {
+	doaHistory.Store(nil)
+}
+
+// recordDOAAudio adds captured interleaved 16-bit stereo PCM to the history
+func recordDOAAudio(data []byte, now time.Time) {
+	if h := doaHistory.Load(); h != nil {
+		h.write(data, now)
+	}
+}
+
+// EstimateBearing estimates the compass bearing in degrees of the loudest
+// source heard by a source between begin and end. It returns false when the
+// source has no stereo history or no clear direction was found. A microphone
+// pair cannot tell front from back, the bearing assumes the bird is in front.
+func EstimateBearing(sourceID string, begin, end time.Time, settings *conf.DOASettings) (float64, bool) {
+	h := doaHistory.Load()
+	if h == nil || !h.sourceIDs[sourceID] {
+		return 0, false
+	}
+
+	left, right := h.window(begin, end)
+	maxLag := int(math.Ceil(settings.MicSpacing / speedOfSound * conf.SampleRate))
+	delay, peak := estimateDelay(left, right, maxLag, conf.SampleRate)
+	if peak < doaMinPeak {
+		return 0, false
+	}
+	return bearingFromDelay(delay/conf.SampleRate, settings.MicSpacing, settings.Orientation), true
+}
+
+// estimateDelay estimates by GCC-PHAT by how many samples the left channel lags
+// the right one, searching up to maxLag samples either way. It returns the
+// delay and the normalized height of the correlation peak, 0 when there is
+// too little audio.
+func estimateDelay(left, right []float64, maxLag, sampleRate int) (delay, peak float64) {
+	n := doaFrameSize
+	if len(left) < n || maxLag >= n/2 {
+		return 0, 0
+	}
+
+	minBin := int(math.Ceil(doaMinFrequency * float64(n) / float64(sampleRate)))
+	maxBin := min(int(doaMaxFrequency*float64(n)/float64(sampleRate)), n/2-1)
+
+	window := make([]float64, n)
+	for i := range window {
+		window[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(n-1))
+	}
+
+	// Sum the phase transform weighted cross spectra of half overlapping frames
+	cross := make([]complex128, n)
+	l := make([]complex128, n)
+	r := make([]complex128, n)
+	frames := 0
+	for start := 0; start+n <= len(left); start += n / 2 {
+		for i := range n {
+			l[i] = complex(left[start+i]*window[i], 0)
+			r[i] = complex(right[start+i]*window[i], 0)
+		}
+		fft(l)
+		fft(r)
+		for k := minBin; k <= maxBin; k++ {
+			g := l[k] * cmplx.Conj(r[k])
+			if magnitude := cmplx.Abs(g); magnitude > 0 {
+				cross[k] += g / complex(magnitude, 0)
+			}
+		}
+		frames++
+	}
+
+	// Mirror the band for a real cross-correlation and transform back
+	for k := minBin; k <= maxBin; k++ {
+		cross[n-k] = cmplx.Conj(cross[k])
+	}
+	for i := range cross {
+		cross[i] = cmplx.Conj(cross[i])
+	}
+	fft(cross)
+	correlation := func(lag int) float64 {
+		return real(cross[(lag+n)%n]) // the conjugate of a real value is itself
+	}
+
+	best := 0
+	for lag := -maxLag; lag <= maxLag; lag++ {
+		if correlation(lag) > correlation(best) {
+			best = lag
+		}
+	}
+
+	// Refine the peak by parabolic interpolation
+	delay = float64(best)
+	if best > -maxLag && best < maxLag {
+		y0, y1, y2 := correlation(best-1), correlation(best), correlation(best+1)
+		if denominator := y0 - 2*y1 + y2; denominator != 0 {
+			delay += 0.5 * (y0 - y2) / denominator
+		}
+	}
+
+	// Each frame contributes at most 2 per band bin to the peak
+	peak = correlation(best) / float64(2*(maxBin-minBin+1)*frames)
+	return delay, peak
+}
+
+// bearingFromDelay converts the delay in seconds of the left microphone behind
+// the right one to a compass bearing in degrees
+func bearingFromDelay(delay, spacing, orientation float64) float64 {
+	sine := math.Max(-1, math.Min(1, delay*speedOfSound/spacing))
+	angle := math.Asin(sine) * 180 / math.Pi // positive to the right of the facing direction
+	return math.Mod(orientation+angle+360, 360)
+}
@@ -0,0 +1,79 @@
+package myaudio
+
+import (
+	"encoding/binary"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+// delayedNoise returns noise heard by the right microphone and the same noise
+// reaching the left microphone delay samples later
+func delayedNoise(seed int64, samples, delay int) (left, right []float64) {
+	rng := rand.New(rand.NewSource(seed)) // #nosec G404 -- deterministic test signal
+	source := make([]float64, samples+delay)
+	for i := range source {
+		source[i] = rng.NormFloat64() * 0.1
+	}
+	return source[:samples], source[delay:]
+}
+
+func TestEstimateDelay(t *testing.T) {
+	t.Parallel()
+
+	left, right := delayedNoise(1, 3*conf.SampleRate, 10)
+	delay, peak := estimateDelay(left, right, 28, conf.SampleRate)
+	assert.InDelta(t, 10, delay, 0.5)
+	assert.Greater(t, peak, 0.5)
+
+	// Independent noise in each channel has no direction
+	other, _ := delayedNoise(2, 3*conf.SampleRate, 0)
+	_, peak = estimateDelay(left, other, 28, conf.SampleRate)
+	assert.Less(t, peak, doaMinPeak)
+
+	_, peak = estimateDelay(left[:100], right[:100], 28, conf.SampleRate)
+	assert.Zero(t, peak, "too little audio should give no estimate")
+}
+
+func TestBearingFromDelay(t *testing.T) {
+	t.Parallel()
+
+	const spacing = 0.2
+	maxDelay := spacing / speedOfSound
+
+	assert.InDelta(t, 90, bearingFromDelay(0, spacing, 90), 0.01, "no delay is straight ahead")
+	assert.InDelta(t, 180, bearingFromDelay(maxDelay, spacing, 90), 0.01, "left lagging means the source is to the right")
+	assert.InDelta(t, 330, bearingFromDelay(-maxDelay/2, spacing, 0), 0.01)
+	assert.InDelta(t, 180, bearingFromDelay(2*maxDelay, spacing, 90), 0.01, "delays beyond the spacing are clamped")
+}
+
+func TestEstimateBearing(t *testing.T) {
+	defer disableDOA()
+
+	settings := &conf.DOASettings{Enabled: true, MicSpacing: 0.2, Orientation: 0}
+	enableDOA([]*AudioSource{{ID: "card"}})
+
+	left, right := delayedNoise(3, 4*conf.SampleRate, 14)
+	data := make([]byte, len(left)*4)
+	for i := range left {
+		binary.LittleEndian.PutUint16(data[i*4:], uint16(int16(left[i]*32767)))    // #nosec G115 -- test signal
+		binary.LittleEndian.PutUint16(data[i*4+2:], uint16(int16(right[i]*32767))) // #nosec G115 -- test signal
+	}
+	end := time.Now()
+	recordDOAAudio(data, end)
+
+	bearing, ok := EstimateBearing("card", end.Add(-3*time.Second), end, settings)
+	require.True(t, ok)
+	// 14 samples at 48 kHz over 20 cm is about 30 degrees to the right
+	assert.InDelta(t, 30, bearing, 3)
+
+	_, ok = EstimateBearing("rtsp", end.Add(-3*time.Second), end, settings)
+	assert.False(t, ok, "sources without stereo history have no bearing")
+
+	_, ok = EstimateBearing("card", end.Add(-time.Minute), end.Add(-40*time.Second), settings)
+	assert.False(t, ok, "audio no longer kept has no bearing")
+}
@@ -0,0 +1,316 @@
+// encoding.go: Shared encoding of audio clips for each destination
+package myaudio
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"os"
+	"strconv"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// Destination is where encoded audio is sent
+type Destination string
+
+// Audio destinations with their own encoding profile
+const (
+	DestinationStorage      Destination = "storage"      // clips saved to local storage
+	DestinationBirdWeather  Destination = "birdweather"  // soundscapes uploaded to BirdWeather
+	DestinationNotification Destination = "notification" // clips attached to notifications
+	DestinationSnippet      Destination = "snippet"      // compressed snippets kept while clip export is disabled
+)
+
+// EncodingProfileFor returns how audio is encoded for a destination.
+// Destinations without a type use the local export settings; BirdWeather only
+// accepts lossless audio and uses FLAC when local clips are lossy.
+func EncodingProfileFor(settings *conf.AudioSettings, destination Destination) conf.EncodingProfile {
+	storage := conf.EncodingProfile{
+		Type:             settings.Export.Type,
+		BitDepth:         settings.Export.BitDepth,
+		CompressionLevel: settings.Export.CompressionLevel,
+		Bitrate:          settings.Export.Bitrate,
+	}
+
+	var profile conf.EncodingProfile
+	switch destination {
+	case DestinationBirdWeather:
+		profile = settings.Export.Destinations.BirdWeather
+	case DestinationNotification:
+		profile = settings.Export.Destinations.Notification
+	case DestinationSnippet:
+		profile = conf.EncodingProfile{Type: settings.Export.Snippet.Type, Bitrate: settings.Export.Snippet.Bitrate}
+	default:
+		return storage
+	}
+
+	if profile.Type == "" {
+		profile = storage
+		if destination == DestinationBirdWeather && profile.Type != "wav" && profile.Type != "flac" {
+			profile.Type = "flac"
+		}
+	}
+	return profile
+}
+
+// EncoderArgs returns the FFmpeg output arguments encoding audio with a profile
+// to a pipe: codec, sample format, compression level or bitrate, and container.
+func EncoderArgs(profile conf.EncodingProfile) []string {
+	args := encoderArgs(profile)
+	if profile.Type == "aac" {
+		// The MP4 container needs a seekable output, stream AAC as ADTS instead
+		args[len(args)-1] = "adts"
+	}
+	return args
+}
+
+// encoderArgs returns the FFmpeg output arguments encoding audio with a profile to a file
+func encoderArgs(profile conf.EncodingProfile) []string {
+	var args []string
+	switch profile.Type {
+	case "wav":
+		codec := "pcm_s16le"
+		if profile.BitDepth == 24 {
+			codec = "pcm_s24le"
+		}
+		args = append(args, "-c:a", codec)
+	case "flac":
+		args = append(args, "-c:a", "flac", "-compression_level", strconv.Itoa(profile.CompressionLevel))
+		if profile.BitDepth == 24 {
+			args = append(args, "-sample_fmt", "s32", "-bits_per_raw_sample", "24")
+		}
+	default:
+		args = append(args, "-c:a", getEncoder(profile.Type), "-b:a", getMaxBitrate(profile.Type, profile.Bitrate))
+	}
+	return append(args, "-f", getOutputFormat(profile.Type))
+}
+
+// StreamExtension returns the file extension of audio encoded with EncoderArgs
+func StreamExtension(profile conf.EncodingProfile) string {
+	return profile.Type // AAC is streamed as ADTS, not in an M4A container
+}
+
+// Encoder backends
+const (
+	EncoderFFmpeg = "ffmpeg" // encoding with the FFmpeg binary
+	EncoderNative = "native" // pure Go WAV and FLAC encoding
+)
+
+// EncodingCapabilities describes how audio is encoded on this system. The
+// native encoder only covers WAV and FLAC: lossy types and live streaming need
+// FFmpeg, and destinations with a lossy profile are encoded as FLAC instead.
+type EncodingCapabilities struct {
+	Encoder      string   `json:"encoder"`                 // EncoderFFmpeg or EncoderNative
+	Formats      []string `json:"formats"`                 // audio types that can be encoded
+	ClipType     string   `json:"clip_type"`               // effective type of saved clips
+	Streaming    bool     `json:"streaming"`               // live HLS streaming, which needs FFmpeg
+	FLACFallback []string `json:"flac_fallback,omitempty"` // destinations whose lossy profile is encoded as FLAC
+}
+
+// GetEncodingCapabilities reports the encoder backend and formats available
+// with the FFmpeg path of the settings
+func GetEncodingCapabilities(settings *conf.AudioSettings) EncodingCapabilities {
+	if settings.FfmpegPath == "" {
+		var fallback []string
+		for _, destination := range []Destination{DestinationStorage, DestinationNotification, DestinationSnippet} {
+			if !isLossless(EncodingProfileFor(settings, destination).Type) {
+				fallback = append(fallback, string(destination))
+			}
+		}
+		return EncodingCapabilities{
+			Encoder:      EncoderNative,
+			Formats:      []string{"wav", "flac"},
+			ClipType:     nativeProfile(EncodingProfileFor(settings, DestinationStorage)).Type,
+			FLACFallback: fallback,
+		}
+	}
+	return EncodingCapabilities{
+		Encoder:   EncoderFFmpeg,
+		Formats:   []string{"wav", "flac", "mp3", "aac", "opus"},
+		ClipType:  settings.Export.Type,
+		Streaming: true,
+	}
+}
+
+// isLossless reports whether an audio type is encoded without FFmpeg
+func isLossless(audioType string) bool {
+	return audioType == "wav" || audioType == "flac"
+}
+
+// nativeProfile returns the profile encoded without FFmpeg in place of a
+// profile. There is no pure Go encoder for lossy types, so they are saved as
+// FLAC; configuration validation warns about each destination this affects.
+func nativeProfile(profile conf.EncodingProfile) conf.EncodingProfile {
+	if isLossless(profile.Type) {
+		return profile
+	}
+	return conf.EncodingProfile{Type: "flac", BitDepth: 16, CompressionLevel: 5}
+}
+
+// EncodeForDestination encodes 16-bit PCM with the profile of a destination to
+// a buffer and returns it with its file extension. WAV is always encoded in Go;
+// without FFmpeg, FLAC is too and lossy types fall back to FLAC.
+func EncodeForDestination(ctx context.Context, pcmData []byte, settings *conf.AudioSettings, destination Destination) (*bytes.Buffer, string, error) {
+	profile := EncodingProfileFor(settings, destination)
+	if settings.FfmpegPath == "" {
+		profile = nativeProfile(profile)
+	}
+
+	if profile.Type == "wav" || (profile.Type == "flac" && settings.FfmpegPath == "") {
+		if len(pcmData) == 0 {
+			return nil, "", errors.Newf("empty PCM data provided for encoding").
+				Component("myaudio").
+				Category(errors.CategoryValidation).
+				Context("operation", "encode_for_destination").
+				Context("destination", string(destination)).
+				Build()
+		}
+		return bytes.NewBuffer(encodeNative(pcmData, profile)), profile.Type, nil
+	}
+
+	buffer, err := ExportAudioWithCustomFFmpegArgsContext(ctx, pcmData, settings.FfmpegPath, EncoderArgs(profile))
+	if err != nil {
+		return nil, "", err
+	}
+	return buffer, StreamExtension(profile), nil
+}
+
+// DestinationExtension returns the file extension of audio encoded for a
+// destination by EncodeForDestination
+func DestinationExtension(settings *conf.AudioSettings, destination Destination) string {
+	profile := EncodingProfileFor(settings, destination)
+	if settings.FfmpegPath == "" {
+		profile = nativeProfile(profile)
+	}
+	return StreamExtension(profile)
+}
+
+// SaveSnippet saves 16-bit PCM as a detection snippet encoded with the snippet
+// profile. outputPath is the full path of the snippet including the extension
+// returned by DestinationExtension.
+func SaveSnippet(ctx context.Context, pcmData []byte, outputPath string, settings *conf.AudioSettings) error {
+	buffer, _, err := EncodeForDestination(ctx, pcmData, settings, DestinationSnippet)
+	if err != nil {
+		return err
+	}
+	tempFilePath, err := createTempFile(outputPath)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(tempFilePath, buffer.Bytes(), 0o644); err != nil { // #nosec G306 -- snippets are served by the web interface
+		return errors.New(err).
+			Component("myaudio").
+			Category(errors.CategoryFileIO).
+			Context("operation", "save_snippet").
+			Context("file_operation", "write_file").
+			Build()
+	}
+	return finalizeOutput(tempFilePath)
+}
+
+// SaveClip saves 16-bit PCM as an audio clip encoded with the local storage
+// profile. outputPath is the full path of the clip including its extension.
+// Without FFmpeg, clips are encoded in Go as WAV or FLAC.
+func SaveClip(pcmData []byte, outputPath string, settings *conf.AudioSettings) error {
+	return SaveClipAtRate(pcmData, conf.SampleRate, outputPath, settings)
+}
+
+// SaveClipAtRate saves 16-bit PCM of the given sample rate as an audio clip
+// like SaveClip, for clips kept at the capture sample rate of a source
+func SaveClipAtRate(pcmData []byte, sampleRate int, outputPath string, settings *conf.AudioSettings) error {
+	profile := EncodingProfileFor(settings, DestinationStorage)
+	if settings.FfmpegPath == "" {
+		profile = nativeProfile(profile)
+	} else if profile.Type != "wav" {
+		return exportAudioWithFFmpeg(pcmData, sampleRate, outputPath, settings)
+	}
+	if profile.Type == "wav" && profile.BitDepth != 24 && sampleRate == conf.SampleRate {
+		return SavePCMDataToWAV(outputPath, pcmData)
+	}
+
+	if len(pcmData) == 0 {
+		return errors.Newf("empty PCM data provided for clip").
+			Component("myaudio").
+			Category(errors.CategoryValidation).
+			Context("operation", "save_clip").
+			Build()
+	}
+	tempFilePath, err := createTempFile(outputPath)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(tempFilePath, encodeNativeAtRate(pcmData, profile, sampleRate), 0o644); err != nil { // #nosec G306 -- clips are served by the web interface
+		return errors.New(err).
+			Component("myaudio").
+			Category(errors.CategoryFileIO).
+			Context("operation", "save_clip").
+			Context("file_operation", "write_file").
+			Build()
+	}
+	return finalizeOutput(tempFilePath)
+}
+
+// KeepsCaptureRate reports whether clips saved with the local storage profile
+// can keep the capture sample rate of a source above 48 kHz: lossy encoders
+// are limited to 48 kHz, so only WAV and FLAC clips do
+func KeepsCaptureRate(settings *conf.AudioSettings) bool {
+	profile := EncodingProfileFor(settings, DestinationStorage)
+	if settings.FfmpegPath == "" {
+		profile = nativeProfile(profile)
+	}
+	return profile.Type == "wav" || profile.Type == "flac"
+}
+
+// encodeNative encodes 16-bit PCM as WAV or FLAC without FFmpeg
+func encodeNative(pcmData []byte, profile conf.EncodingProfile) []byte {
+	return encodeNativeAtRate(pcmData, profile, conf.SampleRate)
+}
+
+// encodeNativeAtRate encodes 16-bit PCM of the given sample rate as WAV or
+// FLAC without FFmpeg
+func encodeNativeAtRate(pcmData []byte, profile conf.EncodingProfile, sampleRate int) []byte {
+	if profile.Type == "flac" {
+		return encodeFLAC(pcmData, profile.BitDepth, profile.CompressionLevel, sampleRate)
+	}
+	return encodeWAV(pcmData, profile.BitDepth, sampleRate)
+}
+
+// encodeWAV returns 16-bit PCM as a WAV file with 16 or 24 bits per sample
+func encodeWAV(pcmData []byte, bitDepth, sampleRate int) []byte {
+	if bitDepth != 24 {
+		bitDepth = 16
+	}
+	const headerSize = 44
+	bytesPerSample := bitDepth / 8
+	samples := len(pcmData) / 2
+	dataSize := samples * bytesPerSample
+	blockAlign := conf.NumChannels * bytesPerSample
+
+	out := make([]byte, headerSize+dataSize)
+	copy(out[0:], "RIFF")
+	binary.LittleEndian.PutUint32(out[4:], uint32(36+dataSize)) // #nosec G115 -- clip sizes fit in 32 bits
+	copy(out[8:], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(out[16:], 16) // fmt chunk size
+	binary.LittleEndian.PutUint16(out[20:], 1)  // PCM
+	binary.LittleEndian.PutUint16(out[22:], conf.NumChannels)
+	binary.LittleEndian.PutUint32(out[24:], uint32(sampleRate))            // #nosec G115 -- sample rates fit in 32 bits
+	binary.LittleEndian.PutUint32(out[28:], uint32(sampleRate*blockAlign)) // #nosec G115 -- byte rate fits in 32 bits
+	binary.LittleEndian.PutUint16(out[32:], uint16(blockAlign))            // #nosec G115 -- block align fits in 16 bits
+	binary.LittleEndian.PutUint16(out[34:], uint16(bitDepth))              // #nosec G115 -- 16 or 24
+	copy(out[36:], "data")
+	binary.LittleEndian.PutUint32(out[40:], uint32(dataSize)) // #nosec G115 -- clip sizes fit in 32 bits
+
+	data := out[headerSize:]
+	if bitDepth == 16 {
+		copy(data, pcmData[:samples*2])
+		return out
+	}
+	// 24-bit samples are the 16-bit samples shifted up by one byte
+	for i := range samples {
+		data[i*3+1] = pcmData[i*2]
+		data[i*3+2] = pcmData[i*2+1]
+	}
+	return out
+}
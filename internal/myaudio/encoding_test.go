@@ -0,0 +1,148 @@
+package myaudio
+
+import (
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+func TestEncodingProfileFor(t *testing.T) {
+	t.Parallel()
+
+	settings := &conf.AudioSettings{Export: conf.ExportSettings{
+		Type:     "mp3",
+		Bitrate:  "128k",
+		BitDepth: 16,
+		Destinations: conf.ExportDestinations{
+			Notification: conf.EncodingProfile{Type: "opus", Bitrate: "32k"},
+		},
+	}}
+
+	assert.Equal(t, conf.EncodingProfile{Type: "mp3", Bitrate: "128k", BitDepth: 16}, EncodingProfileFor(settings, DestinationStorage))
+	assert.Equal(t, "opus", EncodingProfileFor(settings, DestinationNotification).Type)
+	assert.Equal(t, "flac", EncodingProfileFor(settings, DestinationBirdWeather).Type, "BirdWeather should not inherit a lossy type")
+
+	settings.Export.Type = "wav"
+	assert.Equal(t, "wav", EncodingProfileFor(settings, DestinationBirdWeather).Type)
+}
+
+func TestEncoderArgs(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{"-c:a", "flac", "-compression_level", "8", "-sample_fmt", "s32", "-bits_per_raw_sample", "24", "-f", "flac"},
+		EncoderArgs(conf.EncodingProfile{Type: "flac", BitDepth: 24, CompressionLevel: 8}))
+	assert.Equal(t, []string{"-c:a", "libopus", "-b:a", "32k", "-f", "opus"},
+		EncoderArgs(conf.EncodingProfile{Type: "opus", Bitrate: "32k"}))
+	assert.Equal(t, []string{"-c:a", "aac", "-b:a", "96k", "-f", "adts"},
+		EncoderArgs(conf.EncodingProfile{Type: "aac", Bitrate: "96k"}), "streamed AAC needs a container without seeking")
+	assert.Equal(t, []string{"-c:a", "aac", "-b:a", "96k", "-f", "mp4"},
+		encoderArgs(conf.EncodingProfile{Type: "aac", Bitrate: "96k"}))
+}
+
+func TestEncodeWAV(t *testing.T) {
+	t.Parallel()
+
+	pcm := []byte{0x34, 0x12, 0xcc, 0xed} // 0x1234, -0x1234
+
+	wav16 := encodeWAV(pcm, 16, conf.SampleRate)
+	require.Len(t, wav16, 44+4)
+	assert.Equal(t, "RIFF", string(wav16[:4]))
+	assert.Equal(t, uint16(16), binary.LittleEndian.Uint16(wav16[34:]))
+	assert.Equal(t, pcm, wav16[44:])
+
+	wav24 := encodeWAV(pcm, 24, conf.SampleRate)
+	require.Len(t, wav24, 44+6)
+	assert.Equal(t, uint16(24), binary.LittleEndian.Uint16(wav24[34:]))
+	assert.Equal(t, uint32(conf.SampleRate*3), binary.LittleEndian.Uint32(wav24[28:]), "byte rate")
+	assert.Equal(t, uint32(6), binary.LittleEndian.Uint32(wav24[40:]), "data size")
+	assert.Equal(t, []byte{0x00, 0x34, 0x12, 0x00, 0xcc, 0xed}, wav24[44:])
+
+	wav96k := encodeWAV(pcm, 16, 96000)
+	assert.Equal(t, uint32(96000), binary.LittleEndian.Uint32(wav96k[24:]), "sample rate")
+	assert.Equal(t, uint32(96000*2), binary.LittleEndian.Uint32(wav96k[28:]), "byte rate")
+}
+
+func TestKeepsCaptureRate(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, KeepsCaptureRate(&conf.AudioSettings{Export: conf.ExportSettings{Type: "flac"}}))
+	assert.True(t, KeepsCaptureRate(&conf.AudioSettings{Export: conf.ExportSettings{Type: "wav"}}))
+	assert.False(t, KeepsCaptureRate(&conf.AudioSettings{FfmpegPath: "/usr/bin/ffmpeg", Export: conf.ExportSettings{Type: "mp3", Bitrate: "128k"}}))
+	// Without FFmpeg lossy clips fall back to FLAC
+	assert.True(t, KeepsCaptureRate(&conf.AudioSettings{Export: conf.ExportSettings{Type: "mp3", Bitrate: "128k"}}))
+}
+
+func TestEncodeForDestination_WithoutFFmpeg(t *testing.T) {
+	t.Parallel()
+
+	settings := &conf.AudioSettings{Export: conf.ExportSettings{
+		Type: "wav",
+		Destinations: conf.ExportDestinations{
+			Notification: conf.EncodingProfile{Type: "mp3", Bitrate: "64k"},
+		},
+	}}
+
+	buffer, ext, err := EncodeForDestination(context.Background(), make([]byte, 960), settings, DestinationNotification)
+	require.NoError(t, err)
+	assert.Equal(t, "flac", ext, "lossy types need FFmpeg and fall back to native FLAC")
+	assert.Equal(t, "fLaC", buffer.String()[:4])
+
+	buffer, ext, err = EncodeForDestination(context.Background(), make([]byte, 960), settings, DestinationStorage)
+	require.NoError(t, err)
+	assert.Equal(t, "wav", ext)
+	assert.Equal(t, 44+960, buffer.Len())
+
+	_, _, err = EncodeForDestination(context.Background(), nil, settings, DestinationStorage)
+	assert.Error(t, err)
+}
+
+func TestSaveSnippet_WithoutFFmpeg(t *testing.T) {
+	t.Parallel()
+
+	settings := &conf.AudioSettings{Export: conf.ExportSettings{
+		Type:    "wav",
+		Snippet: conf.SnippetSettings{Enabled: true, Length: 6, Type: "opus", Bitrate: "24k"},
+	}}
+	assert.Equal(t, conf.EncodingProfile{Type: "opus", Bitrate: "24k"}, EncodingProfileFor(settings, DestinationSnippet))
+
+	ext := DestinationExtension(settings, DestinationSnippet)
+	assert.Equal(t, "flac", ext, "lossy snippets need FFmpeg and fall back to native FLAC")
+	settings.FfmpegPath = "/usr/bin/ffmpeg"
+	assert.Equal(t, "opus", DestinationExtension(settings, DestinationSnippet))
+	settings.FfmpegPath = ""
+
+	path := filepath.Join(t.TempDir(), "snippet."+ext)
+	require.NoError(t, SaveSnippet(context.Background(), make([]byte, 960), path, settings))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "fLaC", string(data[:4]))
+}
+
+func TestGetEncodingCapabilities(t *testing.T) {
+	t.Parallel()
+
+	settings := &conf.AudioSettings{Export: conf.ExportSettings{Type: "opus"}}
+	native := GetEncodingCapabilities(settings)
+	assert.Equal(t, EncoderNative, native.Encoder)
+	assert.Equal(t, []string{"wav", "flac"}, native.Formats)
+	assert.Equal(t, "flac", native.ClipType)
+	assert.False(t, native.Streaming, "HLS streaming needs FFmpeg")
+	assert.Equal(t, []string{"storage", "notification", "snippet"}, native.FLACFallback)
+
+	settings.Export.Type = "wav"
+	settings.Export.Destinations.Notification.Type = "mp3"
+	assert.Equal(t, []string{"notification"}, GetEncodingCapabilities(settings).FLACFallback)
+
+	settings.FfmpegPath = "/usr/bin/ffmpeg"
+	withFFmpeg := GetEncodingCapabilities(settings)
+	assert.Equal(t, EncoderFFmpeg, withFFmpeg.Encoder)
+	assert.Equal(t, "wav", withFFmpeg.ClipType)
+	assert.True(t, withFFmpeg.Streaming)
+	assert.Empty(t, withFFmpeg.FLACFallback)
+}
@@ -11,6 +11,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -27,6 +28,11 @@ const TempExt = ".temp"
 // outputPath is full path with audio file name and extension based on format
 // pcmData is the PCM data to export
 func ExportAudioWithFFmpeg(pcmData []byte, outputPath string, settings *conf.AudioSettings) error {
+	return exportAudioWithFFmpeg(pcmData, conf.SampleRate, outputPath, settings)
+}
+
+// exportAudioWithFFmpeg exports PCM data of the given sample rate like ExportAudioWithFFmpeg
+func exportAudioWithFFmpeg(pcmData []byte, sampleRate int, outputPath string, settings *conf.AudioSettings) error {
 	start := time.Now()
 
 	// Validate inputs
@@ -106,7 +112,7 @@ func ExportAudioWithFFmpeg(pcmData []byte, outputPath string, settings *conf.Aud
 	}
 
 	// Run the FFmpeg command to process the audio
-	if err := runFFmpegCommand(settings.FfmpegPath, pcmData, tempFilePath, settings); err != nil {
+	if err := runFFmpegCommand(settings.FfmpegPath, pcmData, sampleRate, tempFilePath, settings); err != nil {
 		enhancedErr := errors.New(err).
 			Component("myaudio").
 			Category(errors.CategorySystem).
@@ -269,9 +275,9 @@ func finalizeOutput(tempFilePath string) error {
 
 // runFFmpegCommand executes the FFmpeg command to process the audio
 // This version includes a context timeout to prevent hangs.
-func runFFmpegCommand(ffmpegPath string, pcmData []byte, tempFilePath string, settings *conf.AudioSettings) error {
+func runFFmpegCommand(ffmpegPath string, pcmData []byte, sampleRate int, tempFilePath string, settings *conf.AudioSettings) error {
 	// Build the FFmpeg command arguments
-	args := buildFFmpegArgs(tempFilePath, settings)
+	args := buildFFmpegArgs(tempFilePath, sampleRate, settings)
 
 	// Create a context with a timeout (e.g., 30 seconds)
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -349,13 +355,10 @@ func runFFmpegCommand(ffmpegPath string, pcmData []byte, tempFilePath string, se
 	return nil
 }
 
-// buildFFmpegArgs constructs the arguments for the FFmpeg command
-func buildFFmpegArgs(tempFilePath string, settings *conf.AudioSettings) []string {
-	ffmpegSampleRate, ffmpegNumChannels, ffmpegFormat := getFFmpegFormat(conf.SampleRate, conf.NumChannels, conf.BitDepth)
-
-	outputEncoder := getEncoder(settings.Export.Type)
-	outputFormat := getOutputFormat(settings.Export.Type)
-	outputBitrate := getMaxBitrate(settings.Export.Type, settings.Export.Bitrate)
+// buildFFmpegArgs constructs the arguments for the FFmpeg command encoding
+// PCM of the given sample rate
+func buildFFmpegArgs(tempFilePath string, sampleRate int, settings *conf.AudioSettings) []string {
+	ffmpegSampleRate, ffmpegNumChannels, ffmpegFormat := getFFmpegFormat(sampleRate, conf.NumChannels, conf.BitDepth)
 
 	args := []string{
 		"-hide_banner",     // Suppress FFmpeg banner output for cleaner logs
@@ -371,11 +374,9 @@ func buildFFmpegArgs(tempFilePath string, settings *conf.AudioSettings) []string
 		args = append(args, "-af", audioFilter)
 	}
 
-	// Add output encoding settings
+	// Add output encoding settings of the local storage profile
+	args = append(args, encoderArgs(EncodingProfileFor(settings, DestinationStorage))...)
 	args = append(args,
-		"-c:a", outputEncoder,
-		"-b:a", outputBitrate,
-		"-f", outputFormat, // Specify the output format
 		"-y",         // Overwrite output file if it exists
 		tempFilePath, // Write to the temporary file
 	)
@@ -446,13 +447,18 @@ func getOutputFormat(exportType string) string {
 
 // getMaxBitrate limits the bitrate to the maximum allowed by the format
 func getMaxBitrate(format, requestedBitrate string) string {
+	// Compare in kbps, as "32k" sorts after "256k" as a string
+	requested, err := strconv.Atoi(strings.TrimSuffix(requestedBitrate, "k"))
+	if err != nil {
+		return requestedBitrate
+	}
 	switch format {
 	case "opus":
-		if requestedBitrate > "256k" {
+		if requested > 256 {
 			return "256k"
 		}
 	case "mp3":
-		if requestedBitrate > "320k" {
+		if requested > 320 {
 			return "320k"
 		}
 	}
@@ -131,7 +131,7 @@ func TestBuildFFmpegArgs(t *testing.T) {
 		},
 	}
 
-	args := buildFFmpegArgs(tempFile, settings)
+	args := buildFFmpegArgs(tempFile, conf.SampleRate, settings)
 
 	// Verify -hide_banner is the first argument
 	if len(args) == 0 || args[0] != "-hide_banner" {
@@ -163,7 +163,7 @@ func TestBuildFFmpegArgs(t *testing.T) {
 	settings.Export.Normalization.TruePeak = -2.0
 	settings.Export.Normalization.LoudnessRange = 7.0
 
-	args = buildFFmpegArgs(tempFile, settings)
+	args = buildFFmpegArgs(tempFile, conf.SampleRate, settings)
 
 	foundLoudnorm := false
 	for i, arg := range args {
@@ -182,7 +182,7 @@ func TestBuildFFmpegArgs(t *testing.T) {
 	settings.Export.Gain = 0
 	settings.Export.Normalization.Enabled = false
 
-	args = buildFFmpegArgs(tempFile, settings)
+	args = buildFFmpegArgs(tempFile, conf.SampleRate, settings)
 
 	// Ensure -af flag is NOT present when no filters are needed
 	hasAudioFilter := false
@@ -206,6 +206,9 @@ func (m *FFmpegManager) StopStream(url string) error {
 			"operation", "stop_stream_buffer_cleanup")
 		log.Printf("⚠️ Warning: failed to remove capture buffer for %s: %v", privacy.SanitizeRTSPUrl(url), err)
 	}
+	if stream.source != nil {
+		removeOriginalRateBuffer(stream.source.ID)
+	}
 
 	managerLogger.Info("stopped FFmpeg stream",
 		"url", privacy.SanitizeRTSPUrl(url),
@@ -380,6 +383,32 @@ func (m *FFmpegManager) SyncWithConfig(audioChan chan UnifiedAudioData) error {
 			privacy.SanitizeRTSPUrl(change.url), change.newTransport)
 	}
 
+	// Restart streams whose capture sample rate changed, FFmpeg is started with it
+	restarted := make(map[string]bool, len(toRestart))
+	for _, change := range toRestart {
+		restarted[change.url] = true
+	}
+	m.streamsMu.RLock()
+	var rateChanged []string
+	for url, stream := range m.streams {
+		if _, configured := configuredURLs[url]; configured && !restarted[url] &&
+			stream.captureRateChanged(settings.Realtime.RTSP.CaptureRateFor(url)) {
+			rateChanged = append(rateChanged, url)
+		}
+	}
+	m.streamsMu.RUnlock()
+
+	for _, url := range rateChanged {
+		log.Printf("🔄 Capture sample rate changed for %s (restarting stream)", privacy.SanitizeRTSPUrl(url))
+		if err := m.RestartStream(url); err != nil {
+			managerLogger.Warn("failed to restart stream for capture rate change",
+				"url", privacy.SanitizeRTSPUrl(url),
+				"error", err,
+				"component", "ffmpeg-manager",
+				"operation", "sync_capture_rate_change")
+		}
+	}
+
 	// Stop streams that are no longer configured
 	m.streamsMu.RLock()
 	toStop := []string{}
@@ -365,6 +365,10 @@ type FFmpegStream struct {
 	// Process timing
 	processStartTime time.Time
 
+	// Decimation of audio captured above the analyzed sample rate, set when
+	// the process starts; nil when captured at the analyzed sample rate
+	highRate *highRateCapture
+
 	// Backoff for restarts
 	backoffDuration time.Duration
 	maxBackoff      time.Duration
@@ -793,8 +797,15 @@ func (s *FFmpegStream) startProcess() error {
 			Build()
 	}
 
+	// Streams captured above the analyzed sample rate are decimated for analysis
+	s.highRate = s.setupHighRateCapture()
+	captureRate := conf.SampleRate
+	if s.highRate != nil {
+		captureRate = s.highRate.sampleRate
+	}
+
 	// Get FFmpeg format settings
-	sampleRate, numChannels, format := getFFmpegFormat(conf.SampleRate, conf.NumChannels, conf.BitDepth)
+	sampleRate, numChannels, format := getFFmpegFormat(captureRate, conf.NumChannels, conf.BitDepth)
 
 	// Build FFmpeg command arguments
 	args := []string{
@@ -1282,8 +1293,48 @@ func (s *FFmpegStream) processAudio() error {
 	}
 }
 
+// setupHighRateCapture returns the decimation of the stream audio when the
+// stream is captured above the analyzed sample rate
+func (s *FFmpegStream) setupHighRateCapture() *highRateCapture {
+	connStr, err := s.source.GetConnectionString()
+	if err != nil {
+		return nil
+	}
+	rate := conf.Setting().Realtime.RTSP.CaptureRateFor(connStr)
+	highRate, err := newHighRateCapture(rate, conf.NumChannels, []string{s.source.ID})
+	if err != nil {
+		streamLogger.Warn("invalid capture sample rate, capturing at the analyzed sample rate",
+			"url", privacy.SanitizeRTSPUrl(s.source.SafeString),
+			"sample_rate", rate.SampleRate,
+			"error", err,
+			"operation", "setup_high_rate_capture")
+		return nil
+	}
+	return highRate
+}
+
+// captureRateChanged reports whether the configured capture rate of the
+// stream differs from the rate its FFmpeg process was started with
+func (s *FFmpegStream) captureRateChanged(rate conf.CaptureRateSettings) bool {
+	s.cmdMu.Lock()
+	defer s.cmdMu.Unlock()
+
+	if s.highRate == nil {
+		return rate.SampleRate != 0 && rate.SampleRate != conf.SampleRate
+	}
+	return rate.SampleRate != s.highRate.sampleRate || rate.ArchiveOriginal != s.highRate.archive
+}
+
 // handleAudioData processes a chunk of audio data
 func (s *FFmpegStream) handleAudioData(data []byte) error {
+	// Audio captured above the analyzed sample rate is decimated for analysis
+	if s.highRate != nil {
+		data = s.highRate.downsample(data, []*channelRoute{{sourceID: s.source.ID, channel: channelAsCaptured}})
+		if len(data) == 0 {
+			return nil
+		}
+	}
+
 	// Write to analysis buffer using source ID
 	if err := WriteToAnalysisBuffer(s.source.ID, data); err != nil {
 		return errors.New(fmt.Errorf("failed to write to analysis buffer: %w", err)).
@@ -0,0 +1,362 @@
+// flac_encoder.go: Pure Go FLAC encoder used when FFmpeg is not available
+package myaudio
+
+import (
+	"bytes"
+	"crypto/md5" // #nosec G501 -- MD5 of the audio is part of the FLAC format, not used for security
+	"encoding/binary"
+	"math/bits"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+const (
+	// flacBlockSize is the number of samples per frame
+	flacBlockSize = 4096
+	// flacMaxFixedOrder is the highest order of the fixed linear predictors
+	flacMaxFixedOrder = 4
+	// flacMaxRiceParameter is the largest Rice parameter of the 5-bit parameter coding
+	flacMaxRiceParameter = 30
+)
+
+// flacCRC8Table and flacCRC16Table are the frame header and frame checksum tables
+var flacCRC8Table, flacCRC16Table = func() (t8 [256]uint8, t16 [256]uint16) {
+	for i := range 256 {
+		c8 := uint8(i)        // #nosec G115 -- i < 256
+		c16 := uint16(i) << 8 // #nosec G115 -- i < 256
+		for range 8 {
+			if c8&0x80 != 0 {
+				c8 = c8<<1 ^ 0x07
+			} else {
+				c8 <<= 1
+			}
+			if c16&0x8000 != 0 {
+				c16 = c16<<1 ^ 0x8005
+			} else {
+				c16 <<= 1
+			}
+		}
+		t8[i], t16[i] = c8, c16
+	}
+	return t8, t16
+}()
+
+// flacBitWriter writes big-endian bit fields
+type flacBitWriter struct {
+	buf []byte
+	acc uint64
+	n   uint // bits pending in acc
+}
+
+// write writes the lowest n bits of v, n at most 32
+func (w *flacBitWriter) write(v uint64, n uint) {
+	w.acc = w.acc<<n | v&(1<<n-1)
+	w.n += n
+	for w.n >= 8 {
+		w.n -= 8
+		w.buf = append(w.buf, byte(w.acc>>w.n))
+	}
+}
+
+// writeUnary writes q zero bits followed by a one bit
+func (w *flacBitWriter) writeUnary(q uint64) {
+	for ; q >= 32; q -= 32 {
+		w.write(0, 32)
+	}
+	w.write(1, uint(q)+1)
+}
+
+// align pads with zero bits to the next byte boundary
+func (w *flacBitWriter) align() {
+	if w.n > 0 {
+		w.write(0, 8-w.n)
+	}
+}
+
+// EncodePCMtoFLAC encodes 16-bit PCM as FLAC in Go with the bit depth and
+// compression level of a profile
+func EncodePCMtoFLAC(pcmData []byte, profile conf.EncodingProfile) (*bytes.Buffer, error) {
+	if len(pcmData) == 0 {
+		return nil, errors.Newf("PCM data is empty for FLAC encoding").
+			Component("myaudio").
+			Category(errors.CategoryValidation).
+			Context("operation", "encode_pcm_to_flac").
+			Build()
+	}
+	return bytes.NewBuffer(encodeFLAC(pcmData, profile.BitDepth, profile.CompressionLevel, conf.SampleRate)), nil
+}
+
+// encodeFLAC encodes 16-bit mono PCM of the given sample rate as a FLAC stream
+// with 16 or 24 bits per sample. Higher compression levels search more
+// predictors and finer residual partitions.
+func encodeFLAC(pcmData []byte, bitDepth, compressionLevel, sampleRate int) []byte {
+	if bitDepth != 24 {
+		bitDepth = 16
+	}
+	samples := make([]int32, len(pcmData)/2)
+	for i := range samples {
+		samples[i] = int32(int16(binary.LittleEndian.Uint16(pcmData[i*2:]))) // #nosec G115 -- reinterpreting PCM bytes
+		if bitDepth == 24 {
+			samples[i] <<= 8
+		}
+	}
+
+	maxOrder, maxPartitionOrder := flacMaxFixedOrder, 8
+	switch {
+	case compressionLevel <= 2:
+		maxOrder, maxPartitionOrder = 2, 3
+	case compressionLevel <= 5:
+		maxPartitionOrder = 5
+	}
+
+	var frames []byte
+	minFrame, maxFrame := 0, 0
+	for start, number := 0, uint64(0); start < len(samples); start, number = start+flacBlockSize, number+1 {
+		frame := encodeFLACFrame(samples[start:min(start+flacBlockSize, len(samples))], number, bitDepth, maxOrder, maxPartitionOrder)
+		if minFrame == 0 || len(frame) < minFrame {
+			minFrame = len(frame)
+		}
+		maxFrame = max(maxFrame, len(frame))
+		frames = append(frames, frame...)
+	}
+
+	blockSize := uint64(min(flacBlockSize, max(len(samples), 16))) // #nosec G115 -- positive
+	w := &flacBitWriter{buf: make([]byte, 0, 42+len(frames))}
+	w.buf = append(w.buf, "fLaC"...)
+	w.write(1, 1)   // last metadata block
+	w.write(0, 7)   // STREAMINFO
+	w.write(34, 24) // block length
+	w.write(blockSize, 16)
+	w.write(blockSize, 16)
+	w.write(uint64(minFrame), 24) // #nosec G115 -- frame sizes are positive
+	w.write(uint64(maxFrame), 24) // #nosec G115 -- frame sizes are positive
+	w.write(uint64(sampleRate), 20) // #nosec G115 -- sample rates are positive
+	w.write(conf.NumChannels-1, 3)
+	w.write(uint64(bitDepth-1), 5) // #nosec G115 -- 16 or 24
+	total := uint64(len(samples))
+	w.write(total>>32, 4)
+	w.write(total, 32)
+	w.buf = append(w.buf, flacMD5(samples, bitDepth)...)
+	return append(w.buf, frames...)
+}
+
+// flacMD5 returns the MD5 of the samples as little-endian bytes of the bit depth
+func flacMD5(samples []int32, bitDepth int) []byte {
+	bytesPerSample := bitDepth / 8
+	raw := make([]byte, len(samples)*bytesPerSample)
+	for i, s := range samples {
+		for b := range bytesPerSample {
+			raw[i*bytesPerSample+b] = byte(s >> (8 * b))
+		}
+	}
+	sum := md5.Sum(raw) // #nosec G401 -- part of the FLAC format
+	return sum[:]
+}
+
+// encodeFLACFrame encodes one block of samples as a FLAC frame
+func encodeFLACFrame(block []int32, number uint64, bitDepth, maxOrder, maxPartitionOrder int) []byte {
+	w := &flacBitWriter{buf: make([]byte, 0, len(block)*bitDepth/8+32)}
+
+	// Frame header
+	w.write(0x3ffe, 14) // sync code
+	w.write(0, 1)       // reserved
+	w.write(0, 1)       // fixed block size
+	w.write(0x7, 4)     // block size in a 16-bit field at the end of the header
+	w.write(0, 4)       // sample rate from STREAMINFO
+	w.write(conf.NumChannels-1, 4)
+	if bitDepth == 24 {
+		w.write(0x6, 3)
+	} else {
+		w.write(0x4, 3)
+	}
+	w.write(0, 1) // reserved
+	writeFLACNumber(w, number)
+	w.write(uint64(len(block)-1), 16) // #nosec G115 -- block sizes are positive
+	var crc8 uint8
+	for _, b := range w.buf {
+		crc8 = flacCRC8Table[crc8^b]
+	}
+	w.write(uint64(crc8), 8)
+
+	writeFLACSubframe(w, block, bitDepth, maxOrder, maxPartitionOrder)
+
+	// Frame footer
+	w.align()
+	var crc16 uint16
+	for _, b := range w.buf {
+		crc16 = crc16<<8 ^ flacCRC16Table[byte(crc16>>8)^b]
+	}
+	w.write(uint64(crc16), 16)
+	return w.buf
+}
+
+// writeFLACNumber writes a frame number in the UTF-8 like coding of FLAC
+func writeFLACNumber(w *flacBitWriter, v uint64) {
+	if v < 0x80 {
+		w.write(v, 8)
+		return
+	}
+	n := uint(2)
+	for limit := uint64(0x800); v >= limit && n < 7; limit <<= 5 {
+		n++
+	}
+	w.write(uint64(0xff<<(8-n))&0xff|v>>(6*(n-1)), 8)
+	for i := int(n) - 2; i >= 0; i-- {
+		w.write(0x80|v>>(6*uint(i))&0x3f, 8) // #nosec G115 -- i is not negative
+	}
+}
+
+// writeFLACSubframe writes the smallest of a constant, fixed predictor or verbatim subframe
+func writeFLACSubframe(w *flacBitWriter, block []int32, bitDepth, maxOrder, maxPartitionOrder int) {
+	constant := true
+	for _, s := range block[1:] {
+		if s != block[0] {
+			constant = false
+			break
+		}
+	}
+	if constant {
+		w.write(0, 8)                                     // padding, CONSTANT type, no wasted bits
+		w.write(uint64(uint32(block[0])), uint(bitDepth)) // #nosec G115 -- two's complement of the sample
+		return
+	}
+
+	bestOrder, bestBits := -1, len(block)*bitDepth // verbatim
+	var bestResidual []int32
+	var bestPartitionOrder int
+	var bestParameters []int
+	for order := 0; order <= maxOrder && order < len(block); order++ {
+		residual := fixedResidual(block, order)
+		partitionOrder, parameters, cost := riceParameters(residual, len(block), order, maxPartitionOrder)
+		cost += order * bitDepth
+		if cost < bestBits {
+			bestOrder, bestBits = order, cost
+			bestResidual, bestPartitionOrder, bestParameters = residual, partitionOrder, parameters
+		}
+	}
+
+	if bestOrder < 0 {
+		w.write(0x02, 8) // padding, VERBATIM type, no wasted bits
+		for _, s := range block {
+			w.write(uint64(uint32(s)), uint(bitDepth)) // #nosec G115 -- two's complement of the sample
+		}
+		return
+	}
+
+	w.write(uint64(0x08|bestOrder)<<1, 8) // padding, FIXED type with order, no wasted bits
+	for _, s := range block[:bestOrder] {
+		w.write(uint64(uint32(s)), uint(bitDepth)) // #nosec G115 -- two's complement of the warm-up sample
+	}
+
+	// Residual: 4-bit Rice parameters unless a partition needs a larger one
+	parameterBits := uint(4)
+	for _, k := range bestParameters {
+		if k > 14 {
+			parameterBits = 5
+		}
+	}
+	w.write(uint64(parameterBits-4), 2)
+	w.write(uint64(bestPartitionOrder), 4) // #nosec G115 -- at most 8
+	partitionSize := len(block) >> bestPartitionOrder
+	pos := 0
+	for p, k := range bestParameters {
+		count := partitionSize
+		if p == 0 {
+			count -= bestOrder
+		}
+		w.write(uint64(k), parameterBits) // #nosec G115 -- Rice parameters are small
+		for _, r := range bestResidual[pos : pos+count] {
+			u := uint64(zigzag(r))
+			w.writeUnary(u >> k)
+			if k > 0 {
+				w.write(u, uint(k))
+			}
+		}
+		pos += count
+	}
+}
+
+// fixedResidual returns the residual of a fixed linear predictor of the given order
+func fixedResidual(block []int32, order int) []int32 {
+	residual := make([]int32, len(block)-order)
+	x := block
+	for i := order; i < len(block); i++ {
+		var r int32
+		switch order {
+		case 0:
+			r = x[i]
+		case 1:
+			r = x[i] - x[i-1]
+		case 2:
+			r = x[i] - 2*x[i-1] + x[i-2]
+		case 3:
+			r = x[i] - 3*x[i-1] + 3*x[i-2] - x[i-3]
+		case 4:
+			r = x[i] - 4*x[i-1] + 6*x[i-2] - 4*x[i-3] + x[i-4]
+		}
+		residual[i-order] = r
+	}
+	return residual
+}
+
+// zigzag maps signed residuals to unsigned values, 0, -1, 1, -2 to 0, 1, 2, 3
+func zigzag(r int32) uint32 {
+	return uint32(r<<1) ^ uint32(r>>31) // #nosec G115 -- bit reinterpretation
+}
+
+// riceParameters chooses the partition order and Rice parameter of each
+// partition of a residual, returning them with the estimated size in bits
+func riceParameters(residual []int32, blockSize, order, maxPartitionOrder int) (partitionOrder int, parameters []int, cost int) {
+	cost = -1
+	for p := 0; p <= maxPartitionOrder; p++ {
+		partitionSize := blockSize >> p
+		if blockSize%(1<<p) != 0 || partitionSize <= order {
+			break
+		}
+
+		params := make([]int, 1<<p)
+		total := 6 // coding method and partition order
+		pos := 0
+		for i := range params {
+			count := partitionSize
+			if i == 0 {
+				count -= order
+			}
+			var sum uint64
+			for _, r := range residual[pos : pos+count] {
+				sum += uint64(zigzag(r))
+			}
+			pos += count
+			k, bitsNeeded := riceParameter(sum, count)
+			params[i] = k
+			total += 5 + bitsNeeded
+		}
+		if cost < 0 || total < cost {
+			partitionOrder, parameters, cost = p, params, total
+		}
+	}
+	return partitionOrder, parameters, cost
+}
+
+// riceParameter returns the Rice parameter for count values summing to sum
+// and the estimated bits needed
+func riceParameter(sum uint64, count int) (k, cost int) {
+	if count == 0 {
+		return 0, 0
+	}
+	// The best parameter is close to log2 of the mean value
+	mean := sum / uint64(count)
+	guess := 0
+	if mean > 0 {
+		guess = bits.Len64(mean) - 1
+	}
+	cost = -1
+	for candidate := max(guess-1, 0); candidate <= min(guess+1, flacMaxRiceParameter); candidate++ {
+		c := count*(candidate+1) + int(sum>>candidate) // #nosec G115 -- estimated sizes fit in int
+		if cost < 0 || c < cost {
+			k, cost = candidate, c
+		}
+	}
+	return k, cost
+}
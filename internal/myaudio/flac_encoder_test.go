@@ -0,0 +1,100 @@
+package myaudio
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/flac"
+)
+
+// decodeFLACFile decodes a FLAC file to samples and returns them with the bit depth
+func decodeFLACFile(t *testing.T, path string) (samples []int32, bitDepth int) {
+	t.Helper()
+	file, err := os.Open(path) // #nosec G304 -- test file in a temporary directory
+	require.NoError(t, err)
+	defer func() { _ = file.Close() }()
+
+	decoder, err := flac.NewDecoder(file)
+	require.NoError(t, err)
+	require.Equal(t, conf.SampleRate, decoder.SampleRate)
+	require.Equal(t, 1, decoder.NChannels)
+
+	bytesPerSample := decoder.BitsPerSample / 8
+	for {
+		frame, err := decoder.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		for i := 0; i+bytesPerSample <= len(frame); i += bytesPerSample {
+			var sample int32
+			if bytesPerSample == 3 {
+				sample = int32(uint32(frame[i])|uint32(frame[i+1])<<8|uint32(frame[i+2])<<16) << 8 >> 8 // #nosec G115 -- sign extension of 24-bit samples
+			} else {
+				sample = int32(int16(binary.LittleEndian.Uint16(frame[i:]))) // #nosec G115 -- reinterpreting PCM bytes
+			}
+			samples = append(samples, sample)
+		}
+	}
+	return samples, decoder.BitsPerSample
+}
+
+func TestEncodeFLAC_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	// Noise with a tone, and silence encoded as constant subframes
+	pcm := toneWithNoise(2.5, 0.5, 1, 1000, 0.3)
+	clear(pcm[:conf.SampleRate/2])
+
+	tests := []struct {
+		name             string
+		bitDepth         int
+		compressionLevel int
+	}{
+		{"16-bit fast", 16, 0},
+		{"16-bit default", 16, 5},
+		{"24-bit best", 24, 8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "clip.flac")
+			encoded := encodeFLAC(pcm, tt.bitDepth, tt.compressionLevel, conf.SampleRate)
+			require.NoError(t, os.WriteFile(path, encoded, 0o600))
+			assert.Less(t, len(encoded), len(pcm)*tt.bitDepth/16, "FLAC should compress the signal")
+
+			samples, bitDepth := decodeFLACFile(t, path)
+			assert.Equal(t, tt.bitDepth, bitDepth)
+			require.Len(t, samples, len(pcm)/2)
+			for i, sample := range samples {
+				want := int32(int16(binary.LittleEndian.Uint16(pcm[i*2:]))) // #nosec G115 -- reinterpreting PCM bytes
+				if tt.bitDepth == 24 {
+					want <<= 8
+				}
+				if sample != want {
+					t.Fatalf("sample %d: got %d, want %d", i, sample, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSaveClip_WithoutFFmpeg(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "clip.flac")
+	settings := &conf.AudioSettings{Export: conf.ExportSettings{Type: "flac", BitDepth: 16, CompressionLevel: 5}}
+	pcm := toneWithNoise(1, 0, 1, 440, 0.5)
+	require.NoError(t, SaveClip(pcm, path, settings))
+
+	samples, _ := decodeFLACFile(t, path)
+	assert.Len(t, samples, len(pcm)/2)
+}
@@ -0,0 +1,135 @@
+// pcm_conversion.go: conversion kernels between little-endian PCM and float samples
+package myaudio
+
+import (
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// Scales from PCM integers to floats in [-1, 1). They are powers of two, so
+// multiplying gives the same result as dividing by the full scale value.
+const (
+	pcm16Scale = 1.0 / 32768.0
+	pcm24Scale = 1.0 / 8388608.0
+	pcm32Scale = 1.0 / 2147483648.0
+)
+
+// PCMToFloat32 converts little-endian PCM samples of the given bit depth into
+// dst and returns the number of samples written, which is limited by the
+// length of dst. It writes into a caller owned buffer, such as the model
+// input tensor, so that no intermediate float32 buffer is needed.
+func PCMToFloat32(dst []float32, pcm []byte, bitDepth int) (int, error) {
+	switch bitDepth {
+	case 16:
+		return pcm16ToFloat(dst, pcm), nil
+	case 24:
+		return pcm24ToFloat32(dst, pcm), nil
+	case 32:
+		return pcm32ToFloat32(dst, pcm), nil
+	default:
+		return 0, errors.Newf("unsupported audio bit depth: %d", bitDepth).
+			Component("myaudio").
+			Category(errors.CategoryValidation).
+			Context("operation", "convert_to_float32").
+			Context("bit_depth", bitDepth).
+			Context("supported_bit_depths", "16,24,32").
+			Build()
+	}
+}
+
+// PCMInput returns a function that fills a model input with the PCM samples,
+// for birdnet.PredictWithInput. The input beyond the samples is zeroed, as
+// the tensor still holds the previous sample.
+func PCMInput(pcm []byte, bitDepth int) func(input []float32) error {
+	return func(input []float32) error {
+		n, err := PCMToFloat32(input, pcm, bitDepth)
+		if err != nil {
+			return err
+		}
+		clear(input[n:])
+		return nil
+	}
+}
+
+// PCMSampleCount returns the number of samples in PCM data of the given bit depth
+func PCMSampleCount(pcm []byte, bitDepth int) int {
+	if bitDepth < 8 {
+		return 0
+	}
+	return len(pcm) / (bitDepth / 8)
+}
+
+// The kernels below are written so that the compiler can drop bounds checks:
+// both slices are resliced to the converted length up front and the main
+// loop works on fixed size blocks of four samples, leaving independent
+// operations for the CPU to run in parallel.
+
+// pcm16ToFloat converts 16-bit PCM samples in src to floats in dst and
+// returns the number of samples converted
+func pcm16ToFloat[F float32 | float64](dst []F, src []byte) int {
+	n := min(len(dst), len(src)/2)
+	dst, src = dst[:n], src[:n*2]
+
+	i := 0
+	for ; i <= n-4; i += 4 {
+		s := src[i*2 : i*2+8 : i*2+8]
+		d := dst[i : i+4 : i+4]
+		d[0] = F(int16(uint16(s[0])|uint16(s[1])<<8)) * pcm16Scale // #nosec G115 -- reinterpreting PCM bytes
+		d[1] = F(int16(uint16(s[2])|uint16(s[3])<<8)) * pcm16Scale // #nosec G115 -- reinterpreting PCM bytes
+		d[2] = F(int16(uint16(s[4])|uint16(s[5])<<8)) * pcm16Scale // #nosec G115 -- reinterpreting PCM bytes
+		d[3] = F(int16(uint16(s[6])|uint16(s[7])<<8)) * pcm16Scale // #nosec G115 -- reinterpreting PCM bytes
+	}
+	for ; i < n; i++ {
+		dst[i] = F(int16(uint16(src[i*2])|uint16(src[i*2+1])<<8)) * pcm16Scale // #nosec G115 -- reinterpreting PCM bytes
+	}
+	return n
+}
+
+// floatToPCM16 converts floats in src to 16-bit PCM samples in dst, clamping
+// them to [-1, 1], and returns the number of samples converted
+func floatToPCM16[F float32 | float64](dst []byte, src []F) int {
+	n := min(len(dst)/2, len(src))
+	dst, src = dst[:n*2], src[:n]
+
+	for i, v := range src {
+		v = max(-1, min(1, v))
+		s := uint16(int16(v * 32767)) // #nosec G115 -- clamped to the 16-bit range
+		d := dst[i*2 : i*2+2 : i*2+2]
+		d[0], d[1] = byte(s), byte(s>>8)
+	}
+	return n
+}
+
+// pcm24ToFloat32 converts packed 24-bit PCM samples in src to float32 samples
+// in dst and returns the number of samples converted
+func pcm24ToFloat32(dst []float32, src []byte) int {
+	n := min(len(dst), len(src)/3)
+	dst, src = dst[:n], src[:n*3]
+
+	for i := range dst {
+		s := src[i*3 : i*3+3 : i*3+3]
+		// Shift the sample into the top bits and back for sign extension
+		v := int32(uint32(s[0])<<8|uint32(s[1])<<16|uint32(s[2])<<24) >> 8 // #nosec G115 -- reinterpreting PCM bytes
+		dst[i] = float32(v) * pcm24Scale
+	}
+	return n
+}
+
+// pcm32ToFloat32 converts 32-bit PCM samples in src to float32 samples in dst
+// and returns the number of samples converted
+func pcm32ToFloat32(dst []float32, src []byte) int {
+	n := min(len(dst), len(src)/4)
+	dst, src = dst[:n], src[:n*4]
+
+	i := 0
+	for ; i <= n-2; i += 2 {
+		s := src[i*4 : i*4+8 : i*4+8]
+		d := dst[i : i+2 : i+2]
+		d[0] = float32(int32(uint32(s[0])|uint32(s[1])<<8|uint32(s[2])<<16|uint32(s[3])<<24)) * pcm32Scale // #nosec G115 -- reinterpreting PCM bytes
+		d[1] = float32(int32(uint32(s[4])|uint32(s[5])<<8|uint32(s[6])<<16|uint32(s[7])<<24)) * pcm32Scale // #nosec G115 -- reinterpreting PCM bytes
+	}
+	for ; i < n; i++ {
+		s := src[i*4 : i*4+4 : i*4+4]
+		dst[i] = float32(int32(uint32(s[0])|uint32(s[1])<<8|uint32(s[2])<<16|uint32(s[3])<<24)) * pcm32Scale // #nosec G115 -- reinterpreting PCM bytes
+	}
+	return n
+}
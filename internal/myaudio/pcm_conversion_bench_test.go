@@ -0,0 +1,86 @@
+package myaudio
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+// benchmarkPCM returns 3 seconds of PCM audio at the given bit depth
+func benchmarkPCM(bitDepth int) []byte {
+	pcm := make([]byte, conf.SampleRate*conf.CaptureLength*bitDepth/8)
+	for i := range pcm {
+		pcm[i] = byte(i * 7)
+	}
+	return pcm
+}
+
+// BenchmarkPCMToFloat32 measures converting a chunk into a preallocated
+// buffer, as done when filling the model input tensor. It must not allocate.
+func BenchmarkPCMToFloat32(b *testing.B) {
+	for _, bitDepth := range []int{16, 24, 32} {
+		b.Run(strconv.Itoa(bitDepth)+"bit", func(b *testing.B) {
+			pcm := benchmarkPCM(bitDepth)
+			input := make([]float32, conf.SampleRate*conf.CaptureLength)
+			b.SetBytes(int64(len(pcm)))
+			b.ReportAllocs()
+
+			for b.Loop() {
+				if _, err := PCMToFloat32(input, pcm, bitDepth); err != nil {
+					b.Fatal(err)
+				}
+			}
+			benchResult = input
+		})
+	}
+}
+
+// BenchmarkModelInputFill compares the previous path, converting to a new
+// buffer and copying it into the input tensor, with converting in place
+func BenchmarkModelInputFill(b *testing.B) {
+	pcm := benchmarkPCM(16)
+	input := make([]float32, conf.SampleRate*conf.CaptureLength)
+
+	b.Run("ConvertAndCopy", func(b *testing.B) {
+		originalPool := float32Pool
+		float32Pool = nil
+		defer func() { float32Pool = originalPool }()
+		b.SetBytes(int64(len(pcm)))
+		b.ReportAllocs()
+
+		for b.Loop() {
+			sample, err := ConvertToFloat32(pcm, 16)
+			if err != nil {
+				b.Fatal(err)
+			}
+			copy(input, sample[0])
+		}
+	})
+
+	b.Run("InPlace", func(b *testing.B) {
+		fill := PCMInput(pcm, 16)
+		b.SetBytes(int64(len(pcm)))
+		b.ReportAllocs()
+
+		for b.Loop() {
+			if err := fill(input); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	benchResult = input
+}
+
+// BenchmarkFloatToPCM16 measures converting filtered samples back to PCM
+func BenchmarkFloatToPCM16(b *testing.B) {
+	pcm := benchmarkPCM(16)
+	samples := make([]float64, len(pcm)/2)
+	pcm16ToFloat(samples, pcm)
+	b.SetBytes(int64(len(pcm)))
+	b.ReportAllocs()
+
+	for b.Loop() {
+		floatToPCM16(pcm, samples)
+	}
+}
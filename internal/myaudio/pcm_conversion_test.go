@@ -0,0 +1,116 @@
+package myaudio
+
+import (
+	"encoding/binary"
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPCM16ToFloat_MatchesDivision(t *testing.T) {
+	t.Parallel()
+
+	// Every 16-bit value, with an odd count to cover the tail loop
+	pcm := make([]byte, 0, 65537*2)
+	for v := math.MinInt16; v <= math.MaxInt16; v++ {
+		pcm = binary.LittleEndian.AppendUint16(pcm, uint16(int16(v))) // #nosec G115 -- test values within 16-bit range
+	}
+	pcm = append(pcm, 0x00, 0x40)
+	count := len(pcm) / 2
+
+	out32 := make([]float32, count)
+	out64 := make([]float64, count)
+	require.Equal(t, count, pcm16ToFloat(out32, pcm))
+	require.Equal(t, count, pcm16ToFloat(out64, pcm))
+	for i := range count {
+		s := int16(binary.LittleEndian.Uint16(pcm[i*2:])) // #nosec G115 -- reinterpreting PCM bytes
+		if out32[i] != float32(s)/32768.0 || out64[i] != float64(s)/32768.0 {
+			t.Fatalf("sample %d (%d): got %v and %v", i, s, out32[i], out64[i])
+		}
+	}
+}
+
+func TestPCMToFloat32_WiderSamples(t *testing.T) {
+	t.Parallel()
+
+	rng := rand.New(rand.NewPCG(1, 2)) // #nosec G404 -- deterministic test data
+	pcm24 := []byte{0x00, 0x00, 0x80, 0xFF, 0xFF, 0x7F, 0xFF, 0xFF, 0xFF}
+	pcm32 := []byte{0x00, 0x00, 0x00, 0x80, 0xFF, 0xFF, 0xFF, 0x7F, 0xFF, 0xFF, 0xFF, 0xFF}
+	for range 1001 {
+		v := rng.Uint32()
+		pcm24 = append(pcm24, byte(v), byte(v>>8), byte(v>>16))
+		pcm32 = binary.LittleEndian.AppendUint32(pcm32, v)
+	}
+
+	out := make([]float32, len(pcm24)/3)
+	n, err := PCMToFloat32(out, pcm24, 24)
+	require.NoError(t, err)
+	require.Equal(t, len(out), n)
+	for i := range n {
+		s := int32(pcm24[i*3]) | int32(pcm24[i*3+1])<<8 | int32(pcm24[i*3+2])<<16
+		if s&0x00800000 != 0 {
+			s |= ^0x00FFFFFF
+		}
+		require.Equal(t, float32(s)/8388608.0, out[i], "24-bit sample %d", i)
+	}
+	assert.Equal(t, []float32{-1, 8388607.0 / 8388608.0, -1.0 / 8388608.0}, out[:3])
+
+	out = make([]float32, len(pcm32)/4)
+	n, err = PCMToFloat32(out, pcm32, 32)
+	require.NoError(t, err)
+	require.Equal(t, len(out), n)
+	for i := range n {
+		s := int32(binary.LittleEndian.Uint32(pcm32[i*4:])) // #nosec G115 -- reinterpreting PCM bytes
+		require.Equal(t, float32(s)/2147483648.0, out[i], "32-bit sample %d", i)
+	}
+
+	_, err = PCMToFloat32(out, pcm32, 8)
+	require.Error(t, err)
+}
+
+func TestPCMInput(t *testing.T) {
+	t.Parallel()
+
+	pcm := []byte{0x00, 0x40, 0x00, 0xC0, 0xFF, 0x7F}
+	assert.Equal(t, 3, PCMSampleCount(pcm, 16))
+	assert.Equal(t, 0, PCMSampleCount(pcm, 0))
+
+	// The rest of a larger input is cleared of the previous sample
+	input := []float32{9, 9, 9, 9, 9}
+	require.NoError(t, PCMInput(pcm, 16)(input))
+	assert.Equal(t, []float32{0.5, -0.5, 32767.0 / 32768.0, 0, 0}, input)
+
+	// A smaller input takes the first samples
+	input = []float32{9, 9}
+	require.NoError(t, PCMInput(pcm, 16)(input))
+	assert.Equal(t, []float32{0.5, -0.5}, input)
+
+	require.Error(t, PCMInput(pcm, 12)(input))
+}
+
+func TestFloatToPCM16(t *testing.T) {
+	t.Parallel()
+
+	samples := []float64{0, 0.5, -0.5, 1, -1, 1.5, -7}
+	pcm := make([]byte, len(samples)*2)
+	require.Equal(t, len(samples), floatToPCM16(pcm, samples))
+
+	want := []int16{0, 16383, -16383, 32767, -32767, 32767, -32767}
+	for i, w := range want {
+		assert.Equal(t, w, int16(binary.LittleEndian.Uint16(pcm[i*2:])), "sample %d", i) // #nosec G115 -- reinterpreting PCM bytes
+	}
+
+	// Round trip through float32 keeps the samples within one step
+	back := make([]float32, len(samples))
+	pcm16ToFloat(back, pcm)
+	pcm2 := make([]byte, len(pcm))
+	floatToPCM16(pcm2, back)
+	for i := range want {
+		a := int16(binary.LittleEndian.Uint16(pcm[i*2:]))  // #nosec G115 -- reinterpreting PCM bytes
+		b := int16(binary.LittleEndian.Uint16(pcm2[i*2:])) // #nosec G115 -- reinterpreting PCM bytes
+		assert.InDelta(t, a, b, 1, "sample %d", i)
+	}
+}
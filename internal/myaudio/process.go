@@ -2,6 +2,7 @@
 package myaudio
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"sync"
@@ -19,8 +20,32 @@ var (
 	processMetricsMutex sync.RWMutex            // Mutex for thread-safe access to processMetrics
 	processMetricsOnce  sync.Once               // Ensures metrics are only set once
 	float32Pool         *Float32Pool            // Global pool for float32 conversion buffers
+	shadowAnalyzer      ShadowAnalyzer          // Candidate model receiving the analyzed audio, nil when disabled
+	shadowAnalyzerMutex sync.RWMutex            // Mutex for thread-safe access to shadowAnalyzer
 )
 
+// ShadowAnalyzer receives the audio analyzed by the production model, so a
+// candidate model can analyze it in parallel. Submit must not block and must
+// not retain data after returning.
+type ShadowAnalyzer interface {
+	Submit(data []byte, startTime time.Time, source string)
+}
+
+// SetShadowAnalyzer sets the candidate model receiving the analyzed audio, or
+// removes it when nil
+func SetShadowAnalyzer(analyzer ShadowAnalyzer) {
+	shadowAnalyzerMutex.Lock()
+	defer shadowAnalyzerMutex.Unlock()
+	shadowAnalyzer = analyzer
+}
+
+// getShadowAnalyzer returns the candidate model receiving the analyzed audio
+func getShadowAnalyzer() ShadowAnalyzer {
+	shadowAnalyzerMutex.RLock()
+	defer shadowAnalyzerMutex.RUnlock()
+	return shadowAnalyzer
+}
+
 const (
 	// Float32BufferSize is the number of float32 samples in a standard buffer
 	// For 16-bit audio: conf.BufferSize / 2 (bytes per sample) = 144384 samples
@@ -68,24 +93,16 @@ func ReturnFloat32Buffer(buffer []float32) {
 // processData processes the given audio data to detect bird species, logs the detected species
 // and optionally saves the audio clip if a bird species is detected above the configured threshold.
 func ProcessData(bn *birdnet.BirdNET, data []byte, startTime time.Time, source string) error {
-	// get current time to track processing time
-	predictStart := time.Now()
-
-	// convert audio data to float32
-	sampleData, err := ConvertToFloat32(data, conf.BitDepth)
-	if err != nil {
-		return fmt.Errorf("error converting %v bit PCM data to float32: %w", conf.BitDepth, err)
+	// Hand the audio to the candidate model before ownership moves to the results queue
+	if analyzer := getShadowAnalyzer(); analyzer != nil {
+		analyzer.Submit(data, startTime, source)
 	}
 
-	// run BirdNET inference
-	results, err := bn.Predict(sampleData)
-
-	// Return float32 buffer to pool after prediction
-	// This is safe because Predict copies the data to the input tensor
-	if conf.BitDepth == 16 && len(sampleData) > 0 && len(sampleData[0]) == Float32BufferSize {
-		ReturnFloat32Buffer(sampleData[0])
-	}
+	// get current time to track processing time
+	predictStart := time.Now()
 
+	// run BirdNET inference, converting the PCM data straight into the input tensor
+	results, err := bn.PredictWithInput(context.Background(), PCMSampleCount(data, conf.BitDepth), PCMInput(data, conf.BitDepth))
 	if err != nil {
 		return fmt.Errorf("error predicting species: %w", err)
 	}
@@ -218,43 +235,20 @@ func convert16BitToFloat32(sample []byte) []float32 {
 		float32Data = make([]float32, length)
 	}
 
-	divisor := float32(32768.0)
-
-	for i := 0; i < length; i++ {
-		sample := int16(sample[i*2]) | int16(sample[i*2+1])<<8
-		float32Data[i] = float32(sample) / divisor
-	}
-
+	pcm16ToFloat(float32Data, sample)
 	return float32Data
 }
 
 // convert24BitToFloat32 converts 24-bit sample to float32 values.
 func convert24BitToFloat32(sample []byte) []float32 {
-	length := len(sample) / 3
-	float32Data := make([]float32, length)
-	divisor := float32(8388608.0)
-
-	for i := 0; i < length; i++ {
-		sample := int32(sample[i*3]) | int32(sample[i*3+1])<<8 | int32(sample[i*3+2])<<16
-		if (sample & 0x00800000) > 0 {
-			sample |= ^0x00FFFFFF // Two's complement sign extension
-		}
-		float32Data[i] = float32(sample) / divisor
-	}
-
+	float32Data := make([]float32, len(sample)/3)
+	pcm24ToFloat32(float32Data, sample)
 	return float32Data
 }
 
 // convert32BitToFloat32 converts 32-bit sample to float32 values.
 func convert32BitToFloat32(sample []byte) []float32 {
-	length := len(sample) / 4
-	float32Data := make([]float32, length)
-	divisor := float32(2147483648.0)
-
-	for i := 0; i < length; i++ {
-		sample := int32(sample[i*4]) | int32(sample[i*4+1])<<8 | int32(sample[i*4+2])<<16 | int32(sample[i*4+3])<<24
-		float32Data[i] = float32(sample) / divisor
-	}
-
+	float32Data := make([]float32, len(sample)/4)
+	pcm32ToFloat32(float32Data, sample)
 	return float32Data
 }
@@ -0,0 +1,124 @@
+// quality.go: Per-minute audio statistics for the daily data quality report
+package myaudio
+
+import (
+	"encoding/binary"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+const (
+	// qualityClipLevel is the absolute 16-bit sample value at or above which a sample counts as clipped
+	qualityClipLevel = 32700
+	// qualityRetention is how long minute statistics are kept, covering the previous day
+	qualityRetention = 48 * time.Hour
+)
+
+// AudioQualityMinute holds the audio statistics of one source for one minute
+type AudioQualityMinute struct {
+	Start      time.Time
+	Samples    int64          // samples received
+	Clipped    int64          // samples at or near full scale
+	SumSquares float64        // sum of squared normalized samples, for the RMS level
+	Spectrum   *SpectralStats // statistics of the first frame of the minute, nil when the minute had too few samples
+}
+
+// RMSdBFS returns the RMS level of the minute in dBFS
+func (m *AudioQualityMinute) RMSdBFS() float64 {
+	if m.Samples == 0 || m.SumSquares == 0 {
+		return math.Inf(-1)
+	}
+	return 10 * math.Log10(m.SumSquares/float64(m.Samples))
+}
+
+// audioQualityTracker collects minute statistics of all audio sources
+type audioQualityTracker struct {
+	mu      sync.Mutex
+	since   time.Time
+	sources map[string][]AudioQualityMinute // minutes in ascending order
+	frames  map[string][]float64            // samples collected for the spectrum of the current minute
+}
+
+var qualityTracker = &audioQualityTracker{
+	since:   time.Now(),
+	sources: make(map[string][]AudioQualityMinute),
+	frames:  make(map[string][]float64),
+}
+
+// recordAudioQuality adds 16-bit PCM audio received from a source to the minute statistics
+func recordAudioQuality(sourceID string, data []byte, now time.Time) {
+	if len(data) < 2 {
+		return
+	}
+
+	minute := now.Truncate(time.Minute)
+	qualityTracker.mu.Lock()
+	defer qualityTracker.mu.Unlock()
+
+	minutes := qualityTracker.sources[sourceID]
+	frame := qualityTracker.frames[sourceID]
+	if n := len(minutes); n == 0 || !minutes[n-1].Start.Equal(minute) {
+		// Drop minutes past retention when a new minute starts
+		cutoff := minute.Add(-qualityRetention)
+		drop := 0
+		for drop < len(minutes) && minutes[drop].Start.Before(cutoff) {
+			drop++
+		}
+		minutes = append(minutes[drop:], AudioQualityMinute{Start: minute})
+		if frame == nil {
+			frame = make([]float64, 0, spectrumFrameSize)
+		}
+		frame = frame[:0]
+	}
+	last := &minutes[len(minutes)-1]
+	collect := last.Spectrum == nil
+
+	for i := 0; i+1 < len(data); i += 2 {
+		sample := int16(binary.LittleEndian.Uint16(data[i:])) // #nosec G115 -- reinterpreting PCM bytes
+		if sample >= qualityClipLevel || sample <= -qualityClipLevel {
+			last.Clipped++
+		}
+		v := float64(sample) / 32768
+		last.SumSquares += v * v
+		last.Samples++
+		if collect && len(frame) < spectrumFrameSize {
+			frame = append(frame, v)
+		}
+	}
+	if collect && len(frame) == spectrumFrameSize {
+		stats := measureSpectrum(frame, conf.SampleRate)
+		last.Spectrum = &stats
+	}
+
+	qualityTracker.sources[sourceID] = minutes
+	qualityTracker.frames[sourceID] = frame
+}
+
+// AudioQualityMinutes returns the minute statistics of every source for minutes
+// starting in [from, to). Minutes without audio are absent.
+func AudioQualityMinutes(from, to time.Time) map[string][]AudioQualityMinute {
+	qualityTracker.mu.Lock()
+	defer qualityTracker.mu.Unlock()
+
+	result := make(map[string][]AudioQualityMinute, len(qualityTracker.sources))
+	for source, minutes := range qualityTracker.sources {
+		var selected []AudioQualityMinute
+		for i := range minutes {
+			if !minutes[i].Start.Before(from) && minutes[i].Start.Before(to) {
+				selected = append(selected, minutes[i])
+			}
+		}
+		result[source] = selected
+	}
+	return result
+}
+
+// AudioQualityTrackedSince returns when collection of minute statistics started
+func AudioQualityTrackedSince() time.Time {
+	qualityTracker.mu.Lock()
+	defer qualityTracker.mu.Unlock()
+	return qualityTracker.since
+}
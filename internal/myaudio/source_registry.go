@@ -104,6 +104,7 @@ func (r *AudioSourceRegistry) RegisterSource(connectionString string, config Sou
 		ID:               config.ID,
 		DisplayName:      config.DisplayName,
 		Type:             config.Type,
+		Channel:          config.Channel,
 		connectionString: connectionString,
 		SafeString:       r.sanitizeConnectionString(connectionString, config.Type),
 		RegisteredAt:     time.Now(),
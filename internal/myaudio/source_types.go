@@ -20,9 +20,10 @@ const (
 // AudioSource represents a registered audio source with its metadata
 type AudioSource struct {
 	// Core identification
-	ID          string     `json:"id"`          // Unique identifier (e.g., "rtsp_001", "cam_backyard")
-	DisplayName string     `json:"displayName"` // User-friendly name
-	Type        SourceType `json:"type"`        // Source type
+	ID          string     `json:"id"`                // Unique identifier (e.g., "rtsp_001", "cam_backyard")
+	DisplayName string     `json:"displayName"`       // User-friendly name
+	Type        SourceType `json:"type"`              // Source type
+	Channel     string     `json:"channel,omitempty"` // Analyzed channel of a stereo source, empty when mixed
 
 	// Connection information (private)
 	connectionString string // NEVER exposed in logs or API
@@ -66,4 +67,5 @@ type SourceConfig struct {
 	ID          string
 	DisplayName string
 	Type        SourceType
+	Channel     string // analyzed channel of a stereo source, empty when mixed
 }
\ No newline at end of file
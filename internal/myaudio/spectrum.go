@@ -0,0 +1,120 @@
+// spectrum.go: Spectral statistics used to tell a failed microphone from a quiet one
+package myaudio
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+const (
+	// spectrumFrameSize is the number of samples measured per minute, a power of
+	// two giving a frequency resolution of about 6 Hz at 48 kHz
+	spectrumFrameSize = 8192
+	// humHarmonics is the number of mains hum harmonics counted as hum
+	humHarmonics = 6
+	// humBins is the number of FFT bins on each side of a hum frequency counted
+	// as hum, covering the leakage of the Hann window
+	humBins = 2
+)
+
+// mainsFrequencies are the power grid frequencies producing electrical hum
+var mainsFrequencies = []float64{50, 60}
+
+// SpectralStats holds spectral statistics of one frame of audio
+type SpectralStats struct {
+	ACdBFS   float64 // level in dBFS with the DC offset removed
+	Flatness float64 // spectral flatness, near 0 for a single tone and about 0.5 for broadband noise
+	HumRatio float64 // share of the power at mains hum frequencies and their harmonics
+}
+
+// measureSpectrum returns the spectral statistics of normalized samples
+func measureSpectrum(samples []float64, sampleRate int) SpectralStats {
+	n := len(samples)
+	var mean float64
+	for _, v := range samples {
+		mean += v
+	}
+	mean /= float64(n)
+
+	// Remove the DC offset and apply a Hann window
+	frame := make([]complex128, n)
+	var variance float64
+	for i, v := range samples {
+		v -= mean
+		variance += v * v
+		w := 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(n-1))
+		frame[i] = complex(v*w, 0)
+	}
+	variance /= float64(n)
+
+	stats := SpectralStats{ACdBFS: math.Inf(-1)}
+	if variance == 0 {
+		return stats
+	}
+	stats.ACdBFS = 10 * math.Log10(variance)
+
+	fft(frame)
+
+	// Power of the bins between DC and Nyquist
+	binHz := float64(sampleRate) / float64(n)
+	isHum := make([]bool, n/2)
+	for _, mains := range mainsFrequencies {
+		for h := 1; h <= humHarmonics; h++ {
+			center := int(math.Round(mains * float64(h) / binHz))
+			for k := center - humBins; k <= center+humBins; k++ {
+				if k > 0 && k < n/2 {
+					isHum[k] = true
+				}
+			}
+		}
+	}
+
+	var total, hum, logSum float64
+	bins := 0
+	for k := 1; k < n/2; k++ {
+		p := real(frame[k])*real(frame[k]) + imag(frame[k])*imag(frame[k])
+		total += p
+		if isHum[k] {
+			hum += p
+		}
+		logSum += math.Log(p + 1e-30)
+		bins++
+	}
+	if total > 0 {
+		stats.Flatness = math.Exp(logSum/float64(bins)) / (total / float64(bins))
+		stats.HumRatio = hum / total
+	}
+	return stats
+}
+
+// fft computes the discrete Fourier transform in place. The length of x must
+// be a power of two.
+func fft(x []complex128) {
+	n := len(x)
+
+	// Bit-reversal permutation
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := range size / 2 {
+				even := x[start+k]
+				odd := w * x[start+k+size/2]
+				x[start+k] = even + odd
+				x[start+k+size/2] = even - odd
+				w *= step
+			}
+		}
+	}
+}
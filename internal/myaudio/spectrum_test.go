@@ -0,0 +1,64 @@
+package myaudio
+
+import (
+	"encoding/binary"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+func TestMeasureSpectrum(t *testing.T) {
+	t.Parallel()
+
+	rng := rand.New(rand.NewSource(1)) // #nosec G404 -- deterministic test signal
+	noise := make([]float64, spectrumFrameSize)
+	hum := make([]float64, spectrumFrameSize)
+	dc := make([]float64, spectrumFrameSize)
+	for i := range noise {
+		noise[i] = rng.NormFloat64() * 0.001 // about -60 dBFS
+		phase := 2 * math.Pi * float64(i) / conf.SampleRate
+		hum[i] = 0.1*math.Sin(50*phase) + 0.03*math.Sin(150*phase) + rng.NormFloat64()*0.0001
+		dc[i] = 0.25
+	}
+
+	quiet := measureSpectrum(noise, conf.SampleRate)
+	assert.InDelta(t, -60, quiet.ACdBFS, 1)
+	assert.Greater(t, quiet.Flatness, 0.3, "broadband noise should have a flat spectrum")
+	assert.Less(t, quiet.HumRatio, 0.05)
+
+	mains := measureSpectrum(hum, conf.SampleRate)
+	assert.Greater(t, mains.HumRatio, 0.9, "power should be concentrated at mains hum frequencies")
+	assert.Less(t, mains.Flatness, 0.05)
+
+	flat := measureSpectrum(dc, conf.SampleRate)
+	assert.True(t, math.IsInf(flat.ACdBFS, -1), "a DC offset without signal should have no AC level")
+}
+
+func TestRecordAudioQuality_Spectrum(t *testing.T) {
+	t.Parallel()
+
+	const source = "spectrum_test_source"
+	now := time.Now()
+
+	// Less than a frame of audio leaves the spectrum unmeasured
+	chunk := make([]byte, spectrumFrameSize) // spectrumFrameSize/2 samples
+	for i := 0; i < len(chunk); i += 2 {
+		binary.LittleEndian.PutUint16(chunk[i:], uint16(int16(i%200-100))) // #nosec G115 -- test signal
+	}
+	recordAudioQuality(source, chunk, now)
+	minutes := AudioQualityMinutes(now.Add(-time.Minute), now.Add(time.Minute))[source]
+	require.Len(t, minutes, 1)
+	assert.Nil(t, minutes[0].Spectrum)
+
+	recordAudioQuality(source, chunk, now)
+	minutes = AudioQualityMinutes(now.Add(-time.Minute), now.Add(time.Minute))[source]
+	require.Len(t, minutes, 1)
+	require.NotNil(t, minutes[0].Spectrum)
+	assert.Equal(t, int64(spectrumFrameSize), minutes[0].Samples)
+	assert.False(t, math.IsInf(minutes[0].Spectrum.ACdBFS, -1))
+}
@@ -0,0 +1,91 @@
+// chart.go: Server-side rendering of newsletter charts as SVG images
+package newsletter
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html"
+)
+
+// Chart layout in pixels
+const (
+	chartWidth       = 600
+	chartLabelWidth  = 190
+	chartValueWidth  = 50
+	chartTitleHeight = 30
+	chartRowHeight   = 22
+	chartBarHeight   = 14
+	chartLegendSpace = 20
+)
+
+// Chart colors
+const (
+	chartColor         = "#2f7d4f"
+	chartPreviousColor = "#b7d7c2"
+	chartTextColor     = "#333333"
+)
+
+// Bar is one row of a bar chart
+type Bar struct {
+	Label    string
+	Value    int
+	Previous int // value of the compared period, drawn when the chart compares
+}
+
+// BarChart renders a horizontal bar chart as an SVG image. With compare set
+// each row also shows the value of the previous period as a lighter bar.
+func BarChart(title string, bars []Bar, compare bool) []byte {
+	rowHeight := chartRowHeight
+	if compare {
+		rowHeight += chartBarHeight / 2
+	}
+	height := chartTitleHeight + len(bars)*rowHeight + 10
+	if compare {
+		height += chartLegendSpace
+	}
+
+	maxValue := 1
+	for _, bar := range bars {
+		maxValue = max(maxValue, bar.Value, bar.Previous)
+	}
+	barSpace := float64(chartWidth - chartLabelWidth - chartValueWidth)
+	scale := func(value int) float64 { return barSpace * float64(value) / float64(maxValue) }
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="Helvetica, Arial, sans-serif" font-size="12">`,
+		chartWidth, height, chartWidth, height)
+	fmt.Fprintf(&b, `<rect width="100%%" height="100%%" fill="#ffffff"/>`)
+	fmt.Fprintf(&b, `<text x="0" y="18" font-size="15" font-weight="bold" fill="%s">%s</text>`, chartTextColor, html.EscapeString(title))
+
+	for i, bar := range bars {
+		y := chartTitleHeight + i*rowHeight
+		fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="end" fill="%s">%s</text>`,
+			chartLabelWidth-8, y+chartBarHeight-3, chartTextColor, html.EscapeString(bar.Label))
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%.1f" height="%d" fill="%s"/>`,
+			chartLabelWidth, y, scale(bar.Value), chartBarHeight, chartColor)
+		end := scale(bar.Value)
+		if compare {
+			fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%.1f" height="%d" fill="%s"/>`,
+				chartLabelWidth, y+chartBarHeight, scale(bar.Previous), chartBarHeight/2, chartPreviousColor)
+			end = max(end, scale(bar.Previous))
+		}
+		fmt.Fprintf(&b, `<text x="%.1f" y="%d" fill="%s">%d</text>`,
+			float64(chartLabelWidth)+end+6, y+chartBarHeight-3, chartTextColor, bar.Value)
+	}
+
+	if compare {
+		y := height - chartLegendSpace + 4
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="12" height="10" fill="%s"/><text x="%d" y="%d" fill="%s">This week</text>`,
+			chartLabelWidth, y, chartColor, chartLabelWidth+18, y+9, chartTextColor)
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="12" height="10" fill="%s"/><text x="%d" y="%d" fill="%s">Week before</text>`,
+			chartLabelWidth+110, y, chartPreviousColor, chartLabelWidth+128, y+9, chartTextColor)
+	}
+	b.WriteString(`</svg>`)
+	return b.Bytes()
+}
+
+// dataURI returns an SVG image as a data URI for embedding it into HTML
+func dataURI(svg []byte) string {
+	return "data:image/svg+xml;base64," + base64.StdEncoding.EncodeToString(svg)
+}
@@ -0,0 +1,97 @@
+// job.go: Weekly generation of newsletters as static pages
+package newsletter
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// checkInterval is how often the job checks whether the newsletter of the
+// past week needs to be generated
+const checkInterval = 10 * time.Minute
+
+// FileName returns the file name of the newsletter of the week starting on start
+func FileName(start time.Time) string {
+	return "newsletter-" + WeekStart(start).Format(time.DateOnly) + ".html"
+}
+
+// Job writes the newsletter of each week to a directory once the week is over
+type Job struct {
+	builder *Builder
+	dir     string
+	hour    int
+}
+
+// NewJob creates a weekly newsletter job writing to dir from hour on Monday
+func NewJob(builder *Builder, dir string, hour int) *Job {
+	return &Job{builder: builder, dir: dir, hour: hour}
+}
+
+// Run generates missing newsletters until ctx is cancelled. onNewsletter is
+// called with the path of each generated newsletter or the error and may be nil.
+func (j *Job) Run(ctx context.Context, onNewsletter func(path string, n *Newsletter, err error)) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		path, n, err := j.GeneratePreviousWeek(ctx)
+		if onNewsletter != nil && (n != nil || err != nil) {
+			onNewsletter(path, n, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// GeneratePreviousWeek writes the newsletter of the previous week unless it
+// already exists or it is Monday before the configured hour. It returns the
+// path and content of the new newsletter, or a nil newsletter when none was
+// generated.
+func (j *Job) GeneratePreviousWeek(ctx context.Context) (string, *Newsletter, error) {
+	now := j.builder.now()
+	thisWeek := WeekStart(now)
+	if now.Before(thisWeek.Add(time.Duration(j.hour) * time.Hour)) {
+		return "", nil, nil
+	}
+
+	start := thisWeek.AddDate(0, 0, -7)
+	path := filepath.Join(j.dir, FileName(start))
+	if _, err := os.Stat(path); err == nil {
+		return "", nil, nil
+	}
+
+	n, err := j.builder.Build(ctx, start)
+	if err != nil {
+		return "", nil, err
+	}
+	page, err := Render(n)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if err := os.MkdirAll(j.dir, 0o755); err != nil {
+		return "", nil, fileError(err, "create_newsletter_dir", j.dir)
+	}
+	if err := os.WriteFile(path, page, 0o644); err != nil { // #nosec G306 -- static page meant to be served
+		return "", nil, fileError(err, "write_newsletter", path)
+	}
+	return path, n, nil
+}
+
+// fileError wraps a file system error
+func fileError(err error, operation, path string) error {
+	return errors.New(err).
+		Component("newsletter").
+		Category(errors.CategoryFileIO).
+		Context("operation", operation).
+		Context("path", path).
+		Build()
+}
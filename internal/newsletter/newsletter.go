@@ -0,0 +1,173 @@
+// Package newsletter generates a weekly newsletter, an HTML page with charts of
+// the species counts of the week, the new arrivals and the comparison with the
+// week before. The charts are rendered on the server as SVG images embedded
+// into the page, so it can be sent as an email or served as a static page.
+package newsletter
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+// Store is the datastore capability needed to build a newsletter
+type Store interface {
+	GetSpeciesSummaryData(ctx context.Context, startDate, endDate string) ([]datastore.SpeciesSummaryData, error)
+	GetNewSpeciesDetections(ctx context.Context, startDate, endDate string, limit, offset int) ([]datastore.NewSpeciesData, error)
+}
+
+// Config holds the newsletter parameters
+type Config struct {
+	StationName string // shown in the title
+	TopSpecies  int    // species shown in the species count chart
+}
+
+// ConfigFromSettings returns the newsletter parameters of the configuration
+func ConfigFromSettings(settings *conf.Settings) Config {
+	return Config{
+		StationName: settings.Main.Name,
+		TopSpecies:  settings.Newsletter.TopSpecies,
+	}
+}
+
+// SpeciesCount is the number of detections of a species in the week and the
+// week before
+type SpeciesCount struct {
+	ScientificName string `json:"scientificName"`
+	CommonName     string `json:"commonName"`
+	Count          int    `json:"count"`
+	PreviousCount  int    `json:"previousCount"`
+}
+
+// DayCount is the number of detections on a day of the week
+type DayCount struct {
+	Date  string `json:"date"` // YYYY-MM-DD
+	Count int    `json:"count"`
+}
+
+// Newsletter is the content of the newsletter of a week
+type Newsletter struct {
+	Title              string         `json:"title"`
+	Start              string         `json:"start"`         // Monday (YYYY-MM-DD)
+	End                string         `json:"end"`           // Sunday (YYYY-MM-DD)
+	PreviousStart      string         `json:"previousStart"` // Monday of the week before
+	PreviousEnd        string         `json:"previousEnd"`   // Sunday of the week before
+	Detections         int            `json:"detections"`
+	Species            int            `json:"species"`
+	PreviousDetections int            `json:"previousDetections"`
+	PreviousSpecies    int            `json:"previousSpecies"`
+	TopSpecies         []SpeciesCount `json:"topSpecies"`  // most detected species, most first
+	NewArrivals        []SpeciesCount `json:"newArrivals"` // species detected for the first time ever
+	Returning          []SpeciesCount `json:"returning"`   // species detected this week but not the week before
+	Days               []DayCount     `json:"days"`        // detections per day, Monday first
+	GeneratedAt        time.Time      `json:"generatedAt"`
+}
+
+// WeekStart returns the Monday of the week of t at midnight
+func WeekStart(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	offset := (int(day.Weekday()) + 6) % 7 // days since Monday
+	return day.AddDate(0, 0, -offset)
+}
+
+// Builder gathers the content of newsletters
+type Builder struct {
+	config Config
+	store  Store
+	now    func() time.Time // current time, replaceable in tests
+}
+
+// NewBuilder creates a newsletter builder
+func NewBuilder(config Config, store Store) *Builder {
+	return &Builder{config: config, store: store, now: time.Now}
+}
+
+// Build returns the newsletter of the week (Monday to Sunday) starting on start
+func (b *Builder) Build(ctx context.Context, start time.Time) (*Newsletter, error) {
+	start = WeekStart(start)
+	end := start.AddDate(0, 0, 6)
+	prevStart := start.AddDate(0, 0, -7)
+	prevEnd := start.AddDate(0, 0, -1)
+
+	n := &Newsletter{
+		Title:         "Weekly bird report",
+		Start:         start.Format(time.DateOnly),
+		End:           end.Format(time.DateOnly),
+		PreviousStart: prevStart.Format(time.DateOnly),
+		PreviousEnd:   prevEnd.Format(time.DateOnly),
+		GeneratedAt:   b.now(),
+	}
+	if b.config.StationName != "" {
+		n.Title = "Weekly bird report of " + b.config.StationName
+	}
+
+	week, err := b.store.GetSpeciesSummaryData(ctx, n.Start, n.End)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := b.store.GetSpeciesSummaryData(ctx, n.PreviousStart, n.PreviousEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	previousCounts := make(map[string]int, len(previous))
+	for i := range previous {
+		previousCounts[previous[i].ScientificName] = previous[i].Count
+		n.PreviousDetections += previous[i].Count
+	}
+	n.PreviousSpecies = len(previous)
+
+	counts := make([]SpeciesCount, 0, len(week))
+	for i := range week {
+		count := SpeciesCount{
+			ScientificName: week[i].ScientificName,
+			CommonName:     week[i].CommonName,
+			Count:          week[i].Count,
+			PreviousCount:  previousCounts[week[i].ScientificName],
+		}
+		counts = append(counts, count)
+		n.Detections += count.Count
+	}
+	n.Species = len(counts)
+	slices.SortFunc(counts, func(a, c SpeciesCount) int {
+		return cmp.Or(cmp.Compare(c.Count, a.Count), cmp.Compare(a.CommonName, c.CommonName))
+	})
+	n.TopSpecies = counts[:min(len(counts), max(b.config.TopSpecies, 1))]
+
+	newSpecies, err := b.store.GetNewSpeciesDetections(ctx, n.Start, n.End, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	isNew := make(map[string]bool, len(newSpecies))
+	for i := range newSpecies {
+		isNew[newSpecies[i].ScientificName] = true
+		n.NewArrivals = append(n.NewArrivals, SpeciesCount{
+			ScientificName: newSpecies[i].ScientificName,
+			CommonName:     newSpecies[i].CommonName,
+			Count:          newSpecies[i].CountInPeriod,
+		})
+	}
+	for i := range counts {
+		if counts[i].PreviousCount == 0 && !isNew[counts[i].ScientificName] {
+			n.Returning = append(n.Returning, counts[i])
+		}
+	}
+
+	for day := range 7 {
+		date := start.AddDate(0, 0, day).Format(time.DateOnly)
+		summaries, err := b.store.GetSpeciesSummaryData(ctx, date, date)
+		if err != nil {
+			return nil, err
+		}
+		total := 0
+		for i := range summaries {
+			total += summaries[i].Count
+		}
+		n.Days = append(n.Days, DayCount{Date: date, Count: total})
+	}
+	return n, nil
+}
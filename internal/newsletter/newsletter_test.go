@@ -0,0 +1,123 @@
+package newsletter
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+// fakeStore returns fixed summaries per date range
+type fakeStore struct {
+	summaries  map[string][]datastore.SpeciesSummaryData // keyed by "start..end"
+	newSpecies []datastore.NewSpeciesData
+}
+
+func (s *fakeStore) GetSpeciesSummaryData(ctx context.Context, startDate, endDate string) ([]datastore.SpeciesSummaryData, error) {
+	return s.summaries[startDate+".."+endDate], nil
+}
+
+func (s *fakeStore) GetNewSpeciesDetections(ctx context.Context, startDate, endDate string, limit, offset int) ([]datastore.NewSpeciesData, error) {
+	return s.newSpecies, nil
+}
+
+func testStore() *fakeStore {
+	return &fakeStore{
+		summaries: map[string][]datastore.SpeciesSummaryData{
+			"2024-05-06..2024-05-12": {
+				{ScientificName: "Parus major", CommonName: "Great Tit", Count: 30},
+				{ScientificName: "Upupa epops", CommonName: "Eurasian Hoopoe", Count: 2},
+				{ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", Count: 45},
+				{ScientificName: "Sitta europaea", CommonName: "Eurasian Nuthatch", Count: 3},
+			},
+			"2024-04-29..2024-05-05": {
+				{ScientificName: "Parus major", CommonName: "Great Tit", Count: 20},
+				{ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", Count: 50},
+				{ScientificName: "Erithacus rubecula", CommonName: "European Robin", Count: 7},
+			},
+			"2024-05-08..2024-05-08": {{ScientificName: "Parus major", CommonName: "Great Tit", Count: 11}},
+		},
+		newSpecies: []datastore.NewSpeciesData{
+			{ScientificName: "Upupa epops", CommonName: "Eurasian Hoopoe", FirstSeenDate: "2024-05-09", CountInPeriod: 2},
+		},
+	}
+}
+
+func TestBuild(t *testing.T) {
+	t.Parallel()
+
+	builder := NewBuilder(Config{StationName: "Garden", TopSpecies: 2}, testStore())
+	n, err := builder.Build(context.Background(), time.Date(2024, 5, 9, 12, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	assert.Equal(t, "Weekly bird report of Garden", n.Title)
+	assert.Equal(t, "2024-05-06", n.Start, "weeks start on Monday")
+	assert.Equal(t, "2024-05-12", n.End)
+	assert.Equal(t, 80, n.Detections)
+	assert.Equal(t, 4, n.Species)
+	assert.Equal(t, 77, n.PreviousDetections)
+	assert.Equal(t, 3, n.PreviousSpecies)
+
+	require.Len(t, n.TopSpecies, 2)
+	assert.Equal(t, SpeciesCount{ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", Count: 45, PreviousCount: 50}, n.TopSpecies[0])
+	assert.Equal(t, "Great Tit", n.TopSpecies[1].CommonName)
+
+	require.Len(t, n.NewArrivals, 1)
+	assert.Equal(t, "Eurasian Hoopoe", n.NewArrivals[0].CommonName)
+	require.Len(t, n.Returning, 1, "new arrivals are not listed as returning")
+	assert.Equal(t, "Eurasian Nuthatch", n.Returning[0].CommonName)
+
+	require.Len(t, n.Days, 7)
+	assert.Equal(t, DayCount{Date: "2024-05-08", Count: 11}, n.Days[2])
+}
+
+func TestRender(t *testing.T) {
+	t.Parallel()
+
+	n, err := NewBuilder(Config{TopSpecies: 10}, testStore()).Build(context.Background(), time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	n.TopSpecies[0].CommonName = "<script>"
+
+	page, err := Render(n)
+	require.NoError(t, err)
+	html := string(page)
+	assert.Contains(t, html, "80 detections of 4 species, the week before 77 detections of 3 species")
+	assert.Contains(t, html, "<b>Eurasian Hoopoe</b>")
+	assert.Equal(t, 3, strings.Count(html, `<img src="data:image/svg`), "species, daily and new arrival charts")
+
+	chart := string(BarChart("Top", []Bar{{Label: n.TopSpecies[0].CommonName, Value: 5, Previous: 3}}, true))
+	assert.Contains(t, chart, "&lt;script&gt;", "labels are escaped")
+	assert.Contains(t, chart, "Week before")
+}
+
+func TestJob_GeneratePreviousWeek(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	builder := NewBuilder(Config{TopSpecies: 5}, testStore())
+	job := NewJob(builder, dir, 6)
+
+	builder.now = func() time.Time { return time.Date(2024, 5, 13, 5, 0, 0, 0, time.UTC) }
+	_, n, err := job.GeneratePreviousWeek(context.Background())
+	require.NoError(t, err)
+	assert.Nil(t, n, "not before the hour on Monday")
+
+	builder.now = func() time.Time { return time.Date(2024, 5, 13, 6, 30, 0, 0, time.UTC) }
+	path, n, err := job.GeneratePreviousWeek(context.Background())
+	require.NoError(t, err)
+	require.NotNil(t, n)
+	assert.Equal(t, filepath.Join(dir, "newsletter-2024-05-06.html"), path)
+	page, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(page), "2024-05-06 to 2024-05-12")
+
+	_, n, err = job.GeneratePreviousWeek(context.Background())
+	require.NoError(t, err)
+	assert.Nil(t, n, "generated once per week")
+}
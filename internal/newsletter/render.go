@@ -0,0 +1,112 @@
+// render.go: HTML rendering of newsletters
+package newsletter
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// pageTemplate is the newsletter page. It only uses inline styles as email
+// clients ignore style sheets.
+var pageTemplate = template.Must(template.New("newsletter").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}, {{.Start}} to {{.End}}</title>
+</head>
+<body style="margin:0;padding:20px;background:#f4f4f4;font-family:Helvetica,Arial,sans-serif;color:#333333">
+<div style="max-width:640px;margin:0 auto;background:#ffffff;padding:20px">
+<h1 style="font-size:22px;margin:0 0 4px">{{.Title}}</h1>
+<p style="margin:0 0 16px;color:#666666">{{.Start}} to {{.End}}</p>
+<p>{{.Detections}} detections of {{.Species}} species, the week before {{.PreviousDetections}} detections of {{.PreviousSpecies}} species.</p>
+{{if .NewArrivals}}<h2 style="font-size:17px">New arrivals</h2>
+<p>Detected for the first time ever:{{range $i, $s := .NewArrivals}}{{if $i}},{{end}} <b>{{$s.CommonName}}</b> <i>({{$s.ScientificName}})</i>{{end}}</p>
+{{end}}{{range .Charts}}<p><img src="{{.Image}}" alt="{{.Title}}" width="600" style="max-width:100%"></p>
+{{end}}{{if .Returning}}<h2 style="font-size:17px">Back this week</h2>
+<p>Not detected the week before:{{range $i, $s := .Returning}}{{if $i}},{{end}} {{$s.CommonName}}{{end}}</p>
+{{end}}<p style="margin-top:24px;font-size:11px;color:#999999">Generated by BirdNET-Go on {{.Generated}}</p>
+</div>
+</body>
+</html>
+`))
+
+// pageChart is a chart image of the page
+type pageChart struct {
+	Title string
+	Image template.URL
+}
+
+// pageData is the data of pageTemplate
+type pageData struct {
+	*Newsletter
+	Charts    []pageChart
+	Generated string
+}
+
+// Render returns the newsletter as an HTML page with embedded chart images
+func Render(n *Newsletter) ([]byte, error) {
+	data := pageData{
+		Newsletter: n,
+		Generated:  n.GeneratedAt.Format(time.DateTime),
+	}
+	for _, chart := range Charts(n) {
+		data.Charts = append(data.Charts, pageChart{
+			Title: chart.Title,
+			Image: template.URL(dataURI(chart.SVG)), // #nosec G203 -- generated SVG with escaped labels
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := pageTemplate.Execute(&buf, data); err != nil {
+		return nil, errors.New(err).
+			Component("newsletter").
+			Category(errors.CategorySystem).
+			Context("operation", "render_newsletter").
+			Context("week", n.Start).
+			Build()
+	}
+	return buf.Bytes(), nil
+}
+
+// Chart is a rendered chart of a newsletter
+type Chart struct {
+	Title string
+	SVG   []byte
+}
+
+// Charts renders the charts of a newsletter: the species counts compared to
+// the week before, the detections per day and the detections of new arrivals
+func Charts(n *Newsletter) []Chart {
+	var charts []Chart
+	if len(n.TopSpecies) > 0 {
+		bars := make([]Bar, 0, len(n.TopSpecies))
+		for _, s := range n.TopSpecies {
+			bars = append(bars, Bar{Label: s.CommonName, Value: s.Count, Previous: s.PreviousCount})
+		}
+		title := fmt.Sprintf("Top %d species", len(bars))
+		charts = append(charts, Chart{Title: title, SVG: BarChart(title, bars, true)})
+	}
+
+	days := make([]Bar, 0, len(n.Days))
+	for _, day := range n.Days {
+		label := day.Date
+		if date, err := time.Parse(time.DateOnly, day.Date); err == nil {
+			label = date.Format("Mon 2 Jan")
+		}
+		days = append(days, Bar{Label: label, Value: day.Count})
+	}
+	charts = append(charts, Chart{Title: "Detections per day", SVG: BarChart("Detections per day", days, false)})
+
+	if len(n.NewArrivals) > 0 {
+		bars := make([]Bar, 0, len(n.NewArrivals))
+		for _, s := range n.NewArrivals {
+			bars = append(bars, Bar{Label: s.CommonName, Value: s.Count})
+		}
+		charts = append(charts, Chart{Title: "New arrivals", SVG: BarChart("New arrivals", bars, false)})
+	}
+	return charts
+}
@@ -0,0 +1,74 @@
+package notification
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+// MetadataKeyChannelMessages holds the title and message of the channels
+// whose templates render a notification differently
+const MetadataKeyChannelMessages = "channel_messages"
+
+// channelDefault is the key of the title and message of the channels without
+// their own templates when the UI channel has its own
+const channelDefault = "default"
+
+// ChannelMessage is the title and message of a notification rendered with the
+// templates of one channel
+type ChannelMessage struct {
+	Title   string `json:"title"`
+	Message string `json:"message"`
+}
+
+// WithChannelMessages renders the notification for each channel with render
+// and keeps the titles and messages that differ in the metadata. The UI
+// channel's title and message replace those of the notification, which the
+// other channels keep. Channels render fails for use the shared ones.
+func (n *Notification) WithChannelMessages(channels []string, render func(channel string) (ChannelMessage, error)) *Notification {
+	messages := make(map[string]ChannelMessage)
+	for _, channel := range channels {
+		msg, err := render(channel)
+		if err != nil {
+			continue
+		}
+		if msg.Title != n.Title || msg.Message != n.Message {
+			messages[channel] = msg
+		}
+	}
+
+	if ui, ok := messages[ChannelUI]; ok {
+		delete(messages, ChannelUI)
+		messages[channelDefault] = ChannelMessage{Title: n.Title, Message: n.Message}
+		n.Title, n.Message = ui.Title, ui.Message
+	}
+	if len(messages) > 0 {
+		n.WithMetadata(MetadataKeyChannelMessages, messages)
+	}
+	return n
+}
+
+// ForChannel returns the notification as sent to a channel: a copy with the
+// title and message of the channel when its templates render it differently
+func (n *Notification) ForChannel(channel string) *Notification {
+	messages, ok := n.Metadata[MetadataKeyChannelMessages].(map[string]ChannelMessage)
+	if !ok {
+		return n
+	}
+	msg, ok := messages[channel]
+	if !ok {
+		msg, ok = messages[channelDefault]
+	}
+	if !ok {
+		return n
+	}
+	c := *n
+	c.Title, c.Message = msg.Title, msg.Message
+	return &c
+}
+
+// TemplateChannels returns the channels with their own templates, sorted
+func TemplateChannels(settings *conf.Settings) []string {
+	return slices.Sorted(maps.Keys(settings.Notification.Templates.Channels))
+}
@@ -0,0 +1,34 @@
+package notification
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChannelMessages(t *testing.T) {
+	t.Parallel()
+
+	n := NewNotification(TypeDetection, PriorityHigh, "Owl", "detected").
+		WithChannelMessages([]string{"email", "ntfy", "telegram"}, func(channel string) (ChannelMessage, error) {
+			switch channel {
+			case "email":
+				return ChannelMessage{Title: "Owl (Strix aluco)", Message: "detected"}, nil
+			case "ntfy":
+				return ChannelMessage{}, assert.AnError
+			default:
+				return ChannelMessage{Title: "Owl", Message: "detected"}, nil
+			}
+		})
+
+	messages, ok := n.Metadata[MetadataKeyChannelMessages].(map[string]ChannelMessage)
+	if assert.True(t, ok) {
+		assert.Equal(t, map[string]ChannelMessage{"email": {Title: "Owl (Strix aluco)", Message: "detected"}}, messages,
+			"only differing messages are kept")
+	}
+	assert.Equal(t, "Owl (Strix aluco)", n.ForChannel("email").Title)
+	assert.Equal(t, "Owl", n.Title, "the notification is not changed")
+	assert.Same(t, n, n.ForChannel("ntfy"), "channels failing to render use the shared message")
+	plain := NewNotification(TypeInfo, PriorityLow, "Info", "")
+	assert.Same(t, plain, plain.ForChannel("email"))
+}
@@ -0,0 +1,139 @@
+package notification
+
+import (
+	"maps"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Metadata keys of deduplicated notifications
+const (
+	// MetadataKeyOccurrences counts the notifications collapsed into one,
+	// present once a notification has been repeated
+	MetadataKeyOccurrences = "occurrences"
+	// MetadataKeyLastOccurrence is the time of the latest repeat
+	MetadataKeyLastOccurrence = "last_occurrence"
+)
+
+// deduplicator collapses repeated notifications within a window into the
+// first one. Notifications of a species repeat when their type, component
+// and scientific name match, others when their type, component and title do.
+type deduplicator struct {
+	window time.Duration
+
+	mu     sync.Mutex // held while a notification is published, so repeats wait for the first one
+	recent map[string]dedupEntry
+}
+
+// dedupEntry is the first notification of a key within the window
+type dedupEntry struct {
+	id    string
+	first time.Time
+}
+
+// newDeduplicator returns the deduplicator of a window, nil when disabled
+func newDeduplicator(window time.Duration) *deduplicator {
+	if window <= 0 {
+		return nil
+	}
+	return &deduplicator{window: window, recent: make(map[string]dedupEntry)}
+}
+
+// dedupKey returns the key repeats of a notification share
+func dedupKey(n *Notification) string {
+	if name, ok := n.Metadata["scientific_name"].(string); ok && name != "" {
+		return string(n.Type) + "|" + n.Component + "|" + strings.ToLower(name)
+	}
+	return string(n.Type) + "|" + n.Component + "|" + n.Title
+}
+
+// prune forgets the notifications whose window has passed
+func (d *deduplicator) prune(now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for key, entry := range d.recent {
+		if now.Sub(entry.first) >= d.window {
+			delete(d.recent, key)
+		}
+	}
+}
+
+// IsRepeat reports whether a notification is an update of a notification
+// already sent, counting a repeat collapsed into it by deduplication
+func IsRepeat(n *Notification) bool {
+	return n != nil && occurrences(n) > 1
+}
+
+// occurrences returns the number of notifications collapsed into one, which
+// the notification history reads back from JSON as a float
+func occurrences(n *Notification) int {
+	switch count := n.Metadata[MetadataKeyOccurrences].(type) {
+	case int:
+		return count
+	case float64:
+		return int(count)
+	default:
+		return 1
+	}
+}
+
+// publish saves a notification and broadcasts it to the subscribers. With
+// deduplication enabled, a repeat of a notification published within the
+// window updates the occurrence count of that notification instead, which is
+// returned and broadcast in its place.
+func (s *Service) publish(notification *Notification) (*Notification, error) {
+	d := s.dedup
+	if d == nil || isToastNotification(notification) {
+		if err := s.notificationStore().Save(notification); err != nil {
+			return nil, err
+		}
+		s.broadcast(notification)
+		return notification, nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	key := dedupKey(notification)
+	if entry, ok := d.recent[key]; ok && notification.Timestamp.Sub(entry.first) < d.window {
+		if first, err := s.notificationStore().Get(entry.id); err == nil && !first.IsExpired() {
+			repeat := collapseRepeat(first, notification)
+			if err := s.notificationStore().Update(repeat); err != nil {
+				return nil, err
+			}
+			s.broadcast(repeat)
+			if s.config.Debug {
+				s.logger.Debug("notification collapsed into earlier one",
+					"id", repeat.ID,
+					"type", repeat.Type,
+					"component", repeat.Component,
+					"occurrences", repeat.Metadata[MetadataKeyOccurrences])
+			}
+			return repeat, nil
+		}
+	}
+
+	if err := s.notificationStore().Save(notification); err != nil {
+		return nil, err
+	}
+	d.recent[key] = dedupEntry{id: notification.ID, first: notification.Timestamp}
+	s.broadcast(notification)
+	return notification, nil
+}
+
+// collapseRepeat returns a copy of the first notification counting the
+// repeat, leaving the first one untouched for the subscribers still reading it
+func collapseRepeat(first, repeat *Notification) *Notification {
+	c := *first
+	c.Metadata = maps.Clone(first.Metadata)
+	if c.Metadata == nil {
+		c.Metadata = make(map[string]any)
+	}
+	c.Metadata[MetadataKeyOccurrences] = occurrences(first) + 1
+	c.Metadata[MetadataKeyLastOccurrence] = repeat.Timestamp
+	if repeat.ExpiresAt != nil && (c.ExpiresAt == nil || repeat.ExpiresAt.After(*c.ExpiresAt)) {
+		c.ExpiresAt = repeat.ExpiresAt
+	}
+	return &c
+}
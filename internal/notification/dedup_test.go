@@ -0,0 +1,103 @@
+package notification
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceDeduplication(t *testing.T) {
+	t.Parallel()
+
+	service := NewService(&ServiceConfig{
+		MaxNotifications:   10,
+		CleanupInterval:    time.Minute,
+		RateLimitWindow:    time.Minute,
+		RateLimitMaxEvents: 10,
+		DedupWindow:        10 * time.Minute,
+	})
+	defer service.Stop()
+	ch, _ := service.Subscribe()
+	defer service.Unsubscribe(ch)
+
+	detection := func(species string) *Notification {
+		return NewNotification(TypeDetection, PriorityHigh, "Bird: "+species, "Detected").
+			WithComponent("detection").
+			WithMetadata("scientific_name", species)
+	}
+
+	first, err := service.publish(detection("Parus major"))
+	require.NoError(t, err)
+	assert.False(t, IsRepeat(<-ch))
+
+	// A repeat of the species updates the first notification
+	repeat, err := service.publish(detection("PARUS MAJOR"))
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, repeat.ID)
+	assert.Equal(t, 2, repeat.Metadata[MetadataKeyOccurrences])
+	broadcast := <-ch
+	assert.True(t, IsRepeat(broadcast), "repeats are broadcast to update the UI but not pushed")
+	assert.NotContains(t, first.Metadata, MetadataKeyOccurrences, "the first notification is left untouched")
+
+	_, err = service.publish(detection("Parus major"))
+	require.NoError(t, err)
+	<-ch
+	stored, err := service.Get(first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, stored.Metadata[MetadataKeyOccurrences])
+
+	// Other species and components are notified separately
+	other, err := service.publish(detection("Coccothraustes coccothraustes"))
+	require.NoError(t, err)
+	assert.NotEqual(t, first.ID, other.ID)
+	<-ch
+	errNotif, err := service.CreateWithComponent(TypeError, PriorityHigh, "Bird: Parus major", "Detected", "database")
+	require.NoError(t, err)
+	assert.NotEqual(t, first.ID, errNotif.ID)
+	<-ch
+
+	// Repeats after the window start a new notification
+	late := detection("Parus major")
+	late.Timestamp = late.Timestamp.Add(11 * time.Minute)
+	next, err := service.publish(late)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.ID, next.ID)
+	assert.False(t, IsRepeat(<-ch))
+}
+
+func TestServiceDeduplicationDisabled(t *testing.T) {
+	t.Parallel()
+
+	service := createTestService()
+	defer service.Stop()
+
+	first, err := service.CreateWithComponent(TypeError, PriorityHigh, "Disk full", "No space left", "diskmanager")
+	require.NoError(t, err)
+	second, err := service.CreateWithComponent(TypeError, PriorityHigh, "Disk full", "No space left", "diskmanager")
+	require.NoError(t, err)
+	assert.NotEqual(t, first.ID, second.ID)
+}
+
+func TestDeduplicatorPrune(t *testing.T) {
+	t.Parallel()
+
+	d := newDeduplicator(time.Minute)
+	now := time.Now()
+	d.recent["old"] = dedupEntry{id: "1", first: now.Add(-2 * time.Minute)}
+	d.recent["new"] = dedupEntry{id: "2", first: now.Add(-30 * time.Second)}
+	d.prune(now)
+	assert.NotContains(t, d.recent, "old")
+	assert.Contains(t, d.recent, "new")
+
+	assert.Nil(t, newDeduplicator(0), "a zero window disables deduplication")
+}
+
+func TestOccurrences(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, 1, occurrences(NewNotification(TypeInfo, PriorityLow, "t", "m")))
+	assert.Equal(t, 4, occurrences(NewNotification(TypeInfo, PriorityLow, "t", "m").WithMetadata(MetadataKeyOccurrences, float64(4))),
+		"counts read back from the notification history are floats")
+}
@@ -0,0 +1,464 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+const (
+	// deliveryCheckInterval is how often due deliveries are attempted
+	deliveryCheckInterval = 30 * time.Second
+
+	// deliveryPruneInterval is how often delivered and dead-lettered deliveries
+	// older than the retention are removed
+	deliveryPruneInterval = time.Hour
+
+	// deliveryBatchSize caps the deliveries attempted per check, so a long
+	// outage does not flood the recovered endpoint
+	deliveryBatchSize = 20
+
+	// deliveryAttemptTimeout bounds a single queued delivery attempt
+	deliveryAttemptTimeout = 2 * time.Minute
+
+	// pushTargetPrefix prefixes the delivery target of push providers
+	pushTargetPrefix = "push:"
+)
+
+// Delivery targets of the integrations outside the push providers
+const (
+	DeliveryTargetMQTT        = "mqtt"
+	DeliveryTargetBirdWeather = "birdweather"
+)
+
+// DeliveryStatus is the state of a queued delivery
+type DeliveryStatus string
+
+const (
+	DeliveryPending    DeliveryStatus = "pending"     // waiting for its next attempt
+	DeliveryDelivered  DeliveryStatus = "delivered"   // sent by a queued attempt
+	DeliveryDeadLetter DeliveryStatus = "dead_letter" // failed all attempts, kept for inspection
+)
+
+// Sentinel errors for delivery queue operations. They are plain errors, as
+// enhanced errors of the same category match each other.
+var (
+	ErrDeliveryNotFound       = errors.NewStd("delivery not found")
+	ErrDeliveryQueueFull      = errors.NewStd("delivery queue is full")
+	ErrDeliveryAlreadySent    = errors.NewStd("delivery already delivered")
+	ErrDeliveryTargetNotFound = errors.NewStd("no handler for delivery target")
+	// ErrDeliveryDeferred is returned by handlers that cannot send now, for
+	// example while offline; the attempt is not counted
+	ErrDeliveryDeferred = errors.NewStd("delivery deferred")
+)
+
+// Delivery is an outbound delivery that failed and waits in the delivery
+// queue. Payload holds what the handler of the target needs to send it again.
+type Delivery struct {
+	ID            uint           `json:"id"`
+	Target        string         `json:"target"` // "push:<provider>", "mqtt" or "birdweather"
+	Description   string         `json:"description"`
+	Payload       []byte         `json:"-"`
+	Status        DeliveryStatus `json:"status"`
+	Attempts      int            `json:"attempts"` // queued attempts made
+	MaxAttempts   int            `json:"max_attempts"`
+	NextAttemptAt time.Time      `json:"next_attempt_at"`
+	LastError     string         `json:"last_error,omitempty"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+}
+
+// DeliveryFilter selects queued deliveries. Empty fields match all deliveries.
+type DeliveryFilter struct {
+	Statuses []DeliveryStatus
+	Target   string
+	Limit    int // 0 for no limit
+	Offset   int
+}
+
+// DeliveryStore keeps the delivery queue across restarts
+type DeliveryStore interface {
+	// Save stores a delivery, assigning its ID when zero
+	Save(d *Delivery) error
+	Get(id uint) (*Delivery, error)
+	// List returns the deliveries matching filter, newest first
+	List(filter *DeliveryFilter) ([]*Delivery, error)
+	// Count returns the number of deliveries matching filter, ignoring its
+	// limit and offset
+	Count(filter *DeliveryFilter) (int, error)
+	// Due returns up to limit pending deliveries due at now, oldest first
+	Due(now time.Time, limit int) ([]*Delivery, error)
+	// Prune removes the delivered and dead-lettered deliveries last updated
+	// before the given time and returns the number removed
+	Prune(before time.Time) (int, error)
+}
+
+// DeliveryHandler sends the payload of a queued delivery again
+type DeliveryHandler func(ctx context.Context, payload []byte) error
+
+// DeliveryQueue retries failed outbound deliveries with exponential backoff.
+// Deliveries failing every attempt become dead letters, which are kept until
+// the retention ends and can be retried by hand.
+type DeliveryQueue struct {
+	store    DeliveryStore
+	settings conf.DeliveryQueueSettings
+	log      *slog.Logger
+
+	mu       sync.RWMutex
+	handlers map[string]DeliveryHandler
+
+	attemptMu sync.Mutex // serializes attempts, so a delivery is not sent twice at once
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+var (
+	deliveryQueue   *DeliveryQueue
+	deliveryQueueMu sync.RWMutex
+)
+
+// NewDeliveryQueue creates a delivery queue keeping deliveries in store
+func NewDeliveryQueue(store DeliveryStore, settings *conf.DeliveryQueueSettings, debug bool) *DeliveryQueue {
+	return &DeliveryQueue{
+		store:    store,
+		settings: *settings,
+		log:      getFileLogger(debug),
+		handlers: make(map[string]DeliveryHandler),
+	}
+}
+
+// SetDeliveryQueue installs the global delivery queue, nil to remove it
+func SetDeliveryQueue(q *DeliveryQueue) {
+	deliveryQueueMu.Lock()
+	defer deliveryQueueMu.Unlock()
+	deliveryQueue = q
+}
+
+// GetDeliveryQueue returns the global delivery queue, nil when disabled
+func GetDeliveryQueue() *DeliveryQueue {
+	deliveryQueueMu.RLock()
+	defer deliveryQueueMu.RUnlock()
+	return deliveryQueue
+}
+
+// PushDeliveryTarget returns the delivery target of a push provider
+func PushDeliveryTarget(provider string) string {
+	return pushTargetPrefix + provider
+}
+
+// Register sets the handler sending the deliveries of a target. Deliveries
+// of a target without a handler stay pending. Push provider targets are
+// handled by the push dispatcher.
+func (q *DeliveryQueue) Register(target string, handler DeliveryHandler) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[target] = handler
+}
+
+// Enqueue stores a delivery that failed with cause, to be retried after the
+// initial delay
+func (q *DeliveryQueue) Enqueue(target, description string, payload []byte, cause error) (*Delivery, error) {
+	pending, err := q.store.Count(&DeliveryFilter{Statuses: []DeliveryStatus{DeliveryPending}})
+	if err != nil {
+		return nil, err
+	}
+	if pending >= q.settings.MaxPending {
+		q.log.Warn("delivery queue full, dropping failed delivery",
+			"target", target,
+			"description", description,
+			"max_pending", q.settings.MaxPending)
+		return nil, ErrDeliveryQueueFull
+	}
+
+	now := time.Now()
+	d := &Delivery{
+		Target:        target,
+		Description:   description,
+		Payload:       payload,
+		Status:        DeliveryPending,
+		MaxAttempts:   q.settings.MaxAttempts,
+		NextAttemptAt: now.Add(q.settings.InitialDelay),
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if cause != nil {
+		d.LastError = cause.Error()
+	}
+	if err := q.store.Save(d); err != nil {
+		return nil, err
+	}
+	q.log.Info("failed delivery queued for retry",
+		"delivery_id", d.ID,
+		"target", target,
+		"description", description,
+		"next_attempt_at", d.NextAttemptAt)
+	return d, nil
+}
+
+// Get returns a queued delivery
+func (q *DeliveryQueue) Get(id uint) (*Delivery, error) {
+	return q.store.Get(id)
+}
+
+// List returns the deliveries matching filter, newest first, with the total
+// number of matching deliveries
+func (q *DeliveryQueue) List(filter *DeliveryFilter) (deliveries []*Delivery, total int, err error) {
+	if deliveries, err = q.store.List(filter); err != nil {
+		return nil, 0, err
+	}
+	if total, err = q.store.Count(filter); err != nil {
+		return nil, 0, err
+	}
+	return deliveries, total, nil
+}
+
+// Retry attempts a pending or dead-lettered delivery now, with a new set of
+// attempts, and returns the delivery as updated by the attempt
+func (q *DeliveryQueue) Retry(ctx context.Context, id uint) (*Delivery, error) {
+	q.attemptMu.Lock()
+	defer q.attemptMu.Unlock()
+
+	d, err := q.store.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if d.Status == DeliveryDelivered {
+		return nil, ErrDeliveryAlreadySent
+	}
+	d.Status = DeliveryPending
+	d.Attempts = 0
+	d.MaxAttempts = q.settings.MaxAttempts
+	if err := q.attempt(ctx, d, time.Now()); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Start attempts due deliveries in the background until Stop
+func (q *DeliveryQueue) Start() {
+	if q.cancel != nil {
+		return // already started
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	q.cancel = cancel
+	q.done = make(chan struct{})
+
+	go func() {
+		defer close(q.done)
+		ticker := time.NewTicker(deliveryCheckInterval)
+		defer ticker.Stop()
+		lastPrune := time.Time{}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				q.ProcessDue(ctx, now)
+				if now.Sub(lastPrune) >= deliveryPruneInterval {
+					q.prune(now)
+					lastPrune = now
+				}
+			}
+		}
+	}()
+	q.log.Info("delivery queue started",
+		"max_attempts", q.settings.MaxAttempts,
+		"max_pending", q.settings.MaxPending)
+}
+
+// Stop stops attempting deliveries and waits for the running attempts
+func (q *DeliveryQueue) Stop() {
+	if q.cancel == nil {
+		return
+	}
+	q.cancel()
+	<-q.done
+	q.cancel = nil
+}
+
+// ProcessDue attempts the deliveries due at now and returns the number
+// delivered
+func (q *DeliveryQueue) ProcessDue(ctx context.Context, now time.Time) int {
+	q.attemptMu.Lock()
+	defer q.attemptMu.Unlock()
+
+	due, err := q.store.Due(now, deliveryBatchSize)
+	if err != nil {
+		q.log.Error("failed to read due deliveries", "error", err)
+		return 0
+	}
+
+	delivered := 0
+	for _, d := range due {
+		if ctx.Err() != nil {
+			break
+		}
+		if err := q.attempt(ctx, d, now); err != nil {
+			if !errors.Is(err, ErrDeliveryTargetNotFound) && !errors.Is(err, ErrDeliveryDeferred) {
+				q.log.Error("failed to update queued delivery",
+					"delivery_id", d.ID,
+					"error", err)
+			}
+			continue
+		}
+		if d.Status == DeliveryDelivered {
+			delivered++
+		}
+	}
+	return delivered
+}
+
+// attempt sends a delivery and stores the outcome: delivered, pending with
+// the next attempt backed off, or dead-lettered after its last attempt.
+// Deliveries of targets without a handler and deferred deliveries are left
+// untouched.
+func (q *DeliveryQueue) attempt(ctx context.Context, d *Delivery, now time.Time) error {
+	handler := q.handler(d.Target)
+	if handler == nil {
+		return ErrDeliveryTargetNotFound
+	}
+
+	attemptCtx, cancel := context.WithTimeout(ctx, deliveryAttemptTimeout)
+	sendErr := handler(attemptCtx, d.Payload)
+	cancel()
+	if errors.Is(sendErr, ErrDeliveryDeferred) {
+		return sendErr
+	}
+
+	d.Attempts++
+	d.UpdatedAt = now
+	switch {
+	case sendErr == nil:
+		d.Status = DeliveryDelivered
+		d.LastError = ""
+		q.log.Info("queued delivery sent",
+			"delivery_id", d.ID,
+			"target", d.Target,
+			"attempts", d.Attempts)
+	case d.Attempts >= d.MaxAttempts:
+		d.Status = DeliveryDeadLetter
+		d.LastError = sendErr.Error()
+		q.log.Error("queued delivery failed all attempts, moved to dead letters",
+			"delivery_id", d.ID,
+			"target", d.Target,
+			"attempts", d.Attempts,
+			"error", sendErr)
+	default:
+		d.LastError = sendErr.Error()
+		d.NextAttemptAt = now.Add(q.backoff(d.Attempts))
+		q.log.Warn("queued delivery failed, will retry",
+			"delivery_id", d.ID,
+			"target", d.Target,
+			"attempts", d.Attempts,
+			"next_attempt_at", d.NextAttemptAt,
+			"error", sendErr)
+	}
+	return q.store.Save(d)
+}
+
+// backoff returns the delay after the given number of failed queued
+// attempts: the initial delay doubled per attempt, up to the maximum delay
+func (q *DeliveryQueue) backoff(attempts int) time.Duration {
+	delay := q.settings.InitialDelay
+	for i := 0; i < attempts && delay < q.settings.MaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, q.settings.MaxDelay)
+}
+
+// handler returns the handler of a delivery target, nil when it has none
+func (q *DeliveryQueue) handler(target string) DeliveryHandler {
+	if provider, ok := strings.CutPrefix(target, pushTargetPrefix); ok {
+		if d := GetPushDispatcher(); d != nil {
+			return d.redeliveryHandler(provider)
+		}
+		return nil
+	}
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return q.handlers[target]
+}
+
+// prune removes the delivered and dead-lettered deliveries past the retention
+func (q *DeliveryQueue) prune(now time.Time) {
+	if q.settings.Retention <= 0 {
+		return
+	}
+	removed, err := q.store.Prune(now.Add(-q.settings.Retention))
+	if err != nil {
+		q.log.Error("failed to prune delivery queue", "error", err)
+		return
+	}
+	if removed > 0 {
+		q.log.Debug("pruned delivery queue", "removed", removed)
+	}
+}
+
+// queueFailedDelivery stores a notification the provider failed to send in
+// the delivery queue, unless the provider reported the failure as permanent
+func (d *pushDispatcher) queueFailedDelivery(notif *Notification, ep *enhancedProvider, cause error) {
+	queue := GetDeliveryQueue()
+	if queue == nil {
+		return
+	}
+	var perr *providerError
+	if errors.As(cause, &perr) && !perr.Retryable {
+		return
+	}
+
+	payload, err := json.Marshal(notif)
+	if err != nil {
+		if d.log != nil {
+			d.log.Error("failed to encode notification for the delivery queue",
+				"provider", ep.name,
+				"id", notif.ID,
+				"error", err)
+		}
+		return
+	}
+	if _, err := queue.Enqueue(PushDeliveryTarget(ep.name), notif.Title, payload, cause); err != nil && d.log != nil {
+		d.log.Warn("failed to queue notification for retry",
+			"provider", ep.name,
+			"id", notif.ID,
+			"error", err)
+	}
+}
+
+// redeliveryHandler returns the handler sending queued notifications with
+// the named provider, nil when the provider is not configured
+func (d *pushDispatcher) redeliveryHandler(provider string) DeliveryHandler {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	for i := range d.providers {
+		ep := &d.providers[i]
+		if ep.name != provider {
+			continue
+		}
+		return func(ctx context.Context, payload []byte) error {
+			var notif Notification
+			if err := json.Unmarshal(payload, &notif); err != nil {
+				return errors.New(err).
+					Component("notification").
+					Category(errors.CategorySystem).
+					Context("operation", "decode_queued_notification").
+					Context("provider", provider).
+					Build()
+			}
+			duration, err := d.attemptSend(ctx, &notif, ep)
+			d.recordAttemptMetrics(ep.name, string(notif.Type), err, duration, 1)
+			outcome := OutcomeSuccess
+			if err != nil {
+				outcome = deliveryOutcome(err)
+			}
+			deliveries.record(time.Now(), ep.name, string(notif.Type), outcome)
+			return err
+		}
+	}
+	return nil
+}
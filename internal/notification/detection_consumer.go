@@ -2,24 +2,73 @@ package notification
 
 import (
 	"bytes"
+	"cmp"
+	"context"
 	"fmt"
 	"log/slog"
+	"slices"
+	"strings"
+	"sync"
 	"text/template"
 	"time"
 
 	"github.com/tphakala/birdnet-go/internal/conf"
 	"github.com/tphakala/birdnet-go/internal/events"
+	"github.com/tphakala/birdnet-go/internal/mediaurl"
+	"github.com/tphakala/birdnet-go/internal/notification/rules"
+	"github.com/tphakala/birdnet-go/internal/notification/templatefuncs"
+	"github.com/tphakala/birdnet-go/internal/suncalc"
 )
 
+// MetadataKeyRule names the notification rule that created a detection notification
+const MetadataKeyRule = "rule"
+
+var (
+	subscriptions   rules.Subscriptions
+	subscriptionsMu sync.RWMutex
+)
+
+// SetSubscriptions installs the saved search subscriptions evaluated for
+// detections no notification rule matched, nil to remove them
+func SetSubscriptions(s rules.Subscriptions) {
+	subscriptionsMu.Lock()
+	defer subscriptionsMu.Unlock()
+	subscriptions = s
+}
+
+// getSubscriptions returns the saved search subscriptions, nil when not installed
+func getSubscriptions() rules.Subscriptions {
+	subscriptionsMu.RLock()
+	defer subscriptionsMu.RUnlock()
+	return subscriptions
+}
+
+// HasSubscriptions reports whether any saved search is subscribed to, so
+// detections of known species have to be published as well
+func HasSubscriptions() bool {
+	s := getSubscriptions()
+	return s != nil && s.Active()
+}
+
 type DetectionNotificationConsumer struct {
 	service *Service
 	logger  *slog.Logger
+	rules   *rules.Engine
 }
 
 func NewDetectionNotificationConsumer(service *Service) *DetectionNotificationConsumer {
+	var configured []conf.NotificationRule
+	var sun rules.SunTimes
+	if settings := conf.GetSettings(); settings != nil {
+		configured = settings.Notification.Rules
+		if settings.BirdNET.Latitude != 0 || settings.BirdNET.Longitude != 0 {
+			sun = suncalc.NewSunCalc(settings.BirdNET.Latitude, settings.BirdNET.Longitude)
+		}
+	}
 	return &DetectionNotificationConsumer{
 		service: service,
 		logger:  service.logger,
+		rules:   rules.New(configured, sun),
 	}
 }
 
@@ -40,12 +89,44 @@ func (c *DetectionNotificationConsumer) SupportsBatching() bool {
 }
 
 func (c *DetectionNotificationConsumer) ProcessDetectionEvent(event events.DetectionEvent) error {
-	if !event.IsNewSpecies() {
+	detectionTime := event.GetTimestamp()
+	if bt, ok := event.GetMetadata()["begin_time"].(time.Time); ok {
+		detectionTime = bt
+	}
+	detection := &rules.Detection{
+		CommonName:     event.GetSpeciesName(),
+		ScientificName: event.GetScientificName(),
+		Confidence:     event.GetConfidence(),
+		Time:           detectionTime,
+		NewSpecies:     event.IsNewSpecies(),
+	}
+	if noteID, ok := event.GetMetadata()["note_id"].(uint); ok {
+		detection.NoteID = noteID
+	}
+	rule, ok := c.rules.Evaluate(detection)
+	if !ok {
+		var err error
+		rule, ok, err = c.rules.EvaluateSubscriptions(context.Background(), getSubscriptions(), detection)
+		if err != nil {
+			c.logger.Warn("failed to match saved search subscriptions",
+				"species", event.GetSpeciesName(),
+				"note_id", detection.NoteID,
+				"error", err,
+			)
+		}
+	}
+	if !ok {
 		return nil
 	}
 
+	// New species use the new species templates unless the rule has its own
+	titleTemplate, messageTemplate := rule.Title, rule.Message
+	useNewSpeciesTemplates := event.IsNewSpecies() && rule.Title == "" && rule.Message == ""
+
 	var title, message string
 	var titleSet, messageSet bool
+	var clipURL, spectrogramURL string
+	var templateData *TemplateData
 
 	settings := conf.GetSettings()
 	if settings != nil {
@@ -53,10 +134,20 @@ func (c *DetectionNotificationConsumer) ProcessDetectionEvent(event events.Detec
 		baseURL := BuildBaseURL(settings.Security.Host, settings.WebServer.Port, settings.Security.AutoTLS)
 
 		// Create template data from event
-		templateData := NewTemplateData(event, baseURL, settings.Main.TimeAs24h)
+		templateData = NewTemplateData(event, baseURL, settings.Main.TimeAs24h).
+			WithSignedMediaURLs(mediaurl.FromSettings(settings), baseURL)
+		clipURL, spectrogramURL = templateData.ClipURL, templateData.SpectrogramURL
+
+		// Built-in templates are rendered in the notification language
+		if useNewSpeciesTemplates {
+			titleTemplate, messageTemplate = NewSpeciesTemplates(settings, "")
+		} else {
+			builtin := BuiltinTemplates(TemplateLocale(settings))
+			titleTemplate = cmp.Or(titleTemplate, builtin.DetectionTitle)
+			messageTemplate = cmp.Or(messageTemplate, builtin.DetectionMessage)
+		}
 
 		// Render title template
-		titleTemplate := settings.Notification.Templates.NewSpecies.Title
 		if titleTemplate != "" {
 			var err error
 			title, err = renderTemplate("title", titleTemplate, templateData)
@@ -74,7 +165,6 @@ func (c *DetectionNotificationConsumer) ProcessDetectionEvent(event events.Detec
 		}
 
 		// Render message template
-		messageTemplate := settings.Notification.Templates.NewSpecies.Message
 		if messageTemplate != "" {
 			var err error
 			message, err = renderTemplate("message", messageTemplate, templateData)
@@ -93,40 +183,76 @@ func (c *DetectionNotificationConsumer) ProcessDetectionEvent(event events.Detec
 	}
 
 	// Use defaults only if settings not available or template rendering failed
-	if !titleSet {
+	switch {
+	case !titleSet && event.IsNewSpecies():
 		title = fmt.Sprintf("New Species Detected: %s", event.GetSpeciesName())
+	case !titleSet:
+		title = fmt.Sprintf("%s detected", event.GetSpeciesName())
 	}
-	if !messageSet {
+	switch {
+	case !messageSet && event.IsNewSpecies():
 		message = fmt.Sprintf(
 			"First detection of %s (%s) at %s",
 			event.GetSpeciesName(),
 			event.GetScientificName(),
 			event.GetLocation(),
 		)
+	case !messageSet:
+		message = fmt.Sprintf(
+			"%s (%s) detected at %s",
+			event.GetSpeciesName(),
+			event.GetScientificName(),
+			event.GetLocation(),
+		)
+	}
+
+	priority := PriorityHigh
+	if rule.Priority != "" {
+		priority = Priority(strings.ToLower(rule.Priority))
 	}
 
-	notification := NewNotification(TypeDetection, PriorityHigh, title, message).
+	notification := NewNotification(TypeDetection, priority, title, message).
 		WithComponent("detection").
 		WithMetadata("species", event.GetSpeciesName()).
 		WithMetadata("scientific_name", event.GetScientificName()).
 		WithMetadata("confidence", event.GetConfidence()).
 		WithMetadata("location", event.GetLocation()).
-		WithMetadata("is_new_species", true).
+		WithMetadata("is_new_species", event.IsNewSpecies()).
 		WithMetadata("days_since_first_seen", event.GetDaysSinceFirstSeen()).
+		WithMetadata(MetadataKeyRule, rule.Name).
 		WithExpiry(24 * time.Hour)
 
-	if err := c.service.store.Save(notification); err != nil {
-		c.logger.Error("failed to save new species notification",
+	// Channels with their own new species templates get their own title and message
+	if useNewSpeciesTemplates && templateData != nil {
+		notification.WithChannelMessages(TemplateChannels(settings), func(channel string) (ChannelMessage, error) {
+			return c.renderNewSpeciesChannel(settings, channel, templateData)
+		})
+	}
+
+	// The channels of the rule replace the push routes
+	if len(rule.Channels) > 0 {
+		notification.WithMetadata(MetadataKeyChannels, slices.Clone(rule.Channels))
+	}
+
+	// Signed media URLs let providers attach the clip and spectrogram
+	if clipURL != "" {
+		notification.WithMetadata(MetadataKeyClipURL, clipURL).
+			WithMetadata(MetadataKeySpectrogramURL, spectrogramURL)
+	}
+
+	if _, err := c.service.publish(notification); err != nil {
+		c.logger.Error("failed to save detection notification",
 			"species", event.GetSpeciesName(),
+			"rule", rule.Name,
 			"error", err,
 		)
 		return fmt.Errorf("failed to save notification: %w", err)
 	}
 
-	c.service.broadcast(notification)
-
-	c.logger.Info("created new species notification",
+	c.logger.Info("created detection notification",
 		"species", event.GetSpeciesName(),
+		"rule", rule.Name,
+		"new_species", event.IsNewSpecies(),
 		"confidence", event.GetConfidence(),
 		"location", event.GetLocation(),
 	)
@@ -134,6 +260,27 @@ func (c *DetectionNotificationConsumer) ProcessDetectionEvent(event events.Detec
 	return nil
 }
 
+// renderNewSpeciesChannel renders the title and message of a new species
+// notification with the templates of a channel
+func (c *DetectionNotificationConsumer) renderNewSpeciesChannel(settings *conf.Settings, channel string, data *TemplateData) (ChannelMessage, error) {
+	var msg ChannelMessage
+	titleTemplate, messageTemplate := NewSpeciesTemplates(settings, channel)
+	var err error
+	if titleTemplate != "" {
+		msg.Title, err = renderTemplate("title", titleTemplate, data)
+	}
+	if err == nil && messageTemplate != "" {
+		msg.Message, err = renderTemplate("message", messageTemplate, data)
+	}
+	if err != nil {
+		c.logger.Error("failed to render channel templates, using the shared templates",
+			"channel", channel,
+			"error", err,
+		)
+	}
+	return msg, err
+}
+
 // RenderTemplate renders a Go template string with the provided data.
 // This is exported for use by the API when testing notifications.
 func RenderTemplate(name, tmplStr string, data interface{}) (string, error) {
@@ -141,7 +288,7 @@ func RenderTemplate(name, tmplStr string, data interface{}) (string, error) {
 }
 
 func renderTemplate(name, tmplStr string, data interface{}) (string, error) {
-	tmpl, err := template.New(name).Parse(tmplStr)
+	tmpl, err := template.New(name).Funcs(templatefuncs.FuncMap()).Parse(tmplStr)
 	if err != nil {
 		return "", fmt.Errorf("failed to parse template: %w", err)
 	}
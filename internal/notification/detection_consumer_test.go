@@ -1,13 +1,16 @@
 package notification
 
 import (
+	"context"
 	"os"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/conf"
 	"github.com/tphakala/birdnet-go/internal/events"
+	"github.com/tphakala/birdnet-go/internal/notification/rules"
 	"go.uber.org/goleak"
 )
 
@@ -214,3 +217,91 @@ func TestDetectionNotificationConsumer_PreSanitizedLocations(t *testing.T) {
 		})
 	}
 }
+
+func TestDetectionNotificationConsumer_Rules(t *testing.T) {
+	t.Parallel()
+
+	config := &ServiceConfig{
+		MaxNotifications:   100,
+		CleanupInterval:    5 * time.Minute,
+		RateLimitWindow:    1 * time.Minute,
+		RateLimitMaxEvents: 100,
+	}
+	service := NewService(config)
+	require.NotNil(t, service)
+	defer service.Stop()
+
+	consumer := NewDetectionNotificationConsumer(service)
+	consumer.rules = rules.New([]conf.NotificationRule{{
+		Name:          "owls",
+		Enabled:       true,
+		Species:       []string{"*owl"},
+		MinConfidence: 0.8,
+		Channels:      []string{"telegram"},
+		Priority:      "critical",
+		Title:         "Owl: {{.CommonName}}",
+	}}, nil)
+
+	// A known species matching no rule is not notified
+	event, err := events.NewDetectionEvent("House Sparrow", "Passer domesticus", 0.95, "feeder", true, 0)
+	require.NoError(t, err)
+	require.NoError(t, consumer.ProcessDetectionEvent(event))
+
+	event, err = events.NewDetectionEvent("Tawny Owl", "Strix aluco", 0.9, "garden", false, 3)
+	require.NoError(t, err)
+	require.NoError(t, consumer.ProcessDetectionEvent(event))
+
+	notifications, err := service.List(&FilterOptions{Types: []Type{TypeDetection}, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, notifications, 1)
+
+	notif := notifications[0]
+	assert.Equal(t, PriorityCritical, notif.Priority)
+	assert.Equal(t, "Owl: Tawny Owl", notif.Title)
+	assert.Equal(t, "owls", notif.Metadata[MetadataKeyRule])
+	assert.Equal(t, []string{"telegram"}, notif.Metadata[MetadataKeyChannels])
+	assert.Equal(t, false, notif.Metadata["is_new_species"])
+}
+
+// noteSubscriptions matches the detections with the listed note IDs
+type noteSubscriptions map[uint][]rules.Subscription
+
+func (s noteSubscriptions) Active() bool { return len(s) > 0 }
+
+func (s noteSubscriptions) Matching(_ context.Context, noteID uint) ([]rules.Subscription, error) {
+	return s[noteID], nil
+}
+
+func TestDetectionNotificationConsumer_Subscriptions(t *testing.T) {
+	config := &ServiceConfig{
+		MaxNotifications:   100,
+		CleanupInterval:    5 * time.Minute,
+		RateLimitWindow:    1 * time.Minute,
+		RateLimitMaxEvents: 100,
+	}
+	service := NewService(config)
+	require.NotNil(t, service)
+	defer service.Stop()
+
+	SetSubscriptions(noteSubscriptions{5: {{Name: "Owls"}}})
+	t.Cleanup(func() { SetSubscriptions(nil) })
+	assert.True(t, HasSubscriptions())
+
+	consumer := NewDetectionNotificationConsumer(service)
+	consumer.rules = rules.New([]conf.NotificationRule{{Name: "new", Enabled: true, NewSpecies: true}}, nil)
+
+	detect := func(noteID uint) {
+		event, err := events.NewDetectionEvent("Tawny Owl", "Strix aluco", 0.95, "garden", false, 3)
+		require.NoError(t, err)
+		event.GetMetadata()["note_id"] = noteID
+		require.NoError(t, consumer.ProcessDetectionEvent(event))
+	}
+	detect(4)
+	detect(5)
+
+	notifications, err := service.List(&FilterOptions{Types: []Type{TypeDetection}, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, notifications, 1, "only the detection matching the saved search is notified")
+	assert.Equal(t, rules.SubscriptionRulePrefix+"Owls", notifications[0].Metadata[MetadataKeyRule])
+	assert.Equal(t, PriorityHigh, notifications[0].Priority)
+}
@@ -0,0 +1,254 @@
+// Package digest sends a daily digest notification summarizing the day's
+// detections: species counts, new species and the highest confidence clips.
+package digest
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/errors"
+	"github.com/tphakala/birdnet-go/internal/mediaurl"
+	"github.com/tphakala/birdnet-go/internal/notification"
+)
+
+// checkInterval is how often the job checks whether the digest is due
+const checkInterval = time.Minute
+
+// MetadataKeyDailyDigest is the date (YYYY-MM-DD) summarized by a digest notification
+const MetadataKeyDailyDigest = "daily_digest"
+
+// Store is the datastore capability needed to summarize a day
+type Store interface {
+	GetDailySummary(ctx context.Context, date string, minConfidence float64, topClips int) (*datastore.DailySummary, error)
+}
+
+// Species is a species detected on the day of a digest
+type Species struct {
+	CommonName        string
+	ScientificName    string
+	Count             int
+	ConfidencePercent string // of the highest confidence detection
+	FirstHeard        string
+}
+
+// Clip is one of the highest confidence detections of a digest
+type Clip struct {
+	CommonName        string
+	ScientificName    string
+	Confidence        float64
+	ConfidencePercent string
+	Time              string
+	DetectionURL      string
+	ClipURL           string // signed short-lived URL of the audio clip, empty when signing is unavailable
+}
+
+// Data is the data the digest templates are executed with
+type Data struct {
+	Date       string    // YYYY-MM-DD
+	Detections int       // detections of all species
+	Species    []Species // most detected first
+	NewSpecies []Species // species detected for the first time
+	TopClips   []Clip    // highest confidence clips, one per species
+	URL        string    // dashboard URL
+}
+
+// Digest builds and sends the daily digest
+type Digest struct {
+	settings *conf.Settings
+	store    Store
+	signer   *mediaurl.Signer
+	now      func() time.Time
+	send     func(*notification.Notification) error
+}
+
+// New returns a digest of the detections in store. Notifications are sent
+// through the notification service.
+func New(settings *conf.Settings, store Store) *Digest {
+	return &Digest{
+		settings: settings,
+		store:    store,
+		signer:   mediaurl.FromSettings(settings),
+		now:      time.Now,
+		send:     sendNotification,
+	}
+}
+
+// sendNotification sends a notification through the notification service
+func sendNotification(n *notification.Notification) error {
+	if !notification.IsInitialized() {
+		return errors.Newf("notification service is not initialized").
+			Component("notification.digest").
+			Category(errors.CategorySystem).
+			Build()
+	}
+	return notification.GetService().CreateWithMetadata(n)
+}
+
+// Run sends the digest every day at the configured time until ctx is
+// cancelled. A digest due while the job was not running is not sent.
+func (d *Digest) Run(ctx context.Context, onSent func(*notification.Notification, error)) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	last := d.now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		now := d.now()
+		if due, ok := d.due(last, now); ok {
+			n, err := d.Send(ctx, due)
+			if onSent != nil && (n != nil || err != nil) {
+				onSent(n, err)
+			}
+		}
+		last = now
+	}
+}
+
+// due returns the send time of the digest when it falls in (last, now]
+func (d *Digest) due(last, now time.Time) (time.Time, bool) {
+	sendAt, err := time.Parse("15:04", d.settings.Notification.DailyDigest.Time)
+	if err != nil {
+		return time.Time{}, false
+	}
+	due := time.Date(now.Year(), now.Month(), now.Day(), sendAt.Hour(), sendAt.Minute(), 0, 0, now.Location())
+	return due, last.Before(due) && !now.Before(due)
+}
+
+// Send sends the digest of the day of t. It returns the notification sent,
+// or nil when nothing was detected that day.
+func (d *Digest) Send(ctx context.Context, t time.Time) (*notification.Notification, error) {
+	data, err := d.Build(ctx, t.Format(time.DateOnly))
+	if err != nil {
+		return nil, err
+	}
+	if data.Detections == 0 {
+		return nil, nil
+	}
+
+	title, message, err := render(d.settings, "", data)
+	if err != nil {
+		return nil, err
+	}
+
+	n := notification.NewNotification(notification.TypeDetection, notification.PriorityMedium, title, message).
+		WithComponent("digest").
+		WithMetadata(MetadataKeyDailyDigest, data.Date).
+		WithMetadata("detections", data.Detections).
+		WithMetadata("species_count", len(data.Species)).
+		WithMetadata("new_species_count", len(data.NewSpecies)).
+		WithExpiry(24 * time.Hour)
+
+	// Channels with their own templates get their own title and message
+	n.WithChannelMessages(notification.TemplateChannels(d.settings), func(channel string) (notification.ChannelMessage, error) {
+		title, message, err := render(d.settings, channel, data)
+		return notification.ChannelMessage{Title: title, Message: message}, err
+	})
+
+	// Providers attach the best clip of the day
+	if len(data.TopClips) > 0 && data.TopClips[0].ClipURL != "" {
+		n.WithMetadata(notification.MetadataKeyClipURL, data.TopClips[0].ClipURL)
+	}
+
+	if err := d.send(n); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// Build returns the digest data of a date (YYYY-MM-DD)
+func (d *Digest) Build(ctx context.Context, date string) (*Data, error) {
+	summary, err := d.store.GetDailySummary(ctx, date, 0, d.settings.Notification.DailyDigest.TopClips)
+	if err != nil {
+		return nil, err
+	}
+
+	baseURL := notification.BuildBaseURL(d.settings.Security.Host, d.settings.WebServer.Port, d.settings.Security.AutoTLS)
+	data := &Data{
+		Date:       summary.Date,
+		Detections: summary.TotalDetections,
+		URL:        baseURL + "/ui/dashboard",
+	}
+	for i := range summary.Species {
+		s := &summary.Species[i]
+		species := Species{
+			CommonName:        s.CommonName,
+			ScientificName:    s.ScientificName,
+			Count:             s.Count,
+			ConfidencePercent: percent(s.MaxConfidence),
+			FirstHeard:        d.formatTime(s.FirstHeard),
+		}
+		data.Species = append(data.Species, species)
+		if s.IsNew {
+			data.NewSpecies = append(data.NewSpecies, species)
+		}
+	}
+	for i := range summary.TopClips {
+		note := &summary.TopClips[i]
+		id := strconv.FormatUint(uint64(note.ID), 10)
+		clip := Clip{
+			CommonName:        note.CommonName,
+			ScientificName:    note.ScientificName,
+			Confidence:        note.Confidence,
+			ConfidencePercent: percent(note.Confidence),
+			Time:              d.formatTime(note.Time),
+			DetectionURL:      fmt.Sprintf("%s/ui/detections/%s", baseURL, id),
+		}
+		if d.signer != nil {
+			clipPath, _ := d.signer.Sign(mediaurl.KindAudio, id, 0)
+			clip.ClipURL = baseURL + clipPath
+		}
+		data.TopClips = append(data.TopClips, clip)
+	}
+	return data, nil
+}
+
+// formatTime formats a "15:04:05" time of day in the configured clock format
+func (d *Digest) formatTime(hms string) string {
+	t, err := time.Parse(time.TimeOnly, hms)
+	if err != nil {
+		return hms
+	}
+	if d.settings.Main.TimeAs24h {
+		return t.Format("15:04")
+	}
+	return t.Format("3:04 PM")
+}
+
+// render renders the title and message of the digest with the templates of
+// a channel, the shared ones for the empty channel
+func render(settings *conf.Settings, channel string, data *Data) (title, message string, err error) {
+	titleTemplate, messageTemplate := notification.DigestTemplates(settings, channel)
+	title, err = notification.RenderTemplate("title", titleTemplate, data)
+	if err != nil {
+		return "", "", templateError(err, "title")
+	}
+	message, err = notification.RenderTemplate("message", messageTemplate, data)
+	if err != nil {
+		return "", "", templateError(err, "message")
+	}
+	return title, message, nil
+}
+
+// percent formats a confidence from 0 to 1 as a whole percentage
+func percent(confidence float64) string {
+	return fmt.Sprintf("%.0f", confidence*100)
+}
+
+// templateError wraps a failure to render a daily digest template
+func templateError(err error, template string) error {
+	return errors.New(err).
+		Component("notification.digest").
+		Category(errors.CategoryValidation).
+		Context("operation", "render_daily_digest").
+		Context("template", template).
+		Build()
+}
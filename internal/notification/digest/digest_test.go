@@ -0,0 +1,154 @@
+package digest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/notification"
+)
+
+// fakeStore returns a fixed summary for one date
+type fakeStore struct {
+	summary  datastore.DailySummary
+	topClips int // requested number of clips
+}
+
+func (s *fakeStore) GetDailySummary(_ context.Context, date string, _ float64, topClips int) (*datastore.DailySummary, error) {
+	s.topClips = topClips
+	if date != s.summary.Date {
+		return &datastore.DailySummary{Date: date}, nil
+	}
+	summary := s.summary
+	return &summary, nil
+}
+
+func newTestDigest(store Store) (*Digest, *[]*notification.Notification) {
+	settings := &conf.Settings{}
+	settings.Security.Host = "birdnet.example.org"
+	settings.WebServer.Port = "8080"
+	settings.Main.TimeAs24h = true
+	settings.Notification.DailyDigest = conf.DailyDigestSettings{Enabled: true, Time: "21:00", TopClips: 3}
+
+	var sent []*notification.Notification
+	d := New(settings, store)
+	d.signer = nil
+	d.send = func(n *notification.Notification) error {
+		sent = append(sent, n)
+		return nil
+	}
+	return d, &sent
+}
+
+func mayFirst() datastore.DailySummary {
+	return datastore.DailySummary{
+		Date:            "2024-05-01",
+		TotalDetections: 12,
+		Species: []datastore.DailySpeciesCount{
+			{CommonName: "Eurasian Blackbird", ScientificName: "Turdus merula", Count: 9, MaxConfidence: 0.95, FirstHeard: "04:52:10"},
+			{CommonName: "Eurasian Wryneck", ScientificName: "Jynx torquilla", Count: 3, MaxConfidence: 0.81, FirstHeard: "06:15:00", IsNew: true},
+		},
+		TopClips: []datastore.Note{
+			{ID: 7, CommonName: "Eurasian Blackbird", ScientificName: "Turdus merula", Confidence: 0.95, Time: "05:10:00", ClipName: "a.wav"},
+		},
+	}
+}
+
+func TestDigestSend(t *testing.T) {
+	t.Parallel()
+
+	store := &fakeStore{summary: mayFirst()}
+	d, sent := newTestDigest(store)
+
+	n, err := d.Send(context.Background(), time.Date(2024, 5, 1, 21, 0, 0, 0, time.Local))
+	require.NoError(t, err)
+	require.Len(t, *sent, 1)
+	assert.Same(t, n, (*sent)[0])
+	assert.Equal(t, 3, store.topClips)
+
+	assert.Equal(t, notification.TypeDetection, n.Type)
+	assert.Equal(t, notification.PriorityMedium, n.Priority)
+	assert.Equal(t, "12 detections of 2 species on 2024-05-01", n.Title)
+	assert.Equal(t, `New species: Eurasian Wryneck
+
+Most detected:
+- Eurasian Blackbird: 9
+- Eurasian Wryneck: 3
+
+Best clips:
+- Eurasian Blackbird 95% at 05:10 http://birdnet.example.org:8080/ui/detections/7
+`, n.Message)
+	assert.Equal(t, "2024-05-01", n.Metadata[MetadataKeyDailyDigest])
+	assert.Equal(t, 1, n.Metadata["new_species_count"])
+
+	// Nothing is sent for a day without detections
+	n, err = d.Send(context.Background(), time.Date(2024, 5, 2, 21, 0, 0, 0, time.Local))
+	require.NoError(t, err)
+	assert.Nil(t, n)
+	assert.Len(t, *sent, 1)
+}
+
+func TestDigestTemplates(t *testing.T) {
+	t.Parallel()
+
+	d, sent := newTestDigest(&fakeStore{summary: mayFirst()})
+	d.settings.Notification.Templates.DailyDigest = conf.DailyDigestTemplate{
+		Title:   "Birds on {{.Date}}",
+		Message: "{{range .Species}}{{.CommonName}} first heard {{.FirstHeard}}, best {{.ConfidencePercent}}%\n{{end}}{{.URL}}",
+	}
+
+	_, err := d.Send(context.Background(), time.Date(2024, 5, 1, 21, 0, 0, 0, time.Local))
+	require.NoError(t, err)
+	require.Len(t, *sent, 1)
+	assert.Equal(t, "Birds on 2024-05-01", (*sent)[0].Title)
+	assert.Equal(t, "Eurasian Blackbird first heard 04:52, best 95%\nEurasian Wryneck first heard 06:15, best 81%\nhttp://birdnet.example.org:8080/ui/dashboard", (*sent)[0].Message)
+
+	d.settings.Notification.Templates.DailyDigest.Title = "{{.Missing}}"
+	_, err = d.Send(context.Background(), time.Date(2024, 5, 1, 21, 0, 0, 0, time.Local))
+	require.Error(t, err)
+	assert.Len(t, *sent, 1)
+}
+
+func TestDigestChannelTemplates(t *testing.T) {
+	t.Parallel()
+
+	d, sent := newTestDigest(&fakeStore{summary: mayFirst()})
+	d.settings.Realtime.Dashboard.Locale = "de"
+	d.settings.Notification.Templates.Channels = map[string]conf.ChannelTemplates{
+		"telegram": {DailyDigest: conf.DailyDigestTemplate{Title: "{{.Detections}} birds"}},
+	}
+
+	_, err := d.Send(context.Background(), time.Date(2024, 5, 1, 21, 0, 0, 0, time.Local))
+	require.NoError(t, err)
+	require.Len(t, *sent, 1)
+	n := (*sent)[0]
+
+	// The built-in templates follow the UI language
+	assert.Equal(t, "12 Erkennungen von 2 Arten am 2024-05-01", n.Title)
+	assert.Contains(t, n.Message, "Neue Arten: Eurasian Wryneck")
+
+	telegram := n.ForChannel("telegram")
+	assert.Equal(t, "12 birds", telegram.Title)
+	assert.Equal(t, n.Message, telegram.Message, "the message is shared")
+	assert.Same(t, n, n.ForChannel("webhook"))
+}
+
+func TestDigestDue(t *testing.T) {
+	t.Parallel()
+
+	d, _ := newTestDigest(&fakeStore{})
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local)
+
+	due, ok := d.due(day.Add(20*time.Hour+59*time.Minute+30*time.Second), day.Add(21*time.Hour+30*time.Second))
+	require.True(t, ok)
+	assert.Equal(t, day.Add(21*time.Hour), due)
+
+	_, ok = d.due(day.Add(21*time.Hour+30*time.Second), day.Add(21*time.Hour+90*time.Second))
+	assert.False(t, ok, "the digest is sent once")
+	_, ok = d.due(day.Add(21*time.Hour+5*time.Minute), day.Add(21*time.Hour+6*time.Minute))
+	assert.False(t, ok, "a digest missed before startup is not sent")
+}
@@ -0,0 +1,144 @@
+package history
+
+import (
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/notification"
+)
+
+// DeliveryPersister is the datastore capability of keeping the delivery
+// queue; implemented by datastore.DataStore
+type DeliveryPersister interface {
+	SaveDelivery(record *datastore.DeliveryRecord) error
+	GetDelivery(id uint) (*datastore.DeliveryRecord, error)
+	ListDeliveries(filter *datastore.DeliveryFilter) ([]datastore.DeliveryRecord, error)
+	CountDeliveries(filter *datastore.DeliveryFilter) (int64, error)
+	DueDeliveries(status string, now time.Time, limit int) ([]datastore.DeliveryRecord, error)
+	PruneDeliveries(statuses []string, before time.Time) (int64, error)
+}
+
+// DeliveryStore keeps the delivery queue in the datastore, so failed
+// deliveries are retried after a restart
+type DeliveryStore struct {
+	db DeliveryPersister
+}
+
+// NewDeliveryStore creates a delivery store keeping deliveries in the
+// datastore
+func NewDeliveryStore(db DeliveryPersister) *DeliveryStore {
+	return &DeliveryStore{db: db}
+}
+
+// Save stores a delivery, assigning its ID when zero
+func (s *DeliveryStore) Save(d *notification.Delivery) error {
+	record := toDeliveryRecord(d)
+	if err := s.db.SaveDelivery(record); err != nil {
+		return err
+	}
+	d.ID = record.ID
+	return nil
+}
+
+// Get retrieves a delivery by ID
+func (s *DeliveryStore) Get(id uint) (*notification.Delivery, error) {
+	record, err := s.db.GetDelivery(id)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, notification.ErrDeliveryNotFound
+		}
+		return nil, err
+	}
+	return fromDeliveryRecord(record), nil
+}
+
+// List returns the deliveries matching filter, newest first
+func (s *DeliveryStore) List(filter *notification.DeliveryFilter) ([]*notification.Delivery, error) {
+	records, err := s.db.ListDeliveries(toDeliveryFilter(filter))
+	if err != nil {
+		return nil, err
+	}
+	return fromDeliveryRecords(records), nil
+}
+
+// Count returns the number of deliveries matching filter
+func (s *DeliveryStore) Count(filter *notification.DeliveryFilter) (int, error) {
+	count, err := s.db.CountDeliveries(toDeliveryFilter(filter))
+	return int(count), err
+}
+
+// Due returns up to limit pending deliveries due at now, oldest first
+func (s *DeliveryStore) Due(now time.Time, limit int) ([]*notification.Delivery, error) {
+	records, err := s.db.DueDeliveries(string(notification.DeliveryPending), now, limit)
+	if err != nil {
+		return nil, err
+	}
+	return fromDeliveryRecords(records), nil
+}
+
+// Prune removes the delivered and dead-lettered deliveries last updated
+// before the given time
+func (s *DeliveryStore) Prune(before time.Time) (int, error) {
+	statuses := []string{string(notification.DeliveryDelivered), string(notification.DeliveryDeadLetter)}
+	removed, err := s.db.PruneDeliveries(statuses, before)
+	return int(removed), err
+}
+
+// toDeliveryRecord converts a delivery to its datastore record
+func toDeliveryRecord(d *notification.Delivery) *datastore.DeliveryRecord {
+	return &datastore.DeliveryRecord{
+		ID:            d.ID,
+		Target:        d.Target,
+		Description:   d.Description,
+		Payload:       d.Payload,
+		Status:        string(d.Status),
+		Attempts:      d.Attempts,
+		MaxAttempts:   d.MaxAttempts,
+		NextAttemptAt: d.NextAttemptAt,
+		LastError:     d.LastError,
+		CreatedAt:     d.CreatedAt,
+		UpdatedAt:     d.UpdatedAt,
+	}
+}
+
+// fromDeliveryRecords converts datastore records to deliveries
+func fromDeliveryRecords(records []datastore.DeliveryRecord) []*notification.Delivery {
+	results := make([]*notification.Delivery, 0, len(records))
+	for i := range records {
+		results = append(results, fromDeliveryRecord(&records[i]))
+	}
+	return results
+}
+
+// fromDeliveryRecord converts a datastore record to a delivery
+func fromDeliveryRecord(record *datastore.DeliveryRecord) *notification.Delivery {
+	return &notification.Delivery{
+		ID:            record.ID,
+		Target:        record.Target,
+		Description:   record.Description,
+		Payload:       record.Payload,
+		Status:        notification.DeliveryStatus(record.Status),
+		Attempts:      record.Attempts,
+		MaxAttempts:   record.MaxAttempts,
+		NextAttemptAt: record.NextAttemptAt,
+		LastError:     record.LastError,
+		CreatedAt:     record.CreatedAt,
+		UpdatedAt:     record.UpdatedAt,
+	}
+}
+
+// toDeliveryFilter converts a delivery filter to a datastore filter
+func toDeliveryFilter(filter *notification.DeliveryFilter) *datastore.DeliveryFilter {
+	if filter == nil {
+		return nil
+	}
+	recordFilter := &datastore.DeliveryFilter{
+		Target: filter.Target,
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	}
+	for _, st := range filter.Statuses {
+		recordFilter.Statuses = append(recordFilter.Statuses, string(st))
+	}
+	return recordFilter
+}
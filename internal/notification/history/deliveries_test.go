@@ -0,0 +1,115 @@
+package history
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/notification"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newTestDeliveryQueue(t *testing.T, maxPending int) *notification.DeliveryQueue {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&datastore.DeliveryRecord{}))
+	settings := conf.DeliveryQueueSettings{
+		Enabled:      true,
+		MaxAttempts:  3,
+		InitialDelay: time.Minute,
+		MaxDelay:     3 * time.Minute,
+		MaxPending:   maxPending,
+		Retention:    time.Hour,
+	}
+	return notification.NewDeliveryQueue(NewDeliveryStore(&datastore.DataStore{DB: db}), &settings, false)
+}
+
+func TestDeliveryQueueBackoffAndDeadLetter(t *testing.T) {
+	t.Parallel()
+
+	queue := newTestDeliveryQueue(t, 10)
+	var sent [][]byte
+	failing := true
+	queue.Register(notification.DeliveryTargetMQTT, func(ctx context.Context, payload []byte) error {
+		if failing {
+			return stderrors.New("broker unavailable")
+		}
+		sent = append(sent, payload)
+		return nil
+	})
+
+	d, err := queue.Enqueue(notification.DeliveryTargetMQTT, "Eurasian Wryneck", []byte(`{"topic":"birdnet"}`), stderrors.New("not connected"))
+	require.NoError(t, err)
+	assert.Equal(t, notification.DeliveryPending, d.Status)
+	assert.Equal(t, "not connected", d.LastError)
+
+	ctx := context.Background()
+	now := d.NextAttemptAt
+	assert.Zero(t, queue.ProcessDue(ctx, now.Add(-time.Second)), "not due before the initial delay")
+
+	// Failed attempts back off exponentially up to the maximum delay
+	wantDelays := []time.Duration{2 * time.Minute, 3 * time.Minute}
+	for _, want := range wantDelays {
+		assert.Zero(t, queue.ProcessDue(ctx, now))
+		d, err = queue.Get(d.ID)
+		require.NoError(t, err)
+		assert.Equal(t, notification.DeliveryPending, d.Status)
+		assert.Equal(t, "broker unavailable", d.LastError)
+		assert.WithinDuration(t, now.Add(want), d.NextAttemptAt, time.Millisecond)
+		now = d.NextAttemptAt
+	}
+
+	// The last attempt moves the delivery to the dead letters
+	queue.ProcessDue(ctx, now)
+	d, err = queue.Get(d.ID)
+	require.NoError(t, err)
+	assert.Equal(t, notification.DeliveryDeadLetter, d.Status)
+	assert.Equal(t, 3, d.Attempts)
+	assert.Zero(t, queue.ProcessDue(ctx, now.Add(time.Hour)), "dead letters are not attempted")
+
+	deadLetters, total, err := queue.List(&notification.DeliveryFilter{Statuses: []notification.DeliveryStatus{notification.DeliveryDeadLetter}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	require.Len(t, deadLetters, 1)
+
+	// A manual retry sends it again with a new set of attempts
+	failing = false
+	d, err = queue.Retry(ctx, d.ID)
+	require.NoError(t, err)
+	assert.Equal(t, notification.DeliveryDelivered, d.Status)
+	assert.Equal(t, 1, d.Attempts)
+	assert.Equal(t, [][]byte{[]byte(`{"topic":"birdnet"}`)}, sent)
+
+	_, err = queue.Retry(ctx, d.ID)
+	require.ErrorIs(t, err, notification.ErrDeliveryAlreadySent)
+	_, err = queue.Retry(ctx, 99)
+	require.ErrorIs(t, err, notification.ErrDeliveryNotFound)
+}
+
+func TestDeliveryQueueLimits(t *testing.T) {
+	t.Parallel()
+
+	queue := newTestDeliveryQueue(t, 1)
+
+	d, err := queue.Enqueue(notification.DeliveryTargetBirdWeather, "upload", []byte("clip"), nil)
+	require.NoError(t, err)
+	_, err = queue.Enqueue(notification.DeliveryTargetBirdWeather, "upload", []byte("clip"), nil)
+	require.ErrorIs(t, err, notification.ErrDeliveryQueueFull)
+
+	// Deliveries of targets without a handler wait for one
+	assert.Zero(t, queue.ProcessDue(context.Background(), d.NextAttemptAt))
+	_, err = queue.Retry(context.Background(), d.ID)
+	require.ErrorIs(t, err, notification.ErrDeliveryTargetNotFound)
+	d, err = queue.Get(d.ID)
+	require.NoError(t, err)
+	assert.Equal(t, notification.DeliveryPending, d.Status)
+	assert.Zero(t, d.Attempts)
+}
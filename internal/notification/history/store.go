@@ -0,0 +1,188 @@
+// Package history keeps the notification history in the datastore, so
+// notifications survive restarts.
+package history
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/errors"
+	"github.com/tphakala/birdnet-go/internal/notification"
+)
+
+// Persister is the datastore capability of keeping the notification
+// history; implemented by datastore.DataStore
+type Persister interface {
+	SaveNotification(record *datastore.NotificationRecord) error
+	GetNotification(id string) (*datastore.NotificationRecord, error)
+	ListNotifications(filter *datastore.NotificationFilter) ([]datastore.NotificationRecord, error)
+	CountNotifications(filter *datastore.NotificationFilter) (int64, error)
+	DeleteNotification(id string) error
+	PruneNotifications(now time.Time, keep int) (int64, error)
+}
+
+// Store is a notification store keeping notifications in the datastore.
+// Expired notifications and the oldest notifications beyond maxSize are
+// removed by DeleteExpired, which the notification service runs every cleanup
+// interval. Toast notifications are ephemeral and not stored.
+type Store struct {
+	db      Persister
+	maxSize int
+}
+
+// NewStore creates a notification store keeping up to maxSize notifications
+// in the datastore
+func NewStore(db Persister, maxSize int) *Store {
+	if maxSize <= 0 {
+		maxSize = 1000 // Default to 1000 notifications
+	}
+	return &Store{db: db, maxSize: maxSize}
+}
+
+// Save stores a notification in the datastore
+func (s *Store) Save(n *notification.Notification) error {
+	if isToast, _ := n.Metadata[notification.MetadataKeyIsToast].(bool); isToast {
+		return nil
+	}
+	record, err := toRecord(n)
+	if err != nil {
+		return err
+	}
+	return s.db.SaveNotification(record)
+}
+
+// Get retrieves a notification by ID
+func (s *Store) Get(id string) (*notification.Notification, error) {
+	record, err := s.db.GetNotification(id)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, notification.ErrNotificationNotFound
+		}
+		return nil, err
+	}
+	return fromRecord(record), nil
+}
+
+// List returns filtered notifications, newest first
+func (s *Store) List(filter *notification.FilterOptions) ([]*notification.Notification, error) {
+	records, err := s.db.ListNotifications(toRecordFilter(filter))
+	if err != nil {
+		return nil, err
+	}
+	results := make([]*notification.Notification, 0, len(records))
+	for i := range records {
+		results = append(results, fromRecord(&records[i]))
+	}
+	return results, nil
+}
+
+// Count returns the number of notifications matching the filter
+func (s *Store) Count(filter *notification.FilterOptions) (int, error) {
+	count, err := s.db.CountNotifications(toRecordFilter(filter))
+	return int(count), err
+}
+
+// Update modifies an existing notification
+func (s *Store) Update(n *notification.Notification) error {
+	if _, err := s.Get(n.ID); err != nil {
+		return err
+	}
+	return s.Save(n)
+}
+
+// Delete removes a notification
+func (s *Store) Delete(id string) error {
+	return s.db.DeleteNotification(id)
+}
+
+// DeleteExpired removes expired notifications and the oldest notifications
+// beyond the maximum number kept
+func (s *Store) DeleteExpired() error {
+	_, err := s.db.PruneNotifications(time.Now(), s.maxSize)
+	return err
+}
+
+// GetUnreadCount returns the count of unread notifications
+func (s *Store) GetUnreadCount() (int, error) {
+	return s.Count(&notification.FilterOptions{Status: []notification.Status{notification.StatusUnread}})
+}
+
+// toRecord converts a notification to its datastore record
+func toRecord(n *notification.Notification) (*datastore.NotificationRecord, error) {
+	record := &datastore.NotificationRecord{
+		ID:        n.ID,
+		Type:      string(n.Type),
+		Priority:  string(n.Priority),
+		Status:    string(n.Status),
+		Title:     n.Title,
+		Message:   n.Message,
+		Component: n.Component,
+		Timestamp: n.Timestamp,
+		ExpiresAt: n.ExpiresAt,
+	}
+	if len(n.Metadata) > 0 {
+		metadata, err := json.Marshal(n.Metadata)
+		if err != nil {
+			return nil, errors.New(err).
+				Component("notification.history").
+				Category(errors.CategorySystem).
+				Context("operation", "encode_notification_metadata").
+				Context("notification_id", n.ID).
+				Build()
+		}
+		record.Metadata = string(metadata)
+	}
+	return record, nil
+}
+
+// fromRecord converts a datastore record to a notification. Metadata values
+// are decoded from JSON, so numbers are float64 and times are strings.
+func fromRecord(record *datastore.NotificationRecord) *notification.Notification {
+	n := &notification.Notification{
+		ID:        record.ID,
+		Type:      notification.Type(record.Type),
+		Priority:  notification.Priority(record.Priority),
+		Status:    notification.Status(record.Status),
+		Title:     record.Title,
+		Message:   record.Message,
+		Component: record.Component,
+		Timestamp: record.Timestamp,
+		ExpiresAt: record.ExpiresAt,
+		Metadata:  make(map[string]any),
+	}
+	if record.Metadata != "" {
+		_ = json.Unmarshal([]byte(record.Metadata), &n.Metadata)
+	}
+	return n
+}
+
+// toRecordFilter converts filter options to a datastore filter
+func toRecordFilter(filter *notification.FilterOptions) *datastore.NotificationFilter {
+	if filter == nil {
+		return nil
+	}
+	recordFilter := &datastore.NotificationFilter{
+		Component: filter.Component,
+		Since:     filter.Since,
+		Until:     filter.Until,
+		Limit:     filter.Limit,
+		Offset:    filter.Offset,
+	}
+	for _, t := range filter.Types {
+		recordFilter.Types = append(recordFilter.Types, string(t))
+	}
+	for _, p := range filter.Priorities {
+		recordFilter.Priorities = append(recordFilter.Priorities, string(p))
+	}
+	for _, st := range filter.Status {
+		recordFilter.Statuses = append(recordFilter.Statuses, string(st))
+	}
+	return recordFilter
+}
+
+// isNotFound reports whether err is a not found error
+func isNotFound(err error) bool {
+	var enhanced *errors.EnhancedError
+	return errors.As(err, &enhanced) && enhanced.Category == errors.CategoryNotFound
+}
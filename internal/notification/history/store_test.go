@@ -0,0 +1,89 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/notification"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newTestStore(t *testing.T, maxSize int) *Store {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&datastore.NotificationRecord{}))
+	return NewStore(&datastore.DataStore{DB: db}, maxSize)
+}
+
+func TestStoreRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	store := newTestStore(t, 10)
+
+	n := notification.NewNotification(notification.TypeDetection, notification.PriorityHigh, "New species", "Eurasian Wryneck").
+		WithComponent("detection").
+		WithMetadata("species", "Jynx torquilla").
+		WithMetadata("confidence", 0.81)
+	require.NoError(t, store.Save(n))
+
+	read, err := store.Get(n.ID)
+	require.NoError(t, err)
+	assert.Equal(t, n.Title, read.Title)
+	assert.Equal(t, n.Message, read.Message)
+	assert.Equal(t, n.Component, read.Component)
+	assert.Equal(t, notification.StatusUnread, read.Status)
+	assert.Equal(t, "Jynx torquilla", read.Metadata["species"])
+	assert.InDelta(t, 0.81, read.Metadata["confidence"], 1e-9)
+
+	read.MarkAsRead()
+	require.NoError(t, store.Update(read))
+	unread, err := store.GetUnreadCount()
+	require.NoError(t, err)
+	assert.Zero(t, unread)
+
+	_, err = store.Get("missing")
+	require.ErrorIs(t, err, notification.ErrNotificationNotFound)
+	require.ErrorIs(t, store.Update(notification.NewNotification(notification.TypeInfo, notification.PriorityLow, "x", "y")), notification.ErrNotificationNotFound)
+
+	// Toasts are ephemeral and not kept in the history
+	toast := notification.NewNotification(notification.TypeInfo, notification.PriorityLow, "Saved", "").
+		WithMetadata(notification.MetadataKeyIsToast, true)
+	require.NoError(t, store.Save(toast))
+	total, err := store.Count(nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+
+	require.NoError(t, store.Delete(n.ID))
+	total, err = store.Count(nil)
+	require.NoError(t, err)
+	assert.Zero(t, total)
+}
+
+func TestStoreRetention(t *testing.T) {
+	t.Parallel()
+
+	store := newTestStore(t, 3)
+
+	start := time.Now().Add(-time.Hour)
+	for i := range 5 {
+		n := notification.NewNotification(notification.TypeInfo, notification.PriorityLow, "Info", "")
+		n.Timestamp = start.Add(time.Duration(i) * time.Minute)
+		if i == 4 {
+			n.WithExpiry(-time.Minute)
+		}
+		require.NoError(t, store.Save(n))
+	}
+
+	require.NoError(t, store.DeleteExpired())
+	list, err := store.List(&notification.FilterOptions{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, list, 3, "the expired and the oldest notification are removed")
+	assert.Equal(t, start.Add(3*time.Minute).Unix(), list[0].Timestamp.Unix(), "newest first")
+	assert.Equal(t, start.Add(time.Minute).Unix(), list[2].Timestamp.Unix())
+}
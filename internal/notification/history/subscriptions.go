@@ -0,0 +1,101 @@
+package history
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/notification/rules"
+)
+
+// subscriptionRefresh is how long the subscribed searches are kept before
+// they are read again, so changes to saved searches apply within it
+const subscriptionRefresh = time.Minute
+
+// SearchPersister is the datastore capability of matching detections against
+// saved searches; implemented by datastore.DataStore
+type SearchPersister interface {
+	GetSubscribedSearches() ([]datastore.SavedSearch, error)
+	NoteMatchesQuery(ctx context.Context, query *datastore.NoteQuery, noteID uint) (bool, error)
+}
+
+// SubscriptionStore finds the saved search subscriptions stored detections
+// match, for the notification rules engine
+type SubscriptionStore struct {
+	db  SearchPersister
+	now func() time.Time // current time, replaceable in tests
+
+	mu         sync.Mutex
+	loaded     time.Time
+	subscribed []subscribedSearch
+}
+
+// subscribedSearch is a subscription with its parsed expression
+type subscribedSearch struct {
+	rules.Subscription
+	query *datastore.NoteQuery
+}
+
+// NewSubscriptionStore creates a subscription store reading saved searches
+// from the datastore
+func NewSubscriptionStore(db SearchPersister) *SubscriptionStore {
+	return &SubscriptionStore{db: db, now: time.Now}
+}
+
+// Active reports whether any saved search is subscribed to. Saved searches
+// that cannot be read count as none.
+func (s *SubscriptionStore) Active() bool {
+	subscribed, err := s.load()
+	return err == nil && len(subscribed) > 0
+}
+
+// Matching returns the subscriptions whose expression the stored detection
+// with an ID matches, in the order they were saved
+func (s *SubscriptionStore) Matching(ctx context.Context, noteID uint) ([]rules.Subscription, error) {
+	subscribed, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	var matching []rules.Subscription
+	for i := range subscribed {
+		ok, err := s.db.NoteMatchesQuery(ctx, subscribed[i].query, noteID)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			matching = append(matching, subscribed[i].Subscription)
+		}
+	}
+	return matching, nil
+}
+
+// load returns the subscribed searches, reading them again once they are
+// older than subscriptionRefresh. Searches whose expression no longer parses
+// are skipped.
+func (s *SubscriptionStore) load() ([]subscribedSearch, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if !s.loaded.IsZero() && now.Sub(s.loaded) < subscriptionRefresh {
+		return s.subscribed, nil
+	}
+	searches, err := s.db.GetSubscribedSearches()
+	if err != nil {
+		return nil, err
+	}
+	subscribed := make([]subscribedSearch, 0, len(searches))
+	for i := range searches {
+		query, err := datastore.ParseNoteQuery(searches[i].Query)
+		if err != nil {
+			continue
+		}
+		subscribed = append(subscribed, subscribedSearch{
+			Subscription: rules.Subscription{Name: searches[i].Name, Cooldown: searches[i].Cooldown},
+			query:        query,
+		})
+	}
+	s.subscribed, s.loaded = subscribed, now
+	return subscribed, nil
+}
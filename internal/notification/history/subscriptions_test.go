@@ -0,0 +1,50 @@
+package history
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/notification/rules"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestSubscriptionStore(t *testing.T) {
+	t.Parallel()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&datastore.Note{}, &datastore.SavedSearch{}))
+	ds := &datastore.DataStore{DB: db}
+
+	now := time.Date(2026, 5, 12, 2, 0, 0, 0, time.UTC)
+	store := NewSubscriptionStore(ds)
+	store.now = func() time.Time { return now }
+	assert.False(t, store.Active(), "no saved search is subscribed to")
+
+	require.NoError(t, ds.SaveSavedSearch(&datastore.SavedSearch{Name: "Owls", Query: "owl confidence>0.9", Subscribed: true, Cooldown: time.Hour}))
+	require.NoError(t, ds.SaveSavedSearch(&datastore.SavedSearch{Name: "Night", Query: "hour:22..4", Subscribed: true}))
+	require.NoError(t, ds.SaveSavedSearch(&datastore.SavedSearch{Name: "Tits", Query: "tit"}))
+	assert.False(t, store.Active(), "subscriptions are read again after a minute")
+
+	now = now.Add(subscriptionRefresh)
+	assert.True(t, store.Active())
+
+	notes := []datastore.Note{
+		{Date: "2026-05-12", Time: "02:00:00", ScientificName: "Strix aluco", CommonName: "Tawny Owl", Confidence: 0.95},
+		{Date: "2026-05-12", Time: "12:00:00", ScientificName: "Parus major", CommonName: "Great Tit", Confidence: 0.95},
+	}
+	require.NoError(t, db.Create(&notes).Error)
+
+	matching, err := store.Matching(context.Background(), notes[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, []rules.Subscription{{Name: "Owls", Cooldown: time.Hour}, {Name: "Night"}}, matching)
+
+	matching, err = store.Matching(context.Background(), notes[1].ID)
+	require.NoError(t, err)
+	assert.Empty(t, matching, "unsubscribed searches are not notified")
+}
@@ -0,0 +1,108 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/httpclient"
+)
+
+// discordMaxContent is the longest message content Discord accepts
+const discordMaxContent = 2000
+
+// chatTarget is a Slack incoming webhook or Discord channel webhook
+type chatTarget struct {
+	platform string // "slack" or "discord"
+	url      string
+}
+
+// ChatOpsProvider posts new species alerts to the Slack and Discord channels
+// of the chat-ops integration
+type ChatOpsProvider struct {
+	name    string
+	targets []chatTarget
+	client  *httpclient.Client
+}
+
+// NewChatOpsProvider creates the alert channel of the chat-ops settings
+func NewChatOpsProvider(cfg *conf.ChatOpsSettings) *ChatOpsProvider {
+	cp := &ChatOpsProvider{name: conf.ChatOpsProviderName}
+	if cfg.Slack.AlertWebhookURL != "" {
+		cp.targets = append(cp.targets, chatTarget{platform: "slack", url: cfg.Slack.AlertWebhookURL})
+	}
+	if cfg.Discord.AlertWebhookURL != "" {
+		cp.targets = append(cp.targets, chatTarget{platform: "discord", url: cfg.Discord.AlertWebhookURL})
+	}
+
+	httpCfg := httpclient.DefaultConfig()
+	httpCfg.UserAgent = "BirdNET-Go-ChatOps/1.0"
+	httpCfg.DefaultTimeout = defaultWebhookTimeout
+	cp.client = httpclient.New(&httpCfg)
+	return cp
+}
+
+func (c *ChatOpsProvider) GetName() string { return c.name }
+func (c *ChatOpsProvider) IsEnabled() bool { return len(c.targets) > 0 }
+
+// SupportsType reports detections only; the new species filter is applied
+// by the dispatcher
+func (c *ChatOpsProvider) SupportsType(t Type) bool { return t == TypeDetection }
+func (c *ChatOpsProvider) ValidateConfig() error {
+	for _, t := range c.targets {
+		if !strings.HasPrefix(t.url, "https://") {
+			return fmt.Errorf("%s alert webhook must be an https URL", t.platform)
+		}
+	}
+	return nil
+}
+
+// Send posts the notification to every channel and returns the failures
+func (c *ChatOpsProvider) Send(ctx context.Context, n *Notification) error {
+	var failed []string
+	for _, t := range c.targets {
+		if err := c.post(ctx, t, n); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", t.platform, err))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("chat alert failed: %s", strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+func (c *ChatOpsProvider) post(ctx context.Context, t chatTarget, n *Notification) error {
+	payload, err := json.Marshal(chatAlertPayload(t.platform, n))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(ctx, req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// chatAlertPayload formats a notification as a Slack or Discord webhook message
+func chatAlertPayload(platform string, n *Notification) map[string]string {
+	if platform == "discord" {
+		return map[string]string{"content": truncate("**"+n.Title+"**\n"+n.Message, discordMaxContent)}
+	}
+	return map[string]string{"text": "*" + n.Title + "*\n" + n.Message}
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/tphakala/birdnet-go/internal/conf"
 	"github.com/tphakala/birdnet-go/internal/errors"
 	"github.com/tphakala/birdnet-go/internal/observability/metrics"
+	"github.com/tphakala/birdnet-go/internal/secrets"
 	"golang.org/x/sync/semaphore"
 )
 
@@ -36,12 +37,18 @@ const (
 	filterReasonComponentMismatch   = "component_mismatch"   // Notification component not allowed
 	filterReasonConfidenceThreshold = "confidence_threshold" // Confidence metadata didn't meet threshold
 	filterReasonMetadataMismatch    = "metadata_mismatch"    // Other metadata filter failed
+	filterReasonRouteMismatch       = "route_mismatch"       // Provider not selected by the matching route
+	filterReasonChannelMismatch     = "channel_mismatch"     // Provider not a channel of the notification rule
+	filterReasonQuietHours          = "quiet_hours"          // Held until the quiet hours of the provider end
 )
 
 // pushDispatcher routes notifications to enabled providers based on filters
 // It subscribes to the notification service and forwards notifications asynchronously.
 type pushDispatcher struct {
 	providers         []enhancedProvider
+	routes            []conf.PushRouteConfig // Routing rules selecting providers, evaluated in order
+	escalations       []conf.EscalationPolicy
+	escalated         map[string]bool // Escalations already sent, by policy name and notification ID
 	log               *slog.Logger
 	enabled           bool
 	maxRetries        int
@@ -63,7 +70,9 @@ type enhancedProvider struct {
 	circuitBreaker *PushCircuitBreaker
 	rateLimiter    *PushRateLimiter // Per-provider rate limiting
 	filter         conf.PushFilterConfig
+	quietHours     *quietHours // Holds notifications during the quiet hours of the provider, nil when disabled
 	name           string
+	channel        string // template channel of the provider, its lowercase type
 }
 
 var (
@@ -82,13 +91,20 @@ func InitializePushFromConfig(settings *conf.Settings) error {
 func InitializePushFromConfigWithMetrics(settings *conf.Settings, notificationMetrics *metrics.NotificationMetrics) error {
 	var initErr error
 	dispatcherOnce.Do(func() {
-		if settings == nil || !settings.Notification.Push.Enabled {
+		if settings == nil || !pushChannelsEnabled(settings) {
 			return
 		}
 
 		// Calculate max concurrent jobs based on number of providers
 		maxConcurrentJobs := int64(defaultMaxConcurrentJobs)
-		if providerCount := len(settings.Notification.Push.Providers); providerCount > 0 {
+		providerCount := len(settings.Notification.Push.Providers)
+		if settings.Notification.Email.Enabled {
+			providerCount++
+		}
+		if settings.ChatOps.AlertsEnabled() {
+			providerCount++
+		}
+		if providerCount > 0 {
 			perProviderLimit := int64(providerCount * jobsPerProvider)
 			if perProviderLimit > maxConcurrentJobs {
 				maxConcurrentJobs = perProviderLimit
@@ -97,10 +113,12 @@ func InitializePushFromConfigWithMetrics(settings *conf.Settings, notificationMe
 
 		pd := &pushDispatcher{
 			log:               getFileLogger(settings.Debug),
-			enabled:           settings.Notification.Push.Enabled,
+			enabled:           true,
 			maxRetries:        settings.Notification.Push.MaxRetries,
 			retryDelay:        settings.Notification.Push.RetryDelay,
 			defaultTimeout:    settings.Notification.Push.DefaultTimeout,
+			routes:            settings.Notification.Push.Routes,
+			escalations:       settings.Notification.Push.Escalation,
 			metrics:           notificationMetrics,
 			concurrencySem:    semaphore.NewWeighted(maxConcurrentJobs),
 			maxConcurrentJobs: maxConcurrentJobs,
@@ -143,6 +161,12 @@ func InitializePushFromConfigWithMetrics(settings *conf.Settings, notificationMe
 	return initErr
 }
 
+// pushChannelsEnabled reports whether push providers or a builtin channel
+// delivering through the dispatcher are enabled
+func pushChannelsEnabled(settings *conf.Settings) bool {
+	return settings.Notification.Push.Enabled || settings.Notification.Email.Enabled || settings.ChatOps.AlertsEnabled()
+}
+
 // GetPushDispatcher returns the dispatcher if initialized
 func GetPushDispatcher() *pushDispatcher { return globalPushDispatcher }
 
@@ -175,8 +199,9 @@ func (d *pushDispatcher) start() error {
 				if !ok || notif == nil {
 					return
 				}
-				// Skip ephemeral toast notifications
-				if isToastNotification(notif) {
+				// Skip ephemeral toast notifications, and repeats
+				// collapsed into a notification already sent
+				if isToastNotification(notif) || IsRepeat(notif) {
 					continue
 				}
 				// Dispatch in background
@@ -187,6 +212,16 @@ func (d *pushDispatcher) start() error {
 		}
 	}()
 
+	// Escalate unacknowledged notifications if policies are configured
+	if len(d.escalations) > 0 {
+		go d.runEscalations(ctx, service)
+	}
+
+	// Deliver the notifications held by quiet hours when they end
+	if d.hasQuietHours() {
+		go d.runQuietHours(ctx)
+	}
+
 	// Start health checker if enabled
 	if d.healthChecker != nil {
 		if err := d.healthChecker.Start(ctx); err != nil {
@@ -198,20 +233,57 @@ func (d *pushDispatcher) start() error {
 	d.log.Info("push dispatcher started",
 		"providers", len(d.providers),
 		"health_checker", d.healthChecker != nil,
+		"escalation_policies", len(d.escalations),
+		"quiet_hours", d.hasQuietHours(),
 		"max_concurrent_dispatches", d.maxConcurrentJobs)
 	return nil
 }
 
 func (d *pushDispatcher) dispatch(ctx context.Context, notif *Notification) {
+	// Channels chosen by a notification rule replace the routes
+	channels, _ := notif.Metadata[MetadataKeyChannels].([]string)
+	var route *conf.PushRouteConfig
+	if len(channels) == 0 {
+		route = d.matchRoute(notif)
+	}
 	for i := range d.providers {
 		ep := &d.providers[i]
 		if !ep.prov.IsEnabled() || !ep.prov.SupportsType(notif.Type) {
 			continue
 		}
+		if len(channels) > 0 && !slices.Contains(channels, ep.name) {
+			if d.metrics != nil {
+				d.metrics.RecordFilterRejection(ep.name, filterReasonChannelMismatch)
+			}
+			continue
+		}
+		// Only the providers of the matching route receive the notification
+		if route != nil && !slices.Contains(route.Providers, ep.name) {
+			if d.metrics != nil {
+				d.metrics.RecordFilterRejection(ep.name, filterReasonRouteMismatch)
+			}
+			continue
+		}
+		// In offline mode only local providers are used; notifications remain available in-app
+		if conf.IsOffline() && requiresNetwork(ep.prov) {
+			if d.log != nil {
+				d.log.Debug("skipping push provider in offline mode",
+					"provider", ep.name,
+					"notification_id", notif.ID)
+			}
+			continue
+		}
 		// Apply filter with metrics tracking
 		if !d.matchesFilter(ep, notif) {
 			continue
 		}
+		if ep.quietHours != nil && ep.quietHours.hold(notif, time.Now()) {
+			logDebug(d.log, "holding notification until quiet hours end", "provider", ep.name, "notification_id", notif.ID)
+			if d.metrics != nil {
+				d.metrics.RecordFilterRejection(ep.name, filterReasonQuietHours)
+			}
+			continue
+		}
 
 		// Acquire semaphore slot before spawning goroutine (prevents unbounded goroutine explosion)
 		// Use TryAcquire with timeout to prevent blocking the dispatch loop
@@ -256,6 +328,26 @@ func (d *pushDispatcher) dispatch(ctx context.Context, notif *Notification) {
 	}
 }
 
+// matchRoute returns the first routing rule matching a notification, or nil
+// when there are no rules or none match and every provider may receive it
+func (d *pushDispatcher) matchRoute(notif *Notification) *conf.PushRouteConfig {
+	for i := range d.routes {
+		route := &d.routes[i]
+		if matches, _ := MatchesProviderFilterWithReason(&route.Match, notif, nil, route.Name); matches {
+			logDebug(d.log, "notification matched route", "route", route.Name, "providers", route.Providers, "notification_id", notif.ID)
+			return route
+		}
+	}
+	return nil
+}
+
+// requiresNetwork reports whether a push provider delivers over the network.
+// Script providers run locally and keep working in offline mode.
+func requiresNetwork(p Provider) bool {
+	_, local := p.(*ScriptProvider)
+	return !local
+}
+
 // matchesFilter checks if notification matches provider filter and records metrics with reason.
 func (d *pushDispatcher) matchesFilter(ep *enhancedProvider, notif *Notification) bool {
 	// Use enhanced filter logic that returns rejection reason
@@ -275,6 +367,9 @@ func (d *pushDispatcher) matchesFilter(ep *enhancedProvider, notif *Notification
 
 // dispatchEnhanced dispatches notifications with metrics and circuit breaker support.
 func (d *pushDispatcher) dispatchEnhanced(ctx context.Context, notif *Notification, ep *enhancedProvider) {
+	// Channels with their own templates get their own title and message
+	notif = notif.ForChannel(ep.channel)
+
 	// Apply rate limiting if enabled
 	if !d.checkRateLimit(ep, notif) {
 		return
@@ -302,6 +397,7 @@ func (d *pushDispatcher) checkRateLimit(ep *enhancedProvider, notif *Notificatio
 		if d.metrics != nil {
 			d.metrics.RecordFilterRejection(ep.name, "rate_limited")
 		}
+		deliveries.record(time.Now(), ep.name, string(notif.Type), OutcomeRateLimited)
 		return false
 	}
 	return true
@@ -322,22 +418,29 @@ func (d *pushDispatcher) retryLoop(ctx context.Context, notif *Notification, ep
 		// Handle success
 		if err == nil {
 			d.logSuccess(ep.name, notif, notifType, attempts, duration)
+			deliveries.record(time.Now(), ep.name, notifType, OutcomeSuccess)
 			return
 		}
 
 		// Handle circuit breaker open
 		if errors.Is(err, ErrCircuitBreakerOpen) {
 			d.logCircuitBreakerOpen(ep.name, notif.ID)
+			deliveries.record(time.Now(), ep.name, notifType, OutcomeCircuitOpen)
+			d.queueFailedDelivery(notif, ep, err)
 			return
 		}
 
 		// Check if should retry
 		if !d.shouldRetry(err, attempts, ep.name) {
+			deliveries.record(time.Now(), ep.name, notifType, deliveryOutcome(err))
+			d.queueFailedDelivery(notif, ep, err)
 			return
 		}
 
 		// Wait for retry delay
 		if !d.waitForRetry(ctx, ep.name, attempts) {
+			deliveries.record(time.Now(), ep.name, notifType, deliveryOutcome(err))
+			d.queueFailedDelivery(notif, ep, err)
 			return
 		}
 	}
@@ -539,7 +642,50 @@ func buildProvider(pc *conf.PushProviderConfig, log *slog.Logger) Provider {
 			}
 			return nil
 		}
+		provider.broadcast = pc.Broadcast
 		return provider
+	case "telegram":
+		token, err := secrets.MustResolve("telegram bot token", pc.BotTokenFile, pc.BotToken)
+		if err != nil {
+			if log != nil {
+				log.Error("failed to resolve telegram bot token",
+					"name", pc.Name,
+					"error", err)
+			}
+			return nil
+		}
+		return NewTelegramProvider(pc, token)
+	case "ntfy":
+		token, err := secrets.Resolve(pc.AuthTokenFile, pc.AuthToken)
+		if err != nil {
+			if log != nil {
+				log.Error("failed to resolve ntfy access token",
+					"name", pc.Name,
+					"error", err)
+			}
+			return nil
+		}
+		return NewNtfyProvider(pc, token, types)
+	case "pushover":
+		userKey, err := secrets.MustResolve("pushover user key", pc.UserKeyFile, pc.UserKey)
+		if err != nil {
+			if log != nil {
+				log.Error("failed to resolve pushover user key",
+					"name", pc.Name,
+					"error", err)
+			}
+			return nil
+		}
+		appToken, err := secrets.MustResolve("pushover application token", pc.AppTokenFile, pc.AppToken)
+		if err != nil {
+			if log != nil {
+				log.Error("failed to resolve pushover application token",
+					"name", pc.Name,
+					"error", err)
+			}
+			return nil
+		}
+		return NewPushoverProvider(pc, userKey, appToken, types)
 	default:
 		if log != nil {
 			log.Warn("unknown push provider type; skipping",
@@ -550,9 +696,28 @@ func buildProvider(pc *conf.PushProviderConfig, log *slog.Logger) Provider {
 	}
 }
 
+// buildEmailProvider creates the email channel, resolving the SMTP password
+func buildEmailProvider(cfg *conf.EmailSettings, log *slog.Logger) Provider {
+	password, err := secrets.Resolve(cfg.PasswordFile, cfg.Password)
+	if err != nil {
+		if log != nil {
+			log.Error("failed to resolve email password", "error", err)
+		}
+		return nil
+	}
+	provider, err := NewEmailProvider(cfg, password)
+	if err != nil {
+		if log != nil {
+			log.Error("failed to create email provider", "error", err)
+		}
+		return nil
+	}
+	return provider
+}
+
 func effectiveTypes(cfg []string) []string {
 	if len(cfg) == 0 {
-		return []string{"error", "warning", "info", "detection", "system"}
+		return []string{"error", "warning", "info", "detection", "system", "rare_species"}
 	}
 	return append([]string{}, cfg...)
 }
@@ -840,6 +1005,15 @@ func (e *providerError) Unwrap() error { return e.Err }
 
 // ----------------- Enhanced Provider Initialization -----------------
 
+// providerCandidate is a built provider with the configuration it is registered with
+type providerCandidate struct {
+	prov       Provider
+	name       string
+	ptype      string
+	filter     conf.PushFilterConfig
+	quietHours *conf.QuietHoursConfig
+}
+
 // initializeEnhancedProviders creates enhanced providers with circuit breakers and metrics.
 func (d *pushDispatcher) initializeEnhancedProviders(settings *conf.Settings, notificationMetrics *metrics.NotificationMetrics) []enhancedProvider {
 	var enhanced []enhancedProvider
@@ -861,16 +1035,37 @@ func (d *pushDispatcher) initializeEnhancedProviders(settings *conf.Settings, no
 		}
 	}
 
-	for i := range settings.Notification.Push.Providers {
-		pc := &settings.Notification.Push.Providers[i]
-		prov := buildProvider(pc, d.log)
-		if prov == nil {
-			continue
+	var candidates []providerCandidate
+	if settings.Notification.Push.Enabled {
+		for i := range settings.Notification.Push.Providers {
+			pc := &settings.Notification.Push.Providers[i]
+			if prov := buildProvider(pc, d.log); prov != nil {
+				candidates = append(candidates, providerCandidate{prov: prov, name: pc.Name, ptype: pc.Type, filter: pc.Filter, quietHours: &pc.QuietHours})
+			}
 		}
+	}
+	if settings.Notification.Email.Enabled {
+		if prov := buildEmailProvider(&settings.Notification.Email, d.log); prov != nil {
+			candidates = append(candidates, providerCandidate{prov: prov, name: conf.EmailProviderName, ptype: conf.EmailProviderName, quietHours: &settings.Notification.Email.QuietHours})
+		}
+	}
+	if settings.ChatOps.AlertsEnabled() {
+		// Chat channels receive new species alerts only
+		candidates = append(candidates, providerCandidate{
+			prov:   NewChatOpsProvider(&settings.ChatOps),
+			name:   conf.ChatOpsProviderName,
+			ptype:  conf.ChatOpsProviderName,
+			filter: conf.PushFilterConfig{MetadataFilters: map[string]any{"is_new_species": true}},
+		})
+	}
+
+	for i := range candidates {
+		c := &candidates[i]
+		prov := c.prov
 
 		if err := prov.ValidateConfig(); err != nil {
 			if d.log != nil {
-				d.log.Error("push provider config invalid", "name", pc.Name, "type", pc.Type, "error", err)
+				d.log.Error("push provider config invalid", "name", c.name, "type", c.ptype, "error", err)
 			}
 			continue
 		}
@@ -930,8 +1125,12 @@ func (d *pushDispatcher) initializeEnhancedProviders(settings *conf.Settings, no
 				prov:           prov,
 				circuitBreaker: cb,
 				rateLimiter:    rl,
-				filter:         pc.Filter,
+				filter:         c.filter,
 				name:           name,
+				channel:        strings.ToLower(c.ptype),
+			}
+			if c.quietHours != nil {
+				ep.quietHours = newQuietHours(c.quietHours)
 			}
 
 			enhanced = append(enhanced, ep)
@@ -940,8 +1139,9 @@ func (d *pushDispatcher) initializeEnhancedProviders(settings *conf.Settings, no
 				d.log.Debug("registered enhanced push provider",
 					"name", name,
 					"circuit_breaker", cb != nil,
-					"types", pc.Filter.Types,
-					"priorities", pc.Filter.Priorities)
+					"quiet_hours", ep.quietHours != nil,
+					"types", c.filter.Types,
+					"priorities", c.filter.Priorities)
 			}
 		}
 	}
@@ -1030,12 +1230,19 @@ func convertWebhookEndpoints(cfgEndpoints []conf.WebhookEndpointConfig, log *slo
 			return nil, fmt.Errorf("endpoint %d: %w", i, err)
 		}
 
+		secret, header, err := resolveWebhookSigning(&cfg.Signing)
+		if err != nil {
+			return nil, fmt.Errorf("endpoint %d: %w", i, err)
+		}
+
 		endpoints = append(endpoints, WebhookEndpoint{
-			URL:     cfg.URL,
-			Method:  cfg.Method,
-			Headers: cfg.Headers,
-			Timeout: cfg.Timeout,
-			Auth:    *auth,
+			URL:             cfg.URL,
+			Method:          cfg.Method,
+			Headers:         cfg.Headers,
+			Timeout:         cfg.Timeout,
+			Auth:            *auth,
+			Secret:          secret,
+			SignatureHeader: header,
 		})
 	}
 	return endpoints, nil
@@ -2,6 +2,7 @@ package notification
 
 import (
 	"context"
+	"maps"
 	"math/rand/v2"
 	"testing"
 	"time"
@@ -1317,3 +1318,105 @@ func TestCheckMetadataFilters(t *testing.T) {
 		})
 	}
 }
+
+func TestPushDispatcher_Routes(t *testing.T) {
+	t.Parallel()
+
+	allTypes := map[Type]bool{TypeError: true, TypeInfo: true, TypeWarning: true, TypeDetection: true, TypeSystem: true}
+	newProvider := func(name string) *fakeProvider {
+		return &fakeProvider{name: name, enabled: true, types: allTypes, recvCh: make(chan *Notification, 4)}
+	}
+	email, gotify, telegram := newProvider("email"), newProvider("gotify"), newProvider("telegram")
+
+	var providers []enhancedProvider
+	for _, fp := range []*fakeProvider{email, gotify, telegram} {
+		providers = append(providers, enhancedProvider{prov: fp, name: fp.name})
+	}
+	d := &pushDispatcher{
+		providers:      providers,
+		log:            getFileLogger(false),
+		enabled:        true,
+		defaultTimeout: time.Second,
+		routes: []conf.PushRouteConfig{
+			{Name: "system-errors", Match: conf.PushFilterConfig{Types: []string{"error"}, Components: []string{"system"}}, Providers: []string{"email", "gotify"}},
+			{Name: "detections", Match: conf.PushFilterConfig{Types: []string{"detection"}}, Providers: []string{"telegram"}},
+			{Name: "muted", Match: conf.PushFilterConfig{Priorities: []string{"low"}}},
+		},
+	}
+
+	// received reports which providers got a notification within a short wait
+	received := func() map[string]bool {
+		got := map[string]bool{}
+		timeout := time.After(200 * time.Millisecond)
+		for {
+			select {
+			case <-email.recvCh:
+				got["email"] = true
+			case <-gotify.recvCh:
+				got["gotify"] = true
+			case <-telegram.recvCh:
+				got["telegram"] = true
+			case <-timeout:
+				return got
+			}
+		}
+	}
+
+	tests := []struct {
+		name  string
+		notif *Notification
+		want  map[string]bool
+	}{
+		{"system error", NewNotification(TypeError, PriorityHigh, "Disk", "full").WithComponent("system"), map[string]bool{"email": true, "gotify": true}},
+		{"detection", NewNotification(TypeDetection, PriorityHigh, "Owl", "detected"), map[string]bool{"telegram": true}},
+		{"muted by empty route", NewNotification(TypeInfo, PriorityLow, "Info", "low"), map[string]bool{}},
+		{"no matching route", NewNotification(TypeWarning, PriorityMedium, "Warn", "other"), map[string]bool{"email": true, "gotify": true, "telegram": true}},
+		{"rule channels replace routes", NewNotification(TypeDetection, PriorityHigh, "Owl", "detected").WithMetadata(MetadataKeyChannels, []string{"gotify"}), map[string]bool{"gotify": true}},
+	}
+	for _, tt := range tests {
+		d.dispatch(context.Background(), tt.notif)
+		if got := received(); !maps.Equal(got, tt.want) {
+			t.Errorf("%s: providers %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPushDispatcher_ChannelMessages(t *testing.T) {
+	t.Parallel()
+
+	allTypes := map[Type]bool{TypeDetection: true}
+	telegram := &fakeProvider{name: "family", enabled: true, types: allTypes, recvCh: make(chan *Notification, 1)}
+	webhook := &fakeProvider{name: "hass", enabled: true, types: allTypes, recvCh: make(chan *Notification, 1)}
+	d := &pushDispatcher{
+		providers: []enhancedProvider{
+			{prov: telegram, name: telegram.name, channel: "telegram"},
+			{prov: webhook, name: webhook.name, channel: "webhook"},
+		},
+		log:            getFileLogger(false),
+		enabled:        true,
+		defaultTimeout: time.Second,
+	}
+
+	n := NewNotification(TypeDetection, PriorityHigh, "Neue Art: Uhu", "Erster Nachweis").
+		WithChannelMessages([]string{"telegram", ChannelUI}, func(channel string) (ChannelMessage, error) {
+			if channel == ChannelUI {
+				return ChannelMessage{Title: "Uhu", Message: "Erster Nachweis"}, nil
+			}
+			return ChannelMessage{Title: "🦉 Uhu", Message: "Erster Nachweis"}, nil
+		})
+	if n.Title != "Uhu" {
+		t.Fatalf("title %q, want the UI title", n.Title)
+	}
+
+	d.dispatch(context.Background(), n)
+	for fp, want := range map[*fakeProvider]string{telegram: "🦉 Uhu", webhook: "Neue Art: Uhu"} {
+		select {
+		case got := <-fp.recvCh:
+			if got.Title != want || got.Message != "Erster Nachweis" {
+				t.Errorf("%s: got %q / %q, want %q", fp.name, got.Title, got.Message, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%s did not receive the notification", fp.name)
+		}
+	}
+}
@@ -0,0 +1,312 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/notification/templatefuncs"
+)
+
+// defaultEmailTimeout is the timeout of sending one email when none is configured
+const defaultEmailTimeout = 30 * time.Second
+
+// Built-in email templates, executed with the notification
+const (
+	defaultEmailSubject = `[BirdNET-Go] {{.Title}}`
+	defaultEmailText    = `{{.Title}}
+
+{{.Message}}
+
+Type: {{.Type}}
+Priority: {{.Priority}}
+{{- if .Component}}
+Component: {{.Component}}
+{{- end}}
+Time: {{.Timestamp.Format "2006-01-02 15:04:05 MST"}}
+`
+	defaultEmailHTML = `<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif; color: #222;">
+<h2 style="margin-bottom: 0.5em;">{{.Title}}</h2>
+<p style="white-space: pre-wrap;">{{.Message}}</p>
+<table style="font-size: 0.9em; color: #555;">
+<tr><td>Type</td><td>{{.Type}}</td></tr>
+<tr><td>Priority</td><td>{{.Priority}}</td></tr>
+{{- if .Component}}
+<tr><td>Component</td><td>{{.Component}}</td></tr>
+{{- end}}
+<tr><td>Time</td><td>{{.Timestamp.Format "2006-01-02 15:04:05 MST"}}</td></tr>
+</table>
+</body>
+</html>
+`
+)
+
+// EmailProvider delivers notifications by email over SMTP. Each email has a
+// plain text and an HTML alternative rendered from templates.
+type EmailProvider struct {
+	name     string
+	enabled  bool
+	host     string
+	port     int
+	security string // "starttls", "tls" or "none"
+	username string
+	password string
+	from     string
+	to       []string
+	timeout  time.Duration
+	types    map[string]bool
+	subject  *template.Template
+	text     *template.Template
+	html     *htmltemplate.Template
+}
+
+// NewEmailProvider creates the email provider from the email settings. The
+// password is passed resolved, as secrets are resolved by the caller. Empty
+// templates fall back to the built-in ones.
+func NewEmailProvider(cfg *conf.EmailSettings, password string) (*EmailProvider, error) {
+	ep := &EmailProvider{
+		name:     conf.EmailProviderName,
+		enabled:  cfg.Enabled,
+		host:     strings.TrimSpace(cfg.Host),
+		port:     cfg.Port,
+		security: strings.ToLower(strings.TrimSpace(cfg.Security)),
+		username: cfg.Username,
+		password: password,
+		from:     cfg.From,
+		to:       append([]string{}, cfg.To...),
+		timeout:  cfg.Timeout,
+		types: map[string]bool{
+			string(TypeError):       cfg.Types.Error,
+			string(TypeWarning):     cfg.Types.Warning,
+			string(TypeInfo):        cfg.Types.Info,
+			string(TypeDetection):   cfg.Types.Detection,
+			string(TypeSystem):      cfg.Types.System,
+			string(TypeRareSpecies): cfg.Types.RareSpecies,
+		},
+	}
+	if ep.security == "" {
+		ep.security = "starttls"
+	}
+	if ep.timeout <= 0 {
+		ep.timeout = defaultEmailTimeout
+	}
+
+	var err error
+	if ep.subject, err = template.New("email-subject").Funcs(templatefuncs.FuncMap()).
+		Parse(orDefault(cfg.Templates.Subject, defaultEmailSubject)); err != nil {
+		return nil, fmt.Errorf("failed to parse email subject template: %w", err)
+	}
+	if ep.text, err = template.New("email-text").Funcs(templatefuncs.FuncMap()).
+		Parse(orDefault(cfg.Templates.Text, defaultEmailText)); err != nil {
+		return nil, fmt.Errorf("failed to parse email text template: %w", err)
+	}
+	if ep.html, err = htmltemplate.New("email-html").Funcs(htmltemplate.FuncMap(templatefuncs.FuncMap())).
+		Parse(orDefault(cfg.Templates.HTML, defaultEmailHTML)); err != nil {
+		return nil, fmt.Errorf("failed to parse email HTML template: %w", err)
+	}
+	return ep, nil
+}
+
+func (e *EmailProvider) GetName() string          { return e.name }
+func (e *EmailProvider) IsEnabled() bool          { return e.enabled }
+func (e *EmailProvider) SupportsType(t Type) bool { return e.types[string(t)] }
+func (e *EmailProvider) ValidateConfig() error {
+	if !e.enabled {
+		return nil
+	}
+	if e.host == "" {
+		return fmt.Errorf("email host is required")
+	}
+	if _, err := mail.ParseAddress(e.from); err != nil {
+		return fmt.Errorf("invalid email sender %q: %w", e.from, err)
+	}
+	if len(e.to) == 0 {
+		return fmt.Errorf("at least one email recipient is required")
+	}
+	for _, to := range e.to {
+		if _, err := mail.ParseAddress(to); err != nil {
+			return fmt.Errorf("invalid email recipient %q: %w", to, err)
+		}
+	}
+	return nil
+}
+
+// Send renders the notification and delivers it to every recipient in one
+// SMTP transaction
+func (e *EmailProvider) Send(ctx context.Context, n *Notification) error {
+	msg, err := e.buildMessage(n)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+
+	conn, err := e.dial(ctx)
+	if err != nil {
+		return fmt.Errorf("email: failed to connect to %s: %w", e.host, err)
+	}
+	// net/smtp is not context aware; the deadline bounds the whole session
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, e.host)
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("email: SMTP handshake failed: %w", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	if e.security == "starttls" {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("email: server %s does not support STARTTLS", e.host)
+		}
+		if err := client.StartTLS(e.tlsConfig()); err != nil {
+			return fmt.Errorf("email: STARTTLS failed: %w", err)
+		}
+	}
+	if e.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", e.username, e.password, e.host)); err != nil {
+			return fmt.Errorf("email: authentication failed: %w", err)
+		}
+	}
+
+	if err := client.Mail(envelopeAddress(e.from)); err != nil {
+		return fmt.Errorf("email: sender rejected: %w", err)
+	}
+	for _, to := range e.to {
+		if err := client.Rcpt(envelopeAddress(to)); err != nil {
+			return fmt.Errorf("email: recipient %s rejected: %w", to, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("email: DATA command failed: %w", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		_ = w.Close()
+		return fmt.Errorf("email: failed to write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("email: message rejected: %w", err)
+	}
+	return client.Quit()
+}
+
+// dial opens the connection to the SMTP server, with implicit TLS when the
+// security mode is "tls"
+func (e *EmailProvider) dial(ctx context.Context) (net.Conn, error) {
+	addr := net.JoinHostPort(e.host, strconv.Itoa(e.port))
+	dialer := &net.Dialer{}
+	if e.security == "tls" {
+		tlsDialer := &tls.Dialer{NetDialer: dialer, Config: e.tlsConfig()}
+		return tlsDialer.DialContext(ctx, "tcp", addr)
+	}
+	return dialer.DialContext(ctx, "tcp", addr)
+}
+
+func (e *EmailProvider) tlsConfig() *tls.Config {
+	return &tls.Config{ServerName: e.host, MinVersion: tls.VersionTLS12}
+}
+
+// buildMessage renders the notification as a multipart/alternative MIME
+// message with plain text and HTML parts
+func (e *EmailProvider) buildMessage(n *Notification) ([]byte, error) {
+	var subject, text, html bytes.Buffer
+	if err := e.subject.Execute(&subject, n); err != nil {
+		return nil, fmt.Errorf("email subject template execution failed: %w", err)
+	}
+	if err := e.text.Execute(&text, n); err != nil {
+		return nil, fmt.Errorf("email text template execution failed: %w", err)
+	}
+	if err := e.html.Execute(&html, n); err != nil {
+		return nil, fmt.Errorf("email HTML template execution failed: %w", err)
+	}
+
+	var msg bytes.Buffer
+	body := multipart.NewWriter(&msg)
+
+	// Header lines must not contain line breaks from the rendered subject
+	subjectLine := strings.Join(strings.Fields(subject.String()), " ")
+	headers := []struct{ key, value string }{
+		{"From", e.from},
+		{"To", strings.Join(e.to, ", ")},
+		{"Subject", mime.QEncoding.Encode("utf-8", subjectLine)},
+		{"Date", n.Timestamp.Format(time.RFC1123Z)},
+		{"Message-ID", messageID(e.from)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "multipart/alternative; boundary=" + body.Boundary()},
+	}
+	for _, h := range headers {
+		fmt.Fprintf(&msg, "%s: %s\r\n", h.key, h.value)
+	}
+	msg.WriteString("\r\n")
+
+	parts := []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", text.String()},
+		{"text/html; charset=utf-8", html.String()},
+	}
+	for _, p := range parts {
+		pw, err := body.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {p.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQuotedPrintable(pw, p.content); err != nil {
+			return nil, err
+		}
+	}
+	if err := body.Close(); err != nil {
+		return nil, err
+	}
+	return msg.Bytes(), nil
+}
+
+func writeQuotedPrintable(w io.Writer, content string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(content)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// envelopeAddress returns the bare address of a possibly named address
+func envelopeAddress(addr string) string {
+	if parsed, err := mail.ParseAddress(addr); err == nil {
+		return parsed.Address
+	}
+	return addr
+}
+
+// messageID returns a unique Message-ID in the domain of the sender
+func messageID(from string) string {
+	domain := "birdnet-go.local"
+	if at := strings.LastIndex(envelopeAddress(from), "@"); at >= 0 {
+		domain = envelopeAddress(from)[at+1:]
+	}
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return "<" + hex.EncodeToString(b) + "@" + domain + ">"
+}
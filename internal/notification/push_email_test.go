@@ -0,0 +1,190 @@
+package notification
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+func testEmailSettings() *conf.EmailSettings {
+	return &conf.EmailSettings{
+		Enabled:  true,
+		Host:     "127.0.0.1",
+		Port:     25,
+		Security: "none",
+		From:     "BirdNET-Go <birdnet@example.com>",
+		To:       []string{"owner@example.com"},
+		Types:    conf.EmailTypeSettings{Error: true, Detection: true},
+	}
+}
+
+func TestEmailProviderSupportsType(t *testing.T) {
+	t.Parallel()
+	provider, err := NewEmailProvider(testEmailSettings(), "")
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	if provider.GetName() != conf.EmailProviderName {
+		t.Errorf("expected name %q, got %q", conf.EmailProviderName, provider.GetName())
+	}
+	if !provider.SupportsType(TypeDetection) || !provider.SupportsType(TypeError) {
+		t.Error("expected detection and error types to be supported")
+	}
+	if provider.SupportsType(TypeInfo) {
+		t.Error("expected info type NOT to be supported")
+	}
+	if err := provider.ValidateConfig(); err != nil {
+		t.Errorf("unexpected validation error: %v", err)
+	}
+}
+
+func TestEmailProviderBuildMessage(t *testing.T) {
+	t.Parallel()
+	cfg := testEmailSettings()
+	cfg.Templates.Subject = "New: {{.Title}}"
+	cfg.Templates.HTML = "<p>{{.Message}}</p>"
+	provider, err := NewEmailProvider(cfg, "")
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+
+	n := NewNotification(TypeDetection, PriorityHigh, "Eurasian Wren", "First <b>detection</b> of Eurasian Wren")
+	raw, err := provider.buildMessage(n)
+	if err != nil {
+		t.Fatalf("failed to build message: %v", err)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(string(raw)))
+	if err != nil {
+		t.Fatalf("message does not parse: %v", err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		t.Fatalf("failed to decode subject: %v", err)
+	}
+	if subject != "New: Eurasian Wren" {
+		t.Errorf("unexpected subject %q", subject)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("unexpected content type %q: %v", msg.Header.Get("Content-Type"), err)
+	}
+
+	parts := map[string]string{}
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed to read part: %v", err)
+		}
+		body, _ := io.ReadAll(part) // quoted-printable is decoded by the reader
+		contentType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		parts[contentType] = string(body)
+	}
+	if !strings.Contains(parts["text/plain"], "First <b>detection</b> of Eurasian Wren") {
+		t.Errorf("plain text part missing message: %q", parts["text/plain"])
+	}
+	if parts["text/html"] != "<p>First &lt;b&gt;detection&lt;/b&gt; of Eurasian Wren</p>" {
+		t.Errorf("HTML part not escaped: %q", parts["text/html"])
+	}
+}
+
+func TestEmailProviderInvalidTemplate(t *testing.T) {
+	t.Parallel()
+	cfg := testEmailSettings()
+	cfg.Templates.Text = "{{.Title"
+	if _, err := NewEmailProvider(cfg, ""); err == nil {
+		t.Error("expected error for invalid template")
+	}
+}
+
+// TestEmailProviderSend delivers a notification to a minimal SMTP server
+func TestEmailProviderSend(t *testing.T) {
+	t.Parallel()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer func() { _ = ln.Close() }()
+
+	received := make(chan []string, 1)
+	go serveTestSMTP(ln, received)
+
+	cfg := testEmailSettings()
+	cfg.Port = ln.Addr().(*net.TCPAddr).Port
+	cfg.Timeout = 5 * time.Second
+	provider, err := NewEmailProvider(cfg, "")
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+
+	n := NewNotification(TypeError, PriorityCritical, "Disk full", "No space left on device")
+	if err := provider.Send(context.Background(), n); err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+
+	commands := <-received
+	transcript := strings.Join(commands, "\n")
+	for _, want := range []string{"MAIL FROM:<birdnet@example.com>", "RCPT TO:<owner@example.com>", "No space left on device"} {
+		if !strings.Contains(transcript, want) {
+			t.Errorf("SMTP transcript missing %q", want)
+		}
+	}
+}
+
+// serveTestSMTP accepts one SMTP session and reports the received lines
+func serveTestSMTP(ln net.Listener, received chan<- []string) {
+	conn, err := ln.Accept()
+	if err != nil {
+		return
+	}
+	defer func() { _ = conn.Close() }()
+
+	var lines []string
+	r := bufio.NewReader(conn)
+	reply := func(code int, text string) { _, _ = io.WriteString(conn, strconv.Itoa(code)+" "+text+"\r\n") }
+	reply(220, "test ESMTP")
+	inData := false
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			break
+		}
+		line = strings.TrimRight(line, "\r\n")
+		lines = append(lines, line)
+		if inData {
+			if line == "." {
+				inData = false
+				reply(250, "queued")
+			}
+			continue
+		}
+		switch cmd := strings.ToUpper(strings.Fields(line + " ")[0]); cmd {
+		case "EHLO", "HELO", "MAIL", "RCPT":
+			reply(250, "ok")
+		case "DATA":
+			inData = true
+			reply(354, "go ahead")
+		case "QUIT":
+			reply(221, "bye")
+			received <- lines
+			return
+		default:
+			reply(502, "not implemented")
+		}
+	}
+	received <- lines
+}
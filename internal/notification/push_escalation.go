@@ -0,0 +1,116 @@
+package notification
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+const (
+	// escalationCheckInterval is how often unacknowledged notifications are checked for escalation
+	escalationCheckInterval = 30 * time.Second
+
+	// Metadata keys added to escalated notifications
+	MetadataKeyEscalationPolicy = "escalation_policy"
+	MetadataKeyEscalatedAfter   = "escalated_after"
+)
+
+// runEscalations periodically escalates unacknowledged notifications until ctx is cancelled
+func (d *pushDispatcher) runEscalations(ctx context.Context, service *Service) {
+	ticker := time.NewTicker(escalationCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			notifications, err := service.List(&FilterOptions{Status: []Status{StatusUnread, StatusRead}})
+			if err != nil {
+				if d.log != nil {
+					d.log.Error("failed to list notifications for escalation", "error", err)
+				}
+				continue
+			}
+			d.escalate(ctx, notifications, now)
+		}
+	}
+}
+
+// escalate re-sends each notification that has been unacknowledged longer
+// than an escalation policy allows to the providers of the policy, once per
+// policy. It returns the number of escalations.
+func (d *pushDispatcher) escalate(ctx context.Context, notifications []*Notification, now time.Time) int {
+	if d.escalated == nil {
+		d.escalated = make(map[string]bool)
+	}
+
+	pending := make(map[string]bool, len(d.escalated))
+	count := 0
+	for _, notif := range notifications {
+		if notif.Status == StatusAcknowledged || notif.Status == StatusDismissed || isToastNotification(notif) {
+			continue
+		}
+		for i := range d.escalations {
+			policy := &d.escalations[i]
+			key := policy.Name + "/" + notif.ID
+			if d.escalated[key] {
+				pending[key] = true
+				continue
+			}
+			if now.Sub(notif.Timestamp) < policy.After || !matchesEscalationPolicy(policy, notif) {
+				continue
+			}
+
+			d.sendEscalation(ctx, policy, notif)
+			pending[key] = true
+			count++
+		}
+	}
+	// Forget notifications that were acknowledged or removed
+	d.escalated = pending
+	return count
+}
+
+// matchesEscalationPolicy reports whether a policy applies to a notification.
+// Policies without priorities apply to critical notifications only.
+func matchesEscalationPolicy(policy *conf.EscalationPolicy, notif *Notification) bool {
+	match := policy.Match
+	if len(match.Priorities) == 0 {
+		match.Priorities = []string{string(PriorityCritical)}
+	}
+	matches, _ := MatchesProviderFilterWithReason(&match, notif, nil, policy.Name)
+	return matches
+}
+
+// sendEscalation sends a copy of a notification marked as escalated to the
+// providers of a policy, bypassing routing rules
+func (d *pushDispatcher) sendEscalation(ctx context.Context, policy *conf.EscalationPolicy, notif *Notification) {
+	escalated := *notif
+	escalated.Title = "Escalated: " + notif.Title
+	escalated.Metadata = make(map[string]any, len(notif.Metadata)+2)
+	for key, value := range notif.Metadata {
+		escalated.Metadata[key] = value
+	}
+	escalated.Metadata[MetadataKeyEscalationPolicy] = policy.Name
+	escalated.Metadata[MetadataKeyEscalatedAfter] = policy.After.String()
+
+	if d.log != nil {
+		d.log.Warn("escalating unacknowledged notification",
+			"policy", policy.Name,
+			"notification_id", notif.ID,
+			"component", notif.Component,
+			"unacknowledged_for", policy.After,
+			"providers", policy.Providers)
+	}
+
+	for i := range d.providers {
+		ep := &d.providers[i]
+		if !ep.prov.IsEnabled() || !slices.Contains(policy.Providers, ep.name) {
+			continue
+		}
+		go d.dispatchEnhanced(ctx, &escalated, ep)
+	}
+}
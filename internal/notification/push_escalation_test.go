@@ -0,0 +1,55 @@
+package notification
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+func TestPushDispatcher_Escalate(t *testing.T) {
+	t.Parallel()
+
+	sms := &fakeProvider{name: "sms", enabled: true, types: map[Type]bool{TypeError: true}, recvCh: make(chan *Notification, 4)}
+	d := &pushDispatcher{
+		providers:      []enhancedProvider{{prov: sms, name: sms.name}},
+		log:            getFileLogger(false),
+		enabled:        true,
+		defaultTimeout: time.Second,
+		escalations: []conf.EscalationPolicy{
+			{Name: "field-station", Match: conf.PushFilterConfig{Components: []string{"audio"}}, After: 15 * time.Minute, Providers: []string{"sms"}},
+		},
+	}
+
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	critical := NewNotification(TypeError, PriorityCritical, "Microphone lost", "No audio").WithComponent("audio")
+	critical.Timestamp = created
+	high := NewNotification(TypeError, PriorityHigh, "Gap", "Audio gap").WithComponent("audio")
+	high.Timestamp = created
+	otherComponent := NewNotification(TypeError, PriorityCritical, "Disk", "Full").WithComponent("diskmanager")
+	otherComponent.Timestamp = created
+	notifications := []*Notification{critical, high, otherComponent}
+
+	ctx := context.Background()
+	assert.Zero(t, d.escalate(ctx, notifications, created.Add(10*time.Minute)), "too early to escalate")
+	require.Equal(t, 1, d.escalate(ctx, notifications, created.Add(16*time.Minute)))
+
+	select {
+	case n := <-sms.recvCh:
+		assert.Equal(t, critical.ID, n.ID)
+		assert.Equal(t, "Escalated: Microphone lost", n.Title)
+		assert.Equal(t, "field-station", n.Metadata[MetadataKeyEscalationPolicy])
+		assert.NotContains(t, critical.Metadata, MetadataKeyEscalationPolicy, "the stored notification should not be modified")
+	case <-time.After(time.Second):
+		t.Fatal("escalated notification was not sent")
+	}
+
+	assert.Zero(t, d.escalate(ctx, notifications, created.Add(30*time.Minute)), "notifications are escalated once")
+
+	critical.MarkAsAcknowledged()
+	assert.Zero(t, d.escalate(ctx, notifications, created.Add(45*time.Minute)), "acknowledged notifications are not escalated")
+	assert.Empty(t, d.escalated)
+}
@@ -0,0 +1,137 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/httpclient"
+)
+
+// defaultNtfyServer is the public ntfy server, used when no server is configured
+const defaultNtfyServer = "https://ntfy.sh"
+
+// ntfyPriorities maps notification priorities to ntfy priorities, from 1 (min)
+// to 5 (max); critical notifications bypass do not disturb on most phones
+var ntfyPriorities = map[Priority]int{
+	PriorityLow:      2,
+	PriorityMedium:   3,
+	PriorityHigh:     4,
+	PriorityCritical: 5,
+}
+
+// ntfyTags are emoji tags shown in front of the title, per notification type
+var ntfyTags = map[Type]string{
+	TypeError:       "rotating_light",
+	TypeWarning:     "warning",
+	TypeInfo:        "information_source",
+	TypeDetection:   "bird",
+	TypeSystem:      "gear",
+	TypeRareSpecies: "star2",
+}
+
+// NtfyProvider publishes notifications to a topic of an ntfy server, public
+// or self-hosted, from where the ntfy apps receive them without an account
+type NtfyProvider struct {
+	name    string
+	enabled bool
+	server  string
+	topic   string
+	token   string
+	types   map[string]bool
+	client  *httpclient.Client
+}
+
+// ntfyMessage is a message published with the ntfy JSON API
+type ntfyMessage struct {
+	Topic    string   `json:"topic"`
+	Title    string   `json:"title,omitempty"`
+	Message  string   `json:"message"`
+	Priority int      `json:"priority,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+}
+
+// NewNtfyProvider creates an ntfy provider from its push provider
+// configuration. The access token is passed resolved, as secrets are resolved
+// by the caller; an empty token publishes anonymously.
+func NewNtfyProvider(cfg *conf.PushProviderConfig, token string, types []string) *NtfyProvider {
+	np := &NtfyProvider{
+		name:    orDefault(cfg.Name, "ntfy"),
+		enabled: cfg.Enabled,
+		server:  strings.TrimRight(orDefault(strings.TrimSpace(cfg.ServerURL), defaultNtfyServer), "/"),
+		topic:   strings.TrimSpace(cfg.Topic),
+		token:   strings.TrimSpace(token),
+		types:   make(map[string]bool, len(types)),
+	}
+	for _, t := range types {
+		np.types[strings.ToLower(t)] = true
+	}
+
+	httpCfg := httpclient.DefaultConfig()
+	httpCfg.UserAgent = "BirdNET-Go-Ntfy/1.0"
+	httpCfg.DefaultTimeout = defaultWebhookTimeout
+	np.client = httpclient.New(&httpCfg)
+	return np
+}
+
+func (p *NtfyProvider) GetName() string          { return p.name }
+func (p *NtfyProvider) IsEnabled() bool          { return p.enabled }
+func (p *NtfyProvider) SupportsType(t Type) bool { return p.types[string(t)] }
+func (p *NtfyProvider) ValidateConfig() error {
+	if !p.enabled {
+		return nil
+	}
+	if p.topic == "" {
+		return fmt.Errorf("ntfy topic is required")
+	}
+	if !strings.HasPrefix(p.server, "http://") && !strings.HasPrefix(p.server, "https://") {
+		return fmt.Errorf("ntfy server must be an http or https URL")
+	}
+	return nil
+}
+
+// Send publishes the notification to the topic
+func (p *NtfyProvider) Send(ctx context.Context, n *Notification) error {
+	msg := ntfyMessage{
+		Topic:    p.topic,
+		Title:    n.Title,
+		Message:  n.Message,
+		Priority: ntfyPriorities[n.Priority],
+	}
+	if msg.Message == "" {
+		// ntfy shows "triggered" for empty messages
+		msg.Message, msg.Title = n.Title, ""
+	}
+	if tag, ok := ntfyTags[n.Type]; ok {
+		msg.Tags = []string{tag}
+	}
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode ntfy message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.server+"/", bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create ntfy request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.token)
+	}
+
+	resp, err := p.client.Do(ctx, req)
+	if err != nil {
+		return fmt.Errorf("ntfy request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("ntfy returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
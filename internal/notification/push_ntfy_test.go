@@ -0,0 +1,85 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+func TestNtfyProviderSend(t *testing.T) {
+	var got ntfyMessage
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		auth = r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("invalid JSON body: %v", err)
+		}
+		_, _ = w.Write([]byte(`{"id":"abc"}`))
+	}))
+	defer srv.Close()
+
+	cfg := &conf.PushProviderConfig{Type: "ntfy", Enabled: true, ServerURL: srv.URL + "/", Topic: "backyard-birds"}
+	p := NewNtfyProvider(cfg, "tk_secret", effectiveTypes(nil))
+	if err := p.ValidateConfig(); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+
+	n := NewNotification(TypeDetection, PriorityCritical, "New Species Detected: Eurasian Wryneck", "First detection at backyard")
+	if err := p.Send(context.Background(), n); err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+	if got.Topic != "backyard-birds" || got.Title != n.Title || got.Message != n.Message {
+		t.Errorf("unexpected message: %+v", got)
+	}
+	if got.Priority != 5 {
+		t.Errorf("expected critical to map to ntfy priority 5, got %d", got.Priority)
+	}
+	if len(got.Tags) != 1 || got.Tags[0] != "bird" {
+		t.Errorf("expected the bird tag, got %v", got.Tags)
+	}
+	if auth != "Bearer tk_secret" {
+		t.Errorf("expected bearer token authorization, got %q", auth)
+	}
+
+	// Without a message the title is sent as the message
+	got = ntfyMessage{}
+	if err := p.Send(context.Background(), NewNotification(TypeError, PriorityLow, "Database error", "")); err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+	if got.Message != "Database error" || got.Title != "" || got.Priority != 2 {
+		t.Errorf("unexpected message without body: %+v", got)
+	}
+}
+
+func TestNtfyProviderErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"code":40301,"error":"forbidden"}`))
+	}))
+	defer srv.Close()
+
+	p := NewNtfyProvider(&conf.PushProviderConfig{Enabled: true, ServerURL: srv.URL, Topic: "birds"}, "", []string{"error"})
+	err := p.Send(context.Background(), NewNotification(TypeError, PriorityHigh, "title", "message"))
+	if err == nil || !strings.Contains(err.Error(), "403") {
+		t.Fatalf("expected the status in the error, got %v", err)
+	}
+	if p.SupportsType(TypeDetection) {
+		t.Error("expected detections to be filtered out")
+	}
+
+	defaults := NewNtfyProvider(&conf.PushProviderConfig{Enabled: true}, "", nil)
+	if defaults.server != defaultNtfyServer || defaults.GetName() != "ntfy" {
+		t.Errorf("unexpected defaults: server %q, name %q", defaults.server, defaults.GetName())
+	}
+	if err := defaults.ValidateConfig(); err == nil {
+		t.Error("expected an error without a topic")
+	}
+}
@@ -0,0 +1,172 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/httpclient"
+)
+
+const (
+	// pushoverAPIURL is the Pushover message API endpoint
+	pushoverAPIURL = "https://api.pushover.net/1/messages.json"
+
+	// Pushover priorities; emergency notifications repeat until acknowledged
+	pushoverPriorityLow       = -1
+	pushoverPriorityNormal    = 0
+	pushoverPriorityEmergency = 2
+
+	// Defaults for repeating emergency notifications
+	defaultPushoverRetry  = 60 * time.Second
+	defaultPushoverExpire = time.Hour
+
+	// Pushover rejects longer titles and messages
+	pushoverMaxTitle   = 250
+	pushoverMaxMessage = 1024
+)
+
+// pushoverPriorities maps notification priorities to Pushover priorities.
+// High and critical notifications are sent as emergencies, which bypass quiet
+// hours and repeat until acknowledged or expired.
+var pushoverPriorities = map[Priority]int{
+	PriorityLow:      pushoverPriorityLow,
+	PriorityMedium:   pushoverPriorityNormal,
+	PriorityHigh:     pushoverPriorityEmergency,
+	PriorityCritical: pushoverPriorityEmergency,
+}
+
+// PushoverProvider sends notifications through the Pushover message API
+type PushoverProvider struct {
+	name     string
+	enabled  bool
+	userKey  string
+	appToken string
+	device   string
+	retry    time.Duration
+	expire   time.Duration
+	types    map[string]bool
+	apiURL   string
+	client   *httpclient.Client
+}
+
+// pushoverResponse is the Pushover API response
+type pushoverResponse struct {
+	Status  int      `json:"status"`
+	Request string   `json:"request"`
+	Errors  []string `json:"errors"`
+}
+
+// NewPushoverProvider creates a Pushover provider from its push provider
+// configuration. The user key and application token are passed resolved, as
+// secrets are resolved by the caller.
+func NewPushoverProvider(cfg *conf.PushProviderConfig, userKey, appToken string, types []string) *PushoverProvider {
+	pp := &PushoverProvider{
+		name:     orDefault(cfg.Name, "pushover"),
+		enabled:  cfg.Enabled,
+		userKey:  strings.TrimSpace(userKey),
+		appToken: strings.TrimSpace(appToken),
+		device:   strings.TrimSpace(cfg.Device),
+		retry:    cfg.EmergencyRetry,
+		expire:   cfg.EmergencyExpire,
+		types:    make(map[string]bool, len(types)),
+		apiURL:   pushoverAPIURL,
+	}
+	if pp.retry <= 0 {
+		pp.retry = defaultPushoverRetry
+	}
+	if pp.expire <= 0 {
+		pp.expire = defaultPushoverExpire
+	}
+	for _, t := range types {
+		pp.types[strings.ToLower(t)] = true
+	}
+
+	httpCfg := httpclient.DefaultConfig()
+	httpCfg.UserAgent = "BirdNET-Go-Pushover/1.0"
+	httpCfg.DefaultTimeout = defaultWebhookTimeout
+	pp.client = httpclient.New(&httpCfg)
+	return pp
+}
+
+func (p *PushoverProvider) GetName() string          { return p.name }
+func (p *PushoverProvider) IsEnabled() bool          { return p.enabled }
+func (p *PushoverProvider) SupportsType(t Type) bool { return p.types[string(t)] }
+func (p *PushoverProvider) ValidateConfig() error {
+	if !p.enabled {
+		return nil
+	}
+	if p.userKey == "" {
+		return fmt.Errorf("pushover user key is required")
+	}
+	if p.appToken == "" {
+		return fmt.Errorf("pushover application token is required")
+	}
+	return nil
+}
+
+// Send posts the notification to the Pushover message API
+func (p *PushoverProvider) Send(ctx context.Context, n *Notification) error {
+	title, message := truncateRunes(n.Title, pushoverMaxTitle), truncateRunes(n.Message, pushoverMaxMessage)
+	if message == "" {
+		// Pushover requires a message
+		title, message = "", truncateRunes(n.Title, pushoverMaxMessage)
+	}
+	priority := pushoverPriorities[n.Priority]
+
+	form := url.Values{}
+	form.Set("token", p.appToken)
+	form.Set("user", p.userKey)
+	form.Set("message", message)
+	form.Set("priority", strconv.Itoa(priority))
+	form.Set("timestamp", strconv.FormatInt(n.Timestamp.Unix(), 10))
+	if title != "" {
+		form.Set("title", title)
+	}
+	if p.device != "" {
+		form.Set("device", p.device)
+	}
+	if priority == pushoverPriorityEmergency {
+		form.Set("retry", strconv.Itoa(int(p.retry.Seconds())))
+		form.Set("expire", strconv.Itoa(int(p.expire.Seconds())))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.apiURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create pushover request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := p.client.Do(ctx, req)
+	if err != nil {
+		return fmt.Errorf("pushover request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var result pushoverResponse
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	_ = json.Unmarshal(body, &result)
+	if resp.StatusCode != http.StatusOK || result.Status != 1 {
+		if len(result.Errors) > 0 {
+			return fmt.Errorf("pushover returned status %d: %s", resp.StatusCode, strings.Join(result.Errors, "; "))
+		}
+		return fmt.Errorf("pushover returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// truncateRunes shortens s to at most limit runes
+func truncateRunes(s string, limit int) string {
+	r := []rune(s)
+	if len(r) <= limit {
+		return s
+	}
+	return string(r[:limit-1]) + "…"
+}
@@ -0,0 +1,97 @@
+package notification
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+// newFakePushover starts a message API accepting the application token "app"
+func newFakePushover(t *testing.T) (srv *httptest.Server, received func() url.Values) {
+	t.Helper()
+	var last url.Values
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("invalid form: %v", err)
+		}
+		last = r.PostForm
+		if r.PostForm.Get("token") != "app" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"token":"invalid","errors":["application token is invalid"],"status":0}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":1,"request":"647d2300"}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() url.Values { return last }
+}
+
+func TestPushoverProviderPriorities(t *testing.T) {
+	srv, received := newFakePushover(t)
+	cfg := &conf.PushProviderConfig{Type: "pushover", Enabled: true, Device: "phone", EmergencyRetry: 2 * time.Minute}
+	p := NewPushoverProvider(cfg, "user", "app", effectiveTypes(nil))
+	p.apiURL = srv.URL
+	if err := p.ValidateConfig(); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+
+	tests := []struct {
+		priority Priority
+		want     string
+		repeats  bool
+	}{
+		{PriorityLow, "-1", false},
+		{PriorityMedium, "0", false},
+		{PriorityHigh, "2", true},
+		{PriorityCritical, "2", true},
+	}
+	for _, tt := range tests {
+		n := NewNotification(TypeError, tt.priority, "Database error", "Disk is full")
+		if err := p.Send(context.Background(), n); err != nil {
+			t.Fatalf("%s: send failed: %v", tt.priority, err)
+		}
+		form := received()
+		if form.Get("priority") != tt.want {
+			t.Errorf("%s: expected pushover priority %s, got %s", tt.priority, tt.want, form.Get("priority"))
+		}
+		if form.Get("user") != "user" || form.Get("device") != "phone" || form.Get("title") != "Database error" || form.Get("message") != "Disk is full" {
+			t.Errorf("%s: unexpected form %v", tt.priority, form)
+		}
+		if tt.repeats && (form.Get("retry") != "120" || form.Get("expire") != "3600") {
+			t.Errorf("%s: expected retry 120 and default expire 3600, got %q and %q", tt.priority, form.Get("retry"), form.Get("expire"))
+		}
+		if !tt.repeats && (form.Has("retry") || form.Has("expire")) {
+			t.Errorf("%s: unexpected emergency parameters %v", tt.priority, form)
+		}
+	}
+}
+
+func TestPushoverProviderErrors(t *testing.T) {
+	srv, received := newFakePushover(t)
+	p := NewPushoverProvider(&conf.PushProviderConfig{Enabled: true}, "user", "wrong", []string{"error"})
+	p.apiURL = srv.URL
+
+	err := p.Send(context.Background(), NewNotification(TypeError, PriorityLow, strings.Repeat("a", 300), ""))
+	if err == nil || !strings.Contains(err.Error(), "application token is invalid") {
+		t.Fatalf("expected the API error, got %v", err)
+	}
+	if form := received(); form.Has("title") || len([]rune(form.Get("message"))) != 300 {
+		t.Errorf("expected the title to be sent as message, got %v", form)
+	}
+	if p.SupportsType(TypeDetection) || p.GetName() != "pushover" {
+		t.Errorf("unexpected provider %q supporting detections", p.GetName())
+	}
+
+	if err := NewPushoverProvider(&conf.PushProviderConfig{Enabled: true}, "user", "", nil).ValidateConfig(); err == nil {
+		t.Error("expected an error without an application token")
+	}
+	if got := truncateRunes("Käki käki", 5); got != "Käki…" {
+		t.Errorf("unexpected truncation %q", got)
+	}
+}
@@ -0,0 +1,209 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+const (
+	// quietHoursCheckInterval is how often ended quiet hours are checked for held notifications
+	quietHoursCheckInterval = time.Minute
+
+	// maxHeldNotifications caps the notifications held per provider; the oldest are dropped first
+	maxHeldNotifications = 100
+
+	// maxDigestLines caps the notifications listed in a quiet hours digest
+	maxDigestLines = 20
+
+	// MetadataKeyQuietHoursDigest is the number of notifications summarized by a digest
+	MetadataKeyQuietHoursDigest = "quiet_hours_digest"
+)
+
+// quietHours holds the notifications of a provider during its daily quiet
+// window until the window ends
+type quietHours struct {
+	from, until time.Duration // window bounds since midnight
+	digest      bool
+	types       []string
+	priorities  []string
+
+	mu      sync.Mutex
+	held    []*Notification
+	dropped int // notifications dropped since the last flush because too many were held
+}
+
+// newQuietHours returns the quiet hours of a channel, or nil when they are
+// disabled or their window is invalid
+func newQuietHours(cfg *conf.QuietHoursConfig) *quietHours {
+	if !cfg.Enabled {
+		return nil
+	}
+	from, errFrom := time.Parse("15:04", cfg.From)
+	until, errUntil := time.Parse("15:04", cfg.Until)
+	if errFrom != nil || errUntil != nil {
+		return nil
+	}
+	q := &quietHours{
+		from:       sinceMidnight(from),
+		until:      sinceMidnight(until),
+		digest:     strings.EqualFold(cfg.Mode, "digest"),
+		types:      cfg.Types,
+		priorities: cfg.Priorities,
+	}
+	if len(q.types) == 0 {
+		q.types = []string{string(TypeDetection)}
+	}
+	if len(q.priorities) == 0 {
+		q.priorities = []string{string(PriorityHigh), string(PriorityCritical)}
+	}
+	return q
+}
+
+// active reports whether t falls in the quiet window. A window ending before
+// it starts spans midnight.
+func (q *quietHours) active(t time.Time) bool {
+	now := sinceMidnight(t)
+	if q.from <= q.until {
+		return now >= q.from && now < q.until
+	}
+	return now >= q.from || now < q.until
+}
+
+// hold keeps a notification sent during the quiet window, reporting whether
+// it was held
+func (q *quietHours) hold(notif *Notification, now time.Time) bool {
+	if !q.active(now) ||
+		!slices.Contains(q.types, string(notif.Type)) ||
+		!slices.Contains(q.priorities, string(notif.Priority)) {
+		return false
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.held) >= maxHeldNotifications {
+		q.held = q.held[1:]
+		q.dropped++
+	}
+	q.held = append(q.held, notif)
+	return true
+}
+
+// release returns the held notifications once the quiet window has ended
+func (q *quietHours) release(now time.Time) (held []*Notification, dropped int) {
+	if q.active(now) {
+		return nil, 0
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	held, dropped = q.held, q.dropped
+	q.held, q.dropped = nil, 0
+	return held, dropped
+}
+
+// sinceMidnight returns the time of day of t
+func sinceMidnight(t time.Time) time.Duration {
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+}
+
+// hasQuietHours reports whether any provider has quiet hours
+func (d *pushDispatcher) hasQuietHours() bool {
+	for i := range d.providers {
+		if d.providers[i].quietHours != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// runQuietHours delivers the notifications held by quiet hours once their
+// window ends, until ctx is cancelled
+func (d *pushDispatcher) runQuietHours(ctx context.Context) {
+	ticker := time.NewTicker(quietHoursCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			d.flushQuietHours(ctx, now)
+		}
+	}
+}
+
+// flushQuietHours delivers the held notifications of the providers whose
+// quiet window has ended, in the order they were held, or a digest of them.
+// It returns the number of notifications sent.
+func (d *pushDispatcher) flushQuietHours(ctx context.Context, now time.Time) int {
+	sent := 0
+	for i := range d.providers {
+		ep := &d.providers[i]
+		if ep.quietHours == nil {
+			continue
+		}
+		held, dropped := ep.quietHours.release(now)
+		if len(held) == 0 {
+			continue
+		}
+		if d.log != nil {
+			d.log.Info("quiet hours ended, sending held notifications",
+				"provider", ep.name,
+				"held", len(held),
+				"dropped", dropped,
+				"digest", ep.quietHours.digest)
+		}
+		if ep.quietHours.digest {
+			held = []*Notification{quietHoursDigest(held, dropped)}
+		}
+		sent += len(held)
+		go func(ep *enhancedProvider, held []*Notification) {
+			defer func() {
+				if r := recover(); r != nil && d.log != nil {
+					d.log.Error("panic sending held notifications", "provider", ep.name, "panic", r)
+				}
+			}()
+			for _, notif := range held {
+				d.dispatchEnhanced(ctx, notif, ep)
+			}
+		}(ep, held)
+	}
+	return sent
+}
+
+// quietHoursDigest summarizes the notifications held during quiet hours in a
+// single medium priority notification
+func quietHoursDigest(held []*Notification, dropped int) *Notification {
+	total := len(held) + dropped
+	notifType := held[0].Type
+	for _, notif := range held {
+		if notif.Type != notifType {
+			notifType = TypeInfo
+			break
+		}
+	}
+
+	var b strings.Builder
+	for i, notif := range held {
+		if i == maxDigestLines {
+			fmt.Fprintf(&b, "…and %d more\n", len(held)-maxDigestLines)
+			break
+		}
+		fmt.Fprintf(&b, "%s %s\n", notif.Timestamp.Format("15:04"), notif.Title)
+	}
+	if dropped > 0 {
+		fmt.Fprintf(&b, "%d older notifications were not kept\n", dropped)
+	}
+
+	title := fmt.Sprintf("%d notifications during quiet hours", total)
+	if total == 1 {
+		title = "1 notification during quiet hours"
+	}
+	return NewNotification(notifType, PriorityMedium, title, strings.TrimSuffix(b.String(), "\n")).
+		WithComponent("notification").
+		WithMetadata(MetadataKeyQuietHoursDigest, total)
+}
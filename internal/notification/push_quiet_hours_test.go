@@ -0,0 +1,124 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+func TestQuietHours_Window(t *testing.T) {
+	t.Parallel()
+
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local)
+	overnight := newQuietHours(&conf.QuietHoursConfig{Enabled: true, From: "22:00", Until: "07:00"})
+	require.NotNil(t, overnight)
+	assert.True(t, overnight.active(day.Add(23*time.Hour)))
+	assert.True(t, overnight.active(day.Add(3*time.Hour)))
+	assert.False(t, overnight.active(day.Add(7*time.Hour)))
+	assert.False(t, overnight.active(day.Add(12*time.Hour)))
+
+	siesta := newQuietHours(&conf.QuietHoursConfig{Enabled: true, From: "13:00", Until: "15:30"})
+	require.NotNil(t, siesta)
+	assert.True(t, siesta.active(day.Add(14*time.Hour)))
+	assert.False(t, siesta.active(day.Add(15*time.Hour+30*time.Minute)))
+	assert.False(t, siesta.active(day.Add(3*time.Hour)))
+
+	assert.Nil(t, newQuietHours(&conf.QuietHoursConfig{From: "22:00", Until: "07:00"}), "disabled")
+	assert.Nil(t, newQuietHours(&conf.QuietHoursConfig{Enabled: true, From: "late", Until: "07:00"}), "invalid window")
+}
+
+func TestQuietHours_HoldAndRelease(t *testing.T) {
+	t.Parallel()
+
+	night := time.Date(2024, 5, 1, 23, 0, 0, 0, time.Local)
+	morning := time.Date(2024, 5, 2, 7, 0, 0, 0, time.Local)
+	q := newQuietHours(&conf.QuietHoursConfig{Enabled: true, From: "22:00", Until: "07:00"})
+
+	owl := NewNotification(TypeDetection, PriorityHigh, "New Species: Tawny Owl", "")
+	assert.True(t, q.hold(owl, night))
+	assert.False(t, q.hold(NewNotification(TypeDetection, PriorityLow, "Eurasian Robin", ""), night), "low priority is not held by default")
+	assert.False(t, q.hold(NewNotification(TypeError, PriorityCritical, "Disk full", ""), night), "errors are not held by default")
+	assert.False(t, q.hold(NewNotification(TypeDetection, PriorityHigh, "New Species: Wryneck", ""), morning), "outside the window")
+
+	held, dropped := q.release(night)
+	assert.Empty(t, held, "nothing is released during the window")
+	assert.Zero(t, dropped)
+
+	for i := range maxHeldNotifications {
+		q.hold(NewNotification(TypeDetection, PriorityCritical, fmt.Sprint(i), ""), night)
+	}
+	held, dropped = q.release(morning)
+	require.Len(t, held, maxHeldNotifications)
+	assert.Equal(t, 1, dropped)
+	assert.Equal(t, "0", held[0].Title, "the oldest notification is dropped first")
+
+	held, _ = q.release(morning)
+	assert.Empty(t, held, "held notifications are released once")
+}
+
+func TestPushDispatcher_QuietHours(t *testing.T) {
+	t.Parallel()
+
+	// A window around the current time, so dispatch holds notifications
+	now := time.Now()
+	window := &conf.QuietHoursConfig{
+		Enabled: true,
+		From:    now.Add(-time.Hour).Format("15:04"),
+		Until:   now.Add(time.Hour).Format("15:04"),
+	}
+	digestWindow := *window
+	digestWindow.Mode = "digest"
+
+	phone := &fakeProvider{name: "phone", enabled: true, types: map[Type]bool{TypeDetection: true}, recvCh: make(chan *Notification, 4)}
+	inbox := &fakeProvider{name: "inbox", enabled: true, types: map[Type]bool{TypeDetection: true}, recvCh: make(chan *Notification, 4)}
+	d := &pushDispatcher{
+		providers: []enhancedProvider{
+			{prov: phone, name: phone.name, quietHours: newQuietHours(window)},
+			{prov: inbox, name: inbox.name, quietHours: newQuietHours(&digestWindow)},
+		},
+		log:            getFileLogger(false),
+		enabled:        true,
+		defaultTimeout: time.Second,
+	}
+	require.True(t, d.hasQuietHours())
+
+	ctx := context.Background()
+	first := NewNotification(TypeDetection, PriorityHigh, "New Species: Tawny Owl", "")
+	second := NewNotification(TypeDetection, PriorityHigh, "New Species: Eurasian Nightjar", "")
+	d.dispatch(ctx, first)
+	d.dispatch(ctx, second)
+	select {
+	case n := <-phone.recvCh:
+		t.Fatalf("notification %q was sent during quiet hours", n.Title)
+	case n := <-inbox.recvCh:
+		t.Fatalf("notification %q was sent during quiet hours", n.Title)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	assert.Zero(t, d.flushQuietHours(ctx, now), "the window has not ended")
+	require.Equal(t, 3, d.flushQuietHours(ctx, now.Add(2*time.Hour)))
+
+	for _, want := range []*Notification{first, second} {
+		select {
+		case n := <-phone.recvCh:
+			assert.Equal(t, want.ID, n.ID, "held notifications are sent in order")
+		case <-time.After(time.Second):
+			t.Fatal("held notification was not sent")
+		}
+	}
+	select {
+	case n := <-inbox.recvCh:
+		assert.Equal(t, "2 notifications during quiet hours", n.Title)
+		assert.Equal(t, PriorityMedium, n.Priority)
+		assert.Contains(t, n.Message, "New Species: Tawny Owl")
+		assert.Contains(t, n.Message, "New Species: Eurasian Nightjar")
+		assert.Equal(t, 2, n.Metadata[MetadataKeyQuietHoursDigest])
+	case <-time.After(time.Second):
+		t.Fatal("digest was not sent")
+	}
+}
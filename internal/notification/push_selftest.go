@@ -0,0 +1,104 @@
+package notification
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+// testNotificationTypes are the types a test message is sent as, in order of
+// preference; each provider gets the first type it accepts
+var testNotificationTypes = []Type{TypeSystem, TypeInfo, TypeDetection, TypeWarning, TypeError}
+
+// ProviderTestResult is the outcome of a test message sent through a push provider
+type ProviderTestResult struct {
+	Provider      string `json:"provider"`
+	Channel       string `json:"channel"`
+	Type          Type   `json:"type,omitempty"` // notification type the message was sent as
+	Success       bool   `json:"success"`
+	Skipped       bool   `json:"skipped,omitempty"`
+	LatencyMs     int64  `json:"latency_ms"`
+	Error         string `json:"error,omitempty"`
+	ErrorCategory string `json:"error_category,omitempty"` // as in the delivery metrics, e.g. timeout or permission
+}
+
+// TestProviders sends a test notification through every enabled provider in
+// parallel and returns the outcome of each, in the order the providers are
+// configured. Filters, routes, quiet hours, rate limits and retries do not
+// apply, and failed test messages are not queued for redelivery.
+func (d *pushDispatcher) TestProviders(ctx context.Context, notif *Notification) []ProviderTestResult {
+	d.mu.RLock()
+	providers := make([]*enhancedProvider, 0, len(d.providers))
+	for i := range d.providers {
+		if d.providers[i].prov.IsEnabled() {
+			providers = append(providers, &d.providers[i])
+		}
+	}
+	d.mu.RUnlock()
+
+	results := make([]ProviderTestResult, len(providers))
+	var wg sync.WaitGroup
+	for i, ep := range providers {
+		results[i] = ProviderTestResult{Provider: ep.name, Channel: ep.channel}
+		notifType, ok := testNotificationType(ep.prov)
+		switch {
+		case !ok:
+			results[i].Skipped = true
+			results[i].Error = "provider accepts no notification type"
+			continue
+		case conf.IsOffline() && requiresNetwork(ep.prov):
+			results[i].Skipped = true
+			results[i].Error = "provider requires network access in offline mode"
+			continue
+		}
+
+		test := *notif
+		test.Type = notifType
+		results[i].Type = notifType
+		wg.Add(1)
+		go func(result *ProviderTestResult, ep *enhancedProvider) {
+			defer wg.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					result.Success = false
+					result.Error = "provider panicked"
+					result.ErrorCategory = "provider_error"
+				}
+			}()
+			duration, err := d.attemptSend(ctx, &test, ep)
+			result.LatencyMs = duration.Milliseconds()
+			if err != nil {
+				result.Error = err.Error()
+				result.ErrorCategory = categorizeError(err)
+				return
+			}
+			result.Success = true
+		}(&results[i], ep)
+	}
+	wg.Wait()
+
+	if d.log != nil {
+		for i := range results {
+			d.log.Info("push provider test",
+				"provider", results[i].Provider,
+				"success", results[i].Success,
+				"skipped", results[i].Skipped,
+				"latency", time.Duration(results[i].LatencyMs)*time.Millisecond,
+				"error", results[i].Error)
+		}
+	}
+	return results
+}
+
+// testNotificationType returns the type a test message is sent to a provider
+// as, false when the provider accepts none
+func testNotificationType(p Provider) (Type, bool) {
+	for _, t := range testNotificationTypes {
+		if p.SupportsType(t) {
+			return t, true
+		}
+	}
+	return "", false
+}
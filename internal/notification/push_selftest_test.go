@@ -0,0 +1,53 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPushDispatcher_TestProviders(t *testing.T) {
+	t.Parallel()
+
+	ok := &fakeProvider{name: "family", enabled: true, types: map[Type]bool{TypeDetection: true}, recvCh: make(chan *Notification, 1), sendDelay: 20 * time.Millisecond}
+	denied := &fakeProvider{name: "hass", enabled: true, types: map[Type]bool{TypeSystem: true}, sendFunc: func(context.Context, *Notification) error {
+		return errors.New("401 unauthorized")
+	}}
+	mute := &fakeProvider{name: "mute", enabled: true, types: map[Type]bool{}}
+	disabled := &fakeProvider{name: "off", enabled: false, types: map[Type]bool{TypeSystem: true}}
+	d := &pushDispatcher{
+		providers: []enhancedProvider{
+			{prov: ok, name: ok.name, channel: "telegram"},
+			{prov: denied, name: denied.name, channel: "webhook"},
+			{prov: mute, name: mute.name, channel: "ntfy"},
+			{prov: disabled, name: disabled.name, channel: "ntfy"},
+		},
+		log:            getFileLogger(false),
+		enabled:        true,
+		defaultTimeout: time.Second,
+	}
+
+	results := d.TestProviders(context.Background(), NewNotification(TypeSystem, PriorityLow, "Test", "Test message"))
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want one per enabled provider", len(results))
+	}
+
+	if r := results[0]; !r.Success || r.Type != TypeDetection || r.LatencyMs < 20 || r.Channel != "telegram" {
+		t.Errorf("family: got %+v, want a detection type success with its latency", r)
+	}
+	select {
+	case got := <-ok.recvCh:
+		if got.Title != "Test" || got.Type != TypeDetection {
+			t.Errorf("family received %q as %s", got.Title, got.Type)
+		}
+	default:
+		t.Error("family did not receive the test message")
+	}
+	if r := results[1]; r.Success || r.Type != TypeSystem || r.ErrorCategory != "permission" || r.Error != "401 unauthorized" {
+		t.Errorf("hass: got %+v, want a permission failure", r)
+	}
+	if r := results[2]; r.Success || !r.Skipped {
+		t.Errorf("mute: got %+v, want skipped", r)
+	}
+}
@@ -0,0 +1,278 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/httpclient"
+)
+
+const (
+	// telegramAPIURL is the Telegram Bot API server
+	telegramAPIURL = "https://api.telegram.org"
+
+	// Telegram limits of message text and media captions
+	telegramMaxMessage = 4096
+	telegramMaxCaption = 1024
+
+	// telegramMaxMedia is the largest clip or spectrogram attached
+	telegramMaxMedia = 20 << 20
+
+	// telegramMediaWait is the longest wait for the clip of a detection to be
+	// saved; the notification is sent when the detection is, before the clip
+	telegramMediaWait = 20 * time.Second
+
+	// telegramMediaPoll is the interval of retrying a clip that is not ready
+	telegramMediaPoll = 2 * time.Second
+)
+
+// telegramDefaultTypes are sent when the provider filter names no types:
+// new species detections, rare species and errors
+var telegramDefaultTypes = []string{string(TypeError), string(TypeDetection), string(TypeRareSpecies)}
+
+// TelegramProvider sends notifications to a Telegram chat through a bot. The
+// audio clip and spectrogram of a detection can be attached, downloaded from
+// the signed media URLs in the notification metadata.
+type TelegramProvider struct {
+	name              string
+	enabled           bool
+	token             string
+	chatID            string
+	attachClip        bool
+	attachSpectrogram bool
+	types             map[string]bool
+	apiURL            string
+	mediaWait         time.Duration
+	client            *httpclient.Client
+}
+
+// NewTelegramProvider creates a Telegram provider from its push provider
+// configuration. The bot token is passed resolved, as secrets are resolved by
+// the caller.
+func NewTelegramProvider(cfg *conf.PushProviderConfig, token string) *TelegramProvider {
+	types := cfg.Filter.Types
+	if len(types) == 0 {
+		types = telegramDefaultTypes
+	}
+	tp := &TelegramProvider{
+		name:              orDefault(cfg.Name, "telegram"),
+		enabled:           cfg.Enabled,
+		token:             strings.TrimSpace(token),
+		chatID:            strings.TrimSpace(cfg.ChatID),
+		attachClip:        cfg.AttachClip,
+		attachSpectrogram: cfg.AttachSpectrogram,
+		types:             make(map[string]bool, len(types)),
+		apiURL:            telegramAPIURL,
+		mediaWait:         telegramMediaWait,
+	}
+	for _, t := range types {
+		tp.types[strings.ToLower(t)] = true
+	}
+
+	httpCfg := httpclient.DefaultConfig()
+	httpCfg.UserAgent = "BirdNET-Go-Telegram/1.0"
+	httpCfg.DefaultTimeout = defaultWebhookTimeout
+	tp.client = httpclient.New(&httpCfg)
+	return tp
+}
+
+func (t *TelegramProvider) GetName() string           { return t.name }
+func (t *TelegramProvider) IsEnabled() bool           { return t.enabled }
+func (t *TelegramProvider) SupportsType(ty Type) bool { return t.types[string(ty)] }
+func (t *TelegramProvider) ValidateConfig() error {
+	if !t.enabled {
+		return nil
+	}
+	if t.token == "" {
+		return fmt.Errorf("telegram bot token is required")
+	}
+	if t.chatID == "" {
+		return fmt.Errorf("telegram chat ID is required")
+	}
+	return nil
+}
+
+// Send posts the notification to the chat. With a spectrogram the message is
+// its caption; the clip follows as a separate audio message. Attachments are
+// best effort: a clip that cannot be downloaded never holds back the message.
+func (t *TelegramProvider) Send(ctx context.Context, n *Notification) error {
+	text := telegramText(n)
+
+	var spectrogram, clip *telegramMedia
+	if t.attachSpectrogram {
+		spectrogram = t.fetchMedia(ctx, n.Metadata[MetadataKeySpectrogramURL], "spectrogram")
+	}
+	if t.attachClip {
+		clip = t.fetchMedia(ctx, n.Metadata[MetadataKeyClipURL], "clip")
+	}
+
+	sent := false
+	if spectrogram != nil && len(text) <= telegramMaxCaption {
+		sent = t.upload(ctx, "sendPhoto", "photo", spectrogram, text) == nil
+		spectrogram = nil
+	}
+	if !sent {
+		if err := t.call(ctx, "sendMessage", map[string]any{
+			"chat_id":                  t.chatID,
+			"text":                     truncate(text, telegramMaxMessage),
+			"disable_web_page_preview": true,
+		}); err != nil {
+			return err
+		}
+	}
+	if spectrogram != nil {
+		_ = t.upload(ctx, "sendPhoto", "photo", spectrogram, "")
+	}
+	if clip != nil {
+		_ = t.upload(ctx, "sendAudio", "audio", clip, "")
+	}
+	return nil
+}
+
+// telegramText formats a notification as a plain text message
+func telegramText(n *Notification) string {
+	if n.Message == "" {
+		return n.Title
+	}
+	return n.Title + "\n\n" + n.Message
+}
+
+// telegramMedia is a downloaded attachment
+type telegramMedia struct {
+	filename string
+	data     []byte
+}
+
+// fetchMedia downloads an attachment from a signed media URL, retrying while
+// the server reports it is not saved yet. It returns nil when the URL is
+// missing or the download fails.
+func (t *TelegramProvider) fetchMedia(ctx context.Context, rawURL any, name string) *telegramMedia {
+	u, _ := rawURL.(string)
+	if u == "" {
+		return nil
+	}
+
+	// Leave at least half of the send deadline for the messages
+	wait := t.mediaWait
+	if deadline, ok := ctx.Deadline(); ok {
+		wait = min(wait, time.Until(deadline)/2)
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, wait)
+	defer cancel()
+
+	for {
+		media, retry := t.getMedia(waitCtx, u, name)
+		if media != nil || !retry {
+			return media
+		}
+		select {
+		case <-waitCtx.Done():
+			return nil
+		case <-time.After(telegramMediaPoll):
+		}
+	}
+}
+
+// getMedia makes one download attempt of an attachment and reports whether
+// the media may become available later
+func (t *TelegramProvider) getMedia(ctx context.Context, u, name string) (media *telegramMedia, retry bool) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, http.NoBody)
+	if err != nil {
+		return nil, false
+	}
+	resp, err := t.client.Do(ctx, req)
+	if err != nil {
+		return nil, ctx.Err() == nil
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusServiceUnavailable:
+		return nil, true
+	default:
+		return nil, false
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, telegramMaxMedia+1))
+	if err != nil || len(data) == 0 || len(data) > telegramMaxMedia {
+		return nil, false
+	}
+
+	filename := name
+	if mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err == nil {
+		if exts, _ := mime.ExtensionsByType(mediaType); len(exts) > 0 {
+			filename += exts[0]
+		}
+	}
+	return &telegramMedia{filename: filename, data: data}, false
+}
+
+// upload sends a photo or audio file to the chat as multipart form data
+func (t *TelegramProvider) upload(ctx context.Context, method, field string, media *telegramMedia, caption string) error {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	_ = w.WriteField("chat_id", t.chatID)
+	if caption != "" {
+		_ = w.WriteField("caption", caption)
+	}
+	part, err := w.CreateFormFile(field, media.filename)
+	if err != nil {
+		return fmt.Errorf("failed to create telegram upload: %w", err)
+	}
+	if _, err := part.Write(media.data); err != nil {
+		return fmt.Errorf("failed to create telegram upload: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to create telegram upload: %w", err)
+	}
+	return t.post(ctx, method, w.FormDataContentType(), &body)
+}
+
+// call invokes a Bot API method with JSON parameters
+func (t *TelegramProvider) call(ctx context.Context, method string, params map[string]any) error {
+	payload, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("failed to encode telegram request: %w", err)
+	}
+	return t.post(ctx, method, "application/json", bytes.NewReader(payload))
+}
+
+// post sends a Bot API request and checks the result. Errors never include
+// the request URL, which contains the bot token.
+func (t *TelegramProvider) post(ctx context.Context, method, contentType string, body io.Reader) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.apiURL+"/bot"+t.token+"/"+method, body)
+	if err != nil {
+		return fmt.Errorf("telegram %s: failed to create request", method)
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := t.client.Do(ctx, req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("telegram %s: %w", method, ctx.Err())
+		}
+		return fmt.Errorf("telegram %s: request failed", method)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var result struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&result); err != nil || !result.OK {
+		if result.Description == "" {
+			result.Description = http.StatusText(resp.StatusCode)
+		}
+		return fmt.Errorf("telegram %s failed with status %d: %s", method, resp.StatusCode, result.Description)
+	}
+	return nil
+}
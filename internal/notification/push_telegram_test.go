@@ -0,0 +1,194 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+// telegramRequest is a Bot API call received by the fake Telegram server
+type telegramRequest struct {
+	method   string
+	fields   map[string]string
+	file     string // name of the uploaded file
+	fileData string
+}
+
+// newFakeTelegram starts a Bot API server accepting the token "123:abc"
+func newFakeTelegram(t *testing.T) (srv *httptest.Server, calls func() []telegramRequest) {
+	t.Helper()
+	var mu sync.Mutex
+	var received []telegramRequest
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, ok := strings.CutPrefix(r.URL.Path, "/bot123:abc/")
+		if !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"ok":false,"description":"Unauthorized"}`))
+			return
+		}
+		req := telegramRequest{method: method, fields: map[string]string{}}
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/") {
+			if err := r.ParseMultipartForm(1 << 20); err != nil {
+				t.Errorf("invalid multipart upload: %v", err)
+			}
+			for k, v := range r.MultipartForm.Value {
+				req.fields[k] = v[0]
+			}
+			for _, files := range r.MultipartForm.File {
+				f, _ := files[0].Open()
+				data, _ := io.ReadAll(f)
+				req.file, req.fileData = files[0].Filename, string(data)
+			}
+		} else {
+			var params map[string]any
+			_ = json.NewDecoder(r.Body).Decode(&params)
+			for k, v := range params {
+				if s, ok := v.(string); ok {
+					req.fields[k] = s
+				}
+			}
+		}
+		mu.Lock()
+		received = append(received, req)
+		mu.Unlock()
+		_, _ = w.Write([]byte(`{"ok":true,"result":{}}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []telegramRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]telegramRequest{}, received...)
+	}
+}
+
+func testTelegramProvider(apiURL string, cfg *conf.PushProviderConfig) *TelegramProvider {
+	cfg.Type, cfg.Enabled, cfg.ChatID = "telegram", true, "-100123"
+	tp := NewTelegramProvider(cfg, "123:abc")
+	tp.apiURL = apiURL
+	tp.mediaWait = 5 * time.Second
+	return tp
+}
+
+func TestTelegramProviderDefaults(t *testing.T) {
+	tp := NewTelegramProvider(&conf.PushProviderConfig{Type: "telegram", Enabled: true, ChatID: "@birds"}, " 123:abc ")
+	if tp.GetName() != "telegram" {
+		t.Errorf("expected default name 'telegram', got %q", tp.GetName())
+	}
+	if err := tp.ValidateConfig(); err != nil {
+		t.Errorf("unexpected validation error: %v", err)
+	}
+	if !tp.SupportsType(TypeError) || !tp.SupportsType(TypeDetection) {
+		t.Error("expected errors and detections to be sent by default")
+	}
+	if tp.SupportsType(TypeInfo) || tp.SupportsType(TypeSystem) {
+		t.Error("expected info and system notifications not to be sent by default")
+	}
+
+	missing := NewTelegramProvider(&conf.PushProviderConfig{Type: "telegram", Enabled: true, ChatID: "@birds"}, "")
+	if err := missing.ValidateConfig(); err == nil {
+		t.Error("expected an error without a bot token")
+	}
+}
+
+func TestTelegramProviderSendMessage(t *testing.T) {
+	srv, calls := newFakeTelegram(t)
+	tp := testTelegramProvider(srv.URL, &conf.PushProviderConfig{AttachClip: true})
+
+	n := NewNotification(TypeError, PriorityHigh, "Database error", "Disk is full")
+	if err := tp.Send(context.Background(), n); err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+	got := calls()
+	if len(got) != 1 || got[0].method != "sendMessage" {
+		t.Fatalf("expected one sendMessage call, got %+v", got)
+	}
+	if got[0].fields["chat_id"] != "-100123" || got[0].fields["text"] != "Database error\n\nDisk is full" {
+		t.Errorf("unexpected message fields: %v", got[0].fields)
+	}
+
+	tp.token = "wrong"
+	err := tp.Send(context.Background(), n)
+	if err == nil || !strings.Contains(err.Error(), "Unauthorized") {
+		t.Fatalf("expected the API error, got %v", err)
+	}
+	if strings.Contains(err.Error(), "wrong") {
+		t.Error("error must not contain the bot token")
+	}
+}
+
+func TestTelegramProviderSendAttachments(t *testing.T) {
+	srv, calls := newFakeTelegram(t)
+
+	// The clip is not saved on the first attempt
+	var clipRequests int
+	media := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/audio/5":
+			clipRequests++
+			if clipRequests == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Header().Set("Content-Type", "audio/mpeg")
+			_, _ = w.Write([]byte("mp3 data"))
+		case "/spectrogram/5":
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write([]byte("png data"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer media.Close()
+
+	tp := testTelegramProvider(srv.URL, &conf.PushProviderConfig{AttachClip: true, AttachSpectrogram: true})
+	n := NewNotification(TypeDetection, PriorityHigh, "New Species Detected: Eurasian Wryneck", "First detection of Eurasian Wryneck").
+		WithMetadata(MetadataKeyClipURL, media.URL+"/audio/5").
+		WithMetadata(MetadataKeySpectrogramURL, media.URL+"/spectrogram/5")
+	if err := tp.Send(context.Background(), n); err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+
+	got := calls()
+	if len(got) != 2 {
+		t.Fatalf("expected a photo and an audio message, got %+v", got)
+	}
+	if got[0].method != "sendPhoto" || got[0].fileData != "png data" || !strings.HasPrefix(got[0].file, "spectrogram") {
+		t.Errorf("unexpected photo upload: %+v", got[0])
+	}
+	if got[0].fields["caption"] != "New Species Detected: Eurasian Wryneck\n\nFirst detection of Eurasian Wryneck" {
+		t.Errorf("expected the message as caption, got %q", got[0].fields["caption"])
+	}
+	if got[1].method != "sendAudio" || got[1].fileData != "mp3 data" || got[1].fields["caption"] != "" {
+		t.Errorf("unexpected audio upload: %+v", got[1])
+	}
+	if clipRequests != 2 {
+		t.Errorf("expected the clip to be retried once, got %d requests", clipRequests)
+	}
+}
+
+func TestTelegramProviderMissingMedia(t *testing.T) {
+	srv, calls := newFakeTelegram(t)
+	media := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer media.Close()
+
+	tp := testTelegramProvider(srv.URL, &conf.PushProviderConfig{AttachClip: true, AttachSpectrogram: true})
+	n := NewNotification(TypeDetection, PriorityHigh, "New Species Detected", "").
+		WithMetadata(MetadataKeyClipURL, media.URL+"/audio/5").
+		WithMetadata(MetadataKeySpectrogramURL, media.URL+"/spectrogram/5")
+	if err := tp.Send(context.Background(), n); err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+	if got := calls(); len(got) != 1 || got[0].method != "sendMessage" || got[0].fields["text"] != "New Species Detected" {
+		t.Fatalf("expected the message without attachments, got %+v", got)
+	}
+}
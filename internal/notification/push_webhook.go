@@ -30,6 +30,9 @@ package notification
 import (
 	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -42,6 +45,7 @@ import (
 	"github.com/tphakala/birdnet-go/internal/conf"
 	"github.com/tphakala/birdnet-go/internal/errors"
 	"github.com/tphakala/birdnet-go/internal/httpclient"
+	"github.com/tphakala/birdnet-go/internal/notification/templatefuncs"
 	"github.com/tphakala/birdnet-go/internal/secrets"
 )
 
@@ -51,6 +55,9 @@ const (
 
 	// maxErrorBodySize limits error response body reading to prevent memory issues
 	maxErrorBodySize = 1024
+
+	// defaultSignatureHeader carries the HMAC-SHA256 signature of signed payloads
+	defaultSignatureHeader = "X-BirdNET-Signature"
 )
 
 // WebhookProvider sends notifications to HTTP/HTTPS webhooks with customizable templates,
@@ -65,6 +72,7 @@ type WebhookProvider struct {
 	client    *httpclient.Client
 	template  *template.Template // Optional custom JSON template
 	telemetry *NotificationTelemetry
+	broadcast bool // Send to every endpoint instead of the first that succeeds
 }
 
 // WebhookEndpoint represents a single webhook destination with its configuration.
@@ -74,6 +82,10 @@ type WebhookEndpoint struct {
 	Headers map[string]string
 	Timeout time.Duration
 	Auth    WebhookAuth
+
+	// HMAC-SHA256 signing, disabled when Secret is empty
+	Secret          string // Resolved signing secret
+	SignatureHeader string // Header carrying "sha256=<hex>" of the body
 }
 
 // WebhookAuth holds resolved authentication credentials for webhook requests.
@@ -132,6 +144,28 @@ func resolveWebhookAuth(cfg *conf.WebhookAuthConfig) (*WebhookAuth, error) {
 	return auth, nil
 }
 
+// resolveWebhookSigning resolves the signing secret of an endpoint. Signing is
+// optional, an endpoint without a secret returns an empty secret.
+func resolveWebhookSigning(cfg *conf.WebhookSigningConfig) (secret, header string, err error) {
+	secret, err = secrets.Resolve(cfg.SecretFile, cfg.Secret)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to resolve signing secret: %w", err)
+	}
+	header = strings.TrimSpace(cfg.Header)
+	if secret != "" && header == "" {
+		header = defaultSignatureHeader
+	}
+	return secret, header, nil
+}
+
+// signWebhookPayload returns the signature header value of a payload,
+// "sha256=" followed by the hex encoded HMAC-SHA256 of the body
+func signWebhookPayload(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
 // WebhookPayload is the default JSON structure sent to webhooks.
 // Uses `omitzero` (Go 1.24+) to omit empty fields instead of `omitempty`.
 //
@@ -184,7 +218,7 @@ func NewWebhookProvider(name string, enabled bool, endpoints []WebhookEndpoint,
 
 	// Parse custom template if provided
 	if templateStr != "" {
-		tmpl, err := template.New("webhook").Parse(templateStr)
+		tmpl, err := template.New("webhook").Funcs(templatefuncs.FuncMap()).Parse(templateStr)
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse webhook template: %w", err)
 		}
@@ -285,6 +319,16 @@ func (w *WebhookProvider) ValidateConfig() error {
 		if err := validateResolvedWebhookAuth(&endpoint.Auth); err != nil {
 			return fmt.Errorf("endpoint %d: %w", i, err)
 		}
+
+		// Validate signing header
+		if endpoint.Secret != "" {
+			if endpoint.SignatureHeader == "" {
+				endpoint.SignatureHeader = defaultSignatureHeader
+			}
+			if strings.ContainsAny(endpoint.SignatureHeader, "\r\n: ") {
+				return fmt.Errorf("endpoint %d: signature header contains invalid characters", i)
+			}
+		}
 	}
 
 	return nil
@@ -330,8 +374,9 @@ func validateResolvedWebhookAuth(auth *WebhookAuth) error {
 	return nil
 }
 
-// Send sends a notification to all configured webhook endpoints.
+// Send sends a notification to the configured webhook endpoints.
 // Attempts each endpoint in order until one succeeds, or returns error if all fail.
+// In broadcast mode every endpoint is sent to and any failure is returned.
 //
 // Context handling (Go 1.24+ best practice):
 //   - Respects context cancellation for immediate cleanup
@@ -348,6 +393,10 @@ func (w *WebhookProvider) Send(ctx context.Context, n *Notification) error {
 		return fmt.Errorf("failed to build webhook payload: %w", err)
 	}
 
+	if w.broadcast {
+		return w.broadcastPayload(ctx, payload)
+	}
+
 	// Try each endpoint until one succeeds (use index to avoid copying)
 	errs := make([]error, 0, len(w.endpoints))
 	for i := range w.endpoints {
@@ -382,6 +431,33 @@ func (w *WebhookProvider) Send(ctx context.Context, n *Notification) error {
 	return fmt.Errorf("all webhook endpoints failed: %w", errors.Join(errs...))
 }
 
+// broadcastPayload sends the payload to every endpoint. Receivers of retried
+// notifications can recognize repeated payloads by their ID.
+func (w *WebhookProvider) broadcastPayload(ctx context.Context, payload []byte) error {
+	var errs []error
+	for i := range w.endpoints {
+		endpoint := &w.endpoints[i]
+
+		endpointCtx, cancel := ctx, context.CancelFunc(func() {})
+		if endpoint.Timeout > 0 {
+			endpointCtx, cancel = context.WithTimeout(ctx, endpoint.Timeout)
+		}
+		err := w.sendToEndpoint(endpointCtx, endpoint, payload)
+		cancel()
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("endpoint %d (%s): %w", i, endpoint.URL, err))
+		}
+		if ctx.Err() != nil {
+			return fmt.Errorf("context cancelled while sending webhook: %w", ctx.Err())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%d of %d webhook endpoints failed: %w", len(errs), len(w.endpoints), errors.Join(errs...))
+	}
+	return nil
+}
+
 // buildPayload constructs the JSON payload to send to the webhook.
 // Uses custom template if configured, otherwise uses default structure.
 func (w *WebhookProvider) buildPayload(n *Notification) ([]byte, error) {
@@ -446,6 +522,11 @@ func (w *WebhookProvider) sendToEndpoint(ctx context.Context, endpoint *WebhookE
 		return fmt.Errorf("failed to apply auth: %w", err)
 	}
 
+	// Sign the body last, so custom headers cannot replace the signature
+	if endpoint.Secret != "" {
+		req.Header.Set(endpoint.SignatureHeader, signWebhookPayload(endpoint.Secret, payload))
+	}
+
 	// Execute request
 	// The httpclient.Do method handles context propagation and timeouts
 	resp, err := w.client.Do(ctx, req)
@@ -2,6 +2,9 @@ package notification
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"io"
 	"net/http"
@@ -9,6 +12,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
 )
 
 func TestNewWebhookProvider(t *testing.T) {
@@ -559,6 +564,113 @@ func TestWebhookProvider_Send(t *testing.T) {
 	})
 }
 
+func TestWebhookProvider_Signing(t *testing.T) {
+	t.Run("signature of the body", func(t *testing.T) {
+		var body []byte
+		var signature, custom string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Both endpoints share the server; keep the header each one sets
+			body, _ = io.ReadAll(r.Body)
+			if v := r.Header.Get("X-BirdNET-Signature"); v != "" {
+				signature = v
+			}
+			if v := r.Header.Get("X-Hub-Signature-256"); v != "" {
+				custom = v
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		endpoints := []WebhookEndpoint{
+			{URL: server.URL, Method: "POST", Secret: "s3cret"},
+			{URL: server.URL, Method: "POST", Secret: "other", SignatureHeader: "X-Hub-Signature-256"},
+		}
+		provider, _ := NewWebhookProvider("test", true, endpoints, nil, "")
+		if err := provider.ValidateConfig(); err != nil {
+			t.Fatalf("expected valid config, got %v", err)
+		}
+		provider.broadcast = true
+
+		if err := provider.Send(context.Background(), &Notification{ID: "signed", Type: TypeInfo, Title: "Signed"}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		mac := hmac.New(sha256.New, []byte("s3cret"))
+		mac.Write(body)
+		if expected := "sha256=" + hex.EncodeToString(mac.Sum(nil)); signature != expected {
+			t.Errorf("expected signature %q, got %q", expected, signature)
+		}
+		if custom == "" || custom != signWebhookPayload("other", body) {
+			t.Errorf("expected signature in the configured header, got %q", custom)
+		}
+	})
+
+	t.Run("resolve signing secret", func(t *testing.T) {
+		t.Setenv("TEST_WEBHOOK_SIGNING_SECRET", "from-env")
+		secret, header, err := resolveWebhookSigning(&conf.WebhookSigningConfig{Secret: "${TEST_WEBHOOK_SIGNING_SECRET}"})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if secret != "from-env" || header != defaultSignatureHeader {
+			t.Errorf("expected secret from the environment and the default header, got %q %q", secret, header)
+		}
+
+		secret, header, err = resolveWebhookSigning(&conf.WebhookSigningConfig{})
+		if err != nil || secret != "" || header != "" {
+			t.Errorf("expected signing to be disabled without a secret, got %q %q %v", secret, header, err)
+		}
+	})
+
+	t.Run("invalid signature header", func(t *testing.T) {
+		endpoints := []WebhookEndpoint{
+			{URL: "https://example.com/webhook", Method: "POST", Secret: "s3cret", SignatureHeader: "X-Bad\r\nHeader"},
+		}
+		provider, _ := NewWebhookProvider("test", true, endpoints, nil, "")
+		if err := provider.ValidateConfig(); err == nil {
+			t.Error("expected error for invalid signature header")
+		}
+	})
+}
+
+func TestWebhookProvider_Broadcast(t *testing.T) {
+	var primary, secondary int
+	okServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		primary++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer okServer.Close()
+	failServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secondary++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failServer.Close()
+
+	endpoints := []WebhookEndpoint{
+		{URL: okServer.URL, Method: "POST"},
+		{URL: failServer.URL, Method: "POST"},
+	}
+	provider, _ := NewWebhookProvider("test", true, endpoints, nil, "")
+	_ = provider.ValidateConfig()
+
+	// Failover stops at the first endpoint that succeeds
+	if err := provider.Send(context.Background(), &Notification{ID: "failover", Type: TypeInfo}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if primary != 1 || secondary != 0 {
+		t.Errorf("expected only the first endpoint to be called, got %d and %d calls", primary, secondary)
+	}
+
+	// Broadcast sends to every endpoint and reports the failed ones
+	provider.broadcast = true
+	err := provider.Send(context.Background(), &Notification{ID: "broadcast", Type: TypeInfo})
+	if err == nil || !strings.Contains(err.Error(), "1 of 2 webhook endpoints failed") {
+		t.Errorf("expected the failed endpoint to be reported, got %v", err)
+	}
+	if primary != 2 || secondary != 1 {
+		t.Errorf("expected every endpoint to be called, got %d and %d calls", primary, secondary)
+	}
+}
+
 func TestWebhookProvider_Close(t *testing.T) {
 	endpoints := []WebhookEndpoint{
 		{URL: "https://example.com/webhook", Method: "POST"},
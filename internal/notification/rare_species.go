@@ -0,0 +1,99 @@
+package notification
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/events"
+	"github.com/tphakala/birdnet-go/internal/mediaurl"
+)
+
+// Metadata keys of rare species notifications
+const (
+	// MetadataKeyRarityFrequency is the expected frequency of the species at
+	// the location and week of the detection, 0-1
+	MetadataKeyRarityFrequency = "rarity_frequency"
+	// MetadataKeyRaritySource is the frequency data the rarity was scored
+	// with, "ebird" or "barchart"
+	MetadataKeyRaritySource = "rarity_source"
+)
+
+// RareSpeciesTemplateData is the data of the rare species templates: the
+// detection and the expected frequency of its species
+type RareSpeciesTemplateData struct {
+	*TemplateData
+	Frequency        float64
+	FrequencyPercent string
+}
+
+// NotifyRareSpecies creates a critical notification of a detection of a
+// species that is locally unusual. The frequency is the expected frequency of
+// the species at the location and week of the detection, according to the
+// frequency data of source.
+func NotifyRareSpecies(event events.DetectionEvent, frequency float64, source string) error {
+	if !IsInitialized() {
+		return nil
+	}
+	service := GetService()
+	if service == nil {
+		return nil
+	}
+	_, err := service.CreateRareSpeciesNotification(conf.GetSettings(), event, frequency, source)
+	return err
+}
+
+// CreateRareSpeciesNotification creates the notification of a rare species
+// detection with the built-in templates of the notification language
+func (s *Service) CreateRareSpeciesNotification(settings *conf.Settings, event events.DetectionEvent, frequency float64, source string) (*Notification, error) {
+	var title, message, clipURL, spectrogramURL string
+	if settings != nil {
+		baseURL := BuildBaseURL(settings.Security.Host, settings.WebServer.Port, settings.Security.AutoTLS)
+		data := &RareSpeciesTemplateData{
+			TemplateData: NewTemplateData(event, baseURL, settings.Main.TimeAs24h).
+				WithSignedMediaURLs(mediaurl.FromSettings(settings), baseURL),
+			Frequency:        frequency,
+			FrequencyPercent: fmt.Sprintf("%.1f", frequency*100),
+		}
+		clipURL, spectrogramURL = data.ClipURL, data.SpectrogramURL
+
+		builtin := BuiltinTemplates(TemplateLocale(settings))
+		var err error
+		if title, err = renderTemplate("title", builtin.RareSpeciesTitle, data); err != nil {
+			title = ""
+		}
+		if message, err = renderTemplate("message", builtin.RareSpeciesMessage, data); err != nil {
+			message = ""
+		}
+	}
+	if title == "" {
+		title = fmt.Sprintf("Rare Species: %s", event.GetSpeciesName())
+	}
+	if message == "" {
+		message = fmt.Sprintf("%s (%s) is unusual here this week, expected frequency %.1f%%",
+			event.GetSpeciesName(), event.GetScientificName(), frequency*100)
+	}
+
+	notification := NewNotification(TypeRareSpecies, PriorityCritical, title, message).
+		WithComponent("rare_species").
+		WithMetadata("species", event.GetSpeciesName()).
+		WithMetadata("scientific_name", event.GetScientificName()).
+		WithMetadata("confidence", event.GetConfidence()).
+		WithMetadata("location", event.GetLocation()).
+		WithMetadata(MetadataKeyRarityFrequency, frequency).
+		WithMetadata(MetadataKeyRaritySource, source).
+		WithExpiry(24 * time.Hour)
+	if noteID, ok := event.GetMetadata()["note_id"]; ok {
+		notification.WithMetadata("note_id", noteID)
+	}
+	if clipURL != "" {
+		notification.WithMetadata(MetadataKeyClipURL, clipURL).
+			WithMetadata(MetadataKeySpectrogramURL, spectrogramURL)
+	}
+
+	notification, err := s.publish(notification)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save rare species notification: %w", err)
+	}
+	return notification, nil
+}
@@ -0,0 +1,50 @@
+package notification
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/events"
+)
+
+func TestCreateRareSpeciesNotification(t *testing.T) {
+	t.Parallel()
+
+	service := NewService(DefaultServiceConfig())
+	defer service.Stop()
+
+	event, err := events.NewDetectionEvent("Hawfinch", "Coccothraustes coccothraustes", 0.91, "backyard", false, 0)
+	require.NoError(t, err)
+	event.GetMetadata()["note_id"] = uint(42)
+	event.GetMetadata()["begin_time"] = time.Date(2026, time.January, 10, 8, 15, 0, 0, time.UTC)
+
+	settings := &conf.Settings{}
+	settings.Main.TimeAs24h = true
+	settings.Security.Host = "birdnet.example.com"
+	notif, err := service.CreateRareSpeciesNotification(settings, event, 0.004, "barchart")
+	require.NoError(t, err)
+
+	assert.Equal(t, TypeRareSpecies, notif.Type)
+	assert.Equal(t, PriorityCritical, notif.Priority)
+	assert.Equal(t, "rare_species", notif.Component)
+	assert.Equal(t, "Rare Species: Hawfinch", notif.Title)
+	assert.Contains(t, notif.Message, "expected frequency 0.4%")
+	assert.Contains(t, notif.Message, "91% confidence at 08:15:00")
+	assert.Contains(t, notif.Message, "/ui/detections/42")
+	assert.InDelta(t, 0.004, notif.Metadata[MetadataKeyRarityFrequency], 0.0001)
+	assert.Equal(t, "barchart", notif.Metadata[MetadataKeyRaritySource])
+
+	// The notification is stored as a distinct type
+	stored, err := service.List(&FilterOptions{Types: []Type{TypeRareSpecies}})
+	require.NoError(t, err)
+	assert.Len(t, stored, 1)
+
+	// Built-in templates follow the notification language
+	settings.Notification.Templates.Locale = "de"
+	notif, err = service.CreateRareSpeciesNotification(settings, event, 0, "ebird")
+	require.NoError(t, err)
+	assert.Equal(t, "Seltene Art: Hawfinch", notif.Title)
+}
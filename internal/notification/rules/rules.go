@@ -0,0 +1,239 @@
+// Package rules evaluates the detection notification rules, which decide
+// which detections are notified, with what priority and to which channels.
+package rules
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/suncalc"
+)
+
+// Detection is a detection evaluated against the rules
+type Detection struct {
+	CommonName     string
+	ScientificName string
+	Confidence     float64
+	Time           time.Time
+	NewSpecies     bool
+	NoteID         uint // ID of the stored detection, 0 when it was not stored
+}
+
+// SunTimes provides the sun event times bounding windows such as sunset to
+// sunrise; implemented by suncalc.SunCalc
+type SunTimes interface {
+	GetSunEventTimes(date time.Time) (suncalc.SunEventTimes, error)
+}
+
+// Subscription is a saved search notified of new detections matching it
+type Subscription struct {
+	Name     string
+	Cooldown time.Duration // minimum time between notifications of a species
+}
+
+// Subscriptions finds the saved search subscriptions a stored detection
+// matches; implemented by history.SubscriptionStore
+type Subscriptions interface {
+	// Active reports whether any saved search is subscribed to
+	Active() bool
+	// Matching returns the subscriptions matching a stored detection
+	Matching(ctx context.Context, noteID uint) ([]Subscription, error)
+}
+
+// SubscriptionRulePrefix starts the names of the rules of saved search subscriptions
+const SubscriptionRulePrefix = "saved-search:"
+
+// DefaultRule is the rule used when none are configured: a notification for
+// the first detection of each species
+var DefaultRule = conf.NotificationRule{
+	Name:       "new-species",
+	Enabled:    true,
+	NewSpecies: true,
+	Priority:   "high",
+}
+
+// Engine evaluates notification rules against detections. It is safe for
+// concurrent use.
+type Engine struct {
+	rules []conf.NotificationRule
+	sun   SunTimes
+
+	mu       sync.Mutex
+	notified map[string]time.Time // last notification per rule and species, for cooldowns
+}
+
+// New creates an engine for the enabled rules, or for DefaultRule when no
+// rules are configured. sun may be nil when no rule is bound by sun events.
+func New(rules []conf.NotificationRule, sun SunTimes) *Engine {
+	e := &Engine{sun: sun, notified: make(map[string]time.Time)}
+	for i := range rules {
+		if rules[i].Enabled {
+			e.rules = append(e.rules, rules[i])
+		}
+	}
+	if len(rules) == 0 {
+		e.rules = []conf.NotificationRule{DefaultRule}
+	}
+	return e
+}
+
+// Evaluate returns the first rule matching the detection. A matching rule
+// still in its cooldown for the species is skipped. The returned rule starts
+// its cooldown for the species.
+func (e *Engine) Evaluate(d *Detection) (*conf.NotificationRule, bool) {
+	for i := range e.rules {
+		r := &e.rules[i]
+		if !e.matches(r, d) {
+			continue
+		}
+		if r.Cooldown > 0 && !e.startCooldown(strconv.Itoa(i), r.Cooldown, d) {
+			continue
+		}
+		return r, true
+	}
+	return nil, false
+}
+
+// EvaluateSubscriptions returns a rule notifying the first saved search
+// subscription matching a stored detection, for detections no configured
+// rule matched. Like rules, a subscription in its cooldown for the species
+// is skipped. The rule is named after the saved search.
+func (e *Engine) EvaluateSubscriptions(ctx context.Context, subscriptions Subscriptions, d *Detection) (*conf.NotificationRule, bool, error) {
+	if subscriptions == nil || d.NoteID == 0 || !subscriptions.Active() {
+		return nil, false, nil
+	}
+	matching, err := subscriptions.Matching(ctx, d.NoteID)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, s := range matching {
+		name := SubscriptionRulePrefix + s.Name
+		if s.Cooldown > 0 && !e.startCooldown(name, s.Cooldown, d) {
+			continue
+		}
+		return &conf.NotificationRule{
+			Name:     name,
+			Enabled:  true,
+			Priority: DefaultRule.Priority,
+			Cooldown: s.Cooldown,
+		}, true, nil
+	}
+	return nil, false, nil
+}
+
+// matches reports whether the detection meets all conditions of the rule
+func (e *Engine) matches(r *conf.NotificationRule, d *Detection) bool {
+	if r.NewSpecies && !d.NewSpecies {
+		return false
+	}
+	if d.Confidence < r.MinConfidence {
+		return false
+	}
+	if len(r.Species) > 0 && !matchesSpecies(r.Species, d) {
+		return false
+	}
+	if r.From != "" && r.Until != "" {
+		in, err := e.inWindow(r.From, r.Until, d.Time)
+		if err != nil || !in {
+			return false
+		}
+	}
+	return true
+}
+
+// startCooldown records a notification of the species by a rule, unless the
+// rule notified the species within the cooldown
+func (e *Engine) startCooldown(rule string, cooldown time.Duration, d *Detection) bool {
+	key := rule + "|" + strings.ToLower(d.ScientificName)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if last, ok := e.notified[key]; ok && d.Time.Sub(last) < cooldown {
+		return false
+	}
+	e.notified[key] = d.Time
+
+	// Forget cooldowns that have ended so the map does not grow without bound
+	for k, t := range e.notified {
+		if d.Time.Sub(t) > 24*time.Hour+cooldown {
+			delete(e.notified, k)
+		}
+	}
+	return true
+}
+
+// matchesSpecies reports whether the common or scientific name of the
+// detection matches a name or pattern of the list, ignoring case
+func matchesSpecies(species []string, d *Detection) bool {
+	common, scientific := strings.ToLower(d.CommonName), strings.ToLower(d.ScientificName)
+	for _, s := range species {
+		pattern := strings.ToLower(strings.TrimSpace(s))
+		if pattern == common || pattern == scientific {
+			return true
+		}
+		if ok, _ := path.Match(pattern, common); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, scientific); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// inWindow reports whether t falls in the time of day window from from until
+// until. A window ending before it starts spans midnight.
+func (e *Engine) inWindow(from, until string, t time.Time) (bool, error) {
+	start, err := e.timeOfDay(from, t)
+	if err != nil {
+		return false, err
+	}
+	end, err := e.timeOfDay(until, t)
+	if err != nil {
+		return false, err
+	}
+	now := t.Sub(midnight(t))
+	if start <= end {
+		return now >= start && now < end, nil
+	}
+	return now >= start || now < end, nil
+}
+
+// timeOfDay resolves a window bound to the time since midnight on the day of t
+func (e *Engine) timeOfDay(bound string, t time.Time) (time.Duration, error) {
+	bound = strings.ToLower(strings.TrimSpace(bound))
+	switch bound {
+	case "sunrise", "sunset", "dawn", "dusk":
+		if e.sun == nil {
+			return 0, fmt.Errorf("%s needs the station location", bound)
+		}
+		times, err := e.sun.GetSunEventTimes(t)
+		if err != nil {
+			return 0, err
+		}
+		event := map[string]time.Time{
+			"sunrise": times.Sunrise,
+			"sunset":  times.Sunset,
+			"dawn":    times.CivilDawn,
+			"dusk":    times.CivilDusk,
+		}[bound].In(t.Location())
+		return event.Sub(midnight(event)), nil
+	default:
+		hm, err := time.Parse("15:04", bound)
+		if err != nil {
+			return 0, fmt.Errorf("invalid time %q: %w", bound, err)
+		}
+		return time.Duration(hm.Hour())*time.Hour + time.Duration(hm.Minute())*time.Minute, nil
+	}
+}
+
+// midnight returns the start of the day of t
+func midnight(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,143 @@
+package rules
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/suncalc"
+)
+
+// fixedSun returns the same sun event times for every date
+type fixedSun struct{ sunrise, sunset time.Duration }
+
+func (s fixedSun) GetSunEventTimes(date time.Time) (suncalc.SunEventTimes, error) {
+	day := midnight(date)
+	return suncalc.SunEventTimes{
+		CivilDawn: day.Add(s.sunrise - 30*time.Minute),
+		Sunrise:   day.Add(s.sunrise),
+		Sunset:    day.Add(s.sunset),
+		CivilDusk: day.Add(s.sunset + 30*time.Minute),
+	}, nil
+}
+
+func at(hour, minute int) time.Time {
+	return time.Date(2026, 5, 12, hour, minute, 0, 0, time.UTC)
+}
+
+func TestEngineDefaultRule(t *testing.T) {
+	t.Parallel()
+
+	e := New(nil, nil)
+	rule, ok := e.Evaluate(&Detection{CommonName: "Eurasian Wryneck", NewSpecies: true, Time: at(9, 0)})
+	require.True(t, ok)
+	assert.Equal(t, "new-species", rule.Name)
+
+	_, ok = e.Evaluate(&Detection{CommonName: "Great Tit", Confidence: 0.99, Time: at(9, 0)})
+	assert.False(t, ok, "known species are not notified by default")
+}
+
+func TestEngineOwlsAtNight(t *testing.T) {
+	t.Parallel()
+
+	e := New([]conf.NotificationRule{
+		{Name: "disabled", Species: []string{"*"}},
+		{Name: "owls", Enabled: true, Species: []string{"*owl", "Strix *"}, MinConfidence: 0.7, From: "sunset", Until: "sunrise", Channels: []string{"telegram"}},
+		{Name: "new", Enabled: true, NewSpecies: true},
+	}, fixedSun{sunrise: 5 * time.Hour, sunset: 21 * time.Hour})
+
+	tests := []struct {
+		name string
+		d    Detection
+		want string
+	}{
+		{"owl after midnight", Detection{CommonName: "Tawny Owl", ScientificName: "Strix aluco", Confidence: 0.8, Time: at(2, 30)}, "owls"},
+		{"owl in the evening", Detection{CommonName: "Eurasian Eagle-Owl", ScientificName: "Bubo bubo", Confidence: 0.9, Time: at(22, 0)}, "owls"},
+		{"owl by scientific name", Detection{CommonName: "Ural", ScientificName: "Strix uralensis", Confidence: 0.9, Time: at(23, 0)}, "owls"},
+		{"owl by day", Detection{CommonName: "Barn Owl", ScientificName: "Tyto alba", Confidence: 0.9, Time: at(12, 0)}, ""},
+		{"low confidence owl", Detection{CommonName: "Long-eared Owl", ScientificName: "Asio otus", Confidence: 0.5, Time: at(1, 0)}, ""},
+		{"new species by day", Detection{CommonName: "Barn Owl", ScientificName: "Tyto alba", Confidence: 0.9, Time: at(12, 0), NewSpecies: true}, "new"},
+		{"other species at night", Detection{CommonName: "European Robin", ScientificName: "Erithacus rubecula", Confidence: 0.9, Time: at(2, 0)}, ""},
+	}
+	for _, tt := range tests {
+		rule, ok := e.Evaluate(&tt.d)
+		if tt.want == "" {
+			assert.False(t, ok, tt.name)
+			continue
+		}
+		require.True(t, ok, tt.name)
+		assert.Equal(t, tt.want, rule.Name, tt.name)
+	}
+}
+
+func TestEngineWindowsAndCooldown(t *testing.T) {
+	t.Parallel()
+
+	e := New([]conf.NotificationRule{
+		{Name: "morning", Enabled: true, From: "06:00", Until: "09:30", Cooldown: time.Hour},
+		{Name: "dusk", Enabled: true, From: "dusk", Until: "23:00"},
+	}, nil)
+
+	wryneck := func(tm time.Time) *Detection {
+		return &Detection{CommonName: "Eurasian Wryneck", ScientificName: "Jynx torquilla", Time: tm}
+	}
+	rule, ok := e.Evaluate(wryneck(at(6, 0)))
+	require.True(t, ok)
+	assert.Equal(t, "morning", rule.Name)
+
+	_, ok = e.Evaluate(wryneck(at(6, 30)))
+	assert.False(t, ok, "the species is in the cooldown of the rule")
+	_, ok = e.Evaluate(&Detection{CommonName: "Great Tit", ScientificName: "Parus major", Time: at(6, 30)})
+	assert.True(t, ok, "the cooldown is per species")
+	_, ok = e.Evaluate(wryneck(at(7, 0)))
+	assert.True(t, ok, "the cooldown has ended")
+	_, ok = e.Evaluate(wryneck(at(9, 30)))
+	assert.False(t, ok, "the window ends at 09:30")
+
+	// Sun bounded windows never match without the station location
+	_, ok = e.Evaluate(wryneck(at(22, 0)))
+	assert.False(t, ok)
+}
+
+// fixedSubscriptions matches the detections with the listed note IDs
+type fixedSubscriptions struct {
+	matches map[uint][]Subscription
+}
+
+func (s fixedSubscriptions) Active() bool { return len(s.matches) > 0 }
+
+func (s fixedSubscriptions) Matching(_ context.Context, noteID uint) ([]Subscription, error) {
+	return s.matches[noteID], nil
+}
+
+func TestEngineSubscriptions(t *testing.T) {
+	t.Parallel()
+
+	e := New(nil, nil)
+	subs := fixedSubscriptions{matches: map[uint][]Subscription{
+		1: {{Name: "owls", Cooldown: time.Hour}},
+		2: {{Name: "owls", Cooldown: time.Hour}},
+	}}
+	owl := func(id uint, tm time.Time) *Detection {
+		return &Detection{CommonName: "Tawny Owl", ScientificName: "Strix aluco", Time: tm, NoteID: id}
+	}
+
+	rule, ok, err := e.EvaluateSubscriptions(context.Background(), subs, owl(1, at(2, 0)))
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, SubscriptionRulePrefix+"owls", rule.Name)
+	assert.Equal(t, "high", rule.Priority)
+
+	_, ok, err = e.EvaluateSubscriptions(context.Background(), subs, owl(2, at(2, 30)))
+	require.NoError(t, err)
+	assert.False(t, ok, "the species is in the cooldown of the subscription")
+	_, ok, _ = e.EvaluateSubscriptions(context.Background(), subs, owl(3, at(4, 0)))
+	assert.False(t, ok, "the detection matches no saved search")
+	_, ok, _ = e.EvaluateSubscriptions(context.Background(), subs, owl(0, at(4, 0)))
+	assert.False(t, ok, "detections that were not stored are not matched")
+	_, ok, _ = e.EvaluateSubscriptions(context.Background(), nil, owl(1, at(4, 0)))
+	assert.False(t, ok)
+}
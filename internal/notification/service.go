@@ -3,6 +3,7 @@ package notification
 import (
 	"context"
 	"fmt"
+	"slices"
 	"sync"
 	"time"
 
@@ -21,9 +22,11 @@ type Subscriber struct {
 // Service manages notifications and provides rate limiting
 type Service struct {
 	store         NotificationStore
+	storeMu       sync.RWMutex // guards replacing the store, see UseStore
 	subscribers   []*Subscriber
 	subscribersMu sync.RWMutex
 	rateLimiter   *RateLimiter
+	dedup         *deduplicator // nil when deduplication is disabled
 	cleanupTicker *time.Ticker
 	ctx           context.Context
 	cancel        context.CancelFunc
@@ -45,14 +48,19 @@ type Service struct {
 type ServiceConfig struct {
 	// Debug enables debug logging for the service
 	Debug bool
-	// MaxNotifications is the maximum number of notifications to keep in memory
+	// MaxNotifications is the maximum number of notifications to keep in memory,
+	// or in the notification history once persistence is enabled
 	MaxNotifications int
-	// CleanupInterval is how often to clean up expired notifications
+	// CleanupInterval is how often to clean up expired notifications, and to
+	// trim the notification history to MaxNotifications
 	CleanupInterval time.Duration
 	// RateLimitWindow is the time window for rate limiting
 	RateLimitWindow time.Duration
 	// RateLimitMaxEvents is the maximum number of events per window
 	RateLimitMaxEvents int
+	// DedupWindow is the window within which repeated notifications are
+	// collapsed into the first one, 0 to disable deduplication
+	DedupWindow time.Duration
 }
 
 // DefaultServiceConfig returns a default configuration
@@ -77,6 +85,7 @@ func NewService(config *ServiceConfig) *Service {
 		store:         NewInMemoryStore(config.MaxNotifications),
 		subscribers:   make([]*Subscriber, 0),
 		rateLimiter:   NewRateLimiter(config.RateLimitWindow, config.RateLimitMaxEvents),
+		dedup:         newDeduplicator(config.DedupWindow),
 		cleanupTicker: time.NewTicker(config.CleanupInterval),
 		ctx:           ctx,
 		cancel:        cancel,
@@ -90,6 +99,7 @@ func NewService(config *ServiceConfig) *Service {
 		"cleanup_interval", config.CleanupInterval,
 		"rate_limit_window", config.RateLimitWindow,
 		"rate_limit_max_events", config.RateLimitMaxEvents,
+		"dedup_window", config.DedupWindow,
 		"debug", config.Debug)
 
 	// Start background cleanup
@@ -115,6 +125,39 @@ func (s *Service) GetTelemetry() *NotificationTelemetry {
 	return s.telemetry
 }
 
+// notificationStore returns the store of the notifications
+func (s *Service) notificationStore() NotificationStore {
+	s.storeMu.RLock()
+	defer s.storeMu.RUnlock()
+	return s.store
+}
+
+// UseStore replaces the store of the notifications, such as with the
+// notification history in the datastore once it is open. Notifications
+// created before, such as at startup, are copied to the new store.
+func (s *Service) UseStore(store NotificationStore) error {
+	s.storeMu.Lock()
+	defer s.storeMu.Unlock()
+
+	pending, err := s.store.List(nil)
+	if err != nil {
+		return err
+	}
+	for _, notif := range pending {
+		if notif.IsExpired() {
+			continue
+		}
+		if err := store.Save(notif); err != nil {
+			return err
+		}
+	}
+	s.store = store
+
+	s.logger.Info("notification store replaced",
+		"copied_notifications", len(pending))
+	return nil
+}
+
 // Create adds a new notification to the system
 func (s *Service) Create(notifType Type, priority Priority, title, message string) (*Notification, error) {
 	// Check rate limit
@@ -142,8 +185,9 @@ func (s *Service) Create(notifType Type, priority Priority, title, message strin
 			"message_length", len(message))
 	}
 
-	// Save to store
-	if err := s.store.Save(notification); err != nil {
+	// Save to store and broadcast to subscribers
+	notification, err := s.publish(notification)
+	if err != nil {
 		return nil, errors.New(err).
 			Component("notification").
 			Category(errors.CategorySystem).
@@ -151,9 +195,6 @@ func (s *Service) Create(notifType Type, priority Priority, title, message strin
 			Build()
 	}
 
-	// Broadcast to subscribers
-	s.broadcast(notification)
-
 	if s.config.Debug {
 		s.logger.Debug("notification created and broadcast",
 			"id", notification.ID,
@@ -176,8 +217,9 @@ func (s *Service) CreateWithComponent(notifType Type, priority Priority, title,
 	notification := NewNotification(notifType, priority, title, message).
 		WithComponent(component)
 
-	// Save to store
-	if err := s.store.Save(notification); err != nil {
+	// Save to store and broadcast to subscribers
+	notification, err := s.publish(notification)
+	if err != nil {
 		return nil, errors.New(err).
 			Component("notification").
 			Category(errors.CategorySystem).
@@ -185,20 +227,17 @@ func (s *Service) CreateWithComponent(notifType Type, priority Priority, title,
 			Build()
 	}
 
-	// Broadcast to subscribers
-	s.broadcast(notification)
-
 	return notification, nil
 }
 
 // Get retrieves a notification by ID
 func (s *Service) Get(id string) (*Notification, error) {
-	return s.store.Get(id)
+	return s.notificationStore().Get(id)
 }
 
 // List returns notifications based on filter options
 func (s *Service) List(filter *FilterOptions) ([]*Notification, error) {
-	return s.store.List(filter)
+	return s.notificationStore().List(filter)
 }
 
 // MarkAsRead updates a notification's status to read
@@ -210,13 +249,13 @@ func (s *Service) MarkAsRead(id string) error {
 			Build()
 	}
 
-	notification, err := s.store.Get(id)
+	notification, err := s.notificationStore().Get(id)
 	if err != nil {
 		return err
 	}
 
 	notification.MarkAsRead()
-	return s.store.Update(notification)
+	return s.notificationStore().Update(notification)
 }
 
 // MarkAsAcknowledged updates a notification's status to acknowledged
@@ -228,13 +267,70 @@ func (s *Service) MarkAsAcknowledged(id string) error {
 			Build()
 	}
 
-	notification, err := s.store.Get(id)
+	notification, err := s.notificationStore().Get(id)
 	if err != nil {
 		return err
 	}
 
 	notification.MarkAsAcknowledged()
-	return s.store.Update(notification)
+	return s.notificationStore().Update(notification)
+}
+
+// SetStatus updates a notification's status and returns the updated
+// notification
+func (s *Service) SetStatus(id string, status Status) (*Notification, error) {
+	if id == "" {
+		return nil, errors.Newf("notification ID cannot be empty").
+			Component("notification").
+			Category(errors.CategoryValidation).
+			Build()
+	}
+	if !slices.Contains(Statuses, status) {
+		return nil, errors.Newf("invalid notification status %q", status).
+			Component("notification").
+			Category(errors.CategoryValidation).
+			Context("status", string(status)).
+			Build()
+	}
+
+	notification, err := s.notificationStore().Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	notification.Status = status
+	if err := s.notificationStore().Update(notification); err != nil {
+		return nil, err
+	}
+	return notification, nil
+}
+
+// MarkAllAsRead marks the unread notifications matching the filter as read
+// and returns how many were updated. The filter's status, limit and offset
+// are ignored.
+func (s *Service) MarkAllAsRead(filter *FilterOptions) (int, error) {
+	unreadFilter := FilterOptions{}
+	if filter != nil {
+		unreadFilter = *filter
+	}
+	unreadFilter.Status = []Status{StatusUnread}
+	unreadFilter.Limit = 0
+	unreadFilter.Offset = 0
+
+	unread, err := s.notificationStore().List(&unreadFilter)
+	if err != nil {
+		return 0, err
+	}
+
+	updated := 0
+	for _, notification := range unread {
+		notification.MarkAsRead()
+		if err := s.notificationStore().Update(notification); err != nil {
+			return updated, err
+		}
+		updated++
+	}
+	return updated, nil
 }
 
 // Delete removes a notification
@@ -246,7 +342,7 @@ func (s *Service) Delete(id string) error {
 			Build()
 	}
 
-	return s.store.Delete(id)
+	return s.notificationStore().Delete(id)
 }
 
 // Subscribe creates a channel to receive real-time notifications.
@@ -323,9 +419,32 @@ func (s *Service) Unsubscribe(ch <-chan *Notification) {
 	}
 }
 
+// Count returns the number of notifications matching the filter, ignoring
+// its limit and offset
+func (s *Service) Count(filter *FilterOptions) (int, error) {
+	return s.notificationStore().Count(filter)
+}
+
 // GetUnreadCount returns the number of unread notifications
 func (s *Service) GetUnreadCount() (int, error) {
-	return s.store.GetUnreadCount()
+	return s.notificationStore().GetUnreadCount()
+}
+
+// GetUnreadCountsByType returns the number of unread notifications of each
+// type
+func (s *Service) GetUnreadCountsByType() (map[Type]int, error) {
+	counts := make(map[Type]int, len(Types))
+	for _, notifType := range Types {
+		count, err := s.notificationStore().Count(&FilterOptions{
+			Types:  []Type{notifType},
+			Status: []Status{StatusUnread},
+		})
+		if err != nil {
+			return nil, err
+		}
+		counts[notifType] = count
+	}
+	return counts, nil
 }
 
 // CreateErrorNotification creates a notification from an error
@@ -431,7 +550,7 @@ func (s *Service) cleanupLoop() {
 			if s.config.Debug {
 				// Count expired notifications before cleanup
 				filter := &FilterOptions{}
-				notifications, _ := s.store.List(filter)
+				notifications, _ := s.notificationStore().List(filter)
 				var expiredCount int
 				for _, n := range notifications {
 					if n.IsExpired() {
@@ -445,7 +564,7 @@ func (s *Service) cleanupLoop() {
 				}
 			}
 			
-			if err := s.store.DeleteExpired(); err != nil {
+			if err := s.notificationStore().DeleteExpired(); err != nil {
 				// Log error but don't stop the cleanup loop
 				if s.logger != nil {
 					s.logger.Error("error cleaning up expired notifications", "error", err)
@@ -453,6 +572,9 @@ func (s *Service) cleanupLoop() {
 			} else if s.config.Debug {
 				s.logger.Debug("notification cleanup completed")
 			}
+			if s.dedup != nil {
+				s.dedup.prune(time.Now())
+			}
 		case <-s.ctx.Done():
 			if s.config.Debug {
 				s.logger.Debug("notification cleanup loop shutting down")
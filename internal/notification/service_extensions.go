@@ -40,8 +40,8 @@ func (s *Service) CreateWithMetadata(notification *Notification) error {
 			"metadata_keys", len(notification.Metadata))
 	}
 
-	// Save to store
-	if err := s.store.Save(notification); err != nil {
+	// Save to store and broadcast to subscribers
+	if _, err := s.publish(notification); err != nil {
 		return errors.New(err).
 			Component("notification").
 			Category(errors.CategorySystem).
@@ -49,9 +49,6 @@ func (s *Service) CreateWithMetadata(notification *Notification) error {
 			Build()
 	}
 
-	// Broadcast to subscribers
-	s.broadcast(notification)
-
 	if s.config.Debug {
 		s.logger.Debug("notification created and broadcast",
 			"id", notification.ID,
@@ -305,6 +305,104 @@ func TestService_CreateWithMetadata_WithFailingStore(t *testing.T) {
 	}
 }
 
+func TestService_UseStore(t *testing.T) {
+	t.Parallel()
+
+	service := createTestService()
+	kept, err := service.Create(TypeInfo, PriorityLow, "Kept", "Copied to the new store")
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	expired := NewNotification(TypeInfo, PriorityLow, "Expired", "Not copied").WithExpiry(-time.Minute)
+	if err := service.notificationStore().Save(expired); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	store := NewInMemoryStore(10)
+	if err := service.UseStore(store); err != nil {
+		t.Fatalf("UseStore() error = %v", err)
+	}
+	if _, err := store.Get(kept.ID); err != nil {
+		t.Errorf("pending notification not copied: %v", err)
+	}
+	if _, err := store.Get(expired.ID); err == nil {
+		t.Error("expired notification should not be copied")
+	}
+
+	// New notifications go to the new store
+	created, err := service.Create(TypeInfo, PriorityLow, "New", "Stored in the new store")
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if _, err := store.Get(created.ID); err != nil {
+		t.Errorf("new notification not in the new store: %v", err)
+	}
+	if count, err := service.Count(nil); err != nil || count != 2 {
+		t.Errorf("Count() = %d, %v, want 2", count, err)
+	}
+}
+
+func TestService_SetStatus(t *testing.T) {
+	t.Parallel()
+
+	service := createTestService()
+	notif, err := service.Create(TypeWarning, PriorityMedium, "Status", "Status changes")
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	for _, status := range []Status{StatusRead, StatusUnread, StatusAcknowledged, StatusDismissed} {
+		updated, err := service.SetStatus(notif.ID, status)
+		if err != nil {
+			t.Fatalf("SetStatus(%s) error = %v", status, err)
+		}
+		if updated.Status != status {
+			t.Errorf("SetStatus(%s) returned status %s", status, updated.Status)
+		}
+	}
+	if count, _ := service.GetUnreadCount(); count != 0 {
+		t.Errorf("GetUnreadCount() = %d, want 0", count)
+	}
+
+	if _, err := service.SetStatus(notif.ID, "archived"); err == nil {
+		t.Error("SetStatus() should reject an unknown status")
+	}
+	if _, err := service.SetStatus("missing", StatusRead); err == nil {
+		t.Error("SetStatus() should fail for a missing notification")
+	}
+}
+
+func TestService_MarkAllAsRead(t *testing.T) {
+	t.Parallel()
+
+	service := createTestService()
+	for _, notifType := range []Type{TypeError, TypeError, TypeInfo} {
+		if _, err := service.Create(notifType, PriorityLow, "Unread", "Marked as read"); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	byType, err := service.GetUnreadCountsByType()
+	if err != nil {
+		t.Fatalf("GetUnreadCountsByType() error = %v", err)
+	}
+	if byType[TypeError] != 2 || byType[TypeInfo] != 1 || byType[TypeDetection] != 0 {
+		t.Errorf("GetUnreadCountsByType() = %v, want 2 errors and 1 info", byType)
+	}
+
+	updated, err := service.MarkAllAsRead(&FilterOptions{Types: []Type{TypeError}, Limit: 1})
+	if err != nil || updated != 2 {
+		t.Fatalf("MarkAllAsRead(errors) = %d, %v, want 2", updated, err)
+	}
+	if count, _ := service.GetUnreadCount(); count != 1 {
+		t.Errorf("GetUnreadCount() = %d, want 1", count)
+	}
+
+	if updated, err := service.MarkAllAsRead(nil); err != nil || updated != 1 {
+		t.Errorf("MarkAllAsRead(nil) = %d, %v, want 1", updated, err)
+	}
+}
+
 // Benchmark for CreateWithMetadata performance
 func BenchmarkService_CreateWithMetadata(b *testing.B) {
 	config := &ServiceConfig{
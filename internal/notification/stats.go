@@ -0,0 +1,196 @@
+package notification
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// Delivery outcomes recorded for push notifications
+const (
+	OutcomeSuccess     = "success"      // delivered, possibly after retries
+	OutcomeError       = "error"        // failed after all retries
+	OutcomeTimeout     = "timeout"      // last attempt timed out
+	OutcomeCircuitOpen = "circuit_open" // not attempted, provider circuit breaker open
+	OutcomeRateLimited = "rate_limited" // not attempted, provider rate limit reached
+)
+
+// deliveryRetention is how long delivery outcomes are kept for statistics
+const deliveryRetention = 31 * 24 * time.Hour
+
+// deliveryKey identifies an hourly delivery outcome counter
+type deliveryKey struct {
+	hour      time.Time
+	provider  string
+	notifType string
+	outcome   string
+}
+
+// deliveryHistory counts push delivery outcomes per hour
+type deliveryHistory struct {
+	mu       sync.Mutex
+	counts   map[deliveryKey]int
+	lastHour time.Time
+}
+
+// deliveries is the delivery history of the push dispatcher
+var deliveries = &deliveryHistory{counts: make(map[deliveryKey]int)}
+
+// record counts a delivery outcome and drops counters past the retention
+func (h *deliveryHistory) record(at time.Time, provider, notifType, outcome string) {
+	hour := at.Truncate(time.Hour)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if hour.After(h.lastHour) {
+		h.lastHour = hour
+		cutoff := hour.Add(-deliveryRetention)
+		maps.DeleteFunc(h.counts, func(k deliveryKey, _ int) bool { return k.hour.Before(cutoff) })
+	}
+	h.counts[deliveryKey{hour: hour, provider: provider, notifType: notifType, outcome: outcome}]++
+}
+
+// since returns a copy of the counters from the hour of since onwards
+func (h *deliveryHistory) since(since time.Time) map[deliveryKey]int {
+	from := since.Truncate(time.Hour)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	counts := make(map[deliveryKey]int)
+	for k, n := range h.counts {
+		if !k.hour.Before(from) {
+			counts[k] = n
+		}
+	}
+	return counts
+}
+
+// deliveryOutcome returns the outcome of a delivery ending with err
+func deliveryOutcome(err error) string {
+	switch {
+	case err == nil:
+		return OutcomeSuccess
+	case errors.Is(err, ErrCircuitBreakerOpen):
+		return OutcomeCircuitOpen
+	case errors.Is(err, context.DeadlineExceeded):
+		return OutcomeTimeout
+	default:
+		return OutcomeError
+	}
+}
+
+// Stats summarizes notifications and their push deliveries over a period
+type Stats struct {
+	Since       time.Time       `json:"since"`
+	Interval    string          `json:"interval"`
+	Total       int             `json:"total"`
+	ByType      map[string]int  `json:"by_type"`
+	ByPriority  map[string]int  `json:"by_priority"`
+	ByComponent map[string]int  `json:"by_component"`
+	Timeline    []StatsBucket   `json:"timeline"`
+	Providers   []ProviderStats `json:"providers"`
+}
+
+// StatsBucket holds the notifications and deliveries of one interval
+type StatsBucket struct {
+	Start      time.Time      `json:"start"`
+	Total      int            `json:"total"`
+	ByType     map[string]int `json:"by_type"`
+	Deliveries map[string]int `json:"deliveries"` // count by outcome
+}
+
+// ProviderStats holds the delivery outcomes of one push provider
+type ProviderStats struct {
+	Provider    string         `json:"provider"`
+	Deliveries  int            `json:"deliveries"`
+	Outcomes    map[string]int `json:"outcomes"`
+	ByType      map[string]int `json:"by_type"`
+	FailureRate float64        `json:"failure_rate"` // share of deliveries that were not successful
+}
+
+// Stats summarizes the notifications created and push deliveries made since
+// a time, with a timeline in buckets of interval. Intervals of a day or longer
+// start at local midnight. Deliveries are kept per hour for 31 days and
+// ephemeral toasts are not counted.
+func (s *Service) Stats(since time.Time, interval time.Duration) (*Stats, error) {
+	notifications, err := s.notificationStore().List(&FilterOptions{Since: &since})
+	if err != nil {
+		return nil, err
+	}
+	return summarizeStats(notifications, deliveries.since(since), since, interval), nil
+}
+
+// summarizeStats builds statistics from notifications and delivery counters
+func summarizeStats(notifications []*Notification, deliveryCounts map[deliveryKey]int, since time.Time, interval time.Duration) *Stats {
+	stats := &Stats{
+		Since:       since,
+		Interval:    interval.String(),
+		ByType:      make(map[string]int),
+		ByPriority:  make(map[string]int),
+		ByComponent: make(map[string]int),
+		Timeline:    []StatsBucket{},
+		Providers:   []ProviderStats{},
+	}
+
+	buckets := make(map[time.Time]*StatsBucket)
+	bucket := func(t time.Time) *StatsBucket {
+		start := statsBucketStart(t, interval)
+		b, ok := buckets[start]
+		if !ok {
+			b = &StatsBucket{Start: start, ByType: make(map[string]int), Deliveries: make(map[string]int)}
+			buckets[start] = b
+		}
+		return b
+	}
+
+	for _, notif := range notifications {
+		if isToastNotification(notif) || notif.Timestamp.Before(since) {
+			continue
+		}
+		stats.Total++
+		stats.ByType[string(notif.Type)]++
+		stats.ByPriority[string(notif.Priority)]++
+		if notif.Component != "" {
+			stats.ByComponent[notif.Component]++
+		}
+		b := bucket(notif.Timestamp)
+		b.Total++
+		b.ByType[string(notif.Type)]++
+	}
+
+	providers := make(map[string]*ProviderStats)
+	for k, n := range deliveryCounts {
+		bucket(k.hour).Deliveries[k.outcome] += n
+
+		p, ok := providers[k.provider]
+		if !ok {
+			p = &ProviderStats{Provider: k.provider, Outcomes: make(map[string]int), ByType: make(map[string]int)}
+			providers[k.provider] = p
+		}
+		p.Deliveries += n
+		p.Outcomes[k.outcome] += n
+		p.ByType[k.notifType] += n
+	}
+
+	for _, start := range slices.SortedFunc(maps.Keys(buckets), time.Time.Compare) {
+		stats.Timeline = append(stats.Timeline, *buckets[start])
+	}
+	for _, name := range slices.Sorted(maps.Keys(providers)) {
+		p := providers[name]
+		p.FailureRate = float64(p.Deliveries-p.Outcomes[OutcomeSuccess]) / float64(p.Deliveries)
+		stats.Providers = append(stats.Providers, *p)
+	}
+	return stats
+}
+
+// statsBucketStart returns the start of the statistics bucket containing t
+func statsBucketStart(t time.Time, interval time.Duration) time.Time {
+	if interval >= 24*time.Hour {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	}
+	return t.Truncate(interval)
+}
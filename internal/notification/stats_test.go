@@ -0,0 +1,80 @@
+package notification
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeliveryHistory(t *testing.T) {
+	t.Parallel()
+
+	h := &deliveryHistory{counts: make(map[deliveryKey]int)}
+	start := time.Date(2024, 5, 1, 12, 10, 0, 0, time.UTC)
+	h.record(start, "telegram", "detection", OutcomeSuccess)
+	h.record(start.Add(20*time.Minute), "telegram", "detection", OutcomeSuccess)
+	h.record(start.Add(time.Hour), "email", "error", OutcomeTimeout)
+
+	counts := h.since(start)
+	assert.Equal(t, 2, counts[deliveryKey{hour: start.Truncate(time.Hour), provider: "telegram", notifType: "detection", outcome: OutcomeSuccess}])
+	assert.Len(t, h.since(start.Add(time.Hour)), 1)
+
+	// Counters past the retention are dropped when a later hour is recorded
+	h.record(start.Add(deliveryRetention+2*time.Hour), "email", "error", OutcomeError)
+	assert.Len(t, h.counts, 1)
+}
+
+func TestDeliveryOutcome(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, OutcomeSuccess, deliveryOutcome(nil))
+	assert.Equal(t, OutcomeCircuitOpen, deliveryOutcome(ErrCircuitBreakerOpen))
+	assert.Equal(t, OutcomeTimeout, deliveryOutcome(context.DeadlineExceeded))
+	assert.Equal(t, OutcomeError, deliveryOutcome(assert.AnError))
+}
+
+func TestSummarizeStats(t *testing.T) {
+	t.Parallel()
+
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local)
+	at := func(hours int, n *Notification) *Notification {
+		n.Timestamp = day.Add(time.Duration(hours) * time.Hour)
+		return n
+	}
+	notifications := []*Notification{
+		at(1, NewNotification(TypeError, PriorityCritical, "Disk", "full").WithComponent("diskmanager")),
+		at(2, NewNotification(TypeDetection, PriorityHigh, "Owl", "new")),
+		at(26, NewNotification(TypeDetection, PriorityHigh, "Jay", "new")),
+		at(27, NewNotification(TypeInfo, PriorityLow, "Toast", "saved").WithMetadata(MetadataKeyIsToast, true)),
+		at(-5, NewNotification(TypeInfo, PriorityLow, "Old", "before period")),
+	}
+	deliveryCounts := map[deliveryKey]int{
+		{hour: day.Add(2 * time.Hour), provider: "telegram", notifType: "detection", outcome: OutcomeSuccess}: 3,
+		{hour: day.Add(26 * time.Hour), provider: "telegram", notifType: "detection", outcome: OutcomeError}:  1,
+		{hour: day.Add(1 * time.Hour), provider: "email", notifType: "error", outcome: OutcomeSuccess}:        1,
+	}
+
+	stats := summarizeStats(notifications, deliveryCounts, day, 24*time.Hour)
+	assert.Equal(t, 3, stats.Total, "toasts and older notifications are not counted")
+	assert.Equal(t, map[string]int{"error": 1, "detection": 2}, stats.ByType)
+	assert.Equal(t, map[string]int{"diskmanager": 1}, stats.ByComponent)
+
+	require.Len(t, stats.Timeline, 2)
+	assert.Equal(t, day, stats.Timeline[0].Start)
+	assert.Equal(t, 2, stats.Timeline[0].Total)
+	assert.Equal(t, map[string]int{OutcomeSuccess: 4}, stats.Timeline[0].Deliveries)
+	assert.Equal(t, map[string]int{OutcomeError: 1}, stats.Timeline[1].Deliveries)
+
+	require.Len(t, stats.Providers, 2)
+	assert.Equal(t, "email", stats.Providers[0].Provider)
+	telegram := stats.Providers[1]
+	assert.Equal(t, 4, telegram.Deliveries)
+	assert.InDelta(t, 0.25, telegram.FailureRate, 1e-9)
+
+	hourly := summarizeStats(notifications, nil, day, time.Hour)
+	assert.Len(t, hourly.Timeline, 3)
+	assert.Empty(t, hourly.Providers)
+}
@@ -0,0 +1,178 @@
+package notification
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+// DefaultTemplateLocale is the language of the built-in templates used when
+// the catalog has no templates of the configured language
+const DefaultTemplateLocale = "en"
+
+// ChannelUI is the template channel of the notifications shown in the web UI
+const ChannelUI = "ui"
+
+// LocalizedTemplates are the built-in notification templates of one language
+type LocalizedTemplates struct {
+	NewSpeciesTitle    string
+	NewSpeciesMessage  string
+	DetectionTitle     string // detections notified by a rule that is not limited to new species
+	DetectionMessage   string
+	DigestTitle        string
+	DigestMessage      string
+	RareSpeciesTitle   string // executed with RareSpeciesTemplateData
+	RareSpeciesMessage string
+}
+
+// digestMessage returns the daily digest message template with the headings
+// of a language and the word between a clip and its time
+func digestMessage(newSpecies, mostDetected, bestClips, at string) string {
+	return `{{if .NewSpecies}}` + newSpecies + ` {{range $i, $s := .NewSpecies}}{{if $i}}, {{end}}{{$s.CommonName}}{{end}}
+
+{{end}}` + mostDetected + `
+{{range $i, $s := .Species}}{{if lt $i 10}}- {{$s.CommonName}}: {{$s.Count}}
+{{end}}{{end}}{{if .TopClips}}
+` + bestClips + `
+{{range .TopClips}}- {{.CommonName}} {{.ConfidencePercent}}% ` + at + ` {{.Time}} {{or .ClipURL .DetectionURL}}
+{{end}}{{end}}`
+}
+
+// templateCatalog holds the built-in templates by the UI languages
+var templateCatalog = map[string]LocalizedTemplates{
+	"en": {
+		NewSpeciesTitle:    "New Species: {{.CommonName}}",
+		NewSpeciesMessage:  "{{.ImageURL}}\n\nFirst detection of {{.CommonName}} ({{.ScientificName}}) with {{.ConfidencePercent}}% confidence at {{.DetectionTime}}. \n{{.DetectionURL}}",
+		DetectionTitle:     "{{.CommonName}} detected",
+		DetectionMessage:   "{{.CommonName}} ({{.ScientificName}}) detected with {{.ConfidencePercent}}% confidence at {{.DetectionTime}}",
+		DigestTitle:        "{{.Detections}} detections of {{len .Species}} species on {{.Date}}",
+		DigestMessage:      digestMessage("New species:", "Most detected:", "Best clips:", "at"),
+		RareSpeciesTitle:   "Rare Species: {{.CommonName}}",
+		RareSpeciesMessage: "{{.CommonName}} ({{.ScientificName}}) is unusual here this week, expected frequency {{.FrequencyPercent}}%. Detected with {{.ConfidencePercent}}% confidence at {{.DetectionTime}}.\n{{.DetectionURL}}",
+	},
+	"de": {
+		NewSpeciesTitle:    "Neue Art: {{.CommonName}}",
+		NewSpeciesMessage:  "{{.ImageURL}}\n\nErster Nachweis von {{.CommonName}} ({{.ScientificName}}) mit {{.ConfidencePercent}}% Konfidenz um {{.DetectionTime}}. \n{{.DetectionURL}}",
+		DetectionTitle:     "{{.CommonName}} erkannt",
+		DetectionMessage:   "{{.CommonName}} ({{.ScientificName}}) mit {{.ConfidencePercent}}% Konfidenz um {{.DetectionTime}} erkannt",
+		DigestTitle:        "{{.Detections}} Erkennungen von {{len .Species}} Arten am {{.Date}}",
+		DigestMessage:      digestMessage("Neue Arten:", "Am häufigsten erkannt:", "Beste Aufnahmen:", "um"),
+		RareSpeciesTitle:   "Seltene Art: {{.CommonName}}",
+		RareSpeciesMessage: "{{.CommonName}} ({{.ScientificName}}) ist hier in dieser Woche ungewöhnlich, erwartete Häufigkeit {{.FrequencyPercent}}%. Erkannt mit {{.ConfidencePercent}}% Konfidenz um {{.DetectionTime}}.\n{{.DetectionURL}}",
+	},
+	"es": {
+		NewSpeciesTitle:    "Nueva especie: {{.CommonName}}",
+		NewSpeciesMessage:  "{{.ImageURL}}\n\nPrimera detección de {{.CommonName}} ({{.ScientificName}}) con {{.ConfidencePercent}}% de confianza a las {{.DetectionTime}}. \n{{.DetectionURL}}",
+		DetectionTitle:     "Detección: {{.CommonName}}",
+		DetectionMessage:   "Detección de {{.CommonName}} ({{.ScientificName}}) con {{.ConfidencePercent}}% de confianza a las {{.DetectionTime}}",
+		DigestTitle:        "{{.Detections}} detecciones de {{len .Species}} especies el {{.Date}}",
+		DigestMessage:      digestMessage("Nuevas especies:", "Más detectadas:", "Mejores grabaciones:", "a las"),
+		RareSpeciesTitle:   "Especie rara: {{.CommonName}}",
+		RareSpeciesMessage: "{{.CommonName}} ({{.ScientificName}}) es inusual aquí esta semana, frecuencia esperada {{.FrequencyPercent}}%. Detección con {{.ConfidencePercent}}% de confianza a las {{.DetectionTime}}.\n{{.DetectionURL}}",
+	},
+	"fi": {
+		NewSpeciesTitle:    "Uusi laji: {{.CommonName}}",
+		NewSpeciesMessage:  "{{.ImageURL}}\n\nEnsimmäinen havainto: {{.CommonName}} ({{.ScientificName}}), varmuus {{.ConfidencePercent}}%, klo {{.DetectionTime}}. \n{{.DetectionURL}}",
+		DetectionTitle:     "Havaittu: {{.CommonName}}",
+		DetectionMessage:   "Havaittu {{.CommonName}} ({{.ScientificName}}), varmuus {{.ConfidencePercent}}%, klo {{.DetectionTime}}",
+		DigestTitle:        "{{.Detections}} havaintoa, {{len .Species}} lajia {{.Date}}",
+		DigestMessage:      digestMessage("Uudet lajit:", "Eniten havaitut:", "Parhaat äänitteet:", "klo"),
+		RareSpeciesTitle:   "Harvinainen laji: {{.CommonName}}",
+		RareSpeciesMessage: "{{.CommonName}} ({{.ScientificName}}) on täällä harvinainen tällä viikolla, odotettu yleisyys {{.FrequencyPercent}}%. Havaittu, varmuus {{.ConfidencePercent}}%, klo {{.DetectionTime}}.\n{{.DetectionURL}}",
+	},
+	"fr": {
+		NewSpeciesTitle:    "Nouvelle espèce : {{.CommonName}}",
+		NewSpeciesMessage:  "{{.ImageURL}}\n\nPremière détection de {{.CommonName}} ({{.ScientificName}}) avec {{.ConfidencePercent}}% de confiance à {{.DetectionTime}}. \n{{.DetectionURL}}",
+		DetectionTitle:     "Détection : {{.CommonName}}",
+		DetectionMessage:   "Détection de {{.CommonName}} ({{.ScientificName}}) avec {{.ConfidencePercent}}% de confiance à {{.DetectionTime}}",
+		DigestTitle:        "{{.Detections}} détections de {{len .Species}} espèces le {{.Date}}",
+		DigestMessage:      digestMessage("Nouvelles espèces :", "Les plus détectées :", "Meilleurs enregistrements :", "à"),
+		RareSpeciesTitle:   "Espèce rare : {{.CommonName}}",
+		RareSpeciesMessage: "{{.CommonName}} ({{.ScientificName}}) est inhabituel ici cette semaine, fréquence attendue {{.FrequencyPercent}}%. Détection avec {{.ConfidencePercent}}% de confiance à {{.DetectionTime}}.\n{{.DetectionURL}}",
+	},
+	"pt": {
+		NewSpeciesTitle:    "Nova espécie: {{.CommonName}}",
+		NewSpeciesMessage:  "{{.ImageURL}}\n\nPrimeira detecção de {{.CommonName}} ({{.ScientificName}}) com {{.ConfidencePercent}}% de confiança às {{.DetectionTime}}. \n{{.DetectionURL}}",
+		DetectionTitle:     "Detecção: {{.CommonName}}",
+		DetectionMessage:   "Detecção de {{.CommonName}} ({{.ScientificName}}) com {{.ConfidencePercent}}% de confiança às {{.DetectionTime}}",
+		DigestTitle:        "{{.Detections}} detecções de {{len .Species}} espécies em {{.Date}}",
+		DigestMessage:      digestMessage("Novas espécies:", "Mais detectadas:", "Melhores gravações:", "às"),
+		RareSpeciesTitle:   "Espécie rara: {{.CommonName}}",
+		RareSpeciesMessage: "{{.CommonName}} ({{.ScientificName}}) é incomum aqui nesta semana, frequência esperada {{.FrequencyPercent}}%. Detecção com {{.ConfidencePercent}}% de confiança às {{.DetectionTime}}.\n{{.DetectionURL}}",
+	},
+}
+
+// BuiltinTemplates returns the built-in templates of a locale such as "de"
+// or "pt-BR", falling back to its language and then to English
+func BuiltinTemplates(locale string) LocalizedTemplates {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	if templates, ok := templateCatalog[locale]; ok {
+		return templates
+	}
+	if language, _, found := strings.Cut(strings.ReplaceAll(locale, "_", "-"), "-"); found {
+		if templates, ok := templateCatalog[language]; ok {
+			return templates
+		}
+	}
+	return templateCatalog[DefaultTemplateLocale]
+}
+
+// TemplateLocale returns the language notifications are rendered in: the
+// configured template locale, or the UI language when it is empty
+func TemplateLocale(settings *conf.Settings) string {
+	if settings.Notification.Templates.Locale != "" {
+		return settings.Notification.Templates.Locale
+	}
+	if settings.Realtime.Dashboard.Locale != "" {
+		return settings.Realtime.Dashboard.Locale
+	}
+	return DefaultTemplateLocale
+}
+
+// exampleNewSpeciesMessage is the English new species message of the config
+// file written on first run, treated as a default like the built-in one
+const exampleNewSpeciesMessage = "First detection of {{.CommonName}} ({{.ScientificName}}) with {{.ConfidencePercent}}% confidence at {{.DetectionTime}}. View: {{.DetectionURL}}"
+
+// localize returns the built-in template of the notification language in
+// place of a configured template left at an English default, so the
+// defaults follow the UI language while customized templates are kept
+func localize(configured, localized string, english ...string) string {
+	if slices.Contains(english, configured) {
+		return localized
+	}
+	return configured
+}
+
+// NewSpeciesTemplates returns the title and message templates of new species
+// notifications for a channel: the channel overrides, then the shared
+// templates, localized while left at their defaults
+func NewSpeciesTemplates(settings *conf.Settings, channel string) (title, message string) {
+	english := templateCatalog[DefaultTemplateLocale]
+	builtin := BuiltinTemplates(TemplateLocale(settings))
+	shared := settings.Notification.Templates.NewSpecies
+	title = localize(shared.Title, builtin.NewSpeciesTitle, english.NewSpeciesTitle)
+	message = localize(shared.Message, builtin.NewSpeciesMessage, english.NewSpeciesMessage, exampleNewSpeciesMessage)
+
+	override := settings.Notification.Templates.Channels[channel].NewSpecies
+	if override.Title != "" {
+		title = override.Title
+	}
+	if override.Message != "" {
+		message = override.Message
+	}
+	return title, message
+}
+
+// DigestTemplates returns the title and message templates of the daily
+// digest for a channel: the channel overrides, then the shared templates,
+// then the built-in ones of the notification language
+func DigestTemplates(settings *conf.Settings, channel string) (title, message string) {
+	builtin := BuiltinTemplates(TemplateLocale(settings))
+	shared := settings.Notification.Templates.DailyDigest
+	override := settings.Notification.Templates.Channels[channel].DailyDigest
+	title = cmp.Or(override.Title, shared.Title, builtin.DigestTitle)
+	message = cmp.Or(override.Message, shared.Message, builtin.DigestMessage)
+	return title, message
+}
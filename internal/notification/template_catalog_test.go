@@ -0,0 +1,66 @@
+package notification
+
+import (
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/notification/templatefuncs"
+)
+
+func TestBuiltinTemplates(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "Neue Art: {{.CommonName}}", BuiltinTemplates("de").NewSpeciesTitle)
+	assert.Equal(t, "Nova espécie: {{.CommonName}}", BuiltinTemplates("pt-BR").NewSpeciesTitle, "falls back to the language")
+	assert.Equal(t, "Nouvelle espèce : {{.CommonName}}", BuiltinTemplates("fr_CA").NewSpeciesTitle)
+	assert.Equal(t, "New Species: {{.CommonName}}", BuiltinTemplates("sv").NewSpeciesTitle, "falls back to English")
+
+	// Every language has all templates, and they parse
+	for locale, templates := range templateCatalog {
+		for name, tmpl := range map[string]string{
+			"new species title":    templates.NewSpeciesTitle,
+			"new species message":  templates.NewSpeciesMessage,
+			"detection title":      templates.DetectionTitle,
+			"detection message":    templates.DetectionMessage,
+			"digest title":         templates.DigestTitle,
+			"digest message":       templates.DigestMessage,
+			"rare species title":   templates.RareSpeciesTitle,
+			"rare species message": templates.RareSpeciesMessage,
+		} {
+			assert.NotEmpty(t, tmpl, "%s %s", locale, name)
+			_, err := template.New(name).Funcs(templatefuncs.FuncMap()).Parse(tmpl)
+			assert.NoError(t, err, "%s %s", locale, name)
+		}
+	}
+}
+
+func TestNewSpeciesTemplates(t *testing.T) {
+	t.Parallel()
+
+	english := templateCatalog[DefaultTemplateLocale]
+	settings := &conf.Settings{}
+	settings.Realtime.Dashboard.Locale = "de"
+	settings.Notification.Templates.NewSpecies = conf.NewSpeciesTemplate{
+		Title:   english.NewSpeciesTitle,
+		Message: "{{.CommonName}} at {{.DetectionTime}}",
+	}
+	settings.Notification.Templates.Channels = map[string]conf.ChannelTemplates{
+		"telegram": {NewSpecies: conf.NewSpeciesTemplate{Title: "🐦 {{.CommonName}}"}},
+	}
+
+	// Defaults follow the UI language, customized templates are kept
+	title, message := NewSpeciesTemplates(settings, "")
+	assert.Equal(t, "Neue Art: {{.CommonName}}", title)
+	assert.Equal(t, "{{.CommonName}} at {{.DetectionTime}}", message)
+
+	title, message = NewSpeciesTemplates(settings, "telegram")
+	assert.Equal(t, "🐦 {{.CommonName}}", title)
+	assert.Equal(t, "{{.CommonName}} at {{.DetectionTime}}", message, "empty channel templates use the shared ones")
+
+	// The template locale takes precedence over the UI language
+	settings.Notification.Templates.Locale = "en"
+	title, _ = NewSpeciesTemplates(settings, "")
+	assert.Equal(t, english.NewSpeciesTitle, title)
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/tphakala/birdnet-go/internal/events"
+	"github.com/tphakala/birdnet-go/internal/mediaurl"
 )
 
 type TemplateData struct {
@@ -23,7 +24,12 @@ type TemplateData struct {
 	Location           string
 	DetectionURL       string
 	ImageURL           string
+	ClipURL            string // signed short-lived URL of the audio clip, empty when signing is unavailable
+	SpectrogramURL     string // signed short-lived URL of the spectrogram, empty when signing is unavailable
 	DaysSinceFirstSeen int
+	Metadata           map[string]interface{} // event metadata, for lookups with the meta template function
+
+	noteID string
 }
 
 func NewTemplateData(event events.DetectionEvent, baseURL string, timeAs24h bool) *TemplateData {
@@ -98,7 +104,23 @@ func NewTemplateData(event events.DetectionEvent, baseURL string, timeAs24h bool
 		DetectionURL:       detectionURL,
 		ImageURL:           imageURL,
 		DaysSinceFirstSeen: event.GetDaysSinceFirstSeen(),
+		Metadata:           metadata,
+		noteID:             noteID,
+	}
+}
+
+// WithSignedMediaURLs sets signed URLs of the detection's clip and
+// spectrogram, so that they can be opened from push notifications without
+// logging in until the URLs expire
+func (d *TemplateData) WithSignedMediaURLs(signer *mediaurl.Signer, baseURL string) *TemplateData {
+	if signer == nil || d.noteID == "" {
+		return d
 	}
+	clipPath, _ := signer.Sign(mediaurl.KindAudio, d.noteID, 0)
+	spectrogramPath, _ := signer.Sign(mediaurl.KindSpectrogram, d.noteID, 0)
+	d.ClipURL = baseURL + clipPath
+	d.SpectrogramURL = baseURL + spectrogramPath
+	return d
 }
 
 // BuildBaseURL constructs the base URL for notification links based on host, port, and TLS settings.
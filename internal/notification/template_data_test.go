@@ -2,9 +2,13 @@ package notification
 
 import (
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/mediaurl"
 )
 
 // setEnv is a test helper that sets an environment variable and fails the test if it errors
@@ -323,6 +327,21 @@ func TestNewTemplateData(t *testing.T) {
 	t.Skip("Template data construction is covered by integration tests")
 }
 
+func TestTemplateDataWithSignedMediaURLs(t *testing.T) {
+	t.Parallel()
+
+	signer, err := mediaurl.NewSigner("session-secret", time.Hour)
+	require.NoError(t, err)
+
+	data := (&TemplateData{noteID: "42"}).WithSignedMediaURLs(signer, "https://birds.example.org")
+	assert.True(t, strings.HasPrefix(data.ClipURL, "https://birds.example.org/api/v2/media/signed/audio/42?expires="))
+	assert.True(t, strings.HasPrefix(data.SpectrogramURL, "https://birds.example.org/api/v2/media/signed/spectrogram/42?expires="))
+
+	// Without a detection or a signer no URLs are set
+	assert.Empty(t, (&TemplateData{}).WithSignedMediaURLs(signer, "https://birds.example.org").ClipURL)
+	assert.Empty(t, (&TemplateData{noteID: "42"}).WithSignedMediaURLs(nil, "https://birds.example.org").ClipURL)
+}
+
 // BenchmarkBuildBaseURL measures the performance of URL construction
 func BenchmarkBuildBaseURL(b *testing.B) {
 	scenarios := []struct {
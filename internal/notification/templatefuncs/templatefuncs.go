@@ -0,0 +1,263 @@
+// Package templatefuncs provides the helper functions available in notification
+// templates. It has no dependencies on other internal packages so that both
+// configuration validation and notification rendering can parse templates
+// with the same function set.
+package templatefuncs
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// Function documents a template helper
+type Function struct {
+	Name        string `json:"name"`
+	Signature   string `json:"signature"`
+	Description string `json:"description"`
+	Example     string `json:"example"`
+	Result      string `json:"result"` // output of the example with the test notification data
+}
+
+// functions is the documented function list, in the order shown to users
+var functions = []Function{
+	{
+		Name:        "ifElse",
+		Signature:   "ifElse condition yes no",
+		Description: "Returns yes when condition is true (non-empty, non-zero), otherwise no",
+		Example:     `{{ifElse (gt .Confidence 0.9) "Confirmed" "Possible"}} {{.CommonName}}`,
+		Result:      "Confirmed Test Bird Species",
+	},
+	{
+		Name:        "default",
+		Signature:   "default fallback value",
+		Description: "Returns value, or fallback when value is empty or zero",
+		Example:     `{{default "unknown location" .Location}}`,
+		Result:      "Test Location (Sample Data)",
+	},
+	{
+		Name:        "plural",
+		Signature:   "plural count singular plural",
+		Description: "Returns singular when count is 1, otherwise plural",
+		Example:     `{{.DaysSinceFirstSeen}} {{plural .DaysSinceFirstSeen "day" "days"}}`,
+		Result:      "0 days",
+	},
+	{
+		Name:        "round",
+		Signature:   "round value places",
+		Description: "Rounds a number to the given number of decimal places",
+		Example:     `{{round .Latitude 2}}`,
+		Result:      "42.36",
+	},
+	{
+		Name:        "percent",
+		Signature:   "percent fraction places",
+		Description: "Formats a fraction such as a confidence of 0.0-1.0 as a percentage",
+		Example:     `{{percent .Confidence 1}}`,
+		Result:      "99.0%",
+	},
+	{
+		Name:        "urlJoin",
+		Signature:   "urlJoin base path...",
+		Description: "Appends escaped path elements to a base URL",
+		Example:     `{{urlJoin "https://en.wikipedia.org/wiki" .ScientificName}}`,
+		Result:      "https://en.wikipedia.org/wiki/Testus%20birdicus",
+	},
+	{
+		Name:        "urlQuery",
+		Signature:   "urlQuery base key value...",
+		Description: "Adds escaped query parameters given as key and value pairs to a URL",
+		Example:     `{{urlQuery "https://www.google.com/search" "q" .CommonName}}`,
+		Result:      "https://www.google.com/search?q=Test+Bird+Species",
+	},
+	{
+		Name:        "speciesEmoji",
+		Signature:   "speciesEmoji commonName",
+		Description: "Returns an emoji for the bird family of a species, or a generic bird",
+		Example:     `{{speciesEmoji "Great Horned Owl"}} {{.CommonName}}`,
+		Result:      "🦉 Test Bird Species",
+	},
+	{
+		Name:        "meta",
+		Signature:   "meta metadata key [fallback]",
+		Description: "Looks up a key in a metadata map, returning fallback or an empty string when it is missing",
+		Example:     `{{meta .Metadata "source" "unknown source"}}`,
+		Result:      "unknown source",
+	},
+}
+
+// Functions returns the documentation of the template helpers
+func Functions() []Function {
+	list := make([]Function, len(functions))
+	copy(list, functions)
+	return list
+}
+
+// FuncMap returns the template helpers for use with template.Funcs
+func FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"ifElse":       ifElse,
+		"default":      defaultValue,
+		"plural":       plural,
+		"round":        round,
+		"percent":      percent,
+		"urlJoin":      urlJoin,
+		"urlQuery":     urlQuery,
+		"speciesEmoji": speciesEmoji,
+		"meta":         meta,
+	}
+}
+
+// truthy reports whether a template value is non-empty, following the rules
+// of the template if action
+func truthy(value any) bool {
+	if value == nil {
+		return false
+	}
+	truth, _ := template.IsTrue(value)
+	return truth
+}
+
+func ifElse(condition, yes, no any) any {
+	if truthy(condition) {
+		return yes
+	}
+	return no
+}
+
+func defaultValue(fallback, value any) any {
+	if truthy(value) {
+		return value
+	}
+	return fallback
+}
+
+func plural(count any, singular, pluralForm string) (string, error) {
+	n, err := toFloat(count)
+	if err != nil {
+		return "", err
+	}
+	if n == 1 {
+		return singular, nil
+	}
+	return pluralForm, nil
+}
+
+func round(value any, places int) (float64, error) {
+	n, err := toFloat(value)
+	if err != nil {
+		return 0, err
+	}
+	scale := math.Pow(10, float64(places))
+	return math.Round(n*scale) / scale, nil
+}
+
+func percent(fraction any, places int) (string, error) {
+	n, err := toFloat(fraction)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatFloat(n*100, 'f', max(places, 0), 64) + "%", nil
+}
+
+func urlJoin(base string, elements ...any) (string, error) {
+	parts := make([]string, len(elements))
+	for i, element := range elements {
+		parts[i] = fmt.Sprint(element)
+	}
+	return url.JoinPath(base, parts...)
+}
+
+func urlQuery(base string, pairs ...any) (string, error) {
+	if len(pairs)%2 != 0 {
+		return "", fmt.Errorf("urlQuery needs key and value pairs, got %d arguments", len(pairs))
+	}
+	u, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	for i := 0; i < len(pairs); i += 2 {
+		query.Add(fmt.Sprint(pairs[i]), fmt.Sprint(pairs[i+1]))
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// familyEmojis maps words of common names to the emoji of their family, the
+// first matching word is used
+var familyEmojis = []struct {
+	words []string
+	emoji string
+}{
+	{[]string{"owl"}, "🦉"},
+	{[]string{"duck", "teal", "mallard", "wigeon", "goose", "merganser", "eider", "scoter", "pochard", "scaup"}, "🦆"},
+	{[]string{"swan"}, "🦢"},
+	{[]string{"eagle", "hawk", "falcon", "kestrel", "buzzard", "kite", "harrier", "osprey", "merlin", "vulture", "goshawk", "sparrowhawk"}, "🦅"},
+	{[]string{"dove", "pigeon"}, "🕊️"},
+	{[]string{"parrot", "parakeet", "macaw", "cockatoo", "lorikeet", "budgerigar"}, "🦜"},
+	{[]string{"flamingo"}, "🦩"},
+	{[]string{"penguin"}, "🐧"},
+	{[]string{"turkey"}, "🦃"},
+	{[]string{"chicken", "junglefowl", "pheasant", "grouse", "quail", "partridge", "ptarmigan"}, "🐓"},
+	{[]string{"peacock", "peafowl"}, "🦚"},
+	{[]string{"frog", "toad", "treefrog", "bullfrog", "peeper"}, "🐸"},
+	{[]string{"cricket", "katydid", "grasshopper", "cicada"}, "🦗"},
+	{[]string{"dog", "coyote", "wolf", "fox"}, "🐕"},
+}
+
+func speciesEmoji(commonName string) string {
+	words := strings.FieldsFunc(strings.ToLower(commonName), func(r rune) bool {
+		return r == ' ' || r == '-' || r == '\''
+	})
+	for _, family := range familyEmojis {
+		for _, word := range words {
+			for _, familyWord := range family.words {
+				if word == familyWord || word == familyWord+"s" {
+					return family.emoji
+				}
+			}
+		}
+	}
+	return "🐦"
+}
+
+func meta(metadata any, key string, fallback ...any) (any, error) {
+	if len(fallback) > 1 {
+		return nil, fmt.Errorf("meta takes at most one fallback, got %d", len(fallback))
+	}
+	if metadata != nil {
+		m := reflect.ValueOf(metadata)
+		if m.Kind() == reflect.Map && m.Type().Key().Kind() == reflect.String {
+			value := m.MapIndex(reflect.ValueOf(key).Convert(m.Type().Key()))
+			if value.IsValid() && (value.Kind() != reflect.Interface || !value.IsNil()) {
+				return value.Interface(), nil
+			}
+		}
+	}
+	if len(fallback) == 1 {
+		return fallback[0], nil
+	}
+	return "", nil
+}
+
+// toFloat converts a numeric template value to float64
+func toFloat(value any) (float64, error) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	case reflect.String:
+		return strconv.ParseFloat(strings.TrimSpace(v.String()), 64)
+	default:
+		return 0, fmt.Errorf("expected a number, got %T", value)
+	}
+}
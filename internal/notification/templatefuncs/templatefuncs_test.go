@@ -0,0 +1,90 @@
+package templatefuncs
+
+import (
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// exampleData mirrors the fields of the test new species notification used in
+// the documented examples
+type exampleData struct {
+	CommonName         string
+	ScientificName     string
+	Confidence         float64
+	Location           string
+	Latitude           float64
+	DaysSinceFirstSeen int
+	Metadata           map[string]interface{}
+}
+
+// render renders a template with the helper functions
+func render(t *testing.T, text string, data any) string {
+	t.Helper()
+	tmpl, err := template.New("test").Funcs(FuncMap()).Parse(text)
+	require.NoError(t, err)
+	var out strings.Builder
+	require.NoError(t, tmpl.Execute(&out, data))
+	return out.String()
+}
+
+func TestFunctions_ExamplesRender(t *testing.T) {
+	t.Parallel()
+
+	data := exampleData{
+		CommonName:     "Test Bird Species",
+		ScientificName: "Testus birdicus",
+		Confidence:     0.99,
+		Location:       "Test Location (Sample Data)",
+		Latitude:       42.3601,
+	}
+	funcMap := FuncMap()
+	for _, fn := range Functions() {
+		assert.Contains(t, funcMap, fn.Name, "documented function should exist")
+		assert.Equal(t, fn.Result, render(t, fn.Example, data), "example of %s", fn.Name)
+	}
+	assert.Len(t, Functions(), len(funcMap), "every function should be documented")
+}
+
+func TestFuncMap(t *testing.T) {
+	t.Parallel()
+
+	data := exampleData{
+		CommonName: "Mallard",
+		Metadata:   map[string]interface{}{"count": 3, "source": "garden", "empty": ""},
+	}
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{`{{ifElse .Location "at " "nowhere"}}`, "nowhere"},
+		{`{{default "none" .Metadata.empty}}`, "none"},
+		{`{{plural 1 "bird" "birds"}} {{plural (meta .Metadata "count") "bird" "birds"}}`, "bird birds"},
+		{`{{round 2.345 1}} {{round 7.5 0}}`, "2.3 8"},
+		{`{{percent 0.756 0}}`, "76%"},
+		{`{{urlJoin "https://example.com/api/" "species" "Anas platyrhynchos"}}`, "https://example.com/api/species/Anas%20platyrhynchos"},
+		{`{{urlQuery "https://example.com/?a=1" "q" "a&b"}}`, "https://example.com/?a=1&q=a%26b"},
+		{`{{speciesEmoji .CommonName}} {{speciesEmoji "Barn Owl"}} {{speciesEmoji "American Robin"}}`, "🦆 🦉 🐦"},
+		{`{{meta .Metadata "source"}}|{{meta .Metadata "missing"}}|{{meta .Metadata "missing" "n/a"}}`, "garden||n/a"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, render(t, tt.template, data), tt.template)
+	}
+}
+
+func TestFuncMap_Errors(t *testing.T) {
+	t.Parallel()
+
+	for _, text := range []string{
+		`{{urlQuery "https://example.com" "q"}}`,
+		`{{plural "many" "bird" "birds"}}`,
+		`{{meta .Metadata "a" "b" "c"}}`,
+	} {
+		tmpl, err := template.New("test").Funcs(FuncMap()).Parse(text)
+		require.NoError(t, err)
+		assert.Error(t, tmpl.Execute(&strings.Builder{}, exampleData{}), text)
+	}
+}
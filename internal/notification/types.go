@@ -28,6 +28,8 @@ const (
 	TypeDetection Type = "detection"
 	// TypeSystem indicates a system status notification
 	TypeSystem Type = "system"
+	// TypeRareSpecies indicates a detection of a locally unusual species
+	TypeRareSpecies Type = "rare_species"
 )
 
 // Sentinel errors for notification operations
@@ -59,12 +61,26 @@ const (
 	StatusRead Status = "read"
 	// StatusAcknowledged indicates the user has acted on the notification
 	StatusAcknowledged Status = "acknowledged"
+	// StatusDismissed indicates the user has hidden the notification
+	StatusDismissed Status = "dismissed"
 )
 
+// Statuses lists the valid notification statuses
+var Statuses = []Status{StatusUnread, StatusRead, StatusAcknowledged, StatusDismissed}
+
+// Types lists the notification types
+var Types = []Type{TypeError, TypeWarning, TypeInfo, TypeDetection, TypeSystem, TypeRareSpecies}
+
 // Metadata key constants for common notification metadata fields
 const (
 	// MetadataKeyIsToast identifies toast notifications in metadata
 	MetadataKeyIsToast = "isToast"
+	// MetadataKeyClipURL is the signed URL of a detection's audio clip
+	MetadataKeyClipURL = "clip_url"
+	// MetadataKeySpectrogramURL is the signed URL of a detection's spectrogram
+	MetadataKeySpectrogramURL = "spectrogram_url"
+	// MetadataKeyChannels lists the push providers a notification is sent to, in place of the routes
+	MetadataKeyChannels = "channels"
 )
 
 // isToastNotification checks if a notification is a toast notification
@@ -163,6 +179,8 @@ type NotificationStore interface {
 	Get(id string) (*Notification, error)
 	// List returns notifications with optional filtering
 	List(filter *FilterOptions) ([]*Notification, error)
+	// Count returns the number of notifications matching the filter, ignoring its limit and offset
+	Count(filter *FilterOptions) (int, error)
 	// Update modifies an existing notification
 	Update(notification *Notification) error
 	// Delete removes a notification
@@ -280,6 +298,20 @@ func (s *InMemoryStore) List(filter *FilterOptions) ([]*Notification, error) {
 	return results, nil
 }
 
+// Count returns the number of notifications matching the filter
+func (s *InMemoryStore) Count(filter *FilterOptions) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	count := 0
+	for _, notif := range s.notifications {
+		if s.matchesFilter(notif, filter) {
+			count++
+		}
+	}
+	return count, nil
+}
+
 // Update modifies an existing notification
 func (s *InMemoryStore) Update(notification *Notification) error {
 	s.mu.Lock()
@@ -16,6 +16,8 @@ type ImageProviderMetrics struct {
 	ImageDownloads   prometheus.Counter
 	DownloadErrors   *prometheus.CounterVec
 	DownloadDuration prometheus.Histogram
+	PrefetchSpecies  *prometheus.GaugeVec
+	PrefetchResults  *prometheus.CounterVec
 	registry         *prometheus.Registry
 }
 
@@ -66,6 +68,16 @@ func (m *ImageProviderMetrics) initMetrics() error {
 		Buckets: prometheus.ExponentialBuckets(0.1, 2, 10),
 	})
 
+	m.PrefetchSpecies = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "image_provider_prefetch_species",
+		Help: "Species of the current or last image prefetch run, by state (total, done).",
+	}, []string{"state"})
+
+	m.PrefetchResults = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "image_provider_prefetch_results_total",
+		Help: "Total number of species processed by the image prefetch, by result (hit, fetched, failed).",
+	}, []string{"result"})
+
 	return nil
 }
 
@@ -105,6 +117,17 @@ func (m *ImageProviderMetrics) ObserveDownloadDuration(durationSeconds float64)
 	m.DownloadDuration.Observe(durationSeconds)
 }
 
+// SetPrefetchProgress updates the species done and in total of the image prefetch.
+func (m *ImageProviderMetrics) SetPrefetchProgress(done, total int) {
+	m.PrefetchSpecies.WithLabelValues("done").Set(float64(done))
+	m.PrefetchSpecies.WithLabelValues("total").Set(float64(total))
+}
+
+// IncrementPrefetchResult increases the prefetch result counter of a result by one.
+func (m *ImageProviderMetrics) IncrementPrefetchResult(result string) {
+	m.PrefetchResults.WithLabelValues(result).Inc()
+}
+
 // Collect implements the prometheus.Collector interface.
 func (m *ImageProviderMetrics) Collect(ch chan<- prometheus.Metric) {
 	log.Println("ImageProviderMetrics Collect method called")
@@ -114,6 +137,8 @@ func (m *ImageProviderMetrics) Collect(ch chan<- prometheus.Metric) {
 	m.ImageDownloads.Collect(ch)
 	m.DownloadErrors.Collect(ch)
 	m.DownloadDuration.Collect(ch)
+	m.PrefetchSpecies.Collect(ch)
+	m.PrefetchResults.Collect(ch)
 }
 
 // Describe implements the prometheus.Collector interface.
@@ -124,4 +149,6 @@ func (m *ImageProviderMetrics) Describe(ch chan<- *prometheus.Desc) {
 	m.ImageDownloads.Describe(ch)
 	m.DownloadErrors.Describe(ch)
 	m.DownloadDuration.Describe(ch)
+	m.PrefetchSpecies.Describe(ch)
+	m.PrefetchResults.Describe(ch)
 }
@@ -76,6 +76,13 @@ type MyAudioMetrics struct {
 	birdnetResultsTotal     *prometheus.CounterVec
 	audioQueueOperations    *prometheus.CounterVec
 
+	// Clip writer metrics
+	clipWriterQueueDepth    prometheus.Gauge
+	clipWriterQueueCapacity prometheus.Gauge
+	clipWritesTotal         *prometheus.CounterVec
+	clipWriteDuration       prometheus.Histogram
+	clipWriterQueueWait     prometheus.Histogram
+
 	// collectors is a slice of all collectors for easier iteration
 	collectors []prometheus.Collector
 }
@@ -474,6 +481,44 @@ func (m *MyAudioMetrics) initMetrics() error {
 		[]string{"source", "operation", "status"}, // operation: enqueue, dequeue
 	)
 
+	m.clipWriterQueueDepth = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "myaudio_clip_writer_queue_depth",
+			Help: "Number of audio clips waiting to be encoded and written",
+		},
+	)
+
+	m.clipWriterQueueCapacity = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "myaudio_clip_writer_queue_capacity",
+			Help: "Maximum number of audio clips waiting to be written",
+		},
+	)
+
+	m.clipWritesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "myaudio_clip_writes_total",
+			Help: "Total number of audio clips handled by the clip writer",
+		},
+		[]string{"status"}, // status: written, failed, dropped
+	)
+
+	m.clipWriteDuration = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "myaudio_clip_write_duration_seconds",
+			Help:    "Time taken to encode and write an audio clip",
+			Buckets: prometheus.ExponentialBuckets(0.01, 2, 12), // 10ms to ~20s
+		},
+	)
+
+	m.clipWriterQueueWait = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "myaudio_clip_writer_queue_wait_seconds",
+			Help:    "Time audio clips wait in the clip writer queue",
+			Buckets: prometheus.ExponentialBuckets(0.01, 2, 12), // 10ms to ~20s
+		},
+	)
+
 	// Initialize collectors slice with all metrics
 	m.collectors = []prometheus.Collector{
 		m.bufferAllocationsTotal,
@@ -521,6 +566,11 @@ func (m *MyAudioMetrics) initMetrics() error {
 		m.audioSampleCountTotal,
 		m.birdnetResultsTotal,
 		m.audioQueueOperations,
+		m.clipWriterQueueDepth,
+		m.clipWriterQueueCapacity,
+		m.clipWritesTotal,
+		m.clipWriteDuration,
+		m.clipWriterQueueWait,
 	}
 
 	return nil
@@ -795,3 +845,24 @@ func (m *MyAudioMetrics) RecordBirdNETResults(source string, resultCount int) {
 func (m *MyAudioMetrics) RecordAudioQueueOperation(source, operation, status string) {
 	m.audioQueueOperations.WithLabelValues(source, operation, status).Inc()
 }
+
+// Clip writer recording methods
+
+// UpdateClipWriterQueue updates the depth and capacity of the clip writer queue
+func (m *MyAudioMetrics) UpdateClipWriterQueue(depth, capacity int) {
+	m.clipWriterQueueDepth.Set(float64(depth))
+	m.clipWriterQueueCapacity.Set(float64(capacity))
+}
+
+// RecordClipWrite records a clip written or failed, with the time it waited
+// in the queue and the time taken to write it
+func (m *MyAudioMetrics) RecordClipWrite(status string, wait, duration float64) {
+	m.clipWritesTotal.WithLabelValues(status).Inc()
+	m.clipWriterQueueWait.Observe(wait)
+	m.clipWriteDuration.Observe(duration)
+}
+
+// RecordClipDropped records a clip dropped because the clip writer queue was full
+func (m *MyAudioMetrics) RecordClipDropped() {
+	m.clipWritesTotal.WithLabelValues("dropped").Inc()
+}
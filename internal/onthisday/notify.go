@@ -0,0 +1,86 @@
+// notify.go: Morning notification of the highlights of past years
+package onthisday
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// notifyCheckInterval is how often the notifier checks whether the highlights
+// of the day are due
+const notifyCheckInterval = 10 * time.Minute
+
+// maxMessageHighlights is the most highlights listed in a notification
+const maxMessageHighlights = 5
+
+// Notifier finds the highlights of past years once a day
+type Notifier struct {
+	config   Config
+	store    Store
+	hour     int
+	lastSent string // date of the last highlights (YYYY-MM-DD)
+
+	now func() time.Time // current time, replaceable in tests
+}
+
+// NewNotifier creates a notifier finding the highlights every day from hour on
+func NewNotifier(config Config, store Store, hour int) *Notifier {
+	return &Notifier{config: config, store: store, hour: hour, now: time.Now}
+}
+
+// Run calls onHighlights with the highlights of the day, or the error finding
+// them, once a day until ctx is cancelled
+func (n *Notifier) Run(ctx context.Context, onHighlights func(*OnThisDay, error)) {
+	ticker := time.NewTicker(notifyCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if now := n.now(); n.due(now) {
+			n.lastSent = now.Format(time.DateOnly)
+			onHighlights(Find(ctx, n.config, n.store, now))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// due reports whether the highlights of the day have not been sent yet
+func (n *Notifier) due(now time.Time) bool {
+	return now.Hour() >= n.hour && n.lastSent != now.Format(time.DateOnly)
+}
+
+// Message describes the highlights for a notification, it is empty when
+// there are none
+func Message(result *OnThisDay) string {
+	var parts []string
+	for i := range result.Years {
+		year := &result.Years[i]
+		ago := "1 year ago"
+		if year.YearsAgo > 1 {
+			ago = fmt.Sprintf("%d years ago", year.YearsAgo)
+		}
+		for j := range year.Highlights {
+			h := &year.Highlights[j]
+			if h.Kind == KindFirst {
+				parts = append(parts, fmt.Sprintf("%s the first %s", ago, h.CommonName))
+			} else {
+				parts = append(parts, fmt.Sprintf("%s a rare %s (%d detections ever)", ago, h.CommonName, h.TotalDetections))
+			}
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+
+	message := "On this day " + strings.Join(parts[:min(len(parts), maxMessageHighlights)], ", ")
+	if more := len(parts) - maxMessageHighlights; more > 0 {
+		message += fmt.Sprintf(" and %d more", more)
+	}
+	return message
+}
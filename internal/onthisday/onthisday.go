@@ -0,0 +1,141 @@
+// Package onthisday finds the notable detections of past years on today's
+// date: species detected for the first time ever on that day and rarities,
+// species with only a handful of detections overall.
+package onthisday
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+// Highlight kinds
+const (
+	KindFirst = "first" // first-ever detection of the species
+	KindRare  = "rare"  // species with few detections overall
+)
+
+// Store is the datastore capability needed to find the highlights
+type Store interface {
+	GetSpeciesSummaryData(ctx context.Context, startDate, endDate string) ([]datastore.SpeciesSummaryData, error)
+}
+
+// Config holds the highlight parameters
+type Config struct {
+	Years             int // past years looked at
+	RareMaxDetections int // species with at most this many detections ever are rarities, 0 disables rarities
+}
+
+// ConfigFromSettings returns the highlight parameters of the configuration
+func ConfigFromSettings(settings *conf.OnThisDaySettings) Config {
+	return Config{
+		Years:             settings.Years,
+		RareMaxDetections: settings.RareMaxDetections,
+	}
+}
+
+// Highlight is a notable species detected on the date in a past year
+type Highlight struct {
+	Kind            string  `json:"kind"` // first or rare
+	ScientificName  string  `json:"scientificName"`
+	CommonName      string  `json:"commonName"`
+	SpeciesCode     string  `json:"speciesCode,omitempty"`
+	Count           int     `json:"count"`           // detections on the day
+	TotalDetections int     `json:"totalDetections"` // detections ever
+	MaxConfidence   float64 `json:"maxConfidence"`
+}
+
+// Year summarizes the date in one past year
+type Year struct {
+	Year       int         `json:"year"`
+	Date       string      `json:"date"` // YYYY-MM-DD
+	YearsAgo   int         `json:"yearsAgo"`
+	Species    int         `json:"species"`    // distinct species detected on the day
+	Detections int         `json:"detections"` // detections on the day
+	Highlights []Highlight `json:"highlights"` // first-ever detections first, then rarities
+}
+
+// OnThisDay lists the past years with detections on a date, most recent first
+type OnThisDay struct {
+	Date  string `json:"date"` // YYYY-MM-DD
+	Years []Year `json:"years"`
+}
+
+// Highlights returns the number of highlights of all past years
+func (o *OnThisDay) Highlights() int {
+	total := 0
+	for i := range o.Years {
+		total += len(o.Years[i].Highlights)
+	}
+	return total
+}
+
+// Find returns the detections of past years on the date of now. A 29 February
+// is only looked at in leap years.
+func Find(ctx context.Context, config Config, store Store, now time.Time) (*OnThisDay, error) {
+	result := &OnThisDay{Date: now.Format(time.DateOnly), Years: []Year{}}
+
+	// Detections ever per species, for the first detection date and rarity
+	totals, err := store.GetSpeciesSummaryData(ctx, "", "")
+	if err != nil {
+		return nil, err
+	}
+	ever := make(map[string]datastore.SpeciesSummaryData, len(totals))
+	for i := range totals {
+		ever[totals[i].ScientificName] = totals[i]
+	}
+
+	for yearsAgo := 1; yearsAgo <= max(config.Years, 1); yearsAgo++ {
+		day := time.Date(now.Year()-yearsAgo, now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		if day.Month() != now.Month() {
+			continue // no 29 February this year
+		}
+		date := day.Format(time.DateOnly)
+		summaries, err := store.GetSpeciesSummaryData(ctx, date, date)
+		if err != nil {
+			return nil, err
+		}
+		if len(summaries) == 0 {
+			continue
+		}
+
+		year := Year{Year: day.Year(), Date: date, YearsAgo: yearsAgo, Species: len(summaries), Highlights: []Highlight{}}
+		for i := range summaries {
+			s := &summaries[i]
+			year.Detections += s.Count
+			total := ever[s.ScientificName]
+
+			var kind string
+			switch {
+			case !total.FirstSeen.IsZero() && total.FirstSeen.Format(time.DateOnly) == date:
+				kind = KindFirst
+			case config.RareMaxDetections > 0 && total.Count > 0 && total.Count <= config.RareMaxDetections:
+				kind = KindRare
+			default:
+				continue
+			}
+			year.Highlights = append(year.Highlights, Highlight{
+				Kind:            kind,
+				ScientificName:  s.ScientificName,
+				CommonName:      s.CommonName,
+				SpeciesCode:     s.SpeciesCode,
+				Count:           s.Count,
+				TotalDetections: total.Count,
+				MaxConfidence:   s.MaxConfidence,
+			})
+		}
+		slices.SortFunc(year.Highlights, func(a, b Highlight) int {
+			return cmp.Or(
+				cmp.Compare(a.Kind, b.Kind), // "first" before "rare"
+				cmp.Compare(a.TotalDetections, b.TotalDetections),
+				cmp.Compare(a.CommonName, b.CommonName),
+			)
+		})
+		result.Years = append(result.Years, year)
+	}
+	return result, nil
+}
@@ -0,0 +1,101 @@
+package onthisday
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+// fakeStore returns the summaries of single days, and all detections ever
+// when no dates are given
+type fakeStore struct {
+	ever  []datastore.SpeciesSummaryData
+	byDay map[string][]datastore.SpeciesSummaryData
+	days  []string
+}
+
+func (s *fakeStore) GetSpeciesSummaryData(ctx context.Context, startDate, endDate string) ([]datastore.SpeciesSummaryData, error) {
+	if startDate == "" && endDate == "" {
+		return s.ever, nil
+	}
+	s.days = append(s.days, startDate)
+	return s.byDay[startDate], nil
+}
+
+func TestFind(t *testing.T) {
+	t.Parallel()
+
+	firstSeen := time.Date(2023, 5, 10, 6, 12, 0, 0, time.UTC)
+	store := &fakeStore{
+		ever: []datastore.SpeciesSummaryData{
+			{ScientificName: "Parus major", CommonName: "Great Tit", Count: 500, FirstSeen: time.Date(2020, 3, 1, 7, 0, 0, 0, time.UTC)},
+			{ScientificName: "Upupa epops", CommonName: "Eurasian Hoopoe", Count: 40, FirstSeen: firstSeen},
+			{ScientificName: "Lanius collurio", CommonName: "Red-backed Shrike", Count: 3, FirstSeen: time.Date(2021, 5, 2, 9, 0, 0, 0, time.UTC)},
+		},
+		byDay: map[string][]datastore.SpeciesSummaryData{
+			"2023-05-10": {
+				{ScientificName: "Parus major", CommonName: "Great Tit", Count: 12},
+				{ScientificName: "Upupa epops", CommonName: "Eurasian Hoopoe", Count: 2, MaxConfidence: 0.91},
+			},
+			"2021-05-10": {
+				{ScientificName: "Lanius collurio", CommonName: "Red-backed Shrike", Count: 1},
+				{ScientificName: "Parus major", CommonName: "Great Tit", Count: 8},
+			},
+		},
+	}
+
+	result, err := Find(context.Background(), Config{Years: 4, RareMaxDetections: 5}, store, time.Date(2024, 5, 10, 7, 30, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	assert.Equal(t, "2024-05-10", result.Date)
+	assert.Equal(t, []string{"2023-05-10", "2022-05-10", "2021-05-10", "2020-05-10"}, store.days)
+	require.Len(t, result.Years, 2, "years without detections are left out")
+	assert.Equal(t, 2, result.Highlights())
+
+	recent := result.Years[0]
+	assert.Equal(t, 2023, recent.Year)
+	assert.Equal(t, 1, recent.YearsAgo)
+	assert.Equal(t, 2, recent.Species)
+	assert.Equal(t, 14, recent.Detections)
+	require.Len(t, recent.Highlights, 1)
+	assert.Equal(t, Highlight{
+		Kind: KindFirst, ScientificName: "Upupa epops", CommonName: "Eurasian Hoopoe",
+		Count: 2, TotalDetections: 40, MaxConfidence: 0.91,
+	}, recent.Highlights[0])
+
+	older := result.Years[1]
+	assert.Equal(t, 3, older.YearsAgo)
+	require.Len(t, older.Highlights, 1)
+	assert.Equal(t, KindRare, older.Highlights[0].Kind)
+	assert.Equal(t, "Red-backed Shrike", older.Highlights[0].CommonName)
+
+	assert.Equal(t, "On this day 1 year ago the first Eurasian Hoopoe, 3 years ago a rare Red-backed Shrike (3 detections ever)",
+		Message(result))
+}
+
+func TestFind_LeapDay(t *testing.T) {
+	t.Parallel()
+
+	store := &fakeStore{}
+	result, err := Find(context.Background(), Config{Years: 4}, store, time.Date(2024, 2, 29, 9, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2020-02-29"}, store.days, "29 February only exists in leap years")
+	assert.Empty(t, result.Years)
+	assert.Empty(t, Message(result))
+}
+
+func TestNotifier_Due(t *testing.T) {
+	t.Parallel()
+
+	n := NewNotifier(Config{}, nil, 8)
+	morning := time.Date(2024, 5, 10, 7, 0, 0, 0, time.UTC)
+	assert.False(t, n.due(morning), "before the hour")
+	assert.True(t, n.due(morning.Add(time.Hour)))
+	n.lastSent = morning.Format(time.DateOnly)
+	assert.False(t, n.due(morning.Add(2*time.Hour)), "sent once a day")
+	assert.True(t, n.due(morning.AddDate(0, 0, 1).Add(time.Hour)))
+}
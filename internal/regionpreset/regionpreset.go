@@ -0,0 +1,212 @@
+// Package regionpreset provides default configurations for regions: the
+// language of species names, confidence thresholds of species prone to false
+// detections, sensitive species kept private and the usual quiet hours of
+// notifications.
+//
+// Presets are maintained as JSON files in the presets directory, one per
+// region, embedded at build time. Applying a preset changes only the settings
+// it covers and keeps per-species intervals and actions.
+package regionpreset
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/tphakala/birdnet-go/internal/birdnet"
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+//go:embed presets/*.json
+var presetFiles embed.FS
+
+// Preset is the default configuration of a region
+type Preset struct {
+	ID          string             `json:"id"`
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	Locale      string             `json:"locale"`               // BirdNET locale of species names, such as "en-uk"
+	Thresholds  map[string]float64 `json:"thresholds"`           // confidence thresholds by scientific name
+	Sensitive   []string           `json:"sensitive"`            // scientific names of species not uploaded to BirdWeather
+	QuietHours  *QuietHours        `json:"quietHours,omitempty"` // nil to leave the quiet hours of notifications as they are
+}
+
+// QuietHours is the daily window notifications are held in
+type QuietHours struct {
+	From  string `json:"from"`  // HH:MM local time
+	Until string `json:"until"` // HH:MM local time, before from for windows spanning midnight
+}
+
+// Applied summarizes the settings changed by applying a preset
+type Applied struct {
+	Preset     string   `json:"preset"`
+	Locale     string   `json:"locale,omitempty"`
+	Thresholds []string `json:"thresholds"` // common names in the preset locale with a threshold set
+	Sensitive  []string `json:"sensitive"`  // species added to the sensitive species
+	QuietHours []string `json:"quietHours"` // notification channels with quiet hours set
+}
+
+// clockPattern matches an HH:MM time of day
+var clockPattern = regexp.MustCompile(`^([01][0-9]|2[0-3]):[0-5][0-9]$`)
+
+// presets holds the embedded presets ordered by ID, parsed on first use
+var presets = sync.OnceValues(func() ([]Preset, error) {
+	files, err := fs.Glob(presetFiles, "presets/*.json")
+	if err != nil {
+		return nil, err
+	}
+	all := make([]Preset, 0, len(files))
+	for _, file := range files {
+		data, err := presetFiles.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		preset, err := parsePreset(data)
+		if err != nil {
+			return nil, errors.New(err).
+				Component("regionpreset").
+				Category(errors.CategoryFileParsing).
+				Context("file", file).
+				Build()
+		}
+		all = append(all, preset)
+	}
+	slices.SortFunc(all, func(a, b Preset) int { return strings.Compare(a.ID, b.ID) })
+	return all, nil
+})
+
+// All returns the presets ordered by ID
+func All() ([]Preset, error) {
+	return presets()
+}
+
+// Get returns the preset with an ID, false when there is none
+func Get(id string) (Preset, bool, error) {
+	all, err := presets()
+	if err != nil {
+		return Preset{}, false, err
+	}
+	i := slices.IndexFunc(all, func(p Preset) bool { return p.ID == id })
+	if i < 0 {
+		return Preset{}, false, nil
+	}
+	return all[i], true, nil
+}
+
+// parsePreset decodes a preset, rejecting unknown locales and out of range
+// thresholds and times
+func parsePreset(data []byte) (Preset, error) {
+	var p Preset
+	if err := json.Unmarshal(data, &p); err != nil {
+		return p, err
+	}
+	if p.ID == "" || p.Name == "" {
+		return p, errors.NewStd("preset without an id or name")
+	}
+	if _, ok := conf.LocaleCodes[p.Locale]; p.Locale != "" && !ok {
+		return p, fmt.Errorf("preset %s: unknown locale %q", p.ID, p.Locale)
+	}
+	for species, threshold := range p.Thresholds {
+		if threshold <= 0 || threshold > 1 {
+			return p, fmt.Errorf("preset %s: threshold of %s must be between 0 and 1, got %g", p.ID, species, threshold)
+		}
+	}
+	if q := p.QuietHours; q != nil && (!clockPattern.MatchString(q.From) || !clockPattern.MatchString(q.Until) || q.From == q.Until) {
+		return p, fmt.Errorf("preset %s: quiet hours must be two different HH:MM times, got %q and %q", p.ID, q.From, q.Until)
+	}
+	return p, nil
+}
+
+// Apply changes the settings to the preset. Thresholds are stored under the
+// common names of the preset locale, or of the current locale when the preset
+// has none. Maps and slices are replaced rather than changed in place, so a
+// shallow copy of the settings taken before keeps the previous values.
+func (p *Preset) Apply(settings *conf.Settings) (*Applied, error) {
+	applied := &Applied{Preset: p.ID, Thresholds: []string{}, Sensitive: []string{}, QuietHours: []string{}}
+
+	if p.Locale != "" {
+		settings.BirdNET.Locale = p.Locale
+		applied.Locale = p.Locale
+	}
+
+	if len(p.Thresholds) > 0 {
+		names, err := commonNames(settings.BirdNET.Locale)
+		if err != nil {
+			return nil, err
+		}
+		config := make(map[string]conf.SpeciesConfig, len(settings.Realtime.Species.Config)+len(p.Thresholds))
+		for name, species := range settings.Realtime.Species.Config {
+			config[name] = species
+		}
+		for scientific, threshold := range p.Thresholds {
+			common, ok := names[scientific]
+			if !ok {
+				continue
+			}
+			key := strings.ToLower(common)
+			species := config[key]
+			species.Threshold = threshold
+			config[key] = species
+			applied.Thresholds = append(applied.Thresholds, common)
+		}
+		settings.Realtime.Species.Config = config
+		slices.Sort(applied.Thresholds)
+	}
+
+	sensitive := slices.Clone(settings.Realtime.Species.Sensitive)
+	for _, species := range p.Sensitive {
+		if !settings.Realtime.Species.IsSensitive(species, "") {
+			sensitive = append(sensitive, species)
+			applied.Sensitive = append(applied.Sensitive, species)
+		}
+	}
+	settings.Realtime.Species.Sensitive = sensitive
+
+	if q := p.QuietHours; q != nil {
+		setQuietHours(&settings.Notification.Email.QuietHours, q)
+		applied.QuietHours = append(applied.QuietHours, "email")
+
+		providers := slices.Clone(settings.Notification.Push.Providers)
+		for i := range providers {
+			setQuietHours(&providers[i].QuietHours, q)
+			applied.QuietHours = append(applied.QuietHours, providers[i].Name)
+		}
+		settings.Notification.Push.Providers = providers
+	}
+	return applied, nil
+}
+
+// setQuietHours enables the quiet hours window of a notification channel,
+// keeping its mode, types and priorities
+func setQuietHours(config *conf.QuietHoursConfig, q *QuietHours) {
+	config.Enabled = true
+	config.From = q.From
+	config.Until = q.Until
+}
+
+// commonNames returns the common names of species by scientific name in the
+// BirdNET labels of a locale
+func commonNames(locale string) (map[string]string, error) {
+	data, err := birdnet.GetLabelFileData(birdnet.BirdNET_GLOBAL_6K_V2_4, locale)
+	if err != nil {
+		return nil, errors.New(err).
+			Component("regionpreset").
+			Category(errors.CategoryFileIO).
+			Context("locale", locale).
+			Build()
+	}
+	names := make(map[string]string)
+	for line := range strings.Lines(string(data)) {
+		scientific, common := birdnet.SplitSpeciesName(strings.TrimSpace(line))
+		if scientific != "" && common != "" {
+			names[scientific] = common
+		}
+	}
+	return names, nil
+}
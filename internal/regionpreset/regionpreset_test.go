@@ -0,0 +1,87 @@
+package regionpreset
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+// TestPresetsResolveSpecies checks that the embedded presets parse and name
+// species of the BirdNET labels
+func TestPresetsResolveSpecies(t *testing.T) {
+	t.Parallel()
+
+	presets, err := All()
+	require.NoError(t, err)
+	require.NotEmpty(t, presets)
+
+	for i := range presets {
+		p := &presets[i]
+		names, err := commonNames(p.Locale)
+		require.NoError(t, err, p.ID)
+		for species := range p.Thresholds {
+			assert.Contains(t, names, species, "threshold of preset %s", p.ID)
+		}
+		for _, species := range p.Sensitive {
+			assert.Contains(t, names, species, "sensitive species of preset %s", p.ID)
+		}
+	}
+}
+
+func TestParsePresetErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"no id":          `{"name": "Nowhere"}`,
+		"unknown locale": `{"id": "x", "name": "X", "locale": "xx"}`,
+		"threshold":      `{"id": "x", "name": "X", "thresholds": {"Crex crex": 1.5}}`,
+		"quiet hours":    `{"id": "x", "name": "X", "quietHours": {"from": "22:00", "until": "25:00"}}`,
+	}
+	for name, data := range tests {
+		_, err := parsePreset([]byte(data))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	settings := &conf.Settings{}
+	settings.BirdNET.Locale = "en-us"
+	settings.Realtime.Species.Config = map[string]conf.SpeciesConfig{
+		"rohrdommel": {Threshold: 0.5, Interval: 60},
+		"amsel":      {Threshold: 0.7},
+	}
+	settings.Realtime.Species.Sensitive = []string{"Falco peregrinus"}
+	settings.Notification.Email.QuietHours.Mode = "digest"
+	settings.Notification.Push.Providers = []conf.PushProviderConfig{{Name: "phone"}}
+	before := *settings
+
+	preset, found, err := Get("de")
+	require.NoError(t, err)
+	require.True(t, found)
+	applied, err := preset.Apply(settings)
+	require.NoError(t, err)
+
+	assert.Equal(t, "de", settings.BirdNET.Locale)
+	assert.Contains(t, applied.Thresholds, "Rohrdommel")
+	assert.Equal(t, conf.SpeciesConfig{Threshold: 0.9, Interval: 60}, settings.Realtime.Species.Config["rohrdommel"])
+	assert.Equal(t, 0.7, settings.Realtime.Species.Config["amsel"].Threshold)
+	assert.NotContains(t, applied.Sensitive, "Falco peregrinus")
+	assert.Contains(t, settings.Realtime.Species.Sensitive, "Tetrao urogallus")
+	assert.Equal(t, []string{"email", "phone"}, applied.QuietHours)
+	assert.Equal(t, conf.QuietHoursConfig{Enabled: true, From: "22:00", Until: "06:00", Mode: "digest"}, settings.Notification.Email.QuietHours)
+	assert.True(t, settings.Notification.Push.Providers[0].QuietHours.Enabled)
+
+	// The copy taken before keeps the previous values
+	assert.Equal(t, 0.5, before.Realtime.Species.Config["rohrdommel"].Threshold)
+	assert.Equal(t, []string{"Falco peregrinus"}, before.Realtime.Species.Sensitive)
+	assert.False(t, before.Notification.Push.Providers[0].QuietHours.Enabled)
+
+	// Applying again changes nothing more
+	applied, err = preset.Apply(settings)
+	require.NoError(t, err)
+	assert.Empty(t, applied.Sensitive)
+}
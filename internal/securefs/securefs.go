@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/tphakala/birdnet-go/internal/atrest"
 )
 
 // SecureFS provides filesystem operations with path validation
@@ -610,9 +611,16 @@ func (sfs *SecureFS) serveInternal(c echo.Context, opener func() (*os.File, stri
 		c.Response().Header().Set(echo.HeaderContentType, contentType)
 	}
 
+	// Encrypted files (e.g. audio clips) are decrypted on the fly
+	content, err := atrest.Decrypting(f)
+	if err != nil {
+		log.Printf("SecureFS: Failed to decrypt file %s: %v", effectivePath, err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to read file").SetInternal(err)
+	}
+
 	// Use http.ServeContent which properly handles Range requests, caching, etc.
 	// It uses the validated relative path's base name for the download filename suggestion.
-	http.ServeContent(c.Response(), c.Request(), filepath.Base(effectivePath), stat.ModTime(), f)
+	http.ServeContent(c.Response(), c.Request(), filepath.Base(effectivePath), stat.ModTime(), content)
 	return nil
 }
 
@@ -0,0 +1,268 @@
+package security
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// APIKeyScope is a permission granted to an API key
+type APIKeyScope string
+
+const (
+	// ScopeReadOnly allows reading detections, analytics and status
+	ScopeReadOnly APIKeyScope = "read-only"
+	// ScopeDetectionsWrite allows reading and changing detections, such as reviews and deletion
+	ScopeDetectionsWrite APIKeyScope = "detections:write"
+	// ScopeSettingsAdmin allows everything, including settings and API key management
+	ScopeSettingsAdmin APIKeyScope = "settings:admin"
+	// ScopeOwnData allows changing data of the caller, such as dashboards, saved
+	// searches and notification read state. Every scope includes it, so it is
+	// required by routes but never granted.
+	ScopeOwnData APIKeyScope = "own-data"
+)
+
+// APIKeyPrefix starts every API key, telling them apart from OAuth access tokens
+const APIKeyPrefix = "bngo_"
+
+// APIKeyHeader carries an API key as an alternative to a bearer token
+const APIKeyHeader = "X-API-Key"
+
+// apiKeyDisplayLength is the length of the key shown in listings to recognise it
+const apiKeyDisplayLength = len(APIKeyPrefix) + 6
+
+// Pre-defined errors for API keys
+var (
+	ErrAPIKeyNotFound     = errors.New("API key not found")
+	ErrAPIKeyInvalidScope = errors.New("invalid API key scope")
+)
+
+// ValidAPIKeyScopes returns the scopes an API key can be granted
+func ValidAPIKeyScopes() []APIKeyScope {
+	return []APIKeyScope{ScopeReadOnly, ScopeDetectionsWrite, ScopeSettingsAdmin}
+}
+
+// includes reports whether a granted scope includes the required one:
+// settings:admin includes all scopes, detections:write includes read-only and
+// every scope includes own-data
+func (s APIKeyScope) includes(required APIKeyScope) bool {
+	switch s {
+	case ScopeSettingsAdmin:
+		return true
+	case ScopeDetectionsWrite:
+		return required == ScopeDetectionsWrite || required == ScopeReadOnly || required == ScopeOwnData
+	case ScopeReadOnly:
+		return required == ScopeReadOnly || required == ScopeOwnData
+	default:
+		return false
+	}
+}
+
+// APIKey is a stored API key. Only the SHA-256 hash of the key is kept, the
+// key itself is shown once when created.
+type APIKey struct {
+	ID         string        `json:"id"`
+	Name       string        `json:"name"`
+	Prefix     string        `json:"prefix"` // start of the key, to recognise it
+	Hash       string        `json:"hash"`
+	Scopes     []APIKeyScope `json:"scopes"`
+	CreatedAt  time.Time     `json:"createdAt"`
+	LastUsedAt *time.Time    `json:"lastUsedAt,omitempty"`
+}
+
+// Allows reports whether the key has a scope including the required one
+func (k *APIKey) Allows(required APIKeyScope) bool {
+	return slices.ContainsFunc(k.Scopes, func(scope APIKeyScope) bool { return scope.includes(required) })
+}
+
+// APIKeyStore keeps the API keys, persisted to a JSON file when one is set
+type APIKeyStore struct {
+	mu   sync.RWMutex
+	file string
+	keys map[string]*APIKey // by ID
+}
+
+// NewAPIKeyStore returns a store persisted to file, loading the keys already
+// in it. An empty file keeps the keys in memory only.
+func NewAPIKeyStore(file string) (*APIKeyStore, error) {
+	store := &APIKeyStore{file: file, keys: make(map[string]*APIKey)}
+	if file == "" {
+		return store, nil
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return store, nil
+		}
+		return store, fmt.Errorf("failed to read API key file %s: %w", file, err)
+	}
+	if len(data) == 0 {
+		return store, nil
+	}
+	var keys []*APIKey
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return store, fmt.Errorf("failed to unmarshal API keys from %s: %w", file, err)
+	}
+	for _, key := range keys {
+		store.keys[key.ID] = key
+	}
+	logger().Info("Loaded API keys", "file", file, "count", len(store.keys))
+	return store, nil
+}
+
+// Create generates a new API key with the given scopes. The returned secret
+// is the key itself, it is not stored and cannot be retrieved later.
+func (s *APIKeyStore) Create(name string, scopes []APIKeyScope) (key APIKey, secret string, err error) {
+	if len(scopes) == 0 {
+		return APIKey{}, "", fmt.Errorf("%w: at least one scope is required", ErrAPIKeyInvalidScope)
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(ValidAPIKeyScopes(), scope) {
+			return APIKey{}, "", fmt.Errorf("%w: %q", ErrAPIKeyInvalidScope, scope)
+		}
+	}
+
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return APIKey{}, "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	secret = APIKeyPrefix + base64.RawURLEncoding.EncodeToString(random)
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return APIKey{}, "", fmt.Errorf("failed to generate API key ID: %w", err)
+	}
+
+	stored := &APIKey{
+		ID:        hex.EncodeToString(id),
+		Name:      strings.TrimSpace(name),
+		Prefix:    secret[:apiKeyDisplayLength],
+		Hash:      hashAPIKey(secret),
+		Scopes:    slices.Clone(scopes),
+		CreatedAt: time.Now(),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[stored.ID] = stored
+	if err := s.save(); err != nil {
+		delete(s.keys, stored.ID)
+		return APIKey{}, "", err
+	}
+	logger().Info("API key created", "id", stored.ID, "name", stored.Name, "scopes", stored.Scopes)
+	return stored.copy(), secret, nil
+}
+
+// Revoke deletes an API key, it is rejected from then on
+func (s *APIKeyStore) Revoke(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key, ok := s.keys[id]
+	if !ok {
+		return ErrAPIKeyNotFound
+	}
+	delete(s.keys, id)
+	if err := s.save(); err != nil {
+		s.keys[id] = key
+		return err
+	}
+	logger().Info("API key revoked", "id", id, "name", key.Name)
+	return nil
+}
+
+// List returns the API keys, the oldest first
+func (s *APIKeyStore) List() []APIKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	keys := make([]APIKey, 0, len(s.keys))
+	for _, key := range s.keys {
+		keys = append(keys, key.copy())
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+	return keys
+}
+
+// APIKeyFromRequest returns the API key of a request, from the X-API-Key
+// header or a bearer token with the API key prefix, empty without one
+func APIKeyFromRequest(r *http.Request) string {
+	if key := strings.TrimSpace(r.Header.Get(APIKeyHeader)); key != "" {
+		return key
+	}
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if ok && strings.EqualFold(scheme, "bearer") {
+		if token = strings.TrimSpace(token); strings.HasPrefix(token, APIKeyPrefix) {
+			return token
+		}
+	}
+	return ""
+}
+
+// Authenticate returns the API key matching secret, or ErrAPIKeyNotFound
+func (s *APIKeyStore) Authenticate(secret string) (APIKey, error) {
+	if !strings.HasPrefix(secret, APIKeyPrefix) {
+		return APIKey{}, ErrAPIKeyNotFound
+	}
+	hash := hashAPIKey(secret)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range s.keys {
+		if key.Hash == hash {
+			now := time.Now()
+			key.LastUsedAt = &now // kept in memory, not worth a write on every request
+			return key.copy(), nil
+		}
+	}
+	return APIKey{}, ErrAPIKeyNotFound
+}
+
+// save writes the keys to the store file, the caller holds the lock
+func (s *APIKeyStore) save() error {
+	if s.file == "" {
+		return nil
+	}
+	keys := make([]*APIKey, 0, len(s.keys))
+	for _, key := range s.keys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+
+	data, err := json.MarshalIndent(keys, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal API keys: %w", err)
+	}
+	tempFile := s.file + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write API keys to temp file %s: %w", tempFile, err)
+	}
+	if err := os.Rename(tempFile, s.file); err != nil {
+		_ = os.Remove(tempFile)
+		return fmt.Errorf("failed to rename temp API key file %s to %s: %w", tempFile, s.file, err)
+	}
+	return nil
+}
+
+// copy returns a copy of the key not sharing its scopes
+func (k *APIKey) copy() APIKey {
+	c := *k
+	c.Scopes = slices.Clone(k.Scopes)
+	return c
+}
+
+// hashAPIKey returns the hex SHA-256 of an API key. Keys are 256 random
+// bits, so a plain hash is as strong as a slow password hash here.
+func hashAPIKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,84 @@
+package security
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyStore(t *testing.T) {
+	t.Parallel()
+
+	file := filepath.Join(t.TempDir(), "apikeys.json")
+	store, err := NewAPIKeyStore(file)
+	require.NoError(t, err)
+
+	key, secret, err := store.Create(" Home Assistant ", []APIKeyScope{ScopeReadOnly})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(secret, APIKeyPrefix))
+	assert.Equal(t, "Home Assistant", key.Name)
+	assert.True(t, strings.HasPrefix(secret, key.Prefix))
+
+	// Only the hash of the key is stored
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), secret)
+	info, err := os.Stat(file)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	// The keys survive a restart
+	reloaded, err := NewAPIKeyStore(file)
+	require.NoError(t, err)
+	authenticated, err := reloaded.Authenticate(secret)
+	require.NoError(t, err)
+	assert.Equal(t, key.ID, authenticated.ID)
+	assert.NotNil(t, authenticated.LastUsedAt)
+
+	_, err = reloaded.Authenticate(secret + "x")
+	require.ErrorIs(t, err, ErrAPIKeyNotFound)
+	_, err = reloaded.Authenticate("some-oauth-token")
+	require.ErrorIs(t, err, ErrAPIKeyNotFound)
+
+	require.NoError(t, reloaded.Revoke(key.ID))
+	_, err = reloaded.Authenticate(secret)
+	require.ErrorIs(t, err, ErrAPIKeyNotFound, "revoked keys are rejected")
+	require.ErrorIs(t, reloaded.Revoke(key.ID), ErrAPIKeyNotFound)
+	assert.Empty(t, reloaded.List())
+}
+
+func TestAPIKeyStoreScopes(t *testing.T) {
+	t.Parallel()
+
+	store, err := NewAPIKeyStore("")
+	require.NoError(t, err)
+
+	_, _, err = store.Create("none", nil)
+	require.ErrorIs(t, err, ErrAPIKeyInvalidScope)
+	_, _, err = store.Create("unknown", []APIKeyScope{"detections:delete"})
+	require.ErrorIs(t, err, ErrAPIKeyInvalidScope)
+
+	tests := []struct {
+		granted  APIKeyScope
+		required APIKeyScope
+		allowed  bool
+	}{
+		{ScopeReadOnly, ScopeReadOnly, true},
+		{ScopeReadOnly, ScopeDetectionsWrite, false},
+		{ScopeReadOnly, ScopeSettingsAdmin, false},
+		{ScopeDetectionsWrite, ScopeReadOnly, true},
+		{ScopeDetectionsWrite, ScopeDetectionsWrite, true},
+		{ScopeDetectionsWrite, ScopeSettingsAdmin, false},
+		{ScopeSettingsAdmin, ScopeReadOnly, true},
+		{ScopeSettingsAdmin, ScopeDetectionsWrite, true},
+		{ScopeSettingsAdmin, ScopeSettingsAdmin, true},
+	}
+	for _, tt := range tests {
+		key := APIKey{Scopes: []APIKeyScope{tt.granted}}
+		assert.Equal(t, tt.allowed, key.Allows(tt.required), "%s allows %s", tt.granted, tt.required)
+	}
+}
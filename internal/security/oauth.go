@@ -53,6 +53,9 @@ type OAuth2Server struct {
 	tokensFile    string
 	persistTokens bool
 
+	// API keys with scopes, persisted next to the tokens
+	APIKeys *APIKeyStore
+
 	// Expected Redirect URI for Basic Auth (pre-parsed)
 	ExpectedBasicRedirectURI *url.URL
 
@@ -151,6 +154,7 @@ func NewOAuth2Server() *OAuth2Server {
 	InitializeGoth(settings)
 
 	// Set up token persistence
+	server.APIKeys, _ = NewAPIKeyStore("")
 	configPaths, err := conf.GetDefaultConfigPaths()
 	if err != nil {
 		logger().Warn("Failed to get config paths for token persistence, persistence disabled", "error", err)
@@ -169,6 +173,15 @@ func NewOAuth2Server() *OAuth2Server {
 				// Log as Warn, as failure to load old tokens isn't fatal
 				logger().Warn("Failed to load persisted tokens", "file", server.tokensFile, "error", err)
 			}
+
+			// Load the API keys, an unreadable file is kept rather than overwritten
+			apiKeysFile := filepath.Join(configPaths[0], "apikeys.json")
+			if apiKeys, err := NewAPIKeyStore(apiKeysFile); err != nil {
+				logger().Error("Failed to load API keys, API keys are disabled", "file", apiKeysFile, "error", err)
+				server.APIKeys = nil
+			} else {
+				server.APIKeys = apiKeys
+			}
 		}
 	}
 
@@ -242,7 +255,7 @@ func InitializeGoth(settings *conf.Settings) {
 initProviders:
 	logger().Info("Configuring Goth providers")
 	// Initialize Gothic providers
-	providers := make([]goth.Provider, 0, 2)
+	providers := make([]goth.Provider, 0, 3)
 	if settings.Security.GoogleAuth.Enabled && settings.Security.GoogleAuth.ClientID != "" && settings.Security.GoogleAuth.ClientSecret != "" {
 		logger().Info("Enabling Google Auth provider")
 		googleProvider :=
@@ -266,6 +279,17 @@ initProviders:
 	} else {
 		logger().Info("GitHub Auth provider disabled or not configured")
 	}
+	if settings.Security.OIDCAuth.Enabled && settings.Security.OIDCAuth.Issuer != "" && settings.Security.OIDCAuth.ClientID != "" {
+		logger().Info("Enabling OpenID Connect provider", "issuer", settings.Security.OIDCAuth.Issuer)
+		oidcProvider, err := newOIDCProvider(settings)
+		if err != nil {
+			logger().Error("Failed to initialize OpenID Connect provider", "issuer", settings.Security.OIDCAuth.Issuer, "error", err)
+		} else {
+			providers = append(providers, oidcProvider)
+		}
+	} else {
+		logger().Info("OpenID Connect provider disabled or not configured")
+	}
 
 	if len(providers) > 0 {
 		goth.UseProviders(providers...)
@@ -347,6 +371,16 @@ func (s *OAuth2Server) IsUserAuthenticated(c echo.Context) bool {
 			logger.Warn("GitHub session found, but userId does not match allowed IDs", "allowed_ids", s.Settings.Security.GithubAuth.UserId)
 		}
 	}
+	if s.Settings.Security.OIDCAuth.Enabled {
+		// The role was checked against the allowed users and groups at login
+		if oidcUser, err := gothic.GetFromSession(OIDCUserIDSessionKey, c.Request()); err == nil && oidcUser != "" {
+			if role := SessionRole(c.Request()); role != "" {
+				logger.Info("User authenticated: valid OpenID Connect session found", "role", role)
+				return true
+			}
+			logger.Warn("OpenID Connect session found without a valid role")
+		}
+	}
 
 	logger.Info("User not authenticated")
 	return false
@@ -498,11 +532,12 @@ func (s *OAuth2Server) IsAuthenticationEnabled(ip string) bool {
 		logger.Info("Authentication bypassed: request from allowed subnet")
 		return false // Authentication not required for allowed subnets
 	}
-	if s.Settings.Security.BasicAuth.Enabled || s.Settings.Security.GoogleAuth.Enabled || s.Settings.Security.GithubAuth.Enabled {
+	if s.Settings.Security.BasicAuth.Enabled || s.Settings.Security.GoogleAuth.Enabled || s.Settings.Security.GithubAuth.Enabled || s.Settings.Security.OIDCAuth.Enabled {
 		logger.Info("Authentication required: at least one provider enabled and IP not in allowed subnet",
 			"basic_enabled", s.Settings.Security.BasicAuth.Enabled,
 			"google_enabled", s.Settings.Security.GoogleAuth.Enabled,
 			"github_enabled", s.Settings.Security.GithubAuth.Enabled,
+			"oidc_enabled", s.Settings.Security.OIDCAuth.Enabled,
 		)
 		return true
 	}
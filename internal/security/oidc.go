@@ -0,0 +1,88 @@
+package security
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/providers/openidConnect"
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+// OIDCProviderName is the Goth provider name of OpenID Connect, used in the
+// /auth/oidc login and callback routes
+const OIDCProviderName = "oidc"
+
+// OIDCUserIDSessionKey is the session key of the user ID of an OpenID Connect login
+const OIDCUserIDSessionKey = OIDCProviderName + "_userID"
+
+// oidcDiscoveryPath is where an issuer serves its OpenID Connect configuration
+const oidcDiscoveryPath = "/.well-known/openid-configuration"
+
+// newOIDCProvider creates the OpenID Connect provider from the discovery
+// document of the configured issuer
+func newOIDCProvider(settings *conf.Settings) (goth.Provider, error) {
+	oidc := &settings.Security.OIDCAuth
+	redirectURI := oidc.RedirectURI
+	if redirectURI == "" {
+		redirectURI = fmt.Sprintf("https://%s/auth/%s/callback", settings.Security.Host, OIDCProviderName)
+	}
+	discoveryURL := strings.TrimSuffix(oidc.Issuer, "/") + oidcDiscoveryPath
+
+	provider, err := openidConnect.New(oidc.ClientID, oidc.ClientSecret, redirectURI, discoveryURL, oidc.Scopes...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover OpenID Connect provider at %s: %w", discoveryURL, err)
+	}
+	provider.SetName(OIDCProviderName)
+	return provider, nil
+}
+
+// OIDCRole returns the role of a user logged in with OpenID Connect, false if
+// the user is not allowed in. Listed users and members of the admin groups are
+// admins, members of the editor and viewer groups are editors and viewers.
+func OIDCRole(settings *conf.OIDCProvider, user *goth.User) (string, bool) {
+	if isValidUserId(settings.UserId, user.UserID) || isValidUserId(settings.UserId, user.Email) {
+		return RoleAdmin, true
+	}
+
+	groups := oidcGroups(user.RawData, settings.GroupsClaim)
+	inAny := func(allowed []string) bool {
+		return slices.ContainsFunc(allowed, func(group string) bool {
+			return group != "" && slices.Contains(groups, group)
+		})
+	}
+	switch {
+	case inAny(settings.AdminGroups):
+		return RoleAdmin, true
+	case inAny(settings.EditorGroups):
+		return RoleEditor, true
+	case inAny(settings.ViewerGroups):
+		return RoleViewer, true
+	default:
+		return "", false
+	}
+}
+
+// oidcGroups returns the groups listed in a claim, a list or a single group
+func oidcGroups(claims map[string]any, claim string) []string {
+	if claim == "" {
+		return nil
+	}
+	switch value := claims[claim].(type) {
+	case string:
+		return []string{value}
+	case []string:
+		return value
+	case []any:
+		groups := make([]string, 0, len(value))
+		for _, group := range value {
+			if name, ok := group.(string); ok {
+				groups = append(groups, name)
+			}
+		}
+		return groups
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,134 @@
+package security
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/sessions"
+	"github.com/labstack/echo/v4"
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+func TestOIDCRole(t *testing.T) {
+	t.Parallel()
+
+	settings := &conf.OIDCProvider{
+		UserId:       "owner@example.org",
+		GroupsClaim:  "groups",
+		AdminGroups:  []string{"birdnet-admins"},
+		EditorGroups: []string{"birdnet-editors"},
+		ViewerGroups: []string{"family", "birdnet-viewers"},
+	}
+
+	tests := []struct {
+		name   string
+		user   goth.User
+		role   string
+		denied bool
+	}{
+		{"listed user", goth.User{UserID: "1", Email: "Owner@example.org"}, RoleAdmin, false},
+		{"admin group", goth.User{UserID: "2", RawData: map[string]any{"groups": []any{"users", "birdnet-admins"}}}, RoleAdmin, false},
+		{"admin and viewer groups", goth.User{UserID: "3", RawData: map[string]any{"groups": []any{"family", "birdnet-admins"}}}, RoleAdmin, false},
+		{"editor group", goth.User{UserID: "8", RawData: map[string]any{"groups": []any{"family", "birdnet-editors"}}}, RoleEditor, false},
+		{"viewer group", goth.User{UserID: "4", RawData: map[string]any{"groups": []any{"family"}}}, RoleViewer, false},
+		{"single group claim", goth.User{UserID: "5", RawData: map[string]any{"groups": "birdnet-viewers"}}, RoleViewer, false},
+		{"no mapped group", goth.User{UserID: "6", RawData: map[string]any{"groups": []any{"users"}}}, "", true},
+		{"no groups", goth.User{UserID: "7", Email: "guest@example.org"}, "", true},
+	}
+	for _, tt := range tests {
+		role, ok := OIDCRole(settings, &tt.user)
+		assert.Equal(t, tt.role, role, tt.name)
+		assert.Equal(t, !tt.denied, ok, tt.name)
+	}
+
+	// Without a groups claim only listed users are allowed in
+	settings.GroupsClaim = ""
+	_, ok := OIDCRole(settings, &goth.User{RawData: map[string]any{"groups": []any{"birdnet-admins"}}})
+	assert.False(t, ok)
+}
+
+func TestNewOIDCProvider(t *testing.T) {
+	t.Parallel()
+
+	var issuer string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != oidcDiscoveryPath {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 issuer,
+			"authorization_endpoint": issuer + "/authorize",
+			"token_endpoint":         issuer + "/token",
+			"userinfo_endpoint":      issuer + "/userinfo",
+		})
+	}))
+	t.Cleanup(server.Close)
+	issuer = server.URL
+
+	settings := &conf.Settings{Security: conf.Security{
+		Host: "birdnet.example.org",
+		OIDCAuth: conf.OIDCProvider{
+			Enabled:  true,
+			Issuer:   issuer + "/",
+			ClientID: "birdnet",
+			Scopes:   []string{"profile", "groups"},
+		},
+	}}
+	provider, err := newOIDCProvider(settings)
+	require.NoError(t, err)
+	assert.Equal(t, OIDCProviderName, provider.Name())
+
+	session, err := provider.BeginAuth("state")
+	require.NoError(t, err)
+	authURL, err := session.GetAuthURL()
+	require.NoError(t, err)
+	assert.Contains(t, authURL, issuer+"/authorize")
+	assert.Contains(t, authURL, "redirect_uri=https%3A%2F%2Fbirdnet.example.org%2Fauth%2Foidc%2Fcallback")
+	assert.Contains(t, authURL, "openid")
+
+	settings.Security.OIDCAuth.Issuer = issuer + "/missing"
+	_, err = newOIDCProvider(settings)
+	require.Error(t, err)
+}
+
+func TestIsUserAuthenticatedOIDCSession(t *testing.T) {
+	gothic.Store = sessions.NewFilesystemStore(t.TempDir(), []byte("test-secret"))
+
+	s := NewOAuth2Server()
+	s.Settings = &conf.Settings{Security: conf.Security{OIDCAuth: conf.OIDCProvider{Enabled: true}}}
+
+	login := func(values map[string]string) echo.Context {
+		// Each value is stored by a request carrying the session cookie, as in the login callback
+		var cookie *http.Cookie
+		for key, value := range values {
+			req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+			if cookie != nil {
+				req.AddCookie(cookie)
+			}
+			rec := httptest.NewRecorder()
+			require.NoError(t, gothic.StoreInSession(key, value, req, rec))
+			cookie = rec.Result().Cookies()[0]
+		}
+		req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+		req.RemoteAddr = "203.0.113.10:1234"
+		req.AddCookie(cookie)
+		return echo.New().NewContext(req, httptest.NewRecorder())
+	}
+
+	viewer := login(map[string]string{OIDCUserIDSessionKey: "user-1", RoleSessionKey: RoleViewer})
+	assert.True(t, s.IsUserAuthenticated(viewer))
+	assert.Equal(t, RoleViewer, SessionRole(viewer.Request()))
+
+	assert.False(t, s.IsUserAuthenticated(login(map[string]string{OIDCUserIDSessionKey: "user-1"})), "a session without a role is not logged in")
+	assert.False(t, s.IsUserAuthenticated(login(map[string]string{OIDCUserIDSessionKey: "user-1", RoleSessionKey: "root"})))
+
+	s.Settings.Security.OIDCAuth.Enabled = false
+	assert.False(t, s.IsUserAuthenticated(viewer), "sessions end when OpenID Connect is disabled")
+}
@@ -0,0 +1,58 @@
+package security
+
+import (
+	"net/http"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothic"
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+// Roles of users logged in with a social or OpenID Connect provider
+const (
+	RoleAdmin  = "admin"  // full access
+	RoleEditor = "editor" // may change detections, such as reviews and deletion, but not settings
+	RoleViewer = "viewer" // read-only access
+)
+
+// RoleSessionKey is the session key of the role of a social or OpenID Connect login
+const RoleSessionKey = "role"
+
+// roleScopes gives each role the permissions of an API key scope
+var roleScopes = map[string]APIKeyScope{
+	RoleAdmin:  ScopeSettingsAdmin,
+	RoleEditor: ScopeDetectionsWrite,
+	RoleViewer: ScopeReadOnly,
+}
+
+// UserRole returns the role of a user granted a role by the provider, limited
+// to editor or viewer when listed in the role settings
+func UserRole(roles *conf.RoleSettings, user *goth.User, granted string) string {
+	listed := func(ids string) bool {
+		return isValidUserId(ids, user.UserID) || isValidUserId(ids, user.Email)
+	}
+	switch {
+	case granted == RoleViewer || listed(roles.Viewers):
+		return RoleViewer
+	case granted == RoleEditor || listed(roles.Editors):
+		return RoleEditor
+	default:
+		return RoleAdmin
+	}
+}
+
+// RoleAllows reports whether a role has the permissions of a scope
+func RoleAllows(role string, required APIKeyScope) bool {
+	scope, ok := roleScopes[role]
+	return ok && scope.includes(required)
+}
+
+// SessionRole returns the role stored in the session of a request by a social
+// or OpenID Connect login, empty for other sessions
+func SessionRole(r *http.Request) string {
+	role, err := gothic.GetFromSession(RoleSessionKey, r)
+	if _, ok := roleScopes[role]; err != nil || !ok {
+		return ""
+	}
+	return role
+}
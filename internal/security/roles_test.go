@@ -0,0 +1,36 @@
+package security
+
+import (
+	"testing"
+
+	"github.com/markbates/goth"
+	"github.com/stretchr/testify/assert"
+	"github.com/tphakala/birdnet-go/internal/conf"
+)
+
+func TestUserRole(t *testing.T) {
+	t.Parallel()
+
+	roles := &conf.RoleSettings{
+		Editors: "partner@example.org",
+		Viewers: "kid@example.org, 12345",
+	}
+
+	assert.Equal(t, RoleAdmin, UserRole(roles, &goth.User{UserID: "1", Email: "owner@example.org"}, RoleAdmin))
+	assert.Equal(t, RoleEditor, UserRole(roles, &goth.User{UserID: "2", Email: "Partner@example.org"}, RoleAdmin))
+	assert.Equal(t, RoleViewer, UserRole(roles, &goth.User{UserID: "12345"}, RoleAdmin))
+	assert.Equal(t, RoleViewer, UserRole(roles, &goth.User{Email: "kid@example.org"}, RoleEditor))
+	assert.Equal(t, RoleViewer, UserRole(roles, &goth.User{Email: "partner@example.org"}, RoleViewer), "a listing does not raise the role granted by the provider")
+}
+
+func TestRoleAllows(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, RoleAllows(RoleAdmin, ScopeSettingsAdmin))
+	assert.True(t, RoleAllows(RoleEditor, ScopeDetectionsWrite))
+	assert.False(t, RoleAllows(RoleEditor, ScopeSettingsAdmin))
+	assert.True(t, RoleAllows(RoleViewer, ScopeReadOnly))
+	assert.False(t, RoleAllows(RoleViewer, ScopeDetectionsWrite))
+	assert.True(t, RoleAllows(RoleViewer, ScopeOwnData))
+	assert.False(t, RoleAllows("root", ScopeReadOnly))
+}
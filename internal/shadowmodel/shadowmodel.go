@@ -0,0 +1,277 @@
+// Package shadowmodel runs a candidate BirdNET model in parallel with the
+// production model on the live audio for a trial period. Its would-be
+// detections are logged separately from the detections of the production
+// model, so the two can be compared before switching production over.
+package shadowmodel
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/birdnet"
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/errors"
+	"github.com/tphakala/birdnet-go/internal/myaudio"
+)
+
+const (
+	queueSize       = 4               // Chunks waiting for the candidate model
+	segmentDuration = 3 * time.Second // Length of an analyzed chunk
+	speciesHuman    = "human"         // Never logged, like the production model
+)
+
+// Store is the datastore capability needed by a Runner
+type Store interface {
+	SaveShadowDetections(detections []datastore.ShadowDetection) error
+	GetShadowTrialStart(model string) (time.Time, bool, error)
+}
+
+// predictor is the part of a BirdNET instance used by a Runner
+type predictor interface {
+	PredictWithInput(ctx context.Context, sampleLength int, fill func(input []float32) error) ([]datastore.Results, error)
+	EnrichResultWithTaxonomy(speciesLabel string) (scientific, common, code string)
+}
+
+// Status describes a running candidate model
+type Status struct {
+	Model      string     `json:"model"`
+	Active     bool       `json:"active"` // False once the trial period has ended
+	Threshold  float64    `json:"threshold"`
+	TrialStart *time.Time `json:"trial_start,omitempty"` // First would-be detection
+	TrialEnd   *time.Time `json:"trial_end,omitempty"`
+	Analyzed   int64      `json:"analyzed"`   // Chunks analyzed since startup
+	Detections int64      `json:"detections"` // Would-be detections logged since startup
+	Dropped    int64      `json:"dropped"`    // Chunks skipped while the candidate was busy
+}
+
+// chunk is a copy of live audio waiting for the candidate model
+type chunk struct {
+	data      []byte
+	startTime time.Time
+	source    string
+}
+
+// Runner analyzes the live audio with a candidate model
+type Runner struct {
+	settings  *conf.Settings
+	model     string
+	bn        predictor
+	store     Store
+	threshold float32
+	trialDays int
+	queue     chan chunk
+	logger    *slog.Logger
+
+	mu         sync.Mutex
+	trialStart time.Time
+
+	expired    atomic.Bool
+	analyzed   atomic.Int64
+	detections atomic.Int64
+	dropped    atomic.Int64
+}
+
+// New loads the candidate model configured in settings. The trial period
+// resumes from the first would-be detection already logged for the model.
+func New(settings *conf.Settings, store Store) (*Runner, error) {
+	cfg := settings.BirdNET.Shadow
+	if cfg.ModelPath == "" {
+		return nil, errors.Newf("shadow model path is not configured").
+			Component("shadowmodel").
+			Category(errors.CategoryConfiguration).
+			Build()
+	}
+
+	// The candidate shares every setting of the production model except the model itself
+	candidate := *settings
+	candidate.BirdNET.ModelPath = cfg.ModelPath
+	candidate.BirdNET.LabelPath = cfg.LabelPath
+	bn, err := birdnet.NewBirdNET(&candidate)
+	if err != nil {
+		return nil, errors.New(err).
+			Component("shadowmodel").
+			Category(errors.CategoryModelInit).
+			Context("model_path", cfg.ModelPath).
+			Build()
+	}
+
+	r, err := newRunner(settings, bn, store)
+	if err != nil {
+		bn.Delete()
+		return nil, err
+	}
+	return r, nil
+}
+
+// newRunner returns a Runner analyzing with bn
+func newRunner(settings *conf.Settings, bn predictor, store Store) (*Runner, error) {
+	cfg := settings.BirdNET.Shadow
+	r := &Runner{
+		settings:  settings,
+		model:     cfg.ModelPath,
+		bn:        bn,
+		store:     store,
+		threshold: float32(cmp.Or(cfg.Threshold, settings.BirdNET.Threshold)),
+		trialDays: cfg.TrialDays,
+		queue:     make(chan chunk, queueSize),
+		logger:    slog.Default().With("component", "shadowmodel"),
+	}
+
+	start, ok, err := store.GetShadowTrialStart(r.model)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		r.trialStart = start
+		r.checkTrial(time.Now())
+	}
+	return r, nil
+}
+
+// Submit queues a copy of live audio for the candidate model. Audio is
+// dropped while the candidate is busy, so the production model never waits.
+func (r *Runner) Submit(data []byte, startTime time.Time, source string) {
+	if r.expired.Load() {
+		return
+	}
+	select {
+	case r.queue <- chunk{data: bytes.Clone(data), startTime: startTime, source: source}:
+	default:
+		r.dropped.Add(1)
+	}
+}
+
+// Run analyzes queued audio until ctx is cancelled
+func (r *Runner) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case c := <-r.queue:
+			if err := r.analyze(c); err != nil {
+				r.logger.Warn("Shadow model analysis failed",
+					"model", r.model,
+					"error", err,
+					"operation", "shadow_analyze")
+			}
+		}
+	}
+}
+
+// Close releases the candidate model
+func (r *Runner) Close() {
+	if bn, ok := r.bn.(*birdnet.BirdNET); ok {
+		bn.Delete()
+	}
+}
+
+// Model returns the path of the candidate model
+func (r *Runner) Model() string {
+	return r.model
+}
+
+// Status returns the state of the trial
+func (r *Runner) Status() Status {
+	status := Status{
+		Model:      r.model,
+		Active:     !r.expired.Load(),
+		Threshold:  float64(r.threshold),
+		Analyzed:   r.analyzed.Load(),
+		Detections: r.detections.Load(),
+		Dropped:    r.dropped.Load(),
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.trialStart.IsZero() {
+		start := r.trialStart
+		status.TrialStart = &start
+		if r.trialDays > 0 {
+			end := start.AddDate(0, 0, r.trialDays)
+			status.TrialEnd = &end
+		}
+	}
+	return status
+}
+
+// analyze runs the candidate model on a chunk and logs its would-be detections
+func (r *Runner) analyze(c chunk) error {
+	results, err := r.bn.PredictWithInput(context.Background(), myaudio.PCMSampleCount(c.data, conf.BitDepth), myaudio.PCMInput(c.data, conf.BitDepth))
+	if err != nil {
+		return err
+	}
+	r.analyzed.Add(1)
+
+	detections := r.detectionsOf(c, results)
+	if len(detections) == 0 {
+		return nil
+	}
+	if err := r.store.SaveShadowDetections(detections); err != nil {
+		return err
+	}
+	r.detections.Add(int64(len(detections)))
+
+	r.mu.Lock()
+	if r.trialStart.IsZero() {
+		r.trialStart = c.startTime
+		r.logger.Info("Shadow model trial started",
+			"model", r.model,
+			"trial_days", r.trialDays,
+			"operation", "shadow_trial_start")
+	}
+	r.mu.Unlock()
+	r.checkTrial(time.Now())
+	return nil
+}
+
+// detectionsOf returns the results the production filters would have kept:
+// above the threshold, on the included species list and not human
+func (r *Runner) detectionsOf(c chunk, results []datastore.Results) []datastore.ShadowDetection {
+	var detections []datastore.ShadowDetection
+	for _, result := range results {
+		if result.Confidence <= r.threshold {
+			continue
+		}
+		scientific, common, code := r.bn.EnrichResultWithTaxonomy(result.Species)
+		if scientific == "" || common == "" || strings.Contains(strings.ToLower(common), speciesHuman) {
+			continue
+		}
+		if !r.settings.IsSpeciesIncluded(result.Species) {
+			continue
+		}
+		detections = append(detections, datastore.ShadowDetection{
+			Model:          r.model,
+			Source:         c.source,
+			BeginTime:      c.startTime,
+			EndTime:        c.startTime.Add(segmentDuration),
+			ScientificName: scientific,
+			CommonName:     common,
+			SpeciesCode:    code,
+			Confidence:     float64(result.Confidence),
+		})
+	}
+	return detections
+}
+
+// checkTrial stops accepting audio once the trial period has ended
+func (r *Runner) checkTrial(now time.Time) {
+	if r.trialDays <= 0 || r.expired.Load() {
+		return
+	}
+	r.mu.Lock()
+	ended := !r.trialStart.IsZero() && now.After(r.trialStart.AddDate(0, 0, r.trialDays))
+	r.mu.Unlock()
+	if ended && r.expired.CompareAndSwap(false, true) {
+		r.logger.Info("Shadow model trial ended, compare the results before switching models",
+			"model", r.model,
+			"trial_days", r.trialDays,
+			"operation", "shadow_trial_end")
+	}
+}
@@ -0,0 +1,119 @@
+package shadowmodel
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+// fakePredictor returns fixed results for every chunk
+type fakePredictor struct {
+	results []datastore.Results
+}
+
+func (p *fakePredictor) PredictWithInput(_ context.Context, sampleLength int, fill func(input []float32) error) ([]datastore.Results, error) {
+	if err := fill(make([]float32, sampleLength)); err != nil {
+		return nil, err
+	}
+	return p.results, nil
+}
+
+func (p *fakePredictor) EnrichResultWithTaxonomy(label string) (scientific, common, code string) {
+	scientific, common, _ = strings.Cut(label, "_")
+	return scientific, common, "code"
+}
+
+// fakeStore records saved shadow detections
+type fakeStore struct {
+	saved      []datastore.ShadowDetection
+	trialStart time.Time
+}
+
+func (s *fakeStore) SaveShadowDetections(detections []datastore.ShadowDetection) error {
+	s.saved = append(s.saved, detections...)
+	return nil
+}
+
+func (s *fakeStore) GetShadowTrialStart(model string) (time.Time, bool, error) {
+	return s.trialStart, !s.trialStart.IsZero(), nil
+}
+
+// testSettings returns settings of a candidate model with two included species
+func testSettings() *conf.Settings {
+	settings := &conf.Settings{}
+	settings.BirdNET.Threshold = 0.8
+	settings.BirdNET.Shadow = conf.ShadowModelSettings{Enabled: true, ModelPath: "candidate.tflite", Threshold: 0.5, TrialDays: 7}
+	settings.BirdNET.RangeFilter.Species = []string{"Parus major_Great Tit", "Homo sapiens_Human"}
+	return settings
+}
+
+func TestRunner_LogsFilteredDetections(t *testing.T) {
+	t.Parallel()
+
+	store := &fakeStore{}
+	bn := &fakePredictor{results: []datastore.Results{
+		{Species: "Parus major_Great Tit", Confidence: 0.6},
+		{Species: "Sitta europaea_Eurasian Nuthatch", Confidence: 0.9}, // Not included
+		{Species: "Homo sapiens_Human", Confidence: 0.9},               // Privacy filter
+		{Species: "Parus major_Great Tit", Confidence: 0.4},            // Below threshold
+	}}
+	r, err := newRunner(testSettings(), bn, store)
+	require.NoError(t, err)
+
+	start := time.Now().Add(-time.Hour).UTC()
+	data := make([]byte, 64)
+	r.Submit(data, start, "mic")
+	queued := <-r.queue
+	data[0] = 1
+	assert.Zero(t, queued.data[0], "the submitted audio is a copy")
+	require.NoError(t, r.analyze(queued))
+
+	require.Len(t, store.saved, 1)
+	assert.Equal(t, datastore.ShadowDetection{
+		Model:          "candidate.tflite",
+		Source:         "mic",
+		BeginTime:      start,
+		EndTime:        start.Add(3 * time.Second),
+		ScientificName: "Parus major",
+		CommonName:     "Great Tit",
+		SpeciesCode:    "code",
+		Confidence:     float64(float32(0.6)),
+	}, store.saved[0])
+
+	status := r.Status()
+	assert.True(t, status.Active)
+	assert.Equal(t, int64(1), status.Analyzed)
+	assert.Equal(t, int64(1), status.Detections)
+	require.NotNil(t, status.TrialEnd)
+	assert.Equal(t, start.AddDate(0, 0, 7), *status.TrialEnd)
+}
+
+func TestRunner_DropsAudioWhileBusy(t *testing.T) {
+	t.Parallel()
+
+	r, err := newRunner(testSettings(), &fakePredictor{}, &fakeStore{})
+	require.NoError(t, err)
+
+	for range queueSize + 2 {
+		r.Submit(make([]byte, 8), time.Now(), "mic")
+	}
+	assert.Equal(t, int64(2), r.Status().Dropped)
+}
+
+func TestRunner_TrialEnds(t *testing.T) {
+	t.Parallel()
+
+	store := &fakeStore{trialStart: time.Now().AddDate(0, 0, -8)}
+	r, err := newRunner(testSettings(), &fakePredictor{}, store)
+	require.NoError(t, err)
+
+	assert.False(t, r.Status().Active, "the trial resumes from the first logged detection")
+	r.Submit(make([]byte, 8), time.Now(), "mic")
+	assert.Empty(t, r.queue)
+}
@@ -0,0 +1,273 @@
+// Package speciesalias resolves former and ambiguous species names, left behind
+// by taxonomy splits and renames, to the current species, so that historical
+// detections match the labels of the current model.
+//
+// An alias maps to one current species when a species was renamed or moved to
+// another genus, and to several when it was split. The embedded table can be
+// extended with a JSON file in the same format.
+package speciesalias
+
+import (
+	_ "embed" // For embedding the alias table
+	"encoding/json"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+//go:embed aliases.json
+var aliasData []byte
+
+// Species identifies a current species
+type Species struct {
+	ScientificName string `json:"scientificName"`
+	CommonName     string `json:"commonName"`
+	Code           string `json:"code"` // eBird species code
+}
+
+// Label returns the species in the "ScientificName_CommonName" label format
+func (s Species) Label() string {
+	return s.ScientificName + "_" + s.CommonName
+}
+
+// Alias maps a former scientific or common name to a current species
+type Alias struct {
+	Alias string `json:"alias"`
+	Species
+}
+
+// Index resolves aliases to current species. A nil index resolves nothing.
+type Index struct {
+	byAlias map[string]*entry // keyed by lower-cased alias
+}
+
+// entry holds the current species of an alias
+type entry struct {
+	alias   string // alias as spelled in the table
+	species []Species
+}
+
+// defaultIndex holds the embedded alias table, parsed on first use
+var defaultIndex = sync.OnceValue(func() *Index {
+	aliases, err := parseAliases(aliasData)
+	if err != nil {
+		return &Index{}
+	}
+	return newIndex(aliases)
+})
+
+// Default returns the index of the embedded alias table
+func Default() *Index {
+	return defaultIndex()
+}
+
+// Load returns the index of the embedded alias table extended with the aliases
+// in customPath. Custom entries replace the embedded entries of the same alias.
+func Load(customPath string) (*Index, error) {
+	if customPath == "" {
+		return Default(), nil
+	}
+
+	custom, err := ReadFile(customPath)
+	if err != nil {
+		return nil, err
+	}
+
+	embedded, _ := parseAliases(aliasData)
+	index := newIndex(embedded)
+	for name := range newIndex(custom).byAlias {
+		delete(index.byAlias, name)
+	}
+	for _, a := range custom {
+		index.add(a)
+	}
+	return index, nil
+}
+
+// ReadFile reads an alias table in the format of the embedded table
+func ReadFile(path string) ([]Alias, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.New(err).
+			Component("speciesalias").
+			Category(errors.CategoryFileIO).
+			Context("operation", "read_alias_file").
+			Context("path", path).
+			Build()
+	}
+	aliases, err := parseAliases(data)
+	if err != nil {
+		return nil, errors.New(err).
+			Component("speciesalias").
+			Category(errors.CategoryFileParsing).
+			Context("operation", "parse_alias_file").
+			Context("path", path).
+			Build()
+	}
+	return aliases, nil
+}
+
+// parseAliases decodes an alias table, rejecting entries without an alias or
+// a current scientific name
+func parseAliases(data []byte) ([]Alias, error) {
+	var aliases []Alias
+	if err := json.Unmarshal(data, &aliases); err != nil {
+		return nil, err
+	}
+	for i := range aliases {
+		if strings.TrimSpace(aliases[i].Alias) == "" || strings.TrimSpace(aliases[i].ScientificName) == "" {
+			return nil, errors.Newf("alias entry %d needs an alias and a scientific name", i).
+				Component("speciesalias").
+				Category(errors.CategoryValidation).
+				Build()
+		}
+	}
+	return aliases, nil
+}
+
+// newIndex builds an index of aliases
+func newIndex(aliases []Alias) *Index {
+	index := &Index{byAlias: make(map[string]*entry, len(aliases))}
+	for _, a := range aliases {
+		index.add(a)
+	}
+	return index
+}
+
+// add adds an alias to the index
+func (i *Index) add(a Alias) {
+	key := normalize(a.Alias)
+	e, ok := i.byAlias[key]
+	if !ok {
+		e = &entry{alias: strings.TrimSpace(a.Alias)}
+		i.byAlias[key] = e
+	}
+	if !slices.Contains(e.species, a.Species) {
+		e.species = append(e.species, a.Species)
+	}
+}
+
+// lookup returns the current species of an alias
+func (i *Index) lookup(name string) []Species {
+	if e, ok := i.byAlias[normalize(name)]; ok {
+		return e.species
+	}
+	return nil
+}
+
+// Aliases returns the entries of the index ordered by alias
+func (i *Index) Aliases() []Alias {
+	if i == nil {
+		return nil
+	}
+	var aliases []Alias
+	for _, e := range i.byAlias {
+		for _, s := range e.species {
+			aliases = append(aliases, Alias{Alias: e.alias, Species: s})
+		}
+	}
+	slices.SortStableFunc(aliases, func(a, b Alias) int {
+		return strings.Compare(a.Alias, b.Alias)
+	})
+	return aliases
+}
+
+// normalize returns the lookup key of a name
+func normalize(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// Resolve returns the current species of a former name, or nil when the name
+// is not an alias. The name may be a scientific or common name or a label in
+// the "ScientificName_CommonName" or "ScientificName (CommonName)" format.
+func (i *Index) Resolve(name string) []Species {
+	if i == nil {
+		return nil
+	}
+	scientific, common := splitLabel(name)
+	if species := i.lookup(scientific); len(species) > 0 {
+		return species
+	}
+	if common != "" {
+		return i.lookup(common)
+	}
+	return nil
+}
+
+// Current returns the current species of a detection stored under a former
+// scientific or common name, when the alias is unambiguous. Split species
+// cannot be resolved without review and return false.
+func (i *Index) Current(scientificName, commonName string) (Species, bool) {
+	if i == nil {
+		return Species{}, false
+	}
+	species := i.lookup(scientificName)
+	if len(species) == 0 {
+		species = i.lookup(commonName)
+	}
+	if len(species) != 1 {
+		return Species{}, false
+	}
+	return species[0], true
+}
+
+// ResolveLabel returns the current "ScientificName_CommonName" label of a
+// former label, or the label unchanged when it is not an unambiguous alias
+func (i *Index) ResolveLabel(label string) string {
+	scientific, common := splitLabel(label)
+	if species, ok := i.Current(scientific, common); ok {
+		return species.Label()
+	}
+	return label
+}
+
+// Expand returns the names to search for so that detections stored under
+// former names match: the names themselves, the current scientific names and
+// codes of aliases among them, and the unambiguous former names of the
+// species among them. Former names of split species are not added to their
+// daughter species, as those detections may be of either.
+func (i *Index) Expand(names []string) []string {
+	if i == nil || len(names) == 0 {
+		return names
+	}
+
+	expanded := slices.Clone(names)
+	for _, name := range names {
+		for _, s := range i.Resolve(name) {
+			expanded = append(expanded, s.ScientificName, s.Code)
+		}
+
+		key := normalize(name)
+		for _, e := range i.byAlias {
+			if len(e.species) == 1 && (normalize(e.species[0].ScientificName) == key ||
+				normalize(e.species[0].CommonName) == key || normalize(e.species[0].Code) == key) {
+				expanded = append(expanded, e.alias)
+			}
+		}
+	}
+
+	seen := make(map[string]bool, len(expanded))
+	return slices.DeleteFunc(expanded, func(name string) bool {
+		key := normalize(name)
+		if key == "" || seen[key] {
+			return true
+		}
+		seen[key] = true
+		return false
+	})
+}
+
+// splitLabel splits a "ScientificName_CommonName" or "ScientificName (CommonName)"
+// label into its names; other names are returned as the scientific name
+func splitLabel(label string) (scientific, common string) {
+	if sci, com, ok := strings.Cut(label, "_"); ok {
+		return strings.TrimSpace(sci), strings.TrimSpace(com)
+	}
+	if sci, com, ok := strings.Cut(label, " ("); ok && strings.HasSuffix(com, ")") {
+		return strings.TrimSpace(sci), strings.TrimSpace(strings.TrimSuffix(com, ")"))
+	}
+	return strings.TrimSpace(label), ""
+}
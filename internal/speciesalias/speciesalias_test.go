@@ -0,0 +1,122 @@
+package speciesalias
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolve(t *testing.T) {
+	t.Parallel()
+
+	index := Default()
+
+	// Genus change
+	species := index.Resolve("Carduelis tristis")
+	require.Len(t, species, 1)
+	assert.Equal(t, Species{ScientificName: "Spinus tristis", CommonName: "American Goldfinch", Code: "amegfi"}, species[0])
+
+	// Common name rename, case-insensitive and in label formats
+	for _, name := range []string{"Gray Jay", "gray jay", "Perisoreus canadensis_Gray Jay", "Perisoreus canadensis (Gray Jay)"} {
+		species := index.Resolve(name)
+		require.Len(t, species, 1, name)
+		assert.Equal(t, "Canada Jay", species[0].CommonName, name)
+	}
+
+	// Split
+	species = index.Resolve("Western Scrub-Jay")
+	require.Len(t, species, 2)
+	assert.ElementsMatch(t, []string{"cowscj1", "wooscj2"}, []string{species[0].Code, species[1].Code})
+
+	assert.Nil(t, index.Resolve("Turdus merula"))
+	assert.Nil(t, (*Index)(nil).Resolve("Carduelis tristis"))
+}
+
+func TestCurrentAndResolveLabel(t *testing.T) {
+	t.Parallel()
+
+	index := Default()
+
+	current, ok := index.Current("Dendroica petechia", "Yellow Warbler")
+	require.True(t, ok)
+	assert.Equal(t, "Setophaga petechia", current.ScientificName)
+
+	_, ok = index.Current("Aphelocoma californica", "Western Scrub-Jay")
+	assert.False(t, ok, "split species need review")
+
+	assert.Equal(t, "Spinus tristis_American Goldfinch", index.ResolveLabel("Carduelis tristis_American Goldfinch"))
+	assert.Equal(t, "Turdus merula_Eurasian Blackbird", index.ResolveLabel("Turdus merula_Eurasian Blackbird"))
+	assert.Equal(t, "Sage Sparrow", index.ResolveLabel("Sage Sparrow"))
+}
+
+func TestExpand(t *testing.T) {
+	t.Parallel()
+
+	index := Default()
+
+	// A current species also matches its former names
+	assert.ElementsMatch(t, []string{"Spinus tristis", "Carduelis tristis"}, index.Expand([]string{"Spinus tristis"}))
+	assert.ElementsMatch(t, []string{"lotduc", "Oldsquaw"}, index.Expand([]string{"lotduc"}))
+
+	// A former name matches the current species
+	assert.ElementsMatch(t, []string{"Carduelis tristis", "Spinus tristis", "amegfi"}, index.Expand([]string{"Carduelis tristis"}))
+
+	// Daughter species of a split do not match the former name
+	assert.ElementsMatch(t, []string{"Aphelocoma californica"}, index.Expand([]string{"Aphelocoma californica"}))
+
+	assert.Equal(t, []string{"Turdus merula"}, index.Expand([]string{"Turdus merula"}))
+	assert.Empty(t, index.Expand(nil))
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	index, err := Load("")
+	require.NoError(t, err)
+	assert.Same(t, Default(), index)
+
+	path := filepath.Join(t.TempDir(), "aliases.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[
+		{"alias": "Gray Jay", "scientificName": "Perisoreus infaustus", "commonName": "Siberian Jay", "code": "sibjay1"},
+		{"alias": "Merle", "scientificName": "Turdus merula", "commonName": "Eurasian Blackbird", "code": "eurbla"}
+	]`), 0o600))
+
+	index, err = Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, []Species{{ScientificName: "Perisoreus infaustus", CommonName: "Siberian Jay", Code: "sibjay1"}}, index.Resolve("Gray Jay"),
+		"custom entries replace embedded entries")
+	assert.Len(t, index.Resolve("Merle"), 1)
+	assert.Len(t, index.Resolve("Carduelis tristis"), 1, "embedded entries are kept")
+	assert.Len(t, Default().Resolve("Merle"), 0, "the embedded table is not modified")
+
+	require.NoError(t, os.WriteFile(path, []byte(`[{"alias": "Merle"}]`), 0o600))
+	_, err = Load(path)
+	require.Error(t, err)
+
+	_, err = Load(filepath.Join(t.TempDir(), "missing.json"))
+	require.Error(t, err)
+}
+
+func TestAliases(t *testing.T) {
+	t.Parallel()
+
+	aliases := Default().Aliases()
+	require.NotEmpty(t, aliases)
+	assert.True(t, slices.IsSortedFunc(aliases, func(a, b Alias) int {
+		return strings.Compare(a.Alias, b.Alias)
+	}))
+
+	var split int
+	for _, a := range aliases {
+		if a.Alias == "Western Scrub-Jay" {
+			split++
+		}
+	}
+	assert.Equal(t, 2, split, "split species have an entry per daughter species")
+	assert.Nil(t, (*Index)(nil).Aliases())
+}
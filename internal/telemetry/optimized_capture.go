@@ -29,7 +29,7 @@ func UpdateTelemetryEnabled() {
 // IsTelemetryEnabled returns true if telemetry is enabled
 // This is a fast atomic check that avoids calling conf.GetSettings()
 func IsTelemetryEnabled() bool {
-	return telemetryEnabled.Load()
+	return telemetryEnabled.Load() && !conf.IsOffline()
 }
 
 // init sets up the initial telemetry state
@@ -139,6 +139,10 @@ func initializeSentrySDK(settings *conf.Settings) error {
 // createBeforeSendHook creates the BeforeSend hook for privacy filtering
 func createBeforeSendHook(settings *conf.Settings) func(*sentry.Event, *sentry.EventHint) *sentry.Event {
 	return func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+		// Drop all events in offline mode, returning nil stops the SDK from sending
+		if conf.IsOffline() {
+			return nil
+		}
 		if serviceLogger != nil && settings.Sentry.Debug {
 			return applyPrivacyFiltersWithLogging(event)
 		}
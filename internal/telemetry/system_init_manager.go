@@ -138,6 +138,9 @@ func (m *SystemInitManager) initializeNotification() error {
 			RateLimitWindow:    DefaultRateLimitWindow,
 			RateLimitMaxEvents: DefaultRateLimitMaxEvents,
 		}
+		if settings != nil && settings.Notification.Dedup.Enabled {
+			config.DedupWindow = settings.Notification.Dedup.Window
+		}
 		
 		// Initialize with config
 		notification.Initialize(config)
@@ -0,0 +1,110 @@
+// job.go: Daily generation of time-lapses
+package timelapse
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// checkInterval is how often the job checks whether the time-lapse of the
+// previous day needs to be generated
+const checkInterval = 10 * time.Minute
+
+// Paths returns the audio, image and manifest paths of the time-lapse of a
+// day (YYYY-MM-DD) in dir
+func Paths(dir, date string) (audioPath, imagePath, manifestPath string) {
+	base := filepath.Join(dir, "timelapse-"+date)
+	return base + ".mp3", base + ".png", base + ".json"
+}
+
+// Load returns the time-lapse of a day written to dir, or an error when it
+// has not been generated
+func Load(dir, date string) (*TimeLapse, error) {
+	audioPath, imagePath, manifestPath := Paths(dir, date)
+	data, err := os.ReadFile(manifestPath) // #nosec G304 -- path built from the configured directory and a date
+	if err != nil {
+		return nil, fileError(err, "read_manifest", manifestPath)
+	}
+	var tl TimeLapse
+	if err := json.Unmarshal(data, &tl); err != nil {
+		return nil, fileError(err, "parse_manifest", manifestPath)
+	}
+	tl.AudioPath, tl.ImagePath = audioPath, imagePath
+	return &tl, nil
+}
+
+// Job writes the time-lapse of each day to a directory once the day is over
+type Job struct {
+	generator *Generator
+	dir       string
+	hour      int
+	empty     string // last day without clips (YYYY-MM-DD), not checked again
+}
+
+// NewJob creates a daily time-lapse job writing to dir from hour on
+func NewJob(generator *Generator, dir string, hour int) *Job {
+	return &Job{generator: generator, dir: dir, hour: hour}
+}
+
+// Run generates missing time-lapses until ctx is cancelled. onTimeLapse is
+// called with each generated time-lapse or the error and may be nil.
+func (j *Job) Run(ctx context.Context, onTimeLapse func(tl *TimeLapse, err error)) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		tl, err := j.GeneratePreviousDay(ctx)
+		if onTimeLapse != nil && (tl != nil || err != nil) {
+			onTimeLapse(tl, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// GeneratePreviousDay writes the time-lapse of the previous day unless it
+// already exists, it is before the configured hour or there were no
+// detections with clips. It returns a nil time-lapse when none was generated.
+func (j *Job) GeneratePreviousDay(ctx context.Context) (*TimeLapse, error) {
+	now := j.generator.now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if now.Before(today.Add(time.Duration(j.hour) * time.Hour)) {
+		return nil, nil
+	}
+
+	date := today.AddDate(0, 0, -1).Format(time.DateOnly)
+	audioPath, imagePath, manifestPath := Paths(j.dir, date)
+	if date == j.empty {
+		return nil, nil
+	}
+	if _, err := os.Stat(manifestPath); err == nil {
+		return nil, nil
+	}
+
+	tl, err := j.generator.Generate(ctx, date, audioPath, imagePath)
+	if err != nil {
+		return nil, err
+	}
+	if tl == nil {
+		j.empty = date
+		return nil, nil
+	}
+
+	// The manifest is written last, so a time-lapse interrupted while
+	// rendering is generated again
+	data, err := json.MarshalIndent(tl, "", "  ")
+	if err != nil {
+		return nil, fileError(err, "encode_manifest", manifestPath)
+	}
+	if err := os.WriteFile(manifestPath, data, 0o644); err != nil { // #nosec G306 -- manifest meant to be served
+		return nil, fileError(err, "write_manifest", manifestPath)
+	}
+	return tl, nil
+}
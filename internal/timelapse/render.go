@@ -0,0 +1,268 @@
+// render.go: Cutting the clips and rendering the audio montage and spectrogram strip
+package timelapse
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/atrest"
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+// Rendering parameters
+const (
+	sampleRate    = 48000
+	fadeSeconds   = 0.25
+	tileWidth     = 240 // width of the spectrogram of a segment in the strip
+	tileHeight    = 160
+	tileGap       = 2
+	ffmpegTimeout = 2 * time.Minute
+)
+
+// stripBackground fills the gaps between the spectrograms of the strip
+var stripBackground = color.RGBA{R: 16, G: 16, B: 16, A: 255}
+
+// Generator renders the time-lapse of a day
+type Generator struct {
+	config Config
+	store  Store
+
+	now       func() time.Time                               // current time, replaceable in tests
+	runFFmpeg func(ctx context.Context, args []string) error // FFmpeg runner, replaceable in tests
+}
+
+// NewGenerator creates a time-lapse generator
+func NewGenerator(config Config, store Store) *Generator {
+	g := &Generator{config: config, store: store, now: time.Now}
+	g.runFFmpeg = g.ffmpeg
+	return g
+}
+
+// Generate writes the audio montage and the spectrogram strip of the day to
+// audioPath and imagePath. It returns a nil time-lapse when there were no
+// detections with clips on the day.
+func (g *Generator) Generate(ctx context.Context, date, audioPath, imagePath string) (*TimeLapse, error) {
+	segments, err := Select(ctx, g.store, date)
+	if err != nil {
+		return nil, err
+	}
+	if len(segments) == 0 {
+		return nil, nil
+	}
+
+	workDir, err := os.MkdirTemp("", "timelapse-*")
+	if err != nil {
+		return nil, fileError(err, "create_work_dir", os.TempDir())
+	}
+	defer func() { _ = os.RemoveAll(workDir) }()
+
+	tl := &TimeLapse{Date: date, AudioPath: audioPath, ImagePath: imagePath}
+	var clips, tiles []string
+	for i := range segments {
+		segment := segments[i]
+		clip := filepath.Join(workDir, fmt.Sprintf("%02d.wav", segment.Hour))
+		if err := g.cutSegment(ctx, segment.ClipName, clip); err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			continue // a missing or unreadable clip leaves out its hour
+		}
+		tile := filepath.Join(workDir, fmt.Sprintf("%02d.png", segment.Hour))
+		if err := g.runFFmpeg(ctx, spectrogramArgs(clip, tile)); err != nil {
+			return nil, err
+		}
+
+		segment.Start = float64(len(clips)) * g.config.SegmentSeconds
+		tl.Segments = append(tl.Segments, segment)
+		clips = append(clips, clip)
+		tiles = append(tiles, tile)
+	}
+	if len(clips) == 0 {
+		return nil, errors.Newf("none of the %d clips of %s could be read", len(segments), date).
+			Component("timelapse").
+			Category(errors.CategoryFileIO).
+			Build()
+	}
+	tl.Duration = float64(len(clips)) * g.config.SegmentSeconds
+
+	if err := os.MkdirAll(filepath.Dir(audioPath), 0o755); err != nil {
+		return nil, fileError(err, "create_timelapse_dir", filepath.Dir(audioPath))
+	}
+	if err := g.runFFmpeg(ctx, montageArgs(clips, audioPath)); err != nil {
+		return nil, err
+	}
+	if err := writeStrip(tiles, imagePath); err != nil {
+		return nil, err
+	}
+	tl.GeneratedAt = g.now()
+	return tl, nil
+}
+
+// cutSegment writes the first seconds of a clip, faded in and out and padded
+// with silence to the segment length, as a mono WAV file
+func (g *Generator) cutSegment(ctx context.Context, clipName, output string) error {
+	if !filepath.IsLocal(clipName) {
+		return errors.Newf("clip path %q is outside the clips directory", clipName).
+			Component("timelapse").
+			Category(errors.CategoryValidation).
+			Build()
+	}
+	source, cleanup, err := atrest.PlaintextPath(filepath.Join(g.config.ClipsDir, clipName))
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	return g.runFFmpeg(ctx, segmentArgs(source, output, g.config.SegmentSeconds))
+}
+
+// segmentArgs returns the FFmpeg arguments cutting a segment of seconds from
+// the start of a clip
+func segmentArgs(source, output string, seconds float64) []string {
+	length := strconv.FormatFloat(seconds, 'f', 3, 64)
+	fadeOut := strconv.FormatFloat(max(seconds-fadeSeconds, 0), 'f', 3, 64)
+	return []string{
+		"-hide_banner", "-y", "-i", source,
+		"-af", fmt.Sprintf("apad,afade=t=in:d=%g,afade=t=out:st=%s:d=%g", fadeSeconds, fadeOut, fadeSeconds),
+		"-t", length,
+		"-ac", "1", "-ar", strconv.Itoa(sampleRate),
+		"-c:a", "pcm_s16le", output,
+	}
+}
+
+// spectrogramArgs returns the FFmpeg arguments rendering the spectrogram of
+// a segment as a tile of the strip
+func spectrogramArgs(source, output string) []string {
+	return []string{
+		"-hide_banner", "-y", "-i", source,
+		"-lavfi", fmt.Sprintf("showspectrumpic=s=%dx%d:legend=0:color=intensity", tileWidth, tileHeight),
+		"-frames:v", "1", output,
+	}
+}
+
+// montageArgs returns the FFmpeg arguments joining the segments into an MP3
+func montageArgs(clips []string, output string) []string {
+	args := []string{"-hide_banner", "-y"}
+	var filter strings.Builder
+	for i, clip := range clips {
+		args = append(args, "-i", clip)
+		fmt.Fprintf(&filter, "[%d:a]", i)
+	}
+	fmt.Fprintf(&filter, "concat=n=%d:v=0:a=1[out]", len(clips))
+	return append(args,
+		"-filter_complex", filter.String(),
+		"-map", "[out]",
+		"-c:a", "libmp3lame", "-q:a", "4", output,
+	)
+}
+
+// writeStrip joins the spectrogram tiles side by side into one PNG image
+func writeStrip(tiles []string, output string) error {
+	images := make([]image.Image, 0, len(tiles))
+	width, height := 0, 0
+	for _, tile := range tiles {
+		img, err := readPNG(tile)
+		if err != nil {
+			return err
+		}
+		images = append(images, img)
+		width += img.Bounds().Dx()
+		height = max(height, img.Bounds().Dy())
+	}
+	width += tileGap * (len(images) - 1)
+
+	strip := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(strip, strip.Bounds(), image.NewUniform(stripBackground), image.Point{}, draw.Src)
+	x := 0
+	for _, img := range images {
+		bounds := img.Bounds()
+		draw.Draw(strip, image.Rect(x, 0, x+bounds.Dx(), bounds.Dy()), img, bounds.Min, draw.Src)
+		x += bounds.Dx() + tileGap
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, strip); err != nil {
+		return errors.New(err).
+			Component("timelapse").
+			Category(errors.CategoryProcessing).
+			Context("operation", "encode_strip").
+			Build()
+	}
+	if err := os.WriteFile(output, buf.Bytes(), 0o644); err != nil { // #nosec G306 -- image meant to be served
+		return fileError(err, "write_strip", output)
+	}
+	return nil
+}
+
+// readPNG decodes a PNG file
+func readPNG(path string) (image.Image, error) {
+	f, err := os.Open(path) // #nosec G304 -- tile rendered into the work directory
+	if err != nil {
+		return nil, fileError(err, "open_tile", path)
+	}
+	defer func() { _ = f.Close() }()
+	img, err := png.Decode(f)
+	if err != nil {
+		return nil, errors.New(err).
+			Component("timelapse").
+			Category(errors.CategoryFileParsing).
+			Context("operation", "decode_tile").
+			Context("path", path).
+			Build()
+	}
+	return img, nil
+}
+
+// ffmpeg runs FFmpeg at low priority
+func (g *Generator) ffmpeg(ctx context.Context, args []string) error {
+	if g.config.FfmpegPath == "" {
+		return errors.Newf("ffmpeg path is not configured").
+			Component("timelapse").
+			Category(errors.CategoryConfiguration).
+			Build()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, ffmpegTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		// #nosec G204 - FfmpegPath is validated by ValidateToolPath/exec.LookPath
+		cmd = exec.CommandContext(ctx, g.config.FfmpegPath, args...)
+	} else {
+		// #nosec G204 - FfmpegPath is validated by ValidateToolPath/exec.LookPath
+		cmd = exec.CommandContext(ctx, "nice", append([]string{"-n", "19", g.config.FfmpegPath}, args...)...)
+	}
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		return errors.New(fmt.Errorf("ffmpeg failed: %w (output: %s)", err, output.String())).
+			Component("timelapse").
+			Category(errors.CategoryCommandExecution).
+			Build()
+	}
+	return nil
+}
+
+// fileError wraps a file system error
+func fileError(err error, operation, path string) error {
+	return errors.New(err).
+		Component("timelapse").
+		Category(errors.CategoryFileIO).
+		Context("operation", operation).
+		Context("path", path).
+		Build()
+}
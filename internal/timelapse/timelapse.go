@@ -0,0 +1,108 @@
+// Package timelapse generates a daily soundscape time-lapse, a short audio
+// montage of the day with one clip of every hour detections were made, and an
+// image strip of the spectrograms of the clips in the same order.
+package timelapse
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+// maxHourlyCandidates is the most detections of an hour the best clip is
+// chosen from
+const maxHourlyCandidates = 1000
+
+// Store is the datastore capability needed to select the clips of a day
+type Store interface {
+	GetHourlyDetections(date, hour string, duration, limit, offset int) ([]datastore.Note, error)
+}
+
+// Config holds the time-lapse parameters
+type Config struct {
+	ClipsDir       string  // directory the clip names of detections are relative to
+	FfmpegPath     string  // FFmpeg binary cutting, joining and rendering the clips
+	SegmentSeconds float64 // length of the clip of each hour
+}
+
+// ConfigFromSettings returns the time-lapse parameters of the configuration
+func ConfigFromSettings(settings *conf.Settings) Config {
+	return Config{
+		ClipsDir:       settings.Realtime.Audio.Export.Path,
+		FfmpegPath:     settings.Realtime.Audio.FfmpegPath,
+		SegmentSeconds: settings.TimeLapse.SegmentSeconds,
+	}
+}
+
+// Segment is the clip of an hour in the time-lapse
+type Segment struct {
+	Hour           int     `json:"hour"`
+	Start          float64 `json:"start"` // seconds from the start of the time-lapse
+	DetectionID    uint    `json:"detectionId"`
+	ScientificName string  `json:"scientificName"`
+	CommonName     string  `json:"commonName"`
+	Time           string  `json:"time"`
+	Confidence     float64 `json:"confidence"`
+	ClipName       string  `json:"-"`
+}
+
+// TimeLapse describes the time-lapse of a day
+type TimeLapse struct {
+	Date        string    `json:"date"` // YYYY-MM-DD
+	Segments    []Segment `json:"segments"`
+	Duration    float64   `json:"duration"` // seconds
+	AudioPath   string    `json:"-"`
+	ImagePath   string    `json:"-"`
+	GeneratedAt time.Time `json:"generatedAt"`
+}
+
+// Select returns the most confident clip of every hour of the day with
+// detections, skipping detections marked as false positives
+func Select(ctx context.Context, store Store, date string) ([]Segment, error) {
+	var segments []Segment
+	for hour := range 24 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		notes, err := store.GetHourlyDetections(date, fmt.Sprintf("%02d", hour), 1, maxHourlyCandidates, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		var best *datastore.Note
+		for i := range notes {
+			note := &notes[i]
+			if note.ClipName == "" || note.Verified == "false_positive" {
+				continue
+			}
+			if best == nil || note.Confidence > best.Confidence {
+				best = note
+			}
+		}
+		if best == nil {
+			continue
+		}
+		segments = append(segments, Segment{
+			Hour:           hour,
+			DetectionID:    best.ID,
+			ScientificName: best.ScientificName,
+			CommonName:     best.CommonName,
+			Time:           best.Time,
+			Confidence:     best.Confidence,
+			ClipName:       best.ClipName,
+		})
+	}
+	return segments, nil
+}
+
+// Message describes a time-lapse for a notification
+func Message(tl *TimeLapse) string {
+	hours := "hours"
+	if len(tl.Segments) == 1 {
+		hours = "hour"
+	}
+	return fmt.Sprintf("Soundscape time-lapse of %s: %d active %s in %.0f seconds", tl.Date, len(tl.Segments), hours, tl.Duration)
+}
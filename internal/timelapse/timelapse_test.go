@@ -0,0 +1,150 @@
+package timelapse
+
+import (
+	"context"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+// fakeStore returns fixed detections per hour
+type fakeStore struct {
+	hours map[string][]datastore.Note // keyed by "date hour"
+}
+
+func (s *fakeStore) GetHourlyDetections(date, hour string, duration, limit, offset int) ([]datastore.Note, error) {
+	return s.hours[date+" "+hour], nil
+}
+
+func testStore() *fakeStore {
+	return &fakeStore{hours: map[string][]datastore.Note{
+		"2024-05-01 05": {
+			{ID: 1, ScientificName: "Turdus merula", CommonName: "Eurasian Blackbird", Time: "05:10:00", Confidence: 0.7, ClipName: "1.wav"},
+			{ID: 2, ScientificName: "Erithacus rubecula", CommonName: "European Robin", Time: "05:20:00", Confidence: 0.9, ClipName: "2.wav"},
+			{ID: 3, ScientificName: "Strix aluco", CommonName: "Tawny Owl", Time: "05:30:00", Confidence: 0.99, ClipName: "3.wav", Verified: "false_positive"},
+		},
+		"2024-05-01 07": {
+			{ID: 4, ScientificName: "Parus major", CommonName: "Great Tit", Time: "07:00:00", Confidence: 0.95},
+			{ID: 5, ScientificName: "Parus major", CommonName: "Great Tit", Time: "07:05:00", Confidence: 0.8, ClipName: "5.wav"},
+		},
+		"2024-05-01 21": {
+			{ID: 6, ScientificName: "Strix aluco", CommonName: "Tawny Owl", Time: "21:45:00", Confidence: 0.85, ClipName: "missing.wav"},
+		},
+	}}
+}
+
+func TestSelect(t *testing.T) {
+	t.Parallel()
+
+	segments, err := Select(context.Background(), testStore(), "2024-05-01")
+	require.NoError(t, err)
+	require.Len(t, segments, 3)
+	assert.Equal(t, 5, segments[0].Hour)
+	assert.Equal(t, uint(2), segments[0].DetectionID, "the most confident clip that is not a false positive")
+	assert.Equal(t, uint(5), segments[1].DetectionID, "detections without clips are skipped")
+	assert.Equal(t, 21, segments[2].Hour)
+}
+
+func TestSegmentArgs(t *testing.T) {
+	t.Parallel()
+
+	args := strings.Join(segmentArgs("clip.wav", "05.wav", 4), " ")
+	assert.Contains(t, args, "apad,afade=t=in:d=0.25,afade=t=out:st=3.750:d=0.25")
+	assert.Contains(t, args, "-t 4.000")
+
+	args = strings.Join(montageArgs([]string{"05.wav", "07.wav"}, "out.mp3"), " ")
+	assert.Contains(t, args, "[0:a][1:a]concat=n=2:v=0:a=1[out]")
+	assert.True(t, strings.HasSuffix(args, "libmp3lame -q:a 4 out.mp3"))
+}
+
+// fakeFFmpeg writes the output file of an FFmpeg command line, a spectrogram
+// tile for PNG outputs, and fails for inputs that do not exist
+func fakeFFmpeg(calls *[]string) func(ctx context.Context, args []string) error {
+	return func(ctx context.Context, args []string) error {
+		for i, arg := range args {
+			if arg == "-i" {
+				if _, err := os.Stat(args[i+1]); err != nil {
+					return err
+				}
+			}
+		}
+		output := args[len(args)-1]
+		*calls = append(*calls, filepath.Base(output))
+		if filepath.Ext(output) != ".png" {
+			return os.WriteFile(output, []byte("audio"), 0o600)
+		}
+		f, err := os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+		return png.Encode(f, image.NewRGBA(image.Rect(0, 0, tileWidth, tileHeight)))
+	}
+}
+
+func TestJobGeneratePreviousDay(t *testing.T) {
+	t.Parallel()
+
+	clipsDir, outDir := t.TempDir(), t.TempDir()
+	for _, name := range []string{"2.wav", "5.wav"} {
+		require.NoError(t, os.WriteFile(filepath.Join(clipsDir, name), []byte("RIFF clip data"), 0o600))
+	}
+
+	var calls []string
+	generator := NewGenerator(Config{ClipsDir: clipsDir, FfmpegPath: "ffmpeg", SegmentSeconds: 4}, testStore())
+	generator.runFFmpeg = fakeFFmpeg(&calls)
+	now := time.Date(2024, 5, 2, 0, 30, 0, 0, time.UTC)
+	generator.now = func() time.Time { return now }
+	job := NewJob(generator, outDir, 1)
+
+	tl, err := job.GeneratePreviousDay(context.Background())
+	require.NoError(t, err)
+	assert.Nil(t, tl, "nothing is generated before the configured hour")
+
+	now = now.Add(time.Hour)
+	tl, err = job.GeneratePreviousDay(context.Background())
+	require.NoError(t, err)
+	require.NotNil(t, tl)
+	assert.Equal(t, "2024-05-01", tl.Date)
+	require.Len(t, tl.Segments, 2, "the hour with a missing clip is left out")
+	assert.InDelta(t, 4.0, tl.Segments[1].Start, 1e-9)
+	assert.InDelta(t, 8.0, tl.Duration, 1e-9)
+	assert.True(t, slices.Contains(calls, "timelapse-2024-05-01.mp3"))
+
+	f, err := os.Open(tl.ImagePath)
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+	strip, err := png.DecodeConfig(f)
+	require.NoError(t, err)
+	assert.Equal(t, 2*tileWidth+tileGap, strip.Width)
+	assert.Equal(t, tileHeight, strip.Height)
+
+	loaded, err := Load(outDir, "2024-05-01")
+	require.NoError(t, err)
+	assert.Equal(t, tl.Segments[0].DetectionID, loaded.Segments[0].DetectionID)
+	assert.Equal(t, tl.AudioPath, loaded.AudioPath)
+
+	calls = nil
+	tl, err = job.GeneratePreviousDay(context.Background())
+	require.NoError(t, err)
+	assert.Nil(t, tl, "an existing time-lapse is not generated again")
+	assert.Empty(t, calls)
+}
+
+func TestMessage(t *testing.T) {
+	t.Parallel()
+
+	tl := &TimeLapse{Date: "2024-05-01", Segments: []Segment{{Hour: 5}, {Hour: 7}}, Duration: 8}
+	assert.Equal(t, "Soundscape time-lapse of 2024-05-01: 2 active hours in 8 seconds", Message(tl))
+	tl.Segments = tl.Segments[:1]
+	assert.Equal(t, "Soundscape time-lapse of 2024-05-01: 1 active hour in 8 seconds", Message(tl))
+}
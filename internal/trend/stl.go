@@ -0,0 +1,165 @@
+package trend
+
+import (
+	"math"
+)
+
+// stlInnerLoops is the number of passes refining the seasonal and trend
+// components, two are enough without robustness weights
+const stlInnerLoops = 2
+
+// stlSeasonalSpan is the LOESS span, in years, smoothing each week of the
+// year across the years. Small enough to let the seasonal cycle change over
+// the years, as migration timing does.
+const stlSeasonalSpan = 7
+
+// components is a seasonal-trend decomposition of a series,
+// series = trend + seasonal + remainder
+type components struct {
+	trend     []float64
+	seasonal  []float64
+	remainder []float64
+}
+
+// stl decomposes a series into trend, seasonal and remainder components with
+// the STL procedure of Cleveland et al. (1990), without the robustness passes.
+// The series needs at least two periods.
+func stl(series []float64, period int) components {
+	n := len(series)
+	trend := make([]float64, n)
+	seasonal := make([]float64, n)
+
+	// Spans of the low-pass and trend smoothers as recommended by Cleveland et al.
+	lowPassSpan := nextOdd(float64(period))
+	trendSpan := nextOdd(1.5 * float64(period) / (1 - 1.5/float64(stlSeasonalSpan)))
+
+	detrended := make([]float64, n)
+	cycle := make([]float64, n)
+	for range stlInnerLoops {
+		for i := range series {
+			detrended[i] = series[i] - trend[i]
+		}
+
+		// Smooth every week of the year across the years
+		for phase := range period {
+			var sub []float64
+			for i := phase; i < n; i += period {
+				sub = append(sub, detrended[i])
+			}
+			smoothed := loess(sub, stlSeasonalSpan)
+			for j, i := 0, phase; i < n; j, i = j+1, i+period {
+				cycle[i] = smoothed[j]
+			}
+		}
+
+		// Remove the low-frequency part the cycle-subseries picked up, so it
+		// ends up in the trend and not in the seasonal component
+		low := loess(movingAverage(movingAverage(cycle, period), 3), lowPassSpan)
+		for i := range seasonal {
+			seasonal[i] = cycle[i] - low[i]
+		}
+
+		deseasonalized := make([]float64, n)
+		for i := range series {
+			deseasonalized[i] = series[i] - seasonal[i]
+		}
+		trend = loess(deseasonalized, trendSpan)
+	}
+
+	remainder := make([]float64, n)
+	for i := range series {
+		remainder[i] = series[i] - trend[i] - seasonal[i]
+	}
+	return components{trend: trend, seasonal: seasonal, remainder: remainder}
+}
+
+// loess smooths evenly spaced values with locally weighted linear regression
+// over the span nearest values, weighted with the tricube function. A span
+// beyond the number of values widens the weights as in Cleveland's LOESS.
+func loess(values []float64, span int) []float64 {
+	n := len(values)
+	smoothed := make([]float64, n)
+	if n == 0 {
+		return smoothed
+	}
+	if n == 1 {
+		smoothed[0] = values[0]
+		return smoothed
+	}
+
+	for i := range values {
+		// The window of the span nearest values around i
+		lo, hi := 0, n-1
+		if span < n {
+			lo = max(0, min(i-span/2, n-span))
+			hi = lo + span - 1
+		}
+		maxDist := math.Max(float64(i-lo), float64(hi-i))
+		if span > n {
+			maxDist += float64(span-n) / 2
+		}
+		maxDist = math.Max(maxDist, 1)
+
+		var sumW, sumX, sumY, sumXX, sumXY float64
+		for j := lo; j <= hi; j++ {
+			d := math.Abs(float64(j-i)) / (maxDist * 1.0001)
+			w := tricube(d)
+			x := float64(j - i)
+			sumW += w
+			sumX += w * x
+			sumY += w * values[j]
+			sumXX += w * x * x
+			sumXY += w * x * values[j]
+		}
+		if sumW == 0 {
+			smoothed[i] = values[i]
+			continue
+		}
+
+		// Local linear fit evaluated at x = 0, the point itself
+		meanX, meanY := sumX/sumW, sumY/sumW
+		variance := sumXX/sumW - meanX*meanX
+		if variance <= 1e-12 {
+			smoothed[i] = meanY
+			continue
+		}
+		slope := (sumXY/sumW - meanX*meanY) / variance
+		smoothed[i] = meanY - slope*meanX
+	}
+	return smoothed
+}
+
+// movingAverage returns the centered moving average of a window, shrunk at the
+// ends of the values so that the result keeps their length
+func movingAverage(values []float64, window int) []float64 {
+	averaged := make([]float64, len(values))
+	for i := range values {
+		lo := max(0, i-window/2)
+		hi := min(len(values)-1, lo+window-1)
+		lo = max(0, hi-window+1)
+		var sum float64
+		for j := lo; j <= hi; j++ {
+			sum += values[j]
+		}
+		averaged[i] = sum / float64(hi-lo+1)
+	}
+	return averaged
+}
+
+// tricube is the LOESS weight function of a scaled distance
+func tricube(d float64) float64 {
+	if d >= 1 {
+		return 0
+	}
+	t := 1 - d*d*d
+	return t * t * t
+}
+
+// nextOdd returns the smallest odd integer not below x
+func nextOdd(x float64) int {
+	n := int(math.Ceil(x))
+	if n%2 == 0 {
+		n++
+	}
+	return n
+}
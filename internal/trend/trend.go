@@ -0,0 +1,199 @@
+// Package trend decomposes the weekly detection counts of a species over
+// several years into a long-term trend, a yearly seasonal cycle and a
+// remainder, separating population changes at the site from the migration
+// pattern that dominates the raw counts.
+package trend
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/datastore"
+	"github.com/tphakala/birdnet-go/internal/errors"
+)
+
+const (
+	// WeeksPerYear is the period of the seasonal cycle, 52 weeks of 7 days
+	WeeksPerYear = 52
+
+	// MinYears is the number of years of detections needed to tell the
+	// seasonal cycle from the trend
+	MinYears = 2
+
+	// MaxYears limits the years decomposed
+	MaxYears = 10
+)
+
+// ErrInsufficientData is returned when the station has detected for less than MinYears
+var ErrInsufficientData = errors.NewStd("at least two years of detections are needed for a trend")
+
+// Store is the datastore capability needed to count the weekly detections
+type Store interface {
+	GetDailyAnalyticsData(ctx context.Context, startDate, endDate, species string) ([]datastore.DailyAnalyticsData, error)
+}
+
+// Week is the detection count of a week and its components,
+// count = trend + seasonal + remainder
+type Week struct {
+	Start     string  `json:"start"` // first day (YYYY-MM-DD)
+	Count     int     `json:"count"`
+	Trend     float64 `json:"trend"`
+	Seasonal  float64 `json:"seasonal"`
+	Remainder float64 `json:"remainder"`
+}
+
+// Decomposition is the seasonal-trend decomposition of the weekly detection
+// counts of a species, or of all detections
+type Decomposition struct {
+	Species         string    `json:"species,omitempty"` // empty for all species
+	Start           string    `json:"start"`             // first day of the first week (YYYY-MM-DD)
+	End             string    `json:"end"`               // last day of the last week (YYYY-MM-DD)
+	Years           float64   `json:"years"`
+	Weeks           []Week    `json:"weeks"`
+	SeasonalProfile []float64 `json:"seasonalProfile"` // mean seasonal component by week of the cycle, from the week of Start
+	TrendSlope      float64   `json:"trendSlope"`      // change of the trend, weekly detections per year
+	TrendChange     *float64  `json:"trendChange"`     // change of the mean trend from the first to the last year, percent; null without detections in the first year
+}
+
+// Analyze decomposes the weekly detections of a species, all species when
+// empty, over the years before end. The weeks start when the station first
+// detected anything, as earlier weeks without detections are no absences.
+func Analyze(ctx context.Context, store Store, species string, end time.Time, years int) (*Decomposition, error) {
+	years = min(max(years, MinYears), MaxYears)
+	end = time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, end.Location())
+	from := end.AddDate(0, 0, -years*WeeksPerYear*7)
+	lastDay := end.AddDate(0, 0, -1)
+
+	all, err := store.GetDailyAnalyticsData(ctx, from.Format(time.DateOnly), lastDay.Format(time.DateOnly), "")
+	if err != nil {
+		return nil, err
+	}
+	first := ""
+	for i := range all {
+		if all[i].Count > 0 && (first == "" || all[i].Date < first) {
+			first = all[i].Date
+		}
+	}
+	if first == "" {
+		return nil, ErrInsufficientData
+	}
+	firstDay, err := time.ParseInLocation(time.DateOnly, first, end.Location())
+	if err != nil {
+		return nil, errors.New(err).
+			Component("trend").
+			Category(errors.CategoryValidation).
+			Context("date", first).
+			Build()
+	}
+
+	// Whole weeks ending the day before end, starting at the first detection
+	weeks := daysBetween(firstDay, end) / 7
+	weeks = min(weeks, years*WeeksPerYear)
+	if weeks < MinYears*WeeksPerYear {
+		return nil, ErrInsufficientData
+	}
+	start := end.AddDate(0, 0, -weeks*7)
+
+	daily := all
+	if species != "" {
+		if daily, err = store.GetDailyAnalyticsData(ctx, start.Format(time.DateOnly), lastDay.Format(time.DateOnly), species); err != nil {
+			return nil, err
+		}
+	}
+
+	counts := make([]int, weeks)
+	for i := range daily {
+		day, err := time.ParseInLocation(time.DateOnly, daily[i].Date, end.Location())
+		if err != nil || day.Before(start) || !day.Before(end) {
+			continue
+		}
+		counts[daysBetween(start, day)/7] += daily[i].Count
+	}
+
+	return decompose(species, start, counts), nil
+}
+
+// decompose builds the decomposition of weekly counts starting at start
+func decompose(species string, start time.Time, counts []int) *Decomposition {
+	series := make([]float64, len(counts))
+	for i, count := range counts {
+		series[i] = float64(count)
+	}
+	parts := stl(series, WeeksPerYear)
+
+	d := &Decomposition{
+		Species:         species,
+		Start:           start.Format(time.DateOnly),
+		End:             start.AddDate(0, 0, len(counts)*7-1).Format(time.DateOnly),
+		Years:           round(float64(len(counts))/WeeksPerYear, 2),
+		Weeks:           make([]Week, len(counts)),
+		SeasonalProfile: make([]float64, WeeksPerYear),
+	}
+	for i, count := range counts {
+		d.Weeks[i] = Week{
+			Start:     start.AddDate(0, 0, i*7).Format(time.DateOnly),
+			Count:     count,
+			Trend:     round(parts.trend[i], 3),
+			Seasonal:  round(parts.seasonal[i], 3),
+			Remainder: round(parts.remainder[i], 3),
+		}
+	}
+
+	for phase := range WeeksPerYear {
+		var sum float64
+		var n int
+		for i := phase; i < len(counts); i += WeeksPerYear {
+			sum += parts.seasonal[i]
+			n++
+		}
+		d.SeasonalProfile[phase] = round(sum/float64(n), 3)
+	}
+
+	d.TrendSlope = round(slope(parts.trend)*WeeksPerYear, 3)
+	firstYear := mean(parts.trend[:WeeksPerYear])
+	lastYear := mean(parts.trend[len(counts)-WeeksPerYear:])
+	if firstYear > 0 {
+		change := round((lastYear-firstYear)/firstYear*100, 1)
+		d.TrendChange = &change
+	}
+	return d
+}
+
+// daysBetween returns the number of days from a to b, midnights in the same
+// location, rounded to absorb daylight saving time changes
+func daysBetween(a, b time.Time) int {
+	return int(math.Round(b.Sub(a).Hours() / 24))
+}
+
+// slope returns the least squares slope of evenly spaced values
+func slope(values []float64) float64 {
+	n := float64(len(values))
+	meanX := (n - 1) / 2
+	meanY := mean(values)
+	var sxx, sxy float64
+	for i, y := range values {
+		dx := float64(i) - meanX
+		sxx += dx * dx
+		sxy += dx * (y - meanY)
+	}
+	if sxx == 0 {
+		return 0
+	}
+	return sxy / sxx
+}
+
+// mean returns the mean of values
+func mean(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// round rounds a value to a number of decimals
+func round(value float64, decimals int) float64 {
+	scale := math.Pow(10, float64(decimals))
+	return math.Round(value*scale) / scale
+}
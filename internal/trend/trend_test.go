@@ -0,0 +1,110 @@
+package trend
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tphakala/birdnet-go/internal/datastore"
+)
+
+// fakeStore returns fixed daily counts of all detections and of one species
+type fakeStore struct {
+	all     []datastore.DailyAnalyticsData
+	species map[string][]datastore.DailyAnalyticsData
+}
+
+func (s *fakeStore) GetDailyAnalyticsData(ctx context.Context, startDate, endDate, species string) ([]datastore.DailyAnalyticsData, error) {
+	data := s.all
+	if species != "" {
+		data = s.species[species]
+	}
+	var filtered []datastore.DailyAnalyticsData
+	for _, d := range data {
+		if d.Date >= startDate && d.Date <= endDate {
+			filtered = append(filtered, d)
+		}
+	}
+	return filtered, nil
+}
+
+// seasonalWeek returns a count with a yearly cycle on a rising trend
+func seasonalWeek(week int) float64 {
+	return 20 + 0.1*float64(week) + 10*math.Sin(2*math.Pi*float64(week)/WeeksPerYear)
+}
+
+func TestDecomposeSeparatesTrendAndSeason(t *testing.T) {
+	t.Parallel()
+
+	counts := make([]int, 4*WeeksPerYear)
+	for week := range counts {
+		counts[week] = int(math.Round(seasonalWeek(week)))
+	}
+	d := decompose("Turdus merula", time.Date(2021, 1, 4, 0, 0, 0, 0, time.UTC), counts)
+
+	require.Len(t, d.Weeks, len(counts))
+	assert.Equal(t, "2021-01-04", d.Start)
+	assert.Equal(t, "2024-12-29", d.End)
+	assert.InDelta(t, 4, d.Years, 0.01)
+
+	// The trend rises 0.1 per week, 5.2 per year
+	assert.InDelta(t, 5.2, d.TrendSlope, 0.5)
+	require.NotNil(t, d.TrendChange)
+	assert.Greater(t, *d.TrendChange, 0.0)
+
+	// The seasonal component follows the cycle, the remainder is what rounding left
+	for week := WeeksPerYear; week < 3*WeeksPerYear; week++ {
+		cycle := 10 * math.Sin(2*math.Pi*float64(week)/WeeksPerYear)
+		assert.InDelta(t, cycle, d.Weeks[week].Seasonal, 2, "week %d", week)
+		assert.InDelta(t, 0, d.Weeks[week].Remainder, 2, "week %d", week)
+	}
+	assert.InDelta(t, 10, d.SeasonalProfile[13], 2)
+	assert.InDelta(t, -10, d.SeasonalProfile[39], 2)
+
+	for i := range d.Weeks {
+		w := d.Weeks[i]
+		assert.InDelta(t, float64(w.Count), w.Trend+w.Seasonal+w.Remainder, 0.01)
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	t.Parallel()
+
+	end := time.Date(2025, 6, 2, 15, 0, 0, 0, time.UTC)
+	firstDetection := time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)
+	store := &fakeStore{species: map[string][]datastore.DailyAnalyticsData{}}
+	for day := firstDetection; day.Before(end); day = day.AddDate(0, 0, 1) {
+		date := day.Format(time.DateOnly)
+		store.all = append(store.all, datastore.DailyAnalyticsData{Date: date, Count: 30})
+		store.species["Parus major"] = append(store.species["Parus major"], datastore.DailyAnalyticsData{Date: date, Count: 2})
+	}
+
+	d, err := Analyze(context.Background(), store, "Parus major", end, 5)
+	require.NoError(t, err)
+	assert.Equal(t, "Parus major", d.Species)
+	assert.Equal(t, "2025-06-01", d.End, "the last week ends the day before end")
+	start, err := time.Parse(time.DateOnly, d.Start)
+	require.NoError(t, err)
+	assert.False(t, start.Before(firstDetection), "weeks before the first detection are left out")
+	assert.Less(t, start.Sub(firstDetection), 7*24*time.Hour)
+	for i := range d.Weeks {
+		assert.Equal(t, 14, d.Weeks[i].Count)
+	}
+	assert.InDelta(t, 0, d.TrendSlope, 0.01)
+	require.NotNil(t, d.TrendChange)
+	assert.InDelta(t, 0, *d.TrendChange, 0.1)
+
+	// The years requested limit the weeks
+	d, err = Analyze(context.Background(), store, "", end, 2)
+	require.NoError(t, err)
+	assert.Len(t, d.Weeks, 2*WeeksPerYear)
+	assert.Equal(t, 210, d.Weeks[0].Count)
+
+	_, err = Analyze(context.Background(), store, "", firstDetection.AddDate(1, 6, 0), 5)
+	require.ErrorIs(t, err, ErrInsufficientData)
+	_, err = Analyze(context.Background(), &fakeStore{}, "", end, 5)
+	require.ErrorIs(t, err, ErrInsufficientData)
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/databudget"
 	"github.com/tphakala/birdnet-go/internal/errors"
 )
 
@@ -80,7 +81,8 @@ func (p *OpenWeatherProvider) FetchWeather(settings *conf.Settings) (*WeatherDat
 	logger.Info("Fetching weather data", "url", safeURL)
 
 	client := &http.Client{
-		Timeout: RequestTimeout,
+		Timeout:   RequestTimeout,
+		Transport: databudget.Transport(databudget.Weather, nil),
 	}
 
 	req, err := http.NewRequest("GET", apiURL, http.NoBody)
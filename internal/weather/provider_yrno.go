@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/databudget"
 	"github.com/tphakala/birdnet-go/internal/errors"
 )
 
@@ -62,7 +63,8 @@ func (p *YrNoProvider) FetchWeather(settings *conf.Settings) (*WeatherData, erro
 	logger.Info("Fetching weather data", "url", url)
 
 	client := &http.Client{
-		Timeout: RequestTimeout,
+		Timeout:   RequestTimeout,
+		Transport: databudget.Transport(databudget.Weather, nil),
 	}
 
 	req, err := http.NewRequest("GET", url, http.NoBody)
@@ -3,6 +3,8 @@ package weather
 import (
 	"net/http"
 	"time"
+
+	"github.com/tphakala/birdnet-go/internal/databudget"
 )
 
 // NewYrNoProvider creates a new Yr.no weather provider
@@ -25,7 +27,8 @@ type OpenWeatherProvider struct{}
 func NewWundergroundProvider(client *http.Client) Provider {
 	if client == nil {
 		client = &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   30 * time.Second,
+			Transport: databudget.Transport(databudget.Weather, nil),
 		}
 	}
 	return &WundergroundProvider{
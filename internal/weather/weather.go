@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/tphakala/birdnet-go/internal/conf"
+	"github.com/tphakala/birdnet-go/internal/databudget"
 	"github.com/tphakala/birdnet-go/internal/datastore"
 	"github.com/tphakala/birdnet-go/internal/errors"
 	"github.com/tphakala/birdnet-go/internal/logging"
@@ -260,6 +261,21 @@ func (s *Service) StartPolling(stopChan <-chan struct{}) {
 
 // fetchAndSave fetches weather data and saves it to the database
 func (s *Service) fetchAndSave() error {
+	// No outbound calls in offline mode, weather data is simply not recorded
+	if conf.IsOffline() {
+		weatherLogger.Debug("Skipping weather fetch in offline mode",
+			"provider", s.settings.Realtime.Weather.Provider)
+		return nil
+	}
+
+	// Metered connections defer weather polling once the data budget is tight
+	if !databudget.Allow(databudget.Weather) {
+		databudget.RecordDeferred(databudget.Weather)
+		weatherLogger.Debug("Skipping weather fetch, data budget exceeded",
+			"provider", s.settings.Realtime.Weather.Provider)
+		return nil
+	}
+
 	// Track fetch duration
 	fetchStart := time.Now()
 